@@ -1,9 +1,11 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
+	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 )
@@ -25,13 +27,38 @@ var specChineseAllPartSize = SpecText{
 
     该命令只有一种用法：
 
-    1) ossutil getallpartsize oss://bucket [options]
+    1) ossutil getallpartsize oss://bucket [--prefix prefix] [--older-than duration] [--output json] [--abort] [options]
       查询bucket的所有未完成上传的multipart object的块大小信息以及总和
+
+--prefix选项
+
+    只查询object名称以prefix开头的分片上传。
+
+--older-than选项
+
+    只查询初始化时间早于该时长之前的分片上传，如：7d、36h，可以避免影响正在进行的上传。
+
+--output选项
+
+    取值为text、json或者yaml，默认为text。为json时以json数组输出每个分片上传的object、uploadId、初始化时间、
+    分块数、分块总大小以及分块列表，为yaml时以yaml格式输出相同的内容。
+
+--abort选项
+
+    查询后取消列出的分片上传，已上传的分块会被删除，不可恢复。取消前ossutil显示分片上传的个数、分块数
+    和总大小并询问确认，指定--assume-yes(-y)时不询问，--output为json或者yaml时必须指定--assume-yes。
+    有分片上传取消失败时命令返回错误。
 `,
 
 	sampleText: ` 
 	1) 根据bucket查询所有未完成上传的multipart object的块大小信息以及总和
        ossutil getallpartsize oss://bucket
+
+	2) 以json格式查询logs/前缀下7天前初始化的分片上传
+       ossutil getallpartsize oss://bucket --prefix logs/ --older-than 7d --output json
+
+	3) 取消7天前初始化的分片上传
+       ossutil getallpartsize oss://bucket --older-than 7d --abort
 `,
 }
 
@@ -52,13 +79,41 @@ Usages：
 
     There is only one usage for this command:
 
-    1) ossutil getallpartsize oss://bucket [options]
+    1) ossutil getallpartsize oss://bucket [--prefix prefix] [--older-than duration] [--output json] [--abort] [options]
        Get bucket all uncompleted mulitpart objects's parts size and sum size
+
+--prefix option
+
+    Only the multipart uploads whose object name starts with the prefix are listed.
+
+--older-than option
+
+    Only the multipart uploads initiated earlier than the duration ago are listed, e.g., 7d, 36h,
+    which avoids touching the uploads in progress.
+
+--output option
+
+    The value can be text, json or yaml, the default is text. If it's json, the object, the uploadId, the 
+    initiated time, the part count, the part size and the parts of each multipart upload are printed 
+    as a json array, yaml prints the same content in yaml.
+
+--abort option
+
+    Abort the listed multipart uploads after listing, the uploaded parts are deleted and can't be
+    recovered. Before aborting, ossutil shows the number of the uploads, the parts and the total size,
+    and asks for confirmation, which is skipped by --assume-yes(-y), and --assume-yes is required if
+    --output is json or yaml. The command returns error if any upload fails to be aborted.
 `,
 
 	sampleText: ` 
 	1)  Get bucket all uncompleted multipart objects's parts size and sum size
        ossutil getallpartsize oss://bucket
+
+	2)  List the multipart uploads under logs/ initiated 7 days ago in json format
+       ossutil getallpartsize oss://bucket --prefix logs/ --older-than 7d --output json
+
+	3)  Abort the multipart uploads initiated 7 days ago
+       ossutil getallpartsize oss://bucket --older-than 7d --abort
 `,
 }
 
@@ -67,6 +122,10 @@ type allPartSizeOptionType struct {
 	encodingType   string
 	headLineShowed bool
 	statList       []StatPartInfo
+	prefix         string
+	olderThan      time.Duration
+	output         outputFormat
+	abort          bool
 }
 
 type AllPartSizeCommand struct {
@@ -76,13 +135,11 @@ type AllPartSizeCommand struct {
 
 var allPartSizeCommand = AllPartSizeCommand{
 	command: Command{
-		name:        "getallpartsize",
-		nameAlias:   []string{"getallpartsize"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseAllPartSize,
-		specEnglish: specEnglishAllPartSize,
-		group:       GroupTypeNormalCommand,
+		name:      "getallpartsize",
+		nameAlias: []string{"getallpartsize"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -102,6 +159,11 @@ var allPartSizeCommand = AllPartSizeCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -109,6 +171,10 @@ var allPartSizeCommand = AllPartSizeCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionPrefix,
+			OptionOlderThan,
+			OptionOutput,
+			OptionAbort,
 		},
 	},
 }
@@ -116,6 +182,27 @@ var allPartSizeCommand = AllPartSizeCommand{
 type StatPartInfo struct {
 	objectName string
 	uploadId   string
+	initiated  time.Time
+}
+
+// uploadPartsInfo is the json output of an uncompleted multipart upload
+type uploadPartsInfo struct {
+	Object    string     `json:"object"`
+	UploadID  string     `json:"uploadId"`
+	Initiated string     `json:"initiated,omitempty"`
+	PartCount int64      `json:"partCount"`
+	PartSize  int64      `json:"partSize"`
+	Parts     []partInfo `json:"parts"`
+	Aborted   bool       `json:"aborted"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// partInfo is the json output of an uploaded part
+type partInfo struct {
+	PartNumber   int    `json:"partNumber"`
+	ETag         string `json:"etag"`
+	Size         int64  `json:"size"`
+	LastModified string `json:"lastModified"`
 }
 
 // function for FormatHelper interface
@@ -128,8 +215,8 @@ func (apc *AllPartSizeCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (apc *AllPartSizeCommand) Init(args []string, options OptionMapType) error {
-	return apc.command.Init(args, options, apc)
+func (apc *AllPartSizeCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return apc.command.Init(ctx, args, options, apc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -140,6 +227,26 @@ func (apc *AllPartSizeCommand) RunCommand() error {
 	}
 	apc.apOption.bucketName = srcBucketUrL.bucket
 	apc.apOption.encodingType, _ = GetString(OptionEncodingType, apc.command.options)
+	apc.apOption.prefix, _ = GetString(OptionPrefix, apc.command.options)
+	apc.apOption.abort, _ = GetBool(OptionAbort, apc.command.options)
+	apc.apOption.headLineShowed = false
+	apc.apOption.statList = nil
+
+	strOlderThan, _ := GetString(OptionOlderThan, apc.command.options)
+	apc.apOption.olderThan = 0
+	if strOlderThan != "" {
+		if apc.apOption.olderThan, err = parseAgeDuration(strOlderThan); err != nil {
+			return fmt.Errorf("invalid --older-than: %s, %s", strOlderThan, err.Error())
+		}
+	}
+	if apc.apOption.output, err = getOutputFormat(apc.command.options); err != nil {
+		return err
+	}
+	if apc.apOption.abort {
+		if err = apc.command.checkStructuredConfirm("--abort", apc.apOption.output); err != nil {
+			return err
+		}
+	}
 
 	// first:get all object uploadid
 	err = apc.GetAllStatInfo()
@@ -160,19 +267,66 @@ func (apc *AllPartSizeCommand) RunCommand() error {
 
 	var totalPartCount int64 = 0
 	var totalPartSize int64 = 0
+	var abortErr error
+	uploads := []uploadPartsInfo{}
 	for _, v := range apc.apOption.statList {
-		partCount, partSize, err := apc.GetObjectPartsSize(bucket, v)
+		info, err := apc.GetObjectPartsInfo(bucket, v)
 		if err != nil {
 			return err
 		}
-		totalPartCount += partCount
-		totalPartSize += partSize
+		totalPartCount += info.PartCount
+		totalPartSize += info.PartSize
+		uploads = append(uploads, info)
+	}
+
+	if apc.apOption.abort && len(uploads) > 0 {
+		if !apc.command.confirmOperation(fmt.Sprintf("abort the %d multipart uploads(%d parts, %d bytes) of %s", len(uploads),
+			totalPartCount, totalPartSize, apc.command.args[0])) {
+			return nil
+		}
+		for i, v := range apc.apOption.statList {
+			err = apc.abortUpload(bucket, v)
+			uploads[i].Aborted = err == nil
+			if err != nil {
+				uploads[i].Error = err.Error()
+				abortErr = err
+			}
+		}
+	}
+
+	if apc.apOption.output.structured() {
+		if err = apc.apOption.output.print(uploads); err != nil {
+			return err
+		}
+		return abortErr
 	}
 
 	if totalPartSize > 0 {
 		fmt.Printf("\ntotal part count:%d\ttotal part size(MB):%.2f\n\n", totalPartCount, float64(totalPartSize/1024)/1024)
 	}
+	if apc.apOption.abort {
+		aborted := 0
+		for _, info := range uploads {
+			if info.Aborted {
+				aborted++
+			}
+		}
+		fmt.Printf("aborted upload count:%d\tfailed count:%d\n", aborted, len(uploads)-aborted)
+	}
+	return abortErr
+}
 
+func (apc *AllPartSizeCommand) abortUpload(bucket *oss.Bucket, statInfo StatPartInfo) error {
+	imur := oss.InitiateMultipartUploadResult{Bucket: apc.apOption.bucketName, Key: statInfo.objectName, UploadID: statInfo.uploadId}
+	err := bucket.AbortMultipartUpload(imur)
+	if err != nil {
+		LogError("abort upload %s of %s error:%s\n", statInfo.uploadId, CloudURLToString(apc.apOption.bucketName, statInfo.objectName), err.Error())
+		if !apc.apOption.output.structured() {
+			fmt.Printf("abort upload %s of %s error:%s\n", statInfo.uploadId, CloudURLToString(apc.apOption.bucketName, statInfo.objectName), err.Error())
+		}
+		return err
+	}
+	LogInfo("abort upload %s of %s success\n", statInfo.uploadId, CloudURLToString(apc.apOption.bucketName, statInfo.objectName))
 	return nil
 }
 
@@ -194,6 +348,9 @@ func (apc *AllPartSizeCommand) GetAllStatInfo() error {
 		lpOptions = append(lpOptions, oss.MaxParts(1000))
 		lpOptions = append(lpOptions, oss.KeyMarker(keyMarker))
 		lpOptions = append(lpOptions, oss.UploadIDMarker(uploadIdMarker))
+		if apc.apOption.prefix != "" {
+			lpOptions = append(lpOptions, oss.Prefix(apc.apOption.prefix))
+		}
 
 		lpRes, err := bucket.ListMultipartUploads(lpOptions...)
 		if err != nil {
@@ -201,9 +358,13 @@ func (apc *AllPartSizeCommand) GetAllStatInfo() error {
 		}
 
 		for _, v := range lpRes.Uploads {
+			if apc.apOption.olderThan > 0 && time.Since(v.Initiated) < apc.apOption.olderThan {
+				continue
+			}
 			var statPartInfo StatPartInfo
 			statPartInfo.objectName = v.Key
 			statPartInfo.uploadId = v.UploadID
+			statPartInfo.initiated = v.Initiated
 			apc.apOption.statList = append(apc.apOption.statList, statPartInfo)
 		}
 
@@ -217,15 +378,19 @@ func (apc *AllPartSizeCommand) GetAllStatInfo() error {
 	return nil
 }
 
-func (apc *AllPartSizeCommand) GetObjectPartsSize(bucket *oss.Bucket, statInfo StatPartInfo) (int64, int64, error) {
+// GetObjectPartsInfo lists the parts of the upload, the parts are printed unless --output json
+func (apc *AllPartSizeCommand) GetObjectPartsInfo(bucket *oss.Bucket, statInfo StatPartInfo) (uploadPartsInfo, error) {
 	var imur oss.InitiateMultipartUploadResult
 	imur.Bucket = apc.apOption.bucketName
 	imur.Key = statInfo.objectName
 	imur.UploadID = statInfo.uploadId
 
+	info := uploadPartsInfo{Object: CloudURLToString(imur.Bucket, imur.Key), UploadID: imur.UploadID, Parts: []partInfo{}}
+	if !statInfo.initiated.IsZero() {
+		info.Initiated = statInfo.initiated.Format(time.RFC3339)
+	}
+
 	partNumberMarker := 0
-	var totalPartCount int64 = 0
-	var totalPartSize int64 = 0
 	var cloudUrl CloudURL
 	for i := 0; ; i++ {
 		lpOptions := []oss.Option{}
@@ -234,10 +399,10 @@ func (apc *AllPartSizeCommand) GetObjectPartsSize(bucket *oss.Bucket, statInfo S
 
 		lpRes, err := bucket.ListUploadedParts(imur, lpOptions...)
 		if err != nil {
-			return 0, 0, err
+			return info, err
 		} else {
-			totalPartCount += int64(len(lpRes.UploadedParts))
-			if !apc.apOption.headLineShowed && len(lpRes.UploadedParts) > 0 {
+			info.PartCount += int64(len(lpRes.UploadedParts))
+			if !apc.apOption.output.structured() && !apc.apOption.headLineShowed && len(lpRes.UploadedParts) > 0 {
 				fmt.Printf("%-10s\t%-32s\t%-10s\t%s\n", "PartNumber", "UploadId", "Size(Byte)", "Path")
 				apc.apOption.headLineShowed = true
 			}
@@ -252,19 +417,30 @@ func (apc *AllPartSizeCommand) GetObjectPartsSize(bucket *oss.Bucket, statInfo S
 			}
 
 			//PartNumber,uploadId,Size,Path
-			fmt.Printf("%-10d\t%-32s\t%-10d\t%s\n", v.PartNumber, imur.UploadID, v.Size, cloudUrl.ToString())
-			totalPartSize += int64(v.Size)
+			if !apc.apOption.output.structured() {
+				fmt.Printf("%-10d\t%-32s\t%-10d\t%s\n", v.PartNumber, imur.UploadID, v.Size, cloudUrl.ToString())
+			}
+			info.PartSize += int64(v.Size)
+			info.Parts = append(info.Parts, newPartInfo(v))
 		}
 
 		if lpRes.IsTruncated {
 			partNumberMarker, err = strconv.Atoi(lpRes.NextPartNumberMarker)
 			if err != nil {
-				return 0, 0, err
+				return info, err
 			}
 		} else {
 			break
 		}
 	}
-	return totalPartCount, totalPartSize, nil
+	return info, nil
+}
 
+func newPartInfo(part oss.UploadedPart) partInfo {
+	return partInfo{
+		PartNumber:   part.PartNumber,
+		ETag:         part.ETag,
+		Size:         int64(part.Size),
+		LastModified: part.LastModified.Format(time.RFC3339),
+	}
 }
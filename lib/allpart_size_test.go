@@ -1,9 +1,13 @@
 package lib
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 	. "gopkg.in/check.v1"
@@ -141,3 +145,108 @@ func (s *OssutilCommandSuite) TestAllPartSizeHelp(c *C) {
 	_, err = cm.RunCommand("help", mkArgs, options)
 	c.Assert(err, IsNil)
 }
+
+func (s *OssutilCommandSuite) TestAllPartSizeFilterAndAbort(c *C) {
+	duration, err := parseAgeDuration("7d")
+	c.Assert(err, IsNil)
+	c.Assert(duration, Equals, 7*24*time.Hour)
+	duration, err = parseAgeDuration("36h")
+	c.Assert(err, IsNil)
+	c.Assert(duration, Equals, 36*time.Hour)
+	_, err = parseAgeDuration("7x")
+	c.Assert(err, NotNil)
+
+	var queryPrefix string
+	var aborted []string
+	oldTime := time.Now().Add(-10 * 24 * time.Hour).UTC().Format("2006-01-02T15:04:05.000Z")
+	newTime := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method == "DELETE" {
+			aborted = append(aborted, query.Get("uploadId"))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if _, ok := query["uploads"]; ok {
+			queryPrefix = query.Get("prefix")
+			fmt.Fprintf(w, `<ListMultipartUploadsResult><IsTruncated>false</IsTruncated>
+<Upload><Key>logs/old</Key><UploadId>old-id</UploadId><Initiated>%s</Initiated></Upload>
+<Upload><Key>logs/new</Key><UploadId>new-id</UploadId><Initiated>%s</Initiated></Upload>
+</ListMultipartUploadsResult>`, oldTime, newTime)
+			return
+		}
+		fmt.Fprint(w, `<ListPartsResult><IsTruncated>false</IsTruncated>
+<Part><PartNumber>1</PartNumber><ETag>"etag1"</ETag><Size>100</Size><LastModified>2024-01-01T00:00:00.000Z</LastModified></Part>
+<Part><PartNumber>2</PartNumber><ETag>"etag2"</ETag><Size>50</Size><LastModified>2024-01-01T00:00:00.000Z</LastModified></Part>
+</ListPartsResult>`)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	prefix := "logs/"
+	olderThan := "7d"
+	output := "json"
+	abort := false
+	yes := false
+	forcePathStyle := true
+	run := func() []uploadPartsInfo {
+		apc := &AllPartSizeCommand{}
+		apc.command.args = []string{"oss://bucket"}
+		apc.command.options = OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &str,
+			OptionAccessKeySecret: &str,
+			OptionPrefix:          &prefix,
+			OptionOlderThan:       &olderThan,
+			OptionOutput:          &output,
+			OptionAbort:           &abort,
+			OptionAssumeYes:       &yes,
+			OptionForcePathStyle:  &forcePathStyle,
+		}
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = apc.RunCommand()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		c.Assert(err, IsNil)
+
+		var uploads []uploadPartsInfo
+		c.Assert(json.Unmarshal([]byte(s.readFile(resultPath, c)), &uploads), IsNil)
+		return uploads
+	}
+
+	// only the old upload is listed
+	uploads := run()
+	c.Assert(queryPrefix, Equals, "logs/")
+	c.Assert(len(uploads), Equals, 1)
+	c.Assert(uploads[0].Object, Equals, "oss://bucket/logs/old")
+	c.Assert(uploads[0].UploadID, Equals, "old-id")
+	c.Assert(uploads[0].PartCount, Equals, int64(2))
+	c.Assert(uploads[0].PartSize, Equals, int64(150))
+	c.Assert(uploads[0].Parts[1].PartNumber, Equals, 2)
+	c.Assert(uploads[0].Aborted, Equals, false)
+	c.Assert(len(aborted), Equals, 0)
+
+	// the prompt can't be shown with json
+	abort = true
+	apc := &AllPartSizeCommand{}
+	apc.command.args = []string{"oss://bucket"}
+	apc.command.options = OptionMapType{OptionOutput: &output, OptionAbort: &abort}
+	err = apc.RunCommand()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "needs --assume-yes"), Equals, true)
+
+	yes = true
+	uploads = run()
+	c.Assert(uploads[0].Aborted, Equals, true)
+	c.Assert(aborted, DeepEquals, []string{"old-id"})
+
+	output = "xml"
+	apc = &AllPartSizeCommand{}
+	apc.command.args = []string{"oss://bucket"}
+	apc.command.options = OptionMapType{OptionOutput: &output}
+	c.Assert(apc.RunCommand(), NotNil)
+}
@@ -0,0 +1,410 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// The API below runs the engines of cp, sync, ls and stat in the process of the caller, the
+// options are passed by structs instead of OptionMapType. The engines of cp and sync keep the
+// progress and the signals in package variables, so Copy and Sync are serialized by apiMu, the
+// concurrent calls wait for the running one. The calls never ask for confirmation, the
+// destination files or objects are overwritten unless Update is specified. The progress is
+// passed only to CopyOptions.Progress, and the text output such as the progress bar and the
+// summary goes to the Output of the options instead of stdout. Once ctx is done, the listing and
+// the retries stop, and Copy and Sync finish the transferring files and return like interrupted
+// by Ctrl-C.
+
+// apiMu serializes Copy and Sync, ListObjects and StatObject don't touch the package state
+var apiMu sync.Mutex
+
+// Config is the common settings of the API, the empty fields are read from ConfigFile, or the
+// default config file ~/.ossutilconfig if ConfigFile is empty, as the command line does
+type Config struct {
+	ConfigFile      string
+	Endpoint        string
+	AccessKeyID     string
+	AccessKeySecret string
+	STSToken        string
+	Region          string
+	SignVersion     string
+	ProxyHost       string
+	ProxyUser       string
+	ProxyPwd        string
+	UserAgent       string
+	RequestPayer    string
+	RetryTimes      int64
+	ConnectTimeout  int64
+	ReadTimeout     int64
+	ForcePathStyle  bool
+	SkipVerifyCert  bool
+}
+
+// CopyOptions is the options of Copy, the zero values mean the defaults of cp
+type CopyOptions struct {
+	Recursive        bool
+	Update           bool // only copy when the source is newer than the destination
+	BigFileThreshold int64
+	PartSize         int64
+	Routines         int64
+	Parallel         int64
+	CheckpointDir    string
+	OutputDir        string
+	Meta             string // header:value#header:value...
+	ACL              string
+	DisableCRC64     bool
+	MaxUpSpeed       int64 // KB/s
+	MaxDownSpeed     int64 // KB/s
+	Include          []string
+	Exclude          []string
+	Progress         func(CopyProgress) // called every ProgressInterval and once when the copy ends
+	ProgressInterval time.Duration      // 1 second by default
+	Output           io.Writer          // the text output such as the progress bar, nil means discarded
+}
+
+// CopyProgress is the progress of Copy, the totals are 0 until the source files or objects are
+// scanned
+type CopyProgress struct {
+	TotalBytes       int64 `json:"totalBytes"`
+	TotalFiles       int64 `json:"totalFiles"`
+	TransferredBytes int64 `json:"transferredBytes"`
+	DoneBytes        int64 `json:"doneBytes"`
+	DoneFiles        int64 `json:"doneFiles"`
+	SkippedFiles     int64 `json:"skippedFiles"`
+	ErrorFiles       int64 `json:"errorFiles"`
+}
+
+// SyncOptions is the options of Sync, the zero values mean the defaults of sync
+type SyncOptions struct {
+	Update           bool // only copy when the source is newer than the destination
+	Delete           bool // delete the files or objects not in the source
+	BackupDir        string
+	BigFileThreshold int64
+	PartSize         int64
+	Routines         int64
+	Parallel         int64
+	CheckpointDir    string
+	OutputDir        string
+	DisableCRC64     bool
+	Include          []string
+	Exclude          []string
+	Output           io.Writer // the text output such as the progress bar, nil means discarded
+}
+
+// ListObjectsOptions is the options of ListObjects
+type ListObjectsOptions struct {
+	Directory bool  // only list the current level, the sub directories are returned in Prefixes
+	Limit     int64 // the max number of objects and prefixes, 0 means no limit
+}
+
+// ListObjectsResult is the result of ListObjects
+type ListObjectsResult struct {
+	Objects  []oss.ObjectProperties
+	Prefixes []string
+}
+
+// Copy uploads, downloads or copies objects like "ossutil cp srcURL... destURL"
+func Copy(ctx context.Context, config Config, srcURLs []string, destURL string, options CopyOptions) error {
+	m := config.optionMap()
+	setBoolOption(m, OptionRecursion, options.Recursive)
+	setBoolOption(m, OptionUpdate, options.Update)
+	setBoolOption(m, OptionForce, !options.Update)
+	setIntOption(m, OptionBigFileThreshold, options.BigFileThreshold)
+	setIntOption(m, OptionPartSize, options.PartSize)
+	setIntOption(m, OptionRoutines, options.Routines)
+	setIntOption(m, OptionParallel, options.Parallel)
+	setStringOption(m, OptionCheckpointDir, options.CheckpointDir)
+	setStringOption(m, OptionOutputDir, options.OutputDir)
+	setStringOption(m, OptionMeta, options.Meta)
+	setStringOption(m, OptionACL, options.ACL)
+	setBoolOption(m, OptionDisableCRC64, options.DisableCRC64)
+	setIntOption(m, OptionMaxUpSpeed, options.MaxUpSpeed)
+	setIntOption(m, OptionMaxDownSpeed, options.MaxDownSpeed)
+	if err := checkOption(m); err != nil {
+		return err
+	}
+
+	apiMu.Lock()
+	defer apiMu.Unlock()
+
+	cc := newCopyCommand()
+	cc.filterArgs = filterArgs(options.Include, options.Exclude)
+	cc.textOutput = textOutput(options.Output)
+	if err := cc.Init(ctx, append(append([]string{}, srcURLs...), destURL), m); err != nil {
+		return err
+	}
+	if options.Progress == nil {
+		return cc.RunCommand()
+	}
+
+	interval := options.ProgressInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				options.Progress(cc.progress())
+			case <-done:
+				return
+			}
+		}
+	}()
+	err := cc.RunCommand()
+	close(done)
+	<-stopped
+	options.Progress(cc.progress())
+	return err
+}
+
+// Sync synchronizes the directory or the prefix like "ossutil sync srcURL destURL"
+func Sync(ctx context.Context, config Config, srcURL, destURL string, options SyncOptions) error {
+	m := config.optionMap()
+	setBoolOption(m, OptionUpdate, options.Update)
+	setBoolOption(m, OptionForce, !options.Update)
+	setBoolOption(m, OptionDelete, options.Delete)
+	setStringOption(m, OptionBackupDir, options.BackupDir)
+	setIntOption(m, OptionBigFileThreshold, options.BigFileThreshold)
+	setIntOption(m, OptionPartSize, options.PartSize)
+	setIntOption(m, OptionRoutines, options.Routines)
+	setIntOption(m, OptionParallel, options.Parallel)
+	setStringOption(m, OptionCheckpointDir, options.CheckpointDir)
+	setStringOption(m, OptionOutputDir, options.OutputDir)
+	setBoolOption(m, OptionDisableCRC64, options.DisableCRC64)
+	if err := checkOption(m); err != nil {
+		return err
+	}
+
+	apiMu.Lock()
+	defer apiMu.Unlock()
+
+	sc := &SyncCommand{command: syncCommand.command.template(), copier: newCopyCommand()}
+	sc.filterArgs = filterArgs(options.Include, options.Exclude)
+	sc.copier.filterArgs = sc.filterArgs
+	sc.copier.textOutput = textOutput(options.Output)
+	if err := sc.Init(ctx, []string{srcURL, destURL}, m); err != nil {
+		return err
+	}
+	sc.command.stdout = sc.copier.textOutput
+	return sc.RunCommand()
+}
+
+// ListObjects lists the objects of oss://bucket[/prefix]
+func ListObjects(ctx context.Context, config Config, cloudURL string, options ListObjectsOptions) (ListObjectsResult, error) {
+	var result ListObjectsResult
+	lc := &ListCommand{command: listCommand.command.template()}
+	if err := lc.Init(ctx, []string{cloudURL}, config.optionMap()); err != nil {
+		return result, err
+	}
+	url, err := CloudURLFromString(cloudURL, "")
+	if err != nil {
+		return result, err
+	}
+	if url.bucket == "" {
+		return result, fmt.Errorf("invalid cloud url: %s, miss bucket", cloudURL)
+	}
+	bucket, err := lc.command.cloudBucket(url)
+	if err != nil {
+		return result, err
+	}
+
+	listOptions := []oss.Option{oss.Prefix(url.object), oss.Marker("")}
+	if options.Directory {
+		listOptions = append(listOptions, oss.Delimiter("/"))
+	}
+	if config.RequestPayer != "" {
+		listOptions = append(listOptions, oss.RequestPayer(oss.PayerType(config.RequestPayer)))
+	}
+	for {
+		lor, err := lc.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			return result, err
+		}
+		result.Objects = append(result.Objects, lor.Objects...)
+		result.Prefixes = append(result.Prefixes, lor.CommonPrefixes...)
+		if options.Limit > 0 && int64(len(result.Objects)+len(result.Prefixes)) >= options.Limit {
+			if int64(len(result.Objects)) > options.Limit {
+				result.Objects = result.Objects[:options.Limit]
+			}
+			if remain := options.Limit - int64(len(result.Objects)); int64(len(result.Prefixes)) > remain {
+				result.Prefixes = result.Prefixes[:remain]
+			}
+			return result, nil
+		}
+		if !lor.IsTruncated {
+			return result, nil
+		}
+		listOptions = append(listOptions, oss.Marker(lor.NextMarker))
+	}
+}
+
+// StatObject returns the meta of the object oss://bucket/object
+func StatObject(ctx context.Context, config Config, cloudURL string) (http.Header, error) {
+	sc := &StatCommand{command: statCommand.command.template()}
+	if err := sc.Init(ctx, []string{cloudURL}, config.optionMap()); err != nil {
+		return nil, err
+	}
+	url, err := CloudURLFromString(cloudURL, "")
+	if err != nil {
+		return nil, err
+	}
+	if url.bucket == "" || url.object == "" {
+		return nil, fmt.Errorf("invalid cloud url: %s, miss object", cloudURL)
+	}
+	bucket, err := sc.command.cloudBucket(url)
+	if err != nil {
+		return nil, err
+	}
+
+	var options []oss.Option
+	if config.RequestPayer != "" {
+		options = append(options, oss.RequestPayer(oss.PayerType(config.RequestPayer)))
+	}
+	return sc.command.ossGetObjectStatRetry(bucket, url.object, options...)
+}
+
+// filterArgs returns the arguments of --include and --exclude, the include patterns come first
+func filterArgs(include, exclude []string) []string {
+	args := []string{}
+	for _, pattern := range include {
+		args = append(args, IncludePrompt+"="+pattern)
+	}
+	for _, pattern := range exclude {
+		args = append(args, ExcludePrompt+"="+pattern)
+	}
+	return args
+}
+
+// textOutput returns the writer of the text output of the API, which is discarded if the caller
+// doesn't pass one, so that the embedding process keeps its stdout
+func textOutput(w io.Writer) io.Writer {
+	if w == nil {
+		return ioutil.Discard
+	}
+	return w
+}
+
+// progress returns the progress of the running copy, the counters are updated by the routines
+func (cc *CopyCommand) progress() CopyProgress {
+	m := &cc.monitor
+	progress := CopyProgress{
+		TotalBytes:       atomic.LoadInt64(&m.totalSize),
+		TotalFiles:       atomic.LoadInt64(&m.totalNum),
+		TransferredBytes: atomic.LoadInt64(&m.transferSize),
+		SkippedFiles:     atomic.LoadInt64(&m.skipNum),
+		ErrorFiles:       atomic.LoadInt64(&m.errNum),
+	}
+	progress.DoneBytes = atomic.LoadInt64(&m.dealSize) + atomic.LoadInt64(&m.skipSize)
+	progress.DoneFiles = atomic.LoadInt64(&m.fileNum) + atomic.LoadInt64(&m.dirNum) + progress.SkippedFiles
+	return progress
+}
+
+func newCopyCommand() *CopyCommand {
+	return &CopyCommand{command: copyCommand.command.template()}
+}
+
+// template returns a new command with the definition of cmd, the state of the last run isn't copied
+func (cmd *Command) template() Command {
+	return Command{
+		name:             cmd.name,
+		nameAlias:        cmd.nameAlias,
+		minArgc:          cmd.minArgc,
+		maxArgc:          cmd.maxArgc,
+		validOptionNames: cmd.validOptionNames,
+		group:            cmd.group,
+	}
+}
+
+// optionMap returns the options of all names as ParseArgOptions does, so that the empty options
+// are assembled from the config file
+func (config *Config) optionMap() OptionMapType {
+	m := make(OptionMapType, len(OptionMap))
+	for name, option := range OptionMap {
+		switch option.optionType {
+		case OptionTypeFlagTrue:
+			val := false
+			m[name] = &val
+		case OptionTypeStrings:
+			val := []string{}
+			m[name] = &val
+		default:
+			val := ""
+			m[name] = &val
+		}
+	}
+	setStringOption(m, OptionConfigFile, config.ConfigFile)
+	setStringOption(m, OptionEndpoint, config.Endpoint)
+	setStringOption(m, OptionAccessKeyID, config.AccessKeyID)
+	setStringOption(m, OptionAccessKeySecret, config.AccessKeySecret)
+	setStringOption(m, OptionSTSToken, config.STSToken)
+	setStringOption(m, OptionRegion, config.Region)
+	setStringOption(m, OptionSignVersion, config.SignVersion)
+	setStringOption(m, OptionProxyHost, config.ProxyHost)
+	setStringOption(m, OptionProxyUser, config.ProxyUser)
+	setStringOption(m, OptionProxyPwd, config.ProxyPwd)
+	setStringOption(m, OptionUserAgent, config.UserAgent)
+	setStringOption(m, OptionRequestPayer, config.RequestPayer)
+	setIntOption(m, OptionRetryTimes, config.RetryTimes)
+	setIntOption(m, OptionConnectTimeout, config.ConnectTimeout)
+	setIntOption(m, OptionReadTimeout, config.ReadTimeout)
+	setBoolOption(m, OptionForcePathStyle, config.ForcePathStyle)
+	setBoolOption(m, OptionSkipVerifyCert, config.SkipVerifyCert)
+	return m
+}
+
+// configFromOptions returns the config of the options, which are assembled with the config file
+func configFromOptions(options OptionMapType) Config {
+	var config Config
+	config.ConfigFile, _ = GetString(OptionConfigFile, options)
+	config.Endpoint, _ = GetString(OptionEndpoint, options)
+	config.AccessKeyID, _ = GetString(OptionAccessKeyID, options)
+	config.AccessKeySecret, _ = GetString(OptionAccessKeySecret, options)
+	config.STSToken, _ = GetString(OptionSTSToken, options)
+	config.Region, _ = GetString(OptionRegion, options)
+	config.SignVersion, _ = GetString(OptionSignVersion, options)
+	config.ProxyHost, _ = GetString(OptionProxyHost, options)
+	config.ProxyUser, _ = GetString(OptionProxyUser, options)
+	config.ProxyPwd, _ = GetString(OptionProxyPwd, options)
+	config.UserAgent, _ = GetString(OptionUserAgent, options)
+	config.RequestPayer, _ = GetString(OptionRequestPayer, options)
+	config.RetryTimes, _ = GetInt(OptionRetryTimes, options)
+	config.ConnectTimeout, _ = GetInt(OptionConnectTimeout, options)
+	config.ReadTimeout, _ = GetInt(OptionReadTimeout, options)
+	config.ForcePathStyle, _ = GetBool(OptionForcePathStyle, options)
+	config.SkipVerifyCert, _ = GetBool(OptionSkipVerifyCert, options)
+	return config
+}
+
+func setStringOption(m OptionMapType, name, val string) {
+	if val != "" {
+		m[name] = &val
+	}
+}
+
+func setIntOption(m OptionMapType, name string, val int64) {
+	if val != 0 {
+		str := strconv.FormatInt(val, 10)
+		m[name] = &str
+	}
+}
+
+func setBoolOption(m OptionMapType, name string, val bool) {
+	if val {
+		m[name] = &val
+	}
+}
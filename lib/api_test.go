@@ -0,0 +1,226 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestAPI(c *C) {
+	var mu sync.Mutex
+	uploaded := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			w.Header().Set("X-Oss-Meta-Owner", "teamA")
+		case "PUT":
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded[r.URL.Path] = string(body)
+		default:
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>dir/a</Key><Size>1</Size></Contents><Contents><Key>dir/b</Key><Size>2</Size></Contents>
+<CommonPrefixes><Prefix>dir/sub/</Prefix></CommonPrefixes>
+</ListBucketResult>`)
+		}
+	}))
+	defer server.Close()
+
+	config := Config{
+		Endpoint:        server.URL,
+		AccessKeyID:     "ak",
+		AccessKeySecret: "ak",
+		ForcePathStyle:  true,
+		RetryTimes:      1,
+	}
+	header, err := StatObject(context.Background(), config, "oss://bucket/dir/a")
+	c.Assert(err, IsNil)
+	c.Assert(header.Get("X-Oss-Meta-Owner"), Equals, "teamA")
+	_, err = StatObject(context.Background(), config, "oss://bucket")
+	c.Assert(err, NotNil)
+
+	result, err := ListObjects(context.Background(), config, "oss://bucket/dir/", ListObjectsOptions{Directory: true})
+	c.Assert(err, IsNil)
+	c.Assert(len(result.Objects), Equals, 2)
+	c.Assert(result.Prefixes, DeepEquals, []string{"dir/sub/"})
+	result, err = ListObjects(context.Background(), config, "oss://bucket/dir/", ListObjectsOptions{Limit: 1})
+	c.Assert(err, IsNil)
+	c.Assert(len(result.Objects), Equals, 1)
+	c.Assert(len(result.Prefixes), Equals, 0)
+
+	dir := "ossutil-test-api-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("abc"), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "b.log"), []byte("def"), 0644), IsNil)
+	outputDir := "ossutil-test-api-output-" + randLowStr(5)
+	defer os.RemoveAll(outputDir)
+
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	err = Copy(context.Background(), config, []string{dir}, "oss://bucket/up/", CopyOptions{
+		Recursive:     true,
+		Include:       []string{"*.txt"},
+		CheckpointDir: outputDir + "/checkpoint",
+		OutputDir:     outputDir,
+	})
+	os.Stdout = oldStdout
+	testResultFile.Close()
+	c.Assert(err, IsNil)
+	keys := []string{}
+	for key := range uploaded {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	c.Assert(keys, DeepEquals, []string{"/bucket/up/a.txt"})
+	c.Assert(uploaded["/bucket/up/a.txt"], Equals, "abc")
+
+	testResultFile, err = os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	os.Stdout = testResultFile
+	err = Sync(context.Background(), config, dir, "oss://bucket/sync/", SyncOptions{
+		Exclude:       []string{"*.txt"},
+		CheckpointDir: outputDir + "/checkpoint",
+		OutputDir:     outputDir,
+	})
+	os.Stdout = oldStdout
+	testResultFile.Close()
+	c.Assert(err, IsNil)
+	c.Assert(uploaded["/bucket/sync/b.log"], Equals, "def")
+	_, ok := uploaded["/bucket/sync/a.txt"]
+	c.Assert(ok, Equals, false)
+
+	// the options are checked as the command line does
+	err = Copy(context.Background(), config, []string{dir}, "oss://bucket/up/", CopyOptions{Recursive: true, Routines: -1})
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestAPIContext(c *C) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var mu sync.Mutex
+	pages := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mu.Lock()
+		pages++
+		if pages == 3 {
+			cancel()
+		}
+		mu.Unlock()
+		fmt.Fprint(w, `<ListBucketResult><IsTruncated>true</IsTruncated><NextMarker>next</NextMarker>
+<Contents><Key>a</Key><Size>1</Size></Contents></ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	config := Config{
+		Endpoint:        server.URL,
+		AccessKeyID:     "ak",
+		AccessKeySecret: "ak",
+		ForcePathStyle:  true,
+		RetryTimes:      100,
+	}
+
+	// the endless listing stops once the context is canceled
+	_, err := ListObjects(ctx, config, "oss://bucket", ListObjectsOptions{})
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(pages, Equals, 3)
+
+	// the retries stop at the deadline
+	ctx, cancel = context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = StatObject(ctx, config, "oss://bucket/object")
+	c.Assert(err, Equals, context.DeadlineExceeded)
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestAPIOutput(c *C) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		ioutil.ReadAll(r.Body)
+		time.Sleep(200 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	config := Config{
+		Endpoint:        server.URL,
+		AccessKeyID:     "ak",
+		AccessKeySecret: "ak",
+		ForcePathStyle:  true,
+		RetryTimes:      1,
+	}
+	dir := "ossutil-test-api-output-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "a.txt")
+	c.Assert(ioutil.WriteFile(fileName, []byte("abc"), 0644), IsNil)
+
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+
+	// the concurrent copies run one by one, the text output goes to the writer of the caller or
+	// is discarded, nothing is printed to stdout
+	outputs := make([]bytes.Buffer, 3)
+	errs := make([]error, len(outputs))
+	var wg sync.WaitGroup
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			options := CopyOptions{CheckpointDir: filepath.Join(dir, "checkpoint"), OutputDir: filepath.Join(dir, "output")}
+			if i > 0 {
+				options.Output = &outputs[i]
+			}
+			errs[i] = Copy(context.Background(), config, []string{fileName}, fmt.Sprintf("oss://bucket/%d.txt", i), options)
+		}(i)
+	}
+	wg.Wait()
+	os.Stdout = oldStdout
+	testResultFile.Close()
+
+	for i := range outputs {
+		c.Assert(errs[i], IsNil)
+	}
+	c.Assert(maxRunning, Equals, 1)
+	c.Assert(outputs[0].Len(), Equals, 0)
+	for _, output := range outputs[1:] {
+		c.Assert(strings.Contains(output.String(), "Succeed"), Equals, true)
+	}
+	stdout, err := ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	c.Assert(string(stdout), Equals, "")
+}
@@ -1,7 +1,11 @@
 package lib
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
@@ -17,6 +21,10 @@ var specChineseAppendFile = SpecText{
 
 	syntaxText: ` 
 	ossutil appendfromfile local_file_name oss://bucket/object [options]
+	ossutil appendfromfile - oss://bucket/object [--part-size size] [--flush-interval seconds] [options]
+	ossutil appendfromfile local_dir oss://bucket/object [--separator separator] [options]
+	ossutil appendfromfile --ordered local_file_name... oss://bucket/object [options]
+	ossutil appendfromfile --ordered --file-list list_file oss://bucket/object [options]
 `,
 
 	detailHelpText: ` 
@@ -26,11 +34,59 @@ var specChineseAppendFile = SpecText{
 
 用法：
 
-    该命令只有一种用法：
+    该命令有三种用法：
 
     1) ossutil appendfromfile local_file_name oss://bucket/object [--meta=meta-value]
       将local_file_name内容以append方式上传到可追加的object
       如果输入--meta选项，可以设置object的meta信息
+
+    2) ossutil appendfromfile --ordered local_file_name... oss://bucket/object [--file-list list_file]
+       ossutil appendfromfile local_dir oss://bucket/object
+      将多个本地文件严格按顺序追加到同一个object，文件也可以通过--file-list指定（每行一个文件），
+      此时只需要object参数。追加前检查所有文件，任何文件不合法时不追加任何内容。每个文件追加后
+      校验返回的位置，并再次获取object的长度，如果长度与预期不一致，说明有其他写入者在两次追加
+      之间写入了数据，ossutil停止追加剩余的文件并返回错误，错误信息中包含已追加的文件数。
+      追加因网络错误失败时，ossutil再次获取object的长度：长度已增加文件的大小说明追加已成功但响应
+      丢失，继续追加下一个文件；长度未变时按--retry-times重试；否则停止，因此不会重复追加同一个文件。
+      --maxupspeed限制每次追加的上传速度。
+      --meta只在第一个文件创建object时生效。
+      参数中的目录会被替换为其中的文件（不包括子目录），按文件名的字典序追加，比如将轮转的日志文件
+      合并到一个object；只有一个目录参数时不需要--ordered。--separator指定在相邻文件之间追加的分隔符，
+      支持\n、\r、\t转义，分隔符和下一个文件在同一次追加中写入。
+
+    3) ossutil appendfromfile - oss://bucket/object [--part-size size] [--flush-interval seconds]
+      从stdin读取数据追加到object，比如tail -f输出的日志流。数据先缓冲在内存中，缓冲满--part-size
+      （默认为100MB）、缓冲超过--flush-interval秒（默认为10秒，0表示不按时间追加）或者stdin结束时
+      追加一次。stdin的数据无法再次读取，因此不支持断点续传，追加失败时错误信息中包含已追加的字节数。
+
+断点续传：
+
+    用法1)按--part-size指定的大小（默认为100MB）分多次追加文件，每次追加成功后将已追加的字节数
+    和object的长度记录在--checkpoint-dir（默认为.ossutil_checkpoint）下的checkpoint文件中。网络
+    失败后再次执行相同的命令时，ossutil校验object的长度（以及crc64），跳过文件中已经追加的部分继
+    续追加，而不会重复追加数据。如果最后一次追加已经成功但响应丢失，多出的数据通过crc64确认是
+    文件的后续内容后同样会被跳过；如果object被其他写入者修改，ossutil报错并保留checkpoint。本地
+    文件被修改后，checkpoint失效，文件会被完整追加。追加完成后checkpoint文件会被删除。
+
+    指定--verify-crc时，ossutil在checkpoint中记录追加前object的crc64，整个文件追加完成后（包括多次
+    断点续传），计算整个本地文件的crc64并与追加前object的crc64合并，与object的x-oss-hash-crc64ecma
+    比较，不一致时报错，不会静默接受损坏的数据。追加前object的crc64未知时（比如由旧版本创建的
+    checkpoint）无法校验，ossutil在追加前报错。--verify-crc只能用于用法1)。
+
+并发写入检测：
+
+    ossutil默认从object当前的长度开始追加。脚本可以通过--position指定期望的追加位置（即上次追加后
+    object的长度，object不存在时为0），如果object当前的长度与之不一致，说明有其他写入者追加了数据，
+    ossutil不追加任何内容，报错并以退出码8退出，而不是追加在其他写入者的数据之后。追加过程中oss
+    返回PositionNotEqualToLength，或者追加后的位置与预期不一致时，同样以退出码8退出。三种用法都支持
+    --position。用法1)从checkpoint继续追加时，--position与checkpoint记录的开始追加的位置比较，所以
+    中断后可以使用相同的--position再次执行。
+
+上传回调：
+
+    用法1)可以通过--callback-url、--callback-body和--callback-var设置上传回调，格式与cp命令相同。
+    ossutil只在追加文件最后一部分的请求中设置x-oss-callback头，整个文件追加完成后oss向应用服务器
+    发送一次回调请求，ossutil将应用服务器的响应输出到屏幕上。
 `,
 
 	sampleText: ` 
@@ -42,6 +98,27 @@ var specChineseAppendFile = SpecText{
     
     3) 以访问者付费模式上传文件内容
        ossutil appendfromfile local_file_name oss://bucket/object --payer requester
+
+    4) 按顺序追加多个文件
+       ossutil appendfromfile --ordered part1 part2 part3 oss://bucket/object
+
+    5) 按文件列表中的顺序追加
+       ossutil appendfromfile --ordered --file-list parts.txt oss://bucket/object
+
+    6) 每次追加10MB，失败后再次执行相同的命令继续追加
+       ossutil appendfromfile local_file_name oss://bucket/object --part-size 10485760
+
+    7) 持续追加日志流，每次最多缓冲1MB或者5秒
+       tail -f app.log | ossutil appendfromfile - oss://bucket/logs/app.log --part-size 1048576 --flush-interval 5
+
+    8) 将目录中轮转的日志文件按文件名顺序合并到一个object，文件之间以换行分隔
+       ossutil appendfromfile logs/ oss://bucket/logs/app.log --separator "\n"
+
+    9) 追加完成后校验object的crc64
+       ossutil appendfromfile local_file_name oss://bucket/object --verify-crc
+
+    10) 只有object的长度为1024时才追加，否则以退出码8退出
+       ossutil appendfromfile local_file_name oss://bucket/object --position 1024
 `,
 }
 
@@ -52,6 +129,10 @@ var specEnglishAppendFile = SpecText{
 
 	syntaxText: ` 
 	ossutil appendfromfile local_file_name oss://bucket/object [options]
+	ossutil appendfromfile - oss://bucket/object [--part-size size] [--flush-interval seconds] [options]
+	ossutil appendfromfile local_dir oss://bucket/object [--separator separator] [options]
+	ossutil appendfromfile --ordered local_file_name... oss://bucket/object [options]
+	ossutil appendfromfile --ordered --file-list list_file oss://bucket/object [options]
 `,
 
 	detailHelpText: ` 
@@ -63,11 +144,78 @@ var specEnglishAppendFile = SpecText{
 
 Usages：
 
-    There is only one usage for this command:：
+    There are three usages for this command:
 
     1) ossutil appendfromfile local_file_name oss://bucket/object [--meta=meta-value]
       Upload the local_file_name content to the object by append mode
       If you input the --meta option, you can set the meta value of the object
+
+    2) ossutil appendfromfile --ordered local_file_name... oss://bucket/object [--file-list list_file]
+       ossutil appendfromfile local_dir oss://bucket/object
+      Append multiple local files to one object strictly in order, the files can also be
+      specified by --file-list(one file per line), then only the object argument is needed.
+      All the files are checked before appending, nothing is appended if any file is invalid.
+      After each file is appended, the returned position is verified and the length of the
+      object is got again, if it's not as expected, another writer wrote to the object between
+      the appends, ossutil stops appending the remaining files and returns error, the error
+      message has the number of the files appended. When an append fails by network error,
+      ossutil gets the length of the object again: if it has grown by the size of the file, the
+      append succeeded but its response was lost, and the next file is appended; if it's not
+      changed, the append is retried up to --retry-times; otherwise ossutil stops, so a file is
+      never appended twice. --maxupspeed limits the upload speed of every append. --meta only
+      takes effect when the first file creates the object.
+      The directory in the arguments is replaced by the files in it(not including the sub
+      directories) in lexical order of the names, e.g., to merge the rotated log files into one
+      object, --ordered is not needed if there is only one directory argument. --separator
+      specifies the separator appended between the adjacent files, the escapes \n, \r and \t
+      are supported, the separator is written with the next file in the same append.
+
+    3) ossutil appendfromfile - oss://bucket/object [--part-size size] [--flush-interval seconds]
+      Append the data read from stdin to the object, e.g., the log stream of tail -f. The data
+      is buffered in memory, and appended when --part-size(100MB by default) is buffered, when
+      it has been buffered for --flush-interval seconds(10 by default, 0 means not appending
+      by time), or at the end of stdin. The data of stdin can't be read again, so there is no
+      resume append, the error message has the bytes appended if the append fails.
+
+Resume append:
+
+    Usage 1) appends the file by chunks of --part-size(100MB by default), after each chunk is
+    appended, the bytes appended and the length of the object are recorded in the checkpoint
+    file in --checkpoint-dir(.ossutil_checkpoint by default). When the same command is run
+    again after network failure, ossutil verifies the length(and the crc64) of the object,
+    skips the bytes of the file already appended and continues, instead of appending the data
+    twice. If the last append succeeded but its response was lost, the extra data is skipped
+    too after it's confirmed as the next bytes of the file by crc64. If the object is changed
+    by another writer, ossutil reports error and keeps the checkpoint. The checkpoint is invalid
+    if the local file is modified, then the whole file is appended. The checkpoint file is
+    removed after the file is appended.
+
+    With --verify-crc, ossutil records the crc64 of the object before appending in the checkpoint,
+    after the whole file is appended(including all the resumed runs), the crc64 of the whole local
+    file is calculated and combined with it, then compared with the x-oss-hash-crc64ecma of the
+    object, the error is reported if they mismatch, instead of accepting the corrupted data
+    silently. If the crc64 of the object before appending is unknown(e.g., the checkpoint is created
+    by the old version), it can't be verified and ossutil reports error before appending.
+    --verify-crc only works with usage 1).
+
+Concurrent writer detection:
+
+    By default ossutil appends from the current length of the object. The scripts can specify
+    the expected position to append at by --position(the length of the object after the last
+    append, 0 if the object doesn't exist), if the current length of the object is different,
+    another writer appended to it, ossutil appends nothing, reports error and exits with 8,
+    instead of appending after the data of the other writer. If oss returns
+    PositionNotEqualToLength during appending, or the position after appending is not as
+    expected, ossutil exits with 8 too. --position works with all the three usages. When usage 1)
+    resumes from the checkpoint, --position is compared with the position the checkpoint started
+    appending at, so the command interrupted can be run again with the same --position.
+
+Upload callback:
+
+    Usage 1) can set the upload callback by --callback-url, --callback-body and --callback-var, the
+    format is the same as cp command. ossutil only sets the header x-oss-callback on the request
+    appending the last part of the file, so oss sends the callback request to the application server
+    once after the whole file is appended, and ossutil prints the response of the application server.
 `,
 
 	sampleText: ` 
@@ -79,6 +227,28 @@ Usages：
     
     3) Uploads file content with requester payment mode
        ossutil appendfromfile local_file_name oss://bucket/object --payer requester
+
+    4) Append multiple files in order
+       ossutil appendfromfile --ordered part1 part2 part3 oss://bucket/object
+
+    5) Append the files in the order of the file list
+       ossutil appendfromfile --ordered --file-list parts.txt oss://bucket/object
+
+    6) Append 10MB per request, run the same command again to continue after failure
+       ossutil appendfromfile local_file_name oss://bucket/object --part-size 10485760
+
+    7) Append the log stream continuously, buffering 1MB or 5 seconds at most
+       tail -f app.log | ossutil appendfromfile - oss://bucket/logs/app.log --part-size 1048576 --flush-interval 5
+
+    8) Merge the rotated log files of the directory into one object in the order of the names,
+       separated by the newline
+       ossutil appendfromfile logs/ oss://bucket/logs/app.log --separator "\n"
+
+    9) Verify the crc64 of the object after appending
+       ossutil appendfromfile local_file_name oss://bucket/object --verify-crc
+
+    10) Append only if the length of the object is 1024, otherwise exit with 8
+       ossutil appendfromfile local_file_name oss://bucket/object --position 1024
 `,
 }
 
@@ -86,6 +256,8 @@ type AppendProgressListener struct {
 	lastMilliSecond int64
 	lastSize        int64
 	currSize        int64
+	offset          int64 // the bytes of the file appended before the chunk
+	total           int64 // the size of the file, 0 means the size of the chunk
 }
 
 // ProgressChanged handle progress event
@@ -104,8 +276,12 @@ func (l *AppendProgressListener) ProgressChanged(event *oss.ProgressEvent) {
 				l.lastMilliSecond = now.UnixNano() / 1000 / 1000
 
 				speed := (float64(l.currSize-l.lastSize) / 1024) / (float64(cost) / 1000)
-				rate := float64(l.currSize) * 100 / float64(event.TotalBytes)
-				fmt.Printf("\rtotal append %d(%.2f%%) byte,speed is %.2f(KB/s)", event.ConsumedBytes, rate, speed)
+				total := l.total
+				if total == 0 {
+					total = event.TotalBytes
+				}
+				rate := float64(l.offset+l.currSize) * 100 / float64(total)
+				fmt.Printf("\rtotal append %d(%.2f%%) byte,speed is %.2f(KB/s)", l.offset+event.ConsumedBytes, rate, speed)
 			}
 		}
 	}
@@ -118,6 +294,13 @@ type appendFileOptionType struct {
 	fileName     string
 	fileSize     int64
 	ossMeta      string
+	cmdMeta      string
+	ordered      bool
+	fileList     string
+	separator    string
+	verifyCRC    bool
+	position     int64 // -1 means not --position
+	callback     []oss.Option
 }
 
 type AppendFileCommand struct {
@@ -128,13 +311,11 @@ type AppendFileCommand struct {
 
 var appendFileCommand = AppendFileCommand{
 	command: Command{
-		name:        "appendfromfile",
-		nameAlias:   []string{"appendfromfile"},
-		minArgc:     2,
-		maxArgc:     2,
-		specChinese: specChineseAppendFile,
-		specEnglish: specEnglishAppendFile,
-		group:       GroupTypeNormalCommand,
+		name:      "appendfromfile",
+		nameAlias: []string{"appendfromfile"},
+		minArgc:   1,
+		maxArgc:   MaxInt,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -146,7 +327,19 @@ var appendFileCommand = AppendFileCommand{
 			OptionProxyPwd,
 			OptionEncodingType,
 			OptionMeta,
+			OptionCheckpointDir,
+			OptionPartSize,
+			OptionFlushInterval,
+			OptionOrdered,
+			OptionFileList,
+			OptionSeparator,
+			OptionVerifyCRC,
+			OptionPosition,
 			OptionMaxUpSpeed,
+			OptionRetryTimes,
+			OptionCallbackURL,
+			OptionCallbackBody,
+			OptionCallbackVar,
 			OptionLogLevel,
 			OptionRequestPayer,
 			OptionPassword,
@@ -157,6 +350,11 @@ var appendFileCommand = AppendFileCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -178,16 +376,47 @@ func (afc *AppendFileCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (afc *AppendFileCommand) Init(args []string, options OptionMapType) error {
-	return afc.command.Init(args, options, afc)
+func (afc *AppendFileCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return afc.command.Init(ctx, args, options, afc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
 func (afc *AppendFileCommand) RunCommand() error {
+	afc.commonOptions = []oss.Option{}
 	afc.afOption.encodingType, _ = GetString(OptionEncodingType, afc.command.options)
 	afc.afOption.ossMeta, _ = GetString(OptionMeta, afc.command.options)
+	afc.afOption.cmdMeta = afc.command.commandLineMeta
+	afc.afOption.ordered, _ = GetBool(OptionOrdered, afc.command.options)
+	afc.afOption.fileList, _ = GetString(OptionFileList, afc.command.options)
+	afc.afOption.verifyCRC, _ = GetBool(OptionVerifyCRC, afc.command.options)
+	afc.afOption.position = -1
+	if position, err := GetInt(OptionPosition, afc.command.options); err == nil {
+		afc.afOption.position = position
+	}
+	separator, _ := GetString(OptionSeparator, afc.command.options)
+	afc.afOption.separator = unescapeSeparator(separator)
+	callback, err := getCallbackOptions(afc.command.options)
+	if err != nil {
+		return err
+	}
+	afc.afOption.callback = callback
+
+	// the object is the last argument, the local files are before it
+	argc := len(afc.command.args)
+	if !afc.afOption.ordered {
+		if afc.afOption.fileList != "" {
+			return fmt.Errorf("--file-list only works with --ordered")
+		}
+		if argc != 2 {
+			return CommandError{afc.command.name, "the command needs 2 arguments without --ordered"}
+		}
+	} else if afc.afOption.fileList != "" && argc != 1 {
+		return CommandError{afc.command.name, "the command only needs the object argument with --file-list"}
+	} else if afc.afOption.fileList == "" && argc < 2 {
+		return CommandError{afc.command.name, "the command needs the local files and the object arguments"}
+	}
 
-	srcBucketUrL, err := GetCloudUrl(afc.command.args[1], afc.afOption.encodingType)
+	srcBucketUrL, err := GetCloudUrl(afc.command.args[argc-1], afc.afOption.encodingType)
 	if err != nil {
 		return err
 	}
@@ -207,6 +436,16 @@ func (afc *AppendFileCommand) RunCommand() error {
 	afc.afOption.bucketName = srcBucketUrL.bucket
 	afc.afOption.objectName = srcBucketUrL.object
 
+	if len(afc.afOption.callback) > 0 && (afc.afOption.ordered || afc.command.args[0] == appendStdinName) {
+		return fmt.Errorf("--callback-url only works with appending a single file")
+	}
+	if afc.afOption.ordered {
+		return afc.runOrdered()
+	}
+	if afc.command.args[0] == appendStdinName {
+		return afc.runStdin()
+	}
+
 	// check input file
 	fileName := afc.command.args[0]
 	stat, err := os.Stat(fileName)
@@ -214,8 +453,15 @@ func (afc *AppendFileCommand) RunCommand() error {
 		return err
 	}
 
+	// the files of the directory are appended in lexical order
 	if stat.IsDir() {
-		return fmt.Errorf("%s is dir", fileName)
+		if len(afc.afOption.callback) > 0 {
+			return fmt.Errorf("--callback-url only works with appending a single file")
+		}
+		return afc.runOrdered()
+	}
+	if afc.afOption.separator != "" {
+		return fmt.Errorf("--separator only works with multiple files or a directory")
 	}
 
 	if stat.Size() > MaxAppendObjectSize {
@@ -236,43 +482,117 @@ func (afc *AppendFileCommand) RunCommand() error {
 		return err
 	}
 
-	isExist, err := bucket.IsObjectExist(afc.afOption.objectName, afc.commonOptions...)
+	position, _, err := afc.getAppendPosition(bucket)
 	if err != nil {
 		return err
 	}
 
-	if isExist && afc.afOption.ossMeta != "" {
-		return fmt.Errorf("setting meta on existing append object is not supported")
+	err = afc.AppendFromFile(bucket, position)
+
+	return err
+}
+
+// appendWithCallback appends the last part of the file with the callback options by the raw request,
+// because DoAppendObject discards the response body returned by the application server
+func appendWithCallback(bucket *oss.Bucket, request *oss.AppendObjectRequest, initCRC string, options []oss.Option) (*oss.AppendObjectResult, []byte, error) {
+	params := map[string]interface{}{"append": nil, "position": strconv.FormatInt(request.Position, 10)}
+	options = oss.AddContentType(options, request.ObjectKey)
+	resp, err := bucket.Do("POST", request.ObjectKey, params, options, request.Reader, oss.GetProgressListener(options))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	position := int64(0)
-	if isExist {
-		//get object size
-		props, err := bucket.GetObjectMeta(afc.afOption.objectName, afc.commonOptions...)
-		if err != nil {
-			return err
+	nextPosition, _ := strconv.ParseInt(resp.Headers.Get(oss.HTTPHeaderOssNextAppendPosition), 10, 64)
+	result := &oss.AppendObjectResult{NextPosition: nextPosition, CRC: resp.ServerCRC}
+	// the client crc is of the appended part only, it's combined with the crc before appending
+	if bucket.GetConfig().IsEnableCRC && initCRC != "" && resp.Headers.Get(oss.HTTPHeaderOssCRC64) != "" {
+		crc, _ := strconv.ParseUint(initCRC, 10, 64)
+		clientCRC := oss.CRC64Combine(crc, resp.ClientCRC, uint64(nextPosition-request.Position))
+		if clientCRC != resp.ServerCRC {
+			return result, body, fmt.Errorf("oss: the crc of AppendObject is inconsistent, client %d but server %d", clientCRC, resp.ServerCRC)
 		}
+	}
+	return result, body, nil
+}
 
-		position, err = strconv.ParseInt(props.Get("Content-Length"), 10, 64)
-		if err != nil {
-			return err
-		}
+// getAppendPosition returns the size of the object as the position to append, it's 0 if the object doesn't exist
+func (afc *AppendFileCommand) getAppendPosition(bucket *oss.Bucket) (int64, bool, error) {
+	isExist, err := bucket.IsObjectExist(afc.afOption.objectName, afc.commonOptions...)
+	if err != nil || !isExist {
+		return 0, false, err
 	}
 
-	err = afc.AppendFromFile(bucket, position)
+	//get object size
+	props, err := bucket.GetObjectMeta(afc.afOption.objectName, afc.commonOptions...)
+	if err != nil {
+		return 0, true, err
+	}
 
-	return err
+	position, err := strconv.ParseInt(props.Get("Content-Length"), 10, 64)
+	return position, true, err
+}
+
+// checkPosition returns PositionConflictError if the position to append is not the one of --position,
+// so that the scripts find the object appended by another writer instead of appending after its data
+func (afc *AppendFileCommand) checkPosition(position int64) error {
+	if afc.afOption.position < 0 || afc.afOption.position == position {
+		return nil
+	}
+	return PositionConflictError{fmt.Errorf("the position to append %s is %d, not %d specified by --position, another writer appended to the object",
+		CloudURLToString(afc.afOption.bucketName, afc.afOption.objectName), position, afc.afOption.position)}
+}
+
+// isPositionConflict returns true if oss rejects the append because the position is not the length
+// of the object
+func isPositionConflict(err error) bool {
+	serviceError, ok := err.(oss.ServiceError)
+	return ok && serviceError.Code == "PositionNotEqualToLength"
 }
 
+// AppendFromFile appends the file to the object by chunks of --part-size, the appended bytes are
+// recorded in the checkpoint after each chunk, so that the command run again after failure
+// continues from there
 func (afc *AppendFileCommand) AppendFromFile(bucket *oss.Bucket, position int64) error {
 	file, err := os.OpenFile(afc.afOption.fileName, os.O_RDONLY, 0660)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	acp, err := afc.loadAppendCheckpoint(bucket, file, stat, position)
+	if err != nil {
+		return err
+	}
+	// the resumed append is checked against the position it started at, not the length appended since
+	if err := afc.checkPosition(acp.Position - acp.Appended); err != nil {
+		if acp.Appended == 0 {
+			acp.remove()
+		}
+		return err
+	}
+	if afc.afOption.verifyCRC && acp.BaseCRC64 == "" {
+		return fmt.Errorf("the crc64 of %s before appending is unknown, --verify-crc can't verify it", acp.DestURL)
+	}
+	// the object created by the last run of the command can be resumed with --meta
+	if afc.afOption.cmdMeta != "" && acp.Position > acp.Appended {
+		if acp.Appended == 0 {
+			acp.remove()
+		}
+		return fmt.Errorf("setting meta on existing append object is not supported")
+	}
 
 	var options []oss.Option
-	if afc.afOption.ossMeta != "" {
+	// the meta can only be set by the append which creates the object
+	if afc.afOption.ossMeta != "" && acp.Position == 0 {
 		metas, err := afc.command.parseHeaders(afc.afOption.ossMeta, false)
 		if err != nil {
 			return err
@@ -284,19 +604,80 @@ func (afc *AppendFileCommand) AppendFromFile(bucket *oss.Bucket, position int64)
 		}
 	}
 
-	var listener *AppendProgressListener = &AppendProgressListener{}
-	options = append(options, oss.Progress(listener))
 	options = append(options, afc.commonOptions...)
 
+	chunkSize, _ := GetInt(OptionPartSize, afc.command.options)
+	if chunkSize <= 0 {
+		chunkSize = DefaultAppendChunkSize
+	}
+
 	startT := time.Now()
-	newPosition, err := bucket.AppendObject(afc.afOption.objectName, file, position, options...)
+	resumed := acp.Appended
+	for acp.Appended < acp.FileSize {
+		size := acp.FileSize - acp.Appended
+		if size > chunkSize {
+			size = chunkSize
+		}
+		var respHeader http.Header
+		listener := &AppendProgressListener{offset: acp.Appended, total: acp.FileSize}
+		appendOptions := append([]oss.Option{oss.Progress(listener), oss.GetResponseHeader(&respHeader)}, options...)
+		if acp.CRC64 != "" {
+			initCRC, _ := strconv.ParseUint(acp.CRC64, 10, 64)
+			appendOptions = append(appendOptions, oss.InitCRC(initCRC))
+		}
+		request := &oss.AppendObjectRequest{
+			ObjectKey: afc.afOption.objectName,
+			Reader:    io.NewSectionReader(file, acp.Appended, size),
+			Position:  acp.Position,
+		}
+		var result *oss.AppendObjectResult
+		var callbackBody []byte
+		if len(afc.afOption.callback) > 0 && acp.Appended+size == acp.FileSize {
+			result, callbackBody, err = appendWithCallback(bucket, request, acp.CRC64, append(appendOptions, afc.afOption.callback...))
+		} else {
+			result, err = bucket.DoAppendObject(request, appendOptions)
+		}
+		if err != nil {
+			// nothing is appended if oss responds the error
+			if _, ok := err.(oss.ServiceError); ok && acp.Appended == 0 {
+				acp.remove()
+			}
+			if acp.Appended > resumed {
+				fmt.Printf("\n%d bytes are appended, run the same command again to continue\n", acp.Appended)
+			}
+			if isPositionConflict(err) {
+				return PositionConflictError{err}
+			}
+			return err
+		}
+		if result.NextPosition != acp.Position+size {
+			return PositionConflictError{fmt.Errorf("the position after appending is %d, expected %d", result.NextPosition, acp.Position+size)}
+		}
+		acp.Appended += size
+		acp.Position = result.NextPosition
+		acp.CRC64 = ""
+		if respHeader.Get(oss.HTTPHeaderOssCRC64) != "" {
+			acp.CRC64 = strconv.FormatUint(result.CRC, 10)
+		}
+		if err := acp.save(); err != nil {
+			return err
+		}
+		if callbackBody != nil {
+			fmt.Printf("\ncallback response of %s: %s\n", acp.DestURL, string(callbackBody))
+			LogInfo("callback response of %s: %s\n", acp.DestURL, string(callbackBody))
+		}
+		options = afc.commonOptions
+	}
 	endT := time.Now()
-	if err != nil {
-		return err
-	} else {
-		cost := endT.UnixNano()/1000/1000 - startT.UnixNano()/1000/1000
-		speed := float64(afc.afOption.fileSize) / float64(cost)
-		fmt.Printf("\nlocal file size is %d,the object new size is %d,average speed is %.2f(KB/s)\n\n", afc.afOption.fileSize, newPosition, speed)
-		return nil
+	acp.remove()
+	if afc.afOption.verifyCRC {
+		if err := afc.verifyAppendCRC(bucket, file, acp); err != nil {
+			return err
+		}
 	}
+
+	cost := endT.UnixNano()/1000/1000 - startT.UnixNano()/1000/1000
+	speed := float64(acp.Appended-resumed) / float64(cost)
+	fmt.Printf("\nlocal file size is %d,the object new size is %d,average speed is %.2f(KB/s)\n\n", acp.FileSize, acp.Position, speed)
+	return nil
 }
@@ -2,8 +2,16 @@ package lib
 
 import (
 	"fmt"
+	"hash/crc64"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 	. "gopkg.in/check.v1"
@@ -403,3 +411,748 @@ func (s *OssutilCommandSuite) TestAppendFileWithPayer(c *C) {
 	os.Remove(fileName)
 	os.Remove(downFileName)
 }
+
+func (s *OssutilCommandSuite) TestAppendFileOrdered(c *C) {
+	var mu sync.Mutex
+	data := ""
+	race := false
+	// the connections dropped before and after the data is appended
+	dropBefore, dropAfter := 0, 0
+	drop := func(w http.ResponseWriter) {
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			if data == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		case "POST":
+			position, _ := strconv.Atoi(r.URL.Query().Get("position"))
+			if position != len(data) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `<Error><Code>PositionNotEqualToLength</Code><Message>position is not equal to file length</Message></Error>`)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			if dropBefore > 0 {
+				dropBefore--
+				drop(w)
+				return
+			}
+			data += string(body)
+			if dropAfter > 0 {
+				dropAfter--
+				drop(w)
+				return
+			}
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+			if race {
+				// another writer appends after the response
+				data += "racer"
+			}
+		}
+	}))
+	defer server.Close()
+
+	files := []string{}
+	for _, content := range []string{"first-", "second-", "third"} {
+		fileName := "ossutil-test-append-ordered-" + randLowStr(8)
+		s.createFile(fileName, content, c)
+		defer os.Remove(fileName)
+		files = append(files, fileName)
+	}
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	ordered := true
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// multiple files need --ordered
+	_, err = cm.RunCommand("appendfromfile", append(files, "oss://bucket/object"), options)
+	c.Assert(err, NotNil)
+
+	options[OptionOrdered] = &ordered
+	_, err = cm.RunCommand("appendfromfile", append(files, "oss://bucket/object"), options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "first-second-third")
+
+	// nothing is appended if any file is invalid
+	_, err = cm.RunCommand("appendfromfile", []string{files[0], "not-exist-" + randLowStr(8), "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(data, Equals, "first-second-third")
+
+	// the files of the list, stop after another writer raced in
+	listFile := "ossutil-test-append-list-" + randLowStr(8)
+	s.createFile(listFile, strings.Join(files, "\n")+"\n", c)
+	defer os.Remove(listFile)
+	options[OptionFileList] = &listFile
+	data = ""
+	race = true
+	_, err = cm.RunCommand("appendfromfile", []string{"oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "another writer raced in, 1 of 3 files are appended"), Equals, true)
+	c.Assert(data, Equals, "first-racer")
+
+	// the append whose response is lost isn't appended again, the one not applied is retried
+	race = false
+	data = ""
+	dropAfter, dropBefore = 1, 1
+	retryTimes := "3"
+	options[OptionRetryTimes] = &retryTimes
+	_, err = cm.RunCommand("appendfromfile", []string{"oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "first-second-third")
+	c.Assert(dropAfter+dropBefore, Equals, 0)
+
+	// the client of the appends limits the upload speed
+	maxUpSpeed := "100"
+	options[OptionMaxUpSpeed] = &maxUpSpeed
+	data = ""
+	_, err = cm.RunCommand("appendfromfile", []string{"oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "first-second-third")
+	bucket, err := appendFileCommand.command.ossBucket("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(bucket.GetConfig().UploadLimitSpeed, Equals, 100)
+}
+
+func (s *OssutilCommandSuite) TestAppendFileResume(c *C) {
+	var mu sync.Mutex
+	data := ""
+	appends := 0
+	loseResponse := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			if data == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+		case "POST":
+			position, _ := strconv.Atoi(r.URL.Query().Get("position"))
+			if position != len(data) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `<Error><Code>PositionNotEqualToLength</Code><Message>position is not equal to file length</Message></Error>`)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			data += string(body)
+			appends++
+			if appends == loseResponse {
+				// the data is appended but the response is lost
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-append-resume-" + randLowStr(8)
+	content := randStr(1000)
+	s.createFile(fileName, content, c)
+	defer os.Remove(fileName)
+	cpDir := "ossutil-test-append-cp-" + randLowStr(8)
+	defer os.RemoveAll(cpDir)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	partSize := "300"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionPartSize:        &partSize,
+		OptionCheckpointDir:   &cpDir,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the response of the second chunk is lost
+	loseResponse = 2
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(data, Equals, content[:600])
+	files, _ := ioutil.ReadDir(cpDir)
+	c.Assert(len(files), Equals, 1)
+
+	// the second chunk is verified by crc64 and skipped
+	loseResponse = 0
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, content)
+	_, err = os.Stat(cpDir)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// the object appended by others can't be resumed
+	data = ""
+	appends = 0
+	loseResponse = 1
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	data = "others"
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "appended by others"), Equals, true)
+	c.Assert(data, Equals, "others")
+	c.Assert(os.RemoveAll(cpDir), IsNil)
+
+	// the resumed append is checked against the position of --position it started at
+	data = ""
+	appends = 0
+	loseResponse = 2
+	position := "0"
+	options[OptionPosition] = &position
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(data, Equals, content[:600])
+	loseResponse = 0
+	position = "600"
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(ExitCode(err), Equals, ExitCodePositionConflict)
+	c.Assert(data, Equals, content[:600])
+	position = "0"
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, content)
+}
+
+func (s *OssutilCommandSuite) TestAppendFileFromStdin(c *C) {
+	var mu sync.Mutex
+	data := ""
+	var chunks []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			if data == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+		case "POST":
+			position, _ := strconv.Atoi(r.URL.Query().Get("position"))
+			if position != len(data) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `<Error><Code>PositionNotEqualToLength</Code><Message>position is not equal to file length</Message></Error>`)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			data += string(body)
+			chunks = append(chunks, string(body))
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	partSize := "4"
+	flushInterval := "0"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionPartSize:        &partSize,
+		OptionFlushInterval:   &flushInterval,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout, oldStdin := os.Stdout, os.Stdin
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout, os.Stdin = oldStdout, oldStdin
+		testResultFile.Close()
+	}()
+
+	run := func(write func(w *os.File)) error {
+		r, w, err := os.Pipe()
+		c.Assert(err, IsNil)
+		defer r.Close()
+		os.Stdin = r
+		go func() {
+			write(w)
+			w.Close()
+		}()
+		_, err = cm.RunCommand("appendfromfile", []string{"-", "oss://bucket/logs/app.log"}, options)
+		return err
+	}
+
+	// appended by --part-size, the rest is appended at the end of stdin
+	err = run(func(w *os.File) { w.Write([]byte("hello world")) })
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "hello world")
+	c.Assert(chunks, DeepEquals, []string{"hell", "o wo", "rld"})
+
+	// the buffered data is appended after --flush-interval while stdin is still open
+	partSize = "1024"
+	flushInterval = "1"
+	chunks = nil
+	err = run(func(w *os.File) {
+		w.Write([]byte("\nline1"))
+		for i := 0; i < 50; i++ {
+			mu.Lock()
+			flushed := strings.HasSuffix(data, "line1")
+			mu.Unlock()
+			if flushed {
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte("\nline2"))
+	})
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "hello world\nline1\nline2")
+	c.Assert(chunks, DeepEquals, []string{"\nline1", "\nline2"})
+
+	// the meta can't be set on the existing object
+	meta := "x-oss-meta-author:luxun"
+	options[OptionMeta] = &meta
+	err = run(func(w *os.File) { w.Write([]byte("more")) })
+	c.Assert(err, NotNil)
+	c.Assert(data, Equals, "hello world\nline1\nline2")
+}
+
+func (s *OssutilCommandSuite) TestAppendFileDirectory(c *C) {
+	var mu sync.Mutex
+	data := ""
+	appends := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			if data == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			data += string(body)
+			appends++
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+		}
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-append-dir-" + randLowStr(8)
+	c.Assert(os.MkdirAll(dir+"/sub", 0755), IsNil)
+	defer os.RemoveAll(dir)
+	s.createFile(dir+"/app.log.2", "second", c)
+	s.createFile(dir+"/app.log.1", "first", c)
+	s.createFile(dir+"/app.log.3", "third", c)
+	s.createFile(dir+"/sub/app.log", "sub", c)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	separator := `\n`
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionSeparator:       &separator,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the files are appended in lexical order, the separator is appended with the next file
+	_, err = cm.RunCommand("appendfromfile", []string{dir, "oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "first\nsecond\nthird")
+	c.Assert(appends, Equals, 3)
+
+	// the directory is expanded in the files of --ordered
+	ordered := true
+	options[OptionOrdered] = &ordered
+	separator = "|"
+	data = ""
+	_, err = cm.RunCommand("appendfromfile", []string{dir + "/sub/app.log", dir, "oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "sub|first|second|third")
+
+	// the separator needs multiple files
+	delete(options, OptionOrdered)
+	_, err = cm.RunCommand("appendfromfile", []string{dir + "/app.log.1", "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+
+	// the empty directory
+	_, err = cm.RunCommand("appendfromfile", []string{dir + "/sub/", "oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	emptyDir := dir + "/empty"
+	c.Assert(os.MkdirAll(emptyDir, 0755), IsNil)
+	_, err = cm.RunCommand("appendfromfile", []string{emptyDir, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestAppendFileVerifyCRC(c *C) {
+	var mu sync.Mutex
+	data := "existing-"
+	corrupt := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			if corrupt {
+				// the data is corrupted silently, the crc64 is not returned by the append
+				body[0] ^= 0xff
+				data += string(body)
+				w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+				return
+			}
+			data += string(body)
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-append-crc-" + randLowStr(8)
+	content := randStr(1000)
+	s.createFile(fileName, content, c)
+	defer os.Remove(fileName)
+	cpDir := "ossutil-test-append-cp-" + randLowStr(8)
+	defer os.RemoveAll(cpDir)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	partSize := "300"
+	verifyCRC := true
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionPartSize:        &partSize,
+		OptionCheckpointDir:   &cpDir,
+		OptionVerifyCRC:       &verifyCRC,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the file is appended to the existing object
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "existing-"+content)
+
+	// the corrupted data is reported
+	corrupt = true
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "crc64 of oss://bucket/object mismatch"), Equals, true)
+
+	// --verify-crc only works with a single file
+	_, err = cm.RunCommand("appendfromfile", []string{cpDir, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestAppendFilePosition(c *C) {
+	var mu sync.Mutex
+	data := "existing-"
+	racer := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		case "POST":
+			// another writer appends between the HEAD and the append
+			data += racer
+			racer = ""
+			position, _ := strconv.Atoi(r.URL.Query().Get("position"))
+			if position != len(data) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `<Error><Code>PositionNotEqualToLength</Code><Message>position is not equal to file length</Message></Error>`)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			data += string(body)
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-append-position-" + randLowStr(8)
+	s.createFile(fileName, "appended", c)
+	defer os.Remove(fileName)
+	cpDir := "ossutil-test-append-cp-" + randLowStr(8)
+	defer os.RemoveAll(cpDir)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	position := "5"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionCheckpointDir:   &cpDir,
+		OptionPosition:        &position,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the object is longer than --position, nothing is appended
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(ExitCode(err), Equals, ExitCodePositionConflict)
+	c.Assert(data, Equals, "existing-")
+
+	position = "9"
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "existing-appended")
+
+	// oss rejects the append after another writer appended
+	position = "17"
+	racer = "racer"
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(ExitCode(err), Equals, ExitCodePositionConflict)
+	c.Assert(data, Equals, "existing-appendedracer")
+
+	// the files of the directory and stdin
+	dir := "ossutil-test-append-dir-" + randLowStr(8)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	s.createFile(filepath.Join(dir, "a"), "a", c)
+	_, err = cm.RunCommand("appendfromfile", []string{dir, "oss://bucket/object"}, options)
+	c.Assert(ExitCode(err), Equals, ExitCodePositionConflict)
+
+	reader, writer, err := os.Pipe()
+	c.Assert(err, IsNil)
+	oldStdin := os.Stdin
+	os.Stdin = reader
+	defer func() { os.Stdin = oldStdin }()
+	writer.Write([]byte("stdin"))
+	writer.Close()
+	_, err = cm.RunCommand("appendfromfile", []string{"-", "oss://bucket/object"}, options)
+	c.Assert(ExitCode(err), Equals, ExitCodePositionConflict)
+	c.Assert(data, Equals, "existing-appendedracer")
+}
+
+func (s *OssutilCommandSuite) TestAppendFileCallback(c *C) {
+	var mu sync.Mutex
+	data := ""
+	var callbacks []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			if data == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			data += string(body)
+			callbacks = append(callbacks, r.Header.Get("X-Oss-Callback"))
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+			if r.Header.Get("X-Oss-Callback") != "" {
+				fmt.Fprint(w, `{"status":"ok"}`)
+			}
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-append-callback-" + randLowStr(8)
+	content := randStr(1000)
+	s.createFile(fileName, content, c)
+	defer os.Remove(fileName)
+	cpDir := "ossutil-test-append-cp-" + randLowStr(8)
+	defer os.RemoveAll(cpDir)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	partSize := "300"
+	callbackURL := "http://example.com/callback"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionPartSize:        &partSize,
+		OptionCheckpointDir:   &cpDir,
+		OptionCallbackURL:     &callbackURL,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, options)
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, content)
+
+	// only the last append has the callback
+	c.Assert(len(callbacks), Equals, 4)
+	for _, callback := range callbacks[:3] {
+		c.Assert(callback, Equals, "")
+	}
+	c.Assert(callbacks[3], Not(Equals), "")
+	c.Assert(strings.Contains(s.readFile(resultPath, c), `callback response of oss://bucket/object: {"status":"ok"}`), Equals, true)
+
+	// the callback doesn't work with the ordered append
+	_, err = cm.RunCommand("appendfromfile", []string{"--ordered", fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestAppendFileBucketProfile(c *C) {
+	var mu sync.Mutex
+	data := ""
+	storageClasses := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			if data == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		case "POST":
+			position, _ := strconv.Atoi(r.URL.Query().Get("position"))
+			if position != len(data) {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `<Error><Code>PositionNotEqualToLength</Code><Message>position is not equal to file length</Message></Error>`)
+				return
+			}
+			if position > 0 && (r.Header.Get("X-Oss-Storage-Class") != "" || r.Header.Get("X-Oss-Meta-Team") != "") {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `<Error><Code>InvalidArgument</Code><Message>meta can only be set when creating the object</Message></Error>`)
+				return
+			}
+			storageClasses = append(storageClasses, r.Header.Get("X-Oss-Storage-Class"))
+			body, _ := ioutil.ReadAll(r.Body)
+			data += string(body)
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(data)))
+		}
+	}))
+	defer server.Close()
+
+	configFile := "ossutil-test-config-" + randLowStr(5)
+	defer os.Remove(configFile)
+	config := "[Credentials]\nendpoint=" + server.URL + "\naccessKeyID=ak\naccessKeySecret=sk\n" +
+		"[Bucket-Profile:bucket]\nstorageClass=IA\nmeta=X-Oss-Meta-Team:web\n"
+	c.Assert(ioutil.WriteFile(configFile, []byte(config), 0600), IsNil)
+
+	fileName := "ossutil-test-append-profile-" + randLowStr(8)
+	s.createFile(fileName, "abc", c)
+	defer os.Remove(fileName)
+	cpDir := "ossutil-test-append-cp-" + randLowStr(8)
+	defer os.RemoveAll(cpDir)
+
+	// the options of the command line are parsed again by each run, the profile is merged into them
+	newOptions := func(meta string, ordered bool) OptionMapType {
+		endpoint, ak, sk := "", "", ""
+		forcePathStyle := true
+		return OptionMapType{
+			OptionConfigFile:      &configFile,
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &ak,
+			OptionAccessKeySecret: &sk,
+			OptionMeta:            &meta,
+			OptionOrdered:         &ordered,
+			OptionForcePathStyle:  &forcePathStyle,
+			OptionCheckpointDir:   &cpDir,
+		}
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the profile applies when the object is created, and is ignored when appending to it
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, newOptions("", false))
+	c.Assert(err, IsNil)
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, newOptions("", false))
+	c.Assert(err, IsNil)
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, newOptions("", true))
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "abcabcabc")
+	c.Assert(storageClasses, DeepEquals, []string{"IA", "", ""})
+
+	// --meta of the command line still can't be set on the existing object
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, newOptions("X-Oss-Meta-Team:app", false))
+	c.Assert(err, NotNil)
+	_, err = cm.RunCommand("appendfromfile", []string{fileName, "oss://bucket/object"}, newOptions("X-Oss-Meta-Team:app", true))
+	c.Assert(err, NotNil)
+	c.Assert(data, Equals, "abcabcabc")
+}
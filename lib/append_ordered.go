@@ -0,0 +1,227 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// runOrdered appends the local files to the object strictly in order for --ordered or a directory,
+// the length of the object is verified after each append, so that the remaining files are not
+// appended if another writer appended to the object in between. The --separator is appended
+// together with the next file, so that the object never ends with it
+func (afc *AppendFileCommand) runOrdered() error {
+	if afc.afOption.verifyCRC {
+		return fmt.Errorf("--verify-crc only works with appending a single local file")
+	}
+	files, err := afc.orderedFiles()
+	if err != nil {
+		return err
+	}
+
+	// check all the files before appending, nothing is appended if any of them is invalid
+	sizes := make([]int64, len(files))
+	var totalSize int64
+	for i, fileName := range files {
+		stat, err := os.Stat(fileName)
+		if err != nil {
+			return err
+		}
+		if stat.IsDir() {
+			return fmt.Errorf("%s is dir", fileName)
+		}
+		sizes[i] = stat.Size()
+		if i > 0 {
+			sizes[i] += int64(len(afc.afOption.separator))
+		}
+		totalSize += sizes[i]
+	}
+
+	bucket, err := afc.command.ossBucket(afc.afOption.bucketName)
+	if err != nil {
+		return err
+	}
+	position, isExist, err := afc.getAppendPosition(bucket)
+	if err != nil {
+		return err
+	}
+	if err = afc.checkPosition(position); err != nil {
+		return err
+	}
+	if isExist && afc.afOption.cmdMeta != "" {
+		return fmt.Errorf("setting meta on existing append object is not supported")
+	}
+	if position+totalSize > MaxAppendObjectSize {
+		return fmt.Errorf("the object size will be %d after appending the files, it's bigger than %d, it is not supported by append", position+totalSize, MaxAppendObjectSize)
+	}
+
+	var metaOptions []oss.Option
+	if !isExist && afc.afOption.ossMeta != "" {
+		metas, err := afc.command.parseHeaders(afc.afOption.ossMeta, false)
+		if err != nil {
+			return err
+		}
+		if metaOptions, err = afc.command.getOSSOptions(headerOptionMap, metas); err != nil {
+			return err
+		}
+	}
+
+	url := CloudURLToString(afc.afOption.bucketName, afc.afOption.objectName)
+	for i, fileName := range files {
+		options := append([]oss.Option{}, afc.commonOptions...)
+		prefix := afc.afOption.separator
+		if i == 0 {
+			// the meta can only be set by the first append which creates the object
+			options = append(options, metaOptions...)
+			prefix = ""
+		}
+		nextPosition, err := afc.appendOrderedFileRetry(bucket, fileName, prefix, position, sizes[i], options)
+		if err != nil {
+			if isPositionConflict(err) {
+				return PositionConflictError{fmt.Errorf("another writer appended to %s, %s is not appended at position %d, %d of %d files are appended",
+					url, fileName, position, i, len(files))}
+			}
+			return fmt.Errorf("append %s at position %d error: %s, %d of %d files are appended", fileName, position, err.Error(), i, len(files))
+		}
+		if nextPosition != position+sizes[i] {
+			return PositionConflictError{fmt.Errorf("the position after appending %s is %d, expected %d, stop appending the remaining files", fileName, nextPosition, position+sizes[i])}
+		}
+
+		// the ordering barrier, the next file is appended only if the object is not changed by others
+		length, _, err := afc.getAppendPosition(bucket)
+		if err != nil {
+			return fmt.Errorf("verify the length of %s after appending %s error: %s, %d of %d files are appended", url, fileName, err.Error(), i+1, len(files))
+		}
+		if length != nextPosition {
+			return PositionConflictError{fmt.Errorf("the length of %s is %d after appending %s, expected %d, another writer raced in, %d of %d files are appended",
+				url, length, fileName, nextPosition, i+1, len(files))}
+		}
+		fmt.Printf("%d/%d\t%s\tposition:%d\tsize:%d\n", i+1, len(files), fileName, position, sizes[i])
+		position = nextPosition
+	}
+	fmt.Printf("\n%d files are appended in order, the object new size is %d\n\n", len(files), position)
+	return nil
+}
+
+// orderedFiles returns the local files of the arguments or --file-list, the directory is replaced
+// by the files in it in lexical order, the sub directories are not appended
+func (afc *AppendFileCommand) orderedFiles() ([]string, error) {
+	if afc.afOption.fileList == "" {
+		return expandDirFiles(afc.command.args[:len(afc.command.args)-1])
+	}
+
+	file, err := os.Open(afc.afOption.fileList)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	files := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimRight(scanner.Text(), "\r"); strings.TrimSpace(line) != "" {
+			files = append(files, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("there is no file in --file-list %s", afc.afOption.fileList)
+	}
+	return expandDirFiles(files)
+}
+
+func expandDirFiles(names []string) ([]string, error) {
+	files := []string{}
+	for _, name := range names {
+		stat, err := os.Stat(name)
+		if err != nil || !stat.IsDir() {
+			// the invalid file is reported when it's checked
+			files = append(files, name)
+			continue
+		}
+		// ReadDir returns the entries sorted by name
+		entries, err := ioutil.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		dirFiles := 0
+		for _, entry := range entries {
+			if entry.Mode().IsRegular() {
+				files = append(files, filepath.Join(name, entry.Name()))
+				dirFiles++
+			}
+		}
+		if dirFiles == 0 {
+			return nil, fmt.Errorf("there is no file in the directory %s", name)
+		}
+	}
+	return files, nil
+}
+
+// unescapeSeparator converts the escapes of --separator, e.g., \n to the newline
+func unescapeSeparator(separator string) string {
+	return strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\\`, `\`).Replace(separator)
+}
+
+// appendOrderedFileRetry appends the file at position, the length of the object is got again after
+// a network error or an internal error, the append is done if the object has grown by size, it's
+// retried only if the object is not changed, so that the file is never appended twice
+func (afc *AppendFileCommand) appendOrderedFileRetry(bucket *oss.Bucket, fileName, prefix string, position, size int64, options []oss.Option) (int64, error) {
+	retryTimes, _ := GetInt(OptionRetryTimes, afc.command.options)
+	options = afc.command.withContext(options)
+	for i := 1; ; i++ {
+		nextPosition, err := afc.appendOrderedFile(bucket, fileName, prefix, position, options)
+		if err == nil {
+			return nextPosition, nil
+		}
+
+		// http 4XX error no need to retry
+		if serviceError, ok := err.(oss.ServiceError); ok && serviceError.StatusCode < 500 {
+			return position, err
+		}
+
+		length, _, lengthErr := afc.getAppendPosition(bucket)
+		if lengthErr != nil {
+			return position, err
+		}
+		if length == position+size {
+			// the data is appended but the response is lost
+			LogInfo("append %s at position %d error: %s, the length of the object is %d, the append is done\n", fileName, position, err.Error(), length)
+			return length, nil
+		}
+		if length != position || int64(i) >= retryTimes {
+			return position, err
+		}
+
+		if err := afc.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return position, err
+		}
+	}
+}
+
+func (afc *AppendFileCommand) appendOrderedFile(bucket *oss.Bucket, fileName, prefix string, position int64, options []oss.Option) (int64, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return position, err
+	}
+	defer file.Close()
+	if prefix == "" {
+		return bucket.AppendObject(afc.afOption.objectName, file, position, options...)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		return position, err
+	}
+	// the limited reader tells the content length to the sdk
+	reader := &io.LimitedReader{R: io.MultiReader(strings.NewReader(prefix), file), N: int64(len(prefix)) + stat.Size()}
+	return bucket.AppendObject(afc.afOption.objectName, reader, position, options...)
+}
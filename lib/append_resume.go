@@ -0,0 +1,161 @@
+package lib
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// appendCheckpoint records the bytes of the file acknowledged by oss during appendfromfile, so that
+// running the same command again continues from there instead of appending the file twice
+type appendCheckpoint struct {
+	FilePath string `json:"filePath"`
+	FileSize int64  `json:"fileSize"`
+	ModTime  int64  `json:"modTime"`
+	DestURL  string `json:"destURL"`
+	Appended int64  `json:"appended"` // the bytes of the file appended
+	Position int64  `json:"position"` // the length of the object after the appended bytes
+	CRC64    string `json:"crc64"`    // the crc64 of the object at Position, empty if unknown
+	// the crc64 of the object before the file is appended, empty if unknown
+	BaseCRC64 string `json:"baseCRC64,omitempty"`
+	path      string `json:"-"`
+}
+
+func (acp *appendCheckpoint) save() error {
+	data, err := json.Marshal(acp)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(acp.path, data, 0600)
+}
+
+// remove removes the checkpoint, and the checkpoint dir if it's empty
+func (acp *appendCheckpoint) remove() {
+	os.Remove(acp.path)
+	os.Remove(filepath.Dir(acp.path))
+}
+
+// verify checks the object against the checkpoint before resuming. The object may be longer than
+// the checkpoint if the last append succeeded but its response was lost, the extra bytes are
+// accepted if they are the next bytes of the file by crc64
+func (acp *appendCheckpoint) verify(file *os.File, length int64, crc string) error {
+	if length < acp.Position {
+		return fmt.Errorf("the length of %s is %d, smaller than %d recorded by the last append", acp.DestURL, length, acp.Position)
+	}
+	extra := length - acp.Position
+	if extra > acp.FileSize-acp.Appended {
+		return fmt.Errorf("the length of %s is %d, it's appended by others since the last append", acp.DestURL, length)
+	}
+	if crc != "" && acp.CRC64 != "" {
+		hash := crc64.New(crc64ECMATable)
+		if _, err := io.Copy(hash, io.NewSectionReader(file, acp.Appended, extra)); err != nil {
+			return err
+		}
+		lastCRC, _ := strconv.ParseUint(acp.CRC64, 10, 64)
+		if strconv.FormatUint(oss.CRC64Combine(lastCRC, hash.Sum64(), uint64(extra)), 10) != crc {
+			return fmt.Errorf("the crc64 of %s is different from the data appended, it's appended by others since the last append", acp.DestURL)
+		}
+	} else if extra != 0 {
+		return fmt.Errorf("the length of %s is %d, expected %d, the data appended since the last append can't be verified without crc64", acp.DestURL, length, acp.Position)
+	}
+	acp.Appended += extra
+	acp.Position = length
+	acp.CRC64 = crc
+	return nil
+}
+
+// loadAppendCheckpoint loads the checkpoint of the file and verifies the object against it, a new
+// checkpoint appending from position is returned if there is no checkpoint or the file is changed
+func (afc *AppendFileCommand) loadAppendCheckpoint(bucket *oss.Bucket, file *os.File, stat os.FileInfo, position int64) (*appendCheckpoint, error) {
+	cpDir, _ := GetString(OptionCheckpointDir, afc.command.options)
+	if cpDir == "" {
+		cpDir = CheckpointDir
+	}
+	if err := os.MkdirAll(cpDir, 0755); err != nil {
+		return nil, err
+	}
+	absPath, _ := filepath.Abs(file.Name())
+	destURL := bucketObjectURL(bucket, afc.afOption.objectName)
+	sum := md5.Sum([]byte(absPath + CheckpointSep + destURL))
+	cpPath := filepath.Join(cpDir, hex.EncodeToString(sum[:])+".append.cp")
+
+	acp := &appendCheckpoint{}
+	if data, err := ioutil.ReadFile(cpPath); err == nil && json.Unmarshal(data, acp) == nil &&
+		acp.FilePath == absPath && acp.DestURL == destURL && acp.FileSize == stat.Size() && acp.ModTime == stat.ModTime().UnixNano() {
+		acp.path = cpPath
+		length, crc, err := afc.appendState(bucket)
+		if err != nil {
+			return nil, err
+		}
+		if err := acp.verify(file, length, crc); err != nil {
+			return nil, fmt.Errorf("%s, remove the checkpoint %s if the file should be appended again", err.Error(), cpPath)
+		}
+		fmt.Printf("resume appending %s from %d bytes, the object size is %d\n", file.Name(), acp.Appended, acp.Position)
+		return acp, nil
+	}
+
+	acp = &appendCheckpoint{
+		FilePath: absPath,
+		FileSize: stat.Size(),
+		ModTime:  stat.ModTime().UnixNano(),
+		DestURL:  destURL,
+		Position: position,
+		path:     cpPath,
+	}
+	if position == 0 {
+		acp.CRC64 = "0"
+	} else if length, crc, err := afc.appendState(bucket); err == nil && length == position {
+		acp.CRC64 = crc
+	}
+	acp.BaseCRC64 = acp.CRC64
+	// saved before appending, so that the append whose response is lost can be found
+	return acp, acp.save()
+}
+
+// appendState returns the length and the crc64 of the object, 0 if it doesn't exist
+func (afc *AppendFileCommand) appendState(bucket *oss.Bucket) (int64, string, error) {
+	props, err := afc.command.ossGetObjectStatRetry(bucket, afc.afOption.objectName, afc.commonOptions...)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, "0", nil
+		}
+		return 0, "", err
+	}
+	length, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+	return length, props.Get(oss.HTTPHeaderOssCRC64), err
+}
+
+// verifyAppendCRC compares the crc64 of the object with the one combined from the crc64 of the object
+// before the file was appended and the crc64 of the whole local file for --verify-crc, so that the
+// corrupted data appended in any run of the resumed append is found
+func (afc *AppendFileCommand) verifyAppendCRC(bucket *oss.Bucket, file *os.File, acp *appendCheckpoint) error {
+	hash := crc64.New(crc64ECMATable)
+	if _, err := io.Copy(hash, io.NewSectionReader(file, 0, acp.FileSize)); err != nil {
+		return err
+	}
+	baseCRC, _ := strconv.ParseUint(acp.BaseCRC64, 10, 64)
+	localCRC := strconv.FormatUint(oss.CRC64Combine(baseCRC, hash.Sum64(), uint64(acp.FileSize)), 10)
+
+	length, crc, err := afc.appendState(bucket)
+	if err != nil {
+		return err
+	}
+	if crc == "" {
+		return fmt.Errorf("oss doesn't return the crc64 of %s, it can't be verified", acp.DestURL)
+	}
+	if crc != localCRC {
+		return fmt.Errorf("crc64 of %s mismatch after appending %s, local: %s, oss: %s, the object size is %d, the object may be corrupted or appended by others",
+			acp.DestURL, acp.FilePath, localCRC, crc, length)
+	}
+	LogInfo("verify the crc64 of %s after appending %s success, crc64:%s\n", acp.DestURL, acp.FilePath, crc)
+	return nil
+}
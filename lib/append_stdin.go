@@ -0,0 +1,176 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// appendStdinName is the local file name of appendfromfile meaning the data is read from stdin
+const appendStdinName = "-"
+
+// stdinAppender appends the data read from stdin to the object chunk by chunk
+type stdinAppender struct {
+	afc      *AppendFileCommand
+	bucket   *oss.Bucket
+	options  []oss.Option // the meta options are only used by the append creating the object
+	position int64
+	crc      string // the crc64 of the object, empty if it's unknown
+	appended int64
+}
+
+// runStdin appends the data piped to stdin, e.g., the log stream of tail -f. The data is buffered
+// and appended when --part-size bytes are buffered, when it has been buffered for --flush-interval
+// seconds, or at the end of stdin. Unlike the local file, the data of stdin can't be read again, so
+// there is no checkpoint, the bytes appended are reported if the append fails
+func (afc *AppendFileCommand) runStdin() error {
+	if afc.afOption.verifyCRC {
+		return fmt.Errorf("--verify-crc only works with appending a single local file")
+	}
+	bucket, err := afc.command.ossBucket(afc.afOption.bucketName)
+	if err != nil {
+		return err
+	}
+	position, crc, err := afc.appendState(bucket)
+	if err != nil {
+		return err
+	}
+	if err = afc.checkPosition(position); err != nil {
+		return err
+	}
+
+	appender := &stdinAppender{afc: afc, bucket: bucket, position: position, crc: crc}
+	if position > 0 && afc.afOption.cmdMeta != "" {
+		return fmt.Errorf("setting meta on existing append object is not supported")
+	}
+	// the headers of the bucket profile only apply when the object is created
+	if position == 0 && afc.afOption.ossMeta != "" {
+		metas, err := afc.command.parseHeaders(afc.afOption.ossMeta, false)
+		if err != nil {
+			return err
+		}
+		if appender.options, err = afc.command.getOSSOptions(headerOptionMap, metas); err != nil {
+			return err
+		}
+	}
+
+	chunkSize, _ := GetInt(OptionPartSize, afc.command.options)
+	if chunkSize <= 0 {
+		chunkSize = DefaultAppendChunkSize
+	}
+	flushInterval, err := GetInt(OptionFlushInterval, afc.command.options)
+	if err != nil {
+		flushInterval = DefaultFlushInterval
+	}
+
+	// stdin is read by another goroutine, so that the buffered data can be flushed while the read blocks
+	data := make(chan []byte)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(data)
+		for {
+			buf := make([]byte, 64*1024)
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				select {
+				case data <- buf[:n]:
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					readErr <- err
+				}
+				return
+			}
+		}
+	}()
+
+	var buffer bytes.Buffer
+	var flush <-chan time.Time
+	for {
+		select {
+		case buf, ok := <-data:
+			if !ok {
+				if err := appender.append(buffer.Next(buffer.Len())); err != nil {
+					return err
+				}
+				fmt.Printf("\nappend %d bytes from stdin, the object new size is %d\n\n", appender.appended, appender.position)
+				select {
+				case err := <-readErr:
+					return err
+				default:
+					return nil
+				}
+			}
+			buffer.Write(buf)
+			for int64(buffer.Len()) >= chunkSize {
+				if err := appender.append(buffer.Next(int(chunkSize))); err != nil {
+					return err
+				}
+			}
+			if buffer.Len() == 0 {
+				flush = nil
+			} else if flush == nil && flushInterval > 0 {
+				flush = time.After(time.Duration(flushInterval) * time.Second)
+			}
+		case <-flush:
+			flush = nil
+			if err := appender.append(buffer.Next(buffer.Len())); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// append appends the chunk at the position, the crc64 is chained so that the sdk verifies the whole
+// object after each append
+func (sa *stdinAppender) append(chunk []byte) error {
+	if len(chunk) == 0 {
+		return nil
+	}
+	if sa.position+int64(len(chunk)) > MaxAppendObjectSize {
+		return fmt.Errorf("the object size will be bigger than %d, it is not supported by append, %d bytes are appended from stdin", MaxAppendObjectSize, sa.appended)
+	}
+
+	var respHeader http.Header
+	options := append([]oss.Option{oss.GetResponseHeader(&respHeader)}, sa.options...)
+	options = append(options, sa.afc.commonOptions...)
+	if sa.crc != "" {
+		initCRC, _ := strconv.ParseUint(sa.crc, 10, 64)
+		options = append(options, oss.InitCRC(initCRC))
+	}
+	request := &oss.AppendObjectRequest{
+		ObjectKey: sa.afc.afOption.objectName,
+		Reader:    bytes.NewReader(chunk),
+		Position:  sa.position,
+	}
+	result, err := sa.bucket.DoAppendObject(request, options)
+	if err != nil {
+		if isPositionConflict(err) {
+			return PositionConflictError{fmt.Errorf("another writer appended to the object, %d bytes are appended from stdin, %s", sa.appended, err.Error())}
+		}
+		return fmt.Errorf("%s, %d bytes are appended from stdin", err.Error(), sa.appended)
+	}
+	if result.NextPosition != sa.position+int64(len(chunk)) {
+		return PositionConflictError{fmt.Errorf("the position after appending is %d, expected %d, another writer may append to the object", result.NextPosition, sa.position+int64(len(chunk)))}
+	}
+	sa.position = result.NextPosition
+	sa.appended += int64(len(chunk))
+	sa.crc = ""
+	if respHeader.Get(oss.HTTPHeaderOssCRC64) != "" {
+		sa.crc = strconv.FormatUint(result.CRC, 10)
+	}
+	sa.options = nil
+	fmt.Printf("\rtotal append %d byte from stdin", sa.appended)
+	return nil
+}
@@ -0,0 +1,396 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseAuditIntegrity = SpecText{
+	synopsisText: "抽样校验bucket或者指定前缀下objects的数据完整性",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil audit-integrity oss://bucket[/prefix] [--sample 1%] [-j jobs] [--output-dir dir] [--payer requester]
+`,
+
+	detailHelpText: `
+    该命令从bucket或者指定前缀下随机抽取objects，读取object的全部数据并在本地计算crc64，
+    然后与oss上存储的crc64(X-Oss-Hash-Crc64ecma)进行比较，报告不一致或者无法读取的objects，
+    可以作为备份数据可读、未损坏的合规证明。
+
+    --sample选项指定抽样方式，取值可以为：
+        百分比，如1%，表示每个object被抽中的概率为1%
+        正整数，如1000，表示从所有objects中随机抽取1000个
+    如果不指定该选项，默认校验所有objects。
+
+    没有存储crc64的object(例如早期上传的object)会被跳过，并在结果中统计。
+    不一致或者读取失败的object会记录到--output-dir指定目录下的report文件中。
+`,
+
+	sampleText: `
+    1) 抽取1%的objects进行校验
+       ossutil audit-integrity oss://bucket/backup/ --sample 1%
+
+    2) 随机抽取1000个objects进行校验，并发数为10
+       ossutil audit-integrity oss://bucket/backup/ --sample 1000 -j 10
+
+    3) 校验指定前缀下的所有objects
+       ossutil audit-integrity oss://bucket/backup/
+`,
+}
+
+var specEnglishAuditIntegrity = SpecText{
+	synopsisText: "Verify data integrity of sampled objects in bucket or under the specified prefix",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil audit-integrity oss://bucket[/prefix] [--sample 1%] [-j jobs] [--output-dir dir] [--payer requester]
+`,
+
+	detailHelpText: `
+    The command randomly samples objects in bucket or under the specified prefix, reads the
+    whole data of each sampled object and calculates the crc64 locally, then compares it with
+    the crc64 stored in oss(X-Oss-Hash-Crc64ecma), and reports the objects which mismatch or
+    can not be read. It can be used as compliance evidence that backups are readable.
+
+    --sample option specifies how to sample, the value can be:
+        a percentage, eg: 1%, each object is sampled with the probability of 1%
+        a positive integer, eg: 1000, randomly sample 1000 objects from all objects
+    If the option is not specified, all objects will be verified.
+
+    The objects which have no stored crc64(eg: objects uploaded long ago) will be skipped
+    and counted in the result.
+    The objects which mismatch or fail to be read will be recorded in the report file under
+    the directory specified by --output-dir.
+`,
+
+	sampleText: `
+    1) verify 1% of the objects
+       ossutil audit-integrity oss://bucket/backup/ --sample 1%
+
+    2) randomly verify 1000 objects with 10 concurrency tasks
+       ossutil audit-integrity oss://bucket/backup/ --sample 1000 -j 10
+
+    3) verify all objects under the prefix
+       ossutil audit-integrity oss://bucket/backup/
+`,
+}
+
+/*
+ * Put same type variables together to make them 64bits alignment to avoid
+ * atomic.AddInt64() panic
+ * Please guarantee the alignment if you add new filed
+ */
+type auditIntegrityOptionType struct {
+	scanNum       int64
+	checkNum      int64
+	okNum         int64
+	mismatchNum   int64
+	noCrcNum      int64
+	errNum        int64
+	sampleCount   int64
+	routines      int64
+	samplePercent float64
+	cloudUrl      CloudURL
+	payerOptions  []oss.Option
+	reporter      *Reporter
+}
+
+type AuditIntegrityCommand struct {
+	command  Command
+	aiOption auditIntegrityOptionType
+}
+
+var auditIntegrityCommand = AuditIntegrityCommand{
+	command: Command{
+		name:      "audit-integrity",
+		nameAlias: []string{"audit-integrity"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionOutputDir,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionSample,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (aic *AuditIntegrityCommand) formatHelpForWhole() string {
+	return aic.command.formatHelpForWhole()
+}
+
+func (aic *AuditIntegrityCommand) formatIndependHelp() string {
+	return aic.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (aic *AuditIntegrityCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return aic.command.Init(ctx, args, options, aic)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (aic *AuditIntegrityCommand) RunCommand() error {
+	// clear for go tests
+	aic.aiOption.scanNum = 0
+	aic.aiOption.checkNum = 0
+	aic.aiOption.okNum = 0
+	aic.aiOption.mismatchNum = 0
+	aic.aiOption.noCrcNum = 0
+	aic.aiOption.errNum = 0
+	aic.aiOption.payerOptions = []oss.Option{}
+
+	encodingType, _ := GetString(OptionEncodingType, aic.command.options)
+	cloudUrl, err := GetCloudUrl(aic.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	aic.aiOption.cloudUrl = *cloudUrl
+
+	strSample, _ := GetString(OptionSample, aic.command.options)
+	aic.aiOption.samplePercent, aic.aiOption.sampleCount, err = parseSampleValue(strSample)
+	if err != nil {
+		return err
+	}
+
+	payer, _ := GetString(OptionRequestPayer, aic.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		aic.aiOption.payerOptions = append(aic.aiOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+
+	aic.aiOption.routines, _ = GetInt(OptionRoutines, aic.command.options)
+	outputDir, _ := GetString(OptionOutputDir, aic.command.options)
+	if aic.aiOption.reporter, err = GetReporter(true, outputDir, commandLine); err != nil {
+		return err
+	}
+	defer aic.aiOption.reporter.Clear()
+
+	bucket, err := aic.command.cloudBucket(aic.aiOption.cloudUrl)
+	if err != nil {
+		return err
+	}
+
+	chObjects := make(chan oss.ObjectProperties, ChannelBuf)
+	chError := make(chan error, aic.aiOption.routines)
+	chListError := make(chan error, 1)
+	go aic.sampleProducer(bucket, chObjects, chListError)
+	for i := 0; int64(i) < aic.aiOption.routines; i++ {
+		go aic.verifyConsumer(bucket, chObjects, chError)
+	}
+
+	completed := 0
+	var listErr error
+	for int64(completed) <= aic.aiOption.routines {
+		select {
+		case err := <-chListError:
+			if err != nil {
+				listErr = err
+			}
+			completed++
+		case <-chError:
+			completed++
+		}
+	}
+
+	fmt.Printf("\r%s\r", clearStr)
+	fmt.Printf("scanned object count:%d\tsampled object count:%d\n", aic.aiOption.scanNum, aic.aiOption.checkNum)
+	fmt.Printf("ok:%d\tmismatch:%d\tno stored crc64:%d\tread error:%d\n",
+		aic.aiOption.okNum, aic.aiOption.mismatchNum, aic.aiOption.noCrcNum, aic.aiOption.errNum)
+
+	if listErr != nil {
+		return listErr
+	}
+	if aic.aiOption.mismatchNum > 0 || aic.aiOption.errNum > 0 {
+		return fmt.Errorf("data integrity audit failed, %d object(s) mismatch, %d object(s) can not be read",
+			aic.aiOption.mismatchNum, aic.aiOption.errNum)
+	}
+	return nil
+}
+
+// parseSampleValue parses the value of --sample, returns the percentage or the count
+func parseSampleValue(strSample string) (float64, int64, error) {
+	strSample = strings.TrimSpace(strSample)
+	if strSample == "" {
+		return 100, 0, nil
+	}
+
+	if strings.HasSuffix(strSample, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strSample, "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return 0, 0, fmt.Errorf("invalid sample value: %s, the percentage must be in range (0%%, 100%%]", strSample)
+		}
+		return percent, 0, nil
+	}
+
+	count, err := strconv.ParseInt(strSample, 10, 64)
+	if err != nil || count <= 0 {
+		return 0, 0, fmt.Errorf("invalid sample value: %s, must be a percentage or a positive integer", strSample)
+	}
+	return 0, count, nil
+}
+
+func (aic *AuditIntegrityCommand) sampleProducer(bucket *oss.Bucket, chObjects chan<- oss.ObjectProperties, chListError chan<- error) {
+	defer close(chObjects)
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// reservoir sampling when sample count is specified
+	reservoir := []oss.ObjectProperties{}
+
+	pre := oss.Prefix(aic.aiOption.cloudUrl.object)
+	marker := oss.Marker("")
+	for {
+		listOptions := append(aic.aiOption.payerOptions, pre, marker, oss.MaxKeys(1000))
+		lor, err := aic.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			chListError <- err
+			return
+		}
+
+		for _, object := range lor.Objects {
+			// skip directory object
+			if strings.HasSuffix(object.Key, "/") && object.Size == 0 {
+				continue
+			}
+			aic.aiOption.scanNum++
+
+			if aic.aiOption.sampleCount > 0 {
+				if int64(len(reservoir)) < aic.aiOption.sampleCount {
+					reservoir = append(reservoir, object)
+				} else if j := r.Int63n(aic.aiOption.scanNum); j < aic.aiOption.sampleCount {
+					reservoir[j] = object
+				}
+			} else if r.Float64()*100 < aic.aiOption.samplePercent {
+				chObjects <- object
+			}
+		}
+
+		pre = oss.Prefix(lor.Prefix)
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			break
+		}
+	}
+
+	for _, object := range reservoir {
+		chObjects <- object
+	}
+	chListError <- nil
+}
+
+func (aic *AuditIntegrityCommand) verifyConsumer(bucket *oss.Bucket, chObjects <-chan oss.ObjectProperties, chError chan<- error) {
+	for object := range chObjects {
+		atomic.AddInt64(&aic.aiOption.checkNum, 1)
+		objectUrl := bucketObjectURL(bucket, object.Key)
+
+		stored, actual, err := aic.verifyObject(bucket, object.Key)
+		if err != nil {
+			atomic.AddInt64(&aic.aiOption.errNum, 1)
+			LogError("audit integrity read error,object:%s,error:%s\n", object.Key, err.Error())
+			aic.aiOption.reporter.ReportError(fmt.Sprintf("read %s error, info: %s", objectUrl, err.Error()))
+		} else if stored == "" {
+			atomic.AddInt64(&aic.aiOption.noCrcNum, 1)
+			LogInfo("audit integrity skip,object:%s has no stored crc64\n", object.Key)
+		} else if stored != actual {
+			atomic.AddInt64(&aic.aiOption.mismatchNum, 1)
+			msg := fmt.Sprintf("crc64 mismatch %s, stored: %s, actual: %s", objectUrl, stored, actual)
+			LogError("audit integrity %s\n", msg)
+			aic.aiOption.reporter.ReportError(msg)
+		} else {
+			atomic.AddInt64(&aic.aiOption.okNum, 1)
+		}
+
+		fmt.Printf("\rsampled:%d\tok:%d\tmismatch:%d\tread error:%d", atomic.LoadInt64(&aic.aiOption.checkNum),
+			atomic.LoadInt64(&aic.aiOption.okNum), atomic.LoadInt64(&aic.aiOption.mismatchNum), atomic.LoadInt64(&aic.aiOption.errNum))
+	}
+	chError <- nil
+}
+
+// verifyObject reads the whole object, returns the stored crc64 and the calculated crc64
+func (aic *AuditIntegrityCommand) verifyObject(bucket *oss.Bucket, object string) (string, string, error) {
+	retryTimes, _ := GetInt(OptionRetryTimes, aic.command.options)
+	for i := 1; ; i++ {
+		stored, actual, err := aic.readObjectCRC64(bucket, object)
+		if err == nil {
+			return stored, actual, nil
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return "", "", ObjectError{err, bucket.BucketName, object}
+		}
+
+		if err := aic.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return "", "", err
+		}
+	}
+}
+
+func (aic *AuditIntegrityCommand) readObjectCRC64(bucket *oss.Bucket, object string) (string, string, error) {
+	result, err := bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: object}, aic.aiOption.payerOptions)
+	if err != nil {
+		return "", "", err
+	}
+	defer result.Response.Close()
+
+	stored := result.Response.Headers.Get(oss.HTTPHeaderOssCRC64)
+	if stored == "" {
+		io.Copy(ioutil.Discard, result.Response.Body)
+		return "", "", nil
+	}
+
+	crc64Ins := crc64.New(crc64.MakeTable(crc64.ECMA))
+	if _, err = io.Copy(crc64Ins, result.Response.Body); err != nil {
+		return "", "", err
+	}
+	return stored, strconv.FormatUint(crc64Ins.Sum64(), 10), nil
+}
@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"os"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestAuditIntegrityHelpInfo(c *C) {
+	options := OptionMapType{}
+
+	mkArgs := []string{"audit-integrity"}
+	_, err := cm.RunCommand("help", mkArgs, options)
+	c.Assert(err, IsNil)
+}
+
+func (s *OssutilCommandSuite) TestAuditIntegrityParseSample(c *C) {
+	percent, count, err := parseSampleValue("")
+	c.Assert(err, IsNil)
+	c.Assert(percent, Equals, float64(100))
+	c.Assert(count, Equals, int64(0))
+
+	percent, count, err = parseSampleValue("1.5%")
+	c.Assert(err, IsNil)
+	c.Assert(percent, Equals, 1.5)
+	c.Assert(count, Equals, int64(0))
+
+	percent, count, err = parseSampleValue("1000")
+	c.Assert(err, IsNil)
+	c.Assert(percent, Equals, float64(0))
+	c.Assert(count, Equals, int64(1000))
+
+	for _, v := range []string{"0%", "101%", "abc%", "0", "-1", "abc"} {
+		_, _, err = parseSampleValue(v)
+		c.Assert(err, NotNil)
+	}
+}
+
+func (s *OssutilCommandSuite) TestAuditIntegrityObjects(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	fileName := "ossutil-test-file-" + randLowStr(5)
+	s.createFile(fileName, randLowStr(1024), c)
+	for i := 0; i < 5; i++ {
+		s.putObject(bucketName, "audit/"+randLowStr(8), fileName, c)
+	}
+
+	str := ""
+	sample := "3"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"sample":          &sample,
+	}
+	args := []string{CloudURLToString(bucketName, "audit/")}
+	_, err := cm.RunCommand("audit-integrity", args, options)
+	c.Assert(err, IsNil)
+	c.Assert(auditIntegrityCommand.aiOption.scanNum, Equals, int64(5))
+	c.Assert(auditIntegrityCommand.aiOption.checkNum, Equals, int64(3))
+	c.Assert(auditIntegrityCommand.aiOption.okNum, Equals, int64(3))
+	c.Assert(auditIntegrityCommand.aiOption.mismatchNum, Equals, int64(0))
+
+	// invalid sample value
+	sample = "200%"
+	_, err = cm.RunCommand("audit-integrity", args, options)
+	c.Assert(err, NotNil)
+
+	os.Remove(fileName)
+	s.removeBucket(bucketName, true, c)
+}
@@ -0,0 +1,426 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseBench = SpecText{
+	synopsisText: "对oss进行PUT/GET压力测试并输出吞吐和延迟分位数",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil bench oss://bucket[/prefix] [--object-size sizes] [--concurrency numbers] [--duration duration] [--output format]
+`,
+
+	detailHelpText: `
+    该命令向cloud_url下写入临时object以产生PUT负载，再读取这些object以产生GET负载，并按object大小和
+    并发数的每个组合输出请求数、错误数、吞吐(MB/s)、每秒请求数以及延迟的p50/p95/p99，可用于容量规划
+    以及比较不同的endpoint。测试结束后会删除写入的临时object。
+
+    --object-size选项指定object的大小，多个大小用逗号分隔，支持K、M、G单位，如：4K,4M,256M，默认为4K。
+
+    --concurrency选项指定并发数，多个并发数用逗号分隔，如：1,16,64，默认为1。
+
+    --duration选项指定每个组合中PUT和GET各自的持续时间，如：30s、5m，默认为60s。
+
+    --output为json或者yaml时，以json数组或者yaml的格式输出结果，默认为text。
+
+    临时object的名称为prefix加上ossutil-bench-时间/，请确保对该前缀有写入和删除权限，测试会产生请求
+    和流量费用。
+`,
+
+	sampleText: `
+    1) 使用4K的object，以16个并发测试30秒
+       ossutil bench oss://bucket --object-size 4K --concurrency 16 --duration 30s
+
+    2) 测试多个大小和并发数的组合
+       ossutil bench oss://bucket/bench/ --object-size 4K,4M,256M --concurrency 1,16,64 --duration 60s
+`,
+}
+
+var specEnglishBench = SpecText{
+	synopsisText: "Run PUT/GET load against oss and report the throughput and latency percentiles",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil bench oss://bucket[/prefix] [--object-size sizes] [--concurrency numbers] [--duration duration] [--output format]
+`,
+
+	detailHelpText: `
+    The command writes temporary objects under cloud_url to drive PUT load, then reads the objects
+    to drive GET load, and reports the requests, the errors, the throughput(MB/s), the requests per
+    second and the p50/p95/p99 latency for each combination of the object size and the concurrency,
+    for capacity planning and endpoint comparison. The temporary objects are deleted at the end.
+
+    --object-size option specifies the object sizes separated by comma, K, M and G units are
+    supported, e.g., 4K,4M,256M, the default is 4K.
+
+    --concurrency option specifies the concurrencies separated by comma, e.g., 1,16,64, the default is 1.
+
+    --duration option specifies the duration of PUT and GET respectively in each combination,
+    e.g., 30s, 5m, the default is 60s.
+
+    If --output is json or yaml, the result is printed as a json array or in yaml, the default is text.
+
+    The temporary objects are named as the prefix followed by ossutil-bench-time/, please make sure
+    you can write and delete objects under the prefix, the test generates request and traffic fees.
+`,
+
+	sampleText: `
+    1) test 4K objects with 16 concurrency for 30 seconds
+       ossutil bench oss://bucket --object-size 4K --concurrency 16 --duration 30s
+
+    2) test the combinations of multiple sizes and concurrencies
+       ossutil bench oss://bucket/bench/ --object-size 4K,4M,256M --concurrency 1,16,64 --duration 60s
+`,
+}
+
+const (
+	benchDefaultObjectSize  = "4K"
+	benchDefaultConcurrency = "1"
+	benchDefaultDuration    = "60s"
+	benchPayloadSize        = 1024 * 1024
+	benchDeleteBatch        = 1000
+)
+
+// benchResult is the result of an operation in a combination of the object size and the concurrency
+type benchResult struct {
+	Operation   string  `json:"operation"`
+	ObjectSize  int64   `json:"objectSize"`
+	Concurrency int     `json:"concurrency"`
+	Requests    int     `json:"requests"`
+	Errors      int     `json:"errors"`
+	Throughput  float64 `json:"throughputMBps"`
+	RequestRate float64 `json:"requestsPerSecond"`
+	P50         float64 `json:"p50Ms"`
+	P95         float64 `json:"p95Ms"`
+	P99         float64 `json:"p99Ms"`
+	lastError   error
+}
+
+type BenchCommand struct {
+	command  Command
+	cloudURL CloudURL
+	duration time.Duration
+	payload  []byte
+}
+
+var benchCommand = BenchCommand{
+	command: Command{
+		name:      "bench",
+		nameAlias: []string{"bench"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+			OptionObjectSize,
+			OptionConcurrency,
+			OptionDuration,
+			OptionOutput,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (bc *BenchCommand) formatHelpForWhole() string {
+	return bc.command.formatHelpForWhole()
+}
+
+func (bc *BenchCommand) formatIndependHelp() string {
+	return bc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (bc *BenchCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bc.command.Init(ctx, args, options, bc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (bc *BenchCommand) RunCommand() error {
+	cloudURL, err := CloudURLFromString(bc.command.args[0], "")
+	if err != nil {
+		return err
+	}
+	if cloudURL.bucket == "" {
+		return fmt.Errorf("invalid cloud url: %s, miss bucket", bc.command.args[0])
+	}
+	bc.cloudURL = cloudURL
+
+	strSizes, _ := GetString(OptionObjectSize, bc.command.options)
+	if strSizes == "" {
+		strSizes = benchDefaultObjectSize
+	}
+	var sizes []int64
+	for _, str := range strings.Split(strSizes, ",") {
+		size, err := parseBenchSize(str)
+		if err != nil {
+			return fmt.Errorf("invalid --object-size: %s, %s", strSizes, err.Error())
+		}
+		sizes = append(sizes, size)
+	}
+
+	strConcurrency, _ := GetString(OptionConcurrency, bc.command.options)
+	if strConcurrency == "" {
+		strConcurrency = benchDefaultConcurrency
+	}
+	var concurrencies []int
+	for _, str := range strings.Split(strConcurrency, ",") {
+		concurrency, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil || concurrency <= 0 {
+			return fmt.Errorf("invalid --concurrency: %s, the value should be positive integers separated by comma", strConcurrency)
+		}
+		concurrencies = append(concurrencies, concurrency)
+	}
+
+	strDuration, _ := GetString(OptionDuration, bc.command.options)
+	if strDuration == "" {
+		strDuration = benchDefaultDuration
+	}
+	bc.duration, err = time.ParseDuration(strDuration)
+	if err != nil || bc.duration <= 0 {
+		return fmt.Errorf("invalid --duration: %s, the value should be positive duration, e.g., 30s, 5m", strDuration)
+	}
+
+	bucket, err := bc.command.cloudBucket(cloudURL)
+	if err != nil {
+		return err
+	}
+
+	bc.payload = make([]byte, benchPayloadSize)
+	rand.Read(bc.payload)
+	prefix := cloudURL.object + "ossutil-bench-" + time.Now().Format("20060102150405") + "/"
+
+	var results []*benchResult
+	for _, size := range sizes {
+		for _, concurrency := range concurrencies {
+			objectPrefix := fmt.Sprintf("%s%d-%d/", prefix, size, concurrency)
+			putResult, objects := bc.benchPut(bucket, objectPrefix, size, concurrency)
+			results = append(results, putResult)
+			if len(objects) > 0 {
+				results = append(results, bc.benchGet(bucket, objects, size, concurrency))
+			}
+			bc.deleteObjects(bucket, objects)
+		}
+	}
+	return bc.printResults(results)
+}
+
+// benchPut puts objects with concurrency workers for the duration, and returns the objects put
+func (bc *BenchCommand) benchPut(bucket *oss.Bucket, objectPrefix string, size int64, concurrency int) (*benchResult, []string) {
+	var objects []string
+	var mu sync.Mutex
+	result := bc.runWorkers(concurrency, func(worker, seq int) (int64, error) {
+		objectName := fmt.Sprintf("%s%d-%d", objectPrefix, worker, seq)
+		reader := &benchReader{payload: bc.payload, remain: size}
+		err := bucket.PutObject(objectName, reader, oss.ContentLength(size))
+		if err != nil {
+			return 0, err
+		}
+		mu.Lock()
+		objects = append(objects, objectName)
+		mu.Unlock()
+		return size, nil
+	})
+	result.Operation = "PUT"
+	result.ObjectSize = size
+	return result, objects
+}
+
+// benchGet gets the objects put by benchPut round robin with concurrency workers for the duration
+func (bc *BenchCommand) benchGet(bucket *oss.Bucket, objects []string, size int64, concurrency int) *benchResult {
+	result := bc.runWorkers(concurrency, func(worker, seq int) (int64, error) {
+		objectName := objects[(worker+seq*concurrency)%len(objects)]
+		body, err := bucket.GetObject(objectName)
+		if err != nil {
+			return 0, err
+		}
+		defer body.Close()
+		return io.Copy(ioutil.Discard, body)
+	})
+	result.Operation = "GET"
+	result.ObjectSize = size
+	return result
+}
+
+// runWorkers calls op in concurrency goroutines until the duration is passed, and collects the latencies
+func (bc *BenchCommand) runWorkers(concurrency int, op func(worker, seq int) (int64, error)) *benchResult {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var latencies []time.Duration
+	var bytes int64
+	result := &benchResult{Concurrency: concurrency}
+
+	startT := time.Now()
+	deadline := startT.Add(bc.duration)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for seq := 0; time.Now().Before(deadline); seq++ {
+				opStart := time.Now()
+				n, err := op(worker, seq)
+				latency := time.Since(opStart)
+				mu.Lock()
+				if err != nil {
+					result.Errors++
+					result.lastError = err
+				} else {
+					latencies = append(latencies, latency)
+					bytes += n
+				}
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	elapsed := time.Since(startT).Seconds()
+	result.Requests = len(latencies)
+	if elapsed > 0 {
+		result.Throughput = float64(bytes) / 1024 / 1024 / elapsed
+		result.RequestRate = float64(result.Requests) / elapsed
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.P50 = benchPercentile(latencies, 50)
+	result.P95 = benchPercentile(latencies, 95)
+	result.P99 = benchPercentile(latencies, 99)
+	if result.lastError != nil {
+		LogError("bench error, %d errors, last error:%s\n", result.Errors, result.lastError.Error())
+	}
+	return result
+}
+
+func (bc *BenchCommand) deleteObjects(bucket *oss.Bucket, objects []string) {
+	for i := 0; i < len(objects); i += benchDeleteBatch {
+		end := i + benchDeleteBatch
+		if end > len(objects) {
+			end = len(objects)
+		}
+		if _, err := bucket.DeleteObjects(objects[i:end], oss.DeleteObjectsQuiet(true)); err != nil {
+			LogError("bench delete objects error:%s\n", err.Error())
+		}
+	}
+}
+
+func (bc *BenchCommand) printResults(results []*benchResult) error {
+	output, err := getOutputFormat(bc.command.options)
+	if err != nil {
+		return err
+	}
+	if output.structured() {
+		return output.print(results)
+	}
+
+	fmt.Printf("%-4s %12s %12s %10s %8s %16s %12s %10s %10s %10s\n", "Op", "ObjectSize", "Concurrency", "Requests", "Errors",
+		"Throughput(MB/s)", "Requests/s", "P50(ms)", "P95(ms)", "P99(ms)")
+	for _, r := range results {
+		fmt.Printf("%-4s %12s %12d %10d %8d %16.2f %12.2f %10.2f %10.2f %10.2f\n", r.Operation, getSizeString(r.ObjectSize),
+			r.Concurrency, r.Requests, r.Errors, r.Throughput, r.RequestRate, r.P50, r.P95, r.P99)
+	}
+	for _, r := range results {
+		if r.lastError != nil {
+			fmt.Printf("\n%s of %s with concurrency %d has %d errors, last error:%s\n", r.Operation, getSizeString(r.ObjectSize),
+				r.Concurrency, r.Errors, r.lastError.Error())
+		}
+	}
+	return nil
+}
+
+// benchPercentile returns the percentile of the sorted latencies in milliseconds by the nearest rank
+func benchPercentile(sorted []time.Duration, percent int) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (len(sorted)*percent + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
+
+// parseBenchSize parses the size with K, M or G unit, e.g., 4K, 256M, the B suffix is optional
+func parseBenchSize(str string) (int64, error) {
+	str = strings.ToUpper(strings.TrimSpace(str))
+	str = strings.TrimSuffix(str, "B")
+	unit := int64(1)
+	if str != "" {
+		switch str[len(str)-1] {
+		case 'K':
+			unit = 1024
+		case 'M':
+			unit = 1024 * 1024
+		case 'G':
+			unit = 1024 * 1024 * 1024
+		}
+		if unit > 1 {
+			str = str[:len(str)-1]
+		}
+	}
+	size, err := strconv.ParseInt(str, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("the size should be positive integer with optional K, M or G unit")
+	}
+	return size * unit, nil
+}
+
+// benchReader repeats the random payload for remain bytes, so the large objects are not kept in memory
+type benchReader struct {
+	payload []byte
+	offset  int
+	remain  int64
+}
+
+func (r *benchReader) Read(p []byte) (int, error) {
+	if r.remain <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remain {
+		p = p[:r.remain]
+	}
+	n := copy(p, r.payload[r.offset:])
+	r.offset = (r.offset + n) % len(r.payload)
+	r.remain -= int64(n)
+	return n, nil
+}
@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestBenchParse(c *C) {
+	for str, size := range map[string]int64{"4096": 4096, "4K": 4096, "4kb": 4096, "256M": 256 * 1024 * 1024, "1G": 1024 * 1024 * 1024} {
+		v, err := parseBenchSize(str)
+		c.Assert(err, IsNil)
+		c.Assert(v, Equals, size)
+	}
+	for _, str := range []string{"", "K", "0", "-1K", "4T"} {
+		_, err := parseBenchSize(str)
+		c.Assert(err, NotNil)
+	}
+
+	var latencies []time.Duration
+	for i := 1; i <= 100; i++ {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+	c.Assert(benchPercentile(latencies, 50), Equals, float64(50))
+	c.Assert(benchPercentile(latencies, 99), Equals, float64(99))
+	c.Assert(benchPercentile(latencies[:1], 95), Equals, float64(1))
+	c.Assert(benchPercentile(nil, 95), Equals, float64(0))
+
+	reader := &benchReader{payload: []byte("abc"), remain: 7}
+	data, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "abcabca")
+}
+
+func (s *OssutilCommandSuite) TestBenchRun(c *C) {
+	var mu sync.Mutex
+	objects := map[string]int64{}
+	deleted := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "PUT":
+			n, _ := io.Copy(ioutil.Discard, r.Body)
+			objects[r.URL.Path] = n
+		case "GET":
+			w.Write(make([]byte, objects[r.URL.Path]))
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			deleted += strings.Count(string(body), "<Key>")
+			w.Write([]byte("<DeleteResult></DeleteResult>"))
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	objectSize := "1K,2K"
+	concurrency := "2"
+	duration := "100ms"
+	output := "json"
+	forcePathStyle := true
+	bc := &BenchCommand{}
+	bc.command.args = []string{"oss://bench-bucket/prefix/"}
+	bc.command.options = OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionObjectSize:      &objectSize,
+		OptionConcurrency:     &concurrency,
+		OptionDuration:        &duration,
+		OptionOutput:          &output,
+		OptionForcePathStyle:  &forcePathStyle,
+	}
+
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	err = bc.RunCommand()
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, IsNil)
+
+	var results []benchResult
+	c.Assert(json.Unmarshal([]byte(s.readFile(resultPath, c)), &results), IsNil)
+	c.Assert(len(results), Equals, 4)
+	c.Assert(results[0].Operation, Equals, "PUT")
+	c.Assert(results[0].ObjectSize, Equals, int64(1024))
+	c.Assert(results[1].Operation, Equals, "GET")
+	c.Assert(results[3].ObjectSize, Equals, int64(2048))
+	for _, r := range results {
+		c.Assert(r.Concurrency, Equals, 2)
+		c.Assert(r.Requests > 0, Equals, true)
+		c.Assert(r.Errors, Equals, 0)
+		c.Assert(r.P99 >= r.P50, Equals, true)
+	}
+	c.Assert(deleted, Equals, len(objects))
+	for path, size := range objects {
+		c.Assert(strings.HasPrefix(path, "/bench-bucket/prefix/ossutil-bench-"), Equals, true)
+		c.Assert(size == 1024 || size == 2048, Equals, true)
+	}
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -102,13 +103,11 @@ type BucketAccessMonitorCommand struct {
 
 var bucketAccessMonitorCommand = BucketAccessMonitorCommand{
 	command: Command{
-		name:        "access-monitor",
-		nameAlias:   []string{"access-monitor"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketAccessMonitor,
-		specEnglish: specEnglishBucketAccessMonitor,
-		group:       GroupTypeNormalCommand,
+		name:      "access-monitor",
+		nameAlias: []string{"access-monitor"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -128,6 +127,11 @@ var bucketAccessMonitorCommand = BucketAccessMonitorCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -149,8 +153,8 @@ func (blc *BucketAccessMonitorCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (blc *BucketAccessMonitorCommand) Init(args []string, options OptionMapType) error {
-	return blc.command.Init(args, options, blc)
+func (blc *BucketAccessMonitorCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return blc.command.Init(ctx, args, options, blc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -221,15 +225,6 @@ func (blc *BucketAccessMonitorCommand) PutBucketAccessMonitor() error {
 	return client.PutBucketAccessMonitorXml(blc.blOption.bucketName, string(xmlBody), options...)
 }
 
-func (blc *BucketAccessMonitorCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket access monitor: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (blc *BucketAccessMonitorCommand) GetBucketAccessMonitor() error {
 	client, err := blc.command.ossClient(blc.blOption.bucketName)
 	if err != nil {
@@ -246,7 +241,7 @@ func (blc *BucketAccessMonitorCommand) GetBucketAccessMonitor() error {
 		fileName := blc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := blc.confirm(fileName)
+			bConitnue := blc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -221,13 +222,11 @@ type BucketCnameCommand struct {
 
 var bucketCnameCommand = BucketCnameCommand{
 	command: Command{
-		name:        "bucket-cname",
-		nameAlias:   []string{"bucket-cname"},
-		minArgc:     1,
-		maxArgc:     3,
-		specChinese: specChineseBucketCname,
-		specEnglish: specEnglishBucketCname,
-		group:       GroupTypeNormalCommand,
+		name:      "bucket-cname",
+		nameAlias: []string{"bucket-cname"},
+		minArgc:   1,
+		maxArgc:   3,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -247,6 +246,11 @@ var bucketCnameCommand = BucketCnameCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -269,8 +273,8 @@ func (bwc *BucketCnameCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bwc *BucketCnameCommand) Init(args []string, options OptionMapType) error {
-	return bwc.command.Init(args, options, bwc)
+func (bwc *BucketCnameCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bwc.command.Init(ctx, args, options, bwc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -328,15 +332,6 @@ func (bwc *BucketCnameCommand) RunCommand() error {
 	return err
 }
 
-func (bwc *BucketCnameCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket cname: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (bwc *BucketCnameCommand) GetBucketCname() error {
 	client := bwc.bwOption.client
 	output, err := client.GetBucketCname(bwc.bwOption.bucketName)
@@ -348,7 +343,7 @@ func (bwc *BucketCnameCommand) GetBucketCname() error {
 		fileName := bwc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bwc.confirm(fileName)
+			bConitnue := bwc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -433,7 +428,7 @@ func (bwc *BucketCnameCommand) GetBucketCnameToken() error {
 		fileName := bwc.command.args[2]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bwc.confirm(fileName)
+			bConitnue := bwc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -472,7 +467,7 @@ func (bwc *BucketCnameCommand) PutBucketCnameToken() error {
 		fileName := bwc.command.args[2]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bwc.confirm(fileName)
+			bConitnue := bwc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -127,13 +128,11 @@ type CorsCommand struct {
 
 var corsCommand = CorsCommand{
 	command: Command{
-		name:        "cors",
-		nameAlias:   []string{"cors"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseCors,
-		specEnglish: specEnglishCors,
-		group:       GroupTypeNormalCommand,
+		name:      "cors",
+		nameAlias: []string{"cors"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -153,6 +152,11 @@ var corsCommand = CorsCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -174,8 +178,8 @@ func (corsc *CorsCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (corsc *CorsCommand) Init(args []string, options OptionMapType) error {
-	return corsc.command.Init(args, options, corsc)
+func (corsc *CorsCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return corsc.command.Init(ctx, args, options, corsc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -262,15 +266,6 @@ func (corsc *CorsCommand) PutBucketCors() error {
 	return client.SetBucketCORS(corsc.csOption.bucketName, rulesConfig.CORSRules)
 }
 
-func (corsc *CorsCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("cors: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (corsc *CorsCommand) GetBucketCors() error {
 	client, err := corsc.command.ossClient(corsc.csOption.bucketName)
 	if err != nil {
@@ -292,7 +287,7 @@ func (corsc *CorsCommand) GetBucketCors() error {
 		fileName := corsc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := corsc.confirm(fileName)
+			bConitnue := corsc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
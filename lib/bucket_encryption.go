@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -113,13 +114,11 @@ type BucketEncryptionCommand struct {
 
 var bucketEncryptionCommand = BucketEncryptionCommand{
 	command: Command{
-		name:        "bucket-encryption",
-		nameAlias:   []string{"bucket-encryption"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseBucketEncryption,
-		specEnglish: specEnglishBucketEncryption,
-		group:       GroupTypeNormalCommand,
+		name:      "bucket-encryption",
+		nameAlias: []string{"bucket-encryption"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -142,6 +141,11 @@ var bucketEncryptionCommand = BucketEncryptionCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -163,8 +167,8 @@ func (bec *BucketEncryptionCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bec *BucketEncryptionCommand) Init(args []string, options OptionMapType) error {
-	return bec.command.Init(args, options, bec)
+func (bec *BucketEncryptionCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bec.command.Init(ctx, args, options, bec)
 }
 
 // RunCommand simulate inheritance, and polymorphism
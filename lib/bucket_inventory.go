@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -188,13 +189,11 @@ type BucketInventoryCommand struct {
 
 var bucketInventoryCommand = BucketInventoryCommand{
 	command: Command{
-		name:        "inventory",
-		nameAlias:   []string{"inventory"},
-		minArgc:     1,
-		maxArgc:     3,
-		specChinese: specChineseBucketInventory,
-		specEnglish: specEnglishBucketInventory,
-		group:       GroupTypeNormalCommand,
+		name:      "inventory",
+		nameAlias: []string{"inventory"},
+		minArgc:   1,
+		maxArgc:   3,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -215,6 +214,11 @@ var bucketInventoryCommand = BucketInventoryCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -236,8 +240,8 @@ func (bic *BucketInventoryCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bic *BucketInventoryCommand) Init(args []string, options OptionMapType) error {
-	return bic.command.Init(args, options, bic)
+func (bic *BucketInventoryCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bic.command.Init(ctx, args, options, bic)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -310,15 +314,6 @@ func (bic *BucketInventoryCommand) PutBucketInventory() error {
 	return client.SetBucketInventoryXml(bic.bwOption.bucketName, string(text))
 }
 
-func (bic *BucketInventoryCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket inventory: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (bic *BucketInventoryCommand) GetBucketInventory() error {
 	if len(bic.command.args) < 2 {
 		return fmt.Errorf("get bucket inventory need at least 2 parameters,the parameter id is empty")
@@ -341,7 +336,7 @@ func (bic *BucketInventoryCommand) GetBucketInventory() error {
 		fileName := bic.command.args[2]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bic.confirm(fileName)
+			bConitnue := bic.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -416,7 +411,7 @@ func (bic *BucketInventoryCommand) ListBucketInventory() error {
 		fileName := bic.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bic.confirm(fileName)
+			bConitnue := bic.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -145,13 +146,11 @@ type BucketLifeCycleCommand struct {
 
 var bucketLifeCycleCommand = BucketLifeCycleCommand{
 	command: Command{
-		name:        "lifecycle",
-		nameAlias:   []string{"lifecycle"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketLifeCycle,
-		specEnglish: specEnglishBucketLifeCycle,
-		group:       GroupTypeNormalCommand,
+		name:      "lifecycle",
+		nameAlias: []string{"lifecycle"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -171,6 +170,11 @@ var bucketLifeCycleCommand = BucketLifeCycleCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -192,8 +196,8 @@ func (blc *BucketLifeCycleCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (blc *BucketLifeCycleCommand) Init(args []string, options OptionMapType) error {
-	return blc.command.Init(args, options, blc)
+func (blc *BucketLifeCycleCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return blc.command.Init(ctx, args, options, blc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -265,15 +269,6 @@ func (blc *BucketLifeCycleCommand) PutBucketLifecycle() error {
 	return client.SetBucketLifecycleXml(blc.blOption.bucketName, string(xmlBody), options...)
 }
 
-func (blc *BucketLifeCycleCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket lifecycle: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (blc *BucketLifeCycleCommand) GetBucketLifecycle() error {
 	client, err := blc.command.ossClient(blc.blOption.bucketName)
 	if err != nil {
@@ -290,7 +285,7 @@ func (blc *BucketLifeCycleCommand) GetBucketLifecycle() error {
 		fileName := blc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := blc.confirm(fileName)
+			bConitnue := blc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
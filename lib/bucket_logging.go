@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"os"
@@ -106,13 +107,11 @@ type BucketLogCommand struct {
 
 var bucketLogCommand = BucketLogCommand{
 	command: Command{
-		name:        "logging",
-		nameAlias:   []string{"logging"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketLog,
-		specEnglish: specEnglishBucketLog,
-		group:       GroupTypeNormalCommand,
+		name:      "logging",
+		nameAlias: []string{"logging"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -132,6 +131,11 @@ var bucketLogCommand = BucketLogCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -153,8 +157,8 @@ func (blc *BucketLogCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (blc *BucketLogCommand) Init(args []string, options OptionMapType) error {
-	return blc.command.Init(args, options, blc)
+func (blc *BucketLogCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return blc.command.Init(ctx, args, options, blc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -208,15 +212,6 @@ func (blc *BucketLogCommand) PutBucketLog() error {
 	return client.SetBucketLogging(blc.blOption.srcBucketName, blc.blOption.destBucketName, blc.blOption.destPrefix, true)
 }
 
-func (blc *BucketLogCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket log: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (blc *BucketLogCommand) GetBucketLog() error {
 	client, err := blc.command.ossClient(blc.blOption.srcBucketName)
 	if err != nil {
@@ -238,7 +233,7 @@ func (blc *BucketLogCommand) GetBucketLog() error {
 		fileName := blc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := blc.confirm(fileName)
+			bConitnue := blc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
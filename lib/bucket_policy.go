@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -143,13 +144,11 @@ type BucketPolicyCommand struct {
 
 var bucketPolicyCommand = BucketPolicyCommand{
 	command: Command{
-		name:        "bucket-policy",
-		nameAlias:   []string{"bucket-policy"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketPolicy,
-		specEnglish: specEnglishBucketPolicy,
-		group:       GroupTypeNormalCommand,
+		name:      "bucket-policy",
+		nameAlias: []string{"bucket-policy"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -169,6 +168,11 @@ var bucketPolicyCommand = BucketPolicyCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -190,8 +194,8 @@ func (bpc *BucketPolicyCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bpc *BucketPolicyCommand) Init(args []string, options OptionMapType) error {
-	return bpc.command.Init(args, options, bpc)
+func (bpc *BucketPolicyCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bpc.command.Init(ctx, args, options, bpc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -262,15 +266,6 @@ func (bpc *BucketPolicyCommand) PutBucketPolicy() error {
 	return client.SetBucketPolicy(bpc.bpOption.bucketName, string(text))
 }
 
-func (bpc *BucketPolicyCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket policy: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (bpc *BucketPolicyCommand) GetBucketPolicy() error {
 	client, err := bpc.command.ossClient(bpc.bpOption.bucketName)
 	if err != nil {
@@ -287,7 +282,7 @@ func (bpc *BucketPolicyCommand) GetBucketPolicy() error {
 		fileName := bpc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bpc.confirm(fileName)
+			bConitnue := bpc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
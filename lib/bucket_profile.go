@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	configparser "github.com/alyu/configparser"
+)
+
+// the items of the bucket profile besides the options
+const (
+	ProfileAccelerate   string = "accelerate"
+	ProfileStorageClass        = "storageClass"
+)
+
+// bucketProfileOptions are the options can be set by the bucket profiles
+var bucketProfileOptions = []string{
+	OptionRequestPayer,
+	OptionOutput,
+	OptionMeta,
+	OptionACL,
+	OptionTagging,
+}
+
+// profileWriteItems are the items only applied from the profile of the destination bucket, so
+// that the profile of the source bucket doesn't change the objects written to other places
+var profileWriteItems = []string{
+	OptionMeta,
+	OptionACL,
+	OptionTagging,
+	ProfileStorageClass,
+}
+
+// profileCredentialItems are the credentials of the bucket, the clients of the bucket use them
+// instead of the credentials of the command, so that the buckets of different accounts can be
+// accessed by one command, e.g. cp --fanout
+var profileCredentialItems = []string{
+	OptionAccessKeyID,
+	OptionAccessKeySecret,
+	OptionSTSToken,
+}
+
+// readBucketProfiles reads the sections [Bucket-Profile:bucket] of the config file, the items are
+// keyed by the option names
+func readBucketProfiles(config *configparser.Configuration) (map[string]map[string]string, error) {
+	profiles := map[string]map[string]string{}
+	sections, err := config.AllSections()
+	if err != nil {
+		return profiles, nil
+	}
+	for _, section := range sections {
+		if !strings.HasPrefix(section.Name(), BucketProfileSection) {
+			continue
+		}
+		bucket := strings.TrimSpace(section.Name()[len(BucketProfileSection):])
+		if bucket == "" {
+			return nil, fmt.Errorf("the bucket of section [%s] is empty", section.Name())
+		}
+		profile := map[string]string{}
+		for key, value := range section.Options() {
+			key = strings.TrimSpace(key)
+			if key == "" || strings.HasPrefix(key, "#") || strings.HasPrefix(key, ";") {
+				continue
+			}
+			name, err := profileItemName(key)
+			if err != nil {
+				return nil, fmt.Errorf("%s in section [%s]", err.Error(), section.Name())
+			}
+			value = strings.TrimSpace(value)
+			if name == ProfileAccelerate {
+				if _, err := strconv.ParseBool(value); err != nil {
+					return nil, fmt.Errorf("invalid %s: %s in section [%s], the value should be true or false", key, value, section.Name())
+				}
+			}
+			profile[name] = value
+		}
+		if (profile[OptionAccessKeyID] == "") != (profile[OptionAccessKeySecret] == "") {
+			return nil, fmt.Errorf("%s and %s must be set together in section [%s]", OptionAccessKeyID, OptionAccessKeySecret, section.Name())
+		}
+		profiles[bucket] = profile
+	}
+	return profiles, nil
+}
+
+// profileItemName returns the canonical name of the item, the items are case insensitive
+func profileItemName(key string) (string, error) {
+	names := append([]string{ProfileAccelerate, ProfileStorageClass}, bucketProfileOptions...)
+	for _, name := range append(names, profileCredentialItems...) {
+		if strings.EqualFold(key, name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported bucket profile item: %s", key)
+}
+
+// bucketProfile returns the profile of the bucket in the config file, nil if there is none
+func (cmd *Command) bucketProfile(bucket string) map[string]string {
+	if profiles, ok := cmd.configOptions[BucketProfileSection].(map[string]map[string]string); ok {
+		return profiles[bucket]
+	}
+	return nil
+}
+
+// profileAccelerate returns whether the requests to the bucket are sent to the accelerate endpoint
+func (cmd *Command) profileAccelerate(bucket string) bool {
+	accelerate, _ := strconv.ParseBool(cmd.bucketProfile(bucket)[ProfileAccelerate])
+	return accelerate
+}
+
+// profileCredentials returns the command with the credentials of the profile of the bucket, or
+// cmd itself if the profile has no credentials
+func (cmd *Command) profileCredentials(bucket string) *Command {
+	profile := cmd.bucketProfile(bucket)
+	if profile[OptionAccessKeyID] == "" {
+		return cmd
+	}
+	options := OptionMapType{}
+	for name, value := range cmd.options {
+		options[name] = value
+	}
+	mode := "AK"
+	if profile[OptionSTSToken] != "" {
+		mode = "StsToken"
+	}
+	for _, name := range append(profileCredentialItems, OptionMode) {
+		value := profile[name]
+		if name == OptionMode {
+			value = mode
+		}
+		options[name] = &value
+	}
+	profileCmd := *cmd
+	profileCmd.options = options
+	profileCmd.inputKeySecret = ""
+	return &profileCmd
+}
+
+// dropProfileCredentials removes the credentials from the profiles, so that the credentials of the
+// command line take precedence
+func (cmd *Command) dropProfileCredentials() {
+	profiles, ok := cmd.configOptions[BucketProfileSection].(map[string]map[string]string)
+	if !ok {
+		return
+	}
+	for _, profile := range profiles {
+		for _, name := range profileCredentialItems {
+			delete(profile, name)
+		}
+	}
+}
+
+// applyBucketProfiles sets the options of the profiles of the buckets in the arguments, the options
+// specified by the command line take precedence. The first bucket wins if the buckets have
+// different profiles, and the items writing objects only come from the destination bucket, i.e.,
+// the bucket of the last argument
+func (cmd *Command) applyBucketProfiles() {
+	cmd.commandLineMeta, _ = GetString(OptionMeta, cmd.options)
+	if _, ok := cmd.configOptions[BucketProfileSection]; !ok {
+		return
+	}
+
+	encodingType, _ := GetString(OptionEncodingType, cmd.options)
+	destBucket := ""
+	buckets := []string{}
+	for i, arg := range cmd.args {
+		cloudURL, err := CloudURLFromString(arg, encodingType)
+		if err != nil || cloudURL.bucket == "" {
+			continue
+		}
+		buckets = append(buckets, cloudURL.bucket)
+		if i > 0 && i == len(cmd.args)-1 {
+			destBucket = cloudURL.bucket
+		}
+	}
+
+	for _, bucket := range buckets {
+		profile := cmd.bucketProfile(bucket)
+		// meta is merged before storageClass, so that the storage class of meta takes precedence
+		for _, name := range append(bucketProfileOptions, ProfileStorageClass) {
+			value, ok := profile[name]
+			if !ok || FindPos(name, profileWriteItems) != -1 && bucket != destBucket {
+				continue
+			}
+			switch name {
+			case ProfileStorageClass:
+				if FindPos(OptionMeta, cmd.validOptionNames) != -1 {
+					cmd.mergeProfileMeta(oss.HTTPHeaderOssStorageClass + ":" + value)
+				}
+			case OptionMeta:
+				if FindPos(OptionMeta, cmd.validOptionNames) != -1 {
+					cmd.mergeProfileMeta(value)
+				}
+			default:
+				if FindPos(name, cmd.validOptionNames) == -1 {
+					continue
+				}
+				if val, _ := GetString(name, cmd.options); val == "" {
+					opval := value
+					cmd.options[name] = &opval
+				}
+			}
+		}
+	}
+}
+
+// mergeProfileMeta adds the headers of the profile to --meta, the headers of --meta take precedence
+func (cmd *Command) mergeProfileMeta(meta string) {
+	val, _ := GetString(OptionMeta, cmd.options)
+	names := map[string]bool{}
+	if val != "" {
+		for _, header := range strings.Split(val, "#") {
+			names[strings.ToLower(strings.TrimSpace(strings.SplitN(header, ":", 2)[0]))] = true
+		}
+	}
+	for _, header := range strings.Split(meta, "#") {
+		name := strings.ToLower(strings.TrimSpace(strings.SplitN(header, ":", 2)[0]))
+		if name == "" || names[name] {
+			continue
+		}
+		names[name] = true
+		if val != "" {
+			val += "#"
+		}
+		val += header
+	}
+	cmd.options[OptionMeta] = &val
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -132,13 +133,11 @@ type BucketQosCommand struct {
 
 var bucketQosCommand = BucketQosCommand{
 	command: Command{
-		name:        "bucket-qos",
-		nameAlias:   []string{"bucket-qos"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketQos,
-		specEnglish: specEnglishBucketQos,
-		group:       GroupTypeNormalCommand,
+		name:      "bucket-qos",
+		nameAlias: []string{"bucket-qos"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -158,6 +157,11 @@ var bucketQosCommand = BucketQosCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -179,8 +183,8 @@ func (bqc *BucketQosCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bqc *BucketQosCommand) Init(args []string, options OptionMapType) error {
-	return bqc.command.Init(args, options, bqc)
+func (bqc *BucketQosCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bqc.command.Init(ctx, args, options, bqc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -257,15 +261,6 @@ func (bqc *BucketQosCommand) PutBucketQos() error {
 	return client.SetBucketQoSInfo(bqc.bqOption.bucketName, qosConfig)
 }
 
-func (bqc *BucketQosCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket qos: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (bqc *BucketQosCommand) GetBucketQos() error {
 	client, err := bqc.command.ossClient(bqc.bqOption.bucketName)
 	if err != nil {
@@ -287,7 +282,7 @@ func (bqc *BucketQosCommand) GetBucketQos() error {
 		fileName := bqc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bqc.confirm(fileName)
+			bConitnue := bqc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
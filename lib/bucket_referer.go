@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"os"
@@ -111,13 +112,11 @@ type BucketRefererCommand struct {
 
 var bucketRefererCommand = BucketRefererCommand{
 	command: Command{
-		name:        "referer",
-		nameAlias:   []string{"referer"},
-		minArgc:     1,
-		maxArgc:     MaxInt,
-		specChinese: specChineseBucketReferer,
-		specEnglish: specEnglishBucketReferer,
-		group:       GroupTypeNormalCommand,
+		name:      "referer",
+		nameAlias: []string{"referer"},
+		minArgc:   1,
+		maxArgc:   MaxInt,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -138,6 +137,11 @@ var bucketRefererCommand = BucketRefererCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -159,8 +163,8 @@ func (brc *BucketRefererCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (brc *BucketRefererCommand) Init(args []string, options OptionMapType) error {
-	return brc.command.Init(args, options, brc)
+func (brc *BucketRefererCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return brc.command.Init(ctx, args, options, brc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -209,15 +213,6 @@ func (brc *BucketRefererCommand) PutBucketRefer() error {
 	return client.SetBucketReferer(brc.brOption.bucketName, referers, !brc.brOption.disableEmptyRefer)
 }
 
-func (brc *BucketRefererCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket referer: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (brc *BucketRefererCommand) GetBucketRefer() error {
 	client, err := brc.command.ossClient(brc.brOption.bucketName)
 	if err != nil {
@@ -239,7 +234,7 @@ func (brc *BucketRefererCommand) GetBucketRefer() error {
 		fileName := brc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := brc.confirm(fileName)
+			bConitnue := brc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -1,10 +1,14 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 )
 
 var specChineseReplication = SpecText{
@@ -19,14 +23,15 @@ var specChineseReplication = SpecText{
     ossutil replication --method get --item location oss://bucket [options]
     ossutil replication --method get --item progress oss://bucket [ruleID] [options]
     ossutil replication --method put --item rtc oss://bucket local_xml_file [options]
+    ossutil replication --method verify oss://src_bucket[/prefix] oss://dest_bucket[/prefix] [options]
 `,
 	detailHelpText: `
     replication命令通过设置method选项值为put、get、delete,可以设置、查询或者删除bucket的跨区域复制规则;
     此外,当method选项为get时,可通过设置item选项选项值为location、progress,可以查询可复制到的目标bucket
-    所在的地域或者bucket的跨区域复制进度信息
+    所在的地域或者bucket的跨区域复制进度信息;当method选项为verify时,可以校验源bucket和目标bucket的数据一致性
 
 用法:
-    该命令有六种用法:
+    该命令有七种用法:
 
     1) ossutil replication --method put oss://bucket local_xml_file [options]
         这个命令从配置文件local_xml_file中读取跨区域复制的配置,然后设置bucket的跨区域复制规则,
@@ -72,6 +77,12 @@ var specChineseReplication = SpecText{
             </RTC>
             <ID>rule id</ID>
         </ReplicationRule>
+
+    7) ossutil replication --method verify oss://src_bucket[/prefix] oss://dest_bucket[/prefix] [options]
+        这个命令列举源bucket和目标bucket中的object,逐个比较object名称、ETag和大小,输出尚未复制到目标bucket
+        以及内容不一致的object,并按源object的最后修改时间统计复制延迟。如果存在未复制或不一致的object,命令返回错误
+        源object名称去掉源前缀后与目标object名称去掉目标前缀后进行比较,未指定目标前缀时使用源前缀
+        该命令也可以写成ossutil replication verify oss://src_bucket oss://dest_bucket
 `,
 
 	sampleText: `
@@ -95,6 +106,9 @@ var specChineseReplication = SpecText{
 
     7) 为已有bucket的跨区域复制规则开启或关闭数据复制时间控制
        ossutil replication --method put --item rtc oss://bucket local_xml_file
+
+    8) 校验源bucket中前缀为abc的object是否已经复制到目标bucket
+       ossutil replication --method verify oss://src-bucket/abc oss://dest-bucket
 `,
 }
 
@@ -110,16 +124,18 @@ var specEnglishreplication = SpecText{
     ossutil replication --method get --item location oss://bucket [options]
     ossutil replication --method get --item progress oss://bucket [ruleID] [options]
     ossutil replication --method put --item rtc oss://bucket local_xml_file [options]
+    ossutil replication --method verify oss://src_bucket[/prefix] oss://dest_bucket[/prefix] [options]
 `,
 	detailHelpText: ` 
     replication command can set, get and delete cross region replication rules of 
     the oss bucket by setting method option value to put, get and delete; in addition, 
     when the method option is get, you can get the region where the target bucket can 
     be copied to or the cross region replication progress of the bucket by setting item 
-    option value to location and progress
+    option value to location and progress; when the method option is verify, you can
+    check the consistency between the source bucket and the destination bucket
 
 Usage:
-    There are six usages for this command:
+    There are seven usages for this command:
 	
     1) ossutil replication --method put oss://bucket local_xml_file [options]
         The command sets the cross region replication rules of bucket from local file local_xml_file
@@ -171,6 +187,15 @@ Usage:
             </RTC>
             <ID>rule id</ID>
         </ReplicationRule>
+
+    7) ossutil replication --method verify oss://src_bucket[/prefix] oss://dest_bucket[/prefix] [options]
+        The command lists objects of both the source bucket and the destination bucket, compares
+        the object names, ETags and sizes, reports objects which are not replicated yet or divergent,
+        and summarizes the replication lag by the last modified time of the source objects.
+        If any object is not replicated or divergent, the command returns an error
+        The object names are compared after removing the source prefix and the destination prefix,
+        if the destination prefix is not specified, the source prefix is used
+        The command can also be written as ossutil replication verify oss://src_bucket oss://dest_bucket
 `,
 	sampleText: ` 
     1) put bucket cross region replication rules
@@ -193,23 +218,39 @@ Usage:
 
     7) enable or disable data replication time control for the existing cross region replication rule
        ossutil replication --method get --item rtc oss://bucket local_xml_file
+
+    8) verify whether the objects with prefix abc in source bucket have been replicated to destination bucket
+       ossutil replication --method verify oss://src-bucket/abc oss://dest-bucket
 `,
 }
 
+type replicationVerifyResult struct {
+	srcNum        int64
+	replicatedNum int64
+	missingNum    int64
+	divergentNum  int64
+	extraNum      int64
+	lagCount      [len(replicationLagLevels) + 1]int64
+	maxLag        time.Duration
+}
+
+// the upper bounds of replication lag statistic, the last level is unbounded
+var replicationLagLevels = [...]time.Duration{time.Minute, time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+var replicationLagNames = [...]string{"< 1m", "1m ~ 1h", "1h ~ 1d", "1d ~ 7d", ">= 7d"}
+
 type ReplicationCommand struct {
-	command    Command
-	bucketName string
+	command      Command
+	bucketName   string
+	verifyResult replicationVerifyResult
 }
 
 var replicationCommand = ReplicationCommand{
 	command: Command{
-		name:        "replication",
-		nameAlias:   []string{"replication"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseReplication,
-		specEnglish: specEnglishreplication,
-		group:       GroupTypeNormalCommand,
+		name:      "replication",
+		nameAlias: []string{"replication"},
+		minArgc:   1,
+		maxArgc:   3, // the positional form: verify oss://src_bucket oss://dest_bucket
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -227,9 +268,16 @@ var replicationCommand = ReplicationCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionMethod,
 			OptionItem,
+			OptionRetryTimes,
+			OptionEncodingType,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
 			OptionSignVersion,
@@ -250,8 +298,8 @@ func (replicationc *ReplicationCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (replicationc *ReplicationCommand) Init(args []string, options OptionMapType) error {
-	return replicationc.command.Init(args, options, replicationc)
+func (replicationc *ReplicationCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return replicationc.command.Init(ctx, args, options, replicationc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -259,13 +307,27 @@ func (replicationc *ReplicationCommand) RunCommand() error {
 	strMethod, _ := GetString(OptionMethod, replicationc.command.options)
 	strItem, _ := GetString(OptionItem, replicationc.command.options)
 
+	// ossutil replication verify oss://src oss://dst
+	if strMethod == "" && strings.ToLower(replicationc.command.args[0]) == "verify" {
+		strMethod = "verify"
+		replicationc.command.args = replicationc.command.args[1:]
+	}
+
 	if strMethod == "" {
 		return fmt.Errorf("--method value is empty")
 	}
 
 	strMethod = strings.ToLower(strMethod)
-	if strMethod != "put" && strMethod != "get" && strMethod != "delete" {
-		return fmt.Errorf("--method value is not in the optional value:put|get|delete")
+	if strMethod != "put" && strMethod != "get" && strMethod != "delete" && strMethod != "verify" {
+		return fmt.Errorf("--method value is not in the optional value:put|get|delete|verify")
+	}
+
+	if len(replicationc.command.args) > 2 {
+		return fmt.Errorf("the number of arguments is more than 2 for method %s", strMethod)
+	}
+
+	if strMethod == "verify" {
+		return replicationc.VerifyBucketReplication()
 	}
 
 	strItem = strings.ToLower(strItem)
@@ -432,3 +494,112 @@ func (replicationc *ReplicationCommand) PutBucketRTC() error {
 
 	return client.PutBucketRTCXml(replicationc.bucketName, string(text))
 }
+
+func (replicationc *ReplicationCommand) VerifyBucketReplication() error {
+	if len(replicationc.command.args) < 2 {
+		return fmt.Errorf("verify bucket replication need 2 parameters,the destination bucket is empty")
+	}
+
+	encodingType, _ := GetString(OptionEncodingType, replicationc.command.options)
+	srcURL, err := GetCloudUrl(replicationc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	destURL, err := GetCloudUrl(replicationc.command.args[1], encodingType)
+	if err != nil {
+		return err
+	}
+
+	srcBucket, err := replicationc.command.cloudBucket(*srcURL)
+	if err != nil {
+		return err
+	}
+	destBucket, err := replicationc.command.cloudBucket(*destURL)
+	if err != nil {
+		return err
+	}
+
+	// the objects are matched by the names relative to the source and the destination prefix,
+	// cross region replication keeps the object name, so the destination uses the source prefix by default
+	srcPrefix := srcURL.object
+	destPrefix := destURL.object
+	if destPrefix == "" {
+		destPrefix = srcPrefix
+	}
+	done := make(chan struct{})
+	defer close(done)
+	srcListing := replicationc.command.listObjectsInOrder(srcBucket, srcPrefix, nil, done)
+	destListing := replicationc.command.listObjectsInOrder(destBucket, destPrefix, nil, done)
+
+	replicationc.verifyResult = replicationVerifyResult{}
+	now := time.Now()
+	onlySrc := func(src oss.ObjectProperties) {
+		replicationc.verifyResult.srcNum++
+		replicationc.verifyResult.missingNum++
+		lag := now.Sub(src.LastModified)
+		replicationc.addReplicationLag(lag)
+		fmt.Printf("not replicated: %s, size: %d, last modified: %s, lag: %s\n",
+			srcPrefix+src.Key, src.Size, src.LastModified.Local().Format("2006-01-02 15:04:05"), lag.Truncate(time.Second))
+	}
+	onlyDest := func(dest oss.ObjectProperties) {
+		replicationc.verifyResult.extraNum++
+	}
+	err = joinListings(srcListing, destListing, onlySrc, onlyDest, func(src, dest oss.ObjectProperties) {
+		replicationc.verifyResult.srcNum++
+		if src.ETag == dest.ETag && src.Size == dest.Size {
+			replicationc.verifyResult.replicatedNum++
+			return
+		}
+		replicationc.verifyResult.divergentNum++
+		lag := src.LastModified.Sub(dest.LastModified)
+		if lag < 0 {
+			lag = 0
+		}
+		replicationc.addReplicationLag(lag)
+		fmt.Printf("divergent: %s, source etag: %s, size: %d, destination etag: %s, size: %d\n",
+			srcPrefix+src.Key, src.ETag, src.Size, dest.ETag, dest.Size)
+	})
+	if err != nil {
+		return err
+	}
+
+	replicationc.printVerifyResult()
+
+	result := replicationc.verifyResult
+	if result.missingNum > 0 || result.divergentNum > 0 {
+		return fmt.Errorf("replication is not consistent, %d objects not replicated, %d objects divergent",
+			result.missingNum, result.divergentNum)
+	}
+	return nil
+}
+
+func (replicationc *ReplicationCommand) addReplicationLag(lag time.Duration) {
+	result := &replicationc.verifyResult
+	level := len(replicationLagLevels)
+	for i, bound := range replicationLagLevels {
+		if lag < bound {
+			level = i
+			break
+		}
+	}
+	result.lagCount[level]++
+	if lag > result.maxLag {
+		result.maxLag = lag
+	}
+}
+
+func (replicationc *ReplicationCommand) printVerifyResult() {
+	result := replicationc.verifyResult
+	fmt.Printf("\nsource objects: %d, replicated: %d, not replicated: %d, divergent: %d, only in destination: %d\n",
+		result.srcNum, result.replicatedNum, result.missingNum, result.divergentNum, result.extraNum)
+
+	if result.missingNum+result.divergentNum == 0 {
+		return
+	}
+
+	fmt.Printf("replication lag of not replicated or divergent objects:\n")
+	for i, name := range replicationLagNames {
+		fmt.Printf("%10s: %d\n", name, result.lagCount[i])
+	}
+	fmt.Printf("max lag: %s\n", result.maxLag.Truncate(time.Second))
+}
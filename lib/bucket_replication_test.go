@@ -1332,3 +1332,113 @@ func (s *OssutilCommandSuite) TestBucketReplicationPutError(c *C) {
 	c.Assert(err, IsNil)
 
 }
+
+func (s *OssutilCommandSuite) TestBucketReplicationVerify(c *C) {
+	srcBucketName := bucketNamePrefix + randLowStr(12)
+	s.putBucket(srcBucketName, c)
+	destBucketName := bucketNamePrefix + randLowStr(12)
+	s.putBucket(destBucketName, c)
+
+	fileName := "ossutil-test-file-" + randLowStr(5)
+	s.createFile(fileName, randLowStr(1024), c)
+	divergentFileName := "ossutil-test-file-" + randLowStr(5)
+	s.createFile(divergentFileName, randLowStr(2048), c)
+
+	// same, divergent, not replicated, only in destination
+	s.putObject(srcBucketName, "verify/same", fileName, c)
+	s.putObject(destBucketName, "verify/same", fileName, c)
+	s.putObject(srcBucketName, "verify/divergent", fileName, c)
+	s.putObject(destBucketName, "verify/divergent", divergentFileName, c)
+	s.putObject(srcBucketName, "verify/missing", fileName, c)
+	s.putObject(destBucketName, "verify/extra", fileName, c)
+
+	str := ""
+	strMethod := "verify"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"method":          &strMethod,
+	}
+	args := []string{CloudURLToString(srcBucketName, "verify/"), CloudURLToString(destBucketName, "")}
+	_, err := cm.RunCommand("replication", args, options)
+	c.Assert(err, NotNil)
+	result := replicationCommand.verifyResult
+	c.Assert(result.srcNum, Equals, int64(3))
+	c.Assert(result.replicatedNum, Equals, int64(1))
+	c.Assert(result.missingNum, Equals, int64(1))
+	c.Assert(result.divergentNum, Equals, int64(1))
+	c.Assert(result.extraNum, Equals, int64(1))
+
+	// positional verify, only the consistent object
+	strMethod = ""
+	args = []string{"verify", CloudURLToString(srcBucketName, "verify/same"), CloudURLToString(destBucketName, "")}
+	_, err = cm.RunCommand("replication", args, options)
+	c.Assert(err, IsNil)
+	c.Assert(replicationCommand.verifyResult.replicatedNum, Equals, int64(1))
+
+	// the destination prefix is different from the source prefix
+	s.putObject(destBucketName, "copy/same", fileName, c)
+	strMethod = "verify"
+	args = []string{CloudURLToString(srcBucketName, "verify/"), CloudURLToString(destBucketName, "copy/")}
+	_, err = cm.RunCommand("replication", args, options)
+	c.Assert(err, NotNil)
+	result = replicationCommand.verifyResult
+	c.Assert(result.replicatedNum, Equals, int64(1))
+	c.Assert(result.missingNum, Equals, int64(2))
+	c.Assert(result.extraNum, Equals, int64(0))
+
+	// destination bucket is empty
+	args = []string{CloudURLToString(srcBucketName, "")}
+	_, err = cm.RunCommand("replication", args, options)
+	c.Assert(err, NotNil)
+
+	os.Remove(fileName)
+	os.Remove(divergentFileName)
+	s.removeBucket(srcBucketName, true, c)
+	s.removeBucket(destBucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestBucketReplicationLagLevel(c *C) {
+	replicationc := ReplicationCommand{}
+	replicationc.addReplicationLag(30 * time.Second)
+	replicationc.addReplicationLag(2 * time.Hour)
+	replicationc.addReplicationLag(30 * 24 * time.Hour)
+	c.Assert(replicationc.verifyResult.lagCount[0], Equals, int64(1))
+	c.Assert(replicationc.verifyResult.lagCount[2], Equals, int64(1))
+	c.Assert(replicationc.verifyResult.lagCount[4], Equals, int64(1))
+	c.Assert(replicationc.verifyResult.maxLag, Equals, 30*24*time.Hour)
+}
+
+func (s *OssutilCommandSuite) TestBucketReplicationArgsCount(c *C) {
+	str := ""
+	strMethod := ""
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"method":          &strMethod,
+	}
+
+	// only the positional verify takes 3 arguments
+	for _, method := range []string{"put", "get", "delete", "verify"} {
+		strMethod = method
+		args := []string{"oss://bucket", "rule-id", "extra"}
+		_, err := cm.RunCommand("replication", args, options)
+		c.Assert(err, NotNil)
+		c.Assert(strings.Contains(err.Error(), "more than 2"), Equals, true)
+	}
+
+	strMethod = ""
+	args := []string{"verify", "oss://src-bucket", "oss://dest-bucket", "extra"}
+	_, err := cm.RunCommand("replication", args, options)
+	c.Assert(err, NotNil)
+
+	// the positional verify passes the check of the arguments count
+	args = []string{"verify", "oss://src-bucket"}
+	_, err = cm.RunCommand("replication", args, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "destination bucket is empty"), Equals, true)
+}
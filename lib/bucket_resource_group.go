@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -102,13 +103,11 @@ type BucketResourceGroupCommand struct {
 
 var bucketResourceGroupCommand = BucketResourceGroupCommand{
 	command: Command{
-		name:        "resource-group",
-		nameAlias:   []string{"resource-group"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketResourceGroup,
-		specEnglish: specEnglishBucketResourceGroup,
-		group:       GroupTypeNormalCommand,
+		name:      "resource-group",
+		nameAlias: []string{"resource-group"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -128,6 +127,11 @@ var bucketResourceGroupCommand = BucketResourceGroupCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -149,8 +153,8 @@ func (brgc *BucketResourceGroupCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (brgc *BucketResourceGroupCommand) Init(args []string, options OptionMapType) error {
-	return brgc.command.Init(args, options, brgc)
+func (brgc *BucketResourceGroupCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return brgc.command.Init(ctx, args, options, brgc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -219,15 +223,6 @@ func (brgc *BucketResourceGroupCommand) PutBucketResourceGroup() error {
 	return client.PutBucketResourceGroupXml(brgc.blOption.bucketName, string(xmlBody), options...)
 }
 
-func (brgc *BucketResourceGroupCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket resource group: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (brgc *BucketResourceGroupCommand) GetBucketResourceGroup() error {
 	client, err := brgc.command.ossClient(brgc.blOption.bucketName)
 	if err != nil {
@@ -244,7 +239,7 @@ func (brgc *BucketResourceGroupCommand) GetBucketResourceGroup() error {
 		fileName := brgc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bContinue := brgc.confirm(fileName)
+			bContinue := brgc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bContinue {
 				return nil
 			}
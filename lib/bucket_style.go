@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -128,13 +129,11 @@ type BucketStyleCommand struct {
 
 var bucketStyleCommand = BucketStyleCommand{
 	command: Command{
-		name:        "style",
-		nameAlias:   []string{"style"},
-		minArgc:     1,
-		maxArgc:     3,
-		specChinese: specChineseBucketStyle,
-		specEnglish: specEnglishBucketStyle,
-		group:       GroupTypeNormalCommand,
+		name:      "style",
+		nameAlias: []string{"style"},
+		minArgc:   1,
+		maxArgc:   3,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -154,6 +153,11 @@ var bucketStyleCommand = BucketStyleCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -175,8 +179,8 @@ func (bsc *BucketStyleCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bsc *BucketStyleCommand) Init(args []string, options OptionMapType) error {
-	return bsc.command.Init(args, options, bsc)
+func (bsc *BucketStyleCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bsc.command.Init(ctx, args, options, bsc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -251,15 +255,6 @@ func (bsc *BucketStyleCommand) PutBucketStyle() error {
 	return client.PutBucketStyleXml(bsc.bwOption.bucketName, styleName, string(text))
 }
 
-func (bsc *BucketStyleCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket style: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (bsc *BucketStyleCommand) GetBucketStyle() error {
 	if len(bsc.command.args) < 2 {
 		return fmt.Errorf("get bucket style need at least 2 parameters,the parameter style name is empty")
@@ -282,7 +277,7 @@ func (bsc *BucketStyleCommand) GetBucketStyle() error {
 		fileName := bsc.command.args[2]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bsc.confirm(fileName)
+			bConitnue := bsc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -343,7 +338,7 @@ func (bsc *BucketStyleCommand) ListBucketStyle() error {
 		fileName := bsc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bsc.confirm(fileName)
+			bConitnue := bsc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -96,13 +97,11 @@ type BucketTagCommand struct {
 
 var bucketTagCommand = BucketTagCommand{
 	command: Command{
-		name:        "bucket-tagging",
-		nameAlias:   []string{"bucket-tagging"},
-		minArgc:     1,
-		maxArgc:     11,
-		specChinese: specChineseBucketTag,
-		specEnglish: specEnglishBucketTag,
-		group:       GroupTypeNormalCommand,
+		name:      "bucket-tagging",
+		nameAlias: []string{"bucket-tagging"},
+		minArgc:   1,
+		maxArgc:   11,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -122,6 +121,11 @@ var bucketTagCommand = BucketTagCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -143,8 +147,8 @@ func (btc *BucketTagCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (btc *BucketTagCommand) Init(args []string, options OptionMapType) error {
-	return btc.command.Init(args, options, btc)
+func (btc *BucketTagCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return btc.command.Init(ctx, args, options, btc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
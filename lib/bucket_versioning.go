@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -88,13 +89,11 @@ type BucketVersioningCommand struct {
 
 var bucketVersioningCommand = BucketVersioningCommand{
 	command: Command{
-		name:        "bucket-versioning",
-		nameAlias:   []string{"bucket-versioning"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketVersioning,
-		specEnglish: specEnglishBucketVersioning,
-		group:       GroupTypeNormalCommand,
+		name:      "bucket-versioning",
+		nameAlias: []string{"bucket-versioning"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -114,6 +113,11 @@ var bucketVersioningCommand = BucketVersioningCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -135,8 +139,8 @@ func (bvc *BucketVersioningCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bvc *BucketVersioningCommand) Init(args []string, options OptionMapType) error {
-	return bvc.command.Init(args, options, bvc)
+func (bvc *BucketVersioningCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bvc.command.Init(ctx, args, options, bvc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
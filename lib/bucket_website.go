@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -219,13 +220,11 @@ type BucketWebSiteCommand struct {
 
 var bucketWebsiteCommand = BucketWebSiteCommand{
 	command: Command{
-		name:        "website",
-		nameAlias:   []string{"website"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseBucketWebSite,
-		specEnglish: specEnglishBucketWebSite,
-		group:       GroupTypeNormalCommand,
+		name:      "website",
+		nameAlias: []string{"website"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -245,6 +244,11 @@ var bucketWebsiteCommand = BucketWebSiteCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -266,8 +270,8 @@ func (bwc *BucketWebSiteCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (bwc *BucketWebSiteCommand) Init(args []string, options OptionMapType) error {
-	return bwc.command.Init(args, options, bwc)
+func (bwc *BucketWebSiteCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return bwc.command.Init(ctx, args, options, bwc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -338,15 +342,6 @@ func (bwc *BucketWebSiteCommand) PutBucketWebsite() error {
 	return client.SetBucketWebsiteXml(bwc.bwOption.bucketName, string(text))
 }
 
-func (bwc *BucketWebSiteCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("bucket website: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (bwc *BucketWebSiteCommand) GetBucketWebsite() error {
 	client, err := bwc.command.ossClient(bwc.bwOption.bucketName)
 	if err != nil {
@@ -363,7 +358,7 @@ func (bwc *BucketWebSiteCommand) GetBucketWebsite() error {
 		fileName := bwc.command.args[1]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := bwc.confirm(fileName)
+			bConitnue := bwc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
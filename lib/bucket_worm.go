@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strconv"
@@ -35,6 +36,7 @@ var specChineseWorm = SpecText{
 	
     3) ossutil worm complete oss://bucket wormId
         这个命令提交worm配置，成功后worm状态将由InProgress变为Locked
+        提交后worm配置无法删除，执行前会进行询问提示，指定--assume-yes(-y)选项则不进行询问提示
 
     4) ossutil worm extend oss://bucket days wormId
         这个命令修改worm配置，将Object的保留天数修改为days
@@ -76,6 +78,8 @@ Usage:
     3) ossutil worm complete oss://bucket wormId
        This command complete the worm configuration. 
        After success, the worm status will change from InProgress to Locked
+       The worm configuration can't be removed after it's completed, so ossutil asks user to
+       confirm the operation, unless --assume-yes(-y) option is specified
 
     4) ossutil worm extend oss://bucket days wormId
        This command modifies the worm configuration and changes the retention period of objects to days
@@ -100,13 +104,11 @@ type WormCommand struct {
 
 var wormCommand = WormCommand{
 	command: Command{
-		name:        "worm",
-		nameAlias:   []string{"worm"},
-		minArgc:     2,
-		maxArgc:     4,
-		specChinese: specChineseWorm,
-		specEnglish: specEnglishWorm,
-		group:       GroupTypeNormalCommand,
+		name:      "worm",
+		nameAlias: []string{"worm"},
+		minArgc:   2,
+		maxArgc:   4,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -125,6 +127,11 @@ var wormCommand = WormCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -132,6 +139,7 @@ var wormCommand = WormCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionAssumeYes,
 		},
 	},
 }
@@ -146,8 +154,8 @@ func (wormc *WormCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (wormc *WormCommand) Init(args []string, options OptionMapType) error {
-	return wormc.command.Init(args, options, wormc)
+func (wormc *WormCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return wormc.command.Init(ctx, args, options, wormc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -232,6 +240,11 @@ func (wormc *WormCommand) CompleteBucketWorm() error {
 		return fmt.Errorf("missing parameter,the wormId is empty")
 	}
 
+	// the worm configuration can't be removed after it's completed
+	if !wormc.command.confirmOperation(fmt.Sprintf("complete the worm configuration %s of bucket %s, it can't be removed after that", wormc.command.args[2], wormc.wmOption.bucketName)) {
+		return nil
+	}
+
 	client, err := wormc.command.ossClient(wormc.wmOption.bucketName)
 	if err != nil {
 		return err
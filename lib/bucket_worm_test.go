@@ -110,12 +110,14 @@ func (s *OssutilCommandSuite) TestWormCompleteBucketWormSuccess(c *C) {
 	s.putBucket(bucketName, c)
 
 	var str string
+	assumeYes := true
 	options := OptionMapType{
 		"endpoint":        &str,
 		"accessKeyID":     &str,
 		"accessKeySecret": &str,
 		"stsToken":        &str,
 		"configFile":      &configFile,
+		"assumeYes":       &assumeYes,
 	}
 
 	// init worm
@@ -173,12 +175,14 @@ func (s *OssutilCommandSuite) TestWormExtentBucketWormSuccess(c *C) {
 	s.putBucket(bucketName, c)
 
 	var str string
+	assumeYes := true
 	options := OptionMapType{
 		"endpoint":        &str,
 		"accessKeyID":     &str,
 		"accessKeySecret": &str,
 		"stsToken":        &str,
 		"configFile":      &configFile,
+		"assumeYes":       &assumeYes,
 	}
 
 	// init worm
@@ -0,0 +1,191 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ExitCodeBudgetExceeded is the exit code of ossutil when --max-duration or --retry-budget is exceeded,
+// so that the scheduler can tell it from other errors and run the command again to continue
+const ExitCodeBudgetExceeded = 3
+
+// ExitCodeInterrupted is the exit code of ossutil when it is interrupted by Ctrl-C or SIGTERM
+const ExitCodeInterrupted = 130
+
+// jobBudget limits the duration and the total retries of the whole command,
+// after the budget is exceeded or the command is interrupted, no more files are
+// started and no more retries are made
+type jobBudget struct {
+	deadline    time.Time
+	retryBudget int64 // -1 means no limit
+	retries     int64
+	remain      int64
+	mu          sync.Mutex
+	reason      string
+	interrupted bool
+	stopErr     error
+}
+
+// newJobBudget returns nil if neither --max-duration nor --retry-budget is specified
+func (cmd *Command) newJobBudget() (*jobBudget, error) {
+	strDuration, _ := GetString(OptionMaxDuration, cmd.options)
+	retryBudget, err := GetInt(OptionRetryBudget, cmd.options)
+	if err != nil {
+		retryBudget = -1
+	}
+	if strDuration == "" && retryBudget < 0 {
+		return nil, nil
+	}
+
+	jb := &jobBudget{retryBudget: retryBudget}
+	if strDuration != "" {
+		duration, err := time.ParseDuration(strDuration)
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid --max-duration: %s, the value should be positive duration, e.g., 90m, 2h", strDuration)
+		}
+		jb.deadline = time.Now().Add(duration)
+	}
+	return jb, nil
+}
+
+func (jb *jobBudget) setExceeded(reason string) {
+	jb.mu.Lock()
+	if jb.reason == "" {
+		jb.reason = reason
+		LogInfo("%s\n", reason)
+	}
+	jb.mu.Unlock()
+}
+
+// exceeded returns true if the deadline is passed or the retries are used up
+func (jb *jobBudget) exceeded() bool {
+	if jb == nil {
+		return false
+	}
+	if !jb.deadline.IsZero() && time.Now().After(jb.deadline) {
+		jb.setExceeded(fmt.Sprintf("--max-duration is exceeded at %s", jb.deadline.Format(time.RFC3339)))
+	}
+	jb.mu.Lock()
+	defer jb.mu.Unlock()
+	return jb.reason != ""
+}
+
+// allowRetry takes one retry from the budget
+func (jb *jobBudget) allowRetry() bool {
+	if jb == nil {
+		return true
+	}
+	if jb.exceeded() {
+		return false
+	}
+	if jb.retryBudget >= 0 && atomic.AddInt64(&jb.retries, 1) > jb.retryBudget {
+		jb.setExceeded(fmt.Sprintf("--retry-budget %d is used up", jb.retryBudget))
+		return false
+	}
+	return true
+}
+
+// interrupt stops the command gracefully
+func (jb *jobBudget) interrupt() {
+	jb.mu.Lock()
+	jb.interrupted = true
+	jb.mu.Unlock()
+	jb.setExceeded("interrupted by signal")
+}
+
+// abort stops the command gracefully with the error
+func (jb *jobBudget) abort(err error) {
+	jb.mu.Lock()
+	if jb.stopErr == nil {
+		jb.stopErr = err
+	}
+	jb.mu.Unlock()
+	jb.setExceeded(err.Error())
+}
+
+// skip records the file not started because of the budget
+func (jb *jobBudget) skip() {
+	atomic.AddInt64(&jb.remain, 1)
+}
+
+func (jb *jobBudget) err() error {
+	if !jb.exceeded() {
+		return nil
+	}
+	jb.mu.Lock()
+	defer jb.mu.Unlock()
+	if jb.stopErr != nil {
+		return jb.stopErr
+	}
+	if jb.interrupted {
+		return InterruptedError{atomic.LoadInt64(&jb.remain)}
+	}
+	return BudgetExceededError{jb.reason, atomic.LoadInt64(&jb.remain)}
+}
+
+// gracefulCommander is implemented by the commands which stop gracefully once their context
+// is done, only these commands watch the interrupt signal, the others exit on the first Ctrl-C
+type gracefulCommander interface {
+	stopsGracefully()
+}
+
+func (cc *CopyCommand) stopsGracefully()   {}
+func (sc *SyncCommand) stopsGracefully()   {}
+func (dc *DaemonCommand) stopsGracefully() {}
+
+// interruptContext returns a context which is canceled on the first Ctrl-C or SIGTERM, so that
+// the command stops at the next request or retry. The second signal exits immediately.
+// The returned function stops watching.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	chSignal := make(chan os.Signal, 2)
+	signal.Notify(chSignal, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-chSignal:
+		case <-done:
+			return
+		}
+		LogInfo("cancel the command by the interrupt signal\n")
+		fmt.Fprintf(os.Stderr, "\nreceived interrupt signal, press Ctrl-C again to exit immediately\n")
+		cancel()
+		select {
+		case <-chSignal:
+			LogInfo("exit immediately by the second interrupt signal\n")
+			os.Exit(ExitCodeInterrupted)
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(chSignal)
+		close(done)
+		cancel()
+	}
+}
+
+// watchInterrupt stops the command gracefully once ctx is done, e.g. on the first Ctrl-C or
+// SIGTERM: the transferring files finish, the checkpoints and the report are flushed, then
+// the command returns. The message is printed to out. The returned function stops watching.
+func watchInterrupt(ctx context.Context, jb *jobBudget, out io.Writer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+			return
+		}
+		jb.interrupt()
+		fmt.Fprintf(out, "\nthe command is canceled, waiting for the transferring files to finish\n")
+	}()
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestJobBudget(c *C) {
+	var disabled *jobBudget
+	c.Assert(disabled.exceeded(), Equals, false)
+	c.Assert(disabled.allowRetry(), Equals, true)
+	c.Assert(disabled.err(), IsNil)
+
+	cmd := Command{options: OptionMapType{}}
+	jb, err := cmd.newJobBudget()
+	c.Assert(err, IsNil)
+	c.Assert(jb == nil, Equals, true)
+
+	invalid := "forever"
+	cmd = Command{options: OptionMapType{OptionMaxDuration: &invalid}}
+	_, err = cmd.newJobBudget()
+	c.Assert(err, NotNil)
+
+	// retry budget
+	retryBudget := "2"
+	cmd = Command{options: OptionMapType{OptionRetryBudget: &retryBudget}}
+	jb, err = cmd.newJobBudget()
+	c.Assert(err, IsNil)
+	c.Assert(jb.allowRetry(), Equals, true)
+	c.Assert(jb.allowRetry(), Equals, true)
+	c.Assert(jb.exceeded(), Equals, false)
+	c.Assert(jb.allowRetry(), Equals, false)
+	c.Assert(jb.exceeded(), Equals, true)
+	jb.skip()
+	jb.skip()
+	berr, ok := jb.err().(BudgetExceededError)
+	c.Assert(ok, Equals, true)
+	c.Assert(berr.remain, Equals, int64(2))
+
+	// deadline
+	maxDuration := "1s"
+	cmd = Command{options: OptionMapType{OptionMaxDuration: &maxDuration}}
+	jb, err = cmd.newJobBudget()
+	c.Assert(err, IsNil)
+	c.Assert(jb.exceeded(), Equals, false)
+	c.Assert(jb.allowRetry(), Equals, true)
+	jb.deadline = time.Now().Add(-time.Second)
+	c.Assert(jb.exceeded(), Equals, true)
+	c.Assert(jb.allowRetry(), Equals, false)
+	c.Assert(jb.err(), NotNil)
+}
+
+func (s *OssutilCommandSuite) TestJobBudgetInterrupt(c *C) {
+	jb := &jobBudget{retryBudget: -1}
+	c.Assert(jb.err(), IsNil)
+	jb.interrupt()
+	c.Assert(jb.exceeded(), Equals, true)
+	c.Assert(jb.allowRetry(), Equals, false)
+	jb.skip()
+	ierr, ok := jb.err().(InterruptedError)
+	c.Assert(ok, Equals, true)
+	c.Assert(ierr.remain, Equals, int64(1))
+
+	// the signal is caught and the command is stopped gracefully
+	jb = &jobBudget{retryBudget: -1}
+	ctx, stopCtx := interruptContext()
+	stop := watchInterrupt(ctx, jb, ioutil.Discard)
+	p, err := os.FindProcess(os.Getpid())
+	c.Assert(err, IsNil)
+	if err = p.Signal(os.Interrupt); err == nil {
+		for i := 0; i < 100 && !jb.exceeded(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		c.Assert(jb.exceeded(), Equals, true)
+		_, ok = jb.err().(InterruptedError)
+		c.Assert(ok, Equals, true)
+	}
+	stop()
+	stopCtx()
+
+	// the command is stopped gracefully by the canceled context
+	jb = &jobBudget{retryBudget: -1}
+	ctx, cancel := context.WithCancel(context.Background())
+	stop = watchInterrupt(ctx, jb, ioutil.Discard)
+	cancel()
+	for i := 0; i < 100 && !jb.exceeded(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	_, ok = jb.err().(InterruptedError)
+	c.Assert(ok, Equals, true)
+	stop()
+
+	// only the commands which stop gracefully catch the signal
+	c.Assert(stopsGracefully([]string{"cp"}), Equals, true)
+	c.Assert(stopsGracefully([]string{"sync"}), Equals, true)
+	c.Assert(stopsGracefully([]string{"daemon"}), Equals, true)
+	c.Assert(stopsGracefully([]string{"ls"}), Equals, false)
+	c.Assert(stopsGracefully([]string{"nosuchcommand"}), Equals, false)
+	c.Assert(stopsGracefully(nil), Equals, false)
+}
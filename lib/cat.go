@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -82,13 +83,11 @@ type CatCommand struct {
 
 var catCommand = CatCommand{
 	command: Command{
-		name:        "cat",
-		nameAlias:   []string{"cat"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseCat,
-		specEnglish: specEnglishCat,
-		group:       GroupTypeNormalCommand,
+		name:      "cat",
+		nameAlias: []string{"cat"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -110,6 +109,11 @@ var catCommand = CatCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -131,8 +135,8 @@ func (catc *CatCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (catc *CatCommand) Init(args []string, options OptionMapType) error {
-	return catc.command.Init(args, options, catc)
+func (catc *CatCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return catc.command.Init(ctx, args, options, catc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -0,0 +1,336 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// messageCatalog holds the help messages of a language, the messages missing in the catalog of a
+// loaded language fall back to English
+type messageCatalog struct {
+	usage    string
+	commands map[string]SpecText // command name -> spec text
+	options  map[string]string   // option name without "--" -> help, nil means the help of OptionMap
+}
+
+// catalogFile is the format of the translation file <language>.json in the locale directory
+type catalogFile struct {
+	Usage    string                     `json:"usage"`
+	Commands map[string]catalogSpecText `json:"commands"`
+	Options  map[string]string          `json:"options"`
+}
+
+type catalogSpecText struct {
+	Synopsis string `json:"synopsis"`
+	Param    string `json:"param"`
+	Syntax   string `json:"syntax"`
+	Detail   string `json:"detail"`
+	Sample   string `json:"sample"`
+}
+
+// builtinCatalogs are keyed by the lower case language, the built-in catalogs are Chinese and English
+var builtinCatalogs = map[string]*messageCatalog{
+	LChineseLanguage: {
+		usage: UsageTextChinese,
+		commands: map[string]SpecText{
+			"access-monitor":    specChineseBucketAccessMonitor,
+			"appendfromfile":    specChineseAppendFile,
+			"audit-integrity":   specChineseAuditIntegrity,
+			"bench":             specChineseBench,
+			"bucket-cname":      specChineseBucketCname,
+			"bucket-encryption": specChineseBucketEncryption,
+			"bucket-policy":     specChineseBucketPolicy,
+			"bucket-qos":        specChineseBucketQos,
+			"bucket-tagging":    specChineseBucketTag,
+			"bucket-versioning": specChineseBucketVersioning,
+			"cat":               specChineseCat,
+			"checksum":          specChineseChecksum,
+			"config":            specChineseConfig,
+			"convert-append":    specChineseConvertAppend,
+			"copy-meta":         specChineseCopyMeta,
+			"cors":              specChineseCors,
+			"cors-options":      specChineseOptions,
+			"cp":                specChineseCopy,
+			"create-symlink":    specChineseCreateSymlink,
+			"daemon":            specChineseDaemon,
+			"doctor":            specChineseDoctor,
+			"du":                specChineseDu,
+			"export-config":     specChineseExportConfig,
+			"fetch":             specChineseFetch,
+			"find":              specChineseFind,
+			"gen-docs":          specChineseGenDocs,
+			"getallpartsize":    specChineseAllPartSize,
+			"hash":              specChineseHash,
+			"hashdb":            specChineseHashDB,
+			"help":              specChineseHelp,
+			"index":             specChineseIndex,
+			"inventory":         specChineseBucketInventory,
+			"lcb":               specChineseListCloudBox,
+			"lifecycle":         specChineseBucketLifeCycle,
+			"listpart":          specChineseListPart,
+			"live":              specChineseLive,
+			"logging":           specChineseBucketLog,
+			"lrb":               specChineseListRegionBucket,
+			"ls":                specChineseList,
+			"mb":                specChineseMakeBucket,
+			"mirror-verify":     specChineseMirrorVerify,
+			"mkdir":             specChineseMkdir,
+			"notify-test":       specChineseNotifyTest,
+			"object-tagging":    specChineseObjectTag,
+			"pack":              specChinesePack,
+			"pack-get":          specChinesePackGet,
+			"policy":            specChinesePolicy,
+			"prefetch":          specChinesePrefetch,
+			"probe":             specChineseProbe,
+			"process":           specChineseProcess,
+			"process-async":     specChineseProcessAsync,
+			"process-status":    specChineseProcessStatus,
+			"read-symlink":      specChineseReadSymlink,
+			"referer":           specChineseBucketReferer,
+			"regions":           specChineseRegions,
+			"replication":       specChineseReplication,
+			"request-payment":   specChineseRequestPayment,
+			"resource-group":    specChineseBucketResourceGroup,
+			"restore":           specChineseRestore,
+			"restore-campaign":  specChineseRestoreCampaign,
+			"retention-report":  specChineseRetentionReport,
+			"revert-versioning": specChineseRevert,
+			"rm":                specChineseRemove,
+			"rpc":               specChineseRPC,
+			"set-acl":           specChineseSetACL,
+			"set-meta":          specChineseSetMeta,
+			"sign":              specChineseSignurl,
+			"stat":              specChineseStat,
+			"style":             specChineseBucketStyle,
+			"sync":              specChineseSync,
+			"trash":             specChineseTrash,
+			"unpack":            specChineseUnpack,
+			"update":            specChineseUpdate,
+			"user-qos":          specChineseUserQos,
+			"website":           specChineseBucketWebSite,
+			"worm":              specChineseWorm,
+		},
+	},
+	LEnglishLanguage: {
+		usage: UsageTextEnglish,
+		commands: map[string]SpecText{
+			"access-monitor":    specEnglishBucketAccessMonitor,
+			"appendfromfile":    specEnglishAppendFile,
+			"audit-integrity":   specEnglishAuditIntegrity,
+			"bench":             specEnglishBench,
+			"bucket-cname":      specEnglishBucketCname,
+			"bucket-encryption": specEnglishBucketEncryption,
+			"bucket-policy":     specEnglishBucketPolicy,
+			"bucket-qos":        specEnglishBucketQos,
+			"bucket-tagging":    specEnglishBucketTag,
+			"bucket-versioning": specEnglishBucketVersioning,
+			"cat":               specEnglishCat,
+			"checksum":          specEnglishChecksum,
+			"config":            specEnglishConfig,
+			"convert-append":    specEnglishConvertAppend,
+			"copy-meta":         specEnglishCopyMeta,
+			"cors":              specEnglishCors,
+			"cors-options":      specEnglishOptions,
+			"cp":                specEnglishCopy,
+			"create-symlink":    specEnglishCreateSymlink,
+			"daemon":            specEnglishDaemon,
+			"doctor":            specEnglishDoctor,
+			"du":                specEnglishDu,
+			"export-config":     specEnglishExportConfig,
+			"fetch":             specEnglishFetch,
+			"find":              specEnglishFind,
+			"gen-docs":          specEnglishGenDocs,
+			"getallpartsize":    specEnglishAllPartSize,
+			"hash":              specEnglishHash,
+			"hashdb":            specEnglishHashDB,
+			"help":              specEnglishHelp,
+			"index":             specEnglishIndex,
+			"inventory":         specEnglishBucketInventory,
+			"lcb":               specEnglishListCloudBox,
+			"lifecycle":         specEnglishBucketLifeCycle,
+			"listpart":          specEnglishListPart,
+			"live":              specEnglishLive,
+			"logging":           specEnglishBucketLog,
+			"lrb":               specEnglishListRegionBucket,
+			"ls":                specEnglishList,
+			"mb":                specEnglishMakeBucket,
+			"mirror-verify":     specEnglishMirrorVerify,
+			"mkdir":             specEnglishMkdir,
+			"notify-test":       specEnglishNotifyTest,
+			"object-tagging":    specEnglishObjectTag,
+			"pack":              specEnglishPack,
+			"pack-get":          specEnglishPackGet,
+			"policy":            specEnglishPolicy,
+			"prefetch":          specEnglishPrefetch,
+			"probe":             specEnglishProbe,
+			"process":           specEnglishProcess,
+			"process-async":     specEnglishProcessAsync,
+			"process-status":    specEnglishProcessStatus,
+			"read-symlink":      specEnglishReadSymlink,
+			"referer":           specEnglishBucketReferer,
+			"regions":           specEnglishRegions,
+			"replication":       specEnglishreplication,
+			"request-payment":   specEnglishRequestPayment,
+			"resource-group":    specEnglishBucketResourceGroup,
+			"restore":           specEnglishRestore,
+			"restore-campaign":  specEnglishRestoreCampaign,
+			"retention-report":  specEnglishRetentionReport,
+			"revert-versioning": specEnglishRevert,
+			"rm":                specEnglishRemove,
+			"rpc":               specEnglishRPC,
+			"set-acl":           specEnglishSetACL,
+			"set-meta":          specEnglishSetMeta,
+			"sign":              specEnglishSignurl,
+			"stat":              specEnglishStat,
+			"style":             specEnglishBucketStyle,
+			"sync":              specEnglishSync,
+			"trash":             specEnglishTrash,
+			"unpack":            specEnglishUnpack,
+			"update":            specEnglishUpdate,
+			"user-qos":          specEnglishUserQos,
+			"website":           specEnglishBucketWebSite,
+			"worm":              specEnglishWorm,
+		},
+	},
+}
+
+// loadedCatalogs are the catalogs of the translation files, keyed by the lower case language
+var loadedCatalogs = loadMessageCatalogs(localeDir())
+
+// localeDir returns the directory of the translation files, OSSUTIL_LOCALE_DIR overrides the default
+func localeDir() string {
+	if dir := os.Getenv(LocaleDirEnv); dir != "" {
+		return dir
+	}
+	homeDir := currentHomeDir()
+	if homeDir == "" {
+		return ""
+	}
+	return filepath.Join(homeDir, DefaultLocaleDir)
+}
+
+// loadMessageCatalogs loads the translation files in dir, the invalid files are ignored so that
+// ossutil can still run
+func loadMessageCatalogs(dir string) map[string]*messageCatalog {
+	catalogs := map[string]*messageCatalog{}
+	if dir == "" {
+		return catalogs
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return catalogs
+	}
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		language := strings.ToLower(strings.TrimSuffix(file.Name(), ".json"))
+		if language == LChineseLanguage || language == LEnglishLanguage || strings.Contains(language, "/") {
+			continue
+		}
+		catalog, err := readCatalogFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ignore translation file %s, %s\n", file.Name(), err.Error())
+			continue
+		}
+		catalogs[language] = catalog
+	}
+	return catalogs
+}
+
+func readCatalogFile(fileName string) (*messageCatalog, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var file catalogFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	catalog := &messageCatalog{usage: file.Usage, commands: map[string]SpecText{}, options: file.Options}
+	for name, spec := range file.Commands {
+		catalog.commands[name] = SpecText{
+			synopsisText:   spec.Synopsis,
+			paramText:      spec.Param,
+			syntaxText:     spec.Syntax,
+			detailHelpText: spec.Detail,
+			sampleText:     spec.Sample,
+		}
+	}
+	return catalog, nil
+}
+
+// catalogLanguages returns the available languages in upper case separated by "/", for the value
+// range of --language
+func catalogLanguages() string {
+	languages := []string{ChineseLanguage, EnglishLanguage}
+	extra := []string{}
+	for language := range loadedCatalogs {
+		extra = append(extra, strings.ToUpper(language))
+	}
+	sort.Strings(extra)
+	return strings.Join(append(languages, extra...), "/")
+}
+
+// getCatalog returns the catalog of the language, Chinese is used for unknown language as before
+func getCatalog(language string) *messageCatalog {
+	language = strings.ToLower(language)
+	if catalog, ok := loadedCatalogs[language]; ok {
+		return catalog
+	}
+	if catalog, ok := builtinCatalogs[language]; ok {
+		return catalog
+	}
+	return builtinCatalogs[LChineseLanguage]
+}
+
+// getSpecText returns the spec text of the command in the language
+func getSpecText(language, name string) SpecText {
+	if spec, ok := getCatalog(language).commands[name]; ok {
+		return spec
+	}
+	return builtinCatalogs[LEnglishLanguage].commands[name]
+}
+
+// getUsageText returns the usage of ossutil in the language
+func getUsageText(language string) string {
+	if usage := getCatalog(language).usage; usage != "" {
+		return usage
+	}
+	return UsageTextEnglish
+}
+
+// localeLanguage returns the language of the locale like zh_CN.UTF-8, ja_JP or C, empty means the
+// locale has no catalog
+func localeLanguage(locale string) string {
+	locale = strings.SplitN(strings.SplitN(locale, ".", 2)[0], "@", 2)[0]
+	if locale == "" {
+		return ""
+	}
+	if locale == "zh_CN" || locale == "zh_SG" || locale == "zh" {
+		return ChineseLanguage
+	}
+	code := strings.ToLower(strings.SplitN(locale, "_", 2)[0])
+	if code == LChineseLanguage || code == LEnglishLanguage {
+		return EnglishLanguage
+	}
+	if _, ok := loadedCatalogs[code]; ok {
+		return strings.ToUpper(code)
+	}
+	return EnglishLanguage
+}
+
+// getEnvLang returns the language of LC_ALL, LC_MESSAGES or LANG in order of precedence
+func getEnvLang() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if language := localeLanguage(os.Getenv(name)); language != "" {
+			return language
+		}
+	}
+	return ""
+}
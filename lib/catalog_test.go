@@ -0,0 +1,42 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestMessageCatalog(c *C) {
+	dir := "ossutil-test-locale-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "ja.json"), []byte(`{"usage": "使い方", "commands": {"ls": {"synopsis": "一覧表示"}}, "options": {"recursive": "再帰的"}}`), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{"usage": `), 0644), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"usage": "override"}`), 0644), IsNil)
+
+	oldCatalogs := loadedCatalogs
+	defer func() { loadedCatalogs = oldCatalogs }()
+	loadedCatalogs = loadMessageCatalogs(dir)
+	c.Assert(len(loadedCatalogs), Equals, 1)
+	c.Assert(catalogLanguages(), Equals, "CH/EN/JA")
+
+	c.Assert(getUsageText("JA"), Equals, "使い方")
+	c.Assert(getUsageText("EN"), Equals, UsageTextEnglish)
+	c.Assert(getSpecText("ja", "ls").synopsisText, Equals, "一覧表示")
+	c.Assert(getSpecText("ja", "cp"), DeepEquals, getSpecText(EnglishLanguage, "cp"))
+	c.Assert(getSpecText(ChineseLanguage, "cp").synopsisText, Equals, specChineseCopy.synopsisText)
+
+	recursive := OptionMap[OptionRecursion]
+	c.Assert(recursive.getHelp("JA"), Equals, "再帰的")
+	force := OptionMap[OptionForce]
+	c.Assert(force.getHelp("JA"), Equals, force.helpEnglish)
+	c.Assert(force.getHelp(ChineseLanguage), Equals, force.helpChinese)
+
+	c.Assert(localeLanguage("zh_CN.UTF-8"), Equals, ChineseLanguage)
+	c.Assert(localeLanguage("ja_JP.UTF-8"), Equals, "JA")
+	c.Assert(localeLanguage("de_DE@euro"), Equals, EnglishLanguage)
+	c.Assert(localeLanguage("C"), Equals, EnglishLanguage)
+	c.Assert(localeLanguage(""), Equals, "")
+}
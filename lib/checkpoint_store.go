@@ -0,0 +1,346 @@
+package lib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// CheckpointKeyEnv is the environment variable of the passphrase for --encrypt-checkpoint, the
+// passphrase is not accepted from the command line to keep it out of the shell history
+const CheckpointKeyEnv = "OSSUTIL_CHECKPOINT_KEY"
+
+// sealedCheckpointMagic is the header of the encrypted checkpoint files, it's followed by the
+// nonce and the AES-256-GCM sealed content
+var sealedCheckpointMagic = []byte("OSSUTIL-CP-AES1\n")
+
+// isCheckpointFile reports whether the file in the checkpoint dir is a checkpoint, both the
+// checkpoints of the sdk and the ones of ossutil end with .cp
+func isCheckpointFile(name string) bool {
+	return strings.HasSuffix(name, oss.CheckpointFileSuffix)
+}
+
+// jobCheckpointDir returns the checkpoint dir of the job, the checkpoints of each --job-id are
+// kept in their own sub dir, so that the jobs can run at the same time with the same --checkpoint-dir
+func jobCheckpointDir(cpDir, jobID string) (string, error) {
+	if jobID == "" {
+		return cpDir, nil
+	}
+	if jobID == "." || jobID == ".." || strings.ContainsAny(jobID, `/\`) {
+		return "", fmt.Errorf("invalid --job-id %q, it can't be a path", jobID)
+	}
+	return filepath.Join(cpDir, jobID), nil
+}
+
+// checkpointLockPath returns the lock file of the job in the checkpoint dir, it prevents the same
+// command from running twice at the same time, the commands of different sources or destinations
+// share the checkpoint dir, because the checkpoint files are named by the sources and destinations
+func checkpointLockPath(cpDir string, args []string) string {
+	sum := md5.Sum([]byte(strings.Join(args, CheckpointSep)))
+	return filepath.Join(cpDir, fmt.Sprintf(".ossutil-%s.lock", hex.EncodeToString(sum[:8])))
+}
+
+// checkpointDirInUse returns true if the lock of any job in the dir is held by another command,
+// ownLock is the lock file of the current command
+func checkpointDirInUse(dir, ownLock string) bool {
+	locks, _ := filepath.Glob(filepath.Join(dir, checkpointLockPattern))
+	for _, path := range locks {
+		if filepath.Clean(path) == filepath.Clean(ownLock) {
+			continue
+		}
+		lock, err := tryLockFile(path)
+		if err != nil {
+			LogInfo("the checkpoints in %s are in use: %s\n", dir, err.Error())
+			return true
+		}
+		lock.unlock()
+	}
+	return false
+}
+
+// checkpointCipher returns the cipher of --encrypt-checkpoint, the key is derived from the
+// passphrase in the environment variable
+func checkpointCipher() (cipher.AEAD, error) {
+	passphrase := os.Getenv(CheckpointKeyEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("--encrypt-checkpoint needs the passphrase in the environment variable %s", CheckpointKeyEnv)
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealCheckpoints encrypts the checkpoint files in the dir which are not encrypted yet, e.g., the
+// ones written before --encrypt-checkpoint is specified or left by a killed process
+func sealCheckpoints(dir string, aead cipher.AEAD) error {
+	return walkCheckpoints(dir, func(path string, data []byte) error {
+		if bytes.HasPrefix(data, sealedCheckpointMagic) {
+			return nil
+		}
+		sealed, err := sealCheckpointData(data, aead)
+		if err != nil {
+			return err
+		}
+		return replaceCheckpoint(path, sealed)
+	})
+}
+
+// unsealCheckpoints decrypts the encrypted checkpoint files in the dir, aead is nil if
+// --encrypt-checkpoint is not specified, then it returns error if any checkpoint is encrypted
+func unsealCheckpoints(dir string, aead cipher.AEAD) error {
+	return walkCheckpoints(dir, func(path string, data []byte) error {
+		if !bytes.HasPrefix(data, sealedCheckpointMagic) {
+			return nil
+		}
+		plain, err := openCheckpointData(path, data, aead)
+		if err != nil {
+			return err
+		}
+		return replaceCheckpoint(path, plain)
+	})
+}
+
+func sealCheckpointData(data []byte, aead cipher.AEAD) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return append(append(append([]byte{}, sealedCheckpointMagic...), nonce...), aead.Seal(nil, nonce, data, nil)...), nil
+}
+
+// openCheckpointData returns the plain content of the checkpoint, the content not encrypted is
+// returned as it is
+func openCheckpointData(path string, data []byte, aead cipher.AEAD) ([]byte, error) {
+	if !bytes.HasPrefix(data, sealedCheckpointMagic) {
+		return data, nil
+	}
+	if aead == nil {
+		return nil, fmt.Errorf("the checkpoint %s is encrypted, specify --encrypt-checkpoint with the passphrase in %s to resume, or remove it", path, CheckpointKeyEnv)
+	}
+	data = data[len(sealedCheckpointMagic):]
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("the encrypted checkpoint %s is broken, remove it", path)
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt the checkpoint %s error, the passphrase in %s may be wrong", path, CheckpointKeyEnv)
+	}
+	return plain, nil
+}
+
+// writeCheckpoint writes the checkpoint of ossutil, it's encrypted before written if aead is not nil
+func writeCheckpoint(path string, data []byte, aead cipher.AEAD) error {
+	if aead != nil {
+		sealed, err := sealCheckpointData(data, aead)
+		if err != nil {
+			return err
+		}
+		data = sealed
+	}
+	return replaceCheckpoint(path, data)
+}
+
+// readCheckpoint reads the checkpoint of ossutil, the encrypted one is decrypted
+func readCheckpoint(path string, aead cipher.AEAD) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return openCheckpointData(path, data, aead)
+}
+
+// sdkCheckpoint is the checkpoint of the multipart upload, download and copy of the sdk. The sdk
+// reads and writes the checkpoint by itself, so with --encrypt-checkpoint it's decrypted right
+// before each call of the sdk, and encrypted again right after the sdk loads or writes it: the sdk
+// publishes the progress of the whole file at once after both. The progress of the parts is
+// published with the size of the parts by the workers of the sdk, it's skipped because the sdk may
+// be writing the checkpoint meanwhile
+type sdkCheckpoint struct {
+	listener oss.ProgressListener
+	dir      string
+	path     string
+	size     int64
+	aead     cipher.AEAD
+}
+
+// newSDKCheckpoint returns the checkpoint of the transfer from src to dest of size bytes, aead is
+// nil if --encrypt-checkpoint is not specified, then the sdk names the checkpoint in dir by itself
+func newSDKCheckpoint(dir, src, dest string, size int64, aead cipher.AEAD, listener oss.ProgressListener) *sdkCheckpoint {
+	return &sdkCheckpoint{
+		listener: listener,
+		dir:      dir,
+		path:     sdkCheckpointPath(dir, src, dest),
+		size:     size,
+		aead:     aead,
+	}
+}
+
+// sdkCheckpointPath returns the checkpoint of the sdk in dir with --encrypt-checkpoint
+func sdkCheckpointPath(dir, src, dest string) string {
+	sum := md5.Sum([]byte(src + CheckpointSep + dest))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".sdk.cp")
+}
+
+// options returns the checkpoint and the progress options of the sdk
+func (cp *sdkCheckpoint) options() []oss.Option {
+	if cp.aead == nil {
+		return []oss.Option{oss.CheckpointDir(true, cp.dir), oss.Progress(cp.listener)}
+	}
+	return []oss.Option{oss.Checkpoint(true, cp.path), oss.Progress(cp)}
+}
+
+// open decrypts the checkpoint for the sdk to load it
+func (cp *sdkCheckpoint) open() error {
+	if cp == nil || cp.aead == nil {
+		return nil
+	}
+	data, err := ioutil.ReadFile(cp.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !bytes.HasPrefix(data, sealedCheckpointMagic) {
+		return nil
+	}
+	plain, err := openCheckpointData(cp.path, data, cp.aead)
+	if err != nil {
+		return err
+	}
+	return replaceCheckpoint(cp.path, plain)
+}
+
+// seal encrypts the checkpoint written by the sdk, the checkpoint removed by the sdk is skipped
+func (cp *sdkCheckpoint) seal() {
+	if cp == nil || cp.aead == nil {
+		return
+	}
+	data, err := ioutil.ReadFile(cp.path)
+	if err != nil || bytes.HasPrefix(data, sealedCheckpointMagic) {
+		return
+	}
+	sealed, err := sealCheckpointData(data, cp.aead)
+	if err == nil {
+		err = replaceCheckpoint(cp.path, sealed)
+	}
+	if err != nil {
+		LogError("encrypt the checkpoint %s error: %s\n", cp.path, err.Error())
+	}
+}
+
+// ProgressChanged encrypts the checkpoint after the sdk loads or writes it, then passes the event on
+func (cp *sdkCheckpoint) ProgressChanged(event *oss.ProgressEvent) {
+	if event.TotalBytes == cp.size {
+		switch event.EventType {
+		case oss.TransferStartedEvent, oss.TransferDataEvent, oss.TransferFailedEvent:
+			cp.seal()
+		}
+	}
+	cp.listener.ProgressChanged(event)
+}
+
+func walkCheckpoints(dir string, fn func(path string, data []byte) error) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, file := range files {
+		if !file.Mode().IsRegular() || !isCheckpointFile(file.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := fn(path, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceCheckpoint writes the temp file and renames it to the checkpoint, so that the checkpoint
+// is not truncated if ossutil is killed in between
+func replaceCheckpoint(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// expireCheckpoints removes the checkpoint files older than the days in the checkpoint dir and the
+// dirs of the jobs in it, the dirs in use by other running commands are skipped, ownLock is the lock
+// file of the current command. It returns the number of the removed files
+func expireCheckpoints(cpDir, ownLock string, days int64) int {
+	dirs := []string{cpDir}
+	if files, err := ioutil.ReadDir(cpDir); err == nil {
+		for _, file := range files {
+			if file.IsDir() {
+				dirs = append(dirs, filepath.Join(cpDir, file.Name()))
+			}
+		}
+	}
+
+	ownDir := filepath.Dir(ownLock)
+	deadline := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	removed := 0
+	for _, dir := range dirs {
+		if checkpointDirInUse(dir, ownLock) {
+			continue
+		}
+		removed += expireCheckpointFiles(dir, deadline)
+
+		// the dir of the job is dropped once all its checkpoints expired
+		if dir != cpDir && filepath.Clean(dir) != filepath.Clean(ownDir) {
+			os.Remove(dir)
+		}
+	}
+	return removed
+}
+
+func expireCheckpointFiles(dir string, deadline time.Time) int {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, file := range files {
+		if !file.Mode().IsRegular() || !isCheckpointFile(file.Name()) || !file.ModTime().Before(deadline) {
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if err := os.Remove(path); err != nil {
+			LogWarn("remove expired checkpoint %s error: %s\n", path, err.Error())
+			continue
+		}
+		LogInfo("remove expired checkpoint %s, last modified at %s\n", path, file.ModTime().Format(time.RFC3339))
+		removed++
+	}
+	return removed
+}
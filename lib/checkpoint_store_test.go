@@ -0,0 +1,152 @@
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestCheckpointStore(c *C) {
+	cpDir := "ossutil-test-cp-store-" + randLowStr(5)
+	defer os.RemoveAll(cpDir)
+
+	// --job-id
+	dir, err := jobCheckpointDir(cpDir, "")
+	c.Assert(err, IsNil)
+	c.Assert(dir, Equals, cpDir)
+	dir, err = jobCheckpointDir(cpDir, "nightly")
+	c.Assert(err, IsNil)
+	c.Assert(dir, Equals, filepath.Join(cpDir, "nightly"))
+	_, err = jobCheckpointDir(cpDir, "../nightly")
+	c.Assert(err, NotNil)
+
+	// --encrypt-checkpoint
+	jobDir := filepath.Join(cpDir, "nightly")
+	c.Assert(os.MkdirAll(jobDir, 0755), IsNil)
+	cpPath := filepath.Join(jobDir, "abc.stream.cp")
+	content := []byte(`{"UploadID":"upload-id","DestURL":"oss://bucket/secret-key"}`)
+	c.Assert(ioutil.WriteFile(cpPath, content, 0600), IsNil)
+
+	os.Unsetenv(CheckpointKeyEnv)
+	_, err = checkpointCipher()
+	c.Assert(err, NotNil)
+
+	os.Setenv(CheckpointKeyEnv, "passphrase")
+	aead, err := checkpointCipher()
+	c.Assert(err, IsNil)
+	c.Assert(sealCheckpoints(jobDir, aead), IsNil)
+	data, err := ioutil.ReadFile(cpPath)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.HasPrefix(data, sealedCheckpointMagic), Equals, true)
+	c.Assert(bytes.Contains(data, []byte("secret-key")), Equals, false)
+
+	// sealed only once
+	c.Assert(sealCheckpoints(jobDir, aead), IsNil)
+	sealed, _ := ioutil.ReadFile(cpPath)
+	c.Assert(bytes.Equal(sealed, data), Equals, true)
+
+	// without or with a wrong passphrase
+	c.Assert(unsealCheckpoints(jobDir, nil), NotNil)
+	os.Setenv(CheckpointKeyEnv, "wrong")
+	wrong, err := checkpointCipher()
+	c.Assert(err, IsNil)
+	c.Assert(unsealCheckpoints(jobDir, wrong), NotNil)
+
+	c.Assert(unsealCheckpoints(jobDir, aead), IsNil)
+	data, err = ioutil.ReadFile(cpPath)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, string(content))
+
+	// the checkpoints of ossutil are encrypted when they are written
+	ownPath := filepath.Join(jobDir, "own.crc.cp")
+	c.Assert(writeCheckpoint(ownPath, content, aead), IsNil)
+	data, err = ioutil.ReadFile(ownPath)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Contains(data, []byte("secret-key")), Equals, false)
+	_, err = readCheckpoint(ownPath, nil)
+	c.Assert(err, NotNil)
+	data, err = readCheckpoint(ownPath, aead)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, string(content))
+	c.Assert(os.Remove(ownPath), IsNil)
+
+	// the checkpoint of the sdk is decrypted before the sdk loads it, and encrypted after the sdk
+	// loads or writes it, the progress of the parts is skipped
+	counter := &progressCounter{}
+	sdkCP := newSDKCheckpoint(jobDir, "/src/file", "oss://bucket/secret-key", 100, aead, counter)
+	c.Assert(sdkCP.open(), IsNil)
+	c.Assert(ioutil.WriteFile(sdkCP.path, content, 0600), IsNil)
+	sdkCP.ProgressChanged(&oss.ProgressEvent{EventType: oss.TransferDataEvent, ConsumedBytes: 10, TotalBytes: 50, RwBytes: 10})
+	data, _ = ioutil.ReadFile(sdkCP.path)
+	c.Assert(string(data), Equals, string(content))
+	sdkCP.ProgressChanged(&oss.ProgressEvent{EventType: oss.TransferDataEvent, ConsumedBytes: 50, TotalBytes: 100, RwBytes: 50})
+	data, _ = ioutil.ReadFile(sdkCP.path)
+	c.Assert(bytes.HasPrefix(data, sealedCheckpointMagic), Equals, true)
+	c.Assert(counter.events, Equals, 2)
+	c.Assert(sdkCP.open(), IsNil)
+	data, _ = ioutil.ReadFile(sdkCP.path)
+	c.Assert(string(data), Equals, string(content))
+	sdkCP.seal()
+	data, _ = ioutil.ReadFile(sdkCP.path)
+	c.Assert(bytes.HasPrefix(data, sealedCheckpointMagic), Equals, true)
+	c.Assert(os.Remove(sdkCP.path), IsNil)
+	sdkCP.seal()
+	c.Assert(sdkCP.open(), IsNil)
+
+	// the sdk names the checkpoint by itself without --encrypt-checkpoint
+	plainCP := newSDKCheckpoint(jobDir, "/src/file", "oss://bucket/secret-key", 100, nil, counter)
+	c.Assert(len(plainCP.options()), Equals, 2)
+	c.Assert(plainCP.open(), IsNil)
+	os.Unsetenv(CheckpointKeyEnv)
+
+	// --checkpoint-expire-days
+	old := time.Now().Add(-72 * time.Hour)
+	oldPath := filepath.Join(cpDir, "old.cp")
+	c.Assert(ioutil.WriteFile(oldPath, content, 0600), IsNil)
+	c.Assert(os.Chtimes(oldPath, old, old), IsNil)
+	c.Assert(os.Chtimes(cpPath, old, old), IsNil)
+	otherFile := filepath.Join(cpDir, "other.txt")
+	c.Assert(ioutil.WriteFile(otherFile, content, 0600), IsNil)
+	c.Assert(os.Chtimes(otherFile, old, old), IsNil)
+
+	runningDir := filepath.Join(cpDir, "running")
+	c.Assert(os.MkdirAll(runningDir, 0755), IsNil)
+	runningPath := filepath.Join(runningDir, "running.cp")
+	c.Assert(ioutil.WriteFile(runningPath, content, 0600), IsNil)
+	c.Assert(os.Chtimes(runningPath, old, old), IsNil)
+	lock, err := tryLockFile(filepath.Join(runningDir, CheckpointLockFileName))
+	c.Assert(err, IsNil)
+
+	c.Assert(os.MkdirAll(filepath.Join(cpDir, "current"), 0755), IsNil)
+	ownLock, err := tryLockFile(checkpointLockPath(filepath.Join(cpDir, "current"), []string{"src", "dest"}))
+	c.Assert(err, IsNil)
+	c.Assert(checkpointDirInUse(runningDir, ownLock.path), Equals, true)
+	c.Assert(checkpointDirInUse(filepath.Join(cpDir, "current"), ownLock.path), Equals, false)
+	c.Assert(expireCheckpoints(cpDir, ownLock.path, 5), Equals, 0)
+	c.Assert(expireCheckpoints(cpDir, ownLock.path, 2), Equals, 2)
+	lock.unlock()
+	ownLock.unlock()
+	os.Remove(filepath.Join(cpDir, "current"))
+
+	_, err = os.Stat(oldPath)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(jobDir)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(otherFile)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(runningPath)
+	c.Assert(err, IsNil)
+}
+
+type progressCounter struct {
+	events int
+}
+
+func (p *progressCounter) ProgressChanged(event *oss.ProgressEvent) {
+	p.events++
+}
@@ -0,0 +1,564 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseChecksum = SpecText{
+	synopsisText: "生成objects的校验和清单，或者使用清单校验本地文件或objects",
+
+	paramText: "generate|verify [manifest_file] url [options]",
+
+	syntaxText: `
+    ossutil checksum generate oss://bucket[/prefix] [-o manifest_file] [--algorithm sha256|crc64] [-j jobs] [--payer requester]
+    ossutil checksum verify manifest_file local_dir|oss://bucket[/prefix] [--algorithm sha256|crc64] [-j jobs] [--payer requester]
+`,
+
+	detailHelpText: `
+    校验和清单的格式与sha256sum等工具相同，每行为校验和、两个空格以及文件相对于目录(或者object相对于
+    prefix)的路径，sha256的清单可以直接在本地使用sha256sum -c校验。
+
+    --algorithm指定校验和的算法：
+        sha256    读取objects的数据计算sha256，generate的默认值
+        crc64     使用oss上存储的crc64(X-Oss-Hash-Crc64ecma)，没有存储crc64的objects读取数据计算，
+                  不需要下载数据，适用于大量数据，清单中的crc64为十进制数字
+
+    checksum命令的第一个参数为子命令：
+
+    1) generate: 列举cloud_url下的objects(以/结尾的目录objects除外)，并发计算校验和，按照路径排序后写入
+       -o选项指定的清单文件，没有指定-o时输出到标准输出。
+
+    2) verify: 读取清单文件，校验本地目录下的文件或者cloud_url下的objects，没有指定--algorithm时根据清单
+       中校验和的长度判断算法。输出的每行为一个不一致的文件或object：
+           missing<TAB>path            文件或object不存在
+           mismatch<TAB>path<TAB>原因   校验和不一致
+       存在不一致时命令返回错误。清单之外的文件或objects不被校验。
+
+    -j指定并发计算的文件或objects个数。
+`,
+
+	sampleText: `
+    1) 生成prefix下objects的sha256清单
+       ossutil checksum generate oss://bucket/release/v1/ -o SHA256SUMS
+
+    2) 使用oss上存储的crc64生成清单
+       ossutil checksum generate oss://bucket/backup/ -o CRC64SUMS --algorithm crc64
+
+    3) 校验下载到本地的文件
+       ossutil checksum verify SHA256SUMS ./v1
+
+    4) 校验另一个bucket中的副本
+       ossutil checksum verify CRC64SUMS oss://bucket2/backup/
+`,
+}
+
+var specEnglishChecksum = SpecText{
+	synopsisText: "Generate the checksum manifest of the objects, or verify the local files or the objects with it",
+
+	paramText: "generate|verify [manifest_file] url [options]",
+
+	syntaxText: `
+    ossutil checksum generate oss://bucket[/prefix] [-o manifest_file] [--algorithm sha256|crc64] [-j jobs] [--payer requester]
+    ossutil checksum verify manifest_file local_dir|oss://bucket[/prefix] [--algorithm sha256|crc64] [-j jobs] [--payer requester]
+`,
+
+	detailHelpText: `
+    The format of the checksum manifest is the same as the tools like sha256sum, each line is the
+    checksum, two spaces and the path of the file relative to the directory(or the object relative to
+    the prefix), the sha256 manifest can be verified locally by sha256sum -c directly.
+
+    --algorithm specifies the algorithm of the checksums:
+        sha256    the sha256 calculated by reading the data of the objects, the default of generate
+        crc64     the crc64 stored in oss(X-Oss-Hash-Crc64ecma), it's calculated by reading the data
+                  for the objects without stored crc64. The data isn't downloaded, it's for a large
+                  amount of data, the crc64 in the manifest is the decimal number
+
+    The first argument of checksum command is the sub command:
+
+    1) generate: list the objects under cloud_url(except the directory objects ending with /), calculate
+       their checksums concurrently, and write them sorted by path to the manifest file specified by -o,
+       the manifest is written to stdout without -o.
+
+    2) verify: read the manifest file, and verify the files under the local directory or the objects
+       under cloud_url. The algorithm is decided by the length of the checksums in the manifest if
+       --algorithm is not specified. Each line of the output is a file or an object which doesn't match:
+           missing<TAB>path               the file or the object doesn't exist
+           mismatch<TAB>path<TAB>reason   the checksum is different
+       The command returns error if there is any one which doesn't match. The files or the objects not
+       in the manifest are not verified.
+
+    -j specifies the number of the files or the objects calculated concurrently.
+`,
+
+	sampleText: `
+    1) generate the sha256 manifest of the objects under the prefix
+       ossutil checksum generate oss://bucket/release/v1/ -o SHA256SUMS
+
+    2) generate the manifest by the crc64 stored in oss
+       ossutil checksum generate oss://bucket/backup/ -o CRC64SUMS --algorithm crc64
+
+    3) verify the files downloaded
+       ossutil checksum verify SHA256SUMS ./v1
+
+    4) verify the replica in another bucket
+       ossutil checksum verify CRC64SUMS oss://bucket2/backup/
+`,
+}
+
+// the algorithms of --algorithm
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumCRC64  = "crc64"
+)
+
+// checksumEntry is a line of the checksum manifest
+type checksumEntry struct {
+	checksum string
+	path     string
+}
+
+type checksumOptionType struct {
+	checkedNum   int64
+	missingNum   int64
+	mismatchNum  int64
+	algorithm    string
+	routines     int64
+	payerOptions []oss.Option
+	outMu        sync.Mutex
+}
+
+// ChecksumCommand is the command generates and verifies the checksum manifests
+type ChecksumCommand struct {
+	command  Command
+	csOption checksumOptionType
+}
+
+var checksumCommand = ChecksumCommand{
+	command: Command{
+		name:      "checksum",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   3,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionOutputFile,
+			OptionAlgorithm,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (csc *ChecksumCommand) formatHelpForWhole() string {
+	return csc.command.formatHelpForWhole()
+}
+
+func (csc *ChecksumCommand) formatIndependHelp() string {
+	return csc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (csc *ChecksumCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return csc.command.Init(ctx, args, options, csc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (csc *ChecksumCommand) RunCommand() error {
+	action := strings.ToLower(csc.command.args[0])
+	if action != "generate" && action != "verify" {
+		return fmt.Errorf("the sub command %s is not in the optional value:generate|verify", csc.command.args[0])
+	}
+	if action == "generate" && len(csc.command.args) != 2 {
+		return CommandError{csc.command.name, "generate needs the cloud url only"}
+	}
+	if action == "verify" && len(csc.command.args) != 3 {
+		return CommandError{csc.command.name, "verify needs the manifest file and the local directory or the cloud url"}
+	}
+
+	csc.csOption = checksumOptionType{}
+	csc.csOption.algorithm, _ = GetString(OptionAlgorithm, csc.command.options)
+	csc.csOption.algorithm = strings.ToLower(csc.csOption.algorithm)
+	if csc.csOption.algorithm != "" && csc.csOption.algorithm != ChecksumSHA256 && csc.csOption.algorithm != ChecksumCRC64 {
+		return fmt.Errorf("invalid --algorithm: %s, the value should be %s or %s", csc.csOption.algorithm, ChecksumSHA256, ChecksumCRC64)
+	}
+
+	payer, _ := GetString(OptionRequestPayer, csc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		csc.csOption.payerOptions = append(csc.csOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+	csc.csOption.routines, _ = GetInt(OptionRoutines, csc.command.options)
+	if csc.csOption.routines <= 0 {
+		csc.csOption.routines = int64(Routines)
+	}
+
+	if action == "generate" {
+		return csc.generate()
+	}
+	return csc.verify()
+}
+
+// checksumPrefix returns the bucket and the prefix of the cloud url, the prefix ends with / so
+// that the paths in the manifest are relative to it
+func (csc *ChecksumCommand) checksumPrefix(strURL string) (*oss.Bucket, string, error) {
+	encodingType, _ := GetString(OptionEncodingType, csc.command.options)
+	cloudURL, err := GetCloudUrl(strURL, encodingType)
+	if err != nil {
+		return nil, "", err
+	}
+	prefix := cloudURL.object
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	bucket, err := csc.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return bucket, prefix, nil
+}
+
+func (csc *ChecksumCommand) generate() error {
+	if csc.csOption.algorithm == "" {
+		csc.csOption.algorithm = ChecksumSHA256
+	}
+	bucket, prefix, err := csc.checksumPrefix(csc.command.args[1])
+	if err != nil {
+		return err
+	}
+
+	var entries []checksumEntry
+	var entriesMu sync.Mutex
+	err = csc.concurrently(func(chPaths chan<- string, done <-chan struct{}) error {
+		marker := ""
+		for {
+			listOptions := append(csc.csOption.payerOptions, oss.Prefix(prefix), oss.Marker(marker), oss.MaxKeys(1000))
+			lor, err := csc.command.ossListObjectsRetry(bucket, listOptions...)
+			if err != nil {
+				return err
+			}
+			for _, object := range lor.Objects {
+				if strings.HasSuffix(object.Key, "/") {
+					continue
+				}
+				select {
+				case chPaths <- strings.TrimPrefix(object.Key, prefix):
+				case <-done:
+					return nil
+				}
+			}
+			marker = lor.NextMarker
+			if !lor.IsTruncated {
+				return nil
+			}
+		}
+	}, func(path string) error {
+		checksum, err := csc.objectChecksum(bucket, prefix+path)
+		if err != nil {
+			return err
+		}
+		entriesMu.Lock()
+		entries = append(entries, checksumEntry{checksum, path})
+		entriesMu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].path < entries[j].path })
+
+	out := os.Stdout
+	outputFile, _ := GetString(OptionOutputFile, csc.command.options)
+	if outputFile != "" {
+		if out, err = os.Create(outputFile); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	writer := bufio.NewWriter(out)
+	for _, entry := range entries {
+		fmt.Fprintf(writer, "%s  %s\n", entry.checksum, entry.path)
+	}
+	if err = writer.Flush(); err != nil {
+		return err
+	}
+	if outputFile != "" {
+		fmt.Printf("generated the %s checksums of %d objects to %s\n", csc.csOption.algorithm, len(entries), outputFile)
+	}
+	return nil
+}
+
+func (csc *ChecksumCommand) verify() error {
+	entries, err := readChecksumManifest(csc.command.args[1])
+	if err != nil {
+		return err
+	}
+	if csc.csOption.algorithm == "" && len(entries) > 0 {
+		csc.csOption.algorithm = ChecksumCRC64
+		if len(entries[0].checksum) == sha256.Size*2 {
+			csc.csOption.algorithm = ChecksumSHA256
+		}
+	}
+	for _, entry := range entries {
+		if err := checkManifestChecksum(entry.checksum, csc.csOption.algorithm); err != nil {
+			return fmt.Errorf("invalid %s checksum of %s in manifest %s", csc.csOption.algorithm, entry.path, csc.command.args[1])
+		}
+	}
+
+	var check func(path string) (string, error)
+	target := csc.command.args[2]
+	encodingType, _ := GetString(OptionEncodingType, csc.command.options)
+	storageURL, err := StorageURLFromString(target, encodingType)
+	if err != nil {
+		return err
+	}
+	if storageURL.IsCloudURL() {
+		bucket, prefix, err := csc.checksumPrefix(target)
+		if err != nil {
+			return err
+		}
+		check = func(path string) (string, error) {
+			return csc.objectChecksum(bucket, prefix+path)
+		}
+	} else {
+		if info, err := os.Stat(target); err != nil {
+			return err
+		} else if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", target)
+		}
+		check = func(path string) (string, error) {
+			return fileChecksum(filepath.Join(target, filepath.FromSlash(path)), csc.csOption.algorithm)
+		}
+	}
+
+	expected := map[string]string{}
+	for _, entry := range entries {
+		expected[entry.path] = entry.checksum
+	}
+	err = csc.concurrently(func(chPaths chan<- string, done <-chan struct{}) error {
+		for _, entry := range entries {
+			select {
+			case chPaths <- entry.path:
+			case <-done:
+				return nil
+			}
+		}
+		return nil
+	}, func(path string) error {
+		atomic.AddInt64(&csc.csOption.checkedNum, 1)
+		checksum, err := check(path)
+		if err != nil {
+			if os.IsNotExist(err) || isNotFound(err) {
+				atomic.AddInt64(&csc.csOption.missingNum, 1)
+				csc.output("missing", path, "")
+				return nil
+			}
+			return err
+		}
+		if !strings.EqualFold(checksum, expected[path]) {
+			atomic.AddInt64(&csc.csOption.mismatchNum, 1)
+			csc.output("mismatch", path, fmt.Sprintf("%s %s != %s", csc.csOption.algorithm, checksum, expected[path]))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("checked count:%d\tmissing:%d\tmismatch:%d\n", csc.csOption.checkedNum, csc.csOption.missingNum, csc.csOption.mismatchNum)
+	if csc.csOption.missingNum > 0 || csc.csOption.mismatchNum > 0 {
+		return fmt.Errorf("checksum verification failed, %d missing, %d mismatch", csc.csOption.missingNum, csc.csOption.mismatchNum)
+	}
+	return nil
+}
+
+// concurrently calls handle for the paths sent by produce with --jobs routines, it returns the
+// first error, done is closed to stop produce after an error of handle
+func (csc *ChecksumCommand) concurrently(produce func(chPaths chan<- string, done <-chan struct{}) error, handle func(path string) error) error {
+	chPaths := make(chan string, ChannelBuf)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var handleErr error
+	done := make(chan struct{})
+	for i := 0; int64(i) < csc.csOption.routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range chPaths {
+				if err := handle(path); err != nil {
+					once.Do(func() {
+						handleErr = err
+						close(done)
+					})
+				}
+			}
+		}()
+	}
+
+	err := produce(chPaths, done)
+	close(chPaths)
+	wg.Wait()
+	if handleErr != nil {
+		return handleErr
+	}
+	return err
+}
+
+// objectChecksum returns the crc64 stored in oss, or calculates the checksum by reading the object
+func (csc *ChecksumCommand) objectChecksum(bucket *oss.Bucket, object string) (string, error) {
+	if csc.csOption.algorithm == ChecksumCRC64 {
+		props, err := csc.command.ossGetObjectStatRetry(bucket, object, csc.csOption.payerOptions...)
+		if err != nil {
+			return "", err
+		}
+		if crc := props.Get(oss.HTTPHeaderOssCRC64); crc != "" {
+			return crc, nil
+		}
+	}
+
+	retryTimes, _ := GetInt(OptionRetryTimes, csc.command.options)
+	for i := 1; ; i++ {
+		checksum, err := csc.readObjectChecksum(bucket, object)
+		if err == nil {
+			return checksum, nil
+		}
+		if isNotFound(err) || int64(i) >= retryTimes {
+			return "", ObjectError{err, bucket.BucketName, object}
+		}
+	}
+}
+
+func (csc *ChecksumCommand) readObjectChecksum(bucket *oss.Bucket, object string) (string, error) {
+	body, err := bucket.GetObject(object, csc.csOption.payerOptions...)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	return readerChecksum(body, csc.csOption.algorithm)
+}
+
+func fileChecksum(path, algorithm string) (string, error) {
+	if algorithm == ChecksumCRC64 {
+		return fileCRC64(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return readerChecksum(f, algorithm)
+}
+
+func readerChecksum(reader io.Reader, algorithm string) (string, error) {
+	var h hash.Hash
+	if algorithm == ChecksumCRC64 {
+		h = crc64.New(crc64ECMATable)
+	} else {
+		h = sha256.New()
+	}
+	if _, err := io.Copy(h, reader); err != nil {
+		return "", err
+	}
+	if algorithm == ChecksumCRC64 {
+		return strconv.FormatUint(h.(hash.Hash64).Sum64(), 10), nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readChecksumManifest reads the lines of "checksum  path", the path may be marked as binary by *
+// instead of the second space, the empty lines and the lines starting with # are ignored
+func readChecksumManifest(manifest string) ([]checksumEntry, error) {
+	f, err := os.Open(manifest)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []checksumEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pos := strings.Index(line, " ")
+		if pos <= 0 || pos+2 > len(line) || (line[pos+1] != ' ' && line[pos+1] != '*') {
+			return nil, fmt.Errorf("invalid line %d of manifest %s, the format should be: checksum  path", lineNum, manifest)
+		}
+		entries = append(entries, checksumEntry{checksum: line[:pos], path: line[pos+2:]})
+	}
+	return entries, scanner.Err()
+}
+
+func checkManifestChecksum(checksum, algorithm string) error {
+	if algorithm == ChecksumCRC64 {
+		_, err := strconv.ParseUint(checksum, 10, 64)
+		return err
+	}
+	if b, err := hex.DecodeString(checksum); err != nil || len(b) != sha256.Size {
+		return fmt.Errorf("invalid sha256 %s", checksum)
+	}
+	return nil
+}
+
+func (csc *ChecksumCommand) output(kind, path, reason string) {
+	csc.csOption.outMu.Lock()
+	defer csc.csOption.outMu.Unlock()
+	if reason != "" {
+		fmt.Printf("%s\t%s\t%s\n", kind, path, reason)
+	} else {
+		fmt.Printf("%s\t%s\n", kind, path)
+	}
+}
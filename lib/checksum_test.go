@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestChecksum(c *C) {
+	contents := map[string]string{"/bucket/rel/a.txt": "aaa", "/bucket/rel/sub/b.txt": "bbb"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := contents[r.URL.Path]
+		switch {
+		case r.URL.Path == "/bucket/":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>rel/a.txt</Key><Size>3</Size></Contents><Contents><Key>rel/dir/</Key><Size>0</Size></Contents>
+<Contents><Key>rel/sub/b.txt</Key><Size>3</Size></Contents>
+</ListBucketResult>`)
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "HEAD":
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			// b.txt has no stored crc64
+			if strings.HasSuffix(r.URL.Path, "a.txt") {
+				w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+			}
+		default:
+			fmt.Fprint(w, data)
+		}
+	}))
+	defer server.Close()
+
+	resultPath := "ossutil-test-checksum-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	dir := "ossutil-test-checksum-dir-" + randLowStr(8)
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+		os.RemoveAll(dir)
+	}()
+
+	manifest := filepath.Join(dir, "SHA256SUMS")
+	c.Assert(os.MkdirAll(filepath.Join(dir, "local", "sub"), 0755), IsNil)
+	str := "ak"
+	forcePathStyle := true
+	algorithm := ""
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionOutputFile:      &manifest,
+		OptionAlgorithm:       &algorithm,
+	}
+
+	// sha256 by default, the directory object is skipped
+	_, err = cm.RunCommand("checksum", []string{"generate", "oss://bucket/rel"}, options)
+	c.Assert(err, IsNil)
+	sumA, sumB := sha256.Sum256([]byte("aaa")), sha256.Sum256([]byte("bbb"))
+	c.Assert(s.readFile(manifest, c), Equals, hex.EncodeToString(sumA[:])+"  a.txt\n"+hex.EncodeToString(sumB[:])+"  sub/b.txt\n")
+
+	// verify the local files
+	s.createFile(filepath.Join(dir, "local", "a.txt"), "aaa", c)
+	s.createFile(filepath.Join(dir, "local", "sub", "b.txt"), "bbb", c)
+	_, err = cm.RunCommand("checksum", []string{"verify", manifest, filepath.Join(dir, "local")}, options)
+	c.Assert(err, IsNil)
+	s.createFile(filepath.Join(dir, "local", "a.txt"), "aab", c)
+	os.Remove(filepath.Join(dir, "local", "sub", "b.txt"))
+	_, err = cm.RunCommand("checksum", []string{"verify", manifest, filepath.Join(dir, "local")}, options)
+	c.Assert(err, NotNil)
+	data, err := ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "mismatch\ta.txt\tsha256"), Equals, true)
+	c.Assert(strings.Contains(string(data), "missing\tsub/b.txt\n"), Equals, true)
+
+	// crc64 is stored in oss or calculated by reading the object, verify the objects
+	algorithm = ChecksumCRC64
+	_, err = cm.RunCommand("checksum", []string{"generate", "oss://bucket/rel/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(manifest, c), Equals, fmt.Sprintf("%d  a.txt\n%d  sub/b.txt\n",
+		crc64.Checksum([]byte("aaa"), crc64ECMATable), crc64.Checksum([]byte("bbb"), crc64ECMATable)))
+	algorithm = ""
+	_, err = cm.RunCommand("checksum", []string{"verify", manifest, "oss://bucket/rel/"}, options)
+	c.Assert(err, IsNil)
+	contents["/bucket/rel/sub/b.txt"] = "bbc"
+	_, err = cm.RunCommand("checksum", []string{"verify", manifest, "oss://bucket/rel/"}, options)
+	c.Assert(err, NotNil)
+	delete(contents, "/bucket/rel/a.txt")
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("checksum", []string{"verify", manifest, "oss://bucket/rel/"}, options)
+	c.Assert(err, NotNil)
+	data, err = ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "missing\ta.txt\n"), Equals, true)
+	c.Assert(strings.Contains(string(data), "mismatch\tsub/b.txt\tcrc64"), Equals, true)
+
+	// the binary mark of sha256sum, the invalid manifest
+	s.createFile(manifest, hex.EncodeToString(sumA[:])+" *a.txt\n", c)
+	entries, err := readChecksumManifest(manifest)
+	c.Assert(err, IsNil)
+	c.Assert(entries, DeepEquals, []checksumEntry{{hex.EncodeToString(sumA[:]), "a.txt"}})
+	s.createFile(manifest, "abc a.txt\n", c)
+	_, err = cm.RunCommand("checksum", []string{"verify", manifest, filepath.Join(dir, "local")}, options)
+	c.Assert(err, NotNil)
+	algorithm = "md5"
+	_, err = cm.RunCommand("checksum", []string{"generate", "oss://bucket/rel/"}, options)
+	c.Assert(err, NotNil)
+}
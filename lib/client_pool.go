@@ -0,0 +1,112 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// sharedClients is set by the long running commands such as daemon, so that the commands they
+// run reuse the clients and the connections, nil means every command creates its own clients
+var sharedClients *clientPool
+
+// clientKeyOptions is the options which decide the settings of the client, every option read
+// by createOSSClient must be here
+var clientKeyOptions = []string{
+	OptionAccessKeyID,
+	OptionAccessKeySecret,
+	OptionSTSToken,
+	OptionMode,
+	OptionECSRoleName,
+	OptionTokenTimeout,
+	OptionRamRoleArn,
+	OptionRoleSessionName,
+	OptionSTSRegion,
+	OptionProxyHost,
+	OptionProxyUser,
+	OptionProxyPwd,
+	OptionReadTimeout,
+	OptionConnectTimeout,
+	OptionLocalHost,
+	OptionSkipVerifyCert,
+	OptionRegion,
+	OptionSignVersion,
+	OptionCloudBoxID,
+	OptionForcePathStyle,
+	OptionUserAgent,
+	OptionDisableCRC64,
+	OptionContentMD5,
+	OptionMaxUpSpeed,
+	OptionMaxDownSpeed,
+	OptionMaxIdleConns,
+	OptionMaxConnsPerHost,
+	OptionIdleConnTimeout,
+	OptionHTTP2,
+	OptionMaxQPS,
+}
+
+type pooledClient struct {
+	client  *oss.Client
+	created time.Time
+}
+
+// clientPool caches the clients by their settings, the clients expire after ttl, so that the
+// credentials assumed by the ram role and the changes of the config file take effect
+type clientPool struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	clients map[string]pooledClient
+}
+
+func newClientPool(ttl time.Duration) *clientPool {
+	return &clientPool{ttl: ttl, clients: map[string]pooledClient{}}
+}
+
+// get returns the cached client of key, or the client created by create
+func (p *clientPool) get(key string, create func() (*oss.Client, error)) (*oss.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if pc, ok := p.clients[key]; ok && now.Sub(pc.created) < p.ttl {
+		return pc.client, nil
+	}
+	client, err := create()
+	if err != nil {
+		return nil, err
+	}
+	for k, pc := range p.clients {
+		if now.Sub(pc.created) >= p.ttl {
+			delete(p.clients, k)
+		}
+	}
+	p.clients[key] = pooledClient{client: client, created: now}
+	return client, nil
+}
+
+func (p *clientPool) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.clients)
+}
+
+// clientKey returns the key of the client settings of the command, including the options and
+// the credential items of the config file read by createOSSClient
+func (cmd *Command) clientKey(endpoint string, isCname bool) string {
+	ecsURL, _ := cmd.getEcsRamAkService()
+	ramRoleArn, _ := cmd.getRamRoleArn()
+	values := []string{endpoint, fmt.Sprint(isCname), fmt.Sprint(logLevel), ecsURL, ramRoleArn}
+	for _, name := range clientKeyOptions {
+		switch v := cmd.options[name].(type) {
+		case *string:
+			values = append(values, *v)
+		case *bool:
+			values = append(values, fmt.Sprint(*v))
+		default:
+			values = append(values, "")
+		}
+	}
+	return strings.Join(values, "\x00")
+}
@@ -1,16 +1,21 @@
 package lib
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"golang.org/x/time/rate"
 )
 
 // group spec text of all commands
@@ -44,20 +49,27 @@ type Command struct {
 	nameAlias        []string
 	minArgc          int
 	maxArgc          int
-	specChinese      SpecText
-	specEnglish      SpecText
 	validOptionNames []string
 	group            string
 	args             []string
 	options          OptionMapType
 	configOptions    OptionMapType
 	inputKeySecret   string
+	safetyCap        *safetyCap        // nil means neither --max-objects nor --max-bytes
+	tagSelector      *tagSelector      // nil means no --where-tag
+	timeWindow       *timeWindow       // nil means neither --last-modified-after nor --last-modified-before
+	ctx              context.Context   // nil means the command is never canceled
+	specFilters      []string          // --include and --exclude declared in --spec
+	aliasSchemes     map[string]string // the alias schemes of the buckets in the arguments, e.g. oss-internal://
+	requestLimiter   *rate.Limiter     // nil means not --max-qps
+	commandLineMeta  string            // --meta before the headers of the bucket profiles are merged
+	stdout           io.Writer         // the text output, nil means os.Stdout
 }
 
 // Commander is the interface of all commands
 type Commander interface {
 	RunCommand() error
-	Init(args []string, options OptionMapType) error
+	Init(ctx context.Context, args []string, options OptionMapType) error
 }
 
 // RewriteLoadConfiger is the interface for those commands, which do not need to load config, or have other action
@@ -71,14 +83,26 @@ type RewriteAssembleOptioner interface {
 }
 
 // Init is the common functions for all commands, they use Init to initialize itself
-func (cmd *Command) Init(args []string, options OptionMapType, cmder interface{}) error {
+func (cmd *Command) Init(ctx context.Context, args []string, options OptionMapType, cmder interface{}) error {
 	cmd.args = args
 	cmd.options = options
 	cmd.configOptions = OptionMapType{}
+	cmd.ctx = ctx
+
+	cmd.specFilters = nil
+	if err := cmd.applyJobSpec(); err != nil {
+		return err
+	}
 
 	if err := cmd.checkArgs(); err != nil {
 		return err
 	}
+	if err := cmd.checkS3URLs(); err != nil {
+		return err
+	}
+	if err := cmd.parseEndpointAliases(); err != nil {
+		return err
+	}
 
 	val, _ := GetString(OptionConfigFile, cmd.options)
 	if err := cmd.loadConfig(val, cmder); err != nil {
@@ -88,9 +112,16 @@ func (cmd *Command) Init(args []string, options OptionMapType, cmder interface{}
 	if err := cmd.checkOptions(); err != nil {
 		return err
 	}
+	if err := cmd.checkOutputFormat(); err != nil {
+		return err
+	}
 
+	if val, _ := GetString(OptionAccessKeyID, cmd.options); val != "" {
+		cmd.dropProfileCredentials()
+	}
 	cmd.assembleOptions(cmder)
-	return nil
+	cmd.applyBucketProfiles()
+	return cmd.initRequestLimiter()
 }
 
 func (cmd *Command) checkArgs() error {
@@ -138,6 +169,9 @@ func (cmd *Command) needConfigFile() bool {
 
 func (cmd *Command) checkOptions() error {
 	for name := range cmd.options {
+		if FindPos(name, globalOptionNames) != -1 {
+			continue
+		}
 		msg := fmt.Sprintf("the command does not support option: \"%s\"", name)
 		switch OptionMap[name].optionType {
 		case OptionTypeFlagTrue:
@@ -218,12 +252,7 @@ func (cmd *Command) formatHelpForWhole() string {
 
 func (cmd *Command) getSpecText() SpecText {
 	val, _ := GetString(OptionLanguage, helpCommand.command.options)
-	switch strings.ToLower(val) {
-	case LEnglishLanguage:
-		return cmd.specEnglish
-	default:
-		return cmd.specChinese
-	}
+	return getSpecText(val, cmd.name)
 }
 
 func (cmd *Command) formatIndependHelp() string {
@@ -287,11 +316,60 @@ func (cmd *Command) formatOption(option Option) string {
 // OSS common function
 // get oss client according to bucket(if bucket not empty)
 func (cmd *Command) ossClient(bucket string) (*oss.Client, error) {
+	return cmd.aliasClient(bucket, cmd.aliasSchemes[bucket])
+}
+
+// aliasClient returns the client of the bucket, alias is the scheme selecting the internal or
+// accelerate endpoint, empty for the endpoint of the bucket
+func (cmd *Command) aliasClient(bucket, alias string) (*oss.Client, error) {
+	profileCmd := cmd.profileCredentials(bucket)
 	endpoint, isCname := cmd.getEndpoint(bucket)
+	cloudBoxID, _ := GetString(OptionCloudBoxID, cmd.options)
+	if alias == "" && !isCname && cloudBoxID == "" && cmd.profileAccelerate(bucket) {
+		alias = AccelerateSchemePrefix
+	}
+	if alias != "" {
+		if isCname || cloudBoxID != "" {
+			return nil, fmt.Errorf("%s doesn't work with the cname or the cloud box of bucket %s", alias, bucket)
+		}
+		endpoint, err := aliasEndpoint(endpoint, alias)
+		if err != nil {
+			return nil, err
+		}
+		return profileCmd.newOSSClient(endpoint, false)
+	}
+	if cloudBoxID != "" && !isCname && !isCloudBoxEndpoint(endpoint, cloudBoxID) {
+		dataEndpoint, err := cmd.cloudBoxDataEndpoint(endpoint, cloudBoxID)
+		if err != nil {
+			return nil, err
+		}
+		endpoint = dataEndpoint
+	}
+	return profileCmd.newOSSClient(endpoint, isCname)
+}
+
+// controlClient returns the client of --endpoint without resolving the data endpoint of the cloud box
+func (cmd *Command) controlClient() (*oss.Client, error) {
+	endpoint, _ := GetString(OptionEndpoint, cmd.options)
+	return cmd.newOSSClient(endpoint, false)
+}
+
+func (cmd *Command) newOSSClient(endpoint string, isCname bool) (*oss.Client, error) {
+	// the secret input by --password and the limiter of --max-qps belong to the command, they're never shared
+	if bPassword, _ := GetBool(OptionPassword, cmd.options); sharedClients == nil || bPassword || cmd.requestLimiter != nil {
+		return cmd.createOSSClient(endpoint, isCname)
+	}
+	return sharedClients.get(cmd.clientKey(endpoint, isCname), func() (*oss.Client, error) {
+		return cmd.createOSSClient(endpoint, isCname)
+	})
+}
+
+func (cmd *Command) createOSSClient(endpoint string, isCname bool) (*oss.Client, error) {
 	accessKeyID, _ := GetString(OptionAccessKeyID, cmd.options)
 	accessKeySecret, _ := GetString(OptionAccessKeySecret, cmd.options)
 	stsToken, _ := GetString(OptionSTSToken, cmd.options)
 	disableCRC64, _ := GetBool(OptionDisableCRC64, cmd.options)
+	contentMD5, _ := GetBool(OptionContentMD5, cmd.options)
 	proxyHost, _ := GetString(OptionProxyHost, cmd.options)
 	proxyUser, _ := GetString(OptionProxyUser, cmd.options)
 	proxyPwd, _ := GetString(OptionProxyPwd, cmd.options)
@@ -336,9 +414,14 @@ func (cmd *Command) ossClient(bucket string) (*oss.Client, error) {
 		options = append(options, oss.Region(region))
 	}
 
+	// the cloud box only supports v4 signature, and the cloud box id is signed as the region
+	if cloudBoxID != "" && signVersion == "" {
+		signVersion = string(oss.AuthV4)
+	}
+
 	if signVersion != "" {
 		if strings.EqualFold(signVersion, "v4") {
-			if region == "" {
+			if region == "" && cloudBoxID == "" {
 				return nil, fmt.Errorf("In the v4 signature scenario, please enter the region")
 			}
 		}
@@ -464,6 +547,11 @@ func (cmd *Command) ossClient(bucket string) (*oss.Client, error) {
 		options = append(options, oss.EnableCRC(true))
 	}
 
+	// the sdk computes the MD5 of every request body, the body larger than 16MB is buffered in the temp file
+	if contentMD5 {
+		options = append(options, oss.EnableMD5(true))
+	}
+
 	if proxyHost != "" {
 		if proxyUser != "" {
 			options = append(options, oss.AuthProxy(proxyHost, proxyUser, proxyPwd))
@@ -496,6 +584,12 @@ func (cmd *Command) ossClient(bucket string) (*oss.Client, error) {
 		options = append(options, oss.ForcePathStyle(true))
 	}
 
+	transportOptions, err := cmd.transportOptions()
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, transportOptions...)
+
 	client, err := oss.New(endpoint, accessKeyID, accessKeySecret, options...)
 	if err != nil {
 		return nil, err
@@ -602,8 +696,69 @@ func (cmd *Command) ossBucket(bucketName string) (*oss.Bucket, error) {
 	return bucket, nil
 }
 
+// cloudClient returns the client of the bucket of cloud url, the scheme of the url selects the provider
+func (cmd *Command) cloudClient(cloudURL CloudURL) (*oss.Client, error) {
+	if cloudURL.isS3() {
+		return cmd.s3Client(cloudURL.bucket)
+	}
+	if cloudURL.scheme != "" {
+		return cmd.aliasClient(cloudURL.bucket, cloudURL.scheme)
+	}
+	return cmd.ossClient(cloudURL.bucket)
+}
+
+func (cmd *Command) cloudBucket(cloudURL CloudURL) (*oss.Bucket, error) {
+	client, err := cmd.cloudClient(cloudURL)
+	if err != nil {
+		return nil, err
+	}
+	return client.Bucket(cloudURL.bucket)
+}
+
+// textOut returns the writer of the text output such as the progress, it's stderr when stdout has
+// the structured result or the downloaded data. The writer is passed instead of replacing os.Stdout,
+// which is shared by the goroutines and the commands running concurrently
+func (cmd *Command) textOut() io.Writer {
+	if cmd.stdout == nil {
+		return os.Stdout
+	}
+	return cmd.stdout
+}
+
+// context returns the context of the command passed to Init
+func (cmd *Command) context() context.Context {
+	if cmd.ctx == nil {
+		return context.Background()
+	}
+	return cmd.ctx
+}
+
+// withContext returns a copy of the options with the context of the command, so that the request
+// is aborted once the context is done
+func (cmd *Command) withContext(options []oss.Option) []oss.Option {
+	return append(append(make([]oss.Option, 0, len(options)+1), options...), oss.WithContext(cmd.context()))
+}
+
+// waitRetry waits d before the next retry, it returns the error of the context if the context is
+// done before or during the waiting
+func (cmd *Command) waitRetry(d time.Duration) error {
+	ctx := cmd.context()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (cmd *Command) ossListObjectsRetry(bucket *oss.Bucket, options ...oss.Option) (oss.ListObjectsResult, error) {
 	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
 	for i := 1; ; i++ {
 		lor, err := bucket.ListObjects(options...)
 		if err == nil {
@@ -617,18 +772,63 @@ func (cmd *Command) ossListObjectsRetry(bucket *oss.Bucket, options ...oss.Optio
 			return lor, ObjectError{err, bucket.BucketName, ""}
 		}
 
-		// wait 1 second
-		time.Sleep(time.Duration(1) * time.Second)
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return lor, err
+		}
+	}
+}
+
+func (cmd *Command) ossListObjectsV2Retry(bucket *oss.Bucket, options ...oss.Option) (oss.ListObjectsResultV2, error) {
+	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
+	for i := 1; ; i++ {
+		lor, err := bucket.ListObjectsV2(options...)
+		if err == nil {
+			return lor, err
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return lor, ObjectError{err, bucket.BucketName, ""}
+		}
+
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return lor, err
+		}
+	}
+}
+
+// getStartAfter returns the key after which the listing starts, --start-after is the raw key,
+// --marker is treated as start-after for compatibility and may be url encoded
+func (cmd *Command) getStartAfter() (string, error) {
+	startAfter, _ := GetString(OptionStartAfter, cmd.options)
+	vmarker, _ := GetString(OptionMarker, cmd.options)
+	if vmarker == "" {
+		return startAfter, nil
+	}
+	if startAfter != "" {
+		return "", fmt.Errorf("--start-after and --marker can't be both specified")
 	}
+	rawMarker, err := cmd.getRawMarker(vmarker)
+	if err != nil {
+		return "", fmt.Errorf("invalid marker: %s, marker is not url encoded, %s", vmarker, err.Error())
+	}
+	return rawMarker, nil
 }
 
 func (cmd *Command) ossListObjectVersionsRetry(bucket *oss.Bucket, options ...oss.Option) (oss.ListObjectVersionsResult, error) {
 	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
 	for i := 1; ; i++ {
 		lor, err := bucket.ListObjectVersions(options...)
 		if err == nil {
 			return lor, err
 		}
+		if cerr := cmd.context().Err(); cerr != nil {
+			return lor, cerr
+		}
 		if int64(i) >= retryTimes {
 			return lor, BucketError{err, bucket.BucketName}
 		}
@@ -637,6 +837,7 @@ func (cmd *Command) ossListObjectVersionsRetry(bucket *oss.Bucket, options ...os
 
 func (cmd *Command) ossListMultipartUploadsRetry(bucket *oss.Bucket, options ...oss.Option) (oss.ListMultipartUploadResult, error) {
 	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
 	for i := 1; ; i++ {
 		lmr, err := bucket.ListMultipartUploads(options...)
 		if err == nil {
@@ -650,13 +851,15 @@ func (cmd *Command) ossListMultipartUploadsRetry(bucket *oss.Bucket, options ...
 			return lmr, ObjectError{err, bucket.BucketName, ""}
 		}
 
-		// wait 1 second
-		time.Sleep(time.Duration(1) * time.Second)
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return lmr, err
+		}
 	}
 }
 
 func (cmd *Command) ossGetObjectStatRetry(bucket *oss.Bucket, object string, options ...oss.Option) (http.Header, error) {
 	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
 	for i := 1; ; i++ {
 		props, err := bucket.GetObjectDetailedMeta(object, options...)
 		if err == nil {
@@ -670,13 +873,59 @@ func (cmd *Command) ossGetObjectStatRetry(bucket *oss.Bucket, object string, opt
 			return props, ObjectError{err, bucket.BucketName, object}
 		}
 
-		// wait 1 second
-		time.Sleep(time.Duration(1) * time.Second)
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return props, err
+		}
+	}
+}
+
+func (cmd *Command) ossGetObjectACLRetry(bucket *oss.Bucket, object string, options ...oss.Option) (oss.GetObjectACLResult, error) {
+	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
+	for i := 1; ; i++ {
+		result, err := bucket.GetObjectACL(object, options...)
+		if err == nil {
+			return result, err
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return result, ObjectError{err, bucket.BucketName, object}
+		}
+
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return result, err
+		}
+	}
+}
+
+func (cmd *Command) ossGetObjectTaggingRetry(bucket *oss.Bucket, object string, options ...oss.Option) (oss.GetObjectTaggingResult, error) {
+	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
+	for i := 1; ; i++ {
+		result, err := bucket.GetObjectTagging(object, options...)
+		if err == nil {
+			return result, err
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return result, ObjectError{err, bucket.BucketName, object}
+		}
+
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return result, err
+		}
 	}
 }
 
 func (cmd *Command) ossGetObjectMetaRetry(bucket *oss.Bucket, object string, options ...oss.Option) (http.Header, error) {
 	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	options = cmd.withContext(options)
 	for i := 1; ; i++ {
 		props, err := bucket.GetObjectMeta(object, options...)
 		if err == nil {
@@ -690,8 +939,9 @@ func (cmd *Command) ossGetObjectMetaRetry(bucket *oss.Bucket, object string, opt
 			return props, ObjectError{err, bucket.BucketName, object}
 		}
 
-		// wait 1 second
-		time.Sleep(time.Duration(1) * time.Second)
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return props, err
+		}
 	}
 }
 
@@ -710,11 +960,12 @@ func (cmd *Command) objectStatistic(bucket *oss.Bucket, cloudURL CloudURL, monit
 			return
 		}
 
-		for _, object := range lor.Objects {
-			if doesSingleObjectMatchPatterns(object.Key, filters) {
-				monitor.updateScanNum(1)
-			}
+		objects, err := cmd.selectObjects(bucket, lor.Objects, filters, options...)
+		if err != nil {
+			monitor.setScanError(err)
+			return
 		}
+		monitor.updateScanNum(int64(len(objects)))
 
 		marker = oss.Marker(lor.NextMarker)
 		if !lor.IsTruncated {
@@ -725,6 +976,43 @@ func (cmd *Command) objectStatistic(bucket *oss.Bucket, cloudURL CloudURL, monit
 	monitor.setScanEnd()
 }
 
+// listObjectSizes lists the objects under the url which match the filters, and calls fn with the size of every object,
+// the listing stops when fn returns error
+func (cmd *Command) listObjectSizes(bucket *oss.Bucket, cloudURL CloudURL, filters []filterOptionType, fn func(size int64) error, options ...oss.Option) error {
+	return cmd.listObjects(bucket, cloudURL, filters, func(object oss.ObjectProperties) error {
+		return fn(object.Size)
+	}, options...)
+}
+
+// listObjects lists the objects under the url which match the filters, and calls fn with every object,
+// the listing stops when fn returns error
+func (cmd *Command) listObjects(bucket *oss.Bucket, cloudURL CloudURL, filters []filterOptionType, fn func(object oss.ObjectProperties) error, options ...oss.Option) error {
+	pre := oss.Prefix(cloudURL.object)
+	marker := oss.Marker("")
+	for {
+		listOptions := append(options, marker, pre)
+		lor, err := cmd.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			return err
+		}
+		objects, err := cmd.selectObjects(bucket, lor.Objects, filters, options...)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if err := fn(object); err != nil {
+				return err
+			}
+		}
+
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	return nil
+}
+
 func (cmd *Command) objectProducer(bucket *oss.Bucket, cloudURL CloudURL, chObjects chan<- string, chError chan<- error, filters []filterOptionType, options ...oss.Option) {
 	defer close(chObjects)
 	pre := oss.Prefix(cloudURL.object)
@@ -736,10 +1024,17 @@ func (cmd *Command) objectProducer(bucket *oss.Bucket, cloudURL CloudURL, chObje
 			chError <- err
 			return
 		}
-		for _, object := range lor.Objects {
-			if doesSingleObjectMatchPatterns(object.Key, filters) {
-				chObjects <- object.Key
+		objects, err := cmd.selectObjects(bucket, lor.Objects, filters, options...)
+		if err != nil {
+			chError <- err
+			return
+		}
+		for _, object := range objects {
+			if err := cmd.safetyCap.take(object.Size); err != nil {
+				chError <- err
+				return
 			}
+			chObjects <- object.Key
 		}
 
 		pre = oss.Prefix(lor.Prefix)
@@ -751,6 +1046,129 @@ func (cmd *Command) objectProducer(bucket *oss.Bucket, cloudURL CloudURL, chObje
 	chError <- nil
 }
 
+// listShardChars are the split points of "--list-split auto"
+const listShardChars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// listShard is a part of the keyspace under a prefix, it contains the keys which are greater
+// than marker and not greater than end, empty end means there is no upper bound
+type listShard struct {
+	marker string
+	end    string
+}
+
+// makeListShards splits the keyspace under prefix by the split points, splitPoints is "auto"
+// or the split points relative to prefix separated by comma. "auto" splits the keyspace by
+// the first character after prefix. The keys not greater than marker are excluded.
+func makeListShards(prefix, marker, splitPoints string) ([]listShard, error) {
+	points := []string{}
+	if splitPoints == ListSplitAuto {
+		for _, c := range listShardChars {
+			points = append(points, string(c))
+		}
+	} else {
+		for _, point := range strings.Split(splitPoints, ",") {
+			point = strings.TrimSpace(point)
+			if point == "" {
+				return nil, fmt.Errorf("invalid split points: %s, the split point can't be empty", splitPoints)
+			}
+			points = append(points, point)
+		}
+		sort.Strings(points)
+	}
+
+	shards := []listShard{}
+	lower := marker
+	for _, point := range points {
+		key := prefix + point
+		if key <= lower {
+			continue
+		}
+		shards = append(shards, listShard{lower, key})
+		lower = key
+	}
+	return append(shards, listShard{lower, ""}), nil
+}
+
+// ossListObjectsSharded lists the shards concurrently, fn is called with the objects of every
+// listed page, it may be called from different goroutines at the same time
+func (cmd *Command) ossListObjectsSharded(bucket *oss.Bucket, prefix string, shards []listShard,
+	fn func(objects []oss.ObjectProperties) error, options ...oss.Option) error {
+	routines := ListShardRoutines
+	if routines > len(shards) {
+		routines = len(shards)
+	}
+
+	chShards := make(chan listShard, len(shards))
+	for _, shard := range shards {
+		chShards <- shard
+	}
+	close(chShards)
+
+	chError := make(chan error, routines)
+	for i := 0; i < routines; i++ {
+		go func() {
+			for shard := range chShards {
+				if err := cmd.listObjectShard(bucket, prefix, shard, fn, options...); err != nil {
+					// drain the remaining shards so that other goroutines exit
+					for range chShards {
+					}
+					chError <- err
+					return
+				}
+			}
+			chError <- nil
+		}()
+	}
+
+	var ferr error
+	for i := 0; i < routines; i++ {
+		if err := <-chError; err != nil && ferr == nil {
+			ferr = err
+		}
+	}
+	return ferr
+}
+
+func (cmd *Command) listObjectShard(bucket *oss.Bucket, prefix string, shard listShard,
+	fn func(objects []oss.ObjectProperties) error, options ...oss.Option) error {
+	token := oss.ContinuationToken("")
+	for {
+		// options is shared by goroutines, don't append to it directly
+		listOptions := append([]oss.Option{oss.MaxKeys(1000)}, options...)
+		listOptions = append(listOptions, oss.Prefix(prefix), token)
+		if shard.marker != "" {
+			listOptions = append(listOptions, oss.StartAfter(shard.marker))
+		}
+		lor, err := cmd.ossListObjectsV2Retry(bucket, listOptions...)
+		if err != nil {
+			return err
+		}
+
+		objects := lor.Objects
+		finished := !lor.IsTruncated
+		if shard.end != "" {
+			for i, object := range objects {
+				if object.Key > shard.end {
+					objects = objects[:i]
+					finished = true
+					break
+				}
+			}
+		}
+
+		if len(objects) > 0 {
+			if err := fn(objects); err != nil {
+				return err
+			}
+		}
+
+		if finished {
+			return nil
+		}
+		token = oss.ContinuationToken(lor.NextContinuationToken)
+	}
+}
+
 func (cmd *Command) getRawMarker(str string) (string, error) {
 	encodingType, _ := GetString(OptionEncodingType, cmd.options)
 	if encodingType == URLEncodingType {
@@ -896,5 +1314,34 @@ func GetAllCommands() []interface{} {
 		&lcbCommand,
 		&bucketAccessMonitorCommand,
 		&bucketResourceGroupCommand,
+		&auditIntegrityCommand,
+		&exportConfigCommand,
+		&fetchCommand,
+		&regionsCommand,
+		&benchCommand,
+		&processCommand,
+		&processAsyncCommand,
+		&processStatusCommand,
+		&liveCommand,
+		&trashCommand,
+		&retentionReportCommand,
+		&findCommand,
+		&doctorCommand,
+		&genDocsCommand,
+		&rpcCommand,
+		&daemonCommand,
+		&convertAppendCommand,
+		&mirrorVerifyCommand,
+		&packCommand,
+		&unpackCommand,
+		&packGetCommand,
+		&hashDBCommand,
+		&indexCommand,
+		&copyMetaCommand,
+		&prefetchCommand,
+		&checksumCommand,
+		&restoreCampaignCommand,
+		&notifyTestCommand,
+		&policyCommand,
 	}
 }
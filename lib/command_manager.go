@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"reflect"
@@ -54,19 +55,41 @@ func ParseAndRunCommand() error {
 
 	defer LogEnd(startT)
 
-	showElapse, err := RunCommand(args, options)
+	ctx := context.Background()
+	if stopsGracefully(args) {
+		var stop func()
+		ctx, stop = interruptContext()
+		defer stop()
+	}
+	showElapse, err := RunCommandContext(ctx, args, options)
 	if err != nil {
 		LogError("%s.\n", err.Error())
 		return err
 	}
 	if showElapse {
+		// stdout only has the result of json or yaml
+		out := os.Stdout
+		if output, _ := getOutputFormat(options); output.structured() {
+			out = os.Stderr
+		}
 		te := time.Now().UnixNano()
-		fmt.Printf("\n%.6f(s) elapsed\n", float64(te-ts)/1e9)
+		fmt.Fprintf(out, "\n%.6f(s) elapsed\n", float64(te-ts)/1e9)
 		return nil
 	}
 	return nil
 }
 
+// stopsGracefully reports whether the command of args stops gracefully on the interrupt signal
+func stopsGracefully(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cm := CommandManager{}
+	cm.Init()
+	_, ok := cm.commandMap[args[0]].(gracefulCommander)
+	return ok
+}
+
 func getCommandLine() string {
 	return strings.Join(os.Args, " ")
 }
@@ -79,6 +102,11 @@ func clearEnv() {
 }
 
 func RunCommand(args []string, options OptionMapType) (bool, error) {
+	return RunCommandContext(context.Background(), args, options)
+}
+
+// RunCommandContext runs the command like RunCommand, the command is canceled once ctx is done
+func RunCommandContext(ctx context.Context, args []string, options OptionMapType) (bool, error) {
 	if len(args) == 0 {
 		if val, _ := GetBool(OptionVersion, options); val {
 			fmt.Printf("ossutil version: %s\n", Version)
@@ -91,7 +119,7 @@ func RunCommand(args []string, options OptionMapType) (bool, error) {
 
 	cm := CommandManager{}
 	cm.Init()
-	showElapse, err := cm.RunCommand(command, args, options)
+	showElapse, err := cm.RunCommandContext(ctx, command, args, options)
 	return showElapse, err
 }
 
@@ -113,9 +141,13 @@ func (cm *CommandManager) Init() {
 
 // RunCommand select command from command map, initialize command and run command
 func (cm *CommandManager) RunCommand(commandName string, args []string, options OptionMapType) (bool, error) {
+	return cm.RunCommandContext(context.Background(), commandName, args, options)
+}
 
+// RunCommandContext runs the command like RunCommand, the command is canceled once ctx is done
+func (cm *CommandManager) RunCommandContext(ctx context.Context, commandName string, args []string, options OptionMapType) (bool, error) {
 	if cmd, ok := cm.commandMap[commandName]; ok {
-		if err := cmd.(Commander).Init(args, options); err != nil {
+		if err := cmd.(Commander).Init(ctx, args, options); err != nil {
 			return false, err
 		}
 		if err := cmd.(Commander).RunCommand(); err != nil {
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -625,7 +626,7 @@ func (s *OssutilCommandSuite) initRemove(bucket string, object string, cmdline s
 		"recursive":       &r,
 		"force":           &f,
 	}
-	err := removeCommand.Init(args, options)
+	err := removeCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -801,7 +802,7 @@ func (s *OssutilCommandSuite) initPutBucketWithStorageClass(args []string, stora
 		"configFile":      &configFile,
 		"storageClass":    &storageClass,
 	}
-	err := makeBucketCommand.Init(args, options)
+	err := makeBucketCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1028,7 +1029,7 @@ func (s *OssutilCommandSuite) initCopyCommand(srcURL, destURL string, recursive,
 		"partSize":         &partSize,
 		"outputDir":        &outputDir,
 	}
-	err := copyCommand.Init(args, options)
+	err := copyCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1054,7 +1055,7 @@ func (s *OssutilCommandSuite) initCopyWithSnapshot(srcURL, destURL string, recur
 		"partSize":         &partSize,
 		"snapshotPath":     &snapshotPath,
 	}
-	err := copyCommand.Init(args, options)
+	err := copyCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1080,7 +1081,7 @@ func (s *OssutilCommandSuite) initCopyWithRange(srcURL, destURL string, recursiv
 		"partSize":         &partSize,
 		"range":            &vrange,
 	}
-	err := copyCommand.Init(args, options)
+	err := copyCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1238,7 +1239,7 @@ func (s *OssutilCommandSuite) initSetACL(bucket, object, acl string, recursive,
 		"bucket":          &tobucket,
 		"force":           &force,
 	}
-	err := setACLCommand.Init(args, options)
+	err := setACLCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1274,7 +1275,7 @@ func (s *OssutilCommandSuite) initSetACLWithArgs(args []string, cmdline string,
 		"routines":        &routines,
 		"outputDir":       &outputDir,
 	}
-	err := setACLCommand.Init(args, options)
+	err := setACLCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1444,7 +1445,7 @@ func (s *OssutilCommandSuite) initSetMeta(bucket, object, meta string, update, d
 		"routines":        &routines,
 		"language":        &language,
 	}
-	err := setMetaCommand.Init(args, options)
+	err := setMetaCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1503,7 +1504,7 @@ func (s *OssutilCommandSuite) initSetMetaWithArgs(args []string, cmdline string,
 		"snapshotPath":       &snapshotPath,
 		"disableIgnoreError": &disableIgnoreError,
 	}
-	err := setMetaCommand.Init(args, options)
+	err := setMetaCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1532,7 +1533,7 @@ func (s *OssutilCommandSuite) initCreateSymlink(cmdline string) error {
 		"configFile":      &configFile,
 		"encodingType":    &encodingType,
 	}
-	err := createSymlinkCommand.Init(args, options)
+	err := createSymlinkCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1577,7 +1578,7 @@ func (s *OssutilCommandSuite) initReadSymlink(cmdline string) error {
 		"configFile":      &configFile,
 		"encodingType":    &encodingType,
 	}
-	err := readSymlinkCommand.Init(args, options)
+	err := readSymlinkCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1602,7 +1603,7 @@ func (s *OssutilCommandSuite) initSignURL(cmdline, encodingType string, timeout
 		"encodingType":    &encodingType,
 		"timeout":         &t,
 	}
-	err := signURLCommand.Init(args, options)
+	err := signURLCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1716,7 +1717,7 @@ func (s *OssutilCommandSuite) initRestoreObject(args []string, cmdline string, o
 		"snapshotPath":       &snapshotPath,
 		"disableIgnoreError": &disableIgnoreError,
 	}
-	err := restoreCommand.Init(args, options)
+	err := restoreCommand.Init(context.Background(), args, options)
 	return err
 }
 
@@ -1916,7 +1917,7 @@ func (s *OssutilCommandSuite) TestErrOssDownloadFile(c *C) {
 		"routines":         &routines,
 		"partSize":         &partSize,
 	}
-	err := copyCommand.Init(args, options)
+	err := copyCommand.Init(context.Background(), args, options)
 	c.Assert(err, IsNil)
 	bucket, err := copyCommand.command.ossBucket(bucketName)
 	c.Assert(err, IsNil)
@@ -1983,7 +1984,7 @@ func (s *OssutilCommandSuite) TestNeedConfig(c *C) {
 		"routines":         &routines,
 		"partSize":         &partSize,
 	}
-	err := copyCommand.Init(args, options)
+	err := copyCommand.Init(context.Background(), args, options)
 	c.Assert(err, IsNil)
 
 	c.Assert(copyCommand.command.needConfigFile(), Equals, false)
@@ -2933,3 +2934,28 @@ func (s *OssutilCommandSuite) TestCommandObjectProducer(c *C) {
 		c.Assert(true, Equals, false)
 	}
 }
+
+func (s *OssutilCommandSuite) TestMakeListShards(c *C) {
+	shards, err := makeListShards("dir/", "", "n,g, t,g")
+	c.Assert(err, IsNil)
+	c.Assert(shards, DeepEquals, []listShard{
+		{"", "dir/g"},
+		{"dir/g", "dir/n"},
+		{"dir/n", "dir/t"},
+		{"dir/t", ""},
+	})
+
+	// the split points not greater than marker are skipped
+	shards, err = makeListShards("dir/", "dir/m", "g,n")
+	c.Assert(err, IsNil)
+	c.Assert(shards, DeepEquals, []listShard{{"dir/m", "dir/n"}, {"dir/n", ""}})
+
+	shards, err = makeListShards("", "", ListSplitAuto)
+	c.Assert(err, IsNil)
+	c.Assert(len(shards), Equals, len(listShardChars)+1)
+	c.Assert(shards[0], Equals, listShard{"", "0"})
+	c.Assert(shards[len(shards)-1], Equals, listShard{"z", ""})
+
+	_, err = makeListShards("", "", "a,,b")
+	c.Assert(err, NotNil)
+}
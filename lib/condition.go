@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// objectConditionType holds the conditions of --if-match, --if-none-match and --if-unmodified-since,
+// the object is operated only when all the conditions are met
+type objectConditionType struct {
+	ifMatch           string
+	ifNoneMatch       string
+	ifUnmodifiedSince time.Time
+}
+
+// getObjectCondition parses the condition options, the time of --if-unmodified-since can be
+// http date, RFC3339 or unix timestamp
+func (cmd *Command) getObjectCondition() (objectConditionType, error) {
+	var cond objectConditionType
+	cond.ifMatch, _ = GetString(OptionIfMatch, cmd.options)
+	cond.ifNoneMatch, _ = GetString(OptionIfNoneMatch, cmd.options)
+	strTime, _ := GetString(OptionIfUnmodifiedSince, cmd.options)
+	if strTime == "" {
+		return cond, nil
+	}
+
+	if t, err := time.Parse(http.TimeFormat, strTime); err == nil {
+		cond.ifUnmodifiedSince = t
+	} else if t, err := time.Parse(time.RFC3339, strTime); err == nil {
+		cond.ifUnmodifiedSince = t
+	} else if sec, err := strconv.ParseInt(strTime, 10, 64); err == nil && sec > 0 {
+		cond.ifUnmodifiedSince = time.Unix(sec, 0)
+	} else {
+		return cond, fmt.Errorf("invalid --if-unmodified-since: %s, the time should be http date, RFC3339 or unix timestamp", strTime)
+	}
+	return cond, nil
+}
+
+func (cond objectConditionType) isEmpty() bool {
+	return cond.ifMatch == "" && cond.ifNoneMatch == "" && cond.ifUnmodifiedSince.IsZero()
+}
+
+func trimETag(etag string) string {
+	return strings.ToUpper(strings.Trim(etag, "\""))
+}
+
+// check checks the etag and last modified time of the object with the conditions
+func (cond objectConditionType) check(etag string, lastModified time.Time) string {
+	if cond.ifMatch != "" && cond.ifMatch != "*" && trimETag(cond.ifMatch) != trimETag(etag) {
+		return fmt.Sprintf("etag %s doesn't match %s", etag, cond.ifMatch)
+	}
+	if cond.ifNoneMatch != "" && (cond.ifNoneMatch == "*" || trimETag(cond.ifNoneMatch) == trimETag(etag)) {
+		return fmt.Sprintf("etag %s matches %s", etag, cond.ifNoneMatch)
+	}
+	if !cond.ifUnmodifiedSince.IsZero() && lastModified.After(cond.ifUnmodifiedSince) {
+		return fmt.Sprintf("the object is modified at %s, after %s", lastModified.UTC().Format(http.TimeFormat),
+			cond.ifUnmodifiedSince.UTC().Format(http.TimeFormat))
+	}
+	return ""
+}
+
+// checkObjectCondition gets the meta of the object and checks it with the conditions
+func (cmd *Command) checkObjectCondition(bucket *oss.Bucket, object string, cond objectConditionType, options ...oss.Option) error {
+	if cond.isEmpty() {
+		return nil
+	}
+	props, err := cmd.ossGetObjectStatRetry(bucket, object, options...)
+	if err != nil {
+		return err
+	}
+	lastModified, err := time.Parse(http.TimeFormat, props.Get(oss.HTTPHeaderLastModified))
+	if err != nil {
+		return err
+	}
+	if reason := cond.check(props.Get(oss.HTTPHeaderEtag), lastModified); reason != "" {
+		return PreconditionError{bucket.BucketName, object, reason}
+	}
+	return nil
+}
+
+// getOptions returns the conditional headers sent with GetObject, so that the object
+// changed after checking is not downloaded. If-None-Match is only checked before
+// downloading, because oss returns 304 without error body for it.
+func (cond objectConditionType) getOptions() []oss.Option {
+	options := []oss.Option{}
+	if cond.ifMatch != "" && cond.ifMatch != "*" {
+		options = append(options, oss.IfMatch(cond.ifMatch))
+	}
+	if !cond.ifUnmodifiedSince.IsZero() {
+		options = append(options, oss.IfUnmodifiedSince(cond.ifUnmodifiedSince))
+	}
+	return options
+}
+
+// copySourceOptions returns the conditional headers sent with CopyObject
+func (cond objectConditionType) copySourceOptions() []oss.Option {
+	options := []oss.Option{}
+	if cond.ifMatch != "" && cond.ifMatch != "*" {
+		options = append(options, oss.CopySourceIfMatch(cond.ifMatch))
+	}
+	if cond.ifNoneMatch != "" {
+		options = append(options, oss.CopySourceIfNoneMatch(cond.ifNoneMatch))
+	}
+	if !cond.ifUnmodifiedSince.IsZero() {
+		options = append(options, oss.CopySourceIfUnmodifiedSince(cond.ifUnmodifiedSince))
+	}
+	return options
+}
+
+// isNotModified returns true if the request is refused by If-None-Match, oss returns 304 without
+// error body for it, and the sdk returns a plain error instead of oss.ServiceError
+func isNotModified(err error) bool {
+	return err != nil && strings.Contains(err.Error(), fmt.Sprintf("service returned %d,", http.StatusNotModified))
+}
+
+// isPreconditionFailed returns true if the error is caused by the conditional headers
+func isPreconditionFailed(err error) bool {
+	var serviceError oss.ServiceError
+	return isNotModified(err) || (errors.As(err, &serviceError) && serviceError.StatusCode == http.StatusPreconditionFailed)
+}
@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestGetObjectCondition(c *C) {
+	t := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, value := range []string{t.Format(http.TimeFormat), t.Format(time.RFC3339), strconv.FormatInt(t.Unix(), 10)} {
+		ifMatch := "\"abc\""
+		ifUnmodifiedSince := value
+		cmd := Command{options: OptionMapType{
+			OptionIfMatch:           &ifMatch,
+			OptionIfUnmodifiedSince: &ifUnmodifiedSince,
+		}}
+		cond, err := cmd.getObjectCondition()
+		c.Assert(err, IsNil)
+		c.Assert(cond.isEmpty(), Equals, false)
+		c.Assert(cond.ifMatch, Equals, "\"abc\"")
+		c.Assert(cond.ifUnmodifiedSince.Equal(t), Equals, true)
+		c.Assert(len(cond.getOptions()), Equals, 2)
+		c.Assert(len(cond.copySourceOptions()), Equals, 2)
+	}
+
+	// "*" of --if-none-match is sent with CopyObject
+	cond := objectConditionType{ifNoneMatch: "*"}
+	c.Assert(len(cond.copySourceOptions()), Equals, 1)
+
+	invalid := "yesterday"
+	cmd := Command{options: OptionMapType{OptionIfUnmodifiedSince: &invalid}}
+	_, err := cmd.getObjectCondition()
+	c.Assert(err, NotNil)
+
+	cmd = Command{options: OptionMapType{}}
+	cond, err = cmd.getObjectCondition()
+	c.Assert(err, IsNil)
+	c.Assert(cond.isEmpty(), Equals, true)
+	c.Assert(len(cond.getOptions()), Equals, 0)
+}
+
+func (s *OssutilCommandSuite) TestObjectConditionCheck(c *C) {
+	lastModified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	etag := "\"5B3C1A2E053D763E1B002CC607C5A0FE\""
+
+	c.Assert(objectConditionType{ifMatch: "5b3c1a2e053d763e1b002cc607c5a0fe"}.check(etag, lastModified), Equals, "")
+	c.Assert(objectConditionType{ifMatch: "*"}.check(etag, lastModified), Equals, "")
+	c.Assert(objectConditionType{ifMatch: "abc"}.check(etag, lastModified), Not(Equals), "")
+
+	c.Assert(objectConditionType{ifNoneMatch: "abc"}.check(etag, lastModified), Equals, "")
+	c.Assert(objectConditionType{ifNoneMatch: etag}.check(etag, lastModified), Not(Equals), "")
+	c.Assert(objectConditionType{ifNoneMatch: "*"}.check(etag, lastModified), Not(Equals), "")
+
+	c.Assert(objectConditionType{ifUnmodifiedSince: lastModified}.check(etag, lastModified), Equals, "")
+	c.Assert(objectConditionType{ifUnmodifiedSince: lastModified.Add(-time.Second)}.check(etag, lastModified), Not(Equals), "")
+}
+
+func (s *OssutilCommandSuite) TestConditionalObjectOperations(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+	object := "condition-object"
+	s.putObject(bucketName, object, uploadFileName, c)
+	etag := s.getStat(bucketName, object, c)["Etag"]
+
+	str := ""
+	wrongETag := "00000000000000000000000000000000"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"ifMatch":         &wrongETag,
+	}
+
+	// download
+	fileName := "ossutil-test-condition-" + randLowStr(5)
+	_, err := cm.RunCommand("cp", []string{CloudURLToString(bucketName, object), fileName}, options)
+	c.Assert(err, NotNil)
+	_, err = os.Stat(fileName)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	options["ifMatch"] = &etag
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, object), fileName}, options)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(fileName)
+	c.Assert(err, IsNil)
+	os.Remove(fileName)
+
+	// upload doesn't support the conditions
+	_, err = cm.RunCommand("cp", []string{uploadFileName, CloudURLToString(bucketName, "upload")}, options)
+	c.Assert(err, NotNil)
+
+	// set-meta
+	update := true
+	force := true
+	setMetaOptions := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"update":          &update,
+		"force":           &force,
+		"ifMatch":         &wrongETag,
+	}
+	_, err = cm.RunCommand("set-meta", []string{CloudURLToString(bucketName, object), "X-Oss-Meta-A:b"}, setMetaOptions)
+	c.Assert(err, NotNil)
+	setMetaOptions["ifMatch"] = &etag
+	_, err = cm.RunCommand("set-meta", []string{CloudURLToString(bucketName, object), "X-Oss-Meta-A:b"}, setMetaOptions)
+	c.Assert(err, IsNil)
+	c.Assert(s.getStat(bucketName, object, c)["X-Oss-Meta-A"], Equals, "b")
+
+	// rm
+	past := time.Now().Add(-24 * time.Hour).UTC().Format(time.RFC3339)
+	rmOptions := OptionMapType{
+		"endpoint":          &str,
+		"accessKeyID":       &str,
+		"accessKeySecret":   &str,
+		"configFile":        &configFile,
+		"ifUnmodifiedSince": &past,
+	}
+	_, err = cm.RunCommand("rm", []string{CloudURLToString(bucketName, object)}, rmOptions)
+	c.Assert(err, NotNil)
+	c.Assert(s.getStat(bucketName, object, c)["X-Oss-Meta-A"], Equals, "b")
+
+	recursive := true
+	rmOptions["recursive"] = &recursive
+	_, err = cm.RunCommand("rm", []string{CloudURLToString(bucketName, "")}, rmOptions)
+	c.Assert(err, NotNil)
+
+	future := strconv.FormatInt(time.Now().Add(24*time.Hour).Unix(), 10)
+	delete(rmOptions, "recursive")
+	rmOptions["ifUnmodifiedSince"] = &future
+	_, err = cm.RunCommand("rm", []string{CloudURLToString(bucketName, object)}, rmOptions)
+	c.Assert(err, IsNil)
+
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestSetMetaNotModified(c *C) {
+	copyNum := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			copyNum++
+			c.Assert(r.Header.Get("X-Oss-Copy-Source-If-None-Match"), Equals, "*")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if _, ok := r.URL.Query()["acl"]; ok {
+			fmt.Fprint(w, `<AccessControlPolicy><AccessControlList><Grant>default</Grant></AccessControlList></AccessControlPolicy>`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("ETag", "\"etag\"")
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+	}))
+	defer server.Close()
+
+	str := "ak"
+	forcePathStyle := true
+	update := true
+	ifNoneMatch := "*"
+	retryTimes := "3"
+	_, err := cm.RunCommand("set-meta", []string{"oss://bucket/object", "X-Oss-Meta-A:b"}, OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionUpdate:          &update,
+		OptionIfNoneMatch:     &ifNoneMatch,
+		OptionRetryTimes:      &retryTimes,
+	})
+	c.Assert(err, NotNil)
+	c.Assert(ExitCode(err), Equals, ExitCodePreconditionFailed, Commentf("%s", err))
+	c.Assert(copyNum, Equals, 1)
+	c.Assert(isPreconditionFailed(fmt.Errorf("oss: service returned 304,304 Not Modified")), Equals, true)
+}
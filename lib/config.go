@@ -2,10 +2,14 @@ package lib
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	configparser "github.com/alyu/configparser"
 )
@@ -27,7 +31,9 @@ var specChineseConfig = SpecText{
 
     配置文件路径可由用户指定，默认为` + DecideConfigFile("") + `。如果配置
     文件存在，假设其为:a，ossutil会将文件a另存为：a.bak，然后重新创建文件a
-    并写入配置，此时，如果a.bak存在，其会被文件a覆盖。
+    并写入配置，此时，如果a.bak存在，其会被文件a覆盖。新的配置先写入临时文件a.tmp，再原子地重命名为a，
+    并在写入期间持有文件锁a.lock，多个同时执行的命令（如共享home目录的并行CI任务）读取到的配置文件
+    总是完整的。如果a是符号链接，ossutil写入其指向的文件。
 
     注意：
     （1）如果指定的配置文件路径非默认路径，在使用命令时请将--config-file选
@@ -110,6 +116,25 @@ var specChineseConfig = SpecText{
         选项，--endpoint选项为最高优先级。
         
         优先级：--endpoint > Bucket-Cname > Bucket-Endpoint > endpoint > 默认endpoint
+        (8) Bucket-Profile
+            Bucket-Profile为每个指定的bucket配置命令的默认行为，每个bucket一个section，
+        名称为Bucket-Profile:bucket，团队可以在配置文件中一次性约定bucket的使用规范，不
+        需要在每个脚本中指定。支持的配置项：
+            payer           等同于--payer，如：payer = requester
+            output          等同于--output，如：output = json
+            meta            等同于--meta，如：meta = Cache-Control:no-cache#X-Oss-Meta-Team:web
+            acl             等同于--acl
+            tagging         等同于--tagging
+            storageClass    上传或者拷贝的objects的默认存储类型，如：storageClass = IA
+            accelerate      为true时通过传输加速endpoint访问该bucket，等同于使用oss-acc://
+            accessKeyID、accessKeySecret、stsToken
+                            访问该bucket的凭证，用于访问其他账号的bucket，如cp --fanout上传到
+                            多个账号。命令行指定了--access-key-id时不使用
+            命令参数中的bucket的配置会自动应用于支持相应选项的命令，命令行中指定的选项优先。
+        meta、acl、tagging和storageClass只应用于目标bucket（最后一个参数的bucket），
+        不影响写到其他bucket或者本地的数据；meta和storageClass与--meta按header合并，
+        appendfromfile只在创建object时应用它们，追加到已存在的object时忽略。
+        参数中有多个bucket时，前面的bucket的配置优先。
 
     2) ossutil config options
         如果用户使用命令时输入了除--language和--config-file之外的任何选项，则
@@ -133,6 +158,11 @@ var specChineseConfig = SpecText{
         bucket1 = cname1
         bucket2 = cname2
         ...
+    [Bucket-Profile:bucket1]
+        payer = requester
+        storageClass = IA
+        meta = Cache-Control:no-cache
+        accelerate = true
     [Default]
         userAgent = user_agent
         proxyHost = proxy_host
@@ -141,6 +171,10 @@ var specChineseConfig = SpecText{
         readTimeOut = read_time_out
         connectTimeOut = connect_time_out
         retryTimes = retry_times
+        maxIdleConns = max_idle_conns
+        maxConnsPerHost = max_conns_per_host
+        idleConnTimeout = idle_conn_timeout
+        maxQPS = max_qps
 `,
 
 	sampleText: ` 
@@ -168,7 +202,12 @@ var specEnglishConfig = SpecText{
     The configuration file can be specified by user, which in default
     is ` + DecideConfigFile("") + `. If the configuration file exist, suppose
     the file is: a, ossutil will save a as a.bak, and rewrite file a, 
-    at this time, if file a.bak exists, a.bak will be rewrited.
+    at this time, if file a.bak exists, a.bak will be rewrited. The new 
+    configuration is written to the temp file a.tmp first and renamed to a 
+    atomically, under the lock file a.lock, so the commands running at the 
+    same time(e.g., the parallel CI jobs sharing the home directory) always 
+    read the complete configuration file. If a is a symbolic link, ossutil 
+    writes the file it points to.
 
     Note:
     (1) If the configuration file path you specified is not the default 
@@ -260,6 +299,30 @@ Usage:
         --endpoint option is specified, --endpoint option has the highest priority.
 
         PRI: --endpoint option > Bucket-Cname > Bucket-Endpoint > endpoint > default endpoint
+        (8) Bucket-Profile
+            Bucket-Profile specifies the default behavior of the commands for every 
+        individual bucket, one section named Bucket-Profile:bucket per bucket, so the 
+        team encodes the conventions of the bucket once in the config file instead of 
+        in every script. The items supported:
+            payer           the same as --payer, e.g., payer = requester
+            output          the same as --output, e.g., output = json
+            meta            the same as --meta, e.g., meta = Cache-Control:no-cache#X-Oss-Meta-Team:web
+            acl             the same as --acl
+            tagging         the same as --tagging
+            storageClass    the default storage class of the objects uploaded or copied, e.g., storageClass = IA
+            accelerate      if true, access the bucket by the accelerate endpoint, the same as oss-acc://
+            accessKeyID, accessKeySecret, stsToken
+                            the credentials of the bucket, to access the buckets of other 
+                            accounts, e.g. cp --fanout to several accounts. They aren't used 
+                            if --access-key-id is specified in the command line
+            The profiles of the buckets in the arguments are applied to the commands 
+        supporting the options automatically, the options of the command line take 
+        precedence. meta, acl, tagging and storageClass are only applied from the 
+        destination bucket(the bucket of the last argument), they don't affect the data 
+        written to other buckets or local files, meta and storageClass are merged with 
+        --meta by header, appendfromfile only applies them when it creates the object, 
+        they're ignored when appending to the existing object. If there are several 
+        buckets in the arguments, the profile of the former bucket takes precedence.
 
     2) ossutil config options
         If any options except --language and --config-file is specified, the 
@@ -285,6 +348,11 @@ Credential File Format:
         bucket1 = cname1
         bucket2 = cname2
         ...
+    [Bucket-Profile:bucket1]
+        payer = requester
+        storageClass = IA
+        meta = Cache-Control:no-cache
+        accelerate = true
     [Default]
         userAgent = user_agent
         proxyHost = proxy_host
@@ -293,6 +361,10 @@ Credential File Format:
         readTimeOut = read_time_out
         connectTimeOut = connect_time_out
         retryTimes = retry_times
+        maxIdleConns = max_idle_conns
+        maxConnsPerHost = max_conns_per_host
+        idleConnTimeout = idle_conn_timeout
+        maxQPS = max_qps
 `,
 
 	sampleText: ` 
@@ -308,13 +380,11 @@ type ConfigCommand struct {
 
 var configCommand = ConfigCommand{
 	command: Command{
-		name:        "config",
-		nameAlias:   []string{"cfg", "config"},
-		minArgc:     0,
-		maxArgc:     0,
-		specChinese: specChineseConfig,
-		specEnglish: specEnglishConfig,
-		group:       GroupTypeAdditionalCommand,
+		name:      "config",
+		nameAlias: []string{"cfg", "config"},
+		minArgc:   0,
+		maxArgc:   0,
+		group:     GroupTypeAdditionalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -364,8 +434,8 @@ func (cc *ConfigCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (cc *ConfigCommand) Init(args []string, options OptionMapType) error {
-	return cc.command.Init(args, options, cc)
+func (cc *ConfigCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return cc.command.Init(ctx, args, options, cc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -489,7 +559,7 @@ func (cc *ConfigCommand) configInteractive(configFile, language string) error {
 		}
 	}
 
-	if err := configparser.Save(config, configFile); err != nil {
+	if err := saveConfig(config, configFile); err != nil {
 		return err
 	}
 	return nil
@@ -505,7 +575,44 @@ func (cc *ConfigCommand) runCommandNonInteractive(configFile, language string) e
 			section.Add(name, val)
 		}
 	}
-	if err := configparser.Save(config, configFile); err != nil {
+	if err := saveConfig(config, configFile); err != nil {
+		return err
+	}
+	return nil
+}
+
+// saveConfig writes the config file under the lock, so that the concurrent config commands
+// don't corrupt the file. The config is written to the temp file and renamed to the config file,
+// the commands reading the config file at the same time never see the truncated file
+func saveConfig(config *configparser.Configuration, configFile string) error {
+	if realPath, err := filepath.EvalSymlinks(configFile); err == nil {
+		configFile = realPath
+	}
+	lock, err := lockFile(configFile+ConfigLockSuffix, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	tmpFile := configFile + ConfigTempSuffix
+	os.Remove(tmpFile)
+	if err := configparser.Save(config, tmpFile); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+
+	// keep the mode of the config file, and back it up before it's replaced
+	if data, err := ioutil.ReadFile(configFile); err == nil {
+		if f, err := os.Stat(configFile); err == nil {
+			os.Chmod(tmpFile, f.Mode().Perm())
+		}
+		if err := ioutil.WriteFile(configFile+ConfigBackupSuffix, data, 0600); err != nil {
+			os.Remove(tmpFile)
+			return err
+		}
+	}
+	if err := os.Rename(tmpFile, configFile); err != nil {
+		os.Remove(tmpFile)
 		return err
 	}
 	return nil
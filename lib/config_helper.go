@@ -20,6 +20,9 @@ const (
 	AkServiceSection string = "AkService"
 
 	DefaultSection string = "Default"
+
+	// BucketProfileSection is the prefix of the sections of the bucket profiles, e.g., [Bucket-Profile:bucket1]
+	BucketProfileSection string = "Bucket-Profile:"
 )
 
 // config items in section AKSerivce
@@ -72,14 +75,18 @@ var CredOptionMap = map[string]configOption{
 // DefaultOptionMap allows alias name for options in default section
 // name, allow to show in screen
 var DefaultOptionMap = map[string]configOption{
-	OptionUserAgent:      configOption{[]string{"userAgent", "useragent", "user-agent", "user_agent"}, false, false, "", ""},
-	OptionLogLevel:       configOption{[]string{"loglevel", "log-level", "log_level"}, false, false, "", ""},
-	OptionProxyHost:      configOption{[]string{"proxyHost", "proxyhost", "proxy-host", "proxy_host"}, false, false, "", ""},
-	OptionProxyUser:      configOption{[]string{"proxyUser", "proxyuser", "proxy-user", "proxy_user"}, false, false, "", ""},
-	OptionProxyPwd:       configOption{[]string{"proxyPwd", "proxypwd", "proxy-pwd", "proxy_pwd"}, false, false, "", ""},
-	OptionReadTimeout:    configOption{[]string{"readTimeOut", "readtimeout", "read-timeout", "read_timeout"}, false, false, "", ""},
-	OptionConnectTimeout: configOption{[]string{"connectTimeOut", "connectTimeout", "connecttimeout", "connect-timeout", "connect_timeout"}, false, false, "", ""},
-	OptionRetryTimes:     configOption{[]string{"retryTimes", "retrytimes", "retry-times", "retry_times"}, false, false, "", ""},
+	OptionUserAgent:       configOption{[]string{"userAgent", "useragent", "user-agent", "user_agent"}, false, false, "", ""},
+	OptionLogLevel:        configOption{[]string{"loglevel", "log-level", "log_level"}, false, false, "", ""},
+	OptionProxyHost:       configOption{[]string{"proxyHost", "proxyhost", "proxy-host", "proxy_host"}, false, false, "", ""},
+	OptionProxyUser:       configOption{[]string{"proxyUser", "proxyuser", "proxy-user", "proxy_user"}, false, false, "", ""},
+	OptionProxyPwd:        configOption{[]string{"proxyPwd", "proxypwd", "proxy-pwd", "proxy_pwd"}, false, false, "", ""},
+	OptionReadTimeout:     configOption{[]string{"readTimeOut", "readtimeout", "read-timeout", "read_timeout"}, false, false, "", ""},
+	OptionConnectTimeout:  configOption{[]string{"connectTimeOut", "connectTimeout", "connecttimeout", "connect-timeout", "connect_timeout"}, false, false, "", ""},
+	OptionRetryTimes:      configOption{[]string{"retryTimes", "retrytimes", "retry-times", "retry_times"}, false, false, "", ""},
+	OptionMaxIdleConns:    configOption{[]string{"maxIdleConns", "maxidleconns", "max-idle-conns", "max_idle_conns"}, false, false, "", ""},
+	OptionMaxConnsPerHost: configOption{[]string{"maxConnsPerHost", "maxconnsperhost", "max-conns-per-host", "max_conns_per_host"}, false, false, "", ""},
+	OptionIdleConnTimeout: configOption{[]string{"idleConnTimeout", "idleconntimeout", "idle-conn-timeout", "idle_conn_timeout"}, false, false, "", ""},
+	OptionMaxQPS:          configOption{[]string{"maxQPS", "maxQps", "maxqps", "max-qps", "max_qps"}, false, false, "", ""},
 }
 
 // DecideConfigFile return the config file, if user not specified, return default one
@@ -162,6 +169,15 @@ func readConfigFromFile(configFile string) (OptionMapType, error) {
 		}
 	}
 
+	// get the bucket profiles
+	profiles, err := readBucketProfiles(config)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) > 0 {
+		configMap[BucketProfileSection] = profiles
+	}
+
 	// get options in AKService for user-defined GetAk
 	sec := AkServiceSection
 	if section, err := config.Section(sec); err == nil {
@@ -5,8 +5,10 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"runtime"
 	"strings"
 
+	configparser "github.com/alyu/configparser"
 	. "gopkg.in/check.v1"
 )
 
@@ -501,3 +503,168 @@ func (s *OssutilConfigSuite) createFile(fileName, content string, c *C) {
 	_, err = fout.WriteString(content)
 	c.Assert(err, IsNil)
 }
+
+func (s *OssutilConfigSuite) TestSaveConfigConcurrently(c *C) {
+	configFile := "ossutil-test-config-" + randLowStr(5)
+	c.Assert(ioutil.WriteFile(configFile, []byte("[Credentials]\nendpoint=init\n"), 0600), IsNil)
+
+	stop := make(chan bool)
+	chReadErr := make(chan error, 1)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				chReadErr <- nil
+				return
+			default:
+			}
+			opts, err := LoadConfig(configFile)
+			if err == nil && opts[OptionEndpoint] == nil {
+				err = fmt.Errorf("endpoint is missing")
+			}
+			if err != nil {
+				chReadErr <- err
+				return
+			}
+		}
+	}()
+
+	chErr := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			var err error
+			for j := 0; j < 10 && err == nil; j++ {
+				config := configparser.NewConfiguration()
+				config.NewSection(CREDSection).Add(OptionEndpoint, fmt.Sprintf("endpoint-%d-%d", i, j))
+				err = saveConfig(config, configFile)
+			}
+			chErr <- err
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		c.Assert(<-chErr, IsNil)
+	}
+	close(stop)
+	c.Assert(<-chReadErr, IsNil)
+
+	f, err := os.Stat(configFile)
+	c.Assert(err, IsNil)
+	c.Assert(f.Mode().Perm(), Equals, os.FileMode(0600))
+	_, err = os.Stat(configFile + ConfigTempSuffix)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(configFile + ConfigBackupSuffix)
+	c.Assert(err, IsNil)
+
+	os.Remove(configFile)
+	os.Remove(configFile + ConfigBackupSuffix)
+}
+
+func (s *OssutilConfigSuite) TestSaveConfigSymlink(c *C) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	configFile := "ossutil-test-config-" + randLowStr(5)
+	linkFile := configFile + "-link"
+	c.Assert(ioutil.WriteFile(configFile, []byte("[Credentials]\nendpoint=init\n"), 0600), IsNil)
+	c.Assert(os.Symlink(configFile, linkFile), IsNil)
+
+	config := configparser.NewConfiguration()
+	config.NewSection(CREDSection).Add(OptionEndpoint, "new")
+	c.Assert(saveConfig(config, linkFile), IsNil)
+
+	f, err := os.Lstat(linkFile)
+	c.Assert(err, IsNil)
+	c.Assert(f.Mode()&os.ModeSymlink != 0, Equals, true)
+	opts, err := LoadConfig(linkFile)
+	c.Assert(err, IsNil)
+	c.Assert(opts[OptionEndpoint], Equals, "new")
+
+	os.Remove(linkFile)
+	os.Remove(configFile)
+	os.Remove(configFile + ConfigBackupSuffix)
+}
+
+func (s *OssutilConfigSuite) TestBucketProfile(c *C) {
+	configFile := "ossutil-test-config-" + randLowStr(5)
+	defer os.Remove(configFile)
+	data := "[Credentials]\nendpoint=oss-cn-hangzhou.aliyuncs.com\naccessKeyID=ak\naccessKeySecret=sk\n" +
+		"[Bucket-Profile:bucket1]\npayer=requester\nstorageClass=IA\nmeta=Cache-Control:no-cache#X-Oss-Meta-Team:web\naccelerate=true\n" +
+		"[Bucket-Profile:bucket4]\naccessKeyID=ak4\naccessKeySecret=sk4\n" +
+		"[Bucket-Profile:bucket2]\nOutput=json\npayer=other\n"
+	c.Assert(ioutil.WriteFile(configFile, []byte(data), 0600), IsNil)
+	configOptions, err := LoadConfig(configFile)
+	c.Assert(err, IsNil)
+
+	newCommand := func(args ...string) *Command {
+		meta := "Cache-Control:max-age=60"
+		endpoint := configOptions[OptionEndpoint].(string)
+		ak := "ak"
+		return &Command{
+			name: "cp",
+			args: args,
+			options: OptionMapType{
+				OptionMeta:            &meta,
+				OptionEndpoint:        &endpoint,
+				OptionAccessKeyID:     &ak,
+				OptionAccessKeySecret: &ak,
+			},
+			configOptions:    configOptions,
+			validOptionNames: copyCommand.command.validOptionNames,
+		}
+	}
+
+	// upload to bucket1, --meta takes precedence
+	cmd := newCommand("local", "oss://bucket1/dir/")
+	cmd.applyBucketProfiles()
+	payer, _ := GetString(OptionRequestPayer, cmd.options)
+	c.Assert(payer, Equals, "requester")
+	meta, _ := GetString(OptionMeta, cmd.options)
+	c.Assert(meta, Equals, "Cache-Control:max-age=60#X-Oss-Meta-Team:web#X-Oss-Storage-Class:IA")
+
+	// the meta of the source bucket isn't applied, the former bucket takes precedence
+	cmd = newCommand("oss://bucket1/dir/", "oss://bucket2/dir/")
+	cmd.applyBucketProfiles()
+	payer, _ = GetString(OptionRequestPayer, cmd.options)
+	c.Assert(payer, Equals, "requester")
+	meta, _ = GetString(OptionMeta, cmd.options)
+	c.Assert(meta, Equals, "Cache-Control:max-age=60")
+
+	// the options not supported by the command are skipped
+	cmd = newCommand("oss://bucket2/dir/")
+	cmd.validOptionNames = listPartCommand.command.validOptionNames
+	cmd.applyBucketProfiles()
+	output, _ := GetString(OptionOutput, cmd.options)
+	c.Assert(output, Equals, "json")
+
+	// accelerate
+	client, err := cmd.ossClient("bucket1")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(client.Config.Endpoint, accelerateEndpointHost), Equals, true)
+	client, err = cmd.ossClient("bucket2")
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(client.Config.Endpoint, accelerateEndpointHost), Equals, false)
+
+	// the credentials of the profile are only used by the clients of the bucket
+	client, err = cmd.ossClient("bucket4")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.CredentialsProvider.GetCredentials().GetAccessKeyID(), Equals, "ak4")
+	c.Assert(client.Config.CredentialsProvider.GetCredentials().GetAccessKeySecret(), Equals, "sk4")
+	client, err = cmd.ossClient("bucket2")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.CredentialsProvider.GetCredentials().GetAccessKeyID(), Equals, "ak")
+	cmd.dropProfileCredentials()
+	client, err = cmd.ossClient("bucket4")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.CredentialsProvider.GetCredentials().GetAccessKeyID(), Equals, "ak")
+
+	// invalid items
+	c.Assert(ioutil.WriteFile(configFile, []byte(data+"recursive=true\n"), 0600), IsNil)
+	_, err = LoadConfig(configFile)
+	c.Assert(err, NotNil)
+	c.Assert(ioutil.WriteFile(configFile, []byte(data+"[Bucket-Profile:bucket3]\naccelerate=yes\n"), 0600), IsNil)
+	_, err = LoadConfig(configFile)
+	c.Assert(err, NotNil)
+	c.Assert(ioutil.WriteFile(configFile, []byte(data+"[Bucket-Profile:bucket3]\naccessKeyID=ak3\n"), 0600), IsNil)
+	_, err = LoadConfig(configFile)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,62 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// globalOptionNames are the options accepted by all the commands
+var globalOptionNames = []string{
+	OptionAssumeYes,
+	OptionOutputFormat,
+}
+
+// assumeYes returns true if the destructive operation should go on without asking the user,
+// --force is kept for the commands which used it to skip the confirmation
+func (cmd *Command) assumeYes() bool {
+	yes, _ := GetBool(OptionAssumeYes, cmd.options)
+	force, _ := GetBool(OptionForce, cmd.options)
+	return yes || (force && FindPos(OptionForce, cmd.validOptionNames) != -1)
+}
+
+// confirmOperation asks the user to confirm the destructive operation described by action,
+// it returns true without asking if --assume-yes is specified
+func (cmd *Command) confirmOperation(action string) bool {
+	if cmd.assumeYes() {
+		return true
+	}
+	var val string
+	fmt.Printf(getClearStr(fmt.Sprintf("Do you really mean to %s(y or N)? ", action)))
+	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
+		fmt.Println("operation is canceled.")
+		return false
+	}
+	return true
+}
+
+// checkStructuredConfirm returns error if the operation to be confirmed runs with --output json or yaml
+// but without --assume-yes, because the prompt would break the structured result of stdout
+func (cmd *Command) checkStructuredConfirm(option string, format outputFormat) error {
+	if format.structured() && !cmd.assumeYes() {
+		return fmt.Errorf("%s with --output %s needs --assume-yes, the confirmation can't be asked", option, format)
+	}
+	return nil
+}
+
+// objectsSummary lists the objects under the url and returns the summary of them for the confirmation,
+// e.g., "3 objects, 1024 bytes"
+func (cmd *Command) objectsSummary(bucket *oss.Bucket, cloudURL CloudURL, filters []filterOptionType, options ...oss.Option) string {
+	var num, size int64
+	err := cmd.listObjectSizes(bucket, cloudURL, filters, func(objectSize int64) error {
+		num++
+		size += objectSize
+		return nil
+	}, options...)
+	if err != nil {
+		LogError("count objects of %s error:%s\n", cloudURL.ToString(), err.Error())
+		return "unknown objects"
+	}
+	return fmt.Sprintf("%d objects, %d bytes", num, size)
+}
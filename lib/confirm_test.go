@@ -0,0 +1,133 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestConfirmOperation(c *C) {
+	yes := true
+	no := false
+	cmd := Command{options: OptionMapType{OptionAssumeYes: &yes}}
+	c.Assert(cmd.assumeYes(), Equals, true)
+	c.Assert(cmd.confirmOperation("remove everything"), Equals, true)
+
+	// --force only works for the commands which support it
+	cmd = Command{options: OptionMapType{OptionAssumeYes: &no, OptionForce: &yes}, validOptionNames: []string{OptionForce}}
+	c.Assert(cmd.assumeYes(), Equals, true)
+	cmd.validOptionNames = nil
+	c.Assert(cmd.assumeYes(), Equals, false)
+
+	// --assume-yes is accepted by all the commands
+	cmd = Command{name: "ls", options: OptionMapType{OptionAssumeYes: &yes}}
+	c.Assert(cmd.checkOptions(), IsNil)
+
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "GET" {
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>prefix/a</Key><Size>4</Size></Contents><Contents><Key>prefix/b</Key><Size>6</Size></Contents>
+</ListBucketResult>`)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionAssumeYes:       &no,
+	}
+	cmd = Command{options: options}
+	bucket, err := cmd.ossBucket("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(cmd.objectsSummary(bucket, CloudURL{bucket: "bucket", object: "prefix/"}, nil), Equals, "2 objects, 10 bytes")
+
+	// the answer is read from stdin
+	answer := func(val string) {
+		stdinFile := "ossutil-test-stdin-" + randLowStr(5)
+		s.createFile(stdinFile, val, c)
+		defer os.Remove(stdinFile)
+		f, err := os.Open(stdinFile)
+		c.Assert(err, IsNil)
+		defer f.Close()
+		oldStdin := os.Stdin
+		os.Stdin = f
+		defer func() { os.Stdin = oldStdin }()
+
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		_, err = cm.RunCommand("worm", []string{"complete", "oss://bucket", "wormid"}, options)
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		c.Assert(err, IsNil)
+	}
+	answer("n\n")
+	c.Assert(requests, IsNil)
+	c.Assert(s.readFile(resultPath, c), Matches, "(?s).*complete the worm configuration wormid of bucket bucket.*operation is canceled.*")
+	answer("y\n")
+	c.Assert(len(requests), Equals, 1)
+
+	yes = true
+	options[OptionAssumeYes] = &yes
+	answer("")
+	c.Assert(len(requests), Equals, 2)
+}
+
+func (s *OssutilCommandSuite) TestConfirmOverwriteLocalFile(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<CORSConfiguration><CORSRule><AllowedOrigin>*</AllowedOrigin><AllowedMethod>GET</AllowedMethod></CORSRule></CORSConfiguration>`)
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-cors-" + randLowStr(5)
+	s.createFile(fileName, "existing", c)
+	defer os.Remove(fileName)
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	c.Assert(err, IsNil)
+	defer devNull.Close()
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = devNull, devNull
+	defer func() { os.Stdin, os.Stdout = oldStdin, oldStdout }()
+
+	str := "ak"
+	method := "get"
+	forcePathStyle := true
+	assumeYes := false
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionMethod:          &method,
+		OptionAssumeYes:       &assumeYes,
+	}
+
+	// the local file is kept if the overwriting isn't confirmed
+	_, err = cm.RunCommand("cors", []string{"oss://bucket", fileName}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(fileName, c), Equals, "existing")
+
+	// the global --assume-yes overwrites it without asking
+	assumeYes = true
+	_, err = cm.RunCommand("cors", []string{"oss://bucket", fileName}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(fileName, c), Matches, "(?s).*<AllowedOrigin>\\*</AllowedOrigin>.*")
+}
@@ -106,6 +106,138 @@ const (
 	OptionQueryParam                 = "queryParam"
 	OptionForcePathStyle             = "forcePathStyle"
 	OptionRuntime                    = "runtime"
+	OptionSample                     = "sample"
+	OptionFormat                     = "format"
+	OptionS3Endpoint                 = "s3Endpoint"
+	OptionKeyTemplate                = "keyTemplate"
+	OptionManifest                   = "manifest"
+	OptionMimeMap                    = "mimeMap"
+	OptionDetectContentType          = "detectContentType"
+	OptionListSplit                  = "listSplit"
+	OptionStartAfter                 = "startAfter"
+	OptionFetchOwner                 = "fetchOwner"
+	OptionTop                        = "top"
+	OptionStatSummary                = "statSummary"
+	OptionIfMatch                    = "ifMatch"
+	OptionIfNoneMatch                = "ifNoneMatch"
+	OptionIfUnmodifiedSince          = "ifUnmodifiedSince"
+	OptionForbidOverwrite            = "forbidOverwrite"
+	OptionNoClobber                  = "noClobber"
+	OptionIgnoreExisting             = "ignoreExisting"
+	OptionDisableOssIgnore           = "disableOssIgnore"
+	OptionMaxDuration                = "maxDuration"
+	OptionRetryBudget                = "retryBudget"
+	OptionMaxDiskUsage               = "maxDiskUsage"
+	OptionStagingDir                 = "stagingDir"
+	OptionWindowsNameMapping         = "windowsNameMapping"
+	OptionLocalEncoding              = "localEncoding"
+	OptionDryRun                     = "dryRun"
+	OptionPlan                       = "plan"
+	OptionPrice                      = "price"
+	OptionQPS                        = "qps"
+	OptionOutputFailed               = "outputFailed"
+	OptionRetryFrom                  = "retryFrom"
+	OptionContentMD5                 = "contentMD5"
+	OptionLowMemory                  = "lowMemory"
+	OptionFilterRegion               = "filterRegion"
+	OptionFilterOwner                = "filterOwner"
+	OptionObjectSize                 = "objectSize"
+	OptionConcurrency                = "concurrency"
+	OptionDuration                   = "duration"
+	OptionPrefix                     = "prefix"
+	OptionOlderThan                  = "olderThan"
+	OptionOutput                     = "output"
+	OptionAbort                      = "abort"
+	OptionProcess                    = "process"
+	OptionContentDisposition         = "responseContentDisposition"
+	OptionContentTypeOverride        = "responseContentType"
+	OptionAction                     = "action"
+	OptionSaveAs                     = "saveAs"
+	OptionOutputFile                 = "outputFile"
+	OptionNotifyTopic                = "notifyTopic"
+	OptionWait                       = "wait"
+	OptionPlaylistName               = "playlistName"
+	OptionFragDuration               = "fragDuration"
+	OptionFragCount                  = "fragCount"
+	OptionChannelStatus              = "channelStatus"
+	OptionCallbackURL                = "callbackUrl"
+	OptionCallbackBody               = "callbackBody"
+	OptionCallbackVar                = "callbackVar"
+	OptionDirObject                  = "dirObject"
+	OptionMaxObjects                 = "maxObjects"
+	OptionMaxBytes                   = "maxBytes"
+	OptionAssumeYes                  = "assumeYes"
+	OptionToTrash                    = "toTrash"
+	OptionTrashPrefix                = "trashPrefix"
+	OptionTrashDays                  = "trashDays"
+	OptionTrashDate                  = "trashDate"
+	OptionRetentionStatus            = "retentionStatus"
+	OptionMetaCache                  = "metaCache"
+	OptionWhereTag                   = "whereTag"
+	OptionCheckOnly                  = "checkOnly"
+	OptionSearch                     = "search"
+	OptionOutDir                     = "outDir"
+	OptionListen                     = "listen"
+	OptionAppend                     = "append"
+	OptionFanout                     = "fanout"
+	OptionChecksum                   = "checksum"
+	OptionContainerSize              = "containerSize"
+	OptionHashDB                     = "hashdb"
+	OptionLastModifiedAfter          = "lastModifiedAfter"
+	OptionLastModifiedBefore         = "lastModifiedBefore"
+	OptionSort                       = "sort"
+	OptionReverse                    = "reverse"
+	OptionHead                       = "head"
+	OptionPrint0                     = "print0"
+	OptionNullInput                  = "nullInput"
+	OptionOrdered                    = "ordered"
+	OptionFileList                   = "fileList"
+	OptionJobID                      = "jobID"
+	OptionEncryptCheckpoint          = "encryptCheckpoint"
+	OptionCheckpointExpire           = "checkpointExpireDays"
+	OptionMaxIdleConns               = "maxIdleConns"
+	OptionMaxConnsPerHost            = "maxConnsPerHost"
+	OptionIdleConnTimeout            = "idleConnTimeout"
+	OptionHTTP2                      = "http2"
+	OptionMaxQPS                     = "maxQPS"
+	OptionCopyTags                   = "copyTags"
+	OptionCopyACL                    = "copyACL"
+	OptionRetainUntil                = "retainUntil"
+	OptionRetentionMode              = "retentionMode"
+	OptionLegalHold                  = "legalHold"
+	OptionCDNDomain                  = "cdnDomain"
+	OptionAlgorithm                  = "algorithm"
+	OptionDailyBytes                 = "dailyBytes"
+	OptionStateFile                  = "stateFile"
+	OptionCRCRetryTimes              = "crcRetryTimes"
+	OptionDedup                      = "dedup"
+	OptionEvent                      = "event"
+	OptionKey                        = "key"
+	OptionExpectObject               = "expectObject"
+	OptionExpectURL                  = "expectURL"
+	OptionSpec                       = "spec"
+	OptionAllow                      = "allow"
+	OptionPrincipal                  = "principal"
+	OptionRAMPolicy                  = "ramPolicy"
+	OptionApply                      = "apply"
+	OptionFlushInterval              = "flushInterval"
+	OptionKeepParts                  = "keepParts"
+	OptionSeparator                  = "separator"
+	OptionAsOf                       = "asOf"
+	OptionVerifyCRC                  = "verifyCRC"
+	OptionProgressFile               = "progressFile"
+	OptionPosition                   = "position"
+	OptionOutputFormat               = "outputFormat"
+	OptionFields                     = "fields"
+	OptionPartCRC                    = "partCRC"
+)
+
+// the values of --output and --output-format
+const (
+	OutputFormatText  = "text"
+	OutputFormatTable = "table"
+	OutputFormatJSON  = "json"
+	OutputFormatYAML  = "yaml"
 )
 
 // the elements show in stat object
@@ -128,6 +260,9 @@ const (
 	StatTransferAcceleration          = "TransferAcceleration"
 	StatCrossRegionReplication        = "CrossRegionReplication"
 	StatAccessMonitor                 = "AccessMonitor"
+	StatRetainUntil                   = "RetainUntil"
+	StatRetentionMode                 = "RetentionMode"
+	StatLegalHold                     = "LegalHold"
 )
 
 // the elements show in hash file
@@ -151,6 +286,7 @@ const (
 	updateBinaryMac64             = "ossutilmac64"
 	updateBinaryMacArm64          = "ossutilmacarm64"
 	updateTmpVersionFile          = ".ossutil_tmp_vsersion"
+	updateChecksumSuffix          = ".sha256"
 )
 
 // global public variable
@@ -163,6 +299,8 @@ const (
 	EnglishLanguage                = "EN"
 	Scheme                  string = "oss"
 	DefaultConfigFile              = "~" + string(os.PathSeparator) + ".ossutilconfig"
+	DefaultLocaleDir               = ".ossutil_locale"
+	LocaleDirEnv                   = "OSSUTIL_LOCALE_DIR"
 	MaxUint                 uint   = ^uint(0)
 	MaxInt                  int    = int(MaxUint >> 1)
 	MaxUint64               uint64 = ^uint64(0)
@@ -172,6 +310,8 @@ const (
 	DefaultOutputDir               = "ossutil_output"
 	CheckpointDir                  = ".ossutil_checkpoint"
 	CheckpointSep                  = "---"
+	DefaultHashDBDir               = ".ossutil_hashdb"
+	DefaultIndexDir                = ".ossutil_index"
 	SnapshotConnector              = "==>"
 	SnapshotSep                    = "#"
 	MaxPartNum                     = 10000
@@ -190,9 +330,14 @@ const (
 	RetryTimes              int    = 10
 	MaxRetryTimes           int64  = 500
 	MinRetryTimes           int64  = 1
+	CRCRetryTimes           int    = 3
+	MaxCRCRetryTimes        int64  = 100
+	MinCRCRetryTimes        int64  = 0
 	Routines                int    = 3
 	MaxRoutines             int64  = 10000
 	MinRoutines             int64  = 1
+	ListShardRoutines       int    = 16
+	ListSplitAuto                  = "auto"
 	MaxParallel             int64  = 10000
 	MinParallel             int64  = 1
 	DefaultHashType         string = "crc64"
@@ -212,6 +357,9 @@ const (
 	IncludePrompt                  = "--include"
 	ExcludePrompt                  = "--exclude"
 	MaxAppendObjectSize     int64  = 5368709120
+	DefaultAppendChunkSize  int64  = 104857600
+	DefaultFlushInterval    int64  = 10
+	DefaultContainerSize    int64  = 1073741824
 	MaxBatchCount           int    = 100
 )
 
@@ -0,0 +1,355 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseConvertAppend = SpecText{
+
+	synopsisText: "将追加类型(Appendable)的object转换为普通object",
+
+	paramText: "cloud_url [dest_cloud_url] [options]",
+
+	syntaxText: `
+    ossutil convert-append oss://bucket/object [oss://bucket/dest_object] [-y] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    追加类型(Appendable)的object不支持分片操作，也不能修改存储类型，很多命令都会拒绝这类
+    object。该命令通过服务端拷贝，将追加类型的object转换为普通类型的object，拷贝时保留
+    object的元信息、ACL、标签和存储类型，数据不经过本地。
+
+    如果源object的类型不是Appendable，命令报错退出。
+
+    指定dest_cloud_url时，转换后的object写入dest_cloud_url，源object保持不变。
+
+    不指定dest_cloud_url时，原地转换：命令先将源object拷贝到同目录下的临时object，确认源
+    object在此期间没有被追加后，再用临时object覆盖源object并删除临时object。原地转换会覆盖
+    源object，命令会询问用户确认，指定-y选项时不询问。转换后的object不能再追加写入。
+
+    小于1GB的object通过CopyObject转换，转换后的类型为Normal；大于等于1GB的object通过分片拷
+    贝转换，转换后的类型为Multipart。
+
+用法：
+
+    ossutil convert-append oss://bucket/object [oss://bucket/dest_object] [-y]
+`,
+
+	sampleText: `
+    1) 原地转换object
+    ossutil convert-append oss://bucket1/log/app.log
+
+    2) 转换为另一个object，源object保持不变
+    ossutil convert-append oss://bucket1/log/app.log oss://bucket1/archive/app.log
+
+    3) 不询问确认，原地转换object
+    ossutil convert-append oss://bucket1/log/app.log -y
+`,
+}
+
+var specEnglishConvertAppend = SpecText{
+
+	synopsisText: "Convert the appendable object to normal object",
+
+	paramText: "cloud_url [dest_cloud_url] [options]",
+
+	syntaxText: `
+    ossutil convert-append oss://bucket/object [oss://bucket/dest_object] [-y] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The appendable object doesn't support the multipart operations and its storage class
+    can't be changed, so many commands reject it. The command converts the appendable object
+    to normal object by server side copy, the metadata, the acl, the tags and the storage class
+    of the object are kept, and the data isn't downloaded.
+
+    If the type of the source object isn't Appendable, the command exits with error.
+
+    If dest_cloud_url is specified, the converted object is written to dest_cloud_url, and the
+    source object is left unchanged.
+
+    If dest_cloud_url isn't specified, the object is converted in place: the command copies the
+    source object to a temporary object under the same directory, checks that the source object
+    isn't appended meanwhile, then overwrites the source object with the temporary object and
+    removes the temporary object. Converting in place overwrites the source object, so the command
+    asks the user to confirm unless -y is specified. The converted object can't be appended any more.
+
+    The object smaller than 1GB is converted by CopyObject and its type becomes Normal, the object
+    of 1GB or larger is converted by multipart copy and its type becomes Multipart.
+
+Usage:
+
+    ossutil convert-append oss://bucket/object [oss://bucket/dest_object] [-y]
+`,
+
+	sampleText: `
+    1) convert the object in place
+    ossutil convert-append oss://bucket1/log/app.log
+
+    2) convert the object to another object, the source object is left unchanged
+    ossutil convert-append oss://bucket1/log/app.log oss://bucket1/archive/app.log
+
+    3) convert the object in place without asking
+    ossutil convert-append oss://bucket1/log/app.log -y
+`,
+}
+
+// convertAppendCopyLimit is the max size of the object that CopyObject supports, the larger
+// objects are converted by multipart copy
+const convertAppendCopyLimit int64 = 1024 * 1024 * 1024
+
+// convertAppendPartSize is the min part size of the multipart copy
+const convertAppendPartSize int64 = 100 * 1024 * 1024
+
+// ConvertAppendCommand is the command converts appendable object to normal object
+type ConvertAppendCommand struct {
+	command       Command
+	commonOptions []oss.Option
+}
+
+var convertAppendCommand = ConvertAppendCommand{
+	command: Command{
+		name:      "convert-append",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionEncodingType,
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionLogLevel,
+			OptionRequestPayer,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (cac *ConvertAppendCommand) formatHelpForWhole() string {
+	return cac.command.formatHelpForWhole()
+}
+
+func (cac *ConvertAppendCommand) formatIndependHelp() string {
+	return cac.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (cac *ConvertAppendCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return cac.command.Init(ctx, args, options, cac)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (cac *ConvertAppendCommand) RunCommand() error {
+	cac.commonOptions = nil
+	encodingType, _ := GetString(OptionEncodingType, cac.command.options)
+	srcURL, err := CloudURLFromString(cac.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	if err := checkConvertAppendURL(srcURL, cac.command.args[0]); err != nil {
+		return err
+	}
+
+	inPlace := len(cac.command.args) == 1
+	destURL := srcURL
+	if !inPlace {
+		if destURL, err = CloudURLFromString(cac.command.args[1], encodingType); err != nil {
+			return err
+		}
+		if err := checkConvertAppendURL(destURL, cac.command.args[1]); err != nil {
+			return err
+		}
+		if destURL.bucket != srcURL.bucket {
+			return fmt.Errorf("the bucket of dest object: %s must be the same with the bucket of source object: %s", destURL.bucket, srcURL.bucket)
+		}
+		if destURL.object == srcURL.object {
+			inPlace = true
+		}
+	}
+
+	payer, _ := GetString(OptionRequestPayer, cac.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		cac.commonOptions = append(cac.commonOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+
+	bucket, err := cac.command.cloudBucket(srcURL)
+	if err != nil {
+		return err
+	}
+
+	props, err := cac.command.ossGetObjectStatRetry(bucket, srcURL.object, cac.commonOptions...)
+	if err != nil {
+		return err
+	}
+	if objectType := props.Get("X-Oss-Object-Type"); !strings.EqualFold(objectType, "Appendable") {
+		return fmt.Errorf("the type of %s is %s, only the Appendable object can be converted", srcURL.objectURL(srcURL.object), objectType)
+	}
+
+	if !inPlace {
+		return cac.copy(bucket, srcURL.object, destURL.object, props)
+	}
+
+	if !cac.command.confirmOperation(fmt.Sprintf("overwrite %s with the converted normal object", srcURL.objectURL(srcURL.object))) {
+		return nil
+	}
+
+	tmpObject := fmt.Sprintf("%s.ossutil-convert-%d", srcURL.object, time.Now().UnixNano())
+	if err := cac.copy(bucket, srcURL.object, tmpObject, props); err != nil {
+		return err
+	}
+	defer cac.ossDeleteObjectRetry(bucket, tmpObject)
+
+	// the source object may be appended while copying, don't lose the appended data
+	current, err := cac.command.ossGetObjectStatRetry(bucket, srcURL.object, cac.commonOptions...)
+	if err != nil {
+		return err
+	}
+	if current.Get(oss.HTTPHeaderEtag) != props.Get(oss.HTTPHeaderEtag) {
+		return fmt.Errorf("%s is modified while converting, please try again", srcURL.objectURL(srcURL.object))
+	}
+
+	tmpProps, err := cac.command.ossGetObjectStatRetry(bucket, tmpObject, cac.commonOptions...)
+	if err != nil {
+		return err
+	}
+	return cac.copy(bucket, tmpObject, srcURL.object, tmpProps)
+}
+
+func checkConvertAppendURL(cloudURL CloudURL, arg string) error {
+	if cloudURL.bucket == "" {
+		return fmt.Errorf("invalid cloud url: %s, miss bucket", arg)
+	}
+	if cloudURL.object == "" || strings.HasSuffix(cloudURL.object, "/") {
+		return fmt.Errorf("invalid cloud url: %s, object name must be specified", arg)
+	}
+	return nil
+}
+
+// copy copies srcObject to destObject by CopyObject or by multipart copy according to the size,
+// the acl, the metadata, the tags and the storage class are kept. The appendable object only
+// grows, so the multipart copy of the first size bytes is consistent even if it's appended meanwhile
+func (cac *ConvertAppendCommand) copy(bucket *oss.Bucket, srcObject, destObject string, props http.Header) error {
+	size, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	// CopyObject keeps the metadata and the tags, the multipart copy doesn't, so set them from
+	// the source object
+	multipart := size >= convertAppendCopyLimit
+	options, err := cac.command.copyAttrOptions(bucket, srcObject, multipart, cac.commonOptions)
+	if err != nil {
+		return err
+	}
+	if !multipart {
+		options = append(options, oss.CopySourceIfMatch(props.Get(oss.HTTPHeaderEtag)))
+		return cac.ossCopyObjectRetry(bucket, srcObject, destObject, options...)
+	}
+
+	partSize := size/MaxPartNum + 1
+	if partSize < convertAppendPartSize {
+		partSize = convertAppendPartSize
+	}
+	return cac.ossCopyFileRetry(bucket, srcObject, destObject, partSize, options...)
+}
+
+func (cac *ConvertAppendCommand) ossCopyObjectRetry(bucket *oss.Bucket, srcObject, destObject string, options ...oss.Option) error {
+	retryTimes, _ := GetInt(OptionRetryTimes, cac.command.options)
+	options = cac.command.withContext(options)
+	for i := 1; ; i++ {
+		_, err := bucket.CopyObject(srcObject, destObject, options...)
+		if err == nil {
+			return err
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucket.BucketName, srcObject}
+		}
+
+		if err := cac.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+func (cac *ConvertAppendCommand) ossCopyFileRetry(bucket *oss.Bucket, srcObject, destObject string, partSize int64, options ...oss.Option) error {
+	retryTimes, _ := GetInt(OptionRetryTimes, cac.command.options)
+	options = cac.command.withContext(options)
+	for i := 1; ; i++ {
+		err := bucket.CopyFile(bucket.BucketName, srcObject, destObject, partSize, options...)
+		if err == nil {
+			return err
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucket.BucketName, srcObject}
+		}
+
+		if err := cac.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+func (cac *ConvertAppendCommand) ossDeleteObjectRetry(bucket *oss.Bucket, object string) error {
+	retryTimes, _ := GetInt(OptionRetryTimes, cac.command.options)
+	options := cac.command.withContext(cac.commonOptions)
+	for i := 1; ; i++ {
+		err := bucket.DeleteObject(object, options...)
+		if err == nil {
+			return err
+		}
+
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucket.BucketName, object}
+		}
+
+		if err := cac.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
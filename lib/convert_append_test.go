@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestConvertAppend(c *C) {
+	type mockObject struct {
+		objectType string
+		etag       string
+		data       string
+		acl        string
+	}
+	var mu sync.Mutex
+	objects := map[string]*mockObject{}
+	appendOnCopy := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "GET":
+			object, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if _, ok := r.URL.Query()["acl"]; ok {
+				fmt.Fprintf(w, `<AccessControlPolicy><AccessControlList><Grant>%s</Grant></AccessControlList></AccessControlPolicy>`, object.acl)
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+		case "HEAD":
+			object, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("X-Oss-Object-Type", object.objectType)
+			w.Header().Set("ETag", object.etag)
+			w.Header().Set("Content-Length", fmt.Sprint(len(object.data)))
+		case "PUT":
+			source, _ := url.QueryUnescape(r.Header.Get("X-Oss-Copy-Source"))
+			object, ok := objects[source]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if match := r.Header.Get("X-Oss-Copy-Source-If-Match"); match != "" && match != object.etag {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			objects[r.URL.Path] = &mockObject{"Normal", `"` + randStr(8) + `"`, object.data, r.Header.Get("X-Oss-Object-Acl")}
+			if appendOnCopy {
+				object.etag = `"appended"`
+			}
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+		case "DELETE":
+			delete(objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	yes := true
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionAssumeYes:       &yes,
+	}
+	reset := func() {
+		objects = map[string]*mockObject{
+			"/bucket/app":    {"Appendable", `"app"`, "abc", "public-read"},
+			"/bucket/normal": {"Normal", `"normal"`, "abc", "default"},
+		}
+	}
+
+	// convert to another object
+	reset()
+	_, err := cm.RunCommand("convert-append", []string{"oss://bucket/app", "oss://bucket/app2"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(objects["/bucket/app"].objectType, Equals, "Appendable")
+	c.Assert(objects["/bucket/app2"].objectType, Equals, "Normal")
+	c.Assert(objects["/bucket/app2"].data, Equals, "abc")
+	c.Assert(objects["/bucket/app2"].acl, Equals, "public-read")
+
+	// convert in place, the temporary object is removed
+	reset()
+	_, err = cm.RunCommand("convert-append", []string{"oss://bucket/app"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(objects["/bucket/app"].objectType, Equals, "Normal")
+	c.Assert(objects["/bucket/app"].data, Equals, "abc")
+	c.Assert(objects["/bucket/app"].acl, Equals, "public-read")
+	c.Assert(len(objects), Equals, 2)
+
+	// the object appended while converting isn't overwritten
+	reset()
+	appendOnCopy = true
+	_, err = cm.RunCommand("convert-append", []string{"oss://bucket/app"}, options)
+	appendOnCopy = false
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "modified while converting"), Equals, true)
+	c.Assert(objects["/bucket/app"].objectType, Equals, "Appendable")
+	c.Assert(len(objects), Equals, 2)
+
+	// only the appendable object can be converted
+	reset()
+	_, err = cm.RunCommand("convert-append", []string{"oss://bucket/normal"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "only the Appendable object"), Equals, true)
+
+	_, err = cm.RunCommand("convert-append", []string{"oss://bucket/missing"}, options)
+	c.Assert(isNotFound(err), Equals, true)
+
+	_, err = cm.RunCommand("convert-append", []string{"oss://bucket/app", "oss://bucket2/app"}, options)
+	c.Assert(err, NotNil)
+	_, err = cm.RunCommand("convert-append", []string{"oss://bucket/dir/"}, options)
+	c.Assert(err, NotNil)
+}
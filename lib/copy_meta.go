@@ -0,0 +1,324 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseCopyMeta = SpecText{
+	synopsisText: "将一个object的meta信息复制到另一个object",
+
+	paramText: "src_object dest_object [options]",
+
+	syntaxText: `
+    ossutil copy-meta oss://bucket1/src_object oss://bucket2/dest_object [--tags] [--copy-acl] [--version-id versionId] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    该命令读取源object的meta信息，通过原地拷贝(CopyObject)替换目标object的meta信息，目标object的数据
+    不变。适用于重新上传修正后的数据，但需要保留原有属性的场景。源object和目标object可以属于不同的bucket。
+
+    复制的meta信息包括：
+        Content-Type、Cache-Control、Content-Disposition、Content-Encoding、Content-Language、Expires
+        以及以` + oss.HTTPHeaderOssMetaPrefix + `开头的headers
+    目标object原有的上述headers被替换，目标object的存储类型和服务端加密方式保持不变。
+
+    --tags选项同时通过tagging接口复制源object的tags，源object没有tags时删除目标object的tags；默认保留
+    目标object的tags。
+
+    --copy-acl选项同时复制源object的acl；默认保留目标object的acl。
+
+    --version-id指定源object的版本。
+
+    原地拷贝受CopyObject的限制，目标object不能大于5GB。
+
+用法：
+
+    ossutil copy-meta oss://bucket1/src_object oss://bucket2/dest_object [--tags] [--copy-acl]
+`,
+
+	sampleText: `
+    1) 复制meta信息
+    ossutil copy-meta oss://bucket/old/index.html oss://bucket/new/index.html
+
+    2) 同时复制tags和acl
+    ossutil copy-meta oss://bucket/old/index.html oss://bucket/new/index.html --tags --copy-acl
+
+    3) 复制源object指定版本的meta信息
+    ossutil copy-meta oss://bucket/index.html oss://bucket/index.html --version-id versionId
+`,
+}
+
+var specEnglishCopyMeta = SpecText{
+	synopsisText: "Copy the meta of an object to another object",
+
+	paramText: "src_object dest_object [options]",
+
+	syntaxText: `
+    ossutil copy-meta oss://bucket1/src_object oss://bucket2/dest_object [--tags] [--copy-acl] [--version-id versionId] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The command reads the meta of the source object, and replaces the meta of the destination object by
+    an in-place copy (CopyObject), the data of the destination object is not changed. It's useful when
+    re-uploading the fixed content that must keep its attributes. The source and the destination object
+    can be in different buckets.
+
+    The meta copied includes:
+        Content-Type, Cache-Control, Content-Disposition, Content-Encoding, Content-Language, Expires
+        and the headers start with: ` + oss.HTTPHeaderOssMetaPrefix + `
+    These headers of the destination object are replaced, its storage class and server side encryption
+    are kept.
+
+    --tags copies the tags of the source object by the tagging api too, the tags of the destination object
+    are deleted if the source object has no tags. The tags of the destination object are kept by default.
+
+    --copy-acl copies the acl of the source object too. The acl of the destination object is kept by default.
+
+    --version-id specifies the version of the source object.
+
+    The in-place copy is limited by CopyObject, the destination object can't be larger than 5GB.
+
+Usage:
+
+    ossutil copy-meta oss://bucket1/src_object oss://bucket2/dest_object [--tags] [--copy-acl]
+`,
+
+	sampleText: `
+    1) Copy the meta
+    ossutil copy-meta oss://bucket/old/index.html oss://bucket/new/index.html
+
+    2) Copy the tags and the acl too
+    ossutil copy-meta oss://bucket/old/index.html oss://bucket/new/index.html --tags --copy-acl
+
+    3) Copy the meta of the version of the source object
+    ossutil copy-meta oss://bucket/index.html oss://bucket/index.html --version-id versionId
+`,
+}
+
+// copyMetaHeaders are the headers copied besides the user meta
+var copyMetaHeaders = []string{
+	oss.HTTPHeaderContentType,
+	oss.HTTPHeaderCacheControl,
+	oss.HTTPHeaderContentDisposition,
+	oss.HTTPHeaderContentEncoding,
+	oss.HTTPHeaderContentLanguage,
+	oss.HTTPHeaderExpires,
+}
+
+// keptHeaders are the headers of the destination object kept by the in-place copy
+var keptHeaders = []string{
+	oss.HTTPHeaderOssStorageClass,
+	oss.HTTPHeaderOssServerSideEncryption,
+	oss.HTTPHeaderOssServerSideEncryptionKeyID,
+	oss.HTTPHeaderOssServerSideDataEncryption,
+}
+
+// CopyMetaCommand is the command copies the meta, the tags and the acl between objects
+type CopyMetaCommand struct {
+	command       Command
+	commonOptions []oss.Option
+}
+
+var copyMetaCommand = CopyMetaCommand{
+	command: Command{
+		name:      "copy-meta",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionEncodingType,
+			OptionLogLevel,
+			OptionVersionId,
+			OptionRequestPayer,
+			OptionCopyTags,
+			OptionCopyACL,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (cmc *CopyMetaCommand) formatHelpForWhole() string {
+	return cmc.command.formatHelpForWhole()
+}
+
+func (cmc *CopyMetaCommand) formatIndependHelp() string {
+	return cmc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (cmc *CopyMetaCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return cmc.command.Init(ctx, args, options, cmc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (cmc *CopyMetaCommand) RunCommand() error {
+	cmc.commonOptions = []oss.Option{}
+	encodingType, _ := GetString(OptionEncodingType, cmc.command.options)
+	srcURL, err := ObjectURLFromString(cmc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	destURL, err := ObjectURLFromString(cmc.command.args[1], encodingType)
+	if err != nil {
+		return err
+	}
+
+	payer, _ := GetString(OptionRequestPayer, cmc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		cmc.commonOptions = append(cmc.commonOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+	srcOptions := cmc.commonOptions
+	if versionId, _ := GetString(OptionVersionId, cmc.command.options); versionId != "" {
+		srcOptions = append([]oss.Option{oss.VersionId(versionId)}, cmc.commonOptions...)
+	}
+	copyTags, _ := GetBool(OptionCopyTags, cmc.command.options)
+	copyACL, _ := GetBool(OptionCopyACL, cmc.command.options)
+
+	srcBucket, err := cmc.command.cloudBucket(srcURL)
+	if err != nil {
+		return err
+	}
+	destBucket, err := cmc.command.cloudBucket(destURL)
+	if err != nil {
+		return err
+	}
+
+	srcProps, err := cmc.command.ossGetObjectStatRetry(srcBucket, srcURL.object, srcOptions...)
+	if err != nil {
+		return err
+	}
+	destProps, err := cmc.command.ossGetObjectStatRetry(destBucket, destURL.object, cmc.commonOptions...)
+	if err != nil {
+		return err
+	}
+
+	// the in-place copy resets the acl if it's not specified
+	aclBucket, aclObject, aclOptions := destBucket, destURL.object, cmc.commonOptions
+	if copyACL {
+		aclBucket, aclObject, aclOptions = srcBucket, srcURL.object, srcOptions
+	}
+	aclResult, err := aclBucket.GetObjectACL(aclObject, aclOptions...)
+	if err != nil {
+		return ObjectError{err, aclBucket.BucketName, aclObject}
+	}
+
+	headers := copyMetaOf(srcProps, destProps)
+	headers[oss.HTTPHeaderOssObjectACL] = aclResult.ACL
+	if err = cmc.ossReplaceMetaRetry(destBucket, destURL.object, headers); err != nil {
+		return err
+	}
+
+	if copyTags {
+		if err = cmc.copyTagging(srcBucket, srcURL.object, srcOptions, destBucket, destURL.object); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("copied the meta of %s to %s\n", srcURL.objectURL(srcURL.object), destURL.objectURL(destURL.object))
+	return nil
+}
+
+// copyMetaOf returns the headers of the in-place copy, the meta of the source object and the
+// storage class and the encryption of the destination object
+func copyMetaOf(srcProps, destProps http.Header) map[string]string {
+	headers := map[string]string{}
+	for name := range srcProps {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(oss.HTTPHeaderOssMetaPrefix)) {
+			headers[name] = srcProps.Get(name)
+		}
+	}
+	for _, name := range copyMetaHeaders {
+		if value := srcProps.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	for _, name := range keptHeaders {
+		if value := destProps.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+func (cmc *CopyMetaCommand) ossReplaceMetaRetry(bucket *oss.Bucket, object string, headers map[string]string) error {
+	options := []oss.Option{oss.MetadataDirective(oss.MetaReplace)}
+	for name, value := range headers {
+		options = append(options, oss.SetHeader(name, value))
+	}
+	options = append(options, cmc.commonOptions...)
+	options = cmc.command.withContext(options)
+
+	retryTimes, _ := GetInt(OptionRetryTimes, cmc.command.options)
+	for i := 1; ; i++ {
+		_, err := bucket.CopyObject(object, object, options...)
+		if err == nil {
+			return nil
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucket.BucketName, object}
+		}
+
+		if err := cmc.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+// copyTagging replaces the tags of the destination object with the ones of the source object
+func (cmc *CopyMetaCommand) copyTagging(srcBucket *oss.Bucket, srcObject string, srcOptions []oss.Option, destBucket *oss.Bucket, destObject string) error {
+	result, err := srcBucket.GetObjectTagging(srcObject, srcOptions...)
+	if err != nil {
+		return ObjectError{err, srcBucket.BucketName, srcObject}
+	}
+	if len(result.Tags) == 0 {
+		err = destBucket.DeleteObjectTagging(destObject, cmc.commonOptions...)
+	} else {
+		err = destBucket.PutObjectTagging(destObject, oss.Tagging{Tags: result.Tags}, cmc.commonOptions...)
+	}
+	if err != nil {
+		return ObjectError{err, destBucket.BucketName, destObject}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestCopyMeta(c *C) {
+	var copyHeader http.Header
+	var tagging string
+	deleted := false
+	copies, copyStatus := 0, http.StatusOK
+	srcTags := `<Tag><Key>team</Key><Value>web</Value></Tag>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		src := strings.HasSuffix(r.URL.Path, "/src")
+		switch {
+		case r.Method == "HEAD" && src:
+			w.Header().Set("Content-Type", "text/html")
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("X-Oss-Meta-Owner", "alice")
+			w.Header().Set("X-Oss-Storage-Class", "Standard")
+		case r.Method == "HEAD":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("X-Oss-Storage-Class", "IA")
+		case r.Method == "GET" && r.URL.RawQuery == "acl":
+			acl := "private"
+			if src {
+				acl = "public-read"
+			}
+			fmt.Fprintf(w, `<AccessControlPolicy><AccessControlList><Grant>%s</Grant></AccessControlList></AccessControlPolicy>`, acl)
+		case r.Method == "PUT" && r.Header.Get("X-Oss-Copy-Source") != "":
+			copies++
+			if copyStatus != http.StatusOK {
+				w.WriteHeader(copyStatus)
+				fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>denied</Message></Error>`)
+				return
+			}
+			copyHeader = r.Header
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+		case r.Method == "GET" && r.URL.RawQuery == "tagging":
+			fmt.Fprintf(w, `<Tagging><TagSet>%s</TagSet></Tagging>`, srcTags)
+		case r.Method == "PUT" && r.URL.RawQuery == "tagging":
+			data, _ := ioutil.ReadAll(r.Body)
+			tagging = string(data)
+		case r.Method == "DELETE" && r.URL.RawQuery == "tagging":
+			deleted = true
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	defer server.Close()
+
+	resultPath := "ossutil-test-copy-meta-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+	}
+	args := []string{"oss://bucket/src", "oss://bucket/dest"}
+
+	// the meta is replaced, the storage class and the acl of the destination are kept
+	_, err = cm.RunCommand("copy-meta", args, options)
+	c.Assert(err, IsNil)
+	c.Assert(copyHeader.Get("X-Oss-Metadata-Directive"), Equals, "REPLACE")
+	c.Assert(copyHeader.Get("Content-Type"), Equals, "text/html")
+	c.Assert(copyHeader.Get("Cache-Control"), Equals, "max-age=60")
+	c.Assert(copyHeader.Get("X-Oss-Meta-Owner"), Equals, "alice")
+	c.Assert(copyHeader.Get("X-Oss-Storage-Class"), Equals, "IA")
+	c.Assert(copyHeader.Get("X-Oss-Object-Acl"), Equals, "private")
+	c.Assert(strings.HasSuffix(copyHeader.Get("X-Oss-Copy-Source"), "/dest"), Equals, true)
+	c.Assert(tagging, Equals, "")
+
+	// --tags and --copy-acl
+	bTrue := true
+	options[OptionCopyTags] = &bTrue
+	options[OptionCopyACL] = &bTrue
+	_, err = cm.RunCommand("copy-meta", args, options)
+	c.Assert(err, IsNil)
+	c.Assert(copyHeader.Get("X-Oss-Object-Acl"), Equals, "public-read")
+	c.Assert(strings.Contains(tagging, "<Key>team</Key><Value>web</Value>"), Equals, true)
+	c.Assert(deleted, Equals, false)
+
+	// the tags of the destination are deleted if the source has no tags
+	srcTags = ""
+	_, err = cm.RunCommand("copy-meta", args, options)
+	c.Assert(err, IsNil)
+	c.Assert(deleted, Equals, true)
+
+	// the destination must be an object
+	_, err = cm.RunCommand("copy-meta", []string{"oss://bucket/src", "oss://bucket"}, options)
+	c.Assert(err, NotNil)
+
+	// the client errors are not retried
+	retryTimes := "3"
+	options[OptionRetryTimes] = &retryTimes
+	copies, copyStatus = 0, http.StatusForbidden
+	_, err = cm.RunCommand("copy-meta", args, options)
+	c.Assert(err, NotNil)
+	c.Assert(copies, Equals, 1)
+}
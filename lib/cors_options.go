@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"os"
 	"strings"
 
@@ -69,13 +70,11 @@ type OptionsCommand struct {
 
 var corsOptionsCommand = OptionsCommand{
 	command: Command{
-		name:        "cors-options",
-		nameAlias:   []string{"cors-options"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseOptions,
-		specEnglish: specEnglishOptions,
-		group:       GroupTypeNormalCommand,
+		name:      "cors-options",
+		nameAlias: []string{"cors-options"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -98,6 +97,11 @@ var corsOptionsCommand = OptionsCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -119,8 +123,8 @@ func (opsc *OptionsCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (opsc *OptionsCommand) Init(args []string, options OptionMapType) error {
-	return opsc.command.Init(args, options, opsc)
+func (opsc *OptionsCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return opsc.command.Init(ctx, args, options, opsc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -149,7 +153,7 @@ func (opsc *OptionsCommand) RunCommand() error {
 
 	objectName := srcBucketUrL.object
 
-	client, err := opsc.command.ossClient(srcBucketUrL.bucket)
+	client, err := opsc.command.cloudClient(*srcBucketUrL)
 	if err != nil {
 		return err
 	}
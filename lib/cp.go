@@ -1,16 +1,27 @@
 package lib
 
 import (
+	"context"
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"hash/fnv"
+	"io"
 	"io/ioutil"
 	"math"
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -38,6 +49,7 @@ const (
  */
 type copyOptionType struct {
 	cpDir             string
+	cpRootDir         string
 	snapshotPath      string
 	vrange            string
 	encodingType      string
@@ -60,12 +72,48 @@ type copyOptionType struct {
 	enableSymlinkDir  bool
 	onlyCurrentDir    bool
 	disableDirObject  bool
+	dirObject         bool
 	disableAllSymlink bool
 	tagging           string
 	opType            operationType
 	bSyncCommand      bool
 	startTime         int64
 	endTime           int64
+	mimeMap           map[string]string
+	sniffContentType  bool
+	metaContentType   bool
+	listSplit         string
+	startAfter        string
+	statSummary       *statSummary
+	condition         objectConditionType
+	forbidOverwrite   bool
+	callback          bool
+	glob              string // the glob pattern relative to the listed source
+	globRecursive     bool
+	sources           []CloudURL // the source urls of download or copy if there are more than one
+	noClobber         bool
+	append            bool
+	checksum          bool
+	dedup             bool
+	hashDBPath        string
+	hashDB            *hashDB    // the crc64 cache of the local files for --checksum
+	fanoutURLs        []CloudURL // the destination urls of --fanout
+	destObjects       keyStore
+	disableOssIgnore  bool
+	budget            *jobBudget
+	diskQuota         *diskQuota
+	stagingDir        string
+	crcRetryTimes     int64
+	windowsNameMap    string
+	localEncoding     string
+	plan              *requestPlan
+	renamedKeys       int64
+	failed            *failedManifest
+	retryKeys         []string // nil means not --retry-from
+	keepParts         bool
+	asOf              *asOfSelector // nil means not --as-of
+	progress          *progressFile // nil means not --progress-file
+	cpCipher          cipher.AEAD   // nil means not --encrypt-checkpoint
 }
 
 type filterOptionType struct {
@@ -153,8 +201,10 @@ var specChineseCopy = SpecText{
 
 	syntaxText: ` 
     ossutil cp file_url cloud_url  [-r] [-f] [-u] [--enable-symlink-dir] [--disable-all-symlink] [--disable-ignore-error] [--only-current-dir] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--snapshot-path=sdir] [--payer requester]
+    ossutil cp file_url cloud_url cloud_url... --fanout [--part-size=size] [--payer requester]
     ossutil cp cloud_url file_url  [-r] [-f] [-u] [--only-current-dir] [--disable-ignore-error] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--range=x-y] [--payer requester] [--version-id versionId]
     ossutil cp cloud_url cloud_url [-r] [-f] [-u] [--only-current-dir] [--disable-ignore-error] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--payer requester] [--version-id versionId]
+    ossutil cp [src_url dest_url] --spec job.yaml
 `,
 
 	detailHelpText: ` 
@@ -248,6 +298,15 @@ var specChineseCopy = SpecText{
     该选项在上传文件的同时设置object的tagging信息。当指定--recursive选项时，会设置所有上传的
     objects的tagging信息。
     如果一次设置多个tagging,必须使用双引号,比如 "tagA=A&tagB=B"
+
+--retain-until、--retention-mode、--legal-hold选项
+    这些选项在上传或者拷贝的同时设置object级别的保留(object lock)属性，供需要object级别不可变
+    的备份工具使用：--retain-until指定保留截止时间，取值为日期(2006-01-02，本地时间)或者RFC3339
+    格式的时间，--retention-mode指定保留模式GOVERNANCE(默认)或COMPLIANCE，--legal-hold设置法律
+    保留。目标bucket需要开启object lock。
+    只有s3://的目标支持object级别的保留，oss的objects通过bucket的合规保留策略保护，请使用worm
+    命令。设置了保留属性的上传会自动计算Content-MD5。stat命令显示objects的RetainUntil、
+    RetentionMode和LegalHold。
     
 --acl选项
 
@@ -381,6 +440,19 @@ var specChineseCopy = SpecText{
     可以被删除。
     4）如果使用rm命令删除了未complete的Multipart Upload，可能会造成下次cp命令断点续传失败（报
     错：NoSuchUpload），这种时候如果想要重新上传整个文件，请删除相应的checkpoint文件。
+    5）命令执行期间ossutil在checkpoint目录中持有以命令参数命名的.ossutil-*.lock文件锁，参数相同的命令
+    （如：重叠的定时任务）不能同时执行，后执行的命令会报错退出，源或者目标不同的命令可以同时使用同一个
+    checkpoint目录。进程异常退出后遗留的锁会在下次执行时被报告并接管。
+    6）指定--part-crc时，操作（1）中ossutil在发送每个分片的同时计算该分片的crc64并记录到checkpoint文件中，
+    complete后将所有分片的crc64合并，与oss返回的object的crc64比较，整个过程只读取一次文件，不会为了校验
+    再次读取。--part-crc不能和--disable-crc64同时使用。
+    7）指定--job-id时，checkpoint记录在--checkpoint-dir下以任务名称命名的子目录中。
+    8）checkpoint文件中包含object名称和upload id，指定--encrypt-checkpoint时，checkpoint文件在每次写入
+    时使用AES-256-GCM加密，读取时自动解密，密码从环境变量OSSUTIL_CHECKPOINT_KEY读取。分片上传、下载和拷贝
+    的checkpoint由sdk读写，只在sdk读取之前的短暂时间内是明文。命令开始时会加密目录中遗留的明文checkpoint，
+    未指定--encrypt-checkpoint时遇到加密的checkpoint会报错。
+    9）指定--checkpoint-expire-days时，命令开始时会删除--checkpoint-dir及其任务子目录中超过指定天数未修改
+    的checkpoint文件，正在运行的任务的目录会被跳过。
 
 
 性能调优：
@@ -413,6 +485,24 @@ var specChineseCopy = SpecText{
     如果将part size值设置得过小，可能会影响ossutil文件上传/下载/拷贝的性能，设置得过大，会影
     响实际起作用的分片并发数，所以请合理设置part size选项值。
 
+--max-idle-conns、--max-conns-per-host、--idle-conn-timeout和--http2选项（http连接）
+
+    默认的http连接池最多保持100个空闲连接，当jobs个数乘以parallel个数超过该值时，连接会被频繁
+    关闭和重建，可以用--max-idle-conns调大空闲连接数，用--idle-conn-timeout（单位：秒）设置空闲
+    连接的超时时间，用--max-conns-per-host限制到每个host的总连接数。这些选项也可以在配置文件的
+    Default段中设置。
+    
+    --http2在服务端或代理支持时使用HTTP/2，多个请求复用同一个连接，此时--read-timeout只限制等待
+    响应头的时间。
+
+--max-qps选项（请求频率）
+
+    批量上传/下载/拷贝大量小文件时，请求频率可能触发bucket级别的限流，--max-qps限制每秒发送的
+    请求数，命令所有并发任务的请求（包括重试）共同受该限制，最多允许一秒的突发请求。在配置文件的
+    Default段中设置maxQPS可以对所有命令生效，daemon同时执行的命令各自受自己的限制。请求在签名之前
+    等待，但sdk在等待之前已经填写了请求的时间，请不要将jobs个数乘以parallel个数设置得远大于
+    --max-qps乘以900（15分钟），否则请求可能因为等待时间过长而报错RequestTimeTooSkewed。
+
 
 批量文件迁移：
 
@@ -435,6 +525,299 @@ var specChineseCopy = SpecText{
         ossutil cp your_dir oss://your_bucket -r -f -u --shapshot-path=your-path
 
 
+--mime-map和--detect-content-type选项
+
+    上传文件时，ossutil默认根据文件扩展名和内置的映射表设置object的Content-Type。
+    如果指定了--mime-map选项，优先使用该文件中扩展名到Content-Type的映射，文件每行格式为：
+    扩展名 Content-Type，如：.md text/markdown，以#开头的行为注释。
+    如果指定了--detect-content-type sniff，对于映射文件中没有的扩展名，根据文件开头最多512
+    字节的内容判断Content-Type。通过--meta指定的Content-Type优先级最高。
+
+--stat-summary选项
+
+    批量操作时，如果指定了--stat-summary选项，ossutil在执行结束后输出每个文件的耗时、字节数、重试次数
+    和平均速度，以及耗时和速度的最小值、p50、p90、p99和最大值，便于找出传输慢的文件和不稳定的路径。
+    取值为stdout时按耗时从大到小打印到标准输出，否则将每个文件的统计信息写入该值指定的csv文件。
+
+--list-split选项
+
+    批量下载或拷贝时，如果prefix下的object数量巨大，可以指定--list-split选项将prefix下的key
+    空间分片，ossutil并发列举各个分片，并将列举到的object合并到同一个处理队列中。取值为auto时
+    按prefix后的首字符分片，也可以指定逗号分隔的相对于prefix的分片点，如：--list-split a,g,n,t。
+    分片列举时object的处理顺序与字典序不同。
+
+通配符
+
+    源url可以包含shell风格的通配符*、?和[...]，需要用引号括起来避免被shell展开，如：
+    ossutil cp 'logs/app-2024-*.log' oss://bucket/logs/
+    ossutil cp 'oss://bucket/data/2024-0[1-6]/*.csv' ./
+    ossutil列举第一个通配符所在路径之前的目录或者prefix，将匹配的文件或object作为批量操作处理，
+    它们在目标中保留相对于该目录或prefix的路径，如上例中data/2024-01/a.csv下载为./2024-01/a.csv。
+    *不匹配路径分隔符/，只有指定了--recursive时才会处理匹配的目录下的文件或object。如果源路径
+    本身就是存在的文件或object（非--recursive时），则不进行通配。
+
+多个源url
+
+    可以指定多个源url和一个目标url，如：ossutil cp file1 file2 dir3/ oss://bucket/prefix/ -r，
+    所有源url作为同一个批量任务处理，共享并发数、进度和失败统计，目标url被当作目录或者prefix。
+    下载或拷贝时，所有源url必须在同一个bucket中，非--recursive时每个源url都必须是object。
+    指定了多个源url时不进行通配，也不支持--retry-from选项。
+
+--dirobject选项
+
+    批量上传时，ossutil默认为每个目录生成一个以/结尾的空目录对象，指定--disable-dir-object选项则不生成。
+    如果指定了--dirobject选项，ossutil只为空目录生成目录对象，非空目录的结构由其中文件的路径体现。
+    批量下载时，ossutil根据大小为0且以/结尾的目录对象在本地重建空目录，因此上传和下载往返后目录结构
+    保持不变，如：
+    ossutil cp your_dir oss://bucket/prefix/ -r --dirobject
+    ossutil cp oss://bucket/prefix/ your_dir -r --dirobject
+    --dirobject选项不能和--disable-dir-object选项同时使用。
+
+--max-objects和--max-bytes选项
+
+    递归操作时，可以指定--max-objects和--max-bytes选项限制最多处理的文件或object数量和字节数，防止误操作
+    大bucket中错误的prefix。ossutil在开始操作前先统计源端的文件或objects，超过限制时不做任何操作直接
+    退出；执行过程中如果处理的数量或字节数超过限制（如统计后源端新增了object），ossutil不再开始新的
+    文件并终止命令。rm和set-acl命令也支持这两个选项。如：
+    ossutil cp oss://bucket/prefix/ your_dir -r --max-objects 10000 --max-bytes 10737418240
+
+--start-after选项
+
+    批量下载或拷贝时，可以指定--start-after选项从该key之后开始列举源object，key不需要url编码，
+    可以用于在中断后从上次处理到的key处继续。
+
+--if-match、--if-none-match和--if-unmodified-since选项
+
+    下载时可以指定这些选项，只有object满足条件时才会下载，否则报错PreconditionFailed，可用于
+    实现乐观并发控制，避免下载到其他人已经修改过的object。--if-match和--if-none-match的值为
+    object的etag，--if-unmodified-since的值可以为http日期（如：Mon, 02 Jan 2006 15:04:05 GMT）、
+    RFC3339时间或者unix时间戳。ossutil在下载前检查object的元信息，下载时--if-match和
+    --if-unmodified-since也会随请求发送，确保下载过程中object未被修改。
+
+--forbid-overwrite选项
+
+    上传或拷贝时，如果指定了--forbid-overwrite选项，ossutil在PutObject、CopyObject和
+    CompleteMultipartUpload请求中设置x-oss-forbid-overwrite头，目标object已存在时oss返回409
+    FileAlreadyExists，ossutil报错而不会覆盖已有的object。批量操作时出错的文件会记录到report文件。
+
+--callback-url、--callback-body和--callback-var选项
+
+    上传时如果指定了--callback-url，ossutil在PutObject和CompleteMultipartUpload请求中设置
+    x-oss-callback头，上传完成后oss向应用服务器发送回调请求，应用服务器的响应会随上传请求返回，
+    ossutil将其输出到屏幕上。回调失败时上传报错，但object已经上传成功。--callback-body为回调请求的
+    body，默认为bucket=${bucket}&object=${object}&etag=${etag}&size=${size}&mimeType=${mimeType}，
+    以{开头时以application/json格式发送；--callback-var指定自定义变量，格式为key1=value1#key2=value2，
+    变量名没有x:前缀时会自动添加，可以在--callback-body中以${x:key1}引用。appendfromfile在追加
+    文件最后一部分的请求中设置回调。
+
+--no-clobber和--ignore-existing选项
+
+    如果指定了--no-clobber选项（--ignore-existing与之相同），ossutil跳过所有已经存在的目标object
+    或者本地文件，不比较修改时间，也不会进行询问提示，适用于补齐中断的传输中缺失的文件。批量上传
+    或拷贝时，ossutil先列举一次目标prefix下的objects来判断目标是否存在，列举失败时对每个object
+    发送head请求；下载时检查本地文件是否存在。该选项不能和--update同时使用。列举的目标objects默认
+    保存在内存中，指定--low-memory时保存到系统临时目录中的临时leveldb数据库中，适用于目标prefix下
+    objects数量巨大而内存较小的场景。
+
+--append选项
+
+    上传时如果指定了--append选项，ossutil以追加方式上传文件：目标object为Appendable类型时，从object
+    当前的长度开始，将本地文件剩余的数据分块通过AppendObject追加到object末尾；目标object不存在时创建
+    Appendable类型的object；目标object为其他类型时报错。追加前ossutil比较object的crc64与本地文件对应
+    长度数据的crc64，不一致或者本地文件比object小时报错；本地文件与object长度相同时跳过。每块的大小
+    为--part-size，默认为100MB。适用于定期将不断增长的日志文件增量上传到oss，只能用于上传，不能和
+    --update、--no-clobber、--snapshot-path、--callback-url、--forbid-overwrite同时使用。
+
+--fanout选项
+
+    上传时如果指定了--fanout选项，第一个参数为本地文件，其后的参数均为目标url，ossutil只读取一次
+    本地文件，按--part-size（默认16MB）分块，将内存中的数据同时上传到所有的目标object，目标可以位于
+    不同的bucket、region和账号，其他账号的bucket的访问凭证在配置文件的Bucket-Profile中指定（参见config
+    命令）。各个目标的上传相互独立，某个目标失败时放弃该目标，其他目标继续上传。上传
+    完成后ossutil比较每个目标object的crc64与本地文件的crc64，报告失败的目标。适用于将构建产物同时分
+    发到多个bucket，不能和--recursive、--update、--no-clobber、--snapshot-path、--append同时使用。
+
+.ossignore文件
+
+    递归上传或者sync上传时，ossutil读取源目录及其子目录中的.ossignore文件，排除匹配的文件和目录，
+    语法与.gitignore相同：每行一个规则，#开头为注释，!开头表示重新包含，以/结尾只匹配目录，包含/的
+    规则相对于.ossignore所在的目录，不包含/的规则匹配任意层级的名称，**匹配任意层级的目录。例如：
+        node_modules/
+        .git/
+        build/**
+        *.log
+        !important.log
+    被排除的目录不会被遍历，sync --delete不会删除目标中被.ossignore排除的objects。指定
+    --disable-ossignore选项可以不使用.ossignore文件。
+
+--max-duration和--retry-budget选项
+
+    --max-duration指定整个命令的最长执行时间（如：90m、2h），--retry-budget指定整个命令所有文件的
+    总重试次数上限。超过限制后，ossutil不再开始新的文件，也不再重试，正在传输的文件结束后命令退出，
+    报告未开始的文件数量，并以退出码3结束，便于定时任务区分并重新执行。大文件的断点续传信息保留在
+    --checkpoint-dir中（失败的分片上传需要指定--keep-parts），配合--update或者--snapshot-path再次执行
+    相同的命令可以从中断处继续。
+
+中断
+
+    执行过程中按下Ctrl-C或者收到SIGTERM信号时，ossutil不再开始新的文件，也不再重试，等待正在传输的
+    文件结束，保存断点信息和report文件，输出已完成的统计信息以及继续执行的方法后，以退出码130结束。
+    再次按下Ctrl-C会立即退出。
+
+--keep-parts选项
+
+    大文件分片上传在重试后仍然失败，或者因为中断、--max-duration、--retry-budget不再重试而失败时，
+    ossutil默认删除（abort）该分片上传以及它的断点信息，避免未完成的分片在bucket中占用存储空间。删除
+    失败时，错误信息中包含upload id，并记录在--output-failed指定的文件中，可以之后通过rm -m删除。
+    指定--keep-parts时保留已上传的分片和断点信息，再次执行相同的命令可以从中断处继续上传。
+
+--as-of选项
+
+    从开启版本控制的bucket下载时，--as-of指定一个时间点，ossutil通过ListObjectVersions列举所有版本，
+    对每个object选择最后修改时间不晚于该时间点的最新版本下载。该时间点最新的是删除标记或者当时还不
+    存在的object不会被下载，因此可以将整个前缀恢复到该时间点的状态。时间可以是日期（2006-01-02，本地
+    时间）、RFC3339时间、http date或者unix时间戳。--as-of只能用于下载，不能与--version-id、--list-split、
+    --retry-from或者多个源url一起使用。
+
+--progress-file选项
+
+    长时间运行的批量任务可以指定--progress-file，ossutil在执行过程中定期（与进度条的刷新周期相同）将进度
+    以json格式写入该文件，包括状态（running、succeeded或failed）、已完成和总的字节数及文件数、成功、跳过和
+    出错的文件数、平均速度、预计剩余时间（无法估计时为null）以及正在传输的文件和它们的开始时间。文件通过
+    重命名替换，读取方不会读到写了一半的内容，systemd、k8s探针等监控程序无需解析终端输出即可判断任务状态。
+
+--output选项
+
+    指定--output json或者--output yaml(或者全局的--output-format)时，进度和其他信息输出到标准错误，结束时
+    将汇总结果以json或者yaml格式输出到标准输出，包括操作类型、总的文件数和字节数、成功、跳过和出错的文件数、
+    传输和跳过的字节数、耗时(毫秒)、平均速度以及出错时的错误信息。下载到标准输出时不支持该选项。
+
+--staging-dir选项
+
+    下载的文件先写入--staging-dir指定的目录，crc64校验成功后再原子地重命名到目标路径，监控目标目录的
+    程序不会看到写了一半的文件。该目录需要和目标路径在同一个文件系统，否则ossutil在开始下载前报错。
+    下载中断后，未完成的文件及其断点信息保留在该目录中，再次执行相同的命令可以继续下载。
+
+--crc-retry-times选项
+
+    下载的数据的crc64与object的crc64不一致时，ossutil删除下载的数据及其断点信息，重新下载整个object，
+    最多重试--crc-retry-times指定的次数（默认为3），仍然不一致时报告数据损坏，本地不会留下不一致的
+    文件。指定--range或者--disable-crc64时不进行crc64校验。
+
+--dedup选项
+
+    上传时指定--dedup，ossutil先计算本地文件的crc64，目的端已经存在相同大小和crc64的object时跳过该文件，
+    适用于反复执行的发布构建产物等流程。已上传的和比较过的objects的大小和crc64记录在--hashdb指定的数据库
+    （默认为当前目录下的` + DefaultHashDBDir + `）中，记录与本地文件一致时直接跳过，不再发送HEAD请求；记录不存在或者
+    不一致时通过HEAD请求比较oss上存储的crc64。本地文件的crc64同样缓存在该数据库中，文件没有变化时不再
+    重新计算。该数据库认为目的端的objects只被ossutil修改，objects被其他程序修改或者删除后，需要删除该
+    数据库或者改用--checksum。--dedup只能用于上传，不能与--update、--no-clobber、--snapshot-path或者--append
+    同时使用。
+
+磁盘空间检查
+
+    下载时，ossutil检查目标目录所在文件系统的可用空间，递归下载时列举到的文件总大小超过可用空间，
+    或者超过--max-disk-usage指定的字节数时，ossutil不再开始新的文件并尽早报错退出，而不是在写入最后
+    的文件时才因为磁盘空间不足而失败。指定了--update、--no-clobber或者--snapshot-path时，已存在的文件
+    可能被跳过，列举时不做检查，每个文件开始下载前仍然会检查剩余的空间。
+
+windows上的文件名
+
+    在windows上下载时，超过MAX_PATH长度的路径使用\\?\形式的扩展长度路径。object名中包含保留名称（如：
+    CON、NUL、COM1、LPT1，包括带扩展名的nul.txt）、非法字符（<>:"|?*）或者以点、空格结尾的部分，
+    会按照--windows-name-mapping选项映射为合法的文件名：underscore（默认）将非法字符和结尾的点、空格
+    替换为_，并在保留名称后加_，如CON.txt保存为CON_.txt；percent将这些字符编码为%XX，如CON保存为%43ON；
+    none不做映射。被映射的object会逐个输出，并在结束时输出数量。sync下载时使用相同的映射判断需要删除
+    的文件。
+
+--local-encoding选项
+
+    oss中的object名为UTF-8编码。在旧版windows系统上创建的压缩包在linux或者macOS上解压后，文件名可能是
+    gbk或者shift-jis编码，直接上传会产生乱码的object名。指定--local-encoding后，上传时ossutil将本地文件名
+    从该编码转换为UTF-8的object名，下载时将object名转换为该编码的本地文件名，sync使用相同的转换判断需要
+    删除的文件或者objects。不能转换的名称保持不变。windows上的文件名总是unicode，不支持该选项。
+
+--dry-run选项
+
+    指定--dry-run（或者--plan）时，ossutil只列举需要处理的文件，不传输文件，结束时输出文件数量、大小，
+    以及预计的PUT、POST、GET、HEAD、LIST、DELETE请求次数和下载产生的外网流出流量，并按照--price指定的
+    价格估算费用：PUT、POST、DELETE按照put类请求计费，GET、HEAD、LIST按照get类请求计费。大文件按照
+    --part-size或者自动计算的分片大小计算分片请求。预估不包括失败重试产生的请求，实际费用以账单为准。
+
+--output-failed和--retry-from选项
+
+    批量操作时指定--output-failed failed.csv，ossutil将每个失败的文件或者object以及错误信息以csv格式
+    （key,error）记录到该文件中，key为相对于源url的路径。处理完成后，使用相同的命令并指定
+    --retry-from failed.csv，ossutil只处理文件中记录的key，不再重新扫描源端的所有文件或者objects。
+    两个选项可以指定同一个文件，重试仍然失败的key会记录到新的文件中。--retry-from只能和--recursive
+    一起使用，源端不存在的文件会被忽略。
+
+命名管道和特殊文件
+
+    ossutil支持从命名管道或者字符设备上传，如：cat data | ossutil cp /dev/stdin oss://bucket/object。
+    数据的大小在读取前未知，ossutil按照--part-size（默认16MB）读取数据并流式分片上传，不足一个分片的数据
+    使用PutObject上传，最大object大小为分片大小的10000倍。数据只能读取一次，只有内存中的分片会被重试，
+    且不支持--update和--snapshot-path。进度条显示已传输的大小而不显示百分比。
+    下载时目的文件可以是命名管道或者字符设备，如：ossutil cp oss://bucket/object /dev/stdout | gzip -d，
+    ossutil直接写入目的文件，不使用临时文件，只在写入数据前重试。下载到/dev/stdout时，进度和其他输出信息
+    打印到stderr。
+
+--content-md5选项
+
+    上传时指定--content-md5，ossutil计算每个object或者每个分片的MD5并携带Content-MD5请求头，数据在
+    传输过程中损坏时oss拒绝写入并返回InvalidDigest错误，适用于要求MD5校验的合规场景。计算MD5需要预先读取
+    数据，不超过16MB的数据在内存中计算，更大的数据会先写入系统临时目录中的临时文件，会增加上传的耗时。
+    该选项与crc64校验相互独立。
+
+--cloudbox-id选项
+
+    访问云盒内的bucket时，指定--cloudbox-id为云盒的id，--endpoint可以是云盒的管控域名（如：
+    cn-hangzhou.oss-cloudbox-control.aliyuncs.com），ossutil通过管控域名列举云盒找到该云盒的数据
+    域名，再使用数据域名上传、下载或者拷贝，请求使用v4签名，并以云盒的id作为签名的region，product为
+    oss-cloudbox，无需再指定数据域名和--region。数据域名在一次命令中只查询一次。如果--endpoint已经是
+    该云盒的数据域名，则直接使用。ls和stat命令同样支持该选项。
+
+--spec选项
+
+    --spec指定yaml格式的任务描述文件，在文件中声明命令的参数和选项，使复杂的周期性任务可以像代码
+    一样在git中评审和管理，而不必写在很长的命令行中。cp、sync和rm命令支持该选项。文件格式如下：
+
+        version: 1                  # 必填，目前为1
+        command: cp                 # 可选，指定时必须与执行的命令相同
+        args: [./dist/, oss://bucket/dist/]
+        options:
+          recursive: true
+          jobs: 10
+          include: ["*.html", "*.js"]
+          meta:
+            Cache-Control: max-age=600
+
+    options的键为选项的长名称（不含--），开关选项的值为true或false，--include和--exclude可以是
+    字符串或者字符串列表，--meta可以是header到value的映射，其他选项的值为字符串或数字。命令行
+    没有参数时使用args，命令行指定的选项优先于文件中的选项，但文件中的--include和--exclude追加
+    在命令行的规则之后。文件中不认识的字段、选项或者命令不支持的选项都会报错。
+
+s3://格式的url
+
+    cloud_url也可以是s3://bucket[/object]格式，此时请求发送到s3兼容服务，并使用aws签名v4，
+    访问密钥读取自环境变量AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY和AWS_SESSION_TOKEN，region
+    读取自环境变量AWS_REGION或AWS_DEFAULT_REGION(默认为us-east-1)，endpoint通过--s3-endpoint
+    选项或者环境变量AWS_ENDPOINT_URL_S3、AWS_ENDPOINT_URL指定。s3://格式的url目前支持ls、cp、
+    rm和stat命令。s3与oss之间拷贝时，数据按范围读取并以If-Match限定为源object开始拷贝时的etag，
+    源object在拷贝过程中被修改时，丢弃已上传的分片和断点续传记录，重新拷贝。
+
+oss-internal://和oss-acc://格式的url
+
+    cloud_url也可以是oss-internal://bucket[/object]或oss-acc://bucket[/object]格式，除了endpoint外与
+    oss://相同，该bucket的请求分别发送到bucket所在endpoint对应的内网endpoint（如：
+    oss-cn-hangzhou-internal.aliyuncs.com）或者传输加速endpoint（oss-accelerate.aliyuncs.com），
+    因此同一个命令中可以为不同的参数选择不同的网络，而不需要修改全局的--endpoint。bucket的endpoint
+    需要是oss-cn-hangzhou.aliyuncs.com这样的格式，不支持cname和云盒，同一个bucket不能同时使用两种格式。
+    如：ossutil cp oss-internal://src-bucket/dir/ oss-acc://dest-bucket/dir/ -r
+
+    在s3和oss之间拷贝时，ossutil按范围读取源object并逐个分片写入目标的分片上传中，不会在本地暂存数据；
+    大于--bigfile-threshold的object会在--checkpoint-dir中记录已上传的分片，再次执行相同命令时从断点处继续。
+
 用法：
 
     该命令有三种用法：
@@ -536,6 +919,9 @@ var specChineseCopy = SpecText{
     ossutil cp local_dir oss://bucket1/b --tagging "tagA=A&tagB=B" -r
     上传的同时设置两个tagging,key分别为tagA和tagB,value分别为A和B
 
+    ossutil cp local_dir s3://bucket1/backup/ -r --retain-until 2026-01-01 --retention-mode COMPLIANCE
+    上传到s3的同时设置保留截止时间，2026-01-01之前objects不能被删除或者覆盖
+
     2) 从oss下载object
     假设oss上有下列objects：
         oss://bucket/abcdir1/a
@@ -587,6 +973,15 @@ var specChineseCopy = SpecText{
     ossutil cp oss://bucket/object local_file --version-id versionId
     指定object版本下载
 
+    ossutil cp oss://bucket/dir/ local_dir -r --as-of "2024-06-01T00:00:00Z"
+    下载dir/下的objects在2024-06-01T00:00:00Z时的版本，恢复整个目录到该时间点
+
+    ossutil cp oss://bucket/dir/ local_dir -r --progress-file /var/run/ossutil/progress.json
+    下载dir/下的objects，执行过程中定期将进度写入/var/run/ossutil/progress.json
+
+    ossutil cp oss://bucket/dir/ local_dir -r --output json
+    下载dir/下的objects，结束时以json格式输出汇总结果
+
     ossutil cp oss://bucket/dir/ local_dir -r --only-current-dir
     只下载当前目录下的object, 忽略其他子目录
 
@@ -676,8 +1071,10 @@ var specEnglishCopy = SpecText{
 
 	syntaxText: ` 
     ossutil cp file_url cloud_url  [-r] [-f] [-u] [--enable-symlink-dir] [--disable-all-symlink] [--disable-ignore-error] [--only-current-dir] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--snapshot-path=sdir] [--payer requester]
+    ossutil cp file_url cloud_url cloud_url... --fanout [--part-size=size] [--payer requester]
     ossutil cp cloud_url file_url  [-r] [-f] [-u] [--only-current-dir] [--output-dir=odir] [--disable-ignore-error] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--range=x-y] [--payer requester]
     ossutil cp cloud_url cloud_url [-r] [-f] [-u] [--only-current-dir] [--output-dir=odir] [--disable-ignore-error] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--payer requester]
+    ossutil cp [src_url dest_url] --spec job.yaml
 `,
 
 	detailHelpText: ` 
@@ -795,6 +1192,18 @@ var specEnglishCopy = SpecText{
     ossutil will set tagging for all uploaded objects. 
     If you set more than one tagging at a time, you must use double quotes, such as "tagA=A&tagB=B"
 
+--retain-until, --retention-mode, --legal-hold option
+
+    These options set the object level retention(object lock) of the objects uploaded or copied, for
+    the backup tools that need object level immutability: --retain-until specifies the time until which
+    the objects are retained, the value can be a date(2006-01-02, local time) or RFC3339 time,
+    --retention-mode specifies the mode GOVERNANCE(default) or COMPLIANCE, --legal-hold puts the objects
+    on legal hold. The object lock of the destination bucket must be enabled.
+    Only s3:// destinations support the object level retention, the objects of oss are protected by
+    the retention policy of the bucket, please use the worm command. The uploads with retention compute
+    Content-MD5 automatically. The stat command shows RetainUntil, RetentionMode and LegalHold of the
+    objects.
+
 --acl option
 
     This option will set acl on the specified objects. If --recursive option is specified, 
@@ -953,6 +1362,27 @@ Resume copy of big file:
     4) If you remove the uncompleted multipart upload tasks by rm command, may cause resume upload/download/copy 
         fail the next time(Error: NoSuchUpload). If you want to reupload/download/copy the entire file again, 
         please remove the checkpoint file in checkpoint directory.
+    5) ossutil holds the lock file .ossutil-*.lock named by the command arguments in the checkpoint 
+        directory while the command is running, the commands with the same arguments(e.g., the overlapped 
+        cron jobs) can't run at the same time, the later one reports error and exits. The commands of 
+        different sources or destinations can use the same checkpoint directory at the same time. The 
+        stale lock left by the process exited abnormally is reported and taken over in the next run.
+    6) If --part-crc is specified, in operation (1), ossutil computes the crc64 of each part while 
+        sending it and records it in the checkpoint file, after completing, the crc64s of all parts are 
+        combined and compared with the crc64 of the object returned by oss, so the file is read only 
+        once, not again for the verification. --part-crc can't be used with --disable-crc64.
+    7) If --job-id is specified, the checkpoints are recorded in the sub directory of --checkpoint-dir 
+        named by the job.
+    8) The checkpoint files contain the object names and the upload ids, if --encrypt-checkpoint is 
+        specified, they are encrypted by AES-256-GCM each time they are written, and decrypted when they 
+        are read, the passphrase is read from the environment variable OSSUTIL_CHECKPOINT_KEY. The 
+        checkpoints of multipart upload, download and copy are read and written by the sdk, they are in 
+        plain text only for the short time before the sdk reads them. The checkpoints left in plain text 
+        in the directory are encrypted when the command starts, the encrypted checkpoints are reported as 
+        error if --encrypt-checkpoint is not specified.
+    9) If --checkpoint-expire-days is specified, the checkpoint files not modified for more than the days 
+        in --checkpoint-dir and the sub directories of its jobs are removed when the command starts, the 
+        directories of the running jobs are skipped.
 
 
 Performance Tuning:
@@ -986,6 +1416,28 @@ Performance Tuning:
     size is too big, it may influence the actual parallel num, so, please if specify the option, please set it 
     to a reasonable value. 
 
+--max-idle-conns, --max-conns-per-host, --idle-conn-timeout and --http2 options (HTTP Connections)
+
+    The http connection pool keeps at most 100 idle connections by default, if jobs num multiply by parallel 
+    num is bigger than it, the connections are closed and reconnected frequently. --max-idle-conns increases 
+    the idle connections, --idle-conn-timeout sets the timeout of the idle connections in seconds, and 
+    --max-conns-per-host limits the total connections to each host. They can also be set in the Default 
+    section of the config file.
+
+    --http2 uses HTTP/2 if the server or the proxy supports it, the requests share the connections, and 
+    --read-timeout only limits the time waiting for the response header then.
+
+--max-qps option (Request Rate)
+
+    When uploading/downloading/copying massive small files, the request rate may trip the rate limit of 
+    the bucket, --max-qps limits the requests sent per second, the requests of all the concurrent tasks 
+    of the command including the retries are limited together, a burst of one second of requests is 
+    allowed. It can be set as maxQPS in the Default section of the config file for all commands, the 
+    commands run by daemon at the same time are limited separately. The requests wait before they are 
+    signed, but the sdk stamps the date of the request before the wait, so please don't set jobs num 
+    multiply by parallel num much bigger than --max-qps multiply by 900(15 minutes), otherwise the 
+    requests may fail with RequestTimeTooSkewed for waiting too long.
+
 
 Batch file migration:
 
@@ -1007,6 +1459,390 @@ Batch file migration:
         ossutil cp your_dir oss://your_bucket -r -f -u --shapshot-path=your-path
 
 
+--mime-map and --detect-content-type option
+
+    When uploading files, ossutil sets Content-Type of objects by the file extension and the
+    built-in table by default. If --mime-map option is specified, the extension to Content-Type
+    map in the file is used first, each line of the file is: extension Content-Type, e.g.,
+    .md text/markdown, the lines starting with # are comments.
+    If --detect-content-type sniff is specified, for the extensions not in the map, Content-Type
+    is decided by at most 512 leading bytes of the file. Content-Type specified by --meta takes
+    precedence over all of them.
+
+--stat-summary option
+
+    If --stat-summary option is specified, ossutil outputs the cost, bytes, retries and average
+    speed of every file after the run, together with the min, p50, p90, p99 and max of cost and
+    speed, which helps to identify slow files and flaky paths in batch jobs. If the value is 
+    stdout, the statistics are printed to stdout from slowest to fastest, otherwise the 
+    statistics of every file are written to the csv file specified by the value.
+
+--list-split option
+
+    When downloading or copying in batch and there is a huge number of objects under the prefix,
+    --list-split option can be specified to split the keyspace under the prefix into shards, 
+    ossutil lists the shards concurrently and merges the listed objects into the same processing
+    queue. The value auto splits the keyspace by the first character after the prefix, you can 
+    also specify split points relative to the prefix separated by comma, e.g., --list-split a,g,n,t.
+    The objects are not processed in lexicographical order when listing by shards.
+
+Glob
+
+    The source url can contain the shell-style wildcards *, ? and [...], quote it to avoid the
+    expansion by shell, e.g.,
+    ossutil cp 'logs/app-2024-*.log' oss://bucket/logs/
+    ossutil cp 'oss://bucket/data/2024-0[1-6]/*.csv' ./
+    ossutil lists the directory or prefix before the path containing the first wildcard, and
+    processes the matched files or objects as a batch operation, they keep the paths relative to
+    the directory or prefix in the destination, e.g., data/2024-01/a.csv in the above is downloaded
+    as ./2024-01/a.csv. The * doesn't match the separator /, the files or objects under the matched
+    directories are processed only if --recursive is specified. If the source itself is an existing
+    file or object(without --recursive), it's not expanded.
+
+Multiple source urls
+
+    You can specify multiple source urls and one destination url, e.g.,
+    ossutil cp file1 file2 dir3/ oss://bucket/prefix/ -r
+    All the source urls are processed as one batch job, which shares the concurrency, progress and
+    failure statistics, and the destination url is treated as a directory or prefix. When downloading
+    or copying, all the source urls must be in the same bucket, and every source url must be an
+    object without --recursive. The glob is not expanded and --retry-from option is not supported
+    when there are multiple source urls.
+
+--dirobject option
+
+    When uploading in batch, ossutil generates an empty directory object ending with / for every
+    directory by default, and doesn't generate them if --disable-dir-object is specified. If 
+    --dirobject option is specified, ossutil generates directory objects only for the empty 
+    directories, the structure of the other directories is kept by the paths of their files.
+    When downloading in batch, ossutil recreates the empty local directories from the directory
+    objects whose sizes are 0 and names end with /, so the directory structure survives a round
+    trip, e.g.,
+    ossutil cp your_dir oss://bucket/prefix/ -r --dirobject
+    ossutil cp oss://bucket/prefix/ your_dir -r --dirobject
+    --dirobject option can't be used together with --disable-dir-object option.
+
+--max-objects and --max-bytes option
+
+    When operating recursively, --max-objects and --max-bytes option can be specified to limit
+    the number and the bytes of the files or objects to be operated, which protects against 
+    operating on the wrong prefix of a giant bucket by accident. ossutil counts the files or
+    objects in the source before the operation starts, and exits without operating anything if
+    the limits are exceeded. If the limits are exceeded during the operation(e.g., objects are
+    added to the source after counting), ossutil doesn't start more files and aborts the command.
+    rm and set-acl command support the two options too. e.g.,
+    ossutil cp oss://bucket/prefix/ your_dir -r --max-objects 10000 --max-bytes 10737418240
+
+--start-after option
+
+    When downloading or copying in batch, --start-after option can be specified to list the
+    source objects after the key, the key is not url encoded, it can be used to resume from 
+    the last processed key after interruption.
+
+--if-match, --if-none-match and --if-unmodified-since option
+
+    These options can be specified when downloading, the object is downloaded only when it meets
+    the conditions, otherwise PreconditionFailed error happens, they can be used to implement 
+    optimistic concurrency and avoid downloading the object changed by others. The value of 
+    --if-match and --if-none-match is the etag of object, the value of --if-unmodified-since can 
+    be http date(like: Mon, 02 Jan 2006 15:04:05 GMT), RFC3339 time or unix timestamp. ossutil 
+    checks the meta of the object before downloading, and --if-match and --if-unmodified-since are
+    also sent with the download requests to make sure the object is not changed during downloading.
+
+--forbid-overwrite option
+
+    If --forbid-overwrite option is specified when uploading or copying, ossutil sets the header 
+    x-oss-forbid-overwrite on PutObject, CopyObject and CompleteMultipartUpload requests, oss returns
+    409 FileAlreadyExists if the destination object exists, and ossutil reports the error instead of
+    overwriting the existing object. In batch operation, the failed files are recorded to report file.
+
+--callback-url, --callback-body and --callback-var option
+
+    If --callback-url is specified when uploading, ossutil sets the header x-oss-callback on PutObject
+    and CompleteMultipartUpload requests, oss sends the callback request to the application server
+    after uploading, the response of the application server is returned with the upload request, and
+    ossutil prints it. If the callback fails, the upload reports error, but the object is uploaded.
+    --callback-body is the body of the callback request, default is
+    bucket=${bucket}&object=${object}&etag=${etag}&size=${size}&mimeType=${mimeType}, it's sent as
+    application/json if it starts with {; --callback-var specifies the custom variables, the format is
+    key1=value1#key2=value2, the prefix x: is added if the name doesn't have it, and they can be
+    referenced in --callback-body like ${x:key1}. appendfromfile sets the callback on the request
+    appending the last part of the file.
+
+--no-clobber and --ignore-existing option
+
+    If --no-clobber option(--ignore-existing is the same) is specified, ossutil skips every 
+    destination object or local file which already exists, without comparing the modified time
+    or asking for confirmation, it is the simplest way to fill gaps in a partially completed 
+    transfer. When uploading or copying in batch, ossutil lists the objects under the destination
+    prefix once to decide whether the destination exists, and heads every object if the listing
+    fails; when downloading, ossutil checks whether the local file exists. The option can't be 
+    used together with --update. The listed destination objects are kept in memory by default,
+    they are spooled to the temp leveldb database in the system temp directory if --low-memory is
+    specified, for the huge number of objects under the destination prefix with small memory.
+
+--append option
+
+    If --append option is specified when uploading, ossutil uploads the files in append mode: if the
+    destination object is Appendable, the remaining data of the local file after the current length
+    of the object is appended to the end of the object by successive AppendObject calls; if the
+    destination object doesn't exist, an Appendable object is created; if the destination object is
+    of other type, error is reported. Before appending, ossutil compares the crc64 of the object
+    with the crc64 of the same length of the local file, and reports error if they are different
+    or the local file is smaller than the object; the file is skipped if it has the same length with
+    the object. The size of each appended chunk is --part-size, default is 100MB. The option is for
+    shipping the growing log files to oss incrementally, it only works with upload, and can't be used
+    together with --update, --no-clobber, --snapshot-path, --callback-url or --forbid-overwrite.
+
+--fanout option
+
+    If --fanout option is specified when uploading, the first argument is the local file, and
+    all the other arguments are the destination urls. ossutil reads the local file only once by
+    the parts of --part-size(default is 16MB), and uploads the data in memory to all the destination
+    objects concurrently, the destinations can be in different buckets, regions and accounts, the
+    credentials of the buckets of other accounts are specified in Bucket-Profile of the config
+    file(see the config command). The destinations are uploaded independently, the failed destination is dropped and the others go on. After
+    uploading, ossutil compares the crc64 of each destination object with the crc64 of the local
+    file, and reports the failed destinations. The option is for distributing the build artifacts to
+    multiple buckets, it can't be used together with --recursive, --update, --no-clobber,
+    --snapshot-path or --append.
+
+.ossignore file
+
+    When uploading recursively or syncing from local, ossutil reads the .ossignore files in the 
+    source directory and its sub directories, and excludes the matching files and directories. 
+    The syntax is the same as .gitignore: one rule per line, the line starting with # is comment,
+    the rule starting with ! includes the path again, the rule ending with / only matches 
+    directories, the rule containing / is relative to the directory of the .ossignore, the rule 
+    without / matches the name at any level, and ** matches any levels of directories. e.g.:
+        node_modules/
+        .git/
+        build/**
+        *.log
+        !important.log
+    The excluded directories are not walked, and sync --delete doesn't delete the destination 
+    objects excluded by .ossignore. Specify --disable-ossignore option to ignore the .ossignore files.
+
+--max-duration and --retry-budget option
+
+    --max-duration specifies the max duration of the whole command(e.g., 90m, 2h), --retry-budget 
+    specifies the max retries of all the files in the whole command. After the limit is exceeded, 
+    ossutil doesn't start new files or retry any more, exits after the transferring files finish, 
+    reports the number of files not started, and exits with code 3, so that the cron job can tell 
+    it and run again. The resume information of big files is kept in --checkpoint-dir(the failed 
+    multipart uploads need --keep-parts), run the same command again with --update or --snapshot-path
+    to continue from where it stops.
+
+Interruption
+
+    When Ctrl-C is pressed or SIGTERM is received, ossutil doesn't start new files or retry any more,
+    waits for the transferring files to finish, flushes the checkpoints and the report file, prints 
+    the summary of the finished files and how to continue, then exits with code 130. Pressing Ctrl-C
+    again exits immediately.
+
+--keep-parts option
+
+    When the multipart upload of a big file still fails after the retries, or fails without retry 
+    because of the interruption, --max-duration or --retry-budget, ossutil aborts the multipart upload
+    and removes its checkpoint by default, so that the unfinished parts don't consume the storage of
+    the bucket. If the abort fails, the upload id is in the error message, which is also recorded in
+    the file of --output-failed, the parts can be removed by rm -m later. With --keep-parts, the 
+    uploaded parts and the checkpoint are kept, run the same command again to continue the upload.
+
+--as-of option
+
+    When downloading from the versioned bucket, --as-of specifies a point in time, ossutil lists
+    all the versions by ListObjectVersions, and downloads the newest version of each object whose
+    last modified time is not later than that time. The object whose newest version at that time
+    is a delete marker, or which didn't exist then, is not downloaded, so that the whole prefix
+    can be recovered to the state at that time. The time can be date(2006-01-02, local time),
+    RFC3339 time, http date or unix timestamp. --as-of only works with download, and can't be used
+    together with --version-id, --list-split, --retry-from or multiple source urls.
+
+--progress-file option
+
+    For the long running jobs, --progress-file can be specified, ossutil writes the progress in json
+    to the file periodically (at the same interval as the progress bar), including the state(running,
+    succeeded or failed), the done and total bytes and files, the number of ok, skipped and error
+    files, the average speed, the ETA(null if it can't be estimated), and the files being transferred
+    with their start time. The file is replaced by rename, the readers never see a partially written
+    file, so that the supervisors such as systemd or k8s probes can watch the job without parsing the
+    output of the terminal.
+
+--output option
+
+    With --output json or --output yaml(or the global --output-format), the progress and the other
+    messages are printed to stderr, and the summary is printed to stdout in json or yaml at the end,
+    including the operation, the total files and bytes, the number of ok, skipped and error files,
+    the transferred and skipped bytes, the duration in milliseconds, the average speed, and the error
+    if it fails. It doesn't work with downloading to stdout.
+
+--staging-dir option
+
+    The downloading files are written to the directory specified by --staging-dir first, and renamed 
+    to the destination atomically after the crc64 is verified, so that the programs watching the 
+    destination directory never see partially written files. The directory should be on the same 
+    filesystem as the destination, otherwise ossutil reports error before downloading. If the 
+    download is interrupted, the unfinished files are kept in the directory with their checkpoints,
+    run the same command again to continue.
+
+--crc-retry-times option
+
+    If the crc64 of the downloaded data doesn't match the crc64 of the object, ossutil removes the
+    downloaded data and its checkpoint, and downloads the whole object again, at most the times
+    specified by --crc-retry-times(3 by default). If it still doesn't match, ossutil reports the data
+    is corrupted, no mismatched file is left on disk. The crc64 isn't verified if --range or
+    --disable-crc64 is specified.
+
+--dedup option
+
+    If --dedup is specified when uploading, ossutil calculates the crc64 of the local file first, and
+    skips the file if the destination object with the same size and crc64 already exists, which
+    suits the repeated pipelines such as publishing the build artifacts. The size and the crc64 of
+    the uploaded and the compared objects are recorded in the database specified by --hashdb(` + DefaultHashDBDir + `
+    in the current directory by default), the file is skipped without the HEAD request if the record
+    matches the local file, otherwise the crc64 stored in oss is compared by the HEAD request. The
+    crc64 of the local files is cached in the database too, it isn't calculated again if the file
+    is unchanged. The database assumes the destination objects are only modified by ossutil, remove
+    it or use --checksum instead if the objects are modified or deleted by other programs. --dedup
+    only works with upload, it can't be used together with --update, --no-clobber, --snapshot-path
+    or --append.
+
+Disk space check
+
+    When downloading, ossutil checks the free space of the filesystem of the destination directory.
+    If the total size of the listed files exceeds the free space or the bytes specified by 
+    --max-disk-usage when downloading recursively, ossutil doesn't start new files and fails early,
+    rather than failing with no space left when writing the last files. If --update, --no-clobber 
+    or --snapshot-path is specified, the existing files may be skipped, so the check is not done
+    when listing, but the remaining space is still checked before each file is downloaded.
+
+File names on windows
+
+    When downloading on windows, the paths longer than MAX_PATH are accessed by the \\?\ extended-length
+    paths. The parts of object names containing the reserved names(e.g., CON, NUL, COM1, LPT1, including
+    nul.txt with extension), the invalid characters(<>:"|?*) or the trailing dots and spaces are mapped
+    to valid file names by --windows-name-mapping option: underscore(default) replaces the invalid
+    characters and the trailing dots and spaces with _, and appends _ to the reserved names, e.g., 
+    CON.txt is saved as CON_.txt; percent encodes the characters as %XX, e.g., CON is saved as %43ON;
+    none doesn't map the names. The mapped objects are printed one by one, and the count is printed
+    in the end. sync uses the same mapping to decide the files to be removed when downloading.
+
+--local-encoding option
+
+    The object names in oss are UTF-8. The file names in the archives created on legacy windows systems
+    may be gbk or shift-jis after being extracted on linux or macOS, uploading them directly makes 
+    mojibake object names. If --local-encoding is specified, ossutil converts the local file names from
+    the encoding to UTF-8 object names when uploading, and converts the object names to the local file
+    names in the encoding when downloading, sync uses the same conversion to decide the files or objects
+    to be removed. The names can't be converted are kept. The file names on windows are always unicode,
+    so the option doesn't work on windows.
+
+--dry-run option
+
+    If --dry-run(or --plan) is specified, ossutil only lists the files to be processed without 
+    transferring them, and prints the number and size of the files, the estimated PUT, POST, GET, HEAD,
+    LIST and DELETE requests and the outbound traffic of downloading in the end, then estimates the cost
+    by the prices specified by --price: PUT, POST and DELETE are charged as put type requests, GET, HEAD 
+    and LIST are charged as get type requests. The part requests of big files are calculated by 
+    --part-size or the part size calculated automatically. The estimation doesn't include the requests
+    of retries, the bill is the final cost.
+
+--output-failed and --retry-from option
+
+    If --output-failed failed.csv is specified in batch operation, ossutil records every failed file
+    or object with its error to the file in csv format(key,error), the key is the path relative to the
+    source url. After the command finishes, run the same command with --retry-from failed.csv, ossutil
+    only operates the keys recorded in the file, instead of rescanning all the files or objects of the
+    source. The two options can be the same file, the keys failed again are recorded to the new file.
+    --retry-from only works with --recursive, the files not existing in the source are ignored.
+
+Named pipe and special file
+
+    ossutil supports uploading from the named pipe or the character device, e.g., cat data | 
+    ossutil cp /dev/stdin oss://bucket/object. The size of the data is unknown before it's read,
+    ossutil reads the data by --part-size(16MB by default) and uploads it by streaming multipart 
+    upload, the data less than one part is uploaded by PutObject, the max object size is 10000 times
+    the part size. The data can be read only once, so only the parts in memory are retried, and 
+    --update and --snapshot-path don't work. The progress bar shows the transferred size without the
+    percentage. The destination of download can be the named pipe or the character device, e.g., 
+    ossutil cp oss://bucket/object /dev/stdout | gzip -d, ossutil writes the destination file 
+    directly without the temp file, and retries only before any data is written. When downloading 
+    to /dev/stdout, the progress and the other messages are printed to stderr.
+
+--content-md5 option
+
+    If --content-md5 is specified when uploading, ossutil computes the MD5 of every object or every 
+    part and sends the Content-MD5 header, oss rejects the write with InvalidDigest error if the data
+    is corrupted in transit, which is useful for the compliance environments that mandate the MD5 
+    validation. The data is read ahead to compute the MD5, the data not larger than 16MB is computed 
+    in memory, the larger data is written to the temp file in the system temp directory first, which 
+    makes the upload slower. The option is independent of the crc64 check.
+
+--cloudbox-id option
+
+    To access the buckets in the cloud box, specify --cloudbox-id as the id of the cloud box, 
+    --endpoint can be the control endpoint of the cloud box(e.g., 
+    cn-hangzhou.oss-cloudbox-control.aliyuncs.com), ossutil lists the cloud boxes by the control 
+    endpoint to find the data endpoint of the cloud box, and uploads, downloads or copies by the 
+    data endpoint. The requests are signed by v4 signature with the cloud box id as the region and 
+    oss-cloudbox as the product, so the data endpoint and --region are not needed. The data 
+    endpoint is looked up only once in a command. If --endpoint is the data endpoint of the cloud 
+    box already, it's used directly. ls and stat commands support the option too.
+
+--spec option
+
+    --spec specifies a job spec file in yaml, which declares the arguments and options of the 
+    command, so that the complex recurring jobs can be reviewed and managed in git like the code,
+    rather than encoded in long command lines. cp, sync and rm commands support the option. The 
+    format of the file is:
+
+        version: 1                  # required, 1 at present
+        command: cp                 # optional, it must be the command executed if specified
+        args: [./dist/, oss://bucket/dist/]
+        options:
+          recursive: true
+          jobs: 10
+          include: ["*.html", "*.js"]
+          meta:
+            Cache-Control: max-age=600
+
+    The keys of options are the long names of the options without --, the values of the flags are
+    true or false, --include and --exclude can be a string or a list of strings, --meta can be a 
+    mapping of the headers to the values, and the values of the other options are strings or 
+    numbers. The args are used if there are no arguments in the command line, and the options in 
+    the command line take precedence over the ones in the file, except that --include and --exclude
+    in the file are appended after the patterns in the command line. The unknown fields, options or
+    the options not supported by the command in the file are reported as errors.
+
+s3:// url
+
+    cloud_url can also be s3://bucket[/object], the requests are sent to the s3 compatible 
+    service and signed with aws signature v4. The credentials are read from environment variables
+    AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, the region is read from 
+    AWS_REGION or AWS_DEFAULT_REGION(the default is us-east-1), the endpoint is specified by 
+    --s3-endpoint option or environment variables AWS_ENDPOINT_URL_S3 and AWS_ENDPOINT_URL. 
+    s3:// url is supported by ls, cp, rm and stat commands at present. When copying between s3 and
+    oss, the data is read by range with If-Match of the etag of the source object when the copy
+    starts, if the source object is changed during the copy, the uploaded parts and the checkpoint
+    are dropped and the object is copied again.
+
+oss-internal:// and oss-acc:// url
+
+    cloud_url can also be oss-internal://bucket[/object] or oss-acc://bucket[/object], which is
+    the same as oss:// except the endpoint, the requests of the bucket are sent to the internal
+    endpoint(e.g., oss-cn-hangzhou-internal.aliyuncs.com) or the accelerate endpoint
+    (oss-accelerate.aliyuncs.com) derived from the endpoint of the bucket, so the network can be
+    selected for each argument in one command without changing the global --endpoint. The
+    endpoint of the bucket should be like oss-cn-hangzhou.aliyuncs.com, the cname and the cloud box
+    are not supported, a bucket can't be referenced by both schemes.
+    e.g., ossutil cp oss-internal://src-bucket/dir/ oss-acc://dest-bucket/dir/ -r
+
+    When copying between s3 and oss, ossutil reads the source object by range and pipes the data
+    into the multipart upload of the destination part by part, no data is staged locally. For the
+    objects larger than --bigfile-threshold, the uploaded parts are recorded in --checkpoint-dir,
+    running the same command again resumes the copy from the checkpoint.
+
 Usage:
 
     There are three usages:
@@ -1114,6 +1950,9 @@ Usage:
     ossutil cp local_dir oss://bucket/b --tagging "tagA=A&tagB=B"
     Set two taggings when uploading, the key is tagA and tagB, and the value is A and B
 
+    ossutil cp local_dir s3://bucket1/backup/ -r --retain-until 2026-01-01 --retention-mode COMPLIANCE
+    Set the retention when uploading to s3, the objects can't be deleted or overwritten before 2026-01-01
+
     2) download from oss
     Suppose there are following objects in oss:
         oss://bucket/abcdir1/a
@@ -1163,6 +2002,16 @@ Usage:
     ossutil cp oss://bucket/object1 local_file --version-id versionId
     Specify object version download
 
+    ossutil cp oss://bucket/dir/ local_dir -r --as-of "2024-06-01T00:00:00Z"
+    Download the versions of the objects under dir/ at 2024-06-01T00:00:00Z, recover the whole
+    directory to that point in time
+
+    ossutil cp oss://bucket/dir/ local_dir -r --progress-file /var/run/ossutil/progress.json
+    Download the objects under dir/, write the progress to /var/run/ossutil/progress.json periodically
+
+    ossutil cp oss://bucket/dir/ local_dir -r --output json
+    Download the objects under dir/, print the summary in json at the end
+
     ossutil cp oss://bucket/dir/ local_dir -r --only-current-dir
     Only download the object in the current directory, ignore other subdirectories
 
@@ -1244,20 +2093,21 @@ Usage:
 
 // CopyCommand is the command upload, download and copy objects
 type CopyCommand struct {
-	monitor  CPMonitor //Put first for atomic op on some fileds
-	command  Command
-	cpOption copyOptionType
+	monitor     CPMonitor //Put first for atomic op on some fileds
+	command     Command
+	cpOption    copyOptionType
+	planDeletes int64     // the objects to be deleted by sync --delete, counted by --dry-run
+	filterArgs  []string  // --include and --exclude are parsed from them, nil means os.Args
+	textOutput  io.Writer // the text output passed by the API, nil means stdout or stderr as the command line
 }
 
 var copyCommand = CopyCommand{
 	command: Command{
-		name:        "cp",
-		nameAlias:   []string{"copy"},
-		minArgc:     2,
-		maxArgc:     MaxInt,
-		specChinese: specChineseCopy,
-		specEnglish: specEnglishCopy,
-		group:       GroupTypeNormalCommand,
+		name:      "cp",
+		nameAlias: []string{"copy"},
+		minArgc:   2,
+		maxArgc:   MaxInt,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionRecursion,
 			OptionForce,
@@ -1267,6 +2117,9 @@ var copyCommand = CopyCommand{
 			OptionBigFileThreshold,
 			OptionPartSize,
 			OptionCheckpointDir,
+			OptionJobID,
+			OptionEncryptCheckpoint,
+			OptionCheckpointExpire,
 			OptionRange,
 			OptionEncodingType,
 			OptionInclude,
@@ -1286,6 +2139,7 @@ var copyCommand = CopyCommand{
 			OptionParallel,
 			OptionSnapshotPath,
 			OptionDisableCRC64,
+			OptionPartCRC,
 			OptionRequestPayer,
 			OptionLogLevel,
 			OptionMaxUpSpeed,
@@ -1295,9 +2149,13 @@ var copyCommand = CopyCommand{
 			OptionEnableSymlinkDir,
 			OptionOnlyCurrentDir,
 			OptionDisableDirObject,
+			OptionDirObject,
 			OptionDisableAllSymlink,
 			OptionDisableIgnoreError,
 			OptionTagging,
+			OptionRetainUntil,
+			OptionRetentionMode,
+			OptionLegalHold,
 			OptionPassword,
 			OptionMode,
 			OptionECSRoleName,
@@ -1306,6 +2164,11 @@ var copyCommand = CopyCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionMaxDownSpeed,
@@ -1314,8 +2177,50 @@ var copyCommand = CopyCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionS3Endpoint,
+			OptionMimeMap,
+			OptionDetectContentType,
+			OptionListSplit,
+			OptionStartAfter,
+			OptionStatSummary,
+			OptionIfMatch,
+			OptionIfNoneMatch,
+			OptionIfUnmodifiedSince,
+			OptionForbidOverwrite,
+			OptionCallbackURL,
+			OptionCallbackBody,
+			OptionCallbackVar,
+			OptionNoClobber,
+			OptionIgnoreExisting,
+			OptionDisableOssIgnore,
+			OptionMaxDuration,
+			OptionRetryBudget,
+			OptionMaxDiskUsage,
+			OptionMaxObjects,
+			OptionMaxBytes,
+			OptionStagingDir,
+			OptionCRCRetryTimes,
+			OptionDedup,
+			OptionWindowsNameMapping,
+			OptionLocalEncoding,
+			OptionDryRun,
+			OptionPlan,
+			OptionPrice,
+			OptionOutputFailed,
+			OptionRetryFrom,
+			OptionContentMD5,
+			OptionLowMemory,
 			OptionStartTime,
 			OptionEndTime,
+			OptionAppend,
+			OptionFanout,
+			OptionChecksum,
+			OptionHashDB,
+			OptionSpec,
+			OptionKeepParts,
+			OptionAsOf,
+			OptionProgressFile,
+			OptionOutput,
 		},
 	},
 }
@@ -1330,17 +2235,28 @@ func (cc *CopyCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (cc *CopyCommand) Init(args []string, options OptionMapType) error {
-	return cc.command.Init(args, options, cc)
+func (cc *CopyCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return cc.command.Init(ctx, args, options, cc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
 func (cc *CopyCommand) RunCommand() error {
+	planDeletes := cc.planDeletes
+	cc.planDeletes = 0
+
 	cc.cpOption.recursive, _ = GetBool(OptionRecursion, cc.command.options)
 	cc.cpOption.force, _ = GetBool(OptionForce, cc.command.options)
 	cc.cpOption.update, _ = GetBool(OptionUpdate, cc.command.options)
 	cc.cpOption.threshold, _ = GetInt(OptionBigFileThreshold, cc.command.options)
-	cc.cpOption.cpDir, _ = GetString(OptionCheckpointDir, cc.command.options)
+	cc.cpOption.cpRootDir, _ = GetString(OptionCheckpointDir, cc.command.options)
+	jobID, _ := GetString(OptionJobID, cc.command.options)
+	cpDir, err := jobCheckpointDir(cc.cpOption.cpRootDir, jobID)
+	if err != nil {
+		return err
+	}
+	cc.cpOption.cpDir = cpDir
+	encryptCheckpoint, _ := GetBool(OptionEncryptCheckpoint, cc.command.options)
+	expireDays, _ := GetInt(OptionCheckpointExpire, cc.command.options)
 	cc.cpOption.routines, _ = GetInt(OptionRoutines, cc.command.options)
 	cc.cpOption.ctnu = false
 	if cc.cpOption.recursive {
@@ -1360,14 +2276,26 @@ func (cc *CopyCommand) RunCommand() error {
 	cc.cpOption.enableSymlinkDir, _ = GetBool(OptionEnableSymlinkDir, cc.command.options)
 	cc.cpOption.onlyCurrentDir, _ = GetBool(OptionOnlyCurrentDir, cc.command.options)
 	cc.cpOption.disableDirObject, _ = GetBool(OptionDisableDirObject, cc.command.options)
+	cc.cpOption.dirObject, _ = GetBool(OptionDirObject, cc.command.options)
 	cc.cpOption.disableAllSymlink, _ = GetBool(OptionDisableAllSymlink, cc.command.options)
+	cc.cpOption.disableOssIgnore, _ = GetBool(OptionDisableOssIgnore, cc.command.options)
 
 	if cc.cpOption.enableSymlinkDir && cc.cpOption.disableAllSymlink {
 		return fmt.Errorf("--enable-symlink-dir and --disable-all-symlink can't be both exist")
 	}
+	partCRC, _ := GetBool(OptionPartCRC, cc.command.options)
+	disableCRC64, _ := GetBool(OptionDisableCRC64, cc.command.options)
+	if partCRC && disableCRC64 {
+		return fmt.Errorf("--part-crc and --disable-crc64 can't be both exist")
+	}
 
+	filterArgs := cc.filterArgs
+	if filterArgs == nil {
+		filterArgs = os.Args
+	}
+	filterArgs = cc.command.withSpecFilters(filterArgs)
 	var res bool
-	res, cc.cpOption.filters = getFilter(os.Args)
+	res, cc.cpOption.filters = getFilter(filterArgs)
 	if !res {
 		return fmt.Errorf("--include or --exclude does not support format containing dir info")
 	}
@@ -1387,17 +2315,32 @@ func (cc *CopyCommand) RunCommand() error {
 	}
 
 	//get file list
-	srcURLList, err := cc.getStorageURLs(cc.command.args[0 : len(cc.command.args)-1])
+	srcArgs, destArgs := cc.command.args[0:len(cc.command.args)-1], cc.command.args[len(cc.command.args)-1:]
+	fanout, _ := GetBool(OptionFanout, cc.command.options)
+	cc.cpOption.fanoutURLs = nil
+	if fanout {
+		srcArgs, destArgs = cc.command.args[0:1], cc.command.args[1:]
+		fanoutURLs, err := cc.getFanoutURLs(destArgs)
+		if err != nil {
+			return err
+		}
+		cc.cpOption.fanoutURLs = fanoutURLs
+	}
+	srcURLList, err := cc.getStorageURLs(srcArgs)
 	if err != nil {
 		return err
 	}
 
-	destURL, err := StorageURLFromString(cc.command.args[len(cc.command.args)-1], cc.cpOption.encodingType)
+	destURL, err := StorageURLFromString(destArgs[0], cc.cpOption.encodingType)
 	if err != nil {
 		return err
 	}
 
 	opType := cc.getCommandType(srcURLList, destURL)
+	output, err := getOutputFormat(cc.command.options)
+	if err != nil {
+		return err
+	}
 	if err := cc.checkCopyArgs(srcURLList, destURL, opType); err != nil {
 		return err
 	}
@@ -1405,6 +2348,32 @@ func (cc *CopyCommand) RunCommand() error {
 		return err
 	}
 
+	// the progress and the messages are printed to stderr when the object is downloaded to stdout
+	cc.command.stdout = output.textWriter()
+	if opType == operationTypeGet && isStdoutFile(destURL.ToString()) {
+		if output.structured() {
+			return fmt.Errorf("--output %s doesn't work with downloading to stdout", output)
+		}
+		cc.command.stdout = os.Stderr
+	}
+	if cc.textOutput != nil {
+		cc.command.stdout = cc.textOutput
+	}
+
+	// the multiple sources are processed as a batch operation
+	cc.cpOption.sources = nil
+	if len(srcURLList) > 1 {
+		if !cc.cpOption.recursive {
+			disableIgnoreError, _ := GetBool(OptionDisableIgnoreError, cc.command.options)
+			cc.cpOption.ctnu = !disableIgnoreError
+		}
+		if opType != operationTypePut {
+			for _, url := range srcURLList {
+				cc.cpOption.sources = append(cc.cpOption.sources, url.(CloudURL))
+			}
+		}
+	}
+
 	cc.cpOption.options = []oss.Option{}
 	if cc.cpOption.meta != "" {
 		headers, err := cc.command.parseHeaders(cc.cpOption.meta, false)
@@ -1455,8 +2424,192 @@ func (cc *CopyCommand) RunCommand() error {
 		cc.cpOption.payerOptions = append(cc.cpOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
 	}
 
+	if err := cc.initRetentionOptions(opType, destURL); err != nil {
+		return err
+	}
+
+	if err := cc.initContentTypeOptions(opType); err != nil {
+		return err
+	}
+
+	if srcURLList, err = cc.expandGlob(srcURLList); err != nil {
+		return err
+	}
+
+	if cc.command.safetyCap, err = cc.command.newSafetyCap(); err != nil {
+		return err
+	}
+	if cc.command.safetyCap != nil && !cc.cpOption.recursive {
+		return CommandError{cc.command.name, "--max-objects and --max-bytes only work with --recursive"}
+	}
+
+	cc.cpOption.startAfter, _ = GetString(OptionStartAfter, cc.command.options)
+	if cc.cpOption.startAfter != "" && (!cc.cpOption.recursive || opType == operationTypePut) {
+		return CommandError{cc.command.name, "--start-after only work with --recursive download or copy"}
+	}
+
+	cc.cpOption.listSplit, _ = GetString(OptionListSplit, cc.command.options)
+	if cc.cpOption.listSplit != "" {
+		if !cc.cpOption.recursive || opType == operationTypePut {
+			return CommandError{cc.command.name, "--list-split only work with --recursive download or copy"}
+		}
+		if _, err := makeListShards("", "", cc.cpOption.listSplit); err != nil {
+			return err
+		}
+	}
+
+	if cc.cpOption.condition, err = cc.command.getObjectCondition(); err != nil {
+		return err
+	}
+	if !cc.cpOption.condition.isEmpty() && opType != operationTypeGet {
+		return CommandError{cc.command.name, "--if-match, --if-none-match and --if-unmodified-since only work with download"}
+	}
+
+	cc.cpOption.forbidOverwrite, _ = GetBool(OptionForbidOverwrite, cc.command.options)
+	if cc.cpOption.forbidOverwrite {
+		if opType == operationTypeGet {
+			return CommandError{cc.command.name, "--forbid-overwrite only work with upload or copy"}
+		}
+		cc.cpOption.options = append(cc.cpOption.options, oss.ForbidOverWrite(true))
+	}
+
+	callbackOptions, err := getCallbackOptions(cc.command.options)
+	if err != nil {
+		return err
+	}
+	cc.cpOption.callback = len(callbackOptions) > 0
+	if cc.cpOption.callback {
+		if opType != operationTypePut {
+			return CommandError{cc.command.name, "--callback-url only work with upload"}
+		}
+		cc.cpOption.options = append(cc.cpOption.options, callbackOptions...)
+	}
+
+	if cc.cpOption.dirObject && cc.cpOption.disableDirObject {
+		return CommandError{cc.command.name, "--dirobject and --disable-dir-object can't be used together"}
+	}
+
+	noClobber, _ := GetBool(OptionNoClobber, cc.command.options)
+	ignoreExisting, _ := GetBool(OptionIgnoreExisting, cc.command.options)
+	cc.cpOption.noClobber = noClobber || ignoreExisting
+	if cc.cpOption.destObjects != nil {
+		cc.cpOption.destObjects.close()
+	}
+	cc.cpOption.destObjects = nil
+	if cc.cpOption.noClobber && cc.cpOption.update {
+		return CommandError{cc.command.name, "--no-clobber and --update can't be specified at the same time"}
+	}
+
+	if cc.cpOption.fanoutURLs != nil {
+		if opType != operationTypePut || cc.cpOption.recursive {
+			return CommandError{cc.command.name, "--fanout only work with uploading a single file"}
+		}
+		isAppend, _ := GetBool(OptionAppend, cc.command.options)
+		if cc.cpOption.update || cc.cpOption.noClobber || cc.cpOption.snapshotPath != "" || isAppend {
+			return CommandError{cc.command.name, "--fanout can't be used together with --update, --no-clobber, --snapshot-path or --append"}
+		}
+	}
+
+	cc.cpOption.append, _ = GetBool(OptionAppend, cc.command.options)
+	if cc.cpOption.append {
+		if opType != operationTypePut {
+			return CommandError{cc.command.name, "--append only work with upload"}
+		}
+		if cc.cpOption.update || cc.cpOption.noClobber || cc.cpOption.snapshotPath != "" || cc.cpOption.callback || cc.cpOption.forbidOverwrite {
+			return CommandError{cc.command.name, "--append can't be used together with --update, --no-clobber, --snapshot-path, --callback-url or --forbid-overwrite"}
+		}
+	}
+
+	cc.cpOption.keepParts, _ = GetBool(OptionKeepParts, cc.command.options)
+	if cc.cpOption.keepParts && opType != operationTypePut {
+		return CommandError{cc.command.name, "--keep-parts only work with upload"}
+	}
+
+	cc.cpOption.checksum, _ = GetBool(OptionChecksum, cc.command.options)
+	cc.cpOption.hashDBPath, _ = GetString(OptionHashDB, cc.command.options)
+	cc.cpOption.dedup, _ = GetBool(OptionDedup, cc.command.options)
+	if cc.cpOption.dedup {
+		// --dedup compares the crc64 as --checksum, the uploaded objects are recorded in the hashdb
+		cc.cpOption.checksum = true
+		if cc.cpOption.hashDBPath == "" {
+			cc.cpOption.hashDBPath = DefaultHashDBDir
+		}
+	}
+	if cc.cpOption.checksum {
+		if opType != operationTypePut {
+			return CommandError{cc.command.name, "--checksum or --dedup only work with upload"}
+		}
+		if cc.cpOption.update || cc.cpOption.noClobber || cc.cpOption.snapshotPath != "" || cc.cpOption.append {
+			return CommandError{cc.command.name, "--checksum or --dedup can't be used together with --update, --no-clobber, --snapshot-path or --append"}
+		}
+	} else if cc.cpOption.hashDBPath != "" {
+		return CommandError{cc.command.name, "--hashdb only work with --checksum"}
+	}
+
+	cc.cpOption.windowsNameMap, _ = GetString(OptionWindowsNameMapping, cc.command.options)
+	cc.cpOption.localEncoding, _ = GetString(OptionLocalEncoding, cc.command.options)
+	if cc.cpOption.localEncoding != "" {
+		if opType == operationTypeCopy {
+			return CommandError{cc.command.name, "--local-encoding only work with upload or download"}
+		}
+		if runtime.GOOS == "windows" {
+			return CommandError{cc.command.name, "--local-encoding doesn't work on windows, the file names are always unicode on windows"}
+		}
+	}
+	cc.cpOption.renamedKeys = 0
+
+	cc.cpOption.stagingDir, _ = GetString(OptionStagingDir, cc.command.options)
+	if cc.cpOption.stagingDir != "" {
+		if opType != operationTypeGet {
+			return CommandError{cc.command.name, "--staging-dir only work with download"}
+		}
+		if cc.cpOption.stagingDir, err = filepath.Abs(cc.cpOption.stagingDir); err != nil {
+			return err
+		}
+	}
+
+	cc.cpOption.crcRetryTimes, _ = GetInt(OptionCRCRetryTimes, cc.command.options)
+
+	if cc.cpOption.plan, err = cc.command.newRequestPlan(); err != nil {
+		return err
+	}
+	if cc.cpOption.plan != nil {
+		cc.cpOption.plan.addDelete(planDeletes)
+	}
+
+	if cc.cpOption.budget, err = cc.command.newJobBudget(); err != nil {
+		return err
+	}
+	if cc.cpOption.budget == nil {
+		cc.cpOption.budget = &jobBudget{retryBudget: -1}
+	}
+
+	// the keys are read before --output-failed is created, which may be the same file
+	if cc.cpOption.retryKeys, err = cc.command.readRetryKeys(); err != nil {
+		return err
+	}
+	if cc.cpOption.retryKeys != nil && !cc.cpOption.recursive {
+		return CommandError{cc.command.name, "--retry-from only work with --recursive"}
+	}
+	if cc.cpOption.retryKeys != nil && len(cc.cpOption.sources) > 1 {
+		return CommandError{cc.command.name, "--retry-from doesn't work with multiple source urls of download or copy"}
+	}
+	if cc.cpOption.asOf, err = cc.newAsOfSelector(opType); err != nil {
+		return err
+	}
+	if cc.cpOption.failed, err = cc.command.newFailedManifest(); err != nil {
+		return err
+	}
+	defer cc.cpOption.failed.close()
+
+	statSummaryTarget, _ := GetString(OptionStatSummary, cc.command.options)
+	cc.cpOption.statSummary = nil
+	if statSummaryTarget != "" {
+		cc.cpOption.statSummary = newStatSummary()
+	}
+
 	// init reporter
-	if cc.cpOption.reporter, err = GetReporter(cc.cpOption.recursive, outputDir, commandLine); err != nil {
+	if cc.cpOption.reporter, err = GetReporter(cc.cpOption.recursive || len(srcURLList) > 1, outputDir, commandLine); err != nil {
 		return err
 	}
 
@@ -1468,6 +2621,36 @@ func (cc *CopyCommand) RunCommand() error {
 		return err
 	}
 
+	// the same command running twice at the same time may corrupt the checkpoints of each other
+	cpLockPath := checkpointLockPath(cc.cpOption.cpDir, cc.command.args)
+	cpLock, err := tryLockFile(cpLockPath)
+	if err != nil {
+		return fmt.Errorf("%s, the same command is running, wait for it to finish or specify another --checkpoint-dir", err.Error())
+	}
+	defer cpLock.unlock()
+
+	if expireDays > 0 {
+		if removed := expireCheckpoints(cc.cpOption.cpRootDir, cpLockPath, expireDays); removed > 0 {
+			fmt.Fprintf(cc.command.textOut(), "removed %d checkpoints older than %d days in %s\n", removed, expireDays, cc.cpOption.cpRootDir)
+		}
+	}
+
+	// the checkpoints are encrypted when they are written with --encrypt-checkpoint, and decrypted
+	// one by one when they are loaded, the ones in plain text, e.g., left by a killed process, are
+	// encrypted now. Without --encrypt-checkpoint the encrypted checkpoints can't be resumed
+	cc.cpOption.cpCipher = nil
+	if encryptCheckpoint {
+		if cc.cpOption.cpCipher, err = checkpointCipher(); err != nil {
+			return err
+		}
+		err = sealCheckpoints(cc.cpOption.cpDir, cc.cpOption.cpCipher)
+	} else {
+		err = unsealCheckpoints(cc.cpOption.cpDir, nil)
+	}
+	if err != nil {
+		return err
+	}
+
 	// load snapshot
 	if cc.cpOption.snapshotPath != "" {
 		if cc.cpOption.snapshotldb, err = leveldb.OpenFile(cc.cpOption.snapshotPath, nil); err != nil {
@@ -1476,6 +2659,15 @@ func (cc *CopyCommand) RunCommand() error {
 		defer cc.cpOption.snapshotldb.Close()
 	}
 
+	// load the crc64 cache of the local files
+	cc.cpOption.hashDB = nil
+	if cc.cpOption.hashDBPath != "" {
+		if cc.cpOption.hashDB, err = openHashDB(cc.cpOption.hashDBPath); err != nil {
+			return err
+		}
+		defer cc.cpOption.hashDB.close()
+	}
+
 	if cc.cpOption.partitionInfo != "" {
 		if opType == operationTypeGet {
 			sliceInfo := strings.Split(cc.cpOption.partitionInfo, ":")
@@ -1504,12 +2696,37 @@ func (cc *CopyCommand) RunCommand() error {
 	cc.monitor.init(opType)
 	cc.cpOption.opType = opType
 
+	progressPath, _ := GetString(OptionProgressFile, cc.command.options)
+	cc.cpOption.progress = nil
+	if progressPath != "" {
+		cc.cpOption.progress = newProgressFile(progressPath, &cc.monitor)
+		if err = cc.cpOption.progress.start(); err != nil {
+			return fmt.Errorf("write progress file %s error: %s", progressPath, err.Error())
+		}
+	}
+
 	chProgressSignal = make(chan chProgressSignalType, 10)
 	go cc.progressBar()
 
+	stopWatch := watchInterrupt(cc.command.context(), cc.cpOption.budget, cc.command.textOut())
+	defer stopWatch()
+
+	// the destination objects listed for --no-clobber may be spooled to the temp dir by --low-memory
+	defer func() {
+		if cc.cpOption.destObjects != nil {
+			cc.cpOption.destObjects.close()
+			cc.cpOption.destObjects = nil
+		}
+	}()
+
 	startT := time.Now().UnixNano() / 1000 / 1000
 	switch opType {
 	case operationTypePut:
+		if cc.cpOption.fanoutURLs != nil {
+			LogInfo("begin fanoutUpload\n")
+			err = cc.fanoutUpload(srcURLList[0].(FileURL), cc.cpOption.fanoutURLs)
+			break
+		}
 		LogInfo("begin uploadFiles\n")
 		err = cc.uploadFiles(srcURLList, destURL.(CloudURL))
 	case operationTypeGet:
@@ -1520,17 +2737,48 @@ func (cc *CopyCommand) RunCommand() error {
 		err = cc.copyFiles(srcURLList[0].(CloudURL), destURL.(CloudURL))
 	}
 	endT := time.Now().UnixNano() / 1000 / 1000
+	var averSpeed int64
 	if endT-startT > 0 {
-		averSpeed := (cc.monitor.transferSize / (endT - startT)) * 1000
-		fmt.Printf("\naverage speed %d(byte/s)\n", averSpeed)
+		averSpeed = (cc.monitor.transferSize / (endT - startT)) * 1000
+		fmt.Fprintf(cc.command.textOut(), "\naverage speed %d(byte/s)\n", averSpeed)
 		LogInfo("average speed %d(byte/s)\n", averSpeed)
 	}
 
+	if renamed := atomic.LoadInt64(&cc.cpOption.renamedKeys); renamed > 0 {
+		fmt.Fprintf(cc.command.textOut(), "\n%d objects are saved with the names different from their keys because of the windows naming rules\n", renamed)
+	}
+
+	cc.cpOption.plan.output()
+
+	if serr := cc.cpOption.statSummary.output(cc.command.textOut(), statSummaryTarget); serr != nil && err == nil {
+		err = serr
+	}
+
+	// the unfinished files are resumed by checkpoint, snapshot or --update in the next run
+	if berr := cc.cpOption.budget.err(); berr != nil {
+		err = berr
+		if cc.cpOption.recursive {
+			fmt.Fprintf(cc.command.textOut(), "\nthe checkpoint is kept in %s, run the same command again with --update or --snapshot-path to skip the finished files\n", cc.cpOption.cpDir)
+		}
+	}
+	cc.cpOption.progress.finish(err)
+	if output.structured() {
+		if perr := output.print(cc.monitor.summary(endT-startT, averSpeed, err)); perr != nil && err == nil {
+			err = perr
+		}
+	}
+
 	cc.cpOption.reporter.Clear()
+	cpLock.unlock()
 	ckFiles, _ := ioutil.ReadDir(cc.cpOption.cpDir)
 	if err == nil && len(ckFiles) == 0 {
+		// the dir is only removed if it's empty, the other commands may be using it
 		LogInfo("begin Remove checkpointDir %s\n", cc.cpOption.cpDir)
-		os.RemoveAll(cc.cpOption.cpDir)
+		os.Remove(cc.cpOption.cpDir)
+		if cc.cpOption.cpDir != cc.cpOption.cpRootDir {
+			// the checkpoint dir is kept if other jobs are using it
+			os.Remove(cc.cpOption.cpRootDir)
+		}
 	}
 	return err
 }
@@ -1580,16 +2828,14 @@ func (cc *CopyCommand) checkCopyArgs(srcURLList []StorageURLer, destURL StorageU
 				return fmt.Errorf("invalid url: %s, copy between oss operation appear in upload operation, multi-type operations is not supported in one command", url.ToString())
 			}
 		}
-		if len(srcURLList) > 1 {
-			return fmt.Errorf("invalid url: %s, multiple source url in upload operation", srcURLList[1].ToString())
-		}
-	case operationTypeGet:
-		if len(srcURLList) > 1 {
-			return fmt.Errorf("invalid url: %s, multiple source url in download operation", srcURLList[1].ToString())
-		}
 	default:
-		if len(srcURLList) > 1 {
-			return fmt.Errorf("invalid url: %s, multiple source url in copy operation", srcURLList[1].ToString())
+		for _, url := range srcURLList[1:] {
+			if url.IsFileURL() {
+				return fmt.Errorf("invalid url: %s, upload operation appear in download or copy operation, multi-type operations is not supported in one command", url.ToString())
+			}
+			if url.(CloudURL).bucket != srcURLList[0].(CloudURL).bucket {
+				return fmt.Errorf("invalid url: %s, multiple source urls should be in the same bucket", url.ToString())
+			}
 		}
 	}
 	return nil
@@ -1620,7 +2866,7 @@ func (cc *CopyCommand) checkCopyOptions(opType operationType) error {
 func (cc *CopyCommand) progressBar() {
 	// fetch all reveal
 	for signal := range chProgressSignal {
-		fmt.Printf(cc.monitor.progressBar(signal.finish, signal.exitStat))
+		fmt.Fprintf(cc.command.textOut(), cc.monitor.progressBar(signal.finish, signal.exitStat))
 	}
 }
 
@@ -1634,7 +2880,7 @@ func (cc *CopyCommand) uploadFiles(srcURLList []StorageURLer, destURL CloudURL)
 		return err
 	}
 
-	bucket, err := cc.command.ossBucket(destURL.bucket)
+	bucket, err := cc.command.cloudBucket(destURL)
 	if err != nil {
 		return err
 	}
@@ -1645,12 +2891,18 @@ func (cc *CopyCommand) uploadFiles(srcURLList []StorageURLer, destURL CloudURL)
 		return err
 	}
 
+	if cc.cpOption.noClobber && cc.cpOption.recursive {
+		cc.loadDestObjects(bucket, destURL.object)
+	}
+
 	// producer list files
 	// consumer set acl
+	if err := cc.startStatistic(func() { cc.fileStatistic(srcURLList) }); err != nil {
+		return err
+	}
 	chFiles := make(chan fileInfoType, ChannelBuf)
 	chError := make(chan error, cc.cpOption.routines)
 	chListError := make(chan error, 1)
-	go cc.fileStatistic(srcURLList)
 	go cc.fileProducer(srcURLList, chFiles, chListError)
 
 	LogInfo("upload files,routin count:%d,multi part size threshold:%d\n",
@@ -1678,14 +2930,14 @@ func (cc *CopyCommand) uploadFiles(srcURLList []StorageURLer, destURL CloudURL)
 			} else {
 				if !cc.cpOption.ctnu {
 					cc.closeProgress()
-					fmt.Printf(cc.monitor.progressBar(true, errExit))
+					fmt.Fprintf(cc.command.textOut(), cc.monitor.progressBar(true, errExit))
 					return err
 				}
 			}
 		}
 	}
 	cc.closeProgress()
-	fmt.Printf(cc.monitor.progressBar(true, normalExit))
+	fmt.Fprintf(cc.command.textOut(), cc.monitor.progressBar(true, normalExit))
 	return listError
 }
 
@@ -1716,6 +2968,10 @@ func (cc *CopyCommand) adjustDestURLForUpload(srcURLList []StorageURLer, destURL
 }
 
 func (cc *CopyCommand) fileStatistic(srcURLList []StorageURLer) {
+	if cc.cpOption.retryKeys != nil {
+		cc.retryFileStatistic(srcURLList)
+		return
+	}
 	for _, url := range srcURLList {
 		name := url.ToString()
 		f, err := os.Stat(name)
@@ -1735,8 +2991,11 @@ func (cc *CopyCommand) fileStatistic(srcURLList []StorageURLer) {
 				return
 			}
 		} else {
-			if cc.filterPath(name, cc.cpOption.cpDir) {
+			if cc.filterPath(name, cc.cpOption.cpRootDir) {
 				cc.monitor.updateScanSizeNum(f.Size(), 1)
+				if isSpecialFileMode(f.Mode()) {
+					cc.monitor.setSizeUnknown()
+				}
 			}
 		}
 	}
@@ -1754,6 +3013,7 @@ func (cc *CopyCommand) getCurrentDirFilesStatistic(dpath string) error {
 	if err != nil {
 		return err
 	}
+	ignore := cc.newOssIgnore(dpath)
 
 	for _, fileInfo := range fileList {
 		if !fileInfo.IsDir() {
@@ -1763,7 +3023,8 @@ func (cc *CopyCommand) getCurrentDirFilesStatistic(dpath string) error {
 				continue
 			}
 
-			if doesSingleFileMatchPatterns(fileInfo.Name(), cc.cpOption.filters) {
+			match, _ := cc.matchFileGlob(fileInfo.Name(), false)
+			if match && doesSingleFileMatchPatterns(fileInfo.Name(), cc.cpOption.filters) && !ignore.match(fileInfo.Name(), false) {
 				cc.monitor.updateScanSizeNum(fileInfo.Size(), 1)
 			}
 		}
@@ -1778,12 +3039,13 @@ func (cc *CopyCommand) getFileListStatistic(dpath string) error {
 
 	name := dpath
 	symlinkDiretorys := []string{dpath}
+	ignore := cc.newOssIgnore(dpath)
 	walkFunc := func(fpath string, f os.FileInfo, err error) error {
 		if f == nil {
 			return err
 		}
 
-		if !cc.filterPath(fpath, cc.cpOption.cpDir) {
+		if !cc.filterPath(fpath, cc.cpOption.cpRootDir) {
 			return nil
 		}
 
@@ -1795,6 +3057,22 @@ func (cc *CopyCommand) getFileListStatistic(dpath string) error {
 			return fmt.Errorf("list file error: %s, info: %s", fpath, err.Error())
 		}
 
+		if fpath != dpath && ignore.match(filepath.ToSlash(fileName), f.IsDir()) {
+			if f.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fpath != dpath {
+			if match, skip := cc.matchFileGlob(fileName, f.IsDir()); skip {
+				return filepath.SkipDir
+			} else if !match {
+				// the directory is walked into for the matched files under it
+				return nil
+			}
+		}
+
 		if f.IsDir() {
 			if fpath != dpath {
 				cc.monitor.updateScanNum(1)
@@ -1856,6 +3134,13 @@ func (cc *CopyCommand) getFileListStatistic(dpath string) error {
 
 func (cc *CopyCommand) fileProducer(srcURLList []StorageURLer, chFiles chan<- fileInfoType, chListError chan<- error) {
 	defer close(chFiles)
+	if cc.cpOption.retryKeys != nil {
+		for _, file := range cc.retryFileList(srcURLList) {
+			chFiles <- file
+		}
+		chListError <- nil
+		return
+	}
 	for _, url := range srcURLList {
 		name := url.ToString()
 		f, err := os.Stat(name)
@@ -1891,6 +3176,7 @@ func (cc *CopyCommand) getCurrentDirFileList(dpath string, chFiles chan<- fileIn
 	if err != nil {
 		return err
 	}
+	ignore := cc.newOssIgnore(dpath)
 
 	for _, fileInfo := range fileList {
 		if !fileInfo.IsDir() {
@@ -1900,7 +3186,8 @@ func (cc *CopyCommand) getCurrentDirFileList(dpath string, chFiles chan<- fileIn
 				continue
 			}
 
-			if doesSingleFileMatchPatterns(fileInfo.Name(), cc.cpOption.filters) {
+			match, _ := cc.matchFileGlob(fileInfo.Name(), false)
+			if match && doesSingleFileMatchPatterns(fileInfo.Name(), cc.cpOption.filters) && !ignore.match(fileInfo.Name(), false) {
 				chFiles <- fileInfoType{fileInfo.Name(), dpath}
 			}
 		}
@@ -1915,6 +3202,7 @@ func (cc *CopyCommand) getFileList(dpath string, chFiles chan<- fileInfoType) er
 
 	name := dpath
 	symlinkDiretorys := []string{dpath}
+	ignore := cc.newOssIgnore(dpath)
 	walkFunc := func(fpath string, f os.FileInfo, err error) error {
 		if f == nil {
 			return err
@@ -1928,10 +3216,26 @@ func (cc *CopyCommand) getFileList(dpath string, chFiles chan<- fileInfoType) er
 			return fmt.Errorf("list file error: %s, info: %s", fpath, err.Error())
 		}
 
-		if f.IsDir() {
-			if fpath != dpath {
-				if strings.HasSuffix(fileName, "\\") || strings.HasSuffix(fileName, "/") {
-					chFiles <- fileInfoType{fileName, name}
+		if fpath != dpath && ignore.match(filepath.ToSlash(fileName), f.IsDir()) {
+			if f.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if fpath != dpath {
+			if match, skip := cc.matchFileGlob(fileName, f.IsDir()); skip {
+				return filepath.SkipDir
+			} else if !match {
+				// the directory is walked into for the matched files under it
+				return nil
+			}
+		}
+
+		if f.IsDir() {
+			if fpath != dpath {
+				if strings.HasSuffix(fileName, "\\") || strings.HasSuffix(fileName, "/") {
+					chFiles <- fileInfoType{fileName, name}
 				} else {
 					chFiles <- fileInfoType{fileName + string(os.PathSeparator), name}
 				}
@@ -1985,9 +3289,21 @@ func (cc *CopyCommand) getFileList(dpath string, chFiles chan<- fileInfoType) er
 	return err
 }
 
+// newOssIgnore returns the .ossignore matcher of the directory, nil if --disable-ossignore is specified
+func (cc *CopyCommand) newOssIgnore(dpath string) *ossIgnore {
+	if cc.cpOption.disableOssIgnore {
+		return nil
+	}
+	return newOssIgnore(dpath)
+}
+
 func (cc *CopyCommand) uploadConsumer(bucket *oss.Bucket, destURL CloudURL, chFiles <-chan fileInfoType, chError chan<- error) {
 	for file := range chFiles {
-		if cc.filterFile(file, cc.cpOption.cpDir) {
+		if cc.cpOption.budget.exceeded() || cc.exceedSafetyCap(cc.uploadSize(file)) {
+			cc.cpOption.budget.skip()
+			continue
+		}
+		if cc.filterFile(file, cc.cpOption.cpRootDir) {
 			err := cc.uploadFileWithReport(bucket, destURL, file)
 			if err != nil {
 				chError <- err
@@ -2024,9 +3340,19 @@ func (cc *CopyCommand) filterPath(filePath string, cpDir string) bool {
 }
 
 func (cc *CopyCommand) uploadFileWithReport(bucket *oss.Bucket, destURL CloudURL, file fileInfoType) error {
+	if cc.cpOption.plan != nil {
+		return cc.planUpload(file)
+	}
 	startT := time.Now()
+	filePath := filepath.Join(file.dir, file.filePath)
+	cc.cpOption.progress.begin(filePath)
 	skip, err, isDir, size, msg := cc.uploadFile(bucket, destURL, file)
+	cc.cpOption.progress.end(filePath)
+	err = cc.forbidOverwriteError(err)
 	cost := time.Now().UnixNano()/1000/1000 - startT.UnixNano()/1000/1000
+	if !isDir {
+		cc.cpOption.statSummary.addRecord(filePath, filePath, skip, err, size, time.Since(startT))
+	}
 
 	if err != nil {
 		LogError("upload file error,file:%s,cost:%d(ms),error info:%s\n", file.filePath, cost, err.Error())
@@ -2050,9 +3376,21 @@ func (cc *CopyCommand) uploadFileWithReport(bucket *oss.Bucket, destURL CloudURL
 
 	cc.updateMonitor(skip, err, isDir, size)
 	cc.report(msg, err)
+	cc.cpOption.failed.record(filepath.ToSlash(file.filePath), err)
 	return err
 }
 
+// isEmptyDir returns true if there is no file or sub directory in the directory
+func isEmptyDir(dirName string) bool {
+	dir, err := os.Open(dirName)
+	if err != nil {
+		return false
+	}
+	defer dir.Close()
+	_, err = dir.Readdirnames(1)
+	return err == io.EOF
+}
+
 func (cc *CopyCommand) uploadFile(bucket *oss.Bucket, destURL CloudURL, file fileInfoType) (skip bool, rerr error, isDir bool, size int64, msg string) {
 	//first make object name
 	objectName := cc.makeObjectName(destURL, file)
@@ -2064,7 +3402,7 @@ func (cc *CopyCommand) uploadFile(bucket *oss.Bucket, destURL CloudURL, file fil
 	rerr = nil
 	isDir = false
 	size = 0 // the size update to monitor
-	msg = fmt.Sprintf("%s %s to %s", opUpload, filePath, CloudURLToString(bucket.BucketName, objectName))
+	msg = fmt.Sprintf("%s %s to %s", opUpload, filePath, bucketObjectURL(bucket, objectName))
 
 	//get file size and last modify time
 	f, err := os.Stat(filePath)
@@ -2077,17 +3415,34 @@ func (cc *CopyCommand) uploadFile(bucket *oss.Bucket, destURL CloudURL, file fil
 		size = f.Size()
 	}
 
+	// the named pipe or the device can be read only once, --update and --snapshot-path don't work for it
+	if isSpecialFileMode(f.Mode()) {
+		size = 0
+		rerr = cc.uploadSpecialFile(bucket, objectName, filePath)
+		return
+	}
+
+	if cc.cpOption.append && !f.IsDir() {
+		skip, rerr = cc.appendUploadFile(bucket, objectName, filePath, f.Size())
+		if skip {
+			size = f.Size()
+		} else {
+			size = 0
+		}
+		return
+	}
+
 	srct := f.ModTime().Unix()
 	absPath, _ := filepath.Abs(filePath)
 	spath := cc.formatSnapshotKey(absPath, destURL.bucket, objectName)
-	if skip, rerr = cc.skipUpload(spath, bucket, objectName, destURL, srct); rerr != nil || skip {
+	if skip, rerr = cc.skipUpload(spath, absPath, bucket, objectName, destURL, f); rerr != nil || skip {
 		return
 	}
 
 	skip = false
 	if f.IsDir() {
 		isDir = true
-		if cc.cpOption.disableDirObject {
+		if cc.cpOption.disableDirObject || cc.cpOption.dirObject && !isEmptyDir(filePath) {
 			skip = true
 			return
 		}
@@ -2100,14 +3455,23 @@ func (cc *CopyCommand) uploadFile(bucket *oss.Bucket, destURL CloudURL, file fil
 
 	size = 0
 	//decide whether to use resume upload
+	contentTypeOptions := cc.uploadContentTypeOptions(filePath)
 	if f.Size() < cc.cpOption.threshold {
 		var listener *OssProgressListener = &OssProgressListener{&cc.monitor, 0, 0, false}
-		options := cc.cpOption.options
+		options := append(contentTypeOptions, cc.cpOption.options...)
 		options = append(options, oss.Progress(listener))
+		var callbackBody []byte
+		if cc.cpOption.callback {
+			options = append(options, oss.CallbackResult(&callbackBody))
+		}
 		rerr = cc.ossUploadFileRetry(bucket, objectName, filePath, options...)
+		cc.reportCallback(rerr, bucket.BucketName, objectName, callbackBody)
 		if err := cc.updateSnapshot(rerr, spath, srct); err != nil {
 			rerr = err
 		}
+		if err := cc.recordDedup(rerr, bucket.BucketName, objectName, absPath, f); err != nil {
+			rerr = err
+		}
 		return
 	}
 
@@ -2118,28 +3482,153 @@ func (cc *CopyCommand) uploadFile(bucket *oss.Bucket, destURL CloudURL, file fil
 	partSize, rt := cc.preparePartOption(f.Size())
 	LogInfo("multipart upload,file:%s,file size:%d,partSize:%d,routin count:%d\n",
 		filePath, f.Size(), partSize, rt)
-	cp := oss.CheckpointDir(true, cc.cpOption.cpDir)
-	options := cc.cpOption.options
-	options = append(options, oss.Routines(rt), cp, oss.Progress(listener))
-	rerr = cc.ossResumeUploadRetry(bucket, objectName, filePath, partSize, options...)
+	options := append(contentTypeOptions, cc.cpOption.options...)
+	var callbackBody []byte
+	if cc.cpOption.callback {
+		options = append(options, oss.CallbackResult(&callbackBody))
+	}
+	if cc.usePartCRCUpload(bucket) {
+		options = append(options, oss.Progress(listener))
+		rerr = cc.ossPartCRCUploadRetry(bucket, objectName, filePath, f, partSize, rt, listener, options...)
+	} else {
+		cp := newSDKCheckpoint(cc.cpOption.cpDir, absPath, bucketObjectURL(bucket, objectName), f.Size(), cc.cpOption.cpCipher, listener)
+		options = append(append(options, oss.Routines(rt)), cp.options()...)
+		rerr = cc.ossResumeUploadRetry(bucket, objectName, filePath, partSize, cp, options...)
+	}
+	rerr = cc.abortFailedUpload(bucket, objectName, filePath, rerr)
+	cc.reportCallback(rerr, bucket.BucketName, objectName, callbackBody)
 	if err := cc.updateSnapshot(rerr, spath, srct); err != nil {
 		rerr = err
 	}
+	if err := cc.recordDedup(rerr, bucket.BucketName, objectName, absPath, f); err != nil {
+		rerr = err
+	}
 	return
 }
 
+// initContentTypeOptions loads the mime map file and the content type detection way for upload
+func (cc *CopyCommand) initContentTypeOptions(opType operationType) error {
+	cc.cpOption.mimeMap = nil
+	cc.cpOption.sniffContentType = false
+	cc.cpOption.metaContentType = false
+
+	mimeMapFile, _ := GetString(OptionMimeMap, cc.command.options)
+	detectContentType, _ := GetString(OptionDetectContentType, cc.command.options)
+	if mimeMapFile == "" && detectContentType == "" {
+		return nil
+	}
+	if opType != operationTypePut {
+		return CommandError{cc.command.name, "only upload support option --mime-map and --detect-content-type"}
+	}
+
+	if mimeMapFile != "" {
+		mimeMap, err := loadMimeMap(mimeMapFile)
+		if err != nil {
+			return err
+		}
+		cc.cpOption.mimeMap = mimeMap
+	}
+	cc.cpOption.sniffContentType = strings.EqualFold(detectContentType, "sniff")
+
+	// Content-Type specified by --meta takes precedence
+	if cc.cpOption.meta != "" {
+		headers, err := cc.command.parseHeaders(cc.cpOption.meta, false)
+		if err != nil {
+			return err
+		}
+		for name := range headers {
+			if strings.EqualFold(name, oss.HTTPHeaderContentType) {
+				cc.cpOption.metaContentType = true
+			}
+		}
+	}
+	return nil
+}
+
+// loadMimeMap parses the mime map file, each line is: extension Content-Type
+func loadMimeMap(fileName string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeMap := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line %d of mime map file %s: %s", i+1, fileName, line)
+		}
+		ext := strings.ToLower(fields[0])
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		mimeMap[ext] = fields[1]
+	}
+	return mimeMap, nil
+}
+
+// uploadContentTypeOptions returns the Content-Type option of the file decided by the mime map or
+// the leading bytes of the file, if it returns nothing, oss sdk decides it by the built-in table
+func (cc *CopyCommand) uploadContentTypeOptions(filePath string) []oss.Option {
+	return cc.contentTypeOptions(filePath, func() (string, error) {
+		return sniffContentType(filePath)
+	})
+}
+
+// contentTypeOptions decides the content type by --meta, the mime map file or the content sniffed by sniff
+func (cc *CopyCommand) contentTypeOptions(filePath string, sniff func() (string, error)) []oss.Option {
+	if cc.cpOption.metaContentType {
+		return []oss.Option{}
+	}
+
+	if cc.cpOption.mimeMap != nil {
+		if contentType, ok := cc.cpOption.mimeMap[strings.ToLower(filepath.Ext(filePath))]; ok {
+			return []oss.Option{oss.ContentType(contentType)}
+		}
+	}
+
+	if cc.cpOption.sniffContentType {
+		if contentType, err := sniff(); err == nil {
+			return []oss.Option{oss.ContentType(contentType)}
+		}
+	}
+	return []oss.Option{}
+}
+
+func sniffContentType(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// http.DetectContentType considers at most the first 512 bytes
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
 func (cc *CopyCommand) makeObjectName(destURL CloudURL, file fileInfoType) string {
 	if destURL.object == "" || strings.HasSuffix(destURL.object, "/") {
-		// replace "\" of file.filePath to "/"
-		filePath := file.filePath
-		filePath = strings.Replace(file.filePath, string(os.PathSeparator), "/", -1)
-		filePath = strings.Replace(file.filePath, "\\", "/", -1)
+		// replace "\" of file.filePath to "/", the second byte of gbk or shift-jis character may be "\",
+		// so decode the file name first
+		filePath := decodeLocalName(file.filePath, cc.cpOption.localEncoding)
+		filePath = strings.Replace(filePath, string(os.PathSeparator), "/", -1)
+		filePath = strings.Replace(filePath, "\\", "/", -1)
 		return destURL.object + filePath
 	}
 	return destURL.object
 }
 
-func (cc *CopyCommand) skipUpload(spath string, bucket *oss.Bucket, objectName string, destURL CloudURL, srcModifiedTime int64) (bool, error) {
+func (cc *CopyCommand) skipUpload(spath, absPath string, bucket *oss.Bucket, objectName string, destURL CloudURL, srcInfo os.FileInfo) (bool, error) {
+	srcModifiedTime := srcInfo.ModTime().Unix()
 	if cc.cpOption.startTime > 0 && srcModifiedTime < cc.cpOption.startTime {
 		return true, nil
 	}
@@ -2148,6 +3637,20 @@ func (cc *CopyCommand) skipUpload(spath string, bucket *oss.Bucket, objectName s
 		return true, nil
 	}
 
+	if cc.cpOption.noClobber {
+		return cc.destObjectExists(bucket, objectName)
+	}
+
+	if cc.cpOption.dedup && !srcInfo.IsDir() {
+		if same, err := cc.dedupSkip(bucket, objectName, absPath, srcInfo); err != nil || same {
+			return same, err
+		}
+	} else if cc.cpOption.checksum && !srcInfo.IsDir() {
+		if same, err := cc.sameChecksum(bucket, objectName, absPath, srcInfo); err != nil || same {
+			return same, err
+		}
+	}
+
 	if cc.cpOption.snapshotPath != "" || cc.cpOption.update {
 		if cc.cpOption.snapshotPath != "" {
 			tstr, err := cc.cpOption.snapshotldb.Get([]byte(spath), nil)
@@ -2168,7 +3671,7 @@ func (cc *CopyCommand) skipUpload(spath string, bucket *oss.Bucket, objectName s
 		}
 	} else if !cc.cpOption.force {
 		if _, err := cc.command.ossGetObjectMetaRetry(bucket, objectName, cc.cpOption.payerOptions...); err == nil {
-			if !cc.confirm(CloudURLToString(destURL.bucket, objectName)) {
+			if !cc.confirm(destURL.objectURL(objectName)) {
 				return true, nil
 			}
 		}
@@ -2176,6 +3679,33 @@ func (cc *CopyCommand) skipUpload(spath string, bucket *oss.Bucket, objectName s
 	return false, nil
 }
 
+// sameChecksum returns true if the object has the same size and crc64 as the local file, the crc64
+// of the file is read from --hashdb if it's unchanged
+func (cc *CopyCommand) sameChecksum(bucket *oss.Bucket, objectName, absPath string, srcInfo os.FileInfo) (bool, error) {
+	props, err := cc.command.ossGetObjectStatRetry(bucket, objectName, cc.cpOption.payerOptions...)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	serverCRC := props.Get(oss.HTTPHeaderOssCRC64)
+	if serverCRC == "" || props.Get(oss.HTTPHeaderContentLength) != strconv.FormatInt(srcInfo.Size(), 10) {
+		return false, nil
+	}
+
+	var crc string
+	if cc.cpOption.hashDB != nil {
+		crc, err = cc.cpOption.hashDB.fileCRC64(absPath, srcInfo)
+	} else {
+		crc, err = fileCRC64(absPath)
+	}
+	if err != nil {
+		return false, err
+	}
+	return crc == serverCRC, nil
+}
+
 func (cc *CopyCommand) formatSnapshotKey(absPath, bucket, object string) string {
 	return absPath + SnapshotConnector + CloudURLToString(bucket, object)
 }
@@ -2185,7 +3715,7 @@ func (cc *CopyCommand) confirm(str string) bool {
 	defer mu.Unlock()
 
 	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("cp: overwrite \"%s\"(y or N)? ", str)))
+	fmt.Fprintf(cc.command.textOut(), getClearStr(fmt.Sprintf("cp: overwrite \"%s\"(y or N)? ", str)))
 	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
 		return false
 	}
@@ -2198,7 +3728,7 @@ func (cc *CopyCommand) ossPutObjectRetry(bucket *oss.Bucket, objectName string,
 		if i > 1 {
 			time.Sleep(time.Duration(3) * time.Second)
 			if int64(i) >= retryTimes {
-				fmt.Printf("\nretry count:%d:put object:%s.\n", i-1, objectName)
+				fmt.Fprintf(cc.command.textOut(), "\nretry count:%d:put object:%s.\n", i-1, objectName)
 			}
 		}
 
@@ -2210,7 +3740,7 @@ func (cc *CopyCommand) ossPutObjectRetry(bucket *oss.Bucket, objectName string,
 		// http 4XX error no need to retry
 		// only network error or internal error need to retry
 		serviceError, noNeedRetry := err.(oss.ServiceError)
-		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || !cc.cpOption.budget.allowRetry() {
 			return ObjectError{err, bucket.BucketName, objectName}
 		}
 	}
@@ -2220,9 +3750,10 @@ func (cc *CopyCommand) ossUploadFileRetry(bucket *oss.Bucket, objectName string,
 	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
 	for i := 1; ; i++ {
 		if i > 1 {
+			cc.cpOption.statSummary.addRetry(filePath)
 			time.Sleep(time.Duration(3) * time.Second)
 			if int64(i) >= retryTimes {
-				fmt.Printf("\nretry count:%d:upload file:%s\n", i-1, filePath)
+				fmt.Fprintf(cc.command.textOut(), "\nretry count:%d:upload file:%s\n", i-1, filePath)
 			}
 		}
 
@@ -2240,7 +3771,7 @@ func (cc *CopyCommand) ossUploadFileRetry(bucket *oss.Bucket, objectName string,
 		// http 4XX error no need to retry
 		// only network error or internal error need to retry
 		serviceError, noNeedRetry := err.(oss.ServiceError)
-		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || !cc.cpOption.budget.allowRetry() {
 			return FileError{err, filePath}
 		}
 	}
@@ -2297,17 +3828,22 @@ func (cc *CopyCommand) calcPartSize(fileSize int64) (int64, int64) {
 	return partSize, partNum
 }
 
-func (cc *CopyCommand) ossResumeUploadRetry(bucket *oss.Bucket, objectName string, filePath string, partSize int64, options ...oss.Option) error {
+func (cc *CopyCommand) ossResumeUploadRetry(bucket *oss.Bucket, objectName string, filePath string, partSize int64, cp *sdkCheckpoint, options ...oss.Option) error {
 	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
 	for i := 1; ; i++ {
 		if i > 1 {
+			cc.cpOption.statSummary.addRetry(filePath)
 			time.Sleep(time.Duration(3) * time.Second)
 			if int64(i) >= retryTimes {
-				fmt.Printf("\nretry count:%d,multipart upload file:%s.\n", i-1, filePath)
+				fmt.Fprintf(cc.command.textOut(), "\nretry count:%d,multipart upload file:%s.\n", i-1, filePath)
 			}
 		}
+		if err := cp.open(); err != nil {
+			return FileError{err, filePath}
+		}
 		startT := time.Now()
 		err := bucket.UploadFile(objectName, filePath, partSize, options...)
+		cp.seal()
 		cost := time.Now().UnixNano()/1000/1000 - startT.UnixNano()/1000/1000
 
 		if err == nil {
@@ -2316,12 +3852,26 @@ func (cc *CopyCommand) ossResumeUploadRetry(bucket *oss.Bucket, objectName strin
 		} else {
 			LogError("try count:%d,multipart upload file error %s,cost:%d(ms),error:%s\n", i, filePath, cost, err.Error())
 		}
-		if int64(i) >= retryTimes {
+		if int64(i) >= retryTimes || isFileAlreadyExists(err) || !cc.cpOption.budget.allowRetry() {
 			return FileError{err, filePath}
 		}
 	}
 }
 
+// isFileAlreadyExists returns true if the error is caused by x-oss-forbid-overwrite
+func isFileAlreadyExists(err error) bool {
+	var serviceError oss.ServiceError
+	return errors.As(err, &serviceError) && serviceError.StatusCode == http.StatusConflict && serviceError.Code == "FileAlreadyExists"
+}
+
+// forbidOverwriteError explains the conflict error returned by oss when --forbid-overwrite is specified
+func (cc *CopyCommand) forbidOverwriteError(err error) error {
+	if cc.cpOption.forbidOverwrite && isFileAlreadyExists(err) {
+		return ForbidOverwriteError{err}
+	}
+	return err
+}
+
 func (cc *CopyCommand) report(msg string, err error) {
 	if cc.filterError(err) {
 		cc.cpOption.reporter.ReportError(fmt.Sprintf("%s error, info: %s", msg, err.Error()))
@@ -2376,7 +3926,7 @@ func (cc *CopyCommand) filterError(err error) bool {
 
 // function for download files
 func (cc *CopyCommand) downloadFiles(srcURL CloudURL, destURL FileURL) error {
-	bucket, err := cc.command.ossBucket(srcURL.bucket)
+	bucket, err := cc.command.cloudBucket(srcURL)
 	if err != nil {
 		return err
 	}
@@ -2386,7 +3936,18 @@ func (cc *CopyCommand) downloadFiles(srcURL CloudURL, destURL FileURL) error {
 		return err
 	}
 
+	if cc.cpOption.diskQuota, err = cc.command.newDiskQuota(filePath); err != nil {
+		return err
+	}
+
+	if err = cc.checkStagingDir(filePath); err != nil {
+		return err
+	}
+
 	LogInfo("downloadFiles,recursive flag:%t\n", cc.cpOption.recursive)
+	if len(cc.cpOption.sources) > 1 {
+		return cc.batchDownloadSources(bucket, filePath)
+	}
 	if !cc.cpOption.recursive {
 		if srcURL.object == "" {
 			return fmt.Errorf("copy object invalid url: %v, object empty. If you mean batch copy objects, please use --recursive option", srcURL.ToString())
@@ -2405,6 +3966,11 @@ func (cc *CopyCommand) downloadFiles(srcURL CloudURL, destURL FileURL) error {
 			relativeKey = srcURL.object[index+1:]
 		}
 
+		if cc.cpOption.asOf != nil {
+			if err := cc.selectAsOfObject(bucket, srcURL); err != nil {
+				return err
+			}
+		}
 		go cc.objectStatistic(bucket, srcURL)
 		err := cc.downloadSingleFileWithReport(bucket, objectInfoType{prefix, relativeKey, -1, time.Now()}, filePath)
 		return cc.formatResultPrompt(err)
@@ -2414,7 +3980,7 @@ func (cc *CopyCommand) downloadFiles(srcURL CloudURL, destURL FileURL) error {
 
 func (cc *CopyCommand) formatResultPrompt(err error) error {
 	cc.closeProgress()
-	fmt.Printf(cc.monitor.progressBar(true, normalExit))
+	fmt.Fprintf(cc.command.textOut(), cc.monitor.progressBar(true, normalExit))
 	if err != nil && cc.cpOption.ctnu {
 		return nil
 	}
@@ -2441,7 +4007,7 @@ func (cc *CopyCommand) adjustDestURLForDownload(destURL FileURL) (string, error)
 	}
 
 	if !strings.HasSuffix(filePath, "/") && !strings.HasSuffix(filePath, "\\") {
-		if cc.cpOption.recursive || isDir {
+		if cc.cpOption.recursive || isDir || len(cc.cpOption.sources) > 1 {
 			filePath += "/"
 		}
 	}
@@ -2455,9 +4021,16 @@ func (cc *CopyCommand) adjustDestURLForDownload(destURL FileURL) (string, error)
 }
 
 func (cc *CopyCommand) downloadSingleFileWithReport(bucket *oss.Bucket, objectInfo objectInfoType, filePath string) error {
+	if cc.cpOption.plan != nil {
+		return cc.planDownload(bucket, objectInfo)
+	}
 	startT := time.Now()
+	objectName := objectInfo.prefix + objectInfo.relativeKey
+	cc.cpOption.progress.begin(bucketObjectURL(bucket, objectName))
 	skip, err, size, msg := cc.downloadSingleFile(bucket, objectInfo, filePath)
+	cc.cpOption.progress.end(bucketObjectURL(bucket, objectName))
 	cost := time.Now().UnixNano()/1000/1000 - startT.UnixNano()/1000/1000
+	cc.cpOption.statSummary.addRecord(objectName, bucketObjectURL(bucket, objectName), skip, err, size, time.Since(startT))
 	var realSize int64 = objectInfo.size
 	if err != nil {
 		LogError("download error,file:%s,cost:%d(ms),error info:%s\n", objectInfo.relativeKey, cost, err.Error())
@@ -2478,11 +4051,12 @@ func (cc *CopyCommand) downloadSingleFileWithReport(bucket *oss.Bucket, objectIn
 		}
 		objectKey := objectInfo.prefix + objectInfo.relativeKey
 		LogInfo("download success,object:%s,size:%d,speed:%.2f(KB/s),cost:%d(ms)\n", objectKey, realSize, speed, cost)
-		cc.updateSnapshot(nil, CloudURLToString(bucket.BucketName, objectKey), objectInfo.lastModified.Unix())
+		cc.updateSnapshot(nil, bucketObjectURL(bucket, objectKey), objectInfo.lastModified.Unix())
 	}
 
 	cc.updateMonitor(skip, err, false, size)
 	cc.report(msg, err)
+	cc.cpOption.failed.record(objectInfo.relativeKey, err)
 	return err
 }
 
@@ -2493,12 +4067,12 @@ func (cc *CopyCommand) downloadSingleFile(bucket *oss.Bucket, objectInfo objectI
 	srct := objectInfo.lastModified
 	//make file name
 	fileName := cc.makeFileName(objectInfo.relativeKey, filePath)
-	msg := fmt.Sprintf("%s %s to %s", opDownload, CloudURLToString(bucket.BucketName, object), fileName)
+	msg := fmt.Sprintf("%s %s to %s", opDownload, bucketObjectURL(bucket, object), fileName)
 
 	if size < 0 {
 		statOptions := cc.cpOption.payerOptions
-		if cc.cpOption.versionId != "" {
-			statOptions = append(statOptions, oss.VersionId(cc.cpOption.versionId))
+		if versionId := cc.objectVersionId(object); versionId != "" {
+			statOptions = append(statOptions, oss.VersionId(versionId))
 		}
 		props, err := cc.command.ossGetObjectStatRetry(bucket, object, statOptions...)
 		if err != nil {
@@ -2514,46 +4088,124 @@ func (cc *CopyCommand) downloadSingleFile(bucket *oss.Bucket, objectInfo objectI
 	}
 
 	rsize := cc.getRangeSize(size)
-	if cc.skipDownload(fileName, srct, CloudURLToString(bucket.BucketName, object)) {
+	if cc.skipDownload(fileName, srct, bucketObjectURL(bucket, object)) {
 		return true, nil, rsize, msg
 	}
 
+	cc.reportRenamedKey(bucketObjectURL(bucket, object), objectInfo.relativeKey, filePath)
+
 	if size == 0 && strings.HasSuffix(object, "/") {
 		return false, os.MkdirAll(fileName, 0755), rsize, msg
 	}
 
+	special := isSpecialFile(fileName)
+	if !special {
+		if err := cc.cpOption.diskQuota.reserve(rsize - localFileSize(fileName)); err != nil {
+			cc.cpOption.budget.abort(err)
+			return false, err, rsize, msg
+		}
+	}
+
 	//create parent directory
 	if err := cc.createParentDirectory(fileName); err != nil {
 		return false, err, rsize, msg
 	}
 
+	if !cc.cpOption.condition.isEmpty() {
+		statOptions := cc.cpOption.payerOptions
+		if versionId := cc.objectVersionId(object); versionId != "" {
+			statOptions = append(statOptions, oss.VersionId(versionId))
+		}
+		if err := cc.command.checkObjectCondition(bucket, object, cc.cpOption.condition, statOptions...); err != nil {
+			return false, err, rsize, msg
+		}
+	}
+
 	downloadOptions := cc.cpOption.options
+	if cc.cpOption.asOf != nil {
+		downloadOptions = append(append([]oss.Option{}, downloadOptions...), oss.VersionId(cc.objectVersionId(object)))
+	}
 	if cc.cpOption.vrange != "" {
 		downloadOptions = append(downloadOptions, oss.NormalizedRange(cc.cpOption.vrange))
 	}
+	downloadOptions = append(downloadOptions, cc.cpOption.condition.getOptions()...)
+
+	if special {
+		var listener *OssProgressListener = &OssProgressListener{&cc.monitor, 0, 0, false}
+		downloadOptions = append(downloadOptions, oss.Progress(listener))
+		return false, cc.downloadSpecialFile(bucket, object, fileName, downloadOptions...), 0, msg
+	}
 
+	// download to --staging-dir and rename after the crc64 is verified
+	downloadName := cc.stagingFileName(fileName)
 	if rsize < cc.cpOption.threshold {
 		var listener *OssProgressListener = &OssProgressListener{&cc.monitor, 0, 0, false}
 		downloadOptions = append(downloadOptions, oss.Progress(listener))
-		return false, cc.ossDownloadFileRetry(bucket, object, fileName, downloadOptions...), 0, msg
+		err := cc.downloadCRCRetry(bucket, object, downloadName, false, func() error {
+			return cc.ossDownloadFileRetry(bucket, object, downloadName, downloadOptions...)
+		})
+		if err == nil {
+			err = cc.commitStagingFile(downloadName, fileName)
+		}
+		return false, err, 0, msg
 	}
 
 	var listener *OssResumeProgressListener = &OssResumeProgressListener{&cc.monitor, 0, 0, false, false}
-	downloadOptions = append(downloadOptions, oss.Progress(listener))
+	absDownloadName, _ := filepath.Abs(downloadName)
+	cp := newSDKCheckpoint(cc.cpOption.cpDir, bucketObjectURL(bucket, object)+CheckpointSep+cc.objectVersionId(object), absDownloadName, size, cc.cpOption.cpCipher, listener)
 
 	partSize, rt := cc.preparePartOption(size)
-	cp := oss.CheckpointDir(true, cc.cpOption.cpDir)
 	LogInfo("multipart download,object %s,file size:%d,partSize %d,routin count:%d,checkpoint dir:%s\n",
 		object, size, partSize, rt, cc.cpOption.cpDir)
-	downloadOptions = append(downloadOptions, oss.Routines(rt), cp)
-	return false, cc.ossResumeDownloadRetry(bucket, object, fileName, size, partSize, downloadOptions...), 0, msg
+	downloadOptions = append(append(downloadOptions, oss.Routines(rt)), cp.options()...)
+	err := cc.downloadCRCRetry(bucket, object, downloadName, true, func() error {
+		return cc.ossResumeDownloadRetry(bucket, object, downloadName, size, partSize, cp, downloadOptions...)
+	})
+	if err == nil {
+		err = cc.commitStagingFile(downloadName, fileName)
+	}
+	return false, err, 0, msg
 }
 
 func (cc *CopyCommand) makeFileName(relativeObject, filePath string) string {
+	return cc.makeLocalFileName(relativeObject, filePath, runtime.GOOS == "windows")
+}
+
+// makeLocalFileName maps the object key which is not a valid file name and uses the extended-length
+// path on windows
+func (cc *CopyCommand) makeLocalFileName(relativeObject, filePath string, windows bool) string {
+	fileName := filePath
 	if strings.HasSuffix(filePath, "/") || strings.HasSuffix(filePath, "\\") {
-		return filePath + relativeObject
+		if windows {
+			relativeObject = sanitizeWindowsKey(relativeObject, cc.cpOption.windowsNameMap)
+		}
+		fileName = filePath + encodeLocalName(relativeObject, cc.cpOption.localEncoding)
+	}
+	if windows {
+		fileName = windowsLongPath(fileName)
+	}
+	return fileName
+}
+
+// reportCallback prints the response body of the upload callback returned by the application server
+func (cc *CopyCommand) reportCallback(err error, bucket, object string, body []byte) {
+	if err != nil || !cc.cpOption.callback {
+		return
+	}
+	fmt.Fprintf(cc.command.textOut(), "\r%s\rcallback response of %s: %s\n", clearStr, CloudURLToString(bucket, object), string(body))
+	LogInfo("callback response of %s: %s\n", CloudURLToString(bucket, object), string(body))
+}
+
+// reportRenamedKey prints the object key which is saved with a different file name on windows
+func (cc *CopyCommand) reportRenamedKey(object, relativeKey, filePath string) {
+	if runtime.GOOS != "windows" || !strings.HasSuffix(filePath, "/") && !strings.HasSuffix(filePath, "\\") {
+		return
+	}
+	if mapped := sanitizeWindowsKey(relativeKey, cc.cpOption.windowsNameMap); mapped != relativeKey {
+		atomic.AddInt64(&cc.cpOption.renamedKeys, 1)
+		fmt.Fprintf(cc.command.textOut(), "\r%s\r%s is saved as %s because of the windows naming rules\n", clearStr, object, mapped)
+		LogInfo("%s is saved as %s because of the windows naming rules\n", object, mapped)
 	}
-	return filePath
 }
 
 func (cc *CopyCommand) skipDownload(fileName string, srcModifiedTime time.Time, object string) bool {
@@ -2565,6 +4217,11 @@ func (cc *CopyCommand) skipDownload(fileName string, srcModifiedTime time.Time,
 		return true
 	}
 
+	if cc.cpOption.noClobber {
+		_, err := os.Lstat(fileName)
+		return err == nil
+	}
+
 	if cc.cpOption.snapshotPath != "" || cc.cpOption.update {
 		if cc.cpOption.snapshotPath != "" {
 			tstr, err := cc.cpOption.snapshotldb.Get([]byte(object), nil)
@@ -2595,6 +4252,10 @@ func (cc *CopyCommand) skipDownload(fileName string, srcModifiedTime time.Time,
 }
 
 func (cc *CopyCommand) createParentDirectory(fileName string) error {
+	// the extended-length path is absolute and can't contain "/"
+	if strings.HasPrefix(fileName, `\\?\`) {
+		return os.MkdirAll(filepath.Dir(fileName), 0755)
+	}
 	dir, err := filepath.Abs(filepath.Dir(fileName))
 	if err != nil {
 		return err
@@ -2607,9 +4268,10 @@ func (cc *CopyCommand) ossDownloadFileRetry(bucket *oss.Bucket, objectName, file
 	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
 	for i := 1; ; i++ {
 		if i > 1 {
+			cc.cpOption.statSummary.addRetry(objectName)
 			time.Sleep(time.Duration(3) * time.Second)
 			if int64(i) >= retryTimes {
-				fmt.Printf("\nretry count:%d:get object to file:%s.\n", i-1, fileName)
+				fmt.Fprintf(cc.command.textOut(), "\nretry count:%d:get object to file:%s.\n", i-1, fileName)
 			}
 		}
 
@@ -2626,28 +4288,34 @@ func (cc *CopyCommand) ossDownloadFileRetry(bucket *oss.Bucket, objectName, file
 
 		// http 4XX error no need to retry
 		// only network error or internal error need to retry
+		// the crc64 mismatch is retried by downloadCRCRetry
 		serviceError, noNeedRetry := err.(oss.ServiceError)
-		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || isCRCCheckError(err) || !cc.cpOption.budget.allowRetry() {
 			return ObjectError{err, bucket.BucketName, objectName}
 		}
 	}
 }
 
-func (cc *CopyCommand) ossResumeDownloadRetry(bucket *oss.Bucket, objectName string, filePath string, size, partSize int64, options ...oss.Option) error {
+func (cc *CopyCommand) ossResumeDownloadRetry(bucket *oss.Bucket, objectName string, filePath string, size, partSize int64, cp *sdkCheckpoint, options ...oss.Option) error {
 	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
 	for i := 1; ; i++ {
 		if i > 1 {
+			cc.cpOption.statSummary.addRetry(objectName)
 			time.Sleep(time.Duration(3) * time.Second)
 			if int64(i) >= retryTimes {
-				fmt.Printf("\nretry count:%d:mulitpart download file:%s.\n", i-1, objectName)
+				fmt.Fprintf(cc.command.textOut(), "\nretry count:%d:mulitpart download file:%s.\n", i-1, objectName)
 			}
 		}
 
+		if err := cp.open(); err != nil {
+			return ObjectError{err, bucket.BucketName, objectName}
+		}
 		err := bucket.DownloadFile(objectName, filePath, partSize, options...)
+		cp.seal()
 		if err == nil {
 			return cc.truncateFile(filePath, size)
 		}
-		if int64(i) >= retryTimes {
+		if int64(i) >= retryTimes || isPreconditionFailed(err) || isCRCCheckError(err) || !cc.cpOption.budget.allowRetry() {
 			return ObjectError{err, bucket.BucketName, objectName}
 		}
 	}
@@ -2677,11 +4345,13 @@ func (cc *CopyCommand) updateSnapshot(err error, spath string, srct int64) error
 
 func (cc *CopyCommand) batchDownloadFiles(bucket *oss.Bucket, srcURL CloudURL, filePath string) error {
 	cc.adjustSrcURLForCommand(&srcURL, cc.cpOption.bSyncCommand)
+	// both objectStatistic & object Producer will list objects, this is duplicate
+	if err := cc.startStatistic(func() { cc.objectStatistic(bucket, srcURL) }); err != nil {
+		return err
+	}
 	chObjects := make(chan objectInfoType, ChannelBuf)
 	chError := make(chan error, cc.cpOption.routines)
 	chListError := make(chan error, 1)
-	// both objectStatistic & object Producer will list objects, this is duplicate
-	go cc.objectStatistic(bucket, srcURL)
 	go cc.objectProducer(bucket, srcURL, chObjects, chListError)
 
 	LogInfo("batch download files,routin count:%d,srcurl:%s,filepath:%s\n", cc.cpOption.routines, srcURL.ToString(), filePath)
@@ -2692,71 +4362,97 @@ func (cc *CopyCommand) batchDownloadFiles(bucket *oss.Bucket, srcURL CloudURL, f
 }
 
 func (cc *CopyCommand) objectStatistic(bucket *oss.Bucket, cloudURL CloudURL) {
-	if cc.cpOption.recursive {
-		pre := oss.Prefix(cloudURL.object)
-		marker := oss.Marker("")
-		//while the src object is end with "/", use object key as marker, exclude the object itself
-		if strings.HasSuffix(cloudURL.object, "/") {
-			marker = oss.Marker(cloudURL.object)
-		}
+	if err := cc.scanObjects(bucket, cloudURL); err != nil {
+		cc.monitor.setScanError(err)
+		return
+	}
 
-		del := oss.Delimiter("")
-		if cc.cpOption.onlyCurrentDir {
-			del = oss.Delimiter("/")
-		}
-		listOptions := append(cc.cpOption.payerOptions, pre, marker, del)
+	cc.monitor.setScanEnd()
+	freshProgress()
+}
 
+// scanObjects counts the objects of the cloud url for the progress
+func (cc *CopyCommand) scanObjects(bucket *oss.Bucket, cloudURL CloudURL) error {
+	if cc.cpOption.retryKeys != nil {
+		// the sizes are unknown before the objects are operated
+		cc.monitor.updateScanSizeNum(0, int64(len(cc.cpOption.retryKeys)))
+	} else if cc.cpOption.recursive && cc.cpOption.asOf != nil {
+		fnvIns := fnv.New64()
+		return cc.listAsOfObjects(bucket, cloudURL, func(objects []oss.ObjectProperties) bool {
+			cc.scanListedObjects(bucket, cloudURL, objects, fnvIns)
+			return cc.checkScanDiskSpace() && cc.checkScanCap()
+		})
+	} else if cc.cpOption.recursive {
+		listOptions := cc.srcListOptions(cloudURL)
+		token := oss.ContinuationToken("")
 		fnvIns := fnv.New64()
 		for {
-			lor, err := cc.command.ossListObjectsRetry(bucket, listOptions...)
+			lor, err := cc.command.ossListObjectsV2Retry(bucket, append(listOptions, token)...)
 			if err != nil {
-				cc.monitor.setScanError(err)
-				return
+				return err
 			}
 
-			for _, object := range lor.Objects {
-				if doesSingleObjectMatchPatterns(object.Key, cc.cpOption.filters) {
-					if cc.cpOption.partitionIndex == 0 || (cc.cpOption.partitionIndex > 0 && matchHash(fnvIns, object.Key, cc.cpOption.partitionIndex-1, cc.cpOption.partitionCount)) {
-						if strings.ToLower(object.Type) == "symlink" && cc.cpOption.opType == operationTypeGet {
-							props, _ := cc.command.ossGetObjectStatRetry(bucket, object.Key, cc.cpOption.payerOptions...)
-							size, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
-							if err == nil {
-								object.Size = size
-							}
-						}
-						cc.monitor.updateScanSizeNum(cc.getRangeSize(object.Size), 1)
-					}
-				}
+			cc.scanListedObjects(bucket, cloudURL, lor.Objects, fnvIns)
+			if cc.cpOption.plan != nil {
+				// the objects are listed again by the producer
+				cc.cpOption.plan.addList(2)
 			}
-			pre = oss.Prefix(lor.Prefix)
-			marker = oss.Marker(lor.NextMarker)
-			listOptions = append(cc.cpOption.payerOptions, pre, marker)
+			if !cc.checkScanDiskSpace() || !cc.checkScanCap() {
+				break
+			}
+			token = oss.ContinuationToken(lor.NextContinuationToken)
 			if !lor.IsTruncated {
 				break
 			}
 		}
 	} else {
 		statOptions := cc.cpOption.payerOptions
-		if cc.cpOption.versionId != "" {
-			statOptions = append(statOptions, oss.VersionId(cc.cpOption.versionId))
+		if versionId := cc.objectVersionId(cloudURL.object); versionId != "" {
+			statOptions = append(statOptions, oss.VersionId(versionId))
 		}
 
 		props, err := cc.command.ossGetObjectStatRetry(bucket, cloudURL.object, statOptions...)
 		if err != nil {
-			cc.monitor.setScanError(err)
-			return
+			return err
 		}
 
 		size, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
 		if err != nil {
-			cc.monitor.setScanError(err)
-			return
+			return err
 		}
 		cc.monitor.updateScanSizeNum(cc.getRangeSize(size), 1)
 	}
+	return nil
+}
 
-	cc.monitor.setScanEnd()
-	freshProgress()
+func (cc *CopyCommand) scanListedObjects(bucket *oss.Bucket, cloudURL CloudURL, objects []oss.ObjectProperties, fnvIns hash.Hash64) {
+	for _, object := range objects {
+		if doesSingleObjectMatchPatterns(object.Key, cc.cpOption.filters) && cc.matchObjectGlob(cloudURL, object.Key) {
+			if cc.cpOption.partitionIndex == 0 || (cc.cpOption.partitionIndex > 0 && matchHash(fnvIns, object.Key, cc.cpOption.partitionIndex-1, cc.cpOption.partitionCount)) {
+				if strings.ToLower(object.Type) == "symlink" && cc.cpOption.opType == operationTypeGet {
+					props, _ := cc.command.ossGetObjectStatRetry(bucket, object.Key, cc.cpOption.payerOptions...)
+					size, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+					if err == nil {
+						object.Size = size
+					}
+				}
+				cc.monitor.updateScanSizeNum(cc.getRangeSize(object.Size), 1)
+			}
+		}
+	}
+}
+
+// checkScanDiskSpace stops the download early if the listed bytes exceed the free space or --max-disk-usage,
+// the check is skipped when the existing files may be skipped
+func (cc *CopyCommand) checkScanDiskSpace() bool {
+	if cc.cpOption.opType != operationTypeGet || cc.cpOption.plan != nil || cc.cpOption.update || cc.cpOption.noClobber || cc.cpOption.snapshotPath != "" {
+		return true
+	}
+	if err := cc.cpOption.diskQuota.check(cc.monitor.totalSize); err != nil {
+		cc.cpOption.budget.abort(err)
+		return false
+	}
+	return true
 }
 
 func (cc *CopyCommand) getRangeSize(size int64) int64 {
@@ -2809,63 +4505,116 @@ func (cc *CopyCommand) parseRange(str string, size int64) (int64, error) {
 	}
 }
 
+// listStartAfter returns the key after which the src objects are listed, while the src object is
+// end with "/", the object itself is excluded
+func (cc *CopyCommand) listStartAfter(cloudURL CloudURL) string {
+	startAfter := cc.cpOption.startAfter
+	if strings.HasSuffix(cloudURL.object, "/") && cloudURL.object > startAfter {
+		startAfter = cloudURL.object
+	}
+	return startAfter
+}
+
+func (cc *CopyCommand) srcListOptions(cloudURL CloudURL) []oss.Option {
+	listOptions := append([]oss.Option{oss.Prefix(cloudURL.object)}, cc.cpOption.payerOptions...)
+	if startAfter := cc.listStartAfter(cloudURL); startAfter != "" {
+		listOptions = append(listOptions, oss.StartAfter(startAfter))
+	}
+	if cc.cpOption.onlyCurrentDir {
+		listOptions = append(listOptions, oss.Delimiter("/"))
+	}
+	return listOptions
+}
+
 func (cc *CopyCommand) objectProducer(bucket *oss.Bucket, cloudURL CloudURL, chObjects chan<- objectInfoType, chError chan<- error) {
 	defer close(chObjects)
-	pre := oss.Prefix(cloudURL.object)
-	marker := oss.Marker("")
-	//while the src object is end with "/", use object key as marker, exclude the object itself
-	if strings.HasSuffix(cloudURL.object, "/") {
-		marker = oss.Marker(cloudURL.object)
+	chError <- cc.listObjects(bucket, cloudURL, chObjects)
+}
+
+// listObjects sends the objects under the cloud url to chObjects
+func (cc *CopyCommand) listObjects(bucket *oss.Bucket, cloudURL CloudURL, chObjects chan<- objectInfoType) error {
+	if cc.cpOption.retryKeys != nil {
+		cc.retryObjectProducer(cloudURL, chObjects)
+		return nil
 	}
-	del := oss.Delimiter("")
-	if cc.cpOption.onlyCurrentDir {
-		del = oss.Delimiter("/")
+	if cc.cpOption.listSplit != "" {
+		return cc.shardedObjectProducer(bucket, cloudURL, chObjects)
 	}
 
-	listOptions := append(cc.cpOption.payerOptions, pre, marker, del)
 	fnvIns := fnv.New64()
+	if cc.cpOption.asOf != nil {
+		return cc.listAsOfObjects(bucket, cloudURL, func(objects []oss.ObjectProperties) bool {
+			cc.sendObjects(bucket, cloudURL, objects, fnvIns, chObjects)
+			return true
+		})
+	}
+
+	listOptions := cc.srcListOptions(cloudURL)
+	token := oss.ContinuationToken("")
 	for {
-		lor, err := cc.command.ossListObjectsRetry(bucket, listOptions...)
+		lor, err := cc.command.ossListObjectsV2Retry(bucket, append(listOptions, token)...)
 		if err != nil {
-			chError <- err
-			return
-		}
-		for _, object := range lor.Objects {
-			prefix := ""
-			relativeKey := object.Key
-			index := strings.LastIndex(cloudURL.object, "/")
-			if index > 0 {
-				prefix = object.Key[:index+1]
-				relativeKey = object.Key[index+1:]
-			}
-
-			if doesSingleObjectMatchPatterns(object.Key, cc.cpOption.filters) {
-				if cc.cpOption.partitionIndex == 0 || (cc.cpOption.partitionIndex > 0 && matchHash(fnvIns, object.Key, cc.cpOption.partitionIndex-1, cc.cpOption.partitionCount)) {
-					if strings.ToLower(object.Type) == "symlink" && cc.cpOption.opType == operationTypeGet {
-						props, _ := cc.command.ossGetObjectStatRetry(bucket, object.Key, cc.cpOption.payerOptions...)
-						size, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
-						if err == nil {
-							object.Size = size
-						}
-					}
-					chObjects <- objectInfoType{prefix, relativeKey, int64(object.Size), object.LastModified}
-				}
-			}
+			return err
 		}
+		cc.sendObjects(bucket, cloudURL, lor.Objects, fnvIns, chObjects)
 
-		pre = oss.Prefix(lor.Prefix)
-		marker = oss.Marker(lor.NextMarker)
-		listOptions = append(cc.cpOption.payerOptions, pre, marker)
+		token = oss.ContinuationToken(lor.NextContinuationToken)
 		if !lor.IsTruncated {
 			break
 		}
 	}
+	return nil
+}
 
-	chError <- nil
+// shardedObjectProducer lists the shards of the keyspace concurrently and merges the objects into chObjects
+func (cc *CopyCommand) shardedObjectProducer(bucket *oss.Bucket, cloudURL CloudURL, chObjects chan<- objectInfoType) error {
+	shards, err := makeListShards(cloudURL.object, cc.listStartAfter(cloudURL), cc.cpOption.listSplit)
+	if err != nil {
+		return err
+	}
+	LogInfo("list %s by %d shards\n", cloudURL.ToString(), len(shards))
+
+	listOptions := append([]oss.Option{}, cc.cpOption.payerOptions...)
+	if cc.cpOption.onlyCurrentDir {
+		listOptions = append(listOptions, oss.Delimiter("/"))
+	}
+	return cc.command.ossListObjectsSharded(bucket, cloudURL.object, shards, func(objects []oss.ObjectProperties) error {
+		cc.sendObjects(bucket, cloudURL, objects, fnv.New64(), chObjects)
+		return nil
+	}, listOptions...)
+}
+
+func (cc *CopyCommand) sendObjects(bucket *oss.Bucket, cloudURL CloudURL, objects []oss.ObjectProperties, fnvIns hash.Hash64, chObjects chan<- objectInfoType) {
+	for _, object := range objects {
+		prefix := ""
+		relativeKey := object.Key
+		index := strings.LastIndex(cloudURL.object, "/")
+		if index > 0 {
+			prefix = object.Key[:index+1]
+			relativeKey = object.Key[index+1:]
+		}
+
+		if doesSingleObjectMatchPatterns(object.Key, cc.cpOption.filters) && cc.matchObjectGlob(cloudURL, object.Key) {
+			if cc.cpOption.partitionIndex == 0 || (cc.cpOption.partitionIndex > 0 && matchHash(fnvIns, object.Key, cc.cpOption.partitionIndex-1, cc.cpOption.partitionCount)) {
+				if strings.ToLower(object.Type) == "symlink" && cc.cpOption.opType == operationTypeGet {
+					props, _ := cc.command.ossGetObjectStatRetry(bucket, object.Key, cc.cpOption.payerOptions...)
+					size, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+					if err == nil {
+						object.Size = size
+					}
+				}
+				chObjects <- objectInfoType{prefix, relativeKey, int64(object.Size), object.LastModified}
+			}
+		}
+	}
 }
 
 func (cc *CopyCommand) downloadConsumer(bucket *oss.Bucket, filePath string, chObjects <-chan objectInfoType, chError chan<- error) {
 	for objectInfo := range chObjects {
+		if cc.cpOption.budget.exceeded() || cc.exceedSafetyCap(objectInfo.size) {
+			cc.cpOption.budget.skip()
+			continue
+		}
 		err := cc.downloadSingleFileWithReport(bucket, objectInfo, filePath)
 		if err != nil {
 			chError <- err
@@ -2896,7 +4645,7 @@ func (cc *CopyCommand) waitRoutinueComplete(chError, chListError <-chan error, o
 				ferr = err
 				if !cc.cpOption.ctnu {
 					cc.closeProgress()
-					fmt.Printf(cc.monitor.progressBar(true, errExit))
+					fmt.Fprintf(cc.command.textOut(), cc.monitor.progressBar(true, errExit))
 					return err
 				}
 			}
@@ -2907,11 +4656,15 @@ func (cc *CopyCommand) waitRoutinueComplete(chError, chListError <-chan error, o
 
 // function for copy objects
 func (cc *CopyCommand) copyFiles(srcURL, destURL CloudURL) error {
-	bucket, err := cc.command.ossBucket(srcURL.bucket)
+	bucket, err := cc.command.cloudBucket(srcURL)
 	if err != nil {
 		return err
 	}
 
+	if len(cc.cpOption.sources) > 1 {
+		return cc.batchCopySources(bucket, destURL)
+	}
+
 	if err := cc.checkCopyFileArgs(srcURL, destURL); err != nil {
 		return err
 	}
@@ -2970,9 +4723,19 @@ func (cc *CopyCommand) checkCopyFileArgs(srcURL, destURL CloudURL) error {
 }
 
 func (cc *CopyCommand) copySingleFileWithReport(bucket *oss.Bucket, objectInfo objectInfoType, srcURL, destURL CloudURL) error {
+	if cc.cpOption.plan != nil {
+		return cc.planCopy(bucket, objectInfo)
+	}
+	startT := time.Now()
+	objectName := objectInfo.prefix + objectInfo.relativeKey
+	cc.cpOption.progress.begin(srcURL.objectURL(objectName))
 	skip, err, size, msg := cc.copySingleFile(bucket, objectInfo, srcURL, destURL)
+	cc.cpOption.progress.end(srcURL.objectURL(objectName))
+	err = cc.forbidOverwriteError(err)
+	cc.cpOption.statSummary.addRecord(objectName, srcURL.objectURL(objectName), skip, err, size, time.Since(startT))
 	cc.updateMonitor(skip, err, false, size)
 	cc.report(msg, err)
+	cc.cpOption.failed.record(objectInfo.relativeKey, err)
 	return err
 }
 
@@ -2983,7 +4746,7 @@ func (cc *CopyCommand) copySingleFile(bucket *oss.Bucket, objectInfo objectInfoT
 	size := objectInfo.size
 	srct := objectInfo.lastModified
 
-	msg := fmt.Sprintf("%s %s to %s", opCopy, CloudURLToString(srcURL.bucket, srcObject), CloudURLToString(destURL.bucket, destObject))
+	msg := fmt.Sprintf("%s %s to %s", opCopy, srcURL.objectURL(srcObject), destURL.objectURL(destObject))
 
 	//get object size
 	if size < 0 {
@@ -3009,16 +4772,20 @@ func (cc *CopyCommand) copySingleFile(bucket *oss.Bucket, objectInfo objectInfoT
 		return skip, err, size, msg
 	}
 
+	if isCrossCloudCopy(srcURL, destURL) {
+		return false, cc.ossStreamCopyRetry(bucket, srcObject, destURL, destObject, size), size, msg
+	}
+
 	if size < cc.cpOption.threshold {
 		return false, cc.ossCopyObjectRetry(bucket, srcObject, destURL.bucket, destObject), size, msg
 	}
 
 	var listener *OssResumeProgressListener = &OssResumeProgressListener{&cc.monitor, 0, 0, false, false}
 	partSize, rt := cc.preparePartOption(size)
-	cp := oss.CheckpointDir(true, cc.cpOption.cpDir)
+	cp := newSDKCheckpoint(cc.cpOption.cpDir, CloudURLToString(srcURL.bucket, srcObject), CloudURLToString(destURL.bucket, destObject), size, cc.cpOption.cpCipher, listener)
 	options := cc.cpOption.options
-	options = append(options, oss.Routines(rt), cp, oss.Progress(listener), oss.MetadataDirective(oss.MetaReplace))
-	return false, cc.ossResumeCopyRetry(srcURL.bucket, srcObject, destURL.bucket, destObject, partSize, options...), 0, msg
+	options = append(append(options, oss.Routines(rt), oss.MetadataDirective(oss.MetaReplace)), cp.options()...)
+	return false, cc.ossResumeCopyRetry(srcURL.bucket, srcObject, destURL, destObject, partSize, cp, options...), 0, msg
 }
 
 func (cc *CopyCommand) makeCopyObjectName(srcRelativeObject, destObject string) string {
@@ -3037,11 +4804,15 @@ func (cc *CopyCommand) skipCopy(destURL CloudURL, destObject string, srct time.T
 		return true, nil
 	}
 
-	destBucket, err := cc.command.ossBucket(destURL.bucket)
+	destBucket, err := cc.command.cloudBucket(destURL)
 	if err != nil {
 		return false, err
 	}
 
+	if cc.cpOption.noClobber {
+		return cc.destObjectExists(destBucket, destObject)
+	}
+
 	if cc.cpOption.update {
 		if props, err := cc.command.ossGetObjectStatRetry(destBucket, destObject, cc.cpOption.payerOptions...); err == nil {
 			destt, err := time.Parse(http.TimeFormat, props.Get(oss.HTTPHeaderLastModified))
@@ -3052,7 +4823,7 @@ func (cc *CopyCommand) skipCopy(destURL CloudURL, destObject string, srct time.T
 	} else {
 		if !cc.cpOption.force {
 			if _, err := cc.command.ossGetObjectMetaRetry(destBucket, destObject, cc.cpOption.payerOptions...); err == nil {
-				if !cc.confirm(CloudURLToString(destURL.bucket, destObject)) {
+				if !cc.confirm(destURL.objectURL(destObject)) {
 					return true, nil
 				}
 			}
@@ -3061,6 +4832,61 @@ func (cc *CopyCommand) skipCopy(destURL CloudURL, destObject string, srct time.T
 	return false, nil
 }
 
+// loadDestObjects lists the objects under the destination prefix once, so that --no-clobber
+// doesn't need to head every destination object in batch operation. If the listing fails,
+// the existence of every object is checked by head instead.
+func (cc *CopyCommand) loadDestObjects(bucket *oss.Bucket, prefix string) {
+	lowMemory, _ := GetBool(OptionLowMemory, cc.command.options)
+	destObjects, err := newKeyStore(lowMemory)
+	if err != nil {
+		LogError("create key store for destination objects error:%s\n", err.Error())
+		return
+	}
+	listOptions := []oss.Option{oss.Prefix(prefix), oss.MaxKeys(1000)}
+	listOptions = append(listOptions, cc.cpOption.payerOptions...)
+	token := ""
+	for {
+		lor, err := cc.command.ossListObjectsV2Retry(bucket, append(listOptions, oss.ContinuationToken(token))...)
+		if err != nil {
+			LogError("list destination objects error, prefix:%s, error:%s\n", prefix, err.Error())
+			destObjects.close()
+			return
+		}
+		for _, object := range lor.Objects {
+			if err := destObjects.put(object.Key, ""); err != nil {
+				LogError("save destination object %s error:%s\n", object.Key, err.Error())
+				destObjects.close()
+				return
+			}
+		}
+		token = lor.NextContinuationToken
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	LogInfo("list destination objects, prefix:%s, count:%d\n", prefix, destObjects.len())
+	cc.cpOption.destObjects = destObjects
+}
+
+// destObjectExists checks whether the destination object exists by the listing or head
+func (cc *CopyCommand) destObjectExists(bucket *oss.Bucket, object string) (bool, error) {
+	if cc.cpOption.destObjects != nil {
+		_, ok := cc.cpOption.destObjects.get(object)
+		return ok, nil
+	}
+	_, err := cc.command.ossGetObjectMetaRetry(bucket, object, cc.cpOption.payerOptions...)
+	if err == nil {
+		return true, nil
+	}
+	if objectErr, ok := err.(ObjectError); ok {
+		err = objectErr.err
+	}
+	if serviceError, ok := err.(oss.ServiceError); ok && serviceError.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
 func (cc *CopyCommand) ossCopyObjectRetry(bucket *oss.Bucket, objectName, destBucketName, destObjectName string) error {
 	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
 	options := cc.cpOption.options
@@ -3068,9 +4894,10 @@ func (cc *CopyCommand) ossCopyObjectRetry(bucket *oss.Bucket, objectName, destBu
 	options = append(options, oss.TaggingDirective(oss.TaggingReplace))
 	for i := 1; ; i++ {
 		if i > 1 {
+			cc.cpOption.statSummary.addRetry(objectName)
 			time.Sleep(time.Duration(3) * time.Second)
 			if int64(i) >= retryTimes {
-				fmt.Printf("\nretry count:%d,copy object:%s.\n", i-1, objectName)
+				fmt.Fprintf(cc.command.textOut(), "\nretry count:%d,copy object:%s.\n", i-1, objectName)
 			}
 		}
 		_, err := bucket.CopyObjectTo(destBucketName, destObjectName, objectName, options...)
@@ -3081,42 +4908,350 @@ func (cc *CopyCommand) ossCopyObjectRetry(bucket *oss.Bucket, objectName, destBu
 		// http 4XX error no need to retry
 		// only network error or internal error need to retry
 		serviceError, noNeedRetry := err.(oss.ServiceError)
-		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || !cc.cpOption.budget.allowRetry() {
 			return ObjectError{err, bucket.BucketName, objectName}
 		}
 	}
 }
 
-func (cc *CopyCommand) ossResumeCopyRetry(bucketName, objectName, destBucketName, destObjectName string, partSize int64, options ...oss.Option) error {
-	bucket, err := cc.command.ossBucket(destBucketName)
+func (cc *CopyCommand) ossResumeCopyRetry(bucketName, objectName string, destURL CloudURL, destObjectName string, partSize int64, cp *sdkCheckpoint, options ...oss.Option) error {
+	bucket, err := cc.command.cloudBucket(destURL)
 	if err != nil {
 		return err
 	}
 	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
 	for i := 1; ; i++ {
 		if i > 1 {
+			cc.cpOption.statSummary.addRetry(objectName)
 			time.Sleep(time.Duration(3) * time.Second)
 			if int64(i) >= retryTimes {
-				fmt.Printf("\nretry count:%d, resume copy object:%s.\n", i-1, objectName)
+				fmt.Fprintf(cc.command.textOut(), "\nretry count:%d, resume copy object:%s.\n", i-1, objectName)
 			}
 		}
 
+		if err := cp.open(); err != nil {
+			return ObjectError{err, bucket.BucketName, objectName}
+		}
 		err := bucket.CopyFile(bucketName, objectName, destObjectName, partSize, options...)
+		cp.seal()
 		if err == nil {
 			return err
 		}
-		if int64(i) >= retryTimes {
+		if int64(i) >= retryTimes || isFileAlreadyExists(err) || !cc.cpOption.budget.allowRetry() {
 			return ObjectError{err, bucket.BucketName, objectName}
 		}
 	}
 }
 
+// isCrossCloudCopy returns true if the source and the destination are served by different
+// providers, in which case server side copy is impossible and the data is streamed
+func isCrossCloudCopy(srcURL, destURL CloudURL) bool {
+	return srcURL.isS3() != destURL.isS3()
+}
+
+// streamCopyCheckpoint records the uploaded parts of cross cloud copy for resuming
+type streamCopyCheckpoint struct {
+	SrcURL   string           `json:"srcURL"`
+	DestURL  string           `json:"destURL"`
+	SrcETag  string           `json:"srcETag"`
+	SrcSize  int64            `json:"srcSize"`
+	PartSize int64            `json:"partSize"`
+	UploadID string           `json:"uploadID"`
+	Parts    []oss.UploadPart `json:"parts"`
+	mu       sync.Mutex       `json:"-"`
+	path     string           `json:"-"`
+	aead     cipher.AEAD      `json:"-"`
+}
+
+func (scp *streamCopyCheckpoint) addPart(part oss.UploadPart) error {
+	scp.mu.Lock()
+	defer scp.mu.Unlock()
+	scp.Parts = append(scp.Parts, part)
+	return scp.save()
+}
+
+func (scp *streamCopyCheckpoint) save() error {
+	data, err := json.Marshal(scp)
+	if err != nil {
+		return err
+	}
+	return writeCheckpoint(scp.path, data, scp.aead)
+}
+
+// streamCopyHeaders returns the options which keep the metadata of the source object
+func streamCopyHeaders(props http.Header) []oss.Option {
+	options := []oss.Option{}
+	for _, name := range []string{oss.HTTPHeaderContentType, oss.HTTPHeaderCacheControl, oss.HTTPHeaderContentDisposition,
+		oss.HTTPHeaderContentEncoding, oss.HTTPHeaderExpires} {
+		if value := props.Get(name); value != "" {
+			options = append(options, oss.SetHeader(name, value))
+		}
+	}
+	for name := range props {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(oss.HTTPHeaderOssMetaPrefix)) {
+			options = append(options, oss.SetHeader(name, props.Get(name)))
+		}
+	}
+	return options
+}
+
+// streamCopySourceOptions returns the options which read the source object only if it's still the
+// content of etag, so that the destination isn't combined from the different contents
+func streamCopySourceOptions(etag string) []oss.Option {
+	if etag == "" {
+		return []oss.Option{}
+	}
+	return []oss.Option{oss.IfMatch(etag)}
+}
+
+// ossStreamCopyRetry copies object between different providers without local staging, the data
+// of source object is read by range and piped into multipart upload of the destination part by part.
+// The reads are pinned to the etag of the source object, if the source object is changed during the
+// copy, the checkpoint is dropped and the copy starts over
+func (cc *CopyCommand) ossStreamCopyRetry(bucket *oss.Bucket, objectName string, destURL CloudURL, destObjectName string, size int64) error {
+	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
+	for i := int64(1); ; i++ {
+		err := cc.ossStreamCopy(bucket, objectName, destURL, destObjectName, size, retryTimes)
+		if err == nil || !isPreconditionFailed(err) || i >= retryTimes || !cc.cpOption.budget.allowRetry() {
+			return err
+		}
+		LogError("the source object %s is changed during stream copy, copy it again, retry count:%d\n", bucketObjectURL(bucket, objectName), i)
+		cc.cpOption.statSummary.addRetry(objectName)
+	}
+}
+
+func (cc *CopyCommand) ossStreamCopy(bucket *oss.Bucket, objectName string, destURL CloudURL, destObjectName string, size int64, retryTimes int64) error {
+	destBucketName := destURL.bucket
+	destBucket, err := cc.command.cloudBucket(destURL)
+	if err != nil {
+		return err
+	}
+
+	props, err := cc.command.ossGetObjectStatRetry(bucket, objectName, cc.cpOption.payerOptions...)
+	if err != nil {
+		return err
+	}
+	// the object may be changed after it's listed, the size goes with the etag
+	if length, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64); err == nil {
+		size = length
+	}
+	etag := props.Get(oss.HTTPHeaderEtag)
+	options := append(streamCopyHeaders(props), cc.cpOption.options...)
+
+	if size < cc.cpOption.threshold {
+		for i := 1; ; i++ {
+			err = cc.streamCopySmallObject(bucket, objectName, etag, destBucket, destObjectName, size, options)
+			if err == nil {
+				return nil
+			}
+			serviceError, noNeedRetry := err.(oss.ServiceError)
+			if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || !cc.cpOption.budget.allowRetry() {
+				return ObjectError{err, bucket.BucketName, objectName}
+			}
+			cc.cpOption.statSummary.addRetry(objectName)
+			time.Sleep(time.Duration(3) * time.Second)
+		}
+	}
+
+	partSize, rt := cc.preparePartOption(size)
+	scp, err := cc.loadStreamCopyCheckpoint(bucket, objectName, destBucket, destObjectName, etag, size, partSize)
+	if err != nil {
+		return err
+	}
+	if scp.UploadID == "" {
+		imur, err := destBucket.InitiateMultipartUpload(destObjectName, options...)
+		if err != nil {
+			return ObjectError{err, destBucketName, destObjectName}
+		}
+		scp.UploadID = imur.UploadID
+		if err := scp.save(); err != nil {
+			return err
+		}
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: destBucketName, Key: destObjectName, UploadID: scp.UploadID}
+
+	done := map[int]bool{}
+	for _, part := range scp.Parts {
+		done[part.PartNumber] = true
+	}
+	partNum := int((size-1)/partSize + 1)
+	if size == 0 {
+		partNum = 1
+	}
+	chParts := make(chan int, partNum)
+	for i := 1; i <= partNum; i++ {
+		if !done[i] {
+			chParts <- i
+		}
+	}
+	close(chParts)
+
+	chError := make(chan error, rt)
+	for i := 0; i < rt; i++ {
+		go func() {
+			for partNumber := range chParts {
+				part, err := cc.streamCopyPartRetry(bucket, objectName, etag, destBucket, imur, partNumber, partSize, size, retryTimes)
+				if err == nil {
+					err = scp.addPart(part)
+				}
+				if err != nil {
+					chError <- err
+					return
+				}
+			}
+			chError <- nil
+		}()
+	}
+	for i := 0; i < rt; i++ {
+		if e := <-chError; e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		if isPreconditionFailed(err) {
+			// the parts of the former content can't be resumed
+			destBucket.AbortMultipartUpload(imur, cc.cpOption.payerOptions...)
+			os.Remove(scp.path)
+		}
+		return ObjectError{err, bucket.BucketName, objectName}
+	}
+
+	parts := make([]oss.UploadPart, len(scp.Parts))
+	copy(parts, scp.Parts)
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+	completeOptions := append([]oss.Option{}, cc.cpOption.payerOptions...)
+	if cc.cpOption.forbidOverwrite {
+		completeOptions = append(completeOptions, oss.ForbidOverWrite(true))
+	}
+	if _, err := destBucket.CompleteMultipartUpload(imur, parts, completeOptions...); err != nil {
+		return ObjectError{err, destBucketName, destObjectName}
+	}
+	os.Remove(scp.path)
+	return nil
+}
+
+func (cc *CopyCommand) streamCopySmallObject(bucket *oss.Bucket, objectName, etag string, destBucket *oss.Bucket, destObjectName string, size int64, options []oss.Option) error {
+	body, err := bucket.GetObject(objectName, append(streamCopySourceOptions(etag), cc.cpOption.payerOptions...)...)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	options = append(options, oss.ContentLength(size))
+	return destBucket.PutObject(destObjectName, body, options...)
+}
+
+func (cc *CopyCommand) streamCopyPartRetry(bucket *oss.Bucket, objectName, etag string, destBucket *oss.Bucket, imur oss.InitiateMultipartUploadResult,
+	partNumber int, partSize, size int64, retryTimes int64) (oss.UploadPart, error) {
+	start := int64(partNumber-1) * partSize
+	end := start + partSize - 1
+	if end >= size {
+		end = size - 1
+	}
+	for i := 1; ; i++ {
+		part, err := cc.streamCopyPart(bucket, objectName, etag, destBucket, imur, partNumber, start, end)
+		if err == nil {
+			return part, nil
+		}
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || !cc.cpOption.budget.allowRetry() {
+			return part, err
+		}
+		LogError("stream copy part %d of %s error, retry %d, error:%s\n", partNumber, objectName, i, err.Error())
+		cc.cpOption.statSummary.addRetry(objectName)
+		time.Sleep(time.Duration(3) * time.Second)
+	}
+}
+
+func (cc *CopyCommand) streamCopyPart(bucket *oss.Bucket, objectName, etag string, destBucket *oss.Bucket, imur oss.InitiateMultipartUploadResult,
+	partNumber int, start, end int64) (oss.UploadPart, error) {
+	options := append(streamCopySourceOptions(etag), cc.cpOption.payerOptions...)
+	if end >= start {
+		options = append(options, oss.Range(start, end))
+	}
+	body, err := bucket.GetObject(objectName, options...)
+	if err != nil {
+		return oss.UploadPart{}, err
+	}
+	defer body.Close()
+	return destBucket.UploadPart(imur, body, end-start+1, partNumber, cc.cpOption.payerOptions...)
+}
+
+// loadStreamCopyCheckpoint loads the checkpoint of cross cloud copy, the checkpoint is dropped if the
+// source object is changed or the upload does not exist any more
+func (cc *CopyCommand) loadStreamCopyCheckpoint(bucket *oss.Bucket, objectName string, destBucket *oss.Bucket, destObjectName, etag string,
+	size, partSize int64) (*streamCopyCheckpoint, error) {
+	srcURL := bucketObjectURL(bucket, objectName)
+	destURL := bucketObjectURL(destBucket, destObjectName)
+	if err := os.MkdirAll(cc.cpOption.cpDir, 0755); err != nil {
+		return nil, err
+	}
+	sum := md5.Sum([]byte(srcURL + CheckpointSep + destURL))
+	cpPath := filepath.Join(cc.cpOption.cpDir, hex.EncodeToString(sum[:])+".stream.cp")
+
+	scp := &streamCopyCheckpoint{}
+	if data, err := readCheckpoint(cpPath, cc.cpOption.cpCipher); err == nil && json.Unmarshal(data, scp) == nil &&
+		scp.SrcURL == srcURL && scp.DestURL == destURL && scp.SrcETag == etag && scp.SrcSize == size && scp.PartSize == partSize && scp.UploadID != "" {
+		imur := oss.InitiateMultipartUploadResult{Bucket: destBucket.BucketName, Key: destObjectName, UploadID: scp.UploadID}
+		if parts, err := listAllUploadedParts(destBucket, imur, cc.cpOption.payerOptions...); err == nil {
+			// the parts uploaded by server take precedence over the local records
+			scp.Parts = scp.Parts[:0]
+			for _, part := range parts {
+				scp.Parts = append(scp.Parts, oss.UploadPart{PartNumber: part.PartNumber, ETag: part.ETag})
+			}
+			scp.path = cpPath
+			scp.aead = cc.cpOption.cpCipher
+			LogInfo("resume stream copy %s to %s, upload id %s, %d parts uploaded\n", srcURL, destURL, scp.UploadID, len(scp.Parts))
+			return scp, nil
+		}
+	}
+
+	scp = &streamCopyCheckpoint{
+		SrcURL:   srcURL,
+		DestURL:  destURL,
+		SrcETag:  etag,
+		SrcSize:  size,
+		PartSize: partSize,
+		path:     cpPath,
+		aead:     cc.cpOption.cpCipher,
+	}
+	return scp, nil
+}
+
+// listAllUploadedParts lists the uploaded parts of the multipart upload page by page
+func listAllUploadedParts(bucket *oss.Bucket, imur oss.InitiateMultipartUploadResult, options ...oss.Option) ([]oss.UploadedPart, error) {
+	parts := []oss.UploadedPart{}
+	partNumberMarker := 0
+	for {
+		lpOptions := append([]oss.Option{oss.MaxParts(1000), oss.PartNumberMarker(partNumberMarker)}, options...)
+		lpRes, err := bucket.ListUploadedParts(imur, lpOptions...)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, lpRes.UploadedParts...)
+		if !lpRes.IsTruncated {
+			return parts, nil
+		}
+		if partNumberMarker, err = strconv.Atoi(lpRes.NextPartNumberMarker); err != nil {
+			return nil, err
+		}
+	}
+}
+
 func (cc *CopyCommand) batchCopyFiles(bucket *oss.Bucket, srcURL, destURL CloudURL) error {
 	cc.adjustSrcURLForCommand(&srcURL, cc.cpOption.bSyncCommand)
+	if cc.cpOption.noClobber {
+		destBucket, err := cc.command.cloudBucket(destURL)
+		if err != nil {
+			return err
+		}
+		cc.loadDestObjects(destBucket, destURL.object)
+	}
+	if err := cc.startStatistic(func() { cc.objectStatistic(bucket, srcURL) }); err != nil {
+		return err
+	}
 	chObjects := make(chan objectInfoType, ChannelBuf)
 	chError := make(chan error, cc.cpOption.routines)
 	chListError := make(chan error, 1)
-	go cc.objectStatistic(bucket, srcURL)
 	go cc.objectProducer(bucket, srcURL, chObjects, chListError)
 
 	for i := 0; int64(i) < cc.cpOption.routines; i++ {
@@ -3128,6 +5263,10 @@ func (cc *CopyCommand) batchCopyFiles(bucket *oss.Bucket, srcURL, destURL CloudU
 
 func (cc *CopyCommand) copyConsumer(bucket *oss.Bucket, srcURL, destURL CloudURL, chObjects <-chan objectInfoType, chError chan<- error) {
 	for objectInfo := range chObjects {
+		if cc.cpOption.budget.exceeded() || cc.exceedSafetyCap(objectInfo.size) {
+			cc.cpOption.budget.skip()
+			continue
+		}
 		err := cc.copySingleFileWithReport(bucket, objectInfo, srcURL, destURL)
 		if err != nil {
 			chError <- err
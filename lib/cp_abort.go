@@ -0,0 +1,75 @@
+package lib
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// abortUploadError is the error of the failed multipart upload whose upload can't be aborted, the
+// upload id is reported so that the parts can be removed by rm -m later
+type abortUploadError struct {
+	err      error
+	abortErr error
+	uploadID string
+}
+
+func (e abortUploadError) Error() string {
+	return fmt.Sprintf("%s, abort the multipart upload %s error: %s", e.err.Error(), e.uploadID, e.abortErr.Error())
+}
+
+func (e abortUploadError) Unwrap() error {
+	return wrapServiceError(e.err)
+}
+
+// uploadCheckpointPath returns the checkpoint file of the resumed upload in --checkpoint-dir,
+// it's named in the same way as the sdk, or as newSDKCheckpoint with --encrypt-checkpoint
+func (cc *CopyCommand) uploadCheckpointPath(absPath, destURL string) string {
+	if cc.cpOption.cpCipher != nil {
+		return sdkCheckpointPath(cc.cpOption.cpDir, absPath, destURL)
+	}
+	srcSum := md5.Sum([]byte(absPath))
+	destSum := md5.Sum([]byte(destURL))
+	return cc.cpOption.cpDir + string(os.PathSeparator) + hex.EncodeToString(srcSum[:]) + "-" + hex.EncodeToString(destSum[:]) + ".cp"
+}
+
+// abortFailedUpload aborts the multipart upload of the file which failed after the retries or was
+// canceled, so that the uploaded parts don't consume the storage silently, and removes its
+// checkpoint. Nothing is done with --keep-parts, the upload is resumed when the command is run again.
+// If the abort fails, the upload id is added to the error which is recorded in --output-failed
+func (cc *CopyCommand) abortFailedUpload(bucket *oss.Bucket, objectName, filePath string, err error) error {
+	if err == nil || cc.cpOption.keepParts {
+		return err
+	}
+	absPath, _ := filepath.Abs(filePath)
+	destURL := bucketObjectURL(bucket, objectName)
+	for _, cpPath := range []string{cc.partCRCCheckpointPath(absPath, destURL), cc.uploadCheckpointPath(absPath, destURL)} {
+		data, rerr := readCheckpoint(cpPath, cc.cpOption.cpCipher)
+		if rerr != nil {
+			continue
+		}
+		var cp struct {
+			UploadID string
+		}
+		if json.Unmarshal(data, &cp) != nil || cp.UploadID == "" {
+			continue
+		}
+
+		imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: objectName, UploadID: cp.UploadID}
+		if aerr := bucket.AbortMultipartUpload(imur, cc.cpOption.payerOptions...); aerr != nil && !isNotFound(aerr) {
+			LogError("abort the multipart upload %s of %s error:%s\n", cp.UploadID, destURL, aerr.Error())
+			if fileErr, ok := err.(FileError); ok {
+				return FileError{abortUploadError{fileErr.err, aerr, cp.UploadID}, fileErr.file}
+			}
+			return abortUploadError{err, aerr, cp.UploadID}
+		}
+		LogInfo("abort the multipart upload %s of %s after the upload failed\n", cp.UploadID, destURL)
+		os.Remove(cpPath)
+	}
+	return err
+}
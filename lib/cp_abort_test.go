@@ -0,0 +1,166 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestCopyAbortFailedUpload(c *C) {
+	var mu sync.Mutex
+	initiates, aborts := 0, 0
+	abortStatus := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		ioutil.ReadAll(r.Body)
+		query := r.URL.Query()
+		switch {
+		case r.Method == "POST" && r.URL.RawQuery == "uploads":
+			initiates++
+			fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>object</Key><UploadId>upload%d</UploadId></InitiateMultipartUploadResult>`, initiates)
+		case r.Method == "PUT" && query.Get("partNumber") != "":
+			if query.Get("partNumber") == "2" {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>denied</Message></Error>`)
+				return
+			}
+			w.Header().Set("ETag", `"etag`+query.Get("partNumber")+`"`)
+		case r.Method == "GET" && query.Get("uploadId") != "":
+			fmt.Fprintf(w, `<ListPartsResult><UploadId>%s</UploadId><Part><PartNumber>1</PartNumber><ETag>"etag1"</ETag></Part></ListPartsResult>`, query.Get("uploadId"))
+		case r.Method == "DELETE" && query.Get("uploadId") != "":
+			aborts++
+			w.WriteHeader(abortStatus)
+			if abortStatus != http.StatusNoContent {
+				fmt.Fprint(w, `<Error><Code>InternalError</Code><Message>internal error</Message></Error>`)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-abort-" + randLowStr(5)
+	s.createFile(fileName, strings.Repeat("a", 300*1024), c)
+	defer os.Remove(fileName)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	failedPath := "ossutil-test-failed-" + randLowStr(5) + ".csv"
+	threshold := "102400"
+	routines := "1"
+	partSize := "102400"
+	keepParts := false
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(cpDir)
+	defer os.Remove(failedPath)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionOutputDir:        &outputDir,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+		OptionKeepParts:        &keepParts,
+		OptionOutputFailed:     &failedPath,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the failed upload is aborted, the next run starts a new upload
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(initiates, Equals, 1)
+	c.Assert(aborts, Equals, 1)
+
+	// the parts are kept and resumed with --keep-parts
+	keepParts = true
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(initiates, Equals, 2)
+	c.Assert(aborts, Equals, 1)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(initiates, Equals, 2)
+	c.Assert(aborts, Equals, 1)
+
+	// the upload id is recorded if the abort fails
+	keepParts = false
+	abortStatus = http.StatusInternalServerError
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(initiates, Equals, 2)
+	c.Assert(aborts > 1, Equals, true)
+	c.Assert(strings.Contains(s.readFile(failedPath, c), "abort the multipart upload upload2 error"), Equals, true)
+
+	// the checkpoint of the kept parts is encrypted when it's written, and resumed
+	os.Setenv(CheckpointKeyEnv, "passphrase")
+	defer os.Unsetenv(CheckpointKeyEnv)
+	encryptCheckpoint := true
+	options[OptionEncryptCheckpoint] = &encryptCheckpoint
+	keepParts = true
+	abortStatus = http.StatusNoContent
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	resumed := initiates
+	cpFiles, err := filepath.Glob(filepath.Join(cpDir, "*.cp"))
+	c.Assert(err, IsNil)
+	c.Assert(len(cpFiles) > 0, Equals, true)
+	for _, cpFile := range cpFiles {
+		data, err := ioutil.ReadFile(cpFile)
+		c.Assert(err, IsNil)
+		c.Assert(bytes.HasPrefix(data, sealedCheckpointMagic), Equals, true)
+		c.Assert(bytes.Contains(data, []byte("upload")), Equals, false)
+	}
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(initiates, Equals, resumed)
+
+	// the encrypted checkpoint isn't resumed without --encrypt-checkpoint
+	delete(options, OptionEncryptCheckpoint)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "is encrypted"), Equals, true)
+
+	// the upload of the encrypted checkpoint is aborted without --keep-parts, and the checkpoint is removed
+	options[OptionEncryptCheckpoint] = &encryptCheckpoint
+	keepParts = false
+	aborted := aborts
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(initiates, Equals, resumed)
+	c.Assert(aborts, Equals, aborted+1)
+	cpFiles, err = filepath.Glob(filepath.Join(cpDir, "*.sdk.cp"))
+	c.Assert(err, IsNil)
+	c.Assert(len(cpFiles), Equals, 0)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(initiates, Equals, resumed+1)
+	c.Assert(aborts, Equals, aborted+2)
+
+	// --keep-parts only works with upload
+	keepParts = true
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/object", fileName + ".download"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--keep-parts"), Equals, true)
+}
@@ -0,0 +1,138 @@
+package lib
+
+import (
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// appendUploadFile appends the data of the local file beyond the length of the appendable object
+// to the object, the object is created if it doesn't exist. It returns true if there is nothing
+// to append
+func (cc *CopyCommand) appendUploadFile(bucket *oss.Bucket, objectName, filePath string, fileSize int64) (bool, error) {
+	if fileSize > MaxAppendObjectSize {
+		return false, FileError{fmt.Errorf("the size of %s is bigger than %d, it is not supported by append", filePath, MaxAppendObjectSize), filePath}
+	}
+
+	position, crc, err := cc.appendPosition(bucket, objectName)
+	if err != nil {
+		return false, err
+	}
+	if fileSize < position {
+		return false, fmt.Errorf("%s is smaller than the object %s, it's not the file appended to the object", filePath, bucketObjectURL(bucket, objectName))
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	// make sure the object is the head of the local file before appending the rest
+	if crc != "" {
+		hash := crc64.New(crc64ECMATable)
+		if _, err := io.CopyN(hash, file, position); err != nil {
+			return false, err
+		}
+		if strconv.FormatUint(hash.Sum64(), 10) != crc {
+			return false, fmt.Errorf("the crc64 of the object %s is different from the first %d bytes of %s, it's not the file appended to the object", bucketObjectURL(bucket, objectName), position, filePath)
+		}
+	}
+	if fileSize == position {
+		return true, nil
+	}
+	cc.monitor.updateDealSize(position)
+
+	chunkSize, _ := GetInt(OptionPartSize, cc.command.options)
+	if chunkSize <= 0 {
+		chunkSize = DefaultAppendChunkSize
+	}
+	// the meta, the acl and the tagging can only be set when the object is created
+	options := cc.cpOption.payerOptions
+	if position == 0 {
+		options = append(cc.uploadContentTypeOptions(filePath), cc.cpOption.options...)
+	}
+	for position < fileSize {
+		size := fileSize - position
+		if size > chunkSize {
+			size = chunkSize
+		}
+		if position, crc, err = cc.ossAppendObjectRetry(bucket, objectName, file, position, size, crc, options); err != nil {
+			return false, err
+		}
+		options = cc.cpOption.payerOptions
+	}
+	return false, nil
+}
+
+// appendPosition returns the length and the crc64 of the appendable object, 0 if it doesn't exist
+func (cc *CopyCommand) appendPosition(bucket *oss.Bucket, objectName string) (int64, string, error) {
+	props, err := cc.command.ossGetObjectStatRetry(bucket, objectName, cc.cpOption.payerOptions...)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, "", nil
+		}
+		return 0, "", err
+	}
+	if objectType := props.Get("X-Oss-Object-Type"); objectType != "Appendable" {
+		return 0, "", fmt.Errorf("the type of %s is %s, --append only work with the Appendable object", bucketObjectURL(bucket, objectName), objectType)
+	}
+	position, err := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return position, props.Get(oss.HTTPHeaderOssCRC64), nil
+}
+
+// ossAppendObjectRetry appends size bytes of the file from position, it returns the next position
+// and the crc64 of the object. If the append fails, the object is checked since the data may be
+// appended already
+func (cc *CopyCommand) ossAppendObjectRetry(bucket *oss.Bucket, objectName string, file *os.File, position, size int64, crc string, options []oss.Option) (int64, string, error) {
+	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
+	for i := 1; ; i++ {
+		listener := &OssProgressListener{&cc.monitor, 0, 0, false}
+		appendOptions := append([]oss.Option{oss.Progress(listener)}, options...)
+		if crc != "" {
+			initCRC, _ := strconv.ParseUint(crc, 10, 64)
+			appendOptions = append(appendOptions, oss.InitCRC(initCRC))
+		}
+		request := &oss.AppendObjectRequest{
+			ObjectKey: objectName,
+			Reader:    io.NewSectionReader(file, position, size),
+			Position:  position,
+		}
+		result, err := bucket.DoAppendObject(request, cc.command.withContext(appendOptions))
+		if err == nil {
+			return result.NextPosition, strconv.FormatUint(result.CRC, 10), nil
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500 && serviceError.StatusCode != http.StatusConflict) || !cc.cpOption.budget.allowRetry() {
+			return position, crc, ObjectError{err, bucket.BucketName, objectName}
+		}
+		cc.cpOption.statSummary.addRetry(objectName)
+		if err := cc.command.waitRetry(time.Duration(3) * time.Second); err != nil {
+			return position, crc, err
+		}
+
+		current, currentCRC, err := cc.appendPosition(bucket, objectName)
+		if err != nil {
+			return position, crc, err
+		}
+		if current == position+size {
+			cc.monitor.updateDealSize(size)
+			return current, currentCRC, nil
+		}
+		if current != position {
+			return position, crc, fmt.Errorf("the object %s is appended by others, the length is %d, expected %d", bucketObjectURL(bucket, objectName), current, position)
+		}
+	}
+}
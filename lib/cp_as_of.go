@@ -0,0 +1,143 @@
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// asOfSelector selects the version of each object which was live at the time of --as-of, the
+// selected versions are recorded by the object keys for downloading them
+type asOfSelector struct {
+	asOf     time.Time
+	mu       sync.Mutex
+	versions map[string]string
+}
+
+// asOfEntry is a version or a delete marker of the listed object
+type asOfEntry struct {
+	object       oss.ObjectProperties
+	versionId    string
+	deleteMarker bool
+}
+
+// newAsOfSelector returns nil if --as-of is not specified
+func (cc *CopyCommand) newAsOfSelector(opType operationType) (*asOfSelector, error) {
+	strAsOf, _ := GetString(OptionAsOf, cc.command.options)
+	if strAsOf == "" {
+		return nil, nil
+	}
+	if opType != operationTypeGet {
+		return nil, CommandError{cc.command.name, "--as-of only work with download"}
+	}
+	if cc.cpOption.versionId != "" || cc.cpOption.listSplit != "" || cc.cpOption.retryKeys != nil || len(cc.cpOption.sources) > 1 {
+		return nil, CommandError{cc.command.name, "--as-of can't be used together with --version-id, --list-split, --retry-from or multiple source urls"}
+	}
+	asOf, err := parseTimeBound(strAsOf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --as-of: %s", err.Error())
+	}
+	return &asOfSelector{asOf: asOf, versions: map[string]string{}}, nil
+}
+
+// objectVersionId returns the version of the object to download, it's the version selected by
+// --as-of or --version-id
+func (cc *CopyCommand) objectVersionId(object string) string {
+	if cc.cpOption.asOf == nil {
+		return cc.cpOption.versionId
+	}
+	cc.cpOption.asOf.mu.Lock()
+	defer cc.cpOption.asOf.mu.Unlock()
+	return cc.cpOption.asOf.versions[object]
+}
+
+// listAsOfObjects lists the versions under the cloud url, and calls fn with the objects which were
+// live at the time of --as-of page by page, the listing stops if fn returns false. The versions of
+// an object are listed from the newest to the oldest, the first one not later than --as-of is
+// selected, the object is skipped if it's a delete marker or all the versions are later
+func (cc *CopyCommand) listAsOfObjects(bucket *oss.Bucket, cloudURL CloudURL, fn func(objects []oss.ObjectProperties) bool) error {
+	listOptions := append([]oss.Option{oss.Prefix(cloudURL.object), oss.MaxKeys(1000)}, cc.cpOption.payerOptions...)
+	if cc.cpOption.onlyCurrentDir {
+		listOptions = append(listOptions, oss.Delimiter("/"))
+	}
+
+	keyMarker := oss.KeyMarker("")
+	versionIdMarker := oss.VersionIdMarker("")
+	lastKey, selected := "", false
+	for {
+		lor, err := cc.command.ossListObjectVersionsRetry(bucket, append(listOptions, keyMarker, versionIdMarker)...)
+		if err != nil {
+			return err
+		}
+
+		var objects []oss.ObjectProperties
+		for _, entry := range sortVersionEntries(lor) {
+			if entry.object.Key != lastKey {
+				lastKey, selected = entry.object.Key, false
+			}
+			if selected || entry.object.LastModified.After(cc.cpOption.asOf.asOf) {
+				continue
+			}
+			selected = true
+			if entry.deleteMarker || (!cc.cpOption.recursive && entry.object.Key != cloudURL.object) {
+				continue
+			}
+			cc.cpOption.asOf.mu.Lock()
+			cc.cpOption.asOf.versions[entry.object.Key] = entry.versionId
+			cc.cpOption.asOf.mu.Unlock()
+			objects = append(objects, entry.object)
+		}
+		// the single object is listed before the other keys with it as the prefix
+		if !fn(objects) || !lor.IsTruncated || (!cc.cpOption.recursive && lastKey > cloudURL.object) {
+			return nil
+		}
+		keyMarker = oss.KeyMarker(lor.NextKeyMarker)
+		versionIdMarker = oss.VersionIdMarker(lor.NextVersionIdMarker)
+	}
+}
+
+// sortVersionEntries merges the versions and the delete markers, they are sorted by the keys, and
+// from the newest to the oldest of each key
+func sortVersionEntries(lor oss.ListObjectVersionsResult) []asOfEntry {
+	entries := make([]asOfEntry, 0, len(lor.ObjectVersions)+len(lor.ObjectDeleteMarkers))
+	for _, version := range lor.ObjectVersions {
+		entries = append(entries, asOfEntry{
+			object: oss.ObjectProperties{Key: version.Key, Type: version.Type, Size: version.Size, ETag: version.ETag,
+				LastModified: version.LastModified, StorageClass: version.StorageClass},
+			versionId: version.VersionId,
+		})
+	}
+	for _, marker := range lor.ObjectDeleteMarkers {
+		entries = append(entries, asOfEntry{
+			object:       oss.ObjectProperties{Key: marker.Key, LastModified: marker.LastModified},
+			versionId:    marker.VersionId,
+			deleteMarker: true,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].object.Key != entries[j].object.Key {
+			return entries[i].object.Key < entries[j].object.Key
+		}
+		return entries[i].object.LastModified.After(entries[j].object.LastModified)
+	})
+	return entries
+}
+
+// selectAsOfObject selects the version of the single object to download
+func (cc *CopyCommand) selectAsOfObject(bucket *oss.Bucket, cloudURL CloudURL) error {
+	found := false
+	err := cc.listAsOfObjects(bucket, cloudURL, func(objects []oss.ObjectProperties) bool {
+		found = found || len(objects) > 0
+		return !found
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%s doesn't exist at %s", cloudURL.ToString(), cc.cpOption.asOf.asOf.Format(time.RFC3339))
+	}
+	return nil
+}
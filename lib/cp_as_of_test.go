@@ -0,0 +1,121 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestCopyAsOf(c *C) {
+	contents := map[string]string{
+		"dir/a.txt?v1": "a1", "dir/a.txt?v2": "a2", "dir/a.txt?v3": "a3",
+		"dir/b.txt?v1": "b1", "dir/c.txt?v1": "c1", "dir/d.txt?v1": "d1",
+	}
+	version := func(key, id, lastModified string, latest bool) string {
+		return fmt.Sprintf(`<Version><Key>%s</Key><VersionId>%s</VersionId><IsLatest>%t</IsLatest><LastModified>%s</LastModified><Size>%d</Size></Version>`,
+			key, id, latest, lastModified, len(contents[key+"?"+id]))
+	}
+	marker := func(key, id, lastModified string, latest bool) string {
+		return fmt.Sprintf(`<DeleteMarker><Key>%s</Key><VersionId>%s</VersionId><IsLatest>%t</IsLatest><LastModified>%s</LastModified></DeleteMarker>`,
+			key, id, latest, lastModified)
+	}
+	// the versions of dir/a.txt are listed by two pages
+	page1 := `<ListVersionsResult><IsTruncated>true</IsTruncated><NextKeyMarker>dir/a.txt</NextKeyMarker><NextVersionIdMarker>v3</NextVersionIdMarker>` +
+		version("dir/a.txt", "v3", "2024-07-01T00:00:00.000Z", true) + `</ListVersionsResult>`
+	page2 := `<ListVersionsResult><IsTruncated>false</IsTruncated>` +
+		version("dir/a.txt", "v2", "2024-05-01T00:00:00.000Z", false) +
+		version("dir/a.txt", "v1", "2024-01-01T00:00:00.000Z", false) +
+		version("dir/b.txt", "v1", "2024-02-01T00:00:00.000Z", false) +
+		version("dir/c.txt", "v1", "2024-06-15T00:00:00.000Z", true) +
+		version("dir/d.txt", "v1", "2024-03-01T00:00:00.000Z", false) +
+		marker("dir/b.txt", "m1", "2024-05-15T00:00:00.000Z", true) +
+		marker("dir/d.txt", "m1", "2024-07-01T00:00:00.000Z", true) + `</ListVersionsResult>`
+
+	var downloads []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if _, ok := query["versions"]; ok {
+			if query.Get("key-marker") == "" {
+				fmt.Fprint(w, page1)
+			} else {
+				fmt.Fprint(w, page2)
+			}
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/") + "?" + query.Get("versionId")
+		content, ok := contents[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+		w.Header().Set("Last-Modified", "Wed, 01 May 2024 00:00:00 GMT")
+		if r.Method == "GET" {
+			downloads = append(downloads, key)
+			w.Write([]byte(content))
+		}
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-as-of-" + randLowStr(5)
+	defer os.RemoveAll(dir)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	defer os.RemoveAll(cpDir)
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	asOf := "2024-06-01T00:00:00Z"
+	threshold := "1048576"
+	routines := "1"
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRecursion:        &recursive,
+		OptionAsOf:             &asOf,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the deleted object and the object created later are not downloaded
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/dir/", dir}, options)
+	c.Assert(err, IsNil)
+	c.Assert(downloads, DeepEquals, []string{"dir/a.txt?v2", "dir/d.txt?v1"})
+	c.Assert(s.readFile(dir+"/a.txt", c), Equals, "a2")
+	c.Assert(s.readFile(dir+"/d.txt", c), Equals, "d1")
+	files, _ := ioutil.ReadDir(dir)
+	c.Assert(len(files), Equals, 2)
+
+	// the single object
+	recursive = false
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/dir/a.txt", dir + "/single.txt"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(dir+"/single.txt", c), Equals, "a2")
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/dir/b.txt", dir + "/b.txt"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "doesn't exist at 2024-06-01T00:00:00Z"), Equals, true)
+
+	// --as-of only works with download
+	fileName := dir + "/a.txt"
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/dir/a.txt"}, options)
+	c.Assert(err, NotNil)
+	asOf = "yesterday"
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/dir/a.txt", dir + "/single.txt"}, options)
+	c.Assert(err, NotNil)
+}
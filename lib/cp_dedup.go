@@ -0,0 +1,49 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// dedupRecord is the value of the uploaded object in the hashdb, the key is the object url so that
+// it never conflicts with the absolute paths of the local files
+func dedupRecord(size int64, crc string) string {
+	return fmt.Sprintf("%d:%s", size, crc)
+}
+
+// dedupSkip returns true if the object with the same size and crc64 as the local file exists, the
+// record in the hashdb is trusted without the HEAD request, the object compared by the HEAD request
+// is recorded for the next run
+func (cc *CopyCommand) dedupSkip(bucket *oss.Bucket, objectName, absPath string, srcInfo os.FileInfo) (bool, error) {
+	crc, err := cc.cpOption.hashDB.fileCRC64(absPath, srcInfo)
+	if err != nil {
+		return false, err
+	}
+	key := []byte(bucketObjectURL(bucket, objectName))
+	record := dedupRecord(srcInfo.Size(), crc)
+	if value, err := cc.cpOption.hashDB.db.Get(key, nil); err == nil && string(value) == record {
+		LogInfo("skip %s, the same content is recorded in the hashdb\n", string(key))
+		return true, nil
+	}
+
+	same, err := cc.sameChecksum(bucket, objectName, absPath, srcInfo)
+	if err != nil || !same {
+		return same, err
+	}
+	return true, cc.cpOption.hashDB.db.Put(key, []byte(record), nil)
+}
+
+// recordDedup records the object uploaded from the local file in the hashdb with --dedup
+func (cc *CopyCommand) recordDedup(uploadErr error, bucketName, objectName, absPath string, srcInfo os.FileInfo) error {
+	if !cc.cpOption.dedup || uploadErr != nil {
+		return nil
+	}
+	crc, err := cc.cpOption.hashDB.fileCRC64(absPath, srcInfo)
+	if err != nil {
+		return err
+	}
+	key := []byte(CloudURLToString(bucketName, objectName))
+	return cc.cpOption.hashDB.db.Put(key, []byte(dedupRecord(srcInfo.Size(), crc)), nil)
+}
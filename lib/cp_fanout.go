@@ -0,0 +1,215 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// fanoutDest is one destination of --fanout, the destinations are uploaded independently,
+// the failed destination is dropped and the others go on
+type fanoutDest struct {
+	bucket     *oss.Bucket
+	objectName string
+	imur       oss.InitiateMultipartUploadResult
+	parts      []oss.UploadPart
+	err        error
+}
+
+func (d *fanoutDest) String() string {
+	return CloudURLToString(d.bucket.BucketName, d.objectName)
+}
+
+// getFanoutURLs parses the destination urls of --fanout
+func (cc *CopyCommand) getFanoutURLs(urls []string) ([]CloudURL, error) {
+	if len(urls) < 2 {
+		return nil, CommandError{cc.command.name, "--fanout needs at least two destination urls"}
+	}
+	destURLs := []CloudURL{}
+	for _, url := range urls {
+		destURL, err := CloudURLFromString(url, cc.cpOption.encodingType)
+		if err != nil {
+			return nil, err
+		}
+		if destURL.bucket == "" {
+			return nil, fmt.Errorf("invalid cloud url: %s, miss bucket", url)
+		}
+		destURLs = append(destURLs, destURL)
+	}
+	return destURLs, nil
+}
+
+// fanoutUpload reads the local file once and uploads it to all the destinations concurrently,
+// the parts in memory are shared by the destinations. Each destination is verified by the crc64
+// of the local file after uploading
+func (cc *CopyCommand) fanoutUpload(srcURL FileURL, destURLs []CloudURL) error {
+	filePath := srcURL.ToString()
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if stat.IsDir() {
+		return fmt.Errorf("%s is a directory, --fanout only work with a single file", filePath)
+	}
+
+	dests := []*fanoutDest{}
+	for _, destURL := range destURLs {
+		if err := destURL.checkObjectPrefix(); err != nil {
+			return err
+		}
+		bucket, err := cc.command.cloudBucket(destURL)
+		if err != nil {
+			return err
+		}
+		objectName := cc.makeObjectName(destURL, fileInfoType{filePath: filepath.Base(filePath)})
+		dests = append(dests, &fanoutDest{bucket: bucket, objectName: objectName})
+	}
+	cc.monitor.updateScanSizeNum(stat.Size()*int64(len(dests)), int64(len(dests)))
+	cc.monitor.setScanEnd()
+
+	partSize := cc.streamPartSize()
+	if minSize := stat.Size()/MaxPartNum + 1; partSize < minSize {
+		partSize = minSize
+	}
+	buf := make([]byte, partSize)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	hash := crc64.New(crc64ECMATable)
+	hash.Write(buf[:n])
+	options := append(cc.uploadContentTypeOptions(filePath), cc.cpOption.options...)
+	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
+	LogInfo("fanout upload %s to %d destinations, part size:%d\n", filePath, len(dests), partSize)
+
+	if err != nil {
+		// the file is smaller than one part
+		data := buf[:n]
+		putOptions := cc.command.withContext(append(options, oss.ContentLength(int64(n))))
+		cc.fanoutEach(dests, func(d *fanoutDest) error {
+			return cc.retryStreamRequest(d.bucket.BucketName, d.objectName, retryTimes, func() error {
+				return d.bucket.PutObject(d.objectName, bytes.NewReader(data), putOptions...)
+			})
+		}, int64(n))
+	} else {
+		cc.fanoutEach(dests, func(d *fanoutDest) error {
+			var ierr error
+			if d.imur, ierr = d.bucket.InitiateMultipartUpload(d.objectName, cc.command.withContext(options)...); ierr != nil {
+				return ObjectError{ierr, d.bucket.BucketName, d.objectName}
+			}
+			return nil
+		}, 0)
+		partOptions := cc.command.withContext(cc.cpOption.payerOptions)
+		for partNumber := 1; err == nil; partNumber++ {
+			if partNumber > MaxPartNum {
+				err = fmt.Errorf("the data of %s exceeds %d parts, please specify larger --part-size", filePath, MaxPartNum)
+				break
+			}
+			data := buf[:n]
+			cc.fanoutEach(dests, func(d *fanoutDest) error {
+				return cc.retryStreamRequest(d.bucket.BucketName, d.objectName, retryTimes, func() error {
+					part, perr := d.bucket.UploadPart(d.imur, bytes.NewReader(data), int64(len(data)), partNumber, partOptions...)
+					if perr == nil {
+						d.parts = append(d.parts, part)
+					}
+					return perr
+				})
+			}, int64(n))
+
+			if n, err = io.ReadFull(f, buf); err == io.EOF {
+				err = nil
+				break
+			} else if err == nil || err == io.ErrUnexpectedEOF {
+				hash.Write(buf[:n])
+				err = nil
+			}
+		}
+
+		completeOptions := append([]oss.Option{}, cc.cpOption.payerOptions...)
+		if cc.cpOption.forbidOverwrite {
+			completeOptions = append(completeOptions, oss.ForbidOverWrite(true))
+		}
+		for _, d := range dests {
+			if d.imur.UploadID == "" {
+				continue
+			}
+			if d.err == nil && err != nil {
+				d.err = err
+			}
+			if d.err != nil {
+				d.bucket.AbortMultipartUpload(d.imur, cc.cpOption.payerOptions...)
+				continue
+			}
+			if _, cerr := d.bucket.CompleteMultipartUpload(d.imur, d.parts, cc.command.withContext(completeOptions)...); cerr != nil {
+				d.err = ObjectError{cerr, d.bucket.BucketName, d.objectName}
+			}
+		}
+	}
+
+	crc := strconv.FormatUint(hash.Sum64(), 10)
+	cc.fanoutEach(dests, func(d *fanoutDest) error {
+		props, err := cc.command.ossGetObjectStatRetry(d.bucket, d.objectName, cc.cpOption.payerOptions...)
+		if err != nil {
+			return err
+		}
+		if serverCRC := props.Get(oss.HTTPHeaderOssCRC64); serverCRC != "" && serverCRC != crc {
+			return fmt.Errorf("the crc64 of %s is %s, different from %s of %s", d, serverCRC, crc, filePath)
+		}
+		return nil
+	}, 0)
+
+	failed := []string{}
+	var ferr error
+	for _, d := range dests {
+		cc.updateMonitor(false, d.err, false, 0)
+		cc.report(fmt.Sprintf("%s %s to %s", opUpload, filePath, d), d.err)
+		if d.err != nil {
+			ferr = d.err
+			failed = append(failed, fmt.Sprintf("%s: %s", d, d.err.Error()))
+		}
+	}
+	cc.closeProgress()
+	if len(failed) == 0 {
+		fmt.Fprintf(cc.command.textOut(), cc.monitor.progressBar(true, normalExit))
+		return nil
+	}
+	fmt.Fprintf(cc.command.textOut(), cc.monitor.progressBar(true, errExit))
+	if len(failed) == 1 {
+		return ferr
+	}
+	return fmt.Errorf("upload %s to %d of %d destinations failed:\n%s", filePath, len(failed), len(dests), strings.Join(failed, "\n"))
+}
+
+// fanoutEach calls request for the destinations which haven't failed concurrently, size is the
+// data size uploaded by each request
+func (cc *CopyCommand) fanoutEach(dests []*fanoutDest, request func(d *fanoutDest) error, size int64) {
+	var wg sync.WaitGroup
+	for _, d := range dests {
+		if d.err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(d *fanoutDest) {
+			defer wg.Done()
+			if d.err = request(d); d.err == nil && size > 0 {
+				cc.monitor.updateTransferSize(size)
+				cc.monitor.updateDealSize(size)
+				freshProgress()
+			}
+		}(d)
+	}
+	wg.Wait()
+}
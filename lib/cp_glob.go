@@ -0,0 +1,153 @@
+package lib
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// hasGlobMeta returns true if the path contains the special characters of glob
+func hasGlobMeta(str string) bool {
+	return strings.ContainsAny(str, "*?[")
+}
+
+// splitGlob splits the pattern into the static part before the segment containing the first
+// glob character, which ends with the separator, and the pattern relative to it
+func splitGlob(pattern string, separator string) (string, string) {
+	index := strings.IndexAny(pattern, "*?[")
+	if index < 0 {
+		return pattern, ""
+	}
+	pos := strings.LastIndex(pattern[:index], separator)
+	return pattern[:pos+1], pattern[pos+1:]
+}
+
+// globMatch returns true if the relative path matches the pattern, the '*' doesn't match '/',
+// if recursive is true, the path under the matched directory also matches
+func globMatch(pattern, name string, recursive bool) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	if !recursive {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] != '/' {
+			continue
+		}
+		if ok, _ := path.Match(pattern, name[:i]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// globMayMatchUnder returns false if no path under the relative directory can match the pattern,
+// it's used to skip the directories when walking the local file system
+func globMayMatchUnder(pattern, dir string, recursive bool) bool {
+	patternSegs := strings.Split(pattern, "/")
+	dirSegs := strings.Split(dir, "/")
+	if len(dirSegs) >= len(patternSegs) {
+		return recursive && globMatch(pattern, dir, true)
+	}
+	for i, seg := range dirSegs {
+		if ok, _ := path.Match(patternSegs[i], seg); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// expandGlob turns the source url containing glob characters into the static part of it, which is
+// listed as a batch operation, and the matched files or objects keep the paths relative to it
+func (cc *CopyCommand) expandGlob(srcURLList []StorageURLer) ([]StorageURLer, error) {
+	cc.cpOption.glob = ""
+	cc.cpOption.globRecursive = cc.cpOption.recursive
+	if cc.cpOption.bSyncCommand || len(srcURLList) != 1 {
+		return srcURLList, nil
+	}
+
+	srcURL := srcURLList[0]
+	if srcURL.IsCloudURL() {
+		cloudURL := srcURL.(CloudURL)
+		if !hasGlobMeta(cloudURL.object) || cc.cpOption.versionId != "" {
+			return srcURLList, nil
+		}
+		// the object whose key contains the glob characters is copied as before
+		if !cc.cpOption.recursive {
+			bucket, err := cc.command.cloudBucket(cloudURL)
+			if err != nil {
+				return nil, err
+			}
+			if exist, err := bucket.IsObjectExist(cloudURL.object, cc.cpOption.payerOptions...); err == nil && exist {
+				return srcURLList, nil
+			}
+		}
+		cloudURL.object, cc.cpOption.glob = splitGlob(cloudURL.object, "/")
+		LogInfo("expand glob %s under %s\n", cc.cpOption.glob, cloudURL.ToString())
+		cc.enableGlob()
+		return []StorageURLer{cloudURL}, nil
+	}
+
+	name := srcURL.ToString()
+	if !hasGlobMeta(name) {
+		return srcURLList, nil
+	}
+	if _, err := os.Stat(name); err == nil {
+		return srcURLList, nil
+	}
+	dir, pattern := splitGlob(filepath.ToSlash(name), "/")
+	if dir == "" {
+		dir = "."
+	}
+	fileURL, err := StorageURLFromString(filepath.FromSlash(dir), "")
+	if err != nil {
+		return nil, err
+	}
+	cc.cpOption.glob = pattern
+	LogInfo("expand glob %s under %s\n", pattern, dir)
+	cc.enableGlob()
+	return []StorageURLer{fileURL}, nil
+}
+
+// enableGlob lists the static part of the glob recursively and filters by the pattern
+func (cc *CopyCommand) enableGlob() {
+	if !cc.cpOption.recursive {
+		cc.cpOption.recursive = true
+		disableIgnoreError, _ := GetBool(OptionDisableIgnoreError, cc.command.options)
+		cc.cpOption.ctnu = !disableIgnoreError
+	}
+}
+
+// matchObjectGlob returns true if the object listed under the cloud url matches the glob pattern
+func (cc *CopyCommand) matchObjectGlob(cloudURL CloudURL, key string) bool {
+	if cc.cpOption.glob == "" {
+		return true
+	}
+	relativeKey := key
+	if index := strings.LastIndex(cloudURL.object, "/"); index >= 0 && len(key) > index {
+		relativeKey = key[index+1:]
+	}
+	if strings.HasSuffix(relativeKey, "/") {
+		// the directory object is copied only with --recursive
+		return cc.cpOption.globRecursive && globMatch(cc.cpOption.glob, strings.TrimSuffix(relativeKey, "/"), true)
+	}
+	return globMatch(cc.cpOption.glob, relativeKey, cc.cpOption.globRecursive)
+}
+
+// matchFileGlob returns true if the local file relative to the listed directory matches the glob pattern,
+// skip is true if the directory can be skipped when walking
+func (cc *CopyCommand) matchFileGlob(fileName string, isDir bool) (match bool, skip bool) {
+	if cc.cpOption.glob == "" {
+		return true, false
+	}
+	fileName = filepath.ToSlash(fileName)
+	if isDir {
+		if !globMayMatchUnder(cc.cpOption.glob, fileName, cc.cpOption.globRecursive) {
+			return false, true
+		}
+		return cc.cpOption.globRecursive && globMatch(cc.cpOption.glob, fileName, true), false
+	}
+	return globMatch(cc.cpOption.glob, fileName, cc.cpOption.globRecursive), false
+}
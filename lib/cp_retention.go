@@ -0,0 +1,72 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// the object lock headers, they are sent as x-amz-object-lock-* to s3 by s3SignTransport
+const (
+	HTTPHeaderObjectLockMode        = "X-Oss-Object-Lock-Mode"
+	HTTPHeaderObjectLockRetainUntil = "X-Oss-Object-Lock-Retain-Until-Date"
+	HTTPHeaderObjectLockLegalHold   = "X-Oss-Object-Lock-Legal-Hold"
+)
+
+// the retention modes of --retention-mode
+const (
+	RetentionModeGovernance = "GOVERNANCE"
+	RetentionModeCompliance = "COMPLIANCE"
+)
+
+// initRetentionOptions adds the object lock headers of --retain-until, --retention-mode and
+// --legal-hold to the uploads and the copies. Only s3 supports the retention of the objects,
+// the objects of oss are protected by the retention policy of the bucket
+func (cc *CopyCommand) initRetentionOptions(opType operationType, destURL StorageURLer) error {
+	retainUntil, _ := GetString(OptionRetainUntil, cc.command.options)
+	mode, _ := GetString(OptionRetentionMode, cc.command.options)
+	legalHold, _ := GetBool(OptionLegalHold, cc.command.options)
+	if retainUntil == "" && mode == "" && !legalHold {
+		return nil
+	}
+	if retainUntil == "" && mode != "" {
+		return fmt.Errorf("--retention-mode only works with --retain-until")
+	}
+	if opType == operationTypeGet {
+		return fmt.Errorf("--retain-until and --legal-hold only work with upload or copy")
+	}
+	if !destURL.(CloudURL).isS3() || cc.cpOption.fanoutURLs != nil {
+		return fmt.Errorf("the object level retention is only supported by s3:// destinations, " +
+			"the objects of oss are protected by the retention policy of the bucket, please use worm command")
+	}
+
+	if retainUntil != "" {
+		until, err := parseTimeBound(retainUntil)
+		if err != nil {
+			return fmt.Errorf("invalid --retain-until: %s", err.Error())
+		}
+		if !until.After(time.Now()) {
+			return fmt.Errorf("invalid --retain-until: %s, the time should be in the future", retainUntil)
+		}
+		mode = strings.ToUpper(mode)
+		if mode == "" {
+			mode = RetentionModeGovernance
+		}
+		if mode != RetentionModeGovernance && mode != RetentionModeCompliance {
+			return fmt.Errorf("invalid --retention-mode: %s, the value should be %s or %s", mode, RetentionModeGovernance, RetentionModeCompliance)
+		}
+		cc.cpOption.options = append(cc.cpOption.options,
+			oss.SetHeader(HTTPHeaderObjectLockMode, mode),
+			oss.SetHeader(HTTPHeaderObjectLockRetainUntil, until.UTC().Format(time.RFC3339)))
+	}
+	if legalHold {
+		cc.cpOption.options = append(cc.cpOption.options, oss.SetHeader(HTTPHeaderObjectLockLegalHold, "ON"))
+	}
+
+	// s3 requires the md5 of the uploads with object lock
+	bTrue := true
+	cc.command.options[OptionContentMD5] = &bTrue
+	return nil
+}
@@ -0,0 +1,116 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// checkSources checks the multiple source urls of download or copy, which are the objects
+// without --recursive, or the prefixes listed with --recursive
+func (cc *CopyCommand) checkSources() error {
+	for _, srcURL := range cc.cpOption.sources {
+		if cc.cpOption.recursive {
+			continue
+		}
+		if srcURL.object == "" || strings.HasSuffix(srcURL.object, "/") {
+			return fmt.Errorf("%v is a directory (not support copied) object, please use --recursive option", srcURL.ToString())
+		}
+	}
+	return nil
+}
+
+// sourceObjectStatistic counts the objects of all the source urls for the progress
+func (cc *CopyCommand) sourceObjectStatistic(bucket *oss.Bucket) {
+	for _, srcURL := range cc.cpOption.sources {
+		if err := cc.scanObjects(bucket, srcURL); err != nil {
+			cc.monitor.setScanError(err)
+			return
+		}
+	}
+
+	cc.monitor.setScanEnd()
+	freshProgress()
+}
+
+// sourceObjectProducer sends the objects of all the source urls to the same queue
+func (cc *CopyCommand) sourceObjectProducer(bucket *oss.Bucket, chObjects chan<- objectInfoType, chError chan<- error) {
+	defer close(chObjects)
+	for _, srcURL := range cc.cpOption.sources {
+		if cc.cpOption.recursive {
+			if err := cc.listObjects(bucket, srcURL, chObjects); err != nil {
+				chError <- err
+				return
+			}
+			continue
+		}
+
+		prefix := ""
+		relativeKey := srcURL.object
+		if index := strings.LastIndex(srcURL.object, "/"); index > 0 {
+			prefix = srcURL.object[:index+1]
+			relativeKey = srcURL.object[index+1:]
+		}
+		chObjects <- objectInfoType{prefix, relativeKey, -1, time.Now()}
+	}
+	chError <- nil
+}
+
+// batchDownloadSources downloads the objects of all the source urls into the directory
+func (cc *CopyCommand) batchDownloadSources(bucket *oss.Bucket, filePath string) error {
+	if err := cc.checkSources(); err != nil {
+		return err
+	}
+
+	if err := cc.startStatistic(func() { cc.sourceObjectStatistic(bucket) }); err != nil {
+		return err
+	}
+	chObjects := make(chan objectInfoType, ChannelBuf)
+	chError := make(chan error, cc.cpOption.routines)
+	chListError := make(chan error, 1)
+	go cc.sourceObjectProducer(bucket, chObjects, chListError)
+
+	LogInfo("batch download files,routin count:%d,source count:%d,filepath:%s\n", cc.cpOption.routines, len(cc.cpOption.sources), filePath)
+	for i := 0; int64(i) < cc.cpOption.routines; i++ {
+		go cc.downloadConsumer(bucket, filePath, chObjects, chError)
+	}
+	return cc.waitRoutinueComplete(chError, chListError, opDownload)
+}
+
+// batchCopySources copies the objects of all the source urls under the destination prefix
+func (cc *CopyCommand) batchCopySources(bucket *oss.Bucket, destURL CloudURL) error {
+	if err := cc.checkSources(); err != nil {
+		return err
+	}
+	if destURL.object != "" && !strings.HasSuffix(destURL.object, "/") {
+		destURL.object = destURL.object + "/"
+	}
+	for _, srcURL := range cc.cpOption.sources {
+		if err := cc.checkCopyFileArgs(srcURL, destURL); err != nil {
+			return err
+		}
+	}
+
+	if cc.cpOption.noClobber {
+		destBucket, err := cc.command.cloudBucket(destURL)
+		if err != nil {
+			return err
+		}
+		cc.loadDestObjects(destBucket, destURL.object)
+	}
+	if err := cc.startStatistic(func() { cc.sourceObjectStatistic(bucket) }); err != nil {
+		return err
+	}
+	chObjects := make(chan objectInfoType, ChannelBuf)
+	chError := make(chan error, cc.cpOption.routines)
+	chListError := make(chan error, 1)
+	go cc.sourceObjectProducer(bucket, chObjects, chListError)
+
+	srcURL := cc.cpOption.sources[0]
+	for i := 0; int64(i) < cc.cpOption.routines; i++ {
+		go cc.copyConsumer(bucket, srcURL, destURL, chObjects, chError)
+	}
+	return cc.waitRoutinueComplete(chError, chListError, opCopy)
+}
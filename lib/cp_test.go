@@ -1,17 +1,22 @@
 package lib
 
 import (
+	"encoding/base64"
 	"fmt"
+	"hash/crc64"
 	"hash/fnv"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -1068,7 +1073,7 @@ func (s *OssutilCommandSuite) TestResumeDownloadRetry(c *C) {
 	bucket, err := copyCommand.command.ossBucket(bucketName)
 	c.Assert(err, IsNil)
 
-	err = copyCommand.ossResumeDownloadRetry(bucket, "", "", 0, 0)
+	err = copyCommand.ossResumeDownloadRetry(bucket, "", "", 0, 0, nil)
 	c.Assert(err, NotNil)
 }
 
@@ -6944,3 +6949,1243 @@ func (s *OssutilCommandSuite) TestBatchCPObjectToObjectWithStartTimeAndEndTime(c
 	s.removeBucket(bucketName, true, c)
 	s.removeBucket(bucketName2, true, c)
 }
+
+func (s *OssutilCommandSuite) TestCopyCrossCloudHelpers(c *C) {
+	// the buckets of the same name are served by different providers
+	bucketName := "bucket-" + randLowStr(8)
+	s3URL, err := CloudURLFromString("s3://"+bucketName+"/object", "")
+	c.Assert(err, IsNil)
+	ossURL, err := CloudURLFromString("oss://"+bucketName+"/object", "")
+	c.Assert(err, IsNil)
+
+	c.Assert(isCrossCloudCopy(s3URL, ossURL), Equals, true)
+	c.Assert(isCrossCloudCopy(ossURL, s3URL), Equals, true)
+	c.Assert(isCrossCloudCopy(ossURL, ossURL), Equals, false)
+	c.Assert(isCrossCloudCopy(s3URL, s3URL), Equals, false)
+
+	props := http.Header{}
+	props.Set(oss.HTTPHeaderContentType, "text/plain")
+	props.Set(oss.HTTPHeaderCacheControl, "no-cache")
+	props.Set("X-Oss-Meta-Owner", "test")
+	props.Set(oss.HTTPHeaderContentLength, "100")
+	options := streamCopyHeaders(props)
+	c.Assert(len(options), Equals, 3)
+}
+
+func (s *OssutilCommandSuite) TestCopyStreamCheckpoint(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+	bucket, err := copyCommand.command.ossBucket(bucketName)
+	c.Assert(err, IsNil)
+
+	cpDir := "ossutil-test-cp-dir-" + randLowStr(5)
+	copyCommand.cpOption.cpDir = cpDir
+	scp, err := copyCommand.loadStreamCopyCheckpoint(bucket, "src", bucket, "dest", "etag", 100, 10)
+	c.Assert(err, IsNil)
+	c.Assert(scp.UploadID, Equals, "")
+
+	imur, err := bucket.InitiateMultipartUpload("dest")
+	c.Assert(err, IsNil)
+	scp.UploadID = imur.UploadID
+	c.Assert(scp.save(), IsNil)
+
+	// resume from the checkpoint
+	scp, err = copyCommand.loadStreamCopyCheckpoint(bucket, "src", bucket, "dest", "etag", 100, 10)
+	c.Assert(err, IsNil)
+	c.Assert(scp.UploadID, Equals, imur.UploadID)
+
+	// the source object changed
+	scp, err = copyCommand.loadStreamCopyCheckpoint(bucket, "src", bucket, "dest", "etag2", 100, 10)
+	c.Assert(err, IsNil)
+	c.Assert(scp.UploadID, Equals, "")
+
+	bucket.AbortMultipartUpload(imur)
+	os.RemoveAll(cpDir)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestCopyStreamSourceChanged(c *C) {
+	var mu sync.Mutex
+	version := 0
+	content, etag := strings.Repeat("a", 300), `"etag-0"`
+	// the source object is overwritten by the next version
+	change := func() {
+		version++
+		content, etag = strings.Repeat(string(rune('a'+version)), 300), fmt.Sprintf(`"etag-%d"`, version)
+	}
+	changeAfterGets, gets, initiates, aborts, failed := 0, 0, 0, 0, 0
+	changeAfterHeads, heads := 0, 0
+	parts := map[int]string{}
+	dest := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := ioutil.ReadAll(r.Body)
+		query := r.URL.Query()
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/src-bucket/"):
+			if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != etag {
+				failed++
+				w.WriteHeader(http.StatusPreconditionFailed)
+				fmt.Fprint(w, `<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", "Mon, 12 Oct 2026 00:00:00 GMT")
+			if r.Method == "HEAD" {
+				w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+				if heads++; heads == changeAfterHeads {
+					change()
+				}
+				return
+			}
+			data := content
+			if gets++; gets == changeAfterGets {
+				change()
+			}
+			var start, end int
+			if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil {
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+				w.WriteHeader(http.StatusPartialContent)
+				fmt.Fprint(w, data[start:end+1])
+				return
+			}
+			fmt.Fprint(w, data)
+		case r.Method == "POST" && r.URL.RawQuery == "uploads":
+			initiates++
+			parts = map[int]string{}
+			fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>dest</Key><UploadId>upload%d</UploadId></InitiateMultipartUploadResult>`, initiates)
+		case r.Method == "PUT" && query.Get("partNumber") != "":
+			partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+			parts[partNumber] = string(body)
+			w.Header().Set("ETag", `"part`+query.Get("partNumber")+`"`)
+		case r.Method == "POST" && query.Get("uploadId") != "":
+			dest = parts[1] + parts[2] + parts[3]
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>dest</Key><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		case r.Method == "DELETE" && query.Get("uploadId") != "":
+			aborts++
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "PUT":
+			dest = string(body)
+			w.Header().Set("ETag", `"etag"`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv(EnvAWSAccessKeyID, "AKID")
+	os.Setenv(EnvAWSSecretAccessKey, "secret")
+	defer os.Unsetenv(EnvAWSAccessKeyID)
+	defer os.Unsetenv(EnvAWSSecretAccessKey)
+
+	str := "ak"
+	forcePathStyle := true
+	threshold := "100"
+	partSize := "100"
+	parallel := "1"
+	retryTimes := "3"
+	cpDir := "ossutil-test-cp-dir-" + randLowStr(5)
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	defer os.RemoveAll(cpDir)
+	defer os.RemoveAll(outputDir)
+	options := OptionMapType{
+		OptionEndpoint:         &server.URL,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionS3Endpoint:       &server.URL,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionBigFileThreshold: &threshold,
+		OptionPartSize:         &partSize,
+		OptionParallel:         &parallel,
+		OptionRetryTimes:       &retryTimes,
+		OptionCheckpointDir:    &cpDir,
+		OptionOutputDir:        &outputDir,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the parts are read with If-Match, the upload of the former content is aborted and the copy starts over
+	changeAfterGets = 1
+	_, err = cm.RunCommand("cp", []string{"s3://src-bucket/object", "oss://bucket/dest"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(dest, Equals, strings.Repeat("b", 300))
+	c.Assert(initiates, Equals, 2)
+	c.Assert(aborts, Equals, 1)
+	c.Assert(failed, Equals, 1)
+	cpFiles, _ := filepath.Glob(filepath.Join(cpDir, "*.cp"))
+	c.Assert(len(cpFiles), Equals, 0)
+
+	// the small object is changed between the stat and the read
+	threshold = strconv.FormatInt(DefaultBigFileThreshold, 10)
+	dest, gets, changeAfterGets, failed = "", 0, 0, 0
+	// the last stat of the 3 is the one of the stream copy for the etag
+	heads, changeAfterHeads = 0, 3
+	_, err = cm.RunCommand("cp", []string{"s3://src-bucket/object", "oss://bucket/dest"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(dest, Equals, strings.Repeat("c", 300))
+	c.Assert(failed, Equals, 1)
+
+	// the copy fails after the retries if the source object keeps changing
+	threshold = "100"
+	dest, gets, changeAfterGets = "", 0, 1
+	retryTimes = "1"
+	_, err = cm.RunCommand("cp", []string{"s3://src-bucket/object", "oss://bucket/dest"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(dest, Equals, "")
+}
+
+func (s *OssutilCommandSuite) TestCopyListAllUploadedParts(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		marker, _ := strconv.Atoi(r.URL.Query().Get("part-number-marker"))
+		fmt.Fprint(w, `<ListPartsResult><Bucket>bucket</Bucket><Key>dest</Key><UploadId>id</UploadId>`)
+		// 2 parts a page, 5 parts in total
+		last := marker + 2
+		if last > 5 {
+			last = 5
+		}
+		for i := marker + 1; i <= last; i++ {
+			fmt.Fprintf(w, `<Part><PartNumber>%d</PartNumber><ETag>"etag-%d"</ETag><Size>10</Size></Part>`, i, i)
+		}
+		fmt.Fprintf(w, `<NextPartNumberMarker>%d</NextPartNumberMarker><IsTruncated>%t</IsTruncated></ListPartsResult>`, last, last < 5)
+	}))
+	defer server.Close()
+
+	client, err := oss.New(server.URL, "ak", "sk", oss.ForcePathStyle(true))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	imur := oss.InitiateMultipartUploadResult{Bucket: "bucket", Key: "dest", UploadID: "id"}
+	parts, err := listAllUploadedParts(bucket, imur)
+	c.Assert(err, IsNil)
+	c.Assert(len(parts), Equals, 5)
+	c.Assert(parts[4].PartNumber, Equals, 5)
+	c.Assert(parts[4].ETag, Equals, `"etag-5"`)
+}
+
+func (s *OssutilCommandSuite) TestCopyLoadMimeMap(c *C) {
+	fileName := "ossutil-test-mime-" + randLowStr(5)
+	s.createFile(fileName, "# comment\n.md text/markdown\nYAML=application/yaml\n\n", c)
+	mimeMap, err := loadMimeMap(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(mimeMap[".md"], Equals, "text/markdown")
+	c.Assert(mimeMap[".yaml"], Equals, "application/yaml")
+	os.Remove(fileName)
+
+	s.createFile(fileName, ".md\n", c)
+	_, err = loadMimeMap(fileName)
+	c.Assert(err, NotNil)
+	os.Remove(fileName)
+
+	_, err = loadMimeMap(fileName)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestCopyUploadContentType(c *C) {
+	htmlFileName := "ossutil-test-file-" + randLowStr(5)
+	s.createFile(htmlFileName, "<html><body>test</body></html>", c)
+	mdFileName := "ossutil-test-file-" + randLowStr(5) + ".md"
+	s.createFile(mdFileName, "# title", c)
+
+	contentType, err := sniffContentType(htmlFileName)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(contentType, "text/html"), Equals, true)
+
+	cc := CopyCommand{}
+	cc.cpOption.mimeMap = map[string]string{".md": "text/markdown"}
+	cc.cpOption.sniffContentType = true
+	c.Assert(len(cc.uploadContentTypeOptions(mdFileName)), Equals, 1)
+	c.Assert(len(cc.uploadContentTypeOptions(htmlFileName)), Equals, 1)
+
+	cc.cpOption.metaContentType = true
+	c.Assert(len(cc.uploadContentTypeOptions(mdFileName)), Equals, 0)
+
+	// upload with mime map and sniff
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	mimeFileName := "ossutil-test-mime-" + randLowStr(5)
+	s.createFile(mimeFileName, ".md text/markdown\n", c)
+	str := ""
+	sniff := "sniff"
+	options := OptionMapType{
+		"endpoint":          &str,
+		"accessKeyID":       &str,
+		"accessKeySecret":   &str,
+		"configFile":        &configFile,
+		"mimeMap":           &mimeFileName,
+		"detectContentType": &sniff,
+	}
+	_, err = cm.RunCommand("cp", []string{mdFileName, CloudURLToString(bucketName, "a.md")}, options)
+	c.Assert(err, IsNil)
+	_, err = cm.RunCommand("cp", []string{htmlFileName, CloudURLToString(bucketName, "page")}, options)
+	c.Assert(err, IsNil)
+
+	objectStat := s.getStat(bucketName, "a.md", c)
+	c.Assert(objectStat["Content-Type"], Equals, "text/markdown")
+	objectStat = s.getStat(bucketName, "page", c)
+	c.Assert(strings.HasPrefix(objectStat["Content-Type"], "text/html"), Equals, true)
+
+	// only upload support the options
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, "page"), htmlFileName + "-download"}, options)
+	c.Assert(err, NotNil)
+
+	os.Remove(htmlFileName)
+	os.Remove(mdFileName)
+	os.Remove(mimeFileName)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestCopyListSplit(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	keys := []string{"dir/-1", "dir/0", "dir/5a", "dir/B", "dir/g/1", "dir/n", "dir/zz", "other"}
+	for _, key := range keys {
+		s.putObject(bucketName, key, uploadFileName, c)
+	}
+
+	str := ""
+	recursive := true
+	listSplit := "g,0,n"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &recursive,
+		"listSplit":       &listSplit,
+	}
+
+	dirName := "ossutil-test-dir-" + randLowStr(5)
+	_, err := cm.RunCommand("cp", []string{CloudURLToString(bucketName, "dir/"), dirName}, options)
+	c.Assert(err, IsNil)
+	for _, key := range keys[:len(keys)-1] {
+		_, err := os.Stat(dirName + string(os.PathSeparator) + strings.TrimPrefix(key, "dir/"))
+		c.Assert(err, IsNil)
+	}
+
+	// upload doesn't support --list-split
+	_, err = cm.RunCommand("cp", []string{dirName, CloudURLToString(bucketName, "up/")}, options)
+	c.Assert(err, NotNil)
+
+	// invalid split points
+	listSplit = "a,"
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, "dir/"), dirName}, options)
+	c.Assert(err, NotNil)
+
+	os.RemoveAll(dirName)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestCopyStartAfter(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	for _, key := range []string{"dir/a", "dir/b", "dir/c"} {
+		s.putObject(bucketName, key, uploadFileName, c)
+	}
+
+	str := ""
+	recursive := true
+	startAfter := "dir/a"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &recursive,
+		"startAfter":      &startAfter,
+	}
+
+	dirName := "ossutil-test-dir-" + randLowStr(5)
+	_, err := cm.RunCommand("cp", []string{CloudURLToString(bucketName, "dir/"), dirName}, options)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(dirName + string(os.PathSeparator) + "a")
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(dirName + string(os.PathSeparator) + "b")
+	c.Assert(err, IsNil)
+	_, err = os.Stat(dirName + string(os.PathSeparator) + "c")
+	c.Assert(err, IsNil)
+
+	// upload doesn't support --start-after
+	_, err = cm.RunCommand("cp", []string{dirName, CloudURLToString(bucketName, "up/")}, options)
+	c.Assert(err, NotNil)
+
+	os.RemoveAll(dirName)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestForbidOverwriteError(c *C) {
+	conflict := oss.ServiceError{Code: "FileAlreadyExists", StatusCode: http.StatusConflict}
+	c.Assert(isFileAlreadyExists(conflict), Equals, true)
+	c.Assert(isFileAlreadyExists(ObjectError{conflict, "bucket", "object"}), Equals, true)
+	c.Assert(isFileAlreadyExists(FileError{conflict, "file"}), Equals, true)
+	c.Assert(isFileAlreadyExists(oss.ServiceError{Code: "AccessDenied", StatusCode: http.StatusForbidden}), Equals, false)
+	c.Assert(isFileAlreadyExists(nil), Equals, false)
+
+	cc := CopyCommand{}
+	err := FileError{conflict, "file"}
+	c.Assert(cc.forbidOverwriteError(err), Equals, error(err))
+	cc.cpOption.forbidOverwrite = true
+	_, ok := cc.forbidOverwriteError(err).(ForbidOverwriteError)
+	c.Assert(ok, Equals, true)
+	c.Assert(cc.forbidOverwriteError(nil), IsNil)
+}
+
+func (s *OssutilCommandSuite) TestCopyForbidOverwrite(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+	object := "forbid-overwrite"
+	s.putObject(bucketName, object, uploadFileName, c)
+
+	str := ""
+	forbidOverwrite := true
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"forbidOverwrite": &forbidOverwrite,
+	}
+
+	// upload
+	_, err := cm.RunCommand("cp", []string{uploadFileName, CloudURLToString(bucketName, object)}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--forbid-overwrite"), Equals, true)
+	_, err = cm.RunCommand("cp", []string{uploadFileName, CloudURLToString(bucketName, "new-object")}, options)
+	c.Assert(err, IsNil)
+
+	// copy
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, "new-object"), CloudURLToString(bucketName, object)}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--forbid-overwrite"), Equals, true)
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, object), CloudURLToString(bucketName, "copy-object")}, options)
+	c.Assert(err, IsNil)
+
+	// download doesn't support --forbid-overwrite
+	fileName := "ossutil-test-forbid-" + randLowStr(5)
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, object), fileName}, options)
+	c.Assert(err, NotNil)
+	os.Remove(fileName)
+
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestNoClobberDestObjects(c *C) {
+	cc := CopyCommand{}
+	cc.cpOption.noClobber = true
+	destObjects := newMemoryKeyStore()
+	destObjects.put("dir/a", "")
+	cc.cpOption.destObjects = destObjects
+
+	exist, err := cc.destObjectExists(nil, "dir/a")
+	c.Assert(err, IsNil)
+	c.Assert(exist, Equals, true)
+	exist, err = cc.destObjectExists(nil, "dir/b")
+	c.Assert(err, IsNil)
+	c.Assert(exist, Equals, false)
+
+	fileName := "ossutil-test-no-clobber-" + randLowStr(5)
+	c.Assert(cc.skipDownload(fileName, time.Now(), "object"), Equals, false)
+	s.createFile(fileName, "content", c)
+	c.Assert(cc.skipDownload(fileName, time.Now(), "object"), Equals, true)
+	os.Remove(fileName)
+}
+
+func (s *OssutilCommandSuite) TestCopyNoClobber(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	dirName := "ossutil-test-dir-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dirName, 0755), IsNil)
+	s.createFile(dirName+string(os.PathSeparator)+"a", "new-a", c)
+	s.createFile(dirName+string(os.PathSeparator)+"b", "new-b", c)
+
+	existFile := "ossutil-test-file-" + randLowStr(5)
+	s.createFile(existFile, "old-a", c)
+	s.putObject(bucketName, "dir/a", existFile, c)
+
+	str := ""
+	recursive := true
+	noClobber := true
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &recursive,
+		"noClobber":       &noClobber,
+	}
+
+	// upload skips the existing object
+	_, err := cm.RunCommand("cp", []string{dirName, CloudURLToString(bucketName, "dir/")}, options)
+	c.Assert(err, IsNil)
+	s.getObject(bucketName, "dir/a", downloadFileName, c)
+	c.Assert(s.readFile(downloadFileName, c), Equals, "old-a")
+	s.getObject(bucketName, "dir/b", downloadFileName, c)
+	c.Assert(s.readFile(downloadFileName, c), Equals, "new-b")
+
+	// copy skips the existing object
+	s.putObject(bucketName, "copy/b", existFile, c)
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, "dir/"), CloudURLToString(bucketName, "copy/")}, options)
+	c.Assert(err, IsNil)
+	s.getObject(bucketName, "copy/a", downloadFileName, c)
+	c.Assert(s.readFile(downloadFileName, c), Equals, "old-a")
+	s.getObject(bucketName, "copy/b", downloadFileName, c)
+	c.Assert(s.readFile(downloadFileName, c), Equals, "old-a")
+
+	// download skips the existing file
+	downDir := "ossutil-test-down-" + randLowStr(5)
+	c.Assert(os.MkdirAll(downDir, 0755), IsNil)
+	s.createFile(downDir+string(os.PathSeparator)+"a", "local-a", c)
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, "dir/"), downDir}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(downDir+string(os.PathSeparator)+"a", c), Equals, "local-a")
+	c.Assert(s.readFile(downDir+string(os.PathSeparator)+"b", c), Equals, "new-b")
+
+	// --update is not allowed
+	update := true
+	options["update"] = &update
+	_, err = cm.RunCommand("cp", []string{dirName, CloudURLToString(bucketName, "dir/")}, options)
+	c.Assert(err, NotNil)
+
+	os.RemoveAll(dirName)
+	os.RemoveAll(downDir)
+	os.Remove(existFile)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestContentMD5ClientOption(c *C) {
+	endpoint := "oss-cn-hangzhou.aliyuncs.com"
+	accessKeyID := "ak"
+	accessKeySecret := "sk"
+	contentMD5 := true
+	command := Command{options: OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &accessKeyID,
+		OptionAccessKeySecret: &accessKeySecret,
+		OptionContentMD5:      &contentMD5,
+	}}
+	client, err := command.ossClient("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.IsEnableMD5, Equals, true)
+
+	contentMD5 = false
+	client, err = command.ossClient("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.IsEnableMD5, Equals, false)
+}
+
+func (s *OssutilCommandSuite) TestUploadWithContentMD5(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(12)
+	s.putBucket(bucketName, c)
+
+	testFileName := "ossutil_test_file" + randStr(5)
+	data := randStr(1024 * 300)
+	s.createFile(testFileName, data, c)
+
+	str := ""
+	cpDir := CheckpointDir
+	routines := strconv.Itoa(Routines)
+	contentMD5 := true
+	threshold := "102400"
+	partSize := "102400"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"checkpointDir":   &cpDir,
+		"routines":        &routines,
+		"contentMD5":      &contentMD5,
+	}
+
+	// put object
+	_, err := cm.RunCommand("cp", []string{testFileName, CloudURLToString(bucketName, "small")}, options)
+	c.Assert(err, IsNil)
+
+	// multipart upload
+	options["bigfileThreshold"] = &threshold
+	options["partSize"] = &partSize
+	_, err = cm.RunCommand("cp", []string{testFileName, CloudURLToString(bucketName, "multipart")}, options)
+	c.Assert(err, IsNil)
+
+	c.Assert(s.getStat(bucketName, "small", c)["Content-Length"], Equals, "307200")
+	c.Assert(s.getStat(bucketName, "multipart", c)["Content-Length"], Equals, "307200")
+
+	os.Remove(testFileName)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestCopyUploadCallback(c *C) {
+	callbackURL := "http://example.com/callback"
+	body := `{"object":${object},"user":${x:user}}`
+	vars := "user=test#x:id=1"
+	empty := ""
+	ossOptions, err := getCallbackOptions(OptionMapType{OptionCallbackURL: &callbackURL, OptionCallbackBody: &body, OptionCallbackVar: &vars})
+	c.Assert(err, IsNil)
+	c.Assert(len(ossOptions), Equals, 2)
+	_, err = getCallbackOptions(OptionMapType{OptionCallbackURL: &empty, OptionCallbackBody: &body})
+	c.Assert(err, NotNil)
+	badVars := "user"
+	_, err = getCallbackOptions(OptionMapType{OptionCallbackURL: &callbackURL, OptionCallbackVar: &badVars})
+	c.Assert(err, NotNil)
+
+	var callback, callbackVar string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PUT" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		ioutil.ReadAll(r.Body)
+		callback = r.Header.Get("X-Oss-Callback")
+		callbackVar = r.Header.Get("X-Oss-Callback-Var")
+		fmt.Fprint(w, `{"Status":"OK"}`)
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-callback-" + randLowStr(5)
+	s.createFile(fileName, "content", c)
+	defer os.Remove(fileName)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	partSize := strconv.FormatInt(DefaultPartSize, 10)
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(cpDir)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionCallbackURL:      &callbackURL,
+		OptionCallbackBody:     &body,
+		OptionCallbackVar:      &vars,
+		OptionOutputDir:        &outputDir,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/callback"}, options)
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), `callback response of oss://bucket/callback: {"Status":"OK"}`), Equals, true)
+
+	data, err := base64.StdEncoding.DecodeString(callback)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"callbackBody":"{\"object\":${object},\"user\":${x:user}}","callbackBodyType":"application/json","callbackUrl":"http://example.com/callback"}`)
+	data, err = base64.StdEncoding.DecodeString(callbackVar)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, `{"x:id":"1","x:user":"test"}`)
+
+	// download doesn't support callback
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/callback", fileName + ".download"}, options)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestCopyGlob(c *C) {
+	dir, pattern := splitGlob("data/2024-0[1-6]/*.csv", "/")
+	c.Assert(dir, Equals, "data/")
+	c.Assert(pattern, Equals, "2024-0[1-6]/*.csv")
+	dir, pattern = splitGlob("*.log", "/")
+	c.Assert(dir, Equals, "")
+	c.Assert(pattern, Equals, "*.log")
+
+	c.Assert(globMatch("2024-0[1-6]/*.csv", "2024-01/a.csv", false), Equals, true)
+	c.Assert(globMatch("2024-0[1-6]/*.csv", "2024-07/a.csv", false), Equals, false)
+	c.Assert(globMatch("*.csv", "sub/a.csv", false), Equals, false)
+	c.Assert(globMatch("2024-*", "2024-01/a.csv", false), Equals, false)
+	c.Assert(globMatch("2024-*", "2024-01/a.csv", true), Equals, true)
+	c.Assert(globMayMatchUnder("2024-0[1-6]/*.csv", "2024-01", false), Equals, true)
+	c.Assert(globMayMatchUnder("2024-0[1-6]/*.csv", "2023-01", false), Equals, false)
+	c.Assert(globMayMatchUnder("*.csv", "sub", false), Equals, false)
+	c.Assert(globMayMatchUnder("2024-*", "2024-01", true), Equals, true)
+
+	// the local files
+	dir = "ossutil-test-glob-" + randLowStr(5)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"app-2024-01.log", "app-2023-12.log", "app-2024-02.txt", "sub/app-2024-03.log"} {
+		c.Assert(os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755), IsNil)
+		s.createFile(filepath.Join(dir, name), name, c)
+	}
+
+	cc := CopyCommand{}
+	cc.cpOption.disableOssIgnore = true
+	srcURLList, err := cc.getStorageURLs([]string{dir + "/app-2024-*.log"})
+	c.Assert(err, IsNil)
+	srcURLList, err = cc.expandGlob(srcURLList)
+	c.Assert(err, IsNil)
+	c.Assert(cc.cpOption.recursive, Equals, true)
+	c.Assert(cc.cpOption.glob, Equals, "app-2024-*.log")
+	chFiles := make(chan fileInfoType, 10)
+	c.Assert(cc.getFileList(srcURLList[0].ToString()+string(os.PathSeparator), chFiles), IsNil)
+	close(chFiles)
+	var files []string
+	for file := range chFiles {
+		files = append(files, filepath.ToSlash(file.filePath))
+	}
+	c.Assert(files, DeepEquals, []string{"app-2024-01.log"})
+
+	// the existing file isn't expanded
+	cc = CopyCommand{}
+	srcURLList, err = cc.getStorageURLs([]string{filepath.Join(dir, "app-2024-01.log")})
+	c.Assert(err, IsNil)
+	_, err = cc.expandGlob(srcURLList)
+	c.Assert(err, IsNil)
+	c.Assert(cc.cpOption.glob, Equals, "")
+
+	// the objects
+	cc = CopyCommand{}
+	cc.cpOption.recursive = true
+	srcURLList, err = cc.getStorageURLs([]string{"oss://bucket/data/2024-0[1-6]/*.csv"})
+	c.Assert(err, IsNil)
+	srcURLList, err = cc.expandGlob(srcURLList)
+	c.Assert(err, IsNil)
+	cloudURL := srcURLList[0].(CloudURL)
+	c.Assert(cloudURL.object, Equals, "data/")
+	objects := []oss.ObjectProperties{{Key: "data/2024-01/a.csv"}, {Key: "data/2024-01/sub/b.csv"}, {Key: "data/2024-07/c.csv"}, {Key: "data/2024-02/d.txt"}}
+	chObjects := make(chan objectInfoType, 10)
+	cc.sendObjects(nil, cloudURL, objects, fnv.New64(), chObjects)
+	close(chObjects)
+	var keys []string
+	for object := range chObjects {
+		c.Assert(object.prefix, Equals, "data/")
+		keys = append(keys, object.relativeKey)
+	}
+	c.Assert(keys, DeepEquals, []string{"2024-01/a.csv"})
+}
+
+func (s *OssutilCommandSuite) TestCopyMultipleSources(c *C) {
+	cc := CopyCommand{}
+	srcURLList, err := cc.getStorageURLs([]string{"oss://bucket/a.txt", "oss://bucket/dir/b.txt"})
+	c.Assert(err, IsNil)
+	destURL, err := StorageURLFromString("oss://bucket2/prefix", "")
+	c.Assert(err, IsNil)
+	c.Assert(cc.checkCopyArgs(srcURLList, destURL, operationTypeCopy), IsNil)
+	otherURLList, err := cc.getStorageURLs([]string{"oss://bucket/a.txt", "oss://bucket2/b.txt"})
+	c.Assert(err, IsNil)
+	c.Assert(cc.checkCopyArgs(otherURLList, destURL, operationTypeCopy), NotNil)
+
+	for _, url := range srcURLList {
+		cc.cpOption.sources = append(cc.cpOption.sources, url.(CloudURL))
+	}
+	c.Assert(cc.checkSources(), IsNil)
+	chObjects := make(chan objectInfoType, 10)
+	chError := make(chan error, 1)
+	cc.sourceObjectProducer(nil, chObjects, chError)
+	c.Assert(<-chError, IsNil)
+	var keys []string
+	for object := range chObjects {
+		keys = append(keys, object.prefix+"|"+object.relativeKey)
+	}
+	c.Assert(keys, DeepEquals, []string{"|a.txt", "dir/|b.txt"})
+
+	cc.cpOption.sources = append(cc.cpOption.sources, CloudURL{bucket: "bucket", object: "dir/"})
+	c.Assert(cc.checkSources(), NotNil)
+
+	// upload and download in one job
+	var mu sync.Mutex
+	var puts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "PUT":
+			ioutil.ReadAll(r.Body)
+			puts = append(puts, r.URL.Path)
+		case "HEAD":
+			w.Header().Set("Content-Length", "7")
+			w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		default:
+			fmt.Fprint(w, "content")
+		}
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-sources-" + randLowStr(5)
+	c.Assert(os.MkdirAll(filepath.Join(dir, "dir3"), 0755), IsNil)
+	defer os.RemoveAll(dir)
+	s.createFile(filepath.Join(dir, "file1"), "content", c)
+	s.createFile(filepath.Join(dir, "file2"), "content", c)
+	s.createFile(filepath.Join(dir, "dir3", "file3"), "content", c)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	partSize := strconv.FormatInt(DefaultPartSize, 10)
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(cpDir)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRecursion:        &recursive,
+		OptionForce:            &force,
+		OptionOutputDir:        &outputDir,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("cp", []string{filepath.Join(dir, "file1"), filepath.Join(dir, "file2"), filepath.Join(dir, "dir3"), "oss://bucket/prefix/"}, options)
+	c.Assert(err, IsNil)
+	sort.Strings(puts)
+	c.Assert(puts, DeepEquals, []string{"/bucket/prefix/file1", "/bucket/prefix/file2", "/bucket/prefix/file3"})
+
+	recursive = false
+	downloadDir := filepath.Join(dir, "download")
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/a.txt", "oss://bucket/dir/b.txt", downloadDir}, options)
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(filepath.Join(downloadDir, "a.txt"), c), Equals, "content")
+	c.Assert(s.readFile(filepath.Join(downloadDir, "b.txt"), c), Equals, "content")
+}
+
+func (s *OssutilCommandSuite) TestCopyDirObject(c *C) {
+	var mu sync.Mutex
+	var puts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "PUT":
+			ioutil.ReadAll(r.Body)
+			puts = append(puts, r.URL.Path)
+		case r.URL.Query().Get("list-type") == "2":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>prefix/empty/</Key><Size>0</Size></Contents><Contents><Key>prefix/sub/file</Key><Size>7</Size></Contents>
+</ListBucketResult>`)
+		default:
+			fmt.Fprint(w, "content")
+		}
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-dirobject-" + randLowStr(5)
+	defer os.RemoveAll(dir)
+	c.Assert(os.MkdirAll(filepath.Join(dir, "upload", "empty"), 0755), IsNil)
+	c.Assert(os.MkdirAll(filepath.Join(dir, "upload", "sub"), 0755), IsNil)
+	s.createFile(filepath.Join(dir, "upload", "sub", "file"), "content", c)
+	c.Assert(isEmptyDir(filepath.Join(dir, "upload", "empty")), Equals, true)
+	c.Assert(isEmptyDir(filepath.Join(dir, "upload", "sub")), Equals, false)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	dirObject := true
+	disableDirObject := false
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	partSize := strconv.FormatInt(DefaultPartSize, 10)
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(cpDir)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRecursion:        &recursive,
+		OptionForce:            &force,
+		OptionDirObject:        &dirObject,
+		OptionDisableDirObject: &disableDirObject,
+		OptionOutputDir:        &outputDir,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("cp", []string{filepath.Join(dir, "upload"), "oss://bucket/prefix/"}, options)
+	c.Assert(err, IsNil)
+	sort.Strings(puts)
+	c.Assert(puts, DeepEquals, []string{"/bucket/prefix/empty/", "/bucket/prefix/sub/file"})
+
+	downloadDir := filepath.Join(dir, "download")
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/prefix/", downloadDir}, options)
+	c.Assert(err, IsNil)
+	c.Assert(isEmptyDir(filepath.Join(downloadDir, "empty")), Equals, true)
+	c.Assert(s.readFile(filepath.Join(downloadDir, "sub", "file"), c), Equals, "content")
+
+	disableDirObject = true
+	_, err = cm.RunCommand("cp", []string{filepath.Join(dir, "upload"), "oss://bucket/prefix/"}, options)
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestCopyAppend(c *C) {
+	type mockObject struct {
+		objectType string
+		data       string
+	}
+	var mu sync.Mutex
+	objects := map[string]*mockObject{"/bucket/normal": {"Normal", "abc"}}
+	appendNum := 0
+	crcOf := func(data string) string {
+		return strconv.FormatUint(crc64.Checksum([]byte(data), crc64.MakeTable(crc64.ECMA)), 10)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		object, ok := objects[r.URL.Path]
+		switch r.Method {
+		case "HEAD":
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("X-Oss-Object-Type", object.objectType)
+			w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", crcOf(object.data))
+		case "POST":
+			if !ok {
+				object = &mockObject{"Appendable", ""}
+			}
+			position, _ := strconv.Atoi(r.URL.Query().Get("position"))
+			if object.objectType != "Appendable" || position != len(object.data) {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			object.data += string(body)
+			objects[r.URL.Path] = object
+			appendNum++
+			w.Header().Set("X-Oss-Next-Append-Position", strconv.Itoa(len(object.data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", crcOf(object.data))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-append-" + randLowStr(5)
+	defer os.Remove(fileName)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	appendMode := true
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	partSize := "2"
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(cpDir)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionOutputDir:        &outputDir,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+		OptionAppend:           &appendMode,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the object is created and appended by chunks
+	s.createFile(fileName, "abc", c)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/log"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(objects["/bucket/log"].objectType, Equals, "Appendable")
+	c.Assert(objects["/bucket/log"].data, Equals, "abc")
+	c.Assert(appendNum, Equals, 2)
+
+	// only the data beyond the length of the object is appended
+	s.createFile(fileName, "abcdef", c)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/log"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(objects["/bucket/log"].data, Equals, "abcdef")
+	c.Assert(appendNum, Equals, 4)
+
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/log"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(appendNum, Equals, 4)
+
+	// the object isn't the head of the file
+	s.createFile(fileName, "xbcdefg", c)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/log"}, options)
+	c.Assert(err, NotNil)
+	s.createFile(fileName, "abc", c)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/log"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(objects["/bucket/log"].data, Equals, "abcdef")
+
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/normal"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(objects["/bucket/normal"].data, Equals, "abc")
+
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/log", fileName + ".download"}, options)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestCopyFanout(c *C) {
+	var mu sync.Mutex
+	objects := map[string]string{}
+	uploads := map[string]map[int]string{}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		if strings.HasPrefix(r.URL.Path, "/denied/") && r.Method != "HEAD" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code></Error>`)
+			return
+		}
+		switch {
+		case r.Method == "HEAD":
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64.MakeTable(crc64.ECMA)), 10))
+		case r.Method == "POST" && query["uploads"] != nil:
+			uploads[r.URL.Path] = map[int]string{}
+			names := strings.SplitN(r.URL.Path[1:], "/", 2)
+			fmt.Fprintf(w, `<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>`, names[0], names[1], r.URL.Path)
+		case r.Method == "PUT" && query.Get("uploadId") != "":
+			body, _ := ioutil.ReadAll(r.Body)
+			partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+			uploads[query.Get("uploadId")][partNumber] = string(body)
+			requests++
+			w.Header().Set("ETag", `"part"`)
+		case r.Method == "POST" && query.Get("uploadId") != "":
+			data := ""
+			for i := 1; i <= len(uploads[query.Get("uploadId")]); i++ {
+				data += uploads[query.Get("uploadId")][i]
+			}
+			objects[r.URL.Path] = data
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		case r.Method == "PUT":
+			body, _ := ioutil.ReadAll(r.Body)
+			objects[r.URL.Path] = string(body)
+			requests++
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-fanout-" + randLowStr(5)
+	defer os.Remove(fileName)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	fanout := true
+	retryTimes := "1"
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	partSize := strconv.FormatInt(oss.MinPartSize, 10)
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(cpDir)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRetryTimes:       &retryTimes,
+		OptionOutputDir:        &outputDir,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+		OptionFanout:           &fanout,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the small file is uploaded by PutObject
+	s.createFile(fileName, "content", c)
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucketa/dir/", "oss://bucketb/key"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(objects["/bucketa/dir/"+fileName], Equals, "content")
+	c.Assert(objects["/bucketb/key"], Equals, "content")
+
+	// the large file is uploaded by parts, the failed destination doesn't stop the others
+	content := strings.Repeat("0123456789", int(oss.MinPartSize)/4)
+	s.createFile(fileName, content, c)
+	requests = 0
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucketa/big", "oss://denied/big", "oss://bucketb/big"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(isNotFound(err), Equals, false)
+	c.Assert(strings.Contains(err.Error(), "AccessDenied"), Equals, true)
+	c.Assert(objects["/bucketa/big"], Equals, content)
+	c.Assert(objects["/bucketb/big"], Equals, content)
+	c.Assert(requests, Equals, 6)
+
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucketa/key"}, options)
+	c.Assert(err, NotNil)
+	_, err = cm.RunCommand("cp", []string{"oss://bucketa/key", fileName, "oss://bucketb/key"}, options)
+	c.Assert(err, NotNil)
+	isAppend := true
+	options[OptionAppend] = &isAppend
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucketa/key", "oss://bucketb/key"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--append"), Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestCopyDedup(c *C) {
+	var mu sync.Mutex
+	objects := map[string]string{}
+	heads, puts := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "HEAD":
+			heads++
+			data, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64.MakeTable(crc64.ECMA)), 10))
+		case "PUT":
+			puts++
+			body, _ := ioutil.ReadAll(r.Body)
+			objects[r.URL.Path] = string(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-dedup-" + randLowStr(5)
+	hashDBPath := "ossutil-test-dedup-hashdb-" + randLowStr(5)
+	cpDir := "ossutil-test-dedup-cp-" + randLowStr(5)
+	defer os.RemoveAll(dir)
+	defer os.RemoveAll(hashDBPath)
+	outputDir := "ossutil-test-dedup-output-" + randLowStr(5)
+	defer os.RemoveAll(cpDir)
+	defer os.RemoveAll(outputDir)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	s.createFile(filepath.Join(dir, "a.txt"), "aaa", c)
+	s.createFile(filepath.Join(dir, "b.txt"), "bbb", c)
+
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	dedup := true
+	retryTimes := "1"
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	options := OptionMapType{
+		OptionEndpoint:         &server.URL,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRecursion:        &recursive,
+		OptionForce:            &force,
+		OptionRetryTimes:       &retryTimes,
+		OptionCheckpointDir:    &cpDir,
+		OptionOutputDir:        &outputDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionDedup:            &dedup,
+		OptionHashDB:           &hashDBPath,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the new files are uploaded and recorded
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/dist/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(heads, Equals, 2)
+	c.Assert(puts, Equals, 2)
+	c.Assert(objects["/bucket/dist/a.txt"], Equals, "aaa")
+
+	// the recorded objects are skipped without the HEAD request
+	heads, puts = 0, 0
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/dist/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(heads, Equals, 0)
+	c.Assert(puts, Equals, 0)
+
+	// the objects in oss are compared without the records
+	os.RemoveAll(hashDBPath)
+	heads, puts = 0, 0
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/dist/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(heads, Equals, 2)
+	c.Assert(puts, Equals, 0)
+
+	// the changed file is uploaded
+	s.createFile(filepath.Join(dir, "a.txt"), "abc", c)
+	heads, puts = 0, 0
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/dist/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(heads, Equals, 1)
+	c.Assert(puts, Equals, 1)
+	c.Assert(objects["/bucket/dist/a.txt"], Equals, "abc")
+
+	// --dedup only works with upload
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/dist/", dir}, options)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,61 @@
+package lib
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// isCRCCheckError returns true if the crc64 of the downloaded data doesn't match the object
+func isCRCCheckError(err error) bool {
+	var crcError oss.CRCCheckError
+	return errors.As(err, &crcError)
+}
+
+// downloadCRCRetry calls download again if the crc64 of the downloaded data doesn't match the object,
+// the downloaded data and the checkpoint are removed before retrying, otherwise the resumed download
+// would combine the crc64 of the same parts again
+func (cc *CopyCommand) downloadCRCRetry(bucket *oss.Bucket, object, fileName string, resume bool, download func() error) error {
+	for i := int64(0); ; i++ {
+		err := download()
+		if err == nil || !isCRCCheckError(err) {
+			return err
+		}
+
+		os.Remove(fileName + oss.TempFileSuffix)
+		if resume {
+			os.Remove(cc.downloadCheckpointPath(bucket, object, fileName))
+		}
+		var crcError oss.CRCCheckError
+		errors.As(err, &crcError)
+		if i >= cc.cpOption.crcRetryTimes || !cc.cpOption.budget.allowRetry() {
+			return ObjectError{fmt.Errorf("the downloaded data is corrupted, the crc64 doesn't match the object after %d retries: %s", i, crcError.Error()),
+				bucket.BucketName, object}
+		}
+		cc.cpOption.statSummary.addRetry(object)
+		LogError("crc64 of %s mismatched, download again after removing the local data, retry count:%d,error:%s\n",
+			bucketObjectURL(bucket, object), i+1, crcError.Error())
+	}
+}
+
+// downloadCheckpointPath returns the checkpoint file of the resumed download in --checkpoint-dir,
+// it's named in the same way as the sdk, or as newSDKCheckpoint with --encrypt-checkpoint
+func (cc *CopyCommand) downloadCheckpointPath(bucket *oss.Bucket, object, fileName string) string {
+	absPath, _ := filepath.Abs(fileName)
+	if cc.cpOption.cpCipher != nil {
+		return sdkCheckpointPath(cc.cpOption.cpDir, bucketObjectURL(bucket, object)+CheckpointSep+cc.objectVersionId(object), absPath)
+	}
+	srcSum := md5.Sum([]byte(fmt.Sprintf("oss://%v/%v", bucket.BucketName, object)))
+	destSum := md5.Sum([]byte(absPath))
+	name := hex.EncodeToString(srcSum[:]) + "-" + hex.EncodeToString(destSum[:])
+	if versionId := cc.objectVersionId(object); versionId != "" {
+		versionSum := md5.Sum([]byte(versionId))
+		name += "-" + hex.EncodeToString(versionSum[:])
+	}
+	return cc.cpOption.cpDir + string(os.PathSeparator) + name + ".cp"
+}
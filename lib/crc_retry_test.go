@@ -0,0 +1,135 @@
+package lib
+
+import (
+	"fmt"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestDownloadCRCRetry(c *C) {
+	data := strings.Repeat("0123456789", 3)
+	var mu sync.Mutex
+	corrupt, gets := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Last-Modified", "Mon, 12 Oct 2026 00:00:00 GMT")
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64.MakeTable(crc64.ECMA)), 10))
+		if r.Method == "HEAD" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			return
+		}
+		gets++
+		body := data
+		if corrupt > 0 {
+			corrupt--
+			body = strings.Repeat("x", len(data))
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err == nil {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+			w.WriteHeader(http.StatusPartialContent)
+			fmt.Fprint(w, body[start:end+1])
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-crc-retry-" + randLowStr(5)
+	cpDir := "ossutil-test-crc-retry-cp-" + randLowStr(5)
+	defer os.Remove(fileName)
+	outputDir := "ossutil-test-crc-retry-output-" + randLowStr(5)
+	defer os.RemoveAll(cpDir)
+	defer os.RemoveAll(outputDir)
+	str := "ak"
+	forcePathStyle := true
+	force := true
+	retryTimes := "1"
+	crcRetryTimes := "3"
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	partSize := "10"
+	options := OptionMapType{
+		OptionEndpoint:         &server.URL,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionForce:            &force,
+		OptionRetryTimes:       &retryTimes,
+		OptionCRCRetryTimes:    &crcRetryTimes,
+		OptionCheckpointDir:    &cpDir,
+		OptionOutputDir:        &outputDir,
+		OptionBigFileThreshold: &threshold,
+		OptionPartSize:         &partSize,
+	}
+	assertNoTempFiles := func() {
+		_, err := os.Stat(fileName + oss.TempFileSuffix)
+		c.Assert(os.IsNotExist(err), Equals, true)
+		files, _ := ioutil.ReadDir(cpDir)
+		c.Assert(len(files), Equals, 0)
+	}
+
+	// the corrupted data is downloaded again
+	corrupt = 2
+	_, err := cm.RunCommand("cp", []string{"oss://bucket/object", fileName}, options)
+	c.Assert(err, IsNil)
+	c.Assert(gets, Equals, 3)
+	c.Assert(s.readFile(fileName, c), Equals, data)
+	assertNoTempFiles()
+
+	// the corruption is reported after the retries, the mismatched file is not left
+	os.Remove(fileName)
+	corrupt, gets = 100, 0
+	crcRetryTimes = "1"
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/object", fileName}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "corrupted"), Equals, true)
+	c.Assert(gets, Equals, 2)
+	_, err = os.Stat(fileName)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	assertNoTempFiles()
+
+	// the resumed download removes the checkpoint before retrying, otherwise the crc64 of the
+	// corrupted part would be combined again
+	threshold = "0"
+	corrupt, gets = 1, 0
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/object", fileName}, options)
+	c.Assert(err, IsNil)
+	c.Assert(gets, Equals, 6)
+	c.Assert(s.readFile(fileName, c), Equals, data)
+	assertNoTempFiles()
+
+	os.Remove(fileName)
+	corrupt, gets = 100, 0
+	crcRetryTimes = "0"
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/object", fileName}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "corrupted"), Equals, true)
+	c.Assert(gets, Equals, 3)
+	_, err = os.Stat(fileName)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	assertNoTempFiles()
+
+	// the encrypted checkpoint is removed before retrying as well
+	os.Setenv(CheckpointKeyEnv, "passphrase")
+	defer os.Unsetenv(CheckpointKeyEnv)
+	encryptCheckpoint := true
+	options[OptionEncryptCheckpoint] = &encryptCheckpoint
+	corrupt, gets = 1, 0
+	crcRetryTimes = "3"
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/object", fileName}, options)
+	c.Assert(err, IsNil)
+	c.Assert(gets, Equals, 6)
+	c.Assert(s.readFile(fileName, c), Equals, data)
+	assertNoTempFiles()
+}
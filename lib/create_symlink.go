@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -97,13 +98,11 @@ type CreateSymlinkCommand struct {
 
 var createSymlinkCommand = CreateSymlinkCommand{
 	command: Command{
-		name:        "create-symlink",
-		nameAlias:   []string{},
-		minArgc:     2,
-		maxArgc:     2,
-		specChinese: specChineseCreateSymlink,
-		specEnglish: specEnglishCreateSymlink,
-		group:       GroupTypeNormalCommand,
+		name:      "create-symlink",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionEncodingType,
 			OptionConfigFile,
@@ -125,6 +124,11 @@ var createSymlinkCommand = CreateSymlinkCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -146,8 +150,8 @@ func (cc *CreateSymlinkCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (cc *CreateSymlinkCommand) Init(args []string, options OptionMapType) error {
-	return cc.command.Init(args, options, cc)
+func (cc *CreateSymlinkCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return cc.command.Init(ctx, args, options, cc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -172,7 +176,7 @@ func (cc *CreateSymlinkCommand) RunCommand() error {
 		targetObject = targetURL.(CloudURL).object
 	}
 
-	bucket, err := cc.command.ossBucket(cloudURL.bucket)
+	bucket, err := cc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
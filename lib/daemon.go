@@ -0,0 +1,640 @@
+package lib
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var specChineseDaemon = SpecText{
+	synopsisText: "以守护进程方式运行，通过REST接口提交、查询和取消传输任务",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil daemon [--listen unix:///path/to/socket|tcp://host:port] [-c file] [-e endpoint]
+`,
+
+	detailHelpText: `
+    该命令以守护进程方式运行，在--listen指定的地址上提供HTTP REST接口，调度系统可以通过接口
+    提交传输任务、查询进度和取消任务，而无需为每个任务启动ossutil进程。任务逐个执行，守护进程
+    在任务之间复用oss客户端和网络连接，客户端每15分钟重建一次，以便刷新RamRoleArn方式获取的
+    临时凭证，配置文件的修改对之后创建的客户端生效。收到Ctrl-C或SIGTERM后，守护进程取消正在
+    执行的任务并退出。
+
+--listen选项
+
+    监听的地址，格式为unix:///path/to/socket或者tcp://host:port，默认为系统临时目录下的
+    ossutil.sock。unix socket文件的权限为0600。使用tcp时，守护进程生成随机的token，保存在
+    系统临时目录下的ossutil-daemon.token文件中(权限为0600)，请求需要携带头部
+    Authorization: Bearer <token>，请只监听本机地址。守护进程拒绝带有Origin头部的请求，
+    提交任务的请求的Content-Type必须为application/json，以防止网页发起跨站请求。
+
+    接口如下，请求和响应均为JSON格式：
+
+    POST /v1/jobs              提交任务，请求体为{"method":"cp","params":{...}}，method为cp或
+                               sync，cp的params与rpc命令的cp相同，sync的params为{"src":"...",
+                               "dest":"...","update":false,"delete":false,"include":[],
+                               "exclude":[]}，返回任务信息
+    GET /v1/jobs               列出所有任务
+    GET /v1/jobs/{id}          查询任务，包括状态(queued、running、succeeded、failed、
+                               canceled)、cp任务的进度以及失败的原因
+    POST /v1/jobs/{id}/cancel  取消排队或者正在执行的任务
+    GET /v1/health             查询守护进程的版本和任务数量
+
+    出错时响应为{"error":{"message":"...","kind":"NotFound","exitCode":4}}，失败任务的error
+    字段格式相同。守护进程最多保留最近的1000个已结束的任务。
+`,
+
+	sampleText: `
+    1) 启动守护进程
+       ossutil daemon --listen unix:///tmp/ossutil.sock
+
+    2) 提交上传任务
+       curl --unix-socket /tmp/ossutil.sock -X POST http://localhost/v1/jobs -H "Content-Type: application/json" -d '{"method":"cp","params":{"src":["dir"],"dest":"oss://bucket/dir/","recursive":true}}'
+
+    3) 查询和取消任务
+       curl --unix-socket /tmp/ossutil.sock http://localhost/v1/jobs/1
+       curl --unix-socket /tmp/ossutil.sock -X POST http://localhost/v1/jobs/1/cancel
+
+    4) 监听本机的tcp地址，请求携带token
+       ossutil daemon --listen tcp://127.0.0.1:8080
+       curl -H "Authorization: Bearer $(cat /tmp/ossutil-daemon.token)" http://127.0.0.1:8080/v1/jobs
+`,
+}
+
+var specEnglishDaemon = SpecText{
+	synopsisText: "Run as a daemon, submit, query and cancel the transfer jobs by the REST API",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil daemon [--listen unix:///path/to/socket|tcp://host:port] [-c file] [-e endpoint]
+`,
+
+	detailHelpText: `
+    The command runs as a daemon and serves the HTTP REST API on the address of --listen, so
+    that the schedulers can submit the transfer jobs, query the progress and cancel the jobs
+    by the API, instead of starting an ossutil process for every job. The jobs are run one by
+    one, the daemon reuses the oss clients and the connections between the jobs, the clients
+    are recreated every 15 minutes to refresh the temporary credentials of RamRoleArn, and the
+    changes of the config file take effect on the clients created later. On Ctrl-C or SIGTERM,
+    the daemon cancels the running job and exits.
+
+--listen option
+
+    The address to listen, the format is unix:///path/to/socket or tcp://host:port, the
+    default is ossutil.sock in the temp directory of the system. The permission of the unix
+    socket file is 0600. If tcp is used, the daemon generates a random token and saves it in
+    the file ossutil-daemon.token(the permission is 0600) in the temp directory of the system,
+    the requests need the header Authorization: Bearer <token>, please only listen on the
+    local address. The daemon rejects the requests with the header Origin, and the
+    Content-Type of the request to submit the job must be application/json, so that the web
+    pages can't send the cross-site requests.
+
+    The API is as follows, the requests and the responses are in JSON:
+
+    POST /v1/jobs              submit a job, the body is {"method":"cp","params":{...}}, the
+                               method is cp or sync, the params of cp are the same as cp of
+                               the rpc command, the params of sync are {"src":"...","dest":
+                               "...","update":false,"delete":false,"include":[],"exclude":
+                               []}, the job is returned
+    GET /v1/jobs               list all jobs
+    GET /v1/jobs/{id}          get the job, including the state(queued, running, succeeded,
+                               failed, canceled), the progress of cp and the failure reason
+    POST /v1/jobs/{id}/cancel  cancel the queued or running job
+    GET /v1/health             get the version of the daemon and the number of jobs
+
+    The error response is {"error":{"message":"...","kind":"NotFound","exitCode":4}}, the
+    error of the failed job is in the same format. The daemon keeps at most the latest 1000
+    finished jobs.
+`,
+
+	sampleText: `
+    1) start the daemon
+       ossutil daemon --listen unix:///tmp/ossutil.sock
+
+    2) submit the upload job
+       curl --unix-socket /tmp/ossutil.sock -X POST http://localhost/v1/jobs -H "Content-Type: application/json" -d '{"method":"cp","params":{"src":["dir"],"dest":"oss://bucket/dir/","recursive":true}}'
+
+    3) query and cancel the job
+       curl --unix-socket /tmp/ossutil.sock http://localhost/v1/jobs/1
+       curl --unix-socket /tmp/ossutil.sock -X POST http://localhost/v1/jobs/1/cancel
+
+    4) listen on the local tcp address, the requests carry the token
+       ossutil daemon --listen tcp://127.0.0.1:8080
+       curl -H "Authorization: Bearer $(cat /tmp/ossutil-daemon.token)" http://127.0.0.1:8080/v1/jobs
+`,
+}
+
+// the states of the daemon jobs
+const (
+	jobStateQueued    = "queued"
+	jobStateRunning   = "running"
+	jobStateSucceeded = "succeeded"
+	jobStateFailed    = "failed"
+	jobStateCanceled  = "canceled"
+)
+
+const (
+	daemonClientTTL       = 15 * time.Minute
+	daemonMaxQueuedJobs   = 1000
+	daemonMaxFinishedJobs = 1000
+	daemonShutdownTimeout = 5 * time.Second
+	daemonTokenFile       = "ossutil-daemon.token"
+)
+
+type daemonError struct {
+	Message  string `json:"message"`
+	Kind     string `json:"kind,omitempty"`
+	ExitCode int    `json:"exitCode,omitempty"`
+}
+
+type daemonSyncParams struct {
+	Src     string   `json:"src"`
+	Dest    string   `json:"dest"`
+	Update  bool     `json:"update"`
+	Delete  bool     `json:"delete"`
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+type daemonJobRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// daemonJob is a job of the daemon, the exported fields are returned by the API
+type daemonJob struct {
+	ID         string          `json:"id"`
+	Method     string          `json:"method"`
+	Params     json.RawMessage `json:"params"`
+	State      string          `json:"state"`
+	Progress   *CopyProgress   `json:"progress,omitempty"`
+	Error      *daemonError    `json:"error,omitempty"`
+	SubmitTime time.Time       `json:"submitTime"`
+	StartTime  *time.Time      `json:"startTime,omitempty"`
+	EndTime    *time.Time      `json:"endTime,omitempty"`
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+type DaemonCommand struct {
+	command Command
+	config  Config
+	mu      sync.Mutex
+	jobs    map[string]*daemonJob
+	order   []string // the ids of the jobs in the order of submission
+	nextID  int64
+	queue   chan *daemonJob
+	token   string // the token needed by the requests, it's set if the daemon listens on tcp
+}
+
+var daemonCommand = DaemonCommand{
+	command: Command{
+		name:      "daemon",
+		nameAlias: []string{"daemon"},
+		minArgc:   0,
+		maxArgc:   0,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionListen,
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionLogLevel,
+			OptionRequestPayer,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionReadTimeout,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionForcePathStyle,
+			OptionSkipVerifyCert,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (dc *DaemonCommand) formatHelpForWhole() string {
+	return dc.command.formatHelpForWhole()
+}
+
+func (dc *DaemonCommand) formatIndependHelp() string {
+	return dc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (dc *DaemonCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return dc.command.Init(ctx, args, options, dc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (dc *DaemonCommand) RunCommand() error {
+	listen, _ := GetString(OptionListen, dc.command.options)
+	if listen == "" {
+		listen = "unix://" + filepath.Join(os.TempDir(), "ossutil.sock")
+	}
+	listener, err := daemonListen(listen)
+	if err != nil {
+		return err
+	}
+
+	dc.token = ""
+	if strings.HasPrefix(listen, "tcp://") {
+		tokenPath := filepath.Join(os.TempDir(), daemonTokenFile)
+		if dc.token, err = writeDaemonToken(tokenPath); err != nil {
+			listener.Close()
+			return err
+		}
+		defer os.Remove(tokenPath)
+		fmt.Printf("the token of the API is saved in %s\n", tokenPath)
+	}
+
+	dc.config = configFromOptions(dc.command.options)
+	sharedClients = newClientPool(daemonClientTTL)
+	defer func() { sharedClients = nil }()
+
+	fmt.Printf("ossutil daemon is listening on %s\n", listen)
+	LogInfo("ossutil daemon is listening on %s\n", listen)
+	return dc.serve(dc.command.context(), listener)
+}
+
+// daemonListen listens on unix:///path or tcp://host:port, the stale socket file is removed
+func daemonListen(address string) (net.Listener, error) {
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		path := strings.TrimPrefix(address, "unix://")
+		if path == "" {
+			return nil, fmt.Errorf("invalid --listen: %s, the path of the socket is empty", address)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another daemon", path)
+		}
+		os.Remove(path)
+		listener, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if runtime.GOOS != "windows" {
+			if err = os.Chmod(path, 0600); err != nil {
+				listener.Close()
+				return nil, err
+			}
+		}
+		return listener, nil
+	case strings.HasPrefix(address, "tcp://"):
+		return net.Listen("tcp", strings.TrimPrefix(address, "tcp://"))
+	}
+	return nil, fmt.Errorf("invalid --listen: %s, the format is unix:///path/to/socket or tcp://host:port", address)
+}
+
+// writeDaemonToken generates a random token and saves it in path, only the owner can read it
+func writeDaemonToken(path string) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+	os.Remove(path)
+	if err := ioutil.WriteFile(path, []byte(token), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// serve serves the API on listener until ctx is done, then cancels the jobs and returns
+func (dc *DaemonCommand) serve(ctx context.Context, listener net.Listener) error {
+	dc.jobs = map[string]*daemonJob{}
+	dc.order = nil
+	dc.queue = make(chan *daemonJob, daemonMaxQueuedJobs)
+
+	workerCtx, cancelWorker := context.WithCancel(ctx)
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		dc.runJobs(workerCtx)
+	}()
+
+	server := &http.Server{Handler: dc.handler()}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+
+	var err error
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), daemonShutdownTimeout)
+		server.Shutdown(shutdownCtx)
+		cancel()
+	}
+	cancelWorker()
+	<-workerDone
+	if err == http.ErrServerClosed {
+		err = nil
+	}
+	return err
+}
+
+// runJobs runs the queued jobs one by one, cp keeps the progress in package variables
+func (dc *DaemonCommand) runJobs(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-dc.queue:
+			dc.runJob(ctx, job)
+		}
+	}
+}
+
+func (dc *DaemonCommand) runJob(ctx context.Context, job *daemonJob) {
+	dc.mu.Lock()
+	if job.State != jobStateQueued {
+		dc.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	job.State = jobStateRunning
+	job.StartTime = &now
+	dc.mu.Unlock()
+
+	// the job is canceled by the API or by the shutdown of the daemon
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			job.cancel()
+		case <-stop:
+		}
+	}()
+
+	var err error
+	switch job.Method {
+	case "cp":
+		var p rpcCopyParams
+		json.Unmarshal(job.Params, &p)
+		err = Copy(job.ctx, dc.config, p.Src, p.Dest, CopyOptions{
+			Recursive: p.Recursive,
+			Update:    p.Update,
+			Include:   p.Include,
+			Exclude:   p.Exclude,
+			Progress: func(progress CopyProgress) {
+				dc.mu.Lock()
+				job.Progress = &progress
+				dc.mu.Unlock()
+			},
+			ProgressInterval: time.Duration(p.ProgressInterval) * time.Millisecond,
+		})
+	case "sync":
+		var p daemonSyncParams
+		json.Unmarshal(job.Params, &p)
+		err = Sync(job.ctx, dc.config, p.Src, p.Dest, SyncOptions{
+			Update:  p.Update,
+			Delete:  p.Delete,
+			Include: p.Include,
+			Exclude: p.Exclude,
+		})
+	}
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	end := time.Now()
+	job.EndTime = &end
+	switch {
+	case err == nil:
+		job.State = jobStateSucceeded
+	case job.ctx.Err() != nil:
+		job.State = jobStateCanceled
+		job.Error = newDaemonError(err)
+	default:
+		job.State = jobStateFailed
+		job.Error = newDaemonError(err)
+	}
+	job.cancel()
+	LogInfo("daemon job %s %s, state: %s\n", job.ID, job.Method, job.State)
+}
+
+func newDaemonError(err error) *daemonError {
+	code := ExitCode(err)
+	return &daemonError{Message: err.Error(), Kind: rpcErrorKinds[code], ExitCode: code}
+}
+
+// authorize rejects the requests of the web pages and the requests without the token: the
+// browsers set Origin on the cross-origin requests, and they can't send the json body or the
+// Authorization header without the preflight request, which the daemon never allows
+func (dc *DaemonCommand) authorize(r *http.Request) (int, error) {
+	if r.Header.Get("Origin") != "" {
+		return http.StatusForbidden, fmt.Errorf("the cross-origin request is forbidden")
+	}
+	if dc.token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+dc.token)) != 1 {
+		return http.StatusUnauthorized, fmt.Errorf("invalid token, the request needs the header Authorization: Bearer <token>")
+	}
+	if r.Method == http.MethodPost && r.URL.Path == "/v1/jobs" {
+		if contentType := r.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+			return http.StatusUnsupportedMediaType, fmt.Errorf("invalid Content-Type: %s, the job must be application/json", contentType)
+		}
+	}
+	return 0, nil
+}
+
+func (dc *DaemonCommand) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/health", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeDaemonError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
+			return
+		}
+		dc.mu.Lock()
+		jobNum := len(dc.jobs)
+		dc.mu.Unlock()
+		writeDaemonJSON(w, http.StatusOK, map[string]interface{}{"version": Version, "jobs": jobNum})
+	})
+	mux.HandleFunc("/v1/jobs", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeDaemonJSON(w, http.StatusOK, dc.listJobs())
+		case http.MethodPost:
+			job, status, err := dc.submit(r)
+			if err != nil {
+				writeDaemonError(w, status, err)
+				return
+			}
+			writeDaemonJSON(w, http.StatusCreated, job)
+		default:
+			writeDaemonError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed: %s", r.Method))
+		}
+	})
+	mux.HandleFunc("/v1/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1/jobs/")
+		id, action := path, ""
+		if pos := strings.Index(path, "/"); pos >= 0 {
+			id, action = path[:pos], path[pos+1:]
+		}
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			if job, ok := dc.getJob(id); ok {
+				writeDaemonJSON(w, http.StatusOK, job)
+				return
+			}
+		case action == "cancel" && r.Method == http.MethodPost:
+			if job, ok := dc.cancelJob(id); ok {
+				writeDaemonJSON(w, http.StatusOK, job)
+				return
+			}
+		default:
+			writeDaemonError(w, http.StatusNotFound, fmt.Errorf("no such api: %s %s", r.Method, r.URL.Path))
+			return
+		}
+		writeDaemonError(w, http.StatusNotFound, fmt.Errorf("no such job: %s", id))
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status, err := dc.authorize(r); err != nil {
+			writeDaemonError(w, status, err)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// submit checks the request and queues the job, it returns the http status of the error
+func (dc *DaemonCommand) submit(r *http.Request) (daemonJob, int, error) {
+	var request daemonJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return daemonJob{}, http.StatusBadRequest, fmt.Errorf("invalid job, %s", err.Error())
+	}
+	switch request.Method {
+	case "cp":
+		var p rpcCopyParams
+		if err := json.Unmarshal(request.Params, &p); err != nil || len(p.Src) == 0 || p.Dest == "" {
+			return daemonJob{}, http.StatusBadRequest, fmt.Errorf("invalid params of cp, src and dest are needed")
+		}
+	case "sync":
+		var p daemonSyncParams
+		if err := json.Unmarshal(request.Params, &p); err != nil || p.Src == "" || p.Dest == "" {
+			return daemonJob{}, http.StatusBadRequest, fmt.Errorf("invalid params of sync, src and dest are needed")
+		}
+	default:
+		return daemonJob{}, http.StatusBadRequest, fmt.Errorf("invalid method: %s, the method can be cp or sync", request.Method)
+	}
+
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	dc.nextID++
+	job := &daemonJob{
+		ID:         strconv.FormatInt(dc.nextID, 10),
+		Method:     request.Method,
+		Params:     request.Params,
+		State:      jobStateQueued,
+		SubmitTime: time.Now(),
+	}
+	job.ctx, job.cancel = context.WithCancel(context.Background())
+	select {
+	case dc.queue <- job:
+	default:
+		job.cancel()
+		return daemonJob{}, http.StatusServiceUnavailable, fmt.Errorf("too many queued jobs, the max is %d", daemonMaxQueuedJobs)
+	}
+	dc.jobs[job.ID] = job
+	dc.order = append(dc.order, job.ID)
+	dc.pruneJobs()
+	LogInfo("daemon job %s %s is submitted, params: %s\n", job.ID, job.Method, string(job.Params))
+	return *job, 0, nil
+}
+
+// pruneJobs removes the oldest finished jobs beyond daemonMaxFinishedJobs, dc.mu is held
+func (dc *DaemonCommand) pruneJobs() {
+	finished := 0
+	for _, id := range dc.order {
+		if isJobFinished(dc.jobs[id].State) {
+			finished++
+		}
+	}
+	order := dc.order[:0]
+	for _, id := range dc.order {
+		if finished > daemonMaxFinishedJobs && isJobFinished(dc.jobs[id].State) {
+			delete(dc.jobs, id)
+			finished--
+			continue
+		}
+		order = append(order, id)
+	}
+	dc.order = order
+}
+
+func isJobFinished(state string) bool {
+	return state == jobStateSucceeded || state == jobStateFailed || state == jobStateCanceled
+}
+
+func (dc *DaemonCommand) listJobs() []daemonJob {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	jobs := make([]daemonJob, 0, len(dc.order))
+	for _, id := range dc.order {
+		jobs = append(jobs, *dc.jobs[id])
+	}
+	return jobs
+}
+
+func (dc *DaemonCommand) getJob(id string) (daemonJob, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	job, ok := dc.jobs[id]
+	if !ok {
+		return daemonJob{}, false
+	}
+	return *job, true
+}
+
+// cancelJob cancels the queued or running job, the queued job is skipped by the worker
+func (dc *DaemonCommand) cancelJob(id string) (daemonJob, bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	job, ok := dc.jobs[id]
+	if !ok {
+		return daemonJob{}, false
+	}
+	if job.State == jobStateQueued {
+		now := time.Now()
+		job.State = jobStateCanceled
+		job.EndTime = &now
+	}
+	job.cancel()
+	return *job, true
+}
+
+func writeDaemonJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeDaemonError(w http.ResponseWriter, status int, err error) {
+	writeDaemonJSON(w, status, map[string]*daemonError{"error": {Message: err.Error()}})
+}
@@ -0,0 +1,224 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestDaemon(c *C) {
+	var mu sync.Mutex
+	uploaded := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "PUT" {
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded[r.URL.Path] = string(body)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-daemon-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("abc"), 0644), IsNil)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	dc := &DaemonCommand{config: Config{
+		Endpoint:        server.URL,
+		AccessKeyID:     "ak",
+		AccessKeySecret: "ak",
+		ForcePathStyle:  true,
+		RetryTimes:      1,
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+	go func() { served <- dc.serve(ctx, listener) }()
+
+	api := "http://" + listener.Addr().String()
+	header := http.Header{}
+	request := func(method, path, body string, v interface{}) int {
+		req, err := http.NewRequest(method, api+path, strings.NewReader(body))
+		c.Assert(err, IsNil)
+		for k := range header {
+			req.Header.Set(k, header.Get(k))
+		}
+		if body != "" && req.Header.Get("Content-Type") == "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		if v != nil {
+			c.Assert(json.NewDecoder(resp.Body).Decode(v), IsNil)
+		}
+		return resp.StatusCode
+	}
+	waitJob := func(id string) daemonJob {
+		var job daemonJob
+		for i := 0; i < 500; i++ {
+			c.Assert(request("GET", "/v1/jobs/"+id, "", &job), Equals, http.StatusOK)
+			if isJobFinished(job.State) {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return job
+	}
+
+	var job daemonJob
+	c.Assert(request("POST", "/v1/jobs", `{"method":"cp","params":{"src":["`+dir+`"],"dest":"oss://bucket/up/","recursive":true}}`, &job), Equals, http.StatusCreated)
+	c.Assert(job.ID, Equals, "1")
+	job = waitJob("1")
+	c.Assert(job.State, Equals, jobStateSucceeded)
+	c.Assert(job.Progress.TransferredBytes, Equals, int64(3))
+	mu.Lock()
+	c.Assert(uploaded["/bucket/up/a.txt"], Equals, "abc")
+	mu.Unlock()
+
+	// the failed job has the kind of the error
+	c.Assert(request("POST", "/v1/jobs", `{"method":"cp","params":{"src":["oss://bucket/missing"],"dest":"`+dir+`/b.txt"}}`, &job), Equals, http.StatusCreated)
+	job = waitJob(job.ID)
+	c.Assert(job.State, Equals, jobStateFailed)
+	c.Assert(job.Error.Kind, Equals, "NotFound")
+
+	var jobs []daemonJob
+	c.Assert(request("GET", "/v1/jobs", "", &jobs), Equals, http.StatusOK)
+	c.Assert(len(jobs), Equals, 2)
+
+	var health map[string]interface{}
+	c.Assert(request("GET", "/v1/health", "", &health), Equals, http.StatusOK)
+	c.Assert(health["version"], Equals, Version)
+
+	var apiErr map[string]daemonError
+	c.Assert(request("POST", "/v1/jobs", `{"method":"rm","params":{}}`, &apiErr), Equals, http.StatusBadRequest)
+	c.Assert(strings.Contains(apiErr["error"].Message, "invalid method"), Equals, true)
+	c.Assert(request("POST", "/v1/jobs", `{"method":"cp","params":{"dest":"oss://bucket/"}}`, nil), Equals, http.StatusBadRequest)
+	c.Assert(request("GET", "/v1/jobs/100", "", nil), Equals, http.StatusNotFound)
+	c.Assert(request("POST", "/v1/jobs/100/cancel", "", nil), Equals, http.StatusNotFound)
+	c.Assert(request("DELETE", "/v1/jobs/1", "", nil), Equals, http.StatusNotFound)
+
+	// the cross-site requests of the web pages are rejected
+	header.Set("Content-Type", "text/plain")
+	c.Assert(request("POST", "/v1/jobs", `{"method":"cp","params":{"src":["`+dir+`"],"dest":"oss://bucket/up/","recursive":true}}`, nil), Equals, http.StatusUnsupportedMediaType)
+	header = http.Header{"Origin": {"http://example.com"}}
+	c.Assert(request("GET", "/v1/jobs", "", nil), Equals, http.StatusForbidden)
+	c.Assert(request("POST", "/v1/jobs/1/cancel", "", nil), Equals, http.StatusForbidden)
+
+	// the token is needed once it's set
+	header = http.Header{}
+	dc.token = "token"
+	c.Assert(request("GET", "/v1/jobs", "", nil), Equals, http.StatusUnauthorized)
+	header.Set("Authorization", "Bearer other")
+	c.Assert(request("GET", "/v1/jobs", "", nil), Equals, http.StatusUnauthorized)
+	header.Set("Authorization", "Bearer token")
+	c.Assert(request("GET", "/v1/jobs", "", &jobs), Equals, http.StatusOK)
+	c.Assert(len(jobs), Equals, 2)
+
+	cancel()
+	select {
+	case err = <-served:
+		c.Assert(err, IsNil)
+	case <-time.After(10 * time.Second):
+		c.Fatal("the daemon isn't stopped")
+	}
+}
+
+func (s *OssutilCommandSuite) TestDaemonListen(c *C) {
+	_, err := daemonListen("http://127.0.0.1:80")
+	c.Assert(err, NotNil)
+	_, err = daemonListen("unix://")
+	c.Assert(err, NotNil)
+
+	path := filepath.Join(os.TempDir(), "ossutil-test-daemon-"+randLowStr(5)+".sock")
+	c.Assert(ioutil.WriteFile(path, []byte{}, 0644), IsNil)
+	listener, err := daemonListen("unix://" + path)
+	c.Assert(err, IsNil)
+	info, err := os.Stat(path)
+	c.Assert(err, IsNil)
+	c.Assert(info.Mode().Perm(), Equals, os.FileMode(0600))
+	_, err = daemonListen("unix://" + path)
+	c.Assert(err, NotNil)
+	listener.Close()
+	_, err = os.Stat(path)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	tokenPath := filepath.Join(os.TempDir(), "ossutil-test-daemon-"+randLowStr(5)+".token")
+	defer os.Remove(tokenPath)
+	token, err := writeDaemonToken(tokenPath)
+	c.Assert(err, IsNil)
+	c.Assert(len(token), Equals, 32)
+	saved, err := ioutil.ReadFile(tokenPath)
+	c.Assert(err, IsNil)
+	c.Assert(string(saved), Equals, token)
+	info, err = os.Stat(tokenPath)
+	c.Assert(err, IsNil)
+	c.Assert(info.Mode().Perm(), Equals, os.FileMode(0600))
+	other, err := writeDaemonToken(tokenPath)
+	c.Assert(err, IsNil)
+	c.Assert(other == token, Equals, false)
+}
+
+func (s *OssutilCommandSuite) TestClientPool(c *C) {
+	pool := newClientPool(time.Hour)
+	created := 0
+	create := func() (*oss.Client, error) {
+		created++
+		return oss.New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk")
+	}
+	client1, err := pool.get("a", create)
+	c.Assert(err, IsNil)
+	client2, err := pool.get("a", create)
+	c.Assert(err, IsNil)
+	c.Assert(client1 == client2, Equals, true)
+	_, err = pool.get("b", create)
+	c.Assert(err, IsNil)
+	c.Assert(created, Equals, 2)
+	c.Assert(pool.len(), Equals, 2)
+
+	// the expired clients are recreated
+	pool.ttl = 0
+	client3, err := pool.get("a", create)
+	c.Assert(err, IsNil)
+	c.Assert(client3 == client1, Equals, false)
+	c.Assert(pool.len(), Equals, 1)
+
+	// the key changes with the settings of the client
+	endpoint, ak, other := "oss-cn-hangzhou.aliyuncs.com", "ak", "ak2"
+	cmd := Command{options: OptionMapType{OptionAccessKeyID: &ak}}
+	key := cmd.clientKey(endpoint, false)
+	c.Assert(cmd.clientKey(endpoint, false), Equals, key)
+	c.Assert(cmd.clientKey(endpoint, true) == key, Equals, false)
+	cmd.options[OptionAccessKeyID] = &other
+	c.Assert(cmd.clientKey(endpoint, false) == key, Equals, false)
+	key = cmd.clientKey(endpoint, false)
+	cmd.configOptions = OptionMapType{CREDSection: map[string]string{ItemRamRoleArn: "acs:ram::1:role/a"}}
+	c.Assert(cmd.clientKey(endpoint, false) == key, Equals, false)
+	key = cmd.clientKey(endpoint, false)
+	cmd.configOptions[AkServiceSection] = map[string]string{ItemEcsAk: "http://localhost/ak"}
+	c.Assert(cmd.clientKey(endpoint, false) == key, Equals, false)
+}
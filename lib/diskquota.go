@@ -0,0 +1,93 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// diskQuota limits the bytes written by download to the free space of the destination filesystem
+// and --max-disk-usage, so that the command fails early instead of running out of space in the end
+type diskQuota struct {
+	path     string
+	limit    int64
+	maxUsage bool // the limit is --max-disk-usage rather than the free space
+	used     int64
+}
+
+// newDiskQuota returns nil if neither the free space nor --max-disk-usage is available
+func (cmd *Command) newDiskQuota(filePath string) (*diskQuota, error) {
+	dq := &diskQuota{path: existingParentDir(filePath), limit: -1}
+	if free, err := getDiskFreeSpace(dq.path); err != nil {
+		LogInfo("get free space of %s error:%s, skip the disk space check\n", dq.path, err.Error())
+	} else {
+		dq.limit = int64(free)
+		LogInfo("free space of %s:%d\n", dq.path, free)
+	}
+
+	if maxUsage, err := GetInt(OptionMaxDiskUsage, cmd.options); err == nil {
+		if maxUsage <= 0 {
+			return nil, fmt.Errorf("invalid --max-disk-usage: %d, the value should be positive bytes", maxUsage)
+		}
+		if dq.limit < 0 || maxUsage < dq.limit {
+			dq.limit = maxUsage
+			dq.maxUsage = true
+		}
+	}
+
+	if dq.limit < 0 {
+		return nil, nil
+	}
+	return dq, nil
+}
+
+// existingParentDir returns the path itself or its nearest parent which exists
+func existingParentDir(filePath string) string {
+	dir, err := filepath.Abs(filePath)
+	if err != nil {
+		dir = filePath
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// localFileSize returns the size of the existing file to be overwritten
+func localFileSize(fileName string) int64 {
+	if f, err := os.Stat(fileName); err == nil && f.Mode().IsRegular() {
+		return f.Size()
+	}
+	return 0
+}
+
+func (dq *diskQuota) error(need int64) error {
+	return DiskSpaceError{dq.path, need, dq.limit, dq.maxUsage}
+}
+
+// check returns error if the total bytes to be downloaded exceed the limit
+func (dq *diskQuota) check(total int64) error {
+	if dq == nil || total <= dq.limit {
+		return nil
+	}
+	return dq.error(total)
+}
+
+// reserve takes the size of the file to be downloaded from the limit
+func (dq *diskQuota) reserve(size int64) error {
+	if dq == nil || size <= 0 {
+		return nil
+	}
+	if used := atomic.AddInt64(&dq.used, size); used > dq.limit {
+		atomic.AddInt64(&dq.used, -size)
+		return dq.error(used)
+	}
+	return nil
+}
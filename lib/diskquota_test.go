@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestDiskQuota(c *C) {
+	free, err := getDiskFreeSpace(".")
+	c.Assert(err, IsNil)
+	c.Assert(free > 0, Equals, true)
+
+	dir, _ := filepath.Abs(".")
+	c.Assert(existingParentDir(filepath.Join("ossutil-test-not-exist-"+randLowStr(5), "a", "b")), Equals, dir)
+
+	maxUsage := "100"
+	cmd := Command{options: OptionMapType{OptionMaxDiskUsage: &maxUsage}}
+	dq, err := cmd.newDiskQuota(".")
+	c.Assert(err, IsNil)
+	c.Assert(dq.limit, Equals, int64(100))
+	c.Assert(dq.check(100), IsNil)
+	c.Assert(dq.check(101), NotNil)
+	c.Assert(dq.reserve(60), IsNil)
+	err = dq.reserve(60)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--max-disk-usage"), Equals, true)
+	c.Assert(dq.reserve(40), IsNil)
+
+	invalid := "0"
+	cmd = Command{options: OptionMapType{OptionMaxDiskUsage: &invalid}}
+	_, err = cmd.newDiskQuota(".")
+	c.Assert(err, NotNil)
+
+	cmd = Command{options: OptionMapType{}}
+	dq, err = cmd.newDiskQuota(".")
+	c.Assert(err, IsNil)
+	c.Assert(dq.maxUsage, Equals, false)
+	err = dq.check(dq.limit + 1)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "not enough disk space"), Equals, true)
+
+	var disabled *diskQuota
+	c.Assert(disabled.check(1<<60), IsNil)
+	c.Assert(disabled.reserve(1<<60), IsNil)
+
+	jb := &jobBudget{retryBudget: -1}
+	jb.abort(dq.error(1))
+	c.Assert(jb.exceeded(), Equals, true)
+	_, ok := jb.err().(DiskSpaceError)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestDownloadMaxDiskUsage(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+	for i := 0; i < 3; i++ {
+		s.putObject(bucketName, "disk/"+randLowStr(5), uploadFileName, c)
+	}
+
+	dir := "ossutil-test-disk-" + randLowStr(5)
+	str := ""
+	recursive := true
+	maxUsage := "1"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &recursive,
+		"maxDiskUsage":    &maxUsage,
+	}
+	_, err := cm.RunCommand("cp", []string{CloudURLToString(bucketName, "disk/"), dir}, options)
+	c.Assert(err, NotNil)
+	_, ok := err.(DiskSpaceError)
+	c.Assert(ok, Equals, true)
+
+	delete(options, "maxDiskUsage")
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, "disk/"), dir}, options)
+	c.Assert(err, IsNil)
+
+	os.RemoveAll(dir)
+	s.removeBucket(bucketName, true, c)
+}
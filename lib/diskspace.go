@@ -0,0 +1,19 @@
+// This is for Condition Compling, which means it will be built on all non-windows platform.
+
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"syscall"
+)
+
+// getDiskFreeSpace returns the bytes available to the current user on the filesystem of the path
+func getDiskFreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
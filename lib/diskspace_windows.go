@@ -0,0 +1,25 @@
+// This filename is for Condition Compling, which means it will be built only on windows platform.
+
+package lib
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// getDiskFreeSpace returns the bytes available to the current user on the volume of the path
+func getDiskFreeSpace(path string) (uint64, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var freeBytes uint64
+	var mod = syscall.NewLazyDLL("kernel32.dll")
+	var proc = mod.NewProc("GetDiskFreeSpaceExW")
+	ret, _, err := proc.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&freeBytes)), 0, 0)
+	if ret == 0 {
+		return 0, err
+	}
+	return freeBytes, nil
+}
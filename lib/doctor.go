@@ -0,0 +1,439 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseDoctor = SpecText{
+	synopsisText: "检查ossutil的配置和运行环境",
+
+	paramText: "[cloud_url] [options]",
+
+	syntaxText: `
+    ossutil doctor [oss://bucket] [-c file] [-e endpoint] [--checkpoint-dir dir]
+`,
+
+	detailHelpText: `
+    该命令依次检查以下项目，对于失败的检查给出修复建议，有检查失败时命令返回错误：
+
+    1) config: 配置文件是否存在以及语法、取值是否正确
+    2) credentials: 是否配置了访问凭证，并发送请求验证AccessKeyID、AccessKeySecret和STSToken
+       是否有效，指定了oss://bucket时验证对该bucket的访问（GetBucketInfo），否则列举bucket
+    3) endpoint: endpoint的格式、dns解析和tcp连接
+    4) clock: 本地时间与oss服务端时间的偏差，偏差超过15分钟时请求会因为签名过期而失败
+    5) proxy: --proxy-host以及HTTP_PROXY、HTTPS_PROXY环境变量的格式和代理服务器的连通性
+    6) checkpoint-dir: --checkpoint-dir指定的目录（默认为.ossutil_checkpoint）是否可写
+
+    检查结果中[√]表示通过，[!]表示警告，[x]表示失败。
+`,
+
+	sampleText: `
+    1) 检查默认配置文件和运行环境
+       ossutil doctor
+
+    2) 检查指定配置文件，并验证对bucket的访问
+       ossutil doctor oss://bucket -c ~/.ossutilconfig-test
+`,
+}
+
+var specEnglishDoctor = SpecText{
+	synopsisText: "Check the configuration and the environment of ossutil",
+
+	paramText: "[cloud_url] [options]",
+
+	syntaxText: `
+    ossutil doctor [oss://bucket] [-c file] [-e endpoint] [--checkpoint-dir dir]
+`,
+
+	detailHelpText: `
+    The command checks the following items in order, and shows the fix for every failed check,
+    the command returns error if any check fails:
+
+    1) config: whether the config file exists, and its syntax and values are valid
+    2) credentials: whether the credentials are configured, and sends request to verify the
+       AccessKeyID, AccessKeySecret and STSToken, the access to the bucket is verified by
+       GetBucketInfo if oss://bucket is specified, otherwise the buckets are listed
+    3) endpoint: the format, the dns resolution and the tcp connection of the endpoint
+    4) clock: the skew between the local time and the time of oss, the requests fail because
+       of the expired signature if the skew is more than 15 minutes
+    5) proxy: the format of --proxy-host and the HTTP_PROXY, HTTPS_PROXY environment variables,
+       and the connectivity of the proxy server
+    6) checkpoint-dir: whether the directory of --checkpoint-dir(.ossutil_checkpoint by default)
+       is writable
+
+    In the result, [√] means passed, [!] means warning, [x] means failed.
+`,
+
+	sampleText: `
+    1) check the default config file and the environment
+       ossutil doctor
+
+    2) check the specified config file, and verify the access to the bucket
+       ossutil doctor oss://bucket -c ~/.ossutilconfig-test
+`,
+}
+
+const (
+	doctorPassed = iota
+	doctorWarning
+	doctorFailed
+)
+
+// maxClockSkew is the max time skew accepted by oss
+const maxClockSkew = 15 * time.Minute
+
+// doctorResult is the result of a check, fix is the suggestion when the check doesn't pass
+type doctorResult struct {
+	state  int
+	detail string
+	fix    string
+}
+
+type DoctorCommand struct {
+	command   Command
+	configErr error
+	bucket    string
+	failedNum int
+}
+
+var doctorCommand = DoctorCommand{
+	command: Command{
+		name:      "doctor",
+		nameAlias: []string{"doctor"},
+		minArgc:   0,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionCheckpointDir,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (dc *DoctorCommand) formatHelpForWhole() string {
+	return dc.command.formatHelpForWhole()
+}
+
+func (dc *DoctorCommand) formatIndependHelp() string {
+	return dc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (dc *DoctorCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return dc.command.Init(ctx, args, options, dc)
+}
+
+// function for RewriteLoadConfiger interface
+func (dc *DoctorCommand) rewriteLoadConfig(configFile string) error {
+	// the error of the config file is reported by the check instead of failing the command
+	var err error
+	if dc.command.configOptions, err = LoadConfig(configFile); err != nil {
+		dc.command.configOptions = OptionMapType{}
+	}
+	dc.configErr = err
+	return nil
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (dc *DoctorCommand) RunCommand() error {
+	dc.failedNum = 0
+	dc.bucket = ""
+	if len(dc.command.args) > 0 {
+		cloudURL, err := CloudURLFromString(dc.command.args[0], "")
+		if err != nil {
+			return err
+		}
+		dc.bucket = cloudURL.bucket
+	}
+
+	dc.report("config", dc.checkConfig())
+	dc.report("credentials", dc.checkCredentials())
+	dc.report("endpoint", dc.checkEndpoint())
+	dc.report("clock", dc.checkClock())
+	dc.report("proxy", dc.checkProxy())
+	dc.report("checkpoint-dir", dc.checkCheckpointDir())
+
+	if dc.failedNum > 0 {
+		return fmt.Errorf("%d check(s) failed, please fix them as suggested above", dc.failedNum)
+	}
+	fmt.Println("\nall checks passed.")
+	return nil
+}
+
+func (dc *DoctorCommand) report(item string, result doctorResult) {
+	mark := "[√]"
+	switch result.state {
+	case doctorWarning:
+		mark = "[!]"
+	case doctorFailed:
+		mark = "[x]"
+		dc.failedNum++
+	}
+	fmt.Printf("%s %s: %s\n", mark, item, result.detail)
+	if result.state != doctorPassed && result.fix != "" {
+		fmt.Printf("    fix: %s\n", result.fix)
+	}
+}
+
+func (dc *DoctorCommand) checkConfig() doctorResult {
+	configFile, _ := GetString(OptionConfigFile, dc.command.options)
+	configFile = DecideConfigFile(configFile)
+	if dc.configErr == nil {
+		return doctorResult{doctorPassed, fmt.Sprintf("%s is valid", configFile), ""}
+	}
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		if !dc.command.needConfigFile() {
+			return doctorResult{doctorWarning, fmt.Sprintf("%s doesn't exist, the options of the command line are used", configFile),
+				"run \"ossutil config\" to create the config file if the options should be kept"}
+		}
+		return doctorResult{doctorFailed, fmt.Sprintf("%s doesn't exist", configFile),
+			"run \"ossutil config\" to create the config file, or specify the config file by --config-file"}
+	}
+	return doctorResult{doctorFailed, dc.configErr.Error(),
+		fmt.Sprintf("correct %s as the error shows, see \"ossutil help config\" for the format", configFile)}
+}
+
+func (dc *DoctorCommand) checkCredentials() doctorResult {
+	mode, _ := GetString(OptionMode, dc.command.options)
+	accessKeyID, _ := GetString(OptionAccessKeyID, dc.command.options)
+	accessKeySecret, _ := GetString(OptionAccessKeySecret, dc.command.options)
+	if mode == "" && (accessKeyID == "" || accessKeySecret == "") {
+		return doctorResult{doctorFailed, "AccessKeyID or AccessKeySecret is not configured",
+			"set accessKeyID and accessKeySecret by \"ossutil config\", or specify --access-key-id and --access-key-secret"}
+	}
+
+	client, err := dc.command.ossClient(dc.bucket)
+	if err != nil {
+		return doctorResult{doctorFailed, err.Error(), "check the endpoint and the credentials options"}
+	}
+	if dc.bucket != "" {
+		_, err = client.GetBucketInfo(dc.bucket)
+	} else {
+		_, err = client.ListBuckets(oss.MaxKeys(1))
+	}
+	if err == nil {
+		return doctorResult{doctorPassed, "the credentials are valid", ""}
+	}
+	return diagnoseCredentialError(err)
+}
+
+// diagnoseCredentialError explains the error of the request verifying the credentials
+func diagnoseCredentialError(err error) doctorResult {
+	serviceError, ok := err.(oss.ServiceError)
+	if !ok {
+		return doctorResult{doctorFailed, fmt.Sprintf("the request failed, %s", err.Error()),
+			"check the endpoint and the network as the endpoint and proxy checks show"}
+	}
+	switch serviceError.Code {
+	case "InvalidAccessKeyId":
+		return doctorResult{doctorFailed, "the AccessKeyID doesn't exist or is disabled",
+			"check accessKeyID in the config file or --access-key-id, and make sure the AccessKey is enabled"}
+	case "SignatureDoesNotMatch":
+		return doctorResult{doctorFailed, "the signature doesn't match",
+			"check accessKeySecret in the config file or --access-key-secret, it should be the secret of the AccessKeyID"}
+	case "SecurityTokenExpired", "InvalidSecurityToken":
+		return doctorResult{doctorFailed, fmt.Sprintf("the sts token is invalid, %s", serviceError.Code),
+			"get a new sts token, and update stsToken in the config file or --sts-token"}
+	case "RequestTimeTooSkewed":
+		return doctorResult{doctorFailed, "the local time is too skewed from the time of oss",
+			"synchronize the local clock, e.g., by ntp"}
+	case "AccessDenied":
+		return doctorResult{doctorWarning, fmt.Sprintf("the credentials are valid, but the request is denied, %s", serviceError.Message),
+			"grant the permission to the AccessKey by RAM policy or bucket policy if the operations are denied"}
+	case "NoSuchBucket":
+		return doctorResult{doctorFailed, "the bucket doesn't exist", "check the bucket name and the endpoint of the region"}
+	}
+	return doctorResult{doctorFailed, fmt.Sprintf("the request failed, %s, %s", serviceError.Code, serviceError.Message),
+		"see the error code in the oss documentation"}
+}
+
+func (dc *DoctorCommand) checkEndpoint() doctorResult {
+	endpoint, _ := dc.command.getEndpoint(dc.bucket)
+	if endpoint == "" {
+		return doctorResult{doctorFailed, "the endpoint is not configured",
+			"set endpoint by \"ossutil config\", or specify --endpoint, e.g., oss-cn-hangzhou.aliyuncs.com"}
+	}
+	_, host, port, err := diagnoseEndpoint(endpoint)
+	if err != nil {
+		return doctorResult{doctorFailed, err.Error(), "the endpoint should be like oss-cn-hangzhou.aliyuncs.com or https://oss-cn-hangzhou.aliyuncs.com"}
+	}
+
+	var report bytes.Buffer
+	addrs, err := diagnoseDNS(&report, host)
+	if err != nil {
+		return doctorResult{doctorFailed, fmt.Sprintf("resolve %s error, %s", host, err.Error()),
+			"check the endpoint and the dns server, use the intranet endpoint only in the vpc of the region"}
+	}
+	if err := diagnoseTCP(&report, addrs, port); err != nil {
+		if dc.proxyURL(endpoint) != nil {
+			return doctorResult{doctorWarning, fmt.Sprintf("connect %s directly error, %s, the requests are sent by the proxy", net.JoinHostPort(host, port), err.Error()), ""}
+		}
+		return doctorResult{doctorFailed, fmt.Sprintf("connect %s error, %s", net.JoinHostPort(host, port), err.Error()),
+			"check the firewall and the network, or use the proxy by --proxy-host"}
+	}
+	return doctorResult{doctorPassed, fmt.Sprintf("%s is reachable", endpoint), ""}
+}
+
+func (dc *DoctorCommand) checkClock() doctorResult {
+	endpoint, _ := dc.command.getEndpoint(dc.bucket)
+	scheme, host, port, err := diagnoseEndpoint(endpoint)
+	if err != nil {
+		return doctorResult{doctorWarning, "the time of oss is unknown, the endpoint is invalid", ""}
+	}
+
+	skipVerify, _ := GetBool(OptionSkipVerifyCert, dc.command.options)
+	transport := &http.Transport{
+		Proxy:           func(req *http.Request) (*url.URL, error) { return dc.proxyURL(endpoint), nil },
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: skipVerify},
+	}
+	client := &http.Client{Transport: transport, Timeout: diagnoseDialTimeout}
+	start := time.Now()
+	resp, err := client.Head(scheme + "://" + net.JoinHostPort(host, port) + "/")
+	if err != nil {
+		return doctorResult{doctorWarning, fmt.Sprintf("the time of oss is unknown, %s", err.Error()), ""}
+	}
+	resp.Body.Close()
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return doctorResult{doctorWarning, "the time of oss is unknown, no Date in the response", ""}
+	}
+
+	// the Date of the response is about the middle of the request
+	localTime := start.Add(time.Since(start) / 2)
+	skew := localTime.Sub(serverTime)
+	detail := fmt.Sprintf("the local time is %s ahead of oss", skew.Round(time.Second))
+	if skew < 0 {
+		detail = fmt.Sprintf("the local time is %s behind oss", (-skew).Round(time.Second))
+	}
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return doctorResult{doctorFailed, detail, "the requests are rejected with RequestTimeTooSkewed, synchronize the local clock, e.g., by ntp"}
+	}
+	if skew > time.Minute || skew < -time.Minute {
+		return doctorResult{doctorWarning, detail, "synchronize the local clock, e.g., by ntp"}
+	}
+	return doctorResult{doctorPassed, detail, ""}
+}
+
+// proxyURL returns the proxy of the requests to the endpoint, nil means no proxy
+func (dc *DoctorCommand) proxyURL(endpoint string) *url.URL {
+	proxyHost, _ := GetString(OptionProxyHost, dc.command.options)
+	if proxyHost != "" {
+		proxy, err := url.Parse(proxyHost)
+		if err != nil {
+			return nil
+		}
+		return proxy
+	}
+	scheme, host, port, err := diagnoseEndpoint(endpoint)
+	if err != nil {
+		return nil
+	}
+	req, _ := http.NewRequest("GET", scheme+"://"+net.JoinHostPort(host, port), nil)
+	if req == nil {
+		return nil
+	}
+	proxy, _ := http.ProxyFromEnvironment(req)
+	return proxy
+}
+
+func (dc *DoctorCommand) checkProxy() doctorResult {
+	proxyHost, _ := GetString(OptionProxyHost, dc.command.options)
+	source := "--proxy-host"
+	if proxyHost == "" {
+		for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+			if value := os.Getenv(name); value != "" {
+				proxyHost, source = value, name
+				break
+			}
+		}
+	}
+	if proxyHost == "" {
+		return doctorResult{doctorPassed, "no proxy is used", ""}
+	}
+
+	proxy, err := url.Parse(proxyHost)
+	if err != nil || proxy.Host == "" {
+		return doctorResult{doctorFailed, fmt.Sprintf("invalid proxy of %s: %s", source, proxyHost),
+			"the proxy should be like http://proxy.example.com:8080"}
+	}
+	address := proxy.Host
+	if proxy.Port() == "" {
+		address = net.JoinHostPort(proxy.Hostname(), "80")
+		if proxy.Scheme == "https" {
+			address = net.JoinHostPort(proxy.Hostname(), "443")
+		}
+	}
+	conn, err := net.DialTimeout("tcp", address, diagnoseDialTimeout)
+	if err != nil {
+		return doctorResult{doctorFailed, fmt.Sprintf("connect the proxy %s of %s error, %s", address, source, err.Error()),
+			"check the proxy server, or remove the proxy setting if it's not needed"}
+	}
+	conn.Close()
+	return doctorResult{doctorPassed, fmt.Sprintf("the proxy %s of %s is reachable", address, source), ""}
+}
+
+func (dc *DoctorCommand) checkCheckpointDir() doctorResult {
+	dir, _ := GetString(OptionCheckpointDir, dc.command.options)
+	if dir == "" {
+		dir = CheckpointDir
+	}
+
+	_, statErr := os.Stat(dir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return doctorResult{doctorFailed, fmt.Sprintf("create %s error, %s", dir, err.Error()),
+			"specify a writable directory by --checkpoint-dir"}
+	}
+	if os.IsNotExist(statErr) {
+		// the directory is created by the check, remove it as the successful operations do
+		defer os.RemoveAll(dir)
+	}
+
+	f, err := ioutil.TempFile(dir, ".ossutil_doctor_")
+	if err != nil {
+		return doctorResult{doctorFailed, fmt.Sprintf("%s is not writable, %s", dir, err.Error()),
+			"grant the write permission of the directory, or specify a writable directory by --checkpoint-dir"}
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return doctorResult{doctorPassed, fmt.Sprintf("%s is writable", dir), ""}
+}
@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestDoctorCredentialError(c *C) {
+	c.Assert(diagnoseCredentialError(oss.ServiceError{Code: "InvalidAccessKeyId"}).state, Equals, doctorFailed)
+	c.Assert(diagnoseCredentialError(oss.ServiceError{Code: "RequestTimeTooSkewed"}).state, Equals, doctorFailed)
+	c.Assert(diagnoseCredentialError(oss.ServiceError{Code: "AccessDenied"}).state, Equals, doctorWarning)
+	c.Assert(diagnoseCredentialError(errors.New("timeout")).state, Equals, doctorFailed)
+}
+
+func (s *OssutilCommandSuite) TestDoctor(c *C) {
+	skew := time.Duration(0)
+	code := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(skew).UTC().Format(http.TimeFormat))
+		if r.Method == "HEAD" {
+			return
+		}
+		if code != "" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprintf(w, `<Error><Code>%s</Code><Message>denied</Message></Error>`, code)
+			return
+		}
+		fmt.Fprint(w, `<ListAllMyBucketsResult><Buckets></Buckets></ListAllMyBucketsResult>`)
+	}))
+	defer server.Close()
+
+	configFile := "ossutil-test-doctor-config-" + randLowStr(5)
+	checkpointDir := "ossutil-test-doctor-checkpoint-" + randLowStr(5)
+	endpoint := server.URL
+	str := "ak"
+	options := OptionMapType{
+		OptionConfigFile:      &configFile,
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionCheckpointDir:   &checkpointDir,
+	}
+	runDoctor := func() (string, error) {
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		_, err = cm.RunCommand("doctor", []string{}, options)
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		data, rerr := ioutil.ReadFile(resultPath)
+		c.Assert(rerr, IsNil)
+		return string(data), err
+	}
+
+	// the config file isn't needed as the options are specified
+	out, err := runDoctor()
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(out, "[!] config:"), Equals, true)
+	c.Assert(strings.Contains(out, "[√] credentials:"), Equals, true)
+	c.Assert(strings.Contains(out, "[√] clock:"), Equals, true)
+	c.Assert(strings.Contains(out, "[√] checkpoint-dir:"), Equals, true)
+	_, err = os.Stat(checkpointDir)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// invalid config file
+	c.Assert(ioutil.WriteFile(configFile, []byte("[Credentials]\nretryTimes=abc\n"), 0600), IsNil)
+	defer os.Remove(configFile)
+	skew = 20 * time.Minute
+	code = "RequestTimeTooSkewed"
+	out, err = runDoctor()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(out, "[x] config:"), Equals, true)
+	c.Assert(strings.Contains(out, "[x] credentials: the local time is too skewed"), Equals, true)
+	c.Assert(strings.Contains(out, "[x] clock: the local time is"), Equals, true)
+	c.Assert(strings.Contains(out, "behind oss"), Equals, true)
+	c.Assert(strings.Contains(out, "    fix: synchronize the local clock"), Equals, true)
+}
@@ -1,11 +1,16 @@
 package lib
 
 import (
+	"container/heap"
+	"context"
 	"fmt"
+	"math"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 )
@@ -24,10 +29,26 @@ var specChineseDu = SpecText{
   
 用法：
 
-    该命令只有一种用法：
+    该命令有两种用法：
 
     1) ossutil du oss://bucket[/prefix] [options]
-      查询bucket或者指定前缀(目录)所占存储空间大小
+      查询bucket或者指定前缀(目录)所占存储空间大小，并按存储类型显示object的数量、大小以及
+      占总大小的百分比。如果指定了--top N选项，还会显示最大的N个object，便于找出占用存储空间
+      (如归档存储费用)最多的object。
+
+    2) ossutil du oss://bucket[/prefix] --sample N [-j jobs]
+      估算bucket或者指定前缀(目录)下objects的数量和大小，而不是列举所有的objects，适用于数亿个
+      objects的前缀，几秒内返回结果。该用法列举第一个和最后一个object之间的N个随机位置之后一段等宽
+      key空间中的objects，根据每段中objects的数量和大小估算总数和总大小，并给出95%的置信区间。
+      抽样点越多，估算越准确，建议N为100到1000。key在key空间中分布越均匀(如包含哈希值、时间
+      或者序号)，估算越准确，分布不均匀时置信区间较宽。objects少于1000个时直接给出精确结果。
+      该用法不统计未完成上传的分片，不支持--all-versions和--top选项，-j指定并发列举的个数。
+
+--output选项
+
+    指定--output json或者--output yaml(或者全局的--output-format)时，第一种用法的统计结果以json或者
+    yaml格式输出到标准输出，大小的单位总是字节，不受--block-size影响，列举的进度输出到标准错误。
+    抽样估算不支持该选项。
 `,
 
 	sampleText: ` 
@@ -42,6 +63,15 @@ var specChineseDu = SpecText{
     
     4) 统计结果以KB为单位显示, 支持MB, GB, TB
        ossutil du oss://bucket/prefix --block-size KB
+
+    5) 查询指定前缀(目录)占用存储空间大小, 并显示最大的50个object
+       ossutil du oss://bucket/prefix --top 50
+
+    6) 通过500个抽样点估算指定前缀(目录)下objects的数量和大小
+       ossutil du oss://bucket/prefix --sample 500
+
+    7) 以json格式输出指定前缀(目录)的统计结果
+       ossutil du oss://bucket/prefix --output json
 `,
 }
 
@@ -59,10 +89,32 @@ var specEnglishDu = SpecText{
 
 Usages：
 
-    There is only one usage for this command:
+    There are two usages for this command:
 
     1) ossutil du oss://bucket[/prefix] [options]
-       Gets the bucket or the specified prefix(directory) storage size
+       Gets the bucket or the specified prefix(directory) storage size, the object count, size
+       and percentage of total size are shown by storage class. If --top N option is specified,
+       the N largest objects are shown too, which helps to find the objects costing most storage
+       (e.g., Archive storage bill).
+
+    2) ossutil du oss://bucket[/prefix] --sample N [-j jobs]
+       Estimates the object count and size of the bucket or the specified prefix(directory) without
+       listing all the objects, it returns in seconds for the prefix of hundreds of millions of
+       objects. It lists the objects in a window of the keyspace after each of the N random points
+       between the first and the last object, estimates the total count and size by the count and
+       size of the windows of the same width, and reports the 95% confidence interval. More samples make the estimate more
+       accurate, N is suggested to be 100 to 1000. The estimate is more accurate if the keys are spread
+       evenly in the keyspace(e.g., the keys contain the hashes, the time or the sequence numbers),
+       the confidence interval is wide otherwise. The result is exact if there are less than 1000
+       objects. The parts of the uncompleted uploads are not counted, --all-versions and --top are
+       not supported, -j specifies the concurrent listings.
+
+--output option
+
+    With --output json or --output yaml(or the global --output-format), the result of the first
+    usage is printed to stdout in json or yaml, the sizes are always in bytes regardless of
+    --block-size, the progress of the listing is printed to stderr. It is not supported by the
+    estimation of --sample.
 `,
 
 	sampleText: ` 
@@ -77,6 +129,15 @@ Usages：
 
     4) The du results are displayed in KB block size, Support MB, GB, TB
        ossutil du oss://bucket/prefix --block-size KB
+
+    5) get the prefix(directory) storage size, and show the 50 largest objects
+       ossutil du oss://bucket/prefix --top 50
+
+    6) estimate the object count and size of the prefix(directory) by 500 samples
+       ossutil du oss://bucket/prefix --sample 500
+
+    7) print the statistics of the prefix(directory) in json
+       ossutil du oss://bucket/prefix --output json
 `,
 }
 
@@ -98,6 +159,40 @@ type duSizeOptionType struct {
 	mutex            sync.Mutex
 	displayUnit      string
 	blockSize        int64
+	topNum           int64
+	topObjects       duTopObjectHeap
+}
+
+type duTopObject struct {
+	key          string
+	versionId    string
+	size         int64
+	storageClass string
+	lastModified time.Time
+}
+
+// duTopObjectHeap is a min heap of objects by size, it keeps the largest objects
+type duTopObjectHeap []duTopObject
+
+func (h duTopObjectHeap) Len() int { return len(h) }
+func (h duTopObjectHeap) Less(i, j int) bool {
+	if h[i].size != h[j].size {
+		return h[i].size < h[j].size
+	}
+	return h[i].key > h[j].key
+}
+func (h duTopObjectHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *duTopObjectHeap) Push(x interface{}) {
+	*h = append(*h, x.(duTopObject))
+}
+
+func (h *duTopObjectHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
 }
 
 type DuCommand struct {
@@ -107,13 +202,11 @@ type DuCommand struct {
 
 var duSizeCommand = DuCommand{
 	command: Command{
-		name:        "du",
-		nameAlias:   []string{"du"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseDu,
-		specEnglish: specEnglishDu,
-		group:       GroupTypeNormalCommand,
+		name:      "du",
+		nameAlias: []string{"du"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -128,6 +221,9 @@ var duSizeCommand = DuCommand{
 			OptionAllversions,
 			OptionPassword,
 			OptionBlockSize,
+			OptionTop,
+			OptionSample,
+			OptionRoutines,
 			OptionMode,
 			OptionECSRoleName,
 			OptionTokenTimeout,
@@ -135,6 +231,11 @@ var duSizeCommand = DuCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -142,10 +243,40 @@ var duSizeCommand = DuCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionOutput,
 		},
 	},
 }
 
+// duStorageClassEntry is the statistics of a storage class of du --output json
+type duStorageClassEntry struct {
+	StorageClass string  `json:"storageClass"`
+	ObjectCount  int64   `json:"objectCount"`
+	Size         int64   `json:"size"`
+	Percent      float64 `json:"percent"`
+}
+
+// duTopEntry is one of the largest objects of du --top --output json
+type duTopEntry struct {
+	URL          string    `json:"url"`
+	VersionId    string    `json:"versionId,omitempty"`
+	Size         int64     `json:"size"`
+	StorageClass string    `json:"storageClass"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// duResult is the result of du --output json, the sizes are in bytes regardless of --block-size
+type duResult struct {
+	URL            string                `json:"url"`
+	StorageClasses []duStorageClassEntry `json:"storageClasses"`
+	ObjectCount    int64                 `json:"objectCount"`
+	ObjectSize     int64                 `json:"objectSize"`
+	PartCount      int64                 `json:"partCount"`
+	PartSize       int64                 `json:"partSize"`
+	TotalSize      int64                 `json:"totalSize"`
+	TopObjects     []duTopEntry          `json:"topObjects,omitempty"`
+}
+
 // function for FormatHelper interface
 func (duc *DuCommand) formatHelpForWhole() string {
 	return duc.command.formatHelpForWhole()
@@ -156,8 +287,8 @@ func (duc *DuCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (duc *DuCommand) Init(args []string, options OptionMapType) error {
-	return duc.command.Init(args, options, duc)
+func (duc *DuCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return duc.command.Init(ctx, args, options, duc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -169,6 +300,14 @@ func (duc *DuCommand) RunCommand() error {
 	duc.duOption.sumObjectSize = 0
 	duc.duOption.totalPartCount = 0
 	duc.duOption.sumPartSize = 0
+	duc.duOption.topObjects = duTopObjectHeap{}
+	duc.duOption.topNum, _ = GetInt(OptionTop, duc.command.options)
+	output, err := getOutputFormat(duc.command.options)
+	if err != nil {
+		return err
+	}
+	// the progress is printed to stderr for the structured output
+	duc.command.stdout = output.textWriter()
 
 	blockSizeMap := make(map[string]int64)
 	blockSizeMap["byte"] = 1
@@ -205,6 +344,24 @@ func (duc *DuCommand) RunCommand() error {
 	duc.duOption.displayUnit = strBlockSize
 	duc.duOption.blockSize = blockSizeMap[strBlockSize]
 
+	strSample, _ := GetString(OptionSample, duc.command.options)
+	var sampleCount int64
+	if strSample != "" {
+		if allVersions || duc.duOption.topNum > 0 {
+			return fmt.Errorf("--sample doesn't work with --all-versions or --top")
+		}
+		var samplePercent float64
+		if samplePercent, sampleCount, err = parseSampleValue(strSample); err != nil {
+			return err
+		}
+		if samplePercent > 0 || sampleCount < 2 {
+			return fmt.Errorf("invalid sample value: %s, du only supports the number of the samples, it should be at least 2", strSample)
+		}
+		if output.structured() {
+			return fmt.Errorf("--sample doesn't work with --output %s", output)
+		}
+	}
+
 	duc.duOption.bucketName = srcBucketUrL.bucket
 	duc.duOption.object = srcBucketUrL.object
 	duc.duOption.payer = payer
@@ -212,6 +369,9 @@ func (duc *DuCommand) RunCommand() error {
 	if err != nil {
 		return err
 	}
+	if sampleCount > 0 {
+		return duc.sampleObjectSize(bucket, sampleCount)
+	}
 
 	// first:get all object size
 	if allVersions {
@@ -223,38 +383,32 @@ func (duc *DuCommand) RunCommand() error {
 	if err != nil {
 		return err
 	}
-
-	printHeader := false
-	for k, v := range duc.duOption.countTypeMap {
-		if !printHeader {
-			fmt.Printf("\r                                                                      ")
-			fmt.Printf("\r%-14s\t%-20s\t%-30s\n", "storage class", "object count", "sum size(byte)")
-			fmt.Printf("----------------------------------------------------------\n")
-			printHeader = true
+	if output.structured() {
+		if err = duc.GetAllPartSize(bucket); err != nil {
+			return err
 		}
-		fmt.Printf("%-14s\t%-20d\t%-30d\n", k, v, duc.duOption.sizeTypeMap[k])
-	}
-	if !printHeader {
-		fmt.Printf("\r")
-	} else {
-		fmt.Printf("----------------------------------------------------------\n")
+		fmt.Fprintf(duc.command.textOut(), "\r")
+		return output.print(duc.result())
 	}
-	fmt.Printf("%-20s%-20d\t%-23s%d\n", "total object count:", duc.duOption.totalObjectCount, "total object sum size:", duc.duOption.sumObjectSize)
+
+	duc.printStorageClassTable()
+	fmt.Fprintf(duc.command.textOut(), "%-20s%-20d\t%-23s%d\n", "total object count:", duc.duOption.totalObjectCount, "total object sum size:", duc.duOption.sumObjectSize)
+	duc.printTopObjects()
 
 	//second:get all part size
 	err = duc.GetAllPartSize(bucket)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("\r                                                                      ")
-	fmt.Printf("\r%-20s%-20d\t%-23s%d\n\n", "total part count:", duc.duOption.totalPartCount, "total part sum size:", duc.duOption.sumPartSize)
+	fmt.Fprintf(duc.command.textOut(), "\r                                                                      ")
+	fmt.Fprintf(duc.command.textOut(), "\r%-20s%-20d\t%-23s%d\n\n", "total part count:", duc.duOption.totalPartCount, "total part sum size:", duc.duOption.sumPartSize)
 
 	if duc.duOption.blockSize == int64(1) {
 		displaySize := (duc.duOption.sumObjectSize + duc.duOption.sumPartSize) / duc.duOption.blockSize
-		fmt.Printf("total du size(%s):%d\n", duc.duOption.displayUnit, displaySize)
+		fmt.Fprintf(duc.command.textOut(), "total du size(%s):%d\n", duc.duOption.displayUnit, displaySize)
 	} else {
 		displaySize := float64(duc.duOption.sumObjectSize+duc.duOption.sumPartSize) / float64(duc.duOption.blockSize)
-		fmt.Printf("total du size(%s):%.4f\n", duc.duOption.displayUnit, displaySize)
+		fmt.Fprintf(duc.command.textOut(), "total du size(%s):%.4f\n", duc.duOption.displayUnit, displaySize)
 	}
 	return nil
 }
@@ -276,6 +430,7 @@ func (duc *DuCommand) getAllObjectSize(bucket *oss.Bucket) error {
 		duc.duOption.totalObjectCount += int64(len(lor.Objects))
 		for _, object := range lor.Objects {
 			duc.duOption.sumObjectSize += object.Size
+			duc.addTopObject(duTopObject{object.Key, "", object.Size, object.StorageClass, object.LastModified})
 			if _, ok := duc.duOption.countTypeMap[object.StorageClass]; ok {
 				duc.duOption.countTypeMap[object.StorageClass]++
 				duc.duOption.sizeTypeMap[object.StorageClass] += object.Size
@@ -285,7 +440,7 @@ func (duc *DuCommand) getAllObjectSize(bucket *oss.Bucket) error {
 			}
 		}
 
-		fmt.Printf("\robject count:%d\tobject sum size:%d", duc.duOption.totalObjectCount, duc.duOption.sumObjectSize)
+		fmt.Fprintf(duc.command.textOut(), "\robject count:%d\tobject sum size:%d", duc.duOption.totalObjectCount, duc.duOption.sumObjectSize)
 
 		pre = oss.Prefix(lor.Prefix)
 		marker = oss.Marker(lor.NextMarker)
@@ -312,13 +467,14 @@ func (duc *DuCommand) getAllObjectVersionsSize(bucket *oss.Bucket) error {
 	}
 
 	for {
-		lor, err := bucket.ListObjectVersions(listOptions...)
+		lor, err := bucket.ListObjectVersions(duc.command.withContext(listOptions)...)
 		if err != nil {
 			return err
 		}
 		duc.duOption.totalObjectCount += int64(len(lor.ObjectVersions))
 		for _, object := range lor.ObjectVersions {
 			duc.duOption.sumObjectSize += object.Size
+			duc.addTopObject(duTopObject{object.Key, object.VersionId, object.Size, object.StorageClass, object.LastModified})
 			if _, ok := duc.duOption.countTypeMap[object.StorageClass]; ok {
 				duc.duOption.countTypeMap[object.StorageClass]++
 				duc.duOption.sizeTypeMap[object.StorageClass] += object.Size
@@ -327,7 +483,7 @@ func (duc *DuCommand) getAllObjectVersionsSize(bucket *oss.Bucket) error {
 				duc.duOption.sizeTypeMap[object.StorageClass] = object.Size
 			}
 		}
-		fmt.Printf("\robject count:%d\tobject sum size:%d", duc.duOption.totalObjectCount, duc.duOption.sumObjectSize)
+		fmt.Fprintf(duc.command.textOut(), "\robject count:%d\tobject sum size:%d", duc.duOption.totalObjectCount, duc.duOption.sumObjectSize)
 		keyMarker = oss.KeyMarker(lor.NextKeyMarker)
 		versionIdMarker := oss.VersionIdMarker(lor.NextVersionIdMarker)
 		listOptions = []oss.Option{pre, keyMarker, versionIdMarker, oss.MaxKeys(1000)}
@@ -341,6 +497,117 @@ func (duc *DuCommand) getAllObjectVersionsSize(bucket *oss.Bucket) error {
 	return nil
 }
 
+func (duc *DuCommand) addTopObject(object duTopObject) {
+	if duc.duOption.topNum <= 0 {
+		return
+	}
+	if int64(duc.duOption.topObjects.Len()) < duc.duOption.topNum {
+		heap.Push(&duc.duOption.topObjects, object)
+	} else if object.size > duc.duOption.topObjects[0].size {
+		duc.duOption.topObjects[0] = object
+		heap.Fix(&duc.duOption.topObjects, 0)
+	}
+}
+
+// formatSize formats the size by the block size
+func (duc *DuCommand) formatSize(size int64) string {
+	if duc.duOption.blockSize <= int64(1) {
+		return strconv.FormatInt(size, 10)
+	}
+	return fmt.Sprintf("%.4f", float64(size)/float64(duc.duOption.blockSize))
+}
+
+func (duc *DuCommand) printStorageClassTable() {
+	fmt.Fprintf(duc.command.textOut(), "\r                                                                      ")
+	if len(duc.duOption.countTypeMap) == 0 {
+		fmt.Fprintf(duc.command.textOut(), "\r")
+		return
+	}
+
+	storageClasses := []string{}
+	for k := range duc.duOption.countTypeMap {
+		storageClasses = append(storageClasses, k)
+	}
+	sort.Strings(storageClasses)
+
+	sizeTitle := "sum size(" + duc.duOption.displayUnit + ")"
+	fmt.Fprintf(duc.command.textOut(), "\r%-14s\t%-20s\t%-30s\t%s\n", "storage class", "object count", sizeTitle, "percent")
+	fmt.Fprintf(duc.command.textOut(), "--------------------------------------------------------------------------------\n")
+	for _, k := range storageClasses {
+		percent := float64(0)
+		if duc.duOption.sumObjectSize > 0 {
+			percent = float64(duc.duOption.sizeTypeMap[k]) * 100 / float64(duc.duOption.sumObjectSize)
+		}
+		fmt.Fprintf(duc.command.textOut(), "%-14s\t%-20d\t%-30s\t%.2f%%\n", k, duc.duOption.countTypeMap[k], duc.formatSize(duc.duOption.sizeTypeMap[k]), percent)
+	}
+	fmt.Fprintf(duc.command.textOut(), "--------------------------------------------------------------------------------\n")
+}
+
+// result returns the statistics of du for the structured output
+func (duc *DuCommand) result() duResult {
+	result := duResult{
+		URL:            CloudURLToString(duc.duOption.bucketName, duc.duOption.object),
+		StorageClasses: []duStorageClassEntry{},
+		ObjectCount:    duc.duOption.totalObjectCount,
+		ObjectSize:     duc.duOption.sumObjectSize,
+		PartCount:      duc.duOption.totalPartCount,
+		PartSize:       duc.duOption.sumPartSize,
+		TotalSize:      duc.duOption.sumObjectSize + duc.duOption.sumPartSize,
+	}
+	for storageClass, count := range duc.duOption.countTypeMap {
+		entry := duStorageClassEntry{StorageClass: storageClass, ObjectCount: count, Size: duc.duOption.sizeTypeMap[storageClass]}
+		if duc.duOption.sumObjectSize > 0 {
+			entry.Percent = math.Round(float64(entry.Size)*10000/float64(duc.duOption.sumObjectSize)) / 100
+		}
+		result.StorageClasses = append(result.StorageClasses, entry)
+	}
+	sort.Slice(result.StorageClasses, func(i, j int) bool {
+		return result.StorageClasses[i].StorageClass < result.StorageClasses[j].StorageClass
+	})
+	if duc.duOption.topNum > 0 {
+		for _, object := range duc.sortedTopObjects() {
+			result.TopObjects = append(result.TopObjects, duTopEntry{
+				URL:          CloudURLToString(duc.duOption.bucketName, object.key),
+				VersionId:    object.versionId,
+				Size:         object.size,
+				StorageClass: object.storageClass,
+				LastModified: object.lastModified,
+			})
+		}
+	}
+	return result
+}
+
+// sortedTopObjects returns the top objects from largest to smallest
+func (duc *DuCommand) sortedTopObjects() []duTopObject {
+	objects := append([]duTopObject{}, duc.duOption.topObjects...)
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].size != objects[j].size {
+			return objects[i].size > objects[j].size
+		}
+		return objects[i].key < objects[j].key
+	})
+	return objects
+}
+
+func (duc *DuCommand) printTopObjects() {
+	if duc.duOption.topNum <= 0 {
+		return
+	}
+
+	objects := duc.sortedTopObjects()
+	fmt.Fprintf(duc.command.textOut(), "\ntop %d largest objects:\n", len(objects))
+	fmt.Fprintf(duc.command.textOut(), "%-6s%-20s%-14s%-30s%s\n", "rank", "size("+duc.duOption.displayUnit+")", "storage class", "LastModifiedTime", "ObjectName")
+	for i, object := range objects {
+		name := CloudURLToString(duc.duOption.bucketName, object.key)
+		if object.versionId != "" {
+			name += " (versionId:" + object.versionId + ")"
+		}
+		fmt.Fprintf(duc.command.textOut(), "%-6d%-20s%-14s%-30s%s\n", i+1, duc.formatSize(object.size), object.storageClass, utcToLocalTime(object.lastModified), name)
+	}
+	fmt.Fprintln(duc.command.textOut())
+}
+
 func (duc *DuCommand) GetAllPartSize(bucket *oss.Bucket) error {
 	routineCount := runtime.NumCPU()
 	chObjects := make(chan MultiPartObject, ChannelBuf)
@@ -382,8 +649,7 @@ func (duc *DuCommand) statPartSize(bucket *oss.Bucket, object MultiPartObject) e
 
 		lpRes, err := bucket.ListUploadedParts(imur, lpOptions...)
 		if err != nil {
-			serviceError, ok := err.(oss.ServiceError)
-			if ok && serviceError.StatusCode == 404 {
+			if isNotFound(err) {
 				// ignore 404 error; the uploadid maybe abort or completed
 				return nil
 			} else {
@@ -395,7 +661,7 @@ func (duc *DuCommand) statPartSize(bucket *oss.Bucket, object MultiPartObject) e
 			for _, v := range lpRes.UploadedParts {
 				duc.duOption.sumPartSize += int64(v.Size)
 			}
-			fmt.Printf("\rpart count:%d\tpart sum size:%d", duc.duOption.totalPartCount, duc.duOption.sumPartSize)
+			fmt.Fprintf(duc.command.textOut(), "\rpart count:%d\tpart sum size:%d", duc.duOption.totalPartCount, duc.duOption.sumPartSize)
 			duc.duOption.mutex.Unlock()
 		}
 
@@ -0,0 +1,290 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// the keys are mapped to the positions in [0, 1) of the keyspace by their first duSampleDigits
+// bytes after the prefix, each byte is a digit of base duSampleBase from the space to the end of ascii
+const (
+	duSampleBase      = 96
+	duSampleDigits    = 24
+	duSamplePrecision = 256
+	duSamplePageSize  = 1000
+	duSampleZ95       = 1.96
+	// the last key is searched until the gap is less than 1/2^duSampleSearchBits of the keyspace
+	duSampleSearchBits = 20
+)
+
+// duSampleSeed returns the seed of the random points, it's replaced in the tests
+var duSampleSeed = func() int64 { return time.Now().UnixNano() }
+
+// duSample is the result of listing the keys in a window of the keyspace
+type duSample struct {
+	count int64
+	size  int64
+}
+
+// keyPosition returns the position of the key in the keyspace
+func keyPosition(key string) *big.Float {
+	pos := new(big.Float).SetPrec(duSamplePrecision)
+	scale := new(big.Float).SetPrec(duSamplePrecision).SetInt64(1)
+	base := new(big.Float).SetPrec(duSamplePrecision).SetInt64(duSampleBase)
+	for i := 0; i < duSampleDigits && i < len(key); i++ {
+		digit := int64(key[i]) - ' '
+		if digit < 0 {
+			digit = 0
+		} else if digit >= duSampleBase {
+			digit = duSampleBase - 1
+		}
+		scale.Quo(scale, base)
+		pos.Add(pos, new(big.Float).SetPrec(duSamplePrecision).Mul(scale, new(big.Float).SetInt64(digit)))
+	}
+	return pos
+}
+
+// positionKey returns the key of the position in the keyspace, it only contains the printable ascii
+// characters so that it's a valid marker
+func positionKey(pos *big.Float) string {
+	frac := new(big.Float).SetPrec(duSamplePrecision).Set(pos)
+	base := new(big.Float).SetPrec(duSamplePrecision).SetInt64(duSampleBase)
+	var key []byte
+	for i := 0; i < duSampleDigits; i++ {
+		frac.Mul(frac, base)
+		digit, _ := frac.Int64()
+		frac.Sub(frac, new(big.Float).SetInt64(digit))
+		if digit > duSampleBase-2 {
+			digit = duSampleBase - 2
+		}
+		key = append(key, byte(' '+digit))
+	}
+	return strings.TrimRight(string(key), " ")
+}
+
+// sampleObjectSize estimates the object count and the size under the prefix by listing the windows
+// of the same width after the random points of the keyspace, instead of listing all the objects.
+// The width of the windows is decided by the densities of the pilot pages, the total is the average
+// of the windows multiplied by the number of the windows covering the keyspace
+func (duc *DuCommand) sampleObjectSize(bucket *oss.Bucket, samples int64) error {
+	startTime := time.Now()
+	prefix := duc.duOption.object
+	var payerOptions []oss.Option
+	if duc.duOption.payer != "" {
+		payerOptions = append(payerOptions, oss.RequestPayer(oss.PayerType(duc.duOption.payer)))
+	}
+	listAfter := func(marker string, maxKeys int) (oss.ListObjectsResult, error) {
+		listOptions := append([]oss.Option{oss.Prefix(prefix), oss.Marker(marker), oss.MaxKeys(maxKeys)}, payerOptions...)
+		return duc.command.ossListObjectsRetry(bucket, listOptions...)
+	}
+
+	lor, err := listAfter("", duSamplePageSize)
+	if err != nil {
+		return err
+	}
+	if !lor.IsTruncated {
+		var size int64
+		for _, object := range lor.Objects {
+			size += object.Size
+		}
+		fmt.Printf("the prefix has less than %d objects, the result is exact\n", duSamplePageSize)
+		fmt.Printf("%-20s%-20d\t%-23s%s\n", "total object count:", len(lor.Objects), "total object sum size:", duc.formatSize(size))
+		return nil
+	}
+
+	low := keyPosition(strings.TrimPrefix(lor.Objects[0].Key, prefix))
+	high, err := duc.lastKeyPosition(listAfter, low, lor.Objects[len(lor.Objects)-1].Key)
+	if err != nil {
+		return err
+	}
+	keyspace := new(big.Float).Sub(high, low)
+	r := rand.New(rand.NewSource(duSampleSeed()))
+	randomPoints := func(num int64, from, width *big.Float) []*big.Float {
+		points := make([]*big.Float, 0, num)
+		for i := int64(0); i < num; i++ {
+			point := new(big.Float).SetPrec(duSamplePrecision).Mul(width, big.NewFloat(r.Float64()))
+			points = append(points, point.Add(point, from))
+		}
+		return points
+	}
+
+	// the pilot pages decide the width of the windows, so that a window in the dense parts of the
+	// keyspace has about half a page of keys
+	var densities []float64
+	var mu sync.Mutex
+	err = duc.listSamples(randomPoints(samples/4+1, low, keyspace), func(point *big.Float) error {
+		marker := positionKey(point)
+		lor, err := listAfter(prefix+marker, duSamplePageSize)
+		if err != nil || !lor.IsTruncated {
+			return err
+		}
+		end := keyPosition(strings.TrimPrefix(lor.Objects[len(lor.Objects)-1].Key, prefix))
+		width, _ := end.Sub(end, keyPosition(marker)).Float64()
+		if width > 0 {
+			mu.Lock()
+			densities = append(densities, float64(len(lor.Objects))/width)
+			mu.Unlock()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	window := new(big.Float).SetPrec(duSamplePrecision).Set(keyspace)
+	if len(densities) > 0 {
+		sort.Float64s(densities)
+		window.SetFloat64(duSamplePageSize / 2 / densities[len(densities)*9/10])
+		if window.Cmp(keyspace) > 0 {
+			window.Set(keyspace)
+		}
+	}
+
+	// the key is in the window of the point if the point is in the window before the key, so the
+	// points are chosen from one window before the first key to the last key
+	from := new(big.Float).SetPrec(duSamplePrecision).Sub(low, window)
+	span := new(big.Float).SetPrec(duSamplePrecision).Add(keyspace, window)
+	var results []duSample
+	listedNum := int64(0)
+	err = duc.listSamples(randomPoints(samples, from, span), func(point *big.Float) error {
+		end := new(big.Float).SetPrec(duSamplePrecision).Add(point, window)
+		marker := ""
+		if point.Cmp(low) >= 0 {
+			marker = prefix + positionKey(point)
+		}
+		var sample duSample
+		var listed int64
+		for {
+			lor, err := listAfter(marker, duSamplePageSize)
+			if err != nil {
+				return err
+			}
+			listed += int64(len(lor.Objects))
+			inWindow := true
+			for _, object := range lor.Objects {
+				pos := keyPosition(strings.TrimPrefix(object.Key, prefix))
+				if pos.Cmp(end) > 0 {
+					inWindow = false
+					break
+				}
+				if pos.Cmp(point) > 0 {
+					sample.count++
+					sample.size += object.Size
+				}
+			}
+			if !inWindow || !lor.IsTruncated {
+				break
+			}
+			marker = lor.NextMarker
+			if marker == "" {
+				marker = lor.Objects[len(lor.Objects)-1].Key
+			}
+		}
+		mu.Lock()
+		results = append(results, sample)
+		listedNum += listed
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	windows, _ := new(big.Float).Quo(span, window).Float64()
+	count, countMargin := estimateTotal(results, windows, func(sample duSample) float64 { return float64(sample.count) })
+	size, sizeMargin := estimateTotal(results, windows, func(sample duSample) float64 { return float64(sample.size) })
+
+	fmt.Printf("sampled %d points of the keyspace, listed %d objects, cost %.1fs\n", len(results), listedNum, time.Since(startTime).Seconds())
+	fmt.Printf("%-32s%d\t(95%% confidence interval: %d - %d)\n", "estimated object count:",
+		int64(count), int64(math.Max(count-countMargin, 0)), int64(count+countMargin))
+	fmt.Printf("%-32s%s\t(95%% confidence interval: %s - %s)\n", "estimated object sum size("+duc.duOption.displayUnit+"):",
+		duc.formatSize(int64(size)), duc.formatSize(int64(math.Max(size-sizeMargin, 0))), duc.formatSize(int64(size+sizeMargin)))
+	fmt.Printf("the parts of the uncompleted uploads are not counted in the sample mode\n")
+	return nil
+}
+
+// listSamples lists the points concurrently by the routines, it returns the first error
+func (duc *DuCommand) listSamples(points []*big.Float, list func(point *big.Float) error) error {
+	routines, _ := GetInt(OptionRoutines, duc.command.options)
+	if routines <= 0 {
+		routines = int64(Routines)
+	}
+	chPoints := make(chan *big.Float, len(points))
+	for _, point := range points {
+		chPoints <- point
+	}
+	close(chPoints)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var listErr error
+	for i := 0; int64(i) < routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for point := range chPoints {
+				if err := list(point); err != nil {
+					mu.Lock()
+					listErr = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return listErr
+}
+
+// lastKeyPosition searches the position of the last key by listing one object after the points,
+// the position is found if the gap between the key found and the point without keys is small enough
+// compared with the width from the first key
+func (duc *DuCommand) lastKeyPosition(listAfter func(marker string, maxKeys int) (oss.ListObjectsResult, error), low *big.Float, knownKey string) (*big.Float, error) {
+	prefix := duc.duOption.object
+	found := keyPosition(strings.TrimPrefix(knownKey, prefix))
+	empty := new(big.Float).SetPrec(duSamplePrecision).SetInt64(1)
+	for {
+		threshold := new(big.Float).SetPrec(duSamplePrecision).Sub(found, low)
+		threshold.SetMantExp(threshold, -duSampleSearchBits)
+		if new(big.Float).Sub(empty, found).Cmp(threshold) <= 0 {
+			break
+		}
+		mid := new(big.Float).SetPrec(duSamplePrecision).Add(found, empty)
+		mid.Quo(mid, big.NewFloat(2))
+		lor, err := listAfter(prefix+positionKey(mid), 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(lor.Objects) == 0 {
+			empty = mid
+			continue
+		}
+		next := keyPosition(strings.TrimPrefix(lor.Objects[0].Key, prefix))
+		if next.Cmp(found) <= 0 {
+			// the keys differ beyond the digits of the positions
+			break
+		}
+		found = next
+	}
+	return found, nil
+}
+
+// estimateTotal returns the estimate of the total and the margin of the 95% confidence interval,
+// the total is the average of the samples multiplied by the number of the windows
+func estimateTotal(samples []duSample, windows float64, value func(sample duSample) float64) (float64, float64) {
+	n := float64(len(samples))
+	var sum, sumSquares float64
+	for _, sample := range samples {
+		sum += value(sample)
+		sumSquares += value(sample) * value(sample)
+	}
+	mean := sum / n
+	variance := math.Max((sumSquares-n*mean*mean)/(n-1), 0)
+	return mean * windows, duSampleZ95 * math.Sqrt(variance/n) * windows
+}
@@ -1,7 +1,14 @@
 package lib
 
 import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -475,3 +482,140 @@ func (s *OssutilCommandSuite) TestUploadIdProducer(c *C) {
 		c.Assert(true, Equals, false)
 	}
 }
+
+func (s *OssutilCommandSuite) TestDuTopObjects(c *C) {
+	duc := DuCommand{}
+	duc.duOption.topNum = 3
+	duc.duOption.blockSize = 1
+	sizes := map[string]int64{"a": 10, "b": 50, "c": 5, "d": 50, "e": 30, "f": 1}
+	for key, size := range sizes {
+		duc.addTopObject(duTopObject{key: key, size: size, storageClass: StorageArchive})
+	}
+
+	objects := duc.sortedTopObjects()
+	c.Assert(len(objects), Equals, 3)
+	c.Assert(objects[0].key, Equals, "b")
+	c.Assert(objects[1].key, Equals, "d")
+	c.Assert(objects[2].key, Equals, "e")
+
+	// top is disabled by default
+	duc = DuCommand{}
+	duc.addTopObject(duTopObject{key: "a", size: 1})
+	c.Assert(len(duc.sortedTopObjects()), Equals, 0)
+
+	duc.duOption.blockSize = 1024
+	c.Assert(duc.formatSize(1536), Equals, "1.5000")
+}
+
+func (s *OssutilCommandSuite) TestDuTopWithStorageClass(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	fileName := "test-file-" + randStr(5)
+	s.createFile(fileName, randLowStr(1024), c)
+	s.putObject(bucketName, "small", uploadFileName, c)
+	s.putObject(bucketName, "big", fileName, c)
+
+	str := ""
+	top := "1"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &ConfigFile,
+		"top":             &top,
+	}
+	_, err := cm.RunCommand("du", []string{CloudURLToString(bucketName, "")}, options)
+	c.Assert(err, IsNil)
+
+	objects := duSizeCommand.sortedTopObjects()
+	c.Assert(len(objects), Equals, 1)
+	c.Assert(objects[0].key, Equals, "big")
+	c.Assert(objects[0].size, Equals, int64(1024))
+	c.Assert(duSizeCommand.duOption.countTypeMap[StorageStandard], Equals, int64(2))
+
+	os.Remove(fileName)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestDuSample(c *C) {
+	r := rand.New(rand.NewSource(1))
+	keys := []string{}
+	for i := 0; i < 20000; i++ {
+		keys = append(keys, fmt.Sprintf("data/%08x", r.Uint32()))
+	}
+	sort.Strings(keys)
+	listNum := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		listNum++
+		maxKeys, _ := strconv.Atoi(query.Get("max-keys"))
+		start := sort.SearchStrings(keys, query.Get("marker"))
+		if start < len(keys) && keys[start] == query.Get("marker") {
+			start++
+		}
+		end := start + maxKeys
+		if end > len(keys) {
+			end = len(keys)
+		}
+		fmt.Fprintf(w, `<ListBucketResult><IsTruncated>%t</IsTruncated>`, end < len(keys))
+		for _, key := range keys[start:end] {
+			fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>100</Size></Contents>`, key)
+		}
+		fmt.Fprint(w, `</ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	resultPath := "ossutil-test-du-sample-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	sample := "200"
+	duSampleSeed = func() int64 { return 1 }
+	defer func() { duSampleSeed = func() int64 { return time.Now().UnixNano() } }()
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionSample:          &sample,
+	}
+	_, err = cm.RunCommand("du", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+	// about one listing for each point besides the pilot pages and the search of the last key
+	c.Assert(listNum < 2*200, Equals, true)
+	data, err := ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	var count, low, high int64
+	pos := strings.Index(string(data), "estimated object count:")
+	c.Assert(pos >= 0, Equals, true)
+	_, err = fmt.Sscanf(strings.TrimSpace(string(data)[pos+len("estimated object count:"):]), "%d\t(95%% confidence interval: %d - %d)", &count, &low, &high)
+	c.Assert(err, IsNil)
+	c.Assert(count > 10000 && count < 30000, Equals, true, Commentf("%s", string(data)))
+	c.Assert(low <= 20000 && 20000 <= high, Equals, true, Commentf("%s", string(data)))
+
+	// the result is exact for the small prefix
+	keys = keys[:10]
+	_, err = cm.RunCommand("du", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+	data, err = ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "the result is exact"), Equals, true)
+
+	sample = "1%"
+	_, err = cm.RunCommand("du", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, NotNil)
+
+	// the positions of the keyspace
+	c.Assert(positionKey(keyPosition("abc~")), Equals, "abc~")
+	c.Assert(keyPosition("abc").Cmp(keyPosition("abd")) < 0, Equals, true)
+}
@@ -0,0 +1,79 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the url schemes selecting the internal endpoint or the accelerate endpoint of the bucket,
+// they are the same as oss:// except the endpoint
+const (
+	InternalSchemePrefix   string = "oss-internal://"
+	AccelerateSchemePrefix string = "oss-acc://"
+)
+
+const accelerateEndpointHost = "oss-accelerate.aliyuncs.com"
+
+// endpointAliasPrefix returns the alias scheme of the url, or empty string if it's not an alias url
+func endpointAliasPrefix(urlStr string) string {
+	lower := strings.ToLower(urlStr)
+	for _, prefix := range []string{InternalSchemePrefix, AccelerateSchemePrefix} {
+		if strings.HasPrefix(lower, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// parseEndpointAliases records the alias schemes of the buckets in the arguments, so that the
+// clients of these buckets use the internal or accelerate endpoint even if they are created by
+// the bucket name. A bucket can't be referenced by different alias schemes
+func (cmd *Command) parseEndpointAliases() error {
+	cmd.aliasSchemes = map[string]string{}
+	for _, arg := range cmd.args {
+		prefix := endpointAliasPrefix(arg)
+		if prefix == "" {
+			continue
+		}
+		bucket := strings.SplitN(arg[len(prefix):], "/", 2)[0]
+		if bucket == "" {
+			continue
+		}
+		if old, ok := cmd.aliasSchemes[bucket]; ok && old != prefix {
+			return fmt.Errorf("bucket %s is referenced by both %s and %s, only one endpoint can be used for a bucket", bucket, old, prefix)
+		}
+		cmd.aliasSchemes[bucket] = prefix
+	}
+	return nil
+}
+
+// aliasEndpoint derives the internal or accelerate endpoint from the endpoint of the bucket,
+// e.g., oss-cn-hangzhou.aliyuncs.com to oss-cn-hangzhou-internal.aliyuncs.com or
+// oss-accelerate.aliyuncs.com, the protocol of the endpoint is kept
+func aliasEndpoint(endpoint, prefix string) (string, error) {
+	protocol := ""
+	host := endpoint
+	if pos := strings.Index(endpoint, "://"); pos >= 0 {
+		protocol, host = endpoint[:pos+3], endpoint[pos+3:]
+	}
+	host = strings.TrimSuffix(host, "/")
+	if !strings.HasPrefix(host, "oss-") || !strings.HasSuffix(host, ".aliyuncs.com") {
+		return "", fmt.Errorf("can't get the endpoint of %s from endpoint %s, it should be like oss-cn-hangzhou.aliyuncs.com", prefix, endpoint)
+	}
+
+	if prefix == AccelerateSchemePrefix {
+		if strings.HasPrefix(host, "oss-accelerate") {
+			return endpoint, nil
+		}
+		return protocol + accelerateEndpointHost, nil
+	}
+
+	region := strings.TrimSuffix(host, ".aliyuncs.com")
+	if strings.HasSuffix(region, "-internal") {
+		return endpoint, nil
+	}
+	if strings.HasPrefix(region, "oss-accelerate") {
+		return "", fmt.Errorf("can't get the endpoint of %s from the accelerate endpoint %s", prefix, endpoint)
+	}
+	return protocol + region + "-internal.aliyuncs.com", nil
+}
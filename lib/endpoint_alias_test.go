@@ -0,0 +1,83 @@
+package lib
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestEndpointAliasURL(c *C) {
+	bucketName := "internal-bucket-" + randLowStr(8)
+	cloudURL, err := CloudURLFromString("oss-internal://"+bucketName+"/dir/object", "")
+	c.Assert(err, IsNil)
+	c.Assert(cloudURL.bucket, Equals, bucketName)
+	c.Assert(cloudURL.object, Equals, "dir/object")
+	c.Assert(cloudURL.scheme, Equals, InternalSchemePrefix)
+	c.Assert(cloudURL.ToString(), Equals, "oss-internal://"+bucketName+"/dir/object")
+	c.Assert(cloudURL.objectURL("other"), Equals, "oss-internal://"+bucketName+"/other")
+
+	// the scheme belongs to the url, the same bucket can be referenced by other schemes elsewhere
+	plainURL, err := CloudURLFromString("oss://"+bucketName+"/object", "")
+	c.Assert(err, IsNil)
+	c.Assert(plainURL.ToString(), Equals, "oss://"+bucketName+"/object")
+
+	accBucketName := "acc-bucket-" + randLowStr(8)
+	storageURL, err := StorageURLFromString("OSS-ACC://"+accBucketName, "")
+	c.Assert(err, IsNil)
+	c.Assert(storageURL.IsCloudURL(), Equals, true)
+	c.Assert(storageURL.(CloudURL).scheme, Equals, AccelerateSchemePrefix)
+
+	endpoint := "https://oss-cn-hangzhou.aliyuncs.com"
+	str := "ak"
+	cmd := Command{options: OptionMapType{OptionEndpoint: &endpoint, OptionAccessKeyID: &str, OptionAccessKeySecret: &str}, configOptions: OptionMapType{}}
+	client, err := cmd.cloudClient(cloudURL)
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, "https://oss-cn-hangzhou-internal.aliyuncs.com")
+	client, err = cmd.cloudClient(storageURL.(CloudURL))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, "https://oss-accelerate.aliyuncs.com")
+	client, err = cmd.cloudClient(plainURL)
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, endpoint)
+
+	// the clients created by the bucket name use the schemes of the arguments of the command
+	cmd.args = []string{"oss-internal://" + bucketName, "oss-acc://" + accBucketName + "/dir/"}
+	c.Assert(cmd.parseEndpointAliases(), IsNil)
+	client, err = cmd.ossClient(bucketName)
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, "https://oss-cn-hangzhou-internal.aliyuncs.com")
+	client, err = cmd.ossClient(accBucketName)
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, "https://oss-accelerate.aliyuncs.com")
+	client, err = cmd.ossClient("oss-bucket-" + randLowStr(8))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, endpoint)
+
+	// another command isn't affected
+	other := Command{options: cmd.options, configOptions: OptionMapType{}}
+	client, err = other.ossClient(bucketName)
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, endpoint)
+
+	// the bucket can't be referenced by different alias schemes in a command
+	cmd.args = []string{"oss-internal://" + bucketName, "oss-acc://" + bucketName}
+	c.Assert(cmd.parseEndpointAliases(), NotNil)
+}
+
+func (s *OssutilCommandSuite) TestAliasEndpoint(c *C) {
+	endpoint, err := aliasEndpoint("oss-cn-beijing.aliyuncs.com", InternalSchemePrefix)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "oss-cn-beijing-internal.aliyuncs.com")
+	endpoint, err = aliasEndpoint("http://oss-cn-beijing-internal.aliyuncs.com", InternalSchemePrefix)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "http://oss-cn-beijing-internal.aliyuncs.com")
+	endpoint, err = aliasEndpoint("http://oss-cn-beijing-internal.aliyuncs.com", AccelerateSchemePrefix)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "http://oss-accelerate.aliyuncs.com")
+	endpoint, err = aliasEndpoint("oss-accelerate-overseas.aliyuncs.com", AccelerateSchemePrefix)
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "oss-accelerate-overseas.aliyuncs.com")
+
+	_, err = aliasEndpoint("oss-accelerate.aliyuncs.com", InternalSchemePrefix)
+	c.Assert(err, NotNil)
+	_, err = aliasEndpoint("127.0.0.1:8080", AccelerateSchemePrefix)
+	c.Assert(err, NotNil)
+}
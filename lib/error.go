@@ -1,7 +1,21 @@
 package lib
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// the exit codes of the error kinds, so that the scripts can tell them without parsing the messages
+const (
+	ExitCodeNotFound           = 4
+	ExitCodeAccessDenied       = 5
+	ExitCodePreconditionFailed = 6
+	ExitCodeThrottled          = 7
+	ExitCodePositionConflict   = 8
 )
 
 // CommandError happens when use command in invalid way
@@ -24,6 +38,11 @@ func (e BucketError) Error() string {
 	return fmt.Sprintf("%s, Bucket=%s", e.err.Error(), e.bucket)
 }
 
+// Unwrap returns the typed error of the oss error code if the error is returned by oss
+func (e BucketError) Unwrap() error {
+	return wrapServiceError(e.err)
+}
+
 // ObjectError happens when access object error
 type ObjectError struct {
 	err    error
@@ -35,6 +54,11 @@ func (e ObjectError) Error() string {
 	return fmt.Sprintf("%s, Bucket=%s, Object=%s", e.err.Error(), e.bucket, e.object)
 }
 
+// Unwrap returns the typed error of the oss error code if the error is returned by oss
+func (e ObjectError) Unwrap() error {
+	return wrapServiceError(e.err)
+}
+
 // FileError happens when access file error
 type FileError struct {
 	err  error
@@ -45,6 +69,104 @@ func (e FileError) Error() string {
 	return fmt.Sprintf("%s, File=%s", e.err.Error(), e.file)
 }
 
+// Unwrap returns the typed error of the oss error code if the error is returned by oss
+func (e FileError) Unwrap() error {
+	return wrapServiceError(e.err)
+}
+
+// PreconditionError happens when the object doesn't meet the conditions
+type PreconditionError struct {
+	bucket string
+	object string
+	reason string
+}
+
+func (e PreconditionError) Error() string {
+	return fmt.Sprintf("precondition failed, %s, Bucket=%s, Object=%s", e.reason, e.bucket, e.object)
+}
+
+// ForbidOverwriteError happens when the destination object exists and --forbid-overwrite is specified
+type ForbidOverwriteError struct {
+	err error
+}
+
+func (e ForbidOverwriteError) Error() string {
+	return fmt.Sprintf("the destination object already exists and overwriting is forbidden by --forbid-overwrite, %s", e.err.Error())
+}
+
+func (e ForbidOverwriteError) Unwrap() error {
+	return wrapServiceError(e.err)
+}
+
+// BudgetExceededError happens when --max-duration or --retry-budget is exceeded
+type BudgetExceededError struct {
+	reason string
+	remain int64
+}
+
+func (e BudgetExceededError) Error() string {
+	return fmt.Sprintf("%s, %d files(directories) are not started, the progress is kept in checkpoint, run the same command again to continue", e.reason, e.remain)
+}
+
+// DiskSpaceError happens when the bytes to be downloaded exceed the free space or --max-disk-usage
+type DiskSpaceError struct {
+	path     string
+	need     int64
+	limit    int64
+	maxUsage bool
+}
+
+func (e DiskSpaceError) Error() string {
+	if e.maxUsage {
+		return fmt.Sprintf("%d bytes are needed to download to %s, which exceeds --max-disk-usage %d", e.need, e.path, e.limit)
+	}
+	return fmt.Sprintf("not enough disk space in %s, %d bytes are needed but only %d bytes are available", e.path, e.need, e.limit)
+}
+
+// SafetyCapError happens when the objects of a recursive operation exceed --max-objects or --max-bytes
+type SafetyCapError struct {
+	option string
+	limit  int64
+	count  int64
+}
+
+func (e SafetyCapError) Error() string {
+	unit := "objects"
+	if e.option == "--max-bytes" {
+		unit = "bytes"
+	}
+	return fmt.Sprintf("at least %d %s are to be operated, which exceeds %s %d, please check the url or raise the limit", e.count, unit, e.option, e.limit)
+}
+
+// FileLockedError happens when the lock file is held by another ossutil process
+type FileLockedError struct {
+	path   string
+	holder string
+}
+
+func (e FileLockedError) Error() string {
+	return fmt.Sprintf("%s is locked by another ossutil process(%s)", e.path, e.holder)
+}
+
+// InterruptedError happens when the command is stopped by Ctrl-C or SIGTERM
+type InterruptedError struct {
+	remain int64
+}
+
+func (e InterruptedError) Error() string {
+	return fmt.Sprintf("interrupted, %d files(directories) are not started, the progress is kept in checkpoint, run the same command again to continue", e.remain)
+}
+
+// PositionConflictError happens when the object is appended by another writer, the position to
+// append is not the length of the object
+type PositionConflictError struct {
+	err error
+}
+
+func (e PositionConflictError) Error() string {
+	return e.err.Error()
+}
+
 type CopyError struct {
 	err error
 }
@@ -52,3 +174,95 @@ type CopyError struct {
 func (e CopyError) Error() string {
 	return e.err.Error()
 }
+
+func (e CopyError) Unwrap() error {
+	return e.err
+}
+
+// NotFoundError happens when the bucket, the object or the upload doesn't exist
+type NotFoundError struct {
+	oss.ServiceError
+}
+
+func (e NotFoundError) Unwrap() error {
+	return e.ServiceError
+}
+
+// AccessDeniedError happens when the request is denied by the permission or the signature
+type AccessDeniedError struct {
+	oss.ServiceError
+}
+
+func (e AccessDeniedError) Unwrap() error {
+	return e.ServiceError
+}
+
+// PreconditionFailedError happens when the conditional headers are not met or the object to be
+// created already exists
+type PreconditionFailedError struct {
+	oss.ServiceError
+}
+
+func (e PreconditionFailedError) Unwrap() error {
+	return e.ServiceError
+}
+
+// ThrottledError happens when the requests exceed the qps or the bandwidth limit of oss
+type ThrottledError struct {
+	oss.ServiceError
+}
+
+func (e ThrottledError) Unwrap() error {
+	return e.ServiceError
+}
+
+// wrapServiceError returns the typed error of the error code if err is returned by oss, otherwise
+// err itself. The typed errors wrap the oss.ServiceError, so that errors.As finds both of them.
+func wrapServiceError(err error) error {
+	serviceError, ok := err.(oss.ServiceError)
+	if !ok {
+		return err
+	}
+	switch {
+	case serviceError.StatusCode == http.StatusNotFound:
+		return NotFoundError{serviceError}
+	case serviceError.StatusCode == http.StatusForbidden || serviceError.StatusCode == http.StatusUnauthorized:
+		return AccessDeniedError{serviceError}
+	case serviceError.StatusCode == http.StatusPreconditionFailed || serviceError.Code == "FileAlreadyExists":
+		return PreconditionFailedError{serviceError}
+	case serviceError.StatusCode == http.StatusTooManyRequests || serviceError.Code == "SlowDown" ||
+		serviceError.Code == "QpsLimitExceeded" || serviceError.Code == "DownloadTrafficRateLimitExceeded" ||
+		serviceError.Code == "UploadTrafficRateLimitExceeded":
+		return ThrottledError{serviceError}
+	}
+	return err
+}
+
+// isNotFound returns true if the error is caused by the bucket, object or upload not existing
+func isNotFound(err error) bool {
+	return errors.As(wrapServiceError(err), &NotFoundError{})
+}
+
+// ExitCode returns the exit code of ossutil for the error returned by the commands, 1 for the
+// errors of other kinds
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &BudgetExceededError{}):
+		return ExitCodeBudgetExceeded
+	case errors.As(err, &InterruptedError{}) || errors.Is(err, context.Canceled):
+		return ExitCodeInterrupted
+	case errors.As(wrapServiceError(err), &NotFoundError{}):
+		return ExitCodeNotFound
+	case errors.As(wrapServiceError(err), &AccessDeniedError{}):
+		return ExitCodeAccessDenied
+	case errors.As(wrapServiceError(err), &PreconditionFailedError{}) || errors.As(err, &PreconditionError{}):
+		return ExitCodePreconditionFailed
+	case errors.As(wrapServiceError(err), &ThrottledError{}):
+		return ExitCodeThrottled
+	case errors.As(err, &PositionConflictError{}):
+		return ExitCodePositionConflict
+	}
+	return 1
+}
@@ -0,0 +1,59 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestErrorKinds(c *C) {
+	notFound := oss.ServiceError{Code: "NoSuchKey", StatusCode: 404}
+	denied := oss.ServiceError{Code: "AccessDenied", StatusCode: 403}
+	conflict := oss.ServiceError{Code: "FileAlreadyExists", StatusCode: 409}
+	slowDown := oss.ServiceError{Code: "SlowDown", StatusCode: 503}
+	internal := oss.ServiceError{Code: "InternalError", StatusCode: 500}
+
+	// the typed errors are found through the wrappers of ossutil, and wrap the oss error
+	var err error = CopyError{ObjectError{notFound, "bucket", "object"}}
+	c.Assert(errors.As(err, &NotFoundError{}), Equals, true)
+	c.Assert(errors.As(err, &AccessDeniedError{}), Equals, false)
+	var serviceError oss.ServiceError
+	c.Assert(errors.As(err, &serviceError), Equals, true)
+	c.Assert(serviceError.Code, Equals, "NoSuchKey")
+	c.Assert(err.Error(), Equals, ObjectError{notFound, "bucket", "object"}.Error())
+	c.Assert(isNotFound(notFound), Equals, true)
+	c.Assert(isNotFound(fmt.Errorf("not found")), Equals, false)
+
+	c.Assert(errors.As(BucketError{denied, "bucket"}, &AccessDeniedError{}), Equals, true)
+	c.Assert(errors.As(FileError{conflict, "file"}, &PreconditionFailedError{}), Equals, true)
+	c.Assert(isFileAlreadyExists(ForbidOverwriteError{ObjectError{conflict, "bucket", "object"}}), Equals, true)
+	c.Assert(isPreconditionFailed(CopyError{ObjectError{oss.ServiceError{StatusCode: 412}, "bucket", "object"}}), Equals, true)
+	c.Assert(errors.As(ObjectError{slowDown, "bucket", "object"}, &ThrottledError{}), Equals, true)
+	c.Assert(wrapServiceError(internal), Equals, error(internal))
+
+	c.Assert(ExitCode(nil), Equals, 0)
+	c.Assert(ExitCode(ObjectError{notFound, "bucket", "object"}), Equals, ExitCodeNotFound)
+	c.Assert(ExitCode(denied), Equals, ExitCodeAccessDenied)
+	c.Assert(ExitCode(PreconditionError{"bucket", "object", "etag mismatch"}), Equals, ExitCodePreconditionFailed)
+	c.Assert(ExitCode(fmt.Errorf("list error, %w", BucketError{slowDown, "bucket"})), Equals, ExitCodeThrottled)
+	c.Assert(ExitCode(BudgetExceededError{"--max-duration 1h is exceeded", 1}), Equals, ExitCodeBudgetExceeded)
+	c.Assert(ExitCode(InterruptedError{1}), Equals, ExitCodeInterrupted)
+	c.Assert(ExitCode(context.Canceled), Equals, ExitCodeInterrupted)
+	c.Assert(ExitCode(PositionConflictError{fmt.Errorf("another writer appended")}), Equals, ExitCodePositionConflict)
+	c.Assert(ExitCode(ObjectError{internal, "bucket", "object"}), Equals, 1)
+
+	// the library consumers branch on the kind of the error returned by oss
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Oss-Request-Id", "id")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	config := Config{Endpoint: server.URL, AccessKeyID: "ak", AccessKeySecret: "ak", ForcePathStyle: true, RetryTimes: 1}
+	_, err = StatObject(context.Background(), config, "oss://bucket/object")
+	c.Assert(errors.As(err, &NotFoundError{}), Equals, true)
+}
@@ -0,0 +1,637 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseExportConfig = SpecText{
+	synopsisText: "将bucket的配置导出为Terraform或者ROS模板",
+
+	paramText: "bucket_url [local_file] [options]",
+
+	syntaxText: `
+    ossutil export-config oss://bucket [local_file] [--format terraform|ros] [options]
+`,
+
+	detailHelpText: `
+    该命令读取bucket的ACL、存储类型、冗余类型、生命周期、跨域(CORS)、静态网站、日志、
+    防盗链、版本控制、服务端加密以及标签等配置，并生成对应的基础设施即代码(IaC)模板，
+    便于将已有的bucket导入到Terraform或者阿里云资源编排服务(ROS)中管理。
+
+    --format选项指定导出的格式，取值为：
+        terraform: 生成alicloud_oss_bucket资源的HCL代码(默认值)
+        ros: 生成ALIYUN::OSS::Bucket资源的ROS模板(JSON格式)
+
+    如果指定了local_file，结果写入到该文件中，否则输出到屏幕上。local_file已存在时询问是否覆盖，
+    指定--assume-yes(-y)时不询问。
+    bucket未设置的配置项不会出现在导出结果中。
+`,
+
+	sampleText: `
+    1) 导出bucket的配置为Terraform代码，输出到屏幕上
+       ossutil export-config oss://bucket
+
+    2) 导出bucket的配置为ROS模板，写入到本地文件
+       ossutil export-config oss://bucket bucket.json --format ros
+`,
+}
+
+var specEnglishExportConfig = SpecText{
+	synopsisText: "Export bucket configuration as Terraform or ROS template",
+
+	paramText: "bucket_url [local_file] [options]",
+
+	syntaxText: `
+    ossutil export-config oss://bucket [local_file] [--format terraform|ros] [options]
+`,
+
+	detailHelpText: `
+    The command reads the ACL, storage class, redundancy type, lifecycle, CORS, website,
+    logging, referer, versioning, server side encryption and tags configuration of the
+    bucket, and emits infrastructure-as-code stanzas, so that existing buckets can be
+    imported into Terraform or Alibaba Cloud Resource Orchestration Service(ROS).
+
+    --format option specifies the export format, the value can be:
+        terraform: emit HCL code of alicloud_oss_bucket resource(default)
+        ros: emit ROS template(JSON) of ALIYUN::OSS::Bucket resource
+
+    If local_file is specified, the result is written to the file, otherwise it is
+    printed to stdout. If local_file exists, the confirmation of overwriting it is asked unless
+    --assume-yes(-y) is specified. The configurations which are not set on the bucket are omitted.
+`,
+
+	sampleText: `
+    1) export bucket configuration as Terraform code, output to stdout
+       ossutil export-config oss://bucket
+
+    2) export bucket configuration as ROS template, write to local file
+       ossutil export-config oss://bucket bucket.json --format ros
+`,
+}
+
+const (
+	exportFormatTerraform = "terraform"
+	exportFormatROS       = "ros"
+)
+
+// bucketConfigSnapshot holds the bucket configurations to be exported,
+// nil or empty fields mean the configuration is not set
+type bucketConfigSnapshot struct {
+	bucketName     string
+	acl            string
+	storageClass   string
+	redundancyType string
+	versioning     string
+	sseAlgorithm   string
+	kmsMasterKeyID string
+	lifecycleRules []oss.LifecycleRule
+	corsRules      []oss.CORSRule
+	website        *oss.GetBucketWebsiteResult
+	logging        *oss.LoggingEnabled
+	referer        *oss.GetBucketRefererResult
+	tags           []oss.Tag
+}
+
+type ExportConfigCommand struct {
+	command    Command
+	bucketName string
+	format     string
+}
+
+var exportConfigCommand = ExportConfigCommand{
+	command: Command{
+		name:      "export-config",
+		nameAlias: []string{"export-config"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionLogLevel,
+			OptionFormat,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (ecc *ExportConfigCommand) formatHelpForWhole() string {
+	return ecc.command.formatHelpForWhole()
+}
+
+func (ecc *ExportConfigCommand) formatIndependHelp() string {
+	return ecc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (ecc *ExportConfigCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return ecc.command.Init(ctx, args, options, ecc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (ecc *ExportConfigCommand) RunCommand() error {
+	ecc.format, _ = GetString(OptionFormat, ecc.command.options)
+	ecc.format = strings.ToLower(ecc.format)
+	if ecc.format == "" {
+		ecc.format = exportFormatTerraform
+	}
+	if ecc.format != exportFormatTerraform && ecc.format != exportFormatROS {
+		return fmt.Errorf("--format value is not in the optional value:%s|%s", exportFormatTerraform, exportFormatROS)
+	}
+
+	srcBucketUrL, err := GetCloudUrl(ecc.command.args[0], "")
+	if err != nil {
+		return err
+	}
+	if srcBucketUrL.object != "" {
+		return fmt.Errorf("export-config only support bucket url, %s is invalid", ecc.command.args[0])
+	}
+	ecc.bucketName = srcBucketUrL.bucket
+
+	snapshot, err := ecc.getBucketConfigSnapshot()
+	if err != nil {
+		return err
+	}
+
+	var output []byte
+	if ecc.format == exportFormatROS {
+		output, err = snapshot.toROS()
+		if err != nil {
+			return err
+		}
+	} else {
+		output = snapshot.toTerraform()
+	}
+
+	var outFile *os.File
+	if len(ecc.command.args) >= 2 {
+		fileName := ecc.command.args[1]
+		_, err = os.Stat(fileName)
+		if err == nil {
+			if !ecc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName)) {
+				return nil
+			}
+		}
+
+		outFile, err = os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0660)
+		if err != nil {
+			return err
+		}
+		defer outFile.Close()
+	} else {
+		outFile = os.Stdout
+	}
+
+	_, err = outFile.Write(output)
+	return err
+}
+
+func (ecc *ExportConfigCommand) getBucketConfigSnapshot() (*bucketConfigSnapshot, error) {
+	client, err := ecc.command.ossClient(ecc.bucketName)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &bucketConfigSnapshot{bucketName: ecc.bucketName}
+
+	infoRes, err := client.GetBucketInfo(ecc.bucketName)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.acl = infoRes.BucketInfo.ACL
+	snapshot.storageClass = infoRes.BucketInfo.StorageClass
+	snapshot.redundancyType = infoRes.BucketInfo.RedundancyType
+	snapshot.versioning = infoRes.BucketInfo.Versioning
+	if infoRes.BucketInfo.SseRule.SSEAlgorithm != "" && infoRes.BucketInfo.SseRule.SSEAlgorithm != "None" {
+		snapshot.sseAlgorithm = infoRes.BucketInfo.SseRule.SSEAlgorithm
+		snapshot.kmsMasterKeyID = infoRes.BucketInfo.SseRule.KMSMasterKeyID
+	}
+
+	lifecycleRes, err := client.GetBucketLifecycle(ecc.bucketName)
+	if err == nil {
+		snapshot.lifecycleRules = lifecycleRes.Rules
+	} else if !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+
+	corsRes, err := client.GetBucketCORS(ecc.bucketName)
+	if err == nil {
+		snapshot.corsRules = corsRes.CORSRules
+	} else if !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+
+	websiteRes, err := client.GetBucketWebsite(ecc.bucketName)
+	if err == nil {
+		snapshot.website = &websiteRes
+	} else if !isBucketConfigNotFound(err) {
+		return nil, err
+	}
+
+	loggingRes, err := client.GetBucketLogging(ecc.bucketName)
+	if err != nil {
+		return nil, err
+	}
+	if loggingRes.LoggingEnabled.TargetBucket != "" {
+		snapshot.logging = &loggingRes.LoggingEnabled
+	}
+
+	refererRes, err := client.GetBucketReferer(ecc.bucketName)
+	if err != nil {
+		return nil, err
+	}
+	// allow empty referer with empty referer list is the default configuration
+	if !refererRes.AllowEmptyReferer || len(refererRes.RefererList) > 0 {
+		snapshot.referer = &refererRes
+	}
+
+	taggingRes, err := client.GetBucketTagging(ecc.bucketName)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.tags = taggingRes.Tags
+
+	return snapshot, nil
+}
+
+// isBucketConfigNotFound returns true if the bucket configuration is not set
+func isBucketConfigNotFound(err error) bool {
+	var notFound NotFoundError
+	return errors.As(wrapServiceError(err), &notFound) && notFound.Code != "NoSuchBucket"
+}
+
+// exportResourceName converts bucket name to the name of terraform resource
+func exportResourceName(bucketName string) string {
+	return strings.NewReplacer("-", "_", ".", "_").Replace(bucketName)
+}
+
+// exportLogicalID converts bucket name to the logical id of ROS resource, which only contains letters and digits
+func exportLogicalID(bucketName string) string {
+	var buf bytes.Buffer
+	buf.WriteString("Bucket")
+	for _, part := range strings.FieldsFunc(bucketName, func(r rune) bool { return r == '-' || r == '.' }) {
+		buf.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return buf.String()
+}
+
+// exportDate converts the date of oss(eg: 2006-01-02T00:00:00.000Z) to the date of terraform(eg: 2006-01-02)
+func exportDate(date string) string {
+	if len(date) > 10 {
+		return date[:10]
+	}
+	return date
+}
+
+func hclString(str string) string {
+	return strconv.Quote(strings.Replace(str, "${", "$${", -1))
+}
+
+func hclStringList(strs []string) string {
+	quoted := make([]string, 0, len(strs))
+	for _, str := range strs {
+		quoted = append(quoted, hclString(str))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func (snapshot *bucketConfigSnapshot) toTerraform() []byte {
+	var buf bytes.Buffer
+	name := exportResourceName(snapshot.bucketName)
+	fmt.Fprintf(&buf, "# terraform import alicloud_oss_bucket.%s %s\n", name, snapshot.bucketName)
+	fmt.Fprintf(&buf, "resource \"alicloud_oss_bucket\" \"%s\" {\n", name)
+	fmt.Fprintf(&buf, "  bucket = %s\n", hclString(snapshot.bucketName))
+	if snapshot.acl != "" {
+		fmt.Fprintf(&buf, "  acl    = %s\n", hclString(snapshot.acl))
+	}
+	if snapshot.storageClass != "" {
+		fmt.Fprintf(&buf, "  storage_class   = %s\n", hclString(snapshot.storageClass))
+	}
+	if snapshot.redundancyType != "" {
+		fmt.Fprintf(&buf, "  redundancy_type = %s\n", hclString(snapshot.redundancyType))
+	}
+
+	for _, rule := range snapshot.corsRules {
+		buf.WriteString("\n  cors_rule {\n")
+		fmt.Fprintf(&buf, "    allowed_origins = %s\n", hclStringList(rule.AllowedOrigin))
+		fmt.Fprintf(&buf, "    allowed_methods = %s\n", hclStringList(rule.AllowedMethod))
+		if len(rule.AllowedHeader) > 0 {
+			fmt.Fprintf(&buf, "    allowed_headers = %s\n", hclStringList(rule.AllowedHeader))
+		}
+		if len(rule.ExposeHeader) > 0 {
+			fmt.Fprintf(&buf, "    expose_headers  = %s\n", hclStringList(rule.ExposeHeader))
+		}
+		if rule.MaxAgeSeconds > 0 {
+			fmt.Fprintf(&buf, "    max_age_seconds = %d\n", rule.MaxAgeSeconds)
+		}
+		buf.WriteString("  }\n")
+	}
+
+	if snapshot.website != nil {
+		buf.WriteString("\n  website {\n")
+		fmt.Fprintf(&buf, "    index_document = %s\n", hclString(snapshot.website.IndexDocument.Suffix))
+		if snapshot.website.ErrorDocument.Key != "" {
+			fmt.Fprintf(&buf, "    error_document = %s\n", hclString(snapshot.website.ErrorDocument.Key))
+		}
+		buf.WriteString("  }\n")
+	}
+
+	if snapshot.logging != nil {
+		buf.WriteString("\n  logging {\n")
+		fmt.Fprintf(&buf, "    target_bucket = %s\n", hclString(snapshot.logging.TargetBucket))
+		fmt.Fprintf(&buf, "    target_prefix = %s\n", hclString(snapshot.logging.TargetPrefix))
+		buf.WriteString("  }\n")
+	}
+
+	if snapshot.referer != nil {
+		buf.WriteString("\n  referer_config {\n")
+		fmt.Fprintf(&buf, "    allow_empty = %t\n", snapshot.referer.AllowEmptyReferer)
+		fmt.Fprintf(&buf, "    referers    = %s\n", hclStringList(snapshot.referer.RefererList))
+		buf.WriteString("  }\n")
+	}
+
+	for _, rule := range snapshot.lifecycleRules {
+		buf.WriteString("\n  lifecycle_rule {\n")
+		if rule.ID != "" {
+			fmt.Fprintf(&buf, "    id      = %s\n", hclString(rule.ID))
+		}
+		fmt.Fprintf(&buf, "    prefix  = %s\n", hclString(rule.Prefix))
+		fmt.Fprintf(&buf, "    enabled = %t\n", strings.ToLower(rule.Status) == "enabled")
+		if rule.Expiration != nil {
+			buf.WriteString("\n    expiration {\n")
+			if rule.Expiration.Days > 0 {
+				fmt.Fprintf(&buf, "      days = %d\n", rule.Expiration.Days)
+			}
+			if rule.Expiration.Date != "" {
+				fmt.Fprintf(&buf, "      date = %s\n", hclString(exportDate(rule.Expiration.Date)))
+			}
+			if rule.Expiration.CreatedBeforeDate != "" {
+				fmt.Fprintf(&buf, "      created_before_date = %s\n", hclString(exportDate(rule.Expiration.CreatedBeforeDate)))
+			}
+			buf.WriteString("    }\n")
+		}
+		for _, transition := range rule.Transitions {
+			buf.WriteString("\n    transitions {\n")
+			if transition.Days > 0 {
+				fmt.Fprintf(&buf, "      days = %d\n", transition.Days)
+			}
+			if transition.CreatedBeforeDate != "" {
+				fmt.Fprintf(&buf, "      created_before_date = %s\n", hclString(exportDate(transition.CreatedBeforeDate)))
+			}
+			fmt.Fprintf(&buf, "      storage_class = %s\n", hclString(string(transition.StorageClass)))
+			buf.WriteString("    }\n")
+		}
+		if rule.AbortMultipartUpload != nil {
+			buf.WriteString("\n    abort_multipart_upload {\n")
+			if rule.AbortMultipartUpload.Days > 0 {
+				fmt.Fprintf(&buf, "      days = %d\n", rule.AbortMultipartUpload.Days)
+			}
+			if rule.AbortMultipartUpload.CreatedBeforeDate != "" {
+				fmt.Fprintf(&buf, "      created_before_date = %s\n", hclString(exportDate(rule.AbortMultipartUpload.CreatedBeforeDate)))
+			}
+			buf.WriteString("    }\n")
+		}
+		buf.WriteString("  }\n")
+	}
+
+	if snapshot.versioning != "" && snapshot.versioning != "Disabled" {
+		buf.WriteString("\n  versioning {\n")
+		fmt.Fprintf(&buf, "    status = %s\n", hclString(snapshot.versioning))
+		buf.WriteString("  }\n")
+	}
+
+	if snapshot.sseAlgorithm != "" {
+		buf.WriteString("\n  server_side_encryption_rule {\n")
+		fmt.Fprintf(&buf, "    sse_algorithm = %s\n", hclString(snapshot.sseAlgorithm))
+		if snapshot.kmsMasterKeyID != "" {
+			fmt.Fprintf(&buf, "    kms_master_key_id = %s\n", hclString(snapshot.kmsMasterKeyID))
+		}
+		buf.WriteString("  }\n")
+	}
+
+	if len(snapshot.tags) > 0 {
+		buf.WriteString("\n  tags = {\n")
+		for _, tag := range snapshot.sortedTags() {
+			fmt.Fprintf(&buf, "    %s = %s\n", hclString(tag.Key), hclString(tag.Value))
+		}
+		buf.WriteString("  }\n")
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func (snapshot *bucketConfigSnapshot) sortedTags() []oss.Tag {
+	tags := make([]oss.Tag, len(snapshot.tags))
+	copy(tags, snapshot.tags)
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+	return tags
+}
+
+// structs for ROS template, the field order is kept in the output
+type rosTemplate struct {
+	ROSTemplateFormatVersion string                 `json:"ROSTemplateFormatVersion"`
+	Resources                map[string]rosResource `json:"Resources"`
+}
+
+type rosResource struct {
+	Type       string              `json:"Type"`
+	Properties rosBucketProperties `json:"Properties"`
+}
+
+type rosBucketProperties struct {
+	BucketName                        string                `json:"BucketName"`
+	AccessControl                     string                `json:"AccessControl,omitempty"`
+	StorageClass                      string                `json:"StorageClass,omitempty"`
+	RedundancyType                    string                `json:"RedundancyType,omitempty"`
+	CORSConfiguration                 *rosCORSConfiguration `json:"CORSConfiguration,omitempty"`
+	WebsiteConfiguration              map[string]string     `json:"WebsiteConfiguration,omitempty"`
+	LoggingConfiguration              map[string]string     `json:"LoggingConfiguration,omitempty"`
+	RefererConfiguration              *rosReferer           `json:"RefererConfiguration,omitempty"`
+	LifecycleConfiguration            *rosLifecycle         `json:"LifecycleConfiguration,omitempty"`
+	VersioningConfiguration           map[string]string     `json:"VersioningConfiguration,omitempty"`
+	ServerSideEncryptionConfiguration map[string]string     `json:"ServerSideEncryptionConfiguration,omitempty"`
+	Tags                              map[string]string     `json:"Tags,omitempty"`
+}
+
+type rosCORSConfiguration struct {
+	CORSRule []rosCORSRule `json:"CORSRule"`
+}
+
+type rosCORSRule struct {
+	AllowedOrigin []string `json:"AllowedOrigin,omitempty"`
+	AllowedMethod []string `json:"AllowedMethod,omitempty"`
+	AllowedHeader []string `json:"AllowedHeader,omitempty"`
+	ExposeHeader  []string `json:"ExposeHeader,omitempty"`
+	MaxAgeSeconds int      `json:"MaxAgeSeconds,omitempty"`
+}
+
+type rosReferer struct {
+	AllowEmptyReferer bool     `json:"AllowEmptyReferer"`
+	RefererList       []string `json:"RefererList"`
+}
+
+type rosLifecycle struct {
+	Rule []rosLifecycleRule `json:"Rule"`
+}
+
+type rosLifecycleRule struct {
+	ID                   string              `json:"ID,omitempty"`
+	Prefix               string              `json:"Prefix"`
+	Status               string              `json:"Status"`
+	Expiration           *rosLifecycleAction `json:"Expiration,omitempty"`
+	Transition           []rosTransition     `json:"Transition,omitempty"`
+	AbortMultipartUpload *rosLifecycleAction `json:"AbortMultipartUpload,omitempty"`
+}
+
+type rosLifecycleAction struct {
+	Days              int    `json:"Days,omitempty"`
+	CreatedBeforeDate string `json:"CreatedBeforeDate,omitempty"`
+}
+
+type rosTransition struct {
+	Days              int    `json:"Days,omitempty"`
+	CreatedBeforeDate string `json:"CreatedBeforeDate,omitempty"`
+	StorageClass      string `json:"StorageClass"`
+}
+
+func (snapshot *bucketConfigSnapshot) toROS() ([]byte, error) {
+	props := rosBucketProperties{
+		BucketName:     snapshot.bucketName,
+		AccessControl:  snapshot.acl,
+		StorageClass:   snapshot.storageClass,
+		RedundancyType: snapshot.redundancyType,
+	}
+
+	if len(snapshot.corsRules) > 0 {
+		props.CORSConfiguration = &rosCORSConfiguration{}
+		for _, rule := range snapshot.corsRules {
+			props.CORSConfiguration.CORSRule = append(props.CORSConfiguration.CORSRule, rosCORSRule{
+				AllowedOrigin: rule.AllowedOrigin,
+				AllowedMethod: rule.AllowedMethod,
+				AllowedHeader: rule.AllowedHeader,
+				ExposeHeader:  rule.ExposeHeader,
+				MaxAgeSeconds: rule.MaxAgeSeconds,
+			})
+		}
+	}
+
+	if snapshot.website != nil {
+		props.WebsiteConfiguration = map[string]string{"IndexDocument": snapshot.website.IndexDocument.Suffix}
+		if snapshot.website.ErrorDocument.Key != "" {
+			props.WebsiteConfiguration["ErrorDocument"] = snapshot.website.ErrorDocument.Key
+		}
+	}
+
+	if snapshot.logging != nil {
+		props.LoggingConfiguration = map[string]string{
+			"TargetBucket": snapshot.logging.TargetBucket,
+			"TargetPrefix": snapshot.logging.TargetPrefix,
+		}
+	}
+
+	if snapshot.referer != nil {
+		props.RefererConfiguration = &rosReferer{
+			AllowEmptyReferer: snapshot.referer.AllowEmptyReferer,
+			RefererList:       snapshot.referer.RefererList,
+		}
+		if props.RefererConfiguration.RefererList == nil {
+			props.RefererConfiguration.RefererList = []string{}
+		}
+	}
+
+	if len(snapshot.lifecycleRules) > 0 {
+		props.LifecycleConfiguration = &rosLifecycle{}
+		for _, rule := range snapshot.lifecycleRules {
+			rosRule := rosLifecycleRule{ID: rule.ID, Prefix: rule.Prefix, Status: rule.Status}
+			if rule.Expiration != nil {
+				rosRule.Expiration = &rosLifecycleAction{
+					Days:              rule.Expiration.Days,
+					CreatedBeforeDate: rule.Expiration.CreatedBeforeDate,
+				}
+				if rule.Expiration.Date != "" {
+					rosRule.Expiration.CreatedBeforeDate = rule.Expiration.Date
+				}
+			}
+			for _, transition := range rule.Transitions {
+				rosRule.Transition = append(rosRule.Transition, rosTransition{
+					Days:              transition.Days,
+					CreatedBeforeDate: transition.CreatedBeforeDate,
+					StorageClass:      string(transition.StorageClass),
+				})
+			}
+			if rule.AbortMultipartUpload != nil {
+				rosRule.AbortMultipartUpload = &rosLifecycleAction{
+					Days:              rule.AbortMultipartUpload.Days,
+					CreatedBeforeDate: rule.AbortMultipartUpload.CreatedBeforeDate,
+				}
+			}
+			props.LifecycleConfiguration.Rule = append(props.LifecycleConfiguration.Rule, rosRule)
+		}
+	}
+
+	if snapshot.versioning != "" && snapshot.versioning != "Disabled" {
+		props.VersioningConfiguration = map[string]string{"Status": snapshot.versioning}
+	}
+
+	if snapshot.sseAlgorithm != "" {
+		props.ServerSideEncryptionConfiguration = map[string]string{"SSEAlgorithm": snapshot.sseAlgorithm}
+		if snapshot.kmsMasterKeyID != "" {
+			props.ServerSideEncryptionConfiguration["KMSMasterKeyID"] = snapshot.kmsMasterKeyID
+		}
+	}
+
+	if len(snapshot.tags) > 0 {
+		props.Tags = map[string]string{}
+		for _, tag := range snapshot.tags {
+			props.Tags[tag.Key] = tag.Value
+		}
+	}
+
+	template := rosTemplate{
+		ROSTemplateFormatVersion: "2015-09-01",
+		Resources: map[string]rosResource{
+			exportLogicalID(snapshot.bucketName): {Type: "ALIYUN::OSS::Bucket", Properties: props},
+		},
+	}
+	output, err := json.MarshalIndent(template, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(output, '\n'), nil
+}
@@ -0,0 +1,182 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestExportConfigHelpInfo(c *C) {
+	options := OptionMapType{}
+
+	mkArgs := []string{"export-config"}
+	_, err := cm.RunCommand("help", mkArgs, options)
+	c.Assert(err, IsNil)
+}
+
+func (s *OssutilCommandSuite) newExportConfigSnapshot() *bucketConfigSnapshot {
+	return &bucketConfigSnapshot{
+		bucketName:     "my-bucket.test",
+		acl:            "private",
+		storageClass:   "Standard",
+		redundancyType: "LRS",
+		versioning:     "Enabled",
+		sseAlgorithm:   "KMS",
+		kmsMasterKeyID: "key-id",
+		lifecycleRules: []oss.LifecycleRule{
+			{
+				ID:                   "rule1",
+				Prefix:               "logs/",
+				Status:               "Enabled",
+				Expiration:           &oss.LifecycleExpiration{Date: "2030-01-01T00:00:00.000Z"},
+				Transitions:          []oss.LifecycleTransition{{Days: 30, StorageClass: oss.StorageIA}},
+				AbortMultipartUpload: &oss.LifecycleAbortMultipartUpload{Days: 7},
+			},
+		},
+		corsRules: []oss.CORSRule{
+			{AllowedOrigin: []string{"*"}, AllowedMethod: []string{"GET", "PUT"}, MaxAgeSeconds: 100},
+		},
+		website: &oss.GetBucketWebsiteResult{IndexDocument: oss.IndexDocument{Suffix: "index.html"}},
+		logging: &oss.LoggingEnabled{TargetBucket: "log-bucket", TargetPrefix: "access/"},
+		tags:    []oss.Tag{{Key: "team", Value: "ops"}, {Key: "env", Value: "${prod}"}},
+	}
+}
+
+func (s *OssutilCommandSuite) TestExportConfigTerraform(c *C) {
+	output := string(s.newExportConfigSnapshot().toTerraform())
+	c.Assert(strings.Contains(output, `resource "alicloud_oss_bucket" "my_bucket_test" {`), Equals, true)
+	c.Assert(strings.Contains(output, `bucket = "my-bucket.test"`), Equals, true)
+	c.Assert(strings.Contains(output, `allowed_methods = ["GET", "PUT"]`), Equals, true)
+	c.Assert(strings.Contains(output, `date = "2030-01-01"`), Equals, true)
+	c.Assert(strings.Contains(output, `storage_class = "IA"`), Equals, true)
+	c.Assert(strings.Contains(output, `target_bucket = "log-bucket"`), Equals, true)
+	c.Assert(strings.Contains(output, `kms_master_key_id = "key-id"`), Equals, true)
+	c.Assert(strings.Contains(output, `"env" = "$${prod}"`), Equals, true)
+	c.Assert(strings.Contains(output, "referer_config"), Equals, false)
+
+	// tags are sorted by key
+	c.Assert(strings.Index(output, `"env"`) < strings.Index(output, `"team"`), Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestExportConfigROS(c *C) {
+	output, err := s.newExportConfigSnapshot().toROS()
+	c.Assert(err, IsNil)
+
+	var template rosTemplate
+	err = json.Unmarshal(output, &template)
+	c.Assert(err, IsNil)
+	c.Assert(template.ROSTemplateFormatVersion, Equals, "2015-09-01")
+
+	resource, ok := template.Resources["BucketMyBucketTest"]
+	c.Assert(ok, Equals, true)
+	c.Assert(resource.Type, Equals, "ALIYUN::OSS::Bucket")
+	c.Assert(resource.Properties.BucketName, Equals, "my-bucket.test")
+	c.Assert(resource.Properties.AccessControl, Equals, "private")
+	c.Assert(len(resource.Properties.CORSConfiguration.CORSRule), Equals, 1)
+	c.Assert(resource.Properties.LifecycleConfiguration.Rule[0].Transition[0].StorageClass, Equals, "IA")
+	c.Assert(resource.Properties.VersioningConfiguration["Status"], Equals, "Enabled")
+	c.Assert(resource.Properties.Tags["team"], Equals, "ops")
+	c.Assert(resource.Properties.RefererConfiguration, IsNil)
+}
+
+func (s *OssutilCommandSuite) TestExportConfigBucket(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	str := ""
+	format := "ros"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"format":          &format,
+	}
+
+	fileName := "ossutil-test-export-" + randLowStr(5)
+	args := []string{CloudURLToString(bucketName, ""), fileName}
+	_, err := cm.RunCommand("export-config", args, options)
+	c.Assert(err, IsNil)
+
+	data, err := ioutil.ReadFile(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), bucketName), Equals, true)
+	os.Remove(fileName)
+
+	// invalid format
+	format = "yaml"
+	_, err = cm.RunCommand("export-config", args, options)
+	c.Assert(err, NotNil)
+
+	// object url is invalid
+	format = "terraform"
+	args = []string{CloudURLToString(bucketName, "object")}
+	_, err = cm.RunCommand("export-config", args, options)
+	c.Assert(err, NotNil)
+
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestExportConfigOverwrite(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		has := func(name string) bool {
+			_, ok := query[name]
+			return ok
+		}
+		switch {
+		case has("bucketInfo"):
+			fmt.Fprint(w, `<BucketInfo><Bucket><Name>bucket</Name><AccessControlList><Grant>private</Grant></AccessControlList><StorageClass>Standard</StorageClass></Bucket></BucketInfo>`)
+		case has("logging"):
+			fmt.Fprint(w, `<BucketLoggingStatus></BucketLoggingStatus>`)
+		case has("referer"):
+			fmt.Fprint(w, `<RefererConfiguration><AllowEmptyReferer>true</AllowEmptyReferer><RefererList></RefererList></RefererConfiguration>`)
+		case has("tagging"):
+			fmt.Fprint(w, `<Tagging><TagSet></TagSet></Tagging>`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchConfiguration</Code><Message>not found</Message></Error>`)
+		}
+	}))
+	defer server.Close()
+
+	fileName := "ossutil-test-export-" + randLowStr(5)
+	s.createFile(fileName, "existing", c)
+	defer os.Remove(fileName)
+
+	// the prompt reads EOF from stdin, the existing file is kept
+	stdin, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+	c.Assert(err, IsNil)
+	defer stdin.Close()
+	oldStdin, oldStdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = stdin, stdin
+	defer func() { os.Stdin, os.Stdout = oldStdin, oldStdout }()
+
+	str := "ak"
+	forcePathStyle := true
+	assumeYes := false
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionAssumeYes:       &assumeYes,
+	}
+	args := []string{"oss://bucket", fileName}
+	_, err = cm.RunCommand("export-config", args, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(fileName, c), Equals, "existing")
+
+	// the global --assume-yes overwrites the file without asking
+	assumeYes = true
+	_, err = cm.RunCommand("export-config", args, options)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(s.readFile(fileName, c), `resource "alicloud_oss_bucket"`), Equals, true)
+}
@@ -0,0 +1,183 @@
+package lib
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// failedManifestHeader is the first line of the file written by --output-failed
+var failedManifestHeader = []string{"key", "error"}
+
+// failedManifest records the failed keys of the batch operation with their errors in csv format.
+// For cp and sync the key is relative to the source url, for rm and set-meta it's the object key,
+// so that the file can be passed to --retry-from of the same command to retry the failed keys only
+type failedManifest struct {
+	path   string
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+	count  int64
+	out    io.Writer // the text output of the command
+}
+
+// newFailedManifest returns nil if --output-failed is not specified
+func (cmd *Command) newFailedManifest() (*failedManifest, error) {
+	path, _ := GetString(OptionOutputFailed, cmd.options)
+	if path == "" {
+		return nil, nil
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create --output-failed file error: %s", err.Error())
+	}
+	fm := &failedManifest{path: path, file: file, writer: csv.NewWriter(file), out: cmd.textOut()}
+	fm.writer.Write(failedManifestHeader)
+	fm.writer.Flush()
+	return fm, nil
+}
+
+// record writes the failed key, the line is flushed at once so that the file is complete even if ossutil is killed
+func (fm *failedManifest) record(key string, err error) {
+	if fm == nil || err == nil {
+		return
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.writer.Write([]string{key, strings.Replace(err.Error(), "\n", " ", -1)})
+	fm.writer.Flush()
+	fm.count++
+}
+
+func (fm *failedManifest) close() {
+	if fm == nil {
+		return
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	fm.writer.Flush()
+	fm.file.Close()
+	if fm.count > 0 {
+		fmt.Fprintf(fm.out, "\n%d failures are recorded in %s, run the same command with --retry-from %s to retry them\n", fm.count, fm.path, fm.path)
+	}
+}
+
+// err returns the error to tell that some keys failed, the errors of the keys are ignored to go on
+// with the other keys when they are recorded
+func (fm *failedManifest) err() error {
+	if fm == nil {
+		return nil
+	}
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	if fm.count > 0 {
+		return fmt.Errorf("%d failures, see %s", fm.count, fm.path)
+	}
+	return nil
+}
+
+// readRetryKeys returns the keys in the file of --retry-from, or nil if the option is not specified.
+// The file is read before --output-failed is created, so the same file can be used by both options
+func (cmd *Command) readRetryKeys() ([]string, error) {
+	path, _ := GetString(OptionRetryFrom, cmd.options)
+	if path == "" {
+		return nil, nil
+	}
+	return readFailedManifest(path)
+}
+
+func readFailedManifest(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open --retry-from file error: %s", err.Error())
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	keys := []string{}
+	seen := map[string]bool{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid --retry-from file %s: %s", path, err.Error())
+		}
+		if line == 1 && len(record) > 0 && record[0] == failedManifestHeader[0] {
+			continue
+		}
+		if len(record) == 0 || record[0] == "" {
+			return nil, fmt.Errorf("invalid --retry-from file %s, the key of line %d is empty", path, line)
+		}
+		if !seen[record[0]] {
+			seen[record[0]] = true
+			keys = append(keys, record[0])
+		}
+	}
+	return keys, nil
+}
+
+// retryFileList returns the files of --retry-from under the source urls of upload,
+// the keys not found in the source urls are ignored
+func (cc *CopyCommand) retryFileList(srcURLList []StorageURLer) []fileInfoType {
+	files := []fileInfoType{}
+	for _, url := range srcURLList {
+		name := url.ToString()
+		f, err := os.Stat(name)
+		if err != nil {
+			continue
+		}
+		if !f.IsDir() {
+			dir, fname := filepath.Split(name)
+			for _, key := range cc.cpOption.retryKeys {
+				if key == filepath.ToSlash(fname) {
+					files = append(files, fileInfoType{fname, dir})
+				}
+			}
+			continue
+		}
+
+		if !strings.HasSuffix(name, string(os.PathSeparator)) {
+			name += string(os.PathSeparator)
+		}
+		for _, key := range cc.cpOption.retryKeys {
+			filePath := filepath.FromSlash(key)
+			if _, err := os.Stat(name + filePath); err == nil {
+				files = append(files, fileInfoType{filePath, name})
+			} else {
+				LogInfo("retry file %s not found in %s, skip it\n", key, name)
+			}
+		}
+	}
+	return files
+}
+
+func (cc *CopyCommand) retryFileStatistic(srcURLList []StorageURLer) {
+	for _, file := range cc.retryFileList(srcURLList) {
+		if f, err := os.Stat(file.dir + file.filePath); err == nil && !f.IsDir() {
+			cc.monitor.updateScanSizeNum(f.Size(), 1)
+		} else {
+			cc.monitor.updateScanNum(1)
+		}
+	}
+	cc.monitor.setScanEnd()
+	freshProgress()
+}
+
+// retryObjectProducer sends the objects of --retry-from, the keys are relative to the source url
+func (cc *CopyCommand) retryObjectProducer(cloudURL CloudURL, chObjects chan<- objectInfoType) {
+	prefix := ""
+	if index := strings.LastIndex(cloudURL.object, "/"); index > 0 {
+		prefix = cloudURL.object[:index+1]
+	}
+	for _, key := range cc.cpOption.retryKeys {
+		chObjects <- objectInfoType{prefix, key, -1, time.Now()}
+	}
+}
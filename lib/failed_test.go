@@ -0,0 +1,179 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestFailedManifest(c *C) {
+	fileName := "ossutil-test-failed-" + randLowStr(5) + ".csv"
+	cmd := Command{options: OptionMapType{OptionOutputFailed: &fileName}}
+	fm, err := cmd.newFailedManifest()
+	c.Assert(err, IsNil)
+	c.Assert(fm.err(), IsNil)
+
+	fm.record("a,b.txt", fmt.Errorf("error with \"quote\",\nand new line"))
+	fm.record("dir/c.txt", fmt.Errorf("timeout"))
+	fm.record("dir/c.txt", fmt.Errorf("timeout again"))
+	fm.record("ok.txt", nil)
+	c.Assert(fm.err(), NotNil)
+	fm.close()
+
+	// the file can be read and written by the same command
+	cmd.options[OptionRetryFrom] = &fileName
+	keys, err := cmd.readRetryKeys()
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"a,b.txt", "dir/c.txt"})
+
+	fm, err = cmd.newFailedManifest()
+	c.Assert(err, IsNil)
+	fm.close()
+	keys, err = readFailedManifest(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(len(keys), Equals, 0)
+	c.Assert(keys, NotNil)
+
+	// the file without header
+	s.createFile(fileName, "x.txt,error\n\"y,z.txt\"\n", c)
+	keys, err = readFailedManifest(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"x.txt", "y,z.txt"})
+
+	s.createFile(fileName, "key,error\n,error\n", c)
+	_, err = readFailedManifest(fileName)
+	c.Assert(err, NotNil)
+
+	os.Remove(fileName)
+	_, err = readFailedManifest(fileName)
+	c.Assert(err, NotNil)
+
+	var disabled *failedManifest
+	disabled.record("a", fmt.Errorf("error"))
+	c.Assert(disabled.err(), IsNil)
+	disabled.close()
+
+	cmd = Command{options: OptionMapType{}}
+	keys, err = cmd.readRetryKeys()
+	c.Assert(err, IsNil)
+	c.Assert(keys, IsNil)
+}
+
+func (s *OssutilCommandSuite) TestRetryFileList(c *C) {
+	dir := "ossutil-test-retry-" + randLowStr(5)
+	c.Assert(os.MkdirAll(filepath.Join(dir, "sub"), 0755), IsNil)
+	s.createFile(filepath.Join(dir, "a.txt"), "a", c)
+	s.createFile(filepath.Join(dir, "sub", "b.txt"), "bb", c)
+
+	cc := &CopyCommand{}
+	cc.cpOption.retryKeys = []string{"sub/b.txt", "missing.txt", "a.txt"}
+	files := cc.retryFileList([]StorageURLer{FileURL{dir}})
+	names := []string{}
+	for _, file := range files {
+		c.Assert(file.dir, Equals, dir+string(os.PathSeparator))
+		names = append(names, filepath.ToSlash(file.filePath))
+	}
+	sort.Strings(names)
+	c.Assert(names, DeepEquals, []string{"a.txt", "sub/b.txt"})
+
+	// the single file source
+	files = cc.retryFileList([]StorageURLer{FileURL{filepath.Join(dir, "a.txt")}})
+	c.Assert(len(files), Equals, 1)
+	c.Assert(files[0].filePath, Equals, "a.txt")
+
+	cc.cpOption.retryKeys = []string{"x/y.txt"}
+	chObjects := make(chan objectInfoType, 10)
+	cc.retryObjectProducer(CloudURL{bucket: "bucket", object: "dir/sub"}, chObjects)
+	close(chObjects)
+	objectInfo := <-chObjects
+	c.Assert(objectInfo.prefix, Equals, "dir/")
+	c.Assert(objectInfo.relativeKey, Equals, "x/y.txt")
+	c.Assert(objectInfo.size, Equals, int64(-1))
+
+	os.RemoveAll(dir)
+}
+
+func (s *OssutilCommandSuite) TestRemoveRetryFrom(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	for _, key := range []string{"dir/a", "dir/b", "dir/c", "other"} {
+		s.putObject(bucketName, key, uploadFileName, c)
+	}
+
+	fileName := "ossutil-test-retry-from-" + randLowStr(5) + ".csv"
+	s.createFile(fileName, "key,error\ndir/a,timeout\ndir/c,timeout\nother,timeout\n", c)
+
+	str := ""
+	ok := true
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &ok,
+		"force":           &ok,
+		"retryFrom":       &fileName,
+		"outputFailed":    &fileName,
+	}
+	_, err := cm.RunCommand("rm", []string{CloudURLToString(bucketName, "dir/")}, options)
+	c.Assert(err, IsNil)
+
+	objects := s.listObjects(bucketName, "", "ls -s", c)
+	sort.Strings(objects)
+	c.Assert(objects, DeepEquals, []string{"dir/b", "other"})
+
+	// no failure is recorded
+	keys, err := readFailedManifest(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(len(keys), Equals, 0)
+
+	// --retry-from needs --recursive
+	recursive := false
+	options["recursive"] = &recursive
+	_, err = cm.RunCommand("rm", []string{CloudURLToString(bucketName, "other")}, options)
+	c.Assert(err, NotNil)
+
+	os.Remove(fileName)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestUploadRetryFrom(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	dir := "ossutil-test-upload-retry-" + randLowStr(5)
+	c.Assert(os.MkdirAll(filepath.Join(dir, "sub"), 0755), IsNil)
+	for _, name := range []string{"a.txt", "b.txt", "sub/c.txt"} {
+		s.createFile(filepath.Join(dir, filepath.FromSlash(name)), "content", c)
+	}
+
+	fileName := "ossutil-test-upload-retry-" + randLowStr(5) + ".csv"
+	s.createFile(fileName, "key,error\nsub/c.txt,timeout\n", c)
+
+	str := ""
+	ok := true
+	routines := "2"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &ok,
+		"force":           &ok,
+		"routines":        &routines,
+		"retryFrom":       &fileName,
+	}
+	_, err := cm.RunCommand("cp", []string{dir, CloudURLToString(bucketName, "prefix/")}, options)
+	c.Assert(err, IsNil)
+
+	objects := s.listObjects(bucketName, "", "ls -s", c)
+	c.Assert(objects, DeepEquals, []string{"prefix/sub/c.txt"})
+
+	os.Remove(fileName)
+	os.RemoveAll(dir)
+	s.removeBucket(bucketName, true, c)
+}
@@ -0,0 +1,457 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseFetch = SpecText{
+	synopsisText: "将HTTP(S) url的内容直接写入到oss的objects",
+
+	paramText: "url_list_file cloud_url [options]",
+
+	syntaxText: `
+    ossutil fetch url_list_file oss://bucket[/prefix/] [--key-template template] [-j jobs] [--manifest file] [--retry-times times]
+`,
+
+	detailHelpText: `
+    该命令从url_list_file中读取HTTP(S) url(每行一个,空行和以#开头的行会被忽略),下载每个url的内容
+    并以流的方式直接上传到oss,不会在本地生成临时文件。url_list_file为-时从标准输入读取url。
+
+    每个url对应的object名称为cloud_url中的前缀加上--key-template选项生成的名称,模板中可以使用以下变量:
+        {host}: url中的主机名
+        {path}: url中的路径(不包含开头的/)
+        {dir}: url路径中的目录部分
+        {basename}: url路径中的最后一段
+        {index}: url在列表中的序号,从1开始
+    --key-template的默认值为{basename}。
+
+    每个url失败时会重新下载并上传,重试次数由--retry-times指定。
+    每个url的处理结果会写入到结果清单文件中,文件的每一行以tab分隔,依次为url、object、状态(ok或failed)、
+    大小以及错误信息。清单文件由--manifest选项指定,默认为--output-dir目录下的ossutil_fetch_时间.manifest文件。
+`,
+
+	sampleText: `
+    1) 使用16个并发任务把urls.txt中的url写入到oss://bucket/prefix/下
+       ossutil fetch urls.txt oss://bucket/prefix/ --jobs 16
+
+    2) 保留url的主机名和路径作为object名称
+       ossutil fetch urls.txt oss://bucket/mirror/ --key-template "{host}/{path}"
+
+    3) 从标准输入读取url,并指定结果清单文件
+       cat urls.txt | ossutil fetch - oss://bucket/prefix/ --manifest result.manifest
+`,
+}
+
+var specEnglishFetch = SpecText{
+	synopsisText: "Stream the content of HTTP(S) urls into oss objects",
+
+	paramText: "url_list_file cloud_url [options]",
+
+	syntaxText: `
+    ossutil fetch url_list_file oss://bucket[/prefix/] [--key-template template] [-j jobs] [--manifest file] [--retry-times times]
+`,
+
+	detailHelpText: `
+    The command reads HTTP(S) urls from url_list_file(one url per line, empty lines and lines
+    starting with # are ignored), downloads the content of each url and streams it into oss
+    directly, no local temporary file is created. If url_list_file is -, the urls are read from stdin.
+
+    The object name of each url is the prefix in cloud_url followed by the name generated from
+    --key-template option, the following variables can be used in the template:
+        {host}: the host of the url
+        {path}: the path of the url(without the leading /)
+        {dir}: the directory part of the url path
+        {basename}: the last element of the url path
+        {index}: the index of the url in the list, starting from 1
+    The default value of --key-template is {basename}.
+
+    If an url fails, it is downloaded and uploaded again, the retry times is specified by --retry-times.
+    The result of each url is written to the manifest file, each line of the file is separated by tab,
+    the fields are url, object, status(ok or failed), size and error message. The manifest file is
+    specified by --manifest option, the default is ossutil_fetch_time.manifest under --output-dir.
+`,
+
+	sampleText: `
+    1) stream the urls in urls.txt into oss://bucket/prefix/ with 16 concurrency tasks
+       ossutil fetch urls.txt oss://bucket/prefix/ --jobs 16
+
+    2) keep the host and path of the urls as the object names
+       ossutil fetch urls.txt oss://bucket/mirror/ --key-template "{host}/{path}"
+
+    3) read urls from stdin, and specify the manifest file
+       cat urls.txt | ossutil fetch - oss://bucket/prefix/ --manifest result.manifest
+`,
+}
+
+const defaultFetchKeyTemplate = "{basename}"
+
+type fetchTask struct {
+	index  int64
+	rawURL string
+}
+
+/*
+ * Put same type variables together to make them 64bits alignment to avoid
+ * atomic.AddInt64() panic
+ * Please guarantee the alignment if you add new filed
+ */
+type fetchOptionType struct {
+	okNum       int64
+	errNum      int64
+	totalSize   int64
+	routines    int64
+	retryTimes  int64
+	cloudUrl    CloudURL
+	keyTemplate string
+	httpClient  *http.Client
+	manifest    *os.File
+	manifestMu  sync.Mutex
+}
+
+type FetchCommand struct {
+	command     Command
+	fetchOption fetchOptionType
+}
+
+var fetchCommand = FetchCommand{
+	command: Command{
+		name:      "fetch",
+		nameAlias: []string{"fetch"},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionOutputDir,
+			OptionKeyTemplate,
+			OptionManifest,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (fc *FetchCommand) formatHelpForWhole() string {
+	return fc.command.formatHelpForWhole()
+}
+
+func (fc *FetchCommand) formatIndependHelp() string {
+	return fc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (fc *FetchCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return fc.command.Init(ctx, args, options, fc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (fc *FetchCommand) RunCommand() error {
+	// clear for go tests
+	fc.fetchOption.okNum = 0
+	fc.fetchOption.errNum = 0
+	fc.fetchOption.totalSize = 0
+
+	cloudUrl, err := CloudURLFromString(fc.command.args[1], "")
+	if err != nil {
+		return err
+	}
+	if cloudUrl.bucket == "" {
+		return fmt.Errorf("invalid cloud url: %s, miss bucket", fc.command.args[1])
+	}
+	fc.fetchOption.cloudUrl = cloudUrl
+
+	fc.fetchOption.keyTemplate, _ = GetString(OptionKeyTemplate, fc.command.options)
+	if fc.fetchOption.keyTemplate == "" {
+		fc.fetchOption.keyTemplate = defaultFetchKeyTemplate
+	}
+
+	fc.fetchOption.routines, _ = GetInt(OptionRoutines, fc.command.options)
+	fc.fetchOption.retryTimes, _ = GetInt(OptionRetryTimes, fc.command.options)
+
+	var urlReader io.Reader
+	if fc.command.args[0] == "-" {
+		urlReader = os.Stdin
+	} else {
+		urlFile, err := os.Open(fc.command.args[0])
+		if err != nil {
+			return err
+		}
+		defer urlFile.Close()
+		urlReader = urlFile
+	}
+
+	manifestPath, _ := GetString(OptionManifest, fc.command.options)
+	if manifestPath == "" {
+		outputDir, _ := GetString(OptionOutputDir, fc.command.options)
+		if outputDir == "" {
+			outputDir = DefaultOutputDir
+		}
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return err
+		}
+		manifestPath = outputDir + string(os.PathSeparator) + "ossutil_fetch_" + time.Now().Format("20060102_150405") + ".manifest"
+	}
+	fc.fetchOption.manifest, err = os.OpenFile(manifestPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	defer fc.fetchOption.manifest.Close()
+
+	bucket, err := fc.command.cloudBucket(fc.fetchOption.cloudUrl)
+	if err != nil {
+		return err
+	}
+
+	fc.fetchOption.httpClient = fc.newFetchHTTPClient()
+
+	chTasks := make(chan fetchTask, ChannelBuf)
+	chError := make(chan error, fc.fetchOption.routines)
+	chListError := make(chan error, 1)
+	go fc.fetchProducer(urlReader, chTasks, chListError)
+	for i := 0; int64(i) < fc.fetchOption.routines; i++ {
+		go fc.fetchConsumer(bucket, chTasks, chError)
+	}
+
+	completed := 0
+	var listErr error
+	for int64(completed) <= fc.fetchOption.routines {
+		select {
+		case err := <-chListError:
+			if err != nil {
+				listErr = err
+			}
+			completed++
+		case <-chError:
+			completed++
+		}
+	}
+
+	fmt.Printf("\r%s\r", clearStr)
+	fmt.Printf("succeed:%d\tfailed:%d\ttotal size:%s\n",
+		fc.fetchOption.okNum, fc.fetchOption.errNum, getSizeString(fc.fetchOption.totalSize))
+	fmt.Printf("the result manifest is written to %s\n", manifestPath)
+
+	if listErr != nil {
+		return listErr
+	}
+	if fc.fetchOption.errNum > 0 {
+		return fmt.Errorf("%d url(s) failed to be fetched, see more information in file: %s", fc.fetchOption.errNum, manifestPath)
+	}
+	return nil
+}
+
+func (fc *FetchCommand) newFetchHTTPClient() *http.Client {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	strReadTimeout, _ := GetString(OptionReadTimeout, fc.command.options)
+	if readTimeout, err := strconv.ParseInt(strReadTimeout, 10, 64); err == nil && readTimeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(readTimeout) * time.Second
+	}
+	return &http.Client{Transport: transport}
+}
+
+func (fc *FetchCommand) fetchProducer(reader io.Reader, chTasks chan<- fetchTask, chListError chan<- error) {
+	defer close(chTasks)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var index int64
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		index++
+		chTasks <- fetchTask{index: index, rawURL: line}
+	}
+	chListError <- scanner.Err()
+}
+
+func (fc *FetchCommand) fetchConsumer(bucket *oss.Bucket, chTasks <-chan fetchTask, chError chan<- error) {
+	for task := range chTasks {
+		objectName, size, err := fc.fetchURL(bucket, task)
+		if err != nil {
+			atomic.AddInt64(&fc.fetchOption.errNum, 1)
+			fc.writeManifest(task.rawURL, objectName, "failed", 0, err.Error())
+		} else {
+			atomic.AddInt64(&fc.fetchOption.okNum, 1)
+			atomic.AddInt64(&fc.fetchOption.totalSize, size)
+			fc.writeManifest(task.rawURL, objectName, "ok", size, "")
+		}
+		fmt.Printf("\rfetched:%d\tfailed:%d", atomic.LoadInt64(&fc.fetchOption.okNum), atomic.LoadInt64(&fc.fetchOption.errNum))
+	}
+	chError <- nil
+}
+
+func (fc *FetchCommand) writeManifest(rawURL, objectName, status string, size int64, msg string) {
+	fc.fetchOption.manifestMu.Lock()
+	defer fc.fetchOption.manifestMu.Unlock()
+	objectURL := ""
+	if objectName != "" {
+		objectURL = fc.fetchOption.cloudUrl.objectURL(objectName)
+	}
+	msg = strings.Replace(strings.Replace(msg, "\t", " ", -1), "\n", " ", -1)
+	fmt.Fprintf(fc.fetchOption.manifest, "%s\t%s\t%s\t%d\t%s\n", rawURL, objectURL, status, size, msg)
+}
+
+// fetchURL streams the content of the url to oss, returns the object name and the size
+func (fc *FetchCommand) fetchURL(bucket *oss.Bucket, task fetchTask) (string, int64, error) {
+	srcURL, err := url.Parse(task.rawURL)
+	if err != nil {
+		return "", 0, err
+	}
+	if srcURL.Scheme != "http" && srcURL.Scheme != "https" {
+		return "", 0, fmt.Errorf("unsupported url scheme %s, only http and https are supported", srcURL.Scheme)
+	}
+
+	name, err := renderFetchKey(fc.fetchOption.keyTemplate, srcURL, task.index)
+	if err != nil {
+		return "", 0, err
+	}
+	objectName := fc.fetchOption.cloudUrl.object + name
+
+	for i := 1; ; i++ {
+		size, err := fc.fetchOnce(bucket, task.rawURL, objectName)
+		if err == nil {
+			return objectName, size, nil
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		noNeedRetry := false
+		switch e := err.(type) {
+		case oss.ServiceError:
+			noNeedRetry = e.StatusCode < 500
+		case fetchHTTPError:
+			noNeedRetry = e.statusCode < 500
+		}
+		if int64(i) >= fc.fetchOption.retryTimes || noNeedRetry {
+			return objectName, 0, err
+		}
+		LogError("fetch %s to %s error, retry %d, error:%s\n", task.rawURL, objectName, i, err.Error())
+		if err := fc.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return objectName, 0, err
+		}
+	}
+}
+
+type fetchHTTPError struct {
+	statusCode int
+	status     string
+}
+
+func (e fetchHTTPError) Error() string {
+	return "source returned http status " + e.status
+}
+
+// fetchOnce downloads the url and uploads it, both requests stop once the command is canceled
+func (fc *FetchCommand) fetchOnce(bucket *oss.Bucket, rawURL, objectName string) (int64, error) {
+	req, err := http.NewRequestWithContext(fc.command.context(), http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := fc.fetchOption.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return 0, fetchHTTPError{resp.StatusCode, resp.Status}
+	}
+
+	options := []oss.Option{}
+	if resp.ContentLength >= 0 {
+		options = append(options, oss.ContentLength(resp.ContentLength))
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		options = append(options, oss.ContentType(contentType))
+	}
+
+	counter := &fetchCountReader{reader: resp.Body}
+	if err := bucket.PutObject(objectName, counter, fc.command.withContext(options)...); err != nil {
+		return 0, err
+	}
+	return counter.size, nil
+}
+
+type fetchCountReader struct {
+	reader io.Reader
+	size   int64
+}
+
+func (r *fetchCountReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// renderFetchKey generates the object name of the url by the key template
+func renderFetchKey(template string, srcURL *url.URL, index int64) (string, error) {
+	urlPath := strings.TrimPrefix(srcURL.Path, "/")
+	dir, basename := path.Split(urlPath)
+	dir = strings.TrimSuffix(dir, "/")
+
+	if strings.Contains(template, "{basename}") && basename == "" {
+		return "", fmt.Errorf("can not get basename from url %s, please specify --key-template", srcURL.String())
+	}
+
+	name := strings.NewReplacer(
+		"{host}", srcURL.Host,
+		"{path}", urlPath,
+		"{dir}", dir,
+		"{basename}", basename,
+		"{index}", strconv.FormatInt(index, 10),
+	).Replace(template)
+
+	// the empty variables may leave redundant slashes
+	for strings.Contains(name, "//") {
+		name = strings.Replace(name, "//", "/", -1)
+	}
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "", fmt.Errorf("the object name generated from url %s is empty", srcURL.String())
+	}
+	return name, nil
+}
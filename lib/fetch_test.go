@@ -0,0 +1,148 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestFetchHelpInfo(c *C) {
+	options := OptionMapType{}
+
+	mkArgs := []string{"fetch"}
+	_, err := cm.RunCommand("help", mkArgs, options)
+	c.Assert(err, IsNil)
+}
+
+func (s *OssutilCommandSuite) TestFetchRenderKey(c *C) {
+	srcURL, err := url.Parse("https://example.com/images/2021/a.jpg?x=1")
+	c.Assert(err, IsNil)
+
+	name, err := renderFetchKey(defaultFetchKeyTemplate, srcURL, 1)
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "a.jpg")
+
+	name, err = renderFetchKey("{host}/{path}", srcURL, 1)
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "example.com/images/2021/a.jpg")
+
+	name, err = renderFetchKey("{dir}/{index}-{basename}", srcURL, 12)
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "images/2021/12-a.jpg")
+
+	// empty dir does not leave redundant slashes
+	srcURL, _ = url.Parse("http://example.com/a.jpg")
+	name, err = renderFetchKey("{dir}/{basename}", srcURL, 1)
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "a.jpg")
+
+	// no basename
+	srcURL, _ = url.Parse("http://example.com/dir/")
+	_, err = renderFetchKey(defaultFetchKeyTemplate, srcURL, 1)
+	c.Assert(err, NotNil)
+	name, err = renderFetchKey("{index}.html", srcURL, 3)
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, "3.html")
+}
+
+func (s *OssutilCommandSuite) TestFetchURLs(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "missing.txt") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprintf(w, "content of %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	urlFileName := "ossutil-test-urls-" + randLowStr(5)
+	urls := "# comment line\n" + server.URL + "/a/1.txt\n\n" + server.URL + "/b/2.txt\n" + server.URL + "/missing.txt\n"
+	s.createFile(urlFileName, urls, c)
+	manifestName := "ossutil-test-manifest-" + randLowStr(5)
+
+	str := ""
+	routines := "2"
+	retryTimes := "2"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"routines":        &routines,
+		"retryTimes":      &retryTimes,
+		"manifest":        &manifestName,
+	}
+	args := []string{urlFileName, CloudURLToString(bucketName, "fetch/")}
+	_, err := cm.RunCommand("fetch", args, options)
+	c.Assert(err, NotNil)
+	c.Assert(fetchCommand.fetchOption.okNum, Equals, int64(2))
+	c.Assert(fetchCommand.fetchOption.errNum, Equals, int64(1))
+
+	objectStat := s.getStat(bucketName, "fetch/1.txt", c)
+	c.Assert(objectStat["Content-Length"], Equals, fmt.Sprintf("%d", len("content of /a/1.txt")))
+
+	manifest, err := ioutil.ReadFile(manifestName)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(manifest)), "\n")
+	c.Assert(len(lines), Equals, 3)
+	c.Assert(strings.Contains(string(manifest), "missing.txt\t"+CloudURLToString(bucketName, "fetch/missing.txt")+"\tfailed"), Equals, true)
+
+	os.Remove(urlFileName)
+	os.Remove(manifestName)
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestFetchRetryWait(c *C) {
+	var requests int32
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if strings.HasSuffix(r.URL.Path, "stuck.txt") {
+			select {
+			case <-r.Context().Done():
+			case <-block:
+			}
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	defer close(block)
+
+	fc := &FetchCommand{}
+	fc.fetchOption.retryTimes = 3
+	fc.fetchOption.keyTemplate = defaultFetchKeyTemplate
+	fc.fetchOption.cloudUrl = CloudURL{bucket: "bucket"}
+	fc.fetchOption.httpClient = fc.newFetchHTTPClient()
+
+	// the failed source is requested again after a while
+	start := time.Now()
+	_, _, err := fc.fetchURL(nil, fetchTask{index: 1, rawURL: server.URL + "/error.txt"})
+	c.Assert(err, NotNil)
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(3))
+	c.Assert(time.Since(start) >= 2*time.Second, Equals, true)
+
+	// the stuck download and the retries stop once the command is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	fc.command.ctx = ctx
+	atomic.StoreInt32(&requests, 0)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start = time.Now()
+	_, _, err = fc.fetchURL(nil, fetchTask{index: 1, rawURL: server.URL + "/stuck.txt"})
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(atomic.LoadInt32(&requests), Equals, int32(1))
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
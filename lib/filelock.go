@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// CheckpointLockFileName is the lock file of the checkpoint dir used by the earlier versions
+const CheckpointLockFileName = ".ossutil.lock"
+
+// checkpointLockPattern matches the lock files of the jobs in the checkpoint dir
+const checkpointLockPattern = ".ossutil*.lock"
+
+// ConfigLockSuffix is appended to the config file name to make the lock file of the config file
+const ConfigLockSuffix = ".lock"
+
+// ConfigTempSuffix is appended to the config file name to make the temp file, which is renamed to
+// the config file after it's written
+const ConfigTempSuffix = ".tmp"
+
+// ConfigBackupSuffix is appended to the config file name to make the backup of the replaced config file
+const ConfigBackupSuffix = ".bak"
+
+var errLockHeld = errors.New("the lock is held by another process")
+
+// fileLock is an advisory lock between ossutil processes, the lock is released by the system
+// when the process exits, and the lock file records the holder for reporting
+type fileLock struct {
+	path string
+	file *os.File
+}
+
+// tryLockFile takes the lock without waiting, FileLockedError is returned if another process holds it
+func tryLockFile(path string) (*fileLock, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+		if err != nil {
+			return nil, err
+		}
+		if err = lockFileHandle(f); err != nil {
+			holder := readLockHolder(f)
+			f.Close()
+			if err == errLockHeld {
+				return nil, FileLockedError{path, holder}
+			}
+			return nil, err
+		}
+
+		// the previous holder removes the lock file when unlocking, lock the new file again
+		locked, err1 := f.Stat()
+		current, err2 := os.Stat(path)
+		if err1 != nil || err2 != nil || !os.SameFile(locked, current) {
+			f.Close()
+			continue
+		}
+
+		// the holder is cleared when unlocking, so it is left by a process exited abnormally
+		if holder := readLockHolder(f); holder != "" {
+			fmt.Printf("found stale lock %s of %s, the process may have exited abnormally, take over the lock\n", path, holder)
+			LogWarn("found stale lock %s of %s, take over the lock\n", path, holder)
+		}
+
+		hostname, _ := os.Hostname()
+		holder := fmt.Sprintf("pid %d on %s since %s", os.Getpid(), hostname, time.Now().Format(time.RFC3339))
+		f.Truncate(0)
+		f.WriteAt([]byte(holder), 0)
+		return &fileLock{path: path, file: f}, nil
+	}
+}
+
+// lockFile takes the lock, it waits for the lock released by another process until timeout
+func lockFile(path string, timeout time.Duration) (*fileLock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		fl, err := tryLockFile(path)
+		if _, ok := err.(FileLockedError); !ok || time.Now().After(deadline) {
+			return fl, err
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func readLockHolder(f *os.File) string {
+	buf := make([]byte, 256)
+	n, err := f.ReadAt(buf, 0)
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	return strings.TrimSpace(string(buf[:n]))
+}
+
+// unlock clears the holder and removes the lock file, it can be called more than once
+func (fl *fileLock) unlock() {
+	if fl == nil || fl.file == nil {
+		return
+	}
+	fl.file.Truncate(0)
+	releaseFileHandle(fl.file, fl.path)
+	fl.file = nil
+}
@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestFileLock(c *C) {
+	path := "ossutil-test-lock-" + randLowStr(5)
+
+	lock, err := tryLockFile(path)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "pid"), Equals, true)
+
+	_, err = tryLockFile(path)
+	c.Assert(err, NotNil)
+	_, ok := err.(FileLockedError)
+	c.Assert(ok, Equals, true)
+	c.Assert(strings.Contains(err.Error(), "pid"), Equals, true)
+
+	start := time.Now()
+	_, err = lockFile(path, 300*time.Millisecond)
+	c.Assert(err, NotNil)
+	c.Assert(time.Since(start) >= 300*time.Millisecond, Equals, true)
+
+	lock.unlock()
+	lock.unlock()
+	_, err = os.Stat(path)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	lock, err = lockFile(path, time.Second)
+	c.Assert(err, IsNil)
+	lock.unlock()
+
+	// the holder left in the lock file is stale
+	s.createFile(path, "pid 1 on host since 2020-01-01T00:00:00Z", c)
+	lock, err = tryLockFile(path)
+	c.Assert(err, IsNil)
+	lock.unlock()
+
+	var nilLock *fileLock
+	nilLock.unlock()
+}
+
+func (s *OssutilCommandSuite) TestCopyCheckpointDirLocked(c *C) {
+	cpDir := "ossutil-test-cpdir-" + randLowStr(5)
+	c.Assert(os.MkdirAll(cpDir, 0755), IsNil)
+	bucketName := bucketNamePrefix + randLowStr(10)
+	args := []string{uploadFileName, CloudURLToString(bucketName, "lock")}
+	lock, err := tryLockFile(checkpointLockPath(cpDir, args))
+	c.Assert(err, IsNil)
+
+	s.putBucket(bucketName, c)
+	str := ""
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"checkpointDir":   &cpDir,
+	}
+	_, err = cm.RunCommand("cp", args, options)
+	c.Assert(err, NotNil)
+
+	// the commands of other destinations share the checkpoint dir
+	_, err = cm.RunCommand("cp", []string{uploadFileName, CloudURLToString(bucketName, "other")}, options)
+	c.Assert(err, IsNil)
+
+	lock.unlock()
+	_, err = cm.RunCommand("cp", args, options)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(cpDir)
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	s.removeBucket(bucketName, true, c)
+}
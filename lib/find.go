@@ -0,0 +1,459 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseFind = SpecText{
+	synopsisText: "列举bucket或者指定前缀下meta匹配的objects",
+
+	paramText: "cloud_url --meta conditions [options]",
+
+	syntaxText: `
+    ossutil find oss://bucket[/prefix] --meta "header=value[#header=value...]" [--include pattern] [--exclude pattern] [-j num] [--meta-cache file] [-0] [--payer requester]
+`,
+
+	detailHelpText: `
+    该命令列举bucket或者指定前缀下的objects，并发地获取每个object的meta(HeadObject)，
+    只输出meta满足--meta指定条件的objects，每行输出一个object的cloud_url。
+
+    --meta选项的格式为header=value，多个条件用#分隔，object需要满足全部条件。header不区分
+    大小写，可以为用户自定义meta（如X-Oss-Meta-Owner）或者标准的http头（如Content-Type），
+    value需要完全相等；只指定header（不带=value）表示object存在该meta即可。
+
+    -j选项指定并发获取meta的任务数，默认值为5。
+    --include和--exclude选项按照object名称过滤需要获取meta的objects。
+
+    --meta-cache选项指定本地缓存文件，ossutil在文件中记录获取到的meta，重复查询时，etag和
+    最后修改时间未变化的objects直接使用缓存中的meta，不再发送HeadObject请求。查询结束后，
+    前缀下已经不存在的objects会从缓存中删除。
+
+    --print0(-0)选项表示每个cloud_url以NUL字符而不是换行结尾，统计信息输出到stderr，object名中
+    含有换行或空格时，也可以通过管道安全地传给xargs -0，或者rm、stat的--null-input选项。
+`,
+
+	sampleText: `
+    1) 查找owner为teamA的objects
+       ossutil find oss://bucket --meta X-Oss-Meta-Owner=teamA
+
+    2) 查找指定前缀下owner为teamA并且Content-Type为image/png的objects，并使用缓存
+       ossutil find oss://bucket/images/ --meta "X-Oss-Meta-Owner=teamA#Content-Type=image/png" --meta-cache meta.cache
+
+    3) 查找设置了X-Oss-Meta-Expire的jpg文件，并发数为20
+       ossutil find oss://bucket --meta X-Oss-Meta-Expire --include "*.jpg" -j 20
+
+    4) 删除owner为teamA的objects
+       ossutil find oss://bucket --meta X-Oss-Meta-Owner=teamA -0 | ossutil rm oss://bucket -r -f --null-input
+`,
+}
+
+var specEnglishFind = SpecText{
+	synopsisText: "List the objects whose meta matches in the bucket or under the prefix",
+
+	paramText: "cloud_url --meta conditions [options]",
+
+	syntaxText: `
+    ossutil find oss://bucket[/prefix] --meta "header=value[#header=value...]" [--include pattern] [--exclude pattern] [-j num] [--meta-cache file] [-0] [--payer requester]
+`,
+
+	detailHelpText: `
+    The command lists the objects in the bucket or under the prefix, gets the meta of every
+    object(HeadObject) concurrently, and only outputs the objects whose meta matches the
+    conditions of --meta, the cloud_url of an object is output per line.
+
+    The format of --meta option is header=value, multiple conditions are separated by #, the
+    object should match all the conditions. The header is case insensitive, it can be the user
+    meta(like X-Oss-Meta-Owner) or the standard http header(like Content-Type), the value should
+    be exactly equal; only the header(without =value) means the object should have the meta.
+
+    -j option specifies the number of the concurrent tasks getting the meta, the default value is 5.
+    --include and --exclude option filter the objects to get the meta by the object name.
+
+    --meta-cache option specifies the local cache file, ossutil records the meta got into the
+    file, when the query is repeated, the cached meta is used for the objects whose etag and
+    last modified time are not changed, without sending HeadObject request. After the query,
+    the objects which no longer exist under the prefix are removed from the cache.
+
+    --print0(-0) option means every cloud_url ends with NUL character instead of newline, and the
+    statistics are output to stderr, so the output can be piped safely to xargs -0, or --null-input
+    option of rm and stat, even if the object names contain newlines or spaces.
+`,
+
+	sampleText: `
+    1) find the objects whose owner is teamA
+       ossutil find oss://bucket --meta X-Oss-Meta-Owner=teamA
+
+    2) find the objects under the prefix whose owner is teamA and Content-Type is image/png with the cache
+       ossutil find oss://bucket/images/ --meta "X-Oss-Meta-Owner=teamA#Content-Type=image/png" --meta-cache meta.cache
+
+    3) find the jpg files with X-Oss-Meta-Expire in 20 concurrent tasks
+       ossutil find oss://bucket --meta X-Oss-Meta-Expire --include "*.jpg" -j 20
+
+    4) remove the objects whose owner is teamA
+       ossutil find oss://bucket --meta X-Oss-Meta-Owner=teamA -0 | ossutil rm oss://bucket -r -f --null-input
+`,
+}
+
+// metaCondition is a condition of --meta, anyValue means the header only needs to exist
+type metaCondition struct {
+	name     string
+	value    string
+	anyValue bool
+}
+
+/*
+ * Put same type variables together to make them 64bits alignment to avoid
+ * atomic.AddInt64() panic
+ */
+type findOptionType struct {
+	scanNum      int64
+	headNum      int64
+	matchNum     int64
+	errNum       int64
+	routines     int64
+	print0       bool
+	conditions   []metaCondition
+	filters      []filterOptionType
+	cache        *metaCache
+	payerOptions []oss.Option
+}
+
+type FindCommand struct {
+	command    Command
+	findOption findOptionType
+}
+
+var findCommand = FindCommand{
+	command: Command{
+		name:      "find",
+		nameAlias: []string{"find"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionMeta,
+			OptionMetaCache,
+			OptionPrint0,
+			OptionInclude,
+			OptionExclude,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (fc *FindCommand) formatHelpForWhole() string {
+	return fc.command.formatHelpForWhole()
+}
+
+func (fc *FindCommand) formatIndependHelp() string {
+	return fc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (fc *FindCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return fc.command.Init(ctx, args, options, fc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (fc *FindCommand) RunCommand() error {
+	// clear for go tests
+	fc.findOption = findOptionType{payerOptions: []oss.Option{}}
+
+	encodingType, _ := GetString(OptionEncodingType, fc.command.options)
+	cloudURL, err := GetCloudUrl(fc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+
+	meta, _ := GetString(OptionMeta, fc.command.options)
+	if fc.findOption.conditions, err = parseMetaConditions(meta); err != nil {
+		return err
+	}
+
+	var res bool
+	res, fc.findOption.filters = getFilter(os.Args)
+	if !res {
+		return fmt.Errorf("--include or --exclude does not support format containing dir info")
+	}
+
+	payer, _ := GetString(OptionRequestPayer, fc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		fc.findOption.payerOptions = append(fc.findOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+
+	fc.findOption.routines, err = GetInt(OptionRoutines, fc.command.options)
+	if err != nil || fc.findOption.routines <= 0 {
+		fc.findOption.routines = int64(Routines)
+	}
+
+	fc.findOption.print0, _ = GetBool(OptionPrint0, fc.command.options)
+
+	cachePath, _ := GetString(OptionMetaCache, fc.command.options)
+	if fc.findOption.cache, err = loadMetaCache(cachePath); err != nil {
+		return err
+	}
+
+	bucket, err := fc.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return err
+	}
+
+	chObjects := make(chan oss.ObjectProperties, ChannelBuf)
+	var wg sync.WaitGroup
+	for i := int64(0); i < fc.findOption.routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fc.matchConsumer(bucket, chObjects)
+		}()
+	}
+	listErr := fc.listObjects(bucket, cloudURL.object, chObjects)
+	close(chObjects)
+	wg.Wait()
+
+	if listErr == nil {
+		// the objects not listed are removed from the cache only if the listing is complete
+		if err := fc.findOption.cache.save(bucketObjectURL(bucket, cloudURL.object)); err != nil {
+			return err
+		}
+	}
+
+	// the statistics of --print0 are output to stderr, so that stdout only has the cloud urls
+	summary := os.Stdout
+	if fc.findOption.print0 {
+		summary = os.Stderr
+	}
+	fmt.Fprintf(summary, "\nscanned objects: %d, head requests: %d, matched objects: %d\n",
+		fc.findOption.scanNum, fc.findOption.headNum, fc.findOption.matchNum)
+	if listErr != nil {
+		return listErr
+	}
+	if fc.findOption.errNum > 0 {
+		return fmt.Errorf("get meta of %d objects failed, please check the log", fc.findOption.errNum)
+	}
+	return nil
+}
+
+// parseMetaConditions parses the value of --meta, like X-Oss-Meta-Owner=teamA#Content-Type=image/png
+func parseMetaConditions(str string) ([]metaCondition, error) {
+	if strings.TrimSpace(str) == "" {
+		return nil, fmt.Errorf("find objects need --meta option, like --meta X-Oss-Meta-Owner=teamA")
+	}
+
+	conditions := []metaCondition{}
+	for _, s := range strings.Split(str, "#") {
+		pair := strings.SplitN(s, "=", 2)
+		name := strings.TrimSpace(pair[0])
+		if name == "" {
+			return nil, fmt.Errorf("invalid --meta: %s, the header is empty", str)
+		}
+		if len(pair) == 1 {
+			conditions = append(conditions, metaCondition{name: http.CanonicalHeaderKey(name), anyValue: true})
+		} else {
+			conditions = append(conditions, metaCondition{name: http.CanonicalHeaderKey(name), value: pair[1]})
+		}
+	}
+	return conditions, nil
+}
+
+// matchMetaConditions returns true if the headers match all the conditions
+func matchMetaConditions(headers http.Header, conditions []metaCondition) bool {
+	for _, condition := range conditions {
+		values, ok := headers[condition.name]
+		if !ok || len(values) == 0 {
+			return false
+		}
+		if !condition.anyValue && values[0] != condition.value {
+			return false
+		}
+	}
+	return true
+}
+
+func (fc *FindCommand) listObjects(bucket *oss.Bucket, prefix string, chObjects chan<- oss.ObjectProperties) error {
+	pre := oss.Prefix(prefix)
+	marker := oss.Marker("")
+	for {
+		listOptions := append(fc.findOption.payerOptions, pre, marker, oss.MaxKeys(1000))
+		lor, err := fc.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			return err
+		}
+
+		for _, object := range lor.Objects {
+			if !doesSingleObjectMatchPatterns(object.Key, fc.findOption.filters) {
+				continue
+			}
+			atomic.AddInt64(&fc.findOption.scanNum, 1)
+			chObjects <- object
+		}
+
+		pre = oss.Prefix(lor.Prefix)
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	return nil
+}
+
+func (fc *FindCommand) matchConsumer(bucket *oss.Bucket, chObjects <-chan oss.ObjectProperties) {
+	for object := range chObjects {
+		cacheKey := bucketObjectURL(bucket, object.Key)
+		headers, ok := fc.findOption.cache.get(cacheKey, object.ETag, object.LastModified)
+		if !ok {
+			var err error
+			atomic.AddInt64(&fc.findOption.headNum, 1)
+			headers, err = fc.command.ossGetObjectStatRetry(bucket, object.Key, fc.findOption.payerOptions...)
+			if err != nil {
+				atomic.AddInt64(&fc.findOption.errNum, 1)
+				LogError("find get meta of %s error:%s\n", cacheKey, err.Error())
+				continue
+			}
+			fc.findOption.cache.put(cacheKey, object.ETag, object.LastModified, headers)
+		}
+
+		if matchMetaConditions(headers, fc.findOption.conditions) {
+			atomic.AddInt64(&fc.findOption.matchNum, 1)
+			if fc.findOption.print0 {
+				fmt.Printf("%s\x00", cacheKey)
+			} else {
+				fmt.Println(cacheKey)
+			}
+		}
+	}
+}
+
+// metaCacheEntry is the meta of an object cached by --meta-cache, it's valid while the etag
+// and the last modified time of the listed object are not changed
+type metaCacheEntry struct {
+	ETag         string      `json:"etag"`
+	LastModified time.Time   `json:"lastModified"`
+	Headers      http.Header `json:"headers"`
+}
+
+// metaCache is the on-disk cache of the meta got by find, keyed by the cloud url of the object
+type metaCache struct {
+	lock    sync.Mutex
+	path    string
+	entries map[string]metaCacheEntry
+	seen    map[string]bool
+}
+
+// loadMetaCache returns nil if path is empty, the methods of nil cache do nothing
+func loadMetaCache(path string) (*metaCache, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	mc := &metaCache{path: path, entries: map[string]metaCacheEntry{}, seen: map[string]bool{}}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return mc, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &mc.entries); err != nil {
+		return nil, fmt.Errorf("invalid meta cache file %s, %s, please remove it", path, err.Error())
+	}
+	return mc, nil
+}
+
+func (mc *metaCache) get(key, etag string, lastModified time.Time) (http.Header, bool) {
+	if mc == nil {
+		return nil, false
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	mc.seen[key] = true
+	entry, ok := mc.entries[key]
+	if !ok || entry.ETag != etag || !entry.LastModified.Equal(lastModified) {
+		return nil, false
+	}
+	return entry.Headers, true
+}
+
+func (mc *metaCache) put(key, etag string, lastModified time.Time, headers http.Header) {
+	if mc == nil {
+		return
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	mc.entries[key] = metaCacheEntry{ETag: etag, LastModified: lastModified, Headers: headers}
+}
+
+// save writes the cache file, the entries under the prefix which are not listed are removed
+func (mc *metaCache) save(prefix string) error {
+	if mc == nil {
+		return nil
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	for key := range mc.entries {
+		if strings.HasPrefix(key, prefix) && !mc.seen[key] {
+			delete(mc.entries, key)
+		}
+	}
+
+	data, err := json.Marshal(mc.entries)
+	if err != nil {
+		return err
+	}
+	tmpPath := mc.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, mc.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
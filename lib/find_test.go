@@ -0,0 +1,106 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestFindMetaConditions(c *C) {
+	conditions, err := parseMetaConditions("x-oss-meta-owner=teamA#X-Oss-Meta-Expire")
+	c.Assert(err, IsNil)
+	c.Assert(conditions, DeepEquals, []metaCondition{{name: "X-Oss-Meta-Owner", value: "teamA"}, {name: "X-Oss-Meta-Expire", anyValue: true}})
+
+	headers := http.Header{}
+	headers.Set("X-Oss-Meta-Owner", "teamA")
+	c.Assert(matchMetaConditions(headers, conditions), Equals, false)
+	headers.Set("X-Oss-Meta-Expire", "")
+	c.Assert(matchMetaConditions(headers, conditions), Equals, true)
+	headers.Set("X-Oss-Meta-Owner", "teamB")
+	c.Assert(matchMetaConditions(headers, conditions), Equals, false)
+
+	_, err = parseMetaConditions("")
+	c.Assert(err, NotNil)
+	_, err = parseMetaConditions("=teamA")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestFindMeta(c *C) {
+	var mu sync.Mutex
+	heads := 0
+	etagB := "b1"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "HEAD" {
+			heads++
+			owner := "teamA"
+			if strings.HasSuffix(r.URL.Path, "/c") {
+				owner = "teamB"
+			}
+			w.Header().Set("X-Oss-Meta-Owner", owner)
+			return
+		}
+		fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>dir/a</Key><ETag>"a1"</ETag><LastModified>2020-01-01T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>dir/b</Key><ETag>"`+etagB+`"</ETag><LastModified>2020-01-01T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>dir/c</Key><ETag>"c1"</ETag><LastModified>2020-01-01T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	cachePath := "ossutil-test-meta-cache-" + randLowStr(5)
+	defer os.Remove(cachePath)
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	meta := "X-Oss-Meta-Owner=teamA"
+	routines := "2"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionMeta:            &meta,
+		OptionMetaCache:       &cachePath,
+		OptionRoutines:        &routines,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("find", []string{"oss://bucket/dir/"}, options)
+	os.Stdout = oldStdout
+	testResultFile.Close()
+	c.Assert(err, IsNil)
+	c.Assert(heads, Equals, 3)
+	c.Assert(findCommand.findOption.matchNum, Equals, int64(2))
+	data, err := ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "oss://bucket/dir/a\n"), Equals, true)
+	c.Assert(strings.Contains(string(data), "oss://bucket/dir/c\n"), Equals, false)
+
+	// the cached meta is used for the objects not changed
+	testResultFile, err = os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	os.Stdout = testResultFile
+	heads = 0
+	etagB = "b2"
+	_, err = cm.RunCommand("find", []string{"oss://bucket/dir/"}, options)
+	os.Stdout = oldStdout
+	testResultFile.Close()
+	c.Assert(err, IsNil)
+	c.Assert(heads, Equals, 1)
+	c.Assert(findCommand.findOption.matchNum, Equals, int64(2))
+
+	cache, err := loadMetaCache(cachePath)
+	c.Assert(err, IsNil)
+	c.Assert(len(cache.entries), Equals, 3)
+	c.Assert(cache.entries["oss://bucket/dir/b"].ETag, Equals, `"b2"`)
+}
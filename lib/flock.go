@@ -0,0 +1,26 @@
+// This is for Condition Compling, which means it will be built on all non-windows platform.
+
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"os"
+	"syscall"
+)
+
+func lockFileHandle(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return errLockHeld
+	}
+	return err
+}
+
+// releaseFileHandle removes the lock file before closing it, so that the process waiting for
+// the lock finds the file is removed after it gets the lock
+func releaseFileHandle(f *os.File, path string) {
+	os.Remove(path)
+	f.Close()
+}
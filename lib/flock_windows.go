@@ -0,0 +1,36 @@
+// This filename is for Condition Compling, which means it will be built only on windows platform.
+
+package lib
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	lockfileFailImmediately = 0x00000001
+	lockfileExclusiveLock   = 0x00000002
+	errorLockViolation      = syscall.Errno(33)
+)
+
+func lockFileHandle(f *os.File) error {
+	// lock the byte beyond the content, so that the holder recorded in the file can still be read
+	overlapped := syscall.Overlapped{OffsetHigh: 1}
+	var mod = syscall.NewLazyDLL("kernel32.dll")
+	var proc = mod.NewProc("LockFileEx")
+	ret, _, err := proc.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&overlapped)))
+	if ret == 0 {
+		if err == errorLockViolation {
+			return errLockHeld
+		}
+		return err
+	}
+	return nil
+}
+
+// releaseFileHandle closes the lock file before removing it, the opened file can't be removed on windows
+func releaseFileHandle(f *os.File, path string) {
+	f.Close()
+	os.Remove(path)
+}
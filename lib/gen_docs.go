@@ -0,0 +1,310 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var specChineseGenDocs = SpecText{
+	synopsisText: "生成所有命令的man手册或markdown文档",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil gen-docs [--format man|markdown] [--out dir] [-L language]
+`,
+
+	detailHelpText: `
+    该命令根据所有命令的帮助文档（摘要、语法、详细说明、示例）和选项说明生成文档文件，
+    便于打包安装时附带使用手册。文档的语言由--language选项决定。
+
+--format选项
+
+    文档格式，取值为man或者markdown，默认为man：
+
+    man: 生成man手册，每个命令一个文件ossutil-<command>.1，以及总览文件ossutil.1
+    markdown: 生成markdown文档，每个命令一个文件<command>.md，以及索引文件README.md
+
+--out选项
+
+    文档的输出目录，不存在时自动创建，默认为docs，已存在的同名文件会被覆盖。
+`,
+
+	sampleText: `
+    1) 生成英文man手册到目录./docs
+       ossutil gen-docs --format man --out ./docs -L EN
+
+    2) 生成中文markdown文档到目录./docs/ch
+       ossutil gen-docs --format markdown --out ./docs/ch -L CH
+`,
+}
+
+var specEnglishGenDocs = SpecText{
+	synopsisText: "Generate the man pages or markdown documents of all commands",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil gen-docs [--format man|markdown] [--out dir] [-L language]
+`,
+
+	detailHelpText: `
+    The command generates the document files from the help text(synopsis, syntax, detail
+    and samples) of all commands and the descriptions of options, so that the manuals can
+    be shipped with the packaged installs. The language of the documents is decided by the
+    --language option.
+
+--format option
+
+    The format of the documents, the value can be man or markdown, the default is man:
+
+    man: generate man pages, a file ossutil-<command>.1 for every command, and the
+         overview file ossutil.1
+    markdown: generate markdown documents, a file <command>.md for every command, and the
+         index file README.md
+
+--out option
+
+    The output directory of the documents, it's created if it doesn't exist, the default is
+    docs, the existing files of the same names are overwritten.
+`,
+
+	sampleText: `
+    1) generate the English man pages to the directory ./docs
+       ossutil gen-docs --format man --out ./docs -L EN
+
+    2) generate the Chinese markdown documents to the directory ./docs/ch
+       ossutil gen-docs --format markdown --out ./docs/ch -L CH
+`,
+}
+
+const (
+	docsFormatMan      = "man"
+	docsFormatMarkdown = "markdown"
+	defaultDocsDir     = "docs"
+)
+
+// commandDoc is the document content of a command
+type commandDoc struct {
+	name    string
+	spec    SpecText
+	options []Option
+}
+
+type GenDocsCommand struct {
+	command Command
+}
+
+var genDocsCommand = GenDocsCommand{
+	command: Command{
+		name:      "gen-docs",
+		nameAlias: []string{"gen-docs"},
+		minArgc:   0,
+		maxArgc:   0,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionFormat,
+			OptionOutDir,
+			OptionLanguage,
+			OptionLogLevel,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (gc *GenDocsCommand) formatHelpForWhole() string {
+	return gc.command.formatHelpForWhole()
+}
+
+func (gc *GenDocsCommand) formatIndependHelp() string {
+	return gc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (gc *GenDocsCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return gc.command.Init(ctx, args, options, gc)
+}
+
+// function for RewriteLoadConfiger interface
+func (gc *GenDocsCommand) rewriteLoadConfig(configFile string) error {
+	// read config file, if error exist, do not print error
+	var err error
+	if gc.command.configOptions, err = LoadConfig(configFile); err != nil {
+		gc.command.configOptions = OptionMapType{}
+	}
+	return nil
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (gc *GenDocsCommand) RunCommand() error {
+	format, _ := GetString(OptionFormat, gc.command.options)
+	if format == "" {
+		format = docsFormatMan
+	}
+	format = strings.ToLower(format)
+	if format != docsFormatMan && format != docsFormatMarkdown {
+		return fmt.Errorf("invalid --format: %s, the value can be %s or %s", format, docsFormatMan, docsFormatMarkdown)
+	}
+	outDir, _ := GetString(OptionOutDir, gc.command.options)
+	if outDir == "" {
+		outDir = defaultDocsDir
+	}
+	language, _ := GetString(OptionLanguage, gc.command.options)
+
+	docs := getCommandDocs(language)
+	files := map[string]string{}
+	if format == docsFormatMan {
+		files["ossutil.1"] = renderManIndex(docs, getUsageText(language))
+		for _, doc := range docs {
+			files["ossutil-"+doc.name+".1"] = renderManPage(doc, language)
+		}
+	} else {
+		files["README.md"] = renderMarkdownIndex(docs, getUsageText(language))
+		for _, doc := range docs {
+			files[doc.name+".md"] = renderMarkdownPage(doc, language)
+		}
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(outDir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("generated %d %s files in %s.\n", len(files), format, outDir)
+	return nil
+}
+
+// getCommandDocs returns the documents of all commands sorted by name
+func getCommandDocs(language string) []commandDoc {
+	docs := []commandDoc{}
+	for _, cmd := range GetAllCommands() {
+		name, validOptionNames := commandInfo(cmd)
+		doc := commandDoc{name: name, spec: getSpecText(language, name)}
+		for _, optionName := range validOptionNames {
+			if option, ok := OptionMap[optionName]; ok {
+				doc.options = append(doc.options, option)
+			}
+		}
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].name < docs[j].name })
+	return docs
+}
+
+// optionNames returns the names of the option like "-r, --recursive" and the default value
+func optionNames(option Option) string {
+	names := []string{}
+	if option.name != "" {
+		names = append(names, option.name)
+	}
+	if option.nameAlias != "" {
+		names = append(names, option.nameAlias)
+	}
+	text := strings.Join(names, ", ")
+	if option.def != "" {
+		text += "=" + option.def
+	}
+	return text
+}
+
+// roffEscape escapes the text for man pages, the lines are kept as they are in .nf blocks
+func roffEscape(text string) string {
+	text = strings.Replace(text, "\\", "\\e", -1)
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// trimBlankLines removes the trailing spaces of the lines and the blank lines at both ends
+func trimBlankLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], " \t")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+func roffSection(title, text string) string {
+	text = trimBlankLines(text)
+	if text == "" {
+		return ""
+	}
+	return fmt.Sprintf(".SH %s\n.nf\n%s\n.fi\n", title, roffEscape(text))
+}
+
+func renderManPage(doc commandDoc, language string) string {
+	text := fmt.Sprintf(".TH OSSUTIL-%s 1 \"\" \"ossutil %s\" \"ossutil Manual\"\n", strings.ToUpper(doc.name), Version)
+	text += fmt.Sprintf(".SH NAME\nossutil-%s \\- %s\n", doc.name, roffEscape(strings.TrimSpace(doc.spec.synopsisText)))
+	text += roffSection("SYNOPSIS", doc.spec.syntaxText)
+	text += roffSection("DESCRIPTION", doc.spec.detailHelpText)
+	text += roffSection("EXAMPLES", doc.spec.sampleText)
+	if len(doc.options) > 0 {
+		text += ".SH OPTIONS\n"
+		for _, option := range doc.options {
+			names := strings.Replace(roffEscape(optionNames(option)), "-", "\\-", -1)
+			text += fmt.Sprintf(".TP\n\\fB%s\\fR\n%s\n", names, roffEscape(option.getHelp(language)))
+		}
+	}
+	text += ".SH SEE ALSO\nossutil(1)\n"
+	return text
+}
+
+func renderManIndex(docs []commandDoc, usage string) string {
+	text := fmt.Sprintf(".TH OSSUTIL 1 \"\" \"ossutil %s\" \"ossutil Manual\"\n", Version)
+	text += ".SH NAME\nossutil \\- Simple tool for access OSS\n"
+	text += roffSection("SYNOPSIS", usage)
+	text += ".SH COMMANDS\n"
+	for _, doc := range docs {
+		name := strings.Replace(doc.name, "-", "\\-", -1)
+		text += fmt.Sprintf(".TP\n\\fBossutil\\-%s\\fR(1)\n%s\n", name, roffEscape(strings.TrimSpace(doc.spec.synopsisText)))
+	}
+	return text
+}
+
+func markdownCodeBlock(title, text string) string {
+	text = trimBlankLines(text)
+	if text == "" {
+		return ""
+	}
+	return fmt.Sprintf("## %s\n\n```\n%s\n```\n\n", title, text)
+}
+
+func markdownCell(text string) string {
+	text = strings.Replace(text, "|", "\\|", -1)
+	return strings.Replace(text, "\n", "<br>", -1)
+}
+
+func renderMarkdownPage(doc commandDoc, language string) string {
+	text := fmt.Sprintf("# ossutil %s\n\n%s\n\n", doc.name, strings.TrimSpace(doc.spec.synopsisText))
+	text += markdownCodeBlock("Synopsis", doc.spec.syntaxText)
+	text += markdownCodeBlock("Description", doc.spec.detailHelpText)
+	text += markdownCodeBlock("Examples", doc.spec.sampleText)
+	if len(doc.options) > 0 {
+		text += "## Options\n\n| Option | Description |\n| --- | --- |\n"
+		for _, option := range doc.options {
+			text += fmt.Sprintf("| `%s` | %s |\n", optionNames(option), markdownCell(option.getHelp(language)))
+		}
+		text += "\n"
+	}
+	return strings.TrimRight(text, "\n") + "\n"
+}
+
+func renderMarkdownIndex(docs []commandDoc, usage string) string {
+	text := fmt.Sprintf("# ossutil %s\n\n```\n%s\n```\n\n| Command | Synopsis |\n| --- | --- |\n", Version, usage)
+	for _, doc := range docs {
+		text += fmt.Sprintf("| [%s](%s.md) | %s |\n", doc.name, doc.name, markdownCell(strings.TrimSpace(doc.spec.synopsisText)))
+	}
+	return text
+}
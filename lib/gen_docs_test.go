@@ -0,0 +1,50 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestGenDocs(c *C) {
+	c.Assert(roffEscape(".TH\n'quote\na\\b"), Equals, "\\&.TH\n\\&'quote\na\\eb")
+	c.Assert(optionNames(OptionMap[OptionRecursion]), Equals, "-r, --recursive")
+
+	outDir := "ossutil-test-docs-" + randLowStr(5)
+	defer os.RemoveAll(outDir)
+	format := "man"
+	language := EnglishLanguage
+	options := OptionMapType{
+		OptionFormat:   &format,
+		OptionOutDir:   &outDir,
+		OptionLanguage: &language,
+	}
+	_, err := cm.RunCommand("gen-docs", []string{}, options)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(filepath.Join(outDir, "ossutil-cp.1"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(string(data), ".TH OSSUTIL-CP 1"), Equals, true)
+	c.Assert(strings.Contains(string(data), "ossutil-cp \\- "+specEnglishCopy.synopsisText), Equals, true)
+	c.Assert(strings.Contains(string(data), "\\fB\\-r, \\-\\-recursive\\fR"), Equals, true)
+	data, err = ioutil.ReadFile(filepath.Join(outDir, "ossutil.1"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "\\fBossutil\\-gen\\-docs\\fR(1)"), Equals, true)
+
+	format = "markdown"
+	_, err = cm.RunCommand("gen-docs", []string{}, options)
+	c.Assert(err, IsNil)
+	data, err = ioutil.ReadFile(filepath.Join(outDir, "ls.md"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(string(data), "# ossutil ls\n\n"+specEnglishList.synopsisText), Equals, true)
+	c.Assert(strings.Contains(string(data), "| `-s, --short-format` |"), Equals, true)
+	data, err = ioutil.ReadFile(filepath.Join(outDir, "README.md"))
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "| [ls](ls.md) |"), Equals, true)
+
+	format = "pdf"
+	_, err = cm.RunCommand("gen-docs", []string{}, options)
+	c.Assert(err, NotNil)
+}
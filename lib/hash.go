@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"fmt"
@@ -112,13 +113,11 @@ type HashCommand struct {
 
 var hashCommand = HashCommand{
 	command: Command{
-		name:        "hash",
-		nameAlias:   []string{""},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseHash,
-		specEnglish: specEnglishHash,
-		group:       GroupTypeAdditionalCommand,
+		name:      "hash",
+		nameAlias: []string{""},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
 		validOptionNames: []string{
 			OptionHashType,
 			OptionLogLevel,
@@ -146,8 +145,8 @@ func (hc *HashCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (hc *HashCommand) Init(args []string, options OptionMapType) error {
-	return hc.command.Init(args, options, hc)
+func (hc *HashCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return hc.command.Init(ctx, args, options, hc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
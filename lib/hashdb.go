@@ -0,0 +1,428 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	leveldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var specChineseHashDB = SpecText{
+	synopsisText: "维护本地文件crc64的数据库，并使用它校验本地文件与oss上的objects",
+
+	paramText: "build|verify local_dir [cloud_url] [options]",
+
+	syntaxText: `
+    ossutil hashdb build local_dir [--hashdb path] [-j jobs]
+    ossutil hashdb verify local_dir oss://bucket[/prefix] [--hashdb path] [-j jobs] [--payer requester]
+`,
+
+	detailHelpText: `
+    该命令在本地leveldb数据库中记录文件的大小、修改时间和crc64，key为文件的绝对路径。文件的大小和
+    修改时间没有变化时，直接使用数据库中的crc64，不再重新读取文件计算，使得对大量数据反复进行的
+    完整性校验只需要读取变化过的文件。数据库的路径由--hashdb选项指定，默认为当前目录下的.ossutil_hashdb。
+
+    hashdb命令的第一个参数为子命令：
+
+    1) build: 扫描local_dir下的所有文件，计算新增和变化过的文件的crc64并写入数据库，同时删除数据库中
+       local_dir下已经不存在的文件的记录。
+
+    2) verify: 将local_dir下的文件与cloud_url下的objects进行比较，object的名称为cloud_url加上文件相对
+       于local_dir的路径，比较大小以及oss上存储的crc64，数据库会随之更新。输出的每行为一个不一致的
+       object：
+           missing<TAB>object url            object不存在
+           mismatch<TAB>object url<TAB>原因   大小或者crc64不一致
+       存在不一致时命令返回错误。
+
+    sync命令指定--checksum和--hashdb选项时同样使用该数据库，见sync命令的帮助。
+`,
+
+	sampleText: `
+    1) 每晚更新本地目录的crc64数据库
+       ossutil hashdb build /data --hashdb /var/lib/ossutil/hashdb
+
+    2) 校验本地目录与oss上的备份是否一致
+       ossutil hashdb verify /data oss://bucket/backup/data/ --hashdb /var/lib/ossutil/hashdb
+`,
+}
+
+var specEnglishHashDB = SpecText{
+	synopsisText: "Maintain the database of the crc64 of the local files, and verify the local files with the objects by it",
+
+	paramText: "build|verify local_dir [cloud_url] [options]",
+
+	syntaxText: `
+    ossutil hashdb build local_dir [--hashdb path] [-j jobs]
+    ossutil hashdb verify local_dir oss://bucket[/prefix] [--hashdb path] [-j jobs] [--payer requester]
+`,
+
+	detailHelpText: `
+    The command records the size, the modified time and the crc64 of the files in the local leveldb
+    database, the key is the absolute path of the file. If the size and the modified time of a file
+    are unchanged, the crc64 in the database is used without reading the file again, so that the
+    repeated integrity checks of a large amount of data only read the changed files. The path of the
+    database is specified by --hashdb option, default is .ossutil_hashdb in the current directory.
+
+    The first argument of hashdb command is the sub command:
+
+    1) build: scan all the files under local_dir, calculate the crc64 of the new and the changed files
+       and write them to the database, the records of the files under local_dir which don't exist
+       any more are removed from the database.
+
+    2) verify: compare the files under local_dir with the objects under cloud_url, the object name is
+       cloud_url followed by the path of the file relative to local_dir, the size and the crc64 stored
+       in oss are compared, and the database is updated along the way. Each line of the output is an
+       object which doesn't match:
+           missing<TAB>object url               the object doesn't exist
+           mismatch<TAB>object url<TAB>reason   the size or the crc64 is different
+       The command returns error if there is any object which doesn't match.
+
+    sync command uses the database too with --checksum and --hashdb options, see the help of sync.
+`,
+
+	sampleText: `
+    1) update the crc64 database of the local directory every night
+       ossutil hashdb build /data --hashdb /var/lib/ossutil/hashdb
+
+    2) verify the local directory with the backup in oss
+       ossutil hashdb verify /data oss://bucket/backup/data/ --hashdb /var/lib/ossutil/hashdb
+`,
+}
+
+// hashDB caches the crc64 of the local files in leveldb, the key is the absolute path of the file,
+// the cached crc64 is valid as long as the size and the modified time of the file are unchanged
+type hashDB struct {
+	db        *leveldb.DB
+	hashedNum int64
+	reusedNum int64
+}
+
+func openHashDB(path string) (*hashDB, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open hashdb %s error, reason: %s", path, err.Error())
+	}
+	return &hashDB{db: db}, nil
+}
+
+func (h *hashDB) close() {
+	h.db.Close()
+}
+
+// fileCRC64 returns the crc64 of the file, it's calculated and saved only if the file is changed
+func (h *hashDB) fileCRC64(absPath string, info os.FileInfo) (string, error) {
+	stamp := fmt.Sprintf("%d:%d:", info.Size(), info.ModTime().UnixNano())
+	if value, err := h.db.Get([]byte(absPath), nil); err == nil && strings.HasPrefix(string(value), stamp) {
+		atomic.AddInt64(&h.reusedNum, 1)
+		return strings.TrimPrefix(string(value), stamp), nil
+	}
+
+	crc, err := fileCRC64(absPath)
+	if err != nil {
+		return "", err
+	}
+	atomic.AddInt64(&h.hashedNum, 1)
+	return crc, h.db.Put([]byte(absPath), []byte(stamp+crc), nil)
+}
+
+// prune removes the records of the files under dir which don't exist any more
+func (h *hashDB) prune(dir string) (int64, error) {
+	var removedNum int64
+	iter := h.db.NewIterator(util.BytesPrefix([]byte(strings.TrimSuffix(dir, string(os.PathSeparator))+string(os.PathSeparator))), nil)
+	defer iter.Release()
+	for iter.Next() {
+		path := string(iter.Key())
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			continue
+		} else if !os.IsNotExist(err) && err != nil {
+			return removedNum, err
+		}
+		if err := h.db.Delete([]byte(path), nil); err != nil {
+			return removedNum, err
+		}
+		removedNum++
+	}
+	return removedNum, iter.Error()
+}
+
+func fileCRC64(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := crc64.New(crc64ECMATable)
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(hash.Sum64(), 10), nil
+}
+
+type hashDBOptionType struct {
+	checkedNum  int64
+	missingNum  int64
+	mismatchNum int64
+	db          *hashDB
+	outMu       sync.Mutex
+}
+
+type HashDBCommand struct {
+	command  Command
+	hdOption hashDBOptionType
+}
+
+var hashDBCommand = HashDBCommand{
+	command: Command{
+		name:      "hashdb",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   3,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionHashDB,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (hc *HashDBCommand) formatHelpForWhole() string {
+	return hc.command.formatHelpForWhole()
+}
+
+func (hc *HashDBCommand) formatIndependHelp() string {
+	return hc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (hc *HashDBCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return hc.command.Init(ctx, args, options, hc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (hc *HashDBCommand) RunCommand() error {
+	action := strings.ToLower(hc.command.args[0])
+	if action != "build" && action != "verify" {
+		return fmt.Errorf("the sub command %s is not in the optional value:build|verify", hc.command.args[0])
+	}
+	if action == "build" && len(hc.command.args) != 2 {
+		return CommandError{hc.command.name, "build needs the local directory only"}
+	}
+	if action == "verify" && len(hc.command.args) != 3 {
+		return CommandError{hc.command.name, "verify needs the local directory and the cloud url"}
+	}
+
+	dir, err := filepath.Abs(hc.command.args[1])
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", hc.command.args[1])
+	}
+
+	dbPath, _ := GetString(OptionHashDB, hc.command.options)
+	if dbPath == "" {
+		dbPath = DefaultHashDBDir
+	}
+	hc.hdOption = hashDBOptionType{}
+	if hc.hdOption.db, err = openHashDB(dbPath); err != nil {
+		return err
+	}
+	defer hc.hdOption.db.close()
+
+	if action == "build" {
+		return hc.build(dir)
+	}
+	return hc.verify(dir)
+}
+
+func (hc *HashDBCommand) build(dir string) error {
+	err := hc.walk(dir, func(path string, info os.FileInfo) error {
+		_, err := hc.hdOption.db.fileCRC64(path, info)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	removedNum, err := hc.hdOption.db.prune(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("hashed:%d\treused:%d\tremoved:%d\n", hc.hdOption.db.hashedNum, hc.hdOption.db.reusedNum, removedNum)
+	return nil
+}
+
+func (hc *HashDBCommand) verify(dir string) error {
+	cloudURL, err := GetCloudUrl(hc.command.args[2], "")
+	if err != nil {
+		return err
+	}
+	prefix := cloudURL.object
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	var payerOptions []oss.Option
+	payer, _ := GetString(OptionRequestPayer, hc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		payerOptions = append(payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+	bucket, err := hc.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return err
+	}
+
+	err = hc.walk(dir, func(path string, info os.FileInfo) error {
+		rel, _ := filepath.Rel(dir, path)
+		objectName := prefix + filepath.ToSlash(rel)
+		objectURL := bucketObjectURL(bucket, objectName)
+		atomic.AddInt64(&hc.hdOption.checkedNum, 1)
+
+		props, err := hc.command.ossGetObjectStatRetry(bucket, objectName, payerOptions...)
+		if err != nil {
+			if isNotFound(err) {
+				atomic.AddInt64(&hc.hdOption.missingNum, 1)
+				hc.output("missing", objectURL, "")
+				return nil
+			}
+			return err
+		}
+		if size, _ := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64); size != info.Size() {
+			atomic.AddInt64(&hc.hdOption.mismatchNum, 1)
+			hc.output("mismatch", objectURL, fmt.Sprintf("size %d != %d", info.Size(), size))
+			return nil
+		}
+		serverCRC := props.Get(oss.HTTPHeaderOssCRC64)
+		if serverCRC == "" {
+			return nil
+		}
+		crc, err := hc.hdOption.db.fileCRC64(path, info)
+		if err != nil {
+			return err
+		}
+		if crc != serverCRC {
+			atomic.AddInt64(&hc.hdOption.mismatchNum, 1)
+			hc.output("mismatch", objectURL, fmt.Sprintf("crc64 %s != %s", crc, serverCRC))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("checked file count:%d\tmissing:%d\tmismatch:%d\thashed:%d\treused:%d\n", hc.hdOption.checkedNum,
+		hc.hdOption.missingNum, hc.hdOption.mismatchNum, hc.hdOption.db.hashedNum, hc.hdOption.db.reusedNum)
+	if hc.hdOption.missingNum > 0 || hc.hdOption.mismatchNum > 0 {
+		return fmt.Errorf("hashdb verification failed, %d missing, %d mismatch", hc.hdOption.missingNum, hc.hdOption.mismatchNum)
+	}
+	return nil
+}
+
+// walk calls handle for the regular files under dir concurrently, it returns the first error
+func (hc *HashDBCommand) walk(dir string, handle func(path string, info os.FileInfo) error) error {
+	routines, _ := GetInt(OptionRoutines, hc.command.options)
+	if routines <= 0 {
+		routines = int64(Routines)
+	}
+
+	type walkFile struct {
+		path string
+		info os.FileInfo
+	}
+	chFiles := make(chan walkFile, ChannelBuf)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var handleErr error
+	done := make(chan struct{})
+	for i := 0; int64(i) < routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range chFiles {
+				if err := handle(file.path, file.info); err != nil {
+					once.Do(func() {
+						handleErr = err
+						close(done)
+					})
+				}
+			}
+		}()
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		select {
+		case chFiles <- walkFile{path, info}:
+			return nil
+		case <-done:
+			// the walk is stopped by the error of handle, which is returned below
+			return io.EOF
+		}
+	})
+	close(chFiles)
+	wg.Wait()
+	if handleErr != nil {
+		return handleErr
+	}
+	return err
+}
+
+func (hc *HashDBCommand) output(kind, objectURL, reason string) {
+	hc.hdOption.outMu.Lock()
+	defer hc.hdOption.outMu.Unlock()
+	if reason != "" {
+		fmt.Printf("%s\t%s\t%s\n", kind, objectURL, reason)
+	} else {
+		fmt.Printf("%s\t%s\n", kind, objectURL)
+	}
+}
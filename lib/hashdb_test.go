@@ -0,0 +1,130 @@
+package lib
+
+import (
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestHashDB(c *C) {
+	dir := "ossutil-test-hashdb-" + randLowStr(8)
+	dbPath := dir + "-db"
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	defer os.RemoveAll(dir)
+	defer os.RemoveAll(dbPath)
+	s.createFile(filepath.Join(dir, "a"), "aaa", c)
+	s.createFile(filepath.Join(dir, "sub", "b"), "bbbbb", c)
+	s.createFile(filepath.Join(dir, "c"), "ccccccc", c)
+
+	// the objects uploaded, key -> data
+	var mu sync.Mutex
+	objects := map[string]string{}
+	puts := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := r.URL.Path[1:]
+		switch r.Method {
+		case "PUT":
+			body, _ := ioutil.ReadAll(r.Body)
+			objects[key] = string(body)
+			if !strings.HasSuffix(key, "/") {
+				puts = append(puts, key)
+			}
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum(body, crc64ECMATable), 10))
+		case "HEAD":
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionHashDB:          &dbPath,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	_, err = cm.RunCommand("hashdb", []string{"rebuild", dir}, options)
+	c.Assert(err, NotNil)
+	_, err = cm.RunCommand("hashdb", []string{"verify", dir}, options)
+	c.Assert(err, NotNil)
+
+	_, err = cm.RunCommand("hashdb", []string{"build", dir}, options)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "hashed:3\treused:0\tremoved:0"), Equals, true)
+
+	// only the changed file is hashed again, and the removed file is pruned
+	s.createFile(filepath.Join(dir, "a"), "aaaa", c)
+	os.Chtimes(filepath.Join(dir, "a"), time.Now(), time.Now().Add(time.Hour))
+	os.Remove(filepath.Join(dir, "c"))
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("hashdb", []string{"build", dir}, options)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "hashed:1\treused:1\tremoved:1"), Equals, true)
+
+	// verify with the objects
+	objects["bucket/backup/a"] = "aaaa"
+	objects["bucket/backup/sub/b"] = "bbbbx"
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("hashdb", []string{"verify", dir, "oss://bucket/backup"}, options)
+	c.Assert(err, NotNil)
+	output := s.readFile(resultPath, c)
+	c.Assert(strings.Contains(output, "mismatch\toss://bucket/backup/sub/b\tcrc64 "), Equals, true)
+	c.Assert(strings.Contains(output, "hashed:0\treused:2"), Equals, true)
+
+	// upload with --checksum, the unchanged file is skipped
+	recursive := true
+	force := true
+	checksum := true
+	cpDir := dir + "-checkpoint"
+	outputDir := dir + "-output"
+	defer os.RemoveAll(cpDir)
+	defer os.RemoveAll(outputDir)
+	routines := strconv.Itoa(Routines)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	options[OptionRecursion] = &recursive
+	options[OptionForce] = &force
+	options[OptionCheckpointDir] = &cpDir
+	options[OptionOutputDir] = &outputDir
+	options[OptionRoutines] = &routines
+	options[OptionBigFileThreshold] = &threshold
+	options[OptionChecksum] = &checksum
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/backup/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(puts, DeepEquals, []string{"bucket/backup/sub/b"})
+	c.Assert(objects["bucket/backup/sub/b"], Equals, "bbbbb")
+
+	options[OptionUpdate] = &force
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/backup/"}, options)
+	c.Assert(err, NotNil)
+}
@@ -1,8 +1,11 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -21,7 +24,7 @@ var specChineseHelp = SpecText{
 	paramText: "[command]",
 
 	syntaxText: ` 
-    ossutil help [command]
+    ossutil help [command] [--search keywords]
 `,
 
 	detailHelpText: ` 
@@ -29,19 +32,26 @@ var specChineseHelp = SpecText{
 
 用法：
 
-    该命令有两种用法：
+    该命令有三种用法：
 
     1) ossutil help
         该用法提供ossutil支持的所有命令的简介，对每个命令显示该命令的摘要和语法简介。
 
     2) ossutil help command
         该用法提供指定命令(command)的帮助文档，包括该命令的详细介绍、示例、可选参数。
+
+    3) ossutil help --search keywords
+        该用法在所有命令的名称、摘要、语法、详细介绍、示例以及所有选项的说明中搜索关键词（不区分
+    大小写），输出包含全部关键词的命令和选项，按匹配程度排序，名称和摘要中的匹配优先。对于命令，
+    同时输出匹配的行；对于选项，同时输出支持该选项的命令。
 `,
 
 	sampleText: ` 
     ossutil help
     ossutil help help
     ossutil help ls
+    ossutil help --search lifecycle
+    ossutil help --search "storage class"
 `,
 }
 
@@ -52,7 +62,7 @@ var specEnglishHelp = SpecText{
 	paramText: "[command]",
 
 	syntaxText: ` 
-    ossutil help [command]
+    ossutil help [command] [--search keywords]
 `,
 
 	detailHelpText: ` 
@@ -61,7 +71,7 @@ var specEnglishHelp = SpecText{
 
 Usage:
 
-    There are two usages:
+    There are three usages:
 
     1) ossutil help
         The usage provides a summary of all commands, each command shows the
@@ -70,12 +80,21 @@ Usage:
     2) ossutil help command
         The usage provides help about the specified command, which contains
     a detailed description of the command, include samples and optional options.
+
+    3) ossutil help --search keywords
+        The usage searches the keywords(case insensitive) in the name, synopsis, 
+    syntax, detail and samples of all commands and the descriptions of all options, 
+    outputs the commands and options which contain all the keywords, ranked by 
+    relevance, the matches in name and synopsis come first. For commands, the 
+    matched lines are shown, for options, the commands supporting the option are shown.
 `,
 
 	sampleText: ` 
     ossutil help
     ossutil help help
     ossutil help ls
+    ossutil help --search lifecycle
+    ossutil help --search "storage class"
 `,
 }
 
@@ -86,20 +105,29 @@ type HelpCommand struct {
 
 var helpCommand = HelpCommand{
 	command: Command{
-		name:        "help",
-		nameAlias:   []string{},
-		minArgc:     0,
-		maxArgc:     1,
-		specChinese: specChineseHelp,
-		specEnglish: specEnglishHelp,
-		group:       GroupTypeAdditionalCommand,
+		name:      "help",
+		nameAlias: []string{},
+		minArgc:   0,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
 		validOptionNames: []string{
 			OptionLanguage,
+			OptionSearch,
 			OptionLogLevel,
 		},
 	},
 }
 
+// maxSearchMatchedLines is the max matched lines shown for a command in the search result
+const maxSearchMatchedLines = 3
+
+// helpSearchResult is a command or an option matching the keywords of help --search
+type helpSearchResult struct {
+	name  string
+	score int
+	text  string
+}
+
 // function for RewriteLoadConfiger interface
 func (hc *HelpCommand) rewriteLoadConfig(configFile string) error {
 	// read config file, if error exist, do not print error
@@ -120,13 +148,20 @@ func (hc *HelpCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (hc *HelpCommand) Init(args []string, options OptionMapType) error {
-	return hc.command.Init(args, options, hc)
+func (hc *HelpCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return hc.command.Init(ctx, args, options, hc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
 func (hc *HelpCommand) RunCommand() error {
 	groupCommandMap, subCommandMap := hc.getCommandMap()
+	if search, _ := GetString(OptionSearch, hc.command.options); search != "" {
+		if len(hc.command.args) != 0 {
+			return fmt.Errorf("--search can't be used with command: %s", hc.command.args[0])
+		}
+		Output(hc.searchHelp(search))
+		return nil
+	}
 	if len(hc.command.args) == 0 {
 		// ossutil help
 		text := hc.formatWholeHelp(groupCommandMap)
@@ -155,6 +190,17 @@ func (hc *HelpCommand) getCommandMap() (map[string][]interface{}, map[string]int
 	return groupCommandMap, subCommandMap
 }
 
+// commandInfo returns the name and the valid option names of the command
+func commandInfo(cmd interface{}) (string, []string) {
+	command := reflect.ValueOf(cmd).Elem().FieldByName("command")
+	validOptionNames := command.FieldByName("validOptionNames")
+	names := make([]string, 0, validOptionNames.Len())
+	for i := 0; i < validOptionNames.Len(); i++ {
+		names = append(names, validOptionNames.Index(i).String())
+	}
+	return command.FieldByName("name").String(), names
+}
+
 func (hc *HelpCommand) formatWholeHelp(groupCommandMap map[string][]interface{}) string {
 	if len(groupCommandMap) == 0 {
 		return ""
@@ -177,13 +223,7 @@ func (hc *HelpCommand) formatWholeHelp(groupCommandMap map[string][]interface{})
 
 func (hc *HelpCommand) getUsageText() string {
 	val, _ := GetString(OptionLanguage, helpCommand.command.options)
-	switch strings.ToLower(val) {
-	case LEnglishLanguage:
-		return UsageTextEnglish
-	default:
-		return UsageTextChinese
-	}
-
+	return getUsageText(val)
 }
 
 func (hc *HelpCommand) formatCommandHelp(subCommandMap map[string]interface{}) (string, error) {
@@ -193,3 +233,110 @@ func (hc *HelpCommand) formatCommandHelp(subCommandMap map[string]interface{}) (
 	}
 	return "", fmt.Errorf("no such command: \"%s\", please try \"help\" for more information", subCommandName)
 }
+
+// searchHelp searches the keywords in the help text of all commands and options
+func (hc *HelpCommand) searchHelp(search string) string {
+	keywords := strings.Fields(strings.ToLower(search))
+	language, _ := GetString(OptionLanguage, hc.command.options)
+
+	commandResults := []helpSearchResult{}
+	optionCommands := map[string][]string{}
+	for _, cmd := range GetAllCommands() {
+		commandName, validOptionNames := commandInfo(cmd)
+		for _, name := range validOptionNames {
+			optionCommands[name] = append(optionCommands[name], commandName)
+		}
+
+		spec := getSpecText(language, commandName)
+		score, lines := 0, []string{}
+		for _, keyword := range keywords {
+			keywordScore := 10*strings.Count(strings.ToLower(commandName), keyword) +
+				5*strings.Count(strings.ToLower(spec.synopsisText), keyword)
+			for _, text := range []string{spec.syntaxText, spec.detailHelpText, spec.sampleText} {
+				for _, line := range strings.Split(text, "\n") {
+					if count := strings.Count(strings.ToLower(line), keyword); count > 0 {
+						keywordScore += count
+						lines = appendSearchLine(lines, strings.TrimSpace(line))
+					}
+				}
+			}
+			if keywordScore == 0 {
+				score = 0
+				break
+			}
+			score += keywordScore
+		}
+		if score == 0 {
+			continue
+		}
+
+		text := fmt.Sprintf("  %-"+strconv.Itoa(MaxCommandNameLen)+"s%s\n", commandName, strings.TrimSpace(spec.synopsisText))
+		for _, line := range lines {
+			text += fmt.Sprintf("%s%s%s\n", FormatTAB, FormatTAB, line)
+		}
+		commandResults = append(commandResults, helpSearchResult{commandName, score, text})
+	}
+
+	optionResults := []helpSearchResult{}
+	for name, option := range OptionMap {
+		help := option.getHelp(language)
+		score := 0
+		for _, keyword := range keywords {
+			keywordScore := 10*strings.Count(strings.ToLower(option.nameAlias), keyword) + strings.Count(strings.ToLower(help), keyword)
+			if keywordScore == 0 {
+				score = 0
+				break
+			}
+			score += keywordScore
+		}
+		if score == 0 {
+			continue
+		}
+
+		commands := optionCommands[name]
+		sort.Strings(commands)
+		text := strings.TrimRight(hc.command.formatOption(option), "\n") + "\n"
+		if len(commands) > 0 {
+			text += fmt.Sprintf("%s%scommands: %s\n", FormatTAB, FormatTAB, strings.Join(commands, ", "))
+		}
+		text += "\n"
+		optionResults = append(optionResults, helpSearchResult{option.nameAlias, score, text})
+	}
+
+	if len(commandResults) == 0 && len(optionResults) == 0 {
+		return fmt.Sprintf("no command or option matches \"%s\".", search)
+	}
+	text := ""
+	if len(commandResults) > 0 {
+		text += "COMMANDS\n\n" + joinSearchResults(commandResults)
+	}
+	if len(optionResults) > 0 {
+		if text != "" {
+			text += "\n"
+		}
+		text += "OPTIONS\n\n" + joinSearchResults(optionResults)
+	}
+	return strings.TrimRight(text, "\n")
+}
+
+func appendSearchLine(lines []string, line string) []string {
+	if len(lines) >= maxSearchMatchedLines || FindPos(line, lines) != -1 {
+		return lines
+	}
+	return append(lines, line)
+}
+
+// joinSearchResults sorts the results by score descending, then by name
+func joinSearchResults(results []helpSearchResult) string {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].name < results[j].name
+	})
+	text := ""
+	for _, result := range results {
+		text += result.text
+	}
+	return text
+}
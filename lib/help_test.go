@@ -3,6 +3,7 @@ package lib
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	. "gopkg.in/check.v1"
 )
@@ -176,3 +177,27 @@ func (s *OssutilHelpSuite) TestHelpLoadConfig(c *C) {
 	err = helpCommand.rewriteLoadConfig(configFile)
 	c.Assert(err, IsNil)
 }
+
+func (s *OssutilHelpSuite) TestHelpSearch(c *C) {
+	language := EnglishLanguage
+	hc := HelpCommand{command: Command{options: OptionMapType{OptionLanguage: &language}}}
+	text := hc.searchHelp("LIFECYCLE")
+	c.Assert(strings.HasPrefix(text, "COMMANDS\n\n  lifecycle "), Equals, true)
+	c.Assert(strings.Contains(text, "--trash-days"), Equals, true)
+	c.Assert(strings.Contains(text, "commands: rm"), Equals, true)
+
+	// all the keywords should match
+	text = hc.searchHelp("lifecycle trash-days")
+	c.Assert(strings.Contains(text, "  lifecycle "), Equals, false)
+	c.Assert(strings.Contains(text, "  rm "), Equals, true)
+
+	text = hc.searchHelp("no-such-keyword")
+	c.Assert(text, Equals, `no command or option matches "no-such-keyword".`)
+
+	search := "lifecycle"
+	options := OptionMapType{OptionSearch: &search}
+	_, err := cm.RunCommand("help", []string{}, options)
+	c.Assert(err, IsNil)
+	_, err = cm.RunCommand("help", []string{"ls"}, options)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,817 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	leveldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var specChineseIndex = SpecText{
+	synopsisText: "将bucket或者指定前缀下objects的元数据保存到本地索引，并在索引中查询objects",
+
+	paramText: "build|query cloud_url|index_path [predicate...] [options]",
+
+	syntaxText: `
+    ossutil index build oss://bucket[/prefix] [--fields meta,tags,size,mtime] [-o index_path] [-j jobs] [--payer requester]
+    ossutil index query index_path [predicate...] [--limited-num num] [-0] [--output json|yaml]
+`,
+
+	detailHelpText: `
+    该命令将bucket或者指定前缀下objects的元数据保存到本地leveldb数据库中，之后在本地反复进行的
+    查询不再需要列举bucket和获取每个object的meta。
+
+    index命令的第一个参数为子命令：
+
+    1) build: 列举cloud_url下的所有objects，将--fields指定的字段写入-o指定的索引(默认为当前目录下的
+       .ossutil_index)。--fields的取值为以下字段的组合，以逗号分隔，默认为size,mtime：
+           size    object的大小
+           mtime   object的最后修改时间
+           meta    用户自定义meta(X-Oss-Meta-*)，需要对每个object发送HeadObject请求
+           tags    object的标签，需要对每个object发送GetObjectTagging请求
+       对同一个bucket重复build时，etag和最后修改时间未变化的objects直接使用索引中的meta和标签，
+       不再发送请求，已经不存在的objects会从索引中删除。-j指定并发获取meta和标签的任务数。
+
+    2) query: 在index_path指定的索引中查询同时满足所有条件的objects，按名称顺序每行输出一个object的
+       cloud_url。条件的格式为"字段 操作符 值"：
+           key         object名称，操作符为=、!=、<、<=、>、>=，或者~表示通配符匹配(*匹配任意字符)
+           size        object大小，值可以带KB、MB、GB或TB单位，如size>=10MB
+           mtime       最后修改时间，值可以是日期(2006-01-02，本地时间)、RFC3339时间、http date或者
+                       unix时间戳，如mtime<2024-01-01
+           meta.名称   用户自定义meta的值，名称不区分大小写且不含X-Oss-Meta-前缀，如meta.owner=alice
+           tags.键     标签的值，如tags.env=prod
+       只写meta.名称或者tags.键(不带操作符和值)表示object存在该meta或者标签，不存在该meta或者标签的
+       objects不满足任何比较。只能查询build时--fields指定的字段，不指定条件时输出索引中的所有objects。
+       --limited-num指定最多输出的objects个数，-0表示每个cloud_url以NUL字符结尾，--output json或者yaml
+       表示每个object输出为一行json或者一个yaml文档，这些情况下统计信息输出到stderr。
+`,
+
+	sampleText: `
+    1) 建立包含meta和标签的索引
+       ossutil index build oss://bucket/data/ --fields meta,tags,size,mtime -o data.index
+
+    2) 查询owner为alice并且大于100MB的objects
+       ossutil index query data.index "meta.owner=alice" "size>100MB"
+
+    3) 查询2024年之前修改的jpg文件，以json格式输出
+       ossutil index query data.index "key~*.jpg" "mtime<2024-01-01" --output json
+
+    4) 删除标签env为test的objects
+       ossutil index query data.index "tags.env=test" -0 | ossutil rm oss://bucket -r -f --null-input
+`,
+}
+
+var specEnglishIndex = SpecText{
+	synopsisText: "Save the metadata of the objects in the bucket or under the prefix to a local index, and query the objects in the index",
+
+	paramText: "build|query cloud_url|index_path [predicate...] [options]",
+
+	syntaxText: `
+    ossutil index build oss://bucket[/prefix] [--fields meta,tags,size,mtime] [-o index_path] [-j jobs] [--payer requester]
+    ossutil index query index_path [predicate...] [--limited-num num] [-0] [--output json|yaml]
+`,
+
+	detailHelpText: `
+    The command saves the metadata of the objects in the bucket or under the prefix to a local
+    leveldb database, so that the repeated queries don't list the bucket and get the meta of
+    every object again.
+
+    The first argument of index command is the sub command:
+
+    1) build: list all the objects under cloud_url, and write the fields specified by --fields
+       to the index specified by -o(default is .ossutil_index in the current directory). The
+       value of --fields is the comma separated combination of the fields below, the default
+       is size,mtime:
+           size    the size of the object
+           mtime   the last modified time of the object
+           meta    the user meta(X-Oss-Meta-*), a HeadObject request is sent for every object
+           tags    the tags of the object, a GetObjectTagging request is sent for every object
+       When the index of the same bucket is built again, the meta and the tags in the index are
+       used for the objects whose etag and last modified time are unchanged without sending the
+       requests, and the objects which don't exist any more are removed from the index. -j
+       specifies the concurrent tasks getting the meta and the tags.
+
+    2) query: query the objects matching all the predicates in the index specified by
+       index_path, the cloud_url of an object is output per line in the order of the names.
+       The format of a predicate is "field operator value":
+           key         the object name, the operator is =, !=, <, <=, > or >=, or ~ for the
+                       wildcard matching(* matches any characters)
+           size        the object size, the value can have the unit of KB, MB, GB or TB,
+                       e.g., size>=10MB
+           mtime       the last modified time, the value can be date(2006-01-02, local time),
+                       RFC3339 time, http date or unix timestamp, e.g., mtime<2024-01-01
+           meta.name   the value of the user meta, the name is case insensitive without the
+                       X-Oss-Meta- prefix, e.g., meta.owner=alice
+           tags.key    the value of the tag, e.g., tags.env=prod
+       meta.name or tags.key only(without the operator and the value) means the object has the
+       meta or the tag, the objects without the meta or the tag don't match any comparison. Only
+       the fields specified by --fields of build can be queried, all the objects in the index
+       are output if there is no predicate. --limited-num specifies the max number of the
+       objects to output, -0 means every cloud_url ends with NUL character, --output json or
+       yaml means every object is output as a line of json or a yaml document, the statistics
+       are output to stderr in these cases.
+`,
+
+	sampleText: `
+    1) build the index with the meta and the tags
+       ossutil index build oss://bucket/data/ --fields meta,tags,size,mtime -o data.index
+
+    2) query the objects whose owner is alice and larger than 100MB
+       ossutil index query data.index "meta.owner=alice" "size>100MB"
+
+    3) query the jpg files modified before 2024 in json
+       ossutil index query data.index "key~*.jpg" "mtime<2024-01-01" --output json
+
+    4) remove the objects whose tag env is test
+       ossutil index query data.index "tags.env=test" -0 | ossutil rm oss://bucket -r -f --null-input
+`,
+}
+
+// the fields of --fields
+const (
+	indexFieldSize  = "size"
+	indexFieldMtime = "mtime"
+	indexFieldMeta  = "meta"
+	indexFieldTags  = "tags"
+)
+
+// the keys of the index database, the records of the objects are keyed by the prefix and the object name
+const (
+	indexInfoKey      = "info"
+	indexRecordPrefix = "object/"
+)
+
+// indexInfo describes the index, the index can be queried only if the build is complete
+type indexInfo struct {
+	Bucket      string    `json:"bucket"`
+	Prefix      string    `json:"prefix"`
+	Fields      []string  `json:"fields"`
+	Generation  int64     `json:"generation"`
+	BuildTime   time.Time `json:"buildTime"`
+	ObjectCount int64     `json:"objectCount"`
+	Complete    bool      `json:"complete"`
+}
+
+func (info *indexInfo) hasField(field string) bool {
+	return FindPos(field, info.Fields) != -1
+}
+
+// indexRecord is the metadata of an object in the index, Meta and Tags are nil if they are not got,
+// Generation is the build which saw the object last time, the records of the old builds are removed
+type indexRecord struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	LastModified time.Time         `json:"lastModified"`
+	ETag         string            `json:"etag"`
+	Meta         map[string]string `json:"meta"`
+	Tags         map[string]string `json:"tags"`
+	Generation   int64             `json:"generation"`
+}
+
+// indexEntry is an object of index query --output json, only the indexed fields are output
+type indexEntry struct {
+	URL          string            `json:"url"`
+	Key          string            `json:"key"`
+	Size         *int64            `json:"size,omitempty"`
+	LastModified *time.Time        `json:"lastModified,omitempty"`
+	Meta         map[string]string `json:"meta,omitempty"`
+	Tags         map[string]string `json:"tags,omitempty"`
+}
+
+// objectIndex is the local leveldb database of the metadata of the objects
+type objectIndex struct {
+	db *leveldb.DB
+}
+
+// openObjectIndex opens the index, it's created if create is true, otherwise the missing index is an error
+func openObjectIndex(path string, create bool) (*objectIndex, error) {
+	// leveldb creates the directory even if ErrorIfMissing is set
+	if _, err := os.Stat(path); err != nil && !create {
+		return nil, fmt.Errorf("open index %s error, reason: %s", path, err.Error())
+	}
+	db, err := leveldb.OpenFile(path, &opt.Options{ErrorIfMissing: !create})
+	if err != nil {
+		return nil, fmt.Errorf("open index %s error, reason: %s", path, err.Error())
+	}
+	return &objectIndex{db: db}, nil
+}
+
+func (idx *objectIndex) close() {
+	idx.db.Close()
+}
+
+// readInfo returns nil if the index is empty
+func (idx *objectIndex) readInfo() (*indexInfo, error) {
+	data, err := idx.db.Get([]byte(indexInfoKey), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	info := &indexInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("invalid index info, %s, please build the index again", err.Error())
+	}
+	return info, nil
+}
+
+func (idx *objectIndex) writeInfo(info *indexInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return idx.db.Put([]byte(indexInfoKey), data, nil)
+}
+
+// get returns nil if the object isn't in the index
+func (idx *objectIndex) get(key string) *indexRecord {
+	data, err := idx.db.Get([]byte(indexRecordPrefix+key), nil)
+	if err != nil {
+		return nil
+	}
+	record := &indexRecord{}
+	if json.Unmarshal(data, record) != nil {
+		return nil
+	}
+	return record
+}
+
+func (idx *objectIndex) put(record *indexRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return idx.db.Put([]byte(indexRecordPrefix+record.Key), data, nil)
+}
+
+// scan calls handle for the records in the order of the object names until handle returns false
+func (idx *objectIndex) scan(handle func(record *indexRecord) (bool, error)) error {
+	iter := idx.db.NewIterator(util.BytesPrefix([]byte(indexRecordPrefix)), nil)
+	defer iter.Release()
+	for iter.Next() {
+		record := &indexRecord{}
+		if err := json.Unmarshal(iter.Value(), record); err != nil {
+			return fmt.Errorf("invalid record %s in the index, %s, please build the index again", string(iter.Key()), err.Error())
+		}
+		if next, err := handle(record); err != nil || !next {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// prune removes the records not seen by the build of the generation
+func (idx *objectIndex) prune(generation int64) (int64, error) {
+	var removedNum int64
+	err := idx.scan(func(record *indexRecord) (bool, error) {
+		if record.Generation == generation {
+			return true, nil
+		}
+		removedNum++
+		return true, idx.db.Delete([]byte(indexRecordPrefix+record.Key), nil)
+	})
+	return removedNum, err
+}
+
+// indexPredicate is a predicate of index query, the op is empty if meta.name or tags.key only
+// needs to exist
+type indexPredicate struct {
+	field   string
+	name    string
+	op      string
+	value   string
+	size    int64
+	time    time.Time
+	pattern *regexp.Regexp
+}
+
+// the operators of the predicates, the two characters operators are checked first
+var indexOperators = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// parseIndexPredicate parses a predicate like size>10MB, meta.owner=alice or tags.env
+func parseIndexPredicate(str string) (indexPredicate, error) {
+	p := indexPredicate{}
+	field := str
+	if pos := strings.IndexAny(str, "!=<>~"); pos >= 0 {
+		field = str[:pos]
+		for _, op := range indexOperators {
+			if strings.HasPrefix(str[pos:], op) {
+				p.op = op
+				p.value = str[pos+len(op):]
+				break
+			}
+		}
+		if p.op == "" {
+			return p, fmt.Errorf("invalid predicate: %s, the operator should be =, !=, <, <=, >, >= or ~", str)
+		}
+	}
+	field = strings.TrimSpace(field)
+	p.value = strings.TrimSpace(p.value)
+
+	var err error
+	switch {
+	case field == "key":
+		p.field = field
+	case field == indexFieldSize:
+		p.field = field
+		if p.size, err = parseByteSize(p.value); err != nil {
+			return p, fmt.Errorf("invalid predicate: %s, the size should be the bytes with the optional unit of KB, MB, GB or TB", str)
+		}
+	case field == indexFieldMtime:
+		p.field = field
+		if p.time, err = parseTimeBound(p.value); err != nil {
+			return p, fmt.Errorf("invalid predicate: %s, %s", str, err.Error())
+		}
+	case strings.HasPrefix(field, indexFieldMeta+"."):
+		p.field = indexFieldMeta
+		p.name = strings.ToLower(strings.TrimPrefix(field, indexFieldMeta+"."))
+	case strings.HasPrefix(field, indexFieldTags+"."):
+		p.field = indexFieldTags
+		p.name = strings.TrimPrefix(field, indexFieldTags+".")
+	default:
+		return p, fmt.Errorf("invalid predicate: %s, the field should be key, size, mtime, meta.name or tags.key", str)
+	}
+	if p.op == "" && p.name == "" {
+		return p, fmt.Errorf("invalid predicate: %s, only meta.name and tags.key can be used without the operator", str)
+	}
+	if p.op == "~" {
+		if p.field == indexFieldSize || p.field == indexFieldMtime {
+			return p, fmt.Errorf("invalid predicate: %s, ~ only works with key, meta and tags", str)
+		}
+		expr := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(p.value))
+		p.pattern = regexp.MustCompile("^" + expr + "$")
+	}
+	return p, nil
+}
+
+func (p indexPredicate) match(record *indexRecord) bool {
+	switch p.field {
+	case "key":
+		return p.matchString(record.Key)
+	case indexFieldSize:
+		return compareResult(p.op, int64Compare(record.Size, p.size))
+	case indexFieldMtime:
+		return compareResult(p.op, timeCompare(record.LastModified, p.time))
+	}
+	values := record.Tags
+	if p.field == indexFieldMeta {
+		values = record.Meta
+	}
+	value, ok := values[p.name]
+	if !ok {
+		return false
+	}
+	return p.op == "" || p.matchString(value)
+}
+
+func (p indexPredicate) matchString(value string) bool {
+	if p.pattern != nil {
+		return p.pattern.MatchString(value)
+	}
+	return compareResult(p.op, strings.Compare(value, p.value))
+}
+
+func int64Compare(a, b int64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func timeCompare(a, b time.Time) int {
+	if a.Before(b) {
+		return -1
+	} else if a.After(b) {
+		return 1
+	}
+	return 0
+}
+
+// compareResult returns true if the result of the comparison satisfies the operator
+func compareResult(op string, result int) bool {
+	switch op {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	}
+	return false
+}
+
+// parseIndexFields parses the value of --fields, like meta,tags,size,mtime
+func parseIndexFields(str string) ([]string, error) {
+	if strings.TrimSpace(str) == "" {
+		return []string{indexFieldSize, indexFieldMtime}, nil
+	}
+	fields := []string{}
+	for _, field := range strings.Split(str, ",") {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field != indexFieldSize && field != indexFieldMtime && field != indexFieldMeta && field != indexFieldTags {
+			return nil, fmt.Errorf("invalid --fields: %s, the field should be %s, %s, %s or %s", str,
+				indexFieldMeta, indexFieldTags, indexFieldSize, indexFieldMtime)
+		}
+		if FindPos(field, fields) == -1 {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+/*
+ * Put same type variables together to make them 64bits alignment to avoid
+ * atomic.AddInt64() panic
+ */
+type indexOptionType struct {
+	indexedNum   int64
+	headNum      int64
+	taggingNum   int64
+	reusedNum    int64
+	errNum       int64
+	routines     int64
+	index        *objectIndex
+	info         *indexInfo
+	reuse        bool
+	payerOptions []oss.Option
+}
+
+type IndexCommand struct {
+	command     Command
+	indexOption indexOptionType
+}
+
+var indexCommand = IndexCommand{
+	command: Command{
+		name:      "index",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   MaxInt,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionFields,
+			OptionOutputFile,
+			OptionLimitedNum,
+			OptionPrint0,
+			OptionOutput,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (ic *IndexCommand) formatHelpForWhole() string {
+	return ic.command.formatHelpForWhole()
+}
+
+func (ic *IndexCommand) formatIndependHelp() string {
+	return ic.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (ic *IndexCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return ic.command.Init(ctx, args, options, ic)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (ic *IndexCommand) RunCommand() error {
+	// clear for go tests
+	ic.indexOption = indexOptionType{payerOptions: []oss.Option{}}
+
+	switch strings.ToLower(ic.command.args[0]) {
+	case "build":
+		if len(ic.command.args) != 2 {
+			return CommandError{ic.command.name, "build needs the cloud url only"}
+		}
+		return ic.build()
+	case "query":
+		return ic.query()
+	}
+	return fmt.Errorf("the sub command %s is not in the optional value:build|query", ic.command.args[0])
+}
+
+func (ic *IndexCommand) build() error {
+	encodingType, _ := GetString(OptionEncodingType, ic.command.options)
+	cloudURL, err := GetCloudUrl(ic.command.args[1], encodingType)
+	if err != nil {
+		return err
+	}
+	strFields, _ := GetString(OptionFields, ic.command.options)
+	fields, err := parseIndexFields(strFields)
+	if err != nil {
+		return err
+	}
+
+	payer, _ := GetString(OptionRequestPayer, ic.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		ic.indexOption.payerOptions = append(ic.indexOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+	ic.indexOption.routines, err = GetInt(OptionRoutines, ic.command.options)
+	if err != nil || ic.indexOption.routines <= 0 {
+		ic.indexOption.routines = int64(Routines)
+	}
+
+	bucket, err := ic.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return err
+	}
+
+	path, _ := GetString(OptionOutputFile, ic.command.options)
+	if path == "" {
+		path = DefaultIndexDir
+	}
+	if ic.indexOption.index, err = openObjectIndex(path, true); err != nil {
+		return err
+	}
+	defer ic.indexOption.index.close()
+
+	// the meta and the tags of the unchanged objects are reused from the index of the same bucket,
+	// the index is marked incomplete until the build finishes, so that it isn't queried half built
+	oldInfo, err := ic.indexOption.index.readInfo()
+	if err != nil {
+		return err
+	}
+	ic.indexOption.reuse = oldInfo != nil && oldInfo.Bucket == cloudURL.bucket
+	ic.indexOption.info = &indexInfo{
+		Bucket:     cloudURL.bucket,
+		Prefix:     cloudURL.object,
+		Fields:     fields,
+		Generation: time.Now().UnixNano(),
+		BuildTime:  time.Now(),
+	}
+	if err = ic.indexOption.index.writeInfo(ic.indexOption.info); err != nil {
+		return err
+	}
+
+	chObjects := make(chan oss.ObjectProperties, ChannelBuf)
+	var wg sync.WaitGroup
+	for i := int64(0); i < ic.indexOption.routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ic.indexConsumer(bucket, chObjects)
+		}()
+	}
+	listErr := ic.listObjects(bucket, cloudURL.object, chObjects)
+	close(chObjects)
+	wg.Wait()
+	if listErr != nil {
+		return listErr
+	}
+	if ic.indexOption.errNum > 0 {
+		return fmt.Errorf("index %d objects failed, please check the log and build the index again", ic.indexOption.errNum)
+	}
+
+	removedNum, err := ic.indexOption.index.prune(ic.indexOption.info.Generation)
+	if err != nil {
+		return err
+	}
+	ic.indexOption.info.ObjectCount = ic.indexOption.indexedNum
+	ic.indexOption.info.Complete = true
+	if err = ic.indexOption.index.writeInfo(ic.indexOption.info); err != nil {
+		return err
+	}
+	fmt.Printf("indexed objects: %d, head requests: %d, tagging requests: %d, reused: %d, removed: %d\n",
+		ic.indexOption.indexedNum, ic.indexOption.headNum, ic.indexOption.taggingNum, ic.indexOption.reusedNum, removedNum)
+	return nil
+}
+
+func (ic *IndexCommand) listObjects(bucket *oss.Bucket, prefix string, chObjects chan<- oss.ObjectProperties) error {
+	pre := oss.Prefix(prefix)
+	marker := oss.Marker("")
+	for {
+		listOptions := append(ic.indexOption.payerOptions, pre, marker, oss.MaxKeys(1000))
+		lor, err := ic.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			return err
+		}
+		for _, object := range lor.Objects {
+			chObjects <- object
+		}
+		pre = oss.Prefix(lor.Prefix)
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			return nil
+		}
+	}
+}
+
+func (ic *IndexCommand) indexConsumer(bucket *oss.Bucket, chObjects <-chan oss.ObjectProperties) {
+	info := ic.indexOption.info
+	for object := range chObjects {
+		record := &indexRecord{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+			ETag:         object.ETag,
+			Generation:   info.Generation,
+		}
+
+		var old *indexRecord
+		if ic.indexOption.reuse {
+			if old = ic.indexOption.index.get(object.Key); old != nil && (old.ETag != object.ETag || !old.LastModified.Equal(object.LastModified)) {
+				old = nil
+			}
+		}
+		if old != nil && (!info.hasField(indexFieldMeta) || old.Meta != nil) && (!info.hasField(indexFieldTags) || old.Tags != nil) {
+			atomic.AddInt64(&ic.indexOption.reusedNum, 1)
+			record.Meta, record.Tags = old.Meta, old.Tags
+		} else if err := ic.getMetaAndTags(bucket, record); err != nil {
+			if isNotFound(err) {
+				// the object is removed after it's listed
+				continue
+			}
+			atomic.AddInt64(&ic.indexOption.errNum, 1)
+			LogError("index %s error:%s\n", bucketObjectURL(bucket, object.Key), err.Error())
+			continue
+		}
+
+		if err := ic.indexOption.index.put(record); err != nil {
+			atomic.AddInt64(&ic.indexOption.errNum, 1)
+			LogError("index %s error:%s\n", bucketObjectURL(bucket, object.Key), err.Error())
+			continue
+		}
+		atomic.AddInt64(&ic.indexOption.indexedNum, 1)
+	}
+}
+
+// getMetaAndTags gets the user meta and the tags of the object if they are in --fields
+func (ic *IndexCommand) getMetaAndTags(bucket *oss.Bucket, record *indexRecord) error {
+	if ic.indexOption.info.hasField(indexFieldMeta) {
+		atomic.AddInt64(&ic.indexOption.headNum, 1)
+		headers, err := ic.command.ossGetObjectStatRetry(bucket, record.Key, ic.indexOption.payerOptions...)
+		if err != nil {
+			return err
+		}
+		record.Meta = userMeta(headers)
+	}
+	if ic.indexOption.info.hasField(indexFieldTags) {
+		atomic.AddInt64(&ic.indexOption.taggingNum, 1)
+		result, err := bucket.GetObjectTagging(record.Key, ic.indexOption.payerOptions...)
+		if err != nil {
+			return err
+		}
+		record.Tags = map[string]string{}
+		for _, tag := range result.Tags {
+			record.Tags[tag.Key] = tag.Value
+		}
+	}
+	return nil
+}
+
+// userMeta returns the user meta of the headers, the names are lower case without X-Oss-Meta-
+func userMeta(headers http.Header) map[string]string {
+	meta := map[string]string{}
+	for name, values := range headers {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(oss.HTTPHeaderOssMetaPrefix)) && len(values) > 0 {
+			meta[strings.ToLower(name[len(oss.HTTPHeaderOssMetaPrefix):])] = values[0]
+		}
+	}
+	return meta
+}
+
+func (ic *IndexCommand) query() error {
+	output, err := getOutputFormat(ic.command.options)
+	if err != nil {
+		return err
+	}
+	print0, _ := GetBool(OptionPrint0, ic.command.options)
+	if print0 && output.structured() {
+		return fmt.Errorf("--print0 and --output %s can not be used together", output)
+	}
+	limitedNum, err := GetInt(OptionLimitedNum, ic.command.options)
+	if err != nil {
+		limitedNum = DefaultLimitedNum
+	}
+
+	index, err := openObjectIndex(ic.command.args[1], false)
+	if err != nil {
+		return err
+	}
+	defer index.close()
+	info, err := index.readInfo()
+	if err != nil {
+		return err
+	}
+	if info == nil || !info.Complete {
+		return fmt.Errorf("the index %s is not built completely, please build it again", ic.command.args[1])
+	}
+
+	predicates := []indexPredicate{}
+	for _, str := range ic.command.args[2:] {
+		p, err := parseIndexPredicate(str)
+		if err != nil {
+			return err
+		}
+		if p.field != "key" && !info.hasField(p.field) {
+			return fmt.Errorf("the field %s is not in the index, please build the index with --fields %s",
+				p.field, strings.Join(append(append([]string{}, info.Fields...), p.field), ","))
+		}
+		predicates = append(predicates, p)
+	}
+
+	var matchNum int64
+	err = index.scan(func(record *indexRecord) (bool, error) {
+		if limitedNum >= 0 && matchNum >= limitedNum {
+			return false, nil
+		}
+		for _, p := range predicates {
+			if !p.match(record) {
+				return true, nil
+			}
+		}
+		matchNum++
+		url := CloudURLToString(info.Bucket, record.Key)
+		if print0 {
+			fmt.Printf("%s\x00", url)
+		} else if output.structured() {
+			fmt.Println(output.line(newIndexEntry(url, record, info)))
+		} else {
+			fmt.Println(url)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// the statistics are output to stderr, so that stdout only has the cloud urls or the entries
+	summary := os.Stdout
+	if print0 || output.structured() {
+		summary = os.Stderr
+	}
+	fmt.Fprintf(summary, "\nmatched objects: %d, indexed objects: %d, the index of %s is built at %s\n", matchNum,
+		info.ObjectCount, CloudURLToString(info.Bucket, info.Prefix), info.BuildTime.Format(time.RFC3339))
+	return nil
+}
+
+func newIndexEntry(url string, record *indexRecord, info *indexInfo) indexEntry {
+	entry := indexEntry{URL: url, Key: record.Key}
+	if info.hasField(indexFieldSize) {
+		entry.Size = &record.Size
+	}
+	if info.hasField(indexFieldMtime) {
+		entry.LastModified = &record.LastModified
+	}
+	if info.hasField(indexFieldMeta) {
+		entry.Meta = record.Meta
+	}
+	if info.hasField(indexFieldTags) {
+		entry.Tags = record.Tags
+	}
+	return entry
+}
@@ -0,0 +1,219 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestIndexPredicate(c *C) {
+	record := &indexRecord{
+		Key:          "data/a.jpg",
+		Size:         2 * 1024 * 1024,
+		LastModified: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+		Meta:         map[string]string{"owner": "alice"},
+		Tags:         map[string]string{"env": "prod"},
+	}
+	matches := map[string]bool{
+		"size>1MB":                    true,
+		"size<=1MB":                   false,
+		"size=2097152":                true,
+		"mtime<2024-01-01":            true,
+		"mtime>=2023-06-02":           false,
+		"key~*.jpg":                   true,
+		"key~data/*.png":              false,
+		"key>=data/":                  true,
+		"meta.Owner=alice":            true,
+		"meta.owner!=alice":           false,
+		"meta.owner":                  true,
+		"meta.group":                  false,
+		"meta.group!=x":               false,
+		"tags.env = prod":             true,
+		"tags.Env":                    false,
+		"tags.env~p*":                 true,
+		"key!=data/a.jpg":             false,
+		"size>=2MB":                   true,
+		"mtime=1685577600":            true,
+		"mtime!=2023-06-01T00:00:00Z": false,
+	}
+	for str, expected := range matches {
+		p, err := parseIndexPredicate(str)
+		c.Assert(err, IsNil, Commentf("%s", str))
+		c.Assert(p.match(record), Equals, expected, Commentf("%s", str))
+	}
+
+	for _, str := range []string{"size", "size>abc", "mtime<yesterday", "name=a", "size~1MB", "key", "=a"} {
+		_, err := parseIndexPredicate(str)
+		c.Assert(err, NotNil, Commentf("%s", str))
+	}
+
+	fields, err := parseIndexFields("")
+	c.Assert(err, IsNil)
+	c.Assert(fields, DeepEquals, []string{indexFieldSize, indexFieldMtime})
+	fields, err = parseIndexFields("meta, TAGS,meta")
+	c.Assert(err, IsNil)
+	c.Assert(fields, DeepEquals, []string{indexFieldMeta, indexFieldTags})
+	_, err = parseIndexFields("meta,acl")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestIndexBuildAndQuery(c *C) {
+	type mockObject struct {
+		size  int64
+		etag  string
+		owner string
+		env   string
+	}
+	var mu sync.Mutex
+	objects := map[string]mockObject{
+		"data/a.jpg": {100, "etag-a", "alice", "prod"},
+		"data/b.jpg": {2048, "etag-b", "bob", "test"},
+		"data/c.txt": {4096, "etag-c", "alice", ""},
+	}
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		if r.Method == "GET" && (r.URL.Path == "/bucket/" || r.URL.Path == "/bucket") {
+			requests["list"]++
+			fmt.Fprint(w, `<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>`)
+			for _, name := range []string{"data/a.jpg", "data/b.jpg", "data/c.txt"} {
+				if object, ok := objects[name]; ok {
+					fmt.Fprintf(w, `<Contents><Key>%s</Key><LastModified>2023-06-01T00:00:00.000Z</LastModified><ETag>"%s"</ETag><Size>%d</Size></Contents>`,
+						name, object.etag, object.size)
+				}
+			}
+			fmt.Fprint(w, `</ListBucketResult>`)
+			return
+		}
+		object, ok := objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		if _, tagging := r.URL.Query()["tagging"]; tagging {
+			requests["tagging"]++
+			fmt.Fprint(w, `<Tagging><TagSet>`)
+			if object.env != "" {
+				fmt.Fprintf(w, `<Tag><Key>env</Key><Value>%s</Value></Tag>`, object.env)
+			}
+			fmt.Fprint(w, `</TagSet></Tagging>`)
+			return
+		}
+		requests["head"]++
+		w.Header().Set("X-Oss-Meta-Owner", object.owner)
+		w.Header().Set("Content-Length", fmt.Sprint(object.size))
+	}))
+	defer server.Close()
+
+	indexPath := "ossutil-test-index-" + randLowStr(8)
+	defer os.RemoveAll(indexPath)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	fields := "meta,tags,size,mtime"
+	routines := "2"
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionFields:          &fields,
+		OptionOutputFile:      &indexPath,
+		OptionRoutines:        &routines,
+	}
+	_, err = cm.RunCommand("index", []string{"build", "oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(requests["head"], Equals, 3)
+	c.Assert(requests["tagging"], Equals, 3)
+
+	query := func(args ...string) ([]string, error) {
+		testResultFile.Truncate(0)
+		testResultFile.Seek(0, 0)
+		_, err := cm.RunCommand("index", append([]string{"query", indexPath}, args...), OptionMapType{})
+		urls := []string{}
+		for _, line := range strings.Split(s.readFile(resultPath, c), "\n") {
+			if strings.HasPrefix(line, "oss://") {
+				urls = append(urls, line)
+			}
+		}
+		return urls, err
+	}
+
+	// the queries don't send any request
+	listNum := requests["list"]
+	urls, err := query("meta.owner=alice", "size>1KB")
+	c.Assert(err, IsNil)
+	c.Assert(urls, DeepEquals, []string{"oss://bucket/data/c.txt"})
+	urls, err = query("key~*.jpg", "tags.env")
+	c.Assert(err, IsNil)
+	c.Assert(urls, DeepEquals, []string{"oss://bucket/data/a.jpg", "oss://bucket/data/b.jpg"})
+	urls, err = query("mtime<2024-01-01")
+	c.Assert(err, IsNil)
+	c.Assert(len(urls), Equals, 3)
+	c.Assert(requests["list"], Equals, listNum)
+	_, err = query("size>abc")
+	c.Assert(err, NotNil)
+
+	// json lines with the indexed fields
+	format := "json"
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("index", []string{"query", indexPath, "tags.env=test"}, OptionMapType{OptionOutput: &format})
+	c.Assert(err, IsNil)
+	var entry map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(strings.TrimSpace(s.readFile(resultPath, c))), &entry), IsNil)
+	c.Assert(entry["url"], Equals, "oss://bucket/data/b.jpg")
+	c.Assert(entry["size"], Equals, float64(2048))
+	c.Assert(entry["meta"].(map[string]interface{})["owner"], Equals, "bob")
+
+	// the unchanged objects are reused, the changed one is got again and the removed one is pruned
+	mu.Lock()
+	objects["data/a.jpg"] = mockObject{100, "etag-a2", "carol", "prod"}
+	delete(objects, "data/c.txt")
+	mu.Unlock()
+	_, err = cm.RunCommand("index", []string{"build", "oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(requests["head"], Equals, 4)
+	c.Assert(requests["tagging"], Equals, 4)
+	urls, err = query("meta.owner=carol")
+	c.Assert(err, IsNil)
+	c.Assert(urls, DeepEquals, []string{"oss://bucket/data/a.jpg"})
+	urls, err = query()
+	c.Assert(err, IsNil)
+	c.Assert(len(urls), Equals, 2)
+
+	// only the indexed fields can be queried
+	fields = "size"
+	_, err = cm.RunCommand("index", []string{"build", "oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+	_, err = query("meta.owner=carol")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--fields size,meta"), Equals, true)
+
+	// the index which doesn't exist isn't created by query
+	_, err = cm.RunCommand("index", []string{"query", indexPath + "-notexist"}, OptionMapType{})
+	c.Assert(err, NotNil)
+	_, err = os.Stat(indexPath + "-notexist")
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = cm.RunCommand("index", []string{"rebuild", "oss://bucket/data/"}, options)
+	c.Assert(err, NotNil)
+}
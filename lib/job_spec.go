@@ -0,0 +1,189 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// jobSpecVersion is the version of the job spec document which ossutil supports
+const jobSpecVersion = 1
+
+// jobSpec is the yaml document of --spec. The options are keyed by the long option names without
+// the leading "--", e.g.
+//
+//	version: 1
+//	command: sync
+//	args: [./dist/, oss://bucket/dist/]
+//	options:
+//	  delete: true
+//	  jobs: 10
+//	  include: ["*.html", "*.js"]
+//	  meta:
+//	    Cache-Control: max-age=600
+type jobSpec struct {
+	Version int                    `yaml:"version"`
+	Command string                 `yaml:"command"`
+	Args    []string               `yaml:"args"`
+	Options map[string]interface{} `yaml:"options"`
+}
+
+func loadJobSpec(fileName string) (*jobSpec, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var spec jobSpec
+	if err = yaml.UnmarshalStrict(data, &spec); err != nil {
+		return nil, fmt.Errorf("invalid job spec %s: %s", fileName, err.Error())
+	}
+	if spec.Version != jobSpecVersion {
+		return nil, fmt.Errorf("invalid job spec %s: unsupported version %d, the version should be %d", fileName, spec.Version, jobSpecVersion)
+	}
+	return &spec, nil
+}
+
+// applyJobSpec merges the arguments and the options declared in --spec into the command. The
+// arguments of the spec are used if there are no arguments in the command line, and the options
+// specified in the command line take precedence over the spec, except that the patterns of
+// --include and --exclude are appended to the ones in the command line, the include patterns come first
+func (cmd *Command) applyJobSpec() error {
+	fileName, _ := GetString(OptionSpec, cmd.options)
+	if fileName == "" || FindPos(OptionSpec, cmd.validOptionNames) == -1 {
+		return nil
+	}
+	spec, err := loadJobSpec(fileName)
+	if err != nil {
+		return err
+	}
+	if spec.Command != "" && spec.Command != cmd.name {
+		return fmt.Errorf("the job spec %s is for command %s, it can't be used by %s", fileName, spec.Command, cmd.name)
+	}
+	if len(cmd.args) == 0 {
+		cmd.args = spec.Args
+	}
+
+	optionNames := map[string]string{}
+	for name, option := range OptionMap {
+		if option.nameAlias != "" {
+			optionNames[strings.TrimPrefix(option.nameAlias, "--")] = name
+		}
+	}
+	keys := make([]string, 0, len(spec.Options))
+	for key := range spec.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	patterns := map[string][]string{}
+	for _, key := range keys {
+		value := spec.Options[key]
+		name, ok := optionNames[key]
+		if !ok || name == OptionSpec {
+			return fmt.Errorf("invalid option %s in job spec %s", key, fileName)
+		}
+		if name == OptionInclude || name == OptionExclude {
+			patterns[name], err = specStrings(value)
+		} else {
+			err = cmd.applySpecOption(name, value)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid option %s in job spec %s: %s", key, fileName, err.Error())
+		}
+		// the filters are parsed from specFilters, the option is only checked whether the command supports it
+		if val, _ := GetString(name, cmd.options); len(patterns[name]) > 0 && val == "" {
+			cmd.options[name] = &patterns[name][0]
+		}
+	}
+	cmd.specFilters = filterArgs(patterns[OptionInclude], patterns[OptionExclude])
+	// the ranges of the numbers and the alternatives
+	return checkOption(cmd.options)
+}
+
+func (cmd *Command) applySpecOption(name string, value interface{}) error {
+	switch OptionMap[name].optionType {
+	case OptionTypeFlagTrue:
+		val, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("the value should be true or false")
+		}
+		if set, _ := GetBool(name, cmd.options); !set {
+			cmd.options[name] = &val
+		}
+	case OptionTypeStrings:
+		vals, err := specStrings(value)
+		if err != nil {
+			return err
+		}
+		if set, _ := GetStrings(name, cmd.options); len(set) == 0 {
+			cmd.options[name] = &vals
+		}
+	default:
+		val, err := specString(name, value)
+		if err != nil {
+			return err
+		}
+		if set, _ := GetString(name, cmd.options); set == "" {
+			cmd.options[name] = &val
+		}
+	}
+	return nil
+}
+
+// specString converts the scalar value to the string of the option, --meta can also be a map of
+// the headers
+func specString(name string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case map[interface{}]interface{}:
+		if name != OptionMeta {
+			break
+		}
+		headers := make([]string, 0, len(v))
+		for header, val := range v {
+			headers = append(headers, fmt.Sprintf("%v:%v", header, val))
+		}
+		sort.Strings(headers)
+		return strings.Join(headers, "#"), nil
+	}
+	return "", fmt.Errorf("the value should be a string or a number")
+}
+
+// specStrings accepts a string or a list of strings
+func specStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		vals := make([]string, 0, len(v))
+		for _, item := range v {
+			val, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("the value should be a list of strings")
+			}
+			vals = append(vals, val)
+		}
+		return vals, nil
+	}
+	return nil, fmt.Errorf("the value should be a string or a list of strings")
+}
+
+// withSpecFilters appends the --include and --exclude of --spec to the command line which the
+// filters are parsed from
+func (cmd *Command) withSpecFilters(cmdline []string) []string {
+	if len(cmd.specFilters) == 0 {
+		return cmdline
+	}
+	return append(append([]string{}, cmdline...), cmd.specFilters...)
+}
@@ -0,0 +1,137 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestJobSpec(c *C) {
+	specPath := "ossutil-test-spec-" + randLowStr(8) + ".yaml"
+	defer os.Remove(specPath)
+
+	endpoint := "oss-cn-hangzhou.aliyuncs.com"
+	str := "ak"
+	spec := specPath
+	jobs := ""
+	initCopy := func(content string, args []string) (*CopyCommand, error) {
+		s.createFile(specPath, content, c)
+		cc := &CopyCommand{command: copyCommand.command}
+		err := cc.Init(context.Background(), args, OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &str,
+			OptionAccessKeySecret: &str,
+			OptionSpec:            &spec,
+			OptionRoutines:        &jobs,
+		})
+		return cc, err
+	}
+
+	content := `version: 1
+command: cp
+args: [./dist/, oss://bucket/dist/]
+options:
+  recursive: true
+  jobs: 10
+  include: ["*.html", "*.js"]
+  exclude: "*.map"
+  meta:
+    X-Oss-Meta-Team: web
+    Cache-Control: max-age=600
+`
+	cc, err := initCopy(content, nil)
+	c.Assert(err, IsNil)
+	c.Assert(cc.command.args, DeepEquals, []string{"./dist/", "oss://bucket/dist/"})
+	recursive, _ := GetBool(OptionRecursion, cc.command.options)
+	c.Assert(recursive, Equals, true)
+	routines, _ := GetInt(OptionRoutines, cc.command.options)
+	c.Assert(routines, Equals, int64(10))
+	meta, _ := GetString(OptionMeta, cc.command.options)
+	c.Assert(meta, Equals, "Cache-Control:max-age=600#X-Oss-Meta-Team:web")
+	ok, filters := getFilter(cc.command.withSpecFilters([]string{"ossutil", "cp", "--exclude", "*.tmp"}))
+	c.Assert(ok, Equals, true)
+	c.Assert(filters, DeepEquals, []filterOptionType{
+		{ExcludePrompt, "*.tmp"}, {IncludePrompt, "*.html"}, {IncludePrompt, "*.js"}, {ExcludePrompt, "*.map"},
+	})
+
+	// the arguments and the options in the command line take precedence
+	jobs = "3"
+	cc, err = initCopy(content, []string{"a", "oss://bucket/a"})
+	c.Assert(err, IsNil)
+	c.Assert(cc.command.args, DeepEquals, []string{"a", "oss://bucket/a"})
+	routines, _ = GetInt(OptionRoutines, cc.command.options)
+	c.Assert(routines, Equals, int64(3))
+	jobs = ""
+
+	// the invalid specs
+	for _, content := range []string{
+		"version: 2\nargs: [a, oss://bucket/a]\n",
+		"version: 1\ncommand: sync\nargs: [a, oss://bucket/a]\n",
+		"version: 1\nargs: [a, oss://bucket/a]\nunknown: 1\n",
+		"version: 1\nargs: [a, oss://bucket/a]\noptions:\n  no-such-option: 1\n",
+		"version: 1\nargs: [a, oss://bucket/a]\noptions:\n  recursive: yes please\n",
+		"version: 1\nargs: [a, oss://bucket/a]\noptions:\n  jobs: 100000\n",
+		"version: 1\nargs: [a, oss://bucket/a]\noptions:\n  delete: true\n",
+		"version: 1\nargs: [a, oss://bucket/a]\noptions:\n  spec: other.yaml\n",
+	} {
+		_, err = initCopy(content, nil)
+		c.Assert(err, NotNil, Commentf("%s", content))
+	}
+	spec = "ossutil-test-spec-not-exist.yaml"
+	_, err = initCopy(content, nil)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestRemoveWithJobSpec(c *C) {
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>logs/a.log</Key><Size>1</Size><LastModified>2022-01-01T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>logs/b.txt</Key><Size>2</Size><LastModified>2022-01-01T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`)
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			for _, part := range strings.Split(string(body), "<Key>")[1:] {
+				deleted = append(deleted, part[:strings.Index(part, "</Key>")])
+			}
+			fmt.Fprint(w, `<DeleteResult></DeleteResult>`)
+		}
+	}))
+	defer server.Close()
+
+	specPath := "ossutil-test-spec-" + randLowStr(8) + ".yaml"
+	s.createFile(specPath, `version: 1
+command: rm
+args: [oss://bucket/logs/]
+options:
+  recursive: true
+  force: true
+  include: "*.log"
+`, c)
+	defer os.Remove(specPath)
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	_, err := cm.RunCommand("rm", nil, OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionSpec:            &specPath,
+	})
+	c.Assert(err, IsNil)
+	c.Assert(deleted, DeepEquals, []string{"logs/a.log"})
+}
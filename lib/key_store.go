@@ -0,0 +1,168 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+
+	leveldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// keyStore keeps the keys listed from the source or the destination with their values, e.g., the
+// prefix of the object, to compare the two sides. The keys are kept in memory by default, and
+// in the temporary leveldb with --low-memory, so that billions of keys can be compared
+type keyStore interface {
+	put(key, value string) error
+	get(key string) (string, bool)
+	delete(key string) error
+	len() int
+
+	// each calls f for every key, the keys are in descending order if reverse, otherwise in any order,
+	// it stops at the first error returned by f. The keys can be deleted in f
+	each(reverse bool, f func(key, value string) error) error
+	close()
+}
+
+// newKeyStore returns the leveldb store if lowMemory, otherwise the memory store
+func newKeyStore(lowMemory bool) (keyStore, error) {
+	if lowMemory {
+		return newDiskKeyStore()
+	}
+	return newMemoryKeyStore(), nil
+}
+
+type memoryKeyStore struct {
+	keys map[string]string
+}
+
+func newMemoryKeyStore() *memoryKeyStore {
+	return &memoryKeyStore{keys: map[string]string{}}
+}
+
+func (ks *memoryKeyStore) put(key, value string) error {
+	ks.keys[key] = value
+	return nil
+}
+
+func (ks *memoryKeyStore) get(key string) (string, bool) {
+	value, ok := ks.keys[key]
+	return value, ok
+}
+
+func (ks *memoryKeyStore) delete(key string) error {
+	delete(ks.keys, key)
+	return nil
+}
+
+func (ks *memoryKeyStore) len() int {
+	return len(ks.keys)
+}
+
+func (ks *memoryKeyStore) each(reverse bool, f func(key, value string) error) error {
+	if !reverse {
+		for key, value := range ks.keys {
+			if err := f(key, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	sortList := make([]string, 0, len(ks.keys))
+	for key := range ks.keys {
+		sortList = append(sortList, key)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(sortList)))
+	for _, key := range sortList {
+		value, ok := ks.keys[key]
+		if !ok {
+			continue
+		}
+		if err := f(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ks *memoryKeyStore) close() {
+	ks.keys = map[string]string{}
+}
+
+// diskKeyStore spools the keys to the leveldb in the temp dir, which is removed when closing.
+// The temp dir can be changed by the environment variable TMPDIR(TMP on windows)
+type diskKeyStore struct {
+	dir   string
+	db    *leveldb.DB
+	count int
+}
+
+func newDiskKeyStore() (*diskKeyStore, error) {
+	dir, err := ioutil.TempDir("", "ossutil-keys-")
+	if err != nil {
+		return nil, err
+	}
+	db, err := leveldb.OpenFile(dir, &opt.Options{BlockCacheCapacity: 4 * opt.MiB, WriteBuffer: 4 * opt.MiB})
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	LogInfo("spool the keys to %s\n", dir)
+	return &diskKeyStore{dir: dir, db: db}, nil
+}
+
+func (ks *diskKeyStore) put(key, value string) error {
+	if has, err := ks.db.Has([]byte(key), nil); err != nil {
+		return err
+	} else if !has {
+		ks.count++
+	}
+	return ks.db.Put([]byte(key), []byte(value), nil)
+}
+
+func (ks *diskKeyStore) get(key string) (string, bool) {
+	value, err := ks.db.Get([]byte(key), nil)
+	if err != nil {
+		return "", false
+	}
+	return string(value), true
+}
+
+func (ks *diskKeyStore) delete(key string) error {
+	if has, err := ks.db.Has([]byte(key), nil); err != nil || !has {
+		return err
+	}
+	ks.count--
+	return ks.db.Delete([]byte(key), nil)
+}
+
+func (ks *diskKeyStore) len() int {
+	return ks.count
+}
+
+func (ks *diskKeyStore) each(reverse bool, f func(key, value string) error) error {
+	// the iterator reads the snapshot of the db, the keys deleted by f are still iterated,
+	// so they are checked again
+	iter := ks.db.NewIterator(nil, nil)
+	defer iter.Release()
+	next, ok := iter.Next, iter.First()
+	if reverse {
+		next, ok = iter.Prev, iter.Last()
+	}
+	for ; ok; ok = next() {
+		key := string(iter.Key())
+		if has, _ := ks.db.Has(iter.Key(), nil); !has {
+			continue
+		}
+		if err := f(key, string(iter.Value())); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+func (ks *diskKeyStore) close() {
+	ks.db.Close()
+	os.RemoveAll(ks.dir)
+}
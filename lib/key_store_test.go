@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"os"
+	"sort"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestKeyStore(c *C) {
+	for _, lowMemory := range []bool{false, true} {
+		ks, err := newKeyStore(lowMemory)
+		c.Assert(err, IsNil)
+
+		c.Assert(ks.put("b", "prefix/"), IsNil)
+		c.Assert(ks.put("a", ""), IsNil)
+		c.Assert(ks.put("c/", ""), IsNil)
+		c.Assert(ks.put("a", "new"), IsNil)
+		c.Assert(ks.len(), Equals, 3)
+
+		value, ok := ks.get("a")
+		c.Assert(ok, Equals, true)
+		c.Assert(value, Equals, "new")
+		_, ok = ks.get("d")
+		c.Assert(ok, Equals, false)
+
+		keys := []string{}
+		c.Assert(ks.each(false, func(key, value string) error {
+			keys = append(keys, key+"="+value)
+			return nil
+		}), IsNil)
+		sort.Strings(keys)
+		c.Assert(keys, DeepEquals, []string{"a=new", "b=prefix/", "c/="})
+
+		// the keys deleted during iterating are skipped
+		keys = []string{}
+		c.Assert(ks.each(true, func(key, value string) error {
+			keys = append(keys, key)
+			return ks.delete("a")
+		}), IsNil)
+		c.Assert(keys, DeepEquals, []string{"c/", "b"})
+		c.Assert(ks.len(), Equals, 2)
+		c.Assert(ks.delete("not-exist"), IsNil)
+		c.Assert(ks.len(), Equals, 2)
+
+		if dks, ok := ks.(*diskKeyStore); ok {
+			_, err = os.Stat(dks.dir)
+			c.Assert(err, IsNil)
+			ks.close()
+			_, err = os.Stat(dks.dir)
+			c.Assert(os.IsNotExist(err), Equals, true)
+		} else {
+			ks.close()
+			c.Assert(ks.len(), Equals, 0)
+		}
+	}
+}
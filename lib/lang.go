@@ -5,17 +5,9 @@
 
 package lib
 
-import (
-	"os"
-	"strings"
-)
-
 func getOsLang() string {
-	lang := os.Getenv("LANG")
-	langstr := strings.Split(lang, ".")
-
-	if langstr[0] == "zh_CN" {
-		return ChineseLanguage
+	if language := getEnvLang(); language != "" {
+		return language
 	}
 	return EnglishLanguage
 }
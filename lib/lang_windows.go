@@ -7,6 +7,10 @@ import (
 )
 
 func getOsLang() string {
+	if language := getEnvLang(); language != "" {
+		return language
+	}
+
 	var mod = syscall.NewLazyDLL("kernel32.dll")
 	var proc = mod.NewProc("GetUserDefaultUILanguage")
 	ret, _, _ := proc.Call()
@@ -1,7 +1,10 @@
 package lib
 
 import (
+	"context"
 	"fmt"
+	"strings"
+	"sync"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 )
@@ -13,15 +16,39 @@ var specChineseListCloudBox = SpecText{
 	paramText: "[options]",
 
 	syntaxText: ` 
-    ossutil lcb [-e endpoint]
+    ossutil lcb [oss://prefix] [-e endpoint] [--limited-num num] [--marker marker] [--filter-region region] [--filter-owner owner]
 `,
 
 	detailHelpText: `
-    该命令列举云盒的详细信息
+    该命令列举云盒的详细信息，包括云盒的数据域名，列举云盒内的buckets请使用ls --cloudbox-id命令。
+
+    如果指定了oss://prefix，则只列举id以prefix开头的云盒。
+
+--limited-num选项
+
+    最多显示的云盒个数，跨越多页列举时同样生效。
+
+--marker选项
+
+    从marker之后开始列举云盒，列举结束时如果还有未列举的云盒，ossutil在最后输出NextMarker，
+    将其作为--marker的值可以继续列举。
+
+--filter-region和--filter-owner选项
+
+    在客户端过滤列举的结果，只显示region或者owner与指定值相同的云盒，--limited-num只计算过滤后的云盒。
+
+--output选项
+
+    以json或者yaml格式输出列举的云盒，未列举完时结果中包含nextMarker。
 `,
 
 	sampleText: ` 
     1) ossutil lcb --sign-version v4 --region cn-hangzhou --cloudbox-id cb-abcdef
+    2) 列举云盒内的buckets
+    ossutil ls --cloudbox-id cb-abcdef -e cn-hangzhou.oss-cloudbox-control.aliyuncs.com
+    3) 列举cn-hangzhou的前10个云盒，再从NextMarker继续列举
+    ossutil lcb --filter-region cn-hangzhou --limited-num 10
+    ossutil lcb --filter-region cn-hangzhou --limited-num 10 --marker cb-abcdef
 `,
 }
 
@@ -32,15 +59,41 @@ var specEnglishListCloudBox = SpecText{
 	paramText: "[options]",
 
 	syntaxText: ` 
-    ossutil lcb [-e endpoint] 
+    ossutil lcb [oss://prefix] [-e endpoint] [--limited-num num] [--marker marker] [--filter-region region] [--filter-owner owner]
 `,
 
 	detailHelpText: ` 
-    This command lists cloud box information
+    This command lists cloud box information, including the data endpoint of the cloud box, 
+    use ls --cloudbox-id command to list the buckets in the cloud box.
+
+    If oss://prefix is specified, only the cloud boxes whose id starts with the prefix are listed.
+
+--limited-num option
+
+    The max number of the cloud boxes to show, it works across the pages of listing.
+
+--marker option
+
+    List the cloud boxes after the marker. If there are cloud boxes not listed when the listing 
+    stops, ossutil prints NextMarker at the end, use it as --marker to continue listing.
+
+--filter-region and --filter-owner option
+
+    Filter the listing results on the client side, only the cloud boxes whose region or owner is 
+    the same as the value are shown, --limited-num only counts the filtered cloud boxes.
+
+--output option
+
+    Print the listed cloud boxes in json or yaml, the result has nextMarker if the listing is not finished.
 `,
 
 	sampleText: ` 
     1) ossutil lcb --sign-version v4 --region cn-hangzhou --cloudbox-id cb-abcdef
+    2) list the buckets in the cloud box
+    ossutil ls --cloudbox-id cb-abcdef -e cn-hangzhou.oss-cloudbox-control.aliyuncs.com
+    3) list the first 10 cloud boxes in cn-hangzhou, then continue from NextMarker
+    ossutil lcb --filter-region cn-hangzhou --limited-num 10
+    ossutil lcb --filter-region cn-hangzhou --limited-num 10 --marker cb-abcdef
 `,
 }
 
@@ -51,13 +104,11 @@ type LcbCommand struct {
 
 var lcbCommand = LcbCommand{
 	command: Command{
-		name:        "lcb",
-		nameAlias:   []string{"lcb"},
-		minArgc:     0,
-		maxArgc:     1,
-		specChinese: specChineseListCloudBox,
-		specEnglish: specEnglishListCloudBox,
-		group:       GroupTypeNormalCommand,
+		name:      "lcb",
+		nameAlias: []string{"lcb"},
+		minArgc:   0,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -77,6 +128,11 @@ var lcbCommand = LcbCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -86,10 +142,30 @@ var lcbCommand = LcbCommand{
 			OptionMarker,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionFilterRegion,
+			OptionFilterOwner,
+			OptionOutput,
 		},
 	},
 }
 
+// lcbEntry is a cloud box of lcb --output json
+type lcbEntry struct {
+	No              int64  `json:"no"`
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Owner           string `json:"owner"`
+	Region          string `json:"region"`
+	ControlEndpoint string `json:"controlEndpoint"`
+	DataEndpoint    string `json:"dataEndpoint"`
+}
+
+// lcbResult is the result of lcb --output json, nextMarker is empty if all the cloud boxes are listed
+type lcbResult struct {
+	CloudBoxes []lcbEntry `json:"cloudBoxes"`
+	NextMarker string     `json:"nextMarker,omitempty"`
+}
+
 // function for FormatHelper interface
 func (lc *LcbCommand) formatHelpForWhole() string {
 	return lc.command.formatHelpForWhole()
@@ -100,8 +176,8 @@ func (lc *LcbCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (lc *LcbCommand) Init(args []string, options OptionMapType) error {
-	return lc.command.Init(args, options, lc)
+func (lc *LcbCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return lc.command.Init(ctx, args, options, lc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -117,49 +193,150 @@ func (lc *LcbCommand) RunCommand() error {
 
 	limitedNum, _ := GetInt(OptionLimitedNum, lc.command.options)
 	vmarker, _ := GetString(OptionMarker, lc.command.options)
-	if vmarker, err := lc.command.getRawMarker(vmarker); err != nil {
+	vmarker, err := lc.command.getRawMarker(vmarker)
+	if err != nil {
 		return fmt.Errorf("invalid marker: %s, marker is not url encoded, %s", vmarker, err.Error())
 	}
+	filterRegion, _ := GetString(OptionFilterRegion, lc.command.options)
+	filterOwner, _ := GetString(OptionFilterOwner, lc.command.options)
+	output, err := getOutputFormat(lc.command.options)
+	if err != nil {
+		return err
+	}
 
-	var num int64
-	num = 0
-
-	client, err := lc.command.ossClient("")
+	client, err := lc.command.controlClient()
 	if err != nil {
 		return err
 	}
 
-	// list all cloudbox
-	pre := oss.Prefix(prefix)
-	marker := oss.Marker(vmarker)
-	for limitedNum < 0 || num < limitedNum {
-		lcr, err := lc.ossListCloudBoxesRetry(client, pre, marker)
+	// list all cloudbox, the marker is the id of the last cloud box checked, so that the listing can be
+	// continued from it when --limited-num is reached in the middle of a page
+	var num int64
+	nextMarker := vmarker
+	result := lcbResult{CloudBoxes: []lcbEntry{}}
+	for {
+		lcr, err := lc.ossListCloudBoxesRetry(client, oss.Prefix(prefix), oss.Marker(nextMarker))
 		if err != nil {
 			return err
 		}
-		pre = oss.Prefix(lcr.Prefix)
-		marker = oss.Marker(lcr.NextMarker)
-		for _, box := range lcr.CloudBoxes {
+		for i, box := range lcr.CloudBoxes {
 			if limitedNum >= 0 && num >= limitedNum {
-				break
+				return lc.printResult(output, result, nextMarker, true)
+			}
+			nextMarker = box.ID
+			if (filterRegion != "" && box.Region != filterRegion) || (filterOwner != "" && lcr.Owner != filterOwner) {
+				continue
+			}
+			if output.structured() {
+				result.CloudBoxes = append(result.CloudBoxes, lcbEntry{num, box.ID, box.Name, lcr.Owner, box.Region, box.ControlEndpoint, box.DataEndpoint})
+			} else {
+				fmt.Printf("%-15s:%d\n", "No", num)
+				fmt.Printf("%-15s:%s\n", "Id", box.ID)
+				fmt.Printf("%-15s:%s\n", "Name", box.Name)
+				fmt.Printf("%-15s:%s\n", "Owner", lcr.Owner)
+				fmt.Printf("%-15s:%s\n", "Region", box.Region)
+				fmt.Printf("%-15s:%s\n", "ControlEndpoint", box.ControlEndpoint)
+				fmt.Printf("%-15s:%s\n", "DataEndpoint", box.DataEndpoint)
+				fmt.Printf("----------------------------------------------------------------------\n")
 			}
-			fmt.Printf("%-15s:%d\n", "No", num)
-			fmt.Printf("%-15s:%s\n", "Id", box.ID)
-			fmt.Printf("%-15s:%s\n", "Name", box.Name)
-			fmt.Printf("%-15s:%s\n", "Owner", lcr.Owner)
-			fmt.Printf("%-15s:%s\n", "Region", box.Region)
-			fmt.Printf("%-15s:%s\n", "ControlEndpoint", box.ControlEndpoint)
-			fmt.Printf("%-15s:%s\n", "DataEndpoint", box.DataEndpoint)
-			fmt.Printf("----------------------------------------------------------------------\n")
 			num++
+			if limitedNum >= 0 && num >= limitedNum && (i < len(lcr.CloudBoxes)-1 || lcr.IsTruncated) {
+				return lc.printResult(output, result, nextMarker, true)
+			}
 		}
 		if !lcr.IsTruncated {
-			break
+			return lc.printResult(output, result, "", false)
+		}
+		if lcr.NextMarker != "" {
+			nextMarker = lcr.NextMarker
 		}
 	}
+}
+
+// printResult prints the structured result, or the NextMarker of the text output if the listing is not finished
+func (lc *LcbCommand) printResult(output outputFormat, result lcbResult, nextMarker string, more bool) error {
+	if output.structured() {
+		result.NextMarker = nextMarker
+		return output.print(result)
+	}
+	if more {
+		fmt.Printf("%-15s:%s\n", "NextMarker", nextMarker)
+	}
 	return nil
 }
 
+// findCloudBox lists the cloud boxes to find the one with the id
+func findCloudBox(client *oss.Client, cloudBoxID string, retryTimes int64) (oss.CloudBoxProperties, error) {
+	marker := ""
+	for {
+		var lcr oss.ListCloudBoxResult
+		var err error
+		for i := 1; ; i++ {
+			lcr, err = client.ListCloudBoxes(oss.Marker(marker))
+			if err == nil || int64(i) >= retryTimes {
+				break
+			}
+		}
+		if err != nil {
+			return oss.CloudBoxProperties{}, fmt.Errorf("list cloud boxes to find %s error, %w", cloudBoxID, wrapServiceError(err))
+		}
+		for _, box := range lcr.CloudBoxes {
+			if box.ID == cloudBoxID && box.DataEndpoint != "" {
+				return box, nil
+			}
+		}
+		if !lcr.IsTruncated {
+			return oss.CloudBoxProperties{}, fmt.Errorf("cloud box %s is not found, please check --cloudbox-id or specify the data endpoint of the cloud box by --endpoint", cloudBoxID)
+		}
+		marker = lcr.NextMarker
+	}
+}
+
+// cloudBoxEndpoints caches the data endpoints of the cloud boxes, keyed by the control endpoint and
+// the cloud box id, so the cloud boxes are listed once for the objects of cp, ls and stat
+var cloudBoxEndpoints = struct {
+	sync.Mutex
+	m map[string]string
+}{m: map[string]string{}}
+
+// cloudBoxDataEndpoint returns the data endpoint of the cloud box by listing the cloud boxes on the
+// control endpoint, the scheme of the control endpoint is kept
+func (cmd *Command) cloudBoxDataEndpoint(endpoint, cloudBoxID string) (string, error) {
+	key := endpoint + CheckpointSep + cloudBoxID
+	cloudBoxEndpoints.Lock()
+	defer cloudBoxEndpoints.Unlock()
+	if dataEndpoint, ok := cloudBoxEndpoints.m[key]; ok {
+		return dataEndpoint, nil
+	}
+
+	client, err := cmd.newOSSClient(endpoint, false)
+	if err != nil {
+		return "", err
+	}
+	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	box, err := findCloudBox(client, cloudBoxID, retryTimes)
+	if err != nil {
+		return "", err
+	}
+	dataEndpoint := box.DataEndpoint
+	if strings.HasPrefix(strings.ToLower(endpoint), "https://") && !strings.Contains(dataEndpoint, "://") {
+		dataEndpoint = "https://" + dataEndpoint
+	}
+	LogInfo("data endpoint of cloud box %s:%s\n", cloudBoxID, dataEndpoint)
+	cloudBoxEndpoints.m[key] = dataEndpoint
+	return dataEndpoint, nil
+}
+
+// isCloudBoxEndpoint returns true if the endpoint is the data endpoint or the control endpoint of the cloud box,
+// e.g., cb-xxx.cn-hangzhou.oss-cloudbox.aliyuncs.com
+func isCloudBoxEndpoint(endpoint, cloudBoxID string) bool {
+	endpoint = strings.ToLower(endpoint)
+	for _, scheme := range []string{"http://", "https://"} {
+		endpoint = strings.TrimPrefix(endpoint, scheme)
+	}
+	return strings.HasPrefix(endpoint, strings.ToLower(cloudBoxID)+".")
+}
+
 func (lc *LcbCommand) ossListCloudBoxesRetry(client *oss.Client, options ...oss.Option) (oss.ListCloudBoxResult, error) {
 	retryTimes, _ := GetInt(OptionRetryTimes, lc.command.options)
 	for i := 1; ; i++ {
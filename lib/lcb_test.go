@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestListCloudBoxPaging(c *C) {
+	boxes := []struct{ id, region string }{
+		{"cb-1", "cn-hangzhou"}, {"cb-2", "cn-shanghai"}, {"cb-3", "cn-hangzhou"},
+		{"cb-4", "cn-hangzhou"}, {"cb-5", "cn-hangzhou"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		marker := r.URL.Query().Get("marker")
+		body := ""
+		num := 0
+		truncated := false
+		for _, box := range boxes {
+			if box.id <= marker {
+				continue
+			}
+			if num == 3 {
+				truncated = true
+				break
+			}
+			body += fmt.Sprintf("<CloudBox><ID>%s</ID><Region>%s</Region></CloudBox>", box.id, box.region)
+			marker = box.id
+			num++
+		}
+		fmt.Fprintf(w, `<ListCloudBoxResult><IsTruncated>%t</IsTruncated><NextMarker>%s</NextMarker>
+<Owner><DisplayName>owner-a</DisplayName></Owner><CloudBoxes>%s</CloudBoxes></ListCloudBoxResult>`, truncated, marker, body)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	accessKeyID := "ak"
+	accessKeySecret := "sk"
+	limitedNum := "3"
+	marker := ""
+	filterRegion := "cn-hangzhou"
+	filterOwner := ""
+	run := func() string {
+		lc := &LcbCommand{}
+		lc.command.options = OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &accessKeyID,
+			OptionAccessKeySecret: &accessKeySecret,
+			OptionLimitedNum:      &limitedNum,
+			OptionMarker:          &marker,
+			OptionFilterRegion:    &filterRegion,
+			OptionFilterOwner:     &filterOwner,
+		}
+		outputFile := "test-file-" + randLowStr(5)
+		testResultFile, err := os.OpenFile(outputFile, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = lc.RunCommand()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		c.Assert(err, IsNil)
+		outBody := s.readFile(outputFile, c)
+		os.Remove(outputFile)
+		return outBody
+	}
+
+	// --limited-num works across the pages, the listing stops in the middle of the second page
+	outBody := run()
+	c.Assert(strings.Contains(outBody, "cb-1"), Equals, true)
+	c.Assert(strings.Contains(outBody, "cb-2"), Equals, false)
+	c.Assert(strings.Contains(outBody, "cb-3"), Equals, true)
+	c.Assert(strings.Contains(outBody, "cb-4"), Equals, true)
+	c.Assert(strings.Contains(outBody, "cb-5"), Equals, false)
+	c.Assert(strings.Count(outBody, "DataEndpoint"), Equals, 3)
+	c.Assert(strings.Contains(outBody, "NextMarker     :cb-4"), Equals, true)
+
+	// continue from NextMarker
+	marker = "cb-4"
+	outBody = run()
+	c.Assert(strings.Contains(outBody, "cb-5"), Equals, true)
+	c.Assert(strings.Count(outBody, "DataEndpoint"), Equals, 1)
+	c.Assert(strings.Contains(outBody, "NextMarker"), Equals, false)
+
+	// the owner does not match
+	marker = ""
+	filterRegion = ""
+	filterOwner = "owner-b"
+	outBody = run()
+	c.Assert(strings.Contains(outBody, "DataEndpoint"), Equals, false)
+	c.Assert(strings.Contains(outBody, "NextMarker"), Equals, false)
+
+	filterOwner = "owner-a"
+	limitedNum = "-1"
+	outBody = run()
+	c.Assert(strings.Count(outBody, "DataEndpoint"), Equals, 5)
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -10,10 +11,11 @@ import (
 var specChineseListPart = SpecText{
 	synopsisText: "列出没有完成分块上传的object的分块信息",
 
-	paramText: "oss_object uploadid [options]",
+	paramText: "oss_object [uploadid] [options]",
 
 	syntaxText: ` 
 	ossutil listpart oss://bucket/object uploadid [options]
+	ossutil listpart oss://bucket[/prefix] [--sort age|size|name] [--reverse] [--older-than duration] [options]
 `,
 
 	detailHelpText: ` 
@@ -22,25 +24,39 @@ var specChineseListPart = SpecText{
 
 用法：
 
-    该命令只有一种用法：
+    该命令有两种用法：
 
-    1) ossutil listpart oss://bucket/object uploadid [options]
+    1) ossutil listpart oss://bucket/object uploadid [--output json] [--abort] [options]
       根据object和uploadid查询块信息
+      --output为json或者yaml时以json或者yaml格式输出分块列表，--abort表示查询后取消该分片上传，
+      取消前询问确认，指定--assume-yes(-y)时不询问，--output为json或者yaml时必须指定--assume-yes
+
+    2) ossutil listpart oss://bucket[/prefix] [--sort age|size|name] [--reverse] [--older-than duration] [--output json] [options]
+      不指定uploadid时，列出bucket中以prefix开头的所有未完成的分片上传，每个分片上传输出一行：
+      初始化时间、已存在时长、发起者、存储类型、已上传的分片数和分片总字节数，最后输出汇总信息，
+      用于找出被遗忘的分片上传及其占用的空间。
+      --sort按age（从旧到新）、size（已上传字节数）或name排序，--reverse按降序输出，
+      --older-than只列出早于该时长之前初始化的分片上传，如：7d、36h。
+      如果服务端没有返回发起者或存储类型，对应列显示为-
 `,
 
 	sampleText: ` 
 	1) 根据object和uploadid查询块信息
        ossutil listpart oss://bucket/object 8A1912289A705A5F0503FCA71DABFD5A
+
+	2) 列出bucket中超过30天的分片上传，按已上传的字节数从大到小排序
+       ossutil listpart oss://bucket --older-than 30d --sort size --reverse
 `,
 }
 
 var specEnglishListPart = SpecText{
 	synopsisText: "List parts information of uncompleted multipart object",
 
-	paramText: "oss_object uploadid [options]",
+	paramText: "oss_object [uploadid] [options]",
 
 	syntaxText: ` 
 	ossutil listpart oss://bucket/object uploadid [options]
+	ossutil listpart oss://bucket[/prefix] [--sort age|size|name] [--reverse] [--older-than duration] [options]
 `,
 
 	detailHelpText: ` 
@@ -49,17 +65,34 @@ var specEnglishListPart = SpecText{
 
 Usages：
 
-    There is only one usage for this command:
+    There are two usages for this command:
 
-    1) ossutil listpart oss://bucket/object uploadid [options]
+    1) ossutil listpart oss://bucket/object uploadid [--output json] [--abort] [options]
 
       Query parts information according to object and uploadid
+      If --output is json or yaml, the parts are printed in json or yaml format, --abort aborts the upload after listing,
+      the confirmation is asked before aborting unless --assume-yes(-y), which is required if --output is json or yaml
+
+    2) ossutil listpart oss://bucket[/prefix] [--sort age|size|name] [--reverse] [--older-than duration] [--output json] [options]
+
+      Without uploadid, list all the uncompleted multipart uploads of the bucket whose object starts with
+      the prefix, one line per upload: the initiation time, the age, the initiator, the storage class,
+      the count and the total bytes of the uploaded parts, followed by the totals. It helps to find the
+      abandoned uploads and the space they take.
+      --sort sorts the uploads by age (oldest first), size (bytes uploaded) or name, --reverse outputs
+      in descending order, --older-than only lists the uploads initiated earlier than the duration ago,
+      e.g., 7d, 36h.
+      The initiator or the storage class is shown as - if it's not returned by the server
 `,
 
 	sampleText: ` 
 	1) Query parts information according to object and uploadid
 
       ossutil listpart oss://bucket/object 8A1912289A705A5F0503FCA71DABFD5A
+
+	2) List the uploads of the bucket older than 30 days, the largest first
+
+      ossutil listpart oss://bucket --older-than 30d --sort size --reverse
 `,
 }
 
@@ -67,6 +100,8 @@ type listPartOptionType struct {
 	cloudUrl     CloudURL
 	uploadId     string
 	encodingType string
+	output       outputFormat
+	abort        bool
 }
 
 type ListPartCommand struct {
@@ -76,13 +111,11 @@ type ListPartCommand struct {
 
 var listPartCommand = ListPartCommand{
 	command: Command{
-		name:        "listpart",
-		nameAlias:   []string{"listpart"},
-		minArgc:     2,
-		maxArgc:     2,
-		specChinese: specChineseListPart,
-		specEnglish: specEnglishListPart,
-		group:       GroupTypeNormalCommand,
+		name:      "listpart",
+		nameAlias: []string{"listpart"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -102,6 +135,11 @@ var listPartCommand = ListPartCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -109,6 +147,11 @@ var listPartCommand = ListPartCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionOutput,
+			OptionAbort,
+			OptionSort,
+			OptionReverse,
+			OptionOlderThan,
 		},
 	},
 }
@@ -123,8 +166,8 @@ func (lpc *ListPartCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (lpc *ListPartCommand) Init(args []string, options OptionMapType) error {
-	return lpc.command.Init(args, options, lpc)
+func (lpc *ListPartCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return lpc.command.Init(ctx, args, options, lpc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -135,18 +178,33 @@ func (lpc *ListPartCommand) RunCommand() error {
 		return err
 	}
 
+	lpc.lpOption.cloudUrl = *srcBucketUrL
+	lpc.lpOption.abort, _ = GetBool(OptionAbort, lpc.command.options)
+	if lpc.lpOption.output, err = getOutputFormat(lpc.command.options); err != nil {
+		return err
+	}
+
+	if len(lpc.command.args) == 1 {
+		if lpc.lpOption.abort {
+			return fmt.Errorf("--abort only works with uploadid, use rm -m to remove the uploads")
+		}
+		return lpc.listUploads()
+	}
+	if lpc.lpOption.abort {
+		if err = lpc.command.checkStructuredConfirm("--abort", lpc.lpOption.output); err != nil {
+			return err
+		}
+	}
+
 	if srcBucketUrL.object == "" {
 		return fmt.Errorf("object name is empty")
 	}
-
-	lpc.lpOption.cloudUrl = *srcBucketUrL
 	lpc.lpOption.uploadId = lpc.command.args[1]
-
 	return lpc.ListPart()
 }
 
 func (lpc *ListPartCommand) ListPart() error {
-	client, err := lpc.command.ossClient(lpc.lpOption.cloudUrl.bucket)
+	client, err := lpc.command.cloudClient(lpc.lpOption.cloudUrl)
 	if err != nil {
 		return err
 	}
@@ -161,9 +219,8 @@ func (lpc *ListPartCommand) ListPart() error {
 	imur.Key = lpc.lpOption.cloudUrl.object
 	imur.UploadID = lpc.lpOption.uploadId
 
+	info := uploadPartsInfo{Object: CloudURLToString(imur.Bucket, imur.Key), UploadID: imur.UploadID, Parts: []partInfo{}}
 	partNumberMarker := 0
-	totalPartCount := 0
-	var totalPartSize int64 = 0
 	for i := 0; ; i++ {
 		lpOptions := []oss.Option{}
 		lpOptions = append(lpOptions, oss.MaxParts(1000))
@@ -173,16 +230,19 @@ func (lpc *ListPartCommand) ListPart() error {
 		if err != nil {
 			return err
 		} else {
-			totalPartCount += len(lpRes.UploadedParts)
-			if i == 0 && len(lpRes.UploadedParts) > 0 {
+			info.PartCount += int64(len(lpRes.UploadedParts))
+			if !lpc.lpOption.output.structured() && i == 0 && len(lpRes.UploadedParts) > 0 {
 				fmt.Printf("%-10s\t%-32s\t%-10s\t%s\n", "PartNumber", "Etag", "Size(Byte)", "LastModifyTime")
 			}
 		}
 
 		for _, v := range lpRes.UploadedParts {
 			//PartNumber,ETag,Size,LastModified
-			fmt.Printf("%-10d\t%-32s\t%-10d\t%s\n", v.PartNumber, v.ETag, v.Size, v.LastModified.Format("2006-01-02 15:04:05"))
-			totalPartSize += int64(v.Size)
+			if !lpc.lpOption.output.structured() {
+				fmt.Printf("%-10d\t%-32s\t%-10d\t%s\n", v.PartNumber, v.ETag, v.Size, v.LastModified.Format("2006-01-02 15:04:05"))
+			}
+			info.PartSize += int64(v.Size)
+			info.Parts = append(info.Parts, newPartInfo(v))
 		}
 
 		if lpRes.IsTruncated {
@@ -191,11 +251,32 @@ func (lpc *ListPartCommand) ListPart() error {
 				return err
 			}
 		} else {
-			if totalPartCount > 0 {
-				fmt.Printf("\ntotal part count:%d\ttotal part size(MB):%.2f\n\n", totalPartCount, float64(totalPartSize/1024)/1024)
+			if !lpc.lpOption.output.structured() && info.PartCount > 0 {
+				fmt.Printf("\ntotal part count:%d\ttotal part size(MB):%.2f\n\n", info.PartCount, float64(info.PartSize/1024)/1024)
 			}
 			break
 		}
 	}
-	return nil
+
+	var abortErr error
+	if lpc.lpOption.abort {
+		if !lpc.command.confirmOperation(fmt.Sprintf("abort the upload %s of %s(%d parts, %d bytes)", imur.UploadID, info.Object,
+			info.PartCount, info.PartSize)) {
+			return nil
+		}
+		if abortErr = bucket.AbortMultipartUpload(imur); abortErr != nil {
+			info.Error = abortErr.Error()
+		} else {
+			info.Aborted = true
+			if !lpc.lpOption.output.structured() {
+				fmt.Printf("abort upload %s of %s success\n", imur.UploadID, info.Object)
+			}
+		}
+	}
+	if lpc.lpOption.output.structured() {
+		if err := lpc.lpOption.output.print(info); err != nil {
+			return err
+		}
+	}
+	return abortErr
 }
@@ -1,9 +1,14 @@
 package lib
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/aliyun/aliyun-oss-go-sdk/oss"
 	. "gopkg.in/check.v1"
@@ -178,3 +183,119 @@ func (s *OssutilCommandSuite) TestListPartHelp(c *C) {
 	_, err = cm.RunCommand("help", mkArgs, options)
 	c.Assert(err, IsNil)
 }
+
+func (s *OssutilCommandSuite) TestListPartUploads(c *C) {
+	now := time.Now().UTC()
+	uploadsXML := `<?xml version="1.0" encoding="UTF-8"?>
+<ListMultipartUploadsResult>
+  <Bucket>bucket</Bucket>
+  <EncodingType>url</EncodingType>
+  <IsTruncated>false</IsTruncated>
+  <Upload>
+    <Key>dir%2Fold</Key>
+    <UploadId>old-upload</UploadId>
+    <StorageClass>IA</StorageClass>
+    <Initiator><ID>1234</ID><DisplayName>1234</DisplayName></Initiator>
+    <Initiated>` + now.Add(-40*24*time.Hour).Format(time.RFC3339) + `</Initiated>
+  </Upload>
+  <Upload>
+    <Key>dir%2Fnew</Key>
+    <UploadId>new-upload</UploadId>
+    <Initiated>` + now.Add(-2*time.Hour).Format(time.RFC3339) + `</Initiated>
+  </Upload>
+</ListMultipartUploadsResult>`
+	partsXML := func(size int) string {
+		return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ListPartsResult>
+  <IsTruncated>false</IsTruncated>
+  <Part><PartNumber>1</PartNumber><ETag>"etag"</ETag><Size>%d</Size></Part>
+  <Part><PartNumber>2</PartNumber><ETag>"etag"</ETag><Size>%d</Size></Part>
+</ListPartsResult>`, size, size)
+	}
+	prefixes := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case query.Get("uploadId") == "old-upload":
+			fmt.Fprint(w, partsXML(100))
+		case query.Get("uploadId") == "new-upload":
+			fmt.Fprint(w, partsXML(1000))
+		default:
+			prefixes <- query.Get("prefix")
+			fmt.Fprint(w, uploadsXML)
+		}
+	}))
+	defer server.Close()
+
+	str := "ak"
+	forcePathStyle := true
+	output := "json"
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionOutput:          &output,
+	}
+
+	listUploads := func() []uploadSummary {
+		resultPath := "ossutil-test-listpart-" + randLowStr(8)
+		defer os.Remove(resultPath)
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		_, err = cm.RunCommand("listpart", []string{"oss://bucket/dir/"}, options)
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		c.Assert(err, IsNil)
+		c.Assert(<-prefixes, Equals, "dir/")
+
+		data, err := ioutil.ReadFile(resultPath)
+		c.Assert(err, IsNil)
+		uploads := []uploadSummary{}
+		c.Assert(json.Unmarshal(data, &uploads), IsNil)
+		return uploads
+	}
+
+	// the initiator and the storage class are shown as - if they are not returned
+	uploads := listUploads()
+	c.Assert(len(uploads), Equals, 2)
+	c.Assert(uploads[0].Object, Equals, "oss://bucket/dir/old")
+	c.Assert(uploads[0].Initiator, Equals, "1234")
+	c.Assert(uploads[0].StorageClass, Equals, "IA")
+	c.Assert(uploads[0].PartCount, Equals, int64(2))
+	c.Assert(uploads[0].PartSize, Equals, int64(200))
+	c.Assert(uploads[0].AgeSeconds >= 40*24*3600, Equals, true)
+	c.Assert(uploads[1].Initiator, Equals, "-")
+	c.Assert(uploads[1].StorageClass, Equals, "-")
+	c.Assert(uploads[1].PartSize, Equals, int64(2000))
+
+	// --sort
+	sortField := "size"
+	reverse := true
+	options[OptionSort] = &sortField
+	options[OptionReverse] = &reverse
+	uploads = listUploads()
+	c.Assert(uploads[0].UploadID, Equals, "new-upload")
+	sortField = "age"
+	reverse = false
+	uploads = listUploads()
+	c.Assert(uploads[0].UploadID, Equals, "new-upload")
+	c.Assert(uploads[1].UploadID, Equals, "old-upload")
+
+	// --older-than
+	olderThan := "30d"
+	options[OptionOlderThan] = &olderThan
+	uploads = listUploads()
+	c.Assert(len(uploads), Equals, 1)
+	c.Assert(uploads[0].UploadID, Equals, "old-upload")
+
+	sortField = "mtime"
+	_, err := cm.RunCommand("listpart", []string{"oss://bucket/dir/"}, options)
+	c.Assert(err, NotNil)
+
+	c.Assert(formatUploadAge(3*24*time.Hour+4*time.Hour+time.Minute), Equals, "3d4h")
+	c.Assert(formatUploadAge(5*time.Hour+12*time.Minute), Equals, "5h12m")
+	c.Assert(formatUploadAge(40*time.Minute), Equals, "40m")
+}
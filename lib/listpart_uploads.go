@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// multipartOwner is the initiator or the owner of the multipart upload
+type multipartOwner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+// multipartUpload is the upload of the ListMultipartUploads response, the sdk does not parse the
+// initiator, the owner and the storage class of the upload
+type multipartUpload struct {
+	Key          string         `xml:"Key"`
+	UploadID     string         `xml:"UploadId"`
+	Initiated    time.Time      `xml:"Initiated"`
+	StorageClass string         `xml:"StorageClass"`
+	Initiator    multipartOwner `xml:"Initiator"`
+	Owner        multipartOwner `xml:"Owner"`
+}
+
+type listMultipartUploadsResult struct {
+	XMLName            xml.Name          `xml:"ListMultipartUploadsResult"`
+	IsTruncated        bool              `xml:"IsTruncated"`
+	NextKeyMarker      string            `xml:"NextKeyMarker"`
+	NextUploadIDMarker string            `xml:"NextUploadIdMarker"`
+	Uploads            []multipartUpload `xml:"Upload"`
+}
+
+// uploadSummary is the line of an uncompleted multipart upload when listpart lists the uploads
+type uploadSummary struct {
+	Object       string `json:"object"`
+	UploadID     string `json:"uploadId"`
+	Initiated    string `json:"initiated"`
+	AgeSeconds   int64  `json:"ageSeconds"`
+	Initiator    string `json:"initiator"`
+	StorageClass string `json:"storageClass"`
+	PartCount    int64  `json:"partCount"`
+	PartSize     int64  `json:"partSize"`
+	initiated    time.Time
+}
+
+// listUploads lists the uncompleted multipart uploads under the url with their initiator, storage
+// class and the bytes of the uploaded parts, the uploads are sorted by --sort
+func (lpc *ListPartCommand) listUploads() error {
+	field, _ := GetString(OptionSort, lpc.command.options)
+	reverse, _ := GetBool(OptionReverse, lpc.command.options)
+	field = strings.ToLower(field)
+	if field != "" && field != "age" && field != "size" && field != "name" {
+		return fmt.Errorf("invalid --sort: %s, listpart only supports age, size or name", field)
+	}
+	if reverse && field == "" {
+		return fmt.Errorf("--reverse only works with --sort")
+	}
+	var olderThan time.Duration
+	if strOlderThan, _ := GetString(OptionOlderThan, lpc.command.options); strOlderThan != "" {
+		var err error
+		if olderThan, err = parseAgeDuration(strOlderThan); err != nil {
+			return fmt.Errorf("invalid --older-than: %s, %s", strOlderThan, err.Error())
+		}
+	}
+
+	bucket, err := lpc.command.cloudBucket(lpc.lpOption.cloudUrl)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	uploads := []uploadSummary{}
+	keyMarker, uploadIDMarker := "", ""
+	for {
+		result, err := lpc.listMultipartUploads(bucket, keyMarker, uploadIDMarker)
+		if err != nil {
+			return err
+		}
+		for _, upload := range result.Uploads {
+			if olderThan > 0 && now.Sub(upload.Initiated) < olderThan {
+				continue
+			}
+			summary, err := lpc.summarizeUpload(bucket, upload, now)
+			if err != nil {
+				return err
+			}
+			uploads = append(uploads, summary)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+
+	sortUploadSummaries(uploads, field, reverse)
+	if lpc.lpOption.output.structured() {
+		return lpc.lpOption.output.print(uploads)
+	}
+
+	var totalPartCount, totalPartSize int64
+	if len(uploads) > 0 {
+		fmt.Printf("%-20s  %-10s  %-20s  %-12s  %-10s  %-16s  %-32s  %s\n", "InitiatedTime", "Age", "Initiator", "StorageClass",
+			"PartCount", "PartSize(Byte)", "UploadID", "ObjectName")
+	}
+	for _, upload := range uploads {
+		fmt.Printf("%-20s  %-10s  %-20s  %-12s  %-10d  %-16d  %-32s  %s\n", upload.initiated.Local().Format("2006-01-02 15:04:05"),
+			formatUploadAge(time.Duration(upload.AgeSeconds)*time.Second), upload.Initiator, upload.StorageClass,
+			upload.PartCount, upload.PartSize, upload.UploadID, upload.Object)
+		totalPartCount += upload.PartCount
+		totalPartSize += upload.PartSize
+	}
+	fmt.Printf("\nupload count:%d\ttotal part count:%d\ttotal part size(MB):%.2f\n\n", len(uploads), totalPartCount, float64(totalPartSize/1024)/1024)
+	return nil
+}
+
+// listMultipartUploads lists a page of the uploads by the raw request, so that the fields not
+// parsed by the sdk are kept
+func (lpc *ListPartCommand) listMultipartUploads(bucket *oss.Bucket, keyMarker, uploadIDMarker string) (listMultipartUploadsResult, error) {
+	var result listMultipartUploadsResult
+	params := map[string]interface{}{
+		"uploads":          nil,
+		"max-uploads":      "1000",
+		"encoding-type":    "url",
+		"key-marker":       keyMarker,
+		"upload-id-marker": uploadIDMarker,
+	}
+	if lpc.lpOption.cloudUrl.object != "" {
+		params["prefix"] = lpc.lpOption.cloudUrl.object
+	}
+	resp, err := bucket.Do("GET", "", params, nil, nil, nil)
+	if err != nil {
+		return result, ObjectError{err, bucket.BucketName, lpc.lpOption.cloudUrl.object}
+	}
+	defer resp.Body.Close()
+	if err = xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, err
+	}
+
+	// the keys and the markers are url encoded
+	for i := range result.Uploads {
+		if result.Uploads[i].Key, err = url.QueryUnescape(result.Uploads[i].Key); err != nil {
+			return result, err
+		}
+	}
+	if result.NextKeyMarker, err = url.QueryUnescape(result.NextKeyMarker); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// summarizeUpload lists the parts of the upload to accumulate their bytes
+func (lpc *ListPartCommand) summarizeUpload(bucket *oss.Bucket, upload multipartUpload, now time.Time) (uploadSummary, error) {
+	summary := uploadSummary{
+		Object:       bucketObjectURL(bucket, upload.Key),
+		UploadID:     upload.UploadID,
+		Initiated:    upload.Initiated.Format(time.RFC3339),
+		AgeSeconds:   int64(now.Sub(upload.Initiated) / time.Second),
+		Initiator:    upload.Initiator.ID,
+		StorageClass: upload.StorageClass,
+		initiated:    upload.Initiated,
+	}
+	if summary.Initiator == "" {
+		summary.Initiator = upload.Owner.ID
+	}
+	if summary.Initiator == "" {
+		summary.Initiator = "-"
+	}
+	if summary.StorageClass == "" {
+		summary.StorageClass = "-"
+	}
+
+	imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: upload.Key, UploadID: upload.UploadID}
+	partNumberMarker := 0
+	for {
+		lpRes, err := bucket.ListUploadedParts(imur, oss.MaxParts(1000), oss.PartNumberMarker(partNumberMarker))
+		if err != nil {
+			// the upload may be completed or aborted after it's listed
+			if isNotFound(err) {
+				return summary, nil
+			}
+			return summary, err
+		}
+		for _, part := range lpRes.UploadedParts {
+			summary.PartCount++
+			summary.PartSize += int64(part.Size)
+		}
+		if !lpRes.IsTruncated {
+			return summary, nil
+		}
+		if partNumberMarker, err = strconv.Atoi(lpRes.NextPartNumberMarker); err != nil {
+			return summary, err
+		}
+	}
+}
+
+func sortUploadSummaries(uploads []uploadSummary, field string, reverse bool) {
+	less := func(i, j int) bool { return false }
+	switch field {
+	case "age":
+		less = func(i, j int) bool { return uploads[i].initiated.After(uploads[j].initiated) }
+	case "size":
+		less = func(i, j int) bool { return uploads[i].PartSize < uploads[j].PartSize }
+	case "name":
+		less = func(i, j int) bool { return uploads[i].Object < uploads[j].Object }
+	}
+	if reverse {
+		sort.SliceStable(uploads, func(i, j int) bool { return less(j, i) })
+	} else {
+		sort.SliceStable(uploads, less)
+	}
+}
+
+// formatUploadAge formats the age like 3d4h, 5h12m or 40m
+func formatUploadAge(age time.Duration) string {
+	switch {
+	case age >= 24*time.Hour:
+		return fmt.Sprintf("%dd%dh", age/(24*time.Hour), age%(24*time.Hour)/time.Hour)
+	case age >= time.Hour:
+		return fmt.Sprintf("%dh%dm", age/time.Hour, age%time.Hour/time.Minute)
+	}
+	return fmt.Sprintf("%dm", age/time.Minute)
+}
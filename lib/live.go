@@ -0,0 +1,362 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseLive = SpecText{
+	synopsisText: "管理bucket的LiveChannel",
+
+	paramText: "create|list|delete|status|signrtmp cloud_url [options]",
+
+	syntaxText: `
+    ossutil live create oss://bucket/channel [--playlist-name name] [--frag-duration n] [--frag-count n] [options]
+    ossutil live list oss://bucket[/prefix] [--marker marker] [--limited-num n] [options]
+    ossutil live delete oss://bucket/channel [options]
+    ossutil live status oss://bucket/channel [--channel-status enabled|disabled] [options]
+    ossutil live signrtmp oss://bucket/channel [--playlist-name name] [--timeout t] [options]
+`,
+
+	detailHelpText: `
+    live命令封装了oss的LiveChannel接口，用于通过RTMP协议推流并转储为HLS格式的object，
+    第一个参数为子命令：
+
+    1) create: 创建LiveChannel，输出推流地址和播放地址
+        --playlist-name指定m3u8文件的名称，默认为playlist.m3u8
+        --frag-duration指定每个ts文件的时长（秒），取值范围1-100，默认为5
+        --frag-count指定m3u8文件中包含的ts文件个数，取值范围1-100，默认为3
+
+    2) list: 列举bucket下的LiveChannel，cloud_url中bucket之后的部分作为前缀，
+        指定--limited-num时最多输出该数量的LiveChannel，剩余时输出NextMarker，可作为下一次的--marker
+
+    3) delete: 删除LiveChannel，已生成的ts和m3u8文件不会被删除，删除前询问确认，指定--assume-yes(-y)时不询问
+
+    4) status: 查询LiveChannel的推流状态，包括状态、客户端地址以及音视频信息；
+        如果指定了--channel-status，则将LiveChannel设置为enabled或disabled，
+        disabled的LiveChannel不能推流，正在推流的客户端会被断开
+
+    5) signrtmp: 生成带签名的RTMP推流地址，私有bucket推流时需要使用签名地址，
+        --timeout指定签名地址的有效时长（秒），默认为60秒
+`,
+
+	sampleText: `
+    1) 创建LiveChannel
+       ossutil live create oss://bucket/channel1 --frag-duration 10 --frag-count 5
+
+    2) 列举bucket下前缀为test的LiveChannel
+       ossutil live list oss://bucket/test
+
+    3) 删除LiveChannel
+       ossutil live delete oss://bucket/channel1
+
+    4) 查询LiveChannel的推流状态
+       ossutil live status oss://bucket/channel1
+
+    5) 禁用LiveChannel
+       ossutil live status oss://bucket/channel1 --channel-status disabled
+
+    6) 生成有效期为1小时的签名推流地址
+       ossutil live signrtmp oss://bucket/channel1 --timeout 3600
+`,
+}
+
+var specEnglishLive = SpecText{
+	synopsisText: "Manage the LiveChannels of bucket",
+
+	paramText: "create|list|delete|status|signrtmp cloud_url [options]",
+
+	syntaxText: `
+    ossutil live create oss://bucket/channel [--playlist-name name] [--frag-duration n] [--frag-count n] [options]
+    ossutil live list oss://bucket[/prefix] [--marker marker] [--limited-num n] [options]
+    ossutil live delete oss://bucket/channel [options]
+    ossutil live status oss://bucket/channel [--channel-status enabled|disabled] [options]
+    ossutil live signrtmp oss://bucket/channel [--playlist-name name] [--timeout t] [options]
+`,
+
+	detailHelpText: `
+    live command wraps the LiveChannel apis of oss, which ingest the stream by RTMP and store it
+    as HLS objects, the first argument is the sub command:
+
+    1) create: create the LiveChannel, and print the publish urls and the play urls
+        --playlist-name specifies the name of m3u8 object, default is playlist.m3u8
+        --frag-duration specifies the duration(seconds) of each ts object, range is 1-100, default is 5
+        --frag-count specifies the number of ts objects in m3u8 object, range is 1-100, default is 3
+
+    2) list: list the LiveChannels of bucket, the part after bucket in cloud_url is the prefix, if
+        --limited-num is specified, at most the number of LiveChannels are printed, and NextMarker
+        is printed if more remain, which can be used as --marker of the next listing
+
+    3) delete: delete the LiveChannel, the ts and m3u8 objects generated are not deleted, ossutil asks
+        for confirmation before deleting, which is skipped by --assume-yes(-y)
+
+    4) status: get the ingest status of the LiveChannel, including the status, the client address
+        and the video and audio information; if --channel-status is specified, the LiveChannel is set
+        to enabled or disabled, a disabled LiveChannel can't be ingested, and the client ingesting
+        is disconnected
+
+    5) signrtmp: generate the signed RTMP publish url, which is required to ingest the private
+        bucket, --timeout specifies the seconds the url is valid, default is 60 seconds
+`,
+
+	sampleText: `
+    1) create the LiveChannel
+       ossutil live create oss://bucket/channel1 --frag-duration 10 --frag-count 5
+
+    2) list the LiveChannels with prefix test
+       ossutil live list oss://bucket/test
+
+    3) delete the LiveChannel
+       ossutil live delete oss://bucket/channel1
+
+    4) get the ingest status of the LiveChannel
+       ossutil live status oss://bucket/channel1
+
+    5) disable the LiveChannel
+       ossutil live status oss://bucket/channel1 --channel-status disabled
+
+    6) generate the signed publish url which is valid for 1 hour
+       ossutil live signrtmp oss://bucket/channel1 --timeout 3600
+`,
+}
+
+const (
+	defaultLivePlaylistName = "playlist.m3u8"
+	defaultLiveFragDuration = 5
+	defaultLiveFragCount    = 3
+)
+
+type LiveCommand struct {
+	command Command
+	bucket  *oss.Bucket
+	channel string
+}
+
+var liveCommand = LiveCommand{
+	command: Command{
+		name:      "live",
+		nameAlias: []string{"live"},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionPlaylistName,
+			OptionFragDuration,
+			OptionFragCount,
+			OptionChannelStatus,
+			OptionMarker,
+			OptionLimitedNum,
+			OptionTimeout,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (lc *LiveCommand) formatHelpForWhole() string {
+	return lc.command.formatHelpForWhole()
+}
+
+func (lc *LiveCommand) formatIndependHelp() string {
+	return lc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (lc *LiveCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return lc.command.Init(ctx, args, options, lc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (lc *LiveCommand) RunCommand() error {
+	action := strings.ToLower(lc.command.args[0])
+	if action != "create" && action != "list" && action != "delete" && action != "status" && action != "signrtmp" {
+		return fmt.Errorf("the sub command %s is not in the optional value:create|list|delete|status|signrtmp", lc.command.args[0])
+	}
+
+	cloudURL, err := GetCloudUrl(lc.command.args[1], "")
+	if err != nil {
+		return err
+	}
+	if action != "list" && cloudURL.object == "" {
+		return fmt.Errorf("the channel name is empty, the cloud url should be oss://bucket/channel")
+	}
+	if strings.Contains(cloudURL.object, "/") && action != "list" {
+		return fmt.Errorf("invalid channel name %s, it can't contain '/'", cloudURL.object)
+	}
+
+	lc.bucket, err = lc.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return err
+	}
+	lc.channel = cloudURL.object
+
+	switch action {
+	case "create":
+		err = lc.createChannel()
+	case "list":
+		err = lc.listChannels()
+	case "delete":
+		if !lc.command.confirmOperation(fmt.Sprintf("delete the LiveChannel %s", lc.command.args[1])) {
+			return nil
+		}
+		err = lc.bucket.DeleteLiveChannel(lc.channel)
+	case "status":
+		err = lc.channelStatus()
+	case "signrtmp":
+		err = lc.signRtmpURL()
+	}
+	if err != nil {
+		return BucketError{err, cloudURL.bucket}
+	}
+	return nil
+}
+
+func (lc *LiveCommand) playlistName() string {
+	name, _ := GetString(OptionPlaylistName, lc.command.options)
+	if name == "" {
+		name = defaultLivePlaylistName
+	}
+	return name
+}
+
+func (lc *LiveCommand) createChannel() error {
+	fragDuration, _ := GetInt(OptionFragDuration, lc.command.options)
+	if fragDuration == 0 {
+		fragDuration = defaultLiveFragDuration
+	}
+	fragCount, _ := GetInt(OptionFragCount, lc.command.options)
+	if fragCount == 0 {
+		fragCount = defaultLiveFragCount
+	}
+
+	config := oss.LiveChannelConfiguration{
+		Target: oss.LiveChannelTarget{
+			Type:         "HLS",
+			FragDuration: int(fragDuration),
+			FragCount:    int(fragCount),
+			PlaylistName: lc.playlistName(),
+		},
+	}
+	result, err := lc.bucket.CreateLiveChannel(lc.channel, config)
+	if err != nil {
+		return err
+	}
+	for _, url := range result.PublishUrls {
+		fmt.Printf("PublishUrl:%s\n", url)
+	}
+	for _, url := range result.PlayUrls {
+		fmt.Printf("PlayUrl:%s\n", url)
+	}
+	return nil
+}
+
+func (lc *LiveCommand) listChannels() error {
+	marker, _ := GetString(OptionMarker, lc.command.options)
+	limitedNum, _ := GetInt(OptionLimitedNum, lc.command.options)
+
+	var channels []oss.LiveChannelInfo
+	nextMarker := ""
+	for {
+		result, err := lc.bucket.ListLiveChannel(oss.Prefix(lc.channel), oss.Marker(marker))
+		if err != nil {
+			return err
+		}
+		for _, channel := range result.LiveChannel {
+			if limitedNum > 0 && int64(len(channels)) >= limitedNum {
+				nextMarker = channels[len(channels)-1].Name
+				break
+			}
+			channels = append(channels, channel)
+		}
+		if nextMarker != "" || !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	if len(channels) > 0 {
+		fmt.Printf("%-20s %-10s %-30s %s\n", "LastModifiedTime", "Status", "Name", "PublishUrl")
+	}
+	for _, channel := range channels {
+		publishURL := ""
+		if len(channel.PublishUrls) > 0 {
+			publishURL = channel.PublishUrls[0]
+		}
+		fmt.Printf("%-20s %-10s %-30s %s\n", utcToLocalTime(channel.LastModified).Format("2006-01-02 15:04:05"),
+			channel.Status, channel.Name, publishURL)
+	}
+	fmt.Printf("\nChannel Number is: %d\n", len(channels))
+	if nextMarker != "" {
+		fmt.Printf("NextMarker     :%s\n", nextMarker)
+	}
+	return nil
+}
+
+func (lc *LiveCommand) channelStatus() error {
+	status, _ := GetString(OptionChannelStatus, lc.command.options)
+	if status != "" {
+		status = strings.ToLower(status)
+		if status != "enabled" && status != "disabled" {
+			return fmt.Errorf("--channel-status value is not in the optional value:enabled|disabled")
+		}
+		return lc.bucket.PutLiveChannelStatus(lc.channel, status)
+	}
+
+	stat, err := lc.bucket.GetLiveChannelStat(lc.channel)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Status:%s\n", stat.Status)
+	if stat.Status != "Live" {
+		return nil
+	}
+	fmt.Printf("ConnectedTime:%s\n", utcToLocalTime(stat.ConnectedTime).Format("2006-01-02 15:04:05"))
+	fmt.Printf("RemoteAddr:%s\n", stat.RemoteAddr)
+	fmt.Printf("Video:%dx%d, %dfps, %dB/s\n", stat.Video.Width, stat.Video.Height, stat.Video.FrameRate, stat.Video.Bandwidth)
+	fmt.Printf("Audio:%s, %dHz, %dB/s\n", stat.Audio.Codec, stat.Audio.SampleRate, stat.Audio.Bandwidth)
+	return nil
+}
+
+func (lc *LiveCommand) signRtmpURL() error {
+	timeout, err := GetInt(OptionTimeout, lc.command.options)
+	if err != nil || timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	url, err := lc.bucket.SignRtmpURL(lc.channel, lc.playlistName(), timeout)
+	if err != nil {
+		return err
+	}
+	fmt.Println(url)
+	return nil
+}
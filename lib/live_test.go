@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestLiveChannel(c *C) {
+	var mu sync.Mutex
+	var requests []string
+	var config string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+query.Get("status")+query.Get("marker"))
+		switch {
+		case r.Method == "PUT" && query.Get("status") == "":
+			body, _ := ioutil.ReadAll(r.Body)
+			config = string(body)
+			fmt.Fprint(w, `<CreateLiveChannelResult><PublishUrls><Url>rtmp://bucket.oss/live/channel1</Url></PublishUrls>
+<PlayUrls><Url>http://bucket.oss/channel1/playlist.m3u8</Url></PlayUrls></CreateLiveChannelResult>`)
+		case r.Method == "GET" && query.Get("comp") == "stat":
+			fmt.Fprint(w, `<LiveChannelStat><Status>Live</Status><ConnectedTime>2021-01-01T00:00:00.000Z</ConnectedTime>
+<RemoteAddr>10.0.0.1:1935</RemoteAddr><Video><Width>1280</Width><Height>720</Height><FrameRate>30</FrameRate><Bandwidth>100</Bandwidth></Video>
+<Audio><SampleRate>44100</SampleRate><Bandwidth>10</Bandwidth><Codec>AAC</Codec></Audio></LiveChannelStat>`)
+		case r.Method == "GET" && query.Get("marker") == "":
+			fmt.Fprint(w, `<ListLiveChannelResult><IsTruncated>true</IsTruncated><NextMarker>channel2</NextMarker>
+<LiveChannel><Name>channel1</Name><Status>enabled</Status><LastModified>2021-01-01T00:00:00.000Z</LastModified></LiveChannel>
+<LiveChannel><Name>channel2</Name><Status>disabled</Status><LastModified>2021-01-01T00:00:00.000Z</LastModified></LiveChannel>
+</ListLiveChannelResult>`)
+		case r.Method == "GET":
+			fmt.Fprint(w, `<ListLiveChannelResult><IsTruncated>false</IsTruncated>
+<LiveChannel><Name>channel3</Name><Status>enabled</Status><LastModified>2021-01-01T00:00:00.000Z</LastModified></LiveChannel>
+</ListLiveChannelResult>`)
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	fragDuration := "10"
+	channelStatus := ""
+	limitedNum := "-1"
+	yes := false
+	forcePathStyle := true
+	run := func(args ...string) string {
+		lc := liveCommand
+		options := OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &str,
+			OptionAccessKeySecret: &str,
+			OptionFragDuration:    &fragDuration,
+			OptionChannelStatus:   &channelStatus,
+			OptionLimitedNum:      &limitedNum,
+			OptionForcePathStyle:  &forcePathStyle,
+			OptionAssumeYes:       &yes,
+		}
+		c.Assert(lc.Init(context.Background(), args, options), IsNil)
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = lc.RunCommand()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		if err != nil {
+			return "error: " + err.Error()
+		}
+		return s.readFile(resultPath, c)
+	}
+
+	out := run("create", "oss://bucket/channel1")
+	c.Assert(strings.Contains(out, "PublishUrl:rtmp://bucket.oss/live/channel1"), Equals, true)
+	c.Assert(strings.Contains(out, "PlayUrl:http://bucket.oss/channel1/playlist.m3u8"), Equals, true)
+	c.Assert(strings.Contains(config, "<FragDuration>10</FragDuration><FragCount>3</FragCount><PlaylistName>playlist.m3u8</PlaylistName>"), Equals, true)
+
+	out = run("list", "oss://bucket")
+	c.Assert(strings.Contains(out, "channel3"), Equals, true)
+	c.Assert(strings.Contains(out, "Channel Number is: 3"), Equals, true)
+	c.Assert(strings.Contains(out, "NextMarker"), Equals, false)
+
+	limitedNum = "1"
+	out = run("list", "oss://bucket")
+	c.Assert(strings.Contains(out, "Channel Number is: 1"), Equals, true)
+	c.Assert(strings.Contains(out, "NextMarker     :channel1"), Equals, true)
+
+	out = run("status", "oss://bucket/channel1")
+	c.Assert(strings.Contains(out, "Status:Live"), Equals, true)
+	c.Assert(strings.Contains(out, "Video:1280x720, 30fps, 100B/s"), Equals, true)
+
+	requests = nil
+	channelStatus = "disabled"
+	run("status", "oss://bucket/channel1")
+	c.Assert(requests, DeepEquals, []string{"PUT /bucket/channel1 disabled"})
+	channelStatus = "closed"
+	c.Assert(strings.HasPrefix(run("status", "oss://bucket/channel1"), "error:"), Equals, true)
+
+	// the deletion is canceled without the confirmation
+	requests = nil
+	run("delete", "oss://bucket/channel1")
+	c.Assert(len(requests), Equals, 0)
+	yes = true
+	run("delete", "oss://bucket/channel1")
+	c.Assert(requests, DeepEquals, []string{"DELETE /bucket/channel1 "})
+
+	out = run("signrtmp", "oss://bucket/channel1")
+	c.Assert(strings.Contains(out, "rtmp://"), Equals, true)
+	c.Assert(strings.Contains(out, "playlistName=playlist.m3u8"), Equals, true)
+
+	c.Assert(strings.HasPrefix(run("start", "oss://bucket/channel1"), "error:"), Equals, true)
+	c.Assert(strings.HasPrefix(run("create", "oss://bucket"), "error:"), Equals, true)
+}
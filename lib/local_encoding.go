@@ -0,0 +1,47 @@
+package lib
+
+import (
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// the encodings of local file names supported by --local-encoding, the object names are always UTF-8
+const (
+	LocalEncodingGBK      = "gbk"
+	LocalEncodingShiftJIS = "shift-jis"
+)
+
+var localEncodings = map[string]encoding.Encoding{
+	LocalEncodingGBK:      simplifiedchinese.GBK,
+	LocalEncodingShiftJIS: japanese.ShiftJIS,
+}
+
+// decodeLocalName converts the local file name to UTF-8, the name is kept if it can't be decoded
+func decodeLocalName(name, localEncoding string) string {
+	enc, ok := localEncodings[localEncoding]
+	if !ok {
+		return name
+	}
+	decoded, err := enc.NewDecoder().String(name)
+	if err != nil {
+		LogWarn("decode file name %s by %s error:%s\n", name, localEncoding, err.Error())
+		return name
+	}
+	return decoded
+}
+
+// encodeLocalName converts the UTF-8 object name to the local encoding, the name is kept if
+// there are characters can't be encoded
+func encodeLocalName(name, localEncoding string) string {
+	enc, ok := localEncodings[localEncoding]
+	if !ok {
+		return name
+	}
+	encoded, err := enc.NewEncoder().String(name)
+	if err != nil {
+		LogWarn("encode object name %s by %s error:%s\n", name, localEncoding, err.Error())
+		return name
+	}
+	return encoded
+}
@@ -0,0 +1,28 @@
+package lib
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestLocalEncoding(c *C) {
+	// "中文.txt" in gbk
+	gbkName := "\xd6\xd0\xce\xc4.txt"
+	c.Assert(decodeLocalName(gbkName, LocalEncodingGBK), Equals, "中文.txt")
+	c.Assert(encodeLocalName("中文.txt", LocalEncodingGBK), Equals, gbkName)
+
+	// "表" in shift-jis ends with "\"
+	sjisName := "dir/\x95\x5c.txt"
+	c.Assert(decodeLocalName(sjisName, LocalEncodingShiftJIS), Equals, "dir/表.txt")
+	c.Assert(encodeLocalName("dir/表.txt", LocalEncodingShiftJIS), Equals, sjisName)
+
+	c.Assert(decodeLocalName(gbkName, ""), Equals, gbkName)
+	c.Assert(encodeLocalName("中文.txt", ""), Equals, "中文.txt")
+
+	// the character not in gbk is kept
+	c.Assert(encodeLocalName("한국어", LocalEncodingGBK), Equals, "한국어")
+
+	cc := &CopyCommand{}
+	cc.cpOption.localEncoding = LocalEncodingShiftJIS
+	c.Assert(cc.makeObjectName(CloudURL{object: "prefix/"}, fileInfoType{filePath: sjisName}), Equals, "prefix/dir/表.txt")
+	c.Assert(cc.makeLocalFileName("dir/表.txt", "local/", false), Equals, "local/"+sjisName)
+}
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -67,13 +68,11 @@ type LrbCommand struct {
 
 var lrbCommand = LrbCommand{
 	command: Command{
-		name:        "lrb",
-		nameAlias:   []string{"lrb"},
-		minArgc:     0,
-		maxArgc:     1,
-		specChinese: specChineseListRegionBucket,
-		specEnglish: specEnglishListRegionBucket,
-		group:       GroupTypeNormalCommand,
+		name:      "lrb",
+		nameAlias: []string{"lrb"},
+		minArgc:   0,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -92,6 +91,11 @@ var lrbCommand = LrbCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -113,8 +117,8 @@ func (lc *LrbCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (lc *LrbCommand) Init(args []string, options OptionMapType) error {
-	return lc.command.Init(args, options, lc)
+func (lc *LrbCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return lc.command.Init(ctx, args, options, lc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -168,7 +172,7 @@ func (lc *LrbCommand) listBuckets(endpoint string) error {
 	marker := oss.Marker("")
 
 	for {
-		lbr, err := client.ListBuckets(pre, marker, oss.AddParam("regionList", ""))
+		lbr, err := client.ListBuckets(lc.command.withContext([]oss.Option{pre, marker, oss.AddParam("regionList", "")})...)
 		if err != nil {
 			return err
 		}
@@ -1,7 +1,9 @@
 package lib
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -15,7 +17,7 @@ var specChineseList = SpecText{
 	paramText: "[cloud_url] [options]",
 
 	syntaxText: ` 
-    ossutil ls [oss://bucket[/prefix]] [-s] [-d] [-m] [--limited-num num] [--marker marker] [--upload-id-marker umarker] [--payer requester] [--include include-pattern] [--exclude exclude-pattern]  [--version-id-marker id_marker] [--all-versions]  [-c file] 
+    ossutil ls [oss://bucket[/prefix]] [-s] [-d] [-m] [--limited-num num] [--marker marker] [--start-after key] [--fetch-owner] [--upload-id-marker umarker] [--payer requester] [--include include-pattern] [--exclude exclude-pattern]  [--version-id-marker id_marker] [--all-versions] [--sort field] [--reverse] [--head num] [-0] [--output format]  [-c file] 
 `,
 
 	detailHelpText: ` 
@@ -30,6 +32,13 @@ var specChineseList = SpecText{
     url编码的。注意：形如oss://bucket/object的cloud_url，输入形式为：oss://bucket/url_encode(object)，
     其中oss://bucket/字符串不需要编码。
 
+--start-after和--fetch-owner选项
+
+    ossutil使用ListObjectsV2列举objects。指定--start-after选项时，从该key之后开始列举，与--marker
+    不同，--start-after的值始终为原始的key，不需要url编码，可以用于从任意key处继续列举。默认情况下
+    列举结果中不包含object的owner信息以减小响应大小，如果指定了--fetch-owner选项，长格式的列举
+    结果会增加Owner列。
+
 --include和--exclude选项
 
     可以指定该选项以指定规则筛选要操作的文件/object
@@ -43,6 +52,51 @@ var specChineseList = SpecText{
 
     --include和--exclude可以出现多次。当多个规则出现时，这些规则按从左往右的顺序应用
 
+--last-modified-after和--last-modified-before选项
+
+    只列举最后修改时间在[--last-modified-after, --last-modified-before)之间的objects，可以只指定其中一个，
+    时间可以为日期(2006-01-02，本地时间)、RFC3339时间、http日期或者unix时间戳。过滤在列举时进行，
+    对--all-versions列举的版本同样有效，不影响分片上传的列举。
+
+--sort、--reverse和--head选项
+
+    在客户端按size、mtime或name对列举出的objects排序后输出，默认升序，--reverse表示降序，相同
+    时按object名排序。排序需要列举完所有objects后才能输出，objects较多时，已排序的部分会写入临时
+    文件，最后归并输出，因此内存占用有上限。--head N表示只输出排序后的前N个objects，此时只需在
+    内存中保留N个objects。--limited-num限制的是参与排序的objects个数。--sort不支持-d、-m、-a和
+    --all-versions。
+
+--print0选项
+
+    -0或--print0表示只输出objects（以及-d时的目录）的cloud_url，每个cloud_url以NUL字符而不是换行
+    结尾，统计信息输出到stderr。object名中含有换行或空格时，输出也可以通过管道安全地传给xargs -0，
+    或者rm、stat的--null-input选项。--print0不支持-m、-a和--all-versions。
+
+--output选项
+
+    --output json表示每个bucket、object、目录或者碎片输出为一行json(json lines)，不输出表头，统计
+    信息输出到stderr，便于通过管道交给jq等工具处理。每行json的type字段为bucket、object、directory、
+    deleteMarker或multipart，url字段为cloud_url，时间为RFC3339格式，etag不含引号，--all-versions时
+    含有versionId和isLatest字段，--fetch-owner时含有owner字段。--output yaml表示每行json改为一个
+    以---开头的yaml文档，字段相同。--output json和yaml不能与--print0同时使用，默认为text。
+
+s3://格式的url
+
+    cloud_url也可以是s3://bucket[/object]格式，此时请求发送到s3兼容服务，并使用aws签名v4，
+    访问密钥读取自环境变量AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY和AWS_SESSION_TOKEN，region
+    读取自环境变量AWS_REGION或AWS_DEFAULT_REGION(默认为us-east-1)，endpoint通过--s3-endpoint
+    选项或者环境变量AWS_ENDPOINT_URL_S3、AWS_ENDPOINT_URL指定。s3://格式的url目前支持ls、cp、
+    rm和stat命令。
+
+oss-internal://和oss-acc://格式的url
+
+    cloud_url也可以是oss-internal://bucket[/object]或oss-acc://bucket[/object]格式，除了endpoint外与
+    oss://相同，该bucket的请求分别发送到bucket所在endpoint对应的内网endpoint（如：
+    oss-cn-hangzhou-internal.aliyuncs.com）或者传输加速endpoint（oss-accelerate.aliyuncs.com），
+    因此同一个命令中可以为不同的参数选择不同的网络，而不需要修改全局的--endpoint。bucket的endpoint
+    需要是oss-cn-hangzhou.aliyuncs.com这样的格式，不支持cname和云盒，同一个bucket不能同时使用两种格式。
+    如：ossutil cp oss-internal://src-bucket/dir/ oss-acc://dest-bucket/dir/ -r
+
 用法：
 
     该命令有两种用法：
@@ -51,6 +105,10 @@ var specChineseList = SpecText{
         如果用户列举时缺失cloud_url参数，则ossutil获取用户的身份凭证信息（从配置文件中读取），
     并列举该身份凭证下的所有buckets，并显示每个bucket的最新更新时间，位置，存储方式等信息。
     如果指定了--short-format选项则只输出bucket名称。该用法不支持--directory选项。
+        如果指定了--cloudbox-id选项，则列举该云盒内的所有buckets，请求使用v4签名，并以云盒的id
+    作为签名的region，product为oss-cloudbox。如果--endpoint不是该云盒的数据域名（如：
+    cb-xxx.cn-hangzhou.oss-cloudbox.aliyuncs.com），ossutil先通过--endpoint列举云盒（同lcb
+    命令）找到该云盒的数据域名，再向其发送请求。
 
     2) ossutil ls oss://bucket[/prefix] [-s] [-d] [-m] [-a] [--limited-num num] [--marker marker] [--upload-id-marker umarker]  [--version-id-marker id_marker] [--all-versions]
         如果未指定--multipart和--all-type选项，则ossutil列举指定bucket下的objects（如果指定
@@ -176,6 +234,14 @@ var specChineseList = SpecText{
         Object Number is: 2
 
     15) ossutil ls oss://bucket --all-versions
+
+    16) ossutil ls oss://bucket/logs/ --last-modified-after 2022-01-01 --last-modified-before 2022-02-01
+
+    17) ossutil ls oss://bucket/logs/ --sort size --reverse --head 10
+
+    18) ossutil ls oss://bucket/logs/ -0 | ossutil rm oss://bucket/logs/ -r -f --null-input
+
+    19) ossutil ls oss://bucket/logs/ --output json | jq -r 'select(.size > 1048576) | .url'
 `,
 }
 
@@ -186,7 +252,7 @@ var specEnglishList = SpecText{
 	paramText: "[cloud_url] [options]",
 
 	syntaxText: ` 
-    ossutil ls [oss://bucket[/prefix]] [-s] [-d] [-m] [--limited-num num] [--marker marker] [--upload-id-marker umarker] [--payer requester] [--include include-pattern] [--exclude exclude-pattern]  [--version-id-marker id_marker] [--all-versions]  [-c file] 
+    ossutil ls [oss://bucket[/prefix]] [-s] [-d] [-m] [--limited-num num] [--marker marker] [--start-after key] [--fetch-owner] [--upload-id-marker umarker] [--payer requester] [--include include-pattern] [--exclude exclude-pattern]  [--version-id-marker id_marker] [--all-versions] [--sort field] [--reverse] [--head num] [-0] [--output format]  [-c file] 
 `,
 
 	detailHelpText: ` 
@@ -205,6 +271,14 @@ var specEnglishList = SpecText{
     inputted as: oss://bucket/url_encode(object), the string: oss://bucket/ should not 
     be url encoded. 
 
+--start-after and --fetch-owner option
+
+    ossutil lists objects by ListObjectsV2. If --start-after option is specified, the objects
+    after the key are listed. Unlike --marker, the value of --start-after is always the raw 
+    key which is not url encoded, it can be used to resume the listing from any key. The owner
+    of objects is not returned by default to reduce the response size, if --fetch-owner option
+    is specified, the Owner column is added in long format.
+
 --include and --exclude option:
 
     These parameters perform pattern matching to either exclude or include a particular file or object
@@ -222,6 +296,61 @@ var specEnglishList = SpecText{
     When there are multi filters, the rule is the filters that appear later in the command take precedence
     over filters that appear earlier in the command
 
+--last-modified-after and --last-modified-before option
+
+    Only list the objects whose last modified time is in [--last-modified-after, --last-modified-before),
+    either of them can be specified alone. The time can be date(2006-01-02, local time), RFC3339 time,
+    http date or unix timestamp. The objects are filtered during listing, the versions listed by
+    --all-versions are filtered too, the listing of the multipart uploads isn't affected.
+
+--sort, --reverse and --head option
+
+    Sort the listed objects by size, mtime or name on the client side before outputting, in
+    ascending order by default, --reverse means descending order, the ties are broken by the
+    object name. The objects are output after all of them are listed, if there are many objects,
+    the sorted parts are spilled to the temp files and merged at last, so the memory usage is
+    bounded. --head N means only output the first N objects after sorting, then only N objects
+    are kept in memory. --limited-num limits the number of the objects to be sorted. --sort does
+    not support -d, -m, -a and --all-versions.
+
+--print0 option
+
+    -0 or --print0 means only output the cloud_urls of the objects(and the directories with -d),
+    every cloud_url ends with NUL character instead of newline, and the statistics are output to
+    stderr. The output can be piped safely to xargs -0, or --null-input option of rm and stat, even
+    if the object names contain newlines or spaces. --print0 does not support -m, -a and --all-versions.
+
+--output option
+
+    --output json means every bucket, object, directory or multipart upload is output as a line of
+    json(json lines) without the headers, and the statistics are output to stderr, so that the
+    output can be piped to tools like jq. The type field of every line is bucket, object,
+    directory, deleteMarker or multipart, the url field is the cloud_url, the times are in RFC3339
+    format and the etag is unquoted. The versionId and isLatest fields are present with
+    --all-versions, and the owner field with --fetch-owner. --output yaml outputs every line as a
+    yaml document starting with --- with the same fields. --output json and yaml can not be used
+    with --print0, the default is text.
+
+s3:// url
+
+    cloud_url can also be s3://bucket[/object], the requests are sent to the s3 compatible 
+    service and signed with aws signature v4. The credentials are read from environment variables
+    AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN, the region is read from 
+    AWS_REGION or AWS_DEFAULT_REGION(the default is us-east-1), the endpoint is specified by 
+    --s3-endpoint option or environment variables AWS_ENDPOINT_URL_S3 and AWS_ENDPOINT_URL. 
+    s3:// url is supported by ls, cp, rm and stat commands at present.
+
+oss-internal:// and oss-acc:// url
+
+    cloud_url can also be oss-internal://bucket[/object] or oss-acc://bucket[/object], which is
+    the same as oss:// except the endpoint, the requests of the bucket are sent to the internal
+    endpoint(e.g., oss-cn-hangzhou-internal.aliyuncs.com) or the accelerate endpoint
+    (oss-accelerate.aliyuncs.com) derived from the endpoint of the bucket, so the network can be
+    selected for each argument in one command without changing the global --endpoint. The
+    endpoint of the bucket should be like oss-cn-hangzhou.aliyuncs.com, the cname and the cloud box
+    are not supported, a bucket can't be referenced by both schemes.
+    e.g., ossutil cp oss-internal://src-bucket/dir/ oss-acc://dest-bucket/dir/ -r
+
 Usage:
 
     There are two usages:
@@ -231,6 +360,12 @@ Usage:
     in config file with last modified time and location in addition. --show_format option 
     will ignore last modified time and location. The usage do not support --directory 
     option.
+        If --cloudbox-id option is specified, ossutil lists all the buckets in the cloud box,
+    the requests are signed by v4 signature with the cloud box id as the region and 
+    oss-cloudbox as the product. If --endpoint is not the data endpoint of the cloud box(e.g., 
+    cb-xxx.cn-hangzhou.oss-cloudbox.aliyuncs.com), ossutil lists the cloud boxes by --endpoint 
+    first(the same as lcb command) to find the data endpoint of the cloud box, and sends the 
+    requests to it.
 
     2) ossutil ls oss://bucket[/prefix] [-s] [-d] [-m] [-a] [--limited-num num] [--marker marker] [--upload-id-marker umarker] [--version-id-marker id_marker] [--all-versions]
         If you list without --multipart and --all-type option, ossutil will list objects 
@@ -357,6 +492,14 @@ Usage:
         2019-05-30 14:24:05 +0800 CST         1030      Standard   4A902D176BE0EE4224BC196BBB8CCC69      oss://bucket/test.mp4
         Object Number is: 2
     15) ossutil ls oss://bucket[/prefix] --all-versions
+
+    16) ossutil ls oss://bucket/logs/ --last-modified-after 2022-01-01 --last-modified-before 2022-02-01
+
+    17) ossutil ls oss://bucket/logs/ --sort size --reverse --head 10
+
+    18) ossutil ls oss://bucket/logs/ -0 | ossutil rm oss://bucket/logs/ -r -f --null-input
+
+    19) ossutil ls oss://bucket/logs/ --output json | jq -r 'select(.size > 1048576) | .url'
 `,
 }
 
@@ -365,17 +508,19 @@ type ListCommand struct {
 	command     Command
 	payerOption oss.Option
 	filters     []filterOptionType
+	fetchOwner  bool
+	sorter      *listSorter // nil means no --sort
+	print0      bool
+	output      outputFormat
 }
 
 var listCommand = ListCommand{
 	command: Command{
-		name:        "ls",
-		nameAlias:   []string{"list"},
-		minArgc:     0,
-		maxArgc:     1,
-		specChinese: specChineseList,
-		specEnglish: specEnglishList,
-		group:       GroupTypeNormalCommand,
+		name:      "ls",
+		nameAlias: []string{"list"},
+		minArgc:   0,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -394,6 +539,15 @@ var listCommand = ListCommand{
 			OptionAllType,
 			OptionLimitedNum,
 			OptionMarker,
+			OptionStartAfter,
+			OptionFetchOwner,
+			OptionLastModifiedAfter,
+			OptionLastModifiedBefore,
+			OptionSort,
+			OptionReverse,
+			OptionHead,
+			OptionPrint0,
+			OptionOutput,
 			OptionUploadIDMarker,
 			OptionEncodingType,
 			OptionInclude,
@@ -408,6 +562,11 @@ var listCommand = ListCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -415,6 +574,7 @@ var listCommand = ListCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionS3Endpoint,
 		},
 	},
 }
@@ -429,12 +589,21 @@ func (lc *ListCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (lc *ListCommand) Init(args []string, options OptionMapType) error {
-	return lc.command.Init(args, options, lc)
+func (lc *ListCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return lc.command.Init(ctx, args, options, lc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
 func (lc *ListCommand) RunCommand() error {
+	var err error
+	if lc.output, err = getOutputFormat(lc.command.options); err != nil {
+		return err
+	}
+	lc.print0, _ = GetBool(OptionPrint0, lc.command.options)
+	if lc.print0 && lc.output.structured() {
+		return fmt.Errorf("--print0 and --output %s can not be used together", lc.output)
+	}
+
 	if len(lc.command.args) == 0 {
 		return lc.listBuckets("")
 	}
@@ -464,9 +633,43 @@ func (lc *ListCommand) RunCommand() error {
 		return fmt.Errorf("--include or --exclude does not support format containing dir info")
 	}
 
+	lc.fetchOwner, _ = GetBool(OptionFetchOwner, lc.command.options)
+	if lc.command.timeWindow, err = lc.command.newTimeWindow(); err != nil {
+		return err
+	}
+	if err = lc.initSorter(); err != nil {
+		return err
+	}
+	if allVersions, _ := GetBool(OptionAllversions, lc.command.options); lc.print0 && (allVersions || lc.getSubjectType() != objectType) {
+		return fmt.Errorf("--print0 only works for listing objects, it does not support --all-versions, --multipart or --all-type")
+	}
 	return lc.listFiles(cloudURL)
 }
 
+// initSorter checks --sort, --reverse and --head, the sorter is nil if --sort is not specified
+func (lc *ListCommand) initSorter() error {
+	lc.sorter = nil
+	field, _ := GetString(OptionSort, lc.command.options)
+	reverse, _ := GetBool(OptionReverse, lc.command.options)
+	head, _ := GetInt(OptionHead, lc.command.options)
+	if field == "" {
+		if reverse || head > 0 {
+			return fmt.Errorf("--reverse and --head only work with --sort")
+		}
+		return nil
+	}
+
+	directory, _ := GetBool(OptionDirectory, lc.command.options)
+	allVersions, _ := GetBool(OptionAllversions, lc.command.options)
+	if directory || allVersions || lc.getSubjectType() != objectType {
+		return fmt.Errorf("--sort only works for listing objects, it does not support --directory, --all-versions, --multipart or --all-type")
+	}
+
+	var err error
+	lc.sorter, err = newListSorter(field, reverse, head)
+	return err
+}
+
 func (lc *ListCommand) listBuckets(prefix string) error {
 	var err error
 	if err = lc.lbCheckArgOptions(); err != nil {
@@ -499,14 +702,23 @@ func (lc *ListCommand) listBuckets(prefix string) error {
 		}
 		pre = oss.Prefix(lbr.Prefix)
 		marker = oss.Marker(lbr.NextMarker)
-		if num == 0 && !shortFormat && len(lbr.Buckets) > 0 {
+		if num == 0 && !shortFormat && !lc.output.structured() && len(lbr.Buckets) > 0 {
 			fmt.Printf("%-30s %20s%s%12s%s%s\n", "CreationTime", "Region", FormatTAB, "StorageClass", FormatTAB, "BucketName")
 		}
 		for _, bucket := range lbr.Buckets {
 			if limitedNum >= 0 && num >= limitedNum {
 				break
 			}
-			if !shortFormat {
+			if lc.output.structured() {
+				fmt.Println(lc.output.line(lsBucketEntry{
+					Type:         lsEntryBucket,
+					URL:          CloudURLToString(bucket.Name, ""),
+					Name:         bucket.Name,
+					Region:       bucket.Location,
+					StorageClass: bucket.StorageClass,
+					CreationTime: bucket.CreationDate,
+				}))
+			} else if !shortFormat {
 				fmt.Printf("%-30s %20s%s%12s%s%s\n", utcToLocalTime(bucket.CreationDate), bucket.Location, FormatTAB, bucket.StorageClass, FormatTAB, CloudURLToString(bucket.Name, ""))
 			} else {
 				fmt.Println(CloudURLToString(bucket.Name, ""))
@@ -517,7 +729,7 @@ func (lc *ListCommand) listBuckets(prefix string) error {
 			break
 		}
 	}
-	fmt.Printf("Bucket Number is: %d\n", num)
+	fmt.Fprintf(lc.summary(), "Bucket Number is: %d\n", num)
 	return nil
 }
 
@@ -530,16 +742,20 @@ func (lc *ListCommand) lbCheckArgOptions() error {
 
 func (lc *ListCommand) ossListBucketsRetry(client *oss.Client, options ...oss.Option) (oss.ListBucketsResult, error) {
 	retryTimes, _ := GetInt(OptionRetryTimes, lc.command.options)
+	options = lc.command.withContext(options)
 	for i := 1; ; i++ {
 		lbr, err := client.ListBuckets(options...)
 		if err == nil || int64(i) >= retryTimes {
 			return lbr, err
 		}
+		if cerr := lc.command.context().Err(); cerr != nil {
+			return lbr, cerr
+		}
 	}
 }
 
 func (lc *ListCommand) listFiles(cloudURL CloudURL) error {
-	bucket, err := lc.command.ossBucket(cloudURL.bucket)
+	bucket, err := lc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
@@ -547,6 +763,8 @@ func (lc *ListCommand) listFiles(cloudURL CloudURL) error {
 	shortFormat, _ := GetBool(OptionShortFormat, lc.command.options)
 	directory, _ := GetBool(OptionDirectory, lc.command.options)
 	limitedNum, _ := GetInt(OptionLimitedNum, lc.command.options)
+	// --print0 only outputs the cloud urls, and --output json has no headers
+	shortFormat = shortFormat || lc.print0 || lc.output.structured()
 	allVersions, _ := GetBool(OptionAllversions, lc.command.options)
 	typeSet := lc.getSubjectType()
 	if typeSet&objectType != 0 {
@@ -585,47 +803,77 @@ func (lc *ListCommand) getSubjectType() int64 {
 
 func (lc *ListCommand) listObjects(bucket *oss.Bucket, cloudURL CloudURL, shortFormat bool, directory bool, limitedNum *int64) (int64, error) {
 	//list all objects or directories
-	var err error
 	var num int64
 	num = 0
-	pre := oss.Prefix(cloudURL.object)
-	vmarker, _ := GetString(OptionMarker, lc.command.options)
-	if vmarker, err = lc.command.getRawMarker(vmarker); err != nil {
-		return num, fmt.Errorf("invalid marker: %s, marker is not url encoded, %s", vmarker, err.Error())
+	startAfter, err := lc.command.getStartAfter()
+	if err != nil {
+		return num, err
+	}
+	listOptions := []oss.Option{oss.Prefix(cloudURL.object), lc.payerOption, oss.MaxKeys(1000)}
+	if startAfter != "" {
+		listOptions = append(listOptions, oss.StartAfter(startAfter))
 	}
-	marker := oss.Marker(vmarker)
-	del := oss.Delimiter("")
 	if directory {
-		del = oss.Delimiter("/")
+		listOptions = append(listOptions, oss.Delimiter("/"))
 	}
-	payer := lc.payerOption
+	if lc.fetchOwner {
+		listOptions = append(listOptions, oss.FetchOwner(true))
+	}
+	token := oss.ContinuationToken("")
 
 	var i int64
 	for i = 0; ; i++ {
 		if *limitedNum == 0 {
 			break
 		}
-		lor, err := lc.command.ossListObjectsRetry(bucket, marker, pre, del, payer, oss.MaxKeys(1000))
+		lor, err := lc.command.ossListObjectsV2Retry(bucket, append(listOptions, token)...)
 		if err != nil {
 			return num, err
 		}
-		pre = oss.Prefix(lor.Prefix)
-		marker = oss.Marker(lor.NextMarker)
-		num += lc.displayObjectsResult(lor, cloudURL.bucket, shortFormat, directory, i, limitedNum)
+		token = oss.ContinuationToken(lor.NextContinuationToken)
+		num += lc.displayObjectsResult(lor, cloudURL, shortFormat, directory, i, limitedNum)
 		if !lor.IsTruncated {
 			break
 		}
 	}
 
+	if lc.sorter != nil {
+		if !shortFormat && num > 0 {
+			lc.printObjectsHeader()
+		}
+		if num, err = lc.sorter.output(lc.printLine); err != nil {
+			return num, err
+		}
+	}
+
+	summary := lc.summary()
 	if !directory {
-		fmt.Printf("Object Number is: %d\n", num)
+		fmt.Fprintf(summary, "Object Number is: %d\n", num)
 	} else {
-		fmt.Printf("Object and Directory Number is: %d\n", num)
+		fmt.Fprintf(summary, "Object and Directory Number is: %d\n", num)
 	}
 
 	return num, nil
 }
 
+// summary returns where the statistics are output to, they are output to stderr for --print0
+// and --output json, so that stdout only has the cloud urls or the json lines
+func (lc *ListCommand) summary() io.Writer {
+	if lc.print0 || lc.output.structured() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// printLine outputs a listed object or directory, the line ends with NUL for --print0
+func (lc *ListCommand) printLine(line string) {
+	if lc.print0 {
+		fmt.Printf("%s\x00", line)
+	} else {
+		fmt.Println(line)
+	}
+}
+
 func (lc *ListCommand) listObjectVersions(bucket *oss.Bucket, cloudURL CloudURL, shortFormat bool, directory bool, limitedNum *int64) (int64, error) {
 	//list all object versions or directories
 	var err error
@@ -655,45 +903,53 @@ func (lc *ListCommand) listObjectVersions(bucket *oss.Bucket, cloudURL CloudURL,
 		if *limitedNum == 0 {
 			break
 		}
-		lor, err := bucket.ListObjectVersions(marker, pre, del, payer, versionIdMarker)
+		lor, err := bucket.ListObjectVersions(lc.command.withContext([]oss.Option{marker, pre, del, payer, versionIdMarker})...)
 		if err != nil {
 			return num, err
 		}
 		pre = oss.Prefix(lor.Prefix)
 		marker = oss.KeyMarker(lor.NextKeyMarker)
 		versionIdMarker = oss.VersionIdMarker(lor.NextVersionIdMarker)
-		num += lc.displayObjectVersionsResult(lor, cloudURL.bucket, shortFormat, directory, i, limitedNum)
+		num += lc.displayObjectVersionsResult(lor, cloudURL, shortFormat, directory, i, limitedNum)
 		if !lor.IsTruncated {
 			break
 		}
 	}
 
 	if !directory {
-		fmt.Printf("Object Number is: %d\n", num)
+		fmt.Fprintf(lc.summary(), "Object Number is: %d\n", num)
 	} else {
-		fmt.Printf("Object and Directory Number is: %d\n", num)
+		fmt.Fprintf(lc.summary(), "Object and Directory Number is: %d\n", num)
 	}
 	return num, nil
 }
 
-func (lc *ListCommand) displayObjectsResult(lor oss.ListObjectsResult, bucket string, shortFormat bool, directory bool, i int64, limitedNum *int64) int64 {
-	if i == 0 && !shortFormat && !directory && len(lor.Objects) > 0 {
-		fmt.Printf("%-30s%12s%s%12s%s%-36s%s%s\n", "LastModifiedTime", "Size(B)", "  ", "StorageClass", "   ", "ETAG", "  ", "ObjectName")
+func (lc *ListCommand) displayObjectsResult(lor oss.ListObjectsResultV2, cloudURL CloudURL, shortFormat bool, directory bool, i int64, limitedNum *int64) int64 {
+	if i == 0 && !shortFormat && !directory && len(lor.Objects) > 0 && lc.sorter == nil {
+		lc.printObjectsHeader()
 	}
 
 	var num int64
 	if !directory {
-		num = lc.showObjects(lor, bucket, shortFormat, limitedNum)
+		num = lc.showObjects(lor, cloudURL, shortFormat, limitedNum)
 	} else {
-		num = lc.showObjects(lor, bucket, true, limitedNum)
-		num1 := lc.showDirectories(lor, bucket, limitedNum)
+		num = lc.showObjects(lor, cloudURL, true, limitedNum)
+		num1 := lc.showDirectories(lor, cloudURL, limitedNum)
 		num += num1
 	}
 	return num
 }
 
-func (lc *ListCommand) displayObjectVersionsResult(lor oss.ListObjectVersionsResult, bucket string, shortFormat bool, directory bool, i int64, limitedNum *int64) int64 {
-	if i == 0 && (len(lor.ObjectDeleteMarkers) > 0 || len(lor.ObjectVersions) > 0) {
+func (lc *ListCommand) printObjectsHeader() {
+	if lc.fetchOwner {
+		fmt.Printf("%-30s%12s%s%12s%s%-36s%s%-20s%s%s\n", "LastModifiedTime", "Size(B)", "  ", "StorageClass", "   ", "ETAG", "  ", "Owner", "  ", "ObjectName")
+	} else {
+		fmt.Printf("%-30s%12s%s%12s%s%-36s%s%s\n", "LastModifiedTime", "Size(B)", "  ", "StorageClass", "   ", "ETAG", "  ", "ObjectName")
+	}
+}
+
+func (lc *ListCommand) displayObjectVersionsResult(lor oss.ListObjectVersionsResult, cloudURL CloudURL, shortFormat bool, directory bool, i int64, limitedNum *int64) int64 {
+	if i == 0 && !lc.output.structured() && (len(lor.ObjectDeleteMarkers) > 0 || len(lor.ObjectVersions) > 0) {
 		if directory {
 			fmt.Printf("%-6s%s%-30s%12s%s%12s%s%-36s%s%-66s%s%-10s%s%-13s%s%s\n", "COMMON-PREFIX", "  ", "LastModifiedTime", "Size(B)", "  ", "StorageClass", "  ", "ETAG", "  ", "VERSIONID", "  ", "IS-LATEST", "  ", "DELETE-MARKER", "  ", "ObjectName")
 		} else {
@@ -702,15 +958,15 @@ func (lc *ListCommand) displayObjectVersionsResult(lor oss.ListObjectVersionsRes
 	}
 
 	var num int64
-	num = lc.showObjectVersions(lor, bucket, limitedNum, directory)
+	num = lc.showObjectVersions(lor, cloudURL, limitedNum, directory)
 	if directory {
-		num1 := lc.showDirectoriesVersion(lor, bucket, limitedNum)
+		num1 := lc.showDirectoriesVersion(lor, cloudURL, limitedNum)
 		num += num1
 	}
 	return num
 }
 
-func (lc *ListCommand) showObjects(lor oss.ListObjectsResult, bucket string, shortFormat bool, limitedNum *int64) int64 {
+func (lc *ListCommand) showObjects(lor oss.ListObjectsResultV2, cloudURL CloudURL, shortFormat bool, limitedNum *int64) int64 {
 	var num int64
 	num = 0
 	for _, object := range lor.Objects {
@@ -718,14 +974,24 @@ func (lc *ListCommand) showObjects(lor oss.ListObjectsResult, bucket string, sho
 			break
 		}
 
-		if !doesSingleObjectMatchPatterns(object.Key, lc.filters) {
+		if !doesSingleObjectMatchPatterns(object.Key, lc.filters) || !lc.command.timeWindow.match(object.LastModified) {
 			continue
 		}
 
-		if !shortFormat {
-			fmt.Printf("%-30s%12d%s%12s%s%-36s%s%s\n", utcToLocalTime(object.LastModified), object.Size, "  ", object.StorageClass, "   ", strings.Trim(object.ETag, "\""), "  ", CloudURLToString(bucket, object.Key))
+		var line string
+		if lc.output.structured() {
+			line = lc.output.line(newLsObjectEntry(cloudURL, object, lc.fetchOwner))
+		} else if !shortFormat && lc.fetchOwner {
+			line = fmt.Sprintf("%-30s%12d%s%12s%s%-36s%s%-20s%s%s", utcToLocalTime(object.LastModified), object.Size, "  ", object.StorageClass, "   ", strings.Trim(object.ETag, "\""), "  ", object.Owner.ID, "  ", cloudURL.objectURL(object.Key))
+		} else if !shortFormat {
+			line = fmt.Sprintf("%-30s%12d%s%12s%s%-36s%s%s", utcToLocalTime(object.LastModified), object.Size, "  ", object.StorageClass, "   ", strings.Trim(object.ETag, "\""), "  ", cloudURL.objectURL(object.Key))
 		} else {
-			fmt.Printf("%s\n", CloudURLToString(bucket, object.Key))
+			line = cloudURL.objectURL(object.Key)
+		}
+		if lc.sorter != nil {
+			lc.sorter.add(object, line)
+		} else {
+			lc.printLine(line)
 		}
 		*limitedNum--
 		num++
@@ -733,7 +999,7 @@ func (lc *ListCommand) showObjects(lor oss.ListObjectsResult, bucket string, sho
 	return num
 }
 
-func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, bucket string, limitedNum *int64, directory bool) int64 {
+func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, cloudURL CloudURL, limitedNum *int64, directory bool) int64 {
 	var num int64
 	num = 0
 	for _, object := range lor.ObjectDeleteMarkers {
@@ -741,12 +1007,14 @@ func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, buck
 			break
 		}
 
-		if !doesSingleObjectMatchPatterns(object.Key, lc.filters) {
+		if !doesSingleObjectMatchPatterns(object.Key, lc.filters) || !lc.command.timeWindow.match(object.LastModified) {
 			continue
 		}
 
 		//COMMON-PREFIX LastModifiedTime  Size(B)  StorageClass  ETAG VERSIONID  IS-LATEST  DELETE-MARKER  ObjectName
-		if directory {
+		if lc.output.structured() {
+			fmt.Println(lc.output.line(newLsDeleteMarkerEntry(cloudURL, object)))
+		} else if directory {
 			fmt.Printf("%-13t%s%-30s%12d%s%12s%s%-36s%s%-66s%s%-10t%s%-13t%s%s\n",
 				false, "  ",
 				utcToLocalTime(object.LastModified),
@@ -756,7 +1024,7 @@ func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, buck
 				object.VersionId, "  ",
 				object.IsLatest, "  ",
 				true, "  ",
-				CloudURLToString(bucket, object.Key))
+				cloudURL.objectURL(object.Key))
 		} else {
 			fmt.Printf("%-30s%12d%s%12s%s%-36s%s%-66s%s%-10t%s%-13t%s%s\n",
 				utcToLocalTime(object.LastModified),
@@ -766,7 +1034,7 @@ func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, buck
 				object.VersionId, "  ",
 				object.IsLatest, "  ",
 				true, "  ",
-				CloudURLToString(bucket, object.Key))
+				cloudURL.objectURL(object.Key))
 		}
 
 		*limitedNum--
@@ -778,12 +1046,14 @@ func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, buck
 			break
 		}
 
-		if !doesSingleObjectMatchPatterns(object.Key, lc.filters) {
+		if !doesSingleObjectMatchPatterns(object.Key, lc.filters) || !lc.command.timeWindow.match(object.LastModified) {
 			continue
 		}
 
 		//COMMON-PREFIX LastModifiedTime  Size(B)  StorageClass  ETAG VERSIONID  IS-LATEST  DELETE-MARKER  ObjectName
-		if directory {
+		if lc.output.structured() {
+			fmt.Println(lc.output.line(newLsVersionEntry(cloudURL, object)))
+		} else if directory {
 			fmt.Printf("%-13t%s%-30s%12d%s%12s%s%-36s%s%-66s%s%-10t%s%-13t%s%s\n",
 				false, "  ",
 				utcToLocalTime(object.LastModified),
@@ -793,7 +1063,7 @@ func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, buck
 				object.VersionId, "  ",
 				object.IsLatest, "  ",
 				false, "  ",
-				CloudURLToString(bucket, object.Key))
+				cloudURL.objectURL(object.Key))
 		} else {
 			fmt.Printf("%-30s%12d%s%12s%s%-36s%s%-66s%s%-10t%s%-13t%s%s\n",
 				utcToLocalTime(object.LastModified),
@@ -803,7 +1073,7 @@ func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, buck
 				object.VersionId, "  ",
 				object.IsLatest, "  ",
 				false, "  ",
-				CloudURLToString(bucket, object.Key))
+				cloudURL.objectURL(object.Key))
 		}
 
 		*limitedNum--
@@ -813,7 +1083,7 @@ func (lc *ListCommand) showObjectVersions(lor oss.ListObjectVersionsResult, buck
 	return num
 }
 
-func (lc *ListCommand) showDirectories(lor oss.ListObjectsResult, bucket string, limitedNum *int64) int64 {
+func (lc *ListCommand) showDirectories(lor oss.ListObjectsResultV2, cloudURL CloudURL, limitedNum *int64) int64 {
 	var num int64
 	num = 0
 	for _, prefix := range lor.CommonPrefixes {
@@ -825,14 +1095,18 @@ func (lc *ListCommand) showDirectories(lor oss.ListObjectsResult, bucket string,
 			continue
 		}
 
-		fmt.Printf("%s\n", CloudURLToString(bucket, prefix))
+		if lc.output.structured() {
+			lc.printLine(lc.output.line(newLsDirectoryEntry(cloudURL, prefix)))
+		} else {
+			lc.printLine(cloudURL.objectURL(prefix))
+		}
 		*limitedNum--
 		num++
 	}
 	return num
 }
 
-func (lc *ListCommand) showDirectoriesVersion(lor oss.ListObjectVersionsResult, bucket string, limitedNum *int64) int64 {
+func (lc *ListCommand) showDirectoriesVersion(lor oss.ListObjectVersionsResult, cloudURL CloudURL, limitedNum *int64) int64 {
 	var num int64
 	num = 0
 	for _, prefix := range lor.CommonPrefixes {
@@ -844,6 +1118,13 @@ func (lc *ListCommand) showDirectoriesVersion(lor oss.ListObjectVersionsResult,
 			continue
 		}
 
+		if lc.output.structured() {
+			fmt.Println(lc.output.line(newLsDirectoryEntry(cloudURL, prefix)))
+			*limitedNum--
+			num++
+			continue
+		}
+
 		fmt.Printf("%-13t%s%-30s%12s%s%12s%s%-36s%s%-66s%s%-10s%s%-13s%s%s\n",
 			true, "  ",
 			"", "", "  ",
@@ -852,7 +1133,7 @@ func (lc *ListCommand) showDirectoriesVersion(lor oss.ListObjectVersionsResult,
 			"", "  ",
 			"", "  ",
 			"", "  ",
-			CloudURLToString(bucket, prefix))
+			cloudURL.objectURL(prefix))
 
 		*limitedNum--
 		num++
@@ -896,21 +1177,21 @@ func (lc *ListCommand) listMultipartUploads(bucket *oss.Bucket, cloudURL CloudUR
 		pre = oss.Prefix(lmr.Prefix)
 		keyMarker = oss.Marker(lmr.NextKeyMarker)
 		uploadIdMarker = oss.UploadIDMarker(lmr.NextUploadIDMarker)
-		multipartNum += lc.displayMultipartUploadsResult(lmr, cloudURL.bucket, shortFormat, directory, i, limitedNum)
+		multipartNum += lc.displayMultipartUploadsResult(lmr, cloudURL, shortFormat, directory, i, limitedNum)
 		if !lmr.IsTruncated {
 			break
 		}
 	}
-	fmt.Printf("UploadID Number is: %d\n", multipartNum)
+	fmt.Fprintf(lc.summary(), "UploadID Number is: %d\n", multipartNum)
 	return multipartNum, nil
 }
 
-func (lc *ListCommand) displayMultipartUploadsResult(lmr oss.ListMultipartUploadResult, bucket string, shortFormat bool, directory bool, i int64, limitedNum *int64) int64 {
+func (lc *ListCommand) displayMultipartUploadsResult(lmr oss.ListMultipartUploadResult, cloudURL CloudURL, shortFormat bool, directory bool, i int64, limitedNum *int64) int64 {
 	if directory {
 		shortFormat = true
 	}
 
-	if i == 0 && len(lmr.Uploads) > 0 {
+	if i == 0 && !lc.output.structured() && len(lmr.Uploads) > 0 {
 		if shortFormat {
 			fmt.Printf("%-32s%s%s\n", "UploadID", FormatTAB, "ObjectName")
 		} else {
@@ -918,11 +1199,11 @@ func (lc *ListCommand) displayMultipartUploadsResult(lmr oss.ListMultipartUpload
 		}
 	}
 
-	num := lc.showMultipartUploads(lmr, bucket, shortFormat, limitedNum)
+	num := lc.showMultipartUploads(lmr, cloudURL, shortFormat, limitedNum)
 	return num
 }
 
-func (lc *ListCommand) showMultipartUploads(lmr oss.ListMultipartUploadResult, bucket string, shortFormat bool, limitedNum *int64) int64 {
+func (lc *ListCommand) showMultipartUploads(lmr oss.ListMultipartUploadResult, cloudURL CloudURL, shortFormat bool, limitedNum *int64) int64 {
 	var num int64
 	num = 0
 	for _, upload := range lmr.Uploads {
@@ -934,10 +1215,18 @@ func (lc *ListCommand) showMultipartUploads(lmr oss.ListMultipartUploadResult, b
 			continue
 		}
 
-		if shortFormat {
-			fmt.Printf("%-32s%s%s\n", upload.UploadID, FormatTAB, CloudURLToString(bucket, upload.Key))
+		if lc.output.structured() {
+			fmt.Println(lc.output.line(lsMultipartEntry{
+				Type:      lsEntryMultipart,
+				URL:       cloudURL.objectURL(upload.Key),
+				Key:       upload.Key,
+				UploadID:  upload.UploadID,
+				Initiated: upload.Initiated,
+			}))
+		} else if shortFormat {
+			fmt.Printf("%-32s%s%s\n", upload.UploadID, FormatTAB, cloudURL.objectURL(upload.Key))
 		} else {
-			fmt.Printf("%-30s%s%-32s%s%s\n", utcToLocalTime(upload.Initiated), FormatTAB, upload.UploadID, FormatTAB, CloudURLToString(bucket, upload.Key))
+			fmt.Printf("%-30s%s%-32s%s%s\n", utcToLocalTime(upload.Initiated), FormatTAB, upload.UploadID, FormatTAB, cloudURL.objectURL(upload.Key))
 		}
 		*limitedNum--
 		num++
@@ -0,0 +1,221 @@
+package lib
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// the types of the json lines output by ls --output json
+const (
+	lsEntryBucket       = "bucket"
+	lsEntryObject       = "object"
+	lsEntryDirectory    = "directory"
+	lsEntryDeleteMarker = "deleteMarker"
+	lsEntryMultipart    = "multipart"
+)
+
+// lsBucketEntry is a listed bucket of ls --output json
+type lsBucketEntry struct {
+	Type         string    `json:"type"`
+	URL          string    `json:"url"`
+	Name         string    `json:"name"`
+	Region       string    `json:"region"`
+	StorageClass string    `json:"storageClass"`
+	CreationTime time.Time `json:"creationTime"`
+}
+
+// lsObjectEntry is a listed object, object version or delete marker of ls --output json,
+// the version fields are only set with --all-versions
+type lsObjectEntry struct {
+	Type         string    `json:"type"`
+	URL          string    `json:"url"`
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag,omitempty"`
+	StorageClass string    `json:"storageClass,omitempty"`
+	Owner        string    `json:"owner,omitempty"`
+	VersionId    string    `json:"versionId,omitempty"`
+	IsLatest     *bool     `json:"isLatest,omitempty"`
+}
+
+// lsDirectoryEntry is a listed common prefix of ls -d --output json
+type lsDirectoryEntry struct {
+	Type   string `json:"type"`
+	URL    string `json:"url"`
+	Prefix string `json:"prefix"`
+}
+
+// lsMultipartEntry is a listed multipart upload of ls -m --output json
+type lsMultipartEntry struct {
+	Type      string    `json:"type"`
+	URL       string    `json:"url"`
+	Key       string    `json:"key"`
+	UploadID  string    `json:"uploadId"`
+	Initiated time.Time `json:"initiated"`
+}
+
+// statBucketEntry is the bucket of stat --output json, it has the fields of the bucket of ls and the
+// info of the bucket, the empty fields are omitted
+type statBucketEntry struct {
+	lsBucketEntry
+	ExtranetEndpoint       string `json:"extranetEndpoint,omitempty"`
+	IntranetEndpoint       string `json:"intranetEndpoint,omitempty"`
+	ACL                    string `json:"acl,omitempty"`
+	Owner                  string `json:"owner,omitempty"`
+	RedundancyType         string `json:"redundancyType,omitempty"`
+	SSEAlgorithm           string `json:"sseAlgorithm,omitempty"`
+	KMSMasterKeyID         string `json:"kmsMasterKeyId,omitempty"`
+	KMSDataEncryption      string `json:"kmsDataEncryption,omitempty"`
+	TransferAcceleration   string `json:"transferAcceleration,omitempty"`
+	CrossRegionReplication string `json:"crossRegionReplication,omitempty"`
+	AccessMonitor          string `json:"accessMonitor,omitempty"`
+}
+
+// statObjectEntry is the object of stat --output json, it has the fields of the object of ls, the
+// user meta without the x-oss-meta- prefix, and the other response headers by their names
+type statObjectEntry struct {
+	lsObjectEntry
+	ACL           string            `json:"acl,omitempty"`
+	ContentType   string            `json:"contentType,omitempty"`
+	RetentionMode string            `json:"retentionMode,omitempty"`
+	RetainUntil   string            `json:"retainUntil,omitempty"`
+	LegalHold     string            `json:"legalHold,omitempty"`
+	Meta          map[string]string `json:"meta,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+}
+
+// jsonLine marshals the entry as a single line, so that the output is a stream of json lines
+// which could be processed one by one, e.g. by jq. The entries only have strings, numbers and
+// times, so marshalling never fails
+func jsonLine(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func newLsObjectEntry(cloudURL CloudURL, object oss.ObjectProperties, fetchOwner bool) lsObjectEntry {
+	entry := lsObjectEntry{
+		Type:         lsEntryObject,
+		URL:          cloudURL.objectURL(object.Key),
+		Key:          object.Key,
+		Size:         object.Size,
+		LastModified: object.LastModified,
+		ETag:         strings.Trim(object.ETag, "\""),
+		StorageClass: object.StorageClass,
+	}
+	if fetchOwner {
+		entry.Owner = object.Owner.ID
+	}
+	return entry
+}
+
+func newLsVersionEntry(cloudURL CloudURL, object oss.ObjectVersionProperties) lsObjectEntry {
+	isLatest := object.IsLatest
+	return lsObjectEntry{
+		Type:         lsEntryObject,
+		URL:          cloudURL.objectURL(object.Key),
+		Key:          object.Key,
+		Size:         object.Size,
+		LastModified: object.LastModified,
+		ETag:         strings.Trim(object.ETag, "\""),
+		StorageClass: object.StorageClass,
+		VersionId:    object.VersionId,
+		IsLatest:     &isLatest,
+	}
+}
+
+func newLsDeleteMarkerEntry(cloudURL CloudURL, marker oss.ObjectDeleteMarkerProperties) lsObjectEntry {
+	isLatest := marker.IsLatest
+	return lsObjectEntry{
+		Type:         lsEntryDeleteMarker,
+		URL:          cloudURL.objectURL(marker.Key),
+		Key:          marker.Key,
+		LastModified: marker.LastModified,
+		VersionId:    marker.VersionId,
+		IsLatest:     &isLatest,
+	}
+}
+
+func newLsDirectoryEntry(cloudURL CloudURL, prefix string) lsDirectoryEntry {
+	return lsDirectoryEntry{Type: lsEntryDirectory, URL: cloudURL.objectURL(prefix), Prefix: prefix}
+}
+
+func newStatBucketEntry(info oss.BucketInfo) statBucketEntry {
+	return statBucketEntry{
+		lsBucketEntry: lsBucketEntry{
+			Type:         lsEntryBucket,
+			URL:          CloudURLToString(info.Name, ""),
+			Name:         info.Name,
+			Region:       info.Location,
+			StorageClass: info.StorageClass,
+			CreationTime: info.CreationDate,
+		},
+		ExtranetEndpoint:       info.ExtranetEndpoint,
+		IntranetEndpoint:       info.IntranetEndpoint,
+		ACL:                    info.ACL,
+		Owner:                  info.Owner.ID,
+		RedundancyType:         info.RedundancyType,
+		SSEAlgorithm:           info.SseRule.SSEAlgorithm,
+		KMSMasterKeyID:         info.SseRule.KMSMasterKeyID,
+		KMSDataEncryption:      info.SseRule.KMSDataEncryption,
+		TransferAcceleration:   info.TransferAcceleration,
+		CrossRegionReplication: info.CrossRegionReplication,
+		AccessMonitor:          info.AccessMonitor,
+	}
+}
+
+// newStatObjectEntry converts the response headers of the object, the headers of the fields are
+// not repeated in Headers, and the headers only describing the response are dropped
+func newStatObjectEntry(cloudURL CloudURL, props http.Header, acl oss.GetObjectACLResult) statObjectEntry {
+	entry := statObjectEntry{
+		lsObjectEntry: lsObjectEntry{
+			Type:         lsEntryObject,
+			URL:          cloudURL.objectURL(cloudURL.object),
+			Key:          cloudURL.object,
+			ETag:         strings.Trim(props.Get(oss.HTTPHeaderEtag), "\""),
+			StorageClass: props.Get(oss.HTTPHeaderOssStorageClass),
+			Owner:        acl.Owner.ID,
+			VersionId:    oss.GetVersionId(props),
+		},
+		ACL:           acl.ACL,
+		ContentType:   props.Get(oss.HTTPHeaderContentType),
+		RetentionMode: props.Get(HTTPHeaderObjectLockMode),
+		RetainUntil:   props.Get(HTTPHeaderObjectLockRetainUntil),
+		LegalHold:     props.Get(HTTPHeaderObjectLockLegalHold),
+	}
+	entry.Size, _ = strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+	if lm, err := time.Parse(http.TimeFormat, props.Get(oss.HTTPHeaderLastModified)); err == nil {
+		entry.LastModified = lm.UTC()
+	}
+
+	skipped := []string{oss.HTTPHeaderContentLength, oss.HTTPHeaderLastModified, oss.HTTPHeaderEtag,
+		oss.HTTPHeaderOssStorageClass, "X-Oss-Version-Id", oss.HTTPHeaderContentType,
+		HTTPHeaderObjectLockMode, HTTPHeaderObjectLockRetainUntil, HTTPHeaderObjectLockLegalHold,
+		oss.HTTPHeaderDate, oss.HTTPHeaderOssRequestID, oss.HTTPHeaderServer, "X-Oss-Server-Time", "Connection"}
+	for i := range skipped {
+		skipped[i] = http.CanonicalHeaderKey(skipped[i])
+	}
+	for name := range props {
+		name = http.CanonicalHeaderKey(name)
+		if FindPos(name, skipped) != -1 {
+			continue
+		}
+		if strings.HasPrefix(name, oss.HTTPHeaderOssMetaPrefix) {
+			if entry.Meta == nil {
+				entry.Meta = map[string]string{}
+			}
+			entry.Meta[strings.ToLower(strings.TrimPrefix(name, oss.HTTPHeaderOssMetaPrefix))] = props.Get(name)
+			continue
+		}
+		if entry.Headers == nil {
+			entry.Headers = map[string]string{}
+		}
+		entry.Headers[name] = props.Get(name)
+	}
+	return entry
+}
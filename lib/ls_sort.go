@@ -0,0 +1,207 @@
+package lib
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// the sort fields of ls --sort
+const (
+	lsSortSize  = "size"
+	lsSortMtime = "mtime"
+	lsSortName  = "name"
+)
+
+// lsSortMemoryNum is the max number of the entries kept in memory, the sorted runs are spilled
+// to the temp files beyond it and merged when outputting
+const lsSortMemoryNum = 100000
+
+// sortEntry is a listed object to be sorted, key is the sort key which is compared as string,
+// line is the output of the object
+type sortEntry struct {
+	Key  string `json:"k"`
+	Line string `json:"l"`
+}
+
+// listSorter sorts the listed objects of ls on the client side. The entries are kept in memory,
+// and spilled to the temp files as the sorted runs if there are too many of them
+type listSorter struct {
+	field   string
+	reverse bool
+	head    int64 // 0 means no limit
+	entries []sortEntry
+	runs    []string
+	err     error // the first error of spilling, returned by output
+}
+
+func newListSorter(field string, reverse bool, head int64) (*listSorter, error) {
+	if field != lsSortSize && field != lsSortMtime && field != lsSortName {
+		return nil, fmt.Errorf("invalid --sort: %s, the value should be %s, %s or %s", field, lsSortSize, lsSortMtime, lsSortName)
+	}
+	return &listSorter{field: field, reverse: reverse, head: head}, nil
+}
+
+// sortKey makes the key of the object, the numbers are padded so that they are compared as string,
+// the object name breaks the tie
+func (ls *listSorter) sortKey(object oss.ObjectProperties) string {
+	switch ls.field {
+	case lsSortSize:
+		return fmt.Sprintf("%020d\x00%s", object.Size, object.Key)
+	case lsSortMtime:
+		return fmt.Sprintf("%020d\x00%s", object.LastModified.UnixNano(), object.Key)
+	default:
+		return object.Key
+	}
+}
+
+func (ls *listSorter) less(a, b string) bool {
+	if ls.reverse {
+		return a > b
+	}
+	return a < b
+}
+
+func (ls *listSorter) add(object oss.ObjectProperties, line string) {
+	if ls.err != nil {
+		return
+	}
+	ls.entries = append(ls.entries, sortEntry{ls.sortKey(object), line})
+	if ls.head > 0 && ls.head < lsSortMemoryNum/2 {
+		// only the first head entries are output, the others can be dropped at any time
+		if int64(len(ls.entries)) >= 2*ls.head {
+			ls.sortEntries()
+			ls.entries = ls.entries[:ls.head]
+		}
+		return
+	}
+	if len(ls.entries) >= lsSortMemoryNum {
+		ls.err = ls.spill()
+	}
+}
+
+func (ls *listSorter) sortEntries() {
+	sort.Slice(ls.entries, func(i, j int) bool {
+		return ls.less(ls.entries[i].Key, ls.entries[j].Key)
+	})
+}
+
+// spill writes the sorted entries in memory to a temp file
+func (ls *listSorter) spill() error {
+	ls.sortEntries()
+	f, err := ioutil.TempFile("", "ossutil-sort-")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	ls.runs = append(ls.runs, f.Name())
+
+	w := bufio.NewWriter(f)
+	encoder := json.NewEncoder(w)
+	for i, entry := range ls.entries {
+		if ls.head > 0 && int64(i) >= ls.head {
+			break
+		}
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	ls.entries = ls.entries[:0]
+	LogInfo("spill the sorted run to %s\n", f.Name())
+	return w.Flush()
+}
+
+// sortRun is a sorted run being merged, the run in memory has no decoder
+type sortRun struct {
+	current sortEntry
+	decoder *json.Decoder
+	entries []sortEntry
+}
+
+func (r *sortRun) next() (bool, error) {
+	if r.decoder == nil {
+		if len(r.entries) == 0 {
+			return false, nil
+		}
+		r.current, r.entries = r.entries[0], r.entries[1:]
+		return true, nil
+	}
+	if err := r.decoder.Decode(&r.current); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type sortRunHeap struct {
+	runs []*sortRun
+	less func(a, b string) bool
+}
+
+func (h *sortRunHeap) Len() int { return len(h.runs) }
+func (h *sortRunHeap) Less(i, j int) bool {
+	return h.less(h.runs[i].current.Key, h.runs[j].current.Key)
+}
+func (h *sortRunHeap) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *sortRunHeap) Push(x interface{}) { h.runs = append(h.runs, x.(*sortRun)) }
+func (h *sortRunHeap) Pop() (v interface{}) {
+	v, h.runs = h.runs[len(h.runs)-1], h.runs[:len(h.runs)-1]
+	return v
+}
+
+// output merges the runs and calls f with the lines in order, it returns the number of the lines
+func (ls *listSorter) output(f func(line string)) (int64, error) {
+	defer ls.close()
+	if ls.err != nil {
+		return 0, ls.err
+	}
+	ls.sortEntries()
+	h := &sortRunHeap{less: ls.less}
+	runs := []*sortRun{{entries: ls.entries}}
+	for _, name := range ls.runs {
+		file, err := os.Open(name)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		runs = append(runs, &sortRun{decoder: json.NewDecoder(bufio.NewReader(file))})
+	}
+	for _, run := range runs {
+		if ok, err := run.next(); err != nil {
+			return 0, err
+		} else if ok {
+			heap.Push(h, run)
+		}
+	}
+
+	var num int64
+	for h.Len() > 0 && (ls.head <= 0 || num < ls.head) {
+		run := h.runs[0]
+		f(run.current.Line)
+		num++
+		if ok, err := run.next(); err != nil {
+			return num, err
+		} else if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return num, nil
+}
+
+func (ls *listSorter) close() {
+	for _, name := range ls.runs {
+		os.Remove(name)
+	}
+	ls.runs = nil
+	ls.entries = nil
+}
@@ -0,0 +1,113 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestListSort(c *C) {
+	_, err := newListSorter("etag", false, 0)
+	c.Assert(err, NotNil)
+
+	// merge the spilled runs with the entries in memory
+	sorter, err := newListSorter(lsSortSize, true, 0)
+	c.Assert(err, IsNil)
+	var want []string
+	for i := 0; i < 30; i++ {
+		sorter.add(oss.ObjectProperties{Key: fmt.Sprintf("k%02d", i), Size: int64(i % 10)}, fmt.Sprintf("k%02d", i))
+		if i%7 == 6 {
+			c.Assert(sorter.spill(), IsNil)
+		}
+	}
+	for size := 9; size >= 0; size-- {
+		for i := 20 + size; i >= 0; i -= 10 {
+			want = append(want, fmt.Sprintf("k%02d", i))
+		}
+	}
+	runs := sorter.runs
+	c.Assert(len(runs), Equals, 4)
+	var lines []string
+	num, err := sorter.output(func(line string) { lines = append(lines, line) })
+	c.Assert(err, IsNil)
+	c.Assert(num, Equals, int64(30))
+	c.Assert(lines, DeepEquals, want)
+	for _, name := range runs {
+		_, err = os.Stat(name)
+		c.Assert(os.IsNotExist(err), Equals, true)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>a</Key><Size>30</Size><LastModified>2022-01-03T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>b</Key><Size>10</Size><LastModified>2022-01-01T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>c</Key><Size>20</Size><LastModified>2022-01-02T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	shortFormat := true
+	limitedNum := "10"
+	field := lsSortMtime
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionShortFormat:     &shortFormat,
+		OptionLimitedNum:      &limitedNum,
+		OptionSort:            &field,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	_, err = cm.RunCommand("ls", []string{"oss://bucket"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(resultPath, c), Equals, "oss://bucket/b\noss://bucket/c\noss://bucket/a\nObject Number is: 3\n")
+
+	reverse := true
+	head := "2"
+	field = lsSortSize
+	options[OptionReverse] = &reverse
+	options[OptionHead] = &head
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("ls", []string{"oss://bucket"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile(resultPath, c), Equals, "oss://bucket/a\noss://bucket/c\nObject Number is: 2\n")
+
+	// the long format has the header
+	shortFormat = false
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("ls", []string{"oss://bucket"}, options)
+	c.Assert(err, IsNil)
+	output := s.readFile(resultPath, c)
+	c.Assert(strings.HasPrefix(output, "LastModifiedTime"), Equals, true)
+	c.Assert(strings.Index(output, "oss://bucket/a") < strings.Index(output, "oss://bucket/c"), Equals, true)
+	c.Assert(strings.Contains(output, utcToLocalTime(time.Date(2022, 1, 3, 0, 0, 0, 0, time.UTC)).String()), Equals, true)
+
+	directory := true
+	options[OptionDirectory] = &directory
+	_, err = cm.RunCommand("ls", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	delete(options, OptionDirectory)
+	delete(options, OptionSort)
+	_, err = cm.RunCommand("ls", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+}
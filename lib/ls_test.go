@@ -1,8 +1,11 @@
 package lib
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"sort"
@@ -1484,3 +1487,239 @@ func (s *OssutilCommandSuite) TestListDirectoryfilterIncludeVersions(c *C) {
 	os.RemoveAll(dir2)
 	s.removeBucket(bucketName, true, c)
 }
+
+func (s *OssutilCommandSuite) TestListObjectsStartAfter(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	for _, key := range []string{"a", "b%2F", "c", "d"} {
+		s.putObject(bucketName, key, uploadFileName, c)
+	}
+
+	args := []string{CloudURLToString(bucketName, "")}
+	out := os.Stdout
+	testResultFile, _ = os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	os.Stdout = testResultFile
+	_, err := s.rawList(args, "ls -s", OptionPair{"startAfter", "b%2F"})
+	os.Stdout = out
+	c.Assert(err, IsNil)
+	objects := s.getObjectResults(c)
+	os.Remove(resultPath)
+	c.Assert(objects, DeepEquals, []string{"c", "d"})
+
+	// the owner column is shown with --fetch-owner
+	testResultFile, _ = os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	os.Stdout = testResultFile
+	str := ""
+	fetchOwner := true
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"fetchOwner":      &fetchOwner,
+	}
+	_, err = cm.RunCommand("ls", args, options)
+	os.Stdout = out
+	c.Assert(err, IsNil)
+	result := s.getFileResult(resultPath, c)
+	os.Remove(resultPath)
+	c.Assert(strings.Contains(strings.Join(result, "\n"), "Owner"), Equals, true)
+
+	// --start-after and --marker can't be both specified
+	_, err = s.rawList(args, "ls -s", OptionPair{"startAfter", "b"}, OptionPair{"marker", "a"})
+	c.Assert(err, NotNil)
+
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestGetStartAfter(c *C) {
+	startAfter := "a%2Fb"
+	marker := ""
+	encodingType := URLEncodingType
+	cmd := Command{options: OptionMapType{
+		"startAfter":   &startAfter,
+		"marker":       &marker,
+		"encodingType": &encodingType,
+	}}
+	key, err := cmd.getStartAfter()
+	c.Assert(err, IsNil)
+	c.Assert(key, Equals, "a%2Fb")
+
+	// --marker is url decoded with --encoding-type url
+	startAfter = ""
+	marker = "a%2Fb"
+	key, err = cmd.getStartAfter()
+	c.Assert(err, IsNil)
+	c.Assert(key, Equals, "a/b")
+
+	startAfter = "a"
+	_, err = cmd.getStartAfter()
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestListCloudBoxBuckets(c *C) {
+	c.Assert(isCloudBoxEndpoint("https://cb-test.cn-hangzhou.oss-cloudbox.aliyuncs.com", "cb-test"), Equals, true)
+	c.Assert(isCloudBoxEndpoint("CB-Test.cn-hangzhou.oss-cloudbox.aliyuncs.com", "cb-test"), Equals, true)
+	c.Assert(isCloudBoxEndpoint("cn-hangzhou.oss-cloudbox-control.aliyuncs.com", "cb-test"), Equals, false)
+
+	var authorization string
+	listCount := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		if _, ok := r.URL.Query()["cloudboxes"]; ok {
+			listCount++
+			fmt.Fprintf(w, `<ListCloudBoxResult><IsTruncated>false</IsTruncated><CloudBoxes>
+<CloudBox><ID>cb-other</ID><DataEndpoint>other.example.com</DataEndpoint></CloudBox>
+<CloudBox><ID>cb-test</ID><Region>cn-hangzhou</Region><DataEndpoint>%s</DataEndpoint></CloudBox>
+</CloudBoxes></ListCloudBoxResult>`, strings.TrimPrefix(server.URL, "http://"))
+			return
+		}
+		fmt.Fprint(w, `<ListAllMyBucketsResult><Buckets><Bucket><Name>cloud-box-bucket</Name></Bucket></Buckets></ListAllMyBucketsResult>`)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	accessKeyID := "ak"
+	accessKeySecret := "sk"
+	cloudBoxID := "cb-test"
+	lc := &ListCommand{}
+	lc.command.options = OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &accessKeyID,
+		OptionAccessKeySecret: &accessKeySecret,
+		OptionCloudBoxID:      &cloudBoxID,
+	}
+	client, err := lc.command.ossClient("")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, strings.TrimPrefix(server.URL, "http://"))
+	c.Assert(client.Config.AuthVersion, Equals, oss.AuthV4)
+	c.Assert(client.Config.GetSignProduct(), Equals, "oss-cloudbox")
+	c.Assert(client.Config.GetSignRegion(), Equals, cloudBoxID)
+	c.Assert(strings.Contains(authorization, "/cb-test/oss-cloudbox/aliyun_v4_request"), Equals, true)
+
+	lbr, err := client.ListBuckets()
+	c.Assert(err, IsNil)
+	c.Assert(len(lbr.Buckets), Equals, 1)
+	c.Assert(lbr.Buckets[0].Name, Equals, "cloud-box-bucket")
+
+	// the data endpoint is cached for the clients of the buckets
+	client, err = lc.command.ossClient("cloud-box-bucket")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, strings.TrimPrefix(server.URL, "http://"))
+	c.Assert(listCount, Equals, 1)
+
+	// lcb lists the cloud boxes on the control endpoint
+	client, err = lc.command.controlClient()
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, server.URL)
+
+	// the cloud box is not found
+	cloudBoxID = "cb-not-exist"
+	_, err = lc.command.ossClient("")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestListObjectsJSON(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<ListAllMyBucketsResult><Buckets><Bucket><Name>bucket</Name><Location>oss-cn-hangzhou</Location>
+<CreationDate>2024-01-01T00:00:00.000Z</CreationDate><StorageClass>Standard</StorageClass></Bucket></Buckets></ListAllMyBucketsResult>`)
+			return
+		}
+		prefixes := ""
+		if r.URL.Query().Get("delimiter") == "/" {
+			prefixes = `<CommonPrefixes><Prefix>logs/dir/</Prefix></CommonPrefixes>`
+		}
+		fmt.Fprintf(w, `<ListBucketResult><Name>bucket</Name><Prefix>logs/</Prefix><KeyCount>2</KeyCount><IsTruncated>false</IsTruncated>
+<Contents><Key>logs/a b</Key><LastModified>2024-01-02T03:04:05.000Z</LastModified><ETag>"etag-a"</ETag><Size>100</Size><StorageClass>Standard</StorageClass></Contents>
+<Contents><Key>logs/c</Key><LastModified>2024-01-03T03:04:05.000Z</LastModified><ETag>"etag-c"</ETag><Size>2048</Size><StorageClass>IA</StorageClass></Contents>
+%s</ListBucketResult>`, prefixes)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	output := "json"
+	directory := false
+	print0 := false
+	limitedNum := "-1"
+	forcePathStyle := true
+	run := func(url string) ([]map[string]interface{}, error) {
+		lc := &ListCommand{}
+		lc.command.args = []string{url}
+		lc.command.options = OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &str,
+			OptionAccessKeySecret: &str,
+			OptionOutput:          &output,
+			OptionDirectory:       &directory,
+			OptionPrint0:          &print0,
+			OptionLimitedNum:      &limitedNum,
+			OptionForcePathStyle:  &forcePathStyle,
+		}
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = lc.RunCommand()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+
+		// every line is a json object, the statistics are not in stdout
+		var entries []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(s.readFile(resultPath, c)), "\n") {
+			if line == "" {
+				continue
+			}
+			entry := map[string]interface{}{}
+			c.Assert(json.Unmarshal([]byte(line), &entry), IsNil)
+			entries = append(entries, entry)
+		}
+		return entries, err
+	}
+
+	entries, err := run("oss://bucket/logs/")
+	c.Assert(err, IsNil)
+	c.Assert(len(entries), Equals, 2)
+	c.Assert(entries[0]["type"], Equals, "object")
+	c.Assert(entries[0]["url"], Equals, "oss://bucket/logs/a b")
+	c.Assert(entries[0]["key"], Equals, "logs/a b")
+	c.Assert(entries[0]["size"], Equals, float64(100))
+	c.Assert(entries[0]["etag"], Equals, "etag-a")
+	c.Assert(entries[0]["lastModified"], Equals, "2024-01-02T03:04:05Z")
+	c.Assert(entries[1]["storageClass"], Equals, "IA")
+	_, ok := entries[0]["owner"]
+	c.Assert(ok, Equals, false)
+
+	// the directories are output as the directory entries
+	directory = true
+	entries, err = run("oss://bucket/logs/")
+	c.Assert(err, IsNil)
+	c.Assert(len(entries), Equals, 3)
+	c.Assert(entries[2]["type"], Equals, "directory")
+	c.Assert(entries[2]["prefix"], Equals, "logs/dir/")
+	c.Assert(entries[2]["url"], Equals, "oss://bucket/logs/dir/")
+
+	// the buckets
+	directory = false
+	entries, err = run("oss://")
+	c.Assert(err, IsNil)
+	c.Assert(len(entries), Equals, 1)
+	c.Assert(entries[0]["type"], Equals, "bucket")
+	c.Assert(entries[0]["name"], Equals, "bucket")
+	c.Assert(entries[0]["region"], Equals, "oss-cn-hangzhou")
+	c.Assert(entries[0]["creationTime"], Equals, "2024-01-01T00:00:00Z")
+
+	// --print0 can not be used with --output json
+	print0 = true
+	_, err = run("oss://bucket/logs/")
+	c.Assert(err, NotNil)
+
+	print0 = false
+	output = "xml"
+	_, err = run("oss://bucket/logs/")
+	c.Assert(err, NotNil)
+	os.Remove(resultPath)
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -155,13 +156,11 @@ type bucketOptionType struct {
 
 var makeBucketCommand = MakeBucketCommand{
 	command: Command{
-		name:        "mb",
-		nameAlias:   []string{"cb", "pb"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseMakeBucket,
-		specEnglish: specEnglishMakeBucket,
-		group:       GroupTypeNormalCommand,
+		name:      "mb",
+		nameAlias: []string{"cb", "pb"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -186,6 +185,11 @@ var makeBucketCommand = MakeBucketCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -207,8 +211,8 @@ func (mc *MakeBucketCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (mc *MakeBucketCommand) Init(args []string, options OptionMapType) error {
-	return mc.command.Init(args, options, mc)
+func (mc *MakeBucketCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return mc.command.Init(ctx, args, options, mc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -227,7 +231,7 @@ func (mc *MakeBucketCommand) RunCommand() error {
 		return fmt.Errorf("invalid cloud url: %s, object not empty, upload object please use \"cp\" command", mc.command.args[0])
 	}
 
-	client, err := mc.command.ossClient(cloudURL.bucket)
+	client, err := mc.command.cloudClient(cloudURL)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,355 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseMirrorVerify = SpecText{
+	synopsisText: "校验两个bucket(或者prefix)中的objects是否一致",
+
+	paramText: "src_cloud_url dest_cloud_url [options]",
+
+	syntaxText: `
+    ossutil mirror-verify oss://bucket1[/prefix] oss://bucket2[/prefix] [--checksum] [--sample 1%] [--routines 10] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    该命令同时列举源和目标两个bucket(或者prefix)下的objects，由于列举结果按照key的字典序返回，命令
+    按照相对于prefix的key对两个列举结果进行合并比较，不需要在内存中保存objects，适用于数亿个objects
+    的镜像校验，例如校验跨区域复制或者迁移的结果。
+
+    命令报告下述三种差异，每行一个：
+        missing    源中存在，目标中不存在的object
+        extra      目标中存在，源中不存在的object
+        mismatch   两边都存在，但大小不同，或者都不是分片上传的object而etag不同
+
+    分片上传的object的etag与数据内容无关，大小相同时不比较etag。指定--checksum选项时，命令对两边都存在
+    且大小相同的objects并发发送head请求，比较oss上存储的crc64(X-Oss-Hash-Crc64ecma)，任意一边没有存储
+    crc64时跳过。--sample选项指定抽查的百分比(如1%)，只对抽中的objects比较crc64。
+
+    存在差异时命令返回错误。
+
+用法：
+
+    ossutil mirror-verify oss://bucket1[/prefix] oss://bucket2[/prefix] [--checksum] [--sample percent]
+`,
+
+	sampleText: `
+    1) 校验两个bucket中的objects是否一致
+    ossutil mirror-verify oss://bucket1 oss://bucket2
+
+    2) 校验两个prefix下的objects，并比较crc64
+    ossutil mirror-verify oss://bucket1/data/ oss://bucket2/backup/data/ --checksum
+
+    3) 抽查1%的objects的crc64
+    ossutil mirror-verify oss://bucket1 oss://bucket2 --checksum --sample 1%
+`,
+}
+
+var specEnglishMirrorVerify = SpecText{
+	synopsisText: "Verify the objects of two buckets(or prefixes) are the same",
+
+	paramText: "src_cloud_url dest_cloud_url [options]",
+
+	syntaxText: `
+    ossutil mirror-verify oss://bucket1[/prefix] oss://bucket2[/prefix] [--checksum] [--sample 1%] [--routines 10] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The command lists the objects of the source and the destination buckets(or prefixes) at the same
+    time, the listings are returned in the lexical order of the keys, so the command merges and compares
+    them by the keys relative to the prefixes without keeping the objects in memory. It scales to
+    hundreds of millions of objects, e.g., to verify the result of cross region replication or migration.
+
+    The command reports three kinds of differences, one per line:
+        missing    the object exists in the source but not in the destination
+        extra      the object exists in the destination but not in the source
+        mismatch   the object exists in both, but the sizes are different, or the etags are different
+                   while neither of them is uploaded by multipart
+
+    The etag of the multipart object is unrelated to the data, so the etags are not compared if the
+    sizes are the same. If --checksum option is specified, the command heads the objects which exist in
+    both with the same size concurrently, and compares the crc64 stored in oss(X-Oss-Hash-Crc64ecma),
+    the object is skipped if either of them has no stored crc64. --sample option specifies the
+    percentage(eg: 1%) of the objects to spot check, only the sampled objects are compared by crc64.
+
+    The command returns error if there is any difference.
+
+Usage:
+
+    ossutil mirror-verify oss://bucket1[/prefix] oss://bucket2[/prefix] [--checksum] [--sample percent]
+`,
+
+	sampleText: `
+    1) verify the objects of two buckets are the same
+    ossutil mirror-verify oss://bucket1 oss://bucket2
+
+    2) verify the objects under two prefixes, and compare the crc64
+    ossutil mirror-verify oss://bucket1/data/ oss://bucket2/backup/data/ --checksum
+
+    3) spot check the crc64 of 1% objects
+    ossutil mirror-verify oss://bucket1 oss://bucket2 --checksum --sample 1%
+`,
+}
+
+type mirrorVerifyOptionType struct {
+	srcURL        CloudURL
+	destURL       CloudURL
+	checksum      bool
+	samplePercent float64
+	routines      int64
+	payerOptions  []oss.Option
+	compareNum    int64
+	matchNum      int64
+	missingNum    int64
+	extraNum      int64
+	mismatchNum   int64
+	crcCheckNum   int64
+	crcErrNum     int64
+}
+
+// MirrorVerifyCommand is the command compares the objects of two buckets by merging their listings
+type MirrorVerifyCommand struct {
+	command  Command
+	mvOption mirrorVerifyOptionType
+	outMu    sync.Mutex
+}
+
+var mirrorVerifyCommand = MirrorVerifyCommand{
+	command: Command{
+		name:      "mirror-verify",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionChecksum,
+			OptionSample,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (mvc *MirrorVerifyCommand) formatHelpForWhole() string {
+	return mvc.command.formatHelpForWhole()
+}
+
+func (mvc *MirrorVerifyCommand) formatIndependHelp() string {
+	return mvc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (mvc *MirrorVerifyCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return mvc.command.Init(ctx, args, options, mvc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (mvc *MirrorVerifyCommand) RunCommand() error {
+	// clear for go tests
+	mvc.mvOption = mirrorVerifyOptionType{}
+
+	encodingType, _ := GetString(OptionEncodingType, mvc.command.options)
+	srcURL, err := GetCloudUrl(mvc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	destURL, err := GetCloudUrl(mvc.command.args[1], encodingType)
+	if err != nil {
+		return err
+	}
+	if srcURL.bucket == destURL.bucket && srcURL.object == destURL.object {
+		return fmt.Errorf("the source and the destination are the same: %s", mvc.command.args[0])
+	}
+	mvc.mvOption.srcURL = *srcURL
+	mvc.mvOption.destURL = *destURL
+
+	mvc.mvOption.checksum, _ = GetBool(OptionChecksum, mvc.command.options)
+	strSample, _ := GetString(OptionSample, mvc.command.options)
+	if strSample != "" && !mvc.mvOption.checksum {
+		return fmt.Errorf("--sample only work with --checksum")
+	}
+	var sampleCount int64
+	if mvc.mvOption.samplePercent, sampleCount, err = parseSampleValue(strSample); err != nil {
+		return err
+	}
+	if sampleCount > 0 {
+		return fmt.Errorf("invalid sample value: %s, mirror-verify only supports the percentage", strSample)
+	}
+
+	payer, _ := GetString(OptionRequestPayer, mvc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		mvc.mvOption.payerOptions = append(mvc.mvOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+	mvc.mvOption.routines, _ = GetInt(OptionRoutines, mvc.command.options)
+	if mvc.mvOption.routines <= 0 {
+		mvc.mvOption.routines = int64(Routines)
+	}
+
+	srcBucket, err := mvc.command.cloudBucket(*srcURL)
+	if err != nil {
+		return err
+	}
+	destBucket, err := mvc.command.cloudBucket(*destURL)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	srcListing := mvc.command.listObjectsInOrder(srcBucket, srcURL.object, mvc.mvOption.payerOptions, done)
+	destListing := mvc.command.listObjectsInOrder(destBucket, destURL.object, mvc.mvOption.payerOptions, done)
+
+	chCheck := make(chan string, ChannelBuf)
+	var wg sync.WaitGroup
+	for i := 0; int64(i) < mvc.mvOption.routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mvc.checksumConsumer(srcBucket, destBucket, chCheck)
+		}()
+	}
+
+	listErr := mvc.mergeJoin(srcListing, destListing, chCheck)
+	close(chCheck)
+	wg.Wait()
+
+	fmt.Printf("compared object count:%d\tmatched:%d\tmissing:%d\textra:%d\tmismatch:%d\n",
+		mvc.mvOption.compareNum, mvc.mvOption.matchNum, mvc.mvOption.missingNum, mvc.mvOption.extraNum, mvc.mvOption.mismatchNum)
+	if mvc.mvOption.checksum {
+		fmt.Printf("crc64 checked:%d\tcrc64 check error:%d\n", mvc.mvOption.crcCheckNum, mvc.mvOption.crcErrNum)
+	}
+
+	if listErr != nil {
+		return listErr
+	}
+	if mvc.mvOption.missingNum > 0 || mvc.mvOption.extraNum > 0 || mvc.mvOption.mismatchNum > 0 || mvc.mvOption.crcErrNum > 0 {
+		return fmt.Errorf("mirror verification failed, %d missing, %d extra, %d mismatch, %d crc64 check error",
+			mvc.mvOption.missingNum, mvc.mvOption.extraNum, mvc.mvOption.mismatchNum, mvc.mvOption.crcErrNum)
+	}
+	return nil
+}
+
+// mergeJoin compares the ordered listings of the source and the destination, it stops at the
+// first list error
+func (mvc *MirrorVerifyCommand) mergeJoin(srcListing, destListing *objectListing, chCheck chan<- string) error {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	onlySrc := func(src oss.ObjectProperties) {
+		mvc.mvOption.missingNum++
+		mvc.output("missing", mvc.mvOption.srcURL.objectURL(mvc.mvOption.srcURL.object+src.Key), "")
+	}
+	onlyDest := func(dest oss.ObjectProperties) {
+		mvc.mvOption.extraNum++
+		mvc.output("extra", mvc.mvOption.destURL.objectURL(mvc.mvOption.destURL.object+dest.Key), "")
+	}
+	return joinListings(srcListing, destListing, onlySrc, onlyDest, func(src, dest oss.ObjectProperties) {
+		mvc.mvOption.compareNum++
+		if reason := compareMirrorObjects(src, dest); reason != "" {
+			atomic.AddInt64(&mvc.mvOption.mismatchNum, 1)
+			mvc.output("mismatch", mvc.mvOption.destURL.objectURL(mvc.mvOption.destURL.object+dest.Key), reason)
+			return
+		}
+		mvc.mvOption.matchNum++
+		if mvc.mvOption.checksum && r.Float64()*100 < mvc.mvOption.samplePercent {
+			chCheck <- src.Key
+		}
+	})
+}
+
+// compareMirrorObjects returns the reason if the objects are different by the listing
+func compareMirrorObjects(src, dest oss.ObjectProperties) string {
+	if src.Size != dest.Size {
+		return fmt.Sprintf("size %d != %d", src.Size, dest.Size)
+	}
+	// the etag of the multipart object depends on the part size
+	if !strings.Contains(src.ETag, "-") && !strings.Contains(dest.ETag, "-") && src.ETag != dest.ETag {
+		return fmt.Sprintf("etag %s != %s", src.ETag, dest.ETag)
+	}
+	return ""
+}
+
+// checksumConsumer compares the crc64 stored in oss of the objects with the same key
+func (mvc *MirrorVerifyCommand) checksumConsumer(srcBucket, destBucket *oss.Bucket, chCheck <-chan string) {
+	for key := range chCheck {
+		destObject := mvc.mvOption.destURL.object + key
+		srcCRC, err := mvc.storedCRC64(srcBucket, mvc.mvOption.srcURL.object+key)
+		destCRC := ""
+		if err == nil {
+			destCRC, err = mvc.storedCRC64(destBucket, destObject)
+		}
+		if err != nil {
+			atomic.AddInt64(&mvc.mvOption.crcErrNum, 1)
+			mvc.output("error", bucketObjectURL(destBucket, destObject), err.Error())
+			continue
+		}
+		if srcCRC == "" || destCRC == "" {
+			continue
+		}
+		atomic.AddInt64(&mvc.mvOption.crcCheckNum, 1)
+		if srcCRC != destCRC {
+			atomic.AddInt64(&mvc.mvOption.mismatchNum, 1)
+			mvc.output("mismatch", bucketObjectURL(destBucket, destObject), fmt.Sprintf("crc64 %s != %s", srcCRC, destCRC))
+		}
+	}
+}
+
+func (mvc *MirrorVerifyCommand) storedCRC64(bucket *oss.Bucket, object string) (string, error) {
+	props, err := mvc.command.ossGetObjectStatRetry(bucket, object, mvc.mvOption.payerOptions...)
+	if err != nil {
+		return "", err
+	}
+	return props.Get(oss.HTTPHeaderOssCRC64), nil
+}
+
+func (mvc *MirrorVerifyCommand) output(kind, objectURL, reason string) {
+	mvc.outMu.Lock()
+	defer mvc.outMu.Unlock()
+	if reason != "" {
+		fmt.Printf("%s\t%s\t%s\n", kind, objectURL, reason)
+	} else {
+		fmt.Printf("%s\t%s\n", kind, objectURL)
+	}
+}
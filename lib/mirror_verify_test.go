@@ -0,0 +1,126 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestMirrorVerify(c *C) {
+	c.Assert(compareMirrorObjects(oss.ObjectProperties{Size: 1, ETag: `"a"`}, oss.ObjectProperties{Size: 2, ETag: `"a"`}), Equals, "size 1 != 2")
+	c.Assert(compareMirrorObjects(oss.ObjectProperties{Size: 1, ETag: `"a"`}, oss.ObjectProperties{Size: 1, ETag: `"b"`}), Not(Equals), "")
+	c.Assert(compareMirrorObjects(oss.ObjectProperties{Size: 1, ETag: `"a-2"`}, oss.ObjectProperties{Size: 1, ETag: `"b"`}), Equals, "")
+
+	// key -> size, etag, crc64
+	buckets := map[string][][]string{
+		"src": {
+			{"data/a", "1", "a", "1"},
+			{"data/b", "1", "b", "2"},
+			{"data/c", "1", "c", "3"},
+			{"data/d", "2", "d", "4"},
+			{"data/e", "1", "e-1", "5"},
+		},
+		"dest": {
+			{"backup/a", "1", "a", "1"},
+			{"backup/c", "1", "c", "3"},
+			{"backup/c0", "1", "c0", "6"},
+			{"backup/d", "1", "d", "4"},
+			{"backup/e", "1", "x", "7"},
+		},
+	}
+	listFailed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names := strings.SplitN(r.URL.Path[1:], "/", 2)
+		objects := buckets[names[0]]
+		if r.Method == "HEAD" {
+			for _, object := range objects {
+				if object[0] == names[1] {
+					w.Header().Set("X-Oss-Hash-Crc64ecma", object[3])
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		// two objects per page to test the markers
+		prefix, marker := r.URL.Query().Get("prefix"), r.URL.Query().Get("marker")
+		if listFailed && names[0] == "dest" && marker != "" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code></Error>`)
+			return
+		}
+		listed, keys := []string{}, []string{}
+		for _, object := range objects {
+			if strings.HasPrefix(object[0], prefix) && object[0] > marker {
+				listed = append(listed, fmt.Sprintf("<Contents><Key>%s</Key><Size>%s</Size><ETag>\"%s\"</ETag></Contents>", object[0], object[1], object[2]))
+				keys = append(keys, object[0])
+			}
+		}
+		truncated, nextMarker := len(listed) > 2, ""
+		if truncated {
+			listed = listed[:2]
+			nextMarker = keys[1]
+		}
+		fmt.Fprintf(w, "<ListBucketResult><Prefix>%s</Prefix><IsTruncated>%t</IsTruncated><NextMarker>%s</NextMarker>%s</ListBucketResult>",
+			prefix, truncated, nextMarker, strings.Join(listed, ""))
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	checksum := true
+	retryTimes := "1"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionChecksum:        &checksum,
+		OptionRetryTimes:      &retryTimes,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("mirror-verify", []string{"oss://src/data/", "oss://dest/backup/"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "mirror verification failed, 1 missing, 1 extra, 2 mismatch, 0 crc64 check error")
+
+	output := s.readFile(resultPath, c)
+	c.Assert(strings.Contains(output, "missing\toss://src/data/b\n"), Equals, true)
+	c.Assert(strings.Contains(output, "extra\toss://dest/backup/c0\n"), Equals, true)
+	c.Assert(strings.Contains(output, "mismatch\toss://dest/backup/d\tsize 2 != 1\n"), Equals, true)
+	c.Assert(strings.Contains(output, "mismatch\toss://dest/backup/e\tcrc64 5 != 7\n"), Equals, true)
+	c.Assert(strings.Contains(output, "crc64 checked:3"), Equals, true)
+
+	// the same objects
+	_, err = cm.RunCommand("mirror-verify", []string{"oss://src/data/a", "oss://dest/backup/a"}, options)
+	c.Assert(err, IsNil)
+
+	// the keys after the list error aren't reported as missing
+	listFailed = true
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("mirror-verify", []string{"oss://src/data/", "oss://dest/backup/"}, options)
+	listFailed = false
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "AccessDenied"), Equals, true)
+	output = s.readFile(resultPath, c)
+	c.Assert(strings.Contains(output, "missing\toss://src/data/b\n"), Equals, true)
+	c.Assert(strings.Contains(output, "oss://src/data/d"), Equals, false)
+	c.Assert(strings.Contains(output, "oss://src/data/e"), Equals, false)
+
+	os.Stdout = oldStdout
+	testResultFile.Close()
+
+	sample := "1000"
+	options[OptionSample] = &sample
+	_, err = cm.RunCommand("mirror-verify", []string{"oss://src/data/", "oss://dest/backup/"}, options)
+	c.Assert(err, NotNil)
+}
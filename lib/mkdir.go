@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 )
@@ -62,13 +63,11 @@ type MkdirCommand struct {
 
 var mkdirCommand = MkdirCommand{
 	command: Command{
-		name:        "mkdir",
-		nameAlias:   []string{"mkdir"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseMkdir,
-		specEnglish: specEnglishMkdir,
-		group:       GroupTypeNormalCommand,
+		name:      "mkdir",
+		nameAlias: []string{"mkdir"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -88,6 +87,11 @@ var mkdirCommand = MkdirCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -109,8 +113,8 @@ func (mkc *MkdirCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (mkc *MkdirCommand) Init(args []string, options OptionMapType) error {
-	return mkc.command.Init(args, options, mkc)
+func (mkc *MkdirCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return mkc.command.Init(ctx, args, options, mkc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -144,7 +148,7 @@ func (mkc *MkdirCommand) RunCommand() error {
 }
 
 func (mkc *MkdirCommand) MkBucketDir(dirUrl CloudURL) error {
-	bucket, err := mkc.command.ossBucket(dirUrl.bucket)
+	bucket, err := mkc.command.cloudBucket(dirUrl)
 	if err != nil {
 		return err
 	}
@@ -194,6 +194,7 @@ type RMMonitor struct {
 	seekAheadEnd     bool
 	finish           bool
 	_                uint32 //Add padding to make sure the next data 64bits alignment
+	startTime        time.Time
 }
 
 func (m *RMMonitor) init() {
@@ -208,6 +209,7 @@ func (m *RMMonitor) init() {
 	m.errUploadIdNum = 0
 	m.finish = false
 	m.removedBucket = ""
+	m.startTime = time.Now()
 }
 
 func (m *RMMonitor) updateOP(op int64) {
@@ -282,15 +284,30 @@ func (m *RMMonitor) getProgressBar() string {
 	if m.op&allType != 0 {
 		snap := m.getSnapshot()
 		if m.seekAheadEnd && m.seekAheadError == nil {
-			return getClearStr(fmt.Sprintf("Total %s. %s%s Progress: %d%s", m.getTotalInfo(), m.getOKInfo(snap), m.getErrInfo(snap), m.getPrecent(snap), "%%"))
+			return getClearStr(fmt.Sprintf("Total %s. %s%s Progress: %d%s%s", m.getTotalInfo(), m.getOKInfo(snap), m.getErrInfo(snap), m.getPrecent(snap), "%%", m.getSpeedInfo(snap)))
 		}
 		m.totalObjectNum = max(m.totalObjectNum, snap.objectNum+snap.errObjectNum)
 		m.totalUploadIdNum = max(m.totalUploadIdNum, snap.uploadIdNum+snap.errUploadIdNum)
-		return getClearStr(fmt.Sprintf("Scanned %s. %s%s", m.getTotalInfo(), m.getOKInfo(snap), m.getErrInfo(snap)))
+		return getClearStr(fmt.Sprintf("Scanned %s. %s%s%s", m.getTotalInfo(), m.getOKInfo(snap), m.getErrInfo(snap), m.getSpeedInfo(snap)))
 	}
 	return getClearStr("")
 }
 
+// getSpeedInfo returns the removed number per second, and the remaining time after the scan ends
+func (m *RMMonitor) getSpeedInfo(snap *RMMonitorSnap) string {
+	elapsed := time.Since(m.startTime).Seconds()
+	if m.startTime.IsZero() || elapsed < 1 || snap.dealNum == 0 {
+		return ""
+	}
+	speed := float64(snap.dealNum) / elapsed
+	str := fmt.Sprintf(" Speed: %.0f/s.", speed)
+	if remain := m.totalObjectNum + m.totalUploadIdNum - snap.dealNum; m.seekAheadEnd && m.seekAheadError == nil && remain > 0 {
+		left := time.Duration(float64(remain) / speed * float64(time.Second))
+		str += fmt.Sprintf(" About %s left.", left.Round(time.Second))
+	}
+	return str
+}
+
 func (m *RMMonitor) getTotalInfo() string {
 	strList := []string{}
 	if m.op&objectType != 0 {
@@ -415,6 +432,7 @@ type CPMonitor struct {
 	op             operationType
 	seekAheadEnd   bool
 	finish         bool
+	sizeUnknown    bool   // the size of the named pipe or the device is unknown before it's read
 	_              uint32 //Add padding to make sure the next data 64bits alignment
 	lastSnapTime   time.Time
 }
@@ -433,6 +451,7 @@ func (m *CPMonitor) init(op operationType) {
 	m.skipNum = 0
 	m.errNum = 0
 	m.finish = false
+	m.sizeUnknown = false
 	m.lastSnapSize = 0
 	m.lastSnapTime = time.Now()
 	m.tickDuration = processTickInterval * int64(time.Second)
@@ -447,6 +466,11 @@ func (m *CPMonitor) updateScanNum(num int64) {
 	m.totalNum = m.totalNum + num
 }
 
+// setSizeUnknown shows the transferred size instead of the progress percentage
+func (m *CPMonitor) setSizeUnknown() {
+	m.sizeUnknown = true
+}
+
 func (m *CPMonitor) updateScanSizeNum(size, num int64) {
 	m.totalSize = m.totalSize + size
 	m.totalNum = m.totalNum + num
@@ -508,6 +532,43 @@ func (m *CPMonitor) getSnapshot() *CPMonitorSnap {
 	return &snap
 }
 
+// cpSummary is the summary of cp --output json
+type cpSummary struct {
+	Operation    string `json:"operation"`
+	TotalNum     int64  `json:"totalNum"`
+	TotalSize    int64  `json:"totalSize"`
+	OKNum        int64  `json:"okNum"`
+	SkipNum      int64  `json:"skipNum"`
+	ErrorNum     int64  `json:"errorNum"`
+	TransferSize int64  `json:"transferSize"`
+	SkipSize     int64  `json:"skipSize"`
+	DurationMs   int64  `json:"durationMs"`
+	AverageSpeed int64  `json:"averageSpeed"` // bytes per second
+	Error        string `json:"error,omitempty"`
+}
+
+func (m *CPMonitor) summary(durationMs, averageSpeed int64, err error) cpSummary {
+	mu.RLock()
+	snap := m.getSnapshot()
+	summary := cpSummary{
+		Operation:    m.getOPStr(),
+		TotalNum:     max(m.totalNum, snap.dealNum),
+		TotalSize:    max(m.totalSize, snap.dealSize),
+		OKNum:        snap.fileNum + snap.dirNum,
+		SkipNum:      snap.skipNum,
+		ErrorNum:     snap.errNum,
+		TransferSize: snap.transferSize,
+		SkipSize:     snap.skipSize,
+		DurationMs:   durationMs,
+		AverageSpeed: averageSpeed,
+	}
+	mu.RUnlock()
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	return summary
+}
+
 func (m *CPMonitor) progressBar(finish bool, exitStat int) string {
 	if m.finish {
 		return ""
@@ -532,7 +593,7 @@ func (m *CPMonitor) getProgressBar() string {
 		m.lastSnapSize = snap.transferSize
 	}
 
-	if m.seekAheadEnd && m.seekAheadError == nil {
+	if m.seekAheadEnd && m.seekAheadError == nil && !m.sizeUnknown {
 		return getClearStr(fmt.Sprintf("Total num: %d, size: %s. Dealed num: %d%s%s, Progress: %.3f%s, Speed: %.2fKB/s", m.totalNum, getSizeString(m.totalSize), snap.dealNum, m.getDealNumDetail(snap), m.getDealSizeDetail(snap), m.getPrecent(snap), "%%", m.getSpeed(snap)))
 	}
 	scanNum := max(m.totalNum, snap.dealNum)
@@ -549,7 +610,7 @@ func (m *CPMonitor) getFinishBar(exitStat int) string {
 
 func (m *CPMonitor) getWholeFinishBar() string {
 	snap := m.getSnapshot()
-	if m.seekAheadEnd && m.seekAheadError == nil {
+	if m.seekAheadEnd && m.seekAheadError == nil && !m.sizeUnknown {
 		if snap.errNum == 0 {
 			return getClearStr(fmt.Sprintf("Succeed: Total num: %d, size: %s. OK num: %d%s%s.\n", m.totalNum, getSizeString(m.totalSize), snap.okNum, m.getDealNumDetail(snap), m.getSkipSize(snap)))
 		}
@@ -0,0 +1,463 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseNotifyTest = SpecText{
+	synopsisText: "触发bucket的事件并检查下游目标是否收到，验证事件通知的配置",
+
+	paramText: "bucket_url --event event [--key key] --expect-object cloud_url|--expect-url url [options]",
+
+	syntaxText: `
+    ossutil notify-test oss://bucket --event event [--key key] --expect-object oss://bucket/object [--timeout seconds] [--force] [-c file]
+    ossutil notify-test oss://bucket --event event [--key key] --expect-url url [--timeout seconds] [--force] [-c file]
+`,
+
+	detailHelpText: `
+    该命令通过操作探测object触发--event指定的事件，然后在--timeout指定的秒数（默认为` + strconv.Itoa(DefaultTimeout) + `）内等待下游
+    目标（MNS、EventBridge、函数计算等）处理该事件的结果，用于验证事件规则、目标以及授权等配置是否正确。
+
+    oss不提供查询事件投递结果的接口，所以该命令通过下游目标产生的结果判断事件是否被收到：
+
+    1) --expect-object: 等待该object被创建或者被更新，适用于处理事件后写入结果object的函数计算等目标。
+
+    2) --expect-url: 等待对该url的GET请求返回2xx，适用于EventBridge的HTTP目标、消费MNS队列的服务等可以
+       查询收到的事件的目标。
+
+    --expect-object和--expect-url中可以使用以下变量：
+        {key}     探测object的名称
+        {token}   本次探测的唯一标识，写入探测object的内容以及x-oss-meta-ossutil-notify-token元信息中
+        {event}   事件名称
+    在--expect-url中变量的值经过url编码。
+
+    --key指定探测object的名称，默认为ossutil-notify-probe/加上本次探测的标识，请指定与事件规则的前缀和
+    后缀匹配的名称。探测会覆盖并删除探测object，所以指定的object已经存在时命令返回错误，请指定其他的
+    名称，或者使用--force选项确认覆盖。支持的事件及触发方式为：
+
+        ObjectCreated:PutObject                  上传探测object，可以简写为ObjectCreated:Put
+        ObjectCreated:AppendObject               追加上传探测object，--force覆盖已存在的object时先将其删除
+        ObjectCreated:CopyObject                 上传探测object后将其拷贝到自身并替换元信息
+        ObjectCreated:CompleteMultipartUpload    分片上传探测object
+        ObjectRemoved:DeleteObject               上传探测object后将其删除
+        ObjectRemoved:DeleteObjects              上传探测object后批量删除
+
+    探测结束后探测object会被删除，删除可能触发ObjectRemoved事件。事件在超时时间内没有被收到时命令返回
+    错误。
+`,
+
+	sampleText: `
+    1) 验证上传事件触发函数计算，函数将结果写入result/目录
+    ossutil notify-test oss://bucket --event ObjectCreated:Put --key tmp/probe.jpg --expect-object oss://bucket/result/tmp/probe.jpg
+
+    2) 验证删除事件投递到EventBridge的HTTP目标，该服务提供收到的object的查询接口
+    ossutil notify-test oss://bucket --event ObjectRemoved:DeleteObject --key tmp/probe --expect-url "https://example.com/events?key={key}" --timeout 120
+`,
+}
+
+var specEnglishNotifyTest = SpecText{
+	synopsisText: "Trigger the event of the bucket and check whether the downstream target receives it, to validate the event notification",
+
+	paramText: "bucket_url --event event [--key key] --expect-object cloud_url|--expect-url url [options]",
+
+	syntaxText: `
+    ossutil notify-test oss://bucket --event event [--key key] --expect-object oss://bucket/object [--timeout seconds] [--force] [-c file]
+    ossutil notify-test oss://bucket --event event [--key key] --expect-url url [--timeout seconds] [--force] [-c file]
+`,
+
+	detailHelpText: `
+    The command triggers the event specified by --event by operating a probe object, then waits for the
+    result of the downstream target(MNS, EventBridge, Function Compute, etc.) handling the event within
+    the seconds specified by --timeout(` + strconv.Itoa(DefaultTimeout) + ` by default), to validate the event rules, the targets and
+    the permissions.
+
+    oss doesn't provide the api querying the delivery of the events, so the command decides whether the
+    event is received by the result of the downstream target:
+
+    1) --expect-object: wait for the object to be created or updated, it's for the targets writing the
+       result object after handling the event, such as Function Compute.
+
+    2) --expect-url: wait for the GET request of the url to return 2xx, it's for the targets which can be
+       queried for the received events, such as the HTTP target of EventBridge or the service consuming
+       the MNS queue.
+
+    The variables below can be used in --expect-object and --expect-url:
+        {key}     the name of the probe object
+        {token}   the unique id of the probe, it's written in the content of the probe object and the
+                  x-oss-meta-ossutil-notify-token meta
+        {event}   the event name
+    The values of the variables are url encoded in --expect-url.
+
+    --key specifies the name of the probe object, the default is ossutil-notify-probe/ followed by the id
+    of the probe, please specify the name matching the prefix and the suffix of the event rule. The probe
+    overwrites and deletes the probe object, so the command returns error if the object specified exists,
+    please specify another name, or use --force to overwrite it. The events supported and how they are
+    triggered:
+
+        ObjectCreated:PutObject                  put the probe object, ObjectCreated:Put for short
+        ObjectCreated:AppendObject               append the probe object, the existing object overwritten
+                                                 by --force is deleted first
+        ObjectCreated:CopyObject                 put the probe object and copy it to itself replacing the meta
+        ObjectCreated:CompleteMultipartUpload    upload the probe object by multipart
+        ObjectRemoved:DeleteObject               put the probe object and delete it
+        ObjectRemoved:DeleteObjects              put the probe object and delete it by the batch delete
+
+    The probe object is deleted after the probe, the deletion may trigger the ObjectRemoved events. The
+    command returns error if the event isn't received within the timeout.
+`,
+
+	sampleText: `
+    1) validate the put event triggers Function Compute, which writes the result to the result/ directory
+    ossutil notify-test oss://bucket --event ObjectCreated:Put --key tmp/probe.jpg --expect-object oss://bucket/result/tmp/probe.jpg
+
+    2) validate the delete event is delivered to the HTTP target of EventBridge, the service provides the
+       api querying the received objects
+    ossutil notify-test oss://bucket --event ObjectRemoved:DeleteObject --key tmp/probe --expect-url "https://example.com/events?key={key}" --timeout 120
+`,
+}
+
+const (
+	NotifyEventPutObject               = "ObjectCreated:PutObject"
+	NotifyEventAppendObject            = "ObjectCreated:AppendObject"
+	NotifyEventCopyObject              = "ObjectCreated:CopyObject"
+	NotifyEventCompleteMultipartUpload = "ObjectCreated:CompleteMultipartUpload"
+	NotifyEventDeleteObject            = "ObjectRemoved:DeleteObject"
+	NotifyEventDeleteObjects           = "ObjectRemoved:DeleteObjects"
+	notifyProbePrefix                  = "ossutil-notify-probe/"
+	notifyTokenMeta                    = "ossutil-notify-token"
+)
+
+// notifyPollInterval is the interval of checking the downstream target, it's replaced in the tests
+var notifyPollInterval = 2 * time.Second
+
+type notifyTestOptionType struct {
+	bucket       *oss.Bucket
+	event        string
+	key          string
+	token        string
+	keyExists    bool
+	expectObject *CloudURL
+	expectURL    string
+	timeout      time.Duration
+	httpClient   *http.Client
+}
+
+// NotifyTestCommand triggers the event of the bucket and checks whether the downstream target receives it
+type NotifyTestCommand struct {
+	command      Command
+	notifyOption notifyTestOptionType
+}
+
+var notifyTestCommand = NotifyTestCommand{
+	command: Command{
+		name:      "notify-test",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionEvent,
+			OptionKey,
+			OptionExpectObject,
+			OptionExpectURL,
+			OptionTimeout,
+			OptionForce,
+			OptionEncodingType,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (nc *NotifyTestCommand) formatHelpForWhole() string {
+	return nc.command.formatHelpForWhole()
+}
+
+func (nc *NotifyTestCommand) formatIndependHelp() string {
+	return nc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (nc *NotifyTestCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return nc.command.Init(ctx, args, options, nc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (nc *NotifyTestCommand) RunCommand() error {
+	// clear for go tests
+	nc.notifyOption = notifyTestOptionType{}
+
+	encodingType, _ := GetString(OptionEncodingType, nc.command.options)
+	cloudURL, err := GetCloudUrl(nc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	if cloudURL.bucket == "" {
+		return fmt.Errorf("invalid cloud url: %s, miss bucket", nc.command.args[0])
+	}
+
+	event, _ := GetString(OptionEvent, nc.command.options)
+	if nc.notifyOption.event, err = normalizeNotifyEvent(event); err != nil {
+		return err
+	}
+	nc.notifyOption.token = strconv.FormatInt(time.Now().UnixNano(), 36) + randStr(6)
+	nc.notifyOption.key, _ = GetString(OptionKey, nc.command.options)
+	if nc.notifyOption.key == "" {
+		nc.notifyOption.key = cloudURL.object
+	}
+	if nc.notifyOption.key == "" {
+		nc.notifyOption.key = notifyProbePrefix + nc.notifyOption.token
+	}
+
+	expectObject, _ := GetString(OptionExpectObject, nc.command.options)
+	nc.notifyOption.expectURL, _ = GetString(OptionExpectURL, nc.command.options)
+	if (expectObject == "") == (nc.notifyOption.expectURL == "") {
+		return fmt.Errorf("please specify one of --expect-object and --expect-url to check whether the event is received")
+	}
+	if expectObject != "" {
+		if nc.notifyOption.expectObject, err = GetCloudUrl(nc.expand(expectObject, false), encodingType); err != nil {
+			return err
+		}
+		if nc.notifyOption.expectObject.object == "" {
+			return fmt.Errorf("invalid --expect-object: %s, miss object", expectObject)
+		}
+	} else {
+		nc.notifyOption.expectURL = nc.expand(nc.notifyOption.expectURL, true)
+		if _, err = url.ParseRequestURI(nc.notifyOption.expectURL); err != nil {
+			return fmt.Errorf("invalid --expect-url: %s", err.Error())
+		}
+		nc.notifyOption.httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	timeout, _ := GetInt(OptionTimeout, nc.command.options)
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	nc.notifyOption.timeout = time.Duration(timeout) * time.Second
+
+	if nc.notifyOption.bucket, err = nc.command.cloudBucket(*cloudURL); err != nil {
+		return err
+	}
+	if err = nc.checkProbeKey(); err != nil {
+		return err
+	}
+	return nc.probe()
+}
+
+// normalizeNotifyEvent returns the event name supported, the short forms like ObjectCreated:Put are accepted
+func normalizeNotifyEvent(event string) (string, error) {
+	events := []string{NotifyEventPutObject, NotifyEventAppendObject, NotifyEventCopyObject,
+		NotifyEventCompleteMultipartUpload, NotifyEventDeleteObject, NotifyEventDeleteObjects}
+	for _, name := range events {
+		if strings.EqualFold(event, name) || strings.EqualFold(event+"Object", name) {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --event: %s, the supported events are: %s", event, strings.Join(events, ", "))
+}
+
+// checkProbeKey returns error if the probe object specified by the user exists, because the probe
+// overwrites and deletes it, unless --force is specified. The default key is unique to the probe
+func (nc *NotifyTestCommand) checkProbeKey() error {
+	key := nc.notifyOption.key
+	if key == notifyProbePrefix+nc.notifyOption.token {
+		return nil
+	}
+	bucket := nc.notifyOption.bucket
+	_, err := nc.command.ossGetObjectStatRetry(bucket, key)
+	if err != nil && !isNotFound(err) {
+		return err
+	}
+	nc.notifyOption.keyExists = err == nil
+	if force, _ := GetBool(OptionForce, nc.command.options); nc.notifyOption.keyExists && !force {
+		return fmt.Errorf("the probe object %s exists, the probe would overwrite and delete it, please specify another --key or use --force",
+			bucketObjectURL(bucket, key))
+	}
+	return nil
+}
+
+// expand replaces the variables of --expect-object and --expect-url
+func (nc *NotifyTestCommand) expand(template string, escape bool) string {
+	values := []string{nc.notifyOption.key, nc.notifyOption.token, nc.notifyOption.event}
+	if escape {
+		for i := range values {
+			values[i] = url.QueryEscape(values[i])
+		}
+	}
+	return strings.NewReplacer("{key}", values[0], "{token}", values[1], "{event}", values[2]).Replace(template)
+}
+
+// probe triggers the event, waits for the downstream target and removes the probe object
+func (nc *NotifyTestCommand) probe() error {
+	check, err := nc.newChecker()
+	if err != nil {
+		return err
+	}
+
+	bucket := nc.notifyOption.bucket
+	probeURL := bucketObjectURL(bucket, nc.notifyOption.key)
+	startTime := time.Now()
+	err = nc.trigger()
+	if nc.notifyOption.event != NotifyEventDeleteObject && nc.notifyOption.event != NotifyEventDeleteObjects {
+		defer bucket.DeleteObject(nc.notifyOption.key)
+	}
+	if err != nil {
+		return fmt.Errorf("trigger %s on %s error: %s", nc.notifyOption.event, probeURL, err.Error())
+	}
+	fmt.Printf("triggered %s on %s, token: %s\n", nc.notifyOption.event, probeURL, nc.notifyOption.token)
+
+	for {
+		received, err := check()
+		if err != nil {
+			return err
+		}
+		if received {
+			fmt.Printf("the event is received by the target after %.1fs\n", time.Since(startTime).Seconds())
+			return nil
+		}
+		if time.Since(startTime) >= nc.notifyOption.timeout {
+			return fmt.Errorf("the event isn't received by the target within %s, please check the event rule, the target and its permission",
+				nc.notifyOption.timeout)
+		}
+		time.Sleep(notifyPollInterval)
+	}
+}
+
+// newChecker returns the function checking whether the downstream target receives the event, the
+// expected object is received only if it's created or changed after the probe
+func (nc *NotifyTestCommand) newChecker() (func() (bool, error), error) {
+	if nc.notifyOption.expectObject == nil {
+		return nc.checkURL, nil
+	}
+
+	bucket, err := nc.command.cloudBucket(*nc.notifyOption.expectObject)
+	if err != nil {
+		return nil, err
+	}
+	object := nc.notifyOption.expectObject.object
+	stamp := func() (string, error) {
+		props, err := bucket.GetObjectMeta(object)
+		if err != nil {
+			if isNotFound(err) {
+				return "", nil
+			}
+			return "", err
+		}
+		return props.Get(oss.HTTPHeaderEtag) + props.Get(oss.HTTPHeaderLastModified), nil
+	}
+	before, err := stamp()
+	if err != nil {
+		return nil, err
+	}
+	return func() (bool, error) {
+		after, err := stamp()
+		return after != "" && after != before, err
+	}, nil
+}
+
+// checkURL returns true if the GET request of --expect-url returns 2xx, the other responses mean
+// the event isn't received yet
+func (nc *NotifyTestCommand) checkURL() (bool, error) {
+	resp, err := nc.notifyOption.httpClient.Get(nc.notifyOption.expectURL)
+	if err != nil {
+		LogError("check %s error: %s\n", nc.notifyOption.expectURL, err.Error())
+		return false, nil
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	return resp.StatusCode >= 200 && resp.StatusCode < 300, nil
+}
+
+// trigger operates the probe object to trigger the event
+func (nc *NotifyTestCommand) trigger() error {
+	bucket := nc.notifyOption.bucket
+	key := nc.notifyOption.key
+	content := "ossutil notify-test " + nc.notifyOption.token
+	meta := oss.Meta(notifyTokenMeta, nc.notifyOption.token)
+	put := func() error {
+		return bucket.PutObject(key, strings.NewReader(content), meta)
+	}
+
+	switch nc.notifyOption.event {
+	case NotifyEventPutObject:
+		return put()
+	case NotifyEventAppendObject:
+		// the existing object overwritten by --force may not be appendable
+		if nc.notifyOption.keyExists {
+			if err := bucket.DeleteObject(key); err != nil {
+				return err
+			}
+		}
+		_, err := bucket.AppendObject(key, strings.NewReader(content), 0, meta)
+		return err
+	case NotifyEventCopyObject:
+		if err := put(); err != nil {
+			return err
+		}
+		_, err := bucket.CopyObject(key, key, oss.MetadataDirective(oss.MetaReplace), meta)
+		return err
+	case NotifyEventCompleteMultipartUpload:
+		imur, err := bucket.InitiateMultipartUpload(key, meta)
+		if err != nil {
+			return err
+		}
+		part, err := bucket.UploadPart(imur, strings.NewReader(content), int64(len(content)), 1)
+		if err != nil {
+			bucket.AbortMultipartUpload(imur)
+			return err
+		}
+		_, err = bucket.CompleteMultipartUpload(imur, []oss.UploadPart{part})
+		return err
+	case NotifyEventDeleteObject:
+		if err := put(); err != nil {
+			return err
+		}
+		return bucket.DeleteObject(key)
+	case NotifyEventDeleteObjects:
+		if err := put(); err != nil {
+			return err
+		}
+		_, err := bucket.DeleteObjects([]string{key})
+		return err
+	}
+	return nil
+}
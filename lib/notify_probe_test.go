@@ -0,0 +1,167 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestNotifyTest(c *C) {
+	var mu sync.Mutex
+	var deleted sync.Map
+	objects := map[string]bool{}
+	var requests []string
+	// the function writes the result object when the probe is put
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		switch r.Method {
+		case "PUT":
+			objects[r.URL.Path] = true
+			if r.Header.Get("X-Oss-Copy-Source") != "" {
+				fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+			} else if strings.HasPrefix(r.URL.Path, "/bucket/tmp/") {
+				objects["/bucket/result/"+strings.TrimPrefix(r.URL.Path, "/bucket/")] = true
+			}
+		case "POST":
+			objects[r.URL.Path] = true
+			w.Header().Set("X-Oss-Next-Append-Position", "30")
+		case "DELETE":
+			delete(objects, r.URL.Path)
+			deleted.Store(strings.TrimPrefix(r.URL.Path, "/bucket/"), true)
+			w.WriteHeader(http.StatusNoContent)
+		case "HEAD":
+			if !objects[r.URL.Path] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", `"etag"`)
+		}
+	}))
+	defer server.Close()
+
+	// the service consuming the events provides the query of the deleted keys
+	eventServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := deleted.Load(r.URL.Query().Get("key")); !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer eventServer.Close()
+
+	notifyPollInterval = 10 * time.Millisecond
+	defer func() { notifyPollInterval = 2 * time.Second }()
+	resultPath := "ossutil-test-notify-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	event := "ObjectCreated:Put"
+	key := "tmp/probe"
+	expectObject := "oss://bucket/result/{key}"
+	expectURL := ""
+	timeout := "1"
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionEvent:           &event,
+		OptionKey:             &key,
+		OptionExpectObject:    &expectObject,
+		OptionExpectURL:       &expectURL,
+		OptionTimeout:         &timeout,
+	}
+
+	// the result object is created, the probe object is removed
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(objects["/bucket/tmp/probe"], Equals, false)
+	c.Assert(s.readFile(resultPath, c), Matches, "(?s)triggered ObjectCreated:PutObject on oss://bucket/tmp/probe.*the event is received.*")
+
+	// the result object exists before the probe and isn't changed
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "isn't received"), Equals, true)
+
+	// the probe object is copied to itself
+	event = "ObjectCreated:CopyObject"
+	expectObject = "oss://bucket/other/{token}"
+	requests = nil
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(requests[:4], DeepEquals, []string{"HEAD /bucket/tmp/probe", "HEAD /bucket/other/" + notifyTestCommand.notifyOption.token,
+		"PUT /bucket/tmp/probe", "PUT /bucket/tmp/probe"})
+	c.Assert(requests[len(requests)-1], Equals, "DELETE /bucket/tmp/probe")
+
+	// the existing object isn't overwritten without --force
+	event = "ObjectCreated:AppendObject"
+	objects["/bucket/tmp/probe"] = true
+	requests = nil
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--force"), Equals, true)
+	c.Assert(requests, DeepEquals, []string{"HEAD /bucket/tmp/probe"})
+	c.Assert(objects["/bucket/tmp/probe"], Equals, true)
+
+	// the existing object is deleted before appending with --force, the new object is appended directly
+	force := true
+	options[OptionForce] = &force
+	requests = nil
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(requests[:4], DeepEquals, []string{"HEAD /bucket/tmp/probe", "HEAD /bucket/other/" + notifyTestCommand.notifyOption.token,
+		"DELETE /bucket/tmp/probe", "POST /bucket/tmp/probe"})
+	delete(options, OptionForce)
+	requests = nil
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(requests[:3], DeepEquals, []string{"HEAD /bucket/tmp/probe", "HEAD /bucket/other/" + notifyTestCommand.notifyOption.token,
+		"POST /bucket/tmp/probe"})
+
+	// the default probe object is unique to the probe, it isn't checked
+	key = ""
+	requests = nil
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	probeKey := notifyProbePrefix + notifyTestCommand.notifyOption.token
+	c.Assert(requests[:2], DeepEquals, []string{"HEAD /bucket/other/" + notifyTestCommand.notifyOption.token, "POST /bucket/" + probeKey})
+	c.Assert(requests[len(requests)-1], Equals, "DELETE /bucket/"+probeKey)
+
+	// the deleted key is queried from the url
+	event = "ObjectRemoved:Delete"
+	key = "tmp/probe-delete"
+	expectObject = ""
+	expectURL = eventServer.URL + "/events?key={key}"
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, IsNil)
+	_, ok := deleted.Load("tmp/probe-delete")
+	c.Assert(ok, Equals, true)
+
+	// invalid options
+	event = "ObjectCreated:Unknown"
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+	event = "ObjectCreated:PutObject"
+	expectObject = "oss://bucket/result/{key}"
+	_, err = cm.RunCommand("notify-test", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+
+	name, err := normalizeNotifyEvent("objectcreated:completemultipartupload")
+	c.Assert(err, IsNil)
+	c.Assert(name, Equals, NotifyEventCompleteMultipartUpload)
+}
@@ -0,0 +1,63 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// readNullInputKeys reads the NUL-delimited cloud urls from stdin for --null-input, like the
+// output of ls --print0 or find --print0, and returns the keys of the objects. It returns nil if
+// --null-input is not specified, the urls should be in the bucket of cloudURL
+func (cmd *Command) readNullInputKeys(cloudURL CloudURL) ([]string, error) {
+	nullInput, _ := GetBool(OptionNullInput, cmd.options)
+	if !nullInput {
+		return nil, nil
+	}
+	encodingType, _ := GetString(OptionEncodingType, cmd.options)
+	return readNullDelimitedKeys(os.Stdin, cloudURL.bucket, encodingType)
+}
+
+func readNullDelimitedKeys(reader io.Reader, bucket string, encodingType string) ([]string, error) {
+	scanner := bufio.NewScanner(reader)
+	// the object name is at most 1023 bytes, the url may be longer for url encoding
+	scanner.Buffer(make([]byte, 4096), 64*1024)
+	scanner.Split(scanNull)
+
+	keys := []string{}
+	seen := map[string]bool{}
+	for num := 1; scanner.Scan(); num++ {
+		item := scanner.Text()
+		if item == "" {
+			continue
+		}
+		url, err := CloudURLFromString(item, encodingType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --null-input item %d: %s", num, err.Error())
+		}
+		if url.bucket != bucket || url.object == "" {
+			return nil, fmt.Errorf("invalid --null-input item %d: %q, it should be an object of bucket %s", num, item, bucket)
+		}
+		if !seen[url.object] {
+			seen[url.object] = true
+			keys = append(keys, url.object)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read --null-input error: %s", err.Error())
+	}
+	return keys, nil
+}
+
+// scanNull is the split function of bufio.Scanner for the NUL-delimited items
+func scanNull(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,105 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestNullInput(c *C) {
+	keys, err := readNullDelimitedKeys(strings.NewReader("oss://bucket/a b\x00oss://bucket/c\nd\x00\x00oss://bucket/a b"), "bucket", "")
+	c.Assert(err, IsNil)
+	c.Assert(keys, DeepEquals, []string{"a b", "c\nd"})
+	_, err = readNullDelimitedKeys(strings.NewReader("oss://other/a\x00"), "bucket", "")
+	c.Assert(err, NotNil)
+	_, err = readNullDelimitedKeys(strings.NewReader("oss://bucket\x00"), "bucket", "")
+	c.Assert(err, NotNil)
+
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>logs/a b</Key><Size>1</Size><LastModified>2022-01-01T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>logs/c&#10;d</Key><Size>2</Size><LastModified>2022-01-01T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`)
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			for _, part := range strings.Split(string(body), "<Key>")[1:] {
+				deleted = append(deleted, part[:strings.Index(part, "</Key>")])
+			}
+			fmt.Fprint(w, `<DeleteResult></DeleteResult>`)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	print0 := true
+	limitedNum := "10"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionPrint0:          &print0,
+		OptionLimitedNum:      &limitedNum,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	_, err = cm.RunCommand("ls", []string{"oss://bucket/logs/"}, options)
+	c.Assert(err, IsNil)
+	output := s.readFile(resultPath, c)
+	c.Assert(output, Equals, "oss://bucket/logs/a b\x00oss://bucket/logs/c\nd\x00")
+
+	// feed the output of ls to rm
+	inputPath := "ossutil-test-null-input-" + randLowStr(8)
+	s.createFile(inputPath, output, c)
+	defer os.Remove(inputPath)
+	input, err := os.Open(inputPath)
+	c.Assert(err, IsNil)
+	defer input.Close()
+	oldStdin := os.Stdin
+	os.Stdin = input
+	defer func() { os.Stdin = oldStdin }()
+
+	recursive := true
+	force := true
+	nullInput := true
+	options = OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRecursion:       &recursive,
+		OptionNullInput:       &nullInput,
+	}
+	// --force is required
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/logs/"}, options)
+	c.Assert(err, NotNil)
+
+	input.Seek(0, 0)
+	options[OptionForce] = &force
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/logs/"}, options)
+	c.Assert(err, IsNil)
+	sort.Strings(deleted)
+	c.Assert(deleted, DeepEquals, []string{"logs/a b", "logs/c&#xA;d"})
+}
@@ -0,0 +1,79 @@
+package lib
+
+import (
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// objectListing is the listing of the objects under a prefix in the order of the keys, the keys
+// are relative to the prefix
+type objectListing struct {
+	ch  chan oss.ObjectProperties
+	err error // the error of the listing, it's set before ch is closed
+}
+
+// listObjectsInOrder lists the objects under prefix in background. The listing stops on the first
+// error or once done is closed
+func (cmd *Command) listObjectsInOrder(bucket *oss.Bucket, prefix string, options []oss.Option, done <-chan struct{}) *objectListing {
+	listing := &objectListing{ch: make(chan oss.ObjectProperties, ChannelBuf)}
+	go func() {
+		defer close(listing.ch)
+		marker := ""
+		for {
+			listOptions := append(append([]oss.Option{}, options...), oss.Prefix(prefix), oss.Marker(marker), oss.MaxKeys(1000))
+			lor, err := cmd.ossListObjectsRetry(bucket, listOptions...)
+			if err != nil {
+				listing.err = err
+				return
+			}
+
+			for _, object := range lor.Objects {
+				object.Key = strings.TrimPrefix(object.Key, prefix)
+				select {
+				case listing.ch <- object:
+				case <-done:
+					return
+				}
+			}
+
+			marker = lor.NextMarker
+			if !lor.IsTruncated {
+				return
+			}
+		}
+	}()
+	return listing
+}
+
+// joinListings walks the source and the destination listings by the relative keys, it calls
+// onlySrc, onlyDest and both for the keys only in the source, only in the destination and in
+// both. It stops at the first error of the listings, the keys after the failed listing are
+// unknown and mustn't be reported as missing or extra
+func joinListings(src, dest *objectListing, onlySrc, onlyDest func(object oss.ObjectProperties), both func(src, dest oss.ObjectProperties)) error {
+	srcObject, srcOK := <-src.ch
+	destObject, destOK := <-dest.ch
+	for {
+		if !srcOK && src.err != nil {
+			return src.err
+		}
+		if !destOK && dest.err != nil {
+			return dest.err
+		}
+
+		switch {
+		case !srcOK && !destOK:
+			return nil
+		case !destOK || srcOK && srcObject.Key < destObject.Key:
+			onlySrc(srcObject)
+			srcObject, srcOK = <-src.ch
+		case !srcOK || destObject.Key < srcObject.Key:
+			onlyDest(destObject)
+			destObject, destOK = <-dest.ch
+		default:
+			both(srcObject, destObject)
+			srcObject, srcOK = <-src.ch
+			destObject, destOK = <-dest.ch
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -121,13 +122,11 @@ type ObjectTagCommand struct {
 
 var objectTagCommand = ObjectTagCommand{
 	command: Command{
-		name:        "object-tagging",
-		nameAlias:   []string{"object-tagging"},
-		minArgc:     1,
-		maxArgc:     11,
-		specChinese: specChineseObjectTag,
-		specEnglish: specEnglishObjectTag,
-		group:       GroupTypeNormalCommand,
+		name:      "object-tagging",
+		nameAlias: []string{"object-tagging"},
+		minArgc:   1,
+		maxArgc:   11,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -152,6 +151,11 @@ var objectTagCommand = ObjectTagCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -173,8 +177,8 @@ func (otc *ObjectTagCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (otc *ObjectTagCommand) Init(args []string, options OptionMapType) error {
-	return otc.command.Init(args, options, otc)
+func (otc *ObjectTagCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return otc.command.Init(ctx, args, options, otc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -231,7 +235,7 @@ func (otc *ObjectTagCommand) RunCommand() error {
 		}
 	}
 
-	bucket, err := otc.command.ossBucket(cloudUrL.bucket)
+	bucket, err := otc.command.cloudBucket(*cloudUrL)
 	if err != nil {
 		return err
 	}
@@ -267,7 +271,7 @@ func (otc *ObjectTagCommand) SingleObjectTagging(bucket *oss.Bucket, objectName
 			}
 
 			for index, tag := range resutl.Tags {
-				fmt.Printf("%-15d%-15d\"%s\"\t\"%s\"\t%s\n", otc.objectIndex, index, tag.Key, tag.Value, CloudURLToString(bucket.BucketName, objectName))
+				fmt.Printf("%-15d%-15d\"%s\"\t\"%s\"\t%s\n", otc.objectIndex, index, tag.Key, tag.Value, bucketObjectURL(bucket, objectName))
 			}
 			otc.lock.Unlock()
 		}
@@ -325,7 +329,7 @@ func (otc *ObjectTagCommand) objectTaggingWithReport(bucket *oss.Bucket, object
 	err := otc.SingleObjectTagging(bucket, object)
 	if otc.method != "get" {
 		otc.command.updateMonitor(err, &otc.monitor)
-		msg := fmt.Sprintf("%s %s object tagging", otc.method, CloudURLToString(bucket.BucketName, object))
+		msg := fmt.Sprintf("%s %s object tagging", otc.method, bucketObjectURL(bucket, object))
 		if err == nil {
 			otc.command.report(msg, err, &otc.reportOption)
 		} else {
@@ -35,6 +35,9 @@ type Option struct {
 // LEnglishLanguage is the lower case of EnglishLanguage
 var LEnglishLanguage = strings.ToLower(EnglishLanguage)
 
+// LChineseLanguage is the lower case of ChineseLanguage
+var LChineseLanguage = strings.ToLower(ChineseLanguage)
+
 // OptionMap is a collection of ossutil supported options
 var OptionMap = map[string]Option{
 	OptionConfigFile: Option{"-c", "--config-file", "", OptionTypeString, "", "",
@@ -103,9 +106,9 @@ var OptionMap = map[string]Option{
 	OptionTimeout: Option{"", "--timeout", strconv.FormatInt(DefaultTimeout, 10), OptionTypeInt64, strconv.FormatInt(MinTimeout, 10), strconv.FormatInt(MaxTimeout, 10),
 		fmt.Sprintf("签名url的超时时间，单位为秒，默认值为：%d，取值范围：%d-%d", DefaultTimeout, MinTimeout, MaxTimeout),
 		fmt.Sprintf("time out of signurl, the unit is: s, default value is %d, the value range is: %d-%d", DefaultTimeout, MinTimeout, MaxTimeout)},
-	OptionLanguage: Option{"-L", "--language", DefaultLanguage, OptionTypeAlternative, fmt.Sprintf("%s/%s", ChineseLanguage, EnglishLanguage), "",
-		fmt.Sprintf("设置ossutil工具的语言，默认值：%s，取值范围：%s/%s，若设置成\"%s\"，请确保您的系统编码为UTF-8。", DefaultLanguage, ChineseLanguage, EnglishLanguage, ChineseLanguage),
-		fmt.Sprintf("set the language of ossutil(default: %s), value range is: %s/%s, if you set it to \"%s\", please make sure your system language is UTF-8.", DefaultLanguage, ChineseLanguage, EnglishLanguage, ChineseLanguage)},
+	OptionLanguage: Option{"-L", "--language", DefaultLanguage, OptionTypeAlternative, catalogLanguages(), "",
+		fmt.Sprintf("设置ossutil工具的语言，默认值：%s，取值范围：%s，若设置成\"%s\"，请确保您的系统编码为UTF-8。默认值根据环境变量LC_ALL、LC_MESSAGES、LANG确定，其他语言的翻译文件<language>.json可以放在%s环境变量指定的目录（默认为~/%s）中。", DefaultLanguage, catalogLanguages(), ChineseLanguage, LocaleDirEnv, DefaultLocaleDir),
+		fmt.Sprintf("set the language of ossutil(default: %s), value range is: %s, if you set it to \"%s\", please make sure your system language is UTF-8. The default is decided by the environment variables LC_ALL, LC_MESSAGES and LANG, the translation files <language>.json of other languages can be put in the directory of environment variable %s(~/%s by default).", DefaultLanguage, catalogLanguages(), ChineseLanguage, LocaleDirEnv, DefaultLocaleDir)},
 	OptionHashType: Option{"", "--type", DefaultHashType, OptionTypeAlternative, fmt.Sprintf("%s/%s", DefaultHashType, MD5HashType), "", fmt.Sprintf("计算的类型, 默认值：%s, 取值范围: %s/%s", DefaultHashType, DefaultHashType, MD5HashType),
 		fmt.Sprintf("hash type, Default: %s, value range is: %s/%s", DefaultHashType, DefaultHashType, MD5HashType)},
 	OptionVersion:      Option{"-v", "--version", "", OptionTypeFlagTrue, "", "", fmt.Sprintf("显示ossutil的版本（%s）并退出。", Version), fmt.Sprintf("Show ossutil version (%s) and exit.", Version)},
@@ -198,8 +201,8 @@ var OptionMap = map[string]Option{
 		"表示仅操作当前目录下的文件或者object, 忽略子目录",
 		"specifies that only files or objects in the current directory are manipulated, and subdirectories are ignored."},
 	OptionProbeItem: Option{"", "--probe-item", "", OptionTypeString, "", "",
-		"表示probe命令的探测项目, 取值可为upload-speed, download-speed, cycle-symlink",
-		"specifies probe command's probe item, the value can be upload-speed, download-speed, cycle-symlink"},
+		"表示probe命令的探测项目, 取值可为upload-speed, download-speed, cycle-symlink, download-time, diagnose",
+		"specifies probe command's probe item, the value can be upload-speed, download-speed, cycle-symlink, download-time, diagnose"},
 	OptionDisableEncodeSlash: Option{"", "--disable-encode-slash", "", OptionTypeFlagTrue, "", "",
 		"表示不对url path中的'/'进行编码, 主要用于sign命令",
 		"specifies no encoding of '/' in url path section, primarily used in sign command"},
@@ -276,8 +279,8 @@ var OptionMap = map[string]Option{
 		"bucket所在的地区, 比如cn-hangzhou, 缺省值为空, 如果使用v4签名则必须传入",
 		"The region where the bucket is located, such as cn-hangzhou. The default value is empty. If V4 signature is used, it must be inputted"},
 	OptionCloudBoxID: Option{"", "--cloudbox-id", "", OptionTypeString, "", "",
-		"云盒的id，缺省值为空，适用于云盒场景",
-		"The ID of the cloud box. The default value is empty. It is applicable to cloud box scenarios"},
+		"云盒的id，缺省值为空，适用于云盒场景。请求使用v4签名，如果--endpoint不是该云盒的数据域名，ossutil通过--endpoint列举云盒，使用该云盒的数据域名访问bucket和object",
+		"The ID of the cloud box. The default value is empty. It is applicable to cloud box scenarios. The requests are signed by v4 signature, if --endpoint is not the data endpoint of the cloud box, ossutil lists the cloud boxes by --endpoint, and accesses the buckets and objects by the data endpoint of the cloud box"},
 	OptionQueryParam: Option{"", "--query-param", "", OptionTypeStrings, "", "",
 		"设置请求的query参数",
 		"Set the query parameters for the request"},
@@ -287,15 +290,389 @@ var OptionMap = map[string]Option{
 	OptionRuntime: Option{"", "--runtime", "", OptionTypeInt64, "", "",
 		"设置命令的持续的运行时间",
 		"specifies the max running time of the command."},
+	OptionSample: Option{"", "--sample", "", OptionTypeString, "", "",
+		"抽样比例或抽样个数，取值为百分比(如1%)或者正整数(如1000)",
+		"the sample ratio or sample count, the value is a percentage(eg: 1%) or a positive integer(eg: 1000)"},
+	OptionFormat: Option{"", "--format", "", OptionTypeString, "", "",
+		"导出或生成的格式，export-config取值为terraform或者ros，gen-docs取值为man或者markdown",
+		"the format to export or generate, the value can be terraform or ros for export-config, man or markdown for gen-docs"},
+	OptionS3Endpoint: Option{"", "--s3-endpoint", "", OptionTypeString, "", "",
+		"s3://格式url使用的s3兼容服务的endpoint，默认读取环境变量AWS_ENDPOINT_URL_S3或AWS_ENDPOINT_URL，都为空时使用aws的endpoint",
+		"the endpoint of s3 compatible service used by s3:// url, the default is read from environment variable AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL, if both are empty, the aws endpoint is used"},
+	OptionKeyTemplate: Option{"", "--key-template", "", OptionTypeString, "", "",
+		"根据url生成object名称的模板，可以使用{host}、{path}、{dir}、{basename}和{index}变量",
+		"the template to generate object name from url, the variables {host}, {path}, {dir}, {basename} and {index} can be used"},
+	OptionManifest: Option{"", "--manifest", "", OptionTypeString, "", "",
+		"结果清单文件的路径",
+		"the path of the result manifest file"},
+	OptionMimeMap: Option{"", "--mime-map", "", OptionTypeString, "", "",
+		"上传时使用的文件扩展名到Content-Type的映射文件，文件每行为：扩展名 Content-Type，如：.md text/markdown",
+		"the file of extension to Content-Type map used by upload, each line of the file is: extension Content-Type, e.g., .md text/markdown"},
+	OptionDetectContentType: Option{"", "--detect-content-type", "", OptionTypeAlternative, "extension/sniff", "",
+		"上传时确定Content-Type的方式，取值为：extension(根据扩展名，默认值)、sniff(根据文件开头的内容)",
+		"the way to decide Content-Type for upload, the value can be: extension(by file extension, default), sniff(by the leading bytes of file)"},
+	OptionListSplit: Option{"", "--list-split", "", OptionTypeString, "", "",
+		"批量操作时将prefix下的key空间分片并发列举，取值为auto(按prefix后的首字符分片)或者逗号分隔的相对于prefix的分片点，如：a,g,n,t",
+		"list the keyspace under prefix concurrently by shards in batch operation, the value is auto(split by the first character after prefix) or split points relative to prefix separated by comma, e.g., a,g,n,t"},
+	OptionStartAfter: Option{"", "--start-after", "", OptionTypeString, "", "",
+		"列举objects时从该key之后开始列举，key不需要url编码",
+		"list objects after the key, the key is not url encoded"},
+	OptionFetchOwner: Option{"", "--fetch-owner", "", OptionTypeFlagTrue, "", "",
+		"列举objects时返回object的owner信息",
+		"return the owner of objects when list objects"},
+	OptionTop: Option{"", "--top", "", OptionTypeInt64, "0", "",
+		"du命令显示最大的前N个object",
+		"show the top N largest objects for du command"},
+	OptionStatSummary: Option{"", "--stat-summary", "", OptionTypeString, "", "",
+		"执行结束后输出每个文件的耗时、字节数、重试次数、平均速度以及汇总的百分位数，取值为stdout或者csv文件路径",
+		"output the cost, bytes, retries, average speed of every file and the aggregate percentiles after the run, the value is stdout or the csv file path"},
+	OptionIfMatch: Option{"", "--if-match", "", OptionTypeString, "", "",
+		"只有object的etag与指定值相同时才执行操作，否则报错PreconditionFailed",
+		"operate only when the etag of object matches the value, otherwise PreconditionFailed error happens"},
+	OptionIfNoneMatch: Option{"", "--if-none-match", "", OptionTypeString, "", "",
+		"只有object的etag与指定值不同时才执行操作，否则报错PreconditionFailed",
+		"operate only when the etag of object doesn't match the value, otherwise PreconditionFailed error happens"},
+	OptionIfUnmodifiedSince: Option{"", "--if-unmodified-since", "", OptionTypeString, "", "",
+		"只有object在指定时间之后未被修改时才执行操作，时间可以为http日期、RFC3339时间或者unix时间戳",
+		"operate only when the object is not modified since the time, the time can be http date, RFC3339 time or unix timestamp"},
+	OptionForbidOverwrite: Option{"", "--forbid-overwrite", "", OptionTypeFlagTrue, "", "",
+		"上传或拷贝时禁止覆盖同名object，目标object已存在时报错",
+		"forbid overwriting the object with the same name when uploading or copying, error happens if the destination object exists"},
+	OptionNoClobber: Option{"", "--no-clobber", "", OptionTypeFlagTrue, "", "",
+		"跳过已经存在的目标object或者本地文件，不比较修改时间",
+		"skip the destination object or local file which already exists, without comparing the modified time"},
+	OptionIgnoreExisting: Option{"", "--ignore-existing", "", OptionTypeFlagTrue, "", "",
+		"同--no-clobber",
+		"the same as --no-clobber"},
+	OptionDisableOssIgnore: Option{"", "--disable-ossignore", "", OptionTypeFlagTrue, "", "",
+		"递归上传时不使用目录中的.ossignore文件排除文件",
+		"don't exclude files by the .ossignore files in the directories when uploading recursively"},
+	OptionMaxDuration: Option{"", "--max-duration", "", OptionTypeString, "", "",
+		"整个命令的最长执行时间，如：90m、2h，超过后不再开始新的文件，命令以退出码3结束",
+		"the max duration of the whole command, e.g., 90m, 2h, no more files are started after it is exceeded, and the command exits with code 3"},
+	OptionRetryBudget: Option{"", "--retry-budget", "", OptionTypeInt64, "0", "",
+		"整个命令的总重试次数上限，用完后不再重试和开始新的文件，命令以退出码3结束",
+		"the max retries of the whole command, no more retries are made and no more files are started after it is used up, and the command exits with code 3"},
+	OptionMaxDiskUsage: Option{"", "--max-disk-usage", "", OptionTypeInt64, "", "",
+		"下载时最多写入本地磁盘的字节数，超过后不再开始新的文件",
+		"the max bytes written to the local disk when downloading, no more files are started after it is exceeded"},
+	OptionStagingDir: Option{"", "--staging-dir", "", OptionTypeString, "", "",
+		"下载时先写入该目录，校验成功后再重命名到目标路径，该目录需要与目标路径在同一个文件系统",
+		"download to the directory first, then rename to the destination after the verification succeeds, the directory should be on the same filesystem as the destination"},
+	OptionWindowsNameMapping: Option{"", "--windows-name-mapping", "", OptionTypeAlternative, fmt.Sprintf("%s/%s/%s", WindowsNameUnderscore, WindowsNamePercent, WindowsNameNone), "",
+		fmt.Sprintf("在windows上下载时，object名中的保留名称（如：CON、NUL）、非法字符以及结尾的点和空格的映射方式，取值范围：%s/%s/%s，默认值：%s", WindowsNameUnderscore, WindowsNamePercent, WindowsNameNone, WindowsNameUnderscore),
+		fmt.Sprintf("the mapping of the reserved names(e.g., CON, NUL), the invalid characters and the trailing dots and spaces in object names when downloading on windows, value range is: %s/%s/%s, default is: %s", WindowsNameUnderscore, WindowsNamePercent, WindowsNameNone, WindowsNameUnderscore)},
+	OptionLocalEncoding: Option{"", "--local-encoding", "", OptionTypeAlternative, fmt.Sprintf("%s/%s", LocalEncodingGBK, LocalEncodingShiftJIS), "",
+		fmt.Sprintf("本地文件名的编码，上传时将文件名转换为UTF-8的object名，下载时将object名转换为该编码的文件名，取值范围：%s/%s，不指定时文件名为UTF-8", LocalEncodingGBK, LocalEncodingShiftJIS),
+		fmt.Sprintf("the encoding of local file names, the file names are converted to UTF-8 object names when uploading, and the object names are converted to the encoding when downloading, value range is: %s/%s, the file names are UTF-8 if it is not specified", LocalEncodingGBK, LocalEncodingShiftJIS)},
+	OptionDryRun: Option{"", "--dry-run", "", OptionTypeFlagTrue, "", "",
+		"只列举需要处理的文件，不传输文件，输出预计的各类请求次数、流量和费用",
+		"only list the files to be processed without transferring them, and print the estimated requests of each type, traffic and cost"},
+	OptionPlan: Option{"", "--plan", "", OptionTypeFlagTrue, "", "",
+		"同--dry-run",
+		"the same as --dry-run"},
+	OptionPrice: Option{"", "--price", "", OptionTypeString, "", "",
+		fmt.Sprintf("--dry-run估算费用使用的价格，格式为put=0.01,get=0.01,traffic=0.5，请求的价格为每万次，流量的价格为每GB，默认值：put=%g,get=%g,traffic=%g", DefaultPutRequestPrice, DefaultGetRequestPrice, DefaultTrafficPrice),
+		fmt.Sprintf("the prices used by --dry-run to estimate the cost, the format is put=0.01,get=0.01,traffic=0.5, the requests are priced per 10,000 and the traffic is priced per GB, default is: put=%g,get=%g,traffic=%g", DefaultPutRequestPrice, DefaultGetRequestPrice, DefaultTrafficPrice)},
+	OptionQPS: Option{"", "--qps", "", OptionTypeInt64, "", "",
+		"每秒最多发送的请求数（包括列举和删除请求），用于避免触发bucket级别的限流",
+		"the max requests per second(including list and delete requests), to avoid tripping the bucket level rate limits"},
+	OptionOutputFailed: Option{"", "--output-failed", "", OptionTypeString, "", "",
+		"批量操作时将每个失败的key及其错误信息以csv格式记录到指定的文件中，该文件可以作为--retry-from的输入",
+		"record every failed key of the batch operation with its error in csv format to the file, the file can be the input of --retry-from"},
+	OptionRetryFrom: Option{"", "--retry-from", "", OptionTypeString, "", "",
+		"只处理--output-failed生成的文件中记录的失败的key，不再重新扫描所有的文件或object",
+		"only operate the failed keys recorded in the file generated by --output-failed, instead of rescanning all the files or objects"},
+	OptionContentMD5: Option{"", "--content-md5", "", OptionTypeFlagTrue, "", "",
+		"上传时计算每个object或者分片的MD5并携带Content-MD5请求头，数据在传输中损坏时oss会拒绝写入",
+		"compute the MD5 of every object or part when uploading and send the Content-MD5 header, oss rejects the write if the data is corrupted in transit"},
+	OptionLowMemory: Option{"", "--low-memory", "", OptionTypeFlagTrue, "", "",
+		"将列举的结果和比较的状态保存到临时的嵌入式数据库中而不是内存中，以较慢的速度换取对海量文件或object的支持",
+		"spool the listing results and the compare state to the temporary embedded database instead of memory, trading some speed for supporting a huge number of files or objects"},
+	OptionFilterRegion: Option{"", "--filter-region", "", OptionTypeString, "", "",
+		"列举云盒时只显示该region的云盒，如：cn-hangzhou",
+		"only show the cloud boxes in the region when listing cloud boxes, e.g., cn-hangzhou"},
+	OptionFilterOwner: Option{"", "--filter-owner", "", OptionTypeString, "", "",
+		"列举云盒时只显示owner为该值的云盒",
+		"only show the cloud boxes whose owner is the value when listing cloud boxes"},
+	OptionObjectSize: Option{"", "--object-size", "", OptionTypeString, "", "",
+		"bench命令使用的object大小，多个大小用逗号分隔，如：4K,4M,256M",
+		"the object sizes used by bench command separated by comma, e.g., 4K,4M,256M"},
+	OptionConcurrency: Option{"", "--concurrency", "", OptionTypeString, "", "",
+		"bench命令使用的并发数，多个并发数用逗号分隔，如：1,16,64",
+		"the concurrencies used by bench command separated by comma, e.g., 1,16,64"},
+	OptionDuration: Option{"", "--duration", "", OptionTypeString, "", "",
+		"bench命令中每个组合的PUT和GET各自的持续时间，如：30s、5m",
+		"the duration of PUT and GET respectively in each combination of bench command, e.g., 30s, 5m"},
+	OptionPrefix: Option{"", "--prefix", "", OptionTypeString, "", "",
+		"只处理名称以该前缀开头的object",
+		"only handle the objects whose name starts with the prefix"},
+	OptionOlderThan: Option{"", "--older-than", "", OptionTypeString, "", "",
+		"只处理早于该时长之前初始化的分片上传，如：7d、36h",
+		"only handle the multipart uploads initiated earlier than the duration ago, e.g., 7d, 36h"},
+	OptionOutput: Option{"", "--output", "", OptionTypeString, "", "",
+		"输出格式，取值为text、json或者yaml，默认为text",
+		"the output format, the value can be text, json or yaml, the default is text"},
+	OptionAbort: Option{"", "--abort", "", OptionTypeFlagTrue, "", "",
+		"取消列出的分片上传，并删除已上传的分片",
+		"abort the listed multipart uploads and delete the uploaded parts"},
+	OptionProcess: Option{"", "--process", "", OptionTypeString, "", "",
+		"图片处理、视频截帧等数据处理的参数，即x-oss-process的值，如：image/resize,w_300",
+		"the parameter of the data processing such as image processing and video snapshot, that is the value of x-oss-process, e.g., image/resize,w_300"},
+	OptionContentDisposition: Option{"", "--response-content-disposition", "", OptionTypeString, "", "",
+		"指定下载时返回的Content-Disposition头，如：attachment; filename=report.pdf",
+		"the Content-Disposition header returned when downloading, e.g., attachment; filename=report.pdf"},
+	OptionContentTypeOverride: Option{"", "--response-content-type", "", OptionTypeString, "", "",
+		"指定下载时返回的Content-Type头，如：application/octet-stream",
+		"the Content-Type header returned when downloading, e.g., application/octet-stream"},
+	OptionAction: Option{"", "--action", "", OptionTypeString, "", "",
+		"process命令的数据处理参数，即x-oss-process的值，如：image/resize,w_800/format,webp",
+		"the data processing parameter of process command, that is the value of x-oss-process, e.g., image/resize,w_800/format,webp"},
+	OptionSaveAs: Option{"", "--save-as", "", OptionTypeString, "", "",
+		"将处理结果保存为该object，格式为oss://bucket/object",
+		"save the processing result as the object, the format is oss://bucket/object"},
+	OptionOutputFile: Option{"-o", "--output-file", "", OptionTypeString, "", "",
+		"将结果写入到该本地文件",
+		"write the result to the local file"},
+	OptionNotifyTopic: Option{"", "--notify-topic", "", OptionTypeString, "", "",
+		"异步处理任务完成后发送通知的MNS主题",
+		"the MNS topic notified when the async processing job is done"},
+	OptionWait: Option{"", "--wait", "", OptionTypeFlagTrue, "", "",
+		"等待任务完成",
+		"wait for the job to be done"},
+	OptionPlaylistName: Option{"", "--playlist-name", "", OptionTypeString, "", "",
+		"LiveChannel的m3u8文件名称，默认为playlist.m3u8",
+		"the m3u8 object name of LiveChannel, default is playlist.m3u8"},
+	OptionFragDuration: Option{"", "--frag-duration", "", OptionTypeInt64, "1", "100",
+		"LiveChannel每个ts文件的时长，单位为秒",
+		"the duration of each ts object of LiveChannel, the unit is: s"},
+	OptionFragCount: Option{"", "--frag-count", "", OptionTypeInt64, "1", "100",
+		"LiveChannel的m3u8文件中包含的ts文件个数",
+		"the number of ts objects in the m3u8 object of LiveChannel"},
+	OptionChannelStatus: Option{"", "--channel-status", "", OptionTypeString, "", "",
+		"设置LiveChannel的状态，取值为：enabled、disabled",
+		"set the status of LiveChannel, the value is enabled or disabled"},
+	OptionCallbackURL: Option{"", "--callback-url", "", OptionTypeString, "", "",
+		"上传回调的应用服务器地址，上传完成后oss向该地址发送回调请求",
+		"the url of the application server, oss sends the callback request to it after uploading"},
+	OptionCallbackBody: Option{"", "--callback-body", "", OptionTypeString, "", "",
+		"上传回调的请求body，支持${bucket}、${object}等系统变量和--callback-var指定的自定义变量",
+		"the body of the upload callback request, supports system variables like ${bucket}, ${object} and custom variables of --callback-var"},
+	OptionCallbackVar: Option{"", "--callback-var", "", OptionTypeString, "", "",
+		"上传回调的自定义变量，格式为：key1=value1#key2=value2",
+		"the custom variables of the upload callback, the format is: key1=value1#key2=value2"},
+	OptionDirObject: Option{"", "--dirobject", "", OptionTypeFlagTrue, "", "",
+		"表示上传时只为空目录生成以/结尾的目录对象，下载时根据目录对象重建空目录",
+		"specifies that directory objects ending with / are generated only for empty directories when uploading, and empty directories are recreated from them when downloading"},
+	OptionMaxObjects: Option{"", "--max-objects", "", OptionTypeInt64, "", "",
+		"递归操作最多处理的文件或object数量，预先统计或者执行过程中超过后终止命令",
+		"the max number of files or objects operated by the recursive operation, the command aborts if it is exceeded by the pre-count or during the operation"},
+	OptionAssumeYes: Option{"-y", "--assume-yes", "", OptionTypeFlagTrue, "", "",
+		"全局选项，对rm -r、rm -b、set-acl -r、worm complete、live delete、listpart --abort、getallpartsize --abort等破坏性操作不进行询问提示，直接执行",
+		"global option, run the destructive operations like rm -r, rm -b, set-acl -r, worm complete, live delete, listpart --abort and getallpartsize --abort without asking user to confirm"},
+	OptionMaxBytes: Option{"", "--max-bytes", "", OptionTypeInt64, "", "",
+		"递归操作最多处理的字节数，预先统计或者执行过程中超过后终止命令",
+		"the max bytes of the files or objects operated by the recursive operation, the command aborts if it is exceeded by the pre-count or during the operation"},
+	OptionToTrash: Option{"", "--to-trash", "", OptionTypeFlagTrue, "", "",
+		"删除objects时将它们移动到同一bucket的回收站prefix下，而不是直接删除",
+		"move the objects into the trash prefix of the same bucket instead of deleting them"},
+	OptionTrashPrefix: Option{"", "--trash-prefix", "", OptionTypeString, "", "",
+		"回收站的prefix，默认值为.trash/",
+		"the prefix of the trash, the default value is .trash/"},
+	OptionTrashDays: Option{"", "--trash-days", "", OptionTypeInt64, "1", "",
+		"为回收站prefix设置生命周期规则，回收站中的objects在指定天数后被自动删除",
+		"set the lifecycle rule for the trash prefix, the objects in the trash are deleted automatically after the days"},
+	OptionTrashDate: Option{"", "--trash-date", "", OptionTypeString, "", "",
+		"只处理回收站中该日期删除的objects，格式为2006-01-02",
+		"only the objects removed to the trash on the date are processed, the format is 2006-01-02"},
+	OptionRetentionStatus: Option{"", "--status", "", OptionTypeAlternative, "locked/deletable", "",
+		"只输出指定保留状态的objects，取值为locked或deletable",
+		"only output the objects in the retention state, the value is locked or deletable"},
+	OptionMetaCache: Option{"", "--meta-cache", "", OptionTypeString, "", "",
+		"缓存objects meta的本地文件，etag和最后修改时间未变化的objects不再获取meta",
+		"the local file caching the meta of objects, the meta isn't got again for the objects whose etag and last modified time are not changed"},
+	OptionWhereTag: Option{"", "--where-tag", "", OptionTypeString, "", "",
+		"批量操作时只处理标签匹配的objects，格式为key=value，多个条件用#分隔，只指定key表示存在该标签即可",
+		"only the objects whose tags match are operated by the batch operation, the format is key=value, multiple conditions are separated by #, only the key means the object should have the tag"},
+	OptionCheckOnly: Option{"", "--check", "", OptionTypeFlagTrue, "", "",
+		"只检查是否有可用的更新，不进行升级",
+		"only check whether an update is available, do not update"},
+	OptionSearch: Option{"", "--search", "", OptionTypeString, "", "",
+		"在所有命令的摘要、详细说明、示例以及选项说明中搜索关键词，按匹配程度输出结果，多个关键词用空格分隔",
+		"search the keywords in the synopsis, detail, sample of all commands and the descriptions of options, output the results ranked by relevance, multiple keywords are separated by space"},
+	OptionOutDir: Option{"", "--out", "", OptionTypeString, "", "",
+		"生成文件的输出目录",
+		"the output directory of the generated files"},
+	OptionListen: Option{"", "--listen", "", OptionTypeString, "", "",
+		"守护进程监听的地址，格式为unix:///path/to/socket或tcp://host:port",
+		"the address listened by the daemon, the format is unix:///path/to/socket or tcp://host:port"},
+	OptionAppend: Option{"", "--append", "", OptionTypeFlagTrue, "", "",
+		"以追加方式上传，将本地文件超出目标Appendable object长度的数据通过AppendObject追加到object末尾",
+		"upload in append mode, the data of the local file beyond the length of the destination Appendable object is appended to the object by AppendObject"},
+	OptionFanout: Option{"", "--fanout", "", OptionTypeFlagTrue, "", "",
+		"读取一次本地文件，同时上传到多个目标object，第一个参数为本地文件，其后均为目标url",
+		"read the local file once and upload it to multiple destination objects concurrently, the first argument is the local file, and the others are the destination urls"},
+	OptionChecksum: Option{"", "--checksum", "", OptionTypeFlagTrue, "", "",
+		"比较oss上存储的crc64",
+		"compare the crc64 stored in oss"},
+	OptionContainerSize: Option{"", "--container-size", strconv.FormatInt(DefaultContainerSize, 10), OptionTypeInt64, "1", "",
+		"pack命令每个容器object的大小，单位为byte，默认值为1GB",
+		"the size of each container object of pack command, the unit is byte, default is 1GB"},
+	OptionHashDB: Option{"", "--hashdb", "", OptionTypeString, "", "",
+		"本地文件crc64数据库的路径，文件没有变化时使用其中的crc64，不再重新计算。hashdb命令的默认值为当前目录下的" + DefaultHashDBDir,
+		"the path of the crc64 database of the local files, the crc64 in it is used without calculating again if the file is unchanged. The default of hashdb command is " + DefaultHashDBDir + " in the current directory"},
+	OptionLastModifiedAfter: Option{"", "--last-modified-after", "", OptionTypeString, "", "",
+		"只处理最后修改时间不早于该时间的objects，时间可以为日期(2006-01-02，本地时间)、RFC3339时间、http日期或者unix时间戳",
+		"only handle the objects last modified at or after the time, the time can be date(2006-01-02, local time), RFC3339 time, http date or unix timestamp"},
+	OptionLastModifiedBefore: Option{"", "--last-modified-before", "", OptionTypeString, "", "",
+		"只处理最后修改时间早于该时间的objects，时间格式同--last-modified-after",
+		"only handle the objects last modified before the time, the format of the time is the same as --last-modified-after"},
+	OptionSort: Option{"", "--sort", "", OptionTypeAlternative, "", "",
+		"ls命令在客户端按指定字段对objects排序后输出，取值为size、mtime或name，默认升序；listpart命令列出分片上传时按age、size或name排序",
+		"sort the objects on the client side by the field before outputting for ls command, the value can be size, mtime or name, ascending by default; for listpart command listing the uploads, the value can be age, size or name"},
+	OptionReverse: Option{"", "--reverse", "", OptionTypeFlagTrue, "", "",
+		"与--sort一起使用，按降序输出",
+		"work with --sort, output in descending order"},
+	OptionHead: Option{"", "--head", "", OptionTypeInt64, "1", "",
+		"与--sort一起使用，只输出排序后的前N个objects",
+		"work with --sort, only output the first N objects after sorting"},
+	OptionPrint0: Option{"-0", "--print0", "", OptionTypeFlagTrue, "", "",
+		"只输出objects的cloud_url，每个cloud_url以NUL字符结尾，统计信息输出到stderr，可以通过管道安全地传给xargs -0或者--null-input",
+		"only output the cloud_url of the objects, every cloud_url ends with NUL character, the statistics are output to stderr, it can be piped safely to xargs -0 or --null-input"},
+	OptionNullInput: Option{"", "--null-input", "", OptionTypeFlagTrue, "", "",
+		"从stdin读取以NUL字符分隔的cloud_url（如ls --print0的输出），只处理其中的objects",
+		"read the NUL-delimited cloud_urls(like the output of ls --print0) from stdin, only operate the objects of them"},
+	OptionOrdered: Option{"", "--ordered", "", OptionTypeFlagTrue, "", "",
+		"按顺序将多个本地文件追加到同一个object，每次追加后校验object的长度，发现其他写入者时停止",
+		"append multiple local files to one object in order, the length of the object is verified after each append, stop if another writer is found"},
+	OptionFileList: Option{"", "--file-list", "", OptionTypeString, "", "",
+		"与--ordered一起使用，从文件中读取要追加的本地文件，每行一个",
+		"work with --ordered, read the local files to append from the file, one file per line"},
+	OptionJobID: Option{"", "--job-id", "", OptionTypeString, "", "",
+		"任务的名称，checkpoint记录在--checkpoint-dir下以该名称命名的子目录中，不同任务可以同时使用同一个--checkpoint-dir",
+		"the name of the job, the checkpoints are recorded in the sub dir of --checkpoint-dir named by it, so that different jobs can use the same --checkpoint-dir at the same time"},
+	OptionEncryptCheckpoint: Option{"", "--encrypt-checkpoint", "", OptionTypeFlagTrue, "", "",
+		fmt.Sprintf("写入checkpoint文件（其中包含object名称和upload id）时使用AES-256-GCM加密，读取时自动解密，密码从环境变量%s读取", CheckpointKeyEnv),
+		fmt.Sprintf("encrypt the checkpoint files(they contain the object names and the upload ids) by AES-256-GCM when they are written, and decrypt them when they are read, the passphrase is read from the environment variable %s", CheckpointKeyEnv)},
+	OptionCheckpointExpire: Option{"", "--checkpoint-expire-days", "", OptionTypeInt64, "1", "",
+		"命令开始时删除--checkpoint-dir及其任务子目录中超过N天未修改的checkpoint文件，正在运行的任务的目录会被跳过",
+		"remove the checkpoint files not modified for more than N days in --checkpoint-dir and the sub dirs of its jobs when the command starts, the dirs of the running jobs are skipped"},
+	OptionMaxIdleConns: Option{"", "--max-idle-conns", "", OptionTypeInt64, "1", "",
+		"http连接池保持的最大空闲连接数，缺省值为100，并发数较高时可以调大以减少重建连接",
+		"the max idle connections kept by the http connection pool, default value is 100, increase it for high concurrency to avoid reconnecting"},
+	OptionMaxConnsPerHost: Option{"", "--max-conns-per-host", "", OptionTypeInt64, "1", "",
+		"每个host的最大连接数（包括正在使用的连接），缺省不限制",
+		"the max connections per host including the ones in use, default is unlimited"},
+	OptionIdleConnTimeout: Option{"", "--idle-conn-timeout", "", OptionTypeInt64, "1", "",
+		"空闲连接的超时时间，单位为秒，缺省值与--read-timeout相同",
+		"the timeout of the idle connections in seconds, default value is the same as --read-timeout"},
+	OptionHTTP2: Option{"", "--http2", "", OptionTypeFlagTrue, "", "",
+		"服务端或代理支持时使用HTTP/2，此时--read-timeout只限制等待响应头的时间",
+		"use HTTP/2 if the server or the proxy supports it, --read-timeout only limits the time waiting for the response header then"},
+	OptionMaxQPS: Option{"", "--max-qps", "", OptionTypeInt64, "1", "",
+		"每秒最多发送的请求数，命令所有并发任务（包括重试）共同受该限制，用于避免触发bucket级别的限流",
+		"the max requests sent per second, all the concurrent tasks of the command including the retries are limited together, to avoid tripping the bucket level rate limits"},
+	OptionCopyTags: Option{"", "--tags", "", OptionTypeFlagTrue, "", "",
+		"同时复制源object的tags，源object没有tags时删除目标object的tags",
+		"copy the tags of the source object too, the tags of the destination object are deleted if the source object has no tags"},
+	OptionCopyACL: Option{"", "--copy-acl", "", OptionTypeFlagTrue, "", "",
+		"同时复制源object的acl，默认保留目标object的acl",
+		"copy the acl of the source object too, the acl of the destination object is kept by default"},
+	OptionRetainUntil: Option{"", "--retain-until", "", OptionTypeString, "", "",
+		"上传或者拷贝的objects的保留截止时间，在此之前objects不能被删除或者覆盖，取值为日期(2006-01-02)或者RFC3339格式的时间，只支持s3://的目标",
+		"the time until which the objects uploaded or copied are retained, they can't be deleted or overwritten before it, the value can be a date(2006-01-02) or RFC3339 time, only s3:// destinations support it"},
+	OptionRetentionMode: Option{"", "--retention-mode", "", OptionTypeString, "", "",
+		"与--retain-until一起使用，保留模式，取值为GOVERNANCE或者COMPLIANCE，默认为GOVERNANCE",
+		"work with --retain-until, the retention mode, the value can be GOVERNANCE or COMPLIANCE, the default is GOVERNANCE"},
+	OptionLegalHold: Option{"", "--legal-hold", "", OptionTypeFlagTrue, "", "",
+		"对上传或者拷贝的objects设置法律保留(legal hold)，只支持s3://的目标",
+		"put the objects uploaded or copied on legal hold, only s3:// destinations support it"},
+	OptionCDNDomain: Option{"", "--cdn-domain", "", OptionTypeString, "", "",
+		"prefetch命令通过该CDN加速域名请求objects，可以带有http://或者https://前缀，默认为https",
+		"the CDN domain through which prefetch requests the objects, it can start with http:// or https://, the default is https"},
+	OptionAlgorithm: Option{"", "--algorithm", "", OptionTypeString, "", "",
+		"checksum命令的校验和算法，取值为sha256或者crc64，generate默认为sha256，verify默认根据清单判断",
+		"the checksum algorithm of checksum command, the value can be sha256 or crc64, the default of generate is sha256, verify decides it by the manifest"},
+	OptionDailyBytes: Option{"", "--daily-bytes", "", OptionTypeString, "", "",
+		"restore-campaign命令每天解冻的objects的字节数限额，可以带有KB、MB、GB、TB单位，如10TB",
+		"the budget of the bytes of the objects restored each day by restore-campaign, it can have the unit of KB, MB, GB or TB, e.g., 10TB"},
+	OptionStateFile: Option{"", "--state-file", "", OptionTypeString, "", "",
+		"restore-campaign命令保存进度的状态文件，默认为" + CheckpointDir + "目录下根据cloud_url生成的文件",
+		"the state file saving the progress of restore-campaign, the default is the file named by cloud_url in " + CheckpointDir},
+	OptionCRCRetryTimes: Option{"", "--crc-retry-times", strconv.Itoa(CRCRetryTimes), OptionTypeInt64, strconv.FormatInt(MinCRCRetryTimes, 10), strconv.FormatInt(MaxCRCRetryTimes, 10),
+		fmt.Sprintf("下载的数据crc64与object不一致时，删除本地数据后重新下载的次数，默认值：%d，取值范围：%d-%d", CRCRetryTimes, MinCRCRetryTimes, MaxCRCRetryTimes),
+		fmt.Sprintf("the times of downloading again after the local data is removed when the crc64 of the downloaded data doesn't match the object(default: %d), value range is: %d-%d", CRCRetryTimes, MinCRCRetryTimes, MaxCRCRetryTimes)},
+	OptionDedup: Option{"", "--dedup", "", OptionTypeFlagTrue, "", "",
+		"上传时跳过目的端已经存在相同内容的object的文件，已上传的objects的crc64记录在--hashdb指定的数据库中",
+		"skip the files whose content already exists in the destination object when uploading, the crc64 of the uploaded objects is recorded in the database of --hashdb"},
+	OptionEvent: Option{"", "--event", "", OptionTypeString, "", "",
+		"notify-test命令触发的事件，如ObjectCreated:PutObject",
+		"the event triggered by notify-test, e.g., ObjectCreated:PutObject"},
+	OptionKey: Option{"", "--key", "", OptionTypeString, "", "",
+		"notify-test命令的探测object的名称",
+		"the name of the probe object of notify-test"},
+	OptionExpectObject: Option{"", "--expect-object", "", OptionTypeString, "", "",
+		"notify-test命令等待下游目标创建或者更新的object",
+		"the object which notify-test waits for the downstream target to create or update"},
+	OptionExpectURL: Option{"", "--expect-url", "", OptionTypeString, "", "",
+		"notify-test命令等待返回2xx的url，用于查询下游目标是否收到事件",
+		"the url which notify-test waits to return 2xx, for querying whether the downstream target receives the event"},
+	OptionSpec: Option{"", "--spec", "", OptionTypeString, "", "",
+		"yaml格式的任务描述文件，声明cp、sync或rm命令的参数和选项，命令行指定的选项优先",
+		"the yaml job spec file declaring the arguments and options of cp, sync or rm, the options specified in the command line take precedence"},
+	OptionAllow: Option{"", "--allow", "", OptionTypeString, "", "",
+		"policy generate命令授予的权限，取值为list、read、write或read-write，多个权限以逗号分隔",
+		"the permissions granted by policy generate, the value is list, read, write or read-write, separated by commas"},
+	OptionPrincipal: Option{"", "--principal", "", OptionTypeString, "", "",
+		"bucket policy授权的账号或者RAM用户的uid，多个uid以逗号分隔，*表示所有人",
+		"the uids of the accounts or the RAM users granted by the bucket policy, separated by commas, * means everyone"},
+	OptionRAMPolicy: Option{"", "--ram", "", OptionTypeFlagTrue, "", "",
+		"policy generate命令生成RAM policy，而不是bucket policy",
+		"policy generate generates the RAM policy instead of the bucket policy"},
+	OptionApply: Option{"", "--apply", "", OptionTypeFlagTrue, "", "",
+		"policy generate命令显示差异并确认后，将生成的statements追加到bucket的policy中",
+		"policy generate appends the generated statements to the policy of the bucket after showing the difference and the confirmation"},
+	OptionFlushInterval: Option{"", "--flush-interval", "", OptionTypeInt64, "0", "",
+		fmt.Sprintf("appendfromfile从stdin读取时，数据最多缓冲该秒数后追加，0表示只在缓冲满--part-size或者stdin结束时追加，默认值为%d", DefaultFlushInterval),
+		fmt.Sprintf("when appendfromfile reads from stdin, the data is buffered for the seconds at most before it's appended, 0 means only appending when --part-size is buffered or at the end of stdin, the default is %d", DefaultFlushInterval)},
+	OptionKeepParts: Option{"", "--keep-parts", "", OptionTypeFlagTrue, "", "",
+		"分片上传失败或者被取消时保留已上传的分片和断点信息，以便再次执行时继续上传，默认删除已上传的分片",
+		"keep the uploaded parts and the checkpoint when the multipart upload fails or is canceled, so that it can be resumed by running again, the uploaded parts are aborted by default"},
+	OptionSeparator: Option{"", "--separator", "", OptionTypeString, "", "",
+		"appendfromfile追加多个文件时，在相邻文件之间追加的分隔符，支持\\n、\\r、\\t转义",
+		"the separator appended between the adjacent files when appendfromfile appends multiple files, the escapes \\n, \\r and \\t are supported"},
+	OptionAsOf: Option{"", "--as-of", "", OptionTypeString, "", "",
+		"从开启版本控制的bucket下载objects在该时间点的版本，时间可以是日期(2006-01-02)、RFC3339时间、http date或者unix时间戳",
+		"download the versions of the objects which were live at the time from the versioned bucket, the time can be date(2006-01-02), RFC3339 time, http date or unix timestamp"},
+	OptionVerifyCRC: Option{"", "--verify-crc", "", OptionTypeFlagTrue, "", "",
+		"appendfromfile追加完成后，校验object的crc64与追加前的object和整个本地文件合并的crc64是否一致，不一致时报错",
+		"after appendfromfile appends the file, verify the crc64 of the object is the one combined from the object before appending and the whole local file, report error if they mismatch"},
+	OptionProgressFile: Option{"", "--progress-file", "", OptionTypeString, "", "",
+		"cp或sync执行过程中定期将进度以json格式写入该文件，包括已完成和总的字节数、文件数、正在传输的文件、错误数以及预计剩余时间",
+		"write the progress of cp or sync to the json file periodically, including the done and total bytes and files, the files being transferred, the error count and the ETA"},
+	OptionPosition: Option{"", "--position", "", OptionTypeInt64, "0", strconv.FormatInt(MaxAppendObjectSize, 10),
+		"appendfromfile期望的追加位置，即object当前的长度，与实际位置不一致时报错并以退出码8退出",
+		"the expected position of appendfromfile to append at, which is the current length of the object, report error and exit with 8 if it's not the actual position"},
+	OptionOutputFormat: Option{"", "--output-format", "", OptionTypeString, "", "",
+		"所有命令的全局选项，结果的输出格式，取值为table、json或者yaml，默认为table，优先于命令的--output，只有支持--output的命令支持json和yaml",
+		"the global option of all commands, the output format of the result, the value can be table, json or yaml, the default is table, it takes precedence over --output of the command, json and yaml are only supported by the commands with --output"},
+	OptionFields: Option{"", "--fields", "", OptionTypeString, "", "",
+		"index build保存到索引的字段，取值为meta、tags、size、mtime的组合，以逗号分隔，默认为size,mtime",
+		"the fields saved to the index by index build, the value is the comma separated combination of meta, tags, size and mtime, the default is size,mtime"},
+	OptionPartCRC: Option{"", "--part-crc", "", OptionTypeFlagTrue, "", "",
+		"分片上传时在发送每个分片的同时计算分片的crc64，合并后校验object的crc64，文件只读取一次，与--disable-crc64互斥",
+		"compute the crc64 of each part while sending it in multipart upload, and verify the crc64 of the object by combining them, so the file is read only once, it can't be used with --disable-crc64"},
 }
 
 func (T *Option) getHelp(language string) string {
-	switch strings.ToLower(language) {
-	case LEnglishLanguage:
+	if help, ok := getCatalog(language).options[strings.TrimPrefix(T.nameAlias, "--")]; ok {
+		return help
+	}
+	language = strings.ToLower(language)
+	if _, ok := loadedCatalogs[language]; ok || language == LEnglishLanguage {
 		return T.helpEnglish
-	default:
-		return T.helpChinese
 	}
+	return T.helpChinese
 }
 
 // OptionMapType is the type for ossutil got options
@@ -0,0 +1,167 @@
+package lib
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// OssIgnoreFileName is the file listing the patterns to be excluded by recursive upload, the syntax is
+// the same as .gitignore, and the patterns are relative to the directory where the file is located
+const OssIgnoreFileName = ".ossignore"
+
+type ignoreRule struct {
+	base     string   // the directory of the .ossignore relative to the root, "" for the root
+	segments []string // the pattern split by "/", "**" matches zero or more directories
+	negate   bool
+	dirOnly  bool
+}
+
+// ossIgnore loads the .ossignore files under the root directory lazily and matches the paths
+// relative to the root with the rules of the .ossignore files in the parent directories
+type ossIgnore struct {
+	root  string
+	mu    sync.Mutex
+	rules map[string][]ignoreRule
+}
+
+func newOssIgnore(root string) *ossIgnore {
+	return &ossIgnore{root: root, rules: map[string][]ignoreRule{}}
+}
+
+func parseIgnoreRule(base, line string) (ignoreRule, bool) {
+	rule := ignoreRule{base: base}
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, "\\ ") {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule, false
+	}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule, false
+	}
+
+	// the pattern without slash matches the name at any level below the base
+	if strings.Contains(line, "/") {
+		rule.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+	} else {
+		rule.segments = []string{"**", line}
+	}
+	return rule, true
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			pattern = pattern[1:]
+			if len(pattern) == 0 {
+				return len(name) > 0
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func (rule ignoreRule) match(relPath string, isDir bool) bool {
+	if rule.dirOnly && !isDir {
+		return false
+	}
+	if rule.base != "" {
+		if !strings.HasPrefix(relPath, rule.base+"/") {
+			return false
+		}
+		relPath = relPath[len(rule.base)+1:]
+	}
+	return matchSegments(rule.segments, strings.Split(relPath, "/"))
+}
+
+// dirRules returns the rules of the .ossignore in the directory relative to the root
+func (oi *ossIgnore) dirRules(dir string) []ignoreRule {
+	oi.mu.Lock()
+	defer oi.mu.Unlock()
+	if rules, ok := oi.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	f, err := os.Open(filepath.Join(oi.root, filepath.FromSlash(dir), OssIgnoreFileName))
+	if err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if rule, ok := parseIgnoreRule(dir, scanner.Text()); ok {
+				rules = append(rules, rule)
+			}
+		}
+		f.Close()
+		LogInfo("load %s in %s, rule count:%d\n", OssIgnoreFileName, filepath.Join(oi.root, dir), len(rules))
+	}
+	oi.rules[dir] = rules
+	return rules
+}
+
+// match checks the path relative to the root, the separator of the path is "/".
+// The rules in deeper directories take precedence, and the last matching rule decides.
+// The parent directories are not checked, they should be skipped when walking.
+func (oi *ossIgnore) match(relPath string, isDir bool) bool {
+	if oi == nil {
+		return false
+	}
+	dirs := []string{""}
+	for i := 0; i < len(relPath); i++ {
+		if relPath[i] == '/' {
+			dirs = append(dirs, relPath[:i])
+		}
+	}
+
+	ignored := false
+	for _, dir := range dirs {
+		for _, rule := range oi.dirRules(dir) {
+			if rule.match(relPath, isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// isIgnored checks the path and all its parent directories
+func (oi *ossIgnore) isIgnored(relPath string, isDir bool) bool {
+	if oi == nil {
+		return false
+	}
+	relPath = strings.TrimSuffix(relPath, "/")
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if oi.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return oi.match(relPath, isDir)
+}
@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestOssIgnoreRules(c *C) {
+	_, ok := parseIgnoreRule("", "# comment")
+	c.Assert(ok, Equals, false)
+	_, ok = parseIgnoreRule("", "   ")
+	c.Assert(ok, Equals, false)
+
+	rule, ok := parseIgnoreRule("", "node_modules/")
+	c.Assert(ok, Equals, true)
+	c.Assert(rule.dirOnly, Equals, true)
+	c.Assert(rule.match("node_modules", true), Equals, true)
+	c.Assert(rule.match("a/b/node_modules", true), Equals, true)
+	c.Assert(rule.match("node_modules", false), Equals, false)
+
+	rule, _ = parseIgnoreRule("", "*.log")
+	c.Assert(rule.match("a.log", false), Equals, true)
+	c.Assert(rule.match("dir/a.log", false), Equals, true)
+	c.Assert(rule.match("a.txt", false), Equals, false)
+
+	rule, _ = parseIgnoreRule("", "/build")
+	c.Assert(rule.match("build", true), Equals, true)
+	c.Assert(rule.match("src/build", true), Equals, false)
+
+	rule, _ = parseIgnoreRule("", "docs/**/*.tmp")
+	c.Assert(rule.match("docs/a.tmp", false), Equals, true)
+	c.Assert(rule.match("docs/x/y/a.tmp", false), Equals, true)
+	c.Assert(rule.match("src/docs/a.tmp", false), Equals, false)
+
+	rule, _ = parseIgnoreRule("", "out/**")
+	c.Assert(rule.match("out", true), Equals, false)
+	c.Assert(rule.match("out/a", false), Equals, true)
+
+	rule, _ = parseIgnoreRule("sub", "*.bak")
+	c.Assert(rule.match("sub/a.bak", false), Equals, true)
+	c.Assert(rule.match("a.bak", false), Equals, false)
+
+	rule, _ = parseIgnoreRule("", "!keep.log")
+	c.Assert(rule.negate, Equals, true)
+	rule, _ = parseIgnoreRule("", "\\!name")
+	c.Assert(rule.negate, Equals, false)
+	c.Assert(rule.match("!name", false), Equals, true)
+
+	var disabled *ossIgnore
+	c.Assert(disabled.match("a.log", false), Equals, false)
+	c.Assert(disabled.isIgnored("a/b.log", false), Equals, false)
+}
+
+func (s *OssutilCommandSuite) TestOssIgnoreFileList(c *C) {
+	dir := "ossutil-test-ossignore-" + randLowStr(5)
+	for _, name := range []string{"node_modules", "sub", "build"} {
+		c.Assert(os.MkdirAll(filepath.Join(dir, name), 0755), IsNil)
+	}
+	s.createFile(filepath.Join(dir, OssIgnoreFileName), "# ignore\nnode_modules/\n*.log\n!keep.log\n/build\n", c)
+	s.createFile(filepath.Join(dir, "sub", OssIgnoreFileName), "*.tmp\n", c)
+	for _, name := range []string{"a.txt", "a.log", "keep.log", "node_modules/m.js", "build/out.bin", "sub/b.txt", "sub/b.tmp", "sub/c.log"} {
+		s.createFile(filepath.Join(dir, filepath.FromSlash(name)), "content", c)
+	}
+
+	ignore := newOssIgnore(dir)
+	c.Assert(ignore.isIgnored("node_modules/m.js", false), Equals, true)
+	c.Assert(ignore.isIgnored("sub/b.tmp", false), Equals, true)
+	c.Assert(ignore.isIgnored("b.tmp", false), Equals, false)
+	c.Assert(ignore.isIgnored("keep.log", false), Equals, false)
+	c.Assert(ignore.isIgnored("sub/", true), Equals, false)
+
+	getFiles := func(cc *CopyCommand) []string {
+		chFiles := make(chan fileInfoType, 100)
+		c.Assert(cc.getFileList(dir+string(os.PathSeparator), chFiles), IsNil)
+		close(chFiles)
+		files := []string{}
+		for file := range chFiles {
+			files = append(files, filepath.ToSlash(file.filePath))
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	cc := &CopyCommand{}
+	c.Assert(getFiles(cc), DeepEquals, []string{OssIgnoreFileName, "a.txt", "keep.log", "sub/", "sub/" + OssIgnoreFileName, "sub/b.txt"})
+
+	cc.cpOption.disableOssIgnore = true
+	c.Assert(len(getFiles(cc)), Equals, 13)
+
+	os.RemoveAll(dir)
+}
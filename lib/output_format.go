@@ -0,0 +1,145 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// outputFormat is the format of the result printed by the commands with --output, the table format
+// is the text output of the command, json and yaml print the structured result
+type outputFormat string
+
+// getOutputFormat returns the format of the global --output-format or --output of the command,
+// --output-format takes precedence, the default is table
+func getOutputFormat(options OptionMapType) (outputFormat, error) {
+	name := "--output-format"
+	value, _ := GetString(OptionOutputFormat, options)
+	if value == "" {
+		name = "--output"
+		value, _ = GetString(OptionOutput, options)
+	}
+	switch strings.ToLower(value) {
+	case "", OutputFormatText, OutputFormatTable:
+		return OutputFormatTable, nil
+	case OutputFormatJSON:
+		return OutputFormatJSON, nil
+	case OutputFormatYAML:
+		return OutputFormatYAML, nil
+	}
+	return "", fmt.Errorf("invalid %s: %s, the value should be %s, %s or %s", name, value, OutputFormatTable, OutputFormatJSON, OutputFormatYAML)
+}
+
+// checkOutputFormat checks the global --output-format and --output of the command, json and yaml
+// are only supported by the commands with --output
+func (cmd *Command) checkOutputFormat() error {
+	format, err := getOutputFormat(cmd.options)
+	if err != nil {
+		return err
+	}
+	if format.structured() && FindPos(OptionOutput, cmd.validOptionNames) == -1 {
+		return CommandError{cmd.name, fmt.Sprintf("the command doesn't support --output-format %s", format)}
+	}
+	return nil
+}
+
+func (f outputFormat) structured() bool {
+	return f == OutputFormatJSON || f == OutputFormatYAML
+}
+
+// print prints the value as indented json or a yaml document
+func (f outputFormat) print(v interface{}) error {
+	if f != OutputFormatYAML {
+		return printJSON(v)
+	}
+	data, err := marshalYAML(v)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(data))
+	return nil
+}
+
+// line returns the value as a single json line, or a yaml document starting with ---, so that the
+// streamed entries can be processed one by one
+func (f outputFormat) line(v interface{}) string {
+	if f != OutputFormatYAML {
+		return jsonLine(v)
+	}
+	data, _ := marshalYAML(v)
+	return "---\n" + strings.TrimSuffix(string(data), "\n")
+}
+
+// textWriter returns the writer of the text output such as the progress, it's stderr for json and
+// yaml, so that stdout only has the structured result
+func (f outputFormat) textWriter() io.Writer {
+	if f.structured() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// marshalYAML marshals the value by its json tags, the keys are kept in the order of the json,
+// so that the json and yaml outputs have the same fields
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	value, err := decodeYAMLValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(value)
+}
+
+// decodeYAMLValue decodes the next json value, the objects are decoded as yaml.MapSlice to keep
+// the order of the keys
+func decodeYAMLValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := yaml.MapSlice{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeYAMLValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, yaml.MapItem{Key: key, Value: value})
+		}
+		_, err = decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeYAMLValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	if number, ok := token.(json.Number); ok {
+		if i, err := number.Int64(); err == nil {
+			return i, nil
+		}
+		return number.Float64()
+	}
+	return token, nil
+}
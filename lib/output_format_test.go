@@ -0,0 +1,290 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	. "gopkg.in/check.v1"
+	"gopkg.in/yaml.v2"
+)
+
+func (s *OssutilCommandSuite) TestOutputFormat(c *C) {
+	format := "yaml"
+	output := "json"
+	empty := ""
+
+	// --output-format takes precedence over --output of the command
+	f, err := getOutputFormat(OptionMapType{OptionOutputFormat: &format, OptionOutput: &output})
+	c.Assert(err, IsNil)
+	c.Assert(f, Equals, outputFormat(OutputFormatYAML))
+	f, err = getOutputFormat(OptionMapType{OptionOutputFormat: &empty, OptionOutput: &output})
+	c.Assert(err, IsNil)
+	c.Assert(f, Equals, outputFormat(OutputFormatJSON))
+	f, err = getOutputFormat(OptionMapType{OptionOutput: &empty})
+	c.Assert(err, IsNil)
+	c.Assert(f, Equals, outputFormat(OutputFormatTable))
+	f, err = getOutputFormat(OptionMapType{})
+	c.Assert(err, IsNil)
+	c.Assert(f, Equals, outputFormat(OutputFormatTable))
+	c.Assert(f.structured(), Equals, false)
+	output = "text"
+	f, err = getOutputFormat(OptionMapType{OptionOutput: &output})
+	c.Assert(err, IsNil)
+	c.Assert(f, Equals, outputFormat(OutputFormatTable))
+	output = "xml"
+	_, err = getOutputFormat(OptionMapType{OptionOutput: &output})
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "invalid --output: xml"), Equals, true)
+	format = "xml"
+	_, err = getOutputFormat(OptionMapType{OptionOutputFormat: &format})
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "invalid --output-format: xml"), Equals, true)
+
+	// the yaml keeps the order and the names of the json fields
+	data, err := marshalYAML(cpSummary{Operation: "upload", TotalNum: 2, AverageSpeed: 1024})
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(string(data), "operation: upload\ntotalNum: 2\ntotalSize: 0\n"), Equals, true, Commentf("%s", data))
+	c.Assert(strings.Contains(string(data), "\nerror:"), Equals, false)
+	c.Assert(outputFormat(OutputFormatYAML).line(map[string]string{"url": "oss://bucket/a"}), Equals, "---\nurl: oss://bucket/a")
+	c.Assert(outputFormat(OutputFormatJSON).line(map[string]string{"url": "oss://bucket/a"}), Equals, `{"url":"oss://bucket/a"}`)
+
+	// --output is only supported by the commands with structured output
+	output = "json"
+	str := "ak"
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/object"}, OptionMapType{
+		OptionEndpoint:        &str,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionOutput:          &output,
+	})
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "does not support option: \"output\""), Equals, true)
+
+	// --output-format is accepted by all the commands, json and yaml only by the commands with --output
+	format = "json"
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/object"}, OptionMapType{
+		OptionEndpoint:        &str,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionOutputFormat:    &format,
+	})
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "doesn't support --output-format json"), Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestDuOutputFormat(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["uploads"]; ok {
+			fmt.Fprint(w, `<ListMultipartUploadsResult><IsTruncated>false</IsTruncated></ListMultipartUploadsResult>`)
+			return
+		}
+		fmt.Fprint(w, `<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>
+<Contents><Key>data/a</Key><LastModified>2024-01-02T03:04:05.000Z</LastModified><Size>100</Size><StorageClass>Standard</StorageClass></Contents>
+<Contents><Key>data/b</Key><LastModified>2024-01-03T03:04:05.000Z</LastModified><Size>300</Size><StorageClass>Archive</StorageClass></Contents>
+</ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	resultPath := "ossutil-test-du-output-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	format := "yaml"
+	top := "1"
+	blockSize := "KB"
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionOutput:          &format,
+		OptionTop:             &top,
+		OptionBlockSize:       &blockSize,
+	}
+	_, err = cm.RunCommand("du", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+
+	// stdout only has the yaml result, the sizes are in bytes regardless of --block-size
+	var result struct {
+		URL            string `yaml:"url"`
+		StorageClasses []struct {
+			StorageClass string  `yaml:"storageClass"`
+			ObjectCount  int64   `yaml:"objectCount"`
+			Size         int64   `yaml:"size"`
+			Percent      float64 `yaml:"percent"`
+		} `yaml:"storageClasses"`
+		ObjectCount int64 `yaml:"objectCount"`
+		TotalSize   int64 `yaml:"totalSize"`
+		TopObjects  []struct {
+			URL  string `yaml:"url"`
+			Size int64  `yaml:"size"`
+		} `yaml:"topObjects"`
+	}
+	data := s.readFile(resultPath, c)
+	c.Assert(yaml.Unmarshal([]byte(data), &result), IsNil, Commentf("%s", data))
+	c.Assert(result.URL, Equals, "oss://bucket/data/")
+	c.Assert(result.ObjectCount, Equals, int64(2))
+	c.Assert(result.TotalSize, Equals, int64(400))
+	c.Assert(len(result.StorageClasses), Equals, 2)
+	c.Assert(result.StorageClasses[0].StorageClass, Equals, StorageArchive)
+	c.Assert(result.StorageClasses[0].Percent, Equals, float64(75))
+	c.Assert(len(result.TopObjects), Equals, 1)
+	c.Assert(result.TopObjects[0].URL, Equals, "oss://bucket/data/b")
+
+	// the global --output-format is parsed from the command line, the elapsed time isn't printed to
+	// stdout after the structured result
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"ossutil", "du", "oss://bucket/data/", "-e", server.URL, "-i", "ak", "-k", "ak", "--force-path-style", "--output-format", "json"}
+	c.Assert(testResultFile.Truncate(0), IsNil)
+	_, err = testResultFile.Seek(0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(ParseAndRunCommand(), IsNil)
+	data = s.readFile(resultPath, c)
+	c.Assert(strings.Contains(data, "elapsed"), Equals, false)
+	c.Assert(json.Unmarshal([]byte(data), &map[string]interface{}{}), IsNil, Commentf("%s", data))
+
+	// the estimation doesn't support the structured output
+	sample := "10"
+	options[OptionSample] = &sample
+	noTop := "0"
+	options[OptionTop] = &noTop
+	_, err = cm.RunCommand("du", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestCopyOutputFormat(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "PUT" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum(body, crc64ECMATable), 10))
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-output-format-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	s.createFile(filepath.Join(dir, "a"), strings.Repeat("a", 100), c)
+	s.createFile(filepath.Join(dir, "b"), strings.Repeat("b", 200), c)
+
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	routines := "2"
+	threshold := "1048576"
+	format := "json"
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	defer os.RemoveAll(cpDir)
+	defer os.RemoveAll(outputDir)
+	options := OptionMapType{
+		OptionEndpoint:         &server.URL,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRecursion:        &recursive,
+		OptionForce:            &force,
+		OptionRoutines:         &routines,
+		OptionBigFileThreshold: &threshold,
+		OptionCheckpointDir:    &cpDir,
+		OptionOutputDir:        &outputDir,
+		OptionOutput:           &format,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/"}, options)
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, IsNil)
+
+	// the progress is printed to stderr, stdout only has the summary
+	var summary cpSummary
+	data := s.readFile(resultPath, c)
+	c.Assert(json.Unmarshal([]byte(data), &summary), IsNil, Commentf("%s", data))
+	c.Assert(summary.Operation, Equals, "upload")
+	c.Assert(summary.TotalNum, Equals, int64(2))
+	c.Assert(summary.TotalSize, Equals, int64(300))
+	c.Assert(summary.OKNum, Equals, int64(2))
+	c.Assert(summary.ErrorNum, Equals, int64(0))
+	c.Assert(summary.TransferSize, Equals, int64(300))
+	c.Assert(summary.Error, Equals, "")
+	os.Remove(resultPath)
+}
+
+func (s *OssutilCommandSuite) TestCopyToStdoutTextWriter(c *C) {
+	content := strings.Repeat("a", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Header().Set("Last-Modified", "Tue, 02 Jan 2024 03:04:05 GMT")
+		w.Header().Set("ETag", `"etag"`)
+		if r.Method == "GET" {
+			fmt.Fprint(w, content)
+		}
+	}))
+	defer server.Close()
+
+	// stdout is a pipe, the object is downloaded to it by the path of its fd
+	reader, stdoutFile, err := os.Pipe()
+	c.Assert(err, IsNil)
+	chData := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(reader)
+		chData <- string(data)
+	}()
+	stderrPath := "ossutil-test-stderr-" + randLowStr(8)
+	stderrFile, err := os.Create(stderrPath)
+	c.Assert(err, IsNil)
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdoutFile, stderrFile
+	defer func() {
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+		stderrFile.Close()
+		os.Remove(stderrPath)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	force := true
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	defer os.RemoveAll(cpDir)
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionForce:           &force,
+		OptionCheckpointDir:   &cpDir,
+	}
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/a", fmt.Sprintf("/dev/fd/%d", stdoutFile.Fd())}, options)
+	c.Assert(err, IsNil)
+
+	// stdout only has the object, the progress is written to stderr without replacing os.Stdout
+	c.Assert(os.Stdout == stdoutFile, Equals, true)
+	c.Assert(copyCommand.command.textOut() == stderrFile, Equals, true)
+	stdoutFile.Close()
+	c.Assert(<-chData, Equals, content)
+	c.Assert(strings.Contains(s.readFile(stderrPath, c), "average speed"), Equals, true)
+}
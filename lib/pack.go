@@ -0,0 +1,1099 @@
+package lib
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChinesePack = SpecText{
+	synopsisText: "将prefix下的小objects合并为容器object，并生成索引清单",
+
+	paramText: "src_cloud_url pack_cloud_url [options]",
+
+	syntaxText: `
+    ossutil pack oss://bucket/prefix oss://bucket/pack_dir/ [--container-size size] [--delete] [-y] [-j jobs] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    该命令将src_cloud_url下的小objects依次读取并合并写入pack_cloud_url目录下的容器object
+    (container-000001.pack、container-000002.pack...)，并生成清单object manifest.jsonl，清单的
+    每一行记录一个object的key、所在的容器、偏移、大小、etag、crc64、修改时间，以及Content-Type等
+    元信息、用户自定义元信息、存储类型、ACL和标签。
+    对于冷数据中大量的小objects，合并后可以大幅减少请求费用和列举时间。
+
+    大于16MB的objects不会被合并，命令结束时输出跳过的个数。每个容器object的大小达到
+    --container-size(默认1GB)后开始写入下一个容器。清单object上传成功后，如果指定了--delete选项，
+    命令会删除已合并的原objects，删除前会询问用户确认，指定-y选项时不询问。
+
+    合并后的objects可以通过unpack命令恢复，或者通过pack-get命令读取其中的单个object。
+
+用法：
+
+    ossutil pack oss://bucket/prefix oss://bucket/pack_dir/ [--container-size size] [--delete]
+`,
+
+	sampleText: `
+    1) 将logs/2020/下的objects合并到packs/logs-2020/目录
+    ossutil pack oss://bucket1/logs/2020/ oss://bucket1/packs/logs-2020/
+
+    2) 合并后删除原objects，不询问确认
+    ossutil pack oss://bucket1/logs/2020/ oss://bucket1/packs/logs-2020/ --delete -y
+`,
+}
+
+var specEnglishPack = SpecText{
+	synopsisText: "Merge the small objects under the prefix into container objects with the manifest index",
+
+	paramText: "src_cloud_url pack_cloud_url [options]",
+
+	syntaxText: `
+    ossutil pack oss://bucket/prefix oss://bucket/pack_dir/ [--container-size size] [--delete] [-y] [-j jobs] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The command reads the small objects under src_cloud_url, and merges them into the container objects
+    (container-000001.pack, container-000002.pack...) under the directory pack_cloud_url, and generates
+    the manifest object manifest.jsonl, each line of the manifest records the key, the container, the
+    offset, the size, the etag, the crc64, the modified time, and the metadata such as Content-Type, the
+    user metadata, the storage class, the acl and the tags of an object. For the
+    huge number of small objects of the cold data, packing them cuts the request cost and the listing
+    time greatly.
+
+    The objects larger than 16MB are not packed, the number of the skipped objects is printed when the
+    command ends. The next container is started after the size of the container reaches
+    --container-size(default is 1GB). After the manifest object is uploaded, if --delete option is
+    specified, the command removes the packed objects, the user is asked to confirm before removing
+    unless -y is specified.
+
+    The packed objects can be restored by unpack command, or a single object can be read by pack-get
+    command.
+
+Usage:
+
+    ossutil pack oss://bucket/prefix oss://bucket/pack_dir/ [--container-size size] [--delete]
+`,
+
+	sampleText: `
+    1) pack the objects under logs/2020/ to the directory packs/logs-2020/
+    ossutil pack oss://bucket1/logs/2020/ oss://bucket1/packs/logs-2020/
+
+    2) remove the packed objects without asking
+    ossutil pack oss://bucket1/logs/2020/ oss://bucket1/packs/logs-2020/ --delete -y
+`,
+}
+
+var specChineseUnpack = SpecText{
+	synopsisText: "将pack命令合并的objects恢复为独立的objects",
+
+	paramText: "pack_cloud_url [dest_cloud_url] [options]",
+
+	syntaxText: `
+    ossutil unpack oss://bucket/pack_dir/ [oss://bucket/prefix] [-j jobs] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    该命令读取pack_cloud_url目录下的清单manifest.jsonl，从容器object中读取每个object的数据，并
+    上传为独立的object，元信息、用户自定义元信息、存储类型、ACL和标签与合并前相同。不指定dest_cloud_url时，objects恢复到合并前的
+    位置；指定dest_cloud_url时，objects恢复到dest_cloud_url下，key为合并前相对于源prefix的路径。
+    恢复时会校验每个object的crc64。命令不会删除容器object和清单。
+
+用法：
+
+    ossutil unpack oss://bucket/pack_dir/ [oss://bucket/prefix]
+`,
+
+	sampleText: `
+    1) 将objects恢复到合并前的位置
+    ossutil unpack oss://bucket1/packs/logs-2020/
+
+    2) 将objects恢复到restore/logs/2020/下
+    ossutil unpack oss://bucket1/packs/logs-2020/ oss://bucket1/restore/logs/2020/
+`,
+}
+
+var specEnglishUnpack = SpecText{
+	synopsisText: "Restore the objects packed by pack command to standalone objects",
+
+	paramText: "pack_cloud_url [dest_cloud_url] [options]",
+
+	syntaxText: `
+    ossutil unpack oss://bucket/pack_dir/ [oss://bucket/prefix] [-j jobs] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The command reads the manifest manifest.jsonl under the directory pack_cloud_url, reads the data of
+    each object from the container objects, and uploads it as a standalone object with the same
+    metadata, user metadata, storage class, acl and tags as before. If dest_cloud_url isn't specified, the objects are restored to the location
+    before packing; otherwise the objects are restored under dest_cloud_url, and the keys are the paths
+    relative to the source prefix. The crc64 of each object is verified. The command doesn't remove the
+    container objects and the manifest.
+
+Usage:
+
+    ossutil unpack oss://bucket/pack_dir/ [oss://bucket/prefix]
+`,
+
+	sampleText: `
+    1) restore the objects to the location before packing
+    ossutil unpack oss://bucket1/packs/logs-2020/
+
+    2) restore the objects under restore/logs/2020/
+    ossutil unpack oss://bucket1/packs/logs-2020/ oss://bucket1/restore/logs/2020/
+`,
+}
+
+var specChinesePackGet = SpecText{
+	synopsisText: "读取pack命令合并的单个object",
+
+	paramText: "pack_cloud_url key [local_file] [options]",
+
+	syntaxText: `
+    ossutil pack-get oss://bucket/pack_dir/ key [local_file] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    该命令在pack_cloud_url目录下的清单中查找key(合并前的完整object名)，只读取容器object中对应
+    范围的数据，并校验crc64。指定local_file时写入本地文件，否则输出到屏幕。
+
+用法：
+
+    ossutil pack-get oss://bucket/pack_dir/ key [local_file]
+`,
+
+	sampleText: `
+    1) 输出合并前的logs/2020/app.log
+    ossutil pack-get oss://bucket1/packs/logs-2020/ logs/2020/app.log
+
+    2) 将合并前的logs/2020/app.log保存到本地文件
+    ossutil pack-get oss://bucket1/packs/logs-2020/ logs/2020/app.log ./app.log
+`,
+}
+
+var specEnglishPackGet = SpecText{
+	synopsisText: "Read a single object packed by pack command",
+
+	paramText: "pack_cloud_url key [local_file] [options]",
+
+	syntaxText: `
+    ossutil pack-get oss://bucket/pack_dir/ key [local_file] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The command looks up the key(the full object name before packing) in the manifest under the
+    directory pack_cloud_url, reads only the range of the data in the container object, and verifies
+    the crc64. The data is written to local_file if it's specified, otherwise it's printed to screen.
+
+Usage:
+
+    ossutil pack-get oss://bucket/pack_dir/ key [local_file]
+`,
+
+	sampleText: `
+    1) print logs/2020/app.log before packing
+    ossutil pack-get oss://bucket1/packs/logs-2020/ logs/2020/app.log
+
+    2) save logs/2020/app.log before packing to the local file
+    ossutil pack-get oss://bucket1/packs/logs-2020/ logs/2020/app.log ./app.log
+`,
+}
+
+const (
+	packManifestName = "manifest.jsonl"
+	packSourceMeta   = "pack-source" // the meta of the manifest, the url encoded source url
+	packMaxSize      = 16 * 1024 * 1024
+	packPartSize     = 16 * 1024 * 1024
+)
+
+// packEntry is a line of the manifest, describes where an object is in the containers
+type packEntry struct {
+	Key          string            `json:"key"`
+	Container    string            `json:"container,omitempty"` // empty for the empty object
+	Offset       int64             `json:"offset"`
+	Size         int64             `json:"size"`
+	ETag         string            `json:"etag"`
+	CRC64        string            `json:"crc64,omitempty"`
+	ContentType  string            `json:"contentType,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"` // the content headers, the user meta and the storage class
+	ACL          string            `json:"acl,omitempty"`     // empty for the default acl
+	Tags         map[string]string `json:"tags,omitempty"`
+	LastModified time.Time         `json:"lastModified"`
+}
+
+type packItem struct {
+	object      oss.ObjectProperties
+	data        []byte
+	crc64       string
+	contentType string
+	headers     map[string]string
+	acl         string
+	tags        map[string]string
+	err         error
+}
+
+// packHeaders returns the headers of the object restored by unpack, besides Content-Type
+func packHeaders(props http.Header) map[string]string {
+	headers := map[string]string{}
+	for name := range props {
+		canonical := http.CanonicalHeaderKey(name)
+		switch {
+		case canonical == oss.HTTPHeaderCacheControl, canonical == oss.HTTPHeaderContentDisposition,
+			canonical == oss.HTTPHeaderContentEncoding, canonical == oss.HTTPHeaderExpires,
+			canonical == oss.HTTPHeaderOssStorageClass,
+			strings.HasPrefix(canonical, oss.HTTPHeaderOssMetaPrefix):
+			headers[canonical] = props.Get(name)
+		}
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// restoreOptions returns the options which restore the metadata, the storage class, the acl and
+// the tags of the packed object
+func (entry packEntry) restoreOptions() []oss.Option {
+	props := http.Header{}
+	for name, value := range entry.Headers {
+		props.Set(name, value)
+	}
+	if entry.ContentType != "" {
+		props.Set(oss.HTTPHeaderContentType, entry.ContentType)
+	}
+	options := objectMetaOptions(props)
+	if storageClass := props.Get(oss.HTTPHeaderOssStorageClass); storageClass != "" {
+		options = append(options, oss.ObjectStorageClass(oss.StorageClassType(storageClass)))
+	}
+	if entry.ACL != "" {
+		options = append(options, oss.ObjectACL(oss.ACLType(entry.ACL)))
+	}
+	if len(entry.Tags) > 0 {
+		tags := make([]oss.Tag, 0, len(entry.Tags))
+		for key, value := range entry.Tags {
+			tags = append(tags, oss.Tag{Key: key, Value: value})
+		}
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+		options = append(options, oss.SetTagging(oss.Tagging{Tags: tags}))
+	}
+	return options
+}
+
+// packContainer is the container object being uploaded by multipart upload
+type packContainer struct {
+	name  string
+	imur  oss.InitiateMultipartUploadResult
+	parts []oss.UploadPart
+	buf   bytes.Buffer
+	size  int64
+}
+
+// packDir returns the directory of the containers and the manifest
+func packDir(cloudURL CloudURL) string {
+	if cloudURL.object != "" && !strings.HasSuffix(cloudURL.object, "/") {
+		return cloudURL.object + "/"
+	}
+	return cloudURL.object
+}
+
+func payerOptionsOf(cmd *Command) ([]oss.Option, error) {
+	payer, _ := GetString(OptionRequestPayer, cmd.options)
+	if payer == "" {
+		return nil, nil
+	}
+	if payer != strings.ToLower(string(oss.Requester)) {
+		return nil, fmt.Errorf("invalid request payer: %s, please check", payer)
+	}
+	return []oss.Option{oss.RequestPayer(oss.PayerType(payer))}, nil
+}
+
+// retryPackRequest retries the request on the network error or the internal error
+func retryPackRequest(cmd *Command, bucketName, objectName string, request func() error) error {
+	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	for i := 1; ; i++ {
+		err := request()
+		if err == nil {
+			return nil
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucketName, objectName}
+		}
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+// readPackEntry reads the data of the entry from the container, and verifies the crc64
+func readPackEntry(cmd *Command, bucket *oss.Bucket, dir string, entry packEntry, payerOptions []oss.Option) ([]byte, error) {
+	if entry.Container == "" {
+		return []byte{}, nil
+	}
+	var data []byte
+	options := append([]oss.Option{oss.Range(entry.Offset, entry.Offset+entry.Size-1)}, payerOptions...)
+	err := retryPackRequest(cmd, bucket.BucketName, dir+entry.Container, func() error {
+		body, err := bucket.GetObject(dir+entry.Container, cmd.withContext(options)...)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		data, err = ioutil.ReadAll(body)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != entry.Size {
+		return nil, fmt.Errorf("the data of %s read from %s is %d bytes, expected %d", entry.Key, entry.Container, len(data), entry.Size)
+	}
+	if entry.CRC64 != "" {
+		if crc := strconv.FormatUint(crc64.Checksum(data, crc64ECMATable), 10); crc != entry.CRC64 {
+			return nil, fmt.Errorf("the crc64 of %s read from %s is %s, expected %s", entry.Key, entry.Container, crc, entry.CRC64)
+		}
+	}
+	return data, nil
+}
+
+// readPackManifest calls handle for each entry of the manifest until handle returns false
+func readPackManifest(cmd *Command, bucket *oss.Bucket, dir string, payerOptions []oss.Option, handle func(packEntry) bool) error {
+	var body io.ReadCloser
+	err := retryPackRequest(cmd, bucket.BucketName, dir+packManifestName, func() error {
+		var err error
+		body, err = bucket.GetObject(dir+packManifestName, cmd.withContext(payerOptions)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(body))
+	for {
+		var entry packEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("invalid manifest %s: %s", bucketObjectURL(bucket, dir+packManifestName), err.Error())
+		}
+		if !handle(entry) {
+			return nil
+		}
+	}
+}
+
+// PackCommand is the command merges the small objects into the container objects
+type PackCommand struct {
+	command       Command
+	payerOptions  []oss.Option
+	containerSize int64
+	packedNum     int64
+	packedSize    int64
+	skippedNum    int64
+	containers    []string
+}
+
+var packCommand = PackCommand{
+	command: Command{
+		name:      "pack",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionContainerSize,
+			OptionDelete,
+			OptionForce,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (pc *PackCommand) formatHelpForWhole() string {
+	return pc.command.formatHelpForWhole()
+}
+
+func (pc *PackCommand) formatIndependHelp() string {
+	return pc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (pc *PackCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return pc.command.Init(ctx, args, options, pc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (pc *PackCommand) RunCommand() error {
+	// clear for go tests
+	pc.packedNum, pc.packedSize, pc.skippedNum, pc.containers = 0, 0, 0, nil
+
+	encodingType, _ := GetString(OptionEncodingType, pc.command.options)
+	srcURL, err := GetCloudUrl(pc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	packURL, err := GetCloudUrl(pc.command.args[1], encodingType)
+	if err != nil {
+		return err
+	}
+	dir := packDir(*packURL)
+	if srcURL.bucket == packURL.bucket && strings.HasPrefix(srcURL.object, dir) {
+		return fmt.Errorf("the source %s can't be under the pack directory %s", pc.command.args[0], pc.command.args[1])
+	}
+	if pc.payerOptions, err = payerOptionsOf(&pc.command); err != nil {
+		return err
+	}
+	pc.containerSize, _ = GetInt(OptionContainerSize, pc.command.options)
+	routines, _ := GetInt(OptionRoutines, pc.command.options)
+	if routines <= 0 {
+		routines = int64(Routines)
+	}
+
+	srcBucket, err := pc.command.cloudBucket(*srcURL)
+	if err != nil {
+		return err
+	}
+	packBucket, err := pc.command.cloudBucket(*packURL)
+	if err != nil {
+		return err
+	}
+	if err := pc.checkPackDir(packBucket, dir); err != nil {
+		return err
+	}
+
+	// the manifest is spooled to the temp file, it may be too large to be kept in memory
+	manifest, err := ioutil.TempFile("", "ossutil-pack-manifest-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(manifest.Name())
+	defer manifest.Close()
+
+	chObjects := make(chan oss.ObjectProperties, ChannelBuf)
+	chItems := make(chan packItem, routines)
+	done := make(chan struct{})
+	chListError := make(chan error, 1)
+	go pc.listProducer(srcBucket, srcURL.object, dir, packBucket.BucketName, chObjects, chListError, done)
+	var wg sync.WaitGroup
+	for i := 0; int64(i) < routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pc.readConsumer(srcBucket, chObjects, chItems, done)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(chItems)
+	}()
+
+	err = pc.writeContainers(packBucket, dir, manifest, chItems)
+	if err != nil {
+		close(done)
+		for range chItems {
+		}
+		<-chListError
+		return err
+	}
+	if err := <-chListError; err != nil {
+		return err
+	}
+
+	// the manifest is uploaded at last, so that it only refers to the completed containers
+	source := url.QueryEscape(srcURL.objectURL(srcURL.object))
+	options := append([]oss.Option{oss.Meta(packSourceMeta, source), oss.ContentType("application/x-ndjson")}, pc.payerOptions...)
+	err = retryPackRequest(&pc.command, packBucket.BucketName, dir+packManifestName, func() error {
+		return packBucket.PutObjectFromFile(dir+packManifestName, manifest.Name(), pc.command.withContext(options)...)
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("packed %d objects(%d bytes) into %d containers under %s, skipped %d objects larger than %d bytes\n",
+		pc.packedNum, pc.packedSize, len(pc.containers), bucketObjectURL(packBucket, dir), pc.skippedNum, packMaxSize)
+
+	if deleteSource, _ := GetBool(OptionDelete, pc.command.options); deleteSource && pc.packedNum > 0 {
+		if !pc.command.confirmOperation(fmt.Sprintf("remove the %d packed objects under %s", pc.packedNum, pc.command.args[0])) {
+			return nil
+		}
+		return pc.deletePacked(srcBucket, manifest.Name())
+	}
+	return nil
+}
+
+// checkPackDir makes sure the existing pack is not overwritten
+func (pc *PackCommand) checkPackDir(bucket *oss.Bucket, dir string) error {
+	_, err := pc.command.ossGetObjectStatRetry(bucket, dir+packManifestName, pc.payerOptions...)
+	if err == nil {
+		return fmt.Errorf("%s already exists, please specify another pack directory", bucketObjectURL(bucket, dir+packManifestName))
+	}
+	if !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (pc *PackCommand) listProducer(bucket *oss.Bucket, prefix, dir, packBucketName string, chObjects chan<- oss.ObjectProperties, chListError chan<- error, done <-chan struct{}) {
+	defer close(chObjects)
+	marker := ""
+	for {
+		listOptions := append(pc.payerOptions, oss.Prefix(prefix), oss.Marker(marker), oss.MaxKeys(1000))
+		lor, err := pc.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			chListError <- err
+			return
+		}
+
+		for _, object := range lor.Objects {
+			if bucket.BucketName == packBucketName && strings.HasPrefix(object.Key, dir) {
+				continue
+			}
+			if object.Size > packMaxSize {
+				pc.skippedNum++
+				continue
+			}
+			select {
+			case chObjects <- object:
+			case <-done:
+				chListError <- nil
+				return
+			}
+		}
+
+		marker = lor.NextMarker
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	chListError <- nil
+}
+
+func (pc *PackCommand) readConsumer(bucket *oss.Bucket, chObjects <-chan oss.ObjectProperties, chItems chan<- packItem, done <-chan struct{}) {
+	for object := range chObjects {
+		item := packItem{object: object}
+		tagCount := ""
+		item.err = retryPackRequest(&pc.command, bucket.BucketName, object.Key, func() error {
+			result, err := bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: object.Key}, pc.command.withContext(pc.payerOptions))
+			if err != nil {
+				return err
+			}
+			defer result.Response.Close()
+			item.crc64 = result.Response.Headers.Get(oss.HTTPHeaderOssCRC64)
+			item.contentType = result.Response.Headers.Get(oss.HTTPHeaderContentType)
+			item.headers = packHeaders(result.Response.Headers)
+			tagCount = result.Response.Headers.Get("X-Oss-Tagging-Count")
+			item.data, err = ioutil.ReadAll(result.Response.Body)
+			return err
+		})
+		if item.err == nil {
+			item.err = pc.readPackAttrs(bucket, &item, tagCount)
+		}
+		select {
+		case chItems <- item:
+		case <-done:
+			return
+		}
+	}
+}
+
+// readPackAttrs reads the acl and the tags of the object, which aren't in the response of GetObject
+func (pc *PackCommand) readPackAttrs(bucket *oss.Bucket, item *packItem, tagCount string) error {
+	acl, err := pc.command.ossGetObjectACLRetry(bucket, item.object.Key, pc.payerOptions...)
+	if err != nil {
+		return err
+	}
+	if acl.ACL != "" && acl.ACL != "default" {
+		item.acl = acl.ACL
+	}
+	if tagCount == "" || tagCount == "0" {
+		return nil
+	}
+	tagging, err := pc.command.ossGetObjectTaggingRetry(bucket, item.object.Key, pc.payerOptions...)
+	if err != nil {
+		return err
+	}
+	item.tags = map[string]string{}
+	for _, tag := range tagging.Tags {
+		item.tags[tag.Key] = tag.Value
+	}
+	return nil
+}
+
+// writeContainers appends the objects to the containers in the order they are read, and writes
+// the manifest entries
+func (pc *PackCommand) writeContainers(bucket *oss.Bucket, dir string, manifest io.Writer, chItems <-chan packItem) error {
+	var container *packContainer
+	encoder := json.NewEncoder(manifest)
+	for item := range chItems {
+		if item.err != nil {
+			pc.abortContainer(bucket, container)
+			return item.err
+		}
+		if item.crc64 == "" {
+			item.crc64 = strconv.FormatUint(crc64.Checksum(item.data, crc64ECMATable), 10)
+		}
+		entry := packEntry{
+			Key:          item.object.Key,
+			Size:         int64(len(item.data)),
+			ETag:         item.object.ETag,
+			CRC64:        item.crc64,
+			ContentType:  item.contentType,
+			Headers:      item.headers,
+			ACL:          item.acl,
+			Tags:         item.tags,
+			LastModified: item.object.LastModified,
+		}
+
+		if len(item.data) > 0 {
+			if container == nil {
+				var err error
+				if container, err = pc.newContainer(bucket, dir); err != nil {
+					return err
+				}
+			}
+			entry.Container = container.name
+			entry.Offset = container.size
+			container.buf.Write(item.data)
+			container.size += entry.Size
+			if int64(container.buf.Len()) >= packPartSize {
+				if err := pc.uploadContainerPart(bucket, dir, container); err != nil {
+					pc.abortContainer(bucket, container)
+					return err
+				}
+			}
+			if container.size >= pc.containerSize {
+				if err := pc.completeContainer(bucket, dir, container); err != nil {
+					return err
+				}
+				container = nil
+			}
+		}
+
+		if err := encoder.Encode(entry); err != nil {
+			pc.abortContainer(bucket, container)
+			return err
+		}
+		pc.packedNum++
+		pc.packedSize += entry.Size
+	}
+
+	if container != nil {
+		return pc.completeContainer(bucket, dir, container)
+	}
+	return nil
+}
+
+func (pc *PackCommand) newContainer(bucket *oss.Bucket, dir string) (*packContainer, error) {
+	container := &packContainer{name: fmt.Sprintf("container-%06d.pack", len(pc.containers)+1)}
+	options := append([]oss.Option{oss.ContentType("application/octet-stream")}, pc.payerOptions...)
+	err := retryPackRequest(&pc.command, bucket.BucketName, dir+container.name, func() error {
+		var err error
+		container.imur, err = bucket.InitiateMultipartUpload(dir+container.name, pc.command.withContext(options)...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	pc.containers = append(pc.containers, container.name)
+	return container, nil
+}
+
+func (pc *PackCommand) uploadContainerPart(bucket *oss.Bucket, dir string, container *packContainer) error {
+	data := container.buf.Bytes()
+	partNumber := len(container.parts) + 1
+	err := retryPackRequest(&pc.command, bucket.BucketName, dir+container.name, func() error {
+		part, err := bucket.UploadPart(container.imur, bytes.NewReader(data), int64(len(data)), partNumber, pc.command.withContext(pc.payerOptions)...)
+		if err == nil {
+			container.parts = append(container.parts, part)
+		}
+		return err
+	})
+	container.buf.Reset()
+	return err
+}
+
+func (pc *PackCommand) completeContainer(bucket *oss.Bucket, dir string, container *packContainer) error {
+	if container.buf.Len() > 0 {
+		if err := pc.uploadContainerPart(bucket, dir, container); err != nil {
+			pc.abortContainer(bucket, container)
+			return err
+		}
+	}
+	return retryPackRequest(&pc.command, bucket.BucketName, dir+container.name, func() error {
+		_, err := bucket.CompleteMultipartUpload(container.imur, container.parts, pc.command.withContext(pc.payerOptions)...)
+		return err
+	})
+}
+
+func (pc *PackCommand) abortContainer(bucket *oss.Bucket, container *packContainer) {
+	if container != nil {
+		bucket.AbortMultipartUpload(container.imur, pc.payerOptions...)
+	}
+}
+
+// deletePacked removes the objects recorded in the manifest file
+func (pc *PackCommand) deletePacked(bucket *oss.Bucket, manifestFile string) error {
+	f, err := os.Open(manifestFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var deletedNum int64
+	deleteKeys := func(keys []string) error {
+		options := append([]oss.Option{oss.DeleteObjectsQuiet(true)}, pc.payerOptions...)
+		err := retryPackRequest(&pc.command, bucket.BucketName, keys[0], func() error {
+			_, err := bucket.DeleteObjects(keys, pc.command.withContext(options)...)
+			return err
+		})
+		if err == nil {
+			deletedNum += int64(len(keys))
+		}
+		return err
+	}
+
+	keys := []string{}
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var entry packEntry
+		if err := decoder.Decode(&entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if keys = append(keys, entry.Key); len(keys) == 1000 {
+			if err := deleteKeys(keys); err != nil {
+				return err
+			}
+			keys = []string{}
+		}
+	}
+	if len(keys) > 0 {
+		if err := deleteKeys(keys); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("removed %d packed objects\n", deletedNum)
+	return nil
+}
+
+// UnpackCommand is the command restores the packed objects
+type UnpackCommand struct {
+	command      Command
+	payerOptions []oss.Option
+	restoredNum  int64
+}
+
+var unpackCommand = UnpackCommand{
+	command: Command{
+		name:      "unpack",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (uc *UnpackCommand) formatHelpForWhole() string {
+	return uc.command.formatHelpForWhole()
+}
+
+func (uc *UnpackCommand) formatIndependHelp() string {
+	return uc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (uc *UnpackCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return uc.command.Init(ctx, args, options, uc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (uc *UnpackCommand) RunCommand() error {
+	uc.restoredNum = 0
+	encodingType, _ := GetString(OptionEncodingType, uc.command.options)
+	packURL, err := GetCloudUrl(uc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	dir := packDir(*packURL)
+	if uc.payerOptions, err = payerOptionsOf(&uc.command); err != nil {
+		return err
+	}
+	routines, _ := GetInt(OptionRoutines, uc.command.options)
+	if routines <= 0 {
+		routines = int64(Routines)
+	}
+	packBucket, err := uc.command.cloudBucket(*packURL)
+	if err != nil {
+		return err
+	}
+
+	// the source url is recorded in the meta of the manifest
+	props, err := uc.command.ossGetObjectStatRetry(packBucket, dir+packManifestName, uc.payerOptions...)
+	if err != nil {
+		return err
+	}
+	source, _ := url.QueryUnescape(props.Get(oss.HTTPHeaderOssMetaPrefix + packSourceMeta))
+	sourceURL, err := CloudURLFromString(source, "")
+	if err != nil || sourceURL.bucket == "" {
+		return fmt.Errorf("invalid source %q recorded in the manifest %s", source, bucketObjectURL(packBucket, dir+packManifestName))
+	}
+	destURL := &sourceURL
+	if len(uc.command.args) > 1 {
+		if destURL, err = GetCloudUrl(uc.command.args[1], encodingType); err != nil {
+			return err
+		}
+	}
+	destBucket, err := uc.command.cloudBucket(*destURL)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	var restoreErr error
+	var wg sync.WaitGroup
+	chEntries := make(chan packEntry, ChannelBuf)
+	for i := 0; int64(i) < routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range chEntries {
+				objectName := destURL.object + strings.TrimPrefix(entry.Key, sourceURL.object)
+				if err := uc.restore(packBucket, dir, destBucket, objectName, entry); err != nil {
+					mu.Lock()
+					if restoreErr == nil {
+						restoreErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	err = readPackManifest(&uc.command, packBucket, dir, uc.payerOptions, func(entry packEntry) bool {
+		chEntries <- entry
+		return true
+	})
+	close(chEntries)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	if restoreErr != nil {
+		return restoreErr
+	}
+	fmt.Printf("restored %d objects to %s\n", uc.restoredNum, destURL.objectURL(destURL.object))
+	return nil
+}
+
+func (uc *UnpackCommand) restore(packBucket *oss.Bucket, dir string, destBucket *oss.Bucket, objectName string, entry packEntry) error {
+	data, err := readPackEntry(&uc.command, packBucket, dir, entry, uc.payerOptions)
+	if err != nil {
+		return err
+	}
+	options := append(entry.restoreOptions(), uc.payerOptions...)
+	err = retryPackRequest(&uc.command, destBucket.BucketName, objectName, func() error {
+		return destBucket.PutObject(objectName, bytes.NewReader(data), uc.command.withContext(options)...)
+	})
+	if err == nil {
+		atomic.AddInt64(&uc.restoredNum, 1)
+	}
+	return err
+}
+
+// PackGetCommand is the command reads a single packed object
+type PackGetCommand struct {
+	command Command
+}
+
+var packGetCommand = PackGetCommand{
+	command: Command{
+		name:      "pack-get",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   3,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (pgc *PackGetCommand) formatHelpForWhole() string {
+	return pgc.command.formatHelpForWhole()
+}
+
+func (pgc *PackGetCommand) formatIndependHelp() string {
+	return pgc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (pgc *PackGetCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return pgc.command.Init(ctx, args, options, pgc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (pgc *PackGetCommand) RunCommand() error {
+	encodingType, _ := GetString(OptionEncodingType, pgc.command.options)
+	packURL, err := GetCloudUrl(pgc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	key := pgc.command.args[1]
+	if encodingType == URLEncodingType {
+		if key, err = url.QueryUnescape(key); err != nil {
+			return err
+		}
+	}
+	dir := packDir(*packURL)
+	payerOptions, err := payerOptionsOf(&pgc.command)
+	if err != nil {
+		return err
+	}
+	bucket, err := pgc.command.cloudBucket(*packURL)
+	if err != nil {
+		return err
+	}
+
+	var found *packEntry
+	err = readPackManifest(&pgc.command, bucket, dir, payerOptions, func(entry packEntry) bool {
+		if entry.Key == key {
+			found = &entry
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		return fmt.Errorf("%s is not found in the manifest %s", key, bucketObjectURL(bucket, dir+packManifestName))
+	}
+
+	data, err := readPackEntry(&pgc.command, bucket, dir, *found, payerOptions)
+	if err != nil {
+		return err
+	}
+	if len(pgc.command.args) < 3 {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(pgc.command.args[2], data, 0644)
+}
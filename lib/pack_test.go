@@ -0,0 +1,219 @@
+package lib
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestPackUnpack(c *C) {
+	// a minimal in memory oss, the keys are "bucket/object"
+	var mu sync.Mutex
+	objects := map[string][]byte{
+		"src/data/a":     []byte("aaa"),
+		"src/data/b":     []byte("bbbbb"),
+		"src/data/c":     {},
+		"src/data/d":     []byte("ddddddd"),
+		"src/data/large": make([]byte, packMaxSize+1),
+	}
+	metas := map[string]http.Header{
+		"src/data/a": {
+			"X-Oss-Meta-Team":     {"web"},
+			"Cache-Control":       {"no-cache"},
+			"X-Oss-Storage-Class": {"IA"},
+			"X-Oss-Object-Acl":    {"public-read"},
+			"X-Oss-Tagging":       {"k1=v1&k2=v2"},
+		},
+	}
+	parts := map[string][]byte{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := r.URL.Path[1:]
+		query := r.URL.Query()
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.Method == "GET" && query["acl"] != nil:
+			acl := metas[key].Get("X-Oss-Object-Acl")
+			if acl == "" {
+				acl = "default"
+			}
+			fmt.Fprintf(w, "<AccessControlPolicy><AccessControlList><Grant>%s</Grant></AccessControlList></AccessControlPolicy>", acl)
+		case r.Method == "GET" && query["tagging"] != nil:
+			tags := ""
+			values, _ := url.ParseQuery(metas[key].Get("X-Oss-Tagging"))
+			for k := range values {
+				tags += fmt.Sprintf("<Tag><Key>%s</Key><Value>%s</Value></Tag>", k, values.Get(k))
+			}
+			fmt.Fprintf(w, "<Tagging><TagSet>%s</TagSet></Tagging>", tags)
+		case r.Method == "GET" && strings.TrimSuffix(key, "/") == strings.Split(key, "/")[0]:
+			names := strings.SplitN(key, "/", 2)
+			keys := []string{}
+			for k := range objects {
+				if strings.HasPrefix(k, names[0]+"/"+query.Get("prefix")) {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			listed := ""
+			for _, k := range keys {
+				listed += fmt.Sprintf("<Contents><Key>%s</Key><Size>%d</Size><ETag>\"%s\"</ETag></Contents>", k[len(names[0])+1:], len(objects[k]), k)
+			}
+			fmt.Fprintf(w, "<ListBucketResult><IsTruncated>false</IsTruncated>%s</ListBucketResult>", listed)
+		case r.Method == "POST" && query["delete"] != nil:
+			var request struct {
+				Objects []struct {
+					Key string `xml:"Key"`
+				} `xml:"Object"`
+			}
+			xml.Unmarshal(body, &request)
+			for _, object := range request.Objects {
+				delete(objects, strings.Split(key, "/")[0]+"/"+object.Key)
+			}
+			fmt.Fprint(w, "<DeleteResult></DeleteResult>")
+		case r.Method == "POST" && query["uploads"] != nil:
+			names := strings.SplitN(key, "/", 2)
+			fmt.Fprintf(w, "<InitiateMultipartUploadResult><Bucket>%s</Bucket><Key>%s</Key><UploadId>%s</UploadId></InitiateMultipartUploadResult>", names[0], names[1], key)
+		case r.Method == "PUT" && query.Get("partNumber") != "":
+			parts[key] = append(parts[key], body...)
+			w.Header().Set("ETag", `"part"`)
+		case r.Method == "POST" && query.Get("uploadId") != "":
+			objects[key] = parts[key]
+			fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+		case r.Method == "DELETE" && query.Get("uploadId") != "":
+			delete(parts, key)
+		case r.Method == "PUT":
+			objects[key] = body
+			metas[key] = r.Header
+		case r.Method == "GET" || r.Method == "HEAD":
+			data, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			for k, v := range metas[key] {
+				if strings.HasPrefix(k, "X-Oss-Meta-") || k == "Content-Type" || k == "Cache-Control" || k == "X-Oss-Storage-Class" {
+					w.Header()[k] = v
+				}
+			}
+			if tagging := metas[key].Get("X-Oss-Tagging"); tagging != "" {
+				w.Header().Set("X-Oss-Tagging-Count", strconv.Itoa(len(strings.Split(tagging, "&"))))
+			}
+			if rg := r.Header.Get("Range"); rg != "" {
+				var start, end int
+				fmt.Sscanf(rg, "bytes=%d-%d", &start, &end)
+				data = data[start : end+1]
+				w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(objects[key])))
+				w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+				w.WriteHeader(http.StatusPartialContent)
+			} else {
+				w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			}
+			if r.Method == "GET" {
+				w.Write(data)
+			}
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	containerSize := "10"
+	routines := "2"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionContainerSize:   &containerSize,
+		OptionRoutines:        &routines,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// the source can't be under the pack directory
+	_, err = cm.RunCommand("pack", []string{"oss://src/data/a", "oss://src/data/"}, options)
+	c.Assert(err, NotNil)
+
+	_, err = cm.RunCommand("pack", []string{"oss://src/data/", "oss://src/pack"}, options)
+	c.Assert(err, IsNil)
+	output := s.readFile(resultPath, c)
+	c.Assert(strings.Contains(output, "packed 4 objects(15 bytes)"), Equals, true)
+	c.Assert(strings.Contains(output, "skipped 1 objects"), Equals, true)
+	c.Assert(len(objects["src/pack/manifest.jsonl"]) > 0, Equals, true)
+	c.Assert(metas["src/pack/manifest.jsonl"].Get("X-Oss-Meta-"+packSourceMeta), Equals, "oss%3A%2F%2Fsrc%2Fdata%2F")
+	containers := string(objects["src/pack/container-000001.pack"]) + string(objects["src/pack/container-000002.pack"])
+	c.Assert(len(containers), Equals, 15)
+
+	// the existing pack isn't overwritten
+	_, err = cm.RunCommand("pack", []string{"oss://src/data/", "oss://src/pack/"}, options)
+	c.Assert(err, NotNil)
+
+	delete(options, OptionContainerSize)
+	delete(options, OptionRoutines)
+	os.Remove("packed_d")
+	_, err = cm.RunCommand("pack-get", []string{"oss://src/pack/", "data/d", "packed_d"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.readFile("packed_d", c), Equals, "ddddddd")
+	os.Remove("packed_d")
+	_, err = cm.RunCommand("pack-get", []string{"oss://src/pack/", "data/x"}, options)
+	c.Assert(err, NotNil)
+
+	options[OptionRoutines] = &routines
+	_, err = cm.RunCommand("unpack", []string{"oss://src/pack/", "oss://dest/restore/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(string(objects["dest/restore/a"]), Equals, "aaa")
+	c.Assert(string(objects["dest/restore/b"]), Equals, "bbbbb")
+	c.Assert(objects["dest/restore/c"], NotNil)
+	c.Assert(string(objects["dest/restore/d"]), Equals, "ddddddd")
+
+	// the metadata, the storage class, the acl and the tags are restored
+	restored := metas["dest/restore/a"]
+	c.Assert(restored.Get("X-Oss-Meta-Team"), Equals, "web")
+	c.Assert(restored.Get("Cache-Control"), Equals, "no-cache")
+	c.Assert(restored.Get("X-Oss-Storage-Class"), Equals, "IA")
+	c.Assert(restored.Get("X-Oss-Object-Acl"), Equals, "public-read")
+	c.Assert(restored.Get("X-Oss-Tagging"), Equals, "k1=v1&k2=v2")
+	c.Assert(metas["dest/restore/b"].Get("X-Oss-Object-Acl"), Equals, "")
+	c.Assert(metas["dest/restore/b"].Get("X-Oss-Tagging"), Equals, "")
+
+	// the data read from the container is verified
+	for i := range objects["src/pack/container-000001.pack"] {
+		objects["src/pack/container-000001.pack"][i] = 'x'
+	}
+	delete(options, OptionRoutines)
+	_, err = cm.RunCommand("pack-get", []string{"oss://src/pack/", "data/a"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "crc64"), Equals, true)
+
+	// remove the packed objects after packing
+	force := true
+	deleteSource := true
+	options[OptionContainerSize] = &containerSize
+	options[OptionRoutines] = &routines
+	options[OptionForce] = &force
+	options[OptionDelete] = &deleteSource
+	_, err = cm.RunCommand("pack", []string{"oss://src/data/", "oss://src/pack2/"}, options)
+	c.Assert(err, IsNil)
+	_, ok := objects["src/data/a"]
+	c.Assert(ok, Equals, false)
+	_, ok = objects["src/data/large"]
+	c.Assert(ok, Equals, true)
+}
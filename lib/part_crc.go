@@ -0,0 +1,263 @@
+package lib
+
+import (
+	"crypto/cipher"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var crc64ECMATable = crc64.MakeTable(crc64.ECMA)
+
+// crcPart is the uploaded part with the crc64 computed while it's read from the file
+type crcPart struct {
+	PartNumber int    `json:"partNumber"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+	CRC64      uint64 `json:"crc64"`
+}
+
+// partCRCCheckpoint records the uploaded parts and their crc64 of multipart upload for resuming,
+// so that the crc64 of the whole file can be combined without reading the uploaded parts again
+type partCRCCheckpoint struct {
+	FilePath string      `json:"filePath"`
+	FileSize int64       `json:"fileSize"`
+	ModTime  int64       `json:"modTime"`
+	DestURL  string      `json:"destURL"`
+	PartSize int64       `json:"partSize"`
+	UploadID string      `json:"uploadID"`
+	Parts    []crcPart   `json:"parts"`
+	mu       sync.Mutex  `json:"-"`
+	path     string      `json:"-"`
+	aead     cipher.AEAD `json:"-"`
+}
+
+func (pcp *partCRCCheckpoint) addPart(part crcPart) error {
+	pcp.mu.Lock()
+	defer pcp.mu.Unlock()
+	pcp.Parts = append(pcp.Parts, part)
+	return pcp.save()
+}
+
+func (pcp *partCRCCheckpoint) save() error {
+	data, err := json.Marshal(pcp)
+	if err != nil {
+		return err
+	}
+	return writeCheckpoint(pcp.path, data, pcp.aead)
+}
+
+// combineCRC64 returns the crc64 of the whole object, the parts must be sorted by part number
+func combineCRC64(parts []crcPart) uint64 {
+	var crc uint64
+	for _, part := range parts {
+		crc = oss.CRC64Combine(crc, part.CRC64, uint64(part.Size))
+	}
+	return crc
+}
+
+// usePartCRCUpload returns true if --part-crc is specified and the crc64 of multipart upload can be
+// computed part by part, the s3 compatible service does not return crc64
+func (cc *CopyCommand) usePartCRCUpload(bucket *oss.Bucket) bool {
+	partCRC, _ := GetBool(OptionPartCRC, cc.command.options)
+	return partCRC && !isS3Client(&bucket.Client)
+}
+
+// ossPartCRCUploadRetry uploads the file by multipart upload, the crc64 of each part is computed while
+// the part is streamed, and the part crc64s are combined to verify the crc64 of the completed object,
+// so the file is read only once. Each part is retried separately, the uploaded parts are resumed from
+// the checkpoint in --checkpoint-dir
+func (cc *CopyCommand) ossPartCRCUploadRetry(bucket *oss.Bucket, objectName, filePath string, f os.FileInfo, partSize int64, rt int,
+	listener oss.ProgressListener, options ...oss.Option) error {
+	size := f.Size()
+	pcp, err := cc.loadPartCRCCheckpoint(bucket, objectName, filePath, f, partSize)
+	if err != nil {
+		return FileError{err, filePath}
+	}
+	if pcp.UploadID == "" {
+		imur, err := bucket.InitiateMultipartUpload(objectName, options...)
+		if err != nil {
+			return FileError{err, filePath}
+		}
+		pcp.UploadID = imur.UploadID
+		if err := pcp.save(); err != nil {
+			return FileError{err, filePath}
+		}
+	}
+	imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: objectName, UploadID: pcp.UploadID}
+
+	done := map[int]bool{}
+	var doneSize int64
+	for _, part := range pcp.Parts {
+		done[part.PartNumber] = true
+		doneSize += part.Size
+	}
+	listener.ProgressChanged(&oss.ProgressEvent{EventType: oss.TransferStartedEvent, ConsumedBytes: doneSize, TotalBytes: size})
+
+	partNum := int((size-1)/partSize + 1)
+	if size == 0 {
+		partNum = 1
+	}
+	chParts := make(chan int, partNum)
+	for i := 1; i <= partNum; i++ {
+		if !done[i] {
+			chParts <- i
+		}
+	}
+	close(chParts)
+
+	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
+	partOptions := oss.ChoiceTransferPartOption(options)
+	chError := make(chan error, rt)
+	for i := 0; i < rt; i++ {
+		go func() {
+			for partNumber := range chParts {
+				part, err := cc.uploadCRCPartRetry(bucket, imur, filePath, partNumber, partSize, size, retryTimes, partOptions)
+				if err == nil {
+					err = pcp.addPart(part)
+				}
+				if err != nil {
+					chError <- err
+					return
+				}
+			}
+			chError <- nil
+		}()
+	}
+	for i := 0; i < rt; i++ {
+		if e := <-chError; e != nil && err == nil {
+			err = e
+		}
+	}
+	if err != nil {
+		return FileError{err, filePath}
+	}
+
+	crcParts := make([]crcPart, len(pcp.Parts))
+	copy(crcParts, pcp.Parts)
+	sort.Slice(crcParts, func(i, j int) bool { return crcParts[i].PartNumber < crcParts[j].PartNumber })
+	parts := make([]oss.UploadPart, 0, len(crcParts))
+	for _, part := range crcParts {
+		parts = append(parts, oss.UploadPart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+
+	var respHeader http.Header
+	completeOptions := append(oss.ChoiceCompletePartOption(options), oss.GetResponseHeader(&respHeader))
+	if body, _ := oss.FindOption(options, callbackResultKey, nil); body != nil {
+		completeOptions = append(completeOptions, oss.CallbackResult(body.(*[]byte)))
+	}
+	if _, err := bucket.CompleteMultipartUpload(imur, parts, completeOptions...); err != nil {
+		return FileError{err, filePath}
+	}
+	os.Remove(pcp.path)
+
+	localCRC := strconv.FormatUint(combineCRC64(crcParts), 10)
+	if serverCRC := respHeader.Get(oss.HTTPHeaderOssCRC64); serverCRC != "" && serverCRC != localCRC {
+		return FileError{fmt.Errorf("crc64 of %s mismatch, local: %s, oss: %s", bucketObjectURL(bucket, objectName),
+			localCRC, serverCRC), filePath}
+	}
+	LogInfo("multipart upload %s with part crc64 success, crc64:%s\n", filePath, localCRC)
+	return nil
+}
+
+func (cc *CopyCommand) uploadCRCPartRetry(bucket *oss.Bucket, imur oss.InitiateMultipartUploadResult, filePath string,
+	partNumber int, partSize, size, retryTimes int64, options []oss.Option) (crcPart, error) {
+	start := int64(partNumber-1) * partSize
+	if partSize > size-start {
+		partSize = size - start
+	}
+	for i := 1; ; i++ {
+		part, err := uploadCRCPart(bucket, imur, filePath, partNumber, start, partSize, options)
+		if err == nil {
+			return part, nil
+		}
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || !cc.cpOption.budget.allowRetry() {
+			return part, err
+		}
+		LogError("upload part %d of %s error, retry %d, error:%s\n", partNumber, filePath, i, err.Error())
+		cc.cpOption.statSummary.addRetry(filePath)
+		time.Sleep(time.Duration(3) * time.Second)
+	}
+}
+
+// uploadCRCPart computes the crc64 of the part by the data sent to oss
+func uploadCRCPart(bucket *oss.Bucket, imur oss.InitiateMultipartUploadResult, filePath string, partNumber int,
+	start, partSize int64, options []oss.Option) (crcPart, error) {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return crcPart{}, err
+	}
+	defer fd.Close()
+
+	hash := crc64.New(crc64ECMATable)
+	reader := io.TeeReader(io.NewSectionReader(fd, start, partSize), hash)
+	part, err := bucket.UploadPart(imur, reader, partSize, partNumber, options...)
+	if err != nil {
+		return crcPart{}, err
+	}
+	return crcPart{PartNumber: part.PartNumber, ETag: part.ETag, Size: partSize, CRC64: hash.Sum64()}, nil
+}
+
+func (cc *CopyCommand) partCRCCheckpointPath(absPath, destURL string) string {
+	sum := md5.Sum([]byte(absPath + CheckpointSep + destURL))
+	return filepath.Join(cc.cpOption.cpDir, hex.EncodeToString(sum[:])+".crc.cp")
+}
+
+// loadPartCRCCheckpoint loads the checkpoint of the file, the checkpoint is dropped if the file is changed
+// or the upload does not exist any more, the parts not recorded locally are uploaded again to get their crc64
+func (cc *CopyCommand) loadPartCRCCheckpoint(bucket *oss.Bucket, objectName, filePath string, f os.FileInfo, partSize int64) (*partCRCCheckpoint, error) {
+	absPath, _ := filepath.Abs(filePath)
+	destURL := bucketObjectURL(bucket, objectName)
+	if err := os.MkdirAll(cc.cpOption.cpDir, 0755); err != nil {
+		return nil, err
+	}
+	cpPath := cc.partCRCCheckpointPath(absPath, destURL)
+
+	pcp := &partCRCCheckpoint{}
+	if data, err := readCheckpoint(cpPath, cc.cpOption.cpCipher); err == nil && json.Unmarshal(data, pcp) == nil &&
+		pcp.FilePath == absPath && pcp.DestURL == destURL && pcp.FileSize == f.Size() && pcp.ModTime == f.ModTime().UnixNano() &&
+		pcp.PartSize == partSize && pcp.UploadID != "" {
+		imur := oss.InitiateMultipartUploadResult{Bucket: bucket.BucketName, Key: objectName, UploadID: pcp.UploadID}
+		if uploadedParts, err := listAllUploadedParts(bucket, imur, cc.cpOption.payerOptions...); err == nil {
+			uploaded := map[int]string{}
+			for _, part := range uploadedParts {
+				uploaded[part.PartNumber] = part.ETag
+			}
+			parts := pcp.Parts[:0]
+			for _, part := range pcp.Parts {
+				if uploaded[part.PartNumber] == part.ETag {
+					parts = append(parts, part)
+				}
+			}
+			pcp.Parts = parts
+			pcp.path = cpPath
+			pcp.aead = cc.cpOption.cpCipher
+			LogInfo("resume multipart upload %s to %s, upload id %s, %d parts uploaded\n", filePath, destURL, pcp.UploadID, len(pcp.Parts))
+			return pcp, nil
+		}
+	}
+
+	pcp = &partCRCCheckpoint{
+		FilePath: absPath,
+		FileSize: f.Size(),
+		ModTime:  f.ModTime().UnixNano(),
+		DestURL:  destURL,
+		PartSize: partSize,
+		path:     cpPath,
+		aead:     cc.cpOption.cpCipher,
+	}
+	return pcp, nil
+}
@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"hash/crc64"
+	"os"
+	"strconv"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestCombineCRC64(c *C) {
+	data := []byte(randStr(1000))
+	parts := []crcPart{}
+	for i, start := 1, 0; start < len(data); i, start = i+1, start+300 {
+		end := start + 300
+		if end > len(data) {
+			end = len(data)
+		}
+		parts = append(parts, crcPart{PartNumber: i, Size: int64(end - start), CRC64: crc64.Checksum(data[start:end], crc64ECMATable)})
+	}
+	c.Assert(len(parts), Equals, 4)
+	c.Assert(combineCRC64(parts), Equals, crc64.Checksum(data, crc64ECMATable))
+	c.Assert(combineCRC64(nil), Equals, uint64(0))
+}
+
+func (s *OssutilCommandSuite) TestPartCRCCheckpoint(c *C) {
+	fileName := "ossutil-test-part-crc-" + randLowStr(5)
+	s.createFile(fileName, randStr(1024), c)
+	f, err := os.Stat(fileName)
+	c.Assert(err, IsNil)
+
+	cc := &CopyCommand{}
+	cc.cpOption.cpDir = "ossutil-test-part-crc-cp-" + randLowStr(5)
+	bucket := &oss.Bucket{BucketName: "bucket"}
+	pcp, err := cc.loadPartCRCCheckpoint(bucket, "object", fileName, f, 100)
+	c.Assert(err, IsNil)
+	c.Assert(pcp.UploadID, Equals, "")
+	c.Assert(pcp.FileSize, Equals, int64(1024))
+	c.Assert(pcp.DestURL, Equals, CloudURLToString("bucket", "object"))
+
+	c.Assert(pcp.addPart(crcPart{PartNumber: 1, ETag: "etag", Size: 100, CRC64: 1}), IsNil)
+	_, err = os.Stat(pcp.path)
+	c.Assert(err, IsNil)
+
+	// the part crc upload is used only if --part-crc is specified
+	c.Assert(cc.usePartCRCUpload(bucket), Equals, false)
+	partCRC := true
+	cc.command.options = OptionMapType{OptionPartCRC: &partCRC}
+	c.Assert(cc.usePartCRCUpload(bucket), Equals, true)
+	disableCRC64 := true
+	str := "ak"
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, OptionMapType{
+		OptionEndpoint:        &str,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionPartCRC:         &partCRC,
+		OptionDisableCRC64:    &disableCRC64,
+	})
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--part-crc"), Equals, true)
+
+	// the checkpoint without upload id is dropped
+	pcp, err = cc.loadPartCRCCheckpoint(bucket, "object", fileName, f, 100)
+	c.Assert(err, IsNil)
+	c.Assert(len(pcp.Parts), Equals, 0)
+
+	os.Remove(fileName)
+	os.RemoveAll(cc.cpOption.cpDir)
+}
+
+func (s *OssutilCommandSuite) TestUploadWithPartCRC(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(12)
+	s.putBucket(bucketName, c)
+
+	testFileName := "ossutil_test_file" + randStr(5)
+	data := randStr(1024 * 350)
+	s.createFile(testFileName, data, c)
+
+	str := ""
+	cpDir := CheckpointDir
+	routines := strconv.Itoa(Routines)
+	threshold := "102400"
+	partSize := "102400"
+	partCRC := true
+	options := OptionMapType{
+		"endpoint":         &str,
+		"accessKeyID":      &str,
+		"accessKeySecret":  &str,
+		"configFile":       &configFile,
+		"checkpointDir":    &cpDir,
+		"routines":         &routines,
+		"bigfileThreshold": &threshold,
+		"partSize":         &partSize,
+		"partCRC":          &partCRC,
+	}
+	_, err := cm.RunCommand("cp", []string{testFileName, CloudURLToString(bucketName, "object")}, options)
+	c.Assert(err, IsNil)
+
+	stat := s.getStat(bucketName, "object", c)
+	c.Assert(stat["Content-Length"], Equals, "358400")
+	c.Assert(stat[StatCRC64], Equals, strconv.FormatUint(crc64.Checksum([]byte(data), crc64ECMATable), 10))
+
+	os.Remove(testFileName)
+	s.removeBucket(bucketName, true, c)
+}
@@ -0,0 +1,226 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// the default prices used by --dry-run to estimate the cost, in CNY, the requests are charged per
+// 10,000 and the outbound traffic is charged per GB
+const (
+	DefaultPutRequestPrice = 0.01
+	DefaultGetRequestPrice = 0.01
+	DefaultTrafficPrice    = 0.50
+)
+
+// requestPrices is the prices of the requests and the outbound traffic, specified by --price
+type requestPrices struct {
+	put     float64
+	get     float64
+	traffic float64
+}
+
+// requestPlan counts the requests and the traffic which would be made by the command in --dry-run mode
+type requestPlan struct {
+	put     int64
+	post    int64
+	get     int64
+	head    int64
+	list    int64
+	deletes int64
+	files   int64
+	bytes   int64
+	traffic int64
+	prices  requestPrices
+	out     io.Writer // the text output of the command
+}
+
+// newRequestPlan returns nil if neither --dry-run nor --plan is specified
+func (cmd *Command) newRequestPlan() (*requestPlan, error) {
+	dryRun, _ := GetBool(OptionDryRun, cmd.options)
+	plan, _ := GetBool(OptionPlan, cmd.options)
+	if !dryRun && !plan {
+		return nil, nil
+	}
+
+	rp := &requestPlan{prices: requestPrices{DefaultPutRequestPrice, DefaultGetRequestPrice, DefaultTrafficPrice}, out: cmd.textOut()}
+	strPrice, _ := GetString(OptionPrice, cmd.options)
+	if strPrice == "" {
+		return rp, nil
+	}
+	for _, item := range strings.Split(strPrice, ",") {
+		kv := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid --price: %s, the format should be put=0.01,get=0.01,traffic=0.5", strPrice)
+		}
+		price, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil || price < 0 {
+			return nil, fmt.Errorf("invalid --price: %s, the price of %s should be non-negative number", strPrice, kv[0])
+		}
+		switch strings.ToLower(kv[0]) {
+		case "put":
+			rp.prices.put = price
+		case "get":
+			rp.prices.get = price
+		case "traffic":
+			rp.prices.traffic = price
+		default:
+			return nil, fmt.Errorf("invalid --price: %s, the price type should be put, get or traffic", strPrice)
+		}
+	}
+	return rp, nil
+}
+
+func (rp *requestPlan) addFile(size int64) {
+	atomic.AddInt64(&rp.files, 1)
+	atomic.AddInt64(&rp.bytes, size)
+}
+
+// addParts adds the requests of the multipart upload or copy
+func (rp *requestPlan) addParts(partNum int64) {
+	atomic.AddInt64(&rp.post, 2) // InitiateMultipartUpload and CompleteMultipartUpload
+	atomic.AddInt64(&rp.put, partNum)
+}
+
+func (rp *requestPlan) addList(num int64) {
+	atomic.AddInt64(&rp.list, num)
+}
+
+// addDelete adds the DeleteMultipleObjects requests to delete the objects
+func (rp *requestPlan) addDelete(objectNum int64) {
+	atomic.AddInt64(&rp.deletes, (objectNum+int64(MaxBatchCount)-1)/int64(MaxBatchCount))
+}
+
+// cost returns the cost of the requests and the outbound traffic
+func (rp *requestPlan) cost() (float64, float64) {
+	putType := rp.put + rp.post + rp.deletes
+	getType := rp.get + rp.head + rp.list
+	requestCost := float64(putType)/10000*rp.prices.put + float64(getType)/10000*rp.prices.get
+	trafficCost := float64(rp.traffic) / (1 << 30) * rp.prices.traffic
+	return requestCost, trafficCost
+}
+
+func (rp *requestPlan) output() {
+	if rp == nil {
+		return
+	}
+	requestCost, trafficCost := rp.cost()
+	fmt.Fprintf(rp.out, "\ndry run, no file is transferred, the estimated requests:\n")
+	fmt.Fprintf(rp.out, "%-20s%s\n", "files:", getSizeString(rp.files))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "bytes:", getSizeString(rp.bytes))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "PUT requests:", getSizeString(rp.put))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "POST requests:", getSizeString(rp.post))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "GET requests:", getSizeString(rp.get))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "HEAD requests:", getSizeString(rp.head))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "LIST requests:", getSizeString(rp.list))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "DELETE requests:", getSizeString(rp.deletes))
+	fmt.Fprintf(rp.out, "%-20s%s\n", "outbound traffic:", getSizeString(rp.traffic))
+	fmt.Fprintf(rp.out, "estimated cost: requests %.4f + traffic %.4f = %.4f\n", requestCost, trafficCost, requestCost+trafficCost)
+	fmt.Fprintf(rp.out, "(prices: put=%g,get=%g per 10,000 requests, traffic=%g per GB, specify --price with the prices of your region and storage class)\n",
+		rp.prices.put, rp.prices.get, rp.prices.traffic)
+}
+
+// planUpload counts the requests of uploading the file instead of uploading it
+func (cc *CopyCommand) planUpload(file fileInfoType) error {
+	plan := cc.cpOption.plan
+	f, err := os.Stat(filepath.Join(file.dir, file.filePath))
+	if err != nil {
+		cc.updateMonitor(false, err, false, 0)
+		return err
+	}
+	if (cc.cpOption.update || cc.cpOption.noClobber && cc.cpOption.destObjects == nil) && cc.cpOption.snapshotPath == "" {
+		atomic.AddInt64(&plan.head, 1)
+	}
+
+	if f.IsDir() {
+		if !cc.cpOption.disableDirObject && (!cc.cpOption.dirObject || isEmptyDir(filepath.Join(file.dir, file.filePath))) {
+			atomic.AddInt64(&plan.put, 1)
+		}
+		cc.updateMonitor(false, nil, true, 0)
+		return nil
+	}
+
+	size := f.Size()
+	plan.addFile(size)
+	if size < cc.cpOption.threshold {
+		atomic.AddInt64(&plan.put, 1)
+	} else {
+		partSize, _ := cc.preparePartOption(size)
+		plan.addParts((size-1)/partSize + 1)
+	}
+	cc.updateMonitor(false, nil, false, size)
+	return nil
+}
+
+// planDownload counts the requests and the traffic of downloading the object instead of downloading it
+func (cc *CopyCommand) planDownload(bucket *oss.Bucket, objectInfo objectInfoType) error {
+	plan := cc.cpOption.plan
+	size, err := cc.planObjectSize(bucket, objectInfo)
+	if err != nil {
+		cc.updateMonitor(false, err, false, 0)
+		return err
+	}
+
+	rsize := cc.getRangeSize(size)
+	plan.addFile(rsize)
+	atomic.AddInt64(&plan.traffic, rsize)
+	if rsize < cc.cpOption.threshold {
+		atomic.AddInt64(&plan.get, 1)
+	} else {
+		// the resumable download gets the meta of the object first
+		partSize, _ := cc.preparePartOption(size)
+		atomic.AddInt64(&plan.head, 1)
+		atomic.AddInt64(&plan.get, (rsize-1)/partSize+1)
+	}
+	cc.updateMonitor(false, nil, false, rsize)
+	return nil
+}
+
+// planCopy counts the requests of copying the object instead of copying it
+func (cc *CopyCommand) planCopy(bucket *oss.Bucket, objectInfo objectInfoType) error {
+	plan := cc.cpOption.plan
+	size, err := cc.planObjectSize(bucket, objectInfo)
+	if err != nil {
+		cc.updateMonitor(false, err, false, 0)
+		return err
+	}
+	if (cc.cpOption.update || cc.cpOption.noClobber && cc.cpOption.destObjects == nil) && cc.cpOption.snapshotPath == "" {
+		atomic.AddInt64(&plan.head, 1)
+	}
+
+	plan.addFile(size)
+	if size < cc.cpOption.threshold {
+		atomic.AddInt64(&plan.put, 1)
+	} else {
+		// the resumable copy gets the meta of the source object first
+		partSize, _ := cc.preparePartOption(size)
+		atomic.AddInt64(&plan.head, 1)
+		plan.addParts((size-1)/partSize + 1)
+	}
+	cc.updateMonitor(false, nil, false, size)
+	return nil
+}
+
+// planObjectSize gets the size of the object by HEAD request if it is not listed
+func (cc *CopyCommand) planObjectSize(bucket *oss.Bucket, objectInfo objectInfoType) (int64, error) {
+	if objectInfo.size >= 0 {
+		return objectInfo.size, nil
+	}
+	statOptions := cc.cpOption.payerOptions
+	if versionId := cc.objectVersionId(objectInfo.prefix + objectInfo.relativeKey); versionId != "" {
+		statOptions = append(statOptions, oss.VersionId(versionId))
+	}
+	atomic.AddInt64(&cc.cpOption.plan.head, 1)
+	props, err := cc.command.ossGetObjectStatRetry(bucket, objectInfo.prefix+objectInfo.relativeKey, statOptions...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+}
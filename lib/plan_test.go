@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"os"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestRequestPlan(c *C) {
+	cmd := Command{options: OptionMapType{}}
+	rp, err := cmd.newRequestPlan()
+	c.Assert(err, IsNil)
+	c.Assert(rp, IsNil)
+
+	dryRun := true
+	price := "put=0.1,get=0.01,traffic=1"
+	cmd = Command{options: OptionMapType{OptionDryRun: &dryRun, OptionPrice: &price}}
+	rp, err = cmd.newRequestPlan()
+	c.Assert(err, IsNil)
+	c.Assert(rp.prices, Equals, requestPrices{0.1, 0.01, 1})
+
+	rp.addParts(10)
+	rp.addDelete(101)
+	rp.addList(2)
+	rp.traffic = 1 << 30
+	c.Assert(rp.post, Equals, int64(2))
+	c.Assert(rp.put, Equals, int64(10))
+	c.Assert(rp.deletes, Equals, int64(2))
+	requestCost, trafficCost := rp.cost()
+	c.Assert(requestCost > 0.000142-1e-9 && requestCost < 0.000142+1e-9, Equals, true)
+	c.Assert(trafficCost, Equals, float64(1))
+
+	for _, invalid := range []string{"put", "put=-1", "post=0.1"} {
+		price = invalid
+		_, err = cmd.newRequestPlan()
+		c.Assert(err, NotNil)
+	}
+
+	plan := true
+	cmd = Command{options: OptionMapType{OptionPlan: &plan}}
+	rp, err = cmd.newRequestPlan()
+	c.Assert(err, IsNil)
+	c.Assert(rp.prices.traffic, Equals, DefaultTrafficPrice)
+}
+
+func (s *OssutilCommandSuite) TestPlanUpload(c *C) {
+	dir := "ossutil-test-plan-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	s.createFile(dir+"/small", "content", c)
+	s.createFile(dir+"/big", randStr(1024), c)
+
+	chProgressSignal = make(chan chProgressSignalType, 10)
+	cc := &CopyCommand{}
+	cc.cpOption.plan = &requestPlan{}
+	cc.cpOption.threshold = 1000
+	partSize := "100"
+	cc.command.options = OptionMapType{OptionPartSize: &partSize}
+	c.Assert(cc.planUpload(fileInfoType{"small", dir}), IsNil)
+	c.Assert(cc.planUpload(fileInfoType{"big", dir}), IsNil)
+
+	plan := cc.cpOption.plan
+	c.Assert(plan.files, Equals, int64(2))
+	c.Assert(plan.bytes, Equals, int64(1031))
+	c.Assert(plan.post, Equals, int64(2))
+	c.Assert(plan.put, Equals, int64(12))
+	c.Assert(plan.traffic, Equals, int64(0))
+	os.RemoveAll(dir)
+}
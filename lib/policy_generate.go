@@ -0,0 +1,409 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChinesePolicy = SpecText{
+	synopsisText: "根据共享意图生成bucket policy或者RAM policy",
+
+	paramText: "generate bucket_url [options]",
+
+	syntaxText: `
+    ossutil policy generate oss://bucket --allow read|list|write|read-write --principal uid[,uid...] [--prefix prefix] [--apply] [-y]
+    ossutil policy generate oss://bucket --allow read|list|write|read-write --ram [--prefix prefix]
+`,
+
+	detailHelpText: `
+    该命令根据常见的共享意图生成policy的json，避免手写Action、Resource和Condition时出错。
+    --allow指定授予的权限，多个权限以逗号分隔：
+
+        list        列举prefix下的objects(oss:ListObjects，带oss:Prefix条件)
+        read        list以及下载objects(oss:GetObject)
+        write       上传objects(oss:PutObject、oss:AbortMultipartUpload、oss:ListParts)
+        read-write  read、write以及删除objects(oss:DeleteObject)
+
+    --prefix指定共享的目录，未指定时为整个bucket。object的Resource为acs:oss:*:*:bucket/prefix*，
+    列举的Resource为acs:oss:*:*:bucket。
+
+    默认生成bucket policy，--principal指定被授权的阿里云账号或者RAM用户的uid，多个uid以逗号分隔，
+    *表示所有人（匿名访问）。指定--ram时生成RAM policy，用于授权给RAM用户、用户组或者角色，不含
+    Principal，此时不能指定--principal。
+
+    默认只输出生成的policy。指定--apply时，ossutil查询bucket当前的policy，将生成的statements追加
+    到其中（已经存在的相同statement不再追加），显示修改前后的差异，确认后设置bucket的policy，指定
+    -y则不进行询问提示。--apply不支持--ram。
+`,
+
+	sampleText: `
+    1) 生成授权uid为1234567890的账号读取shared/目录的bucket policy
+       ossutil policy generate oss://bucket --allow read --principal 1234567890 --prefix shared/
+
+    2) 生成并设置bucket policy
+       ossutil policy generate oss://bucket --allow read,write --principal 1234567890 --prefix shared/ --apply
+
+    3) 生成RAM policy
+       ossutil policy generate oss://bucket --allow read-write --ram --prefix logs/ > policy.json
+`,
+}
+
+var specEnglishPolicy = SpecText{
+	synopsisText: "Generate the bucket policy or the RAM policy from the sharing intent",
+
+	paramText: "generate bucket_url [options]",
+
+	syntaxText: `
+    ossutil policy generate oss://bucket --allow read|list|write|read-write --principal uid[,uid...] [--prefix prefix] [--apply] [-y]
+    ossutil policy generate oss://bucket --allow read|list|write|read-write --ram [--prefix prefix]
+`,
+
+	detailHelpText: `
+    The command generates the policy json from the common sharing intents, to avoid the mistakes of
+    writing Action, Resource and Condition by hand. --allow specifies the granted permissions, the
+    multiple permissions are separated by commas:
+
+        list        list the objects under the prefix(oss:ListObjects with the oss:Prefix condition)
+        read        list and download the objects(oss:GetObject)
+        write       upload the objects(oss:PutObject, oss:AbortMultipartUpload, oss:ListParts)
+        read-write  read, write and delete the objects(oss:DeleteObject)
+
+    --prefix specifies the shared directory, the whole bucket is shared if it's not specified. The
+    Resource of the objects is acs:oss:*:*:bucket/prefix*, and the Resource of listing is
+    acs:oss:*:*:bucket.
+
+    The bucket policy is generated by default, --principal specifies the uids of the alibaba cloud
+    accounts or the RAM users to be granted, separated by commas, * means everyone(anonymous access).
+    If --ram is specified, the RAM policy is generated to be attached to the RAM users, groups or
+    roles, it has no Principal, and --principal can't be specified.
+
+    Only the generated policy is output by default. If --apply is specified, ossutil gets the current
+    policy of the bucket, appends the generated statements to it(the same statements existing are not
+    appended), shows the difference, and sets the policy of the bucket after the confirmation, -y
+    means no confirmation. --apply doesn't support --ram.
+`,
+
+	sampleText: `
+    1) Generate the bucket policy granting the account 1234567890 to read the shared/ directory
+       ossutil policy generate oss://bucket --allow read --principal 1234567890 --prefix shared/
+
+    2) Generate and set the bucket policy
+       ossutil policy generate oss://bucket --allow read,write --principal 1234567890 --prefix shared/ --apply
+
+    3) Generate the RAM policy
+       ossutil policy generate oss://bucket --allow read-write --ram --prefix logs/ > policy.json
+`,
+}
+
+// the permissions of --allow
+const (
+	policyAllowList      = "list"
+	policyAllowRead      = "read"
+	policyAllowWrite     = "write"
+	policyAllowReadWrite = "read-write"
+)
+
+// policyStatement is a statement of the bucket policy or the RAM policy, Principal is empty for the RAM policy
+type policyStatement struct {
+	Effect    string                         `json:"Effect"`
+	Action    []string                       `json:"Action"`
+	Principal []string                       `json:"Principal,omitempty"`
+	Resource  []string                       `json:"Resource"`
+	Condition map[string]map[string][]string `json:"Condition,omitempty"`
+}
+
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+type PolicyCommand struct {
+	command Command
+}
+
+var policyCommand = PolicyCommand{
+	command: Command{
+		name:      "policy",
+		nameAlias: []string{},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionAllow,
+			OptionPrincipal,
+			OptionPrefix,
+			OptionRAMPolicy,
+			OptionApply,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (pc *PolicyCommand) formatHelpForWhole() string {
+	return pc.command.formatHelpForWhole()
+}
+
+func (pc *PolicyCommand) formatIndependHelp() string {
+	return pc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (pc *PolicyCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return pc.command.Init(ctx, args, options, pc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (pc *PolicyCommand) RunCommand() error {
+	action := strings.ToLower(pc.command.args[0])
+	if action != "generate" {
+		return fmt.Errorf("the sub command %s is not in the optional value:generate", pc.command.args[0])
+	}
+
+	cloudURL, err := GetCloudUrl(pc.command.args[1], "")
+	if err != nil {
+		return err
+	}
+	if cloudURL.object != "" {
+		return fmt.Errorf("the cloud url should be oss://bucket, the directory is specified by --prefix")
+	}
+
+	allow, _ := GetString(OptionAllow, pc.command.options)
+	principal, _ := GetString(OptionPrincipal, pc.command.options)
+	prefix, _ := GetString(OptionPrefix, pc.command.options)
+	ram, _ := GetBool(OptionRAMPolicy, pc.command.options)
+	apply, _ := GetBool(OptionApply, pc.command.options)
+
+	var principals []string
+	for _, uid := range strings.Split(principal, ",") {
+		if uid = strings.TrimSpace(uid); uid != "" {
+			principals = append(principals, uid)
+		}
+	}
+	if ram {
+		if len(principals) > 0 {
+			return fmt.Errorf("--principal can't be used with --ram, the RAM policy is attached to the RAM identities")
+		}
+		if apply {
+			return fmt.Errorf("--apply only works for the bucket policy, it doesn't support --ram")
+		}
+	} else if len(principals) == 0 {
+		return fmt.Errorf("--principal is required for the bucket policy, or use --ram to generate the RAM policy")
+	}
+
+	statements, err := policyStatements(cloudURL.bucket, prefix, allow, principals)
+	if err != nil {
+		return err
+	}
+	policy := policyDocument{Version: "1", Statement: statements}
+	if !apply {
+		return printJSON(policy)
+	}
+	return pc.apply(cloudURL.bucket, policy)
+}
+
+// policyStatements generates the statements of the permissions, the listing is granted on the bucket
+// with the prefix condition, and the other actions are granted on the objects under the prefix
+func policyStatements(bucket, prefix, allow string, principals []string) ([]policyStatement, error) {
+	var list bool
+	var actions []string
+	for _, permission := range strings.Split(allow, ",") {
+		switch strings.ToLower(strings.TrimSpace(permission)) {
+		case policyAllowList:
+			list = true
+		case policyAllowRead:
+			list = true
+			actions = append(actions, "oss:GetObject")
+		case policyAllowWrite:
+			actions = append(actions, "oss:PutObject", "oss:AbortMultipartUpload", "oss:ListParts")
+		case policyAllowReadWrite:
+			list = true
+			actions = append(actions, "oss:GetObject", "oss:PutObject", "oss:AbortMultipartUpload", "oss:ListParts", "oss:DeleteObject")
+		default:
+			return nil, fmt.Errorf("invalid --allow: %s, the value should be %s, %s, %s or %s", allow,
+				policyAllowList, policyAllowRead, policyAllowWrite, policyAllowReadWrite)
+		}
+	}
+
+	var statements []policyStatement
+	if len(actions) > 0 {
+		statements = append(statements, policyStatement{
+			Effect:    "Allow",
+			Action:    uniqueStrings(actions),
+			Principal: principals,
+			Resource:  []string{fmt.Sprintf("acs:oss:*:*:%s/%s*", bucket, prefix)},
+		})
+	}
+	if list {
+		statement := policyStatement{
+			Effect:    "Allow",
+			Action:    []string{"oss:ListObjects"},
+			Principal: principals,
+			Resource:  []string{fmt.Sprintf("acs:oss:*:*:%s", bucket)},
+		}
+		if prefix != "" {
+			statement.Condition = map[string]map[string][]string{"StringLike": {"oss:Prefix": {prefix + "*"}}}
+		}
+		statements = append(statements, statement)
+	}
+	return statements, nil
+}
+
+func uniqueStrings(vals []string) []string {
+	seen := map[string]bool{}
+	result := []string{}
+	for _, val := range vals {
+		if !seen[val] {
+			seen[val] = true
+			result = append(result, val)
+		}
+	}
+	return result
+}
+
+// apply appends the generated statements to the current bucket policy, and sets the policy after showing
+// the difference. The policies are compared as the generic json, so that the fields of the current
+// policy not known by ossutil are kept
+func (pc *PolicyCommand) apply(bucket string, policy policyDocument) error {
+	client, err := pc.command.ossClient(bucket)
+	if err != nil {
+		return err
+	}
+	current, err := client.GetBucketPolicy(bucket)
+	if err != nil {
+		if serviceError, ok := err.(oss.ServiceError); !ok || serviceError.StatusCode != http.StatusNotFound {
+			return err
+		}
+		current = ""
+	}
+
+	oldPolicy := map[string]interface{}{}
+	if current != "" {
+		if err = json.Unmarshal([]byte(current), &oldPolicy); err != nil {
+			return fmt.Errorf("the current policy of bucket %s is invalid json: %s", bucket, err.Error())
+		}
+	}
+	newPolicy := map[string]interface{}{}
+	for key, val := range oldPolicy {
+		newPolicy[key] = val
+	}
+	if _, ok := newPolicy["Version"]; !ok {
+		newPolicy["Version"] = policy.Version
+	}
+	statements, _ := newPolicy["Statement"].([]interface{})
+	statements = append([]interface{}{}, statements...)
+	for _, statement := range policy.Statement {
+		var generic interface{}
+		data, _ := json.Marshal(statement)
+		json.Unmarshal(data, &generic)
+		exist := false
+		for _, old := range statements {
+			if reflect.DeepEqual(old, generic) {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			statements = append(statements, generic)
+		}
+	}
+	newPolicy["Statement"] = statements
+
+	oldText := ""
+	if current != "" {
+		data, _ := json.MarshalIndent(oldPolicy, "", "  ")
+		oldText = string(data)
+	}
+	data, _ := json.MarshalIndent(newPolicy, "", "  ")
+	newText := string(data)
+	if oldText == newText {
+		fmt.Printf("the statements exist in the policy of bucket %s already\n", bucket)
+		return nil
+	}
+	fmt.Print(lineDiff(oldText, newText))
+	if !pc.command.confirmOperation(fmt.Sprintf("set the policy of bucket %s", bucket)) {
+		return nil
+	}
+	return client.SetBucketPolicy(bucket, newText)
+}
+
+// lineDiff returns the difference of the lines by the longest common subsequence, the removed lines
+// start with "-", the added lines start with "+", and the common lines start with " "
+func lineDiff(oldText, newText string) string {
+	var a, b []string
+	if oldText != "" {
+		a = strings.Split(oldText, "\n")
+	}
+	if newText != "" {
+		b = strings.Split(newText, "\n")
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			diff.WriteString(" " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			diff.WriteString("+" + b[j] + "\n")
+			j++
+		default:
+			diff.WriteString("-" + a[i] + "\n")
+			i++
+		}
+	}
+	return diff.String()
+}
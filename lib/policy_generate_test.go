@@ -0,0 +1,138 @@
+package lib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestPolicyGenerate(c *C) {
+	statements, err := policyStatements("bucket", "shared/", "read", []string{"1234567890"})
+	c.Assert(err, IsNil)
+	c.Assert(statements, DeepEquals, []policyStatement{
+		{
+			Effect:    "Allow",
+			Action:    []string{"oss:GetObject"},
+			Principal: []string{"1234567890"},
+			Resource:  []string{"acs:oss:*:*:bucket/shared/*"},
+		},
+		{
+			Effect:    "Allow",
+			Action:    []string{"oss:ListObjects"},
+			Principal: []string{"1234567890"},
+			Resource:  []string{"acs:oss:*:*:bucket"},
+			Condition: map[string]map[string][]string{"StringLike": {"oss:Prefix": {"shared/*"}}},
+		},
+	})
+
+	// write only doesn't list, the actions are not duplicated
+	statements, err = policyStatements("bucket", "", "write,read-write", nil)
+	c.Assert(err, IsNil)
+	c.Assert(len(statements), Equals, 2)
+	c.Assert(statements[0].Action, DeepEquals, []string{"oss:PutObject", "oss:AbortMultipartUpload", "oss:ListParts", "oss:GetObject", "oss:DeleteObject"})
+	c.Assert(statements[0].Resource, DeepEquals, []string{"acs:oss:*:*:bucket/*"})
+	c.Assert(statements[1].Condition, IsNil)
+	statements, err = policyStatements("bucket", "", "write", nil)
+	c.Assert(err, IsNil)
+	c.Assert(len(statements), Equals, 1)
+	_, err = policyStatements("bucket", "", "admin", nil)
+	c.Assert(err, NotNil)
+
+	c.Assert(lineDiff("a\nb\nc", "a\nc\nd"), Equals, " a\n-b\n c\n+d\n")
+	c.Assert(lineDiff("", "a"), Equals, "+a\n")
+
+	var policy string
+	var puts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			body, _ := ioutil.ReadAll(r.Body)
+			policy = string(body)
+			puts++
+			return
+		}
+		if policy == "" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchBucketPolicy</Code></Error>`))
+			return
+		}
+		w.Write([]byte(policy))
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	allow := "read"
+	principal := "1234567890"
+	prefix := "shared/"
+	apply := true
+	ram := false
+	yes := true
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionAllow:           &allow,
+		OptionPrincipal:       &principal,
+		OptionPrefix:          &prefix,
+		OptionApply:           &apply,
+		OptionRAMPolicy:       &ram,
+		OptionAssumeYes:       &yes,
+	}
+	run := func() error {
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		_, err = cm.RunCommand("policy", []string{"generate", "oss://bucket"}, options)
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		return err
+	}
+	defer os.Remove(resultPath)
+
+	// the bucket has no policy
+	c.Assert(run(), IsNil)
+	c.Assert(puts, Equals, 1)
+	doc := policyDocument{}
+	c.Assert(json.Unmarshal([]byte(policy), &doc), IsNil)
+	c.Assert(doc.Version, Equals, "1")
+	c.Assert(len(doc.Statement), Equals, 2)
+
+	// the statements exist already
+	c.Assert(run(), IsNil)
+	c.Assert(puts, Equals, 1)
+
+	// the statements are appended to the current policy
+	principal = "2222"
+	allow = "write"
+	c.Assert(run(), IsNil)
+	c.Assert(puts, Equals, 2)
+	c.Assert(json.Unmarshal([]byte(policy), &doc), IsNil)
+	c.Assert(len(doc.Statement), Equals, 3)
+	c.Assert(doc.Statement[2].Principal, DeepEquals, []string{"2222"})
+	c.Assert(strings.Contains(s.readFile(resultPath, c), `+      "Principal": [`), Equals, true)
+
+	// the RAM policy has no principal and can't be applied
+	c.Assert(run(), IsNil)
+	ram = true
+	c.Assert(run(), NotNil)
+	apply = false
+	c.Assert(run(), NotNil)
+	principal = ""
+	c.Assert(run(), IsNil)
+	doc = policyDocument{}
+	c.Assert(json.Unmarshal([]byte(s.readFile(resultPath, c)), &doc), IsNil)
+	c.Assert(doc.Statement[0].Principal, IsNil)
+	c.Assert(puts, Equals, 2)
+
+	// the bucket policy needs the principal
+	ram = false
+	c.Assert(run(), NotNil)
+}
@@ -0,0 +1,417 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChinesePrefetch = SpecText{
+	synopsisText: "通过CDN加速域名预热bucket(或者prefix)下的objects",
+
+	paramText: "cloud_url --cdn-domain domain [options]",
+
+	syntaxText: `
+    ossutil prefetch oss://bucket[/prefix] --cdn-domain domain [--range 0-0] [--include pattern] [--exclude pattern] [--routines 10] [--retry-times times] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    该命令列举cloud_url下的objects，通过--cdn-domain指定的CDN加速域名对每个object并发发送带Range的
+    GET请求，使CDN边缘节点回源并缓存objects，适用于发布后预先填充CDN缓存，避免首批用户请求回源。
+
+    --cdn-domain可以带有http://或者https://前缀，默认使用https。请求的url为CDN加速域名加上object名称，
+    请确保CDN的回源路径与bucket一致。
+
+    --range指定每个请求的范围，格式为：3-9或3-或-9，默认为0-0，即只请求第一个字节，由开启了Range回源
+    的CDN缓存整个object。--range 0-请求整个object，适用于没有开启Range回源的CDN。
+
+    --routines指定并发请求数，默认为` + strconv.Itoa(Routines) + `。--include和--exclude选项过滤object名称。
+    以/结尾的目录objects被忽略。
+
+    请求返回2xx时object预热成功，返回5xx或者网络错误时重试，重试次数由--retry-times指定。命令输出每个
+    失败的object及原因，最后输出预热成功和失败的objects个数，存在失败的objects时命令返回错误。
+
+    该命令不修改bucket中的数据，但是CDN回源会产生oss的外网流出流量或者CDN回源流量费用。
+
+用法：
+
+    ossutil prefetch oss://bucket[/prefix] --cdn-domain domain [--range range] [--routines routines]
+`,
+
+	sampleText: `
+    1) 预热prefix下的objects
+    ossutil prefetch oss://bucket/static/ --cdn-domain cdn.example.com
+
+    2) 使用20个并发请求预热整个bucket中的js文件
+    ossutil prefetch oss://bucket --cdn-domain cdn.example.com --include "*.js" --routines 20
+
+    3) CDN没有开启Range回源，请求整个object
+    ossutil prefetch oss://bucket/static/ --cdn-domain http://cdn.example.com --range 0-
+`,
+}
+
+var specEnglishPrefetch = SpecText{
+	synopsisText: "Warm up the objects of the bucket(or prefix) through the CDN domain",
+
+	paramText: "cloud_url --cdn-domain domain [options]",
+
+	syntaxText: `
+    ossutil prefetch oss://bucket[/prefix] --cdn-domain domain [--range 0-0] [--include pattern] [--exclude pattern] [--routines 10] [--retry-times times] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The command lists the objects under cloud_url, and sends the ranged GET requests of the objects
+    through the CDN domain specified by --cdn-domain concurrently, so that the CDN edges fetch and cache
+    the objects from the origin. It's useful to pre-populate the CDN caches after a deploy, so that the
+    first requests of the users don't go back to the origin.
+
+    --cdn-domain can start with http:// or https://, the default is https. The url requested is the
+    CDN domain followed by the object name, please make sure the origin path of the CDN is the bucket.
+
+    --range specifies the range of each request, the form is like: 3-9 or 3- or -9, the default is 0-0,
+    i.e., only the first byte is requested, the whole object is cached by the CDN with range origin
+    fetch enabled. --range 0- requests the whole object, it's for the CDN without range origin fetch.
+
+    --routines specifies the concurrent requests, the default is ` + strconv.Itoa(Routines) + `. --include and --exclude
+    filter the object names. The directory objects ending with / are ignored.
+
+    The object is warmed up if the request returns 2xx, the request is retried if it returns 5xx or
+    network error, the retry times is specified by --retry-times. The command prints each failed object
+    with the reason, and the numbers of the objects warmed up and failed at last, it returns error if
+    any object failed.
+
+    The command doesn't change the data of the bucket, but the origin fetch of the CDN costs the outbound
+    traffic of oss or the origin traffic of the CDN.
+
+Usage:
+
+    ossutil prefetch oss://bucket[/prefix] --cdn-domain domain [--range range] [--routines routines]
+`,
+
+	sampleText: `
+    1) warm up the objects under the prefix
+    ossutil prefetch oss://bucket/static/ --cdn-domain cdn.example.com
+
+    2) warm up the js files of the whole bucket with 20 concurrent requests
+    ossutil prefetch oss://bucket --cdn-domain cdn.example.com --include "*.js" --routines 20
+
+    3) request the whole objects, the CDN hasn't enabled range origin fetch
+    ossutil prefetch oss://bucket/static/ --cdn-domain http://cdn.example.com --range 0-
+`,
+}
+
+const defaultPrefetchRange = "0-0"
+
+/*
+ * Put same type variables together to make them 64bits alignment to avoid
+ * atomic.AddInt64() panic
+ * Please guarantee the alignment if you add new filed
+ */
+type prefetchOptionType struct {
+	okNum        int64
+	errNum       int64
+	routines     int64
+	retryTimes   int64
+	cloudURL     CloudURL
+	cdnURL       *url.URL
+	byteRange    string
+	filters      []filterOptionType
+	payerOptions []oss.Option
+	httpClient   *http.Client
+	outMu        sync.Mutex
+}
+
+// PrefetchCommand is the command warms up the CDN caches of the objects
+type PrefetchCommand struct {
+	command        Command
+	prefetchOption prefetchOptionType
+}
+
+var prefetchCommand = PrefetchCommand{
+	command: Command{
+		name:      "prefetch",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionCDNDomain,
+			OptionRange,
+			OptionInclude,
+			OptionExclude,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (pc *PrefetchCommand) formatHelpForWhole() string {
+	return pc.command.formatHelpForWhole()
+}
+
+func (pc *PrefetchCommand) formatIndependHelp() string {
+	return pc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (pc *PrefetchCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return pc.command.Init(ctx, args, options, pc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (pc *PrefetchCommand) RunCommand() error {
+	// clear for go tests
+	pc.prefetchOption = prefetchOptionType{}
+
+	encodingType, _ := GetString(OptionEncodingType, pc.command.options)
+	cloudURL, err := GetCloudUrl(pc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	pc.prefetchOption.cloudURL = *cloudURL
+
+	cdnDomain, _ := GetString(OptionCDNDomain, pc.command.options)
+	if pc.prefetchOption.cdnURL, err = parseCDNDomain(cdnDomain); err != nil {
+		return err
+	}
+
+	pc.prefetchOption.byteRange, _ = GetString(OptionRange, pc.command.options)
+	if pc.prefetchOption.byteRange == "" {
+		pc.prefetchOption.byteRange = defaultPrefetchRange
+	}
+	if err = checkPrefetchRange(pc.prefetchOption.byteRange); err != nil {
+		return err
+	}
+
+	var res bool
+	res, pc.prefetchOption.filters = getFilter(os.Args)
+	if !res {
+		return fmt.Errorf("--include or --exclude does not support format containing dir info")
+	}
+
+	payer, _ := GetString(OptionRequestPayer, pc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		pc.prefetchOption.payerOptions = append(pc.prefetchOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+
+	pc.prefetchOption.routines, _ = GetInt(OptionRoutines, pc.command.options)
+	if pc.prefetchOption.routines <= 0 {
+		pc.prefetchOption.routines = int64(Routines)
+	}
+	pc.prefetchOption.retryTimes, _ = GetInt(OptionRetryTimes, pc.command.options)
+	if pc.prefetchOption.retryTimes <= 0 {
+		pc.prefetchOption.retryTimes = int64(RetryTimes)
+	}
+	pc.prefetchOption.httpClient = pc.newPrefetchHTTPClient()
+
+	bucket, err := pc.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return err
+	}
+
+	chObjects := make(chan string, ChannelBuf)
+	chListError := make(chan error, 1)
+	go pc.listProducer(bucket, chObjects, chListError)
+
+	var wg sync.WaitGroup
+	for i := 0; int64(i) < pc.prefetchOption.routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pc.prefetchConsumer(chObjects)
+		}()
+	}
+	wg.Wait()
+	listErr := <-chListError
+
+	fmt.Printf("prefetched:%d\tfailed:%d\n", pc.prefetchOption.okNum, pc.prefetchOption.errNum)
+	if listErr != nil {
+		return listErr
+	}
+	if pc.prefetchOption.errNum > 0 {
+		return fmt.Errorf("%d object(s) failed to be prefetched through %s", pc.prefetchOption.errNum, pc.prefetchOption.cdnURL.Host)
+	}
+	return nil
+}
+
+// parseCDNDomain returns the base url of the CDN domain, the scheme is https by default
+func parseCDNDomain(cdnDomain string) (*url.URL, error) {
+	cdnDomain = strings.TrimSpace(cdnDomain)
+	if cdnDomain == "" {
+		return nil, fmt.Errorf("--cdn-domain is required, please specify the CDN domain of the bucket")
+	}
+	if !strings.Contains(cdnDomain, "://") {
+		cdnDomain = "https://" + cdnDomain
+	}
+	cdnURL, err := url.Parse(cdnDomain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --cdn-domain: %s", err.Error())
+	}
+	if cdnURL.Scheme != "http" && cdnURL.Scheme != "https" {
+		return nil, fmt.Errorf("invalid --cdn-domain: %s, only http and https are supported", cdnDomain)
+	}
+	if cdnURL.Host == "" || strings.Trim(cdnURL.Path, "/") != "" || cdnURL.RawQuery != "" {
+		return nil, fmt.Errorf("invalid --cdn-domain: %s, it should be a domain without path", cdnDomain)
+	}
+	cdnURL.Path = ""
+	return cdnURL, nil
+}
+
+// checkPrefetchRange checks the form of --range: 3-9 or 3- or -9
+func checkPrefetchRange(byteRange string) error {
+	bounds := strings.Split(byteRange, "-")
+	if len(bounds) != 2 || bounds[0] == "" && bounds[1] == "" {
+		return fmt.Errorf("invalid --range: %s, the form should be like: 3-9 or 3- or -9", byteRange)
+	}
+	for _, bound := range bounds {
+		if _, err := strconv.ParseUint(bound, 10, 64); bound != "" && err != nil {
+			return fmt.Errorf("invalid --range: %s, the form should be like: 3-9 or 3- or -9", byteRange)
+		}
+	}
+	return nil
+}
+
+func (pc *PrefetchCommand) newPrefetchHTTPClient() *http.Client {
+	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	strReadTimeout, _ := GetString(OptionReadTimeout, pc.command.options)
+	if readTimeout, err := strconv.ParseInt(strReadTimeout, 10, 64); err == nil && readTimeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(readTimeout) * time.Second
+	}
+	return &http.Client{Transport: transport}
+}
+
+// listProducer lists the objects matching the filters, chListError receives exactly one result
+func (pc *PrefetchCommand) listProducer(bucket *oss.Bucket, chObjects chan<- string, chListError chan<- error) {
+	defer close(chObjects)
+	marker := ""
+	for {
+		listOptions := append(pc.prefetchOption.payerOptions, oss.Prefix(pc.prefetchOption.cloudURL.object), oss.Marker(marker), oss.MaxKeys(1000))
+		lor, err := pc.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			chListError <- err
+			return
+		}
+
+		for _, object := range lor.Objects {
+			if strings.HasSuffix(object.Key, "/") || !doesSingleObjectMatchPatterns(object.Key, pc.prefetchOption.filters) {
+				continue
+			}
+			chObjects <- object.Key
+		}
+
+		marker = lor.NextMarker
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	chListError <- nil
+}
+
+func (pc *PrefetchCommand) prefetchConsumer(chObjects <-chan string) {
+	for object := range chObjects {
+		objectURL := pc.objectCDNURL(object)
+		if err := pc.prefetchObject(objectURL); err != nil {
+			atomic.AddInt64(&pc.prefetchOption.errNum, 1)
+			pc.output(fmt.Sprintf("failed\t%s\t%s", objectURL, err.Error()))
+			continue
+		}
+		atomic.AddInt64(&pc.prefetchOption.okNum, 1)
+	}
+}
+
+// objectCDNURL returns the url of the object through the CDN domain
+func (pc *PrefetchCommand) objectCDNURL(object string) string {
+	objectURL := *pc.prefetchOption.cdnURL
+	objectURL.Path = "/" + object
+	return objectURL.String()
+}
+
+// prefetchObject sends the ranged GET request of the object, retries if it's a network error or 5xx
+func (pc *PrefetchCommand) prefetchObject(objectURL string) error {
+	for i := 1; ; i++ {
+		err := pc.prefetchOnce(objectURL)
+		if err == nil {
+			return nil
+		}
+
+		// http 4XX error no need to retry
+		if e, ok := err.(fetchHTTPError); (ok && e.statusCode < 500) || int64(i) >= pc.prefetchOption.retryTimes {
+			return err
+		}
+		LogError("prefetch %s error, retry %d, error:%s\n", objectURL, i, err.Error())
+	}
+}
+
+func (pc *PrefetchCommand) prefetchOnce(objectURL string) error {
+	req, err := http.NewRequest("GET", objectURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(oss.HTTPHeaderRange, "bytes="+pc.prefetchOption.byteRange)
+	resp, err := pc.prefetchOption.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// the CDN caches the response after the body is read
+	if _, err = io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fetchHTTPError{resp.StatusCode, resp.Status}
+	}
+	return nil
+}
+
+func (pc *PrefetchCommand) output(line string) {
+	pc.prefetchOption.outMu.Lock()
+	defer pc.prefetchOption.outMu.Unlock()
+	fmt.Println(line)
+}
@@ -0,0 +1,103 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestPrefetch(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>static/a.js</Key><Size>10</Size></Contents><Contents><Key>static/dir/</Key><Size>0</Size></Contents>
+<Contents><Key>static/img/b c.png</Key><Size>10</Size></Contents><Contents><Key>static/bad.js</Key><Size>10</Size></Contents>
+</ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var paths []string
+	ranges := map[string]bool{}
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.EscapedPath())
+		ranges[r.Header.Get("Range")] = true
+		if strings.HasSuffix(r.URL.Path, "bad.js") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, "a")
+	}))
+	defer cdn.Close()
+
+	resultPath := "ossutil-test-prefetch-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	retryTimes := "2"
+	cdnDomain := cdn.URL
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRetryTimes:      &retryTimes,
+		OptionCDNDomain:       &cdnDomain,
+	}
+
+	// the directory object is skipped, the failed object is retried
+	_, err = cm.RunCommand("prefetch", []string{"oss://bucket/static/"}, options)
+	c.Assert(err, NotNil)
+	sort.Strings(paths)
+	c.Assert(paths, DeepEquals, []string{"/static/a.js", "/static/bad.js", "/static/bad.js", "/static/img/b%20c.png"})
+	c.Assert(ranges, DeepEquals, map[string]bool{"bytes=0-0": true})
+	data, err := ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "failed\t"+cdn.URL+"/static/bad.js"), Equals, true)
+	c.Assert(strings.Contains(string(data), "prefetched:2\tfailed:1"), Equals, true)
+
+	// --range
+	byteRange := "0-"
+	options[OptionRange] = &byteRange
+	ranges = map[string]bool{}
+	cm.RunCommand("prefetch", []string{"oss://bucket/static/"}, options)
+	c.Assert(ranges, DeepEquals, map[string]bool{"bytes=0-": true})
+
+	byteRange = "a-1"
+	_, err = cm.RunCommand("prefetch", []string{"oss://bucket/static/"}, options)
+	c.Assert(err, NotNil)
+	byteRange = "-"
+	_, err = cm.RunCommand("prefetch", []string{"oss://bucket/static/"}, options)
+	c.Assert(err, NotNil)
+
+	// --cdn-domain is required and has no path
+	byteRange = ""
+	cdnDomain = ""
+	_, err = cm.RunCommand("prefetch", []string{"oss://bucket/static/"}, options)
+	c.Assert(err, NotNil)
+	cdnDomain = "cdn.example.com/static"
+	_, err = cm.RunCommand("prefetch", []string{"oss://bucket/static/"}, options)
+	c.Assert(err, NotNil)
+
+	cdnURL, err := parseCDNDomain("cdn.example.com/")
+	c.Assert(err, IsNil)
+	c.Assert(cdnURL.String(), Equals, "https://cdn.example.com")
+}
@@ -114,6 +114,12 @@ var specChineseProbe = SpecText{
     4) ossutil probe --probe-item item_value --bucketname bucket-name [--object=object_name]
        该功能通过选项--probe-item不同的取值,可以实现不同的探测功能,目前取值有cycle-symlink, upload-speed, download-speed, download-time
     分别表示本地死链检测, 探测上传带宽, 探测下载带宽, 探测下载时间
+
+    5) ossutil probe --probe-item diagnose [--bucketname bucket-name]
+        该用法对endpoint进行网络诊断，包括代理检测、DNS解析、TCP和TLS(https时)握手耗时、网卡MTU以及
+    路径MTU探测;如果指定了--bucketname，还会上传并下载4KB和4MB的临时object以测量延迟和吞吐，
+    结束后删除临时object。诊断报告、ossutil日志的最后1MB以及最近的probe日志会打包到当前目录下的
+    ossutil-diagnose-时间.zip文件中，可以直接附加到工单中
 `,
 
 	sampleText: ` 
@@ -161,6 +167,9 @@ var specChineseProbe = SpecText{
     
     15) 探测下载带宽, object要已经存在,且大小最好超过5M
         ossutil probe --probe-item download-speed --bucketname bucket-name --object object_name
+
+    16) 网络诊断并生成诊断包
+        ossutil probe --probe-item diagnose --bucketname bucket-name
 `,
 }
 
@@ -253,6 +262,14 @@ Usage:
         You can implement different detection functions by using the value of the option --probe-item.
     The current values are cycle-symlink, upload-speed, download-speedh and download-time which represents local dead link detection,
     probe upload bandwidth, probe download bandwidth and probe download time
+
+    5) ossutil probe --probe-item diagnose [--bucketname bucket-name]
+        The usage runs the network diagnostics of the endpoint, including the proxy detection, the 
+    DNS resolution, the time of TCP and TLS(if https) handshake, the MTU of the network interfaces and 
+    the path MTU probe. If --bucketname is specified, 4KB and 4MB temporary objects are uploaded and 
+    downloaded to measure the latency and the throughput, and they are deleted at the end. The report, 
+    the last 1MB of ossutil log and the recent probe logs are packaged into ossutil-diagnose-time.zip in 
+    the current directory, which can be attached to the support tickets
 `,
 
 	sampleText: ` 
@@ -300,6 +317,9 @@ Usage:
     
     15) Detect download bandwidth, object must already exist, and the size is better than 5M
         ossutil probe --probe-item download-speed --bucketname bucket-name --object object_name
+
+    16) Run the network diagnostics and generate the diagnostics archive
+        ossutil probe --probe-item diagnose --bucketname bucket-name
 `,
 }
 
@@ -327,13 +347,11 @@ type ProbeCommand struct {
 
 var probeCommand = ProbeCommand{
 	command: Command{
-		name:        "probe",
-		nameAlias:   []string{"probe"},
-		minArgc:     0,
-		maxArgc:     MaxInt,
-		specChinese: specChineseProbe,
-		specEnglish: specEnglishProbe,
-		group:       GroupTypeNormalCommand,
+		name:      "probe",
+		nameAlias: []string{"probe"},
+		minArgc:   0,
+		maxArgc:   MaxInt,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -360,6 +378,11 @@ var probeCommand = ProbeCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -457,8 +480,8 @@ func (pc *ProbeCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (pc *ProbeCommand) Init(args []string, options OptionMapType) error {
-	err := pc.command.Init(args, options, pc)
+func (pc *ProbeCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	err := pc.command.Init(ctx, args, options, pc)
 	if err == nil {
 		return nil
 	}
@@ -504,6 +527,8 @@ func (pc *ProbeCommand) RunCommand() error {
 			err = pc.DetectBandWidth()
 		} else if pc.pbOption.probeItem == "download-time" {
 			err = pc.DetectDownloadTime()
+		} else if pc.pbOption.probeItem == probeItemDiagnose {
+			err = pc.probeDiagnose()
 		} else {
 			err = fmt.Errorf("not support %s", pc.pbOption.probeItem)
 		}
@@ -882,7 +907,7 @@ func (pc *ProbeCommand) downloadWithHttpUrl() error {
 
 	sizeStat, err := os.Stat(downloadFileName)
 	if err == nil {
-		bConitnue := confirm(downloadFileName)
+		bConitnue := pc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", downloadFileName))
 		if !bConitnue {
 			return nil
 		}
@@ -956,14 +981,14 @@ func (pc *ProbeCommand) probeDownloadObject(srcURL CloudURL, bDeleteObject bool)
 	} else {
 		_, err := os.Stat(downloadFileName)
 		if err == nil {
-			bConitnue := confirm(downloadFileName)
+			bConitnue := pc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", downloadFileName))
 			if !bConitnue {
 				return nil
 			}
 		}
 	}
 
-	bucket, err := pc.command.ossBucket(srcURL.bucket)
+	bucket, err := pc.command.cloudBucket(srcURL)
 	if err != nil {
 		return fmt.Errorf("bucket:%s,probeDownloadObject error,%s", srcURL.bucket, err.Error())
 	}
@@ -1157,15 +1182,6 @@ func pingProcess(logFile *os.File, instruction string, args ...string) {
 	c.Run()
 }
 
-func confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("probe: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (pc *ProbeCommand) probeUpload() error {
 	upMode := pc.pbOption.upMode
 	if upMode == "" {
@@ -1265,7 +1281,7 @@ func (pc *ProbeCommand) probeUpload() error {
 		if bDeleteObject {
 			return fmt.Errorf("oss temp object %s exist,please try again", objectName)
 		} else {
-			bConitnue := confirm(objectName)
+			bConitnue := pc.command.confirmOperation(fmt.Sprintf("overwrite the existing object %s", objectName))
 			if !bConitnue {
 				return nil
 			}
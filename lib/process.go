@@ -0,0 +1,367 @@
+package lib
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseProcess = SpecText{
+	synopsisText: "对object进行图片处理等数据处理，结果保存为object或者下载到本地",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil process oss://bucket/object --action action [--save-as oss://bucket/object | -o file] [-r] [-j jobs]
+`,
+
+	detailHelpText: `
+    该命令使用--action指定的x-oss-process参数处理object，如：image/resize,w_800/format,webp，
+    处理的结果可以：
+        --save-as: 通过sys/saveas持久化为oss中的object，目标bucket必须与源bucket位于同一个region
+        -o, --output-file: 下载到本地文件
+    两者必须且只能指定一个。
+
+    如果指定了-r选项，则处理cloud_url前缀下的所有object，此时--save-as和-o表示目标的前缀或者本地
+    目录，每个object的结果名称为目标前缀加上object相对于cloud_url的名称，可以用于批量生成缩略图。
+    并发数由-j选项指定，每个object失败时会重试，次数由--retry-times指定。
+`,
+
+	sampleText: `
+    1) 将图片缩放为800像素宽并转换为webp格式，保存为oss://bucket/thumbs/photo.webp
+       ossutil process oss://bucket/photo.jpg --action "image/resize,w_800/format,webp" --save-as oss://bucket/thumbs/photo.webp
+
+    2) 处理后下载到本地文件
+       ossutil process oss://bucket/photo.jpg --action "image/resize,w_800/format,webp" -o local.webp
+
+    3) 为photos/下的所有图片生成缩略图，保存到thumbs/下
+       ossutil process oss://bucket/photos/ --action "image/resize,w_200" --save-as oss://bucket/thumbs/ -r -j 10
+`,
+}
+
+var specEnglishProcess = SpecText{
+	synopsisText: "Process objects such as image processing, save the result as objects or download it",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil process oss://bucket/object --action action [--save-as oss://bucket/object | -o file] [-r] [-j jobs]
+`,
+
+	detailHelpText: `
+    The command processes the object with the x-oss-process parameter specified by --action, e.g.,
+    image/resize,w_800/format,webp, and the result can be:
+        --save-as: persisted as an object in oss by sys/saveas, the destination bucket must be in
+                   the same region as the source bucket
+        -o, --output-file: downloaded to the local file
+    One and only one of them must be specified.
+
+    If -r option is specified, all the objects under the prefix of cloud_url are processed, --save-as
+    and -o are the destination prefix or the local directory, the result name of each object is the
+    destination prefix followed by the object name relative to cloud_url, which is useful to generate
+    thumbnails in batch. The concurrency is specified by -j option, each object is retried when it
+    fails, the retry times is specified by --retry-times.
+`,
+
+	sampleText: `
+    1) resize the image to 800 pixels wide and convert it to webp, save it as oss://bucket/thumbs/photo.webp
+       ossutil process oss://bucket/photo.jpg --action "image/resize,w_800/format,webp" --save-as oss://bucket/thumbs/photo.webp
+
+    2) download the processed result to the local file
+       ossutil process oss://bucket/photo.jpg --action "image/resize,w_800/format,webp" -o local.webp
+
+    3) generate the thumbnails of the images under photos/ into thumbs/
+       ossutil process oss://bucket/photos/ --action "image/resize,w_200" --save-as oss://bucket/thumbs/ -r -j 10
+`,
+}
+
+type processTask struct {
+	object string
+	dest   string
+}
+
+/*
+ * Put same type variables together to make them 64bits alignment to avoid
+ * atomic.AddInt64() panic
+ * Please guarantee the alignment if you add new filed
+ */
+type processOptionType struct {
+	okNum      int64
+	errNum     int64
+	routines   int64
+	retryTimes int64
+	cloudURL   CloudURL
+	action     string
+	saveAs     CloudURL
+	outputFile string
+	recursive  bool
+}
+
+type ProcessCommand struct {
+	command       Command
+	processOption processOptionType
+}
+
+var processCommand = ProcessCommand{
+	command: Command{
+		name:      "process",
+		nameAlias: []string{"process"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionRecursion,
+			OptionAction,
+			OptionSaveAs,
+			OptionOutputFile,
+			OptionEncodingType,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (pc *ProcessCommand) formatHelpForWhole() string {
+	return pc.command.formatHelpForWhole()
+}
+
+func (pc *ProcessCommand) formatIndependHelp() string {
+	return pc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (pc *ProcessCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return pc.command.Init(ctx, args, options, pc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (pc *ProcessCommand) RunCommand() error {
+	// clear for go tests
+	pc.processOption.okNum = 0
+	pc.processOption.errNum = 0
+
+	encodingType, _ := GetString(OptionEncodingType, pc.command.options)
+	cloudURL, err := CloudURLFromString(pc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	if cloudURL.bucket == "" {
+		return fmt.Errorf("invalid cloud url: %s, miss bucket", pc.command.args[0])
+	}
+	pc.processOption.cloudURL = cloudURL
+	pc.processOption.recursive, _ = GetBool(OptionRecursion, pc.command.options)
+	if !pc.processOption.recursive && cloudURL.object == "" {
+		return fmt.Errorf("invalid cloud url: %s, miss object", pc.command.args[0])
+	}
+
+	pc.processOption.action, _ = GetString(OptionAction, pc.command.options)
+	if pc.processOption.action == "" {
+		return fmt.Errorf("--action is empty, e.g., --action \"image/resize,w_800\"")
+	}
+
+	saveAs, _ := GetString(OptionSaveAs, pc.command.options)
+	pc.processOption.outputFile, _ = GetString(OptionOutputFile, pc.command.options)
+	if (saveAs == "") == (pc.processOption.outputFile == "") {
+		return fmt.Errorf("one and only one of --save-as and --output-file must be specified")
+	}
+	if saveAs != "" {
+		if pc.processOption.saveAs, err = CloudURLFromString(saveAs, encodingType); err != nil {
+			return err
+		}
+		if pc.processOption.saveAs.bucket == "" {
+			return fmt.Errorf("invalid --save-as: %s, miss bucket", saveAs)
+		}
+		if !pc.processOption.recursive && pc.processOption.saveAs.object == "" {
+			return fmt.Errorf("invalid --save-as: %s, miss object", saveAs)
+		}
+	}
+
+	pc.processOption.routines, _ = GetInt(OptionRoutines, pc.command.options)
+	if pc.processOption.routines <= 0 {
+		pc.processOption.routines = 1
+	}
+	pc.processOption.retryTimes, _ = GetInt(OptionRetryTimes, pc.command.options)
+
+	bucket, err := pc.command.cloudBucket(cloudURL)
+	if err != nil {
+		return err
+	}
+
+	if !pc.processOption.recursive {
+		task := processTask{object: cloudURL.object, dest: pc.processOption.outputFile}
+		if saveAs != "" {
+			task.dest = pc.processOption.saveAs.object
+		}
+		if err = pc.processObjectRetry(bucket, task); err != nil {
+			return ObjectError{err, cloudURL.bucket, cloudURL.object}
+		}
+		fmt.Printf("%s processed to %s\n", cloudURL.objectURL(cloudURL.object), pc.destString(task))
+		return nil
+	}
+
+	chTasks := make(chan processTask, ChannelBuf)
+	chError := make(chan error, pc.processOption.routines)
+	chListError := make(chan error, 1)
+	go pc.processProducer(bucket, chTasks, chListError)
+	for i := 0; int64(i) < pc.processOption.routines; i++ {
+		go pc.processConsumer(bucket, chTasks, chError)
+	}
+
+	completed := 0
+	var listErr error
+	for int64(completed) <= pc.processOption.routines {
+		select {
+		case err := <-chListError:
+			if err != nil {
+				listErr = err
+			}
+			completed++
+		case <-chError:
+			completed++
+		}
+	}
+
+	fmt.Printf("\r%s\r", clearStr)
+	fmt.Printf("succeed:%d\tfailed:%d\n", pc.processOption.okNum, pc.processOption.errNum)
+	if listErr != nil {
+		return listErr
+	}
+	if pc.processOption.errNum > 0 {
+		return fmt.Errorf("%d object(s) failed to be processed, see more information in the log", pc.processOption.errNum)
+	}
+	return nil
+}
+
+func (pc *ProcessCommand) processProducer(bucket *oss.Bucket, chTasks chan<- processTask, chListError chan<- error) {
+	defer close(chTasks)
+	prefix := pc.processOption.cloudURL.object
+	continuationToken := ""
+	for {
+		lor, err := bucket.ListObjectsV2(pc.command.withContext([]oss.Option{oss.Prefix(prefix), oss.ContinuationToken(continuationToken), oss.MaxKeys(1000)})...)
+		if err != nil {
+			chListError <- err
+			return
+		}
+		for _, object := range lor.Objects {
+			if strings.HasSuffix(object.Key, "/") {
+				continue
+			}
+			chTasks <- processTask{object: object.Key, dest: pc.recursiveDest(object.Key)}
+		}
+		if !lor.IsTruncated {
+			break
+		}
+		continuationToken = lor.NextContinuationToken
+	}
+	chListError <- nil
+}
+
+// recursiveDest returns the destination object or local file of the object under the prefix
+func (pc *ProcessCommand) recursiveDest(object string) string {
+	relative := strings.TrimPrefix(object, pc.processOption.cloudURL.object)
+	if pc.processOption.outputFile == "" {
+		return pc.processOption.saveAs.object + relative
+	}
+	return filepath.Join(pc.processOption.outputFile, filepath.FromSlash(relative))
+}
+
+func (pc *ProcessCommand) processConsumer(bucket *oss.Bucket, chTasks <-chan processTask, chError chan<- error) {
+	for task := range chTasks {
+		if err := pc.processObjectRetry(bucket, task); err != nil {
+			atomic.AddInt64(&pc.processOption.errNum, 1)
+			LogError("process %s to %s error:%s\n", bucketObjectURL(bucket, task.object), pc.destString(task), err.Error())
+		} else {
+			atomic.AddInt64(&pc.processOption.okNum, 1)
+			LogInfo("process %s to %s success\n", bucketObjectURL(bucket, task.object), pc.destString(task))
+		}
+		fmt.Printf("\rprocessed:%d\tfailed:%d", atomic.LoadInt64(&pc.processOption.okNum), atomic.LoadInt64(&pc.processOption.errNum))
+	}
+	chError <- nil
+}
+
+func (pc *ProcessCommand) destString(task processTask) string {
+	if pc.processOption.outputFile != "" {
+		return task.dest
+	}
+	return pc.processOption.saveAs.objectURL(task.dest)
+}
+
+func (pc *ProcessCommand) processObjectRetry(bucket *oss.Bucket, task processTask) error {
+	for i := 1; ; i++ {
+		err := pc.processObject(bucket, task)
+		if err == nil {
+			return nil
+		}
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= pc.processOption.retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return err
+		}
+		if err := pc.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+func (pc *ProcessCommand) processObject(bucket *oss.Bucket, task processTask) error {
+	if pc.processOption.outputFile != "" {
+		if dir := filepath.Dir(task.dest); dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+		return bucket.GetObjectToFile(task.object, task.dest, oss.Process(pc.processOption.action))
+	}
+
+	result, err := bucket.ProcessObject(task.object, saveAsProcess(pc.processOption.action, pc.processOption.saveAs.bucket, task.dest))
+	if err != nil {
+		return err
+	}
+	if result.Status != "" && !strings.EqualFold(result.Status, "OK") {
+		return fmt.Errorf("process status:%s", result.Status)
+	}
+	return nil
+}
+
+// saveAsProcess returns the x-oss-process which persists the result by sys/saveas,
+// the object and the bucket are url safe base64 encoded without padding
+func saveAsProcess(action, bucket, object string) string {
+	return fmt.Sprintf("%s|sys/saveas,o_%s,b_%s", action, base64.RawURLEncoding.EncodeToString([]byte(object)),
+		base64.RawURLEncoding.EncodeToString([]byte(bucket)))
+}
@@ -0,0 +1,504 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseProcessAsync = SpecText{
+	synopsisText: "提交视频截帧、文档转换等异步数据处理任务",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil process-async oss://bucket/object --action action --save-as oss://bucket/object [--notify-topic topic]
+`,
+
+	detailHelpText: `
+    该命令使用x-oss-async-process提交异步数据处理任务，--action为处理参数，如：
+    video/convert,f_mp4,vcodec_h265，处理结果通过sys/saveas保存为--save-as指定的object，
+    目标bucket必须与源bucket位于同一个region。指定--notify-topic时，任务完成后向该MNS主题发送通知。
+
+    提交成功后输出任务的TaskId、EventId和RequestId，并将任务记录到--output-dir目录下的
+    ossutil_process_async.tasks文件中，之后可以使用process-status命令查询任务的状态。
+`,
+
+	sampleText: `
+    1) 提交视频转码任务
+       ossutil process-async oss://bucket/video.avi --action "video/convert,f_mp4,vcodec_h265" --save-as oss://bucket/video.mp4
+
+    2) 提交任务，完成后通知到MNS主题
+       ossutil process-async oss://bucket/video.avi --action "video/convert,f_mp4,vcodec_h265" --save-as oss://bucket/video.mp4 --notify-topic topic1
+`,
+}
+
+var specEnglishProcessAsync = SpecText{
+	synopsisText: "Submit async data processing jobs such as video snapshot and document conversion",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil process-async oss://bucket/object --action action --save-as oss://bucket/object [--notify-topic topic]
+`,
+
+	detailHelpText: `
+    The command submits the async data processing job by x-oss-async-process, --action is the
+    processing parameter, e.g., video/convert,f_mp4,vcodec_h265, the result is saved as the object
+    specified by --save-as through sys/saveas, the destination bucket must be in the same region as
+    the source bucket. If --notify-topic is specified, a notification is sent to the MNS topic when
+    the job is done.
+
+    After the job is submitted, its TaskId, EventId and RequestId are printed, and the job is recorded
+    in ossutil_process_async.tasks under --output-dir, then process-status command can be used to
+    query the status of the job.
+`,
+
+	sampleText: `
+    1) submit the video transcoding job
+       ossutil process-async oss://bucket/video.avi --action "video/convert,f_mp4,vcodec_h265" --save-as oss://bucket/video.mp4
+
+    2) submit the job and notify the MNS topic when it's done
+       ossutil process-async oss://bucket/video.avi --action "video/convert,f_mp4,vcodec_h265" --save-as oss://bucket/video.mp4 --notify-topic topic1
+`,
+}
+
+var specChineseProcessStatus = SpecText{
+	synopsisText: "查询process-async提交的异步数据处理任务的状态",
+
+	paramText: "task_id [options]",
+
+	syntaxText: `
+    ossutil process-status task_id [--wait] [--max-duration duration]
+`,
+
+	detailHelpText: `
+    oss没有提供查询异步处理任务的接口，该命令从--output-dir目录下的ossutil_process_async.tasks文件中
+    找到process-async提交的任务，process-async提交时记录了结果object的ETag(不存在时为空)，该命令
+    检查结果object是否已经生成或者ETag已经改变，输出任务的状态：
+        running: 结果object还未生成
+        succeeded: 结果object已经生成，该状态会记录到tasks文件中，之后的查询不再访问oss
+    任务失败时结果object不会生成，请通过--notify-topic的通知获取失败原因。
+
+    如果指定了--wait选项，则每隔5秒查询一次，直到任务完成，或者超过--max-duration指定的时长(默认
+    为1小时)，超时或者Ctrl-C后命令返回错误。
+`,
+
+	sampleText: `
+    1) 查询任务状态
+       ossutil process-status 7a5e6f8b9c
+
+    2) 等待任务完成，最多等待30分钟
+       ossutil process-status 7a5e6f8b9c --wait --max-duration 30m
+`,
+}
+
+var specEnglishProcessStatus = SpecText{
+	synopsisText: "Query the status of the async data processing job submitted by process-async",
+
+	paramText: "task_id [options]",
+
+	syntaxText: `
+    ossutil process-status task_id [--wait] [--max-duration duration]
+`,
+
+	detailHelpText: `
+    oss provides no api to query the async processing jobs, the command finds the job submitted by
+    process-async in ossutil_process_async.tasks under --output-dir, process-async records the ETag
+    of the result object(empty if it doesn't exist) when submitting, the command checks whether the
+    result object is generated or its ETag is changed, and prints the status of the job:
+        running: the result object is not generated yet
+        succeeded: the result object is generated, the status is recorded in the tasks file, and the
+                   later queries don't access oss
+    The result object is not generated if the job fails, please get the reason from the notification
+    of --notify-topic.
+
+    If --wait option is specified, the status is queried every 5 seconds until the job is done, or
+    the duration specified by --max-duration(1 hour by default) is exceeded, the command returns error
+    after the timeout or Ctrl-C.
+`,
+
+	sampleText: `
+    1) query the status of the job
+       ossutil process-status 7a5e6f8b9c
+
+    2) wait for the job to be done at most 30 minutes
+       ossutil process-status 7a5e6f8b9c --wait --max-duration 30m
+`,
+}
+
+const (
+	processAsyncTasksFile     = "ossutil_process_async.tasks"
+	processStatusRunning      = "running"
+	processStatusSucceeded    = "succeeded"
+	processStatusPollInterval = 5 * time.Second
+	processStatusMaxWait      = time.Hour
+)
+
+// processAsyncTask is the record of the async processing job in the tasks file
+type processAsyncTask struct {
+	TaskID     string `json:"taskId"`
+	EventID    string `json:"eventId"`
+	RequestID  string `json:"requestId"`
+	Source     string `json:"source"`
+	Dest       string `json:"dest"`
+	Action     string `json:"action"`
+	SubmitTime int64  `json:"submitTime"`
+	DestETag   string `json:"destETag"`
+	Status     string `json:"status,omitempty"`
+}
+
+type ProcessAsyncCommand struct {
+	command Command
+}
+
+var processAsyncCommand = ProcessAsyncCommand{
+	command: Command{
+		name:      "process-async",
+		nameAlias: []string{"process-async"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionAction,
+			OptionSaveAs,
+			OptionNotifyTopic,
+			OptionOutputDir,
+			OptionEncodingType,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (pac *ProcessAsyncCommand) formatHelpForWhole() string {
+	return pac.command.formatHelpForWhole()
+}
+
+func (pac *ProcessAsyncCommand) formatIndependHelp() string {
+	return pac.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (pac *ProcessAsyncCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return pac.command.Init(ctx, args, options, pac)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (pac *ProcessAsyncCommand) RunCommand() error {
+	encodingType, _ := GetString(OptionEncodingType, pac.command.options)
+	cloudURL, err := ObjectURLFromString(pac.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+
+	action, _ := GetString(OptionAction, pac.command.options)
+	if action == "" {
+		return fmt.Errorf("--action is empty, e.g., --action \"video/convert,f_mp4,vcodec_h265\"")
+	}
+	saveAs, _ := GetString(OptionSaveAs, pac.command.options)
+	if saveAs == "" {
+		return fmt.Errorf("--save-as is empty, the result of the async processing must be saved as an object")
+	}
+	destURL, err := ObjectURLFromString(saveAs, encodingType)
+	if err != nil {
+		return err
+	}
+	topic, _ := GetString(OptionNotifyTopic, pac.command.options)
+
+	bucket, err := pac.command.cloudBucket(cloudURL)
+	if err != nil {
+		return err
+	}
+
+	// the etag of the existing result object tells whether the job overwrites it
+	destBucket, err := pac.command.cloudBucket(destURL)
+	if err != nil {
+		return err
+	}
+	destETag, err := processAsyncDestETag(destBucket, destURL.object, pac.command.withContext(nil)...)
+	if err != nil {
+		return ObjectError{err, destURL.bucket, destURL.object}
+	}
+
+	process := asyncSaveAsProcess(action, destURL.bucket, destURL.object, topic)
+	retryTimes, _ := GetInt(OptionRetryTimes, pac.command.options)
+	var result oss.AsyncProcessObjectResult
+	for i := 1; ; i++ {
+		result, err = bucket.AsyncProcessObject(cloudURL.object, process)
+		if err == nil || int64(i) >= retryTimes {
+			break
+		}
+		if serviceError, ok := err.(oss.ServiceError); ok && serviceError.StatusCode < 500 {
+			break
+		}
+	}
+	if err != nil {
+		return ObjectError{err, cloudURL.bucket, cloudURL.object}
+	}
+
+	task := processAsyncTask{
+		TaskID:     result.TaskId,
+		EventID:    result.EventId,
+		RequestID:  result.RequestId,
+		Source:     cloudURL.objectURL(cloudURL.object),
+		Dest:       destURL.objectURL(destURL.object),
+		Action:     action,
+		SubmitTime: time.Now().Unix(),
+		DestETag:   destETag,
+	}
+	if err = appendProcessAsyncTask(pac.command.options, task); err != nil {
+		return fmt.Errorf("the job %s is submitted, but it's not recorded, %s", task.TaskID, err.Error())
+	}
+
+	fmt.Printf("TaskId:%s\n", task.TaskID)
+	fmt.Printf("EventId:%s\n", task.EventID)
+	fmt.Printf("RequestId:%s\n", task.RequestID)
+	return nil
+}
+
+// asyncSaveAsProcess returns the x-oss-async-process which saves the result by sys/saveas
+// and notifies the topic if it's not empty
+func asyncSaveAsProcess(action, bucket, object, topic string) string {
+	process := fmt.Sprintf("%s|sys/saveas,b_%s,o_%s", action, base64.RawURLEncoding.EncodeToString([]byte(bucket)),
+		base64.RawURLEncoding.EncodeToString([]byte(object)))
+	if topic != "" {
+		process += "/notify,topic_" + base64.RawURLEncoding.EncodeToString([]byte(topic))
+	}
+	return process
+}
+
+func processAsyncTasksPath(options OptionMapType) string {
+	outputDir, _ := GetString(OptionOutputDir, options)
+	if outputDir == "" {
+		outputDir = DefaultOutputDir
+	}
+	return filepath.Join(outputDir, processAsyncTasksFile)
+}
+
+func appendProcessAsyncTask(options OptionMapType, task processAsyncTask) error {
+	path := processAsyncTasksPath(options)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// findProcessAsyncTask returns the last record of the task id
+func findProcessAsyncTask(options OptionMapType, taskID string) (processAsyncTask, error) {
+	path := processAsyncTasksPath(options)
+	f, err := os.Open(path)
+	if err != nil {
+		return processAsyncTask{}, fmt.Errorf("read the tasks of process-async error, %s", err.Error())
+	}
+	defer f.Close()
+
+	var found *processAsyncTask
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var task processAsyncTask
+		if err = json.Unmarshal(scanner.Bytes(), &task); err != nil {
+			return processAsyncTask{}, fmt.Errorf("invalid task at line %d of %s, %s", line, path, err.Error())
+		}
+		if task.TaskID == taskID {
+			found = &task
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return processAsyncTask{}, err
+	}
+	if found == nil {
+		return processAsyncTask{}, fmt.Errorf("task %s is not found in %s, it's recorded by process-async with the same --output-dir", taskID, path)
+	}
+	return *found, nil
+}
+
+type ProcessStatusCommand struct {
+	command Command
+}
+
+var processStatusCommand = ProcessStatusCommand{
+	command: Command{
+		name:      "process-status",
+		nameAlias: []string{"process-status"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionWait,
+			OptionMaxDuration,
+			OptionOutputDir,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (psc *ProcessStatusCommand) formatHelpForWhole() string {
+	return psc.command.formatHelpForWhole()
+}
+
+func (psc *ProcessStatusCommand) formatIndependHelp() string {
+	return psc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (psc *ProcessStatusCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return psc.command.Init(ctx, args, options, psc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (psc *ProcessStatusCommand) RunCommand() error {
+	task, err := findProcessAsyncTask(psc.command.options, psc.command.args[0])
+	if err != nil {
+		return err
+	}
+	wait, _ := GetBool(OptionWait, psc.command.options)
+	maxWait := processStatusMaxWait
+	if strDuration, _ := GetString(OptionMaxDuration, psc.command.options); strDuration != "" {
+		duration, err := time.ParseDuration(strDuration)
+		if err != nil || duration <= 0 {
+			return fmt.Errorf("invalid --max-duration: %s, the value should be positive duration, e.g., 90m, 2h", strDuration)
+		}
+		maxWait = duration
+	}
+	deadline := time.Now().Add(maxWait)
+
+	status := task.Status
+	if status != processStatusSucceeded {
+		if status, err = psc.waitStatus(task, wait, deadline); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("TaskId:%s\n", task.TaskID)
+	fmt.Printf("Source:%s\n", task.Source)
+	fmt.Printf("Dest:%s\n", task.Dest)
+	fmt.Printf("SubmitTime:%s\n", time.Unix(task.SubmitTime, 0).Format("2006-01-02 15:04:05"))
+	fmt.Printf("Status:%s\n", status)
+	return nil
+}
+
+// waitStatus queries the status of the task until it succeeds if wait is true, the succeeded status
+// is recorded in the tasks file
+func (psc *ProcessStatusCommand) waitStatus(task processAsyncTask, wait bool, deadline time.Time) (string, error) {
+	destURL, err := ObjectURLFromString(task.Dest, "")
+	if err != nil {
+		return "", err
+	}
+	bucket, err := psc.command.cloudBucket(destURL)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		etag, err := processAsyncDestETag(bucket, destURL.object, psc.command.withContext(nil)...)
+		if err != nil {
+			return "", ObjectError{err, destURL.bucket, destURL.object}
+		}
+		if etag != "" && etag != task.DestETag {
+			task.Status = processStatusSucceeded
+			if err = appendProcessAsyncTask(psc.command.options, task); err != nil {
+				return "", fmt.Errorf("the task %s is succeeded, but the status is not recorded, %s", task.TaskID, err.Error())
+			}
+			return processStatusSucceeded, nil
+		}
+		if !wait {
+			return processStatusRunning, nil
+		}
+		if time.Now().Add(processStatusPollInterval).After(deadline) {
+			return "", fmt.Errorf("task %s is still %s after --max-duration", task.TaskID, processStatusRunning)
+		}
+		if err = psc.command.waitRetry(processStatusPollInterval); err != nil {
+			return "", err
+		}
+	}
+}
+
+// processAsyncDestETag returns the etag of the result object, empty if it doesn't exist
+func processAsyncDestETag(bucket *oss.Bucket, object string, options ...oss.Option) (string, error) {
+	meta, err := bucket.GetObjectMeta(object, options...)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return meta.Get(oss.HTTPHeaderEtag), nil
+}
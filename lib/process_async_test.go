@@ -0,0 +1,144 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestProcessAsyncAndStatus(c *C) {
+	c.Assert(asyncSaveAsProcess("video/convert,f_mp4", "test", "test.mp4", ""), Equals, "video/convert,f_mp4|sys/saveas,b_dGVzdA,o_dGVzdC5tcDQ")
+	c.Assert(asyncSaveAsProcess("video/convert,f_mp4", "test", "test.mp4", "topic"), Equals, "video/convert,f_mp4|sys/saveas,b_dGVzdA,o_dGVzdC5tcDQ/notify,topic_dG9waWM")
+
+	var mu sync.Mutex
+	var submitted string
+	destETag := ""
+	heads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			submitted = r.URL.Path + " " + string(body)
+			fmt.Fprint(w, `{"EventId":"event-1","RequestId":"request-1","TaskId":"task-1"}`)
+		default:
+			heads++
+			if destETag == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("ETag", destETag)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	action := "video/convert,f_mp4"
+	saveAs := "oss://bucket/out/video.mp4"
+	topic := "topic"
+	outputDir := "ossutil-process-async-" + randLowStr(5)
+	defer os.RemoveAll(outputDir)
+	retryTimes := int64(1)
+	forcePathStyle := true
+	wait := false
+	capture := func(run func() error) error {
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = run()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		return err
+	}
+
+	pac := &ProcessAsyncCommand{}
+	pac.command.args = []string{"oss://bucket/video.avi"}
+	pac.command.options = OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionAction:          &action,
+		OptionSaveAs:          &saveAs,
+		OptionNotifyTopic:     &topic,
+		OptionOutputDir:       &outputDir,
+		OptionRetryTimes:      &retryTimes,
+		OptionForcePathStyle:  &forcePathStyle,
+	}
+	c.Assert(capture(pac.RunCommand), IsNil)
+	c.Assert(submitted, Equals, "/bucket/video.avi x-oss-async-process="+asyncSaveAsProcess(action, "bucket", "out/video.mp4", topic))
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "TaskId:task-1"), Equals, true)
+
+	task, err := findProcessAsyncTask(pac.command.options, "task-1")
+	c.Assert(err, IsNil)
+	c.Assert(task.EventID, Equals, "event-1")
+	c.Assert(task.Source, Equals, "oss://bucket/video.avi")
+	c.Assert(task.Dest, Equals, saveAs)
+	c.Assert(task.DestETag, Equals, "")
+
+	psc := &ProcessStatusCommand{}
+	psc.command.options = OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionOutputDir:       &outputDir,
+		OptionWait:            &wait,
+		OptionForcePathStyle:  &forcePathStyle,
+	}
+
+	// unknown task
+	psc.command.args = []string{"task-2"}
+	c.Assert(capture(psc.RunCommand), NotNil)
+
+	psc.command.args = []string{"task-1"}
+	c.Assert(capture(psc.RunCommand), IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "Status:running"), Equals, true)
+
+	// --wait is bounded by --max-duration
+	wait = true
+	maxDuration := "1s"
+	psc.command.options[OptionMaxDuration] = &maxDuration
+	err = capture(psc.RunCommand)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "still running after --max-duration"), Equals, true)
+
+	destETag = `"etag-1"`
+	c.Assert(capture(psc.RunCommand), IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "Status:succeeded"), Equals, true)
+
+	// the succeeded status is recorded, oss is not queried again
+	heads = 0
+	c.Assert(capture(psc.RunCommand), IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "Status:succeeded"), Equals, true)
+	c.Assert(heads, Equals, 0)
+
+	// the job overwriting the existing object is running until the etag changes
+	c.Assert(capture(pac.RunCommand), IsNil)
+	task, err = findProcessAsyncTask(pac.command.options, "task-1")
+	c.Assert(err, IsNil)
+	c.Assert(task.DestETag, Equals, `"etag-1"`)
+	c.Assert(task.Status, Equals, "")
+	wait = false
+	c.Assert(capture(psc.RunCommand), IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "Status:running"), Equals, true)
+	destETag = `"etag-2"`
+	c.Assert(capture(psc.RunCommand), IsNil)
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "Status:succeeded"), Equals, true)
+
+	// the broken record is reported instead of being skipped
+	f, err := os.OpenFile(processAsyncTasksPath(pac.command.options), os.O_APPEND|os.O_WRONLY, 0664)
+	c.Assert(err, IsNil)
+	f.WriteString("{broken\n")
+	f.Close()
+	_, err = findProcessAsyncTask(pac.command.options, "task-1")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "invalid task at line"), Equals, true)
+}
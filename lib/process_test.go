@@ -0,0 +1,110 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestProcessObject(c *C) {
+	c.Assert(saveAsProcess("image/resize,w_100", "test", "test.jpg"), Equals, "image/resize,w_100|sys/saveas,o_dGVzdC5qcGc,b_dGVzdA")
+
+	var mu sync.Mutex
+	var processes []string
+	var getProcess string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		switch {
+		case r.Method == "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			processes = append(processes, r.URL.Path+" "+string(body))
+			fmt.Fprint(w, `{"bucket":"bucket","fileSize":10,"object":"thumbs/a.jpg","status":"OK"}`)
+		case query.Get("list-type") == "2":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>photos/</Key></Contents><Contents><Key>photos/a.jpg</Key></Contents><Contents><Key>photos/sub/b.jpg</Key></Contents>
+</ListBucketResult>`)
+		default:
+			getProcess = query.Get("x-oss-process")
+			fmt.Fprint(w, "processed")
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	action := "image/resize,w_100"
+	saveAs := "oss://bucket/thumbs/a.jpg"
+	outputFile := ""
+	recursive := false
+	routines := int64(2)
+	retryTimes := int64(1)
+	forcePathStyle := true
+	run := func(url string) error {
+		pc := &ProcessCommand{}
+		pc.command.args = []string{url}
+		pc.command.options = OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &str,
+			OptionAccessKeySecret: &str,
+			OptionAction:          &action,
+			OptionSaveAs:          &saveAs,
+			OptionOutputFile:      &outputFile,
+			OptionRecursion:       &recursive,
+			OptionRoutines:        &routines,
+			OptionRetryTimes:      &retryTimes,
+			OptionForcePathStyle:  &forcePathStyle,
+		}
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = pc.RunCommand()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		return err
+	}
+
+	// save as an object
+	c.Assert(run("oss://bucket/photos/a.jpg"), IsNil)
+	c.Assert(processes, DeepEquals, []string{"/bucket/photos/a.jpg x-oss-process=" + saveAsProcess(action, "bucket", "thumbs/a.jpg")})
+
+	// download to the local file
+	saveAs = ""
+	outputFile = "ossutil-process-" + randLowStr(5) + ".jpg"
+	defer os.Remove(outputFile)
+	c.Assert(run("oss://bucket/photos/a.jpg"), IsNil)
+	c.Assert(getProcess, Equals, action)
+	c.Assert(s.readFile(outputFile, c), Equals, "processed")
+
+	// both or neither of --save-as and --output-file
+	outputFile = ""
+	c.Assert(run("oss://bucket/photos/a.jpg"), NotNil)
+
+	// process the objects under the prefix
+	processes = nil
+	saveAs = "oss://bucket/thumbs/"
+	recursive = true
+	c.Assert(run("oss://bucket/photos/"), IsNil)
+	sort.Strings(processes)
+	c.Assert(processes, DeepEquals, []string{
+		"/bucket/photos/a.jpg x-oss-process=" + saveAsProcess(action, "bucket", "thumbs/a.jpg"),
+		"/bucket/photos/sub/b.jpg x-oss-process=" + saveAsProcess(action, "bucket", "thumbs/sub/b.jpg"),
+	})
+
+	saveAs = ""
+	outputFile = "ossutil-process-dir-" + randLowStr(5)
+	defer os.RemoveAll(outputFile)
+	c.Assert(run("oss://bucket/photos/"), IsNil)
+	c.Assert(s.readFile(filepath.Join(outputFile, "sub", "b.jpg"), c), Equals, "processed")
+	c.Assert(strings.Contains(s.readFile(resultPath, c), "succeed:2"), Equals, true)
+}
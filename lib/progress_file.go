@@ -0,0 +1,201 @@
+package lib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	progressStateRunning   = "running"
+	progressStateSucceeded = "succeeded"
+	progressStateFailed    = "failed"
+)
+
+// progressCurrentFile is the file being transferred
+type progressCurrentFile struct {
+	Name      string `json:"name"`
+	StartTime string `json:"startTime"`
+}
+
+// progressSnapshot is the content of --progress-file
+type progressSnapshot struct {
+	State          string                `json:"state"`
+	Operation      string                `json:"operation"`
+	PID            int                   `json:"pid"`
+	StartTime      string                `json:"startTime"`
+	UpdateTime     string                `json:"updateTime"`
+	ElapsedSeconds int64                 `json:"elapsedSeconds"`
+	TotalKnown     bool                  `json:"totalKnown"` // false while the files are still being scanned
+	BytesTotal     int64                 `json:"bytesTotal"`
+	BytesDone      int64                 `json:"bytesDone"`
+	FilesTotal     int64                 `json:"filesTotal"`
+	FilesDone      int64                 `json:"filesDone"`
+	FilesOK        int64                 `json:"filesOK"`
+	FilesSkipped   int64                 `json:"filesSkipped"`
+	FilesError     int64                 `json:"filesError"`
+	Speed          int64                 `json:"speed"`      // the average speed in bytes per second
+	ETASeconds     *int64                `json:"etaSeconds"` // null if it can't be estimated
+	CurrentFiles   []progressCurrentFile `json:"currentFiles"`
+	Error          string                `json:"error,omitempty"`
+}
+
+// progressFile writes the progress of cp or sync to a json file every processTickInterval seconds,
+// so that the supervisors can watch the health of the long jobs without parsing the progress bar.
+// The file is replaced by rename, the readers never see a partially written file
+type progressFile struct {
+	path      string
+	monitor   *CPMonitor
+	startTime time.Time
+	mu        sync.Mutex
+	current   map[string]time.Time
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func newProgressFile(path string, monitor *CPMonitor) *progressFile {
+	return &progressFile{path: path, monitor: monitor, current: map[string]time.Time{}}
+}
+
+// start writes the initial progress and begins the periodical updating, the error of the first
+// write is returned so that the invalid path is reported before the transfer
+func (pf *progressFile) start() error {
+	if pf == nil {
+		return nil
+	}
+	pf.startTime = time.Now()
+	if err := pf.write(progressStateRunning, nil); err != nil {
+		return err
+	}
+	pf.stop = make(chan struct{})
+	pf.done = make(chan struct{})
+	go func() {
+		defer close(pf.done)
+		ticker := time.NewTicker(time.Duration(processTickInterval) * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := pf.write(progressStateRunning, nil); err != nil {
+					LogError("write progress file %s error: %s\n", pf.path, err.Error())
+				}
+			case <-pf.stop:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// finish stops the updating and writes the final state of the job
+func (pf *progressFile) finish(err error) {
+	if pf == nil || pf.stop == nil {
+		return
+	}
+	close(pf.stop)
+	<-pf.done
+	state := progressStateSucceeded
+	if err != nil || pf.monitor.errNum > 0 {
+		state = progressStateFailed
+	}
+	if werr := pf.write(state, err); werr != nil {
+		LogError("write progress file %s error: %s\n", pf.path, werr.Error())
+	}
+}
+
+func (pf *progressFile) begin(name string) {
+	if pf == nil {
+		return
+	}
+	pf.mu.Lock()
+	pf.current[name] = time.Now()
+	pf.mu.Unlock()
+}
+
+func (pf *progressFile) end(name string) {
+	if pf == nil {
+		return
+	}
+	pf.mu.Lock()
+	delete(pf.current, name)
+	pf.mu.Unlock()
+}
+
+func (pf *progressFile) snapshot(state string, err error) progressSnapshot {
+	mu.RLock()
+	m := pf.monitor
+	snap := m.getSnapshot()
+	totalKnown := m.seekAheadEnd && m.seekAheadError == nil && !m.sizeUnknown
+	progress := progressSnapshot{
+		State:        state,
+		Operation:    m.getOPStr(),
+		PID:          os.Getpid(),
+		TotalKnown:   totalKnown,
+		BytesTotal:   max(m.totalSize, snap.dealSize),
+		BytesDone:    snap.dealSize,
+		FilesTotal:   max(m.totalNum, snap.dealNum),
+		FilesDone:    snap.dealNum,
+		FilesOK:      snap.fileNum + snap.dirNum,
+		FilesSkipped: snap.skipNum,
+		FilesError:   snap.errNum,
+		CurrentFiles: []progressCurrentFile{},
+	}
+	mu.RUnlock()
+
+	now := time.Now()
+	elapsed := now.Sub(pf.startTime)
+	progress.StartTime = pf.startTime.Format(time.RFC3339)
+	progress.UpdateTime = now.Format(time.RFC3339)
+	progress.ElapsedSeconds = int64(elapsed.Seconds())
+	if elapsed > 0 {
+		progress.Speed = int64(float64(snap.transferSize) / elapsed.Seconds())
+	}
+	if err != nil {
+		progress.Error = err.Error()
+	}
+
+	// the remaining time is estimated by the average speed of the bytes, or the files if the sizes are 0
+	if state == progressStateRunning && totalKnown {
+		var eta int64 = -1
+		if progress.BytesTotal > 0 && progress.BytesDone > 0 {
+			eta = int64(elapsed.Seconds() * float64(progress.BytesTotal-progress.BytesDone) / float64(progress.BytesDone))
+		} else if progress.BytesTotal == 0 && progress.FilesDone > 0 {
+			eta = int64(elapsed.Seconds() * float64(progress.FilesTotal-progress.FilesDone) / float64(progress.FilesDone))
+		}
+		if eta >= 0 {
+			progress.ETASeconds = &eta
+		}
+	} else if state != progressStateRunning {
+		var eta int64
+		progress.ETASeconds = &eta
+	}
+
+	pf.mu.Lock()
+	for name, startTime := range pf.current {
+		progress.CurrentFiles = append(progress.CurrentFiles, progressCurrentFile{name, startTime.Format(time.RFC3339)})
+	}
+	pf.mu.Unlock()
+	sort.Slice(progress.CurrentFiles, func(i, j int) bool {
+		return progress.CurrentFiles[i].Name < progress.CurrentFiles[j].Name
+	})
+	return progress
+}
+
+func (pf *progressFile) write(state string, jobErr error) error {
+	data, err := json.MarshalIndent(pf.snapshot(state, jobErr), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := pf.path + ".tmp"
+	if err = ioutil.WriteFile(tmpPath, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpPath, pf.path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
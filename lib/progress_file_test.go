@@ -0,0 +1,137 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestCopyProgressFile(c *C) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "PUT" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "/b") {
+			<-release
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<Error><Code>AccessDenied</Code><Message>denied</Message></Error>`)
+			return
+		}
+		w.Header().Set("X-Oss-Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum(body, crc64ECMATable), 10))
+	}))
+	defer server.Close()
+	var releaseOnce sync.Once
+	releaseAll := func() { releaseOnce.Do(func() { close(release) }) }
+	defer releaseAll()
+
+	dir := "ossutil-test-progress-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	s.createFile(filepath.Join(dir, "a"), strings.Repeat("a", 100), c)
+	s.createFile(filepath.Join(dir, "b"), strings.Repeat("b", 200), c)
+
+	oldInterval := processTickInterval
+	processTickInterval = 1
+	defer func() { processTickInterval = oldInterval }()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	routines := "2"
+	threshold := "1048576"
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	progressPath := "ossutil-test-progress-" + randLowStr(5) + ".json"
+	defer os.RemoveAll(cpDir)
+	defer os.RemoveAll(outputDir)
+	defer os.Remove(progressPath)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRecursion:        &recursive,
+		OptionForce:            &force,
+		OptionRoutines:         &routines,
+		OptionBigFileThreshold: &threshold,
+		OptionCheckpointDir:    &cpDir,
+		OptionOutputDir:        &outputDir,
+		OptionProgressFile:     &progressPath,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	readProgress := func() progressSnapshot {
+		var progress progressSnapshot
+		data, err := ioutil.ReadFile(progressPath)
+		c.Assert(err, IsNil)
+		c.Assert(json.Unmarshal(data, &progress), IsNil)
+		return progress
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cm.RunCommand("cp", []string{dir, "oss://bucket/"}, options)
+		done <- err
+	}()
+
+	// the file b being uploaded is reported while the job is running
+	var progress progressSnapshot
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := os.Stat(progressPath); err != nil {
+			continue
+		}
+		if progress = readProgress(); progress.FilesOK == 1 {
+			break
+		}
+	}
+	c.Assert(progress.State, Equals, progressStateRunning)
+	c.Assert(progress.Operation, Equals, "upload")
+	c.Assert(progress.FilesOK, Equals, int64(1))
+	c.Assert(progress.CurrentFiles, HasLen, 1)
+	c.Assert(progress.CurrentFiles[0].Name, Equals, filepath.Join(dir, "b"))
+	c.Assert(progress.TotalKnown, Equals, true)
+	c.Assert(progress.BytesTotal, Equals, int64(300))
+	c.Assert(progress.ETASeconds, NotNil)
+
+	releaseAll()
+	c.Assert(<-done, NotNil)
+	progress = readProgress()
+	c.Assert(progress.State, Equals, progressStateFailed)
+	c.Assert(progress.FilesTotal, Equals, int64(2))
+	c.Assert(progress.FilesDone, Equals, int64(2))
+	c.Assert(progress.FilesError, Equals, int64(1))
+	c.Assert(progress.CurrentFiles, HasLen, 0)
+	c.Assert(*progress.ETASeconds, Equals, int64(0))
+	c.Assert(progress.Error, Not(Equals), "")
+
+	// the progress file can't be written
+	invalidPath := filepath.Join(dir, "notexist", "progress.json")
+	options[OptionProgressFile] = &invalidPath
+	_, err = cm.RunCommand("cp", []string{dir, "oss://bucket/"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "write progress file"), Equals, true)
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -94,13 +95,11 @@ type ReadSymlinkCommand struct {
 
 var readSymlinkCommand = ReadSymlinkCommand{
 	command: Command{
-		name:        "read-symlink",
-		nameAlias:   []string{},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseReadSymlink,
-		specEnglish: specEnglishReadSymlink,
-		group:       GroupTypeNormalCommand,
+		name:      "read-symlink",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionEncodingType,
 			OptionConfigFile,
@@ -123,6 +122,11 @@ var readSymlinkCommand = ReadSymlinkCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -144,8 +148,8 @@ func (rc *ReadSymlinkCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (rc *ReadSymlinkCommand) Init(args []string, options OptionMapType) error {
-	return rc.command.Init(args, options, rc)
+func (rc *ReadSymlinkCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return rc.command.Init(ctx, args, options, rc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -171,7 +175,7 @@ func (rc *ReadSymlinkCommand) RunCommand() error {
 	}
 
 	symlinkOptions = append(symlinkOptions, rc.commonOptions...)
-	bucket, err := rc.command.ossBucket(cloudURL.bucket)
+	bucket, err := rc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
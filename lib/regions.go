@@ -0,0 +1,195 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseRegions = SpecText{
+	synopsisText: "列举oss的region及其endpoint",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil regions [--region region] [--output format]
+`,
+
+	detailHelpText: `
+    该命令调用DescribeRegions列举oss支持的所有region，并显示每个region的外网endpoint、内网endpoint
+    以及传输加速endpoint，便于脚本获取正确的endpoint。
+
+    如果指定了--region选项，则只显示该region，region可以是cn-hangzhou或者oss-cn-hangzhou的格式。
+    使用v4签名时，--region同时作为签名的region。
+
+    --output为json或者yaml时，以json数组或者yaml的格式输出，每个元素包含region、
+    internetEndpoint、internalEndpoint和accelerateEndpoint字段，默认为text。
+`,
+
+	sampleText: `
+    1) 列举所有的region
+       ossutil regions
+
+    2) 以json格式显示cn-hangzhou的endpoint
+       ossutil regions --region cn-hangzhou --output json
+`,
+}
+
+var specEnglishRegions = SpecText{
+	synopsisText: "List the regions of oss and their endpoints",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil regions [--region region] [--output format]
+`,
+
+	detailHelpText: `
+    The command calls DescribeRegions to list all the regions of oss, and shows the internet
+    endpoint, the internal endpoint and the accelerate endpoint of each region, so that the
+    scripts can discover the correct endpoints.
+
+    If --region option is specified, only the region is shown, the region can be in the format
+    of cn-hangzhou or oss-cn-hangzhou. --region is also the region of the v4 signature.
+
+    If --output is json or yaml, the result is printed as a json array or in yaml, each element
+    has the fields region, internetEndpoint, internalEndpoint and accelerateEndpoint, the
+    default is text.
+`,
+
+	sampleText: `
+    1) list all the regions
+       ossutil regions
+
+    2) show the endpoints of cn-hangzhou in json format
+       ossutil regions --region cn-hangzhou --output json
+`,
+}
+
+// regionEndpoints is the json output of a region
+type regionEndpoints struct {
+	Region             string `json:"region"`
+	InternetEndpoint   string `json:"internetEndpoint"`
+	InternalEndpoint   string `json:"internalEndpoint"`
+	AccelerateEndpoint string `json:"accelerateEndpoint"`
+}
+
+type RegionsCommand struct {
+	command Command
+}
+
+var regionsCommand = RegionsCommand{
+	command: Command{
+		name:      "regions",
+		nameAlias: []string{"regions"},
+		minArgc:   0,
+		maxArgc:   0,
+		group:     GroupTypeNormalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionOutput,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (rc *RegionsCommand) formatHelpForWhole() string {
+	return rc.command.formatHelpForWhole()
+}
+
+func (rc *RegionsCommand) formatIndependHelp() string {
+	return rc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (rc *RegionsCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return rc.command.Init(ctx, args, options, rc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (rc *RegionsCommand) RunCommand() error {
+	region, _ := GetString(OptionRegion, rc.command.options)
+	output, err := getOutputFormat(rc.command.options)
+	if err != nil {
+		return err
+	}
+
+	client, err := rc.command.ossClient("")
+	if err != nil {
+		return err
+	}
+
+	var options []oss.Option
+	if region != "" {
+		if !strings.HasPrefix(region, "oss-") {
+			region = "oss-" + region
+		}
+		options = append(options, oss.AddParam("regions", region))
+	}
+	result, err := rc.ossDescribeRegionsRetry(client, options...)
+	if err != nil {
+		return err
+	}
+
+	regions := make([]regionEndpoints, 0, len(result.Regions))
+	for _, info := range result.Regions {
+		regions = append(regions, regionEndpoints{
+			Region:             info.Region,
+			InternetEndpoint:   info.InternetEndpoint,
+			InternalEndpoint:   info.InternalEndpoint,
+			AccelerateEndpoint: info.AccelerateEndpoint,
+		})
+	}
+
+	if output.structured() {
+		return output.print(regions)
+	}
+
+	if len(regions) > 0 {
+		fmt.Printf("%-24s%s%-40s%s%-40s%s%s\n", "Region", FormatTAB, "InternetEndpoint", FormatTAB, "InternalEndpoint", FormatTAB, "AccelerateEndpoint")
+	}
+	for _, r := range regions {
+		fmt.Printf("%-24s%s%-40s%s%-40s%s%s\n", r.Region, FormatTAB, r.InternetEndpoint, FormatTAB, r.InternalEndpoint, FormatTAB, r.AccelerateEndpoint)
+	}
+	fmt.Printf("\nRegion Number is: %d\n", len(regions))
+	return nil
+}
+
+func (rc *RegionsCommand) ossDescribeRegionsRetry(client *oss.Client, options ...oss.Option) (oss.DescribeRegionsResult, error) {
+	retryTimes, _ := GetInt(OptionRetryTimes, rc.command.options)
+	for i := 1; ; i++ {
+		result, err := client.DescribeRegions(options...)
+		if err == nil || int64(i) >= retryTimes {
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestDescribeRegions(c *C) {
+	var queryRegion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queryRegion = r.URL.Query().Get("regions")
+		fmt.Fprint(w, `<RegionInfoList><RegionInfo><Region>oss-cn-hangzhou</Region>
+<InternetEndpoint>oss-cn-hangzhou.aliyuncs.com</InternetEndpoint>
+<InternalEndpoint>oss-cn-hangzhou-internal.aliyuncs.com</InternalEndpoint>
+<AccelerateEndpoint>oss-accelerate.aliyuncs.com</AccelerateEndpoint></RegionInfo></RegionInfoList>`)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	accessKeyID := "ak"
+	accessKeySecret := "sk"
+	region := ""
+	output := ""
+	run := func() string {
+		rc := &RegionsCommand{}
+		rc.command.options = OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &accessKeyID,
+			OptionAccessKeySecret: &accessKeySecret,
+			OptionRegion:          &region,
+			OptionOutput:          &output,
+		}
+		outputFile := "test-file-" + randLowStr(5)
+		testResultFile, err := os.OpenFile(outputFile, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = rc.RunCommand()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		c.Assert(err, IsNil)
+		outBody := s.readFile(outputFile, c)
+		os.Remove(outputFile)
+		return outBody
+	}
+
+	outBody := run()
+	c.Assert(queryRegion, Equals, "")
+	c.Assert(strings.Contains(outBody, "oss-cn-hangzhou-internal.aliyuncs.com"), Equals, true)
+	c.Assert(strings.Contains(outBody, "Region Number is: 1"), Equals, true)
+
+	region = "cn-hangzhou"
+	output = "json"
+	outBody = run()
+	c.Assert(queryRegion, Equals, "oss-cn-hangzhou")
+	var regions []regionEndpoints
+	c.Assert(json.Unmarshal([]byte(outBody), &regions), IsNil)
+	c.Assert(len(regions), Equals, 1)
+	c.Assert(regions[0].Region, Equals, "oss-cn-hangzhou")
+	c.Assert(regions[0].InternetEndpoint, Equals, "oss-cn-hangzhou.aliyuncs.com")
+	c.Assert(regions[0].AccelerateEndpoint, Equals, "oss-accelerate.aliyuncs.com")
+}
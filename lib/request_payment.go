@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -88,13 +89,11 @@ type RequestPaymentCommand struct {
 
 var requestPaymentCommand = RequestPaymentCommand{
 	command: Command{
-		name:        "request-payment",
-		nameAlias:   []string{"request-payment"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseRequestPayment,
-		specEnglish: specEnglishRequestPayment,
-		group:       GroupTypeNormalCommand,
+		name:      "request-payment",
+		nameAlias: []string{"request-payment"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -114,6 +113,11 @@ var requestPaymentCommand = RequestPaymentCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -135,8 +139,8 @@ func (reqpc *RequestPaymentCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (reqpc *RequestPaymentCommand) Init(args []string, options OptionMapType) error {
-	return reqpc.command.Init(args, options, reqpc)
+func (reqpc *RequestPaymentCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return reqpc.command.Init(ctx, args, options, reqpc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net/url"
@@ -63,6 +64,11 @@ var specChineseRestore = SpecText{
     误时，会将出错object的错误信息记录到report文件，并继续操作其他object，成功操作的
     object信息将不会被记录到report文件中（更多信息见cp命令的帮助）。如果--force选项被
     指定，则不会进行询问提示。
+        如果指定了--where-tag选项，ossutil获取每个object的标签，只恢复标签匹配的objects，
+    如：--where-tag env=staging，多个条件用#分隔，只指定key表示存在该标签即可。
+        如果指定了--last-modified-after或者--last-modified-before选项，只恢复最后修改时间在
+    [--last-modified-after, --last-modified-before)之间的objects，时间可以为日期(2006-01-02，本地时间)、
+    RFC3339时间、http日期或者unix时间戳。
 
     上面的local_xml_file是本地xml格式文件, 支持设置更多的restore参数, 举例如下
     <RestoreRequest>
@@ -94,6 +100,7 @@ var specChineseRestore = SpecText{
     6) ossutil restore oss://bucket-restore/object-prefix -r -f local_xml_file
     7) ossutil restore oss://bucket-restore --object-file file -f local_xml_file
     8) ossutil restore oss://bucket-restore --object-file file --snapshot-path dir -f local_xml_file
+    9) ossutil restore oss://bucket-restore/object-prefix -r -f --where-tag archive=true
 `,
 }
 
@@ -143,6 +150,12 @@ Usage:
     error message to report file, and ossutil will continue to attempt to set acl on the remaining 
     objects(more information see help of cp command). If --force option is specified, ossutil will 
     not show prompt question. 
+        If --where-tag option is specified, ossutil gets the tags of every object, and only restores
+    the objects whose tags match, e.g., --where-tag env=staging, multiple conditions are separated
+    by #, only the key means the object should have the tag.
+        If --last-modified-after or --last-modified-before option is specified, only the objects whose
+    last modified time is in [--last-modified-after, --last-modified-before) are restored, the time
+    can be date(2006-01-02, local time), RFC3339 time, http date or unix timestamp.
 
     The local_xml_file is a local XML format file, which supports setting more restore configurations. For example:
     <RestoreRequest>
@@ -176,6 +189,7 @@ Usage:
     6) ossutil restore oss://bucket-restore/object-prefix -r -f local_xml_file
     7) ossutil restore oss://bucket-restore --object-file file -f local_xml_file
     8) ossutil restore oss://bucket-restore --object-file file --snapshot-path dir -f local_xml_file
+    9) ossutil restore oss://bucket-restore/object-prefix -r -f --where-tag archive=true
 `,
 }
 
@@ -194,13 +208,11 @@ type RestoreCommand struct {
 
 var restoreCommand = RestoreCommand{
 	command: Command{
-		name:        "restore",
-		nameAlias:   []string{},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseRestore,
-		specEnglish: specEnglishRestore,
-		group:       GroupTypeNormalCommand,
+		name:      "restore",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionRecursion,
 			OptionForce,
@@ -227,10 +239,18 @@ var restoreCommand = RestoreCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
 			OptionObjectFile,
+			OptionWhereTag,
+			OptionLastModifiedAfter,
+			OptionLastModifiedBefore,
 			OptionSnapshotPath,
 			OptionDisableIgnoreError,
 			OptionSignVersion,
@@ -251,8 +271,8 @@ func (rc *RestoreCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (rc *RestoreCommand) Init(args []string, options OptionMapType) error {
-	return rc.command.Init(args, options, rc)
+func (rc *RestoreCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return rc.command.Init(ctx, args, options, rc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -287,10 +307,22 @@ func (rc *RestoreCommand) RunCommand() error {
 	if err != nil {
 		return err
 	}
+	if rc.command.tagSelector, err = rc.command.newTagSelector(); err != nil {
+		return err
+	}
+	if rc.command.tagSelector != nil && (!recursive || objFileXml != "") {
+		return fmt.Errorf("--where-tag only work with --recursive and without --object-file")
+	}
+	if rc.command.timeWindow, err = rc.command.newTimeWindow(); err != nil {
+		return err
+	}
+	if rc.command.timeWindow != nil && (!recursive || objFileXml != "") {
+		return fmt.Errorf("--last-modified-after and --last-modified-before only work with --recursive and without --object-file")
+	}
 	if err = rc.checkOptions(cloudURL, recursive, force, versionid, objFileXml); err != nil {
 		return err
 	}
-	bucket, err := rc.command.ossBucket(cloudURL.bucket)
+	bucket, err := rc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
@@ -543,7 +575,7 @@ func (rc *RestoreCommand) restoreConsumer(bucket *oss.Bucket, cloudURL CloudURL,
 func (rc *RestoreCommand) restoreObjectWithReport(bucket *oss.Bucket, object string) error {
 	err := rc.ossRestoreObject(bucket, object, "", true)
 	rc.command.updateMonitor(err, &rc.monitor)
-	msg := fmt.Sprintf("restore %s", CloudURLToString(bucket.BucketName, object))
+	msg := fmt.Sprintf("restore %s", bucketObjectURL(bucket, object))
 	rc.command.report(msg, err, &rc.reOption)
 	return err
 }
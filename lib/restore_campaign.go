@@ -0,0 +1,537 @@
+package lib
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseRestoreCampaign = SpecText{
+	synopsisText: "按照每日解冻字节数的限额，分多天解冻大量归档类型的objects，支持断点续做",
+
+	paramText: "cloud_url [local_xml_file] [options]",
+
+	syntaxText: `
+    ossutil restore-campaign oss://bucket[/prefix] [local_xml_file] --daily-bytes size [--state-file file] [-j jobs] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    该命令按照key的字典序列举cloud_url下的objects，解冻其中存储类型为Archive、ColdArchive和
+    DeepColdArchive的objects，每天(本地时间)解冻的objects的大小之和不超过--daily-bytes指定的限额。
+    当天的限额用完后，命令等待到第二天继续解冻，直到所有的objects都被解冻，适用于数百万个冷归档
+    objects需要持续数天解冻的场景，控制每天的解冻费用和解冻任务的数量。
+
+    --daily-bytes的取值为字节数，可以带有KB、MB、GB、TB单位，如10TB。大于限额的object在一天的开始
+    单独解冻。
+
+    命令在每解冻一批objects后把进度保存到状态文件中，包括已经处理到的key、当天已经解冻的字节数以及
+    解冻失败的objects。状态文件由--state-file选项指定，默认为` + CheckpointDir + `目录下根据cloud_url
+    生成的文件。命令中断后使用相同的参数再次执行，会从状态文件记录的位置继续，先重试之前解冻失败的
+    objects，并且遵守当天剩余的限额。所有的objects都处理完成后，状态文件被标记为完成，再次执行不会
+    重复解冻；需要重新解冻时请删除状态文件。
+
+    local_xml_file的格式与restore命令相同，可以指定解冻天数和解冻优先级，例如：
+    <RestoreRequest>
+        <Days>2</Days>
+        <JobParameters>
+            <Tier>Bulk</Tier>
+        </JobParameters>
+    </RestoreRequest>
+
+    -j指定并发解冻的objects个数。
+`,
+
+	sampleText: `
+    1) 每天解冻10TB的冷归档objects
+    ossutil restore-campaign oss://bucket/archive/ --daily-bytes 10TB
+
+    2) 使用Bulk优先级解冻，并指定状态文件
+    ossutil restore-campaign oss://bucket/archive/ restore.xml --daily-bytes 50TB --state-file archive.campaign
+
+    3) 中断后继续
+    ossutil restore-campaign oss://bucket/archive/ restore.xml --daily-bytes 50TB --state-file archive.campaign
+`,
+}
+
+var specEnglishRestoreCampaign = SpecText{
+	synopsisText: "Restore a large number of archived objects over days within the daily restore bytes budget, it's resumable",
+
+	paramText: "cloud_url [local_xml_file] [options]",
+
+	syntaxText: `
+    ossutil restore-campaign oss://bucket[/prefix] [local_xml_file] --daily-bytes size [--state-file file] [-j jobs] [--encoding-type url] [--payer requester] [-c file]
+`,
+
+	detailHelpText: `
+    The command lists the objects under cloud_url in the lexical order of the keys, and restores the
+    objects of Archive, ColdArchive and DeepColdArchive storage classes, the total size of the objects
+    restored each day(local time) doesn't exceed the budget specified by --daily-bytes. After the budget
+    of the day is used up, the command waits until the next day to continue, until all the objects are
+    restored. It's for the millions of cold archive objects which take days to restore, the restore cost
+    and the number of the restore jobs of each day are under control.
+
+    The value of --daily-bytes is the number of bytes, it can have the unit of KB, MB, GB or TB, e.g.,
+    10TB. The object larger than the budget is restored alone at the beginning of a day.
+
+    The command saves the progress to the state file after each batch of objects, including the key
+    processed, the bytes restored in the day and the objects failed to be restored. The state file is
+    specified by --state-file, the default is the file named by cloud_url in ` + CheckpointDir + `. If the
+    command is interrupted, run it again with the same arguments, it continues from the position of the
+    state file, retries the objects failed before first, and respects the budget left of the day. After
+    all the objects are processed, the state file is marked finished, running the command again doesn't
+    restore the objects again, please remove the state file to restore them again.
+
+    The format of local_xml_file is the same as restore command, the restore days and the tier can be
+    specified, e.g.:
+    <RestoreRequest>
+        <Days>2</Days>
+        <JobParameters>
+            <Tier>Bulk</Tier>
+        </JobParameters>
+    </RestoreRequest>
+
+    -j specifies the number of the objects restored concurrently.
+`,
+
+	sampleText: `
+    1) restore 10TB cold archive objects every day
+    ossutil restore-campaign oss://bucket/archive/ --daily-bytes 10TB
+
+    2) restore by the Bulk tier, and specify the state file
+    ossutil restore-campaign oss://bucket/archive/ restore.xml --daily-bytes 50TB --state-file archive.campaign
+
+    3) continue after the interruption
+    ossutil restore-campaign oss://bucket/archive/ restore.xml --daily-bytes 50TB --state-file archive.campaign
+`,
+}
+
+// campaignNow and campaignWait are replaced by the tests to run the campaign over days
+var (
+	campaignNow  = time.Now
+	campaignWait = (*Command).waitRetry
+)
+
+// restoreCampaignClasses are the storage classes need to be restored before reading
+var restoreCampaignClasses = []string{
+	string(oss.StorageArchive),
+	string(oss.StorageColdArchive),
+	string(oss.StorageDeepColdArchive),
+}
+
+// restoreCampaignState is the progress of the campaign saved in the state file
+type restoreCampaignState struct {
+	URL           string   `json:"url"`
+	Marker        string   `json:"marker"` // the objects up to the key are processed
+	Day           string   `json:"day"`
+	DayBytes      int64    `json:"dayBytes"` // the bytes restored in the day
+	RestoredNum   int64    `json:"restoredNum"`
+	RestoredBytes int64    `json:"restoredBytes"`
+	Failed        []string `json:"failed"`
+	Finished      bool     `json:"finished"`
+	path          string   `json:"-"`
+}
+
+func (state *restoreCampaignState) save() error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return replaceCheckpoint(state.path, data)
+}
+
+type restoreCampaignObject struct {
+	key  string
+	size int64
+}
+
+type restoreCampaignOptionType struct {
+	cloudURL     CloudURL
+	dailyBytes   int64
+	routines     int64
+	configXML    string
+	payerOptions []oss.Option
+	state        *restoreCampaignState
+}
+
+// RestoreCampaignCommand is the command restores the archived objects over days within the daily budget
+type RestoreCampaignCommand struct {
+	command  Command
+	rcOption restoreCampaignOptionType
+}
+
+var restoreCampaignCommand = RestoreCampaignCommand{
+	command: Command{
+		name:      "restore-campaign",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionRoutines,
+			OptionDailyBytes,
+			OptionStateFile,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (rcc *RestoreCampaignCommand) formatHelpForWhole() string {
+	return rcc.command.formatHelpForWhole()
+}
+
+func (rcc *RestoreCampaignCommand) formatIndependHelp() string {
+	return rcc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (rcc *RestoreCampaignCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return rcc.command.Init(ctx, args, options, rcc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (rcc *RestoreCampaignCommand) RunCommand() error {
+	// clear for go tests
+	rcc.rcOption = restoreCampaignOptionType{}
+
+	encodingType, _ := GetString(OptionEncodingType, rcc.command.options)
+	cloudURL, err := GetCloudUrl(rcc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+	rcc.rcOption.cloudURL = *cloudURL
+
+	strDailyBytes, _ := GetString(OptionDailyBytes, rcc.command.options)
+	if strDailyBytes == "" {
+		return fmt.Errorf("--daily-bytes is required, please specify the budget of the bytes restored each day")
+	}
+	if rcc.rcOption.dailyBytes, err = parseByteSize(strDailyBytes); err != nil || rcc.rcOption.dailyBytes <= 0 {
+		return fmt.Errorf("invalid --daily-bytes: %s, the value should be positive bytes with the optional unit of KB, MB, GB or TB", strDailyBytes)
+	}
+
+	if len(rcc.command.args) > 1 {
+		data, err := ioutil.ReadFile(rcc.command.args[1])
+		if err != nil {
+			return err
+		}
+		if len(data) == 0 {
+			return fmt.Errorf("%s is empty file", rcc.command.args[1])
+		}
+		rcc.rcOption.configXML = string(data)
+	}
+
+	payer, _ := GetString(OptionRequestPayer, rcc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		rcc.rcOption.payerOptions = append(rcc.rcOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+	rcc.rcOption.routines, _ = GetInt(OptionRoutines, rcc.command.options)
+	if rcc.rcOption.routines <= 0 {
+		rcc.rcOption.routines = int64(Routines)
+	}
+
+	statePath, _ := GetString(OptionStateFile, rcc.command.options)
+	if rcc.rcOption.state, err = loadRestoreCampaignState(statePath, cloudURL.ToString()); err != nil {
+		return err
+	}
+	state := rcc.rcOption.state
+	if state.Finished {
+		fmt.Printf("the campaign of %s is finished, restored %d objects, %s, remove %s to run it again\n",
+			state.URL, state.RestoredNum, getSizeString(state.RestoredBytes), state.path)
+		return nil
+	}
+
+	bucket, err := rcc.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return err
+	}
+	if err = rcc.retryFailed(bucket); err != nil {
+		return err
+	}
+	if err = rcc.restoreListed(bucket); err != nil {
+		return err
+	}
+
+	state.Finished = len(state.Failed) == 0
+	if err = state.save(); err != nil {
+		return err
+	}
+	fmt.Printf("restored:%d\tsize:%s\tfailed:%d\n", state.RestoredNum, getSizeString(state.RestoredBytes), len(state.Failed))
+	if len(state.Failed) > 0 {
+		return fmt.Errorf("%d object(s) failed to be restored, run the command again to retry them, see the failed objects in %s", len(state.Failed), state.path)
+	}
+	return nil
+}
+
+// loadRestoreCampaignState loads the state of the campaign, a new state is returned if the file
+// doesn't exist. The default state file is named by the md5 of the cloud url
+func loadRestoreCampaignState(path, strURL string) (*restoreCampaignState, error) {
+	if path == "" {
+		sum := md5.Sum([]byte(strURL))
+		path = filepath.Join(CheckpointDir, "restore_campaign_"+hex.EncodeToString(sum[:])+".json")
+	}
+	state := &restoreCampaignState{URL: strURL}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err = json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("invalid state file %s, %s", path, err.Error())
+		}
+		if state.URL != strURL {
+			return nil, fmt.Errorf("the state file %s is of the campaign of %s, not %s", path, state.URL, strURL)
+		}
+	} else if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	state.path = path
+	return state, nil
+}
+
+// retryFailed restores the objects failed in the last run, they are kept in the state if they fail again
+func (rcc *RestoreCampaignCommand) retryFailed(bucket *oss.Bucket) error {
+	state := rcc.rcOption.state
+	if len(state.Failed) == 0 {
+		return nil
+	}
+	var objects []restoreCampaignObject
+	for _, key := range state.Failed {
+		props, err := rcc.command.ossGetObjectStatRetry(bucket, key, rcc.rcOption.payerOptions...)
+		if err != nil && !isNotFound(err) {
+			return err
+		}
+		if err == nil {
+			size, _ := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+			objects = append(objects, restoreCampaignObject{key, size})
+		}
+	}
+	state.Failed = nil
+	return rcc.restoreWithinBudget(bucket, objects, "")
+}
+
+// restoreListed lists the objects from the marker of the state, and restores the archived ones
+func (rcc *RestoreCampaignCommand) restoreListed(bucket *oss.Bucket) error {
+	state := rcc.rcOption.state
+	for {
+		listOptions := append(rcc.rcOption.payerOptions, oss.Prefix(rcc.rcOption.cloudURL.object), oss.Marker(state.Marker), oss.MaxKeys(1000))
+		lor, err := rcc.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			return err
+		}
+
+		var objects []restoreCampaignObject
+		for _, object := range lor.Objects {
+			if FindPos(object.StorageClass, restoreCampaignClasses) != -1 {
+				objects = append(objects, restoreCampaignObject{object.Key, object.Size})
+			}
+		}
+		pageMarker := lor.NextMarker
+		if len(lor.Objects) > 0 {
+			pageMarker = lor.Objects[len(lor.Objects)-1].Key
+		}
+		if err = rcc.restoreWithinBudget(bucket, objects, pageMarker); err != nil {
+			return err
+		}
+
+		if !lor.IsTruncated {
+			return nil
+		}
+	}
+}
+
+// restoreWithinBudget restores the objects in batches within the budget of the day, it waits for
+// the next day if the budget is used up. The state is saved after each batch, the marker is set
+// to pageMarker after all the objects are restored if it's not empty
+func (rcc *RestoreCampaignCommand) restoreWithinBudget(bucket *oss.Bucket, objects []restoreCampaignObject, pageMarker string) error {
+	state := rcc.rcOption.state
+	for {
+		today := campaignNow().Format("2006-01-02")
+		if state.Day != today {
+			state.Day = today
+			state.DayBytes = 0
+		}
+
+		// the object larger than the budget is restored alone at the beginning of a day
+		batchEnd, batchBytes := 0, int64(0)
+		for batchEnd < len(objects) {
+			size := objects[batchEnd].size
+			if state.DayBytes+batchBytes+size > rcc.rcOption.dailyBytes && (state.DayBytes > 0 || batchEnd > 0) {
+				break
+			}
+			batchBytes += size
+			batchEnd++
+		}
+
+		if batchEnd > 0 {
+			rcc.restoreBatch(bucket, objects[:batchEnd])
+			if pageMarker != "" {
+				state.Marker = objects[batchEnd-1].key
+			}
+			objects = objects[batchEnd:]
+		}
+		if len(objects) == 0 && pageMarker != "" {
+			state.Marker = pageMarker
+		}
+		if err := rcc.saveState(objects, pageMarker == ""); err != nil {
+			return err
+		}
+		if len(objects) == 0 {
+			return nil
+		}
+
+		fmt.Printf("the budget of %s is used up, restored %s today, waiting for the next day, %d objects restored, %s\n",
+			today, getSizeString(state.DayBytes), state.RestoredNum, getSizeString(state.RestoredBytes))
+		if err := rcc.waitNextDay(); err != nil {
+			return err
+		}
+	}
+}
+
+// saveState saves the state, the objects left are saved as failed if they are the failed objects
+// of the last run being retried, so that they are retried again after an interruption
+func (rcc *RestoreCampaignCommand) saveState(objectsLeft []restoreCampaignObject, retrying bool) error {
+	state := rcc.rcOption.state
+	if !retrying || len(objectsLeft) == 0 {
+		return state.save()
+	}
+	failed := state.Failed
+	defer func() { state.Failed = failed }()
+	state.Failed = append([]string{}, failed...)
+	for _, object := range objectsLeft {
+		state.Failed = append(state.Failed, object.key)
+	}
+	return state.save()
+}
+
+// restoreBatch restores the objects concurrently, the failed objects are added to the state
+func (rcc *RestoreCampaignCommand) restoreBatch(bucket *oss.Bucket, objects []restoreCampaignObject) {
+	state := rcc.rcOption.state
+	chObjects := make(chan restoreCampaignObject, len(objects))
+	for _, object := range objects {
+		chObjects <- object
+	}
+	close(chObjects)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; int64(i) < rcc.rcOption.routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range chObjects {
+				err := rcc.restoreObjectRetry(bucket, object.key)
+				mu.Lock()
+				state.DayBytes += object.size
+				if err != nil {
+					state.Failed = append(state.Failed, object.key)
+					LogError("restore %s error: %s\n", bucketObjectURL(bucket, object.key), err.Error())
+				} else {
+					state.RestoredNum++
+					state.RestoredBytes += object.size
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("restored:%d\tsize:%s\ttoday:%s\tfailed:%d\n", state.RestoredNum, getSizeString(state.RestoredBytes),
+		getSizeString(state.DayBytes), len(state.Failed))
+}
+
+func (rcc *RestoreCampaignCommand) restoreObjectRetry(bucket *oss.Bucket, object string) error {
+	retryTimes, _ := GetInt(OptionRetryTimes, rcc.command.options)
+	options := rcc.command.withContext(rcc.rcOption.payerOptions)
+	for i := 1; ; i++ {
+		var err error
+		if rcc.rcOption.configXML != "" {
+			err = bucket.RestoreObjectXML(object, rcc.rcOption.configXML, options...)
+		} else {
+			err = bucket.RestoreObject(object, options...)
+		}
+		if err == nil {
+			return nil
+		}
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if noNeedRetry && serviceError.StatusCode == 409 && serviceError.Code == "RestoreAlreadyInProgress" {
+			return nil
+		}
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucket.BucketName, object}
+		}
+		if err := rcc.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+// waitNextDay waits until the beginning of the next day of local time, it returns the error of the
+// context if the command is canceled during the waiting, the state is saved before it
+func (rcc *RestoreCampaignCommand) waitNextDay() error {
+	now := campaignNow()
+	next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	return campaignWait(&rcc.command, next.Sub(now))
+}
+
+// parseByteSize parses the bytes with the optional unit of KB, MB, GB or TB, e.g., 10TB
+func parseByteSize(str string) (int64, error) {
+	str = strings.ToUpper(strings.TrimSpace(str))
+	units := []struct {
+		suffix string
+		size   int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	for _, unit := range units {
+		if strings.HasSuffix(str, unit.suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(str, unit.suffix)), 64)
+			if err != nil {
+				return 0, err
+			}
+			return int64(value * float64(unit.size)), nil
+		}
+	}
+	return strconv.ParseInt(str, 10, 64)
+}
@@ -0,0 +1,224 @@
+package lib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestRestoreCampaign(c *C) {
+	objects := []struct {
+		key, class string
+		size       int
+	}{
+		{"data/a", "ColdArchive", 40},
+		{"data/b", "Standard", 10},
+		{"data/c", "ColdArchive", 40},
+		{"data/d", "Archive", 40},
+		{"data/e", "DeepColdArchive", 100},
+	}
+	var mu sync.Mutex
+	var restored []string
+	failing := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "POST":
+			key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+			if failing[key] {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			restored = append(restored, key)
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "HEAD":
+			w.Header().Set("Content-Length", "40")
+		default:
+			marker := r.URL.Query().Get("marker")
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`)
+			for _, object := range objects {
+				if object.key > marker {
+					fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>%d</Size><StorageClass>%s</StorageClass></Contents>`, object.key, object.size, object.class)
+				}
+			}
+			fmt.Fprint(w, `</ListBucketResult>`)
+		}
+	}))
+	defer server.Close()
+
+	// the clock moves to the next day when the campaign waits
+	now := time.Date(2026, 10, 15, 20, 0, 0, 0, time.Local)
+	sleeps := 0
+	campaignNow = func() time.Time { return now }
+	campaignWait = func(cmd *Command, d time.Duration) error {
+		sleeps++
+		now = now.Add(d)
+		return nil
+	}
+	defer func() {
+		campaignNow = time.Now
+		campaignWait = (*Command).waitRetry
+	}()
+
+	resultPath := "ossutil-test-restore-campaign-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	dir := "ossutil-test-restore-campaign-dir-" + randLowStr(8)
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+		os.RemoveAll(dir)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	retryTimes := "1"
+	dailyBytes := "100B"
+	statePath := filepath.Join(dir, "campaign.state")
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRetryTimes:      &retryTimes,
+		OptionDailyBytes:      &dailyBytes,
+		OptionStateFile:       &statePath,
+	}
+	readState := func() restoreCampaignState {
+		var state restoreCampaignState
+		data, err := ioutil.ReadFile(statePath)
+		c.Assert(err, IsNil)
+		c.Assert(json.Unmarshal(data, &state), IsNil)
+		return state
+	}
+
+	// day 1: a and c, day 2: d, e exceeds the budget, day 3: e alone, d fails
+	failing["data/d"] = true
+	_, err = cm.RunCommand("restore-campaign", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(sleeps, Equals, 2)
+	c.Assert(restored, DeepEquals, []string{"data/a", "data/c", "data/e"})
+	state := readState()
+	c.Assert(state.Failed, DeepEquals, []string{"data/d"})
+	c.Assert(state.Marker, Equals, "data/e")
+	c.Assert(state.Day, Equals, "2026-10-17")
+	c.Assert(state.DayBytes, Equals, int64(100))
+	c.Assert(state.RestoredNum, Equals, int64(3))
+	c.Assert(state.Finished, Equals, false)
+
+	// the failed object is retried on the next day, then the campaign is finished
+	failing["data/d"] = false
+	_, err = cm.RunCommand("restore-campaign", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(sleeps, Equals, 3)
+	c.Assert(restored, DeepEquals, []string{"data/a", "data/c", "data/e", "data/d"})
+	state = readState()
+	c.Assert(state.Failed, IsNil)
+	c.Assert(state.RestoredBytes, Equals, int64(220))
+	c.Assert(state.Finished, Equals, true)
+
+	// the finished campaign isn't run again
+	_, err = cm.RunCommand("restore-campaign", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(len(restored), Equals, 4)
+
+	// the state file of another campaign
+	_, err = cm.RunCommand("restore-campaign", []string{"oss://bucket/other/"}, options)
+	c.Assert(err, NotNil)
+
+	dailyBytes = "abc"
+	_, err = cm.RunCommand("restore-campaign", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, NotNil)
+
+	size, err := parseByteSize("1.5KB")
+	c.Assert(err, IsNil)
+	c.Assert(size, Equals, int64(1536))
+	size, err = parseByteSize("10tb")
+	c.Assert(err, IsNil)
+	c.Assert(size, Equals, int64(10<<40))
+}
+
+func (s *OssutilCommandSuite) TestRestoreCampaignRetry(c *C) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "POST":
+			key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+			attempts[key]++
+			switch key {
+			case "data/denied":
+				w.WriteHeader(http.StatusForbidden)
+			case "data/busy":
+				w.WriteHeader(http.StatusServiceUnavailable)
+			default:
+				w.WriteHeader(http.StatusAccepted)
+			}
+		default:
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`)
+			for _, key := range []string{"data/busy", "data/denied", "data/ok"} {
+				fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>10</Size><StorageClass>Archive</StorageClass></Contents>`, key)
+			}
+			fmt.Fprint(w, `</ListBucketResult>`)
+		}
+	}))
+	defer server.Close()
+
+	resultPath := "ossutil-test-restore-campaign-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	dir := "ossutil-test-restore-campaign-dir-" + randLowStr(8)
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+		os.RemoveAll(dir)
+	}()
+
+	str := "ak"
+	forcePathStyle := true
+	retryTimes := "3"
+	dailyBytes := "1KB"
+	statePath := filepath.Join(dir, "campaign.state")
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRetryTimes:      &retryTimes,
+		OptionDailyBytes:      &dailyBytes,
+		OptionStateFile:       &statePath,
+	}
+
+	// the client error isn't retried, the server error is retried after waiting
+	start := time.Now()
+	_, err = cm.RunCommand("restore-campaign", []string{"oss://bucket/data/"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(attempts, DeepEquals, map[string]int{"data/busy": 3, "data/denied": 1, "data/ok": 1})
+	c.Assert(time.Since(start) >= 2*time.Second, Equals, true)
+
+	// the waiting for the next day stops once the command is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rcc := RestoreCampaignCommand{}
+	rcc.command.ctx = ctx
+	c.Assert(rcc.waitNextDay(), Equals, context.Canceled)
+}
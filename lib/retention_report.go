@@ -0,0 +1,323 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseRetentionReport = SpecText{
+	synopsisText: "扫描开启了worm的bucket，报告每个object的保留状态",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil retention-report oss://bucket[/prefix] [--status locked|deletable] [--output text|json|yaml] [--payer requester]
+`,
+
+	detailHelpText: `
+    该命令读取bucket的worm(合规保留)配置，列举bucket或者指定前缀下的objects，根据保留天数和
+    object的最后修改时间计算每个object的保留截止时间，报告object当前仍处于保留期(locked)
+    还是已经可以删除(deletable)，结果可以作为审计证据。
+
+    oss的worm是bucket级别的策略，object的保留期从object的最后修改时间开始计算，对覆盖写入
+    的object重新计算。worm状态为InProgress时策略已经生效，但策略本身可以在24小时内被删除，
+    报告的头部会显示策略的状态，审计时应确认状态为Locked。oss不支持object级别的legal hold，
+    因此报告中不包含该信息。
+
+    --status选项只输出指定状态的objects，统计信息仍然包含所有objects。
+    --output选项指定输出格式，取值为text（默认）、json或yaml，json和yaml格式便于归档和程序处理。
+    如果bucket没有worm配置，命令返回错误。
+`,
+
+	sampleText: `
+    1) 报告bucket中所有objects的保留状态
+       ossutil retention-report oss://bucket
+
+    2) 只列出指定前缀下已经可以删除的objects
+       ossutil retention-report oss://bucket/logs/ --status deletable
+
+    3) 以json格式输出报告并保存到文件
+       ossutil retention-report oss://bucket --output json > report.json
+`,
+}
+
+var specEnglishRetentionReport = SpecText{
+	synopsisText: "Scan the worm enabled bucket and report the retention state of every object",
+
+	paramText: "cloud_url [options]",
+
+	syntaxText: `
+    ossutil retention-report oss://bucket[/prefix] [--status locked|deletable] [--output text|json|yaml] [--payer requester]
+`,
+
+	detailHelpText: `
+    The command reads the worm(compliance retention) configuration of the bucket, lists the
+    objects in the bucket or under the prefix, calculates the retain-until time of every object
+    from the retention days and the last modified time of the object, and reports whether the
+    object is still in the retention period(locked) or can be deleted now(deletable). The
+    report can be used as audit evidence.
+
+    The worm of oss is a bucket level policy, the retention period of an object starts from its
+    last modified time, and starts again when the object is overwritten. The policy takes effect
+    when the state of worm is InProgress, but the policy itself can be deleted in 24 hours, the
+    state is shown in the header of the report, make sure it's Locked for audit. oss doesn't
+    support object level legal hold, so it's not included in the report.
+
+    --status option only outputs the objects in the state, the statistics still include all
+    the objects.
+    --output option specifies the output format, the value is text(default), json or yaml,
+    json and yaml formats are convenient for archiving and processing by programs.
+    If the bucket has no worm configuration, the command returns error.
+`,
+
+	sampleText: `
+    1) report the retention state of all the objects in the bucket
+       ossutil retention-report oss://bucket
+
+    2) only list the deletable objects under the prefix
+       ossutil retention-report oss://bucket/logs/ --status deletable
+
+    3) output the report in json format and save it into file
+       ossutil retention-report oss://bucket --output json > report.json
+`,
+}
+
+const (
+	retentionLocked    = "locked"
+	retentionDeletable = "deletable"
+)
+
+// retentionObject is the retention state of an object in the report
+type retentionObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	RetainUntil  time.Time `json:"retainUntil"`
+	Status       string    `json:"status"`
+}
+
+// retentionReport is the report printed by --output json
+type retentionReport struct {
+	Bucket                string            `json:"bucket"`
+	Prefix                string            `json:"prefix"`
+	WormId                string            `json:"wormId"`
+	WormState             string            `json:"wormState"`
+	RetentionPeriodInDays int               `json:"retentionPeriodInDays"`
+	WormCreationDate      string            `json:"wormCreationDate"`
+	ReportTime            time.Time         `json:"reportTime"`
+	TotalNum              int64             `json:"totalNum"`
+	LockedNum             int64             `json:"lockedNum"`
+	DeletableNum          int64             `json:"deletableNum"`
+	NextUnlockTime        *time.Time        `json:"nextUnlockTime,omitempty"`
+	Objects               []retentionObject `json:"objects"`
+}
+
+type retentionReportOptionType struct {
+	status       string
+	output       outputFormat
+	payerOptions []oss.Option
+}
+
+type RetentionReportCommand struct {
+	command  Command
+	rrOption retentionReportOptionType
+}
+
+var retentionReportCommand = RetentionReportCommand{
+	command: Command{
+		name:      "retention-report",
+		nameAlias: []string{"retention-report"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionEncodingType,
+			OptionRequestPayer,
+			OptionRetentionStatus,
+			OptionOutput,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (rrc *RetentionReportCommand) formatHelpForWhole() string {
+	return rrc.command.formatHelpForWhole()
+}
+
+func (rrc *RetentionReportCommand) formatIndependHelp() string {
+	return rrc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (rrc *RetentionReportCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return rrc.command.Init(ctx, args, options, rrc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (rrc *RetentionReportCommand) RunCommand() error {
+	rrc.rrOption.payerOptions = []oss.Option{}
+
+	encodingType, _ := GetString(OptionEncodingType, rrc.command.options)
+	cloudURL, err := GetCloudUrl(rrc.command.args[0], encodingType)
+	if err != nil {
+		return err
+	}
+
+	rrc.rrOption.status, _ = GetString(OptionRetentionStatus, rrc.command.options)
+	rrc.rrOption.status = strings.ToLower(rrc.rrOption.status)
+	if rrc.rrOption.output, err = getOutputFormat(rrc.command.options); err != nil {
+		return err
+	}
+
+	payer, _ := GetString(OptionRequestPayer, rrc.command.options)
+	if payer != "" {
+		if payer != strings.ToLower(string(oss.Requester)) {
+			return fmt.Errorf("invalid request payer: %s, please check", payer)
+		}
+		rrc.rrOption.payerOptions = append(rrc.rrOption.payerOptions, oss.RequestPayer(oss.PayerType(payer)))
+	}
+
+	bucket, err := rrc.command.cloudBucket(*cloudURL)
+	if err != nil {
+		return err
+	}
+	wormConfig, err := bucket.Client.GetBucketWorm(cloudURL.bucket)
+	if err != nil {
+		if isNotFound(err) {
+			return fmt.Errorf("bucket %s has no worm configuration, %w", cloudURL.bucket, wrapServiceError(err))
+		}
+		return BucketError{err, cloudURL.bucket}
+	}
+
+	report := retentionReport{
+		Bucket:                cloudURL.bucket,
+		Prefix:                cloudURL.object,
+		WormId:                wormConfig.WormId,
+		WormState:             wormConfig.State,
+		RetentionPeriodInDays: wormConfig.RetentionPeriodInDays,
+		WormCreationDate:      wormConfig.CreationDate,
+		ReportTime:            time.Now(),
+		Objects:               []retentionObject{},
+	}
+	if !rrc.rrOption.output.structured() {
+		rrc.printHeader(report)
+	}
+
+	if err := rrc.scanObjects(bucket, &report); err != nil {
+		return err
+	}
+
+	if rrc.rrOption.output.structured() {
+		return rrc.rrOption.output.print(report)
+	}
+	fmt.Printf("\ntotal:%d\tlocked:%d\tdeletable:%d\n", report.TotalNum, report.LockedNum, report.DeletableNum)
+	if report.NextUnlockTime != nil {
+		fmt.Printf("next unlock time: %s\n", report.NextUnlockTime.Local().Format(time.RFC3339))
+	}
+	return nil
+}
+
+func (rrc *RetentionReportCommand) printHeader(report retentionReport) {
+	fmt.Printf("bucket: %s\n", report.Bucket)
+	fmt.Printf("worm id: %s\n", report.WormId)
+	fmt.Printf("worm state: %s\n", report.WormState)
+	fmt.Printf("retention period in days: %d\n", report.RetentionPeriodInDays)
+	fmt.Printf("worm creation date: %s\n", report.WormCreationDate)
+	fmt.Printf("report time: %s\n\n", report.ReportTime.Format(time.RFC3339))
+	fmt.Printf("%-30s%12s  %-30s  %-10s%s\n", "LastModifiedTime", "Size(B)", "RetainUntil", "Status", "ObjectName")
+}
+
+// scanObjects lists the objects and calculates their retention state
+func (rrc *RetentionReportCommand) scanObjects(bucket *oss.Bucket, report *retentionReport) error {
+	period := time.Duration(report.RetentionPeriodInDays) * 24 * time.Hour
+	pre := oss.Prefix(report.Prefix)
+	marker := oss.Marker("")
+	for {
+		listOptions := append(rrc.rrOption.payerOptions, pre, marker, oss.MaxKeys(1000))
+		lor, err := rrc.command.ossListObjectsRetry(bucket, listOptions...)
+		if err != nil {
+			return err
+		}
+
+		for _, object := range lor.Objects {
+			state := retentionStateOf(object, period, report.ReportTime)
+			report.TotalNum++
+			if state.Status == retentionLocked {
+				report.LockedNum++
+				if report.NextUnlockTime == nil || state.RetainUntil.Before(*report.NextUnlockTime) {
+					retainUntil := state.RetainUntil
+					report.NextUnlockTime = &retainUntil
+				}
+			} else {
+				report.DeletableNum++
+			}
+
+			if rrc.rrOption.status != "" && rrc.rrOption.status != state.Status {
+				continue
+			}
+			if rrc.rrOption.output.structured() {
+				report.Objects = append(report.Objects, state)
+				continue
+			}
+			fmt.Printf("%-30s%12d  %-30s  %-10s%s\n", utcToLocalTime(state.LastModified), state.Size,
+				utcToLocalTime(state.RetainUntil), state.Status, bucketObjectURL(bucket, state.Key))
+		}
+
+		pre = oss.Prefix(lor.Prefix)
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	return nil
+}
+
+// retentionStateOf returns the retention state of the object at the time,
+// the retention period starts from the last modified time of the object
+func retentionStateOf(object oss.ObjectProperties, period time.Duration, now time.Time) retentionObject {
+	state := retentionObject{
+		Key:          object.Key,
+		Size:         object.Size,
+		LastModified: object.LastModified,
+		RetainUntil:  object.LastModified.Add(period),
+		Status:       retentionDeletable,
+	}
+	if now.Before(state.RetainUntil) {
+		state.Status = retentionLocked
+	}
+	return state
+}
@@ -0,0 +1,84 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestRetentionReport(c *C) {
+	now := time.Now()
+	period := 10 * 24 * time.Hour
+	state := retentionStateOf(oss.ObjectProperties{Key: "a", LastModified: now.Add(-11 * 24 * time.Hour)}, period, now)
+	c.Assert(state.Status, Equals, retentionDeletable)
+	state = retentionStateOf(oss.ObjectProperties{Key: "b", LastModified: now.Add(-time.Hour)}, period, now)
+	c.Assert(state.Status, Equals, retentionLocked)
+	c.Assert(state.RetainUntil.Equal(now.Add(-time.Hour).Add(period)), Equals, true)
+
+	hasWorm := true
+	recent := now.Add(-time.Hour).UTC().Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery == "worm" {
+			if !hasWorm {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `<Error><Code>NoSuchWORMConfiguration</Code></Error>`)
+				return
+			}
+			fmt.Fprint(w, `<WormConfiguration><WormId>1666E2CFB2B3418****</WormId><State>Locked</State>
+<RetentionPeriodInDays>10</RetentionPeriodInDays><CreationDate>2020-10-15T15:50:32</CreationDate></WormConfiguration>`)
+			return
+		}
+		fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>logs/old</Key><Size>1</Size><LastModified>2020-01-01T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>logs/new</Key><Size>2</Size><LastModified>`+recent+`</LastModified></Contents>
+</ListBucketResult>`)
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	output := "json"
+	status := "locked"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionOutput:          &output,
+		OptionRetentionStatus: &status,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("retention-report", []string{"oss://bucket/logs/"}, options)
+	os.Stdout = oldStdout
+	testResultFile.Close()
+	c.Assert(err, IsNil)
+
+	data, err := ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	var report retentionReport
+	c.Assert(json.Unmarshal(data, &report), IsNil)
+	c.Assert(report.WormState, Equals, "Locked")
+	c.Assert(report.RetentionPeriodInDays, Equals, 10)
+	c.Assert(report.TotalNum, Equals, int64(2))
+	c.Assert(report.LockedNum, Equals, int64(1))
+	c.Assert(report.DeletableNum, Equals, int64(1))
+	c.Assert(report.NextUnlockTime, NotNil)
+	c.Assert(len(report.Objects), Equals, 1)
+	c.Assert(report.Objects[0].Key, Equals, "logs/new")
+
+	// the bucket without worm configuration
+	hasWorm = false
+	_, err = cm.RunCommand("retention-report", []string{"oss://bucket"}, options)
+	c.Assert(err, NotNil)
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -116,13 +117,11 @@ type RevertCommand struct {
 
 var revertCommand = RevertCommand{
 	command: Command{
-		name:        "revert-versioning",
-		nameAlias:   []string{"revert-versioning"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseRevert,
-		specEnglish: specEnglishRevert,
-		group:       GroupTypeNormalCommand,
+		name:      "revert-versioning",
+		nameAlias: []string{"revert-versioning"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -148,6 +147,11 @@ var revertCommand = RevertCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -169,8 +173,8 @@ func (revert *RevertCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (revert *RevertCommand) Init(args []string, options OptionMapType) error {
-	return revert.command.Init(args, options, revert)
+func (revert *RevertCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return revert.command.Init(ctx, args, options, revert)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -232,7 +236,7 @@ func (revert *RevertCommand) revertObjects(bucket *oss.Bucket) error {
 			break
 		}
 		batchCount++
-		lor, err := bucket.ListObjectVersions(listOptions...)
+		lor, err := bucket.ListObjectVersions(revert.command.withContext(listOptions)...)
 		if err != nil {
 			return err
 		}
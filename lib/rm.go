@@ -1,12 +1,14 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"golang.org/x/time/rate"
 )
 
 type uploadIdInfoType struct {
@@ -22,6 +24,20 @@ type removeOptionType struct {
 	//version
 	versionId   string
 	allVersions bool
+
+	listSplit string
+	condition objectConditionType
+	routines  int
+	limiter   *rate.Limiter // nil means no limit of --qps
+	failed    *failedManifest
+	retryKeys []string // nil means neither --retry-from nor --null-input
+
+	toTrash     bool
+	trashPrefix string
+	trashDays   int64 // 0 means the lifecycle of the trash isn't set
+
+	dryRun    bool
+	retention *rmRetention
 }
 
 var specChineseRemove = SpecText{
@@ -32,6 +48,7 @@ var specChineseRemove = SpecText{
 
 	syntaxText: ` 
     ossutil rm oss://bucket[/prefix] [-r] [-b] [-m] [-a] [-f]  [--include include-pattern] [--exclude exclude-pattern]  [--version-id versionId | --all-versions] [--payer requester] [-c file]
+    ossutil rm [oss://bucket[/prefix]] --spec job.yaml [-f]
 `,
 
 	detailHelpText: ` 
@@ -46,6 +63,8 @@ var specChineseRemove = SpecText{
 
         对bucket进行删除，都需要添加--bucket选项。
         如果指定了--force选项，则删除前不会进行询问提示。
+        批量删除objects时，询问提示中会显示待删除的object数量和字节数。全局选项--assume-yes(-y)
+        与--force选项作用相同，可以用于所有需要确认的破坏性操作。
         
         结果：显示命令耗时前未报错，则表示成功删除。
 
@@ -74,6 +93,83 @@ var specChineseRemove = SpecText{
 
     --include和--exclude可以出现多次。当多个规则出现时，这些规则按从左往右的顺序应用
 
+--list-split选项
+
+    批量删除object时，如果prefix下的object数量巨大，可以指定--list-split选项将prefix下的
+    key空间分片，ossutil并发列举各个分片并删除列举到的object。取值为auto时按prefix后的首字符
+    分片，也可以指定逗号分隔的相对于prefix的分片点，如：--list-split a,g,n,t。该选项只能和
+    --recursive一起使用，且不支持--all-versions。
+
+--jobs和--qps选项
+
+    批量删除object时，ossutil一边列举一边通过批量删除接口（每批最多1000个object）并发删除列举
+    到的object，--jobs选项指定并发删除的任务数。删除过程中进度条显示每秒删除的object数量，扫描
+    结束后还会显示预计剩余时间。如果bucket级别的请求频率限制被触发，可以指定--qps选项限制每秒
+    发送的列举和删除请求数，如：--qps 10。--max-qps选项则限制所有请求（包括重试和读取object信息的请
+    求），在配置文件的Default段中设置maxQPS可以对所有命令生效。
+
+--output-failed和--retry-from选项
+
+    指定--output-failed failed.csv时，ossutil将每个删除失败的object及错误信息以csv格式（key,error）记录到
+    该文件中，并继续删除其他objects，结束时如果有删除失败的object，命令返回错误。之后执行
+    ossutil rm oss://bucket[/prefix] -r --retry-from failed.csv，ossutil只删除文件中记录的prefix下的
+    objects，不再列举整个prefix。--retry-from不支持--multipart、--all-type、--bucket、--all-versions和
+    --list-split。
+
+worm保护的objects
+
+    如果bucket开启了worm(合规保留)，仍在保留期内的objects无法删除。删除失败时，ossutil读取bucket的
+    worm配置，根据object的最后修改时间计算保留截止时间，仍在保留期内的objects不作为错误处理，也不
+    中断其他objects的删除，而是在结束时的汇总中单独列出(blocked by retention)，并显示每个object的
+    保留截止时间，此时命令返回错误。指定了--output-failed时，这些objects也会记录到文件中。
+
+--null-input选项
+
+    从stdin读取以NUL字符分隔的cloud_url（如ls --print0或find --print0的输出），只删除其中在
+    oss://bucket[/prefix]下的objects，不再列举整个prefix，object名中含有换行或空格时也能正确处理，
+    如：ossutil ls oss://bucket/logs/ -0 | ossutil rm oss://bucket/logs/ -r -f --null-input。cloud_url
+    必须属于命令指定的bucket。由于stdin已被占用，需要同时指定--force。限制与--retry-from相同，
+    并且不能与--retry-from同时使用。
+
+--if-match、--if-none-match和--if-unmodified-since选项
+
+    删除单个object时可以指定这些选项，只有object满足条件时才会删除，否则报错PreconditionFailed，
+    可用于避免删除其他人刚修改过的object。--if-match和--if-none-match的值为object的etag，
+    --if-unmodified-since的值可以为http日期（如：Mon, 02 Jan 2006 15:04:05 GMT）、RFC3339时间
+    或者unix时间戳。由于oss不支持条件删除，ossutil在删除前检查object的元信息。
+
+--to-trash、--trash-prefix和--trash-days选项
+
+    指定--to-trash时，ossutil不直接删除objects，而是通过服务端拷贝将它们移动到同一bucket的
+    <回收站prefix><当天日期>/下（回收站prefix由--trash-prefix指定，默认为.trash/），回收站中的
+    objects不会被再次移动。指定--trash-days时，ossutil为回收站prefix设置生命周期规则，回收站中的
+    objects在指定天数后被自动删除。可以使用ossutil trash restore|purge命令恢复或者清空回收站，
+    详见help trash。--to-trash不支持--multipart、--all-type、--bucket、--version-id、--all-versions、
+    --list-split、--retry-from和条件选项。
+
+--where-tag选项
+
+    批量删除objects时指定--where-tag，ossutil获取每个object的标签，只删除标签匹配的objects，如：
+    --where-tag env=staging，多个条件用#分隔，只指定key表示存在该标签即可。获取标签的并发数由
+    --jobs选项指定。--where-tag只支持--recursive，不支持--multipart、--all-type、--bucket、
+    --all-versions和--retry-from。
+
+--last-modified-after和--last-modified-before选项
+
+    批量删除objects时只删除最后修改时间在[--last-modified-after, --last-modified-before)之间的objects，
+    可以只指定其中一个，时间可以为日期(2006-01-02，本地时间)、RFC3339时间、http日期或者unix时间戳。
+    只支持--recursive，不支持--all-versions和--retry-from。
+
+--dry-run选项
+
+    批量删除objects时指定--dry-run，ossutil只输出将被删除的objects以及它们的数量和大小，不删除任何object，
+    可以与--include、--exclude、--where-tag和时间选项一起使用，先确认删除的范围。
+
+--spec选项
+
+    从yaml格式的任务描述文件读取参数和选项，文件格式参见cp命令的帮助。周期性的清理任务可以把
+    prefix、--include、--exclude、时间选项等写在文件中，在git中评审。
+
 
 用法：
 
@@ -138,10 +234,14 @@ var specChineseRemove = SpecText{
     ossutil rm oss://bucket2/%e4%b8%ad%e6%96%87 --encoding-type url
     ossutil rm oss://bucket1/objdir -r --include "*.jpg" --include "*.png" --exclude "*.avi" --exclude "*.mp4"
     ossutil rm oss://bucket1/obj1 --version-id versionId
+    ossutil rm oss://bucket1/obj1 --if-match 5B3C1A2E053D763E1B002CC607C5A0FE
     ossutil rm oss://bucket1/obj1 --all-versions
     ossutil rm oss://bucket1/objdir -r  --all-versions
     ossutil rm oss://bucket1 -r -b --all-versions
     ossutil rm oss://bucket1 -r --payer requester
+    ossutil rm oss://bucket1/objdir -r --to-trash --trash-days 7
+    ossutil rm oss://bucket1/tmp/ -r --where-tag env=staging
+    ossutil rm oss://bucket1/logs/ -r --last-modified-before 2022-01-01 --dry-run
 `,
 }
 
@@ -153,6 +253,7 @@ var specEnglishRemove = SpecText{
 
 	syntaxText: ` 
     ossutil rm oss://bucket[/prefix] [-r] [-b] [-m] [-a] [-f]  [--include include-pattern] [--exclude exclude-pattern]  [--version-id versionId | --all-versions] [--payer requester] [-c file]
+    ossutil rm [oss://bucket[/prefix]] --spec job.yaml [-f]
 `,
 
 	detailHelpText: ` 
@@ -170,6 +271,9 @@ var specEnglishRemove = SpecText{
         When remove bucket, the --bucket option must be specified.
         If --force option is specified, remove silently without asking user to confirm the 
         operation.  
+        When removing objects in batch, the prompt shows the number and the bytes of the objects
+        to be removed. The global option --assume-yes(-y) works as --force, and it works for all
+        the destructive operations which need to be confirmed.
 
         Result: if no error displayed before show elasped time, then the target is removed 
         successfully.
@@ -206,6 +310,100 @@ var specEnglishRemove = SpecText{
     When there are multi filters, the rule is the filters that appear later in the command take precedence
     over filters that appear earlier in the command
 
+--list-split option
+
+    When removing objects in batch and there is a huge number of objects under the prefix, 
+    --list-split option can be specified to split the keyspace under the prefix into shards, 
+    ossutil lists the shards concurrently and removes the listed objects. The value auto splits
+    the keyspace by the first character after the prefix, you can also specify split points 
+    relative to the prefix separated by comma, e.g., --list-split a,g,n,t. The option only works
+    with --recursive, and --all-versions is not supported.
+
+--jobs and --qps option
+
+    When removing objects in batch, ossutil lists the objects and removes the listed objects 
+    concurrently by batch delete requests(at most 1000 objects per request), --jobs option specifies
+    the number of the concurrent delete tasks. The progress bar shows the number of objects removed
+    per second, and the estimated remaining time after the scan is finished. If the request rate 
+    limit of the bucket is tripped, --qps option can be specified to limit the list and delete 
+    requests sent per second, e.g., --qps 10. --max-qps option limits all the requests instead, 
+    including the retries and the requests getting the object meta, it can be set as maxQPS in the 
+    Default section of the config file for all commands.
+
+--output-failed and --retry-from option
+
+    If --output-failed failed.csv is specified, ossutil records every object failed to be removed with
+    its error to the file in csv format(key,error), and goes on removing the other objects, the command
+    returns error in the end if any object failed. Then run ossutil rm oss://bucket[/prefix] -r 
+    --retry-from failed.csv, ossutil only removes the objects under the prefix recorded in the file,
+    instead of listing the whole prefix. --retry-from doesn't support --multipart, --all-type, 
+    --bucket, --all-versions and --list-split.
+
+objects protected by worm
+
+    If the worm(compliance retention) of the bucket is enabled, the objects in the retention period
+    can't be removed. When some objects failed to be removed, ossutil reads the worm configuration
+    of the bucket and calculates the retain-until time from the last modified time of the objects,
+    the objects still in the retention period are not treated as errors and don't abort removing
+    the other objects, they are listed in a separate section(blocked by retention) of the summary
+    in the end with their retain-until time, and the command returns error. They are also recorded
+    to the file of --output-failed if it's specified.
+
+--null-input option
+
+    Read the NUL-delimited cloud_urls(like the output of ls --print0 or find --print0) from stdin,
+    only remove the objects of them under oss://bucket[/prefix], instead of listing the whole
+    prefix, the object names containing newlines or spaces are handled correctly, e.g., ossutil ls
+    oss://bucket/logs/ -0 | ossutil rm oss://bucket/logs/ -r -f --null-input. The cloud_urls must
+    belong to the bucket of the command. --force is required since stdin is occupied. The
+    limitations are the same as --retry-from, and it can't be used with --retry-from.
+
+--if-match, --if-none-match and --if-unmodified-since option
+
+    These options can be specified when removing single object, the object is removed only when 
+    it meets the conditions, otherwise PreconditionFailed error happens, they can be used to avoid
+    removing the object just changed by others. The value of --if-match and --if-none-match is the
+    etag of object, the value of --if-unmodified-since can be http date(like: Mon, 02 Jan 2006 
+    15:04:05 GMT), RFC3339 time or unix timestamp. Because oss doesn't support conditional delete,
+    ossutil checks the meta of the object just before removing it.
+
+--to-trash, --trash-prefix and --trash-days option
+
+    If --to-trash is specified, ossutil doesn't delete the objects directly, but moves them by server
+    side copy into <trash prefix><today>/ of the same bucket(the trash prefix is specified by 
+    --trash-prefix, .trash/ by default), the objects in the trash are not moved again. If --trash-days
+    is specified, ossutil sets the lifecycle rule for the trash prefix, the objects in the trash are
+    deleted automatically after the days. Use ossutil trash restore|purge command to restore or empty
+    the trash, see help trash for details. --to-trash doesn't support --multipart, --all-type, --bucket,
+    --version-id, --all-versions, --list-split, --retry-from and the condition options.
+
+--where-tag option
+
+    If --where-tag is specified when removing objects in batch, ossutil gets the tags of every object,
+    and only removes the objects whose tags match, e.g., --where-tag env=staging, multiple conditions
+    are separated by #, only the key means the object should have the tag. The concurrency of getting
+    the tags is specified by --jobs option. --where-tag only works with --recursive, and doesn't support
+    --multipart, --all-type, --bucket, --all-versions and --retry-from.
+
+--last-modified-after and --last-modified-before option
+
+    Only remove the objects whose last modified time is in [--last-modified-after, --last-modified-before)
+    when removing objects in batch, either of them can be specified alone. The time can be date(2006-01-02,
+    local time), RFC3339 time, http date or unix timestamp. They only work with --recursive, and don't
+    support --all-versions and --retry-from.
+
+--dry-run option
+
+    If --dry-run is specified when removing objects in batch, ossutil only prints the objects which would
+    be removed with their number and size, no object is removed. It can be used together with --include,
+    --exclude, --where-tag and the time options to check the scope of the removal first.
+
+--spec option
+
+    Read the arguments and options from the job spec file in yaml, the format of the file is 
+    described in the help of cp command. The recurring cleanup jobs can declare the prefix, 
+    --include, --exclude and the time options in the file to be reviewed in git.
+
 
 Usage:
 
@@ -281,10 +479,14 @@ Usage:
     ossutil rm oss://bucket2/%e4%b8%ad%e6%96%87 --encoding-type url
     ossutil rm oss://bucket1/objdir -r --include "*.jpg" --include "*.png" --exclude "*.avi" --exclude "*.mp4"
     ossutil rm oss://bucket1/obj1 --version-id versionId
+    ossutil rm oss://bucket1/obj1 --if-match 5B3C1A2E053D763E1B002CC607C5A0FE
     ossutil rm oss://bucket1/obj1 --all-versions
     ossutil rm oss://bucket1/objdir -r  --all-versions
     ossutil rm oss://bucket1 -r -b --all-versions
     ossutil rm oss://bucket1 -r --payer requester
+    ossutil rm oss://bucket1/objdir -r --to-trash --trash-days 7
+    ossutil rm oss://bucket1/tmp/ -r --where-tag env=staging
+    ossutil rm oss://bucket1/logs/ -r --last-modified-before 2022-01-01 --dry-run
 `,
 }
 
@@ -299,13 +501,11 @@ type RemoveCommand struct {
 
 var removeCommand = RemoveCommand{
 	command: Command{
-		name:        "rm",
-		nameAlias:   []string{"remove", "delete", "del"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseRemove,
-		specEnglish: specEnglishRemove,
-		group:       GroupTypeNormalCommand,
+		name:      "rm",
+		nameAlias: []string{"remove", "delete", "del"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -328,6 +528,13 @@ var removeCommand = RemoveCommand{
 			OptionVersionId,
 			OptionAllversions,
 			OptionRequestPayer,
+			OptionWhereTag,
+			OptionLastModifiedAfter,
+			OptionLastModifiedBefore,
+			OptionDryRun,
+			OptionToTrash,
+			OptionTrashPrefix,
+			OptionTrashDays,
 			OptionPassword,
 			OptionMode,
 			OptionECSRoleName,
@@ -336,6 +543,11 @@ var removeCommand = RemoveCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -343,6 +555,20 @@ var removeCommand = RemoveCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionS3Endpoint,
+			OptionListSplit,
+			OptionIfMatch,
+			OptionIfNoneMatch,
+			OptionIfUnmodifiedSince,
+			OptionRoutines,
+			OptionQPS,
+			OptionOutputFailed,
+			OptionRetryFrom,
+			OptionNullInput,
+			OptionMaxObjects,
+			OptionMaxBytes,
+			OptionAssumeYes,
+			OptionSpec,
 		},
 	},
 }
@@ -357,8 +583,8 @@ func (rc *RemoveCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (rc *RemoveCommand) Init(args []string, options OptionMapType) error {
-	return rc.command.Init(args, options, rc)
+func (rc *RemoveCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return rc.command.Init(ctx, args, options, rc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -383,7 +609,7 @@ func (rc *RemoveCommand) RunCommand() error {
 		return fmt.Errorf("invalid cloud url: %s, miss bucket", rc.command.args[0])
 	}
 
-	bucket, err := rc.command.ossBucket(cloudURL.bucket)
+	bucket, err := rc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
@@ -394,7 +620,7 @@ func (rc *RemoveCommand) RunCommand() error {
 	}
 
 	var res bool
-	res, rc.filters = getFilter(os.Args)
+	res, rc.filters = getFilter(rc.command.withSpecFilters(os.Args))
 	if !res {
 		return fmt.Errorf("--include or --exclude does not support format containing dir info")
 	}
@@ -403,19 +629,40 @@ func (rc *RemoveCommand) RunCommand() error {
 		return fmt.Errorf("--include or --exclude only work with --recursive")
 	}
 
+	// count the objects before confirm, so that the user isn't asked for the command which will be aborted
+	if err := rc.preCountObjects(bucket, cloudURL); err != nil {
+		return err
+	}
+
+	if rc.rmOption.dryRun {
+		return rc.dryRunObjects(bucket, cloudURL)
+	}
+
 	// confirm remove objects/multiparts/allTypes before statistic
-	if !rc.confirmRemoveObject(cloudURL) {
+	if !rc.confirmRemoveObject(bucket, cloudURL) {
 		return nil
 	}
 
+	// the keys are read before --output-failed is created, which may be the same file
+	if rc.rmOption.failed, err = rc.command.newFailedManifest(); err != nil {
+		return err
+	}
+	defer rc.rmOption.failed.close()
+
 	// start progressbar
 	go rc.entryStatistic(bucket, cloudURL)
 
 	exitStat := normalExit
-	if err = rc.removeEntry(bucket, cloudURL); err != nil {
+	if err = rc.removeEntry(bucket, cloudURL); err == nil {
+		err = rc.rmOption.failed.err()
+	}
+	if err != nil {
 		exitStat = errExit
 	}
 	fmt.Printf(rc.monitor.progressBar(true, exitStat))
+	if blockedErr := rc.printRetentionBlocked(cloudURL.bucket); err == nil {
+		err = blockedErr
+	}
 	return err
 }
 
@@ -427,6 +674,61 @@ func (rc *RemoveCommand) assembleOption(cloudURL CloudURL) error {
 	toBucket, _ := GetBool(OptionBucket, rc.command.options)
 	rc.rmOption.versionId, _ = GetString(OptionVersionId, rc.command.options)
 	rc.rmOption.allVersions, _ = GetBool(OptionAllversions, rc.command.options)
+	rc.rmOption.listSplit, _ = GetString(OptionListSplit, rc.command.options)
+
+	routines, err := GetInt(OptionRoutines, rc.command.options)
+	if err != nil || routines <= 0 {
+		routines = int64(Routines)
+	}
+	rc.rmOption.routines = int(routines)
+
+	rc.rmOption.limiter = nil
+	if qps, err := GetInt(OptionQPS, rc.command.options); err == nil {
+		if qps <= 0 {
+			return fmt.Errorf("invalid --qps: %d, the value should be positive", qps)
+		}
+		rc.rmOption.limiter = rate.NewLimiter(rate.Limit(qps), 1)
+	}
+
+	if rc.rmOption.condition, err = rc.command.getObjectCondition(); err != nil {
+		return err
+	}
+
+	if rc.rmOption.retryKeys, err = rc.command.readRetryKeys(); err != nil {
+		return err
+	}
+	if nullKeys, err := rc.command.readNullInputKeys(cloudURL); err != nil {
+		return err
+	} else if nullKeys != nil {
+		if rc.rmOption.retryKeys != nil {
+			return fmt.Errorf("remove objects: %s, --retry-from and --null-input can't be specified at the same time", rc.command.args[0])
+		}
+		// stdin is used by the cloud urls, the confirmation can't be read
+		if !rc.command.assumeYes() {
+			return fmt.Errorf("remove objects: %s, --null-input reads the cloud urls from stdin, please specify --force to skip the confirmation", rc.command.args[0])
+		}
+		rc.rmOption.retryKeys = nullKeys
+	}
+
+	if rc.command.safetyCap, err = rc.command.newSafetyCap(); err != nil {
+		return err
+	}
+
+	if rc.command.tagSelector, err = rc.command.newTagSelector(); err != nil {
+		return err
+	}
+
+	if rc.command.timeWindow, err = rc.command.newTimeWindow(); err != nil {
+		return err
+	}
+	rc.rmOption.dryRun, _ = GetBool(OptionDryRun, rc.command.options)
+	rc.rmOption.retention = &rmRetention{}
+
+	rc.rmOption.toTrash, _ = GetBool(OptionToTrash, rc.command.options)
+	if rc.rmOption.trashPrefix, err = rc.command.getTrashPrefix(); err != nil {
+		return err
+	}
+	rc.rmOption.trashDays, _ = GetInt(OptionTrashDays, rc.command.options)
 
 	if err := rc.checkOption(cloudURL, isMultipart, isAllType, toBucket); err != nil {
 		return err
@@ -487,25 +789,97 @@ func (rc *RemoveCommand) checkOption(cloudURL CloudURL, isMultipart, isAllType,
 		}
 	}
 
+	if rc.rmOption.listSplit != "" {
+		if !rc.rmOption.recursive || rc.rmOption.allVersions {
+			return fmt.Errorf("remove objects: %s, --list-split only work with --recursive and without --all-versions", rc.command.args[0])
+		}
+		if _, err := makeListShards("", "", rc.rmOption.listSplit); err != nil {
+			return err
+		}
+	}
+
+	if rc.rmOption.retryKeys != nil {
+		if !rc.rmOption.recursive || isMultipart || isAllType || toBucket || rc.rmOption.allVersions || rc.rmOption.listSplit != "" {
+			return fmt.Errorf("remove objects: %s, --retry-from and --null-input only work with --recursive, and without --multipart, --all-type, --bucket, --all-versions and --list-split", rc.command.args[0])
+		}
+	}
+
+	if rc.command.safetyCap != nil {
+		if !rc.rmOption.recursive || rc.rmOption.allVersions {
+			return fmt.Errorf("remove objects: %s, --max-objects and --max-bytes only work with --recursive and without --all-versions", rc.command.args[0])
+		}
+	}
+
+	if rc.command.tagSelector != nil {
+		if !rc.rmOption.recursive || isMultipart || isAllType || toBucket || rc.rmOption.allVersions || rc.rmOption.retryKeys != nil {
+			return fmt.Errorf("remove objects: %s, --where-tag only work with --recursive and without --multipart, --all-type, --bucket, --all-versions and --retry-from", rc.command.args[0])
+		}
+	}
+
+	if rc.command.timeWindow != nil {
+		if !rc.rmOption.recursive || rc.rmOption.allVersions || rc.rmOption.retryKeys != nil {
+			return fmt.Errorf("remove objects: %s, --last-modified-after and --last-modified-before only work with --recursive and without --all-versions and --retry-from", rc.command.args[0])
+		}
+	}
+
+	if rc.rmOption.dryRun {
+		if !rc.rmOption.recursive || isMultipart || isAllType || toBucket || rc.rmOption.allVersions || rc.rmOption.retryKeys != nil {
+			return fmt.Errorf("remove objects: %s, --dry-run only work with --recursive and without --multipart, --all-type, --bucket, --all-versions and --retry-from", rc.command.args[0])
+		}
+	}
+
+	if rc.rmOption.toTrash {
+		if isMultipart || isAllType || toBucket || rc.rmOption.versionId != "" || rc.rmOption.allVersions ||
+			rc.rmOption.listSplit != "" || rc.rmOption.retryKeys != nil || !rc.rmOption.condition.isEmpty() {
+			return fmt.Errorf("remove objects: %s, --to-trash doesn't work with --multipart, --all-type, --bucket, --version-id, --all-versions, --list-split, --retry-from and the conditions", rc.command.args[0])
+		}
+	} else if rc.rmOption.trashDays > 0 {
+		return fmt.Errorf("remove objects: %s, --trash-days only works with --to-trash", rc.command.args[0])
+	}
+
+	if !rc.rmOption.condition.isEmpty() {
+		if rc.rmOption.recursive || isMultipart || isAllType || toBucket || rc.rmOption.versionId != "" || rc.rmOption.allVersions {
+			return fmt.Errorf("remove object: %s, --if-match, --if-none-match and --if-unmodified-since only work with removing single object", rc.command.args[0])
+		}
+	}
+
 	return nil
 }
 
-func (rc *RemoveCommand) confirmRemoveObject(cloudURL CloudURL) bool {
-	if !rc.rmOption.force && rc.rmOption.recursive && rc.rmOption.typeSet&allType != 0 {
+// dryRunObjects prints the objects which would be removed without removing them
+func (rc *RemoveCommand) dryRunObjects(bucket *oss.Bucket, cloudURL CloudURL) error {
+	var num, size int64
+	err := rc.command.listObjects(bucket, cloudURL, rc.filters, func(object oss.ObjectProperties) error {
+		fmt.Printf("%s\n", bucketObjectURL(bucket, object.Key))
+		num++
+		size += object.Size
+		return nil
+	}, rc.commonOptions...)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\ndry run, no object is removed, %d objects(%d bytes) would be removed\n", num, size)
+	return nil
+}
+
+func (rc *RemoveCommand) confirmRemoveObject(bucket *oss.Bucket, cloudURL CloudURL) bool {
+	if rc.rmOption.recursive && rc.rmOption.typeSet&allType != 0 && !rc.command.assumeYes() {
 		stringList := []string{}
 		if rc.rmOption.typeSet&objectType != 0 {
-			stringList = append(stringList, "objects")
+			if rc.rmOption.retryKeys != nil {
+				stringList = append(stringList, fmt.Sprintf("objects(%d objects)", len(rc.retryObjectKeys(cloudURL))))
+			} else {
+				stringList = append(stringList, fmt.Sprintf("objects(%s)", rc.command.objectsSummary(bucket, cloudURL, rc.filters, rc.commonOptions...)))
+			}
 		}
 		if rc.rmOption.typeSet&multipartType != 0 {
 			stringList = append(stringList, "multipart uploadIds")
 		}
-		var val string
-		fmt.Printf("Do you really mean to remove recursively %s of %s(y or N)? ", strings.Join(stringList, " and "), rc.command.args[0])
-		if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-			fmt.Println("operation is canceled.")
-			return false
+		action := "remove recursively"
+		if rc.rmOption.toTrash {
+			action = "move to the trash recursively"
 		}
-		return true
+		return rc.command.confirmOperation(fmt.Sprintf("%s %s of %s", action, strings.Join(stringList, " and "), rc.command.args[0]))
 	}
 	return true
 }
@@ -521,6 +895,11 @@ func (rc *RemoveCommand) entryStatistic(bucket *oss.Bucket, cloudURL CloudURL) {
 }
 
 func (rc *RemoveCommand) objectStatistic(bucket *oss.Bucket, cloudURL CloudURL) error {
+	if rc.rmOption.retryKeys != nil {
+		rc.monitor.updateScanNum(int64(len(rc.retryObjectKeys(cloudURL))))
+		return nil
+	}
+
 	// single object statistic before remove
 	if rc.rmOption.recursive {
 		if rc.rmOption.allVersions {
@@ -566,14 +945,15 @@ func (rc *RemoveCommand) batchObjectStatistic(bucket *oss.Bucket, cloudURL Cloud
 			return err
 		}
 
-		if len(rc.filters) == 0 {
+		if len(rc.filters) == 0 && rc.command.tagSelector == nil && rc.command.timeWindow == nil {
 			rc.monitor.updateScanNum(int64(len(lor.Objects)))
 		} else {
-			for _, object := range lor.Objects {
-				if doesSingleObjectMatchPatterns(object.Key, rc.filters) {
-					rc.monitor.updateScanNum(int64(1))
-				}
+			objects, err := rc.command.selectObjects(bucket, lor.Objects, rc.filters, rc.commonOptions...)
+			if err != nil {
+				rc.monitor.setScanError(err)
+				return err
 			}
+			rc.monitor.updateScanNum(int64(len(objects)))
 		}
 
 		pre = oss.Prefix(lor.Prefix)
@@ -638,7 +1018,7 @@ func (rc *RemoveCommand) removeEntry(bucket *oss.Bucket, cloudURL CloudURL) erro
 			return err
 		}
 
-		if rc.rmOption.recursive && len(rc.filters) == 0 {
+		if rc.rmOption.recursive && len(rc.filters) == 0 && rc.rmOption.retryKeys == nil && !rc.rmOption.toTrash && rc.command.tagSelector == nil && rc.command.timeWindow == nil {
 			// check again
 			// the key including special character can't be deleted by function removeObjectEntry
 			// so delete them one by one
@@ -662,6 +1042,10 @@ func (rc *RemoveCommand) removeEntry(bucket *oss.Bucket, cloudURL CloudURL) erro
 }
 
 func (rc *RemoveCommand) removeObjectEntry(bucket *oss.Bucket, cloudURL CloudURL) error {
+	if rc.rmOption.toTrash {
+		return rc.trashObjects(bucket, cloudURL)
+	}
+
 	//version mode
 	if len(rc.rmOption.versionId) > 0 || rc.rmOption.allVersions {
 		if len(rc.rmOption.versionId) > 0 {
@@ -683,8 +1067,22 @@ func (rc *RemoveCommand) removeObjectEntry(bucket *oss.Bucket, cloudURL CloudURL
 func (rc *RemoveCommand) removeObject(bucket *oss.Bucket, cloudURL CloudURL) error {
 	// single object statistic before remove to avoid inconsistency
 	exist, err := rc.touchObject(bucket, cloudURL)
+	if err == nil && exist {
+		// oss doesn't support conditional delete, so check the conditions just before deleting
+		if err = rc.command.checkObjectCondition(bucket, cloudURL.object, rc.rmOption.condition, rc.commonOptions...); err != nil {
+			rc.updateObjectMonitor(0, 1)
+			rc.monitor.setOP(0)
+			return err
+		}
+	}
 	if err != nil || exist {
 		err = rc.deleteObjectWithMonitor(bucket, cloudURL.object)
+		if err != nil && len(rc.filterRetentionBlocked(bucket, []string{cloudURL.object})) == 0 {
+			// the object is reported in the blocked section of the summary
+			rc.monitor.setOP(0)
+			return nil
+		}
+		rc.rmOption.failed.record(cloudURL.object, err)
 		if err != nil && rc.monitor.op == objectType {
 			// remove single object error, return error information, do not print progressbar
 			rc.monitor.setOP(0)
@@ -724,31 +1122,159 @@ func (rc *RemoveCommand) updateObjectMonitor(okNum, errNum int64) {
 }
 
 func (rc *RemoveCommand) batchDeleteObjects(bucket *oss.Bucket, cloudURL CloudURL) error {
-	// list objects
-	pre := oss.Prefix(cloudURL.object)
-	marker := oss.Marker("")
-	for {
-		listOptions := append(rc.commonOptions, marker, pre, oss.MaxKeys(1000))
-		lor, err := rc.command.ossListObjectsRetry(bucket, listOptions...)
-		if err != nil {
-			return err
+	if rc.rmOption.listSplit != "" {
+		return rc.shardedDeleteObjects(bucket, cloudURL)
+	}
+	if rc.rmOption.retryKeys != nil {
+		return rc.retryDeleteObjects(bucket, cloudURL)
+	}
+
+	// list objects and delete the pages concurrently
+	return rc.runDeleteTasks(func(submit func(task func() error) bool) error {
+		pre := oss.Prefix(cloudURL.object)
+		marker := oss.Marker("")
+		for {
+			listOptions := append(rc.commonOptions, marker, pre, oss.MaxKeys(1000))
+			rc.waitQPS()
+			lor, err := rc.command.ossListObjectsRetry(bucket, listOptions...)
+			if err != nil {
+				return err
+			}
+
+			// batch delete
+			skipLor, err := rc.getObjectsFromListResult(bucket, lor)
+			if err != nil {
+				return err
+			}
+			if !submit(func() error {
+				delNum, err := rc.ossBatchDeleteObjectsRetry(bucket, skipLor)
+				rc.updateObjectMonitor(int64(delNum), int64(len(skipLor)-delNum))
+				return rc.deleteTaskError(err)
+			}) {
+				return nil
+			}
+
+			pre = oss.Prefix(lor.Prefix)
+			marker = oss.Marker(lor.NextMarker)
+			if !lor.IsTruncated {
+				break
+			}
 		}
+		return nil
+	})
+}
 
-		// batch delete
-		skipLor := rc.getObjectsFromListResult(lor)
-		delNum, err := rc.ossBatchDeleteObjectsRetry(bucket, skipLor)
-		rc.updateObjectMonitor(int64(delNum), int64(len(skipLor)-delNum))
-		if err != nil {
+// runDeleteTasks runs the delete tasks submitted by list in --jobs goroutines, and returns the first error.
+// submit returns false if the tasks are stopped by error, then list should return
+func (rc *RemoveCommand) runDeleteTasks(list func(submit func(task func() error) bool) error) error {
+	routines := rc.rmOption.routines
+	if routines <= 0 {
+		routines = 1
+	}
+	chTasks := make(chan func() error, routines)
+	chError := make(chan error, routines+1)
+	chStop := make(chan struct{})
+	defer close(chStop)
+
+	go func() {
+		defer close(chTasks)
+		chError <- list(func(task func() error) bool {
+			select {
+			case chTasks <- task:
+				return true
+			case <-chStop:
+				return false
+			}
+		})
+	}()
+
+	for i := 0; i < routines; i++ {
+		go func() {
+			for task := range chTasks {
+				if err := task(); err != nil {
+					chError <- err
+					return
+				}
+			}
+			chError <- nil
+		}()
+	}
+
+	for i := 0; i <= routines; i++ {
+		if err := <-chError; err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		pre = oss.Prefix(lor.Prefix)
-		marker = oss.Marker(lor.NextMarker)
-		if !lor.IsTruncated {
-			break
+// deleteTaskError ignores the error of the batch when the failed objects are recorded by --output-failed,
+// so that the other batches go on
+func (rc *RemoveCommand) deleteTaskError(err error) error {
+	if rc.rmOption.failed != nil {
+		return nil
+	}
+	return err
+}
+
+// retryObjectKeys returns the keys of --retry-from or --null-input under the prefix of cloud url
+func (rc *RemoveCommand) retryObjectKeys(cloudURL CloudURL) []string {
+	keys := []string{}
+	for _, key := range rc.rmOption.retryKeys {
+		if strings.HasPrefix(key, cloudURL.object) && doesSingleObjectMatchPatterns(key, rc.filters) {
+			keys = append(keys, key)
 		}
 	}
-	return nil
+	return keys
+}
+
+// retryDeleteObjects deletes the objects of --retry-from or --null-input in batches instead of listing the prefix
+func (rc *RemoveCommand) retryDeleteObjects(bucket *oss.Bucket, cloudURL CloudURL) error {
+	return rc.runDeleteTasks(func(submit func(task func() error) bool) error {
+		keys := rc.retryObjectKeys(cloudURL)
+		for len(keys) > 0 {
+			num := len(keys)
+			if num > 1000 {
+				num = 1000
+			}
+			objects := keys[:num]
+			keys = keys[num:]
+			if !submit(func() error {
+				delNum, err := rc.ossBatchDeleteObjectsRetry(bucket, objects)
+				rc.updateObjectMonitor(int64(delNum), int64(len(objects)-delNum))
+				return rc.deleteTaskError(err)
+			}) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// waitQPS waits until the next request is allowed by --qps
+func (rc *RemoveCommand) waitQPS() {
+	if rc.rmOption.limiter != nil {
+		rc.rmOption.limiter.Wait(context.Background())
+	}
+}
+
+// shardedDeleteObjects lists the shards of the keyspace concurrently and deletes the objects of every page
+func (rc *RemoveCommand) shardedDeleteObjects(bucket *oss.Bucket, cloudURL CloudURL) error {
+	shards, err := makeListShards(cloudURL.object, "", rc.rmOption.listSplit)
+	if err != nil {
+		return err
+	}
+	LogInfo("list %s by %d shards\n", cloudURL.ToString(), len(shards))
+
+	return rc.command.ossListObjectsSharded(bucket, cloudURL.object, shards, func(objects []oss.ObjectProperties) error {
+		skipLor, err := rc.getObjectsFromListResult(bucket, oss.ListObjectsResult{Objects: objects})
+		if err != nil {
+			return err
+		}
+		delNum, err := rc.ossBatchDeleteObjectsRetry(bucket, skipLor)
+		rc.updateObjectMonitor(int64(delNum), int64(len(skipLor)-delNum))
+		return rc.deleteTaskError(err)
+	}, rc.commonOptions...)
 }
 
 func (rc *RemoveCommand) ossBatchDeleteObjectsRetry(bucket *oss.Bucket, objects []string) (int, error) {
@@ -761,13 +1287,17 @@ func (rc *RemoveCommand) ossBatchDeleteObjectsRetry(bucket *oss.Bucket, objects
 	deletedNum := 0
 	for i := 1; ; i++ {
 		listOptions := append(rc.commonOptions, oss.DeleteObjectsQuiet(true))
+		rc.waitQPS()
 		delRes, err := bucket.DeleteObjects(objects, listOptions...)
 		if err == nil {
 			deletedNum += (len(objects) - len(delRes.DeletedObjects))
 			if len(delRes.DeletedObjects) == 0 {
 				return deletedNum, nil
 			}
-			objects = delRes.DeletedObjects
+			// the objects protected by the worm of the bucket can't be removed by retrying
+			if objects = rc.filterRetentionBlocked(bucket, delRes.DeletedObjects); len(objects) == 0 {
+				return deletedNum, nil
+			}
 		} else {
 			// when 4XX,5XX error,delRes.DeletedObjects is empty
 			if len(delRes.DeletedObjects) > 0 {
@@ -779,6 +1309,16 @@ func (rc *RemoveCommand) ossBatchDeleteObjectsRetry(bucket *oss.Bucket, objects
 		if err != nil {
 			serviceError, noNeedRetry := err.(oss.ServiceError)
 			if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+				num := len(objects)
+				if objects = rc.filterRetentionBlocked(bucket, objects); len(objects) == 0 {
+					return deletedNum, nil
+				} else if len(objects) < num {
+					// the batch may fail for the blocked objects, retry the others
+					continue
+				}
+				for _, object := range objects {
+					rc.rmOption.failed.record(object, err)
+				}
 				return deletedNum, fmt.Errorf("%s,delete objects: %#v failed", err.Error(), objects)
 			}
 		}
@@ -790,14 +1330,22 @@ func (rc *RemoveCommand) removeSpecialCharacterObjects(bucket *oss.Bucket, cloud
 	marker := oss.Marker("")
 	for {
 		listOptions := append(rc.commonOptions, marker, pre, oss.MaxKeys(1000))
+		rc.waitQPS()
 		lor, err := rc.command.ossListObjectsRetry(bucket, listOptions...)
 		if err != nil {
 			return err
 		}
 
 		for _, object := range lor.Objects {
+			rc.waitQPS()
 			if err := bucket.DeleteObject(object.Key, rc.commonOptions...); err != nil {
-				return err
+				if len(rc.filterRetentionBlocked(bucket, []string{object.Key})) == 0 {
+					continue
+				}
+				rc.rmOption.failed.record(object.Key, err)
+				if rc.rmOption.failed == nil {
+					return err
+				}
 			}
 		}
 
@@ -810,14 +1358,31 @@ func (rc *RemoveCommand) removeSpecialCharacterObjects(bucket *oss.Bucket, cloud
 	return nil
 }
 
-func (rc *RemoveCommand) getObjectsFromListResult(lor oss.ListObjectsResult) []string {
+func (rc *RemoveCommand) getObjectsFromListResult(bucket *oss.Bucket, lor oss.ListObjectsResult) ([]string, error) {
+	selected, err := rc.command.selectObjects(bucket, lor.Objects, rc.filters, rc.commonOptions...)
+	if err != nil {
+		return nil, err
+	}
 	objects := []string{}
-	for _, object := range lor.Objects {
-		if doesSingleObjectMatchPatterns(object.Key, rc.filters) {
-			objects = append(objects, object.Key)
+	for _, object := range selected {
+		if err := rc.command.safetyCap.take(object.Size); err != nil {
+			return nil, err
 		}
+		objects = append(objects, object.Key)
 	}
-	return objects
+	return objects, nil
+}
+
+// preCountObjects checks the objects to be removed against --max-objects and --max-bytes
+func (rc *RemoveCommand) preCountObjects(bucket *oss.Bucket, cloudURL CloudURL) error {
+	if rc.command.safetyCap == nil || rc.rmOption.typeSet&objectType == 0 {
+		return nil
+	}
+	if rc.rmOption.retryKeys != nil {
+		// the sizes are unknown before the objects are removed
+		return rc.command.safetyCap.check(int64(len(rc.retryObjectKeys(cloudURL))), 0)
+	}
+	return rc.command.preCountObjects(bucket, cloudURL, rc.filters, rc.commonOptions...)
 }
 
 func (rc *RemoveCommand) removeMultipartUploadsEntry(bucket *oss.Bucket, cloudURL CloudURL) error {
@@ -939,16 +1504,7 @@ func (rc *RemoveCommand) removeBucket(bucket *oss.Bucket, cloudURL CloudURL) err
 }
 
 func (rc *RemoveCommand) confirmRemoveBucket(cloudURL CloudURL) bool {
-	if !rc.rmOption.force {
-		var val string
-		fmt.Printf(getClearStr(fmt.Sprintf("Do you really mean to remove the Bucket: %s(y or N)? ", cloudURL.bucket)))
-		if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-			fmt.Println("operation is canceled.")
-			return false
-		}
-		return true
-	}
-	return true
+	return rc.command.confirmOperation(fmt.Sprintf("remove the Bucket: %s", cloudURL.bucket))
 }
 
 func (rc *RemoveCommand) ossDeleteBucketRetry(client *oss.Client, bucket string) error {
@@ -969,8 +1525,9 @@ func (rc *RemoveCommand) ossDeleteBucketRetry(client *oss.Client, bucket string)
 			return BucketError{err, bucket}
 		}
 
-		// wait 1 second
-		time.Sleep(time.Duration(1) * time.Second)
+		if err := rc.command.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
 	}
 }
 
@@ -1109,51 +1666,56 @@ func (rc *RemoveCommand) batchObjectStatisticVersion(bucket *oss.Bucket, cloudUR
 }
 
 func (rc *RemoveCommand) batchDeleteObjectsVersion(bucket *oss.Bucket, cloudURL CloudURL) error {
-	// list objects
-	pre := oss.Prefix(cloudURL.object)
-	keyMarker := oss.KeyMarker("")
-	versionIdMarker := oss.VersionIdMarker("")
-
-	for {
-		listOptions := append(rc.commonOptions, pre, keyMarker, versionIdMarker, oss.MaxKeys(1000))
-		lor, err := rc.command.ossListObjectVersionsRetry(bucket, listOptions...)
-		if err != nil {
-			return err
-		}
+	// list objects and delete the pages concurrently
+	return rc.runDeleteTasks(func(submit func(task func() error) bool) error {
+		pre := oss.Prefix(cloudURL.object)
+		keyMarker := oss.KeyMarker("")
+		versionIdMarker := oss.VersionIdMarker("")
+
+		for {
+			listOptions := append(rc.commonOptions, pre, keyMarker, versionIdMarker, oss.MaxKeys(1000))
+			rc.waitQPS()
+			lor, err := rc.command.ossListObjectVersionsRetry(bucket, listOptions...)
+			if err != nil {
+				return err
+			}
 
-		objectsToDelete := make([]oss.DeleteObject, 0)
-		for _, object := range lor.ObjectDeleteMarkers {
-			if doesSingleObjectMatchPatterns(object.Key, rc.filters) {
-				objectsToDelete = append(objectsToDelete, oss.DeleteObject{
-					Key:       object.Key,
-					VersionId: object.VersionId,
-				})
+			objectsToDelete := make([]oss.DeleteObject, 0)
+			for _, object := range lor.ObjectDeleteMarkers {
+				if doesSingleObjectMatchPatterns(object.Key, rc.filters) {
+					objectsToDelete = append(objectsToDelete, oss.DeleteObject{
+						Key:       object.Key,
+						VersionId: object.VersionId,
+					})
+				}
 			}
-		}
 
-		for _, object := range lor.ObjectVersions {
-			if doesSingleObjectMatchPatterns(object.Key, rc.filters) {
-				objectsToDelete = append(objectsToDelete, oss.DeleteObject{
-					Key:       object.Key,
-					VersionId: object.VersionId,
-				})
+			for _, object := range lor.ObjectVersions {
+				if doesSingleObjectMatchPatterns(object.Key, rc.filters) {
+					objectsToDelete = append(objectsToDelete, oss.DeleteObject{
+						Key:       object.Key,
+						VersionId: object.VersionId,
+					})
+				}
 			}
-		}
 
-		// batch delete
-		delNum, err := rc.ossBatchDeleteObjectsRetryVersion(bucket, objectsToDelete)
-		rc.updateObjectMonitor(int64(delNum), int64(len(objectsToDelete)-delNum))
-		if err != nil {
-			return err
-		}
-		pre = oss.Prefix(lor.Prefix)
-		keyMarker = oss.KeyMarker(lor.NextKeyMarker)
-		versionIdMarker = oss.VersionIdMarker(lor.NextVersionIdMarker)
-		if !lor.IsTruncated {
-			break
+			// batch delete
+			if !submit(func() error {
+				delNum, err := rc.ossBatchDeleteObjectsRetryVersion(bucket, objectsToDelete)
+				rc.updateObjectMonitor(int64(delNum), int64(len(objectsToDelete)-delNum))
+				return rc.deleteTaskError(err)
+			}) {
+				return nil
+			}
+			pre = oss.Prefix(lor.Prefix)
+			keyMarker = oss.KeyMarker(lor.NextKeyMarker)
+			versionIdMarker = oss.VersionIdMarker(lor.NextVersionIdMarker)
+			if !lor.IsTruncated {
+				break
+			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (rc *RemoveCommand) ossBatchDeleteObjectsRetryVersion(bucket *oss.Bucket, objectVersions []oss.DeleteObject) (int, error) {
@@ -1166,6 +1728,7 @@ func (rc *RemoveCommand) ossBatchDeleteObjectsRetryVersion(bucket *oss.Bucket, o
 	deletedNum := 0
 	for i := 1; ; i++ {
 		listOptions := append(rc.commonOptions, oss.DeleteObjectsQuiet(true))
+		rc.waitQPS()
 		delRes, err := bucket.DeleteObjectVersions(objectVersions, listOptions...)
 		getFailedObject := false
 		if err == nil {
@@ -1185,6 +1748,9 @@ func (rc *RemoveCommand) ossBatchDeleteObjectsRetryVersion(bucket *oss.Bucket, o
 		if err != nil {
 			serviceError, noNeedRetry := err.(oss.ServiceError)
 			if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+				for _, object := range objectVersions {
+					rc.rmOption.failed.record(object.Key, err)
+				}
 				return deletedNum, fmt.Errorf("%s,delete versioning objects: %#v failed", err.Error(), objectVersions)
 			}
 		}
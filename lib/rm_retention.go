@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// rmRetention collects the objects protected by the worm of the bucket during rm, the worm
+// configuration is only read when some objects failed to be removed
+type rmRetention struct {
+	once    sync.Once
+	period  time.Duration // 0 means the bucket has no worm
+	mu      sync.Mutex
+	blocked map[string]time.Time // key -> retain until
+}
+
+// filterRetentionBlocked returns the keys which are not protected by the worm of the bucket, the
+// protected ones are collected into the blocked section of the summary instead of the errors
+func (rc *RemoveCommand) filterRetentionBlocked(bucket *oss.Bucket, keys []string) []string {
+	r := rc.rmOption.retention
+	if r == nil || len(keys) == 0 {
+		return keys
+	}
+	r.once.Do(func() {
+		wormConfig, err := bucket.Client.GetBucketWorm(bucket.BucketName, rc.commonOptions...)
+		if err != nil {
+			if !isNotFound(err) {
+				LogError("get worm of bucket %s error: %s\n", bucket.BucketName, err.Error())
+			}
+			return
+		}
+		r.period = time.Duration(wormConfig.RetentionPeriodInDays) * 24 * time.Hour
+	})
+	if r.period == 0 {
+		return keys
+	}
+
+	rest := []string{}
+	now := time.Now()
+	for _, key := range keys {
+		r.mu.Lock()
+		_, ok := r.blocked[key]
+		r.mu.Unlock()
+		if ok {
+			// the object may be removed again, e.g., by removing the special character objects
+			continue
+		}
+
+		props, err := rc.command.ossGetObjectStatRetry(bucket, key, rc.commonOptions...)
+		if err != nil {
+			rest = append(rest, key)
+			continue
+		}
+		lastModified, err := time.Parse(http.TimeFormat, props.Get(oss.HTTPHeaderLastModified))
+		if err != nil {
+			rest = append(rest, key)
+			continue
+		}
+		state := retentionStateOf(oss.ObjectProperties{Key: key, LastModified: lastModified}, r.period, now)
+		if state.Status != retentionLocked {
+			rest = append(rest, key)
+			continue
+		}
+		r.mu.Lock()
+		if r.blocked == nil {
+			r.blocked = map[string]time.Time{}
+		}
+		r.blocked[key] = state.RetainUntil
+		r.mu.Unlock()
+		rc.rmOption.failed.record(key, fmt.Errorf("protected by retention until %s", state.RetainUntil.Format(time.RFC3339)))
+	}
+	return rest
+}
+
+// printRetentionBlocked prints the blocked section of the summary, and returns the error if any
+// object is blocked
+func (rc *RemoveCommand) printRetentionBlocked(bucket string) error {
+	r := rc.rmOption.retention
+	if r == nil || len(r.blocked) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(r.blocked))
+	for key := range r.blocked {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Printf("\nblocked by retention(%d objects):\n", len(keys))
+	fmt.Printf("%-30s  %s\n", "RetainUntil", "ObjectName")
+	for _, key := range keys {
+		fmt.Printf("%-30s  %s\n", utcToLocalTime(r.blocked[key]), CloudURLToString(bucket, key))
+	}
+	return fmt.Errorf("%d objects are protected by the retention policy of bucket %s, they are not removed", len(r.blocked), bucket)
+}
@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestRemoveRetentionBlocked(c *C) {
+	var mu sync.Mutex
+	var deleted []string
+	removed := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		switch {
+		case r.Method == "GET" && r.URL.Query().Get("worm") != "" || r.URL.RawQuery == "worm":
+			fmt.Fprint(w, `<WormConfiguration><WormId>1</WormId><State>Locked</State><RetentionPeriodInDays>1</RetentionPeriodInDays></WormConfiguration>`)
+		case r.Method == "GET":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>`)
+			for _, key := range []string{"logs/a", "logs/locked", "logs/b"} {
+				if !removed[key] {
+					fmt.Fprintf(w, `<Contents><Key>%s</Key><Size>1</Size></Contents>`, key)
+				}
+			}
+			fmt.Fprint(w, `</ListBucketResult>`)
+		case r.Method == "HEAD":
+			lastModified := time.Now().Add(-48 * time.Hour)
+			if strings.HasPrefix(key, "logs/locked") {
+				lastModified = time.Now()
+			}
+			w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+		case r.Method == "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			keys := []string{}
+			for _, part := range strings.Split(string(body), "<Key>")[1:] {
+				keys = append(keys, part[:strings.Index(part, "</Key>")])
+			}
+			// the batch fails if it has any locked object
+			for _, key := range keys {
+				if strings.HasPrefix(key, "logs/locked") {
+					w.WriteHeader(http.StatusConflict)
+					fmt.Fprint(w, `<Error><Code>FileImmutable</Code><Message>the object is immutable</Message></Error>`)
+					return
+				}
+			}
+			deleted = append(deleted, keys...)
+			for _, key := range keys {
+				removed[key] = true
+			}
+			fmt.Fprint(w, `<DeleteResult></DeleteResult>`)
+		case r.Method == "DELETE":
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `<Error><Code>FileImmutable</Code><Message>the object is immutable</Message></Error>`)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRecursion:       &recursive,
+		OptionForce:           &force,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/logs/"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "1 objects are protected by the retention policy"), Equals, true)
+	sort.Strings(deleted)
+	c.Assert(deleted, DeepEquals, []string{"logs/a", "logs/b"})
+	output := s.readFile(resultPath, c)
+	c.Assert(strings.Contains(output, "blocked by retention(1 objects):"), Equals, true)
+	c.Assert(strings.Contains(output, "oss://bucket/logs/locked\n"), Equals, true)
+
+	// remove single object
+	delete(options, OptionRecursion)
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/logs/locked"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "protected by the retention policy"), Equals, true)
+}
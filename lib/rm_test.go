@@ -7,6 +7,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
 	. "gopkg.in/check.v1"
@@ -1188,3 +1190,126 @@ func (s *OssutilCommandSuite) TestMultipartUploadsProducer(c *C) {
 		c.Assert(true, Equals, false)
 	}
 }
+
+func (s *OssutilCommandSuite) TestRemoveListSplit(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	keys := []string{"dir/0", "dir/a", "dir/h", "dir/x/y", "other"}
+	for _, key := range keys {
+		s.putObject(bucketName, key, uploadFileName, c)
+	}
+
+	str := ""
+	ok := true
+	listSplit := ListSplitAuto
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &ok,
+		"force":           &ok,
+		"listSplit":       &listSplit,
+	}
+	_, err := cm.RunCommand("rm", []string{CloudURLToString(bucketName, "dir/")}, options)
+	c.Assert(err, IsNil)
+
+	objects := s.listObjects(bucketName, "", "ls -s", c)
+	c.Assert(len(objects), Equals, 1)
+	c.Assert(objects[0], Equals, "other")
+
+	// --list-split needs --recursive
+	recursive := false
+	options["recursive"] = &recursive
+	_, err = cm.RunCommand("rm", []string{CloudURLToString(bucketName, "other")}, options)
+	c.Assert(err, NotNil)
+
+	s.removeBucket(bucketName, true, c)
+}
+
+func (s *OssutilCommandSuite) TestRemoveSpeedInfo(c *C) {
+	var m RMMonitor
+	m.init()
+	m.updateOP(objectType)
+	c.Assert(m.getSpeedInfo(m.getSnapshot()), Equals, "")
+
+	m.startTime = time.Now().Add(-10 * time.Second)
+	m.updateScanNum(300)
+	m.updateObjectNum(100)
+	c.Assert(m.getSpeedInfo(m.getSnapshot()), Equals, " Speed: 10/s.")
+
+	m.setScanEnd()
+	c.Assert(m.getSpeedInfo(m.getSnapshot()), Equals, " Speed: 10/s. About 20s left.")
+	c.Assert(strings.Contains(m.progressBar(false, normalExit), "Speed: 10/s."), Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestRemoveDeleteTasks(c *C) {
+	rc := &RemoveCommand{}
+	rc.rmOption.routines = 3
+
+	var count int64
+	err := rc.runDeleteTasks(func(submit func(task func() error) bool) error {
+		for i := 0; i < 20; i++ {
+			submit(func() error {
+				atomic.AddInt64(&count, 1)
+				return nil
+			})
+		}
+		return nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, int64(20))
+
+	// the first error stops the listing
+	err = rc.runDeleteTasks(func(submit func(task func() error) bool) error {
+		for i := 0; ; i++ {
+			if !submit(func() error { return fmt.Errorf("delete error") }) {
+				return nil
+			}
+		}
+	})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "delete error")
+
+	err = rc.runDeleteTasks(func(submit func(task func() error) bool) error {
+		return fmt.Errorf("list error")
+	})
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, "list error")
+}
+
+func (s *OssutilCommandSuite) TestRemoveWithQPS(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	for i := 0; i < 5; i++ {
+		s.putObject(bucketName, fmt.Sprintf("dir/%d", i), uploadFileName, c)
+	}
+
+	str := ""
+	ok := true
+	routines := strconv.Itoa(2)
+	qps := "0"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &ok,
+		"force":           &ok,
+		"routines":        &routines,
+		"qps":             &qps,
+	}
+	_, err := cm.RunCommand("rm", []string{CloudURLToString(bucketName, "dir/")}, options)
+	c.Assert(err, NotNil)
+
+	qps = "5"
+	_, err = cm.RunCommand("rm", []string{CloudURLToString(bucketName, "dir/")}, options)
+	c.Assert(err, IsNil)
+
+	objects := s.listObjects(bucketName, "dir/", "ls -s", c)
+	c.Assert(len(objects), Equals, 0)
+
+	s.removeBucket(bucketName, true, c)
+}
@@ -0,0 +1,476 @@
+package lib
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var specChineseRPC = SpecText{
+	synopsisText: "以JSON-RPC方式从标准输入读取请求，供图形界面等程序集成",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil rpc [-c file] [-e endpoint] [-i id] [-k key] [-t token]
+`,
+
+	detailHelpText: `
+    该命令从标准输入逐行读取JSON-RPC 2.0请求，每行一个请求，并向标准输出逐行写入JSON格式的
+    响应和事件，便于图形界面等程序以结构化的方式调用ossutil，而无需解析命令行的输出。命令执行
+    过程中的其它输出被重定向到标准错误输出。标准输入结束后，命令等待正在执行的请求完成后退出。
+
+    请求的格式为：
+        {"jsonrpc":"2.0","id":1,"method":"list","params":{"url":"oss://bucket/prefix/"}}
+
+    支持的method及其params如下：
+
+    list: 列举object，params为{"url":"oss://bucket[/prefix]","directory":false,"limit":0}，
+          directory为true时只列举当前目录，子目录在结果的prefixes中返回，limit为最大返回数量，
+          0表示不限制
+    stat: 获取object的meta，params为{"url":"oss://bucket/object"}
+    cp:   上传、下载或拷贝，params为{"src":["..."],"dest":"...","recursive":false,"update":false,
+          "include":[],"exclude":[],"progress":false,"progressInterval":1000}，progress为true时，
+          每隔progressInterval毫秒写入一次进度事件：
+          {"jsonrpc":"2.0","method":"progress","params":{"id":1,"progress":{...}}}
+          cp请求逐个执行，行为与Go API的Copy一致，update为false时覆盖已存在的文件或object
+    cancel: 取消正在执行的请求，params为{"id":1}
+
+    执行成功时响应为{"jsonrpc":"2.0","id":1,"result":{...}}，失败时为
+    {"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"...","data":{"kind":"NotFound","exitCode":4}}}，
+    其中kind和exitCode为错误的类型和对应的ossutil退出码。
+`,
+
+	sampleText: `
+    1) 列举bucket下的目录
+       echo '{"jsonrpc":"2.0","id":1,"method":"list","params":{"url":"oss://bucket/","directory":true}}' | ossutil rpc
+
+    2) 上传目录并订阅进度
+       echo '{"jsonrpc":"2.0","id":1,"method":"cp","params":{"src":["dir"],"dest":"oss://bucket/dir/","recursive":true,"progress":true}}' | ossutil rpc
+`,
+}
+
+var specEnglishRPC = SpecText{
+	synopsisText: "Read JSON-RPC requests from stdin for the integration of GUI frontends",
+
+	paramText: "[options]",
+
+	syntaxText: `
+    ossutil rpc [-c file] [-e endpoint] [-i id] [-k key] [-t token]
+`,
+
+	detailHelpText: `
+    The command reads JSON-RPC 2.0 requests from stdin, one request per line, and writes the
+    responses and events in JSON to stdout line by line, so that the GUI frontends can call
+    ossutil in a structured way instead of parsing the output of the command line. The other
+    output of the running requests is redirected to stderr. After stdin ends, the command
+    waits for the running requests to finish and exits.
+
+    The format of the requests is:
+        {"jsonrpc":"2.0","id":1,"method":"list","params":{"url":"oss://bucket/prefix/"}}
+
+    The methods and their params are:
+
+    list: list the objects, the params are {"url":"oss://bucket[/prefix]","directory":false,
+          "limit":0}, the sub directories are returned in prefixes of the result if directory
+          is true, limit is the max number to return, 0 means no limit
+    stat: get the meta of the object, the params are {"url":"oss://bucket/object"}
+    cp:   upload, download or copy, the params are {"src":["..."],"dest":"...","recursive":
+          false,"update":false,"include":[],"exclude":[],"progress":false,"progressInterval":
+          1000}, if progress is true, the progress event is written every progressInterval
+          milliseconds:
+          {"jsonrpc":"2.0","method":"progress","params":{"id":1,"progress":{...}}}
+          the cp requests are run one by one, they behave like Copy of the Go API, the
+          existing files or objects are overwritten unless update is true
+    cancel: cancel the running request, the params are {"id":1}
+
+    The response is {"jsonrpc":"2.0","id":1,"result":{...}} on success, or
+    {"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"...","data":{"kind":"NotFound","exitCode":4}}}
+    on failure, kind and exitCode are the kind of the error and the exit code of ossutil for it.
+`,
+
+	sampleText: `
+    1) list the directories of the bucket
+       echo '{"jsonrpc":"2.0","id":1,"method":"list","params":{"url":"oss://bucket/","directory":true}}' | ossutil rpc
+
+    2) upload the directory and subscribe the progress
+       echo '{"jsonrpc":"2.0","id":1,"method":"cp","params":{"src":["dir"],"dest":"oss://bucket/dir/","recursive":true,"progress":true}}' | ossutil rpc
+`,
+}
+
+// the error codes of JSON-RPC 2.0, rpcErrorCommand is for the errors of the commands
+const (
+	rpcErrorParse          = -32700
+	rpcErrorInvalidRequest = -32600
+	rpcErrorMethodNotFound = -32601
+	rpcErrorInvalidParams  = -32602
+	rpcErrorCommand        = -32000
+	rpcMaxLineSize         = 16 * 1024 * 1024
+)
+
+// rpcErrorKinds is the kinds of errors by the exit codes
+var rpcErrorKinds = map[int]string{
+	ExitCodeBudgetExceeded:     "BudgetExceeded",
+	ExitCodeNotFound:           "NotFound",
+	ExitCodeAccessDenied:       "AccessDenied",
+	ExitCodePreconditionFailed: "PreconditionFailed",
+	ExitCodeThrottled:          "Throttled",
+	ExitCodePositionConflict:   "PositionConflict",
+	ExitCodeInterrupted:        "Canceled",
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    *rpcErrorData `json:"data,omitempty"`
+}
+
+type rpcErrorData struct {
+	Kind     string `json:"kind,omitempty"`
+	ExitCode int    `json:"exitCode"`
+}
+
+type rpcListParams struct {
+	URL       string `json:"url"`
+	Directory bool   `json:"directory"`
+	Limit     int64  `json:"limit"`
+}
+
+type rpcObject struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"lastModified"`
+	ETag         string    `json:"etag"`
+	StorageClass string    `json:"storageClass"`
+	Type         string    `json:"type"`
+}
+
+type rpcListResult struct {
+	Objects  []rpcObject `json:"objects"`
+	Prefixes []string    `json:"prefixes"`
+}
+
+type rpcStatParams struct {
+	URL string `json:"url"`
+}
+
+type rpcCopyParams struct {
+	Src              []string `json:"src"`
+	Dest             string   `json:"dest"`
+	Recursive        bool     `json:"recursive"`
+	Update           bool     `json:"update"`
+	Include          []string `json:"include"`
+	Exclude          []string `json:"exclude"`
+	Progress         bool     `json:"progress"`
+	ProgressInterval int64    `json:"progressInterval"` // milliseconds
+}
+
+type rpcProgressParams struct {
+	ID       json.RawMessage `json:"id"`
+	Progress CopyProgress    `json:"progress"`
+}
+
+type rpcCancelParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+type RPCCommand struct {
+	command Command
+	config  Config
+	out     *json.Encoder
+	outMu   sync.Mutex
+	running map[string]context.CancelFunc
+	runMu   sync.Mutex
+}
+
+var rpcCommand = RPCCommand{
+	command: Command{
+		name:      "rpc",
+		nameAlias: []string{"rpc"},
+		minArgc:   0,
+		maxArgc:   0,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRetryTimes,
+			OptionLogLevel,
+			OptionRequestPayer,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionReadTimeout,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionForcePathStyle,
+			OptionSkipVerifyCert,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (rc *RPCCommand) formatHelpForWhole() string {
+	return rc.command.formatHelpForWhole()
+}
+
+func (rc *RPCCommand) formatIndependHelp() string {
+	return rc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (rc *RPCCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return rc.command.Init(ctx, args, options, rc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (rc *RPCCommand) RunCommand() error {
+	rc.config = configFromOptions(rc.command.options)
+	rc.running = map[string]context.CancelFunc{}
+
+	// the responses are written to stdout, the other output goes to stderr
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+	return rc.serve(rc.command.context(), os.Stdin, stdout)
+}
+
+// serve reads the requests from in until it ends, and waits for the running requests to finish
+func (rc *RPCCommand) serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	rc.out = json.NewEncoder(out)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), rpcMaxLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var request rpcRequest
+		if err := json.Unmarshal(line, &request); err != nil {
+			rc.reply(nil, nil, &rpcError{Code: rpcErrorParse, Message: err.Error()})
+			continue
+		}
+		if request.JSONRPC != "2.0" || request.Method == "" {
+			rc.reply(request.ID, nil, &rpcError{Code: rpcErrorInvalidRequest, Message: "invalid request, jsonrpc must be 2.0 and method is needed"})
+			continue
+		}
+		if request.Method == "cancel" {
+			result, rerr := rc.cancel(request.Params)
+			rc.reply(request.ID, result, rerr)
+			continue
+		}
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		if !rc.start(request.ID, cancel) {
+			cancel()
+			rc.reply(request.ID, nil, &rpcError{Code: rpcErrorInvalidRequest, Message: fmt.Sprintf("request %s is running", string(request.ID))})
+			continue
+		}
+		wg.Add(1)
+		go func(request rpcRequest) {
+			defer wg.Done()
+			defer rc.finish(request.ID, cancel)
+			result, rerr := rc.call(reqCtx, request)
+			rc.reply(request.ID, result, rerr)
+		}(request)
+	}
+	return scanner.Err()
+}
+
+// start records the cancel function of the request, false if the request of the same id is running
+func (rc *RPCCommand) start(id json.RawMessage, cancel context.CancelFunc) bool {
+	if len(id) == 0 {
+		return true
+	}
+	rc.runMu.Lock()
+	defer rc.runMu.Unlock()
+	if _, ok := rc.running[string(id)]; ok {
+		return false
+	}
+	rc.running[string(id)] = cancel
+	return true
+}
+
+func (rc *RPCCommand) finish(id json.RawMessage, cancel context.CancelFunc) {
+	cancel()
+	if len(id) == 0 {
+		return
+	}
+	rc.runMu.Lock()
+	delete(rc.running, string(id))
+	rc.runMu.Unlock()
+}
+
+func (rc *RPCCommand) cancel(params json.RawMessage) (interface{}, *rpcError) {
+	var p rpcCancelParams
+	if rerr := parseParams(params, &p); rerr != nil || len(p.ID) == 0 {
+		return nil, &rpcError{Code: rpcErrorInvalidParams, Message: "invalid params, the id of the request to cancel is needed"}
+	}
+	rc.runMu.Lock()
+	cancel, ok := rc.running[string(p.ID)]
+	rc.runMu.Unlock()
+	if ok {
+		cancel()
+	}
+	return map[string]bool{"canceled": ok}, nil
+}
+
+func (rc *RPCCommand) call(ctx context.Context, request rpcRequest) (interface{}, *rpcError) {
+	var result interface{}
+	var err error
+	switch request.Method {
+	case "list":
+		var p rpcListParams
+		if rerr := parseParams(request.Params, &p); rerr != nil {
+			return nil, rerr
+		}
+		result, err = rc.list(ctx, p)
+	case "stat":
+		var p rpcStatParams
+		if rerr := parseParams(request.Params, &p); rerr != nil {
+			return nil, rerr
+		}
+		result, err = rc.stat(ctx, p)
+	case "cp":
+		var p rpcCopyParams
+		if rerr := parseParams(request.Params, &p); rerr != nil {
+			return nil, rerr
+		}
+		result, err = rc.copy(ctx, request.ID, p)
+	default:
+		return nil, &rpcError{Code: rpcErrorMethodNotFound, Message: fmt.Sprintf("method not found: %s", request.Method)}
+	}
+	if err != nil {
+		code := ExitCode(err)
+		return nil, &rpcError{Code: rpcErrorCommand, Message: err.Error(), Data: &rpcErrorData{Kind: rpcErrorKinds[code], ExitCode: code}}
+	}
+	return result, nil
+}
+
+// parseParams decodes the params of the request, the params can be omitted
+func parseParams(params json.RawMessage, v interface{}) *rpcError {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: rpcErrorInvalidParams, Message: "invalid params, " + err.Error()}
+	}
+	return nil
+}
+
+func (rc *RPCCommand) list(ctx context.Context, p rpcListParams) (interface{}, error) {
+	lor, err := ListObjects(ctx, rc.config, p.URL, ListObjectsOptions{Directory: p.Directory, Limit: p.Limit})
+	if err != nil {
+		return nil, err
+	}
+	result := rpcListResult{Objects: []rpcObject{}, Prefixes: []string{}}
+	for _, object := range lor.Objects {
+		result.Objects = append(result.Objects, rpcObject{
+			Key:          object.Key,
+			Size:         object.Size,
+			LastModified: object.LastModified,
+			ETag:         object.ETag,
+			StorageClass: object.StorageClass,
+			Type:         object.Type,
+		})
+	}
+	result.Prefixes = append(result.Prefixes, lor.Prefixes...)
+	return result, nil
+}
+
+func (rc *RPCCommand) stat(ctx context.Context, p rpcStatParams) (interface{}, error) {
+	header, err := StatObject(ctx, rc.config, p.URL)
+	if err != nil {
+		return nil, err
+	}
+	meta := map[string]string{}
+	for name := range header {
+		meta[name] = header.Get(name)
+	}
+	return meta, nil
+}
+
+func (rc *RPCCommand) copy(ctx context.Context, id json.RawMessage, p rpcCopyParams) (interface{}, error) {
+	if len(p.Src) == 0 || p.Dest == "" {
+		return nil, fmt.Errorf("invalid params, src and dest are needed")
+	}
+	var last CopyProgress
+	options := CopyOptions{
+		Recursive: p.Recursive,
+		Update:    p.Update,
+		Include:   p.Include,
+		Exclude:   p.Exclude,
+		Progress:  func(progress CopyProgress) { last = progress },
+		Output:    os.Stderr,
+	}
+	if p.Progress {
+		options.ProgressInterval = time.Duration(p.ProgressInterval) * time.Millisecond
+		options.Progress = func(progress CopyProgress) {
+			last = progress
+			rc.notify("progress", rpcProgressParams{ID: id, Progress: progress})
+		}
+	}
+	if err := Copy(ctx, rc.config, p.Src, p.Dest, options); err != nil {
+		return nil, err
+	}
+	return last, nil
+}
+
+func (rc *RPCCommand) reply(id json.RawMessage, result interface{}, rerr *rpcError) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	if result == nil && rerr == nil {
+		result = struct{}{}
+	}
+	rc.write(rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rerr})
+}
+
+func (rc *RPCCommand) notify(method string, params interface{}) {
+	rc.write(rpcNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (rc *RPCCommand) write(message interface{}) {
+	rc.outMu.Lock()
+	defer rc.outMu.Unlock()
+	if err := rc.out.Encode(message); err != nil {
+		LogError("write rpc message error, %s\n", err.Error())
+	}
+}
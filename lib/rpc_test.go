@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestRPC(c *C) {
+	var mu sync.Mutex
+	uploaded := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "HEAD" && r.URL.Path == "/bucket/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "HEAD":
+			w.Header().Set("X-Oss-Meta-Owner", "teamA")
+		case r.Method == "PUT":
+			body, _ := ioutil.ReadAll(r.Body)
+			uploaded[r.URL.Path] = string(body)
+		default:
+			w.Write([]byte(`<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>dir/a</Key><Size>1</Size><StorageClass>Standard</StorageClass></Contents>
+<CommonPrefixes><Prefix>dir/sub/</Prefix></CommonPrefixes>
+</ListBucketResult>`))
+		}
+	}))
+	defer server.Close()
+
+	dir := "ossutil-test-rpc-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	defer os.RemoveAll(dir)
+	c.Assert(ioutil.WriteFile(filepath.Join(dir, "a.txt"), []byte("abc"), 0644), IsNil)
+	outputDir := "ossutil-test-rpc-output-" + randLowStr(5)
+	defer os.RemoveAll(outputDir)
+
+	requests := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"list","params":{"url":"oss://bucket/dir/","directory":true}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"stat","params":{"url":"oss://bucket/dir/a"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"stat","params":{"url":"oss://bucket/missing"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"cp","params":{"src":["` + dir + `"],"dest":"oss://bucket/up/","recursive":true,"progress":true,"progressInterval":10}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"mv"}`,
+		`{"jsonrpc":"2.0","id":6,"method":"cancel","params":{"id":100}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"list","params":{"url":1}}`,
+		`not json`,
+		``,
+	}
+	rc := &RPCCommand{
+		config: Config{
+			Endpoint:        server.URL,
+			AccessKeyID:     "ak",
+			AccessKeySecret: "ak",
+			ForcePathStyle:  true,
+			RetryTimes:      1,
+		},
+		running: map[string]context.CancelFunc{},
+	}
+
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	var out bytes.Buffer
+	err = rc.serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out)
+	os.Stdout = oldStdout
+	testResultFile.Close()
+	c.Assert(err, IsNil)
+
+	type message struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	responses := map[string]message{}
+	progressNum := 0
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m message
+		c.Assert(json.Unmarshal([]byte(line), &m), IsNil)
+		if m.Method == "progress" {
+			progressNum++
+			continue
+		}
+		responses[string(m.ID)] = m
+	}
+	c.Assert(len(responses), Equals, 8)
+	c.Assert(progressNum > 0, Equals, true)
+
+	var list rpcListResult
+	c.Assert(json.Unmarshal(responses["1"].Result, &list), IsNil)
+	c.Assert(len(list.Objects), Equals, 1)
+	c.Assert(list.Objects[0].Key, Equals, "dir/a")
+	c.Assert(list.Objects[0].StorageClass, Equals, "Standard")
+	c.Assert(list.Prefixes, DeepEquals, []string{"dir/sub/"})
+
+	var meta map[string]string
+	c.Assert(json.Unmarshal(responses["2"].Result, &meta), IsNil)
+	c.Assert(meta["X-Oss-Meta-Owner"], Equals, "teamA")
+
+	c.Assert(responses["3"].Error.Code, Equals, rpcErrorCommand)
+	c.Assert(responses["3"].Error.Data.Kind, Equals, "NotFound")
+	c.Assert(responses["3"].Error.Data.ExitCode, Equals, ExitCodeNotFound)
+
+	var progress CopyProgress
+	c.Assert(responses["4"].Error, IsNil)
+	c.Assert(json.Unmarshal(responses["4"].Result, &progress), IsNil)
+	c.Assert(progress.TransferredBytes, Equals, int64(3))
+	c.Assert(uploaded["/bucket/up/a.txt"], Equals, "abc")
+
+	c.Assert(responses["5"].Error.Code, Equals, rpcErrorMethodNotFound)
+	c.Assert(string(responses["6"].Result), Equals, `{"canceled":false}`)
+	c.Assert(responses["7"].Error.Code, Equals, rpcErrorInvalidParams)
+	c.Assert(responses["null"].Error.Code, Equals, rpcErrorParse)
+}
@@ -0,0 +1,327 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// S3SchemePrefix is the prefix of s3 url
+const S3SchemePrefix string = "s3://"
+
+// environment variables used by s3 compatible urls, same as aws cli
+const (
+	EnvAWSAccessKeyID     = "AWS_ACCESS_KEY_ID"
+	EnvAWSSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	EnvAWSSessionToken    = "AWS_SESSION_TOKEN"
+	EnvAWSRegion          = "AWS_REGION"
+	EnvAWSDefaultRegion   = "AWS_DEFAULT_REGION"
+	EnvAWSEndpointURL     = "AWS_ENDPOINT_URL"
+	EnvAWSEndpointURLS3   = "AWS_ENDPOINT_URL_S3"
+)
+
+const (
+	s3DefaultRegion    = "us-east-1"
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	s3TimeFormat       = "20060102T150405Z"
+	s3ShortTimeFormat  = "20060102"
+)
+
+// s3URLCommands are the commands which accept s3:// urls
+var s3URLCommands = map[string]bool{"ls": true, "cp": true, "rm": true, "stat": true}
+
+// isS3Client returns true if the requests of the client are sent to s3 compatible service
+func isS3Client(client *oss.Client) bool {
+	if client == nil || client.HTTPClient == nil {
+		return false
+	}
+	_, ok := client.HTTPClient.Transport.(*s3SignTransport)
+	return ok
+}
+
+// bucketObjectURL formats the url of the object with the scheme of the provider serving the bucket
+func bucketObjectURL(bucket *oss.Bucket, object string) string {
+	cloudURL := CloudURL{bucket: bucket.BucketName, object: object}
+	if isS3Client(&bucket.Client) {
+		cloudURL.scheme = S3SchemePrefix
+	}
+	return cloudURL.ToString()
+}
+
+// checkS3URLs rejects the s3:// urls of the commands which don't support them
+func (cmd *Command) checkS3URLs() error {
+	if s3URLCommands[cmd.name] {
+		return nil
+	}
+	for _, arg := range cmd.args {
+		if hasS3SchemePrefix(arg) {
+			return CommandError{cmd.name, fmt.Sprintf("s3:// url is not supported, it's only supported by ls, cp, rm and stat: %s", arg)}
+		}
+	}
+	return nil
+}
+
+func hasS3SchemePrefix(urlStr string) bool {
+	return strings.HasPrefix(strings.ToLower(urlStr), S3SchemePrefix)
+}
+
+// s3Endpoint returns the endpoint of s3 service, --s3-endpoint option takes precedence over
+// the environment variables, the default is the aws endpoint of the region
+func (cmd *Command) s3Endpoint(region string) string {
+	endpoint, _ := GetString(OptionS3Endpoint, cmd.options)
+	if endpoint == "" {
+		endpoint = os.Getenv(EnvAWSEndpointURLS3)
+	}
+	if endpoint == "" {
+		endpoint = os.Getenv(EnvAWSEndpointURL)
+	}
+	if endpoint == "" {
+		endpoint = "s3." + region + ".amazonaws.com"
+	}
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "https://" + endpoint
+	}
+	return endpoint
+}
+
+func s3Region() string {
+	region := os.Getenv(EnvAWSRegion)
+	if region == "" {
+		region = os.Getenv(EnvAWSDefaultRegion)
+	}
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	return region
+}
+
+// s3Client creates a client for s3 compatible service, the requests are built by oss sdk
+// and translated to s3 requests by s3SignTransport
+func (cmd *Command) s3Client(bucket string) (*oss.Client, error) {
+	accessKeyID := os.Getenv(EnvAWSAccessKeyID)
+	accessKeySecret := os.Getenv(EnvAWSSecretAccessKey)
+	if accessKeyID == "" || accessKeySecret == "" {
+		return nil, fmt.Errorf("%s or %s is empty, they are required by s3 url of bucket %s",
+			EnvAWSAccessKeyID, EnvAWSSecretAccessKey, bucket)
+	}
+
+	region := s3Region()
+	endpoint := cmd.s3Endpoint(region)
+
+	proxyHost, _ := GetString(OptionProxyHost, cmd.options)
+	proxyUser, _ := GetString(OptionProxyUser, cmd.options)
+	proxyPwd, _ := GetString(OptionProxyPwd, cmd.options)
+	bSkipVerifyCert, _ := GetBool(OptionSkipVerifyCert, cmd.options)
+	bForcePathStyle, _ := GetBool(OptionForcePathStyle, cmd.options)
+
+	base := &http.Transport{Proxy: http.ProxyFromEnvironment}
+	if proxyHost != "" {
+		proxyURL, err := url.Parse(proxyHost)
+		if err != nil {
+			return nil, err
+		}
+		if proxyUser != "" {
+			proxyURL.User = url.UserPassword(proxyUser, proxyPwd)
+		}
+		base.Proxy = http.ProxyURL(proxyURL)
+	}
+	if bSkipVerifyCert {
+		LogInfo("skip verify s3 server's tls certificate\n")
+		base.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	transport := &s3SignTransport{
+		base:            base,
+		accessKeyID:     accessKeyID,
+		accessKeySecret: accessKeySecret,
+		sessionToken:    os.Getenv(EnvAWSSessionToken),
+		region:          region,
+	}
+	httpClient := &http.Client{Transport: transport}
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	userAgent, _ := GetString(OptionUserAgent, cmd.options)
+	options := []oss.ClientOption{
+		oss.HTTPClient(httpClient),
+		oss.UserAgent(getUserAgent(userAgent)),
+		// s3 does not return crc64 of objects
+		oss.EnableCRC(false),
+	}
+	if bForcePathStyle {
+		options = append(options, oss.ForcePathStyle(true))
+	}
+	if contentMD5, _ := GetBool(OptionContentMD5, cmd.options); contentMD5 {
+		options = append(options, oss.EnableMD5(true))
+	}
+	if logLevel > oss.LogOff {
+		options = append(options, oss.SetLogLevel(logLevel))
+		options = append(options, oss.SetLogger(utilLogger))
+	}
+
+	LogInfo("use s3 endpoint %s, region %s for bucket %s\n", endpoint, region, bucket)
+	return oss.New(endpoint, accessKeyID, accessKeySecret, options...)
+}
+
+// s3SignTransport translates the oss requests to s3 requests: renames the x-oss-* headers
+// to x-amz-* headers, and signs the requests with aws signature v4, the x-amz-* headers of
+// responses are renamed back to x-oss-* headers so that oss sdk can parse them
+type s3SignTransport struct {
+	base            http.RoundTripper
+	accessKeyID     string
+	accessKeySecret string
+	sessionToken    string
+	region          string
+}
+
+// the oss headers which have different names in s3
+var s3HeaderRenames = map[string]string{
+	"X-Oss-Object-Acl": "X-Amz-Acl",
+}
+
+func (t *s3SignTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Del("Authorization")
+	for key, values := range req.Header {
+		if !strings.HasPrefix(key, "X-Oss-") {
+			continue
+		}
+		req.Header.Del(key)
+		newKey, ok := s3HeaderRenames[key]
+		if !ok {
+			newKey = "X-Amz-" + key[len("X-Oss-"):]
+		}
+		req.Header[newKey] = values
+	}
+	// send the path in the same form as the canonical uri
+	req.URL.RawPath = s3CanonicalURI(req.URL)
+	t.sign(req, time.Now().UTC())
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	for key, values := range resp.Header {
+		if strings.HasPrefix(key, "X-Amz-") {
+			resp.Header["X-Oss-"+key[len("X-Amz-"):]] = values
+		}
+	}
+	return resp, nil
+}
+
+// sign adds aws signature v4 to the request, the payload is not signed
+func (t *s3SignTransport) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format(s3TimeFormat)
+	shortDate := now.Format(s3ShortTimeFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	if t.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", t.sessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for key := range req.Header {
+		lowerKey := strings.ToLower(key)
+		if strings.HasPrefix(lowerKey, "x-amz-") || lowerKey == "content-type" || lowerKey == "content-md5" {
+			headers[lowerKey] = strings.TrimSpace(req.Header.Get(key))
+		}
+	}
+	headerKeys := make([]string, 0, len(headers))
+	for key := range headers {
+		headerKeys = append(headerKeys, key)
+	}
+	sort.Strings(headerKeys)
+
+	var canonicalHeaders strings.Builder
+	for _, key := range headerKeys {
+		canonicalHeaders.WriteString(key + ":" + headers[key] + "\n")
+	}
+	signedHeaders := strings.Join(headerKeys, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3CanonicalURI(req.URL),
+		s3CanonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+
+	scope := shortDate + "/" + t.region + "/s3/aws4_request"
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := s3SigningAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hashedRequest[:])
+
+	key := s3HmacSHA256([]byte("AWS4"+t.accessKeySecret), shortDate)
+	key = s3HmacSHA256(key, t.region)
+	key = s3HmacSHA256(key, "s3")
+	key = s3HmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(s3HmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgorithm, t.accessKeyID, scope, signedHeaders, signature))
+}
+
+func s3HmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3URIEncode encodes the string as required by aws signature v4
+func s3URIEncode(str string, encodeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			buf.WriteByte(c)
+		} else {
+			buf.WriteString("%" + strings.ToUpper(strconv.FormatInt(int64(c)|0x100, 16)[1:]))
+		}
+	}
+	return buf.String()
+}
+
+func s3CanonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return s3URIEncode(path, false)
+}
+
+func s3CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, s3URIEncode(key, true)+"="+s3URIEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
@@ -0,0 +1,222 @@
+package lib
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestS3CloudURL(c *C) {
+	bucketName := "s3-bucket-" + randLowStr(8)
+	cloudURL, err := CloudURLFromString("s3://"+bucketName+"/dir/object", "")
+	c.Assert(err, IsNil)
+	c.Assert(cloudURL.bucket, Equals, bucketName)
+	c.Assert(cloudURL.object, Equals, "dir/object")
+	c.Assert(cloudURL.isS3(), Equals, true)
+	c.Assert(cloudURL.ToString(), Equals, "s3://"+bucketName+"/dir/object")
+	c.Assert(cloudURL.objectURL(""), Equals, "s3://"+bucketName)
+
+	// oss url of the same bucket name is not affected
+	cloudURL, err = CloudURLFromString("oss://"+bucketName+"/object", "")
+	c.Assert(err, IsNil)
+	c.Assert(cloudURL.isS3(), Equals, false)
+	c.Assert(cloudURL.ToString(), Equals, "oss://"+bucketName+"/object")
+	c.Assert(CloudURLToString(bucketName, ""), Equals, "oss://"+bucketName)
+
+	// the clients are selected by the scheme of url
+	os.Setenv(EnvAWSAccessKeyID, "AKID")
+	os.Setenv(EnvAWSSecretAccessKey, "secret")
+	defer os.Unsetenv(EnvAWSAccessKeyID)
+	defer os.Unsetenv(EnvAWSSecretAccessKey)
+	str := "ak"
+	endpoint := "oss-cn-hangzhou.aliyuncs.com"
+	cmd := Command{options: OptionMapType{OptionEndpoint: &endpoint, OptionAccessKeyID: &str, OptionAccessKeySecret: &str}}
+	bucket, err := cmd.cloudBucket(cloudURL)
+	c.Assert(err, IsNil)
+	c.Assert(isS3Client(&bucket.Client), Equals, false)
+	c.Assert(bucketObjectURL(bucket, "a"), Equals, "oss://"+bucketName+"/a")
+	s3URL, _ := CloudURLFromString("s3://"+bucketName, "")
+	bucket, err = cmd.cloudBucket(s3URL)
+	c.Assert(err, IsNil)
+	c.Assert(isS3Client(&bucket.Client), Equals, true)
+	c.Assert(bucketObjectURL(bucket, "a"), Equals, "s3://"+bucketName+"/a")
+
+	// s3:// url is only accepted by the commands supporting it
+	_, err = cm.RunCommand("set-meta", []string{"s3://" + bucketName + "/object", "X-Oss-Meta-A:b"}, OptionMapType{})
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "s3:// url is not supported"), Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestS3URIEncode(c *C) {
+	c.Assert(s3URIEncode("/dir/a b+c~.txt", false), Equals, "/dir/a%20b%2Bc~.txt")
+	c.Assert(s3URIEncode("a/b", true), Equals, "a%2Fb")
+	c.Assert(s3URIEncode("中", true), Equals, "%E4%B8%AD")
+
+	u, err := url.Parse("http://host/bucket?uploads&prefix=a%2Fb&max-keys=100")
+	c.Assert(err, IsNil)
+	c.Assert(s3CanonicalQuery(u), Equals, "max-keys=100&prefix=a%2Fb&uploads=")
+}
+
+func (s *OssutilCommandSuite) TestS3SignTransport(c *C) {
+	var header http.Header
+	var rawPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		rawPath = r.URL.EscapedPath()
+		w.Header().Set("X-Amz-Meta-Owner", "test")
+		w.Header().Set("X-Amz-Request-Id", "request-id")
+	}))
+	defer server.Close()
+
+	transport := &s3SignTransport{
+		base:            http.DefaultTransport,
+		accessKeyID:     "AKID",
+		accessKeySecret: "secret",
+		sessionToken:    "token",
+		region:          "us-west-2",
+	}
+	req, err := http.NewRequest("PUT", server.URL+"/bucket/dir%2Fa%20b", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Authorization", "OSS AKID:signature")
+	req.Header.Set("X-Oss-Meta-Owner", "test")
+	req.Header.Set("X-Oss-Object-Acl", "private")
+
+	resp, err := transport.RoundTrip(req)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+
+	c.Assert(rawPath, Equals, "/bucket/dir/a%20b")
+	c.Assert(header.Get("X-Oss-Meta-Owner"), Equals, "")
+	c.Assert(header.Get("X-Amz-Meta-Owner"), Equals, "test")
+	c.Assert(header.Get("X-Amz-Acl"), Equals, "private")
+	c.Assert(header.Get("X-Amz-Security-Token"), Equals, "token")
+	c.Assert(header.Get("X-Amz-Content-Sha256"), Equals, s3UnsignedPayload)
+	authorization := header.Get("Authorization")
+	c.Assert(strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKID/"), Equals, true)
+	c.Assert(strings.Contains(authorization, "/us-west-2/s3/aws4_request"), Equals, true)
+	c.Assert(strings.Contains(authorization, "SignedHeaders=host;x-amz-acl;x-amz-content-sha256;x-amz-date;x-amz-meta-owner;x-amz-security-token"), Equals, true)
+
+	// the original request is not modified
+	c.Assert(req.Header.Get("X-Oss-Meta-Owner"), Equals, "test")
+
+	// response headers are renamed back
+	c.Assert(resp.Header.Get("X-Oss-Meta-Owner"), Equals, "test")
+	c.Assert(resp.Header.Get("X-Oss-Request-Id"), Equals, "request-id")
+}
+
+func (s *OssutilCommandSuite) TestS3SignStable(c *C) {
+	transport := &s3SignTransport{accessKeyID: "AKID", accessKeySecret: "secret", region: s3DefaultRegion}
+	now := time.Date(2013, 5, 24, 0, 0, 0, 0, time.UTC)
+
+	req1, _ := http.NewRequest("GET", "https://examplebucket.s3.amazonaws.com/test.txt", nil)
+	transport.sign(req1, now)
+	req2, _ := http.NewRequest("GET", "https://examplebucket.s3.amazonaws.com/test.txt", nil)
+	transport.sign(req2, now)
+	c.Assert(req1.Header.Get("Authorization"), Equals, req2.Header.Get("Authorization"))
+	c.Assert(req1.Header.Get("X-Amz-Date"), Equals, "20130524T000000Z")
+
+	req3, _ := http.NewRequest("GET", "https://examplebucket.s3.amazonaws.com/test2.txt", nil)
+	transport.sign(req3, now)
+	c.Assert(req1.Header.Get("Authorization") != req3.Header.Get("Authorization"), Equals, true)
+}
+
+func (s *OssutilCommandSuite) TestS3ObjectRetention(c *C) {
+	var header http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			header = r.Header
+			w.Header().Set("ETag", `"etag"`)
+		case "HEAD":
+			w.Header().Set("Content-Length", "3")
+			w.Header().Set("X-Amz-Object-Lock-Mode", "COMPLIANCE")
+			w.Header().Set("X-Amz-Object-Lock-Retain-Until-Date", "2099-01-01T00:00:00Z")
+			w.Header().Set("X-Amz-Object-Lock-Legal-Hold", "ON")
+		case "GET":
+			w.Write([]byte(`<AccessControlPolicy><Owner><ID>owner</ID></Owner></AccessControlPolicy>`))
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv(EnvAWSAccessKeyID, "AKID")
+	os.Setenv(EnvAWSSecretAccessKey, "secret")
+	defer os.Unsetenv(EnvAWSAccessKeyID)
+	defer os.Unsetenv(EnvAWSSecretAccessKey)
+
+	fileName := "ossutil-test-retention-" + randLowStr(8)
+	s.createFile(fileName, "abc", c)
+	defer os.Remove(fileName)
+	resultPath := "ossutil-test-retention-result-" + randLowStr(8)
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+		os.Remove(resultPath)
+	}()
+
+	str := "ak"
+	bTrue := true
+	retainUntil := "2099-01-01"
+	mode := "compliance"
+	cpDir := "ossutil-test-retention-cp-" + randLowStr(8)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	partSize := strconv.FormatInt(DefaultPartSize, 10)
+	defer os.RemoveAll(cpDir)
+	options := OptionMapType{
+		OptionEndpoint:         &str,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionS3Endpoint:       &server.URL,
+		OptionForcePathStyle:   &bTrue,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+		OptionForce:            &bTrue,
+		OptionRetainUntil:      &retainUntil,
+		OptionRetentionMode:    &mode,
+		OptionLegalHold:        &bTrue,
+	}
+	bucketName := "s3-bucket-" + randLowStr(8)
+	_, err = cm.RunCommand("cp", []string{fileName, "s3://" + bucketName + "/object"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(header.Get("X-Amz-Object-Lock-Mode"), Equals, "COMPLIANCE")
+	until, err := time.Parse(time.RFC3339, header.Get("X-Amz-Object-Lock-Retain-Until-Date"))
+	c.Assert(err, IsNil)
+	c.Assert(until.Year(), Equals, 2099)
+	c.Assert(header.Get("X-Amz-Object-Lock-Legal-Hold"), Equals, "ON")
+	c.Assert(header.Get("Content-Md5"), Not(Equals), "")
+
+	// oss doesn't support the object level retention
+	_, err = cm.RunCommand("cp", []string{fileName, "oss://bucket/object"}, options)
+	c.Assert(err, NotNil)
+	retainUntil = "2000-01-01"
+	_, err = cm.RunCommand("cp", []string{fileName, "s3://" + bucketName + "/object"}, options)
+	c.Assert(err, NotNil)
+
+	// stat shows the retention
+	_, err = cm.RunCommand("stat", []string{"s3://" + bucketName + "/object"}, OptionMapType{
+		OptionEndpoint:        &str,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionS3Endpoint:      &server.URL,
+		OptionForcePathStyle:  &bTrue,
+	})
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(resultPath)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), StatRetentionMode), Equals, true)
+	c.Assert(strings.Contains(string(data), StatLegalHold), Equals, true)
+	c.Assert(strings.Contains(string(data), "2099-01-01"), Equals, true)
+}
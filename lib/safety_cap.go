@@ -0,0 +1,116 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// safetyCap limits the number and the total size of the objects operated by a recursive command,
+// so that the command aborts instead of operating on the wrong prefix of a giant bucket by accident
+type safetyCap struct {
+	maxObjects int64 // 0 means no limit
+	maxBytes   int64 // 0 means no limit
+	objects    int64
+	bytes      int64
+}
+
+// newSafetyCap returns nil if neither --max-objects nor --max-bytes is specified
+func (cmd *Command) newSafetyCap() (*safetyCap, error) {
+	sc := &safetyCap{}
+	if maxObjects, err := GetInt(OptionMaxObjects, cmd.options); err == nil {
+		if maxObjects <= 0 {
+			return nil, fmt.Errorf("invalid --max-objects: %d, the value should be positive", maxObjects)
+		}
+		sc.maxObjects = maxObjects
+	}
+	if maxBytes, err := GetInt(OptionMaxBytes, cmd.options); err == nil {
+		if maxBytes <= 0 {
+			return nil, fmt.Errorf("invalid --max-bytes: %d, the value should be positive bytes", maxBytes)
+		}
+		sc.maxBytes = maxBytes
+	}
+	if sc.maxObjects == 0 && sc.maxBytes == 0 {
+		return nil, nil
+	}
+	return sc, nil
+}
+
+// check returns error if the objects or the bytes exceed the caps
+func (sc *safetyCap) check(objects, bytes int64) error {
+	if sc == nil {
+		return nil
+	}
+	if sc.maxObjects > 0 && objects > sc.maxObjects {
+		return SafetyCapError{"--max-objects", sc.maxObjects, objects}
+	}
+	if sc.maxBytes > 0 && bytes > sc.maxBytes {
+		return SafetyCapError{"--max-bytes", sc.maxBytes, bytes}
+	}
+	return nil
+}
+
+// take counts the object to be operated, and returns error once the caps are exceeded
+func (sc *safetyCap) take(size int64) error {
+	if sc == nil {
+		return nil
+	}
+	if size < 0 {
+		size = 0
+	}
+	return sc.check(atomic.AddInt64(&sc.objects, 1), atomic.AddInt64(&sc.bytes, size))
+}
+
+// preCountObjects lists the objects under the url before the operation starts, it returns error
+// as soon as the listed objects exceed the caps, so that nothing is operated
+func (cmd *Command) preCountObjects(bucket *oss.Bucket, cloudURL CloudURL, filters []filterOptionType, options ...oss.Option) error {
+	if cmd.safetyCap == nil {
+		return nil
+	}
+
+	counter := &safetyCap{maxObjects: cmd.safetyCap.maxObjects, maxBytes: cmd.safetyCap.maxBytes}
+	if err := cmd.listObjectSizes(bucket, cloudURL, filters, counter.take, options...); err != nil {
+		return err
+	}
+	LogInfo("pre-count of %s:%d objects,%d bytes\n", cloudURL.ToString(), counter.objects, counter.bytes)
+	return nil
+}
+
+// startStatistic scans the files or objects in the background, with --max-objects or --max-bytes the scan
+// is finished before the operation starts, so that nothing is operated if the caps are exceeded
+func (cc *CopyCommand) startStatistic(statistic func()) error {
+	if cc.command.safetyCap == nil {
+		go statistic()
+		return nil
+	}
+	statistic()
+	return cc.command.safetyCap.check(cc.monitor.totalNum, cc.monitor.totalSize)
+}
+
+// checkScanCap stops the scan early if the scanned files or objects exceed the caps
+func (cc *CopyCommand) checkScanCap() bool {
+	return cc.command.safetyCap.check(cc.monitor.totalNum, cc.monitor.totalSize) == nil
+}
+
+// exceedSafetyCap takes the file or object from the caps, and stops the command gracefully once they are exceeded
+func (cc *CopyCommand) exceedSafetyCap(size int64) bool {
+	if err := cc.command.safetyCap.take(size); err != nil {
+		cc.cpOption.budget.abort(err)
+		return true
+	}
+	return false
+}
+
+// uploadSize returns the size of the file to be uploaded for --max-bytes
+func (cc *CopyCommand) uploadSize(file fileInfoType) int64 {
+	if cc.command.safetyCap == nil {
+		return 0
+	}
+	if f, err := os.Stat(filepath.Join(file.dir, file.filePath)); err == nil && !f.IsDir() {
+		return f.Size()
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestSafetyCap(c *C) {
+	maxObjects := "2"
+	maxBytes := "10"
+	cmd := Command{options: OptionMapType{OptionMaxObjects: &maxObjects, OptionMaxBytes: &maxBytes}}
+	sc, err := cmd.newSafetyCap()
+	c.Assert(err, IsNil)
+	c.Assert(sc.take(4), IsNil)
+	c.Assert(sc.take(4), IsNil)
+	err = sc.take(-1)
+	c.Assert(err, FitsTypeOf, SafetyCapError{})
+	c.Assert(err.(SafetyCapError).option, Equals, "--max-objects")
+	c.Assert(sc.check(1, 11).(SafetyCapError).option, Equals, "--max-bytes")
+
+	invalid := "0"
+	cmd = Command{options: OptionMapType{OptionMaxObjects: &invalid}}
+	_, err = cmd.newSafetyCap()
+	c.Assert(err, NotNil)
+	cmd = Command{options: OptionMapType{}}
+	sc, err = cmd.newSafetyCap()
+	c.Assert(err, IsNil)
+	c.Assert(sc, IsNil)
+	c.Assert(sc.take(100), IsNil)
+
+	// the command stops gracefully when the caps are exceeded during the operation
+	cc := CopyCommand{}
+	cc.command.safetyCap = &safetyCap{maxObjects: 1}
+	cc.cpOption.budget = &jobBudget{retryBudget: -1}
+	c.Assert(cc.exceedSafetyCap(1), Equals, false)
+	c.Assert(cc.exceedSafetyCap(1), Equals, true)
+	c.Assert(cc.cpOption.budget.err(), FitsTypeOf, SafetyCapError{})
+
+	// nothing is operated if the pre-count exceeds the caps
+	var mu sync.Mutex
+	var operated []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == "GET" && r.URL.Query().Get("prefix") != "" {
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>prefix/a</Key><Size>4</Size></Contents><Contents><Key>prefix/b</Key><Size>4</Size></Contents><Contents><Key>prefix/c</Key><Size>4</Size></Contents>
+</ListBucketResult>`)
+			return
+		}
+		operated = append(operated, r.Method+" "+r.URL.String())
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	outputDir := "ossutil-test-output-" + randLowStr(5)
+	cpDir := "ossutil-test-cp-" + randLowStr(5)
+	downloadDir := "ossutil-test-cap-" + randLowStr(5)
+	threshold := strconv.FormatInt(DefaultBigFileThreshold, 10)
+	routines := strconv.Itoa(Routines)
+	partSize := strconv.FormatInt(DefaultPartSize, 10)
+	defer os.RemoveAll(outputDir)
+	defer os.RemoveAll(cpDir)
+	defer os.RemoveAll(downloadDir)
+	options := OptionMapType{
+		OptionEndpoint:         &endpoint,
+		OptionAccessKeyID:      &str,
+		OptionAccessKeySecret:  &str,
+		OptionForcePathStyle:   &forcePathStyle,
+		OptionRecursion:        &recursive,
+		OptionForce:            &force,
+		OptionMaxObjects:       &maxObjects,
+		OptionOutputDir:        &outputDir,
+		OptionCheckpointDir:    &cpDir,
+		OptionBigFileThreshold: &threshold,
+		OptionRoutines:         &routines,
+		OptionPartSize:         &partSize,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/prefix/", downloadDir}, options)
+	c.Assert(err, FitsTypeOf, SafetyCapError{})
+	objectOptions := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRecursion:       &recursive,
+		OptionForce:           &force,
+		OptionMaxObjects:      &maxObjects,
+		OptionRoutines:        &routines,
+	}
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/prefix/"}, objectOptions)
+	c.Assert(err, FitsTypeOf, SafetyCapError{})
+	_, err = cm.RunCommand("set-acl", []string{"oss://bucket/prefix/", "private"}, objectOptions)
+	c.Assert(err, FitsTypeOf, SafetyCapError{})
+	c.Assert(operated, IsNil)
+
+	// the caps only work with --recursive
+	recursive = false
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/prefix/a", downloadDir}, options)
+	c.Assert(err, NotNil)
+	c.Assert(operated, IsNil)
+
+	// the objects are operated under the caps
+	recursive = true
+	maxObjects = "3"
+	_, err = cm.RunCommand("cp", []string{"oss://bucket/prefix/", downloadDir}, options)
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, IsNil)
+	c.Assert(len(operated), Equals, 3)
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -107,10 +108,12 @@ ACL：
     的objects，设置它们的acl，当一个object操作出现错误时，会将出错object的错误信息记录到report文件，
     并继续操作其他object，成功操作的object信息将不会被记录到report文件中（更多信息见cp命令的帮助）。
     此时不支持--bucket选项，即ossutil不支持同时设置bucket和其中objects的acl，如有需要，请分开操作。
-    如果--force选项被指定，则不会进行询问提示。如果用户在命令行中缺失acl信息，会进入交互模式，询问
-    用户的acl信息。
+    如果--force或者--assume-yes(-y)选项被指定，则不会进行询问提示，否则询问提示中会显示objects的数量和
+    字节数。如果用户在命令行中缺失acl信息，会进入交互模式，询问用户的acl信息。
         如果指定了--include/--exclude选项，ossutil会查找所有匹配pattern的objects，批量设置。
         --include和--exclude选项说明，请参考cp命令帮助。
+        如果指定了--where-tag选项，ossutil获取每个object的标签，只设置标签匹配的objects的acl，
+    如：--where-tag env=staging，多个条件用#分隔，只指定key表示存在该标签即可。
 `,
 
 	sampleText: ` 
@@ -121,6 +124,7 @@ ACL：
     (3)ossutil set-acl oss://bucket1/obj default -r
        ossutil set-acl oss://bucket1/obj default -r --include "*.jpg"
        ossutil set-acl oss://bucket1/obj default -r --exclude "*.jpg"
+       ossutil set-acl oss://bucket1/obj private -r --where-tag env=staging
 
     (4)ossutil set-acl oss://bucket1/%e4%b8%ad%e6%96%87 default --encoding-type url
 
@@ -188,12 +192,16 @@ Usage：
     If an error occurs, ossutil will record the error message to report file, and ossutil 
     will continue to attempt to set acl on the remaining objects(more information see 
     help of cp command). In the usage, --bucket option is not supported, which means set 
-    acl on bucket an objects inside simultaneously is not supported. If --force option 
-    is specified, ossutil will not show prompt question. If acl information is missed, 
+    acl on bucket an objects inside simultaneously is not supported. If --force or 
+    --assume-yes(-y) option is specified, ossutil will not show prompt question, otherwise the
+    prompt shows the number and the bytes of the objects. If acl information is missed, 
     ossutil will enter interactive mode and ask you for it. 
         If --include/--exclude option is specified, ossutil will search for pattern-matching 
     objects and set meta on those objects.
         --include and --exclude option, please refer cp command help.
+        If --where-tag option is specified, ossutil gets the tags of every object, and only sets
+    acl on the objects whose tags match, e.g., --where-tag env=staging, multiple conditions are
+    separated by #, only the key means the object should have the tag.
 `,
 
 	sampleText: ` 
@@ -204,6 +212,7 @@ Usage：
     (3)ossutil set-acl oss://bucket1/obj default -r
        ossutil set-acl oss://bucket1/obj default -r --include "*.jpg"
        ossutil set-acl oss://bucket1/obj default -r --exclude "*.jpg"
+       ossutil set-acl oss://bucket1/obj private -r --where-tag env=staging
 
     (4)ossutil set-acl oss://bucket1/%e4%b8%ad%e6%96%87 default --encoding-type url
 
@@ -221,13 +230,11 @@ type SetACLCommand struct {
 
 var setACLCommand = SetACLCommand{
 	command: Command{
-		name:        "set-acl",
-		nameAlias:   []string{"setacl", "set_acl"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseSetACL,
-		specEnglish: specEnglishSetACL,
-		group:       GroupTypeNormalCommand,
+		name:      "set-acl",
+		nameAlias: []string{"setacl", "set_acl"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionRecursion,
 			OptionBucket,
@@ -236,6 +243,7 @@ var setACLCommand = SetACLCommand{
 			OptionConfigFile,
 			OptionInclude,
 			OptionExclude,
+			OptionWhereTag,
 			OptionEndpoint,
 			OptionAccessKeyID,
 			OptionAccessKeySecret,
@@ -256,6 +264,11 @@ var setACLCommand = SetACLCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -263,6 +276,9 @@ var setACLCommand = SetACLCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionMaxObjects,
+			OptionMaxBytes,
+			OptionAssumeYes,
 		},
 	},
 }
@@ -277,8 +293,8 @@ func (sc *SetACLCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (sc *SetACLCommand) Init(args []string, options OptionMapType) error {
-	return sc.command.Init(args, options, sc)
+func (sc *SetACLCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return sc.command.Init(ctx, args, options, sc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -306,6 +322,20 @@ func (sc *SetACLCommand) RunCommand() error {
 		return fmt.Errorf("--version-id only work on single object")
 	}
 
+	var err error
+	if sc.command.safetyCap, err = sc.command.newSafetyCap(); err != nil {
+		return err
+	}
+	if sc.command.safetyCap != nil && (!recursive || toBucket) {
+		return fmt.Errorf("--max-objects and --max-bytes only work with --recursive on objects")
+	}
+	if sc.command.tagSelector, err = sc.command.newTagSelector(); err != nil {
+		return err
+	}
+	if sc.command.tagSelector != nil && (!recursive || toBucket) {
+		return fmt.Errorf("--where-tag only work with --recursive on objects")
+	}
+
 	cloudURL, err := CloudURLFromString(sc.command.args[0], encodingType)
 	if err != nil {
 		return err
@@ -315,7 +345,7 @@ func (sc *SetACLCommand) RunCommand() error {
 		return fmt.Errorf("invalid cloud url: %s, miss bucket", sc.command.args[0])
 	}
 
-	bucket, err := sc.command.ossBucket(cloudURL.bucket)
+	bucket, err := sc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
@@ -435,11 +465,13 @@ func (sc *SetACLCommand) ossSetObjectACLRetry(bucket *oss.Bucket, object string,
 }
 
 func (sc *SetACLCommand) batchSetObjectACL(bucket *oss.Bucket, cloudURL CloudURL, force bool, routines int64) error {
-	if !force {
-		var val string
-		fmt.Printf("Do you really mean to recursivlly set acl on objects of %s(y or N)? ", sc.command.args[0])
-		if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-			fmt.Println("operation is canceled.")
+	if err := sc.command.preCountObjects(bucket, cloudURL, sc.filters); err != nil {
+		return err
+	}
+
+	if !sc.command.assumeYes() {
+		summary := sc.command.objectsSummary(bucket, cloudURL, sc.filters)
+		if !sc.command.confirmOperation(fmt.Sprintf("recursivlly set acl on objects of %s(%s)", sc.command.args[0], summary)) {
 			return nil
 		}
 	}
@@ -494,7 +526,7 @@ func (sc *SetACLCommand) setObjectACLConsumer(bucket *oss.Bucket, acl oss.ACLTyp
 func (sc *SetACLCommand) setObjectACLWithReport(bucket *oss.Bucket, object string, acl oss.ACLType) error {
 	err := sc.ossSetObjectACLRetry(bucket, object, acl, "")
 	sc.command.updateMonitor(err, &sc.monitor)
-	msg := fmt.Sprintf("set acl on %s", CloudURLToString(bucket.BucketName, object))
+	msg := fmt.Sprintf("set acl on %s", bucketObjectURL(bucket, object))
 	sc.command.report(msg, err, &sc.saOption)
 	return err
 }
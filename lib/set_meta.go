@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -89,7 +90,7 @@ var specChineseSetMeta = SpecText{
 	paramText: "cloud_url [meta] [options]",
 
 	syntaxText: ` 
-    ossutil set-meta oss://bucket[/prefix] [header:value#header:value...] [--update] [--delete] [-r] [-f] [-c file] [--version-id versionId] [--object-file file] [--snapshot-path dir] [--disable-ignore-error]
+    ossutil set-meta oss://bucket[/prefix] [header:value#header:value...] [--update] [--delete] [-r] [-f] [-c file] [--version-id versionId] [--object-file file] [--snapshot-path dir] [--disable-ignore-error] [--if-match etag] [--if-none-match etag] [--if-unmodified-since time]
 `,
 
 	detailHelpText: ` 
@@ -110,6 +111,16 @@ var specChineseSetMeta = SpecText{
         删除的headers，即：不以` + oss.HTTPHeaderOssMetaPrefix + `开头的headers，该选项不起作用），该此时value必须
         为空（header:或者header），指定objects的其他meta信息不会改变。此时不支持--update选项。
 
+    （4）条件设置：如果指定了--if-match、--if-none-match或者--if-unmodified-since选项，ossutil
+        只在object满足条件时设置meta，否则报错PreconditionFailed，可用于避免覆盖其他人刚修改
+        过的object。--if-match和--if-none-match的值为object的etag，--if-unmodified-since的
+        值可以为http日期（如：Mon, 02 Jan 2006 15:04:05 GMT）、RFC3339时间或者unix时间戳。
+
+    （5）失败重试：指定--output-failed failed.csv时，ossutil将每个设置失败的object及错误信息以csv
+        格式（key,error）记录到该文件中。之后使用相同的命令并指定--retry-from failed.csv，ossutil
+        只设置文件中记录的prefix下的objects，不再列举所有objects。--retry-from需要和--recursive
+        一起使用。
+
     该命令不支持bucket的meta设置，需要设置bucket的meta信息，请使用bucket相关操作。
     查看bucket或者object的meta信息，请使用stat命令。
 
@@ -183,6 +194,9 @@ Headers:
 
     (10)ossutil set-meta oss://bucket1 X-Oss-Meta-empty:#Content-Type:plain/text --update --object-file file --snapshot-path dir
         批量更新file文件中所有objects的X-Oss-Meta-empty和Content-Type头域，并开启快照
+
+    (11)ossutil set-meta oss://bucket1/obj1 X-Oss-Meta-a:b --update --if-match 5B3C1A2E053D763E1B002CC607C5A0FE
+        当obj1的etag为5B3C1A2E053D763E1B002CC607C5A0FE时，更新obj1的X-Oss-Meta-a头域
 `,
 }
 
@@ -193,7 +207,7 @@ var specEnglishSetMeta = SpecText{
 	paramText: "cloud_url [meta] [options]",
 
 	syntaxText: ` 
-    ossutil set-meta oss://bucket[/prefix] [header:value#header:value...] [--update] [--delete] [-r] [-f] [-c file] [--version-id versionId] [--object-file file] [--snapshot-path dir] [--disable-ignore-error]
+    ossutil set-meta oss://bucket[/prefix] [header:value#header:value...] [--update] [--delete] [-r] [-f] [-c file] [--version-id versionId] [--object-file file] [--snapshot-path dir] [--disable-ignore-error] [--if-match etag] [--if-none-match etag] [--if-unmodified-since time]
 `,
 
 	detailHelpText: ` 
@@ -221,6 +235,19 @@ var specEnglishSetMeta = SpecText{
         of the specified objects will not be changed. --update option is not supported 
         in the usage.
 
+    (4) Conditional set: If --if-match, --if-none-match or --if-unmodified-since option is 
+        specified, ossutil sets meta only when the object meets the conditions, otherwise 
+        PreconditionFailed error happens, it can be used to avoid overwriting the object 
+        changed by others. The value of --if-match and --if-none-match is the etag of object, 
+        the value of --if-unmodified-since can be http date(like: Mon, 02 Jan 2006 15:04:05 GMT), 
+        RFC3339 time or unix timestamp.
+
+    (5) Retry failures: If --output-failed failed.csv is specified, ossutil records every object 
+        failed to set meta with its error to the file in csv format(key,error). Then run the same
+        command with --retry-from failed.csv, ossutil only sets meta on the objects under the 
+        prefix recorded in the file, instead of listing all the objects. --retry-from needs 
+        --recursive.
+
     The meta data of bucket can not be setted by the command, please use other commands. 
     User can use stat command to check the meta information of bucket or objects.
 
@@ -304,6 +331,9 @@ Usage:
 
     (10)ossutil set-meta oss://bucket1 X-Oss-Meta-empty:#Content-Type:plain/text --update --object-file file --snapshot-path dir
         Batch update X-Oss-Meta-empty and Content-Type header on objects that in file, and open snapshot
+
+    (11)ossutil set-meta oss://bucket1/obj1 X-Oss-Meta-a:b --update --if-match 5B3C1A2E053D763E1B002CC607C5A0FE
+        Update X-Oss-Meta-a header of obj1 only when the etag of obj1 is 5B3C1A2E053D763E1B002CC607C5A0FE
 `,
 }
 
@@ -316,17 +346,18 @@ type SetMetaCommand struct {
 	skipCount   uint64
 	hasObjFile  bool
 	objFilePath string
+	condition   objectConditionType
+	failed      *failedManifest
+	retryKeys   []string // nil means not --retry-from
 }
 
 var setMetaCommand = SetMetaCommand{
 	command: Command{
-		name:        "set-meta",
-		nameAlias:   []string{"setmeta", "set_meta"},
-		minArgc:     1,
-		maxArgc:     2,
-		specChinese: specChineseSetMeta,
-		specEnglish: specEnglishSetMeta,
-		group:       GroupTypeNormalCommand,
+		name:      "set-meta",
+		nameAlias: []string{"setmeta", "set_meta"},
+		minArgc:   1,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionRecursion,
 			OptionUpdate,
@@ -357,6 +388,11 @@ var setMetaCommand = SetMetaCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -367,6 +403,11 @@ var setMetaCommand = SetMetaCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionIfMatch,
+			OptionIfNoneMatch,
+			OptionIfUnmodifiedSince,
+			OptionOutputFailed,
+			OptionRetryFrom,
 		},
 	},
 }
@@ -381,8 +422,8 @@ func (sc *SetMetaCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (sc *SetMetaCommand) Init(args []string, options OptionMapType) error {
-	return sc.command.Init(args, options, sc)
+func (sc *SetMetaCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return sc.command.Init(ctx, args, options, sc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -414,10 +455,20 @@ func (sc *SetMetaCommand) RunCommand() error {
 	if err != nil {
 		return err
 	}
+	// the keys are read before --output-failed is created, which may be the same file
+	if sc.retryKeys, err = sc.command.readRetryKeys(); err != nil {
+		return err
+	}
+	if sc.retryKeys != nil && (!recursive || objFileXml != "") {
+		return fmt.Errorf("--retry-from only work with --recursive and without --object-file")
+	}
 	if err := sc.checkOptions(cloudURL, isUpdate, isDelete, force, recursive, language, versionId, objFileXml); err != nil {
 		return err
 	}
-	bucket, err := sc.command.ossBucket(cloudURL.bucket)
+	if sc.condition, err = sc.command.getObjectCondition(); err != nil {
+		return err
+	}
+	bucket, err := sc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
@@ -432,6 +483,10 @@ func (sc *SetMetaCommand) RunCommand() error {
 	}
 
 	sc.smOption.ctnu = true
+	if sc.failed, err = sc.command.newFailedManifest(); err != nil {
+		return err
+	}
+	defer sc.failed.close()
 
 	// check --object-file mode
 	if objFileXml != "" {
@@ -444,6 +499,7 @@ func (sc *SetMetaCommand) RunCommand() error {
 	} else {
 		if !recursive {
 			err = sc.setObjectMeta(bucket, cloudURL.object, headers, isUpdate, isDelete, false, versionId)
+			sc.failed.record(cloudURL.object, err)
 		} else {
 			err = sc.batchSetObjectMeta(bucket, cloudURL, headers, isUpdate, isDelete, force, routines)
 		}
@@ -621,6 +677,7 @@ func (sc *SetMetaCommand) setObjectMeta(bucket *oss.Bucket, object string, heade
 	if len(versionId) > 0 {
 		options = append(options, oss.VersionId(versionId))
 	}
+	options = append(options, sc.condition.copySourceOptions()...)
 
 	err = sc.ossSetObjectMetaRetry(bucket, object, options...)
 	if batchOperate && sc.smOption.snapshotPath != "" {
@@ -686,7 +743,10 @@ func (sc *SetMetaCommand) ossSetObjectMetaRetry(bucket *oss.Bucket, object strin
 		if err == nil {
 			return nil
 		}
-		if int64(i) >= retryTimes {
+		if isNotModified(err) {
+			return PreconditionError{bucket.BucketName, object, fmt.Sprintf("etag matches %s", sc.condition.ifNoneMatch)}
+		}
+		if int64(i) >= retryTimes || isPreconditionFailed(err) {
 			return ObjectError{err, bucket.BucketName, object}
 		}
 	}
@@ -711,8 +771,12 @@ func (sc *SetMetaCommand) setObjectMetas(bucket *oss.Bucket, cloudURL CloudURL,
 	chObjects := make(chan string, ChannelBuf)
 	chError := make(chan error, routines+1)
 	chListError := make(chan error, 1)
-	go sc.command.objectStatistic(bucket, cloudURL, &sc.monitor, sc.filters)
-	go sc.command.objectProducer(bucket, cloudURL, chObjects, chListError, sc.filters)
+	if sc.retryKeys != nil {
+		go sc.retryObjectProducer(cloudURL, chObjects, chListError)
+	} else {
+		go sc.command.objectStatistic(bucket, cloudURL, &sc.monitor, sc.filters)
+		go sc.command.objectProducer(bucket, cloudURL, chObjects, chListError, sc.filters)
+	}
 
 	for i := 0; int64(i) < routines; i++ {
 		go sc.setObjectMetaConsumer(bucket, headers, isUpdate, isDelete, chObjects, chError)
@@ -739,11 +803,29 @@ func (sc *SetMetaCommand) setObjectMetaConsumer(bucket *oss.Bucket, headers map[
 func (sc *SetMetaCommand) setObjectMetaWithReport(bucket *oss.Bucket, object string, headers map[string]string, isUpdate, isDelete bool) error {
 	err := sc.setObjectMeta(bucket, object, headers, isUpdate, isDelete, true, "")
 	sc.command.updateMonitor(err, &sc.monitor)
-	msg := fmt.Sprintf("set meta on %s", CloudURLToString(bucket.BucketName, object))
+	msg := fmt.Sprintf("set meta on %s", bucketObjectURL(bucket, object))
 	sc.command.report(msg, err, &sc.smOption)
+	sc.failed.record(object, err)
 	return err
 }
 
+// retryObjectProducer sends the keys of --retry-from under the prefix of cloud url instead of listing the objects
+func (sc *SetMetaCommand) retryObjectProducer(cloudURL CloudURL, chObjects chan<- string, chError chan<- error) {
+	defer close(chObjects)
+	keys := []string{}
+	for _, key := range sc.retryKeys {
+		if strings.HasPrefix(key, cloudURL.object) && doesSingleObjectMatchPatterns(key, sc.filters) {
+			keys = append(keys, key)
+		}
+	}
+	sc.monitor.updateScanNum(int64(len(keys)))
+	sc.monitor.setScanEnd()
+	for _, key := range keys {
+		chObjects <- key
+	}
+	chError <- nil
+}
+
 func (sc *SetMetaCommand) waitRoutinueComplete(chError, chListError <-chan error, routines int64) error {
 	completed := 0
 	var ferr error
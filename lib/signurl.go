@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -14,17 +15,20 @@ var specChineseSignurl = SpecText{
 	paramText: "cloud_url [meta] [options]",
 
 	syntaxText: ` 
-    ossutil sign cloud_url [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value]
+    ossutil sign cloud_url [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value] [--process process] [--response-content-disposition value] [--response-content-type value]
 `,
 
 	detailHelpText: ` 
     该命令签名用户指定的cloud_url，生成经过签名的url可供第三方用户访问object，其中cloud_url
     必须为形如：oss://bucket/object的cloud_url，bucket和object不可缺少。通过--timeout选项指
     定url的过期时间，默认为60s。通过--version-id选项指定版本号。
+    通过--process选项指定x-oss-process参数，访问url时oss对object进行图片处理等数据处理。
+    通过--response-content-disposition和--response-content-type选项指定访问url时返回的
+    Content-Disposition和Content-Type头，如强制浏览器下载并指定保存的文件名。
 
 用法：
 
-    ossutil sign oss://bucket/object [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value]
+    ossutil sign oss://bucket/object [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value] [--process process] [--response-content-disposition value] [--response-content-type value]
 `,
 
 	sampleText: ` 
@@ -51,6 +55,12 @@ var specChineseSignurl = SpecText{
 
     ossutil sign oss://bucket1/object1.jpg  --query-param x-oss-process:image/resize,m_fixed,w_100,h_100/rotate,90
         生成处理过的图片 oss://bucket1/dir/object1.jpg的签名url 
+
+    ossutil sign oss://bucket1/object1.jpg --process "image/resize,w_300"
+        生成缩放为300像素宽的图片oss://bucket1/object1.jpg的签名url
+
+    ossutil sign oss://bucket1/report --response-content-disposition "attachment; filename=report.pdf" --response-content-type application/pdf
+        生成oss://bucket1/report的签名url，浏览器以report.pdf的文件名下载
 `,
 }
 
@@ -61,7 +71,7 @@ var specEnglishSignurl = SpecText{
 	paramText: "cloud_url [options]",
 
 	syntaxText: ` 
-    ossutil sign cloud_url [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value]
+    ossutil sign cloud_url [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value] [--process process] [--response-content-disposition value] [--response-content-type value]
 `,
 
 	detailHelpText: ` 
@@ -74,10 +84,15 @@ var specEnglishSignurl = SpecText{
     use --disable-encode-slash to specify not encoding of '/' in url path section
     use --payer to specify request payment
     use --query-param to specify the query parameters, can be passed multiple times.
+    use --process to specify the x-oss-process parameter, oss processes the object, e.g., 
+    image processing, when the url is accessed.
+    use --response-content-disposition and --response-content-type to specify the 
+    Content-Disposition and Content-Type headers returned when the url is accessed, e.g., 
+    force the browser to download with the file name.
 
 Usage:
 
-    ossutil sign oss://bucket/object [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value]
+    ossutil sign oss://bucket/object [--timeout t] [--version-id versionId] [--trafic-limit limitSpeed] [--disable-encode-slash] [--payer requester] [--query-param key:value] [--process process] [--response-content-disposition value] [--response-content-type value]
 `,
 
 	sampleText: ` 
@@ -104,6 +119,12 @@ Usage:
 
     ossutil sign oss://bucket1/object1.jpg  --query-param x-oss-process:image/resize,m_fixed,w_100,h_100/rotate,90
 		Generate the signature of processed picture oss://bucket1/dir/object1.jpg
+
+    ossutil sign oss://bucket1/object1.jpg --process "image/resize,w_300"
+        Generate the signature of oss://bucket1/object1.jpg resized to 300 pixels wide
+
+    ossutil sign oss://bucket1/report --response-content-disposition "attachment; filename=report.pdf" --response-content-type application/pdf
+        Generate the signature of oss://bucket1/report, the browser downloads it as report.pdf
 `,
 }
 
@@ -115,13 +136,11 @@ type SignurlCommand struct {
 
 var signURLCommand = SignurlCommand{
 	command: Command{
-		name:        "sign",
-		nameAlias:   []string{},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseSignurl,
-		specEnglish: specEnglishSignurl,
-		group:       GroupTypeNormalCommand,
+		name:      "sign",
+		nameAlias: []string{},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionTimeout,
 			OptionEncodingType,
@@ -145,6 +164,9 @@ var signURLCommand = SignurlCommand{
 			OptionSTSRegion,
 			OptionUserAgent,
 			OptionQueryParam,
+			OptionProcess,
+			OptionContentDisposition,
+			OptionContentTypeOverride,
 			OptionSignVersion,
 			OptionRegion,
 			OptionCloudBoxID,
@@ -163,8 +185,8 @@ func (sc *SignurlCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (sc *SignurlCommand) Init(args []string, options OptionMapType) error {
-	return sc.command.Init(args, options, sc)
+func (sc *SignurlCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return sc.command.Init(ctx, args, options, sc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -187,8 +209,11 @@ func (sc *SignurlCommand) RunCommand() error {
 		return fmt.Errorf("invalid request payer: %s, please check", payer)
 	}
 	query, _ := GetStrings(OptionQueryParam, sc.command.options)
+	process, _ := GetString(OptionProcess, sc.command.options)
+	contentDisposition, _ := GetString(OptionContentDisposition, sc.command.options)
+	contentType, _ := GetString(OptionContentTypeOverride, sc.command.options)
 
-	bucket, err := sc.command.ossBucket(cloudURL.bucket)
+	bucket, err := sc.command.cloudBucket(cloudURL)
 	if err != nil {
 		return err
 	}
@@ -206,6 +231,18 @@ func (sc *SignurlCommand) RunCommand() error {
 		options = append(options, oss.RequestPayerParam(oss.PayerType(payer)))
 	}
 
+	if process != "" {
+		options = append(options, oss.Process(process))
+	}
+
+	if contentDisposition != "" {
+		options = append(options, oss.ResponseContentDisposition(contentDisposition))
+	}
+
+	if contentType != "" {
+		options = append(options, oss.ResponseContentType(contentType))
+	}
+
 	if len(query) > 0 {
 		options, err = AddStringsToOption(query, options)
 		if err != nil {
@@ -1080,3 +1080,40 @@ func (s *OssutilCommandSuite) TestSignUrlWithSignV4(c *C) {
 	c.Assert(str != "", Equals, true)
 	os.Remove(downFileName)
 }
+
+func (s *OssutilCommandSuite) TestSignUrlWithProcessAndResponseHeader(c *C) {
+	endpoint := "oss-cn-hangzhou.aliyuncs.com"
+	str := "ak"
+	timeout := int64(60)
+	process := "image/resize,w_300"
+	contentDisposition := "attachment; filename=report.pdf"
+	contentType := "application/pdf"
+	sc := &SignurlCommand{}
+	sc.command.args = []string{"oss://bucket/report"}
+	sc.command.options = OptionMapType{
+		OptionEndpoint:            &endpoint,
+		OptionAccessKeyID:         &str,
+		OptionAccessKeySecret:     &str,
+		OptionTimeout:             &timeout,
+		OptionProcess:             &process,
+		OptionContentDisposition:  &contentDisposition,
+		OptionContentTypeOverride: &contentType,
+	}
+
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	err = sc.RunCommand()
+	testResultFile.Close()
+	os.Stdout = oldStdout
+	c.Assert(err, IsNil)
+
+	u, err := url.Parse(sc.signUrl)
+	c.Assert(err, IsNil)
+	query := u.Query()
+	c.Assert(query.Get("x-oss-process"), Equals, process)
+	c.Assert(query.Get("response-content-disposition"), Equals, contentDisposition)
+	c.Assert(query.Get("response-content-type"), Equals, contentType)
+	c.Assert(query.Get("Signature") != "", Equals, true)
+}
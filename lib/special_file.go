@@ -0,0 +1,165 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// DefaultStreamPartSize is the part size of uploading the named pipe or the device if --part-size is not specified,
+// the size of the data is unknown before it's read, so the max object size is the part size * MaxPartNum
+const DefaultStreamPartSize int64 = 16 * 1024 * 1024
+
+// isSpecialFileMode returns true for the named pipe, the device and the socket, which can't be seeked,
+// and the size is unknown
+func isSpecialFileMode(mode os.FileMode) bool {
+	return mode&(os.ModeNamedPipe|os.ModeCharDevice|os.ModeDevice|os.ModeSocket) != 0
+}
+
+func isSpecialFile(filePath string) bool {
+	f, err := os.Stat(filePath)
+	return err == nil && isSpecialFileMode(f.Mode())
+}
+
+// isStdoutFile returns true if the file is the stdout of ossutil, e.g., /dev/stdout
+func isStdoutFile(filePath string) bool {
+	f, err := os.Stat(filePath)
+	if err != nil || !isSpecialFileMode(f.Mode()) {
+		return false
+	}
+	stdout, err := os.Stdout.Stat()
+	return err == nil && os.SameFile(f, stdout)
+}
+
+func (cc *CopyCommand) streamPartSize() int64 {
+	partSize, _ := GetInt(OptionPartSize, cc.command.options)
+	if partSize < oss.MinPartSize {
+		return DefaultStreamPartSize
+	}
+	return partSize
+}
+
+// uploadSpecialFile reads the named pipe or the device like /dev/stdin once, and uploads the data by
+// streaming multipart upload, the data less than one part is uploaded by PutObject.
+// The data can't be read again, so only the parts in memory are retried
+func (cc *CopyCommand) uploadSpecialFile(bucket *oss.Bucket, objectName, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buf := make([]byte, cc.streamPartSize())
+	n, err := io.ReadFull(f, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	options := cc.contentTypeOptions(filePath, func() (string, error) {
+		return http.DetectContentType(buf[:n]), nil
+	})
+	options = append(options, cc.cpOption.options...)
+	LogInfo("stream upload %s to %s, part size:%d\n", filePath, objectName, len(buf))
+
+	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
+	if err != nil {
+		var listener *OssProgressListener = &OssProgressListener{&cc.monitor, 0, 0, false}
+		options = append(options, oss.Progress(listener), oss.ContentLength(int64(n)))
+		return cc.retryStreamRequest(bucket.BucketName, objectName, retryTimes, func() error {
+			return bucket.PutObject(objectName, bytes.NewReader(buf[:n]), options...)
+		})
+	}
+
+	imur, err := bucket.InitiateMultipartUpload(objectName, options...)
+	if err != nil {
+		return ObjectError{err, bucket.BucketName, objectName}
+	}
+	parts := []oss.UploadPart{}
+	for partNumber := 1; ; partNumber++ {
+		if partNumber > MaxPartNum {
+			err = fmt.Errorf("the data of %s exceeds %d parts, please specify larger --part-size", filePath, MaxPartNum)
+			break
+		}
+		var part oss.UploadPart
+		data := buf[:n]
+		err = cc.retryStreamRequest(bucket.BucketName, objectName, retryTimes, func() error {
+			var perr error
+			part, perr = bucket.UploadPart(imur, bytes.NewReader(data), int64(len(data)), partNumber, cc.cpOption.payerOptions...)
+			return perr
+		})
+		if err != nil {
+			break
+		}
+		parts = append(parts, part)
+		cc.monitor.updateTransferSize(int64(n))
+		cc.monitor.updateDealSize(int64(n))
+		freshProgress()
+
+		if n, err = io.ReadFull(f, buf); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil && err != io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	if err != nil {
+		bucket.AbortMultipartUpload(imur, cc.cpOption.payerOptions...)
+		return err
+	}
+	completeOptions := append([]oss.Option{}, cc.cpOption.payerOptions...)
+	if cc.cpOption.forbidOverwrite {
+		completeOptions = append(completeOptions, oss.ForbidOverWrite(true))
+	}
+	if _, err := bucket.CompleteMultipartUpload(imur, parts, completeOptions...); err != nil {
+		return ObjectError{err, bucket.BucketName, objectName}
+	}
+	return nil
+}
+
+// downloadSpecialFile writes the object to the named pipe or the device like /dev/stdout, which can't be
+// written by a temp file and renamed. The request is retried only before any data is written
+func (cc *CopyCommand) downloadSpecialFile(bucket *oss.Bucket, objectName, fileName string, options ...oss.Option) error {
+	f, err := os.OpenFile(fileName, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	retryTimes, _ := GetInt(OptionRetryTimes, cc.command.options)
+	var body io.ReadCloser
+	err = cc.retryStreamRequest(bucket.BucketName, objectName, retryTimes, func() error {
+		var gerr error
+		body, gerr = bucket.GetObject(objectName, options...)
+		return gerr
+	})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if _, err = io.Copy(f, body); err != nil {
+		return ObjectError{err, bucket.BucketName, objectName}
+	}
+	return nil
+}
+
+func (cc *CopyCommand) retryStreamRequest(bucketName, objectName string, retryTimes int64, request func() error) error {
+	for i := 1; ; i++ {
+		err := request()
+		if err == nil {
+			return nil
+		}
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) || isPreconditionFailed(err) || !cc.cpOption.budget.allowRetry() {
+			return ObjectError{err, bucketName, objectName}
+		}
+		LogError("stream request of %s error, retry %d, error:%s\n", objectName, i, err.Error())
+		cc.cpOption.statSummary.addRetry(objectName)
+		time.Sleep(time.Duration(3) * time.Second)
+	}
+}
@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestSpecialFileMode(c *C) {
+	c.Assert(isSpecialFileMode(os.ModeNamedPipe), Equals, true)
+	c.Assert(isSpecialFileMode(os.ModeDevice|os.ModeCharDevice), Equals, true)
+	c.Assert(isSpecialFileMode(os.ModeSocket), Equals, true)
+	c.Assert(isSpecialFileMode(0644), Equals, false)
+	c.Assert(isSpecialFileMode(os.ModeDir), Equals, false)
+	c.Assert(isSpecialFileMode(os.ModeSymlink), Equals, false)
+
+	c.Assert(isSpecialFile(uploadFileName), Equals, false)
+	c.Assert(isStdoutFile(uploadFileName), Equals, false)
+	c.Assert(isSpecialFile("ossutil-test-not-exist-"+randLowStr(5)), Equals, false)
+	if runtime.GOOS != "windows" {
+		c.Assert(isSpecialFile(os.DevNull), Equals, true)
+	}
+
+	cc := &CopyCommand{}
+	partSize := "1024"
+	cc.command.options = OptionMapType{OptionPartSize: &partSize}
+	c.Assert(cc.streamPartSize(), Equals, DefaultStreamPartSize)
+	partSize = "1048576"
+	c.Assert(cc.streamPartSize(), Equals, int64(1048576))
+}
+
+func (s *OssutilCommandSuite) TestSizeUnknownProgress(c *C) {
+	var m CPMonitor
+	m.init(operationTypePut)
+	m.updateScanSizeNum(0, 1)
+	m.setSizeUnknown()
+	m.setScanEnd()
+	m.updateFile(2048, 1)
+	bar := m.progressBar(true, normalExit)
+	c.Assert(strings.Contains(bar, "size: 2,048"), Equals, true)
+
+	m.init(operationTypePut)
+	c.Assert(m.sizeUnknown, Equals, false)
+}
+
+func (s *OssutilCommandSuite) TestUploadDownloadNamedPipe(c *C) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	content := strings.Repeat("ossutil named pipe\n", 1024)
+	pipeName := "ossutil-test-pipe-" + randLowStr(5)
+	c.Assert(exec.Command("mkfifo", pipeName).Run(), IsNil)
+	go func() {
+		f, err := os.OpenFile(pipeName, os.O_WRONLY, 0)
+		if err == nil {
+			f.WriteString(content)
+			f.Close()
+		}
+	}()
+
+	str := ""
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+	}
+	object := "named-pipe"
+	_, err := cm.RunCommand("cp", []string{pipeName, CloudURLToString(bucketName, object)}, options)
+	c.Assert(err, IsNil)
+	c.Assert(s.getStat(bucketName, object, c)["Content-Length"], Equals, "19456")
+
+	// download to the named pipe
+	chContent := make(chan string, 1)
+	go func() {
+		data, _ := ioutil.ReadFile(pipeName)
+		chContent <- string(data)
+	}()
+	_, err = cm.RunCommand("cp", []string{CloudURLToString(bucketName, object), pipeName}, options)
+	c.Assert(err, IsNil)
+	c.Assert(<-chContent, Equals, content)
+
+	os.Remove(pipeName)
+	s.removeBucket(bucketName, true, c)
+}
@@ -0,0 +1,57 @@
+package lib
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// stagingFileName returns the file in --staging-dir to download to, the name is stable for the same
+// destination file so that the checkpoint of the resumable download still works in the next run
+func (cc *CopyCommand) stagingFileName(fileName string) string {
+	if cc.cpOption.stagingDir == "" {
+		return fileName
+	}
+	absName, err := filepath.Abs(fileName)
+	if err != nil {
+		absName = fileName
+	}
+	sum := md5.Sum([]byte(absName))
+	return filepath.Join(cc.cpOption.stagingDir, hex.EncodeToString(sum[:])+"-"+filepath.Base(fileName))
+}
+
+// checkStagingDir makes sure the file in --staging-dir can be renamed to the destination,
+// the rename is atomic only if they are on the same filesystem
+func (cc *CopyCommand) checkStagingDir(filePath string) error {
+	if cc.cpOption.stagingDir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(cc.cpOption.stagingDir, 0755); err != nil {
+		return err
+	}
+
+	probe, err := ioutil.TempFile(cc.cpOption.stagingDir, ".ossutil-staging-")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	defer os.Remove(probe.Name())
+
+	target := filepath.Join(existingParentDir(filePath), filepath.Base(probe.Name()))
+	if err := os.Rename(probe.Name(), target); err != nil {
+		return fmt.Errorf("--staging-dir %s can't be renamed to the destination, it should be on the same filesystem as the destination, %s", cc.cpOption.stagingDir, err.Error())
+	}
+	return os.Remove(target)
+}
+
+// commitStagingFile moves the downloaded file in --staging-dir to the destination
+func (cc *CopyCommand) commitStagingFile(stagingName, fileName string) error {
+	if stagingName == fileName {
+		return nil
+	}
+	LogInfo("rename staging file %s to %s\n", stagingName, fileName)
+	return os.Rename(stagingName, fileName)
+}
@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestStagingFile(c *C) {
+	stagingDir, _ := filepath.Abs("ossutil-test-staging-" + randLowStr(5))
+	destDir := "ossutil-test-staging-dest-" + randLowStr(5)
+	c.Assert(os.MkdirAll(destDir, 0755), IsNil)
+	fileName := filepath.Join(destDir, "a.txt")
+
+	cc := &CopyCommand{}
+	c.Assert(cc.stagingFileName(fileName), Equals, fileName)
+	c.Assert(cc.checkStagingDir(destDir), IsNil)
+
+	cc.cpOption.stagingDir = stagingDir
+	stagingName := cc.stagingFileName(fileName)
+	c.Assert(filepath.Dir(stagingName), Equals, stagingDir)
+	c.Assert(cc.stagingFileName(fileName), Equals, stagingName)
+	c.Assert(cc.stagingFileName(filepath.Join(destDir, "b.txt")), Not(Equals), stagingName)
+
+	c.Assert(cc.checkStagingDir(fileName), IsNil)
+	s.createFile(stagingName, "content", c)
+	c.Assert(cc.commitStagingFile(stagingName, fileName), IsNil)
+	_, err := os.Stat(stagingName)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	data, err := ioutil.ReadFile(fileName)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "content")
+
+	files, _ := ioutil.ReadDir(stagingDir)
+	c.Assert(len(files), Equals, 0)
+
+	os.RemoveAll(stagingDir)
+	os.RemoveAll(destDir)
+}
+
+func (s *OssutilCommandSuite) TestDownloadWithStagingDir(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+	object := "staging-object"
+	s.putObject(bucketName, object, uploadFileName, c)
+
+	stagingDir := "ossutil-test-staging-" + randLowStr(5)
+	fileName := "ossutil-test-staging-file-" + randLowStr(5)
+	str := ""
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"stagingDir":      &stagingDir,
+	}
+	_, err := cm.RunCommand("cp", []string{CloudURLToString(bucketName, object), fileName}, options)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(fileName)
+	c.Assert(err, IsNil)
+	files, _ := ioutil.ReadDir(stagingDir)
+	c.Assert(len(files), Equals, 0)
+
+	// upload doesn't support --staging-dir
+	_, err = cm.RunCommand("cp", []string{uploadFileName, CloudURLToString(bucketName, "upload")}, options)
+	c.Assert(err, NotNil)
+
+	os.Remove(fileName)
+	os.RemoveAll(stagingDir)
+	s.removeBucket(bucketName, true, c)
+}
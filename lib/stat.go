@@ -1,8 +1,10 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -17,7 +19,8 @@ var specChineseStat = SpecText{
 	paramText: "cloud_url [options]",
 
 	syntaxText: ` 
-    ossutil stat oss://bucket[/object] [--encoding-type url] [--version-id versionId] [--payer requester] [-c file] 
+    ossutil stat oss://bucket[/object] [--encoding-type url] [--version-id versionId] [--payer requester] [--output format] [-c file] 
+    ossutil stat oss://bucket --null-input [--encoding-type url] [--payer requester] [--output format] [-c file] 
 `,
 
 	detailHelpText: ` 
@@ -34,7 +37,23 @@ var specChineseStat = SpecText{
 
     2) ossutil stat oss://bucket/object [--encoding-type url] [--version-id versionId]
         ossutil显示指定object的元信息，包括文件大小，最新更新时间，etag，文件类型，acl，文
-    件的自定义meta等信息。
+    件的自定义meta等信息。s3://的object设置了object lock时，同时显示保留模式RetentionMode、
+    保留截止时间RetainUntil和法律保留LegalHold。
+
+    3) ossutil stat oss://bucket --null-input [--encoding-type url]
+        ossutil从stdin读取以NUL字符分隔的cloud_url（如ls --print0或find --print0的输出），依次
+    显示这些object的元信息，每个object的元信息前输出其cloud_url，object名中含有换行或空格时也能
+    正确处理。cloud_url必须属于指定的bucket，不支持--version-id。获取某个object失败时继续处理
+    其他objects，结束时返回错误。
+
+--output选项
+
+    --output json表示以json对象输出bucket或者object的信息，字段名与ls --output json相同，为驼峰
+    格式，如url、size、lastModified、etag、storageClass，大小为数字，时间为RFC3339格式，etag不含引号，
+    便于通过管道交给jq等工具处理。object的用户自定义meta去掉x-oss-meta-前缀后输出在meta字段中，
+    其他响应头按原名称输出在headers字段中。--null-input时每个object输出为一行json(json lines)。
+    --output yaml以yaml格式输出相同的字段，--null-input时每个object输出为一个以---开头的yaml文档。
+    默认为text。
 `,
 
 	sampleText: ` 
@@ -43,6 +62,8 @@ var specChineseStat = SpecText{
     ossutil stat oss://bucket1/object --version-id versionId
     ossutil stat oss://bucket1/%e4%b8%ad%e6%96%87 --encoding-type url
     ossutil stat oss://bucket1/object --payer requester
+    ossutil ls oss://bucket1/logs/ -0 | ossutil stat oss://bucket1 --null-input
+    ossutil stat oss://bucket1/object --output json | jq -r '.size'
 `,
 }
 
@@ -53,7 +74,8 @@ var specEnglishStat = SpecText{
 	paramText: "cloud_url [options]",
 
 	syntaxText: ` 
-    ossutil stat oss://bucket[/object] [--encoding-type url]  [--version-id versionId] [--payer requester] [-c file] 
+    ossutil stat oss://bucket[/object] [--encoding-type url]  [--version-id versionId] [--payer requester] [--output format] [-c file] 
+    ossutil stat oss://bucket --null-input [--encoding-type url] [--payer requester] [--output format] [-c file] 
 `,
 
 	detailHelpText: ` 
@@ -70,7 +92,26 @@ Usage：
 
     2) ossutil stat oss://bucket/object [--encoding-type url] [--version-id versionId]
         ossutil display object meta info, include file size, last modify time, etag, content-type, 
-    user meta etc.
+    user meta etc. If the s3:// object is locked, its retention mode RetentionMode, retain until 
+    time RetainUntil and legal hold LegalHold are displayed too.
+
+    3) ossutil stat oss://bucket --null-input [--encoding-type url]
+        ossutil reads the NUL-delimited cloud_urls(like the output of ls --print0 or find --print0)
+    from stdin, and displays the meta info of the objects one by one, the cloud_url is output before
+    the meta info of every object, the object names containing newlines or spaces are handled
+    correctly. The cloud_urls must belong to the bucket, --version-id is not supported. If getting
+    an object failed, the other objects are still displayed, and the command returns error in the end.
+
+--output option
+
+    --output json means the info of the bucket or object is output as a json object, the field
+    names are the same as ls --output json in camelCase, e.g., url, size, lastModified, etag and
+    storageClass, the sizes are numbers, the times are in RFC3339 format and the etag is unquoted,
+    so that the output can be piped to tools like jq. The user meta of the object is output in the
+    meta field without the x-oss-meta- prefix, the other response headers are output in the headers
+    field by their names. With --null-input, every object is output as a line of json(json lines).
+    --output yaml outputs the same fields in yaml, every object is a yaml document starting with ---
+    with --null-input. The default is text.
 `,
 
 	sampleText: ` 
@@ -79,6 +120,8 @@ Usage：
     ossutil stat oss://bucket1/object --version-id versionId  
     ossutil stat oss://bucket1/%e4%b8%ad%e6%96%87 --encoding-type url
     ossutil stat oss://bucket1/object --payer requester
+    ossutil ls oss://bucket1/logs/ -0 | ossutil stat oss://bucket1 --null-input
+    ossutil stat oss://bucket1/object --output json | jq -r '.size'
 `,
 }
 
@@ -87,17 +130,16 @@ type StatCommand struct {
 	command       Command
 	versionId     string
 	commonOptions []oss.Option
+	output        outputFormat
 }
 
 var statCommand = StatCommand{
 	command: Command{
-		name:        "stat",
-		nameAlias:   []string{"meta", "info"},
-		minArgc:     1,
-		maxArgc:     1,
-		specChinese: specChineseStat,
-		specEnglish: specEnglishStat,
-		group:       GroupTypeNormalCommand,
+		name:      "stat",
+		nameAlias: []string{"meta", "info"},
+		minArgc:   1,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionEncodingType,
 			OptionConfigFile,
@@ -120,6 +162,11 @@ var statCommand = StatCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -127,6 +174,9 @@ var statCommand = StatCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionS3Endpoint,
+			OptionNullInput,
+			OptionOutput,
 		},
 	},
 }
@@ -141,13 +191,18 @@ func (sc *StatCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (sc *StatCommand) Init(args []string, options OptionMapType) error {
-	return sc.command.Init(args, options, sc)
+func (sc *StatCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return sc.command.Init(ctx, args, options, sc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
 func (sc *StatCommand) RunCommand() error {
 	sc.versionId, _ = GetString(OptionVersionId, sc.command.options)
+	output, err := getOutputFormat(sc.command.options)
+	if err != nil {
+		return err
+	}
+	sc.output = output
 	encodingType, _ := GetString(OptionEncodingType, sc.command.options)
 	cloudURL, err := CloudURLFromString(sc.command.args[0], encodingType)
 	if err != nil {
@@ -166,10 +221,21 @@ func (sc *StatCommand) RunCommand() error {
 		sc.commonOptions = append(sc.commonOptions, oss.RequestPayer(oss.PayerType(payer)))
 	}
 
-	bucket, err := sc.command.ossBucket(cloudURL.bucket)
+	bucket, err := sc.command.cloudBucket(cloudURL)
+	if err != nil {
+		return err
+	}
+
+	keys, err := sc.command.readNullInputKeys(cloudURL)
 	if err != nil {
 		return err
 	}
+	if keys != nil {
+		if cloudURL.object != "" || sc.versionId != "" {
+			return fmt.Errorf("stat --null-input only supports oss://bucket, and doesn't support --version-id")
+		}
+		return sc.objectsStat(bucket, keys)
+	}
 
 	if cloudURL.object == "" {
 		return sc.bucketStat(bucket, cloudURL)
@@ -177,6 +243,31 @@ func (sc *StatCommand) RunCommand() error {
 	return sc.objectStat(bucket, cloudURL)
 }
 
+// objectsStat displays the meta info of the objects of --null-input, the cloud url is output before
+// the meta info of every object. With --output json, every object is output as a line of json
+// which has the cloud url as the url field
+func (sc *StatCommand) objectsStat(bucket *oss.Bucket, keys []string) error {
+	var errNum int
+	for i, key := range keys {
+		url := bucketObjectURL(bucket, key)
+		if !sc.output.structured() {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(url)
+		}
+		if err := sc.objectStat(bucket, CloudURL{bucket: bucket.BucketName, object: key}); err != nil {
+			errNum++
+			fmt.Fprintf(os.Stderr, "stat %s error: %s\n", url, err.Error())
+			LogError("stat %s error: %s\n", url, err.Error())
+		}
+	}
+	if errNum > 0 {
+		return fmt.Errorf("stat %d of %d objects failed", errNum, len(keys))
+	}
+	return nil
+}
+
 func (sc *StatCommand) bucketStat(bucket *oss.Bucket, cloudURL CloudURL) error {
 	// TODO: go sdk should implement GetBucketInfo
 	gbar, err := sc.ossGetBucketStatRetry(bucket)
@@ -184,30 +275,35 @@ func (sc *StatCommand) bucketStat(bucket *oss.Bucket, cloudURL CloudURL) error {
 		return err
 	}
 
-	fmt.Printf("%-22s: %s\n", StatName, gbar.BucketInfo.Name)
-	fmt.Printf("%-22s: %s\n", StatLocation, gbar.BucketInfo.Location)
-	fmt.Printf("%-22s: %s\n", StatCreationDate, utcToLocalTime(gbar.BucketInfo.CreationDate))
-	fmt.Printf("%-22s: %s\n", StatExtranetEndpoint, gbar.BucketInfo.ExtranetEndpoint)
-	fmt.Printf("%-22s: %s\n", StatIntranetEndpoint, gbar.BucketInfo.IntranetEndpoint)
-	fmt.Printf("%-22s: %s\n", StatACL, gbar.BucketInfo.ACL)
-	fmt.Printf("%-22s: %s\n", StatOwner, gbar.BucketInfo.Owner.ID)
-	fmt.Printf("%-22s: %s\n", StatStorageClass, gbar.BucketInfo.StorageClass)
-	if len(gbar.BucketInfo.RedundancyType) > 0 {
-		fmt.Printf("%-22s: %s\n", StatRedundancyType, gbar.BucketInfo.RedundancyType)
+	info := gbar.BucketInfo
+	if sc.output.structured() {
+		return sc.output.print(newStatBucketEntry(info))
+	}
+
+	fmt.Printf("%-22s: %s\n", StatName, info.Name)
+	fmt.Printf("%-22s: %s\n", StatLocation, info.Location)
+	fmt.Printf("%-22s: %s\n", StatCreationDate, utcToLocalTime(info.CreationDate))
+	fmt.Printf("%-22s: %s\n", StatExtranetEndpoint, info.ExtranetEndpoint)
+	fmt.Printf("%-22s: %s\n", StatIntranetEndpoint, info.IntranetEndpoint)
+	fmt.Printf("%-22s: %s\n", StatACL, info.ACL)
+	fmt.Printf("%-22s: %s\n", StatOwner, info.Owner.ID)
+	fmt.Printf("%-22s: %s\n", StatStorageClass, info.StorageClass)
+	if len(info.RedundancyType) > 0 {
+		fmt.Printf("%-22s: %s\n", StatRedundancyType, info.RedundancyType)
 	}
-	if len(gbar.BucketInfo.SseRule.SSEAlgorithm) > 0 {
-		fmt.Printf("%-22s: %s\n", StatSSEAlgorithm, gbar.BucketInfo.SseRule.SSEAlgorithm)
+	if len(info.SseRule.SSEAlgorithm) > 0 {
+		fmt.Printf("%-22s: %s\n", StatSSEAlgorithm, info.SseRule.SSEAlgorithm)
 	}
-	if len(gbar.BucketInfo.SseRule.KMSMasterKeyID) > 0 {
-		fmt.Printf("%-22s: %s\n", StatKMSMasterKeyID, gbar.BucketInfo.SseRule.KMSMasterKeyID)
+	if len(info.SseRule.KMSMasterKeyID) > 0 {
+		fmt.Printf("%-22s: %s\n", StatKMSMasterKeyID, info.SseRule.KMSMasterKeyID)
 	}
-	if len(gbar.BucketInfo.SseRule.KMSDataEncryption) > 0 {
-		fmt.Printf("%-22s: %s\n", StatKMSDataEncryption, gbar.BucketInfo.SseRule.KMSDataEncryption)
+	if len(info.SseRule.KMSDataEncryption) > 0 {
+		fmt.Printf("%-22s: %s\n", StatKMSDataEncryption, info.SseRule.KMSDataEncryption)
 	}
-	fmt.Printf("%-22s: %s\n", StatTransferAcceleration, gbar.BucketInfo.TransferAcceleration)
-	fmt.Printf("%-22s: %s\n", StatCrossRegionReplication, gbar.BucketInfo.CrossRegionReplication)
-	if len(gbar.BucketInfo.AccessMonitor) > 0 {
-		fmt.Printf("%-22s: %s\n", StatAccessMonitor, gbar.BucketInfo.AccessMonitor)
+	fmt.Printf("%-22s: %s\n", StatTransferAcceleration, info.TransferAcceleration)
+	fmt.Printf("%-22s: %s\n", StatCrossRegionReplication, info.CrossRegionReplication)
+	if len(info.AccessMonitor) > 0 {
+		fmt.Printf("%-22s: %s\n", StatAccessMonitor, info.AccessMonitor)
 	}
 
 	return nil
@@ -245,6 +341,15 @@ func (sc *StatCommand) objectStat(bucket *oss.Bucket, cloudURL CloudURL) error {
 		return err
 	}
 
+	if sc.output.structured() {
+		entry := newStatObjectEntry(cloudURL, props, goar)
+		if nullInput, _ := GetBool(OptionNullInput, sc.command.options); nullInput {
+			fmt.Println(sc.output.line(entry))
+			return nil
+		}
+		return sc.output.print(entry)
+	}
+
 	sortNames := []string{}
 	attrMap := map[string]string{}
 	maxNameLen := 0
@@ -268,6 +373,19 @@ func (sc *StatCommand) objectStat(bucket *oss.Bucket, cloudURL CloudURL) error {
 	sortNames = append(sortNames, "ACL")
 	attrMap[StatOwner] = goar.Owner.ID
 	attrMap[StatACL] = goar.ACL
+	// the object lock of s3 objects
+	if mode := props.Get(HTTPHeaderObjectLockMode); mode != "" {
+		sortNames = append(sortNames, StatRetentionMode, StatRetainUntil)
+		attrMap[StatRetentionMode] = mode
+		attrMap[StatRetainUntil] = props.Get(HTTPHeaderObjectLockRetainUntil)
+		if until, err := time.Parse(time.RFC3339, attrMap[StatRetainUntil]); err == nil {
+			attrMap[StatRetainUntil] = fmt.Sprintf("%s", utcToLocalTime(until.UTC()))
+		}
+	}
+	if legalHold := props.Get(HTTPHeaderObjectLockLegalHold); legalHold != "" {
+		sortNames = append(sortNames, StatLegalHold)
+		attrMap[StatLegalHold] = legalHold
+	}
 	if lm, err := time.Parse(http.TimeFormat, attrMap[StatLastModified]); err == nil {
 		attrMap[StatLastModified] = fmt.Sprintf("%s", utcToLocalTime(lm.UTC()))
 	}
@@ -0,0 +1,194 @@
+package lib
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatSummaryStdout is the value of --stat-summary to print the statistics to stdout
+const StatSummaryStdout = "stdout"
+
+const (
+	fileStatOK    = "ok"
+	fileStatSkip  = "skip"
+	fileStatError = "error"
+)
+
+type fileStatRecord struct {
+	name    string
+	status  string
+	size    int64
+	cost    time.Duration
+	retries int64
+}
+
+// speed returns the average speed in bytes per second
+func (r fileStatRecord) speed() float64 {
+	if r.cost <= 0 {
+		return 0
+	}
+	return float64(r.size) / r.cost.Seconds()
+}
+
+// statSummary collects the statistics of every file transferred by cp or sync,
+// the retries are recorded by the retry functions and merged into the record of the file
+type statSummary struct {
+	mu      sync.Mutex
+	retries map[string]int64
+	records []fileStatRecord
+}
+
+func newStatSummary() *statSummary {
+	return &statSummary{retries: map[string]int64{}}
+}
+
+func (ss *statSummary) addRetry(key string) {
+	if ss == nil {
+		return
+	}
+	ss.mu.Lock()
+	ss.retries[key]++
+	ss.mu.Unlock()
+}
+
+func (ss *statSummary) addRecord(key, name string, skip bool, err error, size int64, cost time.Duration) {
+	if ss == nil {
+		return
+	}
+	status := fileStatOK
+	if err != nil {
+		status = fileStatError
+	} else if skip {
+		status = fileStatSkip
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	ss.records = append(ss.records, fileStatRecord{name, status, size, cost, ss.retries[key]})
+	delete(ss.retries, key)
+}
+
+// sortedRecords returns the records from slowest to fastest
+func (ss *statSummary) sortedRecords() []fileStatRecord {
+	ss.mu.Lock()
+	records := append([]fileStatRecord{}, ss.records...)
+	ss.mu.Unlock()
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].cost > records[j].cost
+	})
+	return records
+}
+
+// percentile returns the p-th percentile of the sorted values by nearest rank
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+type statAggregate struct {
+	okNum      int64
+	skipNum    int64
+	errNum     int64
+	totalBytes int64
+	retries    int64
+	costs      []float64 // milliseconds, sorted
+	speeds     []float64 // bytes per second, sorted
+}
+
+func (ss *statSummary) aggregate(records []fileStatRecord) statAggregate {
+	var agg statAggregate
+	for _, record := range records {
+		agg.retries += record.retries
+		switch record.status {
+		case fileStatOK:
+			agg.okNum++
+			agg.totalBytes += record.size
+			agg.costs = append(agg.costs, float64(record.cost)/float64(time.Millisecond))
+			agg.speeds = append(agg.speeds, record.speed())
+		case fileStatSkip:
+			agg.skipNum++
+		default:
+			agg.errNum++
+		}
+	}
+	sort.Float64s(agg.costs)
+	sort.Float64s(agg.speeds)
+	return agg
+}
+
+func (ss *statSummary) print(w io.Writer) {
+	records := ss.sortedRecords()
+	fmt.Fprintf(w, "\n%-10s%-8s%-16s%-8s%-16s%s\n", "cost(ms)", "status", "size(byte)", "retries", "speed(KB/s)", "name")
+	for _, record := range records {
+		fmt.Fprintf(w, "%-10d%-8s%-16d%-8d%-16.2f%s\n", record.cost.Nanoseconds()/int64(time.Millisecond),
+			record.status, record.size, record.retries, record.speed()/1024, record.name)
+	}
+	ss.printAggregate(w, records)
+}
+
+func (ss *statSummary) printAggregate(w io.Writer, records []fileStatRecord) {
+	agg := ss.aggregate(records)
+	fmt.Fprintf(w, "\nsucceed: %d, skipped: %d, failed: %d, total size: %d(byte), retries: %d\n",
+		agg.okNum, agg.skipNum, agg.errNum, agg.totalBytes, agg.retries)
+	if agg.okNum == 0 {
+		return
+	}
+	fmt.Fprintf(w, "%-12s%12s%12s%12s%12s%12s\n", "", "min", "p50", "p90", "p99", "max")
+	fmt.Fprintf(w, "%-12s%12.0f%12.0f%12.0f%12.0f%12.0f\n", "cost(ms)", agg.costs[0], percentile(agg.costs, 50),
+		percentile(agg.costs, 90), percentile(agg.costs, 99), agg.costs[len(agg.costs)-1])
+	fmt.Fprintf(w, "%-12s%12.2f%12.2f%12.2f%12.2f%12.2f\n", "speed(KB/s)", agg.speeds[0]/1024, percentile(agg.speeds, 50)/1024,
+		percentile(agg.speeds, 90)/1024, percentile(agg.speeds, 99)/1024, agg.speeds[len(agg.speeds)-1]/1024)
+}
+
+func (ss *statSummary) writeCSV(fileName string) error {
+	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"name", "status", "size", "cost_ms", "retries", "speed_bytes_per_second"})
+	for _, record := range ss.sortedRecords() {
+		w.Write([]string{
+			record.name,
+			record.status,
+			strconv.FormatInt(record.size, 10),
+			strconv.FormatInt(record.cost.Nanoseconds()/int64(time.Millisecond), 10),
+			strconv.FormatInt(record.retries, 10),
+			strconv.FormatFloat(record.speed(), 'f', 2, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// output prints the statistics to w or writes them to the csv file
+func (ss *statSummary) output(w io.Writer, target string) error {
+	if ss == nil {
+		return nil
+	}
+	if target == StatSummaryStdout {
+		ss.print(w)
+		return nil
+	}
+	if err := ss.writeCSV(target); err != nil {
+		return fmt.Errorf("write stat summary to %s error: %s", target, err.Error())
+	}
+	ss.printAggregate(w, ss.sortedRecords())
+	fmt.Fprintf(w, "the statistics of files are written to %s\n", target)
+	return nil
+}
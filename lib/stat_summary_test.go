@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestStatSummaryPercentile(c *C) {
+	values := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	c.Assert(percentile(values, 50), Equals, float64(5))
+	c.Assert(percentile(values, 90), Equals, float64(9))
+	c.Assert(percentile(values, 99), Equals, float64(10))
+	c.Assert(percentile(values, 0), Equals, float64(1))
+	c.Assert(percentile([]float64{}, 50), Equals, float64(0))
+}
+
+func (s *OssutilCommandSuite) TestStatSummaryRecords(c *C) {
+	// nil summary is disabled
+	var disabled *statSummary
+	disabled.addRetry("a")
+	disabled.addRecord("a", "a", false, nil, 1, time.Second)
+	c.Assert(disabled.output(os.Stdout, StatSummaryStdout), IsNil)
+
+	ss := newStatSummary()
+	ss.addRetry("a")
+	ss.addRetry("a")
+	ss.addRecord("a", "file-a", false, nil, 2048, 2*time.Second)
+	ss.addRecord("b", "file-b", true, nil, 100, time.Millisecond)
+	ss.addRecord("c", "file-c", false, errors.New("failed"), 0, 3*time.Second)
+
+	records := ss.sortedRecords()
+	c.Assert(len(records), Equals, 3)
+	c.Assert(records[0].name, Equals, "file-c")
+	c.Assert(records[0].status, Equals, fileStatError)
+	c.Assert(records[1].retries, Equals, int64(2))
+	c.Assert(records[1].speed(), Equals, float64(1024))
+	c.Assert(records[2].status, Equals, fileStatSkip)
+	c.Assert(len(ss.retries), Equals, 0)
+
+	agg := ss.aggregate(records)
+	c.Assert(agg.okNum, Equals, int64(1))
+	c.Assert(agg.skipNum, Equals, int64(1))
+	c.Assert(agg.errNum, Equals, int64(1))
+	c.Assert(agg.totalBytes, Equals, int64(2048))
+	c.Assert(agg.retries, Equals, int64(2))
+
+	var buf bytes.Buffer
+	ss.print(&buf)
+	c.Assert(strings.Contains(buf.String(), "p99"), Equals, true)
+	c.Assert(strings.Contains(buf.String(), "file-a"), Equals, true)
+
+	fileName := "ossutil-test-stat-" + randLowStr(5) + ".csv"
+	c.Assert(ss.writeCSV(fileName), IsNil)
+	data, err := ioutil.ReadFile(fileName)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	c.Assert(len(lines), Equals, 4)
+	c.Assert(lines[0], Equals, "name,status,size,cost_ms,retries,speed_bytes_per_second")
+	c.Assert(lines[2], Equals, "file-a,ok,2048,2000,2,1024.00")
+	os.Remove(fileName)
+}
+
+func (s *OssutilCommandSuite) TestCopyStatSummary(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	dir := "ossutil-test-dir-" + randLowStr(5)
+	c.Assert(os.MkdirAll(dir, 0755), IsNil)
+	s.createFile(dir+string(os.PathSeparator)+"a", randLowStr(100), c)
+	s.createFile(dir+string(os.PathSeparator)+"b", randLowStr(200), c)
+
+	str := ""
+	recursive := true
+	statFile := "ossutil-test-stat-" + randLowStr(5) + ".csv"
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"recursive":       &recursive,
+		"statSummary":     &statFile,
+	}
+	_, err := cm.RunCommand("cp", []string{dir, CloudURLToString(bucketName, "")}, options)
+	c.Assert(err, IsNil)
+
+	data, err := ioutil.ReadFile(statFile)
+	c.Assert(err, IsNil)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	c.Assert(len(lines), Equals, 3)
+
+	os.Remove(statFile)
+	os.RemoveAll(dir)
+	s.removeBucket(bucketName, true, c)
+}
@@ -1,8 +1,11 @@
 package lib
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"strings"
@@ -294,3 +297,94 @@ func (s *OssutilCommandSuite) TestStatObjectWithPayer(c *C) {
 	c.Assert(strings.Contains(statBody, "X-Oss-Hash-Crc64ecma"), Equals, true)
 	os.Remove(resultfileName)
 }
+
+func (s *OssutilCommandSuite) TestStatJSON(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if _, ok := query["bucketInfo"]; ok {
+			fmt.Fprint(w, `<BucketInfo><Bucket><Name>bucket</Name><Location>oss-cn-hangzhou</Location>
+<CreationDate>2024-01-01T00:00:00.000Z</CreationDate><ExtranetEndpoint>oss-cn-hangzhou.aliyuncs.com</ExtranetEndpoint>
+<Owner><ID>owner-id</ID></Owner><AccessControlList><Grant>private</Grant></AccessControlList>
+<StorageClass>Standard</StorageClass></Bucket></BucketInfo>`)
+			return
+		}
+		if _, ok := query["acl"]; ok {
+			fmt.Fprint(w, `<AccessControlPolicy><Owner><ID>owner-id</ID></Owner><AccessControlList><Grant>default</Grant></AccessControlList></AccessControlPolicy>`)
+			return
+		}
+		w.Header().Set("ETag", `"etag-a"`)
+		w.Header().Set("Content-Length", "100")
+		w.Header().Set("Last-Modified", "Tue, 02 Jan 2024 03:04:05 GMT")
+		w.Header().Set("X-Oss-Meta-Color", "red")
+		w.Header().Set("X-Oss-Storage-Class", "IA")
+		w.Header().Set("X-Oss-Object-Type", "Normal")
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	output := "json"
+	forcePathStyle := true
+	run := func(url string) (string, error) {
+		sc := &StatCommand{}
+		sc.command.args = []string{url}
+		sc.command.options = OptionMapType{
+			OptionEndpoint:        &endpoint,
+			OptionAccessKeyID:     &str,
+			OptionAccessKeySecret: &str,
+			OptionOutput:          &output,
+			OptionForcePathStyle:  &forcePathStyle,
+		}
+		testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+		c.Assert(err, IsNil)
+		oldStdout := os.Stdout
+		os.Stdout = testResultFile
+		err = sc.RunCommand()
+		testResultFile.Close()
+		os.Stdout = oldStdout
+		return s.readFile(resultPath, c), err
+	}
+	defer os.Remove(resultPath)
+
+	// the fields of the object are the same as ls, the sizes are numbers
+	out, err := run("oss://bucket/a")
+	c.Assert(err, IsNil)
+	attrs := map[string]interface{}{}
+	c.Assert(json.Unmarshal([]byte(out), &attrs), IsNil)
+	c.Assert(attrs["type"], Equals, lsEntryObject)
+	c.Assert(attrs["url"], Equals, "oss://bucket/a")
+	c.Assert(attrs["key"], Equals, "a")
+	c.Assert(attrs["size"], Equals, float64(100))
+	c.Assert(attrs["lastModified"], Equals, "2024-01-02T03:04:05Z")
+	c.Assert(attrs["etag"], Equals, "etag-a")
+	c.Assert(attrs["storageClass"], Equals, "IA")
+	c.Assert(attrs["owner"], Equals, "owner-id")
+	c.Assert(attrs["acl"], Equals, "default")
+	c.Assert(attrs["meta"], DeepEquals, map[string]interface{}{"color": "red"})
+	c.Assert(attrs["headers"], DeepEquals, map[string]interface{}{"X-Oss-Object-Type": "Normal"})
+	var lsEntry map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(jsonLine(lsObjectEntry{})), &lsEntry), IsNil)
+	for name := range lsEntry {
+		_, ok := attrs[name]
+		c.Assert(ok, Equals, true)
+	}
+
+	out, err = run("oss://bucket")
+	c.Assert(err, IsNil)
+	attrs = map[string]interface{}{}
+	c.Assert(json.Unmarshal([]byte(out), &attrs), IsNil)
+	c.Assert(attrs["type"], Equals, lsEntryBucket)
+	c.Assert(attrs["url"], Equals, "oss://bucket")
+	c.Assert(attrs["name"], Equals, "bucket")
+	c.Assert(attrs["region"], Equals, "oss-cn-hangzhou")
+	c.Assert(attrs["creationTime"], Equals, "2024-01-01T00:00:00Z")
+	c.Assert(attrs["extranetEndpoint"], Equals, "oss-cn-hangzhou.aliyuncs.com")
+	c.Assert(attrs["acl"], Equals, "private")
+	// the empty fields are omitted
+	_, ok := attrs["intranetEndpoint"]
+	c.Assert(ok, Equals, false)
+
+	output = "xml"
+	_, err = run("oss://bucket/a")
+	c.Assert(err, NotNil)
+}
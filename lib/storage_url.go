@@ -29,6 +29,7 @@ type StorageURLer interface {
 // CloudURL describes oss url
 type CloudURL struct {
 	urlStr string
+	scheme string
 	bucket string
 	object string
 }
@@ -51,6 +52,10 @@ func (cu *CloudURL) parseBucketObject(encodingType string) error {
 
 	if strings.HasPrefix(strings.ToLower(path), SchemePrefix) {
 		path = string(path[len(SchemePrefix):])
+	} else if hasS3SchemePrefix(path) {
+		path = string(path[len(S3SchemePrefix):])
+	} else if prefix := endpointAliasPrefix(path); prefix != "" {
+		path = string(path[len(prefix):])
 	} else {
 		// deal with the url: /bucket/object
 		if strings.HasPrefix(path, "/") {
@@ -60,6 +65,11 @@ func (cu *CloudURL) parseBucketObject(encodingType string) error {
 
 	sli := strings.SplitN(path, "/", 2)
 	cu.bucket = sli[0]
+	if hasS3SchemePrefix(cu.urlStr) {
+		cu.scheme = S3SchemePrefix
+	} else if prefix := endpointAliasPrefix(cu.urlStr); prefix != "" {
+		cu.scheme = prefix
+	}
 	if len(sli) > 1 {
 		cu.object = sli[1]
 		if encodingType == URLEncodingType {
@@ -115,10 +125,25 @@ func (cu CloudURL) IsFileURL() bool {
 
 // ToString reconstruct url
 func (cu CloudURL) ToString() string {
+	prefix := SchemePrefix
+	if cu.scheme != "" {
+		prefix = cu.scheme
+	}
 	if cu.object == "" {
-		return fmt.Sprintf("%s%s", SchemePrefix, cu.bucket)
+		return fmt.Sprintf("%s%s", prefix, cu.bucket)
 	}
-	return fmt.Sprintf("%s%s/%s", SchemePrefix, cu.bucket, cu.object)
+	return fmt.Sprintf("%s%s/%s", prefix, cu.bucket, cu.object)
+}
+
+// isS3 shows if the url is served by s3 compatible service
+func (cu CloudURL) isS3() bool {
+	return cu.scheme == S3SchemePrefix
+}
+
+// objectURL returns the url of the object in the same bucket
+func (cu CloudURL) objectURL(object string) string {
+	cu.object = object
+	return cu.ToString()
 }
 
 // FileURL describes file url
@@ -165,7 +190,7 @@ func (fu FileURL) ToString() string {
 
 // StorageURLFromString analysis input url type and build a storage url from the url
 func StorageURLFromString(urlStr, encodingType string) (StorageURLer, error) {
-	if strings.HasPrefix(strings.ToLower(urlStr), SchemePrefix) {
+	if strings.HasPrefix(strings.ToLower(urlStr), SchemePrefix) || hasS3SchemePrefix(urlStr) || endpointAliasPrefix(urlStr) != "" {
 		var cloudURL CloudURL
 		if err := cloudURL.Init(urlStr, encodingType); err != nil {
 			return nil, err
@@ -2,11 +2,12 @@ package lib
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"sort"
+	"strconv"
 	"strings"
 
 	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
@@ -32,6 +33,7 @@ type syncOptionType struct {
 	disableAllSymlink bool
 	cpDir             string
 	removeCount       int
+	lowMemory         bool
 
 	filters      []filterOptionType
 	payerOptions []oss.Option
@@ -47,6 +49,7 @@ var specChineseSync = SpecText{
     ossutil sync local_dir cloud_url [-f] [-u] [--delete] [--backup-dir] [--enable-symlink-dir] [--disable-all-symlink] [--disable-ignore-error] [--only-current-dir] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--snapshot-path=sdir] [--payer requester]
     ossutil sync cloud_url local_dir [-f] [-u] [--delete] [--backup-dir] [--only-current-dir] [--disable-ignore-error] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--range=x-y] [--payer requester]
     ossutil sync cloud_url cloud_url [-f] [-u] [--delete] [--backup-dir] [--only-current-dir] [--disable-ignore-error] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--payer requester]
+    ossutil sync [src_url dest_url] --spec job.yaml
 `,
 
 	detailHelpText: ` 
@@ -69,6 +72,29 @@ var specChineseSync = SpecText{
 --backup-dir
     该选项表示用于备份目的端文件的目录, 不能是目的端目录的子目录,如果输入了--delete, 该选项必须输入
 
+--dry-run
+    只列举需要同步的文件，不传输文件，也不删除或者移走目的端的object或者文件，输出预计的请求次数和费用，
+    指定--delete时，包括删除目的端objects的DeleteMultipleObjects请求
+
+--output-failed和--retry-from
+    --output-failed将同步失败的文件或者object及错误信息记录到csv文件中，--retry-from只同步文件中记录的失败
+    的key，此时不再扫描源端和目的端，也不删除或者移走目的端的object或者文件
+
+--low-memory
+    指定--delete时，sync需要列举源端和目的端的全部文件或者object进行比较，默认保存在内存中，数量上限为
+    ` + strconv.Itoa(MaxSyncNumbers) + `。指定--low-memory时，列举的结果和比较的状态保存到系统临时目录（可通过环境变量TMPDIR修改）
+    中的临时leveldb数据库中，命令结束后删除，速度较慢但是内存占用很小且没有数量上限，可以在小内存的机器上
+    同步数十亿objects的prefix。--no-clobber列举的目的端objects同样保存到该数据库中
+
+--checksum和--hashdb
+    上传时指定--checksum，目的端object的大小和oss上存储的crc64与本地文件相同时跳过该文件，不能与--update、
+    --no-clobber或者--snapshot-path同时使用。同时指定--hashdb时，本地文件的crc64从hashdb命令维护的数据库中
+    读取，文件没有变化时不再重新计算，新计算的crc64会写入该数据库
+
+--spec
+    从yaml格式的任务描述文件读取参数和选项，文件格式参见cp命令的帮助，--delete、--backup-dir等sync
+    特有的选项同样可以在文件中声明
+
   
     其他选项说明、用法和cp命令相同
 `,
@@ -204,6 +230,7 @@ var specEnglishSync = SpecText{
     ossutil sync local_dir cloud_url [-f] [-u] [--delete] [--backup-dir] [--enable-symlink-dir] [--disable-all-symlink] [--disable-ignore-error] [--only-current-dir] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--snapshot-path=sdir] [--payer requester]
     ossutil sync cloud_url local_dir [-f] [-u] [--delete] [--backup-dir] [--only-current-dir] [--disable-ignore-error] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--range=x-y] [--payer requester]
     ossutil sync cloud_url cloud_url [-f] [-u] [--delete] [--backup-dir] [--only-current-dir] [--disable-ignore-error] [--output-dir=odir] [--bigfile-threshold=size] [--checkpoint-dir=cdir] [--payer requester]
+    ossutil sync [src_url dest_url] --spec job.yaml
 `,
 
 	detailHelpText: ` 
@@ -232,6 +259,37 @@ var specEnglishSync = SpecText{
     It cannot be a subdirectory of the destination directory. 
     If you enter --delete, this option must be entered
 
+--dry-run
+    Only list the files to be synced, without transferring the files or deleting or removing the 
+    destination objects or files, and print the estimated requests and cost. If --delete is specified,
+    the DeleteMultipleObjects requests to delete the destination objects are included
+
+--output-failed and --retry-from
+    --output-failed records the files or objects failed to sync with their errors to the csv file,
+    --retry-from only syncs the failed keys recorded in the file, without scanning the src and the
+    destination, or deleting or removing the destination objects or files
+
+--low-memory
+    If --delete is specified, sync lists all the files or objects of the src and the destination to
+    compare them, which are kept in memory by default, and the max number is ` + strconv.Itoa(MaxSyncNumbers) + `. If 
+    --low-memory is specified, the listing results and the compare state are spooled to the temp
+    leveldb database in the system temp directory(can be changed by the environment variable TMPDIR),
+    which is removed after the command finishes. It's slower, but uses little memory and has no max
+    number, so the prefixes of billions of objects can be synced on the machine with small memory.
+    The destination objects listed for --no-clobber are spooled to the database too
+
+--checksum and --hashdb
+    If --checksum is specified when uploading, the file is skipped if the destination object has the
+    same size and crc64 stored in oss, it can't be used together with --update, --no-clobber or
+    --snapshot-path. If --hashdb is specified too, the crc64 of the local files is read from the
+    database maintained by hashdb command, and isn't calculated again if the file is unchanged, the
+    newly calculated crc64 is written to the database
+
+--spec
+    Read the arguments and options from the job spec file in yaml, the format of the file is
+    described in the help of cp command, the options only supported by sync such as --delete and
+    --backup-dir can be declared in the file too
+
     Other options descriptions and usage are the same as the cp command
 `,
 
@@ -361,17 +419,17 @@ var specEnglishSync = SpecText{
 type SyncCommand struct {
 	command    Command
 	syncOption syncOptionType
+	copier     *CopyCommand // nil means the global copyCommand
+	filterArgs []string     // --include and --exclude are parsed from them, nil means os.Args
 }
 
 var syncCommand = SyncCommand{
 	command: Command{
-		name:        "sync",
-		nameAlias:   []string{"sync"},
-		minArgc:     2,
-		maxArgc:     2,
-		specChinese: specChineseSync,
-		specEnglish: specEnglishSync,
-		group:       GroupTypeNormalCommand,
+		name:      "sync",
+		nameAlias: []string{"sync"},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			// The following options are supported by sc command and cp command
 			//OptionRecursion,
@@ -382,6 +440,9 @@ var syncCommand = SyncCommand{
 			OptionBigFileThreshold,
 			OptionPartSize,
 			OptionCheckpointDir,
+			OptionJobID,
+			OptionEncryptCheckpoint,
+			OptionCheckpointExpire,
 			OptionRange,
 			OptionEncodingType,
 			OptionInclude,
@@ -401,6 +462,7 @@ var syncCommand = SyncCommand{
 			OptionParallel,
 			OptionSnapshotPath,
 			OptionDisableCRC64,
+			OptionPartCRC,
 			OptionRequestPayer,
 			OptionLogLevel,
 			OptionMaxUpSpeed,
@@ -421,6 +483,11 @@ var syncCommand = SyncCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionMaxDownSpeed,
@@ -429,6 +496,34 @@ var syncCommand = SyncCommand{
 			OptionRegion,
 			OptionCloudBoxID,
 			OptionForcePathStyle,
+			OptionS3Endpoint,
+			OptionMimeMap,
+			OptionDetectContentType,
+			OptionListSplit,
+			OptionStatSummary,
+			OptionForbidOverwrite,
+			OptionNoClobber,
+			OptionIgnoreExisting,
+			OptionDisableOssIgnore,
+			OptionMaxDuration,
+			OptionRetryBudget,
+			OptionMaxDiskUsage,
+			OptionStagingDir,
+			OptionCRCRetryTimes,
+			OptionWindowsNameMapping,
+			OptionLocalEncoding,
+			OptionDryRun,
+			OptionPlan,
+			OptionPrice,
+			OptionOutputFailed,
+			OptionRetryFrom,
+			OptionContentMD5,
+			OptionLowMemory,
+			OptionChecksum,
+			OptionHashDB,
+			OptionSpec,
+			OptionKeepParts,
+			OptionProgressFile,
 
 			// The following options are only supported by sc command, not supported by cp command
 			OptionDelete,
@@ -446,8 +541,16 @@ func (sc *SyncCommand) formatIndependHelp() string {
 	return sc.command.formatIndependHelp()
 }
 
+// getCopier returns the cp command which transfers the files or objects of sync
+func (sc *SyncCommand) getCopier() *CopyCommand {
+	if sc.copier == nil {
+		return &copyCommand
+	}
+	return sc.copier
+}
+
 // Init simulate inheritance, and polymorphism
-func (sc *SyncCommand) Init(args []string, options OptionMapType) error {
+func (sc *SyncCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
 	recursive := true
 	bakupOptions := make(OptionMapType)
 	for k, v := range options {
@@ -459,12 +562,18 @@ func (sc *SyncCommand) Init(args []string, options OptionMapType) error {
 	delete(bakupOptions, OptionDelete)
 	delete(bakupOptions, OptionBackupDir)
 
-	copyCommand.cpOption.bSyncCommand = true
-	err := (&copyCommand).Init(args, bakupOptions)
+	cc := sc.getCopier()
+	cc.cpOption.bSyncCommand = true
+	err := cc.Init(ctx, args, bakupOptions)
 	if err != nil {
 		return err
 	}
-	return sc.command.Init(args, options, sc)
+	if err = sc.command.Init(ctx, args, options, sc); err != nil {
+		return err
+	}
+	// the requests of listing and copying are limited together by --max-qps
+	cc.command.requestLimiter = sc.command.requestLimiter
+	return nil
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -482,6 +591,7 @@ func (sc *SyncCommand) RunCommand() error {
 
 	// check point dir
 	sc.syncOption.cpDir, _ = GetString(OptionCheckpointDir, sc.command.options)
+	sc.syncOption.lowMemory, _ = GetBool(OptionLowMemory, sc.command.options)
 
 	// payer
 	payer, _ := GetString(OptionRequestPayer, sc.command.options)
@@ -493,8 +603,13 @@ func (sc *SyncCommand) RunCommand() error {
 	}
 
 	// filters
+	filterArgs := sc.filterArgs
+	if filterArgs == nil {
+		filterArgs = os.Args
+	}
+	filterArgs = sc.command.withSpecFilters(filterArgs)
 	var res bool
-	res, sc.syncOption.filters = getFilter(os.Args)
+	res, sc.syncOption.filters = getFilter(filterArgs)
 	if !res {
 		return fmt.Errorf("--include or --exclude does not support format containing dir info")
 	}
@@ -527,8 +642,10 @@ func (sc *SyncCommand) RunCommand() error {
 		}
 	}
 
-	if !sc.syncOption.bDelete {
-		return copyCommand.RunCommand()
+	// the retry pass only transfers the failed files, the extra files have been deleted by the first pass
+	retryFrom, _ := GetString(OptionRetryFrom, sc.command.options)
+	if !sc.syncOption.bDelete || retryFrom != "" {
+		return sc.getCopier().RunCommand()
 	}
 
 	// sync command add '/' afert cloud prefix
@@ -546,8 +663,16 @@ func (sc *SyncCommand) RunCommand() error {
 	opType := sc.getCommandType(srcURL, destURL)
 
 	// get file list or object key list
-	srcKeys := make(map[string]string)
-	destKeys := make(map[string]string)
+	srcKeys, err := newKeyStore(sc.syncOption.lowMemory)
+	if err != nil {
+		return err
+	}
+	defer srcKeys.close()
+	destKeys, err := newKeyStore(sc.syncOption.lowMemory)
+	if err != nil {
+		return err
+	}
+	defer destKeys.close()
 	if srcURL.IsFileURL() {
 		err = sc.GetLocalFileKeys(srcURL, srcKeys)
 	} else {
@@ -570,24 +695,54 @@ func (sc *SyncCommand) RunCommand() error {
 
 	// Get keys to be deleted
 	bSame := (string(os.PathSeparator) == "/")
-	for k, _ := range srcKeys {
+	windowsNameMap, _ := GetString(OptionWindowsNameMapping, sc.command.options)
+	localEncoding, _ := GetString(OptionLocalEncoding, sc.command.options)
+	err = srcKeys.each(false, func(k, _ string) error {
+		if opType == operationTypePut {
+			k = decodeLocalName(k, localEncoding)
+		} else if opType == operationTypeGet {
+			k = encodeLocalName(k, localEncoding)
+		}
 		if bSame || opType == operationTypeCopy {
-			delete(destKeys, k)
+			return destKeys.delete(k)
 		} else if opType == operationTypePut {
-			delete(destKeys, strings.Replace(k, "\\", "/", -1))
-		} else {
-			delete(destKeys, strings.Replace(k, "/", "\\", -1))
+			return destKeys.delete(strings.Replace(k, "\\", "/", -1))
+		}
+		return destKeys.delete(strings.Replace(sanitizeWindowsKey(k, windowsNameMap), "/", "\\", -1))
+	})
+	if err != nil {
+		return err
+	}
+
+	// the objects excluded by .ossignore are kept
+	disableOssIgnore, _ := GetBool(OptionDisableOssIgnore, sc.command.options)
+	if opType == operationTypePut && !disableOssIgnore {
+		ignore := newOssIgnore(srcURL.ToString())
+		err = destKeys.each(false, func(k, _ string) error {
+			if ignore.isIgnored(k, strings.HasSuffix(k, "/")) {
+				return destKeys.delete(k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 
 	if destURL.IsFileURL() {
-		fmt.Printf("\nfile(directory) will be removed count:%d\n", len(destKeys))
+		fmt.Fprintf(sc.command.textOut(), "\nfile(directory) will be removed count:%d\n", destKeys.len())
 	} else {
-		fmt.Printf("\nobject will be deleted count:%d\n", len(destKeys))
+		fmt.Fprintf(sc.command.textOut(), "\nobject will be deleted count:%d\n", destKeys.len())
 	}
 
-	err = copyCommand.RunCommand()
-	if err != nil {
+	dryRun, _ := GetBool(OptionDryRun, sc.command.options)
+	plan, _ := GetBool(OptionPlan, sc.command.options)
+	if !destURL.IsFileURL() {
+		sc.getCopier().planDeletes = int64(destKeys.len())
+	}
+
+	err = sc.getCopier().RunCommand()
+	if err != nil || dryRun || plan {
 		return err
 	}
 
@@ -612,9 +767,8 @@ func (sc *SyncCommand) adjustCloudUrl(sUrl StorageURLer) StorageURLer {
 	return cloudUrl
 }
 
-func (sc *SyncCommand) DeleteExtraObjects(keys map[string]string, sUrl StorageURLer) error {
-	bucketName := sUrl.(CloudURL).bucket
-	bucket, err := sc.command.ossBucket(bucketName)
+func (sc *SyncCommand) DeleteExtraObjects(keys keyStore, sUrl StorageURLer) error {
+	bucket, err := sc.command.cloudBucket(sUrl.(CloudURL))
 	if err != nil {
 		return err
 	}
@@ -622,7 +776,7 @@ func (sc *SyncCommand) DeleteExtraObjects(keys map[string]string, sUrl StorageUR
 	deleteCount := 0
 	rmOptions := append(sc.syncOption.payerOptions, oss.DeleteObjectsQuiet(true))
 	objects := []string{}
-	for k, v := range keys {
+	err = keys.each(false, func(k, v string) error {
 		if len(objects) >= MaxBatchCount {
 			if sc.confirm(objects) {
 				err := sc.BatchRmObjects(bucket, objects, rmOptions)
@@ -632,10 +786,14 @@ func (sc *SyncCommand) DeleteExtraObjects(keys map[string]string, sUrl StorageUR
 			}
 			objects = []string{}
 			deleteCount += MaxBatchCount
-			fmt.Printf("\rdelete object count:%d", deleteCount)
+			fmt.Fprintf(sc.command.textOut(), "\rdelete object count:%d", deleteCount)
 		}
 		// prefix + relativeKey
 		objects = append(objects, v+k)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	if len(objects) > 0 && sc.confirm(objects) {
@@ -644,33 +802,27 @@ func (sc *SyncCommand) DeleteExtraObjects(keys map[string]string, sUrl StorageUR
 			return err
 		}
 		deleteCount += len(objects)
-		fmt.Printf("\rdelete object count:%d", deleteCount)
+		fmt.Fprintf(sc.command.textOut(), "\rdelete object count:%d", deleteCount)
 	}
 	return nil
 }
 
-func (sc *SyncCommand) RemoveExtraFiles(keys map[string]string, sUrl StorageURLer) error {
-	var sortList []string
-	for k, _ := range keys {
-		sortList = append(sortList, k)
-	}
-	// remove files first,then remove dir
-	sort.Sort(sort.Reverse(sort.StringSlice(sortList)))
-
+func (sc *SyncCommand) RemoveExtraFiles(keys keyStore, sUrl StorageURLer) error {
 	absDirName, err := sc.GetAbsPath(sUrl.ToString())
 	if err != nil {
 		return err
 	}
 
+	// remove files first,then remove dir
 	nowFatherDirName := ""
-	for _, k := range sortList {
+	return keys.each(true, func(k, _ string) error {
 		if strings.HasSuffix(k, string(os.PathSeparator)) {
 			// is dir
 			dirName := k[0 : len(k)-1]
 			readerInfos, _ := sc.readDirLimit(absDirName+dirName, 10)
 
 			if len(readerInfos) > 0 {
-				continue
+				return nil
 			} else {
 				//empty dir,need to remove or delete
 				f, err := os.Stat(sc.syncOption.backupDir + dirName)
@@ -703,8 +855,8 @@ func (sc *SyncCommand) RemoveExtraFiles(keys map[string]string, sUrl StorageURLe
 				return err
 			}
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 func (sc *SyncCommand) BatchRmObjects(bucket *oss.Bucket, objects []string, options []oss.Option) error {
@@ -739,7 +891,7 @@ func (sc *SyncCommand) getCommandType(srcURL StorageURLer, destURL StorageURLer)
 	return operationTypePut
 }
 
-func (sc *SyncCommand) GetLocalFileKeys(sUrl StorageURLer, keys map[string]string) error {
+func (sc *SyncCommand) GetLocalFileKeys(sUrl StorageURLer, keys keyStore) error {
 	strPath := sUrl.ToString()
 	if !strings.HasSuffix(strPath, string(os.PathSeparator)) {
 		// for symlink dir
@@ -767,22 +919,26 @@ func (sc *SyncCommand) GetFileList(strPath string, chFiles chan<- fileInfoType,
 	}
 }
 
-func (sc *SyncCommand) ReadLocalFileKeys(chFiles <-chan fileInfoType, chFinish chan<- error, keys map[string]string) {
+func (sc *SyncCommand) ReadLocalFileKeys(chFiles <-chan fileInfoType, chFinish chan<- error, keys keyStore) {
 	totalCount := 0
-	fmt.Printf("\n")
+	fmt.Fprintf(sc.command.textOut(), "\n")
 	for fileInfo := range chFiles {
-		if copyCommand.filterFile(fileInfo, sc.syncOption.cpDir) { // exclude checkpoint files
+		if sc.getCopier().filterFile(fileInfo, sc.syncOption.cpDir) { // exclude checkpoint files
 			totalCount++
-			fmt.Printf("\rtotal file(directory) count:%d", totalCount)
-			keys[fileInfo.filePath] = ""
-			if len(keys) > MaxSyncNumbers {
-				fmt.Printf("\n")
+			fmt.Fprintf(sc.command.textOut(), "\rtotal file(directory) count:%d", totalCount)
+			if err := keys.put(fileInfo.filePath, ""); err != nil {
+				fmt.Fprintf(sc.command.textOut(), "\n")
+				chFinish <- err
+				break
+			}
+			if !sc.syncOption.lowMemory && keys.len() > MaxSyncNumbers {
+				fmt.Fprintf(sc.command.textOut(), "\n")
 				chFinish <- fmt.Errorf("over max sync numbers %d", MaxSyncNumbers)
 				break
 			}
 		}
 	}
-	fmt.Printf("\rtotal file(directory) count:%d", totalCount)
+	fmt.Fprintf(sc.command.textOut(), "\rtotal file(directory) count:%d", totalCount)
 	chFinish <- nil
 }
 
@@ -864,9 +1020,8 @@ func (sc *SyncCommand) CheckDestBackupDir(sUrl StorageURLer) error {
 	return nil
 }
 
-func (sc *SyncCommand) GetOssKeys(sUrl StorageURLer, keys map[string]string) error {
-	bucketName := sUrl.(CloudURL).bucket
-	bucket, err := sc.command.ossBucket(bucketName)
+func (sc *SyncCommand) GetOssKeys(sUrl StorageURLer, keys keyStore) error {
+	bucket, err := sc.command.cloudBucket(sUrl.(CloudURL))
 	if err != nil {
 		return err
 	}
@@ -887,26 +1042,30 @@ func (sc *SyncCommand) GetOssKeys(sUrl StorageURLer, keys map[string]string) err
 func (sc *SyncCommand) GetOssKeyList(bucket *oss.Bucket, sURL StorageURLer, chObjects chan<- objectInfoType, chFinish chan<- error) {
 	cloudURL := sURL.(CloudURL)
 	err := getObjectListCommon(bucket, cloudURL, chObjects, sc.syncOption.onlyCurrentDir,
-		sc.syncOption.filters, sc.syncOption.payerOptions)
+		sc.syncOption.filters, sc.command.withContext(sc.syncOption.payerOptions))
 	if err != nil {
 		chFinish <- err
 	}
 }
 
-func (sc *SyncCommand) ReadOssKeys(keys map[string]string, sURL StorageURLer, chObjects <-chan objectInfoType, chFinish chan<- error) {
+func (sc *SyncCommand) ReadOssKeys(keys keyStore, sURL StorageURLer, chObjects <-chan objectInfoType, chFinish chan<- error) {
 	totalCount := 0
-	fmt.Printf("\n")
+	fmt.Fprintf(sc.command.textOut(), "\n")
 	for objectInfo := range chObjects {
 		totalCount++
-		fmt.Printf("\r%s,total oss object count:%d", sURL.ToString(), totalCount)
-		keys[objectInfo.relativeKey] = objectInfo.prefix
-		if len(keys) > MaxSyncNumbers {
-			fmt.Printf("\n")
+		fmt.Fprintf(sc.command.textOut(), "\r%s,total oss object count:%d", sURL.ToString(), totalCount)
+		if err := keys.put(objectInfo.relativeKey, objectInfo.prefix); err != nil {
+			fmt.Fprintf(sc.command.textOut(), "\n")
+			chFinish <- err
+			break
+		}
+		if !sc.syncOption.lowMemory && keys.len() > MaxSyncNumbers {
+			fmt.Fprintf(sc.command.textOut(), "\n")
 			chFinish <- fmt.Errorf("over max sync numbers %d", MaxSyncNumbers)
 			break
 		}
 	}
-	fmt.Printf("\r%s,total oss object count:%d", sURL.ToString(), totalCount)
+	fmt.Fprintf(sc.command.textOut(), "\r%s,total oss object count:%d", sURL.ToString(), totalCount)
 	chFinish <- nil
 }
 
@@ -921,7 +1080,7 @@ func (sc *SyncCommand) confirm(keys []string) bool {
 		logBuffer.WriteString(fmt.Sprintf("%s\n", v))
 	}
 	logBuffer.WriteString(fmt.Sprintf("sync:delete above objects(y or N)? "))
-	fmt.Printf(logBuffer.String())
+	fmt.Fprintf(sc.command.textOut(), logBuffer.String())
 
 	var val string
 	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
@@ -948,7 +1107,7 @@ func (sc *SyncCommand) movePath(srcName, destName string) error {
 		LogError("rename %s %s error,%s\n", srcName, destName, err.Error())
 	} else {
 		sc.syncOption.removeCount += 1
-		fmt.Printf("\rremove file(directory) count:%d", sc.syncOption.removeCount)
+		fmt.Fprintf(sc.command.textOut(), "\rremove file(directory) count:%d", sc.syncOption.removeCount)
 		LogInfo("rename success %s %s\n", srcName, destName)
 	}
 	return err
@@ -2235,3 +2235,54 @@ func (s *OssutilCommandSuite) TestSyncUploadSubSymlinkDir(c *C) {
 	os.RemoveAll(dirName)
 	s.removeBucket(bucketName, true, c)
 }
+
+func (s *OssutilCommandSuite) TestSyncUploadLowMemory(c *C) {
+	bucketName := bucketNamePrefix + randLowStr(10)
+	s.putBucket(bucketName, c)
+
+	dirName := "testdir-lowmem-" + randLowStr(3)
+	s.prepareTestFiles(dirName, "subdir-"+randLowStr(4), "prefix1", "", 3, c)
+	s.putObject(bucketName, "prefix/extra-object", uploadFileName, c)
+
+	// the max sync numbers doesn't limit --low-memory
+	maxCount := MaxSyncNumbers
+	MaxSyncNumbers = 2
+
+	str := ""
+	cpDir := CheckpointDir
+	ok := true
+	routines := strconv.Itoa(Routines)
+	backupDir := "testdir-lowmem-backup-" + randLowStr(3)
+	options := OptionMapType{
+		"endpoint":        &str,
+		"accessKeyID":     &str,
+		"accessKeySecret": &str,
+		"configFile":      &configFile,
+		"checkpointDir":   &cpDir,
+		"routines":        &routines,
+		"delete":          &ok,
+		"force":           &ok,
+		"lowMemory":       &ok,
+	}
+	_, err := cm.RunCommand("sync", []string{dirName, CloudURLToString(bucketName, "prefix")}, options)
+	MaxSyncNumbers = maxCount
+	c.Assert(err, IsNil)
+
+	objects := s.listObjects(bucketName, "prefix/extra-object", "ls -s", c)
+	c.Assert(len(objects), Equals, 0)
+
+	// download and remove the extra local files to the backup dir
+	extraFile := dirName + string(os.PathSeparator) + "extra-file"
+	s.createFile(extraFile, "extra", c)
+	options["backupDir"] = &backupDir
+	_, err = cm.RunCommand("sync", []string{CloudURLToString(bucketName, "prefix"), dirName}, options)
+	c.Assert(err, IsNil)
+	_, err = os.Stat(extraFile)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	_, err = os.Stat(backupDir + string(os.PathSeparator) + "extra-file")
+	c.Assert(err, IsNil)
+
+	os.RemoveAll(dirName)
+	os.RemoveAll(backupDir)
+	s.removeBucket(bucketName, true, c)
+}
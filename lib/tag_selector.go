@@ -0,0 +1,162 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// tagCondition is a condition of --where-tag, anyValue means the object only needs to have the tag
+type tagCondition struct {
+	key      string
+	value    string
+	anyValue bool
+}
+
+// tagSelectorCacheSize is the max number of the cached results, the cache is cleared when it's full
+const tagSelectorCacheSize = 100000
+
+// tagSelector selects the listed objects by their tags for the batch operations, the tags are got
+// by GetObjectTagging concurrently for every page of the listing
+type tagSelector struct {
+	conditions []tagCondition
+	routines   int
+	lock       sync.Mutex
+	matched    map[string]bool // key+etag -> bool, the statistic and the producer list the same objects
+}
+
+// newTagSelector returns nil if --where-tag isn't specified
+func (cmd *Command) newTagSelector() (*tagSelector, error) {
+	str, _ := GetString(OptionWhereTag, cmd.options)
+	if str == "" {
+		return nil, nil
+	}
+
+	ts := &tagSelector{routines: Routines, matched: map[string]bool{}}
+	for _, s := range strings.Split(str, "#") {
+		pair := strings.SplitN(s, "=", 2)
+		if pair[0] == "" {
+			return nil, fmt.Errorf("invalid --where-tag: %s, the tag key is empty", str)
+		}
+		if len(pair) == 1 {
+			ts.conditions = append(ts.conditions, tagCondition{key: pair[0], anyValue: true})
+		} else {
+			ts.conditions = append(ts.conditions, tagCondition{key: pair[0], value: pair[1]})
+		}
+	}
+	if routines, err := GetInt(OptionRoutines, cmd.options); err == nil && routines > 0 {
+		ts.routines = int(routines)
+	}
+	return ts, nil
+}
+
+// matchTags returns true if the tags match all the conditions
+func (ts *tagSelector) matchTags(tags []oss.Tag) bool {
+	for _, condition := range ts.conditions {
+		found := false
+		for _, tag := range tags {
+			if tag.Key == condition.key && (condition.anyValue || tag.Value == condition.value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// selectObjects returns the listed objects which match the filters, the time window and --where-tag
+func (cmd *Command) selectObjects(bucket *oss.Bucket, objects []oss.ObjectProperties, filters []filterOptionType, options ...oss.Option) ([]oss.ObjectProperties, error) {
+	selected := make([]oss.ObjectProperties, 0, len(objects))
+	for _, object := range objects {
+		if doesSingleObjectMatchPatterns(object.Key, filters) && cmd.timeWindow.match(object.LastModified) {
+			selected = append(selected, object)
+		}
+	}
+	if cmd.tagSelector == nil || len(selected) == 0 {
+		return selected, nil
+	}
+
+	ts := cmd.tagSelector
+	matches := make([]bool, len(selected))
+	chIndex := make(chan int, len(selected))
+	for i := range selected {
+		chIndex <- i
+	}
+	close(chIndex)
+
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var ferr error
+	for i := 0; i < ts.routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range chIndex {
+				match, err := cmd.matchObjectTags(bucket, selected[index], options...)
+				if err != nil {
+					lock.Lock()
+					if ferr == nil {
+						ferr = err
+					}
+					lock.Unlock()
+					return
+				}
+				matches[index] = match
+			}
+		}()
+	}
+	wg.Wait()
+	if ferr != nil {
+		return nil, ferr
+	}
+
+	objects = selected[:0]
+	for i, object := range selected {
+		if matches[i] {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+func (cmd *Command) matchObjectTags(bucket *oss.Bucket, object oss.ObjectProperties, options ...oss.Option) (bool, error) {
+	ts := cmd.tagSelector
+	cacheKey := object.Key + "\x00" + object.ETag
+	if match, ok := ts.load(cacheKey); ok {
+		return match, nil
+	}
+
+	result, err := cmd.ossGetObjectTaggingRetry(bucket, object.Key, filterPayerOptions(options)...)
+	if err != nil {
+		// the object is removed after it's listed
+		if isNotFound(err) {
+			ts.store(cacheKey, false)
+			return false, nil
+		}
+		return false, err
+	}
+	match := ts.matchTags(result.Tags)
+	ts.store(cacheKey, match)
+	return match, nil
+}
+
+func (ts *tagSelector) load(cacheKey string) (bool, bool) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	match, ok := ts.matched[cacheKey]
+	return match, ok
+}
+
+func (ts *tagSelector) store(cacheKey string, match bool) {
+	ts.lock.Lock()
+	defer ts.lock.Unlock()
+	if len(ts.matched) >= tagSelectorCacheSize {
+		ts.matched = map[string]bool{}
+	}
+	ts.matched[cacheKey] = match
+}
@@ -0,0 +1,133 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestTagSelector(c *C) {
+	whereTag := "env=staging#team"
+	cmd := Command{options: OptionMapType{OptionWhereTag: &whereTag}}
+	ts, err := cmd.newTagSelector()
+	c.Assert(err, IsNil)
+	c.Assert(ts.matchTags([]oss.Tag{{Key: "env", Value: "staging"}, {Key: "team", Value: "a"}}), Equals, true)
+	c.Assert(ts.matchTags([]oss.Tag{{Key: "env", Value: "staging"}}), Equals, false)
+	c.Assert(ts.matchTags([]oss.Tag{{Key: "env", Value: "prod"}, {Key: "team", Value: "a"}}), Equals, false)
+
+	// the cache is cleared when it's full
+	for i := 0; i < tagSelectorCacheSize; i++ {
+		ts.matched[fmt.Sprint(i)] = true
+	}
+	ts.store("key", false)
+	c.Assert(len(ts.matched), Equals, 1)
+	match, ok := ts.load("key")
+	c.Assert(ok, Equals, true)
+	c.Assert(match, Equals, false)
+
+	whereTag = "=staging"
+	_, err = cmd.newTagSelector()
+	c.Assert(err, NotNil)
+	cmd = Command{options: OptionMapType{}}
+	ts, err = cmd.newTagSelector()
+	c.Assert(err, IsNil)
+	c.Assert(ts, IsNil)
+
+	var mu sync.Mutex
+	var operated []string
+	missingTagging := 0
+	tags := map[string]string{"tmp/a": "staging", "tmp/b": "prod", "tmp/c": "staging"}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		switch {
+		case r.Method == "GET" && query.Get("prefix") != "":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>tmp/a</Key><Size>1</Size></Contents><Contents><Key>tmp/b</Key><Size>1</Size></Contents><Contents><Key>tmp/c</Key><Size>1</Size></Contents>
+<Contents><Key>tmp/d</Key><Size>1</Size></Contents>
+</ListBucketResult>`)
+		case r.Method == "GET" && r.URL.RawQuery == "tagging":
+			key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+			if _, ok := tags[key]; !ok {
+				// the object is removed after it's listed
+				missingTagging++
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `<Error><Code>NoSuchKey</Code></Error>`)
+				return
+			}
+			fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>env</Key><Value>`+tags[key]+`</Value></Tag></TagSet></Tagging>`)
+		case r.Method == "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			for _, part := range strings.Split(string(body), "<Key>")[1:] {
+				operated = append(operated, "DELETE "+part[:strings.Index(part, "</Key>")])
+			}
+			fmt.Fprint(w, `<DeleteResult></DeleteResult>`)
+		default:
+			operated = append(operated, r.Method+" "+r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	recursive := true
+	force := true
+	routines := "2"
+	whereTag = "env=staging"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRecursion:       &recursive,
+		OptionForce:           &force,
+		OptionRoutines:        &routines,
+		OptionWhereTag:        &whereTag,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/tmp/"}, options)
+	c.Assert(err, IsNil)
+	sort.Strings(operated)
+	c.Assert(operated, DeepEquals, []string{"DELETE tmp/a", "DELETE tmp/c"})
+	// 404 isn't retried, the statistic and the removal may both request it
+	c.Assert(missingTagging <= 2, Equals, true)
+
+	// --where-tag doesn't select the multipart uploads
+	multipart := true
+	options[OptionMultipart] = &multipart
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/tmp/"}, options)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "--where-tag only work with"), Equals, true)
+	delete(options, OptionMultipart)
+
+	operated = nil
+	_, err = cm.RunCommand("set-acl", []string{"oss://bucket/tmp/", "private"}, options)
+	c.Assert(err, IsNil)
+	sort.Strings(operated)
+	c.Assert(operated, DeepEquals, []string{"PUT /bucket/tmp/a", "PUT /bucket/tmp/c"})
+
+	// --where-tag only works with --recursive
+	recursive = false
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/tmp/a"}, options)
+	c.Assert(err, NotNil)
+	_, err = cm.RunCommand("set-acl", []string{"oss://bucket/tmp/a", "private"}, options)
+	c.Assert(err, NotNil)
+}
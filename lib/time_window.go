@@ -0,0 +1,70 @@
+package lib
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// timeWindow selects the listed objects by their last modified time for --last-modified-after
+// and --last-modified-before, the zero time means there is no bound on that side
+type timeWindow struct {
+	after  time.Time
+	before time.Time
+}
+
+// newTimeWindow returns nil if neither --last-modified-after nor --last-modified-before is specified
+func (cmd *Command) newTimeWindow() (*timeWindow, error) {
+	strAfter, _ := GetString(OptionLastModifiedAfter, cmd.options)
+	strBefore, _ := GetString(OptionLastModifiedBefore, cmd.options)
+	if strAfter == "" && strBefore == "" {
+		return nil, nil
+	}
+
+	tw := &timeWindow{}
+	var err error
+	if strAfter != "" {
+		if tw.after, err = parseTimeBound(strAfter); err != nil {
+			return nil, fmt.Errorf("invalid --last-modified-after: %s", err.Error())
+		}
+	}
+	if strBefore != "" {
+		if tw.before, err = parseTimeBound(strBefore); err != nil {
+			return nil, fmt.Errorf("invalid --last-modified-before: %s", err.Error())
+		}
+	}
+	if !tw.after.IsZero() && !tw.before.IsZero() && !tw.after.Before(tw.before) {
+		return nil, fmt.Errorf("--last-modified-after %s should be earlier than --last-modified-before %s", strAfter, strBefore)
+	}
+	return tw, nil
+}
+
+// parseTimeBound parses the time of the window, it can be a date in local time, RFC3339 time,
+// http date or unix timestamp
+func parseTimeBound(str string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", str, time.Local); err == nil {
+		return t, nil
+	} else if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t, nil
+	} else if t, err := time.Parse(http.TimeFormat, str); err == nil {
+		return t, nil
+	} else if sec, err := strconv.ParseInt(str, 10, 64); err == nil && sec > 0 {
+		return time.Unix(sec, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("%s, the time should be date(2006-01-02), RFC3339, http date or unix timestamp", str)
+}
+
+// match returns true if the time is in [after, before), the nil window matches any time
+func (tw *timeWindow) match(t time.Time) bool {
+	if tw == nil {
+		return true
+	}
+	if !tw.after.IsZero() && t.Before(tw.after) {
+		return false
+	}
+	if !tw.before.IsZero() && !t.Before(tw.before) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestTimeWindow(c *C) {
+	after := "2022-01-01"
+	before := "2022-02-01T00:00:00Z"
+	cmd := Command{options: OptionMapType{OptionLastModifiedAfter: &after, OptionLastModifiedBefore: &before}}
+	tw, err := cmd.newTimeWindow()
+	c.Assert(err, IsNil)
+	c.Assert(tw.match(time.Date(2022, 1, 15, 0, 0, 0, 0, time.UTC)), Equals, true)
+	c.Assert(tw.match(time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)), Equals, false)
+	c.Assert(tw.match(time.Date(2021, 12, 1, 0, 0, 0, 0, time.UTC)), Equals, false)
+
+	after = "1643673600"
+	_, err = cmd.newTimeWindow()
+	c.Assert(err, NotNil)
+	after = "yesterday"
+	_, err = cmd.newTimeWindow()
+	c.Assert(err, NotNil)
+	cmd = Command{options: OptionMapType{}}
+	tw, err = cmd.newTimeWindow()
+	c.Assert(err, IsNil)
+	c.Assert(tw, IsNil)
+	c.Assert(tw.match(time.Now()), Equals, true)
+
+	var mu sync.Mutex
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case "GET":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<Contents><Key>logs/a</Key><Size>1</Size><LastModified>2021-06-01T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>logs/b</Key><Size>2</Size><LastModified>2021-12-31T00:00:00.000Z</LastModified></Contents>
+<Contents><Key>logs/c</Key><Size>4</Size><LastModified>2022-03-01T00:00:00.000Z</LastModified></Contents>
+</ListBucketResult>`)
+		case "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			for _, part := range strings.Split(string(body), "<Key>")[1:] {
+				deleted = append(deleted, part[:strings.Index(part, "</Key>")])
+			}
+			fmt.Fprint(w, `<DeleteResult></DeleteResult>`)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	before = "2022-01-01T00:00:00Z"
+	options := OptionMapType{
+		OptionEndpoint:           &endpoint,
+		OptionAccessKeyID:        &str,
+		OptionAccessKeySecret:    &str,
+		OptionForcePathStyle:     &forcePathStyle,
+		OptionLastModifiedBefore: &before,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	limitedNum := "10"
+	options[OptionLimitedNum] = &limitedNum
+	_, err = cm.RunCommand("ls", []string{"oss://bucket/logs/"}, options)
+	c.Assert(err, IsNil)
+	output := s.readFile(resultPath, c)
+	c.Assert(strings.Contains(output, "oss://bucket/logs/a"), Equals, true)
+	c.Assert(strings.Contains(output, "oss://bucket/logs/b"), Equals, true)
+	c.Assert(strings.Contains(output, "oss://bucket/logs/c"), Equals, false)
+
+	// the time window only works with --recursive
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/logs/a"}, options)
+	c.Assert(err, NotNil)
+
+	delete(options, OptionLimitedNum)
+	recursive := true
+	force := true
+	dryRun := true
+	options[OptionRecursion] = &recursive
+	options[OptionForce] = &force
+	options[OptionDryRun] = &dryRun
+	testResultFile.Truncate(0)
+	testResultFile.Seek(0, 0)
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/logs/"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(deleted, IsNil)
+	output = s.readFile(resultPath, c)
+	c.Assert(strings.Contains(output, "oss://bucket/logs/b\n"), Equals, true)
+	c.Assert(strings.Contains(output, "2 objects(3 bytes) would be removed"), Equals, true)
+
+	delete(options, OptionDryRun)
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/logs/"}, options)
+	c.Assert(err, IsNil)
+	sort.Strings(deleted)
+	c.Assert(deleted, DeepEquals, []string{"logs/a", "logs/b"})
+}
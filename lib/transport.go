@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	"golang.org/x/time/rate"
+)
+
+// newRequestLimiter returns the limiter of --max-qps, the burst is one second of requests, so that
+// the workers starting together don't wait for each other. It's nil without --max-qps
+func newRequestLimiter(options OptionMapType) (*rate.Limiter, error) {
+	maxQPS, err := GetInt(OptionMaxQPS, options)
+	if err != nil {
+		return nil, nil
+	}
+	if maxQPS <= 0 {
+		return nil, fmt.Errorf("invalid --max-qps: %d, the value should be positive", maxQPS)
+	}
+	return rate.NewLimiter(rate.Limit(maxQPS), int(maxQPS)), nil
+}
+
+// initRequestLimiter creates the limiter of --max-qps of the command, it's shared by all the
+// clients of the command, so that the requests of all the workers are limited together, the
+// commands run by daemon at the same time have their own limiters
+func (cmd *Command) initRequestLimiter() error {
+	limiter, err := newRequestLimiter(cmd.options)
+	if err != nil {
+		return err
+	}
+	cmd.requestLimiter = limiter
+	return nil
+}
+
+// transportOptions returns the client options of --max-idle-conns, --max-conns-per-host,
+// --idle-conn-timeout, --http2 and --max-qps, they must be applied after the other client options
+func (cmd *Command) transportOptions() ([]oss.ClientOption, error) {
+	options := []oss.ClientOption{}
+	if maxIdleConns, err := GetInt(OptionMaxIdleConns, cmd.options); err == nil {
+		// ossutil mostly talks to one host, so the idle connections per host are the same
+		options = append(options, func(client *oss.Client) {
+			client.Config.HTTPMaxConns.MaxIdleConns = int(maxIdleConns)
+			client.Config.HTTPMaxConns.MaxIdleConnsPerHost = int(maxIdleConns)
+		})
+	}
+	if maxConnsPerHost, err := GetInt(OptionMaxConnsPerHost, cmd.options); err == nil {
+		options = append(options, func(client *oss.Client) {
+			client.Config.HTTPMaxConns.MaxConnsPerHost = int(maxConnsPerHost)
+		})
+	}
+	if idleConnTimeout, err := GetInt(OptionIdleConnTimeout, cmd.options); err == nil {
+		// oss.Timeout sets the idle timeout to the read timeout, it's overridden here
+		options = append(options, func(client *oss.Client) {
+			client.Config.HTTPTimeout.IdleConnTimeout = time.Duration(idleConnTimeout) * time.Second
+		})
+	}
+	if http2, _ := GetBool(OptionHTTP2, cmd.options); http2 {
+		LogInfo("use http/2 if the server supports it\n")
+		options = append(options, func(client *oss.Client) {
+			client.HTTPClient = newHTTPClient(client.Config)
+		})
+	}
+	limiter := cmd.requestLimiter
+	if limiter == nil {
+		// the command is not initialized by Init
+		var err error
+		if limiter, err = newRequestLimiter(cmd.options); err != nil {
+			return nil, err
+		}
+	}
+	if limiter != nil {
+		ctx := cmd.context()
+		options = append(options, func(client *oss.Client) {
+			client.Config.CredentialsProvider = &limitedCredentials{provider: client.Config.CredentialsProvider, limiter: limiter, ctx: ctx}
+		})
+	}
+	return options, nil
+}
+
+// limitedCredentials waits for the limiter of --max-qps when the sdk gets the credentials to sign
+// every request, including the retries of the sdk, so that the requests are signed after waiting
+type limitedCredentials struct {
+	provider oss.CredentialsProvider
+	limiter  *rate.Limiter
+	ctx      context.Context
+}
+
+// GetCredentials is used by the signed urls, they are not limited
+func (p *limitedCredentials) GetCredentials() oss.Credentials {
+	return p.provider.GetCredentials()
+}
+
+func (p *limitedCredentials) GetCredentialsE() (oss.Credentials, error) {
+	if err := p.limiter.Wait(p.ctx); err != nil {
+		return nil, err
+	}
+	if provider, ok := p.provider.(oss.CredentialsProviderE); ok {
+		return provider.GetCredentialsE()
+	}
+	return p.provider.GetCredentials(), nil
+}
+
+// newHTTPClient creates the http client used instead of the one of the sdk for --http2. The
+// transport of the sdk dials the connections by itself, which disables http/2. With http/2 the
+// read timeout only limits the time waiting for the response header, since the connections are
+// shared by the requests
+func newHTTPClient(config *oss.Config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   config.HTTPTimeout.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if config.LocalAddr != nil {
+		dialer.LocalAddr = config.LocalAddr
+	}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          config.HTTPMaxConns.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.HTTPMaxConns.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.HTTPMaxConns.MaxConnsPerHost,
+		IdleConnTimeout:       config.HTTPTimeout.IdleConnTimeout,
+		ResponseHeaderTimeout: config.HTTPTimeout.HeaderTimeout,
+		ForceAttemptHTTP2:     true,
+		TLSClientConfig:       &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify},
+	}
+	if config.IsUseProxy {
+		proxyURL, err := url.Parse(config.ProxyHost)
+		if err == nil && config.IsAuthProxy {
+			if config.ProxyPassword != "" {
+				proxyURL.User = url.UserPassword(config.ProxyUser, config.ProxyPassword)
+			} else {
+				proxyURL.User = url.User(config.ProxyUser)
+			}
+		}
+		// the invalid proxy is reported by the requests
+		transport.Proxy = func(*http.Request) (*url.URL, error) {
+			return proxyURL, err
+		}
+	}
+
+	client := &http.Client{Transport: transport}
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}
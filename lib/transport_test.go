@@ -0,0 +1,149 @@
+package lib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestTransportOptions(c *C) {
+	protos := make(chan int, 10)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protos <- r.ProtoMajor
+		w.Header().Set("Content-Length", "3")
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	str := "ak"
+	maxIdleConns := "500"
+	maxConnsPerHost := "200"
+	idleConnTimeout := "30"
+	bTrue := true
+	bFalse := false
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionSkipVerifyCert:  &bTrue,
+		OptionForcePathStyle:  &bTrue,
+		OptionMaxIdleConns:    &maxIdleConns,
+		OptionMaxConnsPerHost: &maxConnsPerHost,
+		OptionIdleConnTimeout: &idleConnTimeout,
+		OptionHTTP2:           &bFalse,
+	}
+	cmd := Command{options: options, configOptions: OptionMapType{}}
+	client, err := cmd.ossClient("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.HTTPMaxConns.MaxIdleConns, Equals, 500)
+	c.Assert(client.Config.HTTPMaxConns.MaxIdleConnsPerHost, Equals, 500)
+	c.Assert(client.Config.HTTPMaxConns.MaxConnsPerHost, Equals, 200)
+	c.Assert(client.Config.HTTPTimeout.IdleConnTimeout, Equals, 30*time.Second)
+	c.Assert(client.HTTPClient, IsNil)
+
+	// the transport of the sdk does not negotiate http/2
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(<-protos, Equals, 1)
+
+	// --http2
+	options[OptionHTTP2] = &bTrue
+	cmd = Command{options: options, configOptions: OptionMapType{}}
+	client, err = cmd.ossClient("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(client.HTTPClient, NotNil)
+	transport, ok := client.HTTPClient.Transport.(*http.Transport)
+	c.Assert(ok, Equals, true)
+	c.Assert(transport.MaxIdleConns, Equals, 500)
+	c.Assert(transport.MaxConnsPerHost, Equals, 200)
+	c.Assert(transport.IdleConnTimeout, Equals, 30*time.Second)
+
+	bucket, err = client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectMeta("object")
+	c.Assert(err, IsNil)
+	c.Assert(<-protos, Equals, 2)
+}
+
+func (s *OssutilCommandSuite) TestMaxQPS(c *C) {
+	var requests int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		w.Header().Set("Content-Length", "3")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	str := "ak"
+	maxQPS := "0"
+	bTrue := true
+	options := OptionMapType{
+		OptionEndpoint:        &server.URL,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &bTrue,
+		OptionMaxQPS:          &maxQPS,
+	}
+	cmd := Command{options: options, configOptions: OptionMapType{}}
+	c.Assert(cmd.initRequestLimiter(), NotNil)
+	_, err := cmd.ossClient("bucket")
+	c.Assert(err, NotNil)
+
+	maxQPS = "5"
+	c.Assert(cmd.initRequestLimiter(), IsNil)
+	c.Assert(cmd.requestLimiter.Burst(), Equals, 5)
+
+	// the requests of all the workers of the command are limited together, the burst is one second
+	// of requests, the other command has its own limiter
+	other := Command{options: options, configOptions: OptionMapType{}}
+	c.Assert(other.initRequestLimiter(), IsNil)
+	c.Assert(other.requestLimiter == cmd.requestLimiter, Equals, false)
+	getMeta := func(cmd *Command, times int) {
+		client, err := cmd.ossClient("bucket")
+		c.Assert(err, IsNil)
+		bucket, err := client.Bucket("bucket")
+		c.Assert(err, IsNil)
+		var wg sync.WaitGroup
+		for i := 0; i < times; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				bucket.GetObjectMeta("object")
+			}()
+		}
+		wg.Wait()
+	}
+	start := time.Now()
+	getMeta(&other, 5)
+	getMeta(&cmd, 5)
+	c.Assert(time.Since(start) < 500*time.Millisecond, Equals, true)
+	getMeta(&cmd, 5)
+	c.Assert(atomic.LoadInt64(&requests), Equals, int64(15))
+	c.Assert(time.Since(start) >= 800*time.Millisecond, Equals, true)
+
+	// the limiter waits before the credentials are got to sign the request
+	client, err := cmd.ossClient("bucket")
+	c.Assert(err, IsNil)
+	provider, ok := client.Config.CredentialsProvider.(*limitedCredentials)
+	c.Assert(ok, Equals, true)
+	c.Assert(provider.limiter == cmd.requestLimiter, Equals, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	provider.ctx = ctx
+	for i := 0; i < 5; i++ {
+		provider.limiter.Allow()
+	}
+	_, err = provider.GetCredentialsE()
+	c.Assert(err, NotNil)
+	c.Assert(provider.GetCredentials().GetAccessKeyID(), Equals, "ak")
+}
@@ -0,0 +1,652 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+var specChineseTrash = SpecText{
+	synopsisText: "恢复或者清空rm --to-trash移入回收站的objects",
+
+	paramText: "restore|purge cloud_url [options]",
+
+	syntaxText: `
+    ossutil trash restore oss://bucket/object [-r] [-f] [--trash-date date] [--trash-prefix prefix] [-j num]
+    ossutil trash purge oss://bucket [--trash-date date] [--trash-prefix prefix] [-y]
+`,
+
+	detailHelpText: `
+    rm命令指定--to-trash选项时，ossutil不直接删除objects，而是通过服务端拷贝将它们移动到同一bucket的
+    回收站prefix（默认为.trash/）下，key为：<回收站prefix><日期>/<原key>，日期的格式为2006-01-02。
+    rm命令指定--trash-days选项时，ossutil为回收站prefix设置id为ossutil-trash的生命周期规则，
+    回收站中的objects在指定天数后被oss自动删除，bucket已有的其他生命周期规则保持不变。
+    注意：移动object时不会保留object的acl。
+
+    trash命令的第一个参数为子命令：
+
+    1) restore: 将回收站中的object移回原key，cloud_url为object原来的key，指定--recursive时为原来的prefix。
+        同一个key在多个日期被删除时恢复最近一次删除的object，指定--trash-date时只恢复该日期删除的objects。
+        默认不覆盖已经存在的object，该object保留在回收站中，指定--force选项则覆盖。
+
+    2) purge: 删除回收站中的objects，cloud_url为bucket，指定--trash-date时只删除该日期删除的objects。
+        删除前显示objects的数量和字节数并询问提示，指定--assume-yes(-y)选项则不进行询问提示。
+`,
+
+	sampleText: `
+    1) 删除object到回收站，回收站中的objects在7天后被自动删除
+       ossutil rm oss://bucket/dir/a.txt --to-trash --trash-days 7
+
+    2) 恢复回收站中的object
+       ossutil trash restore oss://bucket/dir/a.txt
+
+    3) 恢复回收站中2024-01-02删除的prefix为dir/的objects
+       ossutil trash restore oss://bucket/dir/ -r --trash-date 2024-01-02
+
+    4) 清空回收站
+       ossutil trash purge oss://bucket
+`,
+}
+
+var specEnglishTrash = SpecText{
+	synopsisText: "Restore or purge the objects moved to the trash by rm --to-trash",
+
+	paramText: "restore|purge cloud_url [options]",
+
+	syntaxText: `
+    ossutil trash restore oss://bucket/object [-r] [-f] [--trash-date date] [--trash-prefix prefix] [-j num]
+    ossutil trash purge oss://bucket [--trash-date date] [--trash-prefix prefix] [-y]
+`,
+
+	detailHelpText: `
+    If --to-trash option of rm command is specified, ossutil doesn't delete the objects directly,
+    but moves them by server side copy into the trash prefix(.trash/ by default) of the same bucket,
+    the key is: <trash prefix><date>/<original key>, the format of the date is 2006-01-02.
+    If --trash-days option of rm command is specified, ossutil sets the lifecycle rule whose id is
+    ossutil-trash for the trash prefix, the objects in the trash are deleted by oss automatically
+    after the days, the other lifecycle rules of the bucket are kept.
+    Note: the acl of the object is not kept when it's moved.
+
+    The first parameter of trash command is the sub command:
+
+    1) restore: move the object in the trash back to the original key, cloud_url is the original key
+        of the object, or the original prefix if --recursive is specified. If the same key is removed
+        on multiple dates, the latest removed object is restored. If --trash-date is specified, only
+        the objects removed on the date are restored. The existing object isn't overwritten by default,
+        the object in the trash is kept, specify --force option to overwrite it.
+
+    2) purge: delete the objects in the trash, cloud_url is the bucket. If --trash-date is specified,
+        only the objects removed on the date are deleted. ossutil shows the number and the bytes of
+        the objects and asks user to confirm before deleting, unless --assume-yes(-y) is specified.
+`,
+
+	sampleText: `
+    1) remove the object to the trash, the objects in the trash are deleted after 7 days
+       ossutil rm oss://bucket/dir/a.txt --to-trash --trash-days 7
+
+    2) restore the object in the trash
+       ossutil trash restore oss://bucket/dir/a.txt
+
+    3) restore the objects with prefix dir/ removed on 2024-01-02
+       ossutil trash restore oss://bucket/dir/ -r --trash-date 2024-01-02
+
+    4) empty the trash
+       ossutil trash purge oss://bucket
+`,
+}
+
+const (
+	defaultTrashPrefix   = ".trash/"
+	trashDateFormat      = "2006-01-02"
+	trashLifecycleRuleID = "ossutil-trash"
+	maxCopyObjectSize    = 1024 * 1024 * 1024
+	trashCopyPartSize    = 100 * 1024 * 1024
+)
+
+var trashRuleRegexp = regexp.MustCompile(`<Rule>\s*<ID>` + trashLifecycleRuleID + `</ID>(?s:.*?)</Rule>`)
+
+type TrashCommand struct {
+	command     Command
+	bucket      *oss.Bucket
+	trashPrefix string
+	routines    int64
+}
+
+var trashCommand = TrashCommand{
+	command: Command{
+		name:      "trash",
+		nameAlias: []string{"trash"},
+		minArgc:   2,
+		maxArgc:   2,
+		group:     GroupTypeAdditionalCommand,
+		validOptionNames: []string{
+			OptionConfigFile,
+			OptionEndpoint,
+			OptionAccessKeyID,
+			OptionAccessKeySecret,
+			OptionSTSToken,
+			OptionProxyHost,
+			OptionProxyUser,
+			OptionProxyPwd,
+			OptionRecursion,
+			OptionForce,
+			OptionTrashPrefix,
+			OptionTrashDate,
+			OptionRoutines,
+			OptionRetryTimes,
+			OptionAssumeYes,
+			OptionLogLevel,
+			OptionPassword,
+			OptionMode,
+			OptionECSRoleName,
+			OptionTokenTimeout,
+			OptionRamRoleArn,
+			OptionRoleSessionName,
+			OptionReadTimeout,
+			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
+			OptionSTSRegion,
+			OptionSkipVerifyCert,
+			OptionUserAgent,
+			OptionSignVersion,
+			OptionRegion,
+			OptionCloudBoxID,
+			OptionForcePathStyle,
+		},
+	},
+}
+
+// function for FormatHelper interface
+func (tc *TrashCommand) formatHelpForWhole() string {
+	return tc.command.formatHelpForWhole()
+}
+
+func (tc *TrashCommand) formatIndependHelp() string {
+	return tc.command.formatIndependHelp()
+}
+
+// Init simulate inheritance, and polymorphism
+func (tc *TrashCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return tc.command.Init(ctx, args, options, tc)
+}
+
+// RunCommand simulate inheritance, and polymorphism
+func (tc *TrashCommand) RunCommand() error {
+	action := strings.ToLower(tc.command.args[0])
+	if action != "restore" && action != "purge" {
+		return fmt.Errorf("the sub command %s is not in the optional value:restore|purge", tc.command.args[0])
+	}
+
+	cloudURL, err := GetCloudUrl(tc.command.args[1], "")
+	if err != nil {
+		return err
+	}
+	if tc.trashPrefix, err = tc.command.getTrashPrefix(); err != nil {
+		return err
+	}
+	date, _ := GetString(OptionTrashDate, tc.command.options)
+	if date != "" {
+		if _, err := time.Parse(trashDateFormat, date); err != nil {
+			return fmt.Errorf("invalid --trash-date: %s, the format should be 2006-01-02", date)
+		}
+	}
+	if tc.routines, err = GetInt(OptionRoutines, tc.command.options); err != nil || tc.routines <= 0 {
+		tc.routines = int64(Routines)
+	}
+
+	if tc.bucket, err = tc.command.cloudBucket(*cloudURL); err != nil {
+		return err
+	}
+
+	if action == "purge" {
+		if cloudURL.object != "" {
+			return fmt.Errorf("the cloud url of purge should be oss://bucket")
+		}
+		return tc.purge(date)
+	}
+
+	recursive, _ := GetBool(OptionRecursion, tc.command.options)
+	if cloudURL.object == "" && !recursive {
+		return fmt.Errorf("the object is empty, please use --recursive option to restore the objects of the bucket")
+	}
+	if strings.HasPrefix(cloudURL.object, tc.trashPrefix) {
+		return fmt.Errorf("%s is in the trash, the cloud url should be the original key", cloudURL.ToString())
+	}
+	return tc.restore(cloudURL.object, date, recursive)
+}
+
+// restore moves the objects in the trash back to the original keys, the dates are processed from the
+// latest so that the latest removed object is restored and the older ones are kept in the trash, with
+// --force the dates are processed from the earliest so that the latest removed object is restored at last
+func (tc *TrashCommand) restore(object, date string, recursive bool) error {
+	dates := []string{date}
+	if date == "" {
+		var err error
+		if dates, err = tc.command.listTrashDates(tc.bucket, tc.trashPrefix); err != nil {
+			return err
+		}
+	}
+	force, _ := GetBool(OptionForce, tc.command.options)
+	options := []oss.Option{}
+	if force {
+		sort.Strings(dates)
+	} else {
+		sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+		options = append(options, oss.ForbidOverWrite(true))
+	}
+
+	var restored, skipped int64
+	var ferr error
+	for _, date := range dates {
+		trashDir := tc.trashPrefix + date + "/"
+		if !recursive {
+			props, err := tc.bucket.GetObjectDetailedMeta(trashDir + object)
+			if err != nil {
+				if serviceError, ok := err.(oss.ServiceError); ok && serviceError.StatusCode == http.StatusNotFound {
+					continue
+				}
+				return err
+			}
+			size, _ := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+			err = tc.restoreObject(trashDir+object, object, size, options)
+			if isFileAlreadyExists(err) {
+				return fmt.Errorf("%s already exists, the object is kept in the trash, use --force option to overwrite it", CloudURLToString(tc.bucket.BucketName, object))
+			}
+			if err == nil {
+				restored++
+			}
+			ferr = err
+			break
+		}
+
+		err := tc.command.forEachObject(tc.bucket, trashDir+object, tc.routines, func(key string, size int64) error {
+			err := tc.restoreObject(key, strings.TrimPrefix(key, trashDir), size, options)
+			if isFileAlreadyExists(err) {
+				LogInfo("%s already exists, skip restoring %s\n", strings.TrimPrefix(key, trashDir), key)
+				atomic.AddInt64(&skipped, 1)
+				return nil
+			}
+			if err != nil {
+				fmt.Printf("restore %s error: %s\n", CloudURLToString(tc.bucket.BucketName, key), err.Error())
+				return err
+			}
+			atomic.AddInt64(&restored, 1)
+			return nil
+		})
+		if err != nil {
+			ferr = err
+		}
+	}
+
+	if restored == 0 && skipped == 0 && ferr == nil {
+		return fmt.Errorf("no object of %s is found in the trash", CloudURLToString(tc.bucket.BucketName, object))
+	}
+	fmt.Printf("restored %d objects, skipped %d existing objects.\n", restored, skipped)
+	return ferr
+}
+
+func (tc *TrashCommand) restoreObject(trashKey, object string, size int64, options []oss.Option) error {
+	if err := tc.command.moveObject(tc.bucket, trashKey, object, size, options...); err != nil {
+		return err
+	}
+	LogInfo("restore %s to %s\n", trashKey, object)
+	return nil
+}
+
+// purge deletes the objects in the trash after the confirmation
+func (tc *TrashCommand) purge(date string) error {
+	prefix := tc.trashPrefix
+	if date != "" {
+		prefix += date + "/"
+	}
+	cloudURL := CloudURL{bucket: tc.bucket.BucketName, object: prefix}
+	if !tc.command.assumeYes() {
+		summary := tc.command.objectsSummary(tc.bucket, cloudURL, nil)
+		if !tc.command.confirmOperation(fmt.Sprintf("purge the trash %s(%s)", cloudURL.ToString(), summary)) {
+			return nil
+		}
+	}
+
+	var num int64
+	pre := oss.Prefix(prefix)
+	marker := oss.Marker("")
+	for {
+		lor, err := tc.command.ossListObjectsRetry(tc.bucket, marker, pre, oss.MaxKeys(1000))
+		if err != nil {
+			return err
+		}
+		keys := []string{}
+		for _, object := range lor.Objects {
+			keys = append(keys, object.Key)
+		}
+		if len(keys) > 0 {
+			if _, err := tc.bucket.DeleteObjects(keys, oss.DeleteObjectsQuiet(true)); err != nil {
+				return err
+			}
+			num += int64(len(keys))
+		}
+
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	fmt.Printf("purged %d objects of %s.\n", num, cloudURL.ToString())
+	return nil
+}
+
+// getTrashPrefix returns the trash prefix of --trash-prefix, which ends with "/"
+func (cmd *Command) getTrashPrefix() (string, error) {
+	prefix, _ := GetString(OptionTrashPrefix, cmd.options)
+	if prefix == "" {
+		return defaultTrashPrefix, nil
+	}
+	if strings.HasPrefix(prefix, "/") {
+		return "", fmt.Errorf("invalid --trash-prefix: %s, it can't start with '/'", prefix)
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix, nil
+}
+
+// listTrashDates returns the dates of the objects in the trash
+func (cmd *Command) listTrashDates(bucket *oss.Bucket, trashPrefix string) ([]string, error) {
+	dates := []string{}
+	marker := oss.Marker("")
+	for {
+		lor, err := cmd.ossListObjectsRetry(bucket, marker, oss.Prefix(trashPrefix), oss.Delimiter("/"))
+		if err != nil {
+			return nil, err
+		}
+		for _, prefix := range lor.CommonPrefixes {
+			date := strings.TrimSuffix(strings.TrimPrefix(prefix, trashPrefix), "/")
+			if _, err := time.Parse(trashDateFormat, date); err == nil {
+				dates = append(dates, date)
+			}
+		}
+
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	return dates, nil
+}
+
+// forEachObject lists the objects under the prefix and calls fn for them in the routines,
+// it returns the first error after all the listed objects are processed
+func (cmd *Command) forEachObject(bucket *oss.Bucket, prefix string, routines int64, fn func(key string, size int64) error) error {
+	type objectInfo struct {
+		key  string
+		size int64
+	}
+	chObjects := make(chan objectInfo, ChannelBuf)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var ferr error
+	for i := int64(0); i < routines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range chObjects {
+				if err := fn(object.key, object.size); err != nil {
+					mu.Lock()
+					if ferr == nil {
+						ferr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	err := cmd.listObjectSizesWithKey(bucket, prefix, func(key string, size int64) {
+		chObjects <- objectInfo{key, size}
+	})
+	close(chObjects)
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	return ferr
+}
+
+// listObjectSizesWithKey lists all the objects under the prefix
+func (cmd *Command) listObjectSizesWithKey(bucket *oss.Bucket, prefix string, fn func(key string, size int64)) error {
+	pre := oss.Prefix(prefix)
+	marker := oss.Marker("")
+	for {
+		lor, err := cmd.ossListObjectsRetry(bucket, marker, pre)
+		if err != nil {
+			return err
+		}
+		for _, object := range lor.Objects {
+			fn(object.Key, object.Size)
+		}
+
+		marker = oss.Marker(lor.NextMarker)
+		if !lor.IsTruncated {
+			break
+		}
+	}
+	return nil
+}
+
+// moveObject copies the object to the destination key by server side copy, then deletes it,
+// the objects larger than 1GB are copied by parts
+func (cmd *Command) moveObject(bucket *oss.Bucket, srcKey, destKey string, size int64, options ...oss.Option) error {
+	multipart := size >= maxCopyObjectSize
+	copyOptions, err := cmd.copyAttrOptions(bucket, srcKey, multipart, options)
+	if err != nil {
+		return err
+	}
+	copyOptions = cmd.withContext(copyOptions)
+
+	retryTimes, _ := GetInt(OptionRetryTimes, cmd.options)
+	for i := 1; ; i++ {
+		if multipart {
+			err = bucket.CopyFile(bucket.BucketName, srcKey, destKey, trashCopyPartSize, copyOptions...)
+		} else {
+			_, err = bucket.CopyObject(srcKey, destKey, copyOptions...)
+		}
+		if err == nil {
+			break
+		}
+
+		// http 4XX error no need to retry
+		// only network error or internal error need to retry
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucket.BucketName, srcKey}
+		}
+
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+
+	deleteOptions := cmd.withContext(filterPayerOptions(options))
+	for i := 1; ; i++ {
+		err := bucket.DeleteObject(srcKey, deleteOptions...)
+		if err == nil {
+			return nil
+		}
+
+		serviceError, noNeedRetry := err.(oss.ServiceError)
+		if int64(i) >= retryTimes || (noNeedRetry && serviceError.StatusCode < 500) {
+			return ObjectError{err, bucket.BucketName, srcKey}
+		}
+
+		if err := cmd.waitRetry(time.Duration(1) * time.Second); err != nil {
+			return err
+		}
+	}
+}
+
+// copyAttrOptions returns the copy options which keep the acl and the storage class of the object,
+// oss doesn't copy the acl, and if multipart is true, the content headers, the user metadata and
+// the tags are kept too, because the multipart copy only copies the data
+func (cmd *Command) copyAttrOptions(bucket *oss.Bucket, object string, multipart bool, options []oss.Option) ([]oss.Option, error) {
+	payerOptions := filterPayerOptions(options)
+	copyOptions := append([]oss.Option{}, options...)
+
+	acl, err := cmd.ossGetObjectACLRetry(bucket, object, payerOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if acl.ACL != "" && acl.ACL != "default" {
+		copyOptions = append(copyOptions, oss.ObjectACL(oss.ACLType(acl.ACL)))
+	}
+
+	props, err := cmd.ossGetObjectStatRetry(bucket, object, payerOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if storageClass := props.Get(oss.HTTPHeaderOssStorageClass); storageClass != "" {
+		copyOptions = append(copyOptions, oss.ObjectStorageClass(oss.StorageClassType(storageClass)))
+	}
+	if !multipart {
+		return copyOptions, nil
+	}
+
+	copyOptions = append(copyOptions, objectMetaOptions(props)...)
+	tagging, err := cmd.ossGetObjectTaggingRetry(bucket, object, payerOptions...)
+	if err != nil {
+		return nil, err
+	}
+	if len(tagging.Tags) > 0 {
+		copyOptions = append(copyOptions, oss.SetTagging(oss.Tagging{Tags: tagging.Tags}))
+	}
+	return copyOptions, nil
+}
+
+// filterPayerOptions returns the options which also work for deleting the object
+func filterPayerOptions(options []oss.Option) []oss.Option {
+	payerOptions := []oss.Option{}
+	if payer, err := oss.FindOption(options, oss.HTTPHeaderOssRequester, nil); err == nil && payer != nil {
+		payerOptions = append(payerOptions, oss.RequestPayer(oss.PayerType(payer.(string))))
+	}
+	return payerOptions
+}
+
+// ensureTrashLifecycle sets the lifecycle rule which expires the objects in the trash after the days,
+// the other rules of the bucket are kept
+func (cmd *Command) ensureTrashLifecycle(bucket *oss.Bucket, trashPrefix string, days int64) error {
+	var prefix bytes.Buffer
+	xml.EscapeText(&prefix, []byte(trashPrefix))
+	rule := fmt.Sprintf("<Rule><ID>%s</ID><Prefix>%s</Prefix><Status>Enabled</Status><Expiration><Days>%d</Days></Expiration></Rule>",
+		trashLifecycleRuleID, prefix.String(), days)
+
+	config, err := bucket.Client.GetBucketLifecycleXml(bucket.BucketName)
+	if err != nil {
+		if serviceError, ok := err.(oss.ServiceError); !ok || serviceError.Code != "NoSuchLifecycle" {
+			return BucketError{err, bucket.BucketName}
+		}
+		config = "<LifecycleConfiguration></LifecycleConfiguration>"
+	}
+	if loc := trashRuleRegexp.FindStringIndex(config); loc != nil {
+		config = config[:loc[0]] + rule + config[loc[1]:]
+	} else {
+		index := strings.LastIndex(config, "</LifecycleConfiguration>")
+		if index < 0 {
+			return fmt.Errorf("invalid lifecycle configuration of bucket %s", bucket.BucketName)
+		}
+		config = config[:index] + rule + config[index:]
+	}
+	if err := bucket.Client.SetBucketLifecycleXml(bucket.BucketName, config); err != nil {
+		return BucketError{err, bucket.BucketName}
+	}
+	LogInfo("set lifecycle rule %s of bucket %s, prefix:%s, days:%d\n", trashLifecycleRuleID, bucket.BucketName, trashPrefix, days)
+	return nil
+}
+
+// trashObjects moves the objects to the trash instead of deleting them
+func (rc *RemoveCommand) trashObjects(bucket *oss.Bucket, cloudURL CloudURL) error {
+	if rc.rmOption.trashDays > 0 {
+		if err := rc.command.ensureTrashLifecycle(bucket, rc.rmOption.trashPrefix, rc.rmOption.trashDays); err != nil {
+			return err
+		}
+	}
+	trashDir := rc.rmOption.trashPrefix + time.Now().Format(trashDateFormat) + "/"
+
+	if !rc.rmOption.recursive {
+		if strings.HasPrefix(cloudURL.object, rc.rmOption.trashPrefix) {
+			return fmt.Errorf("%s is in the trash already, please use trash purge command to delete it", cloudURL.ToString())
+		}
+		props, err := rc.command.ossGetObjectStatRetry(bucket, cloudURL.object, rc.commonOptions...)
+		if err != nil {
+			return err
+		}
+		rc.monitor.updateScanNum(1)
+		size, _ := strconv.ParseInt(props.Get(oss.HTTPHeaderContentLength), 10, 64)
+		err = rc.command.moveObject(bucket, cloudURL.object, trashDir+cloudURL.object, size, rc.commonOptions...)
+		rc.rmOption.failed.record(cloudURL.object, err)
+		if err != nil {
+			rc.updateObjectMonitor(0, 1)
+			rc.monitor.setOP(0)
+			return err
+		}
+		rc.updateObjectMonitor(1, 0)
+		return nil
+	}
+
+	return rc.runDeleteTasks(func(submit func(task func() error) bool) error {
+		pre := oss.Prefix(cloudURL.object)
+		marker := oss.Marker("")
+		for {
+			listOptions := append(rc.commonOptions, marker, pre, oss.MaxKeys(1000))
+			lor, err := rc.command.ossListObjectsRetry(bucket, listOptions...)
+			if err != nil {
+				return err
+			}
+
+			objects, err := rc.command.selectObjects(bucket, lor.Objects, rc.filters, rc.commonOptions...)
+			if err != nil {
+				return err
+			}
+			for _, object := range objects {
+				if strings.HasPrefix(object.Key, rc.rmOption.trashPrefix) {
+					continue
+				}
+				if err := rc.command.safetyCap.take(object.Size); err != nil {
+					return err
+				}
+				key, size := object.Key, object.Size
+				if !submit(func() error {
+					err := rc.command.moveObject(bucket, key, trashDir+key, size, rc.commonOptions...)
+					if err != nil {
+						rc.rmOption.failed.record(key, err)
+						rc.updateObjectMonitor(0, 1)
+						return rc.deleteTaskError(err)
+					}
+					rc.updateObjectMonitor(1, 0)
+					return nil
+				}) {
+					return nil
+				}
+			}
+
+			marker = oss.Marker(lor.NextMarker)
+			if !lor.IsTruncated {
+				break
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,199 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	oss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestTrash(c *C) {
+	var mu sync.Mutex
+	var operated []string
+	lifecycle := ""
+	existing := map[string]bool{}
+	copyACL := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query := r.URL.Query()
+		switch {
+		case r.Method == "GET" && r.URL.RawQuery == "lifecycle":
+			if lifecycle == "" {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `<Error><Code>NoSuchLifecycle</Code></Error>`)
+				return
+			}
+			fmt.Fprint(w, lifecycle)
+		case r.Method == "PUT" && r.URL.RawQuery == "lifecycle":
+			body, _ := ioutil.ReadAll(r.Body)
+			lifecycle = string(body)
+		case r.Method == "GET" && query.Get("delimiter") == "/":
+			fmt.Fprint(w, `<ListBucketResult><IsTruncated>false</IsTruncated>
+<CommonPrefixes><Prefix>.trash/2024-01-01/</Prefix></CommonPrefixes><CommonPrefixes><Prefix>.trash/2024-01-02/</Prefix></CommonPrefixes>
+</ListBucketResult>`)
+		case r.Method == "GET" && strings.HasPrefix(query.Get("prefix"), ".trash/"):
+			contents := ""
+			for _, key := range []string{".trash/2024-01-01/dir/a", ".trash/2024-01-02/dir/a"} {
+				if strings.HasPrefix(key, query.Get("prefix")) {
+					contents += "<Contents><Key>" + key + "</Key><Size>3</Size></Contents>"
+				}
+			}
+			fmt.Fprint(w, "<ListBucketResult><IsTruncated>false</IsTruncated>"+contents+"</ListBucketResult>")
+		case r.Method == "GET" && r.URL.RawQuery == "acl":
+			fmt.Fprint(w, `<AccessControlPolicy><Owner><ID>id</ID></Owner><AccessControlList><Grant>public-read</Grant></AccessControlList></AccessControlPolicy>`)
+		case r.Method == "GET" && r.URL.RawQuery == "tagging":
+			fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>env</Key><Value>test</Value></Tag></TagSet></Tagging>`)
+		case r.Method == "HEAD":
+			w.Header().Set("Content-Length", "3")
+			w.Header().Set("Content-Type", "text/plain")
+			w.Header().Set("X-Oss-Meta-Author", "luxun")
+			w.Header().Set("X-Oss-Storage-Class", "IA")
+		case r.Method == "PUT" && r.Header.Get("X-Oss-Copy-Source") != "":
+			source, _ := url.QueryUnescape(r.Header.Get("X-Oss-Copy-Source"))
+			operated = append(operated, "COPY "+source+" "+r.URL.Path)
+			copyACL = r.Header.Get("X-Oss-Object-Acl")
+			if existing[r.URL.Path] && r.Header.Get("X-Oss-Forbid-Overwrite") == "true" {
+				w.WriteHeader(http.StatusConflict)
+				fmt.Fprint(w, `<Error><Code>FileAlreadyExists</Code></Error>`)
+				return
+			}
+			fmt.Fprint(w, `<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`)
+		case r.Method == "POST":
+			body, _ := ioutil.ReadAll(r.Body)
+			operated = append(operated, "BATCH "+fmt.Sprint(strings.Count(string(body), "<Key>")))
+			fmt.Fprint(w, `<DeleteResult></DeleteResult>`)
+		default:
+			operated = append(operated, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	endpoint := server.URL
+	str := "ak"
+	forcePathStyle := true
+	trashDays := "7"
+	toTrash := true
+	routines := "1"
+	options := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionToTrash:         &toTrash,
+		OptionTrashDays:       &trashDays,
+		OptionRoutines:        &routines,
+	}
+	testResultFile, err := os.OpenFile(resultPath, os.O_RDWR|os.O_TRUNC|os.O_CREATE, 0664)
+	c.Assert(err, IsNil)
+	oldStdout := os.Stdout
+	os.Stdout = testResultFile
+	defer func() {
+		os.Stdout = oldStdout
+		testResultFile.Close()
+	}()
+
+	// rm moves the object into the trash of today and sets the lifecycle of the trash
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/dir/a"}, options)
+	c.Assert(err, IsNil)
+	today := time.Now().Format(trashDateFormat)
+	c.Assert(operated, DeepEquals, []string{"COPY /bucket/dir/a /bucket/.trash/" + today + "/dir/a", "DELETE /bucket/dir/a"})
+	c.Assert(copyACL, Equals, "public-read")
+	c.Assert(strings.Contains(lifecycle, "<ID>ossutil-trash</ID><Prefix>.trash/</Prefix><Status>Enabled</Status><Expiration><Days>7</Days>"), Equals, true)
+
+	// the rule is replaced and the other rules are kept
+	lifecycle = `<LifecycleConfiguration><Rule><ID>other</ID><Prefix>logs/</Prefix><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule>` +
+		`<Rule><ID>ossutil-trash</ID><Prefix>.trash/</Prefix><Status>Enabled</Status><Expiration><Days>7</Days></Expiration></Rule></LifecycleConfiguration>`
+	trashDays = "3"
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/dir/a"}, options)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Count(lifecycle, "<Rule>"), Equals, 2)
+	c.Assert(strings.Contains(lifecycle, "<ID>other</ID>"), Equals, true)
+	c.Assert(strings.Contains(lifecycle, "<Days>3</Days>"), Equals, true)
+
+	// the options conflicting with --to-trash
+	allVersions := true
+	options[OptionAllversions] = &allVersions
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/dir/a"}, options)
+	c.Assert(err, NotNil)
+	delete(options, OptionAllversions)
+	delete(options, OptionToTrash)
+	_, err = cm.RunCommand("rm", []string{"oss://bucket/dir/a"}, options)
+	c.Assert(err, NotNil)
+
+	// restore moves back the latest removed object without overwriting
+	trashOptions := OptionMapType{
+		OptionEndpoint:        &endpoint,
+		OptionAccessKeyID:     &str,
+		OptionAccessKeySecret: &str,
+		OptionForcePathStyle:  &forcePathStyle,
+		OptionRoutines:        &routines,
+	}
+	operated = nil
+	_, err = cm.RunCommand("trash", []string{"restore", "oss://bucket/dir/a"}, trashOptions)
+	c.Assert(err, IsNil)
+	c.Assert(operated, DeepEquals, []string{"COPY /bucket/.trash/2024-01-02/dir/a /bucket/dir/a", "DELETE /bucket/.trash/2024-01-02/dir/a"})
+
+	existing["/bucket/dir/a"] = true
+	operated = nil
+	_, err = cm.RunCommand("trash", []string{"restore", "oss://bucket/dir/a"}, trashOptions)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "already exists"), Equals, true)
+	c.Assert(operated, DeepEquals, []string{"COPY /bucket/.trash/2024-01-02/dir/a /bucket/dir/a"})
+
+	// restore recursively with --force processes the dates from the earliest
+	recursive := true
+	force := true
+	trashOptions[OptionRecursion] = &recursive
+	trashOptions[OptionForce] = &force
+	operated = nil
+	_, err = cm.RunCommand("trash", []string{"restore", "oss://bucket/dir/"}, trashOptions)
+	c.Assert(err, IsNil)
+	c.Assert(operated[0], Equals, "COPY /bucket/.trash/2024-01-01/dir/a /bucket/dir/a")
+	c.Assert(operated[len(operated)-1], Equals, "DELETE /bucket/.trash/2024-01-02/dir/a")
+
+	_, err = cm.RunCommand("trash", []string{"list", "oss://bucket/dir/"}, trashOptions)
+	c.Assert(err, NotNil)
+
+	// purge deletes the objects in the trash
+	delete(trashOptions, OptionRecursion)
+	delete(trashOptions, OptionForce)
+	assumeYes := true
+	trashOptions[OptionAssumeYes] = &assumeYes
+	operated = nil
+	_, err = cm.RunCommand("trash", []string{"purge", "oss://bucket"}, trashOptions)
+	c.Assert(err, IsNil)
+	c.Assert(operated, DeepEquals, []string{"BATCH 2"})
+
+	// the multipart copy keeps the metadata and the tags besides the acl and the storage class
+	rc := &RemoveCommand{}
+	rc.command.options = trashOptions
+	client, err := oss.New(endpoint, str, str, oss.ForcePathStyle(true))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	copyOptions, err := rc.command.copyAttrOptions(bucket, "dir/a", true, nil)
+	c.Assert(err, IsNil)
+	header := func(name string) interface{} {
+		value, _ := oss.FindOption(copyOptions, name, nil)
+		return value
+	}
+	c.Assert(header("X-Oss-Object-Acl"), Equals, "public-read")
+	c.Assert(header("X-Oss-Storage-Class"), Equals, "IA")
+	c.Assert(header("Content-Type"), Equals, "text/plain")
+	c.Assert(header("X-Oss-Meta-Author"), Equals, "luxun")
+	c.Assert(header("X-Oss-Tagging"), Equals, "env=test")
+
+	copyOptions, err = rc.command.copyAttrOptions(bucket, "dir/a", false, nil)
+	c.Assert(err, IsNil)
+	c.Assert(len(copyOptions), Equals, 2)
+}
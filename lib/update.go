@@ -1,11 +1,15 @@
 package lib
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -24,18 +28,32 @@ var specChineseUpdate = SpecText{
 	paramText: "[options]",
 
 	syntaxText: ` 
-    ossutil update [-f] 
+    ossutil update [--version vX.Y.Z] [--check] [-f] 
 `,
 
 	detailHelpText: ` 
     该命令检查当前ossutil的版本与最新版本，输出两者的版本号，如果有更新版本，询问是否
     进行升级。如果指定了--force选项，则不询问，当有可用更新时，直接升级。
 
+--version选项
+
+    指定升级（或降级）到的版本，如：--version v1.7.19，不指定时升级到最新版本。
+
+--check选项
+
+    只检查并输出是否有可用的更新，不进行升级。
+
+    升级时，ossutil从发布地址下载当前平台的可执行文件到当前可执行文件所在的目录，并使用
+    随版本发布的sha256校验和校验下载的文件，校验失败时不进行升级。校验通过后，通过重命名
+    原子地替换当前的可执行文件。
+
 `,
 
 	sampleText: ` 
     ossutil update
     ossutil update -f
+    ossutil update --check
+    ossutil update --version v1.7.19 -f
 `,
 }
 
@@ -46,18 +64,34 @@ var specEnglishUpdate = SpecText{
 	paramText: "[options]",
 
 	syntaxText: ` 
-    ossutil update [-f]
+    ossutil update [--version vX.Y.Z] [--check] [-f]
 `,
 
 	detailHelpText: ` 
     The command check version of current ossutil and get the latest version, output the 
     versions, if any updated version exists, the command ask you for upgrading. If --force 
     option is specified, the command upgrade without asking. 
+
+--version option
+
+    Specify the version to upgrade(or downgrade) to, e.g., --version v1.7.19, the latest 
+    version is used if it's not specified.
+
+--check option
+
+    Only check and output whether an update is available, do not update.
+
+    When updating, ossutil downloads the binary of current platform from the release location 
+    to the directory of current binary, and verifies the downloaded file by the sha256 checksum 
+    released with the version, the update is aborted if the verification fails. After the 
+    verification, the current binary is atomically replaced by renaming.
 `,
 
 	sampleText: ` 
     ossutil update
     ossutil update -f
+    ossutil update --check
+    ossutil update --version v1.7.19 -f
 `,
 }
 
@@ -68,15 +102,15 @@ type UpdateCommand struct {
 
 var updateCommand = UpdateCommand{
 	command: Command{
-		name:        "update",
-		nameAlias:   []string{""},
-		minArgc:     0,
-		maxArgc:     0,
-		specChinese: specChineseUpdate,
-		specEnglish: specEnglishUpdate,
-		group:       GroupTypeAdditionalCommand,
+		name:      "update",
+		nameAlias: []string{""},
+		minArgc:   0,
+		maxArgc:   1,
+		group:     GroupTypeAdditionalCommand,
 		validOptionNames: []string{
 			OptionForce,
+			OptionVersion,
+			OptionCheckOnly,
 			OptionRetryTimes,
 			OptionLanguage,
 			OptionProxyHost,
@@ -107,21 +141,32 @@ func (uc *UpdateCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (uc *UpdateCommand) Init(args []string, options OptionMapType) error {
-	return uc.command.Init(args, options, uc)
+func (uc *UpdateCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return uc.command.Init(ctx, args, options, uc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
 func (uc *UpdateCommand) RunCommand() error {
 	force, _ := GetBool(OptionForce, uc.command.options)
+	checkOnly, _ := GetBool(OptionCheckOnly, uc.command.options)
 	language, _ := GetString(OptionLanguage, uc.command.options)
 	language = strings.ToLower(language)
 
-	// get lastest version
-	version, err := uc.getLastestVersion()
+	// --version is a flag of ossutil, the version to update to follows it as the argument
+	targetVersion := ""
+	if len(uc.command.args) > 0 {
+		if withVersion, _ := GetBool(OptionVersion, uc.command.options); !withVersion {
+			return fmt.Errorf("invalid argument: %s, specify the version by --version", uc.command.args[0])
+		}
+		targetVersion = trimVersionPrefix(uc.command.args[0])
+	}
+
+	// get version list
+	versions, err := uc.getVersionList()
 	if err != nil {
 		return fmt.Errorf("get lastest vsersion error, %s", err.Error())
 	}
+	version := versions[len(versions)-1]
 
 	if language == LEnglishLanguage {
 		fmt.Printf("current version is: %s, the lastest version is: %s", vVersion, version)
@@ -131,9 +176,15 @@ func (uc *UpdateCommand) RunCommand() error {
 
 	// version is X.X.X
 	// vVersion is vX.X.X
-	if vVersion[0] < '0' || vVersion[0] > '9' {
-		vVersion = vVersion[1:len(vVersion)]
+	vVersion = trimVersionPrefix(vVersion)
+	if targetVersion != "" {
+		if FindPos(targetVersion, versions) == -1 {
+			fmt.Println("")
+			return fmt.Errorf("version %s doesn't exist, the lastest version is %s", targetVersion, version)
+		}
+		version = targetVersion
 	}
+
 	if version == vVersion {
 		if language == LEnglishLanguage {
 			fmt.Println(", current version is the lastest version, no need to update.")
@@ -144,11 +195,20 @@ func (uc *UpdateCommand) RunCommand() error {
 	}
 	fmt.Println("")
 
+	if checkOnly {
+		if language == LEnglishLanguage {
+			fmt.Printf("version %s is available, run \"ossutil update\" to update.\n", version)
+		} else {
+			fmt.Printf("版本%s可用，执行\"ossutil update\"进行更新。\n", version)
+		}
+		return nil
+	}
+
 	if !force {
 		if language == LEnglishLanguage {
-			fmt.Printf("sure to update ossutil(y or N)? ")
+			fmt.Printf("sure to update ossutil to version %s(y or N)? ", version)
 		} else {
-			fmt.Printf("确定更新版本(y or N)? ")
+			fmt.Printf("确定更新到版本%s(y or N)? ", version)
 		}
 
 		var val string
@@ -167,16 +227,37 @@ func (uc *UpdateCommand) RunCommand() error {
 	return nil
 }
 
+func trimVersionPrefix(version string) string {
+	if version != "" && (version[0] < '0' || version[0] > '9') {
+		return version[1:]
+	}
+	return version
+}
+
 func (uc *UpdateCommand) getLastestVersion() (string, error) {
-	if err := uc.anonymousGetToFileRetry(vUpdateBucket, updateVersionObject, updateTmpVersionFile); err != nil {
+	versions, err := uc.getVersionList()
+	if err != nil {
 		return "", err
 	}
+	return versions[len(versions)-1], nil
+}
+
+// getVersionList returns the released versions in ascending order
+func (uc *UpdateCommand) getVersionList() ([]string, error) {
+	if err := uc.anonymousGetToFileRetry(vUpdateBucket, updateVersionObject, updateTmpVersionFile); err != nil {
+		return nil, err
+	}
 
 	v, err := ioutil.ReadFile(updateTmpVersionFile)
+	os.Remove(updateTmpVersionFile)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	versionStr := strings.TrimSpace(strings.Trim(string(v), "\n"))
+	return parseVersionList(string(v)), nil
+}
+
+func parseVersionList(versionStr string) []string {
+	versionStr = strings.TrimSpace(strings.Trim(versionStr, "\n"))
 
 	// get version list and sort
 	sli := strings.Split(versionStr, "\n")
@@ -185,11 +266,7 @@ func (uc *UpdateCommand) getLastestVersion() (string, error) {
 		vl = append(vl, strings.TrimSpace(strings.Trim(string(vstr), "\n")))
 	}
 	sort.Strings(vl)
-	version := vl[len(vl)-1]
-
-	os.Remove(updateTmpVersionFile)
-
-	return version, nil
+	return vl
 }
 
 func (uc *UpdateCommand) anonymousGetToFileRetry(bucketName, objectName, filePath string) error {
@@ -242,20 +319,29 @@ func (uc *UpdateCommand) updateVersion(version, language string) error {
 	}
 	mode := f.Mode()
 
+	// download the binary of the specified version beside the current one, so that the rename is atomic
+	downloadFilePath := filepath.Join(filepath.Dir(filePath), ".download_"+filepath.Base(filePath))
+	defer os.Remove(downloadFilePath)
+	if err := uc.getBinary(downloadFilePath, version); err != nil {
+		return fmt.Errorf("download binary of version: %s error, %s", version, err.Error())
+	}
+
+	if err := uc.verifyBinary(downloadFilePath, version); err != nil {
+		return fmt.Errorf("verify binary of version: %s error, %s", version, err.Error())
+	}
+
+	if err := os.Chmod(downloadFilePath, mode); err != nil {
+		return fmt.Errorf("chmod binary error, %s", err.Error())
+	}
+
 	// rename the current binary to another one
 	if err := os.Rename(filePath, renameFilePath); err != nil {
 		return fmt.Errorf("update binary error, %s", err.Error())
 	}
 
-	// download the binary of the specified version
-	if err := uc.getBinary(filePath, version); err != nil {
-		uc.revertRename(filePath, renameFilePath)
-		return fmt.Errorf("download binary of version: %s error, %s", version, err.Error())
-	}
-
-	if err := os.Chmod(filePath, mode); err != nil {
+	if err := os.Rename(downloadFilePath, filePath); err != nil {
 		uc.revertRename(filePath, renameFilePath)
-		return fmt.Errorf("chmod binary error, %s", err.Error())
+		return fmt.Errorf("update binary error, %s", err.Error())
 	}
 
 	// remove the current one
@@ -274,6 +360,46 @@ func (uc *UpdateCommand) updateVersion(version, language string) error {
 	return nil
 }
 
+// verifyBinary checks the downloaded binary by the sha256 checksum released with the version
+func (uc *UpdateCommand) verifyBinary(filePath, version string) error {
+	checksumFilePath := filePath + updateChecksumSuffix
+	defer os.Remove(checksumFilePath)
+	object := version + "/" + uc.getBinaryName() + updateChecksumSuffix
+	if err := uc.anonymousGetToFileRetry(vUpdateBucket, object, checksumFilePath); err != nil {
+		return fmt.Errorf("get checksum error, %s", err.Error())
+	}
+	checksum, err := ioutil.ReadFile(checksumFilePath)
+	if err != nil {
+		return err
+	}
+	return verifyFileChecksum(filePath, string(checksum))
+}
+
+// verifyFileChecksum compares the sha256 of the file with the checksum, the checksum is in the
+// format of sha256sum, the file name after the hex digest is ignored
+func verifyFileChecksum(filePath, checksum string) error {
+	fields := strings.Fields(checksum)
+	if len(fields) == 0 {
+		return fmt.Errorf("the checksum is empty")
+	}
+	expected := strings.ToLower(fields[0])
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expected {
+		return fmt.Errorf("sha256 mismatch, expected: %s, actual: %s", expected, actual)
+	}
+	return nil
+}
+
 func (uc *UpdateCommand) revertRename(filePath, renameFilePath string) error {
 	if _, err := os.Stat(filePath); err == nil {
 		os.Remove(filePath)
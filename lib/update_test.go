@@ -244,3 +244,31 @@ func (s *OssutilCommandSuite) TestUpdateWithLinuxArm(c *C) {
 	os.Remove(binaryName)
 	os.Remove(".temp_" + binaryName)
 }
+
+func (s *OssutilCommandSuite) TestUpdateVerifyChecksum(c *C) {
+	fileName := ".ossutil_test_checksum" + randStr(5)
+	c.Assert(ioutil.WriteFile(fileName, []byte("test-binary"), 0644), IsNil)
+	defer os.Remove(fileName)
+
+	// sha256 of "test-binary"
+	checksum := "6f850b10b66ff0af26a1151306cbc59427ae2572330935d95ab5febdef8763bf"
+	err := verifyFileChecksum(fileName, checksum+"  ossutil64\n")
+	c.Assert(err, IsNil)
+	err = verifyFileChecksum(fileName, strings.ToUpper(checksum))
+	c.Assert(err, IsNil)
+	err = verifyFileChecksum(fileName, strings.Repeat("0", 64))
+	c.Assert(err, NotNil)
+	err = verifyFileChecksum(fileName, "")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssutilCommandSuite) TestUpdateTargetVersion(c *C) {
+	c.Assert(parseVersionList("1.7.2\n1.6.19\n1.7.10\n"), DeepEquals, []string{"1.6.19", "1.7.10", "1.7.2"})
+	c.Assert(trimVersionPrefix("v1.7.19"), Equals, "1.7.19")
+	c.Assert(trimVersionPrefix("1.7.19"), Equals, "1.7.19")
+
+	// the version argument needs --version
+	force := true
+	_, err := cm.RunCommand("update", []string{"v1.7.19"}, OptionMapType{OptionForce: &force})
+	c.Assert(err, NotNil)
+}
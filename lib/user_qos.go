@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"os"
@@ -66,13 +67,11 @@ type UserQosCommand struct {
 
 var userQosCommand = UserQosCommand{
 	command: Command{
-		name:        "user-qos",
-		nameAlias:   []string{"user-qos"},
-		minArgc:     0,
-		maxArgc:     1,
-		specChinese: specChineseUserQos,
-		specEnglish: specEnglishUserQos,
-		group:       GroupTypeNormalCommand,
+		name:      "user-qos",
+		nameAlias: []string{"user-qos"},
+		minArgc:   0,
+		maxArgc:   1,
+		group:     GroupTypeNormalCommand,
 		validOptionNames: []string{
 			OptionConfigFile,
 			OptionEndpoint,
@@ -92,6 +91,11 @@ var userQosCommand = UserQosCommand{
 			OptionRoleSessionName,
 			OptionReadTimeout,
 			OptionConnectTimeout,
+			OptionMaxIdleConns,
+			OptionMaxConnsPerHost,
+			OptionIdleConnTimeout,
+			OptionHTTP2,
+			OptionMaxQPS,
 			OptionSTSRegion,
 			OptionSkipVerifyCert,
 			OptionUserAgent,
@@ -113,8 +117,8 @@ func (uqc *UserQosCommand) formatIndependHelp() string {
 }
 
 // Init simulate inheritance, and polymorphism
-func (uqc *UserQosCommand) Init(args []string, options OptionMapType) error {
-	return uqc.command.Init(args, options, uqc)
+func (uqc *UserQosCommand) Init(ctx context.Context, args []string, options OptionMapType) error {
+	return uqc.command.Init(ctx, args, options, uqc)
 }
 
 // RunCommand simulate inheritance, and polymorphism
@@ -132,15 +136,6 @@ func (uqc *UserQosCommand) RunCommand() error {
 	return uqc.GetUserQos()
 }
 
-func (uqc *UserQosCommand) confirm(str string) bool {
-	var val string
-	fmt.Printf(getClearStr(fmt.Sprintf("user qos: overwrite \"%s\"(y or N)? ", str)))
-	if _, err := fmt.Scanln(&val); err != nil || (strings.ToLower(val) != "yes" && strings.ToLower(val) != "y") {
-		return false
-	}
-	return true
-}
-
 func (uqc *UserQosCommand) GetUserQos() error {
 	client, err := uqc.command.ossClient("")
 	if err != nil {
@@ -162,7 +157,7 @@ func (uqc *UserQosCommand) GetUserQos() error {
 		fileName := uqc.command.args[0]
 		_, err = os.Stat(fileName)
 		if err == nil {
-			bConitnue := uqc.confirm(fileName)
+			bConitnue := uqc.command.confirmOperation(fmt.Sprintf("overwrite the existing file %s", fileName))
 			if !bConitnue {
 				return nil
 			}
@@ -2,10 +2,13 @@ package lib
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"hash"
 	"io/ioutil"
 	"math/rand"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/user"
@@ -736,3 +739,108 @@ func AddStringsToOption(params []string, options []oss.Option) ([]oss.Option, er
 	}
 	return options, nil
 }
+
+// parseAgeDuration parses the duration with the d(day) unit besides the units of time.ParseDuration, e.g., 7d, 36h
+func parseAgeDuration(str string) (time.Duration, error) {
+	var duration time.Duration
+	var err error
+	if strings.HasSuffix(str, "d") {
+		var days int64
+		days, err = strconv.ParseInt(strings.TrimSuffix(str, "d"), 10, 64)
+		duration = time.Duration(days) * 24 * time.Hour
+	} else {
+		duration, err = time.ParseDuration(str)
+	}
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("the value should be positive duration, e.g., 7d, 36h")
+	}
+	return duration, nil
+}
+
+// printJSON prints the value as indented json
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// callbackResultKey is the option key of oss.CallbackResult, which is not exported by the sdk
+const callbackResultKey = "x-response-body"
+
+const defaultCallbackBody = "bucket=${bucket}&object=${object}&etag=${etag}&size=${size}&mimeType=${mimeType}"
+
+// getCallbackOptions returns the x-oss-callback and x-oss-callback-var options of upload,
+// --callback-var is like key1=value1#key2=value2, the key is prefixed with x: if it's not
+func getCallbackOptions(options OptionMapType) ([]oss.Option, error) {
+	callbackURL, _ := GetString(OptionCallbackURL, options)
+	callbackBody, _ := GetString(OptionCallbackBody, options)
+	callbackVar, _ := GetString(OptionCallbackVar, options)
+	if callbackURL == "" {
+		if callbackBody != "" || callbackVar != "" {
+			return nil, fmt.Errorf("--callback-body and --callback-var need --callback-url")
+		}
+		return nil, nil
+	}
+
+	callback := map[string]string{"callbackUrl": callbackURL, "callbackBody": defaultCallbackBody}
+	if callbackBody != "" {
+		callback["callbackBody"] = callbackBody
+		if strings.HasPrefix(strings.TrimSpace(callbackBody), "{") {
+			callback["callbackBodyType"] = "application/json"
+		}
+	}
+	data, err := json.Marshal(callback)
+	if err != nil {
+		return nil, err
+	}
+	ossOptions := []oss.Option{oss.Callback(base64.StdEncoding.EncodeToString(data))}
+
+	if callbackVar != "" {
+		vars := map[string]string{}
+		for _, item := range strings.Split(callbackVar, "#") {
+			pos := strings.Index(item, "=")
+			if pos <= 0 {
+				return nil, fmt.Errorf("invalid --callback-var: %s, it should be like key1=value1#key2=value2", callbackVar)
+			}
+			key := item[:pos]
+			if !strings.HasPrefix(key, "x:") {
+				key = "x:" + key
+			}
+			vars[key] = item[pos+1:]
+		}
+		if data, err = json.Marshal(vars); err != nil {
+			return nil, err
+		}
+		ossOptions = append(ossOptions, oss.CallbackVar(base64.StdEncoding.EncodeToString(data)))
+	}
+	return ossOptions, nil
+}
+
+// objectMetaOptions returns the options of the content headers and the user metadata in props, so
+// that they are kept by the copy which doesn't copy the metadata, such as the multipart copy
+func objectMetaOptions(props http.Header) []oss.Option {
+	var options []oss.Option
+	headers := map[string]func(string) oss.Option{
+		oss.HTTPHeaderContentType:        oss.ContentType,
+		oss.HTTPHeaderCacheControl:       oss.CacheControl,
+		oss.HTTPHeaderContentDisposition: oss.ContentDisposition,
+		oss.HTTPHeaderContentEncoding:    oss.ContentEncoding,
+	}
+	for header, option := range headers {
+		if value := props.Get(header); value != "" {
+			options = append(options, option(value))
+		}
+	}
+	if expires, err := http.ParseTime(props.Get(oss.HTTPHeaderExpires)); err == nil {
+		options = append(options, oss.Expires(expires))
+	}
+	for name := range props {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(oss.HTTPHeaderOssMetaPrefix)) {
+			options = append(options, oss.Meta(name[len(oss.HTTPHeaderOssMetaPrefix):], props.Get(name)))
+		}
+	}
+	return options
+}
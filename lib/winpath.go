@@ -0,0 +1,102 @@
+package lib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// the mappings of the object key which is not a valid file name on windows
+const (
+	WindowsNameUnderscore = "underscore"
+	WindowsNamePercent    = "percent"
+	WindowsNameNone       = "none"
+)
+
+// windowsMaxPath is the max length of the directory path without \\?\ prefix, which is MAX_PATH
+// minus the space for 8.3 file name
+const windowsMaxPath = 248
+
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+func mapWindowsChar(c byte, mapping string) string {
+	if mapping == WindowsNamePercent {
+		return fmt.Sprintf("%%%02X", c)
+	}
+	return "_"
+}
+
+// sanitizeWindowsName maps the invalid characters, the trailing dots and spaces, and the reserved
+// device names like CON, NUL in a file name
+func sanitizeWindowsName(name, mapping string) string {
+	if mapping == WindowsNameNone || name == "" || name == "." || name == ".." {
+		return name
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; c < 32 || strings.IndexByte(`<>:"|?*`, c) >= 0 {
+			sb.WriteString(mapWindowsChar(c, mapping))
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	name = sb.String()
+
+	trimmed := strings.TrimRight(name, ". ")
+	if trimmed != name {
+		sb.Reset()
+		sb.WriteString(trimmed)
+		for i := len(trimmed); i < len(name); i++ {
+			sb.WriteString(mapWindowsChar(name[i], mapping))
+		}
+		name = sb.String()
+	}
+
+	// the reserved names are invalid even with extension, e.g., nul.txt
+	base := name
+	if index := strings.Index(name, "."); index >= 0 {
+		base = name[:index]
+	}
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		if mapping == WindowsNamePercent {
+			name = mapWindowsChar(name[0], mapping) + name[1:]
+		} else {
+			name = base + "_" + name[len(base):]
+		}
+	}
+	return name
+}
+
+// sanitizeWindowsKey maps each part of the object key separated by "/"
+func sanitizeWindowsKey(key, mapping string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = sanitizeWindowsName(part, mapping)
+	}
+	return strings.Join(parts, "/")
+}
+
+// windowsLongPath returns the \\?\ extended-length path if the path is too long for windows, the
+// relative path is resolved by the working directory, so its length is checked after it's made absolute
+func windowsLongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if len(absPath) < windowsMaxPath {
+		return path
+	}
+	absPath = strings.Replace(absPath, "/", `\`, -1)
+	if strings.HasPrefix(absPath, `\\`) {
+		return `\\?\UNC\` + absPath[2:]
+	}
+	return `\\?\` + absPath
+}
@@ -0,0 +1,43 @@
+package lib
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *OssutilCommandSuite) TestSanitizeWindowsName(c *C) {
+	c.Assert(sanitizeWindowsName("a.txt", ""), Equals, "a.txt")
+	c.Assert(sanitizeWindowsName("CON", ""), Equals, "CON_")
+	c.Assert(sanitizeWindowsName("nul.txt", WindowsNameUnderscore), Equals, "nul_.txt")
+	c.Assert(sanitizeWindowsName("com1.tar.gz", ""), Equals, "com1_.tar.gz")
+	c.Assert(sanitizeWindowsName("console", ""), Equals, "console")
+	c.Assert(sanitizeWindowsName("a. ", ""), Equals, "a__")
+	c.Assert(sanitizeWindowsName("a:b?c", ""), Equals, "a_b_c")
+	c.Assert(sanitizeWindowsName("..", ""), Equals, "..")
+
+	c.Assert(sanitizeWindowsName("CON", WindowsNamePercent), Equals, "%43ON")
+	c.Assert(sanitizeWindowsName("a.", WindowsNamePercent), Equals, "a%2E")
+	c.Assert(sanitizeWindowsName("a*b", WindowsNamePercent), Equals, "a%2Ab")
+
+	c.Assert(sanitizeWindowsName("CON", WindowsNameNone), Equals, "CON")
+
+	c.Assert(sanitizeWindowsKey("dir./aux/b.txt", ""), Equals, "dir_/aux_/b.txt")
+	c.Assert(sanitizeWindowsKey("dir/", ""), Equals, "dir/")
+}
+
+func (s *OssutilCommandSuite) TestWindowsLongPath(c *C) {
+	c.Assert(windowsLongPath(`C:\short`), Equals, `C:\short`)
+	long := `\\?\C:\` + strings.Repeat("a", 300)
+	c.Assert(windowsLongPath(long), Equals, long)
+
+	// the relative path is short, but it's too long after joining the working directory
+	relative := strings.Repeat("b", windowsMaxPath-8)
+	c.Assert(strings.HasPrefix(windowsLongPath(relative), `\\?\`), Equals, true)
+	c.Assert(strings.HasSuffix(windowsLongPath(relative), relative), Equals, true)
+
+	cc := &CopyCommand{}
+	c.Assert(cc.makeLocalFileName("con/a.txt", "dir/", false), Equals, "dir/con/a.txt")
+	c.Assert(cc.makeLocalFileName("con/a.txt", "dir/", true), Equals, "dir/con_/a.txt")
+	c.Assert(cc.makeLocalFileName("con/a.txt", "file.txt", true), Equals, "file.txt")
+}